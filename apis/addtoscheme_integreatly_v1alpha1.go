@@ -1,6 +1,7 @@
 package apis
 
 import (
+	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
 	v1 "github.com/integr8ly/cloud-resource-operator/apis/config/v1"
 	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
 	creds "github.com/openshift/cloud-credential-operator/pkg/apis/cloudcredential/v1"
@@ -12,5 +13,6 @@ func init() {
 		AddToSchemes,
 		v1alpha1.SchemeBuilder.AddToScheme,
 		v1.SchemeBuilder.AddToScheme,
-		creds.AddToScheme)
+		creds.AddToScheme,
+		monitoringv1.AddToScheme)
 }
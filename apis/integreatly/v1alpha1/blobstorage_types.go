@@ -24,6 +24,13 @@ import (
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:path=blobstorages,scope=Namespaced
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Provider",type=string,JSONPath=`.status.provider`
+// +kubebuilder:printcolumn:name="Instance ID",type=string,JSONPath=`.status.cloudResource.instanceID`
+// +kubebuilder:printcolumn:name="Endpoint",type=string,JSONPath=`.status.cloudResource.endpoint`
+// +kubebuilder:printcolumn:name="Region",type=string,JSONPath=`.status.cloudResource.region`,priority=1
+// +kubebuilder:printcolumn:name="ARN",type=string,JSONPath=`.status.cloudResource.arn`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // BlobStorage is the Schema for the blobstorages API
 type BlobStorage struct {
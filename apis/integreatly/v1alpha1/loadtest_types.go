@@ -0,0 +1,76 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LoadTestSpec defines the desired state of LoadTest
+type LoadTestSpec struct {
+	// ResourceType is the type of the resource under test, one of postgres or redis
+	ResourceType string `json:"resourceType"`
+	// ResourceName is the name of the postgres or redis resource under test, in the namespace of the load test
+	ResourceName string `json:"resourceName"`
+	// Clients is the number of concurrent clients, defaults to 10
+	Clients int `json:"clients,omitempty"`
+	// Scale is the pgbench scale factor for postgres, redis runs 10000 requests per unit of scale, defaults to 10
+	Scale int `json:"scale,omitempty"`
+	// DurationSeconds is the duration of the postgres benchmark, defaults to 60
+	DurationSeconds int `json:"durationSeconds,omitempty"`
+	// Image overrides the image the benchmark is run with
+	Image string `json:"image,omitempty"`
+}
+
+// LoadTestStatus defines the observed state of LoadTest
+type LoadTestStatus struct {
+	Phase          types.StatusPhase   `json:"phase,omitempty"`
+	Message        types.StatusMessage `json:"message,omitempty"`
+	JobName        string              `json:"jobName,omitempty"`
+	ReportName     string              `json:"reportName,omitempty"`
+	StartTime      *metav1.Time        `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time        `json:"completionTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=loadtests,scope=Namespaced
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Report",type=string,JSONPath=`.status.reportName`
+
+// LoadTest is the Schema for the loadtests API
+type LoadTest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   LoadTestSpec   `json:"spec,omitempty"`
+	Status LoadTestStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// LoadTestList contains a list of LoadTest
+type LoadTestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LoadTest `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&LoadTest{}, &LoadTestList{})
+}
@@ -0,0 +1,83 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// OperatorConfigSpec defines the desired configuration of the operator, it's read from the OperatorConfig named
+// cloud-resource-operator in the namespace of the operator
+type OperatorConfigSpec struct {
+	// Telemetry configures the anonymous usage reports of the operator, no report is sent unless it's enabled
+	Telemetry *TelemetrySpec `json:"telemetry,omitempty"`
+}
+
+// TelemetrySpec configures the anonymous usage reports of the operator. A report only holds counts of the resources
+// by kind, provider and tier and of the failed resources by error category, no names, namespaces or messages
+type TelemetrySpec struct {
+	// Enabled opts in to the usage reports
+	Enabled bool `json:"enabled,omitempty"`
+	// Endpoint is the url the reports are posted to as json
+	Endpoint string `json:"endpoint,omitempty"`
+	// Interval is how often a report is posted, daily if unset
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// OperatorConfigStatus defines the observed state of OperatorConfig
+type OperatorConfigStatus struct {
+	Telemetry *TelemetryStatus `json:"telemetry,omitempty"`
+}
+
+// TelemetryStatus is the state of the usage reports of the operator
+type TelemetryStatus struct {
+	// InstallationID is the random identifier of the installation in its reports, so the reports of an installation
+	// can be told apart without identifying its cluster
+	InstallationID string `json:"installationID,omitempty"`
+	// LastReportedAt is the time the last report was posted
+	LastReportedAt *metav1.Time `json:"lastReportedAt,omitempty"`
+	// Message is the error of the last attempt to post a report, empty once a report was posted
+	Message string `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=operatorconfigs,scope=Namespaced
+// +kubebuilder:printcolumn:name="Telemetry",type=boolean,JSONPath=`.spec.telemetry.enabled`
+// +kubebuilder:printcolumn:name="Last Report",type=date,JSONPath=`.status.telemetry.lastReportedAt`
+
+// OperatorConfig is the Schema for the operatorconfigs API
+type OperatorConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   OperatorConfigSpec   `json:"spec,omitempty"`
+	Status OperatorConfigStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// OperatorConfigList contains a list of OperatorConfig
+type OperatorConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []OperatorConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&OperatorConfig{}, &OperatorConfigList{})
+}
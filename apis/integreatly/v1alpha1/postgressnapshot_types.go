@@ -27,6 +27,8 @@ type PostgresSnapshotSpec struct {
 	// Important: Run "operator-sdk generate k8s" to regenerate code after modifying this file
 	// Add custom validation using kubebuilder tags: https://book-v1.book.kubebuilder.io/beyond_basics/generating_crd.html
 	ResourceName string `json:"resourceName"`
+	// RetentionDays is the number of days the snapshot is kept for, if unset it's kept until deleted
+	RetentionDays int `json:"retentionDays,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,93 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ProductResourcesSpec defines the desired state of ProductResources
+type ProductResourcesSpec struct {
+	// Type is the deployment type the resources are provisioned with, e.g. managed or workshop
+	Type string `json:"type"`
+	// Tier is the tier the resources are provisioned with, unless set on the resource, defaults to production
+	Tier string `json:"tier,omitempty"`
+	// Resources are the postgres, redis and blob storage resources of the product
+	Resources []ProductResource `json:"resources"`
+}
+
+// ProductResource is a resource created and owned by a ProductResources, in its namespace
+type ProductResource struct {
+	// Kind is the kind of the resource, one of Postgres, Redis or BlobStorage
+	// +kubebuilder:validation:Enum=Postgres;Redis;BlobStorage
+	Kind string `json:"kind"`
+	// Name is the name of the resource
+	Name string `json:"name"`
+	// Tier overrides the tier of the product resources for this resource
+	Tier string `json:"tier,omitempty"`
+	// SecretName is the name of the connection secret of the resource, defaults to the name of the resource
+	SecretName string `json:"secretName,omitempty"`
+	// DependsOn are the resources that must be complete before this resource is provisioned
+	DependsOn []types.Dependency `json:"dependsOn,omitempty"`
+}
+
+// ProductResourceStatus is the observed state of a resource of a ProductResources
+type ProductResourceStatus struct {
+	Kind      string              `json:"kind"`
+	Name      string              `json:"name"`
+	Phase     types.StatusPhase   `json:"phase,omitempty"`
+	Message   types.StatusMessage `json:"message,omitempty"`
+	SecretRef *types.SecretRef    `json:"secretRef,omitempty"`
+}
+
+// ProductResourcesStatus defines the observed state of ProductResources
+type ProductResourcesStatus struct {
+	Phase   types.StatusPhase   `json:"phase,omitempty"`
+	Message types.StatusMessage `json:"message,omitempty"`
+	// Ready is the number of complete resources out of all resources, e.g. 2/3
+	Ready     string                  `json:"ready,omitempty"`
+	Resources []ProductResourceStatus `json:"resources,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=productresources,scope=Namespaced
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.ready`
+
+// ProductResources is the Schema for the productresources API
+type ProductResources struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ProductResourcesSpec   `json:"spec,omitempty"`
+	Status ProductResourcesStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ProductResourcesList contains a list of ProductResources
+type ProductResourcesList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ProductResources `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ProductResources{}, &ProductResourcesList{})
+}
@@ -28,6 +28,8 @@ type RedisSnapshotSpec struct {
 
 	// Foo is an example field of RedisSnapshot. Edit RedisSnapshot_types.go to remove/update
 	ResourceName string `json:"resourceName"`
+	// RetentionDays is the number of days the snapshot is kept for, if unset it's kept until deleted
+	RetentionDays int `json:"retentionDays,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,62 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ResourceGrantSpec defines the desired state of ResourceGrant. A grant is the consent of its namespace to resources
+// being requested on its behalf from other namespaces
+type ResourceGrantSpec struct {
+	// Namespaces are the namespaces allowed to request resources on behalf of the namespace of the grant
+	Namespaces []string `json:"namespaces"`
+	// Kinds are the kinds of the resources that can be requested, all of Postgres, Redis and BlobStorage if empty
+	Kinds []ResourceGrantKind `json:"kinds,omitempty"`
+	// Tiers are the tiers the resources can be requested with, any tier if empty
+	Tiers []string `json:"tiers,omitempty"`
+}
+
+// ResourceGrantKind is the kind of a resource that can be requested with a grant
+// +kubebuilder:validation:Enum=Postgres;Redis;BlobStorage
+type ResourceGrantKind string
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=resourcegrants,scope=Namespaced
+// +kubebuilder:printcolumn:name="Namespaces",type=string,JSONPath=`.spec.namespaces`
+// +kubebuilder:printcolumn:name="Kinds",type=string,JSONPath=`.spec.kinds`
+
+// ResourceGrant is the Schema for the resourcegrants API
+type ResourceGrant struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ResourceGrantSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ResourceGrantList contains a list of ResourceGrant
+type ResourceGrantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ResourceGrant `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ResourceGrant{}, &ResourceGrantList{})
+}
@@ -0,0 +1,82 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ResourceGroupStatusSpec defines the desired state of ResourceGroupStatus. The group is the resources in its namespace
+// matching its selector, so an installer can wait for a single resource instead of every resource of a product
+type ResourceGroupStatusSpec struct {
+	// Selector selects the resources of the group by their labels, an empty selector selects every resource
+	Selector metav1.LabelSelector `json:"selector"`
+	// Kinds are the kinds of the resources of the group, all of Postgres, Redis, BlobStorage and Queue if empty
+	Kinds []ResourceGroupKind `json:"kinds,omitempty"`
+}
+
+// ResourceGroupKind is the kind of a resource that can be part of a group
+// +kubebuilder:validation:Enum=Postgres;Redis;BlobStorage;Queue
+type ResourceGroupKind string
+
+// ResourceGroupMemberStatus is the observed state of a resource of a ResourceGroupStatus
+type ResourceGroupMemberStatus struct {
+	Kind    string              `json:"kind"`
+	Name    string              `json:"name"`
+	Phase   types.StatusPhase   `json:"phase,omitempty"`
+	Message types.StatusMessage `json:"message,omitempty"`
+}
+
+// ResourceGroupStatusStatus defines the observed state of ResourceGroupStatus
+type ResourceGroupStatusStatus struct {
+	Phase   types.StatusPhase   `json:"phase,omitempty"`
+	Message types.StatusMessage `json:"message,omitempty"`
+	// Ready is the number of complete resources out of all resources of the group, e.g. 2/3
+	Ready     string                      `json:"ready,omitempty"`
+	Resources []ResourceGroupMemberStatus `json:"resources,omitempty"`
+	// Conditions are the observations of the state of the group, Ready is true once every resource is complete
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=resourcegroupstatuses,scope=Namespaced
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.ready`
+
+// ResourceGroupStatus is the Schema for the resourcegroupstatuses API
+type ResourceGroupStatus struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ResourceGroupStatusSpec   `json:"spec,omitempty"`
+	Status ResourceGroupStatusStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ResourceGroupStatusList contains a list of ResourceGroupStatus
+type ResourceGroupStatusList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ResourceGroupStatus `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ResourceGroupStatus{}, &ResourceGroupStatusList{})
+}
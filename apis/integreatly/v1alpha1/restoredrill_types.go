@@ -0,0 +1,82 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RestoreDrillSpec defines the desired state of RestoreDrill
+type RestoreDrillSpec struct {
+	// Schedule is a five field cron expression, evaluated in UTC, of when drills run, defaults to "0 3 1 * *" for
+	// 03:00 on the first day of every month
+	Schedule string `json:"schedule,omitempty"`
+	// ResourceNames are the names of the postgres resources, in the namespace of the drill, whose most recent complete
+	// snapshot is restored
+	ResourceNames []string `json:"resourceNames"`
+	// ChecksumQuery is the sql query run against each restored postgres, its output is recorded in the report.
+	// Defaults to a checksum of the names of the tables of the database
+	ChecksumQuery string `json:"checksumQuery,omitempty"`
+	// ExpectedChecksum, if set, must equal the output of the checksum query for a restore to pass
+	ExpectedChecksum string `json:"expectedChecksum,omitempty"`
+	// TimeoutMinutes is the time allowed for restoring, validating and cleaning up each resource, defaults to 180
+	TimeoutMinutes int `json:"timeoutMinutes,omitempty"`
+	// ReportHistoryLimit is the number of completed reports kept, defaults to 12
+	ReportHistoryLimit int `json:"reportHistoryLimit,omitempty"`
+	// Image overrides the image the checksum query is run with
+	Image string `json:"image,omitempty"`
+}
+
+// RestoreDrillStatus defines the observed state of RestoreDrill
+type RestoreDrillStatus struct {
+	Phase   types.StatusPhase   `json:"phase,omitempty"`
+	Message types.StatusMessage `json:"message,omitempty"`
+	// LastScheduleTime is the time the latest drill was started
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+	// LastReportName is the name of the report of the latest drill
+	LastReportName string `json:"lastReportName,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=restoredrills,scope=Namespaced
+// +kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=`.spec.schedule`
+// +kubebuilder:printcolumn:name="Last Schedule",type=date,JSONPath=`.status.lastScheduleTime`
+// +kubebuilder:printcolumn:name="Last Report",type=string,JSONPath=`.status.lastReportName`
+
+// RestoreDrill is the Schema for the restoredrills API
+type RestoreDrill struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RestoreDrillSpec   `json:"spec,omitempty"`
+	Status RestoreDrillStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RestoreDrillList contains a list of RestoreDrill
+type RestoreDrillList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RestoreDrill `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RestoreDrill{}, &RestoreDrillList{})
+}
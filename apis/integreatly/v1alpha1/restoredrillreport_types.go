@@ -0,0 +1,106 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type RestoreDrillResult string
+
+var (
+	RestoreDrillResultPass RestoreDrillResult = "pass"
+	RestoreDrillResultFail RestoreDrillResult = "fail"
+)
+
+// RestoreDrillReportSpec defines the desired state of RestoreDrillReport, it's copied from the restore drill the
+// report was created by so the report records what was validated
+type RestoreDrillReportSpec struct {
+	// DrillName is the name of the restore drill the report was created by
+	DrillName string `json:"drillName,omitempty"`
+	// ResourceNames are the names of the postgres resources whose most recent complete snapshot is restored
+	ResourceNames []string `json:"resourceNames"`
+	// ChecksumQuery is the sql query run against each restored postgres
+	ChecksumQuery string `json:"checksumQuery,omitempty"`
+	// ExpectedChecksum, if set, must equal the output of the checksum query for a restore to pass
+	ExpectedChecksum string `json:"expectedChecksum,omitempty"`
+	// TimeoutMinutes is the time allowed for restoring, validating and cleaning up each resource
+	TimeoutMinutes int `json:"timeoutMinutes,omitempty"`
+	// Image overrides the image the checksum query is run with
+	Image string `json:"image,omitempty"`
+}
+
+// RestoreDrillCheck is the outcome of restoring, validating and cleaning up the snapshot of a single resource
+type RestoreDrillCheck struct {
+	// ResourceName is the name of the postgres resource whose snapshot is restored
+	ResourceName string `json:"resourceName"`
+	// SnapshotName is the name of the postgres snapshot that was restored
+	SnapshotName string `json:"snapshotName,omitempty"`
+	// RestoredName is the name of the temporary postgres resource restored from the snapshot
+	RestoredName string              `json:"restoredName,omitempty"`
+	Phase        types.StatusPhase   `json:"phase,omitempty"`
+	Result       RestoreDrillResult  `json:"result,omitempty"`
+	Message      types.StatusMessage `json:"message,omitempty"`
+	// Checksum is the output of the checksum query against the restored postgres
+	Checksum string `json:"checksum,omitempty"`
+	// RestoreDuration is the time taken for the restored postgres to become available
+	RestoreDuration *metav1.Duration `json:"restoreDuration,omitempty"`
+	// Duration is the time taken to restore, validate and clean up the snapshot
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
+// RestoreDrillReportStatus defines the observed state of RestoreDrillReport
+type RestoreDrillReportStatus struct {
+	Phase          types.StatusPhase   `json:"phase,omitempty"`
+	Result         RestoreDrillResult  `json:"result,omitempty"`
+	Message        types.StatusMessage `json:"message,omitempty"`
+	StartTime      *metav1.Time        `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time        `json:"completionTime,omitempty"`
+	Duration       *metav1.Duration    `json:"duration,omitempty"`
+	Checks         []RestoreDrillCheck `json:"checks,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=restoredrillreports,scope=Namespaced
+// +kubebuilder:printcolumn:name="Drill",type=string,JSONPath=`.spec.drillName`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Result",type=string,JSONPath=`.status.result`
+// +kubebuilder:printcolumn:name="Duration",type=string,JSONPath=`.status.duration`
+
+// RestoreDrillReport is the Schema for the restoredrillreports API
+type RestoreDrillReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RestoreDrillReportSpec   `json:"spec,omitempty"`
+	Status RestoreDrillReportStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RestoreDrillReportList contains a list of RestoreDrillReport
+type RestoreDrillReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RestoreDrillReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RestoreDrillReport{}, &RestoreDrillReportList{})
+}
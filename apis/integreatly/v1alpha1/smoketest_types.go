@@ -0,0 +1,94 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type SmokeTestResult string
+
+var (
+	SmokeTestResultPass SmokeTestResult = "pass"
+	SmokeTestResultFail SmokeTestResult = "fail"
+)
+
+// SmokeTestSpec defines the desired state of SmokeTest
+type SmokeTestSpec struct {
+	// Type is the deployment type the resources are provisioned with, e.g. managed or workshop
+	Type string `json:"type"`
+	// Tier is the tier the resources are provisioned with, defaults to development
+	Tier string `json:"tier,omitempty"`
+	// ResourceTypes limits the tested resource types to a subset of blobstorage, postgres and redis, all are tested if unset
+	ResourceTypes []string `json:"resourceTypes,omitempty"`
+	// TimeoutMinutes is the time allowed for provisioning and tearing down the resources, defaults to 30
+	TimeoutMinutes int `json:"timeoutMinutes,omitempty"`
+}
+
+// SmokeTestCheck is the outcome of provisioning, verifying and tearing down a single resource type
+type SmokeTestCheck struct {
+	ResourceType string              `json:"resourceType"`
+	Strategy     string              `json:"strategy,omitempty"`
+	Phase        types.StatusPhase   `json:"phase,omitempty"`
+	Result       SmokeTestResult     `json:"result,omitempty"`
+	Message      types.StatusMessage `json:"message,omitempty"`
+	// ProvisionDuration is the time taken for the resource to become available
+	ProvisionDuration *metav1.Duration `json:"provisionDuration,omitempty"`
+	// Duration is the time taken to provision, verify and tear down the resource
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
+// SmokeTestStatus defines the observed state of SmokeTest
+type SmokeTestStatus struct {
+	Phase          types.StatusPhase   `json:"phase,omitempty"`
+	Result         SmokeTestResult     `json:"result,omitempty"`
+	Message        types.StatusMessage `json:"message,omitempty"`
+	StartTime      *metav1.Time        `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time        `json:"completionTime,omitempty"`
+	Duration       *metav1.Duration    `json:"duration,omitempty"`
+	Checks         []SmokeTestCheck    `json:"checks,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=smoketests,scope=Namespaced
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Result",type=string,JSONPath=`.status.result`
+// +kubebuilder:printcolumn:name="Duration",type=string,JSONPath=`.status.duration`
+
+// SmokeTest is the Schema for the smoketests API
+type SmokeTest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SmokeTestSpec   `json:"spec,omitempty"`
+	Status SmokeTestStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SmokeTestList contains a list of SmokeTest
+type SmokeTestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SmokeTest `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SmokeTest{}, &SmokeTestList{})
+}
@@ -2,6 +2,9 @@ package types
 
 import (
 	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 var (
@@ -29,6 +32,106 @@ type ResourceTypeSpec struct {
 	// ApplyImmediately is only available to Postgres cr, for blobstorage and redis cr's currently does nothing
 	ApplyImmediately bool       `json:"applyImmediately,omitempty"`
 	SecretRef        *SecretRef `json:"secretRef"`
+	// SnapshotSchedule is only available to Postgres and Redis cr's using the aws provider, for blobstorage cr's currently does nothing
+	SnapshotSchedule *SnapshotSchedule `json:"snapshotSchedule,omitempty"`
+	// ApplyFinalSnapshot takes a final snapshot of the cloud resource when the resource is deleted if true, or skips it
+	// if false, overriding the strategy. Only available to Postgres and Redis cr's using the aws provider, for
+	// blobstorage cr's currently does nothing
+	ApplyFinalSnapshot *bool `json:"applyFinalSnapshot,omitempty"`
+	// FinalSnapshotNameTemplate is the go template of the name of the final snapshot, e.g.
+	// "{{.Namespace}}-{{.Name}}-{{.Timestamp}}", with the fields ClusterID, Namespace, Name and Timestamp, the time the
+	// resource was deleted. Only available to Postgres and Redis cr's using the aws provider, for blobstorage cr's
+	// currently does nothing
+	FinalSnapshotNameTemplate string `json:"finalSnapshotNameTemplate,omitempty"`
+	// RestoreFrom is only available to Postgres cr's using the aws or openshift provider, for blobstorage and redis cr's currently does nothing
+	RestoreFrom *RestoreFrom `json:"restoreFrom,omitempty"`
+	// CredentialRotation is only available to Postgres cr's using the aws or openshift provider, for blobstorage and redis cr's currently does nothing
+	CredentialRotation *CredentialRotation `json:"credentialRotation,omitempty"`
+	// DeletionProtection blocks the deletion of the cloud resource while set, the resource is kept until it's unset. For
+	// Postgres cr's using the aws provider it also enables the deletion protection of the rds instance. Only available
+	// to Postgres and Redis cr's, for blobstorage and queue cr's currently does nothing
+	DeletionProtection bool `json:"deletionProtection,omitempty"`
+	// Paused stops the cloud resource while set, e.g. to save the cost of a staging database outside working hours, and
+	// starts it once unset. The resource isn't ready while it's stopped. Only available to Postgres cr's using the aws
+	// provider, for blobstorage, redis and queue cr's currently does nothing
+	Paused bool `json:"paused,omitempty"`
+	// Hibernate scales the workload of the resource to zero replicas while set, keeping its pvc and secret, and restores
+	// its replicas once unset. The resource isn't ready while it's hibernated. Only available to Postgres and Redis cr's
+	// using the openshift provider, for blobstorage and queue cr's currently does nothing
+	Hibernate bool `json:"hibernate,omitempty"`
+	// ProviderConfig is merged over the strategy of the tier of the resource as a json merge patch, so a resource can
+	// change single values of its tier, e.g. the instance class, without a tier of its own. It's merged over the
+	// createStrategy of the aws and gcp providers and the strategy of the openshift provider. Only available to
+	// Postgres, Redis and BlobStorage cr's, for queue cr's currently does nothing
+	// +kubebuilder:pruning:PreserveUnknownFields
+	ProviderConfig *runtime.RawExtension `json:"providerConfig,omitempty"`
+	// DependsOn are the resources that must be complete before this resource is provisioned
+	DependsOn []Dependency `json:"dependsOn,omitempty"`
+	// OnBehalfOf is the namespace the resource is requested on behalf of, which must allow the namespace of the resource
+	// with a ResourceGrant. Only available to Postgres, Redis and BlobStorage cr's, for queue cr's currently does nothing
+	OnBehalfOf string `json:"onBehalfOf,omitempty"`
+	// TLS is only available to Postgres cr's using the openshift provider, for blobstorage and redis cr's currently does nothing
+	TLS bool `json:"tls,omitempty"`
+	// Version is the major postgres version, e.g. "13", mapped by the provider to a concrete engine version from the
+	// supported versions of the strategy. Only available to Postgres cr's, for blobstorage and redis cr's currently does nothing
+	Version string `json:"version,omitempty"`
+	// Sizing is only available to Redis cr's using the aws provider, for blobstorage and postgres cr's currently does nothing
+	Sizing *RedisSizing `json:"sizing,omitempty"`
+	// ExternalAccess is only available to Postgres cr's using the aws or openshift provider and Redis cr's using the
+	// openshift provider, for blobstorage cr's currently does nothing
+	ExternalAccess *ExternalAccess `json:"externalAccess,omitempty"`
+}
+
+// ExternalAccess allows clients outside the cluster, e.g. a BI tool, to connect to a database from the listed source
+// ranges. Removing it revokes the access
+// +kubebuilder:object:generate=true
+type ExternalAccess struct {
+	// AllowedCIDRs are the source ranges allowed to connect, e.g. 203.0.113.0/24
+	// +kubebuilder:validation:MinItems=1
+	AllowedCIDRs []string `json:"allowedCIDRs"`
+}
+
+// RedisSizing sizes the replication group of a redis, overriding the node type and number of cache clusters of the
+// strategy. Changes are applied to the existing replication group
+type RedisSizing struct {
+	// NodeType is the cache node type of every cache cluster, e.g. cache.t3.small
+	NodeType string `json:"nodeType,omitempty"`
+	// NumCacheClusters is the number of cache clusters, the primary and its replicas
+	// +kubebuilder:validation:Minimum=2
+	// +kubebuilder:validation:Maximum=6
+	NumCacheClusters int64 `json:"numCacheClusters,omitempty"`
+}
+
+// Dependency references a resource, in the namespace of the dependent resource, that must be complete before the
+// dependent resource is provisioned
+type Dependency struct {
+	// Kind is the kind of the resource, one of Postgres, Redis or BlobStorage
+	// +kubebuilder:validation:Enum=Postgres;Redis;BlobStorage
+	Kind string `json:"kind"`
+	// Name is the name of the resource
+	Name string `json:"name"`
+}
+
+// CredentialRotation defines how often the credentials of a resource are replaced
+type CredentialRotation struct {
+	// IntervalDays is the number of days between rotations, if unset credentials are only rotated on request
+	IntervalDays int `json:"intervalDays,omitempty"`
+}
+
+// RestoreFrom references the snapshot a new resource is restored from, it's ignored once the resource exists
+type RestoreFrom struct {
+	// SnapshotName is the name of a complete snapshot cr, in the namespace of the resource, to restore from
+	SnapshotName string `json:"snapshotName,omitempty"`
+	// SnapshotID is the provider identifier of a snapshot to restore from, used if snapshotName isn't set
+	SnapshotID string `json:"snapshotID,omitempty"`
+}
+
+// SnapshotSchedule defines when snapshots of a resource are taken and how long they're kept
+type SnapshotSchedule struct {
+	// Schedule is a five field cron expression, evaluated in UTC, e.g. "0 2 * * *" for 02:00 every day
+	Schedule string `json:"schedule"`
+	// RetentionDays is the number of days scheduled snapshots are kept for, if unset they're kept until deleted
+	RetentionDays int `json:"retentionDays,omitempty"`
 }
 
 type StatusPhase string
@@ -50,10 +153,208 @@ type ResourceTypeStatus struct {
 	SecretRef *SecretRef    `json:"secretRef,omitempty"`
 	Phase     StatusPhase   `json:"phase,omitempty"`
 	Message   StatusMessage `json:"message,omitempty"`
+	// CostClass is the cost class of the provisioned resource, one of S, M, L or XL
+	CostClass string `json:"costClass,omitempty"`
+	// EstimatedMonthlyCost is the approximate monthly cost of the provisioned resource
+	EstimatedMonthlyCost string `json:"estimatedMonthlyCost,omitempty"`
+	// CredentialsRotatedAt is the time, in RFC3339 format, the credentials were last rotated
+	CredentialsRotatedAt string `json:"credentialsRotatedAt,omitempty"`
+	// Network is the network the provisioned resource was placed in, if the provider places it in a network
+	Network *NetworkStatus `json:"network,omitempty"`
+	// Conditions are the observations of the state of the resource, e.g. if its dependencies are complete
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Provisioning is the job creating the cloud resource of the resource
+	Provisioning *ProvisioningJob `json:"provisioning,omitempty"`
+	// CloudResource identifies the cloud resource provisioned for the resource
+	CloudResource *CloudResourceStatus `json:"cloudResource,omitempty"`
+	// ExternalAccess is the external access applied to the resource, if its spec allows external access
+	ExternalAccess *ExternalAccessStatus `json:"externalAccess,omitempty"`
+	// Checkpoint is the state of an operation of the resource that was in flight when the operator shut down
+	Checkpoint *OperationCheckpoint `json:"checkpoint,omitempty"`
+	// Migration is the migration of the data of the resource to the cloud resource of another strategy, set once the
+	// strategy of the resource changed
+	Migration *MigrationStatus `json:"migration,omitempty"`
+	// History is the last significant transitions of the resource, oldest first, e.g. when it became complete, when
+	// it failed or when the strategy of its tier changed
+	History []HistoryEntry `json:"history,omitempty"`
+}
+
+// HistoryEntry is a significant transition of a resource
+// +kubebuilder:object:generate=true
+type HistoryEntry struct {
+	// Time is when the transition happened
+	Time metav1.Time `json:"time"`
+	// Reason is a CamelCase reason of the transition, e.g. ProvisioningCompleted
+	Reason string `json:"reason"`
+	// Phase is the phase of the resource after the transition
+	Phase StatusPhase `json:"phase,omitempty"`
+	// Message is the status message of the resource after the transition
+	Message StatusMessage `json:"message,omitempty"`
+	// StrategyHash is the sha256 of the tier configuration the resource was reconciled with at the time
+	StrategyHash string `json:"strategyHash,omitempty"`
+}
+
+type ProvisioningState string
+
+const (
+	// ProvisioningStateQueued is the state of a job waiting for a free slot of its provider
+	ProvisioningStateQueued ProvisioningState = "Queued"
+	// ProvisioningStateRunning is the state of a job holding a slot of its provider while the resource is created
+	ProvisioningStateRunning ProvisioningState = "Running"
+	// ProvisioningStateSucceeded is the state of a job once the resource was created
+	ProvisioningStateSucceeded ProvisioningState = "Succeeded"
+	// ProvisioningStateCancelled is the state of a job whose resource creation was cancelled
+	ProvisioningStateCancelled ProvisioningState = "Cancelled"
+)
+
+// ProvisioningJob tracks the creation of a cloud resource, so the number of resources a provider creates at once
+// can be capped
+// +kubebuilder:object:generate=true
+type ProvisioningJob struct {
+	// Pool is the provider the job holds a slot of, e.g. aws-rds
+	Pool string `json:"pool"`
+	// State is one of Queued, Running, Succeeded or Cancelled
+	State ProvisioningState `json:"state"`
+	// QueuedAt is the time the job was first queued, if it had to wait for a slot
+	QueuedAt *metav1.Time `json:"queuedAt,omitempty"`
+	// StartedAt is the time the job was given a slot
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+	// CompletedAt is the time the resource was created, or its creation was cancelled
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+	// Steps are the objects created in order by providers creating several objects for the resource, e.g. the
+	// openshift provider, so a partial failure shows which object failed
+	Steps []ProvisioningStep `json:"steps,omitempty"`
+}
+
+type ProvisioningStepState string
+
+const (
+	// ProvisioningStepPending is the state of a step whose object isn't created yet
+	ProvisioningStepPending ProvisioningStepState = "Pending"
+	// ProvisioningStepWaiting is the state of a step whose object is created but isn't ready yet
+	ProvisioningStepWaiting ProvisioningStepState = "Waiting"
+	// ProvisioningStepReady is the state of a step whose object is ready
+	ProvisioningStepReady ProvisioningStepState = "Ready"
+	// ProvisioningStepFailed is the state of a step whose object failed to be created, it's retried
+	ProvisioningStepFailed ProvisioningStepState = "Failed"
+	// ProvisioningStepRolledBack is the state of a step whose object was deleted after a later step failed for good
+	ProvisioningStepRolledBack ProvisioningStepState = "RolledBack"
+)
+
+// ProvisioningStep is an object created while a resource is provisioned
+// +kubebuilder:object:generate=true
+type ProvisioningStep struct {
+	// Name is the kind of the object created by the step, e.g. Secret
+	Name string `json:"name"`
+	// State is one of Pending, Waiting, Ready, Failed or RolledBack
+	State ProvisioningStepState `json:"state"`
+	// Created is true if the object was created by the provisioning job, only created objects are deleted when the
+	// provisioning is rolled back
+	Created bool `json:"created,omitempty"`
+	// Message is why the step isn't ready, or why it failed
+	Message string `json:"message,omitempty"`
+	// LastTransitionTime is the time the step last changed state
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// OperationCheckpoint is the state of a long running operation persisted when the operator shut down while it was in
+// flight, so the operation is resumed on restart instead of its state being re-derived from the provider
+// +kubebuilder:object:generate=true
+type OperationCheckpoint struct {
+	// Operation is the interrupted operation, e.g. CreateSnapshot or FinalSnapshot
+	Operation string `json:"operation"`
+	// Step is the step of the operation that was in flight, e.g. CreateDBSnapshot
+	Step string `json:"step,omitempty"`
+	// SnapshotID is the identifier of the snapshot the operation is taking, if it takes one
+	SnapshotID string `json:"snapshotID,omitempty"`
+	// CheckpointedAt is the time the operator shut down
+	CheckpointedAt *metav1.Time `json:"checkpointedAt,omitempty"`
+}
+
+type MigrationState string
+
+const (
+	// MigrationStateProvisioning is the state of a migration while the cloud resource of the new strategy is created
+	MigrationStateProvisioning MigrationState = "Provisioning"
+	// MigrationStateCopying is the state of a migration while the job copies the data to the new cloud resource
+	MigrationStateCopying MigrationState = "Copying"
+	// MigrationStateCuttingOver is the state of a migration once the data was copied, while the connection secret is
+	// swapped and the old cloud resource is removed
+	MigrationStateCuttingOver MigrationState = "CuttingOver"
+	// MigrationStateCompleted is the state of a migration once the old cloud resource was removed
+	MigrationStateCompleted MigrationState = "Completed"
+	// MigrationStateFailed is the state of a migration whose job failed, it's retried once the job is deleted
+	MigrationStateFailed MigrationState = "Failed"
+)
+
+// MigrationStatus tracks the migration of the data of a resource from the cloud resource of one strategy to the cloud
+// resource of another, e.g. from an in-cluster deployment to an rds instance
+// +kubebuilder:object:generate=true
+type MigrationStatus struct {
+	// From is the strategy the data is migrated from
+	From string `json:"from"`
+	// To is the strategy the data is migrated to
+	To string `json:"to"`
+	// State is the step the migration is in
+	State MigrationState `json:"state"`
+	// Job is the name of the job copying the data
+	Job string `json:"job,omitempty"`
+	// StartedAt is the time the migration started
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+	// CompletedAt is the time the connection secret was swapped to the cloud resource of the new strategy
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+}
+
+// CloudResourceStatus identifies the cloud resource provisioned for a resource, so it can be found in its provider
+// +kubebuilder:object:generate=true
+type CloudResourceStatus struct {
+	// InstanceID is the identifier of the cloud resource in its provider, e.g. the rds instance identifier or the
+	// namespace and name of the in-cluster deployment
+	InstanceID string `json:"instanceID,omitempty"`
+	// ARN is the amazon resource name of the cloud resource, only set by aws providers
+	ARN string `json:"arn,omitempty"`
+	// Endpoint is the host and port clients connect to, not set for blob storage
+	Endpoint string `json:"endpoint,omitempty"`
+	// Region is the region of the cloud resource, not set for resources provisioned in the cluster
+	Region string `json:"region,omitempty"`
+	// KmsKeyARN is the arn of the kms key the cloud resource is encrypted at rest with, only set by aws providers and
+	// not set for s3 buckets encrypted with s3 managed keys
+	KmsKeyARN string `json:"kmsKeyARN,omitempty"`
+}
+
+// ExternalAccessStatus describes the external access applied to a database
+// +kubebuilder:object:generate=true
+type ExternalAccessStatus struct {
+	// AllowedCIDRs are the source ranges currently allowed to connect
+	AllowedCIDRs []string `json:"allowedCIDRs,omitempty"`
+	// Endpoint is the host and port external clients connect to, empty until the provider assigns it
+	Endpoint string `json:"endpoint,omitempty"`
+	// LastChangedAt is the time the allowed source ranges last changed
+	LastChangedAt *metav1.Time `json:"lastChangedAt,omitempty"`
+}
+
+// NetworkStatus describes the network a resource was placed in
+// +kubebuilder:object:generate=true
+type NetworkStatus struct {
+	// VpcID is the id of the vpc the resource was placed in
+	VpcID string `json:"vpcID,omitempty"`
+	// SubnetIDs are the ids of the subnets the resource can be placed in
+	SubnetIDs []string `json:"subnetIDs,omitempty"`
 }
 
+// +kubebuilder:object:generate=true
 type ResourceTypeSnapshotStatus struct {
 	SnapshotID string        `json:"snapshotID,omitempty"`
 	Phase      StatusPhase   `json:"phase,omitempty"`
 	Message    StatusMessage `json:"message,omitempty"`
+	// CrossRegionSnapshotID is the id of the copy of the snapshot in the secondary region, if configured
+	CrossRegionSnapshotID string `json:"crossRegionSnapshotID,omitempty"`
+	// CrossRegionSnapshotRegion is the secondary region the snapshot is copied to
+	CrossRegionSnapshotRegion string `json:"crossRegionSnapshotRegion,omitempty"`
+	// SnapshotARN is the arn of the snapshot once it's available
+	SnapshotARN string `json:"snapshotARN,omitempty"`
+	// RetainUntil is the time, in RFC3339 format, after which the snapshot is deleted, if it has a retention period
+	RetainUntil string `json:"retainUntil,omitempty"`
+	// Checkpoint is the state of the creation of the snapshot if it was in flight when the operator shut down
+	Checkpoint *OperationCheckpoint `json:"checkpoint,omitempty"`
 }
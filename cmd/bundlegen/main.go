@@ -0,0 +1,74 @@
+// bundlegen renders the helm chart or olm bundle of the operator
+//
+//	go run ./cmd/bundlegen --format helm --providers aws,openshift --preset production --output ./tmp/chart
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/bundle"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+)
+
+func main() {
+	var format, providerList, preset, featureGates, ver, image, crdDir, output string
+	flag.StringVar(&format, "format", bundle.FormatHelm, "Output format, one of helm or olm")
+	flag.StringVar(&providerList, "providers", strings.Join([]string{providers.AWSDeploymentStrategy, providers.OpenShiftDeploymentStrategy}, ","), "Comma separated list of enabled providers")
+	flag.StringVar(&preset, "preset", bundle.PresetDevelopment, "Feature gate preset, one of development or production")
+	flag.StringVar(&featureGates, "feature-gates", "", "Comma separated feature gate overrides, e.g. RedisSentinel=false")
+	flag.StringVar(&ver, "version", "", "Operator version")
+	flag.StringVar(&image, "image", "", "Operator image, defaults to the released image of the version")
+	flag.StringVar(&crdDir, "crd-dir", "config/crd/bases", "Directory containing the generated crds")
+	flag.StringVar(&output, "output", "", "Directory the chart or bundle is written to")
+	flag.Parse()
+
+	if err := run(format, providerList, preset, featureGates, ver, image, crdDir, output); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(format, providerList, preset, featureGates, ver, image, crdDir, output string) error {
+	if output == "" {
+		return fmt.Errorf("output must be set")
+	}
+	gates, err := bundle.ParseFeatureGates(featureGates)
+	if err != nil {
+		return err
+	}
+	var enabled []string
+	for _, p := range strings.Split(providerList, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			enabled = append(enabled, p)
+		}
+	}
+
+	files, err := bundle.Render(&bundle.Options{
+		Format:       format,
+		Providers:    enabled,
+		Preset:       preset,
+		FeatureGates: gates,
+		Version:      ver,
+		Image:        image,
+		CRDDir:       crdDir,
+	})
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		path := filepath.Join(output, f.Path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", path, err)
+		}
+		if err := ioutil.WriteFile(path, f.Content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	fmt.Printf("rendered %d files to %s\n", len(files), output)
+	return nil
+}
@@ -0,0 +1,133 @@
+// croctl inspects the cloud resources of a namespace
+//
+//	go run ./cmd/croctl tiers usage --namespace cloud-resources
+//	go run ./cmd/croctl resources --namespace cloud-resources
+//	go run ./cmd/croctl render --file postgres.yaml --strategy openshift --strategy-config strategies.yaml
+//	go run ./cmd/croctl postgres token --secret-dir /etc/postgres --file /var/run/postgres/token --refresh 10m
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/integr8ly/cloud-resource-operator/apis"
+	"github.com/integr8ly/cloud-resource-operator/internal/k8sutil"
+	"github.com/integr8ly/cloud-resource-operator/pkg/tiers"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const usage = `usage: croctl <command> [flags]
+
+commands:
+  tiers usage    report the strategy config map tiers used by resources and the resources whose tier was removed
+  resources      list the resources and the identifiers of the cloud resources provisioned for them
+  render         print the objects created for a postgres or redis resource and tier, for review in gitops pipelines
+  postgres token generate an iam authentication token for an aws postgres, and keep a token file fresh in a sidecar
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	if len(args) >= 2 && args[0] == "tiers" && args[1] == "usage" {
+		return tiersUsage(args[2:], out)
+	}
+	if len(args) >= 1 && args[0] == "resources" {
+		return listResources(args[1:], out)
+	}
+	if len(args) >= 2 && args[0] == "postgres" && args[1] == "token" {
+		return postgresToken(args[2:], out)
+	}
+	if len(args) >= 1 && args[0] == "render" {
+		return render(args[1:], out)
+	}
+	fmt.Fprint(os.Stderr, usage)
+	return fmt.Errorf("unknown command")
+}
+
+func tiersUsage(args []string, out io.Writer) error {
+	watchNamespace, _ := k8sutil.GetWatchNamespace()
+	fs := flag.NewFlagSet("tiers usage", flag.ExitOnError)
+	namespace := fs.String("namespace", watchNamespace, "Namespace of the resources and strategy config maps")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *namespace == "" {
+		return fmt.Errorf("namespace must be set")
+	}
+
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	usages, err := tiers.GetUsage(ctx, c, *namespace)
+	if err != nil {
+		return err
+	}
+	missing, err := tiers.MissingUsage(ctx, c, *namespace, usages)
+	if err != nil {
+		return err
+	}
+	return writeUsage(out, usages, missing)
+}
+
+// writeUsage writes a table of the tier usages, marking those whose tier is missing from its strategy config map
+func writeUsage(out io.Writer, usages, missing []tiers.Usage) error {
+	isMissing := map[tiers.Usage]bool{}
+	for _, u := range missing {
+		isMissing[u] = true
+	}
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAMESPACE\tNAME\tSTRATEGY\tTIER\tSTATUS")
+	for _, u := range usages {
+		status := "ok"
+		if u.Strategy == "" {
+			status = "unknown strategy"
+		} else if isMissing[u] {
+			status = "missing"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", u.Kind, u.Namespace, u.Name, u.Strategy, u.Tier, status)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%d resources use tiers missing from their strategy config map", len(missing))
+	}
+	return nil
+}
+
+func newClient() (client.Client, error) {
+	cfg, err := ctrl.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kubeconfig: %w", err)
+	}
+	scheme, err := newScheme()
+	if err != nil {
+		return nil, err
+	}
+	return client.New(cfg, client.Options{Scheme: scheme})
+}
+
+func newScheme() (*runtime.Scheme, error) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	if err := apis.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	return scheme, nil
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/internal/k8sutil"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/openshift"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// renderOptions are the flags of the render command
+type renderOptions struct {
+	file           string
+	tier           string
+	strategy       string
+	strategyConfig string
+	namespace      string
+}
+
+func render(args []string, out io.Writer) error {
+	watchNamespace, _ := k8sutil.GetWatchNamespace()
+	opts := renderOptions{}
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	fs.StringVar(&opts.file, "file", "", "File of the postgres or redis resource to render")
+	fs.StringVar(&opts.tier, "tier", "", "Tier to render the resource with, defaults to the tier of the resource")
+	fs.StringVar(&opts.strategy, "strategy", "", "Strategy provider to render the resource with, e.g. openshift, defaults to the provider of the deployment type in the cluster")
+	fs.StringVar(&opts.strategyConfig, "strategy-config", "", "File of the openshift strategy config map, defaults to the config map in the cluster")
+	fs.StringVar(&opts.namespace, "namespace", watchNamespace, "Namespace of the resource if the file doesn't set one, and of the config maps in the cluster")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if opts.file == "" {
+		return fmt.Errorf("file must be set")
+	}
+
+	objs, err := renderFile(context.Background(), opts)
+	if err != nil {
+		return err
+	}
+	return writeRendered(out, objs)
+}
+
+// renderFile returns the result secret and, for the openshift strategy, the workload objects created for the resource
+// in the file. The cluster is only read for what isn't set by the options
+func renderFile(ctx context.Context, opts renderOptions) ([]runtime.Object, error) {
+	data, err := ioutil.ReadFile(opts.file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", opts.file, err)
+	}
+	scheme, err := newScheme()
+	if err != nil {
+		return nil, err
+	}
+	decoded, _, err := serializer.NewCodecFactory(scheme).UniversalDeserializer().Decode(data, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", opts.file, err)
+	}
+
+	var c client.Client
+	getClient := func() (client.Client, error) {
+		if c != nil {
+			return c, nil
+		}
+		c, err = newClient()
+		return c, err
+	}
+
+	switch cr := decoded.(type) {
+	case *v1alpha1.Postgres:
+		applyRenderOptions(cr, &cr.Spec, opts)
+		strategy, err := resolveRenderStrategy(ctx, getClient, opts, cr.Spec.Type, func(m *providers.DeploymentStrategyMapping) string { return m.Postgres })
+		if err != nil {
+			return nil, err
+		}
+		sec, err := buildRenderedResultSecret(cr, cr.Spec, (&providers.PostgresDeploymentDetails{}).Data())
+		if err != nil {
+			return nil, err
+		}
+		if strategy != providers.OpenShiftDeploymentStrategy {
+			return []runtime.Object{sec}, nil
+		}
+		stratCfg, err := readRenderStrategyConfig(ctx, getClient, opts, providers.PostgresResourceType, cr.Spec.Tier)
+		if err != nil {
+			return nil, err
+		}
+		objs, err := openshift.RenderPostgres(cr, stratCfg)
+		if err != nil {
+			return nil, err
+		}
+		return append([]runtime.Object{sec}, objs...), nil
+	case *v1alpha1.Redis:
+		applyRenderOptions(cr, &cr.Spec, opts)
+		strategy, err := resolveRenderStrategy(ctx, getClient, opts, cr.Spec.Type, func(m *providers.DeploymentStrategyMapping) string { return m.Redis })
+		if err != nil {
+			return nil, err
+		}
+		sec, err := buildRenderedResultSecret(cr, cr.Spec, (&providers.RedisDeploymentDetails{}).Data())
+		if err != nil {
+			return nil, err
+		}
+		if strategy != providers.OpenShiftDeploymentStrategy {
+			return []runtime.Object{sec}, nil
+		}
+		stratCfg, err := readRenderStrategyConfig(ctx, getClient, opts, providers.RedisResourceType, cr.Spec.Tier)
+		if err != nil {
+			return nil, err
+		}
+		objs, err := openshift.RenderRedis(cr, stratCfg)
+		if err != nil {
+			return nil, err
+		}
+		return append([]runtime.Object{sec}, objs...), nil
+	default:
+		return nil, fmt.Errorf("rendering %s is not supported, only postgres and redis resources can be rendered", decoded.GetObjectKind().GroupVersionKind().Kind)
+	}
+}
+
+// applyRenderOptions sets the namespace and tier of the resource from the options
+func applyRenderOptions(cr metav1.Object, spec *croType.ResourceTypeSpec, opts renderOptions) {
+	if cr.GetNamespace() == "" {
+		cr.SetNamespace(opts.namespace)
+	}
+	if opts.tier != "" {
+		spec.Tier = opts.tier
+	}
+}
+
+// resolveRenderStrategy returns the strategy provider of the options, or of the deployment type in the cluster
+func resolveRenderStrategy(ctx context.Context, getClient func() (client.Client, error), opts renderOptions, deploymentType string, strategyFor func(*providers.DeploymentStrategyMapping) string) (string, error) {
+	if opts.strategy != "" {
+		return opts.strategy, nil
+	}
+	c, err := getClient()
+	if err != nil {
+		return "", err
+	}
+	mapping, err := providers.NewConfigManager("", opts.namespace, c).GetStrategyMappingForDeploymentType(ctx, deploymentType)
+	if err != nil {
+		return "", err
+	}
+	return strategyFor(mapping), nil
+}
+
+// readRenderStrategyConfig returns the openshift strategy config of the tier from the strategy config file of the
+// options, or from the cluster
+func readRenderStrategyConfig(ctx context.Context, getClient func() (client.Client, error), opts renderOptions, rt providers.ResourceType, tier string) (*openshift.StrategyConfig, error) {
+	if opts.strategyConfig == "" {
+		c, err := getClient()
+		if err != nil {
+			return nil, err
+		}
+		return openshift.NewConfigMapConfigManager("", opts.namespace, c).ReadStorageStrategy(ctx, rt, tier)
+	}
+	data, err := ioutil.ReadFile(opts.strategyConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", opts.strategyConfig, err)
+	}
+	cm := &v1.ConfigMap{}
+	if err := yaml.Unmarshal(data, cm); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", opts.strategyConfig, err)
+	}
+	return openshift.StrategyFromConfigMap(cm, rt, tier)
+}
+
+// buildRenderedResultSecret returns the secret the connection details of the resource are written to, the values are
+// only known once the resource is provisioned so they're rendered as placeholders
+func buildRenderedResultSecret(cr metav1.Object, spec croType.ResourceTypeSpec, details map[string][]byte) (*v1.Secret, error) {
+	if spec.SecretRef == nil || spec.SecretRef.Name == "" {
+		return nil, fmt.Errorf("secretRef of %s must be set", cr.GetName())
+	}
+	ns := cr.GetNamespace()
+	if spec.SecretRef.Namespace != "" {
+		ns = spec.SecretRef.Namespace
+	}
+	sec := &v1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      spec.SecretRef.Name,
+			Namespace: ns,
+		},
+		StringData: map[string]string{},
+		Type:       v1.SecretTypeOpaque,
+	}
+	for k := range details {
+		sec.StringData[k] = openshift.RenderPlaceholder
+	}
+	resources.AddGitOpsAnnotations(sec)
+	return sec, nil
+}
+
+// writeRendered writes the objects as a multi document yaml stream
+func writeRendered(out io.Writer, objs []runtime.Object) error {
+	for _, obj := range objs {
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to marshal rendered object: %w", err)
+		}
+		if _, err := fmt.Fprintf(out, "---\n%s", data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/internal/k8sutil"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// resourceRow is a postgres, redis or blobstorage resource and the cloud resource provisioned for it
+type resourceRow struct {
+	kind      string
+	namespace string
+	name      string
+	status    croType.ResourceTypeStatus
+}
+
+func listResources(args []string, out io.Writer) error {
+	watchNamespace, _ := k8sutil.GetWatchNamespace()
+	fs := flag.NewFlagSet("resources", flag.ExitOnError)
+	namespace := fs.String("namespace", watchNamespace, "Namespace of the resources")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *namespace == "" {
+		return fmt.Errorf("namespace must be set")
+	}
+
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	rows, err := getResourceRows(context.Background(), c, *namespace)
+	if err != nil {
+		return err
+	}
+	return writeResources(out, rows)
+}
+
+func getResourceRows(ctx context.Context, c client.Client, ns string) ([]resourceRow, error) {
+	var rows []resourceRow
+	postgresList := &v1alpha1.PostgresList{}
+	if err := c.List(ctx, postgresList, client.InNamespace(ns)); err != nil {
+		return nil, fmt.Errorf("failed to list postgres: %w", err)
+	}
+	for _, r := range postgresList.Items {
+		rows = append(rows, resourceRow{kind: "Postgres", namespace: r.Namespace, name: r.Name, status: r.Status})
+	}
+	redisList := &v1alpha1.RedisList{}
+	if err := c.List(ctx, redisList, client.InNamespace(ns)); err != nil {
+		return nil, fmt.Errorf("failed to list redis: %w", err)
+	}
+	for _, r := range redisList.Items {
+		rows = append(rows, resourceRow{kind: "Redis", namespace: r.Namespace, name: r.Name, status: r.Status})
+	}
+	blobStorageList := &v1alpha1.BlobStorageList{}
+	if err := c.List(ctx, blobStorageList, client.InNamespace(ns)); err != nil {
+		return nil, fmt.Errorf("failed to list blobstorage: %w", err)
+	}
+	for _, r := range blobStorageList.Items {
+		rows = append(rows, resourceRow{kind: "BlobStorage", namespace: r.Namespace, name: r.Name, status: r.Status})
+	}
+	return rows, nil
+}
+
+// writeResources writes a table of the resources and the identifiers of their cloud resources
+func writeResources(out io.Writer, rows []resourceRow) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAMESPACE\tNAME\tPHASE\tPROVIDER\tINSTANCE ID\tENDPOINT\tREGION\tARN")
+	for _, r := range rows {
+		cr := r.status.CloudResource
+		if cr == nil {
+			cr = &croType.CloudResourceStatus{}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.kind, r.namespace, r.name, r.status.Phase, r.status.Provider,
+			cr.InstanceID, cr.Endpoint, cr.Region, cr.ARN)
+	}
+	return w.Flush()
+}
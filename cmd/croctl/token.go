@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	awsSDK "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+)
+
+// tokenOptions are the flags of the postgres token command, values not set are read from the connection secret
+// mounted in secretDir
+type tokenOptions struct {
+	secretDir string
+	host      string
+	port      string
+	region    string
+	user      string
+	file      string
+	refresh   time.Duration
+}
+
+func postgresToken(args []string, out io.Writer) error {
+	opts := tokenOptions{}
+	fs := flag.NewFlagSet("postgres token", flag.ExitOnError)
+	fs.StringVar(&opts.secretDir, "secret-dir", "", "Directory the connection secret of the postgres is mounted in")
+	fs.StringVar(&opts.host, "host", "", "Host of the rds instance, defaults to the host of the connection secret")
+	fs.StringVar(&opts.port, "port", "", "Port of the rds instance, defaults to the port of the connection secret")
+	fs.StringVar(&opts.region, "region", "", "Region of the rds instance, defaults to the region of the connection secret")
+	fs.StringVar(&opts.user, "user", "", "Database user to connect as, defaults to the username of the connection secret")
+	fs.StringVar(&opts.file, "file", "", "File the token is written to, the token is printed if not set")
+	fs.DurationVar(&opts.refresh, "refresh", 0, "Interval the token file is rewritten on until the command is stopped, e.g. 10m in a sidecar")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := opts.complete(); err != nil {
+		return err
+	}
+	if opts.refresh > 0 && opts.file == "" {
+		return fmt.Errorf("file must be set to refresh the token")
+	}
+	if opts.refresh >= aws.RDSAuthTokenLifetime {
+		return fmt.Errorf("refresh must be shorter than the %s lifetime of a token", aws.RDSAuthTokenLifetime)
+	}
+
+	sess, err := session.NewSession(&awsSDK.Config{Region: awsSDK.String(opts.region)})
+	if err != nil {
+		return fmt.Errorf("failed to create aws session: %w", err)
+	}
+	for {
+		token, err := aws.BuildRDSAuthToken(opts.host+":"+opts.port, opts.region, opts.user, sess.Config.Credentials, time.Now())
+		if err != nil {
+			return err
+		}
+		if opts.file == "" {
+			_, err := fmt.Fprintln(out, token)
+			return err
+		}
+		if err := writeFileAtomic(opts.file, []byte(token)); err != nil {
+			return err
+		}
+		if opts.refresh == 0 {
+			return nil
+		}
+		time.Sleep(opts.refresh)
+	}
+}
+
+// complete reads the values not set by flags from the mounted connection secret
+func (o *tokenOptions) complete() error {
+	for key, value := range map[string]*string{"host": &o.host, "port": &o.port, "region": &o.region, "username": &o.user} {
+		if *value != "" || o.secretDir == "" {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(o.secretDir, key))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read %s of connection secret: %w", key, err)
+		}
+		*value = strings.TrimSpace(string(data))
+	}
+	if o.port == "" {
+		o.port = "5432"
+	}
+	if o.host == "" || o.region == "" || o.user == "" {
+		return fmt.Errorf("host, region and user must be set, or read from the connection secret")
+	}
+	return nil
+}
+
+// writeFileAtomic replaces the file with the data, so a reader never sees a partly written token
+func writeFileAtomic(file string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(file), "."+filepath.Base(file))
+	if err != nil {
+		return fmt.Errorf("failed to create token file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return fmt.Errorf("failed to replace token file: %w", err)
+	}
+	return nil
+}
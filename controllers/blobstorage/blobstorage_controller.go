@@ -24,6 +24,9 @@ import (
 	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
 	"github.com/integr8ly/cloud-resource-operator/pkg/providers/openshift"
 	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources/metrics"
+	"github.com/integr8ly/cloud-resource-operator/pkg/supportbundle"
+	"github.com/integr8ly/cloud-resource-operator/pkg/tiers"
 
 	"github.com/sirupsen/logrus"
 
@@ -32,8 +35,10 @@ import (
 	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
 	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
 	errorUtil "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -51,6 +56,7 @@ var log = logf.Log.WithName("controller_blobstorage")
 type BlobStorageReconciler struct {
 	k8sclient.Client
 	scheme           *runtime.Scheme
+	recorder         record.EventRecorder
 	logger           *logrus.Entry
 	resourceProvider *resources.ReconcileResourceProvider
 	providerList     []providers.BlobStorageProvider
@@ -66,6 +72,8 @@ func New(mgr manager.Manager) (*BlobStorageReconciler, error) {
 	if err != nil {
 		return nil, err
 	}
+	// config maps and secrets are read from the shared informer cache of the manager instead of the api server
+	client = resources.NewCachedClient(client, mgr.GetCache())
 
 	logger := logrus.WithFields(logrus.Fields{"controller": "controller_blobstorage"})
 	awsBlobStorageProvider, err := aws.NewAWSBlobStorageProvider(client, logger)
@@ -77,6 +85,7 @@ func New(mgr manager.Manager) (*BlobStorageReconciler, error) {
 	return &BlobStorageReconciler{
 		Client:           client,
 		scheme:           mgr.GetScheme(),
+		recorder:         mgr.GetEventRecorderFor(resources.EventSource),
 		logger:           logger,
 		resourceProvider: rp,
 		providerList:     providerList,
@@ -87,22 +96,27 @@ func (r *BlobStorageReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&integreatlyv1alpha1.BlobStorage{}).
 		Watches(&source.Kind{Type: &v1alpha1.BlobStorage{}}, &handler.EnqueueRequestForObject{}).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, resources.EnqueueOnMetadataChange(mgr.GetClient(), &v1alpha1.BlobStorageList{})).
 		Complete(r)
 }
 
-func (r *BlobStorageReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error) {
+func (r *BlobStorageReconciler) Reconcile(request ctrl.Request) (result ctrl.Result, err error) {
 	r.logger.Info("reconciling BlobStorage")
 	ctx := context.TODO()
 	cfgMgr := providers.NewConfigManager(providers.DefaultProviderConfigMapName, request.Namespace, r.Client)
 
 	// Fetch the BlobStorage instance
 	instance := &v1alpha1.BlobStorage{}
-	err := r.Client.Get(ctx, request.NamespacedName, instance)
+	err = r.Client.Get(ctx, request.NamespacedName, instance)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
+			metrics.DeleteResourcePhase(string(providers.BlobStorageResourceType), request.NamespacedName)
+			providers.ResetRequeue(providers.BlobStorageResourceType, request.NamespacedName)
+			metrics.SetDeletionPaused(string(providers.BlobStorageResourceType), request.NamespacedName, false)
+			metrics.DeleteArtifactExpiry(string(providers.BlobStorageResourceType), request.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -132,6 +146,20 @@ func (r *BlobStorageReconciler) Reconcile(request ctrl.Request) (ctrl.Result, er
 		}
 		instance.Status.Strategy = strategyToUse
 		instance.Status.Provider = p.GetName()
+		defer func(provider string) {
+			metrics.ObserveReconcile(string(providers.BlobStorageResourceType), request.NamespacedName, provider, instance.Spec.Tier, instance.Status.Phase, err)
+		}(p.GetName())
+		defer func(before croType.ResourceTypeStatus) {
+			resources.RecordReconcileEvents(r.recorder, instance, instance.DeletionTimestamp != nil, before, instance.Status, err)
+		}(*instance.Status.DeepCopy())
+		defer func() {
+			if instance.Status.Phase != croType.PhaseFailed {
+				return
+			}
+			if bundleErr := supportbundle.Reconcile(ctx, r.Client, r.scheme, providers.BlobStorageResourceType, instance, instance.Spec, instance.Status, nil); bundleErr != nil {
+				r.logger.Warnf("failed to write support bundle of blobstorage %s: %v", instance.Name, bundleErr)
+			}
+		}()
 		if instance.Status.Strategy != strategyToUse || instance.Status.Provider != p.GetName() {
 			if err = r.Client.Status().Update(ctx, instance); err != nil {
 				return ctrl.Result{}, errorUtil.Wrapf(err, "failed to update instance %s in namespace %s", instance.Name, instance.Namespace)
@@ -139,6 +167,22 @@ func (r *BlobStorageReconciler) Reconcile(request ctrl.Request) (ctrl.Result, er
 		}
 
 		if instance.GetDeletionTimestamp() != nil {
+			// pause the deletion if too many resources were deleted recently, e.g. by a namespace or gitops mistake
+			guardMsg, err := providers.ReconcileDeletionGuard(ctx, r.Client, providers.BlobStorageResourceType, instance, &instance.Status)
+			if err != nil {
+				if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, guardMsg.WrapError(err)); updateErr != nil {
+					return ctrl.Result{}, updateErr
+				}
+				return ctrl.Result{}, err
+			}
+			if guardMsg != croType.StatusEmpty {
+				r.logger.Warn(guardMsg)
+				if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhasePaused, guardMsg); err != nil {
+					return ctrl.Result{}, err
+				}
+				return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+			}
+
 			msg, err := p.DeleteStorage(ctx, instance)
 			if err != nil {
 				if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, msg.WrapError(err)); updateErr != nil {
@@ -151,6 +195,92 @@ func (r *BlobStorageReconciler) Reconcile(request ctrl.Request) (ctrl.Result, er
 			if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseDeleteInProgress, msg.WrapError(err)); err != nil {
 				return ctrl.Result{}, err
 			}
+			return ctrl.Result{Requeue: true, RequeueAfter: providers.NextRequeue(p.GetName(), providers.BlobStorageResourceType, request.NamespacedName, croType.PhaseDeleteInProgress, p.GetReconcileTime(instance))}, nil
+		}
+
+		// abort the creation of the blob storage if it was cancelled, removing what was created so far
+		if providers.IsProvisioningCancelled(instance, &instance.Status) {
+			cancelled, msg, err := providers.ReconcileCancelledProvisioning(instance, &instance.Status, func() (croType.StatusMessage, error) {
+				return p.DeleteStorage(ctx, instance)
+			})
+			if err != nil {
+				if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, msg.WrapError(err)); updateErr != nil {
+					return ctrl.Result{}, updateErr
+				}
+				return ctrl.Result{}, errorUtil.Wrapf(err, "failed to cancel blob storage provisioning")
+			}
+			phase := croType.PhaseDeleteInProgress
+			if cancelled {
+				phase = croType.PhasePaused
+			}
+			r.logger.Info(msg)
+			if err = resources.UpdatePhase(ctx, r.Client, instance, phase, msg); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+		}
+
+		// fail clearly if the tier of the blob storage was removed from the strategy config map
+		tierMsg, err := tiers.ReconcileTierCondition(ctx, r.Client, instance, providers.BlobStorageResourceType, strategyToUse, &instance.Spec, &instance.Status)
+		if err != nil {
+			if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, tierMsg.WrapError(err)); updateErr != nil {
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{}, err
+		}
+		if tierMsg != croType.StatusEmpty {
+			r.logger.Warn(tierMsg)
+			if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, tierMsg); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+		}
+
+		// fail clearly until the namespace the blob storage is requested on behalf of allows it with a resource grant
+		grantMsg, err := providers.ReconcileGrant(ctx, r.Client, instance, "BlobStorage", &instance.Spec, &instance.Status)
+		if err != nil {
+			if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, grantMsg.WrapError(err)); updateErr != nil {
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{}, err
+		}
+		if grantMsg != croType.StatusEmpty {
+			r.logger.Warn(grantMsg)
+			if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, grantMsg); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+		}
+
+		// wait for the resources this blob storage depends on before provisioning it
+		depMsg, err := providers.ReconcileDependencies(ctx, r.Client, instance, &instance.Spec, &instance.Status)
+		if err != nil {
+			if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, depMsg.WrapError(err)); updateErr != nil {
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{}, err
+		}
+		if depMsg != croType.StatusEmpty {
+			r.logger.Info(depMsg)
+			if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseInProgress, depMsg); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+		}
+
+		// wait for a provisioning slot of the provider before creating the blob storage
+		provMsg, err := providers.ReconcileProvisioning(ctx, r.Client, p.GetName(), instance, &instance.Status)
+		if err != nil {
+			if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, provMsg.WrapError(err)); updateErr != nil {
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{}, err
+		}
+		if provMsg != croType.StatusEmpty {
+			r.logger.Info(provMsg)
+			if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseInProgress, provMsg); err != nil {
+				return ctrl.Result{}, err
+			}
 			return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
 		}
 
@@ -168,18 +298,41 @@ func (r *BlobStorageReconciler) Reconcile(request ctrl.Request) (ctrl.Result, er
 			if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseInProgress, msg); err != nil {
 				return ctrl.Result{}, err
 			}
-			return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+			return ctrl.Result{Requeue: true, RequeueAfter: providers.NextRequeue(p.GetName(), providers.BlobStorageResourceType, request.NamespacedName, croType.PhaseInProgress, p.GetReconcileTime(instance))}, nil
 		}
+		// the cloud resource is ready, polls of it start from the minimum interval of the provider again
+		providers.ResetRequeue(providers.BlobStorageResourceType, request.NamespacedName)
 
-		if err := r.resourceProvider.ReconcileResultSecret(ctx, instance, bsi.DeploymentDetails.Data()); err != nil {
+		secretData, secretMsg, err := tiers.BuildSecretData(ctx, r.Client, instance.Namespace, providers.BlobStorageResourceType, strategyToUse, instance.Spec.Tier, bsi.DeploymentDetails.Data())
+		if err != nil {
+			if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, secretMsg.WrapError(err)); updateErr != nil {
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{}, err
+		}
+		if err := r.resourceProvider.ReconcileResultSecret(ctx, instance, secretData); err != nil {
 			return ctrl.Result{}, errorUtil.Wrap(err, "failed to reconcile secret")
 		}
 
+		// warn about artifacts expiring within the warning period of the tier, so they're rotated before consumers break
+		expiryCfg, err := tiers.GetExpiryConfig(ctx, r.Client, strategyToUse, instance.Namespace, providers.BlobStorageResourceType, instance.Spec.Tier)
+		if err != nil {
+			return ctrl.Result{}, errorUtil.Wrap(err, "failed to get expiry config")
+		}
+		if expiryMsg := providers.ReconcileExpiry(providers.BlobStorageResourceType, instance, &instance.Status, bsi.Artifacts, expiryCfg, time.Now()); expiryMsg != "" {
+			r.logger.Warn(expiryMsg)
+		}
+
+		if providers.CompleteProvisioning(instance, &instance.Status) {
+			metrics.ObserveProvisioningDuration(string(providers.BlobStorageResourceType), p.GetName(), instance.Spec.Tier, instance.Status.Provisioning)
+		}
+		resources.RecordPhaseTransition(&instance.Status, instance.Status.Phase, croType.PhaseComplete, msg)
 		instance.Status.Phase = croType.PhaseComplete
 		instance.Status.Message = msg
 		instance.Status.SecretRef = instance.Spec.SecretRef
 		instance.Status.Strategy = strategyToUse
 		instance.Status.Provider = p.GetName()
+		resources.SetPhaseConditions(instance, &instance.Status)
 		if err = r.Client.Status().Update(ctx, instance); err != nil {
 			return ctrl.Result{}, errorUtil.Wrapf(err, "failed to update instance %s in namespace %s", instance.Name, instance.Namespace)
 		}
@@ -29,14 +29,17 @@ import (
 )
 
 const (
-	postgresFreeStorageAverage    = "cro_postgres_free_storage_average"
+	postgresFreeStorageAverage    = resources.DefaultPostgresFreeStorageMetricName
 	postgresCPUUtilizationAverage = "cro_postgres_cpu_utilization_average"
 	postgresFreeableMemoryAverage = "cro_postgres_freeable_memory_average"
+	postgresReplicaLagAverage     = resources.DefaultPostgresReplicaLagMetricName
 
-	redisMemoryUsagePercentageAverage = "cro_redis_memory_usage_percentage_average"
+	redisMemoryUsagePercentageAverage = resources.DefaultRedisMemoryUsageMetricName
 	redisFreeableMemoryAverage        = "cro_redis_freeable_memory_average"
 	redisCPUUtilizationAverage        = "cro_redis_cpu_utilization_average"
 	redisEngineCPUUtilizationAverage  = "cro_redis_engine_cpu_utilization_average"
+	redisReplicationLagAverage        = resources.DefaultRedisReplicationLagMetricName
+	redisEvictionsSum                 = "cro_redis_evictions_sum"
 
 	labelClusterIDKey   = "clusterID"
 	labelResourceIDKey  = "resourceID"
@@ -114,6 +117,22 @@ var postgresGaugeMetrics = []CroGaugeMetric{
 			},
 		},
 	},
+	{
+		Name: postgresReplicaLagAverage,
+		GaugeVec: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: postgresReplicaLagAverage,
+				Help: "The lag of a read replica behind its source instance. Units: Seconds",
+			},
+			labels),
+		ProviderType: map[string]providers.CloudProviderMetricType{
+			providers.AWSDeploymentStrategy: {
+				PromethuesMetricName: postgresReplicaLagAverage,
+				ProviderMetricName:   "ReplicaLag",
+				Statistic:            cloudwatch.StatisticAverage,
+			},
+		},
+	},
 }
 
 // redisGaugeMetrics stores a mapping between an exposed (redis) prometheus metric and multiple cloud provider specific metric
@@ -184,6 +203,38 @@ var redisGaugeMetrics = []CroGaugeMetric{
 			},
 		},
 	},
+	{
+		Name: redisReplicationLagAverage,
+		GaugeVec: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: redisReplicationLagAverage,
+				Help: "The lag of a replica behind the primary node. Units: Seconds",
+			},
+			labels),
+		ProviderType: map[string]providers.CloudProviderMetricType{
+			providers.AWSDeploymentStrategy: {
+				PromethuesMetricName: redisReplicationLagAverage,
+				ProviderMetricName:   "ReplicationLag",
+				Statistic:            cloudwatch.StatisticAverage,
+			},
+		},
+	},
+	{
+		Name: redisEvictionsSum,
+		GaugeVec: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: redisEvictionsSum,
+				Help: "The number of keys evicted due to the maxmemory limit in the scrape period. Units: Count",
+			},
+			labels),
+		ProviderType: map[string]providers.CloudProviderMetricType{
+			providers.AWSDeploymentStrategy: {
+				PromethuesMetricName: redisEvictionsSum,
+				ProviderMetricName:   "Evictions",
+				Statistic:            cloudwatch.StatisticSum,
+			},
+		},
+	},
 }
 
 // blank assignment to verify that ReconcileCloudMetrics implements reconcile.Reconciler
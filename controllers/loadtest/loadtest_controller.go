@@ -0,0 +1,234 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loadtest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	errorUtil "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const defaultRequeueTime = time.Second * 10
+
+// LoadTestReconciler reconciles a LoadTest object
+type LoadTestReconciler struct {
+	k8sclient.Client
+	scheme *runtime.Scheme
+	logger *logrus.Entry
+}
+
+func New(mgr manager.Manager) (*LoadTestReconciler, error) {
+	return &LoadTestReconciler{
+		Client: mgr.GetClient(),
+		scheme: mgr.GetScheme(),
+		logger: logrus.WithFields(logrus.Fields{"controller": "controller_loadtest"}),
+	}, nil
+}
+
+func (r *LoadTestReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&integreatlyv1alpha1.LoadTest{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
+
+func (r *LoadTestReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error) {
+	r.logger.Info("reconciling load test")
+	ctx := context.TODO()
+
+	instance := &integreatlyv1alpha1.LoadTest{}
+	if err := r.Client.Get(ctx, request.NamespacedName, instance); err != nil {
+		if k8serr.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// a load test runs once, create a new load test to run it again
+	if instance.Status.Phase == croType.PhaseComplete || instance.Status.Phase == croType.PhaseFailed || instance.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	if instance.Status.StartTime == nil {
+		return r.start(ctx, instance)
+	}
+
+	job := &batchv1.Job{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: instance.Status.JobName, Namespace: instance.Namespace}, job); err != nil {
+		if k8serr.IsNotFound(err) {
+			return r.fail(ctx, instance, fmt.Sprintf("load test job %s no longer exists", instance.Status.JobName))
+		}
+		return ctrl.Result{}, errorUtil.Wrapf(err, "failed to get load test job %s", instance.Status.JobName)
+	}
+
+	switch {
+	case job.Status.Succeeded > 0:
+		output, err := r.getOutput(ctx, job)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		report, err := buildReport(instance, output)
+		if err != nil {
+			return r.fail(ctx, instance, errorUtil.Wrap(err, "failed to read load test results").Error())
+		}
+		if err := r.writeReport(ctx, instance, report); err != nil {
+			return ctrl.Result{}, err
+		}
+		now := metav1.Now()
+		instance.Status.Phase = croType.PhaseComplete
+		instance.Status.Message = croType.StatusMessage(fmt.Sprintf("results written to configmap %s", reportName(instance)))
+		instance.Status.ReportName = reportName(instance)
+		instance.Status.CompletionTime = &now
+		if err := r.Client.Status().Update(ctx, instance); err != nil {
+			return ctrl.Result{}, errorUtil.Wrap(err, "failed to update load test status")
+		}
+		r.logger.Infof("load test %s completed, results written to configmap %s", instance.Name, instance.Status.ReportName)
+		return ctrl.Result{}, nil
+	case job.Status.Failed > 0:
+		msg := fmt.Sprintf("load test job %s failed", job.Name)
+		if output, err := r.getOutput(ctx, job); err == nil && output != "" {
+			msg = fmt.Sprintf("%s: %s", msg, output)
+		}
+		return r.fail(ctx, instance, msg)
+	}
+	return ctrl.Result{Requeue: true, RequeueAfter: defaultRequeueTime}, nil
+}
+
+// start creates the benchmark job once the resource under test is available
+func (r *LoadTestReconciler) start(ctx context.Context, lt *integreatlyv1alpha1.LoadTest) (ctrl.Result, error) {
+	var resource runtime.Object
+	switch providers.ResourceType(lt.Spec.ResourceType) {
+	case providers.PostgresResourceType:
+		resource = &integreatlyv1alpha1.Postgres{}
+	case providers.RedisResourceType:
+		resource = &integreatlyv1alpha1.Redis{}
+	default:
+		return r.fail(ctx, lt, fmt.Sprintf("unsupported resource type %q, expected %s or %s", lt.Spec.ResourceType, providers.PostgresResourceType, providers.RedisResourceType))
+	}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: lt.Spec.ResourceName, Namespace: lt.Namespace}, resource); err != nil {
+		if k8serr.IsNotFound(err) {
+			return r.fail(ctx, lt, fmt.Sprintf("%s %s not found", lt.Spec.ResourceType, lt.Spec.ResourceName))
+		}
+		return ctrl.Result{}, errorUtil.Wrapf(err, "failed to get %s %s", lt.Spec.ResourceType, lt.Spec.ResourceName)
+	}
+
+	status := resourceStatus(resource)
+	if status.Phase != croType.PhaseComplete {
+		lt.Status.Phase = croType.PhaseInProgress
+		lt.Status.Message = croType.StatusMessage(fmt.Sprintf("waiting for %s %s to be available", lt.Spec.ResourceType, lt.Spec.ResourceName))
+		if err := r.Client.Status().Update(ctx, lt); err != nil {
+			return ctrl.Result{}, errorUtil.Wrap(err, "failed to update load test status")
+		}
+		return ctrl.Result{Requeue: true, RequeueAfter: defaultRequeueTime}, nil
+	}
+	// the job reads the connection details from the secret, so it must be in the namespace of the job
+	if status.SecretRef == nil || status.SecretRef.Namespace != lt.Namespace {
+		return r.fail(ctx, lt, fmt.Sprintf("%s %s has no connection secret in namespace %s", lt.Spec.ResourceType, lt.Spec.ResourceName, lt.Namespace))
+	}
+
+	job, err := buildJob(lt, status.SecretRef.Name)
+	if err != nil {
+		return r.fail(ctx, lt, err.Error())
+	}
+	if err := controllerutil.SetControllerReference(lt, job, r.scheme); err != nil {
+		return ctrl.Result{}, errorUtil.Wrapf(err, "failed to set owner of load test job %s", job.Name)
+	}
+	if err := r.Client.Create(ctx, job); err != nil && !k8serr.IsAlreadyExists(err) {
+		return ctrl.Result{}, errorUtil.Wrapf(err, "failed to create load test job %s", job.Name)
+	}
+
+	now := metav1.Now()
+	lt.Status.StartTime = &now
+	lt.Status.JobName = job.Name
+	lt.Status.Phase = croType.PhaseInProgress
+	lt.Status.Message = croType.StatusMessage(fmt.Sprintf("running load test job %s", job.Name))
+	if err := r.Client.Status().Update(ctx, lt); err != nil {
+		return ctrl.Result{}, errorUtil.Wrap(err, "failed to start load test")
+	}
+	return ctrl.Result{Requeue: true, RequeueAfter: defaultRequeueTime}, nil
+}
+
+func (r *LoadTestReconciler) fail(ctx context.Context, lt *integreatlyv1alpha1.LoadTest, msg string) (ctrl.Result, error) {
+	now := metav1.Now()
+	lt.Status.Phase = croType.PhaseFailed
+	lt.Status.Message = croType.StatusMessage(msg)
+	lt.Status.CompletionTime = &now
+	if err := r.Client.Status().Update(ctx, lt); err != nil {
+		return ctrl.Result{}, errorUtil.Wrap(err, "failed to update load test status")
+	}
+	r.logger.Errorf("load test %s failed: %s", lt.Name, msg)
+	return ctrl.Result{}, nil
+}
+
+// getOutput returns the benchmark summary, which the job writes to the termination message of its container
+func (r *LoadTestReconciler) getOutput(ctx context.Context, job *batchv1.Job) (string, error) {
+	pods := &corev1.PodList{}
+	if err := r.Client.List(ctx, pods, k8sclient.InNamespace(job.Namespace), k8sclient.MatchingLabels{"job-name": job.Name}); err != nil {
+		return "", errorUtil.Wrapf(err, "failed to list pods of load test job %s", job.Name)
+	}
+	for _, pod := range pods.Items {
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.Name == benchmarkContainerName && cs.State.Terminated != nil {
+				return cs.State.Terminated.Message, nil
+			}
+		}
+	}
+	return "", errorUtil.Errorf("no terminated benchmark container found for load test job %s", job.Name)
+}
+
+func (r *LoadTestReconciler) writeReport(ctx context.Context, lt *integreatlyv1alpha1.LoadTest, report map[string]string) error {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      reportName(lt),
+			Namespace: lt.Namespace,
+		},
+	}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, cm, func() error {
+		cm.Labels = map[string]string{labelLoadTest: lt.Name}
+		cm.Data = report
+		return controllerutil.SetControllerReference(lt, cm, r.scheme)
+	}); err != nil {
+		return errorUtil.Wrapf(err, "failed to write load test report %s", cm.Name)
+	}
+	return nil
+}
+
+func resourceStatus(resource runtime.Object) croType.ResourceTypeStatus {
+	switch r := resource.(type) {
+	case *integreatlyv1alpha1.Postgres:
+		return r.Status
+	case *integreatlyv1alpha1.Redis:
+		return r.Status
+	}
+	return croType.ResourceTypeStatus{}
+}
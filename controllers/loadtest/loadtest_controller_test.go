@@ -0,0 +1,265 @@
+package loadtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testName      = "test"
+	testNamespace = "test-ns"
+
+	testPgbenchOutput = `number of transactions actually processed: 48231
+latency average = 12.441 ms
+tps = 803.784512 (including connections establishing)
+tps = 804.012345 (excluding connections establishing)`
+)
+
+func buildTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := integreatlyv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	if err := batchv1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	return scheme
+}
+
+func buildTestLoadTest(started bool) *integreatlyv1alpha1.LoadTest {
+	lt := &integreatlyv1alpha1.LoadTest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testName,
+			Namespace: testNamespace,
+		},
+		Spec: integreatlyv1alpha1.LoadTestSpec{
+			ResourceType: "postgres",
+			ResourceName: "test-postgres",
+		},
+	}
+	if started {
+		start := metav1.NewTime(time.Now().Add(-time.Minute))
+		lt.Status.StartTime = &start
+		lt.Status.Phase = croType.PhaseInProgress
+		lt.Status.JobName = "test-loadtest"
+	}
+	return lt
+}
+
+func buildTestPostgres(phase croType.StatusPhase, secretNamespace string) *integreatlyv1alpha1.Postgres {
+	return &integreatlyv1alpha1.Postgres{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-postgres",
+			Namespace: testNamespace,
+		},
+		Status: croType.ResourceTypeStatus{
+			Phase:     phase,
+			SecretRef: &croType.SecretRef{Name: "test-postgres-sec", Namespace: secretNamespace},
+		},
+	}
+}
+
+func buildTestJob(succeeded, failed int32) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-loadtest",
+			Namespace: testNamespace,
+		},
+		Status: batchv1.JobStatus{
+			Succeeded: succeeded,
+			Failed:    failed,
+		},
+	}
+}
+
+func buildTestJobPod(message string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-loadtest-abcde",
+			Namespace: testNamespace,
+			Labels:    map[string]string{"job-name": "test-loadtest"},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name: benchmarkContainerName,
+					State: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{Message: message},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestLoadTestReconciler_Reconcile(t *testing.T) {
+	scheme := buildTestScheme(t)
+
+	tests := []struct {
+		name        string
+		objs        []runtime.Object
+		wantPhase   croType.StatusPhase
+		wantJob     bool
+		wantReport  map[string]string
+		wantMessage string
+	}{
+		{
+			name:      "test load test waits for the resource to be available",
+			objs:      []runtime.Object{buildTestLoadTest(false), buildTestPostgres(croType.PhaseInProgress, testNamespace)},
+			wantPhase: croType.PhaseInProgress,
+		},
+		{
+			name:      "test load test job is created once the resource is available",
+			objs:      []runtime.Object{buildTestLoadTest(false), buildTestPostgres(croType.PhaseComplete, testNamespace)},
+			wantPhase: croType.PhaseInProgress,
+			wantJob:   true,
+		},
+		{
+			name:      "test load test fails when the resource does not exist",
+			objs:      []runtime.Object{buildTestLoadTest(false)},
+			wantPhase: croType.PhaseFailed,
+		},
+		{
+			name:      "test load test fails when the connection secret is in another namespace",
+			objs:      []runtime.Object{buildTestLoadTest(false), buildTestPostgres(croType.PhaseComplete, "other-ns")},
+			wantPhase: croType.PhaseFailed,
+		},
+		{
+			name:      "test load test waits for the job to complete",
+			objs:      []runtime.Object{buildTestLoadTest(true), buildTestJob(0, 0)},
+			wantPhase: croType.PhaseInProgress,
+		},
+		{
+			name:      "test load test report is written when the job succeeds",
+			objs:      []runtime.Object{buildTestLoadTest(true), buildTestJob(1, 0), buildTestJobPod(testPgbenchOutput)},
+			wantPhase: croType.PhaseComplete,
+			wantReport: map[string]string{
+				"tps":              "804.012345",
+				"latencyAverageMs": "12.441",
+				"transactions":     "48231",
+				"clients":          "10",
+				"scale":            "10",
+				"durationSeconds":  "60",
+			},
+		},
+		{
+			name:        "test load test fails when the job fails",
+			objs:        []runtime.Object{buildTestLoadTest(true), buildTestJob(0, 1), buildTestJobPod("connection refused")},
+			wantPhase:   croType.PhaseFailed,
+			wantMessage: "load test job test-loadtest failed: connection refused",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewFakeClientWithScheme(scheme, tt.objs...)
+			r := &LoadTestReconciler{
+				Client: client,
+				scheme: scheme,
+				logger: logrus.NewEntry(logrus.StandardLogger()),
+			}
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: testName, Namespace: testNamespace}}
+			if _, err := r.Reconcile(req); err != nil {
+				t.Fatalf("Reconcile() unexpected error = %v", err)
+			}
+
+			lt := &integreatlyv1alpha1.LoadTest{}
+			if err := client.Get(context.TODO(), req.NamespacedName, lt); err != nil {
+				t.Fatalf("failed to get load test: %v", err)
+			}
+			if lt.Status.Phase != tt.wantPhase {
+				t.Errorf("Reconcile() phase = %v, want %v, message %s", lt.Status.Phase, tt.wantPhase, lt.Status.Message)
+			}
+			if tt.wantMessage != "" && string(lt.Status.Message) != tt.wantMessage {
+				t.Errorf("Reconcile() message = %v, want %v", lt.Status.Message, tt.wantMessage)
+			}
+			if tt.wantJob {
+				job := &batchv1.Job{}
+				if err := client.Get(context.TODO(), types.NamespacedName{Name: "test-loadtest", Namespace: testNamespace}, job); err != nil {
+					t.Fatalf("Reconcile() expected job to be created, got error = %v", err)
+				}
+				if len(job.OwnerReferences) != 1 {
+					t.Errorf("Reconcile() expected job to be owned by the load test")
+				}
+				if lt.Status.JobName != job.Name {
+					t.Errorf("Reconcile() job name = %v, want %v", lt.Status.JobName, job.Name)
+				}
+			}
+			if tt.wantReport != nil {
+				cm := &corev1.ConfigMap{}
+				if err := client.Get(context.TODO(), types.NamespacedName{Name: "test-report", Namespace: testNamespace}, cm); err != nil {
+					t.Fatalf("Reconcile() expected report to be created, got error = %v", err)
+				}
+				for k, v := range tt.wantReport {
+					if cm.Data[k] != v {
+						t.Errorf("Reconcile() report %s = %v, want %v", k, cm.Data[k], v)
+					}
+				}
+				if lt.Status.ReportName != cm.Name {
+					t.Errorf("Reconcile() report name = %v, want %v", lt.Status.ReportName, cm.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRedisBenchmarkOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:   "test requests per second are read without a header",
+			output: "\"SET\",\"81234.57\"\n\"GET\",\"90909.09\"\n",
+			want: map[string]string{
+				"setRequestsPerSecond": "81234.57",
+				"getRequestsPerSecond": "90909.09",
+			},
+		},
+		{
+			name:   "test latency is read when reported",
+			output: "\"test\",\"rps\",\"avg_latency_ms\",\"min_latency_ms\"\n\"SET\",\"81234.57\",\"0.313\",\"0.104\"\n",
+			want: map[string]string{
+				"setRequestsPerSecond": "81234.57",
+				"setLatencyAverageMs":  "0.313",
+			},
+		},
+		{
+			name:    "test error when no results are reported",
+			output:  "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRedisBenchmarkOutput(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRedisBenchmarkOutput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("parseRedisBenchmarkOutput() = %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("parseRedisBenchmarkOutput() %s = %v, want %v", k, got[k], v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package loadtest
+
+import (
+	"encoding/csv"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	integreatlyv1alpha1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	errorUtil "github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultClients         = 10
+	defaultScale           = 10
+	defaultDurationSeconds = 60
+	// redis-benchmark runs a fixed number of requests rather than a fixed duration
+	redisRequestsPerScale = 10000
+	// allowance on top of the benchmark duration for initialising and cleaning up the data set
+	jobDeadlineAllowanceSeconds = 1800
+
+	postgresBenchmarkImage = "registry.redhat.io/rhscl/postgresql-10-rhel7"
+	redisBenchmarkImage    = "registry.redhat.io/rhscl/redis-32-rhel7"
+
+	benchmarkContainerName = "benchmark"
+	labelLoadTest          = "integreatly.org/load-test"
+
+	// the pgbench tables are created in the database under test and dropped once the benchmark completes
+	postgresBenchmarkScript = `set -o pipefail
+pgbench -i -q -s "$SCALE" || exit 1
+pgbench -c "$CLIENTS" -j "$CLIENTS" -T "$DURATION" | tee /tmp/pgbench.out
+rc=$?
+psql -c 'DROP TABLE IF EXISTS pgbench_accounts, pgbench_branches, pgbench_history, pgbench_tellers'
+grep -E '^(number of transactions actually processed|latency average|tps)' /tmp/pgbench.out > /dev/termination-log
+exit $rc`
+
+	redisBenchmarkScript = `set -o pipefail
+redis-benchmark -h "$REDIS_HOST" -p "$REDIS_PORT" -c "$CLIENTS" -n "$REQUESTS" -t set,get --csv | tee /dev/termination-log`
+)
+
+var (
+	pgbenchTransactionsRegexp = regexp.MustCompile(`^number of transactions actually processed: (\d+)`)
+	pgbenchLatencyRegexp      = regexp.MustCompile(`^latency average = ([\d.]+) ms`)
+	pgbenchTPSRegexp          = regexp.MustCompile(`^tps = ([\d.]+)`)
+)
+
+// buildJob builds the benchmark job for the load test, the connection details are read from the connection secret of
+// the resource under test
+func buildJob(lt *integreatlyv1alpha1.LoadTest, secretName string) (*batchv1.Job, error) {
+	clients := valueOrDefault(lt.Spec.Clients, defaultClients)
+	scale := valueOrDefault(lt.Spec.Scale, defaultScale)
+	duration := valueOrDefault(lt.Spec.DurationSeconds, defaultDurationSeconds)
+
+	var image, script string
+	var env []corev1.EnvVar
+	switch providers.ResourceType(lt.Spec.ResourceType) {
+	case providers.PostgresResourceType:
+		image, script = postgresBenchmarkImage, postgresBenchmarkScript
+		env = []corev1.EnvVar{
+			secretEnvVar("PGHOST", secretName, "host"),
+			secretEnvVar("PGPORT", secretName, "port"),
+			secretEnvVar("PGUSER", secretName, "username"),
+			secretEnvVar("PGPASSWORD", secretName, "password"),
+			secretEnvVar("PGDATABASE", secretName, "database"),
+			{Name: "SCALE", Value: strconv.Itoa(scale)},
+			{Name: "DURATION", Value: strconv.Itoa(duration)},
+		}
+	case providers.RedisResourceType:
+		image, script = redisBenchmarkImage, redisBenchmarkScript
+		env = []corev1.EnvVar{
+			secretEnvVar("REDIS_HOST", secretName, "uri"),
+			secretEnvVar("REDIS_PORT", secretName, "port"),
+			{Name: "REQUESTS", Value: strconv.Itoa(scale * redisRequestsPerScale)},
+		}
+	default:
+		return nil, errorUtil.Errorf("unsupported resource type %q, expected %s or %s", lt.Spec.ResourceType, providers.PostgresResourceType, providers.RedisResourceType)
+	}
+	env = append(env, corev1.EnvVar{Name: "CLIENTS", Value: strconv.Itoa(clients)})
+	if lt.Spec.Image != "" {
+		image = lt.Spec.Image
+	}
+
+	backoffLimit := int32(0)
+	deadline := int64(duration + jobDeadlineAllowanceSeconds)
+	labels := map[string]string{labelLoadTest: lt.Name}
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName(lt),
+			Namespace: lt.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          &backoffLimit,
+			ActiveDeadlineSeconds: &deadline,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:                     benchmarkContainerName,
+							Image:                    image,
+							Command:                  []string{"/bin/bash", "-c", script},
+							Env:                      env,
+							TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func jobName(lt *integreatlyv1alpha1.LoadTest) string {
+	return fmt.Sprintf("%s-loadtest", lt.Name)
+}
+
+func reportName(lt *integreatlyv1alpha1.LoadTest) string {
+	return fmt.Sprintf("%s-report", lt.Name)
+}
+
+func secretEnvVar(name, secretName, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  key,
+			},
+		},
+	}
+}
+
+func valueOrDefault(value, defaultValue int) int {
+	if value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// buildReport builds the report data from the benchmark output of the load test
+func buildReport(lt *integreatlyv1alpha1.LoadTest, output string) (map[string]string, error) {
+	report := map[string]string{
+		"resourceType": lt.Spec.ResourceType,
+		"resourceName": lt.Spec.ResourceName,
+		"clients":      strconv.Itoa(valueOrDefault(lt.Spec.Clients, defaultClients)),
+		"scale":        strconv.Itoa(valueOrDefault(lt.Spec.Scale, defaultScale)),
+		"output":       output,
+	}
+	var results map[string]string
+	var err error
+	switch providers.ResourceType(lt.Spec.ResourceType) {
+	case providers.PostgresResourceType:
+		report["durationSeconds"] = strconv.Itoa(valueOrDefault(lt.Spec.DurationSeconds, defaultDurationSeconds))
+		results, err = parsePgbenchOutput(output)
+	case providers.RedisResourceType:
+		report["requests"] = strconv.Itoa(valueOrDefault(lt.Spec.Scale, defaultScale) * redisRequestsPerScale)
+		results, err = parseRedisBenchmarkOutput(output)
+	default:
+		return nil, errorUtil.Errorf("unsupported resource type %q", lt.Spec.ResourceType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range results {
+		report[k] = v
+	}
+	return report, nil
+}
+
+// parsePgbenchOutput reads the throughput and latency from the pgbench summary. pgbench 10 reports tps both including
+// and excluding connection establishment, the last reported value excludes it
+func parsePgbenchOutput(output string) (map[string]string, error) {
+	results := map[string]string{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if m := pgbenchTransactionsRegexp.FindStringSubmatch(line); m != nil {
+			results["transactions"] = m[1]
+		}
+		if m := pgbenchLatencyRegexp.FindStringSubmatch(line); m != nil {
+			results["latencyAverageMs"] = m[1]
+		}
+		if m := pgbenchTPSRegexp.FindStringSubmatch(line); m != nil {
+			results["tps"] = m[1]
+		}
+	}
+	if results["tps"] == "" {
+		return nil, errorUtil.New("pgbench output does not contain a tps result")
+	}
+	return results, nil
+}
+
+// parseRedisBenchmarkOutput reads the requests per second and, when reported, the average latency of each test from
+// the redis-benchmark csv output. Older versions only report the requests per second and print no header
+func parseRedisBenchmarkOutput(output string) (map[string]string, error) {
+	reader := csv.NewReader(strings.NewReader(strings.TrimSpace(output)))
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to parse redis-benchmark output")
+	}
+	columns := map[string]int{"rps": 1}
+	results := map[string]string{}
+	for _, row := range rows {
+		if len(row) < 2 || strings.TrimSpace(row[0]) == "" {
+			continue
+		}
+		if row[0] == "test" {
+			columns = map[string]int{}
+			for i, name := range row {
+				columns[name] = i
+			}
+			continue
+		}
+		test := strings.ToLower(strings.Fields(row[0])[0])
+		if i, ok := columns["rps"]; ok && i < len(row) {
+			results[test+"RequestsPerSecond"] = row[i]
+		}
+		if i, ok := columns["avg_latency_ms"]; ok && i < len(row) {
+			results[test+"LatencyAverageMs"] = row[i]
+		}
+	}
+	if len(results) == 0 {
+		return nil, errorUtil.New("redis-benchmark output does not contain any results")
+	}
+	return results, nil
+}
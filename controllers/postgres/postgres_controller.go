@@ -20,10 +20,16 @@ import (
 	"context"
 	"fmt"
 	"github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/gcp"
 
 	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
 
+	"github.com/integr8ly/cloud-resource-operator/pkg/consumers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/migration"
 	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources/metrics"
+	"github.com/integr8ly/cloud-resource-operator/pkg/supportbundle"
+	"github.com/integr8ly/cloud-resource-operator/pkg/tiers"
 
 	"github.com/integr8ly/cloud-resource-operator/pkg/providers/openshift"
 
@@ -34,10 +40,12 @@ import (
 
 	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
 	errorUtil "github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -55,6 +63,7 @@ var log = logf.Log.WithName("controller_postgres")
 type PostgresReconciler struct {
 	k8sclient.Client
 	scheme           *runtime.Scheme
+	recorder         record.EventRecorder
 	logger           *logrus.Entry
 	resourceProvider *resources.ReconcileResourceProvider
 	providerList     []providers.PostgresProvider
@@ -71,6 +80,8 @@ func New(mgr manager.Manager) (*PostgresReconciler, error) {
 	if err != nil {
 		return nil, err
 	}
+	// config maps and secrets are read from the shared informer cache of the manager instead of the api server
+	client = resources.NewCachedClient(client, mgr.GetCache())
 
 	clientSet, err := resources.GetK8Client()
 	if err != nil {
@@ -82,11 +93,16 @@ func New(mgr manager.Manager) (*PostgresReconciler, error) {
 	if err != nil {
 		return nil, err
 	}
-	providerList := []providers.PostgresProvider{openshift.NewOpenShiftPostgresProvider(client, clientSet, logger), awsPostgresProvider}
+	gcpPostgresProvider, err := gcp.NewGCPPostgresProvider(client, logger)
+	if err != nil {
+		return nil, err
+	}
+	providerList := []providers.PostgresProvider{openshift.NewOpenShiftPostgresProvider(client, clientSet, logger), awsPostgresProvider, gcpPostgresProvider}
 	rp := resources.NewResourceProvider(client, mgr.GetScheme(), logger)
 	return &PostgresReconciler{
 		Client:           client,
 		scheme:           mgr.GetScheme(),
+		recorder:         mgr.GetEventRecorderFor(resources.EventSource),
 		logger:           logger,
 		resourceProvider: rp,
 		providerList:     providerList,
@@ -101,6 +117,11 @@ func (r *PostgresReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			IsController: true,
 			OwnerType:    &v1alpha1.Postgres{},
 		}).
+		Watches(&source.Kind{Type: &batchv1.Job{}}, &handler.EnqueueRequestForOwner{
+			IsController: true,
+			OwnerType:    &v1alpha1.Postgres{},
+		}).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, resources.EnqueueOnMetadataChange(mgr.GetClient(), &v1alpha1.PostgresList{})).
 		Complete(r)
 }
 
@@ -109,12 +130,18 @@ func (r *PostgresReconciler) SetupWithManager(mgr ctrl.Manager) error {
 // +kubebuilder:rbac:groups="config.openshift.io",resources=infrastructures;networks,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=persistentvolumes;configmaps,verbs="*"
 // +kubebuilder:rbac:groups="monitoring.coreos.com",resources=prometheusrules,verbs="*"
-// +kubebuilder:rbac:groups=integreatly.org,resources=postgres;postgressnapshots;redis;redissnapshots,verbs=list;watch
+// +kubebuilder:rbac:groups=integreatly.org,resources=postgres;postgressnapshots;redis;redissnapshots;blobstorages;smoketests;loadtests;productresources;restoredrills;restoredrillreports;queues;resourcegrants;resourcegroupstatuses;operatorconfigs,verbs=list;watch
+// +kubebuilder:rbac:groups="",resources=namespaces;resourcequotas,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups="networking.k8s.io",resources=networkpolicies,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups="policy",resources=poddisruptionbudgets,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups="storage.k8s.io",resources=storageclasses,verbs=get;list;watch
 
 // Role permissions
 
 // +kubebuilder:rbac:groups="",resources=pods;pods/exec;services;services/finalizers;endpoints;persistentvolumeclaims;events;configmaps;secrets,verbs="*",namespace=cloud-resource-operator
 // +kubebuilder:rbac:groups="apps",resources="*",verbs="*",namespace=cloud-resource-operator
+// +kubebuilder:rbac:groups="batch",resources=jobs,verbs="*",namespace=cloud-resource-operator
+// +kubebuilder:rbac:groups="snapshot.storage.k8s.io",resources=volumesnapshots,verbs="*",namespace=cloud-resource-operator
 // +kubebuilder:rbac:groups="monitoring.coreos.com",resources=servicemonitors,verbs=get;create,namespace=cloud-resource-operator
 // +kubebuilder:rbac:groups="monitoring.coreos.com",resources=prometheusrules,verbs="*",namespace=cloud-resource-operator
 // +kubebuilder:rbac:groups="cloud-resource-operator",resources=deployments/finalizers,verbs=update,namespace=cloud-resource-operator
@@ -126,19 +153,24 @@ func (r *PostgresReconciler) SetupWithManager(mgr ctrl.Manager) error {
 // +kubebuilder:rbac:groups="cloudcredential.openshift.io",resources=credentialsrequests,verbs="*",namespace=cloud-resource-operator
 // +kubebuilder:rbac:groups=operators.coreos.com,resources=catalogsources,verbs=get;update;patch,namespace=cloud-resource-operator
 
-func (r *PostgresReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error) {
+func (r *PostgresReconciler) Reconcile(request ctrl.Request) (result ctrl.Result, err error) {
 	r.logger.Info("reconciling Postgres")
 	ctx := context.TODO()
 	cfgMgr := providers.NewConfigManager(providers.DefaultProviderConfigMapName, request.Namespace, r.Client)
 
 	// Fetch the Postgres instance
 	instance := &v1alpha1.Postgres{}
-	err := r.Client.Get(context.TODO(), request.NamespacedName, instance)
+	err = r.Client.Get(context.TODO(), request.NamespacedName, instance)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
+			metrics.DeleteResourcePhase(string(providers.PostgresResourceType), request.NamespacedName)
+			metrics.DeleteProbe(string(providers.PostgresResourceType), request.NamespacedName)
+			providers.ResetRequeue(providers.PostgresResourceType, request.NamespacedName)
+			metrics.SetDeletionPaused(string(providers.PostgresResourceType), request.NamespacedName, false)
+			metrics.DeleteArtifactExpiry(string(providers.PostgresResourceType), request.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -153,6 +185,12 @@ func (r *PostgresReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error
 		return ctrl.Result{}, errorUtil.Wrapf(err, "failed to read deployment type config for deployment %s", instance.Spec.Type)
 	}
 
+	// move the data to the cloud resource of the new strategy if the strategy of the postgres changed, a started
+	// migration runs to completion even if the strategy is changed back
+	if migration.InProgress(&instance.Status) || (instance.Status.Strategy != "" && migration.Supported(instance.Status.Strategy, stratMap.Postgres)) {
+		return r.reconcileMigration(ctx, instance, stratMap.Postgres)
+	}
+
 	// Check the CR for existing Strategy
 	strategyToUse := stratMap.Postgres
 	if instance.Status.Strategy != "" {
@@ -168,6 +206,26 @@ func (r *PostgresReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error
 		}
 		instance.Status.Strategy = strategyToUse
 		instance.Status.Provider = p.GetName()
+		defer func(provider string) {
+			metrics.ObserveReconcile(string(providers.PostgresResourceType), request.NamespacedName, provider, instance.Spec.Tier, instance.Status.Phase, err)
+		}(p.GetName())
+		defer func(before croType.ResourceTypeStatus) {
+			resources.RecordReconcileEvents(r.recorder, instance, instance.DeletionTimestamp != nil, before, instance.Status, err)
+		}(*instance.Status.DeepCopy())
+		defer func(provider providers.PostgresProvider) {
+			if instance.Status.Phase != croType.PhaseFailed {
+				return
+			}
+			var readEvents supportbundle.EventReader
+			if er, ok := provider.(providers.PostgresEventReader); ok {
+				readEvents = func(ctx context.Context) ([]providers.ProviderEvent, error) {
+					return er.GetPostgresEvents(ctx, instance)
+				}
+			}
+			if bundleErr := supportbundle.Reconcile(ctx, r.Client, r.scheme, providers.PostgresResourceType, instance, instance.Spec, instance.Status, readEvents); bundleErr != nil {
+				r.logger.Warnf("failed to write support bundle of postgres %s: %v", instance.Name, bundleErr)
+			}
+		}(p)
 		if instance.Status.Strategy != strategyToUse || instance.Status.Provider != p.GetName() {
 			if err = r.Client.Status().Update(ctx, instance); err != nil {
 				return ctrl.Result{}, errorUtil.Wrapf(err, "failed to update instance %s in namespace %s", instance.Name, instance.Namespace)
@@ -176,6 +234,31 @@ func (r *PostgresReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error
 
 		// delete the postgres if the deletion timestamp exists
 		if instance.DeletionTimestamp != nil {
+			// keep the cloud resource of a protected postgres until its deletion protection is unset
+			if protectedMsg := providers.CheckDeletionProtection(providers.PostgresResourceType, &instance.Spec); protectedMsg != croType.StatusEmpty {
+				r.logger.Warn(protectedMsg)
+				if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhasePaused, protectedMsg); err != nil {
+					return ctrl.Result{}, err
+				}
+				return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+			}
+
+			// pause the deletion if too many resources were deleted recently, e.g. by a namespace or gitops mistake
+			guardMsg, err := providers.ReconcileDeletionGuard(ctx, r.Client, providers.PostgresResourceType, instance, &instance.Status)
+			if err != nil {
+				if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, guardMsg.WrapError(err)); updateErr != nil {
+					return ctrl.Result{}, updateErr
+				}
+				return ctrl.Result{}, err
+			}
+			if guardMsg != croType.StatusEmpty {
+				r.logger.Warn(guardMsg)
+				if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhasePaused, guardMsg); err != nil {
+					return ctrl.Result{}, err
+				}
+				return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+			}
+
 			msg, err := p.DeletePostgres(ctx, instance)
 			if err != nil {
 				if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, msg.WrapError(err)); updateErr != nil {
@@ -188,6 +271,28 @@ func (r *PostgresReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error
 			if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseDeleteInProgress, msg); err != nil {
 				return ctrl.Result{}, err
 			}
+			return ctrl.Result{Requeue: true, RequeueAfter: providers.NextRequeue(p.GetName(), providers.PostgresResourceType, request.NamespacedName, croType.PhaseDeleteInProgress, p.GetReconcileTime(instance))}, nil
+		}
+
+		// abort the creation of the postgres if it was cancelled, removing what was created so far
+		if providers.IsProvisioningCancelled(instance, &instance.Status) {
+			cancelled, msg, err := providers.ReconcileCancelledProvisioning(instance, &instance.Status, func() (croType.StatusMessage, error) {
+				return p.DeletePostgres(ctx, instance)
+			})
+			if err != nil {
+				if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, msg.WrapError(err)); updateErr != nil {
+					return ctrl.Result{}, updateErr
+				}
+				return ctrl.Result{}, errorUtil.Wrapf(err, "failed to cancel postgres provisioning")
+			}
+			phase := croType.PhaseDeleteInProgress
+			if cancelled {
+				phase = croType.PhasePaused
+			}
+			r.logger.Info(msg)
+			if err = resources.UpdatePhase(ctx, r.Client, instance, phase, msg); err != nil {
+				return ctrl.Result{}, err
+			}
 			return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
 		}
 
@@ -200,6 +305,70 @@ func (r *PostgresReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error
 			return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
 		}
 
+		// fail clearly if the tier of the postgres was removed from the strategy config map
+		tierMsg, err := tiers.ReconcileTierCondition(ctx, r.Client, instance, providers.PostgresResourceType, strategyToUse, &instance.Spec, &instance.Status)
+		if err != nil {
+			if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, tierMsg.WrapError(err)); updateErr != nil {
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{}, err
+		}
+		if tierMsg != croType.StatusEmpty {
+			r.logger.Warn(tierMsg)
+			if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, tierMsg); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+		}
+
+		// fail clearly until the namespace the postgres is requested on behalf of allows it with a resource grant
+		grantMsg, err := providers.ReconcileGrant(ctx, r.Client, instance, "Postgres", &instance.Spec, &instance.Status)
+		if err != nil {
+			if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, grantMsg.WrapError(err)); updateErr != nil {
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{}, err
+		}
+		if grantMsg != croType.StatusEmpty {
+			r.logger.Warn(grantMsg)
+			if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, grantMsg); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+		}
+
+		// wait for the resources this postgres depends on before provisioning it
+		depMsg, err := providers.ReconcileDependencies(ctx, r.Client, instance, &instance.Spec, &instance.Status)
+		if err != nil {
+			if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, depMsg.WrapError(err)); updateErr != nil {
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{}, err
+		}
+		if depMsg != croType.StatusEmpty {
+			r.logger.Info(depMsg)
+			if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseInProgress, depMsg); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+		}
+
+		// wait for a provisioning slot of the provider before creating the postgres
+		provMsg, err := providers.ReconcileProvisioning(ctx, r.Client, p.GetName(), instance, &instance.Status)
+		if err != nil {
+			if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, provMsg.WrapError(err)); updateErr != nil {
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{}, err
+		}
+		if provMsg != croType.StatusEmpty {
+			r.logger.Info(provMsg)
+			if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseInProgress, provMsg); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+		}
+
 		// create the postgres instance
 		ps, msg, err := p.ReconcilePostgres(ctx, instance)
 		if err != nil {
@@ -212,26 +381,119 @@ func (r *PostgresReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error
 		if ps == nil {
 			r.logger.Info(msg)
 			instance.Status.SecretRef = &croType.SecretRef{}
+			// the cloud resource of a paused or hibernated postgres is stopped, it isn't ready until it's resumed
+			if providers.StopRequested(&instance.Spec) && providers.IsStopped(&instance.Status) {
+				if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhasePaused, msg); err != nil {
+					return ctrl.Result{}, err
+				}
+				return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+			}
 			if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseInProgress, msg); err != nil {
 				return ctrl.Result{}, err
 			}
-			return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+			return ctrl.Result{Requeue: true, RequeueAfter: providers.NextRequeue(p.GetName(), providers.PostgresResourceType, request.NamespacedName, croType.PhaseInProgress, p.GetReconcileTime(instance))}, nil
+		}
+		// the cloud resource is ready, polls of it start from the minimum interval of the provider again
+		providers.ResetRequeue(providers.PostgresResourceType, request.NamespacedName)
+
+		// the resource is only complete once a connection can be opened to it, a complete resource which can't be
+		// connected to is degraded until a later check succeeds
+		if probe, ok := providers.ConnectivityProbe(ps.DeploymentDetails); ok {
+			// export the latency and availability of the resource seen by its connectivity checks
+			probe = probe.Observed(func(duration time.Duration, err error) {
+				metrics.ObserveProbe(string(providers.PostgresResourceType), request.NamespacedName, p.GetName(), duration, err == nil)
+			})
+			if probeMsg, connected := resources.CheckConnectivity(ctx, instance, &instance.Status, probe); !connected {
+				r.logger.Warn(probeMsg)
+				if instance.Status.Phase != croType.PhaseComplete {
+					if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseInProgress, probeMsg); err != nil {
+						return ctrl.Result{}, err
+					}
+					return ctrl.Result{Requeue: true, RequeueAfter: providers.NextRequeue(p.GetName(), providers.PostgresResourceType, request.NamespacedName, croType.PhaseInProgress, p.GetReconcileTime(instance))}, nil
+				}
+			}
 		}
 
-		// return the connection secret
-		if err := r.resourceProvider.ReconcileResultSecret(ctx, instance, ps.DeploymentDetails.Data()); err != nil {
+		// return the connection secret, with the outputs configured by the tier
+		secretData, secretMsg, err := tiers.BuildSecretData(ctx, r.Client, instance.Namespace, providers.PostgresResourceType, strategyToUse, instance.Spec.Tier, ps.DeploymentDetails.Data())
+		if err != nil {
+			if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, secretMsg.WrapError(err)); updateErr != nil {
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{}, err
+		}
+		if err := r.resourceProvider.ReconcileResultSecret(ctx, instance, secretData); err != nil {
 			return ctrl.Result{}, errorUtil.Wrap(err, "failed to reconcile secret")
 		}
 
+		// maintain the alerts of the postgres configured by its tier, the alerts are built on the metrics exposed for aws
+		if strategyToUse == providers.AWSDeploymentStrategy {
+			rule, alertMsg, err := tiers.BuildAlertRule(ctx, r.Client, instance.Namespace, providers.PostgresResourceType, strategyToUse, instance.Spec.Tier, instance.Name)
+			if err != nil {
+				if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, alertMsg.WrapError(err)); updateErr != nil {
+					return ctrl.Result{}, updateErr
+				}
+				return ctrl.Result{}, err
+			}
+			if err := r.resourceProvider.ReconcileAlertRule(ctx, instance, providers.AlertRuleName(providers.PostgresResourceType, instance.Name), rule); err != nil {
+				return ctrl.Result{}, errorUtil.Wrap(err, "failed to reconcile alerts")
+			}
+		}
+
+		// take snapshots on the schedule, only the aws provider supports snapshots
+		requeueAfter := p.GetReconcileTime(instance)
+		if instance.Spec.SnapshotSchedule != nil && strategyToUse == providers.AWSDeploymentStrategy {
+			nextSnapshotIn, snapshotMsg, err := providers.ReconcilePostgresSnapshotSchedule(ctx, r.Client, instance, time.Now())
+			if err != nil {
+				if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, snapshotMsg.WrapError(err)); updateErr != nil {
+					return ctrl.Result{}, updateErr
+				}
+				return ctrl.Result{}, err
+			}
+			if snapshotMsg != "" {
+				r.logger.Info(snapshotMsg)
+			}
+			if nextSnapshotIn > 0 && nextSnapshotIn < requeueAfter {
+				requeueAfter = nextSnapshotIn
+			}
+		}
+
+		// warn about artifacts expiring within the warning period of the tier, so they're rotated before consumers break
+		expiryCfg, err := tiers.GetExpiryConfig(ctx, r.Client, strategyToUse, instance.Namespace, providers.PostgresResourceType, instance.Spec.Tier)
+		if err != nil {
+			return ctrl.Result{}, errorUtil.Wrap(err, "failed to get expiry config")
+		}
+		if expiryMsg := providers.ReconcileExpiry(providers.PostgresResourceType, instance, &instance.Status, ps.Artifacts, expiryCfg, time.Now()); expiryMsg != "" {
+			r.logger.Warn(expiryMsg)
+		}
+
+		// warn about consumers expected to open more connections than the postgres accepts, before they run out
+		connectionMsg, err := consumers.ReconcileMetrics(ctx, r.Client, instance, strategyToUse)
+		if err != nil {
+			r.logger.Warnf("failed to reconcile consumer metrics: %v", err)
+		}
+		if connectionMsg != "" {
+			r.logger.Warn(connectionMsg)
+		}
+
+		if providers.CompleteProvisioning(instance, &instance.Status) {
+			metrics.ObserveProvisioningDuration(string(providers.PostgresResourceType), p.GetName(), instance.Spec.Tier, instance.Status.Provisioning)
+		}
+		resources.RecordPhaseTransition(&instance.Status, instance.Status.Phase, croType.PhaseComplete, msg)
 		instance.Status.Phase = croType.PhaseComplete
 		instance.Status.Message = msg
 		instance.Status.SecretRef = instance.Spec.SecretRef
 		instance.Status.Strategy = strategyToUse
 		instance.Status.Provider = p.GetName()
+		providers.SetCostStatus(&instance.Status, ps.Cost)
+		resources.SetPhaseConditions(instance, &instance.Status)
 		if err = r.Client.Status().Update(ctx, instance); err != nil {
 			return ctrl.Result{}, errorUtil.Wrapf(err, "failed to update instance %s in namespace %s", instance.Name, instance.Namespace)
 		}
-		return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+		if ps.Cost != nil {
+			resources.SetCostMetric(resources.DefaultPostgresCostMetricName, instance.Namespace, instance.Name, instance.Spec.Tier, string(ps.Cost.Class), ps.Cost.MonthlyCost)
+		}
+		return ctrl.Result{Requeue: true, RequeueAfter: requeueAfter}, nil
 	}
 
 	// unsupported strategy
@@ -240,3 +502,100 @@ func (r *PostgresReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error
 	}
 	return ctrl.Result{}, errorUtil.New(fmt.Sprintf("unsupported deployment strategy %s", stratMap.Postgres))
 }
+
+// reconcileMigration migrates the data of the postgres to the cloud resource of the new strategy, the postgres keeps
+// its old strategy until the connection secret points at the new cloud resource
+func (r *PostgresReconciler) reconcileMigration(ctx context.Context, instance *v1alpha1.Postgres, newStrategy string) (ctrl.Result, error) {
+	from, to := instance.Status.Strategy, newStrategy
+	if instance.Status.Migration != nil {
+		from, to = instance.Status.Migration.From, instance.Status.Migration.To
+	}
+	var source, target providers.PostgresProvider
+	for _, p := range r.providerList {
+		if p.SupportsStrategy(from) {
+			source = p
+		}
+		if p.SupportsStrategy(to) {
+			target = p
+		}
+	}
+	if source == nil || target == nil {
+		return ctrl.Result{}, errorUtil.New(fmt.Sprintf("unsupported migration of postgres %s from strategy %s to strategy %s", instance.Name, from, to))
+	}
+	m := &migration.PostgresMigration{
+		Client: r.Client,
+		Scheme: r.scheme,
+		From:   from,
+		To:     to,
+		Source: source,
+		Target: target,
+		WriteSecret: func(ctx context.Context, ps *providers.PostgresInstance) error {
+			secretData, _, err := tiers.BuildSecretData(ctx, r.Client, instance.Namespace, providers.PostgresResourceType, to, instance.Spec.Tier, ps.DeploymentDetails.Data())
+			if err != nil {
+				return err
+			}
+			return r.resourceProvider.ReconcileResultSecret(ctx, instance, secretData)
+		},
+	}
+
+	// a postgres deleted while its data is migrated removes the cloud resources of both strategies
+	if instance.DeletionTimestamp != nil {
+		if protectedMsg := providers.CheckDeletionProtection(providers.PostgresResourceType, &instance.Spec); protectedMsg != croType.StatusEmpty {
+			r.logger.Warn(protectedMsg)
+			if err := resources.UpdatePhase(ctx, r.Client, instance, croType.PhasePaused, protectedMsg); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true, RequeueAfter: target.GetReconcileTime(instance)}, nil
+		}
+
+		// pause the deletion if too many resources were deleted recently, e.g. by a namespace or gitops mistake
+		guardMsg, err := providers.ReconcileDeletionGuard(ctx, r.Client, providers.PostgresResourceType, instance, &instance.Status)
+		if err != nil {
+			if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, guardMsg.WrapError(err)); updateErr != nil {
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{}, err
+		}
+		if guardMsg != croType.StatusEmpty {
+			r.logger.Warn(guardMsg)
+			if err := resources.UpdatePhase(ctx, r.Client, instance, croType.PhasePaused, guardMsg); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true, RequeueAfter: target.GetReconcileTime(instance)}, nil
+		}
+
+		msg, err := m.Delete(ctx, instance)
+		if err != nil {
+			if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, msg.WrapError(err)); updateErr != nil {
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{}, errorUtil.Wrapf(err, "failed to delete migrating postgres")
+		}
+		if msg == croType.StatusEmpty {
+			return ctrl.Result{}, nil
+		}
+		if err := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseDeleteInProgress, msg); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{Requeue: true, RequeueAfter: target.GetReconcileTime(instance)}, nil
+	}
+
+	before := *instance.Status.DeepCopy()
+	msg, err := m.Reconcile(ctx, instance)
+	resources.RecordReconcileEvents(r.recorder, instance, false, before, instance.Status, err)
+	if err != nil {
+		if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, msg.WrapError(err)); updateErr != nil {
+			return ctrl.Result{}, updateErr
+		}
+		return ctrl.Result{}, err
+	}
+	r.logger.Info(msg)
+	if err := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseInProgress, msg); err != nil {
+		return ctrl.Result{}, err
+	}
+	// the postgres is reconciled with its new strategy straight after the migration completes
+	if !migration.InProgress(&instance.Status) {
+		return ctrl.Result{Requeue: true}, nil
+	}
+	return ctrl.Result{Requeue: true, RequeueAfter: target.GetReconcileTime(instance)}, nil
+}
@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testNamespace = "test"
+
+// fakePostgresProvider records whether the postgres was deleted with it
+type fakePostgresProvider struct {
+	strategy string
+	deleted  bool
+}
+
+func (f *fakePostgresProvider) GetName() string                { return f.strategy }
+func (f *fakePostgresProvider) SupportsStrategy(s string) bool { return s == f.strategy }
+func (f *fakePostgresProvider) GetReconcileTime(_ *v1alpha1.Postgres) time.Duration {
+	return time.Second
+}
+
+func (f *fakePostgresProvider) ReconcilePostgres(_ context.Context, _ *v1alpha1.Postgres) (*providers.PostgresInstance, croType.StatusMessage, error) {
+	return nil, "creating", nil
+}
+
+func (f *fakePostgresProvider) DeletePostgres(_ context.Context, _ *v1alpha1.Postgres) (croType.StatusMessage, error) {
+	f.deleted = true
+	return croType.StatusEmpty, nil
+}
+
+func buildTestDeletedPostgres(name string, admitted bool) *v1alpha1.Postgres {
+	now := metav1.Now()
+	pg := &v1alpha1.Postgres{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         testNamespace,
+			DeletionTimestamp: &now,
+			Finalizers:        []string{"test"},
+		},
+	}
+	if admitted {
+		meta.SetStatusCondition(&pg.Status.Conditions, metav1.Condition{
+			Type:   providers.DeletionThrottledCondition,
+			Status: metav1.ConditionFalse,
+			Reason: providers.WithinDeletionRateReason,
+		})
+	}
+	return pg
+}
+
+func TestPostgresReconciler_reconcileMigration_deletionGuard(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{v1alpha1.AddToScheme, v1.AddToScheme, batchv1.AddToScheme} {
+		if err := add(scheme); err != nil {
+			t.Fatal("failed to build scheme", err)
+		}
+	}
+	providers.DeletionRateLimit = &providers.DeletionRate{Max: 1, Window: time.Hour}
+	defer func() { providers.DeletionRateLimit = nil }()
+
+	// another postgres was deleted within the window, so the migrating postgres is beyond the rate limit
+	pg := buildTestDeletedPostgres("test-migrating", false)
+	pg.Status.Strategy = providers.OpenShiftDeploymentStrategy
+	pg.Status.Migration = &croType.MigrationStatus{From: providers.OpenShiftDeploymentStrategy, To: providers.AWSDeploymentStrategy}
+	c := fake.NewFakeClientWithScheme(scheme, pg, buildTestDeletedPostgres("test-deleted", true))
+	source := &fakePostgresProvider{strategy: providers.OpenShiftDeploymentStrategy}
+	target := &fakePostgresProvider{strategy: providers.AWSDeploymentStrategy}
+	r := &PostgresReconciler{
+		Client:       c,
+		scheme:       scheme,
+		recorder:     record.NewFakeRecorder(10),
+		logger:       logrus.WithFields(logrus.Fields{"testing": "true"}),
+		providerList: []providers.PostgresProvider{source, target},
+	}
+
+	if _, err := r.reconcileMigration(context.TODO(), pg, providers.AWSDeploymentStrategy); err != nil {
+		t.Fatalf("reconcileMigration() unexpected error = %v", err)
+	}
+	if source.deleted || target.deleted {
+		t.Errorf("reconcileMigration() deleted the cloud resources, want the deletion paused by the deletion guard")
+	}
+	if pg.Status.Phase != croType.PhasePaused {
+		t.Errorf("reconcileMigration() phase = %s, want %s", pg.Status.Phase, croType.PhasePaused)
+	}
+	if !meta.IsStatusConditionTrue(pg.Status.Conditions, providers.DeletionThrottledCondition) {
+		t.Errorf("reconcileMigration() conditions = %v, want the deletion throttled", pg.Status.Conditions)
+	}
+}
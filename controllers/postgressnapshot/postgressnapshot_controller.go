@@ -26,6 +26,7 @@ import (
 	integreatlyv1alpha1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
 	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
 	croAws "github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/openshift"
 	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
 	errorUtil "github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -33,6 +34,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -52,8 +54,9 @@ type PostgresSnapshotReconciler struct {
 	// that reads objects from the cache and writes to the apiserver
 	k8sclient.Client
 	scheme        *runtime.Scheme
+	recorder      record.EventRecorder
 	logger        *logrus.Entry
-	provider      providers.PostgresSnapshotProvider
+	providerList  []providers.PostgresSnapshotProvider
 	ConfigManager croAws.ConfigManager
 }
 
@@ -68,6 +71,12 @@ func New(mgr manager.Manager) (*PostgresSnapshotReconciler, error) {
 	if err != nil {
 		return nil, err
 	}
+	// config maps and secrets are read from the shared informer cache of the manager instead of the api server
+	client = resources.NewCachedClient(client, mgr.GetCache())
+	clientSet, err := resources.GetK8Client()
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to build client set")
+	}
 	logger := logrus.WithFields(logrus.Fields{"controller": "controller_postgres_snapshot"})
 	awsPostgresSnapshotProvider, err := croAws.NewAWSPostgresSnapshotProvider(client, logger)
 	if err != nil {
@@ -76,8 +85,9 @@ func New(mgr manager.Manager) (*PostgresSnapshotReconciler, error) {
 	return &PostgresSnapshotReconciler{
 		Client:        client,
 		scheme:        mgr.GetScheme(),
+		recorder:      mgr.GetEventRecorderFor(resources.EventSource),
 		logger:        logger,
-		provider:      awsPostgresSnapshotProvider,
+		providerList:  []providers.PostgresSnapshotProvider{awsPostgresSnapshotProvider, openshift.NewOpenShiftPostgresSnapshotProvider(client, clientSet, logger)},
 		ConfigManager: croAws.NewDefaultConfigMapConfigManager(mgr.GetClient()),
 	}, nil
 }
@@ -125,17 +135,24 @@ func (r *PostgresSnapshotReconciler) Reconcile(request ctrl.Request) (ctrl.Resul
 		return ctrl.Result{}, errorUtil.New(errMsg)
 	}
 
-	// check postgres deployment strategy is aws
-	if !r.provider.SupportsStrategy(postgresCr.Status.Strategy) {
-		errMsg := fmt.Sprintf("the resource %s uses an unsupported provider strategy %s, only resources using the aws provider are valid", instance.Spec.ResourceName, postgresCr.Status.Strategy)
+	// check postgres deployment strategy is aws or openshift
+	var provider providers.PostgresSnapshotProvider
+	for _, p := range r.providerList {
+		if p.SupportsStrategy(postgresCr.Status.Strategy) {
+			provider = p
+			break
+		}
+	}
+	if provider == nil {
+		errMsg := fmt.Sprintf("the resource %s uses an unsupported provider strategy %s, only resources using the aws or openshift provider are valid", instance.Spec.ResourceName, postgresCr.Status.Strategy)
 		if updateErr := resources.UpdateSnapshotPhase(ctx, r.Client, instance, croType.PhaseFailed, croType.StatusMessage(errMsg)); updateErr != nil {
-			return ctrl.Result{Requeue: true, RequeueAfter: r.provider.GetReconcileTime(instance)}, updateErr
+			return ctrl.Result{}, updateErr
 		}
 		return ctrl.Result{}, errorUtil.New(errMsg)
 	}
 
 	if instance.DeletionTimestamp != nil {
-		msg, err := r.provider.DeletePostgresSnapshot(ctx, instance, postgresCr)
+		msg, err := provider.DeletePostgresSnapshot(ctx, instance, postgresCr)
 		if err != nil {
 			if updateErr := resources.UpdateSnapshotPhase(ctx, r.Client, instance, croType.PhaseFailed, msg.WrapError(err)); updateErr != nil {
 				return ctrl.Result{}, updateErr
@@ -147,20 +164,29 @@ func (r *PostgresSnapshotReconciler) Reconcile(request ctrl.Request) (ctrl.Resul
 		if err = resources.UpdateSnapshotPhase(ctx, r.Client, instance, croType.PhaseDeleteInProgress, msg); err != nil {
 			return ctrl.Result{}, err
 		}
-		return ctrl.Result{Requeue: true, RequeueAfter: r.provider.GetReconcileTime(instance)}, nil
+		return ctrl.Result{Requeue: true, RequeueAfter: provider.GetReconcileTime(instance)}, nil
 	}
 
 	// check status, if complete return
 	if instance.Status.Phase == croType.PhaseComplete {
+		// delete the snapshot once its retention period has passed, the finalizer removes it from aws
+		if providers.IsSnapshotExpired(instance.Status, time.Now()) {
+			r.logger.Infof("retention period of snapshot %s has passed, deleting", instance.Name)
+			if err := r.Client.Delete(ctx, instance); err != nil && !errors.IsNotFound(err) {
+				return ctrl.Result{}, errorUtil.Wrapf(err, "failed to delete expired postgres snapshot %s", instance.Name)
+			}
+			return ctrl.Result{Requeue: true, RequeueAfter: provider.GetReconcileTime(instance)}, nil
+		}
 		r.logger.Infof("skipping creation of snapshot for %s as phase is complete", instance.Name)
-		return ctrl.Result{Requeue: true, RequeueAfter: r.provider.GetReconcileTime(instance)}, nil
+		return ctrl.Result{Requeue: true, RequeueAfter: provider.GetReconcileTime(instance)}, nil
 	}
 
 	// create the snapshot and return the phase
-	snap, msg, err := r.provider.CreatePostgresSnapshot(ctx, instance, postgresCr)
+	snap, msg, err := provider.CreatePostgresSnapshot(ctx, instance, postgresCr)
 
 	// error trying to create snapshot
 	if err != nil {
+		resources.RecordAWSAPIError(r.recorder, instance, err)
 		if updateErr := resources.UpdateSnapshotPhase(ctx, r.Client, instance, croType.PhaseFailed, msg); updateErr != nil {
 			return ctrl.Result{}, updateErr
 		}
@@ -172,14 +198,15 @@ func (r *PostgresSnapshotReconciler) Reconcile(request ctrl.Request) (ctrl.Resul
 		if updateErr := resources.UpdateSnapshotPhase(ctx, r.Client, instance, croType.PhaseInProgress, msg); updateErr != nil {
 			return ctrl.Result{}, updateErr
 		}
-		return ctrl.Result{Requeue: true, RequeueAfter: r.provider.GetReconcileTime(instance)}, nil
+		return ctrl.Result{Requeue: true, RequeueAfter: provider.GetReconcileTime(instance)}, nil
 	}
 
 	// no error, snapshot exists
 	if updateErr := resources.UpdateSnapshotPhase(ctx, r.Client, instance, croType.PhaseComplete, msg); updateErr != nil {
 		return ctrl.Result{}, updateErr
 	}
-	return ctrl.Result{Requeue: true, RequeueAfter: r.provider.GetReconcileTime(instance)}, nil
+	r.recorder.Eventf(instance, corev1.EventTypeNormal, resources.SnapshotTakenEventReason, "snapshot of postgres %s taken", instance.Spec.ResourceName)
+	return ctrl.Result{Requeue: true, RequeueAfter: provider.GetReconcileTime(instance)}, nil
 }
 
 func buildPostgresSnapshotStatusMetricLabels(cr *integreatlyv1alpha1.PostgresSnapshot, clusterID, snapshotName string, phase croType.StatusPhase) map[string]string {
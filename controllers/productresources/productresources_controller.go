@@ -0,0 +1,284 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package productresources
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	defaultTier        = "production"
+	defaultRequeueTime = time.Second * 30
+
+	labelProductResources = "integreatly.org/product-resources"
+)
+
+// productResource is implemented by the custom resources created for a product
+type productResource interface {
+	runtime.Object
+	metav1.Object
+}
+
+// ProductResourcesReconciler reconciles a ProductResources object
+type ProductResourcesReconciler struct {
+	k8sclient.Client
+	scheme *runtime.Scheme
+	logger *logrus.Entry
+}
+
+func New(mgr manager.Manager) (*ProductResourcesReconciler, error) {
+	return &ProductResourcesReconciler{
+		Client: mgr.GetClient(),
+		scheme: mgr.GetScheme(),
+		logger: logrus.WithFields(logrus.Fields{"controller": "controller_productresources"}),
+	}, nil
+}
+
+func (r *ProductResourcesReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&integreatlyv1alpha1.ProductResources{}).
+		Owns(&integreatlyv1alpha1.BlobStorage{}).
+		Owns(&integreatlyv1alpha1.Postgres{}).
+		Owns(&integreatlyv1alpha1.Redis{}).
+		Complete(r)
+}
+
+func (r *ProductResourcesReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error) {
+	r.logger.Info("reconciling product resources")
+	ctx := context.TODO()
+
+	instance := &integreatlyv1alpha1.ProductResources{}
+	if err := r.Client.Get(ctx, request.NamespacedName, instance); err != nil {
+		if k8serr.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// the resources are owned by the product resources, so they're deleted by garbage collection
+	if instance.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	var statuses []integreatlyv1alpha1.ProductResourceStatus
+	for _, res := range instance.Spec.Resources {
+		statuses = append(statuses, r.reconcileResource(ctx, instance, res))
+	}
+	if err := r.deleteRemovedResources(ctx, instance); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	instance.Status.Resources = statuses
+	instance.Status.Phase, instance.Status.Message, instance.Status.Ready = aggregateStatus(statuses)
+	if err := r.Client.Status().Update(ctx, instance); err != nil {
+		return ctrl.Result{}, errorUtil.Wrapf(err, "failed to update product resources %s", instance.Name)
+	}
+	if instance.Status.Phase == croType.PhaseComplete {
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{Requeue: true, RequeueAfter: defaultRequeueTime}, nil
+}
+
+// reconcileResource creates or updates the custom resource of a product resource and returns its status, a failure to
+// reconcile one resource is reported in its status so the other resources are still reconciled
+func (r *ProductResourcesReconciler) reconcileResource(ctx context.Context, pr *integreatlyv1alpha1.ProductResources, res integreatlyv1alpha1.ProductResource) integreatlyv1alpha1.ProductResourceStatus {
+	status := integreatlyv1alpha1.ProductResourceStatus{Kind: res.Kind, Name: res.Name}
+	resource, err := buildResource(pr, res.Kind, res.Name)
+	if err != nil {
+		status.Phase = croType.PhaseFailed
+		status.Message = croType.StatusMessage(resources.Redact(err.Error()))
+		return status
+	}
+	tier := res.Tier
+	if tier == "" {
+		tier = pr.Spec.Tier
+	}
+	if tier == "" {
+		tier = defaultTier
+	}
+	secretName := res.SecretName
+	if secretName == "" {
+		secretName = res.Name
+	}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, resource, func() error {
+		labels := resource.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[labelProductResources] = pr.Name
+		labels["productName"] = pr.Name
+		resource.SetLabels(labels)
+		spec := resourceSpec(resource)
+		spec.Type = pr.Spec.Type
+		spec.Tier = tier
+		spec.SecretRef = &croType.SecretRef{Name: secretName, Namespace: pr.Namespace}
+		spec.DependsOn = res.DependsOn
+		return controllerutil.SetControllerReference(pr, resource, r.scheme)
+	}); err != nil {
+		errMsg := fmt.Sprintf("failed to create or update %s %s", res.Kind, res.Name)
+		r.logger.Error(errorUtil.Wrap(err, errMsg))
+		status.Phase = croType.PhaseFailed
+		status.Message = croType.StatusMessage(errMsg).WrapError(resources.RedactError(err))
+		return status
+	}
+	rts := resourceStatus(resource)
+	status.Phase = rts.Phase
+	status.Message = rts.Message
+	status.SecretRef = rts.SecretRef
+	return status
+}
+
+// deleteRemovedResources deletes the resources owned by the product resources which are no longer in its spec
+func (r *ProductResourcesReconciler) deleteRemovedResources(ctx context.Context, pr *integreatlyv1alpha1.ProductResources) error {
+	wanted := map[string]bool{}
+	for _, res := range pr.Spec.Resources {
+		wanted[fmt.Sprintf("%s/%s", res.Kind, res.Name)] = true
+	}
+	opts := []k8sclient.ListOption{k8sclient.InNamespace(pr.Namespace), k8sclient.MatchingLabels{labelProductResources: pr.Name}}
+
+	var owned []productResource
+	bsList := &integreatlyv1alpha1.BlobStorageList{}
+	if err := r.Client.List(ctx, bsList, opts...); err != nil {
+		return errorUtil.Wrap(err, "failed to list blob storages of product resources")
+	}
+	for i := range bsList.Items {
+		owned = append(owned, &bsList.Items[i])
+	}
+	pgList := &integreatlyv1alpha1.PostgresList{}
+	if err := r.Client.List(ctx, pgList, opts...); err != nil {
+		return errorUtil.Wrap(err, "failed to list postgres of product resources")
+	}
+	for i := range pgList.Items {
+		owned = append(owned, &pgList.Items[i])
+	}
+	redisList := &integreatlyv1alpha1.RedisList{}
+	if err := r.Client.List(ctx, redisList, opts...); err != nil {
+		return errorUtil.Wrap(err, "failed to list redis of product resources")
+	}
+	for i := range redisList.Items {
+		owned = append(owned, &redisList.Items[i])
+	}
+
+	for _, resource := range owned {
+		key := fmt.Sprintf("%s/%s", resourceKind(resource), resource.GetName())
+		if wanted[key] || !metav1.IsControlledBy(resource, pr) || resource.GetDeletionTimestamp() != nil {
+			continue
+		}
+		r.logger.Infof("deleting %s, it was removed from product resources %s", key, pr.Name)
+		if err := r.Client.Delete(ctx, resource); err != nil && !k8serr.IsNotFound(err) {
+			return errorUtil.Wrapf(err, "failed to delete %s", key)
+		}
+	}
+	return nil
+}
+
+// aggregateStatus returns the phase, message and ready count of the product resources, it's failed if any resource
+// failed and complete once every resource is complete
+func aggregateStatus(statuses []integreatlyv1alpha1.ProductResourceStatus) (croType.StatusPhase, croType.StatusMessage, string) {
+	complete := 0
+	var waiting, failed *integreatlyv1alpha1.ProductResourceStatus
+	for i, status := range statuses {
+		switch status.Phase {
+		case croType.PhaseComplete:
+			complete++
+		case croType.PhaseFailed:
+			if failed == nil {
+				failed = &statuses[i]
+			}
+		default:
+			if waiting == nil {
+				waiting = &statuses[i]
+			}
+		}
+	}
+	ready := fmt.Sprintf("%d/%d", complete, len(statuses))
+	if failed != nil {
+		return croType.PhaseFailed, croType.StatusMessage(fmt.Sprintf("%s %s failed: %s", failed.Kind, failed.Name, failed.Message)), ready
+	}
+	if waiting != nil {
+		return croType.PhaseInProgress, croType.StatusMessage(fmt.Sprintf("waiting for %s %s to be complete", waiting.Kind, waiting.Name)), ready
+	}
+	return croType.PhaseComplete, "all resources are complete", ready
+}
+
+// buildResource returns an empty custom resource of the kind, in the namespace of the product resources
+func buildResource(pr *integreatlyv1alpha1.ProductResources, kind, name string) (productResource, error) {
+	om := metav1.ObjectMeta{
+		Name:      name,
+		Namespace: pr.Namespace,
+	}
+	switch kind {
+	case "BlobStorage":
+		return &integreatlyv1alpha1.BlobStorage{ObjectMeta: om}, nil
+	case "Postgres":
+		return &integreatlyv1alpha1.Postgres{ObjectMeta: om}, nil
+	case "Redis":
+		return &integreatlyv1alpha1.Redis{ObjectMeta: om}, nil
+	}
+	return nil, errorUtil.Errorf("unsupported resource kind %s", kind)
+}
+
+func resourceKind(resource productResource) string {
+	switch resource.(type) {
+	case *integreatlyv1alpha1.BlobStorage:
+		return "BlobStorage"
+	case *integreatlyv1alpha1.Postgres:
+		return "Postgres"
+	case *integreatlyv1alpha1.Redis:
+		return "Redis"
+	}
+	return ""
+}
+
+func resourceSpec(resource productResource) *croType.ResourceTypeSpec {
+	switch r := resource.(type) {
+	case *integreatlyv1alpha1.BlobStorage:
+		return &r.Spec
+	case *integreatlyv1alpha1.Postgres:
+		return &r.Spec
+	case *integreatlyv1alpha1.Redis:
+		return &r.Spec
+	}
+	return &croType.ResourceTypeSpec{}
+}
+
+func resourceStatus(resource productResource) croType.ResourceTypeStatus {
+	switch r := resource.(type) {
+	case *integreatlyv1alpha1.BlobStorage:
+		return r.Status
+	case *integreatlyv1alpha1.Postgres:
+		return r.Status
+	case *integreatlyv1alpha1.Redis:
+		return r.Status
+	}
+	return croType.ResourceTypeStatus{}
+}
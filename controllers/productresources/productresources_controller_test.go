@@ -0,0 +1,199 @@
+package productresources
+
+import (
+	"context"
+	"testing"
+
+	integreatlyv1alpha1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/sirupsen/logrus"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testName      = "test"
+	testNamespace = "test-ns"
+	testUID       = "test-uid"
+)
+
+func buildTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := integreatlyv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	return scheme
+}
+
+func buildTestProductResources(resources ...integreatlyv1alpha1.ProductResource) *integreatlyv1alpha1.ProductResources {
+	return &integreatlyv1alpha1.ProductResources{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testName,
+			Namespace: testNamespace,
+			UID:       testUID,
+		},
+		Spec: integreatlyv1alpha1.ProductResourcesSpec{
+			Type:      "workshop",
+			Resources: resources,
+		},
+	}
+}
+
+func buildTestPostgres(phase croType.StatusPhase) *integreatlyv1alpha1.Postgres {
+	return &integreatlyv1alpha1.Postgres{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-postgres",
+			Namespace: testNamespace,
+		},
+		Status: croType.ResourceTypeStatus{
+			Phase:   phase,
+			Message: "test message",
+		},
+	}
+}
+
+func buildTestOwnedRedis() *integreatlyv1alpha1.Redis {
+	controller := true
+	return &integreatlyv1alpha1.Redis{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-redis",
+			Namespace: testNamespace,
+			Labels:    map[string]string{labelProductResources: testName},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: integreatlyv1alpha1.GroupVersion.String(),
+					Kind:       "ProductResources",
+					Name:       testName,
+					UID:        testUID,
+					Controller: &controller,
+				},
+			},
+		},
+	}
+}
+
+func TestProductResourcesReconciler_Reconcile(t *testing.T) {
+	scheme := buildTestScheme(t)
+	postgresRes := integreatlyv1alpha1.ProductResource{Kind: "Postgres", Name: "test-postgres", Tier: "development"}
+	redisRes := integreatlyv1alpha1.ProductResource{
+		Kind:       "Redis",
+		Name:       "test-redis",
+		SecretName: "test-redis-sec",
+		DependsOn:  []croType.Dependency{{Kind: "Postgres", Name: "test-postgres"}},
+	}
+
+	tests := []struct {
+		name        string
+		objs        []runtime.Object
+		wantPhase   croType.StatusPhase
+		wantReady   string
+		wantMessage croType.StatusMessage
+		wantDeleted bool
+	}{
+		{
+			name:        "test resources are created and waited for",
+			objs:        []runtime.Object{buildTestProductResources(postgresRes, redisRes)},
+			wantPhase:   croType.PhaseInProgress,
+			wantReady:   "0/2",
+			wantMessage: "waiting for Postgres test-postgres to be complete",
+		},
+		{
+			name:        "test complete when every resource is complete",
+			objs:        []runtime.Object{buildTestProductResources(postgresRes), buildTestPostgres(croType.PhaseComplete)},
+			wantPhase:   croType.PhaseComplete,
+			wantReady:   "1/1",
+			wantMessage: "all resources are complete",
+		},
+		{
+			name:        "test failed when a resource failed",
+			objs:        []runtime.Object{buildTestProductResources(postgresRes, redisRes), buildTestPostgres(croType.PhaseFailed)},
+			wantPhase:   croType.PhaseFailed,
+			wantReady:   "0/2",
+			wantMessage: "Postgres test-postgres failed: test message",
+		},
+		{
+			name:        "test failed when a resource kind is unsupported",
+			objs:        []runtime.Object{buildTestProductResources(integreatlyv1alpha1.ProductResource{Kind: "Memcached", Name: "test"})},
+			wantPhase:   croType.PhaseFailed,
+			wantReady:   "0/1",
+			wantMessage: "Memcached test failed: unsupported resource kind Memcached",
+		},
+		{
+			name:        "test resources removed from the spec are deleted",
+			objs:        []runtime.Object{buildTestProductResources(postgresRes), buildTestPostgres(croType.PhaseComplete), buildTestOwnedRedis()},
+			wantPhase:   croType.PhaseComplete,
+			wantReady:   "1/1",
+			wantMessage: "all resources are complete",
+			wantDeleted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewFakeClientWithScheme(scheme, tt.objs...)
+			r := &ProductResourcesReconciler{
+				Client: client,
+				scheme: scheme,
+				logger: logrus.NewEntry(logrus.StandardLogger()),
+			}
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: testName, Namespace: testNamespace}}
+			if _, err := r.Reconcile(req); err != nil {
+				t.Fatalf("Reconcile() unexpected error = %v", err)
+			}
+
+			pr := &integreatlyv1alpha1.ProductResources{}
+			if err := client.Get(context.TODO(), req.NamespacedName, pr); err != nil {
+				t.Fatalf("failed to get product resources: %v", err)
+			}
+			if pr.Status.Phase != tt.wantPhase {
+				t.Errorf("Reconcile() phase = %v, want %v, message %s", pr.Status.Phase, tt.wantPhase, pr.Status.Message)
+			}
+			if pr.Status.Ready != tt.wantReady {
+				t.Errorf("Reconcile() ready = %v, want %v", pr.Status.Ready, tt.wantReady)
+			}
+			if pr.Status.Message != tt.wantMessage {
+				t.Errorf("Reconcile() message = %v, want %v", pr.Status.Message, tt.wantMessage)
+			}
+			if len(pr.Status.Resources) != len(pr.Spec.Resources) {
+				t.Errorf("Reconcile() resource statuses = %v, want one per resource", pr.Status.Resources)
+			}
+
+			for _, res := range pr.Spec.Resources {
+				if res.Kind != "Postgres" {
+					continue
+				}
+				pg := &integreatlyv1alpha1.Postgres{}
+				if err := client.Get(context.TODO(), types.NamespacedName{Name: res.Name, Namespace: testNamespace}, pg); err != nil {
+					t.Fatalf("Reconcile() expected postgres to be created, got error = %v", err)
+				}
+				if !metav1.IsControlledBy(pg, pr) {
+					t.Errorf("Reconcile() expected postgres to be owned by the product resources")
+				}
+				if pg.Spec.Tier != "development" || pg.Spec.Type != "workshop" || pg.Spec.SecretRef.Name != "test-postgres" {
+					t.Errorf("Reconcile() unexpected postgres spec %v", pg.Spec)
+				}
+			}
+			for _, res := range pr.Spec.Resources {
+				if res.Kind != "Redis" {
+					continue
+				}
+				redis := &integreatlyv1alpha1.Redis{}
+				if err := client.Get(context.TODO(), types.NamespacedName{Name: res.Name, Namespace: testNamespace}, redis); err != nil {
+					t.Fatalf("Reconcile() expected redis to be created, got error = %v", err)
+				}
+				if redis.Spec.Tier != defaultTier || redis.Spec.SecretRef.Name != "test-redis-sec" || len(redis.Spec.DependsOn) != 1 {
+					t.Errorf("Reconcile() unexpected redis spec %v", redis.Spec)
+				}
+			}
+			if tt.wantDeleted {
+				err := client.Get(context.TODO(), types.NamespacedName{Name: "test-redis", Namespace: testNamespace}, &integreatlyv1alpha1.Redis{})
+				if !k8serr.IsNotFound(err) {
+					t.Errorf("Reconcile() expected removed redis to be deleted, got error = %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,330 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources/metrics"
+	"github.com/integr8ly/cloud-resource-operator/pkg/supportbundle"
+	"github.com/integr8ly/cloud-resource-operator/pkg/tiers"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	errorUtil "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	integreatlyv1alpha1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+)
+
+var log = logf.Log.WithName("controller_queue")
+
+// QueueReconciler reconciles a Queue object
+type QueueReconciler struct {
+	k8sclient.Client
+	scheme           *runtime.Scheme
+	recorder         record.EventRecorder
+	logger           *logrus.Entry
+	resourceProvider *resources.ReconcileResourceProvider
+	providerList     []providers.QueueProvider
+}
+
+// New returns a new reconcile.Reconciler
+func New(mgr manager.Manager) (*QueueReconciler, error) {
+	restConfig := controllerruntime.GetConfigOrDie()
+	restConfig.Timeout = time.Second * 10
+	client, err := k8sclient.New(restConfig, k8sclient.Options{
+		Scheme: mgr.GetScheme(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	// config maps and secrets are read from the shared informer cache of the manager instead of the api server
+	client = resources.NewCachedClient(client, mgr.GetCache())
+
+	logger := logrus.WithFields(logrus.Fields{"controller": "controller_queue"})
+	awsQueueProvider, err := aws.NewAWSQueueProvider(client, logger)
+	if err != nil {
+		return nil, err
+	}
+	providerList := []providers.QueueProvider{awsQueueProvider}
+	rp := resources.NewResourceProvider(client, mgr.GetScheme(), logger)
+	return &QueueReconciler{
+		Client:           client,
+		scheme:           mgr.GetScheme(),
+		recorder:         mgr.GetEventRecorderFor(resources.EventSource),
+		logger:           logger,
+		resourceProvider: rp,
+		providerList:     providerList,
+	}, nil
+}
+
+func (r *QueueReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&integreatlyv1alpha1.Queue{}).
+		Watches(&source.Kind{Type: &v1alpha1.Queue{}}, &handler.EnqueueRequestForObject{}).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, resources.EnqueueOnMetadataChange(mgr.GetClient(), &v1alpha1.QueueList{})).
+		Complete(r)
+}
+
+func (r *QueueReconciler) Reconcile(request ctrl.Request) (result ctrl.Result, err error) {
+	r.logger.Info("reconciling Queue")
+	ctx := context.TODO()
+	cfgMgr := providers.NewConfigManager(providers.DefaultProviderConfigMapName, request.Namespace, r.Client)
+
+	// Fetch the Queue instance
+	instance := &v1alpha1.Queue{}
+	err = r.Client.Get(ctx, request.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// Return and don't requeue
+			metrics.DeleteResourcePhase(string(providers.QueueResourceType), request.NamespacedName)
+			providers.ResetRequeue(providers.QueueResourceType, request.NamespacedName)
+			metrics.SetDeletionPaused(string(providers.QueueResourceType), request.NamespacedName, false)
+			metrics.DeleteArtifactExpiry(string(providers.QueueResourceType), request.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	stratMap, err := cfgMgr.GetStrategyMappingForDeploymentType(ctx, instance.Spec.Type)
+	if err != nil {
+		if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, croType.StatusDeploymentConfigNotFound.WrapError(err)); updateErr != nil {
+			return ctrl.Result{}, updateErr
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Check the CR for existing Strategy
+	strategyToUse := stratMap.Queue
+	if instance.Status.Strategy != "" {
+		strategyToUse = instance.Status.Strategy
+		if strategyToUse != stratMap.Queue {
+			r.logger.Infof("strategy and provider already set, changing of cloud-resource-config config maps not allowed in existing installation. the existing strategy is '%s' , cloud-resource-config is now set to '%s'. operator will continue to use existing strategy", strategyToUse, stratMap.Queue)
+		}
+	}
+
+	for _, p := range r.providerList {
+		if !p.SupportsStrategy(strategyToUse) {
+			continue
+		}
+		instance.Status.Strategy = strategyToUse
+		instance.Status.Provider = p.GetName()
+		defer func(provider string) {
+			metrics.ObserveReconcile(string(providers.QueueResourceType), request.NamespacedName, provider, instance.Spec.Tier, instance.Status.Phase, err)
+		}(p.GetName())
+		defer func(before croType.ResourceTypeStatus) {
+			resources.RecordReconcileEvents(r.recorder, instance, instance.DeletionTimestamp != nil, before, instance.Status, err)
+		}(*instance.Status.DeepCopy())
+		defer func() {
+			if instance.Status.Phase != croType.PhaseFailed {
+				return
+			}
+			if bundleErr := supportbundle.Reconcile(ctx, r.Client, r.scheme, providers.QueueResourceType, instance, instance.Spec, instance.Status, nil); bundleErr != nil {
+				r.logger.Warnf("failed to write support bundle of queue %s: %v", instance.Name, bundleErr)
+			}
+		}()
+		if instance.Status.Strategy != strategyToUse || instance.Status.Provider != p.GetName() {
+			if err = r.Client.Status().Update(ctx, instance); err != nil {
+				return ctrl.Result{}, errorUtil.Wrapf(err, "failed to update instance %s in namespace %s", instance.Name, instance.Namespace)
+			}
+		}
+
+		if instance.GetDeletionTimestamp() != nil {
+			// pause the deletion if too many resources were deleted recently, e.g. by a namespace or gitops mistake
+			guardMsg, err := providers.ReconcileDeletionGuard(ctx, r.Client, providers.QueueResourceType, instance, &instance.Status)
+			if err != nil {
+				if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, guardMsg.WrapError(err)); updateErr != nil {
+					return ctrl.Result{}, updateErr
+				}
+				return ctrl.Result{}, err
+			}
+			if guardMsg != croType.StatusEmpty {
+				r.logger.Warn(guardMsg)
+				if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhasePaused, guardMsg); err != nil {
+					return ctrl.Result{}, err
+				}
+				return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+			}
+
+			msg, err := p.DeleteQueue(ctx, instance)
+			if err != nil {
+				if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, msg.WrapError(err)); updateErr != nil {
+					return ctrl.Result{}, updateErr
+				}
+				return ctrl.Result{}, errorUtil.Wrapf(err, "failed to perform provider-specific queue deletion")
+			}
+
+			r.logger.Info("waiting on queue to successfully delete")
+			if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseDeleteInProgress, msg.WrapError(err)); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true, RequeueAfter: providers.NextRequeue(p.GetName(), providers.QueueResourceType, request.NamespacedName, croType.PhaseDeleteInProgress, p.GetReconcileTime(instance))}, nil
+		}
+
+		// abort the creation of the queue if it was cancelled, removing what was created so far
+		if providers.IsProvisioningCancelled(instance, &instance.Status) {
+			cancelled, msg, err := providers.ReconcileCancelledProvisioning(instance, &instance.Status, func() (croType.StatusMessage, error) {
+				return p.DeleteQueue(ctx, instance)
+			})
+			if err != nil {
+				if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, msg.WrapError(err)); updateErr != nil {
+					return ctrl.Result{}, updateErr
+				}
+				return ctrl.Result{}, errorUtil.Wrapf(err, "failed to cancel queue provisioning")
+			}
+			phase := croType.PhaseDeleteInProgress
+			if cancelled {
+				phase = croType.PhasePaused
+			}
+			r.logger.Info(msg)
+			if err = resources.UpdatePhase(ctx, r.Client, instance, phase, msg); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+		}
+
+		// fail clearly if the tier of the queue was removed from the strategy config map
+		tierMsg, err := tiers.ReconcileTierCondition(ctx, r.Client, instance, providers.QueueResourceType, strategyToUse, &instance.Spec, &instance.Status)
+		if err != nil {
+			if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, tierMsg.WrapError(err)); updateErr != nil {
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{}, err
+		}
+		if tierMsg != croType.StatusEmpty {
+			r.logger.Warn(tierMsg)
+			if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, tierMsg); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+		}
+
+		// wait for the resources this queue depends on before provisioning it
+		depMsg, err := providers.ReconcileDependencies(ctx, r.Client, instance, &instance.Spec, &instance.Status)
+		if err != nil {
+			if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, depMsg.WrapError(err)); updateErr != nil {
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{}, err
+		}
+		if depMsg != croType.StatusEmpty {
+			r.logger.Info(depMsg)
+			if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseInProgress, depMsg); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+		}
+
+		// wait for a provisioning slot of the provider before creating the queue
+		provMsg, err := providers.ReconcileProvisioning(ctx, r.Client, p.GetName(), instance, &instance.Status)
+		if err != nil {
+			if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, provMsg.WrapError(err)); updateErr != nil {
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{}, err
+		}
+		if provMsg != croType.StatusEmpty {
+			r.logger.Info(provMsg)
+			if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseInProgress, provMsg); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+		}
+
+		qi, msg, err := p.CreateQueue(ctx, instance)
+		if err != nil {
+			instance.Status.SecretRef = &croType.SecretRef{}
+			if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, msg.WrapError(err)); updateErr != nil {
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{}, err
+		}
+		if qi == nil {
+			r.logger.Info("secret data is still reconciling, queue is nil")
+			instance.Status.SecretRef = &croType.SecretRef{}
+			if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseInProgress, msg); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true, RequeueAfter: providers.NextRequeue(p.GetName(), providers.QueueResourceType, request.NamespacedName, croType.PhaseInProgress, p.GetReconcileTime(instance))}, nil
+		}
+		// the cloud resource is ready, polls of it start from the minimum interval of the provider again
+		providers.ResetRequeue(providers.QueueResourceType, request.NamespacedName)
+
+		secretData, secretMsg, err := tiers.BuildSecretData(ctx, r.Client, instance.Namespace, providers.QueueResourceType, strategyToUse, instance.Spec.Tier, qi.DeploymentDetails.Data())
+		if err != nil {
+			if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, secretMsg.WrapError(err)); updateErr != nil {
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{}, err
+		}
+		if err := r.resourceProvider.ReconcileResultSecret(ctx, instance, secretData); err != nil {
+			return ctrl.Result{}, errorUtil.Wrap(err, "failed to reconcile secret")
+		}
+
+		// warn about artifacts expiring within the warning period of the tier, so they're rotated before consumers break
+		expiryCfg, err := tiers.GetExpiryConfig(ctx, r.Client, strategyToUse, instance.Namespace, providers.QueueResourceType, instance.Spec.Tier)
+		if err != nil {
+			return ctrl.Result{}, errorUtil.Wrap(err, "failed to get expiry config")
+		}
+		if expiryMsg := providers.ReconcileExpiry(providers.QueueResourceType, instance, &instance.Status, qi.Artifacts, expiryCfg, time.Now()); expiryMsg != "" {
+			r.logger.Warn(expiryMsg)
+		}
+
+		if providers.CompleteProvisioning(instance, &instance.Status) {
+			metrics.ObserveProvisioningDuration(string(providers.QueueResourceType), p.GetName(), instance.Spec.Tier, instance.Status.Provisioning)
+		}
+		resources.RecordPhaseTransition(&instance.Status, instance.Status.Phase, croType.PhaseComplete, msg)
+		instance.Status.Phase = croType.PhaseComplete
+		instance.Status.Message = msg
+		instance.Status.SecretRef = instance.Spec.SecretRef
+		instance.Status.Strategy = strategyToUse
+		instance.Status.Provider = p.GetName()
+		resources.SetPhaseConditions(instance, &instance.Status)
+		if err = r.Client.Status().Update(ctx, instance); err != nil {
+			return ctrl.Result{}, errorUtil.Wrapf(err, "failed to update instance %s in namespace %s", instance.Name, instance.Namespace)
+		}
+		return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+	}
+
+	// unsupported strategy
+	if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, croType.StatusUnsupportedType.WrapError(err)); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, errorUtil.New(fmt.Sprintf("unsupported deployment strategy %s", stratMap.Queue))
+}
@@ -27,11 +27,15 @@ import (
 	"github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
 	"github.com/integr8ly/cloud-resource-operator/pkg/providers/openshift"
 	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources/metrics"
+	"github.com/integr8ly/cloud-resource-operator/pkg/supportbundle"
+	"github.com/integr8ly/cloud-resource-operator/pkg/tiers"
 	errorUtil "github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -49,6 +53,7 @@ var log = logf.Log.WithName("controller_redis")
 type RedisReconciler struct {
 	k8sclient.Client
 	scheme           *runtime.Scheme
+	recorder         record.EventRecorder
 	logger           *logrus.Entry
 	resourceProvider *resources.ReconcileResourceProvider
 	providerList     []providers.RedisProvider
@@ -65,6 +70,8 @@ func New(mgr manager.Manager) (*RedisReconciler, error) {
 	if err != nil {
 		return nil, err
 	}
+	// config maps and secrets are read from the shared informer cache of the manager instead of the api server
+	client = resources.NewCachedClient(client, mgr.GetCache())
 	logger := logrus.WithFields(logrus.Fields{"controller": "controller_redis"})
 	awsRedisProvider, err := aws.NewAWSRedisProvider(client, logger)
 	if err != nil {
@@ -75,6 +82,7 @@ func New(mgr manager.Manager) (*RedisReconciler, error) {
 	return &RedisReconciler{
 		Client:           mgr.GetClient(),
 		scheme:           mgr.GetScheme(),
+		recorder:         mgr.GetEventRecorderFor(resources.EventSource),
 		logger:           logger,
 		resourceProvider: rp,
 		providerList:     providerList,
@@ -89,22 +97,27 @@ func (r *RedisReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			IsController: true,
 			OwnerType:    &v1alpha1.Redis{},
 		}).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, resources.EnqueueOnMetadataChange(mgr.GetClient(), &v1alpha1.RedisList{})).
 		Complete(r)
 }
 
-func (r *RedisReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error) {
+func (r *RedisReconciler) Reconcile(request ctrl.Request) (result ctrl.Result, err error) {
 	r.logger.Info("reconciling Redis")
 	ctx := context.TODO()
 	cfgMgr := providers.NewConfigManager(providers.DefaultProviderConfigMapName, request.Namespace, r.Client)
 
 	// Fetch the Redis instance
 	instance := &v1alpha1.Redis{}
-	err := r.Client.Get(ctx, request.NamespacedName, instance)
+	err = r.Client.Get(ctx, request.NamespacedName, instance)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
+			metrics.DeleteResourcePhase(string(providers.RedisResourceType), request.NamespacedName)
+			metrics.DeleteProbe(string(providers.RedisResourceType), request.NamespacedName)
+			providers.ResetRequeue(providers.RedisResourceType, request.NamespacedName)
+			metrics.SetDeletionPaused(string(providers.RedisResourceType), request.NamespacedName, false)
 			return ctrl.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -134,6 +147,26 @@ func (r *RedisReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error) {
 		}
 		instance.Status.Strategy = strategyToUse
 		instance.Status.Provider = p.GetName()
+		defer func(provider string) {
+			metrics.ObserveReconcile(string(providers.RedisResourceType), request.NamespacedName, provider, instance.Spec.Tier, instance.Status.Phase, err)
+		}(p.GetName())
+		defer func(before croType.ResourceTypeStatus) {
+			resources.RecordReconcileEvents(r.recorder, instance, instance.DeletionTimestamp != nil, before, instance.Status, err)
+		}(*instance.Status.DeepCopy())
+		defer func(provider providers.RedisProvider) {
+			if instance.Status.Phase != croType.PhaseFailed {
+				return
+			}
+			var readEvents supportbundle.EventReader
+			if er, ok := provider.(providers.RedisEventReader); ok {
+				readEvents = func(ctx context.Context) ([]providers.ProviderEvent, error) {
+					return er.GetRedisEvents(ctx, instance)
+				}
+			}
+			if bundleErr := supportbundle.Reconcile(ctx, r.Client, r.scheme, providers.RedisResourceType, instance, instance.Spec, instance.Status, readEvents); bundleErr != nil {
+				r.logger.Warnf("failed to write support bundle of redis %s: %v", instance.Name, bundleErr)
+			}
+		}(p)
 		if instance.Status.Strategy != strategyToUse || instance.Status.Provider != p.GetName() {
 			if err = r.Client.Status().Update(ctx, instance); err != nil {
 				return ctrl.Result{}, errorUtil.Wrapf(err, "failed to update instance %s in namespace %s", instance.Name, instance.Namespace)
@@ -142,6 +175,31 @@ func (r *RedisReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error) {
 
 		// handle deletion of redis and remove any finalizers added
 		if instance.GetDeletionTimestamp() != nil {
+			// keep the cloud resource of a protected redis until its deletion protection is unset
+			if protectedMsg := providers.CheckDeletionProtection(providers.RedisResourceType, &instance.Spec); protectedMsg != croType.StatusEmpty {
+				r.logger.Warn(protectedMsg)
+				if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhasePaused, protectedMsg); err != nil {
+					return ctrl.Result{}, err
+				}
+				return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+			}
+
+			// pause the deletion if too many resources were deleted recently, e.g. by a namespace or gitops mistake
+			guardMsg, err := providers.ReconcileDeletionGuard(ctx, r.Client, providers.RedisResourceType, instance, &instance.Status)
+			if err != nil {
+				if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, guardMsg.WrapError(err)); updateErr != nil {
+					return ctrl.Result{}, updateErr
+				}
+				return ctrl.Result{}, err
+			}
+			if guardMsg != croType.StatusEmpty {
+				r.logger.Warn(guardMsg)
+				if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhasePaused, guardMsg); err != nil {
+					return ctrl.Result{}, err
+				}
+				return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+			}
+
 			msg, err := p.DeleteRedis(ctx, instance)
 			if err != nil {
 				if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, msg.WrapError(err)); updateErr != nil {
@@ -154,6 +212,28 @@ func (r *RedisReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error) {
 			if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseDeleteInProgress, msg); err != nil {
 				return ctrl.Result{}, err
 			}
+			return ctrl.Result{Requeue: true, RequeueAfter: providers.NextRequeue(p.GetName(), providers.RedisResourceType, request.NamespacedName, croType.PhaseDeleteInProgress, p.GetReconcileTime(instance))}, nil
+		}
+
+		// abort the creation of the redis if it was cancelled, removing what was created so far
+		if providers.IsProvisioningCancelled(instance, &instance.Status) {
+			cancelled, msg, err := providers.ReconcileCancelledProvisioning(instance, &instance.Status, func() (croType.StatusMessage, error) {
+				return p.DeleteRedis(ctx, instance)
+			})
+			if err != nil {
+				if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, msg.WrapError(err)); updateErr != nil {
+					return ctrl.Result{}, updateErr
+				}
+				return ctrl.Result{}, errorUtil.Wrapf(err, "failed to cancel redis provisioning")
+			}
+			phase := croType.PhaseDeleteInProgress
+			if cancelled {
+				phase = croType.PhasePaused
+			}
+			r.logger.Info(msg)
+			if err = resources.UpdatePhase(ctx, r.Client, instance, phase, msg); err != nil {
+				return ctrl.Result{}, err
+			}
 			return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
 		}
 
@@ -166,6 +246,70 @@ func (r *RedisReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error) {
 			return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
 		}
 
+		// fail clearly if the tier of the redis was removed from the strategy config map
+		tierMsg, err := tiers.ReconcileTierCondition(ctx, r.Client, instance, providers.RedisResourceType, strategyToUse, &instance.Spec, &instance.Status)
+		if err != nil {
+			if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, tierMsg.WrapError(err)); updateErr != nil {
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{}, err
+		}
+		if tierMsg != croType.StatusEmpty {
+			r.logger.Warn(tierMsg)
+			if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, tierMsg); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+		}
+
+		// fail clearly until the namespace the redis is requested on behalf of allows it with a resource grant
+		grantMsg, err := providers.ReconcileGrant(ctx, r.Client, instance, "Redis", &instance.Spec, &instance.Status)
+		if err != nil {
+			if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, grantMsg.WrapError(err)); updateErr != nil {
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{}, err
+		}
+		if grantMsg != croType.StatusEmpty {
+			r.logger.Warn(grantMsg)
+			if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, grantMsg); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+		}
+
+		// wait for the resources this redis depends on before provisioning it
+		depMsg, err := providers.ReconcileDependencies(ctx, r.Client, instance, &instance.Spec, &instance.Status)
+		if err != nil {
+			if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, depMsg.WrapError(err)); updateErr != nil {
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{}, err
+		}
+		if depMsg != croType.StatusEmpty {
+			r.logger.Info(depMsg)
+			if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseInProgress, depMsg); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+		}
+
+		// wait for a provisioning slot of the provider before creating the redis
+		provMsg, err := providers.ReconcileProvisioning(ctx, r.Client, p.GetName(), instance, &instance.Status)
+		if err != nil {
+			if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, provMsg.WrapError(err)); updateErr != nil {
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{}, err
+		}
+		if provMsg != croType.StatusEmpty {
+			r.logger.Info(provMsg)
+			if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseInProgress, provMsg); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+		}
+
 		// handle creation of redis and apply any finalizers to instance required for deletion
 		redis, msg, err := p.CreateRedis(ctx, instance)
 		if err != nil {
@@ -178,27 +322,102 @@ func (r *RedisReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error) {
 		if redis == nil {
 			instance.Status.SecretRef = &croType.SecretRef{}
 			r.logger.Info("waiting for redis cluster to become available")
+			// the cloud resource of a hibernated redis is stopped, it isn't ready until it's woken up
+			if providers.StopRequested(&instance.Spec) && providers.IsStopped(&instance.Status) {
+				if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhasePaused, msg); err != nil {
+					return ctrl.Result{}, err
+				}
+				return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+			}
 			if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseInProgress, msg); err != nil {
 				return ctrl.Result{}, err
 			}
-			return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+			return ctrl.Result{Requeue: true, RequeueAfter: providers.NextRequeue(p.GetName(), providers.RedisResourceType, request.NamespacedName, croType.PhaseInProgress, p.GetReconcileTime(instance))}, nil
+		}
+		// the cloud resource is ready, polls of it start from the minimum interval of the provider again
+		providers.ResetRequeue(providers.RedisResourceType, request.NamespacedName)
+
+		// the resource is only complete once a connection can be opened to it, a complete resource which can't be
+		// connected to is degraded until a later check succeeds
+		if probe, ok := providers.ConnectivityProbe(redis.DeploymentDetails); ok {
+			// export the latency and availability of the resource seen by its connectivity checks
+			probe = probe.Observed(func(duration time.Duration, err error) {
+				metrics.ObserveProbe(string(providers.RedisResourceType), request.NamespacedName, p.GetName(), duration, err == nil)
+			})
+			if probeMsg, connected := resources.CheckConnectivity(ctx, instance, &instance.Status, probe); !connected {
+				r.logger.Warn(probeMsg)
+				if instance.Status.Phase != croType.PhaseComplete {
+					if err = resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseInProgress, probeMsg); err != nil {
+						return ctrl.Result{}, err
+					}
+					return ctrl.Result{Requeue: true, RequeueAfter: providers.NextRequeue(p.GetName(), providers.RedisResourceType, request.NamespacedName, croType.PhaseInProgress, p.GetReconcileTime(instance))}, nil
+				}
+			}
 		}
 
-		// create the secret with the redis cluster connection details
-		if err := r.resourceProvider.ReconcileResultSecret(ctx, instance, redis.DeploymentDetails.Data()); err != nil {
+		// create the secret with the redis cluster connection details, with the outputs configured by the tier
+		secretData, secretMsg, err := tiers.BuildSecretData(ctx, r.Client, instance.Namespace, providers.RedisResourceType, strategyToUse, instance.Spec.Tier, redis.DeploymentDetails.Data())
+		if err != nil {
+			if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, secretMsg.WrapError(err)); updateErr != nil {
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{}, err
+		}
+		if err := r.resourceProvider.ReconcileResultSecret(ctx, instance, secretData); err != nil {
 			return ctrl.Result{}, errorUtil.Wrap(err, "failed to reconcile secret")
 		}
 
+		// maintain the alerts of the redis configured by its tier, the alerts are built on the metrics exposed for aws
+		if strategyToUse == providers.AWSDeploymentStrategy {
+			rule, alertMsg, err := tiers.BuildAlertRule(ctx, r.Client, instance.Namespace, providers.RedisResourceType, strategyToUse, instance.Spec.Tier, instance.Name)
+			if err != nil {
+				if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, alertMsg.WrapError(err)); updateErr != nil {
+					return ctrl.Result{}, updateErr
+				}
+				return ctrl.Result{}, err
+			}
+			if err := r.resourceProvider.ReconcileAlertRule(ctx, instance, providers.AlertRuleName(providers.RedisResourceType, instance.Name), rule); err != nil {
+				return ctrl.Result{}, errorUtil.Wrap(err, "failed to reconcile alerts")
+			}
+		}
+
+		// take snapshots on the schedule, only the aws provider supports snapshots
+		requeueAfter := p.GetReconcileTime(instance)
+		if instance.Spec.SnapshotSchedule != nil && strategyToUse == providers.AWSDeploymentStrategy {
+			nextSnapshotIn, snapshotMsg, err := providers.ReconcileRedisSnapshotSchedule(ctx, r.Client, instance, time.Now())
+			if err != nil {
+				if updateErr := resources.UpdatePhase(ctx, r.Client, instance, croType.PhaseFailed, snapshotMsg.WrapError(err)); updateErr != nil {
+					return ctrl.Result{}, updateErr
+				}
+				return ctrl.Result{}, err
+			}
+			if snapshotMsg != "" {
+				r.logger.Info(snapshotMsg)
+			}
+			if nextSnapshotIn > 0 && nextSnapshotIn < requeueAfter {
+				requeueAfter = nextSnapshotIn
+			}
+		}
+
 		// update the redis custom resource
+		if providers.CompleteProvisioning(instance, &instance.Status) {
+			metrics.ObserveProvisioningDuration(string(providers.RedisResourceType), p.GetName(), instance.Spec.Tier, instance.Status.Provisioning)
+		}
+		resources.RecordPhaseTransition(&instance.Status, instance.Status.Phase, croType.PhaseComplete, msg)
 		instance.Status.Phase = croType.PhaseComplete
 		instance.Status.Message = msg
 		instance.Status.SecretRef = instance.Spec.SecretRef
 		instance.Status.Strategy = strategyToUse
 		instance.Status.Provider = p.GetName()
+		providers.SetCostStatus(&instance.Status, redis.Cost)
+		resources.SetPhaseConditions(instance, &instance.Status)
 		if err = r.Client.Status().Update(ctx, instance); err != nil {
 			return ctrl.Result{}, errorUtil.Wrapf(err, "failed to update instance %s in namespace %s", instance.Name, instance.Namespace)
 		}
-		return ctrl.Result{Requeue: true, RequeueAfter: p.GetReconcileTime(instance)}, nil
+		if redis.Cost != nil {
+			resources.SetCostMetric(resources.DefaultRedisCostMetricName, instance.Namespace, instance.Name, instance.Spec.Tier, string(redis.Cost.Class), redis.Cost.MonthlyCost)
+		}
+		return ctrl.Result{Requeue: true, RequeueAfter: requeueAfter}, nil
 	}
 
 	// unsupported strategy
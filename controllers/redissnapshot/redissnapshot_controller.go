@@ -32,6 +32,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -48,6 +49,7 @@ const (
 type RedisSnapshotReconciler struct {
 	k8sclient.Client
 	scheme        *runtime.Scheme
+	recorder      record.EventRecorder
 	logger        *logrus.Entry
 	provider      providers.RedisSnapshotProvider
 	ConfigManager croAws.ConfigManager
@@ -63,6 +65,8 @@ func New(mgr manager.Manager) (*RedisSnapshotReconciler, error) {
 	if err != nil {
 		return nil, err
 	}
+	// config maps and secrets are read from the shared informer cache of the manager instead of the api server
+	client = resources.NewCachedClient(client, mgr.GetCache())
 	logger := logrus.WithFields(logrus.Fields{"controller": "controller_redis_snapshot"})
 	redisSnapshotProvider, err := croAws.NewAWSRedisSnapshotProvider(client, logger)
 	if err != nil {
@@ -71,6 +75,7 @@ func New(mgr manager.Manager) (*RedisSnapshotReconciler, error) {
 	return &RedisSnapshotReconciler{
 		Client:        client,
 		scheme:        mgr.GetScheme(),
+		recorder:      mgr.GetEventRecorderFor(resources.EventSource),
 		logger:        logger,
 		provider:      redisSnapshotProvider,
 		ConfigManager: croAws.NewDefaultConfigMapConfigManager(mgr.GetClient()),
@@ -147,6 +152,14 @@ func (r *RedisSnapshotReconciler) Reconcile(request ctrl.Request) (ctrl.Result,
 
 	// check status, if complete return
 	if instance.Status.Phase == croType.PhaseComplete {
+		// delete the snapshot once its retention period has passed, the finalizer removes it from aws
+		if providers.IsSnapshotExpired(instance.Status, time.Now()) {
+			r.logger.Infof("retention period of snapshot %s has passed, deleting", instance.Name)
+			if err := r.Client.Delete(ctx, instance); err != nil && !errors.IsNotFound(err) {
+				return ctrl.Result{}, errorUtil.Wrapf(err, "failed to delete expired redis snapshot %s", instance.Name)
+			}
+			return ctrl.Result{Requeue: true, RequeueAfter: r.provider.GetReconcileTime(instance)}, nil
+		}
 		r.logger.Infof("skipping creation of snapshot for %s as phase is complete", instance.Name)
 		return ctrl.Result{Requeue: true, RequeueAfter: r.provider.GetReconcileTime(instance)}, nil
 	}
@@ -156,6 +169,7 @@ func (r *RedisSnapshotReconciler) Reconcile(request ctrl.Request) (ctrl.Result,
 
 	// error trying to create snapshot
 	if err != nil {
+		resources.RecordAWSAPIError(r.recorder, instance, err)
 		if updateErr := resources.UpdateSnapshotPhase(ctx, r.Client, instance, croType.PhaseFailed, msg); updateErr != nil {
 			return ctrl.Result{}, updateErr
 		}
@@ -174,6 +188,7 @@ func (r *RedisSnapshotReconciler) Reconcile(request ctrl.Request) (ctrl.Result,
 	if updateErr := resources.UpdateSnapshotPhase(ctx, r.Client, instance, croType.PhaseComplete, msg); updateErr != nil {
 		return ctrl.Result{}, updateErr
 	}
+	r.recorder.Eventf(instance, corev1.EventTypeNormal, resources.SnapshotTakenEventReason, "snapshot of redis %s taken", instance.Spec.ResourceName)
 	return ctrl.Result{Requeue: true, RequeueAfter: r.provider.GetReconcileTime(instance)}, nil
 }
 
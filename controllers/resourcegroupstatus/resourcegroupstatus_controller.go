@@ -0,0 +1,286 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcegroupstatus
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	integreatlyv1alpha1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// groupKinds are the kinds of the resources of a group with no kinds set
+var groupKinds = []integreatlyv1alpha1.ResourceGroupKind{"Postgres", "Redis", "BlobStorage", "Queue"}
+
+// ResourceGroupStatusReconciler reconciles a ResourceGroupStatus object
+type ResourceGroupStatusReconciler struct {
+	k8sclient.Client
+	scheme *runtime.Scheme
+	logger *logrus.Entry
+}
+
+func New(mgr manager.Manager) (*ResourceGroupStatusReconciler, error) {
+	return &ResourceGroupStatusReconciler{
+		Client: mgr.GetClient(),
+		scheme: mgr.GetScheme(),
+		logger: logrus.WithFields(logrus.Fields{"controller": "controller_resourcegroupstatus"}),
+	}, nil
+}
+
+func (r *ResourceGroupStatusReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&integreatlyv1alpha1.ResourceGroupStatus{}).
+		Watches(&source.Kind{Type: &integreatlyv1alpha1.Postgres{}}, r.enqueueGroups("Postgres")).
+		Watches(&source.Kind{Type: &integreatlyv1alpha1.Redis{}}, r.enqueueGroups("Redis")).
+		Watches(&source.Kind{Type: &integreatlyv1alpha1.BlobStorage{}}, r.enqueueGroups("BlobStorage")).
+		Watches(&source.Kind{Type: &integreatlyv1alpha1.Queue{}}, r.enqueueGroups("Queue")).
+		Complete(r)
+}
+
+func (r *ResourceGroupStatusReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error) {
+	r.logger.Info("reconciling resource group status")
+	ctx := context.TODO()
+
+	instance := &integreatlyv1alpha1.ResourceGroupStatus{}
+	if err := r.Client.Get(ctx, request.NamespacedName, instance); err != nil {
+		if k8serr.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if instance.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	status := instance.Status.DeepCopy()
+	statuses, err := r.listGroupResources(ctx, instance)
+	if err != nil {
+		status.Phase = croType.PhaseFailed
+		status.Message = croType.StatusMessage("failed to list resources of group").WrapError(resources.RedactError(err))
+		status.Resources = nil
+		status.Ready = ""
+	} else {
+		status.Resources = statuses
+		status.Phase, status.Message, status.Ready = aggregateStatus(statuses)
+	}
+	setReadyCondition(instance, status)
+
+	// the group is enqueued on every change of its resources, so its status is only written when it changed
+	if !equality.Semantic.DeepEqual(status, &instance.Status) {
+		instance.Status = *status
+		if err := r.Client.Status().Update(ctx, instance); err != nil {
+			return ctrl.Result{}, errorUtil.Wrapf(err, "failed to update resource group status %s", instance.Name)
+		}
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// listGroupResources returns the status of every resource of the group, sorted by kind and name
+func (r *ResourceGroupStatusReconciler) listGroupResources(ctx context.Context, group *integreatlyv1alpha1.ResourceGroupStatus) ([]integreatlyv1alpha1.ResourceGroupMemberStatus, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&group.Spec.Selector)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to parse selector")
+	}
+	opts := []k8sclient.ListOption{k8sclient.InNamespace(group.Namespace), k8sclient.MatchingLabelsSelector{Selector: selector}}
+
+	var statuses []integreatlyv1alpha1.ResourceGroupMemberStatus
+	for _, kind := range kindsOf(group) {
+		list, err := buildList(kind)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.Client.List(ctx, list, opts...); err != nil {
+			return nil, errorUtil.Wrapf(err, "failed to list %s", kind)
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return nil, errorUtil.Wrapf(err, "failed to extract %s", kind)
+		}
+		for _, item := range items {
+			accessor, err := meta.Accessor(item)
+			if err != nil {
+				return nil, errorUtil.Wrapf(err, "failed to read %s", kind)
+			}
+			rts := resourceStatus(item)
+			statuses = append(statuses, integreatlyv1alpha1.ResourceGroupMemberStatus{
+				Kind:    string(kind),
+				Name:    accessor.GetName(),
+				Phase:   rts.Phase,
+				Message: rts.Message,
+			})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Kind != statuses[j].Kind {
+			return statuses[i].Kind < statuses[j].Kind
+		}
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses, nil
+}
+
+// enqueueGroups returns an event handler enqueueing the groups in the namespace of a changed resource of the kind whose
+// selector matches its labels
+func (r *ResourceGroupStatusReconciler) enqueueGroups(kind integreatlyv1alpha1.ResourceGroupKind) handler.EventHandler {
+	return &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(func(o handler.MapObject) []reconcile.Request {
+		groups := &integreatlyv1alpha1.ResourceGroupStatusList{}
+		if err := r.Client.List(context.TODO(), groups, k8sclient.InNamespace(o.Meta.GetNamespace())); err != nil {
+			r.logger.Errorf("failed to list resource group statuses of %s %s: %v", kind, o.Meta.GetName(), err)
+			return nil
+		}
+		var requests []reconcile.Request
+		for _, group := range groups.Items {
+			if matchesGroup(&group, kind, o.Meta.GetLabels()) {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: group.Name, Namespace: group.Namespace}})
+			}
+		}
+		return requests
+	})}
+}
+
+// matchesGroup returns true if a resource of the kind with the labels is part of the group. groups with an invalid
+// selector are always enqueued so the error is reported in their status
+func matchesGroup(group *integreatlyv1alpha1.ResourceGroupStatus, kind integreatlyv1alpha1.ResourceGroupKind, resourceLabels map[string]string) bool {
+	included := false
+	for _, k := range kindsOf(group) {
+		if k == kind {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+	selector, err := metav1.LabelSelectorAsSelector(&group.Spec.Selector)
+	if err != nil {
+		return true
+	}
+	return selector.Matches(labels.Set(resourceLabels))
+}
+
+// aggregateStatus returns the phase, message and ready count of the group, it's failed if any resource failed and
+// complete once every resource is complete. a group without resources is in progress, it's waiting for them to be
+// created
+func aggregateStatus(statuses []integreatlyv1alpha1.ResourceGroupMemberStatus) (croType.StatusPhase, croType.StatusMessage, string) {
+	if len(statuses) == 0 {
+		return croType.PhaseInProgress, "no resources match the selector", "0/0"
+	}
+	complete := 0
+	var waiting, failed *integreatlyv1alpha1.ResourceGroupMemberStatus
+	for i, status := range statuses {
+		switch status.Phase {
+		case croType.PhaseComplete:
+			complete++
+		case croType.PhaseFailed:
+			if failed == nil {
+				failed = &statuses[i]
+			}
+		default:
+			if waiting == nil {
+				waiting = &statuses[i]
+			}
+		}
+	}
+	ready := fmt.Sprintf("%d/%d", complete, len(statuses))
+	if failed != nil {
+		return croType.PhaseFailed, croType.StatusMessage(fmt.Sprintf("%s %s failed: %s", failed.Kind, failed.Name, failed.Message)), ready
+	}
+	if waiting != nil {
+		return croType.PhaseInProgress, croType.StatusMessage(fmt.Sprintf("waiting for %s %s to be complete", waiting.Kind, waiting.Name)), ready
+	}
+	return croType.PhaseComplete, "all resources are complete", ready
+}
+
+// setReadyCondition sets the ready condition of the group from its phase, so installers can wait for the group with
+// kubectl wait --for=condition=Ready
+func setReadyCondition(group *integreatlyv1alpha1.ResourceGroupStatus, status *integreatlyv1alpha1.ResourceGroupStatusStatus) {
+	reason := "InProgress"
+	switch status.Phase {
+	case croType.PhaseComplete:
+		reason = "Complete"
+	case croType.PhaseFailed:
+		reason = "Failed"
+	}
+	cond := metav1.Condition{
+		Type:               resources.ReadyCondition,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: group.Generation,
+		Reason:             reason,
+		Message:            string(status.Message),
+	}
+	if status.Phase == croType.PhaseComplete {
+		cond.Status = metav1.ConditionTrue
+	}
+	meta.SetStatusCondition(&status.Conditions, cond)
+}
+
+func kindsOf(group *integreatlyv1alpha1.ResourceGroupStatus) []integreatlyv1alpha1.ResourceGroupKind {
+	if len(group.Spec.Kinds) == 0 {
+		return groupKinds
+	}
+	return group.Spec.Kinds
+}
+
+// buildList returns an empty list of the resources of the kind
+func buildList(kind integreatlyv1alpha1.ResourceGroupKind) (runtime.Object, error) {
+	switch kind {
+	case "Postgres":
+		return &integreatlyv1alpha1.PostgresList{}, nil
+	case "Redis":
+		return &integreatlyv1alpha1.RedisList{}, nil
+	case "BlobStorage":
+		return &integreatlyv1alpha1.BlobStorageList{}, nil
+	case "Queue":
+		return &integreatlyv1alpha1.QueueList{}, nil
+	}
+	return nil, errorUtil.Errorf("unsupported resource kind %s", kind)
+}
+
+func resourceStatus(resource runtime.Object) croType.ResourceTypeStatus {
+	switch r := resource.(type) {
+	case *integreatlyv1alpha1.Postgres:
+		return r.Status
+	case *integreatlyv1alpha1.Redis:
+		return r.Status
+	case *integreatlyv1alpha1.BlobStorage:
+		return r.Status
+	case *integreatlyv1alpha1.Queue:
+		return r.Status
+	}
+	return croType.ResourceTypeStatus{}
+}
@@ -0,0 +1,188 @@
+package resourcegroupstatus
+
+import (
+	"context"
+	"testing"
+
+	integreatlyv1alpha1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+)
+
+const (
+	testName      = "test"
+	testNamespace = "test-ns"
+	testProduct   = "test-product"
+)
+
+func buildTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := integreatlyv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	return scheme
+}
+
+func buildTestGroup(kinds ...integreatlyv1alpha1.ResourceGroupKind) *integreatlyv1alpha1.ResourceGroupStatus {
+	return &integreatlyv1alpha1.ResourceGroupStatus{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testName,
+			Namespace: testNamespace,
+		},
+		Spec: integreatlyv1alpha1.ResourceGroupStatusSpec{
+			Selector: metav1.LabelSelector{MatchLabels: map[string]string{"productName": testProduct}},
+			Kinds:    kinds,
+		},
+	}
+}
+
+func buildTestOm(name, product string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: testNamespace,
+		Labels:    map[string]string{"productName": product},
+	}
+}
+
+func buildTestPostgres(name, product string, phase croType.StatusPhase) *integreatlyv1alpha1.Postgres {
+	return &integreatlyv1alpha1.Postgres{
+		ObjectMeta: buildTestOm(name, product),
+		Status:     croType.ResourceTypeStatus{Phase: phase, Message: "test message"},
+	}
+}
+
+func buildTestRedis(name, product string, phase croType.StatusPhase) *integreatlyv1alpha1.Redis {
+	return &integreatlyv1alpha1.Redis{
+		ObjectMeta: buildTestOm(name, product),
+		Status:     croType.ResourceTypeStatus{Phase: phase, Message: "test message"},
+	}
+}
+
+func TestResourceGroupStatusReconciler_Reconcile(t *testing.T) {
+	scheme := buildTestScheme(t)
+	tests := []struct {
+		name        string
+		objs        []runtime.Object
+		wantPhase   croType.StatusPhase
+		wantReady   string
+		wantMessage croType.StatusMessage
+	}{
+		{
+			name:        "test in progress without matching resources",
+			objs:        []runtime.Object{buildTestGroup(), buildTestPostgres("other", "other-product", croType.PhaseComplete)},
+			wantPhase:   croType.PhaseInProgress,
+			wantReady:   "0/0",
+			wantMessage: "no resources match the selector",
+		},
+		{
+			name: "test in progress while a resource isn't complete",
+			objs: []runtime.Object{
+				buildTestGroup(),
+				buildTestPostgres("db", testProduct, croType.PhaseComplete),
+				buildTestRedis("cache", testProduct, croType.PhaseInProgress),
+			},
+			wantPhase:   croType.PhaseInProgress,
+			wantReady:   "1/2",
+			wantMessage: "waiting for Redis cache to be complete",
+		},
+		{
+			name: "test complete once every matching resource is complete",
+			objs: []runtime.Object{
+				buildTestGroup(),
+				buildTestPostgres("db", testProduct, croType.PhaseComplete),
+				buildTestRedis("cache", testProduct, croType.PhaseComplete),
+				buildTestPostgres("other", "other-product", croType.PhaseFailed),
+			},
+			wantPhase:   croType.PhaseComplete,
+			wantReady:   "2/2",
+			wantMessage: "all resources are complete",
+		},
+		{
+			name: "test failed when a resource failed",
+			objs: []runtime.Object{
+				buildTestGroup(),
+				buildTestPostgres("db", testProduct, croType.PhaseFailed),
+				buildTestRedis("cache", testProduct, croType.PhaseInProgress),
+			},
+			wantPhase:   croType.PhaseFailed,
+			wantReady:   "0/2",
+			wantMessage: "Postgres db failed: test message",
+		},
+		{
+			name: "test only resources of the kinds of the group are included",
+			objs: []runtime.Object{
+				buildTestGroup("Postgres"),
+				buildTestPostgres("db", testProduct, croType.PhaseComplete),
+				buildTestRedis("cache", testProduct, croType.PhaseFailed),
+			},
+			wantPhase:   croType.PhaseComplete,
+			wantReady:   "1/1",
+			wantMessage: "all resources are complete",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewFakeClientWithScheme(scheme, tt.objs...)
+			r := &ResourceGroupStatusReconciler{
+				Client: client,
+				scheme: scheme,
+				logger: logrus.NewEntry(logrus.StandardLogger()),
+			}
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: testName, Namespace: testNamespace}}
+			if _, err := r.Reconcile(req); err != nil {
+				t.Fatalf("Reconcile() unexpected error = %v", err)
+			}
+
+			group := &integreatlyv1alpha1.ResourceGroupStatus{}
+			if err := client.Get(context.TODO(), req.NamespacedName, group); err != nil {
+				t.Fatalf("failed to get resource group status: %v", err)
+			}
+			if group.Status.Phase != tt.wantPhase {
+				t.Errorf("Reconcile() phase = %v, want %v, message %s", group.Status.Phase, tt.wantPhase, group.Status.Message)
+			}
+			if group.Status.Ready != tt.wantReady {
+				t.Errorf("Reconcile() ready = %v, want %v", group.Status.Ready, tt.wantReady)
+			}
+			if group.Status.Message != tt.wantMessage {
+				t.Errorf("Reconcile() message = %v, want %v", group.Status.Message, tt.wantMessage)
+			}
+			wantReady := tt.wantPhase == croType.PhaseComplete
+			if got := meta.IsStatusConditionTrue(group.Status.Conditions, resources.ReadyCondition); got != wantReady {
+				t.Errorf("Reconcile() ready condition = %v, want %v", got, wantReady)
+			}
+		})
+	}
+}
+
+func TestResourceGroupStatusReconciler_enqueueGroups(t *testing.T) {
+	scheme := buildTestScheme(t)
+	client := fake.NewFakeClientWithScheme(scheme, buildTestGroup("Postgres"))
+	r := &ResourceGroupStatusReconciler{
+		Client: client,
+		scheme: scheme,
+		logger: logrus.NewEntry(logrus.StandardLogger()),
+	}
+
+	pg := buildTestPostgres("db", testProduct, croType.PhaseComplete)
+	mapper := r.enqueueGroups("Postgres").(*handler.EnqueueRequestsFromMapFunc).ToRequests
+	if got := mapper.Map(handler.MapObject{Meta: pg, Object: pg}); len(got) != 1 || got[0].Name != testName {
+		t.Errorf("Map() = %v, want the group enqueued", got)
+	}
+	other := buildTestPostgres("other", "other-product", croType.PhaseComplete)
+	if got := mapper.Map(handler.MapObject{Meta: other, Object: other}); len(got) != 0 {
+		t.Errorf("Map() = %v, want no group enqueued for resources not matching the selector", got)
+	}
+	redis := buildTestRedis("cache", testProduct, croType.PhaseComplete)
+	mapper = r.enqueueGroups("Redis").(*handler.EnqueueRequestsFromMapFunc).ToRequests
+	if got := mapper.Map(handler.MapObject{Meta: redis, Object: redis}); len(got) != 0 {
+		t.Errorf("Map() = %v, want no group enqueued for kinds not in the group", got)
+	}
+}
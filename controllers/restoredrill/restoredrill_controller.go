@@ -0,0 +1,190 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restoredrill
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	defaultSchedule           = "0 3 1 * *"
+	defaultReportHistoryLimit = 12
+
+	// labelRestoreDrill is set on the reports created by a restore drill, to the name of the drill
+	labelRestoreDrill = "integreatly.org/restore-drill"
+	reportTimeFormat  = "20060102-1504"
+)
+
+// RestoreDrillReconciler reconciles a RestoreDrill object
+type RestoreDrillReconciler struct {
+	k8sclient.Client
+	scheme *runtime.Scheme
+	logger *logrus.Entry
+}
+
+func New(mgr manager.Manager) (*RestoreDrillReconciler, error) {
+	return &RestoreDrillReconciler{
+		Client: mgr.GetClient(),
+		scheme: mgr.GetScheme(),
+		logger: logrus.WithFields(logrus.Fields{"controller": "controller_restoredrill"}),
+	}, nil
+}
+
+func (r *RestoreDrillReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&integreatlyv1alpha1.RestoreDrill{}).
+		Complete(r)
+}
+
+func (r *RestoreDrillReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error) {
+	r.logger.Info("reconciling restore drill")
+	ctx := context.TODO()
+
+	instance := &integreatlyv1alpha1.RestoreDrill{}
+	if err := r.Client.Get(ctx, request.NamespacedName, instance); err != nil {
+		if k8serr.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if instance.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	if len(instance.Spec.ResourceNames) == 0 {
+		return r.fail(ctx, instance, "resourceNames must contain at least one postgres resource")
+	}
+	scheduleExpr := instance.Spec.Schedule
+	if scheduleExpr == "" {
+		scheduleExpr = defaultSchedule
+	}
+	schedule, err := resources.ParseCronSchedule(scheduleExpr)
+	if err != nil {
+		return r.fail(ctx, instance, errorUtil.Wrap(err, "failed to parse restore drill schedule").Error())
+	}
+
+	now := time.Now().UTC()
+	last := instance.CreationTimestamp.Time
+	if instance.Status.LastScheduleTime != nil {
+		last = instance.Status.LastScheduleTime.Time
+	}
+	if due := schedule.Next(last); !due.After(now) {
+		report, err := r.createReport(ctx, instance, due)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		scheduled := metav1.NewTime(now)
+		instance.Status.LastScheduleTime = &scheduled
+		instance.Status.LastReportName = report.Name
+		r.logger.Infof("restore drill %s started, results are written to report %s", instance.Name, report.Name)
+	}
+
+	if err := r.pruneReports(ctx, instance); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	next := schedule.Next(now)
+	instance.Status.Phase = croType.PhaseComplete
+	instance.Status.Message = croType.StatusMessage(fmt.Sprintf("next restore drill scheduled for %s", next.Format(time.RFC3339)))
+	if err := r.Client.Status().Update(ctx, instance); err != nil {
+		return ctrl.Result{}, errorUtil.Wrap(err, "failed to update restore drill status")
+	}
+	return ctrl.Result{Requeue: true, RequeueAfter: next.Sub(now)}, nil
+}
+
+// createReport creates the report the drill scheduled for the due time runs in. Reports aren't owned by the drill, so
+// the evidence of past drills is kept if the drill is deleted
+func (r *RestoreDrillReconciler) createReport(ctx context.Context, rd *integreatlyv1alpha1.RestoreDrill, due time.Time) (*integreatlyv1alpha1.RestoreDrillReport, error) {
+	report := &integreatlyv1alpha1.RestoreDrillReport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", rd.Name, due.UTC().Format(reportTimeFormat)),
+			Namespace: rd.Namespace,
+			Labels: map[string]string{
+				labelRestoreDrill: rd.Name,
+			},
+		},
+		Spec: integreatlyv1alpha1.RestoreDrillReportSpec{
+			DrillName:        rd.Name,
+			ResourceNames:    rd.Spec.ResourceNames,
+			ChecksumQuery:    rd.Spec.ChecksumQuery,
+			ExpectedChecksum: rd.Spec.ExpectedChecksum,
+			TimeoutMinutes:   rd.Spec.TimeoutMinutes,
+			Image:            rd.Spec.Image,
+		},
+	}
+	if err := r.Client.Create(ctx, report); err != nil && !k8serr.IsAlreadyExists(err) {
+		return nil, errorUtil.Wrapf(err, "failed to create restore drill report %s", report.Name)
+	}
+	return report, nil
+}
+
+// pruneReports deletes the oldest completed reports of the drill beyond its report history limit, reports of drills
+// that are still running are never deleted
+func (r *RestoreDrillReconciler) pruneReports(ctx context.Context, rd *integreatlyv1alpha1.RestoreDrill) error {
+	limit := rd.Spec.ReportHistoryLimit
+	if limit <= 0 {
+		limit = defaultReportHistoryLimit
+	}
+	reports := &integreatlyv1alpha1.RestoreDrillReportList{}
+	if err := r.Client.List(ctx, reports, k8sclient.InNamespace(rd.Namespace), k8sclient.MatchingLabels{labelRestoreDrill: rd.Name}); err != nil {
+		return errorUtil.Wrapf(err, "failed to list reports of restore drill %s", rd.Name)
+	}
+	var completed []integreatlyv1alpha1.RestoreDrillReport
+	for _, report := range reports.Items {
+		if report.Status.Phase == croType.PhaseComplete {
+			completed = append(completed, report)
+		}
+	}
+	if len(completed) <= limit {
+		return nil
+	}
+	// report names end with the time they were scheduled for, so they sort oldest first
+	sort.Slice(completed, func(i, j int) bool {
+		return completed[i].Name < completed[j].Name
+	})
+	for i := range completed[:len(completed)-limit] {
+		if err := r.Client.Delete(ctx, &completed[i]); err != nil && !k8serr.IsNotFound(err) {
+			return errorUtil.Wrapf(err, "failed to delete restore drill report %s", completed[i].Name)
+		}
+	}
+	return nil
+}
+
+func (r *RestoreDrillReconciler) fail(ctx context.Context, rd *integreatlyv1alpha1.RestoreDrill, msg string) (ctrl.Result, error) {
+	rd.Status.Phase = croType.PhaseFailed
+	rd.Status.Message = croType.StatusMessage(msg)
+	if err := r.Client.Status().Update(ctx, rd); err != nil {
+		return ctrl.Result{}, errorUtil.Wrap(err, "failed to update restore drill status")
+	}
+	r.logger.Errorf("restore drill %s failed: %s", rd.Name, msg)
+	return ctrl.Result{}, nil
+}
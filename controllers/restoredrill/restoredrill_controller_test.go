@@ -0,0 +1,154 @@
+package restoredrill
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testName      = "test"
+	testNamespace = "test-ns"
+)
+
+func buildTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := integreatlyv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	return scheme
+}
+
+func buildTestRestoreDrill(schedule string, createdAgo time.Duration, lastScheduled *time.Time) *integreatlyv1alpha1.RestoreDrill {
+	rd := &integreatlyv1alpha1.RestoreDrill{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              testName,
+			Namespace:         testNamespace,
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-createdAgo)),
+		},
+		Spec: integreatlyv1alpha1.RestoreDrillSpec{
+			Schedule:           schedule,
+			ResourceNames:      []string{"test-postgres"},
+			ReportHistoryLimit: 2,
+		},
+	}
+	if lastScheduled != nil {
+		last := metav1.NewTime(*lastScheduled)
+		rd.Status.LastScheduleTime = &last
+	}
+	return rd
+}
+
+func buildTestReport(name string, phase croType.StatusPhase) *integreatlyv1alpha1.RestoreDrillReport {
+	return &integreatlyv1alpha1.RestoreDrillReport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+			Labels:    map[string]string{labelRestoreDrill: testName},
+		},
+		Status: integreatlyv1alpha1.RestoreDrillReportStatus{
+			Phase: phase,
+		},
+	}
+}
+
+func TestRestoreDrillReconciler_Reconcile(t *testing.T) {
+	scheme := buildTestScheme(t)
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		objs        []runtime.Object
+		wantPhase   croType.StatusPhase
+		wantReports []string
+		wantCreated bool
+	}{
+		{
+			name:      "test drill fails with an invalid schedule",
+			objs:      []runtime.Object{buildTestRestoreDrill("not a schedule", time.Hour, nil)},
+			wantPhase: croType.PhaseFailed,
+		},
+		{
+			name:        "test report is created when the drill is due",
+			objs:        []runtime.Object{buildTestRestoreDrill("@hourly", 2*time.Hour, nil)},
+			wantPhase:   croType.PhaseComplete,
+			wantCreated: true,
+		},
+		{
+			name:      "test no report is created before the drill is due",
+			objs:      []runtime.Object{buildTestRestoreDrill("", 2*time.Hour, &now)},
+			wantPhase: croType.PhaseComplete,
+		},
+		{
+			name: "test oldest completed reports beyond the history limit are deleted",
+			objs: []runtime.Object{
+				buildTestRestoreDrill("", 2*time.Hour, &now),
+				buildTestReport(fmt.Sprintf("%s-20200101-0300", testName), croType.PhaseComplete),
+				buildTestReport(fmt.Sprintf("%s-20200201-0300", testName), croType.PhaseComplete),
+				buildTestReport(fmt.Sprintf("%s-20200301-0300", testName), croType.PhaseComplete),
+				buildTestReport(fmt.Sprintf("%s-20200401-0300", testName), croType.PhaseInProgress),
+			},
+			wantPhase: croType.PhaseComplete,
+			wantReports: []string{
+				fmt.Sprintf("%s-20200201-0300", testName),
+				fmt.Sprintf("%s-20200301-0300", testName),
+				fmt.Sprintf("%s-20200401-0300", testName),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewFakeClientWithScheme(scheme, tt.objs...)
+			r := &RestoreDrillReconciler{
+				Client: client,
+				scheme: scheme,
+				logger: logrus.NewEntry(logrus.StandardLogger()),
+			}
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: testName, Namespace: testNamespace}}
+			if _, err := r.Reconcile(req); err != nil {
+				t.Fatalf("Reconcile() unexpected error = %v", err)
+			}
+
+			rd := &integreatlyv1alpha1.RestoreDrill{}
+			if err := client.Get(context.TODO(), req.NamespacedName, rd); err != nil {
+				t.Fatalf("failed to get restore drill: %v", err)
+			}
+			if rd.Status.Phase != tt.wantPhase {
+				t.Errorf("Reconcile() phase = %v, want %v, message %s", rd.Status.Phase, tt.wantPhase, rd.Status.Message)
+			}
+
+			reports := &integreatlyv1alpha1.RestoreDrillReportList{}
+			if err := client.List(context.TODO(), reports, k8sclient.InNamespace(testNamespace)); err != nil {
+				t.Fatalf("failed to list reports: %v", err)
+			}
+			if tt.wantCreated {
+				if len(reports.Items) != 1 || reports.Items[0].Name != rd.Status.LastReportName {
+					t.Fatalf("Reconcile() expected report %s to be created, found %+v", rd.Status.LastReportName, reports.Items)
+				}
+				if reports.Items[0].Spec.DrillName != testName || len(reports.Items[0].OwnerReferences) != 0 {
+					t.Errorf("Reconcile() expected an unowned report of drill %s, got %+v", testName, reports.Items[0].ObjectMeta)
+				}
+				return
+			}
+			if len(reports.Items) != len(tt.wantReports) {
+				t.Fatalf("Reconcile() found %d reports, want %d", len(reports.Items), len(tt.wantReports))
+			}
+			for i, report := range reports.Items {
+				if report.Name != tt.wantReports[i] {
+					t.Errorf("Reconcile() found report %s, want %s", report.Name, tt.wantReports[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,388 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restoredrillreport
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	errorUtil "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	defaultTimeoutMinutes = 180
+	defaultRequeueTime    = time.Second * 30
+)
+
+// RestoreDrillReportReconciler reconciles a RestoreDrillReport object
+type RestoreDrillReportReconciler struct {
+	k8sclient.Client
+	scheme *runtime.Scheme
+	logger *logrus.Entry
+}
+
+func New(mgr manager.Manager) (*RestoreDrillReportReconciler, error) {
+	return &RestoreDrillReportReconciler{
+		Client: mgr.GetClient(),
+		scheme: mgr.GetScheme(),
+		logger: logrus.WithFields(logrus.Fields{"controller": "controller_restoredrillreport"}),
+	}, nil
+}
+
+func (r *RestoreDrillReportReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&integreatlyv1alpha1.RestoreDrillReport{}).
+		Owns(&integreatlyv1alpha1.Postgres{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
+
+func (r *RestoreDrillReportReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error) {
+	r.logger.Info("reconciling restore drill report")
+	ctx := context.TODO()
+
+	instance := &integreatlyv1alpha1.RestoreDrillReport{}
+	if err := r.Client.Get(ctx, request.NamespacedName, instance); err != nil {
+		if k8serr.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// a report records a single drill, the restore drill creates a new report for every scheduled drill
+	if instance.Status.Phase == croType.PhaseComplete || instance.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	if instance.Status.StartTime == nil {
+		if len(instance.Spec.ResourceNames) == 0 {
+			instance.Status.Phase = croType.PhaseFailed
+			instance.Status.Message = "resourceNames must contain at least one postgres resource"
+			if err := r.Client.Status().Update(ctx, instance); err != nil {
+				return ctrl.Result{}, errorUtil.Wrap(err, "failed to update restore drill report status")
+			}
+			return ctrl.Result{}, nil
+		}
+		now := metav1.Now()
+		instance.Status.StartTime = &now
+		instance.Status.Phase = croType.PhaseInProgress
+		instance.Status.Message = "restoring snapshots"
+		instance.Status.Checks = nil
+		for _, name := range instance.Spec.ResourceNames {
+			instance.Status.Checks = append(instance.Status.Checks, integreatlyv1alpha1.RestoreDrillCheck{ResourceName: name})
+		}
+		if err := r.Client.Status().Update(ctx, instance); err != nil {
+			return ctrl.Result{}, errorUtil.Wrap(err, "failed to start restore drill")
+		}
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	timeout := time.Duration(instance.Spec.TimeoutMinutes) * time.Minute
+	if timeout == 0 {
+		timeout = defaultTimeoutMinutes * time.Minute
+	}
+	deadline := instance.Status.StartTime.Add(timeout)
+	timedOut := time.Now().After(deadline)
+
+	done := true
+	for i := range instance.Status.Checks {
+		check := &instance.Status.Checks[i]
+		if check.Phase != croType.PhaseComplete {
+			if err := r.reconcileCheck(ctx, instance, check, deadline, timedOut); err != nil {
+				return ctrl.Result{}, errorUtil.Wrapf(err, "failed to reconcile restore drill of %s", check.ResourceName)
+			}
+		}
+		done = done && check.Phase == croType.PhaseComplete
+	}
+
+	if done {
+		r.complete(instance)
+	}
+	if err := r.Client.Status().Update(ctx, instance); err != nil {
+		return ctrl.Result{}, errorUtil.Wrap(err, "failed to update restore drill report status")
+	}
+	if done {
+		r.logger.Infof("restore drill report %s completed with result %s in %s", instance.Name, instance.Status.Result, instance.Status.Duration.Duration)
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{Requeue: true, RequeueAfter: defaultRequeueTime}, nil
+}
+
+// reconcileCheck restores the latest complete snapshot of the resource to a temporary postgres, validates it with the
+// checksum query once it is available and tears it down
+func (r *RestoreDrillReportReconciler) reconcileCheck(ctx context.Context, report *integreatlyv1alpha1.RestoreDrillReport, check *integreatlyv1alpha1.RestoreDrillCheck, deadline time.Time, timedOut bool) error {
+	if check.Phase == "" {
+		return r.restore(ctx, report, check)
+	}
+
+	restored := &integreatlyv1alpha1.Postgres{}
+	exists := true
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: check.RestoredName, Namespace: report.Namespace}, restored); err != nil {
+		if !k8serr.IsNotFound(err) {
+			return errorUtil.Wrapf(err, "failed to get restored postgres %s", check.RestoredName)
+		}
+		exists = false
+	}
+
+	if check.Phase == croType.PhaseDeleteInProgress {
+		if !exists {
+			check.Phase = croType.PhaseComplete
+			check.Duration = since(report.Status.StartTime)
+			return nil
+		}
+		if timedOut {
+			fail(report, check, fmt.Sprintf("timed out waiting for restored postgres %s to be deleted", check.RestoredName))
+		}
+		return nil
+	}
+
+	switch {
+	case !exists:
+		check.Result = integreatlyv1alpha1.RestoreDrillResultFail
+		check.Message = croType.StatusMessage(fmt.Sprintf("restored postgres %s not found", check.RestoredName))
+	case restored.Status.Phase == croType.PhaseComplete:
+		if check.RestoreDuration == nil {
+			check.RestoreDuration = since(report.Status.StartTime)
+		}
+		validated, err := r.validate(ctx, report, check, restored, deadline)
+		if err != nil {
+			return err
+		}
+		if !validated {
+			if !timedOut {
+				return nil
+			}
+			check.Result = integreatlyv1alpha1.RestoreDrillResultFail
+			check.Message = croType.StatusMessage(fmt.Sprintf("timed out waiting for the checksum query of restored postgres %s", check.RestoredName))
+		}
+	case restored.Status.Phase == croType.PhaseFailed:
+		check.Result = integreatlyv1alpha1.RestoreDrillResultFail
+		check.Message = croType.StatusMessage(fmt.Sprintf("failed to restore snapshot %s to postgres %s: %s", check.SnapshotName, check.RestoredName, restored.Status.Message))
+	case timedOut:
+		check.Result = integreatlyv1alpha1.RestoreDrillResultFail
+		check.Message = croType.StatusMessage(fmt.Sprintf("timed out waiting for snapshot %s to be restored to postgres %s", check.SnapshotName, check.RestoredName))
+	default:
+		return nil
+	}
+
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: jobName(check), Namespace: report.Namespace}}
+	if err := r.Client.Delete(ctx, job, k8sclient.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !k8serr.IsNotFound(err) {
+		return errorUtil.Wrapf(err, "failed to delete checksum job %s", job.Name)
+	}
+	if exists {
+		if err := r.Client.Delete(ctx, restored); err != nil && !k8serr.IsNotFound(err) {
+			return errorUtil.Wrapf(err, "failed to delete restored postgres %s", restored.Name)
+		}
+	}
+	check.Phase = croType.PhaseDeleteInProgress
+	return nil
+}
+
+// restore creates the temporary postgres, restored from the latest complete snapshot of the resource
+func (r *RestoreDrillReportReconciler) restore(ctx context.Context, report *integreatlyv1alpha1.RestoreDrillReport, check *integreatlyv1alpha1.RestoreDrillCheck) error {
+	source := &integreatlyv1alpha1.Postgres{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: check.ResourceName, Namespace: report.Namespace}, source); err != nil {
+		if k8serr.IsNotFound(err) {
+			fail(report, check, fmt.Sprintf("postgres %s not found", check.ResourceName))
+			return nil
+		}
+		return errorUtil.Wrapf(err, "failed to get postgres %s", check.ResourceName)
+	}
+	snapshot, err := r.latestSnapshot(ctx, report.Namespace, check.ResourceName)
+	if err != nil {
+		return err
+	}
+	if snapshot == nil {
+		fail(report, check, fmt.Sprintf("postgres %s has no complete snapshot to restore", check.ResourceName))
+		return nil
+	}
+
+	restored := buildRestoredPostgres(report, source, snapshot)
+	if err := controllerutil.SetControllerReference(report, restored, r.scheme); err != nil {
+		return errorUtil.Wrapf(err, "failed to set owner of restored postgres %s", restored.Name)
+	}
+	if err := r.Client.Create(ctx, restored); err != nil && !k8serr.IsAlreadyExists(err) {
+		return errorUtil.Wrapf(err, "failed to create restored postgres %s", restored.Name)
+	}
+	check.SnapshotName = snapshot.Name
+	check.RestoredName = restored.Name
+	check.Phase = croType.PhaseInProgress
+	check.Message = croType.StatusMessage(fmt.Sprintf("restoring snapshot %s to postgres %s", snapshot.Name, restored.Name))
+	return nil
+}
+
+// latestSnapshot returns the most recently created complete snapshot of the postgres, or nil if it has none
+func (r *RestoreDrillReportReconciler) latestSnapshot(ctx context.Context, namespace, resourceName string) (*integreatlyv1alpha1.PostgresSnapshot, error) {
+	snapshots := &integreatlyv1alpha1.PostgresSnapshotList{}
+	if err := r.Client.List(ctx, snapshots, k8sclient.InNamespace(namespace)); err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to list snapshots of postgres %s", resourceName)
+	}
+	var latest *integreatlyv1alpha1.PostgresSnapshot
+	for i := range snapshots.Items {
+		snapshot := &snapshots.Items[i]
+		if snapshot.Spec.ResourceName != resourceName || snapshot.Status.Phase != croType.PhaseComplete || snapshot.DeletionTimestamp != nil {
+			continue
+		}
+		if latest == nil || latest.CreationTimestamp.Before(&snapshot.CreationTimestamp) {
+			latest = snapshot
+		}
+	}
+	return latest, nil
+}
+
+// validate runs the checksum query against the restored postgres, returning true once the check has a result
+func (r *RestoreDrillReportReconciler) validate(ctx context.Context, report *integreatlyv1alpha1.RestoreDrillReport, check *integreatlyv1alpha1.RestoreDrillCheck, restored *integreatlyv1alpha1.Postgres, deadline time.Time) (bool, error) {
+	// the job reads the connection details from the secret, so it must be in the namespace of the job
+	if restored.Status.SecretRef == nil || restored.Status.SecretRef.Namespace != report.Namespace {
+		check.Result = integreatlyv1alpha1.RestoreDrillResultFail
+		check.Message = croType.StatusMessage(fmt.Sprintf("restored postgres %s has no connection secret in namespace %s", restored.Name, report.Namespace))
+		return true, nil
+	}
+
+	job := &batchv1.Job{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: jobName(check), Namespace: report.Namespace}, job); err != nil {
+		if !k8serr.IsNotFound(err) {
+			return false, errorUtil.Wrapf(err, "failed to get checksum job %s", jobName(check))
+		}
+		job = buildJob(report, check, restored.Status.SecretRef.Name, int64(time.Until(deadline).Seconds()))
+		if err := controllerutil.SetControllerReference(report, job, r.scheme); err != nil {
+			return false, errorUtil.Wrapf(err, "failed to set owner of checksum job %s", job.Name)
+		}
+		if err := r.Client.Create(ctx, job); err != nil {
+			return false, errorUtil.Wrapf(err, "failed to create checksum job %s", job.Name)
+		}
+		check.Message = croType.StatusMessage(fmt.Sprintf("running checksum query against restored postgres %s", restored.Name))
+		return false, nil
+	}
+
+	switch {
+	case job.Status.Succeeded > 0:
+		output, err := r.getOutput(ctx, job)
+		if err != nil {
+			return false, err
+		}
+		check.Checksum = strings.TrimSpace(output)
+		check.Result = integreatlyv1alpha1.RestoreDrillResultFail
+		switch {
+		case check.Checksum == "":
+			check.Message = "checksum query returned no output"
+		case report.Spec.ExpectedChecksum != "" && check.Checksum != report.Spec.ExpectedChecksum:
+			check.Message = croType.StatusMessage(fmt.Sprintf("checksum %s does not match the expected checksum %s", check.Checksum, report.Spec.ExpectedChecksum))
+		default:
+			check.Result = integreatlyv1alpha1.RestoreDrillResultPass
+			check.Message = croType.StatusMessage(fmt.Sprintf("snapshot %s restored and validated", check.SnapshotName))
+		}
+		return true, nil
+	case job.Status.Failed > 0:
+		msg := fmt.Sprintf("checksum job %s failed", job.Name)
+		if output, err := r.getOutput(ctx, job); err == nil && output != "" {
+			msg = fmt.Sprintf("%s: %s", msg, strings.TrimSpace(output))
+		}
+		check.Result = integreatlyv1alpha1.RestoreDrillResultFail
+		check.Message = croType.StatusMessage(msg)
+		return true, nil
+	}
+	return false, nil
+}
+
+// getOutput returns the output of the checksum query, which the job writes to the termination message of its container
+func (r *RestoreDrillReportReconciler) getOutput(ctx context.Context, job *batchv1.Job) (string, error) {
+	pods := &corev1.PodList{}
+	if err := r.Client.List(ctx, pods, k8sclient.InNamespace(job.Namespace), k8sclient.MatchingLabels{"job-name": job.Name}); err != nil {
+		return "", errorUtil.Wrapf(err, "failed to list pods of checksum job %s", job.Name)
+	}
+	for _, pod := range pods.Items {
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.Name == checksumContainerName && cs.State.Terminated != nil {
+				return cs.State.Terminated.Message, nil
+			}
+		}
+	}
+	return "", errorUtil.Errorf("no terminated checksum container found for checksum job %s", job.Name)
+}
+
+// complete sets the overall result, the drill passes if the snapshot of every resource was restored and validated
+func (r *RestoreDrillReportReconciler) complete(report *integreatlyv1alpha1.RestoreDrillReport) {
+	now := metav1.Now()
+	report.Status.Phase = croType.PhaseComplete
+	report.Status.CompletionTime = &now
+	report.Status.Duration = &metav1.Duration{Duration: now.Sub(report.Status.StartTime.Time).Round(time.Second)}
+	report.Status.Result = integreatlyv1alpha1.RestoreDrillResultPass
+	report.Status.Message = "all snapshots restored and validated"
+	for _, check := range report.Status.Checks {
+		if check.Result != integreatlyv1alpha1.RestoreDrillResultPass {
+			report.Status.Result = integreatlyv1alpha1.RestoreDrillResultFail
+			report.Status.Message = croType.StatusMessage(fmt.Sprintf("restore drill of %s failed: %s", check.ResourceName, check.Message))
+			return
+		}
+	}
+}
+
+// buildRestoredPostgres returns the temporary postgres the snapshot is restored to, provisioned with the type, tier and
+// version of the postgres the snapshot was taken of
+func buildRestoredPostgres(report *integreatlyv1alpha1.RestoreDrillReport, source *integreatlyv1alpha1.Postgres, snapshot *integreatlyv1alpha1.PostgresSnapshot) *integreatlyv1alpha1.Postgres {
+	name := fmt.Sprintf("%s-%s", report.Name, source.Name)
+	return &integreatlyv1alpha1.Postgres{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: report.Namespace,
+			Labels: map[string]string{
+				labelRestoreDrillReport: report.Name,
+				"productName":           "restore-drill",
+			},
+		},
+		Spec: croType.ResourceTypeSpec{
+			Type:    source.Spec.Type,
+			Tier:    source.Spec.Tier,
+			Version: source.Spec.Version,
+			SecretRef: &croType.SecretRef{
+				Name:      fmt.Sprintf("%s-sec", name),
+				Namespace: report.Namespace,
+			},
+			RestoreFrom: &croType.RestoreFrom{
+				SnapshotName: snapshot.Name,
+			},
+		},
+	}
+}
+
+// fail completes the check with a failed result, used when there's nothing to tear down
+func fail(report *integreatlyv1alpha1.RestoreDrillReport, check *integreatlyv1alpha1.RestoreDrillCheck, msg string) {
+	check.Result = integreatlyv1alpha1.RestoreDrillResultFail
+	check.Message = croType.StatusMessage(msg)
+	check.Phase = croType.PhaseComplete
+	check.Duration = since(report.Status.StartTime)
+}
+
+func since(start *metav1.Time) *metav1.Duration {
+	return &metav1.Duration{Duration: time.Since(start.Time).Round(time.Second)}
+}
@@ -0,0 +1,269 @@
+package restoredrillreport
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testName      = "test"
+	testNamespace = "test-ns"
+	testRestored  = "test-test-postgres"
+	testChecksum  = "d41d8cd98f00b204e9800998ecf8427e"
+)
+
+func buildTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := integreatlyv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	if err := batchv1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	return scheme
+}
+
+func buildTestReport(startedAgo time.Duration, checks ...integreatlyv1alpha1.RestoreDrillCheck) *integreatlyv1alpha1.RestoreDrillReport {
+	report := &integreatlyv1alpha1.RestoreDrillReport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testName,
+			Namespace: testNamespace,
+		},
+		Spec: integreatlyv1alpha1.RestoreDrillReportSpec{
+			ResourceNames:    []string{"test-postgres"},
+			ExpectedChecksum: testChecksum,
+		},
+	}
+	if len(checks) > 0 {
+		start := metav1.NewTime(time.Now().Add(-startedAgo))
+		report.Status.StartTime = &start
+		report.Status.Phase = croType.PhaseInProgress
+		report.Status.Checks = checks
+	}
+	return report
+}
+
+func buildTestPostgres(name string, phase croType.StatusPhase) *integreatlyv1alpha1.Postgres {
+	return &integreatlyv1alpha1.Postgres{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+		},
+		Spec: croType.ResourceTypeSpec{
+			Type:    "workshop",
+			Tier:    "production",
+			Version: "13",
+		},
+		Status: croType.ResourceTypeStatus{
+			Phase:     phase,
+			SecretRef: &croType.SecretRef{Name: name + "-sec", Namespace: testNamespace},
+		},
+	}
+}
+
+func buildTestSnapshot(name string, createdAgo time.Duration, phase croType.StatusPhase) *integreatlyv1alpha1.PostgresSnapshot {
+	return &integreatlyv1alpha1.PostgresSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         testNamespace,
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-createdAgo)),
+		},
+		Spec: integreatlyv1alpha1.PostgresSnapshotSpec{
+			ResourceName: "test-postgres",
+		},
+		Status: croType.ResourceTypeSnapshotStatus{
+			Phase: phase,
+		},
+	}
+}
+
+func buildTestJob(succeeded bool) *batchv1.Job {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testRestored + "-checksum",
+			Namespace: testNamespace,
+		},
+	}
+	if succeeded {
+		job.Status.Succeeded = 1
+	} else {
+		job.Status.Failed = 1
+	}
+	return job
+}
+
+func buildTestJobPod(output string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testRestored + "-checksum-abcde",
+			Namespace: testNamespace,
+			Labels:    map[string]string{"job-name": testRestored + "-checksum"},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name: checksumContainerName,
+					State: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{Message: output},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestRestoreDrillReportReconciler_Reconcile(t *testing.T) {
+	scheme := buildTestScheme(t)
+	pending := integreatlyv1alpha1.RestoreDrillCheck{ResourceName: "test-postgres"}
+	restoring := integreatlyv1alpha1.RestoreDrillCheck{ResourceName: "test-postgres", SnapshotName: "test-snapshot", RestoredName: testRestored, Phase: croType.PhaseInProgress}
+
+	tests := []struct {
+		name             string
+		objs             []runtime.Object
+		reconciles       int
+		wantPhase        croType.StatusPhase
+		wantResult       integreatlyv1alpha1.RestoreDrillResult
+		wantRestoredFrom string
+		wantJob          bool
+	}{
+		{
+			name:       "test checks are initialised on the first reconcile",
+			objs:       []runtime.Object{buildTestReport(0)},
+			reconciles: 1,
+			wantPhase:  croType.PhaseInProgress,
+		},
+		{
+			name: "test the latest complete snapshot is restored",
+			objs: []runtime.Object{
+				buildTestReport(time.Second, pending),
+				buildTestPostgres("test-postgres", croType.PhaseComplete),
+				buildTestSnapshot("test-old-snapshot", 48*time.Hour, croType.PhaseComplete),
+				buildTestSnapshot("test-snapshot", 24*time.Hour, croType.PhaseComplete),
+				buildTestSnapshot("test-failed-snapshot", time.Hour, croType.PhaseFailed),
+			},
+			reconciles:       1,
+			wantPhase:        croType.PhaseInProgress,
+			wantRestoredFrom: "test-snapshot",
+		},
+		{
+			name:       "test drill fails when the resource has no complete snapshot",
+			objs:       []runtime.Object{buildTestReport(time.Second, pending), buildTestPostgres("test-postgres", croType.PhaseComplete)},
+			reconciles: 1,
+			wantPhase:  croType.PhaseComplete,
+			wantResult: integreatlyv1alpha1.RestoreDrillResultFail,
+		},
+		{
+			name:       "test checksum job is created once the restore is available",
+			objs:       []runtime.Object{buildTestReport(time.Minute, restoring), buildTestPostgres(testRestored, croType.PhaseComplete)},
+			reconciles: 1,
+			wantPhase:  croType.PhaseInProgress,
+			wantJob:    true,
+		},
+		{
+			name:       "test drill passes when the checksum matches and the restore is torn down",
+			objs:       []runtime.Object{buildTestReport(time.Minute, restoring), buildTestPostgres(testRestored, croType.PhaseComplete), buildTestJob(true), buildTestJobPod(testChecksum + "\n")},
+			reconciles: 2,
+			wantPhase:  croType.PhaseComplete,
+			wantResult: integreatlyv1alpha1.RestoreDrillResultPass,
+		},
+		{
+			name:       "test drill fails when the checksum does not match",
+			objs:       []runtime.Object{buildTestReport(time.Minute, restoring), buildTestPostgres(testRestored, croType.PhaseComplete), buildTestJob(true), buildTestJobPod("unexpected")},
+			reconciles: 2,
+			wantPhase:  croType.PhaseComplete,
+			wantResult: integreatlyv1alpha1.RestoreDrillResultFail,
+		},
+		{
+			name:       "test drill fails when the checksum job fails",
+			objs:       []runtime.Object{buildTestReport(time.Minute, restoring), buildTestPostgres(testRestored, croType.PhaseComplete), buildTestJob(false), buildTestJobPod("connection refused")},
+			reconciles: 2,
+			wantPhase:  croType.PhaseComplete,
+			wantResult: integreatlyv1alpha1.RestoreDrillResultFail,
+		},
+		{
+			name:       "test drill fails when the restore fails",
+			objs:       []runtime.Object{buildTestReport(time.Minute, restoring), buildTestPostgres(testRestored, croType.PhaseFailed)},
+			reconciles: 2,
+			wantPhase:  croType.PhaseComplete,
+			wantResult: integreatlyv1alpha1.RestoreDrillResultFail,
+		},
+		{
+			name:       "test drill fails when the restore times out",
+			objs:       []runtime.Object{buildTestReport(4*time.Hour, restoring), buildTestPostgres(testRestored, croType.PhaseInProgress)},
+			reconciles: 2,
+			wantPhase:  croType.PhaseComplete,
+			wantResult: integreatlyv1alpha1.RestoreDrillResultFail,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewFakeClientWithScheme(scheme, tt.objs...)
+			r := &RestoreDrillReportReconciler{
+				Client: client,
+				scheme: scheme,
+				logger: logrus.NewEntry(logrus.StandardLogger()),
+			}
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: testName, Namespace: testNamespace}}
+			for i := 0; i < tt.reconciles; i++ {
+				if _, err := r.Reconcile(req); err != nil {
+					t.Fatalf("Reconcile() unexpected error = %v", err)
+				}
+			}
+
+			report := &integreatlyv1alpha1.RestoreDrillReport{}
+			if err := client.Get(context.TODO(), req.NamespacedName, report); err != nil {
+				t.Fatalf("failed to get restore drill report: %v", err)
+			}
+			if report.Status.Phase != tt.wantPhase {
+				t.Errorf("Reconcile() phase = %v, want %v", report.Status.Phase, tt.wantPhase)
+			}
+			if report.Status.Result != tt.wantResult {
+				t.Errorf("Reconcile() result = %v, want %v, message %s", report.Status.Result, tt.wantResult, report.Status.Message)
+			}
+			if tt.wantPhase == croType.PhaseComplete {
+				if report.Status.Duration == nil {
+					t.Errorf("Reconcile() expected duration to be set on completion")
+				}
+				if err := client.Get(context.TODO(), types.NamespacedName{Name: testRestored, Namespace: testNamespace}, &integreatlyv1alpha1.Postgres{}); err == nil {
+					t.Errorf("Reconcile() expected restored postgres to be deleted on completion")
+				}
+			}
+			if tt.wantRestoredFrom != "" {
+				pg := &integreatlyv1alpha1.Postgres{}
+				if err := client.Get(context.TODO(), types.NamespacedName{Name: testRestored, Namespace: testNamespace}, pg); err != nil {
+					t.Fatalf("Reconcile() expected restored postgres to be created, got error = %v", err)
+				}
+				if pg.Spec.RestoreFrom == nil || pg.Spec.RestoreFrom.SnapshotName != tt.wantRestoredFrom {
+					t.Errorf("Reconcile() expected postgres restored from %s, got %+v", tt.wantRestoredFrom, pg.Spec.RestoreFrom)
+				}
+				if pg.Spec.Version != "13" || pg.Spec.Tier != "production" || len(pg.OwnerReferences) != 1 {
+					t.Errorf("Reconcile() expected postgres owned by the report with the version and tier of the source, got %+v", pg)
+				}
+			}
+			if tt.wantJob {
+				job := &batchv1.Job{}
+				if err := client.Get(context.TODO(), types.NamespacedName{Name: testRestored + "-checksum", Namespace: testNamespace}, job); err != nil {
+					t.Fatalf("Reconcile() expected checksum job to be created, got error = %v", err)
+				}
+				if job.Spec.Template.Spec.Containers[0].Env[0].ValueFrom.SecretKeyRef.Name != testRestored+"-sec" {
+					t.Errorf("Reconcile() expected checksum job to read the connection secret of the restored postgres")
+				}
+			}
+		})
+	}
+}
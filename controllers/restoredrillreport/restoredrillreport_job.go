@@ -0,0 +1,98 @@
+package restoredrillreport
+
+import (
+	"fmt"
+
+	integreatlyv1alpha1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// the names of the tables of the database are expected to survive any restore, even of an empty database
+	defaultChecksumQuery = `SELECT md5(coalesce(string_agg(table_schema || '.' || table_name, ',' ORDER BY table_schema, table_name), '')) FROM information_schema.tables WHERE table_schema NOT IN ('pg_catalog', 'information_schema')`
+
+	checksumImage         = "registry.redhat.io/rhscl/postgresql-10-rhel7"
+	checksumContainerName = "checksum"
+	// the checksum job is given the time remaining of the check, but never less than this
+	minJobDeadlineSeconds = 300
+
+	labelRestoreDrillReport = "integreatly.org/restore-drill-report"
+
+	// the output, or error, of the query is written to the termination message, which is limited to 4096 bytes
+	checksumScript = `psql -X -A -t -v ON_ERROR_STOP=1 -c "$CHECKSUM_QUERY" > /tmp/checksum.out 2>&1
+rc=$?
+head -c 4096 /tmp/checksum.out > /dev/termination-log
+exit $rc`
+)
+
+// buildJob builds the job running the checksum query of the report against the restored postgres, the connection
+// details are read from the connection secret of the restored postgres
+func buildJob(report *integreatlyv1alpha1.RestoreDrillReport, check *integreatlyv1alpha1.RestoreDrillCheck, secretName string, deadlineSeconds int64) *batchv1.Job {
+	query := report.Spec.ChecksumQuery
+	if query == "" {
+		query = defaultChecksumQuery
+	}
+	image := checksumImage
+	if report.Spec.Image != "" {
+		image = report.Spec.Image
+	}
+	if deadlineSeconds < minJobDeadlineSeconds {
+		deadlineSeconds = minJobDeadlineSeconds
+	}
+
+	backoffLimit := int32(0)
+	labels := map[string]string{labelRestoreDrillReport: report.Name}
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName(check),
+			Namespace: report.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          &backoffLimit,
+			ActiveDeadlineSeconds: &deadlineSeconds,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:    checksumContainerName,
+							Image:   image,
+							Command: []string{"/bin/bash", "-c", checksumScript},
+							Env: []corev1.EnvVar{
+								secretEnvVar("PGHOST", secretName, "host"),
+								secretEnvVar("PGPORT", secretName, "port"),
+								secretEnvVar("PGUSER", secretName, "username"),
+								secretEnvVar("PGPASSWORD", secretName, "password"),
+								secretEnvVar("PGDATABASE", secretName, "database"),
+								{Name: "CHECKSUM_QUERY", Value: query},
+							},
+							TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func jobName(check *integreatlyv1alpha1.RestoreDrillCheck) string {
+	return fmt.Sprintf("%s-checksum", check.RestoredName)
+}
+
+func secretEnvVar(name, secretName, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  key,
+			},
+		},
+	}
+}
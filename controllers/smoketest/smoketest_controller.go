@@ -0,0 +1,351 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smoketest
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	croAws "github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	defaultTier           = "development"
+	defaultTimeoutMinutes = 30
+	defaultRequeueTime    = time.Second * 10
+
+	labelSmokeTest = "integreatly.org/smoke-test"
+)
+
+// smokeTestResource is implemented by the custom resources provisioned by a smoke test
+type smokeTestResource interface {
+	runtime.Object
+	metav1.Object
+}
+
+// SmokeTestReconciler reconciles a SmokeTest object
+type SmokeTestReconciler struct {
+	k8sclient.Client
+	scheme           *runtime.Scheme
+	logger           *logrus.Entry
+	ConnectionTester croAws.ConnectionTester
+}
+
+func New(mgr manager.Manager) (*SmokeTestReconciler, error) {
+	return &SmokeTestReconciler{
+		Client:           mgr.GetClient(),
+		scheme:           mgr.GetScheme(),
+		logger:           logrus.WithFields(logrus.Fields{"controller": "controller_smoketest"}),
+		ConnectionTester: croAws.NewConnectionTestManager(),
+	}, nil
+}
+
+func (r *SmokeTestReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&integreatlyv1alpha1.SmokeTest{}).
+		Owns(&integreatlyv1alpha1.BlobStorage{}).
+		Owns(&integreatlyv1alpha1.Postgres{}).
+		Owns(&integreatlyv1alpha1.Redis{}).
+		Complete(r)
+}
+
+func (r *SmokeTestReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error) {
+	r.logger.Info("reconciling smoke test")
+	ctx := context.TODO()
+
+	instance := &integreatlyv1alpha1.SmokeTest{}
+	if err := r.Client.Get(ctx, request.NamespacedName, instance); err != nil {
+		if k8serr.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// a smoke test runs once, create a new smoke test to run it again
+	if instance.Status.Phase == croType.PhaseComplete || instance.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	if instance.Status.StartTime == nil {
+		checks, err := buildChecks(instance)
+		if err != nil {
+			instance.Status.Phase = croType.PhaseFailed
+			instance.Status.Message = croType.StatusMessage(resources.Redact(err.Error()))
+			if updateErr := r.Client.Status().Update(ctx, instance); updateErr != nil {
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{}, err
+		}
+		now := metav1.Now()
+		instance.Status.StartTime = &now
+		instance.Status.Phase = croType.PhaseInProgress
+		instance.Status.Message = "provisioning resources"
+		instance.Status.Checks = checks
+		if err := r.Client.Status().Update(ctx, instance); err != nil {
+			return ctrl.Result{}, errorUtil.Wrap(err, "failed to start smoke test")
+		}
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	timeout := time.Duration(instance.Spec.TimeoutMinutes) * time.Minute
+	if timeout == 0 {
+		timeout = defaultTimeoutMinutes * time.Minute
+	}
+	timedOut := time.Since(instance.Status.StartTime.Time) > timeout
+
+	done := true
+	for i := range instance.Status.Checks {
+		check := &instance.Status.Checks[i]
+		if check.Phase != croType.PhaseComplete {
+			if err := r.reconcileCheck(ctx, instance, check, timedOut); err != nil {
+				return ctrl.Result{}, errorUtil.Wrapf(err, "failed to reconcile %s check", check.ResourceType)
+			}
+		}
+		done = done && check.Phase == croType.PhaseComplete
+	}
+
+	if done {
+		r.complete(instance)
+	}
+	if err := r.Client.Status().Update(ctx, instance); err != nil {
+		return ctrl.Result{}, errorUtil.Wrap(err, "failed to update smoke test status")
+	}
+	if done {
+		r.logger.Infof("smoke test %s completed with result %s in %s", instance.Name, instance.Status.Result, instance.Status.Duration.Duration)
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{Requeue: true, RequeueAfter: defaultRequeueTime}, nil
+}
+
+// reconcileCheck provisions the resource of the check, verifies it once it is available and tears it down
+func (r *SmokeTestReconciler) reconcileCheck(ctx context.Context, st *integreatlyv1alpha1.SmokeTest, check *integreatlyv1alpha1.SmokeTestCheck, timedOut bool) error {
+	resource, err := buildResource(st, check.ResourceType)
+	if err != nil {
+		return err
+	}
+	exists := true
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: resource.GetName(), Namespace: resource.GetNamespace()}, resource); err != nil {
+		if !k8serr.IsNotFound(err) {
+			return errorUtil.Wrapf(err, "failed to get %s %s", check.ResourceType, resource.GetName())
+		}
+		exists = false
+	}
+
+	if check.Phase == croType.PhaseDeleteInProgress {
+		if !exists {
+			check.Phase = croType.PhaseComplete
+			check.Duration = since(st.Status.StartTime)
+			return nil
+		}
+		if timedOut {
+			check.Result = integreatlyv1alpha1.SmokeTestResultFail
+			check.Message = croType.StatusMessage(fmt.Sprintf("timed out waiting for %s %s to be deleted", check.ResourceType, resource.GetName()))
+			check.Phase = croType.PhaseComplete
+			check.Duration = since(st.Status.StartTime)
+		}
+		return nil
+	}
+
+	if !exists {
+		if err := controllerutil.SetControllerReference(st, resource, r.scheme); err != nil {
+			return errorUtil.Wrapf(err, "failed to set owner of %s %s", check.ResourceType, resource.GetName())
+		}
+		if err := r.Client.Create(ctx, resource); err != nil {
+			return errorUtil.Wrapf(err, "failed to create %s %s", check.ResourceType, resource.GetName())
+		}
+		check.Phase = croType.PhaseInProgress
+		check.Message = croType.StatusMessage(fmt.Sprintf("provisioning %s %s", check.ResourceType, resource.GetName()))
+		return nil
+	}
+
+	status := resourceStatus(resource)
+	check.Strategy = status.Strategy
+	switch {
+	case status.Phase == croType.PhaseComplete:
+		check.ProvisionDuration = since(st.Status.StartTime)
+		if err := r.verify(ctx, check.ResourceType, status); err != nil {
+			check.Result = integreatlyv1alpha1.SmokeTestResultFail
+			check.Message = croType.StatusMessage(resources.Redact(err.Error()))
+		} else {
+			check.Result = integreatlyv1alpha1.SmokeTestResultPass
+			check.Message = croType.StatusMessage(fmt.Sprintf("%s %s verified", check.ResourceType, resource.GetName()))
+		}
+	case status.Phase == croType.PhaseFailed:
+		check.Result = integreatlyv1alpha1.SmokeTestResultFail
+		check.Message = croType.StatusMessage(fmt.Sprintf("%s %s failed to provision: %s", check.ResourceType, resource.GetName(), status.Message))
+	case timedOut:
+		check.Result = integreatlyv1alpha1.SmokeTestResultFail
+		check.Message = croType.StatusMessage(fmt.Sprintf("timed out waiting for %s %s to provision", check.ResourceType, resource.GetName()))
+	default:
+		return nil
+	}
+
+	if err := r.Client.Delete(ctx, resource); err != nil && !k8serr.IsNotFound(err) {
+		return errorUtil.Wrapf(err, "failed to delete %s %s", check.ResourceType, resource.GetName())
+	}
+	check.Phase = croType.PhaseDeleteInProgress
+	return nil
+}
+
+// verify checks the connection secret of the resource contains the expected keys and the resource accepts connections
+func (r *SmokeTestReconciler) verify(ctx context.Context, resourceType string, status croType.ResourceTypeStatus) error {
+	if status.SecretRef == nil {
+		return errorUtil.Errorf("%s status does not reference a connection secret", resourceType)
+	}
+	sec := &corev1.Secret{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: status.SecretRef.Name, Namespace: status.SecretRef.Namespace}, sec); err != nil {
+		return errorUtil.Wrapf(err, "failed to get connection secret %s", status.SecretRef.Name)
+	}
+	for _, key := range requiredSecretKeys(resourceType, status.Strategy) {
+		if len(sec.Data[key]) == 0 {
+			return errorUtil.Errorf("connection secret %s is missing key %s", sec.Name, key)
+		}
+	}
+
+	var hostKey string
+	switch resourceType {
+	case string(providers.PostgresResourceType):
+		hostKey = "host"
+	case string(providers.RedisResourceType):
+		hostKey = "uri"
+	default:
+		return nil
+	}
+	host := string(sec.Data[hostKey])
+	port, err := strconv.Atoi(string(sec.Data["port"]))
+	if err != nil {
+		return errorUtil.Wrapf(err, "connection secret %s contains an invalid port", sec.Name)
+	}
+	if !r.ConnectionTester.TCPConnection(host, port) {
+		return errorUtil.Errorf("failed to connect to %s at %s:%d", resourceType, host, port)
+	}
+	return nil
+}
+
+// complete sets the overall result, the smoke test passes if every check passed
+func (r *SmokeTestReconciler) complete(st *integreatlyv1alpha1.SmokeTest) {
+	now := metav1.Now()
+	st.Status.Phase = croType.PhaseComplete
+	st.Status.CompletionTime = &now
+	st.Status.Duration = &metav1.Duration{Duration: now.Sub(st.Status.StartTime.Time).Round(time.Second)}
+	st.Status.Result = integreatlyv1alpha1.SmokeTestResultPass
+	st.Status.Message = "all checks passed"
+	for _, check := range st.Status.Checks {
+		if check.Result != integreatlyv1alpha1.SmokeTestResultPass {
+			st.Status.Result = integreatlyv1alpha1.SmokeTestResultFail
+			st.Status.Message = croType.StatusMessage(fmt.Sprintf("%s check failed: %s", check.ResourceType, check.Message))
+			return
+		}
+	}
+}
+
+func buildChecks(st *integreatlyv1alpha1.SmokeTest) ([]integreatlyv1alpha1.SmokeTestCheck, error) {
+	resourceTypes := st.Spec.ResourceTypes
+	if len(resourceTypes) == 0 {
+		resourceTypes = []string{string(providers.BlobStorageResourceType), string(providers.PostgresResourceType), string(providers.RedisResourceType)}
+	}
+	var checks []integreatlyv1alpha1.SmokeTestCheck
+	for _, rt := range resourceTypes {
+		if _, err := buildResource(st, rt); err != nil {
+			return nil, err
+		}
+		checks = append(checks, integreatlyv1alpha1.SmokeTestCheck{ResourceType: rt})
+	}
+	return checks, nil
+}
+
+// buildResource returns the custom resource provisioned for the resource type, named after the smoke test
+func buildResource(st *integreatlyv1alpha1.SmokeTest, resourceType string) (smokeTestResource, error) {
+	name := fmt.Sprintf("%s-%s", st.Name, resourceType)
+	om := metav1.ObjectMeta{
+		Name:      name,
+		Namespace: st.Namespace,
+		Labels: map[string]string{
+			labelSmokeTest: st.Name,
+			"productName":  "smoke-test",
+		},
+	}
+	tier := st.Spec.Tier
+	if tier == "" {
+		tier = defaultTier
+	}
+	spec := croType.ResourceTypeSpec{
+		Type: st.Spec.Type,
+		Tier: tier,
+		SecretRef: &croType.SecretRef{
+			Name:      fmt.Sprintf("%s-sec", name),
+			Namespace: st.Namespace,
+		},
+	}
+	switch resourceType {
+	case string(providers.BlobStorageResourceType):
+		return &integreatlyv1alpha1.BlobStorage{ObjectMeta: om, Spec: spec}, nil
+	case string(providers.PostgresResourceType):
+		return &integreatlyv1alpha1.Postgres{ObjectMeta: om, Spec: spec}, nil
+	case string(providers.RedisResourceType):
+		return &integreatlyv1alpha1.Redis{ObjectMeta: om, Spec: spec}, nil
+	}
+	return nil, errorUtil.Errorf("unsupported resource type %s", resourceType)
+}
+
+func resourceStatus(resource smokeTestResource) croType.ResourceTypeStatus {
+	switch r := resource.(type) {
+	case *integreatlyv1alpha1.BlobStorage:
+		return r.Status
+	case *integreatlyv1alpha1.Postgres:
+		return r.Status
+	case *integreatlyv1alpha1.Redis:
+		return r.Status
+	}
+	return croType.ResourceTypeStatus{}
+}
+
+// requiredSecretKeys returns the keys every provider of the resource type writes to the connection secret
+func requiredSecretKeys(resourceType, strategy string) []string {
+	switch resourceType {
+	case string(providers.PostgresResourceType):
+		return []string{"username", "password", "host", "port", "database"}
+	case string(providers.RedisResourceType):
+		return []string{"uri", "port"}
+	case string(providers.BlobStorageResourceType):
+		if strategy == providers.AWSDeploymentStrategy {
+			return []string{croAws.DetailsBlobStorageBucketName, croAws.DetailsBlobStorageBucketRegion}
+		}
+	}
+	return nil
+}
+
+func since(start *metav1.Time) *metav1.Duration {
+	return &metav1.Duration{Duration: time.Since(start.Time).Round(time.Second)}
+}
@@ -0,0 +1,202 @@
+package smoketest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	croAws "github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testName      = "test"
+	testNamespace = "test-ns"
+)
+
+func buildTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := integreatlyv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	return scheme
+}
+
+func buildTestSmokeTest(startedAgo time.Duration, checks ...integreatlyv1alpha1.SmokeTestCheck) *integreatlyv1alpha1.SmokeTest {
+	st := &integreatlyv1alpha1.SmokeTest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testName,
+			Namespace: testNamespace,
+		},
+		Spec: integreatlyv1alpha1.SmokeTestSpec{
+			Type:          "workshop",
+			ResourceTypes: []string{"postgres"},
+		},
+	}
+	if len(checks) > 0 {
+		start := metav1.NewTime(time.Now().Add(-startedAgo))
+		st.Status.StartTime = &start
+		st.Status.Phase = croType.PhaseInProgress
+		st.Status.Checks = checks
+	}
+	return st
+}
+
+func buildTestPostgres(phase croType.StatusPhase) *integreatlyv1alpha1.Postgres {
+	return &integreatlyv1alpha1.Postgres{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-postgres",
+			Namespace: testNamespace,
+		},
+		Status: croType.ResourceTypeStatus{
+			Phase:     phase,
+			Strategy:  "openshift",
+			SecretRef: &croType.SecretRef{Name: "test-postgres-sec", Namespace: testNamespace},
+		},
+	}
+}
+
+func buildTestSecret(data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-postgres-sec",
+			Namespace: testNamespace,
+		},
+		Data: data,
+	}
+}
+
+func buildTestConnectionTester(connected bool) *croAws.ConnectionTesterMock {
+	return &croAws.ConnectionTesterMock{
+		TCPConnectionFunc: func(host string, port int) bool {
+			return connected
+		},
+	}
+}
+
+var validPostgresSecretData = map[string][]byte{
+	"username": []byte("user"),
+	"password": []byte("pass"),
+	"host":     []byte("test-postgres.test-ns.svc.cluster.local"),
+	"port":     []byte("5432"),
+	"database": []byte("test"),
+}
+
+func TestSmokeTestReconciler_Reconcile(t *testing.T) {
+	scheme := buildTestScheme(t)
+	pending := integreatlyv1alpha1.SmokeTestCheck{ResourceType: "postgres"}
+	provisioning := integreatlyv1alpha1.SmokeTestCheck{ResourceType: "postgres", Phase: croType.PhaseInProgress}
+
+	tests := []struct {
+		name        string
+		objs        []runtime.Object
+		connected   bool
+		reconciles  int
+		wantPhase   croType.StatusPhase
+		wantResult  integreatlyv1alpha1.SmokeTestResult
+		wantCreated bool
+	}{
+		{
+			name:       "test checks are initialised on the first reconcile",
+			objs:       []runtime.Object{buildTestSmokeTest(0)},
+			reconciles: 1,
+			wantPhase:  croType.PhaseInProgress,
+		},
+		{
+			name:        "test resources are created for pending checks",
+			objs:        []runtime.Object{buildTestSmokeTest(time.Second, pending)},
+			reconciles:  1,
+			wantPhase:   croType.PhaseInProgress,
+			wantCreated: true,
+		},
+		{
+			name:       "test smoke test passes when the resource is verified and torn down",
+			objs:       []runtime.Object{buildTestSmokeTest(time.Minute, provisioning), buildTestPostgres(croType.PhaseComplete), buildTestSecret(validPostgresSecretData)},
+			connected:  true,
+			reconciles: 2,
+			wantPhase:  croType.PhaseComplete,
+			wantResult: integreatlyv1alpha1.SmokeTestResultPass,
+		},
+		{
+			name:       "test smoke test fails when the resource can not be connected to",
+			objs:       []runtime.Object{buildTestSmokeTest(time.Minute, provisioning), buildTestPostgres(croType.PhaseComplete), buildTestSecret(validPostgresSecretData)},
+			connected:  false,
+			reconciles: 2,
+			wantPhase:  croType.PhaseComplete,
+			wantResult: integreatlyv1alpha1.SmokeTestResultFail,
+		},
+		{
+			name:       "test smoke test fails when the connection secret is missing keys",
+			objs:       []runtime.Object{buildTestSmokeTest(time.Minute, provisioning), buildTestPostgres(croType.PhaseComplete), buildTestSecret(map[string][]byte{"host": []byte("test")})},
+			connected:  true,
+			reconciles: 2,
+			wantPhase:  croType.PhaseComplete,
+			wantResult: integreatlyv1alpha1.SmokeTestResultFail,
+		},
+		{
+			name:       "test smoke test fails when the resource fails to provision",
+			objs:       []runtime.Object{buildTestSmokeTest(time.Minute, provisioning), buildTestPostgres(croType.PhaseFailed)},
+			reconciles: 2,
+			wantPhase:  croType.PhaseComplete,
+			wantResult: integreatlyv1alpha1.SmokeTestResultFail,
+		},
+		{
+			name:       "test smoke test fails when provisioning times out",
+			objs:       []runtime.Object{buildTestSmokeTest(time.Hour, provisioning), buildTestPostgres(croType.PhaseInProgress)},
+			reconciles: 2,
+			wantPhase:  croType.PhaseComplete,
+			wantResult: integreatlyv1alpha1.SmokeTestResultFail,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewFakeClientWithScheme(scheme, tt.objs...)
+			r := &SmokeTestReconciler{
+				Client:           client,
+				scheme:           scheme,
+				logger:           logrus.NewEntry(logrus.StandardLogger()),
+				ConnectionTester: buildTestConnectionTester(tt.connected),
+			}
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: testName, Namespace: testNamespace}}
+			for i := 0; i < tt.reconciles; i++ {
+				if _, err := r.Reconcile(req); err != nil {
+					t.Fatalf("Reconcile() unexpected error = %v", err)
+				}
+			}
+
+			st := &integreatlyv1alpha1.SmokeTest{}
+			if err := client.Get(context.TODO(), req.NamespacedName, st); err != nil {
+				t.Fatalf("failed to get smoke test: %v", err)
+			}
+			if st.Status.Phase != tt.wantPhase {
+				t.Errorf("Reconcile() phase = %v, want %v", st.Status.Phase, tt.wantPhase)
+			}
+			if st.Status.Result != tt.wantResult {
+				t.Errorf("Reconcile() result = %v, want %v, message %s", st.Status.Result, tt.wantResult, st.Status.Message)
+			}
+			if tt.wantPhase == croType.PhaseComplete && st.Status.Duration == nil {
+				t.Errorf("Reconcile() expected duration to be set on completion")
+			}
+			if tt.wantCreated {
+				pg := &integreatlyv1alpha1.Postgres{}
+				if err := client.Get(context.TODO(), types.NamespacedName{Name: "test-postgres", Namespace: testNamespace}, pg); err != nil {
+					t.Errorf("Reconcile() expected postgres to be created, got error = %v", err)
+				}
+				if len(pg.OwnerReferences) != 1 {
+					t.Errorf("Reconcile() expected postgres to be owned by the smoke test")
+				}
+			}
+		})
+	}
+}
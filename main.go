@@ -17,29 +17,58 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
+	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	apis "github.com/integr8ly/cloud-resource-operator/apis"
 	v1 "github.com/integr8ly/cloud-resource-operator/apis/config/v1"
 	integreatlyv1alpha1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
 	blobstorageController "github.com/integr8ly/cloud-resource-operator/controllers/blobstorage"
 	cloudmetricsController "github.com/integr8ly/cloud-resource-operator/controllers/cloudmetrics"
+	loadtestController "github.com/integr8ly/cloud-resource-operator/controllers/loadtest"
 	postgresController "github.com/integr8ly/cloud-resource-operator/controllers/postgres"
 	postgressnapshotController "github.com/integr8ly/cloud-resource-operator/controllers/postgressnapshot"
+	productresourcesController "github.com/integr8ly/cloud-resource-operator/controllers/productresources"
+	queueController "github.com/integr8ly/cloud-resource-operator/controllers/queue"
 	redisController "github.com/integr8ly/cloud-resource-operator/controllers/redis"
 	redissnapshotController "github.com/integr8ly/cloud-resource-operator/controllers/redissnapshot"
+	resourcegroupstatusController "github.com/integr8ly/cloud-resource-operator/controllers/resourcegroupstatus"
+	restoredrillController "github.com/integr8ly/cloud-resource-operator/controllers/restoredrill"
+	restoredrillreportController "github.com/integr8ly/cloud-resource-operator/controllers/restoredrillreport"
+	smoketestController "github.com/integr8ly/cloud-resource-operator/controllers/smoketest"
 	"github.com/integr8ly/cloud-resource-operator/internal/k8sutil"
+	"github.com/integr8ly/cloud-resource-operator/pkg/bundle"
+	"github.com/integr8ly/cloud-resource-operator/pkg/consumers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/health"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	awsclient "github.com/integr8ly/cloud-resource-operator/pkg/providers/aws/client"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/gcp"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providersdk"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	"github.com/integr8ly/cloud-resource-operator/pkg/telemetry"
+	"github.com/integr8ly/cloud-resource-operator/pkg/tiers"
+	errorUtil "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	// +kubebuilder:scaffold:imports
 )
 
+// healthCheckInterval is how often the backends used by the operator are checked
+const healthCheckInterval = 5 * time.Minute
+
 var (
 	scheme   = runtime.NewScheme()
 	setupLog = ctrl.Log.WithName("setup")
@@ -57,11 +86,61 @@ func init() {
 
 func main() {
 	var metricsAddr string
+	var probeAddr string
 	var enableLeaderElection bool
+	var bootstrapStrategies bool
+	var enableWebhooks bool
+	var provisioningLimits string
+	var deletionRateLimit string
+	var allowMassDeletion bool
+	var connectionAdmission string
+	var requeueStrategies string
+	var minRequeueInterval time.Duration
+	var maxRequeueInterval time.Duration
+	var callDeadlines string
+	var awsRateLimits string
+	var awsDescribeCacheTTL time.Duration
+	var awsAuthMode string
+	var shutdownGracePeriod time.Duration
+	var passwordPolicy string
+	var connectivityCheckTimeout time.Duration
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8383", "The address the metric endpoint binds to.")
+	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.BoolVar(&bootstrapStrategies, "bootstrap-strategies", true,
+		"Create the provider and strategy configmaps for the detected platform if they don't exist.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false,
+		"Serve the admission webhooks, requires a serving certificate for the webhook server.")
+	flag.StringVar(&provisioningLimits, "provisioning-limits", "",
+		"Comma separated caps on the resources a provider creates at once, e.g. aws-rds=5,aws-elasticache=5.")
+	flag.StringVar(&deletionRateLimit, "deletion-rate-limit", "",
+		"Maximum number of resources deleted within a window, e.g. 10/1h. Further deletions are paused until confirmed.")
+	flag.BoolVar(&allowMassDeletion, "allow-mass-deletion", false,
+		"Confirm every deletion paused by the deletion rate limit.")
+	flag.StringVar(&connectionAdmission, "connection-admission", consumers.AdmissionWarn,
+		"warn or deny postgres consumer secrets expecting more connections than the postgres accepts, requires --enable-webhooks.")
+	flag.StringVar(&requeueStrategies, "requeue-strategies", "",
+		"Comma separated intervals a provider polls resources that aren't ready at, doubling from min to max, e.g. aws-rds=30s-5m.")
+	flag.DurationVar(&minRequeueInterval, "requeue-min-interval", 0,
+		"Minimum interval resources that aren't ready are polled at, for every provider.")
+	flag.DurationVar(&maxRequeueInterval, "requeue-max-interval", 0,
+		"Maximum interval resources that aren't ready are polled at, for every provider.")
+	flag.StringVar(&callDeadlines, "provider-call-deadlines", "",
+		"Comma separated deadlines of the calls of a provider to its cloud, e.g. aws-rds/Modify*=5m,Describe*=30s. 0 disables a deadline.")
+	flag.StringVar(&awsRateLimits, "aws-rate-limits", "",
+		"Comma separated requests per second allowed to an aws service by every reconcile together, e.g. rds=5,elasticache=5.")
+	flag.DurationVar(&awsDescribeCacheTTL, "aws-describe-cache-ttl", awsclient.DescribeCacheTTL,
+		"How long rds instances and elasticache replication groups listed by a reconcile are shared with other reconciles, 0 disables it.")
+	flag.StringVar(&awsAuthMode, "aws-auth-mode", aws.AuthModeAuto,
+		"How the aws providers get credentials: auto, credential-minter or sts. auto uses sts if the sts-credentials secret exists.")
+	flag.DurationVar(&shutdownGracePeriod, "shutdown-grace-period", resources.DefaultShutdownGracePeriod,
+		"How long in-flight operations are given to finish on shutdown, before their state is checkpointed in the status of their resources.")
+	flag.StringVar(&passwordPolicy, "password-policy", "",
+		"Comma separated settings of generated passwords, e.g. length=40,symbols=2,exclude=shell,exclude=uri,min-entropy=160.")
+	flag.DurationVar(&connectivityCheckTimeout, "connectivity-check-timeout", resources.DefaultConnectivityCheckTimeout,
+		"How long the connectivity check of a postgres or redis waits for it to answer, 0 disables the checks.")
 	flag.Parse()
 
 	opts := zap.Options{
@@ -70,7 +149,10 @@ func main() {
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
+	// secrets are redacted from the logs of the operator, including the errors returned by reconcilers
+	opts.DestWritter = resources.NewRedactWriter(os.Stderr)
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	logrus.AddHook(resources.NewRedactHook())
 
 	namespace, err := k8sutil.GetWatchNamespace()
 	if err != nil {
@@ -78,18 +160,102 @@ func main() {
 		os.Exit(1)
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Namespace:          namespace,
-		Scheme:             scheme,
-		MetricsBindAddress: metricsAddr,
-		Port:               9443,
-		LeaderElection:     enableLeaderElection,
-		LeaderElectionID:   "ce8de7ea.integreatly.org",
+	limits, err := providers.ParseProvisioningLimits(provisioningLimits)
+	if err != nil {
+		setupLog.Error(err, "Failed to parse provisioning limits")
+		os.Exit(1)
+	}
+	providers.ProvisioningLimits = limits
+
+	rate, err := providers.ParseDeletionRateLimit(deletionRateLimit)
+	if err != nil {
+		setupLog.Error(err, "Failed to parse deletion rate limit")
+		os.Exit(1)
+	}
+	providers.DeletionRateLimit = rate
+	providers.AllowMassDeletion = allowMassDeletion
+
+	strategies, err := providers.ParseRequeueStrategies(requeueStrategies)
+	if err != nil {
+		setupLog.Error(err, "Failed to parse requeue strategies")
+		os.Exit(1)
+	}
+	if maxRequeueInterval > 0 && maxRequeueInterval < minRequeueInterval {
+		setupLog.Error(errorUtil.Errorf("max requeue interval %s is less than min requeue interval %s", maxRequeueInterval, minRequeueInterval), "Failed to parse requeue intervals")
+		os.Exit(1)
+	}
+	providers.RequeueStrategies = strategies
+	providers.MinRequeueInterval = minRequeueInterval
+	providers.MaxRequeueInterval = maxRequeueInterval
+
+	deadlines, err := providers.ParseCallDeadlines(callDeadlines)
+	if err != nil {
+		setupLog.Error(err, "Failed to parse provider call deadlines")
+		os.Exit(1)
+	}
+	providers.CallDeadlines = deadlines
+
+	awsLimits, err := awsclient.ParseRateLimits(awsRateLimits)
+	if err != nil {
+		setupLog.Error(err, "Failed to parse aws rate limits")
+		os.Exit(1)
+	}
+	awsclient.RateLimits = awsLimits
+	awsclient.DescribeCacheTTL = awsDescribeCacheTTL
+
+	authMode, err := aws.ParseAuthMode(awsAuthMode)
+	if err != nil {
+		setupLog.Error(err, "Failed to parse aws auth mode")
+		os.Exit(1)
+	}
+	aws.RequestedAuthMode = authMode
+
+	policy, err := resources.ParsePasswordPolicy(passwordPolicy)
+	if err == nil {
+		err = policy.Validate(aws.RDSMasterPasswordConstraints)
+	}
+	if err != nil {
+		setupLog.Error(err, "Failed to parse password policy")
+		os.Exit(1)
+	}
+	resources.CurrentPasswordPolicy = policy
+	resources.ConnectivityCheckTimeout = connectivityCheckTimeout
+
+	if connectionAdmission != consumers.AdmissionWarn && connectionAdmission != consumers.AdmissionDeny {
+		setupLog.Error(errorUtil.Errorf("unknown connection admission %s", connectionAdmission), "Failed to parse connection admission")
+		os.Exit(1)
+	}
+
+	cfg := ctrl.GetConfigOrDie()
+	if bootstrapStrategies {
+		if err := bootstrapStrategyConfigMaps(cfg, namespace); err != nil {
+			setupLog.Error(err, "Failed to bootstrap strategy configmaps")
+			os.Exit(1)
+		}
+	}
+	if err := checkProviderPermissions(cfg, namespace); err != nil {
+		setupLog.Error(err, "Failed permission check for enabled providers")
+		os.Exit(1)
+	}
+	if err := checkProviderAuthModes(cfg, namespace); err != nil {
+		setupLog.Error(err, "Failed credentials check for enabled providers")
+		os.Exit(1)
+	}
+
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Namespace:              namespace,
+		Scheme:                 scheme,
+		MetricsBindAddress:     metricsAddr,
+		HealthProbeBindAddress: probeAddr,
+		Port:                   9443,
+		LeaderElection:         enableLeaderElection,
+		LeaderElectionID:       "ce8de7ea.integreatly.org",
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
+	providersdk.SetEventRecorder(mgr.GetEventRecorderFor(resources.EventSource))
 
 	blobstorageCtrl, err := blobstorageController.New(mgr)
 	if err != nil {
@@ -131,6 +297,16 @@ func main() {
 		os.Exit(1)
 	}
 
+	queueCtrl, err := queueController.New(mgr)
+	if err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Queue")
+		os.Exit(1)
+	}
+	if err = queueCtrl.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to setup controller", "controller", "Queue")
+		os.Exit(1)
+	}
+
 	redisCtrl, err := redisController.New(mgr)
 	if err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Redis")
@@ -151,11 +327,206 @@ func main() {
 		os.Exit(1)
 	}
 
+	smoketestCtrl, err := smoketestController.New(mgr)
+	if err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Smoketest")
+		os.Exit(1)
+	}
+	if err = smoketestCtrl.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to setup controller", "controller", "Smoketest")
+		os.Exit(1)
+	}
+
+	loadtestCtrl, err := loadtestController.New(mgr)
+	if err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "LoadTest")
+		os.Exit(1)
+	}
+	if err = loadtestCtrl.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to setup controller", "controller", "LoadTest")
+		os.Exit(1)
+	}
+
+	productresourcesCtrl, err := productresourcesController.New(mgr)
+	if err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ProductResources")
+		os.Exit(1)
+	}
+	if err = productresourcesCtrl.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to setup controller", "controller", "ProductResources")
+		os.Exit(1)
+	}
+
+	resourcegroupstatusCtrl, err := resourcegroupstatusController.New(mgr)
+	if err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ResourceGroupStatus")
+		os.Exit(1)
+	}
+	if err = resourcegroupstatusCtrl.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to setup controller", "controller", "ResourceGroupStatus")
+		os.Exit(1)
+	}
+
+	restoredrillCtrl, err := restoredrillController.New(mgr)
+	if err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RestoreDrill")
+		os.Exit(1)
+	}
+	if err = restoredrillCtrl.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to setup controller", "controller", "RestoreDrill")
+		os.Exit(1)
+	}
+
+	restoredrillreportCtrl, err := restoredrillreportController.New(mgr)
+	if err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RestoreDrillReport")
+		os.Exit(1)
+	}
+	if err = restoredrillreportCtrl.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to setup controller", "controller", "RestoreDrillReport")
+		os.Exit(1)
+	}
+
+	var webhookCertDir string
+	if enableWebhooks {
+		mgr.GetWebhookServer().Register(tiers.ValidateStrategyConfigMapsPath, &webhook.Admission{
+			Handler: &tiers.StrategyConfigMapValidator{Client: mgr.GetClient(), Namespace: namespace},
+		})
+		mgr.GetWebhookServer().Register(tiers.ValidateResourcesPath, &webhook.Admission{
+			Handler: &tiers.ResourceValidator{Client: mgr.GetClient(), Namespace: namespace, GrantReader: mgr.GetAPIReader()},
+		})
+		mgr.GetWebhookServer().Register(consumers.ValidateConsumersPath, &webhook.Admission{
+			Handler: &consumers.ConsumerValidator{Client: mgr.GetClient(), Namespace: namespace, Mode: connectionAdmission},
+		})
+		// the cert dir is defaulted once a webhook is registered
+		webhookCertDir = mgr.GetWebhookServer().CertDir
+	}
+
 	// +kubebuilder:scaffold:builder
 
+	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
+		setupLog.Error(err, "unable to set up health check")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+		setupLog.Error(err, "unable to set up ready check")
+		os.Exit(1)
+	}
+	monitor, err := buildHealthMonitor(cfg, mgr.GetClient(), namespace, webhookCertDir)
+	if err != nil {
+		setupLog.Error(err, "unable to set up health monitor")
+		os.Exit(1)
+	}
+	if err := mgr.Add(monitor); err != nil {
+		setupLog.Error(err, "unable to add health monitor")
+		os.Exit(1)
+	}
+	if err := mgr.AddMetricsExtraHandler(health.DetailPath, monitor); err != nil {
+		setupLog.Error(err, "unable to serve detailed health")
+		os.Exit(1)
+	}
+
+	// usage reports are only posted once enabled in the operator config
+	if err := mgr.Add(telemetry.NewReporter(mgr.GetClient(), namespace)); err != nil {
+		setupLog.Error(err, "unable to add telemetry reporter")
+		os.Exit(1)
+	}
+
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	// in-flight operations are checkpointed on shutdown, so they're resumed instead of repeated on restart
+	if err := mgr.Start(resources.SetupGracefulSignalHandler(shutdownGracePeriod)); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
 }
+
+// checkProviderPermissions verifies the operator has been granted the permissions required by the providers enabled
+// in the provider config map, installations only need to grant permissions for the providers they use
+func checkProviderPermissions(cfg *rest.Config, namespace string) error {
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	enabled, err := providers.NewConfigManager(providers.DefaultProviderConfigMapName, namespace, c).GetEnabledProviders(ctx)
+	if err != nil {
+		return err
+	}
+	rules, err := providers.RulesForProviders(enabled)
+	if err != nil {
+		return err
+	}
+	setupLog.Info("checking permissions", "providers", enabled)
+	return providers.CheckPermissions(ctx, c, namespace, rules)
+}
+
+// checkProviderAuthModes reports the auth mode of each provider enabled in the provider config map, failing if the
+// credentials of a workload identity auth mode are found but can't be used
+func checkProviderAuthModes(cfg *rest.Config, namespace string) error {
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	enabled, err := providers.NewConfigManager(providers.DefaultProviderConfigMapName, namespace, c).GetEnabledProviders(ctx)
+	if err != nil {
+		return err
+	}
+	for _, p := range enabled {
+		var mode string
+		switch p {
+		case providers.AWSDeploymentStrategy:
+			mode, err = aws.GetAuthMode(ctx, c, namespace)
+		case providers.GCPDeploymentStrategy:
+			mode, err = gcp.GetAuthMode(ctx, c, namespace)
+		default:
+			continue
+		}
+		if err != nil {
+			return errorUtil.Wrapf(err, "failed to check credentials of provider %s", p)
+		}
+		setupLog.Info("provider auth mode", "provider", p, "mode", mode)
+	}
+	return nil
+}
+
+// bootstrapStrategyConfigMaps creates the default provider and strategy config maps of the platform the cluster runs
+// on, it runs before the permission check as the provider config map selects the providers checked
+func bootstrapStrategyConfigMaps(cfg *rest.Config, namespace string) error {
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+	created, err := bundle.BootstrapStrategyConfigMaps(context.Background(), c, namespace)
+	if err != nil {
+		return err
+	}
+	if len(created) > 0 {
+		setupLog.Info("created default configmaps", "configmaps", created)
+	}
+	return nil
+}
+
+// buildHealthMonitor builds the monitor of the backends used by the operator, the checks of a backend are only added if
+// it's in use, the webhook certificate is only checked if a webhook cert dir is given. The enabled providers are read
+// once with a direct client as the cache of the manager isn't started yet
+func buildHealthMonitor(cfg *rest.Config, mgrClient client.Client, namespace string, webhookCertDir string) (*health.Monitor, error) {
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := providers.NewConfigManager(providers.DefaultProviderConfigMapName, namespace, c).GetEnabledProviders(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	checks := []health.Check{health.StrategyConfigMapsCheck(mgrClient, namespace)}
+	for _, p := range enabled {
+		if p == providers.AWSDeploymentStrategy {
+			checks = append(checks, health.AWSCheck(mgrClient, namespace))
+		}
+	}
+	if webhookCertDir != "" {
+		checks = append(checks, health.WebhookCertificateCheck(webhookCertDir))
+	}
+	return health.NewMonitor(healthCheckInterval, checks...), nil
+}
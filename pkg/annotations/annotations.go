@@ -30,3 +30,14 @@ func Has(instance metav1.Object, key string) bool {
 
 	return false
 }
+
+// Remove makes sure that the provided key is not set as an annotation
+func Remove(instance metav1.Object, key string) {
+	annotations := instance.GetAnnotations()
+	if annotations == nil {
+		return
+	}
+
+	delete(annotations, key)
+	instance.SetAnnotations(annotations)
+}
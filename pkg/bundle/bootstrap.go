@@ -0,0 +1,59 @@
+package bundle
+
+import (
+	"context"
+
+	configv1 "github.com/integr8ly/cloud-resource-operator/apis/config/v1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ProvidersForPlatform returns the providers enabled on a cluster of the platform, the openshift provider is always
+// enabled so resources the cloud provider doesn't offer can be provisioned in-cluster
+func ProvidersForPlatform(platform configv1.PlatformType) []string {
+	switch platform {
+	case configv1.AWSPlatformType:
+		return []string{providers.AWSDeploymentStrategy, providers.OpenShiftDeploymentStrategy}
+	case configv1.GCPPlatformType:
+		return []string{providers.GCPDeploymentStrategy, providers.OpenShiftDeploymentStrategy}
+	default:
+		return []string{providers.OpenShiftDeploymentStrategy}
+	}
+}
+
+// BootstrapStrategyConfigMaps creates the provider config map and the default strategy config maps of the providers
+// enabled on the platform the cluster runs on, using the development preset, so a fresh install can provision resources
+// without writing strategies by hand. Config maps which already exist are never changed, the names of the created
+// config maps are returned
+func BootstrapStrategyConfigMaps(ctx context.Context, c client.Client, namespace string) ([]string, error) {
+	infra, err := resources.GetClusterInfrastructure(ctx, c)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to detect platform")
+	}
+	platform := infra.Status.Platform
+	if infra.Status.PlatformStatus != nil {
+		platform = infra.Status.PlatformStatus.Type
+	}
+	gates, err := ResolveFeatureGates(PresetDevelopment, nil)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to resolve feature gates")
+	}
+	cms, err := buildStrategyConfigMaps(namespace, ProvidersForPlatform(platform), gates)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to build strategy config maps")
+	}
+	var created []string
+	for _, cm := range cms {
+		if err := c.Create(ctx, cm); err != nil {
+			if k8serr.IsAlreadyExists(err) {
+				continue
+			}
+			return created, errorUtil.Wrapf(err, "failed to create config map %s", cm.Name)
+		}
+		created = append(created, cm.Name)
+	}
+	return created, nil
+}
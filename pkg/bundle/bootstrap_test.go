@@ -0,0 +1,106 @@
+package bundle
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	configv1 "github.com/integr8ly/cloud-resource-operator/apis/config/v1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/gcp"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/openshift"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func buildTestInfrastructure(platform configv1.PlatformType) *configv1.Infrastructure {
+	return &configv1.Infrastructure{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cluster",
+		},
+		Status: configv1.InfrastructureStatus{
+			PlatformStatus: &configv1.PlatformStatus{
+				Type: platform,
+			},
+		},
+	}
+}
+
+func TestBootstrapStrategyConfigMaps(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := configv1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	existingProviderConfig := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      providers.DefaultProviderConfigMapName,
+			Namespace: "test",
+		},
+		Data: map[string]string{"managed": "existing"},
+	}
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		want     []string
+		wantKept bool
+		wantErr  bool
+	}{
+		{
+			name:     "test aws and openshift config maps are created on aws",
+			existing: []runtime.Object{buildTestInfrastructure(configv1.AWSPlatformType)},
+			want:     []string{providers.DefaultProviderConfigMapName, aws.DefaultConfigMapName, openshift.DefaultConfigMapName},
+		},
+		{
+			name:     "test gcp and openshift config maps are created on gcp",
+			existing: []runtime.Object{buildTestInfrastructure(configv1.GCPPlatformType)},
+			want:     []string{providers.DefaultProviderConfigMapName, gcp.DefaultConfigMapName, openshift.DefaultConfigMapName},
+		},
+		{
+			name:     "test only openshift config maps are created on other platforms",
+			existing: []runtime.Object{buildTestInfrastructure(configv1.AzurePlatformType)},
+			want:     []string{providers.DefaultProviderConfigMapName, openshift.DefaultConfigMapName},
+		},
+		{
+			name:     "test existing config maps are not changed",
+			existing: []runtime.Object{buildTestInfrastructure(configv1.NonePlatformType), existingProviderConfig},
+			want:     []string{openshift.DefaultConfigMapName},
+			wantKept: true,
+		},
+		{
+			name:    "test error when the platform can not be detected",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme, tt.existing...)
+			got, err := BootstrapStrategyConfigMaps(context.TODO(), c, "test")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BootstrapStrategyConfigMaps() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			sort.Strings(got)
+			sort.Strings(tt.want)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BootstrapStrategyConfigMaps() = %v, want %v", got, tt.want)
+			}
+			providerConfig := &v1.ConfigMap{}
+			if err := c.Get(context.TODO(), client.ObjectKey{Name: providers.DefaultProviderConfigMapName, Namespace: "test"}, providerConfig); err != nil {
+				t.Fatal("failed to get provider config map", err)
+			}
+			if kept := providerConfig.Data["managed"] == "existing"; kept != tt.wantKept {
+				t.Errorf("BootstrapStrategyConfigMaps() provider config %v, want existing kept %v", providerConfig.Data, tt.wantKept)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+// Package bundle renders the installation manifests of the operator, as a helm chart or an olm bundle, from the
+// code that defines the permissions and strategies the operator relies on so they can not drift apart
+package bundle
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	errorUtil "github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	FormatHelm = "helm"
+	FormatOLM  = "olm"
+
+	operatorName         = "cloud-resource-operator"
+	defaultImageRepo     = "quay.io/integreatly/cloud-resource-operator"
+	tagKeyPrefix         = "integreatly.org/"
+	boundSATokenName     = "bound-sa-token"
+	boundSATokenPath     = "/var/run/secrets/openshift/serviceaccount"
+	boundSATokenAudience = "openshift"
+)
+
+// Options configures the rendered manifests
+type Options struct {
+	// Format is one of helm or olm
+	Format string
+	// Providers are the providers the installation enables, rbac and strategies are only rendered for these
+	Providers []string
+	// Preset selects the feature gate defaults, one of development or production
+	Preset string
+	// FeatureGates override the gates of the preset
+	FeatureGates map[string]bool
+	// Version is the version of the operator
+	Version string
+	// Image is the operator image, defaults to the released image of the version
+	Image string
+	// CRDDir is the directory the custom resource definitions are read from
+	CRDDir string
+}
+
+// File is a rendered manifest, Path is relative to the output directory
+type File struct {
+	Path    string
+	Content []byte
+}
+
+// Render returns the files of the helm chart or olm bundle described by the options
+func Render(opts *Options) ([]*File, error) {
+	if opts.Version == "" {
+		return nil, errorUtil.New("version must be set")
+	}
+	rules, err := providers.RulesForProviders(opts.Providers)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to build rbac rules")
+	}
+	gates, err := ResolveFeatureGates(opts.Preset, opts.FeatureGates)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to resolve feature gates")
+	}
+	crds, err := readCRDs(opts.CRDDir)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Image == "" {
+		opts.Image = fmt.Sprintf("%s:v%s", defaultImageRepo, opts.Version)
+	}
+
+	switch opts.Format {
+	case FormatHelm:
+		return renderHelm(opts, rules, gates, crds)
+	case FormatOLM:
+		return renderOLM(opts, rules, gates, crds)
+	}
+	return nil, fmt.Errorf("unsupported format %s, supported formats are %s, %s", opts.Format, FormatHelm, FormatOLM)
+}
+
+// readCRDs reads the custom resource definitions generated by controller-gen, keyed by file name
+func readCRDs(dir string) (map[string][]byte, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to list crds in %s", dir)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no crds found in %s", dir)
+	}
+	crds := map[string][]byte{}
+	for _, p := range paths {
+		content, err := ioutil.ReadFile(p)
+		if err != nil {
+			return nil, errorUtil.Wrapf(err, "failed to read crd %s", p)
+		}
+		crds[filepath.Base(p)] = content
+	}
+	return crds, nil
+}
+
+// buildDeploymentSpec mirrors config/manager/manager.yaml, watchNamespacePath is the field the watch namespace is read from
+func buildDeploymentSpec(image, watchNamespacePath string) appsv1.DeploymentSpec {
+	replicas := int32(1)
+	labels := map[string]string{"name": operatorName}
+	return appsv1.DeploymentSpec{
+		Replicas: &replicas,
+		Selector: &metav1.LabelSelector{MatchLabels: labels},
+		Template: v1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Labels: labels},
+			Spec: v1.PodSpec{
+				ServiceAccountName: operatorName,
+				Containers: []v1.Container{
+					{
+						Name:            operatorName,
+						Command:         []string{operatorName},
+						Image:           image,
+						ImagePullPolicy: v1.PullAlways,
+						Env: []v1.EnvVar{
+							{
+								Name:      "WATCH_NAMESPACE",
+								ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: watchNamespacePath}},
+							},
+							{
+								Name:      "POD_NAME",
+								ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.name"}},
+							},
+							{
+								Name:  "OPERATOR_NAME",
+								Value: operatorName,
+							},
+							{
+								Name:  "TAG_KEY_PREFIX",
+								Value: tagKeyPrefix,
+							},
+						},
+						VolumeMounts: []v1.VolumeMount{
+							{
+								Name:      boundSATokenName,
+								MountPath: boundSATokenPath,
+							},
+						},
+					},
+				},
+				Volumes: []v1.Volume{
+					{
+						Name: boundSATokenName,
+						VolumeSource: v1.VolumeSource{
+							Projected: &v1.ProjectedVolumeSource{
+								Sources: []v1.VolumeProjection{
+									{
+										ServiceAccountToken: &v1.ServiceAccountTokenProjection{
+											Path:     "token",
+											Audience: boundSATokenAudience,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// marshalDocuments marshals the objects into a single multi document yaml file
+func marshalDocuments(objs ...interface{}) ([]byte, error) {
+	var docs []string
+	for _, obj := range objs {
+		raw, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, errorUtil.Wrap(err, "failed to marshal manifest")
+		}
+		docs = append(docs, string(raw))
+	}
+	return []byte("---\n" + strings.Join(docs, "---\n")), nil
+}
+
+// sortedKeys returns the keys of the map in a stable order so rendered output is reproducible
+func sortedKeys(m map[string][]byte) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
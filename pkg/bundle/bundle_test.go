@@ -0,0 +1,196 @@
+package bundle
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+)
+
+const testCRDDir = "../../config/crd/bases"
+
+func TestResolveFeatureGates(t *testing.T) {
+	tests := []struct {
+		name      string
+		preset    string
+		overrides map[string]bool
+		want      map[string]bool
+		wantErr   bool
+	}{
+		{
+			name:   "test development preset",
+			preset: PresetDevelopment,
+			want:   map[string]bool{FeatureRedisSentinel: false, FeatureWorkloadIsolation: false},
+		},
+		{
+			name:      "test production preset with override",
+			preset:    PresetProduction,
+			overrides: map[string]bool{FeatureWorkloadIsolation: false},
+			want:      map[string]bool{FeatureRedisSentinel: true, FeatureWorkloadIsolation: false},
+		},
+		{
+			name:    "test error on unknown preset",
+			preset:  "staging",
+			wantErr: true,
+		},
+		{
+			name:      "test error on unknown feature gate",
+			preset:    PresetDevelopment,
+			overrides: map[string]bool{"Unknown": true},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveFeatureGates(tt.preset, tt.overrides)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveFeatureGates() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveFeatureGates() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFeatureGates(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]bool
+		wantErr bool
+	}{
+		{
+			name:  "test empty input",
+			input: "",
+			want:  map[string]bool{},
+		},
+		{
+			name:  "test multiple gates",
+			input: "RedisSentinel=true, WorkloadIsolation=false",
+			want:  map[string]bool{FeatureRedisSentinel: true, FeatureWorkloadIsolation: false},
+		},
+		{
+			name:    "test error on missing value",
+			input:   "RedisSentinel",
+			wantErr: true,
+		},
+		{
+			name:    "test error on invalid value",
+			input:   "RedisSentinel=maybe",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFeatureGates(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFeatureGates() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseFeatureGates() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         *Options
+		wantFiles    []string
+		wantContains map[string]string
+		wantErr      bool
+	}{
+		{
+			name: "test helm chart with production preset",
+			opts: &Options{
+				Format:    FormatHelm,
+				Providers: []string{providers.OpenShiftDeploymentStrategy},
+				Preset:    PresetProduction,
+				Version:   "0.40.0",
+				CRDDir:    testCRDDir,
+			},
+			wantFiles: []string{"Chart.yaml", "values.yaml", "templates/rbac.yaml", "templates/strategies.yaml", "templates/deployment.yaml", "crds/integreatly.org_redis.yaml"},
+			wantContains: map[string]string{
+				"values.yaml":               "quay.io/integreatly/cloud-resource-operator:v0.40.0",
+				"templates/strategies.yaml": `"topology":"sentinel"`,
+			},
+		},
+		{
+			name: "test olm bundle without aws strategies",
+			opts: &Options{
+				Format:    FormatOLM,
+				Providers: []string{providers.OpenShiftDeploymentStrategy},
+				Preset:    PresetDevelopment,
+				Version:   "0.40.0",
+				CRDDir:    testCRDDir,
+			},
+			wantFiles: []string{"metadata/annotations.yaml", "manifests/cloud-resource-operator.clusterserviceversion.yaml", "manifests/cloud-resources-openshift-strategies_v1_configmap.yaml"},
+			wantContains: map[string]string{
+				"manifests/cloud-resource-operator.clusterserviceversion.yaml": "redis.integreatly.org",
+				"manifests/cloud-resource-config_v1_configmap.yaml":            `managed: '{"blobstorage":"openshift"`,
+			},
+		},
+		{
+			name: "test olm bundle with gcp postgres",
+			opts: &Options{
+				Format:    FormatOLM,
+				Providers: []string{providers.GCPDeploymentStrategy, providers.OpenShiftDeploymentStrategy},
+				Preset:    PresetDevelopment,
+				Version:   "0.40.0",
+				CRDDir:    testCRDDir,
+			},
+			wantFiles: []string{"manifests/cloud-resources-gcp-strategies_v1_configmap.yaml", "manifests/cloud-resources-openshift-strategies_v1_configmap.yaml"},
+			wantContains: map[string]string{
+				"manifests/cloud-resource-config_v1_configmap.yaml": `managed: '{"blobstorage":"openshift","redis":"openshift","postgres":"gcp"}'`,
+			},
+		},
+		{
+			name: "test error on unsupported format",
+			opts: &Options{
+				Format:    "kustomize",
+				Providers: []string{providers.AWSDeploymentStrategy},
+				Preset:    PresetDevelopment,
+				Version:   "0.40.0",
+				CRDDir:    testCRDDir,
+			},
+			wantErr: true,
+		},
+		{
+			name: "test error when crds are missing",
+			opts: &Options{
+				Format:    FormatHelm,
+				Providers: []string{providers.AWSDeploymentStrategy},
+				Preset:    PresetDevelopment,
+				Version:   "0.40.0",
+				CRDDir:    t.TempDir(),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			files := map[string]string{}
+			for _, f := range got {
+				files[filepath.ToSlash(f.Path)] = string(f.Content)
+			}
+			for _, path := range tt.wantFiles {
+				if _, ok := files[path]; !ok {
+					t.Errorf("Render() expected file %s", path)
+				}
+			}
+			for path, substr := range tt.wantContains {
+				if !strings.Contains(files[path], substr) {
+					t.Errorf("Render() expected %s to contain %s, got %s", path, substr, files[path])
+				}
+			}
+		})
+	}
+}
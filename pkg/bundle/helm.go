@@ -0,0 +1,147 @@
+package bundle
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	helmReleaseNamespace = "{{ .Release.Namespace }}"
+	helmImageValue       = "{{ .Values.image }}"
+)
+
+type helmChart struct {
+	APIVersion  string            `json:"apiVersion"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Type        string            `json:"type"`
+	Version     string            `json:"version"`
+	AppVersion  string            `json:"appVersion"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type helmValues struct {
+	Image string `json:"image"`
+}
+
+// renderHelm renders a chart installing the operator into the release namespace, crds are placed in the crds
+// directory so helm installs them before the templates
+func renderHelm(opts *Options, rules *providers.Rules, gates map[string]bool, crds map[string][]byte) ([]*File, error) {
+	chart, err := yaml.Marshal(&helmChart{
+		APIVersion:  "v2",
+		Name:        operatorName,
+		Description: "Provision and manage in-cluster and cloud provider resources (Blob Storage, Postgres, Redis)",
+		Type:        "application",
+		Version:     opts.Version,
+		AppVersion:  opts.Version,
+		Annotations: buildAnnotations(opts, gates),
+	})
+	if err != nil {
+		return nil, err
+	}
+	values, err := yaml.Marshal(&helmValues{Image: opts.Image})
+	if err != nil {
+		return nil, err
+	}
+	rbac, err := marshalDocuments(buildRBAC(helmReleaseNamespace, rules)...)
+	if err != nil {
+		return nil, err
+	}
+	cms, err := buildStrategyConfigMaps(helmReleaseNamespace, opts.Providers, gates)
+	if err != nil {
+		return nil, err
+	}
+	var cmObjs []interface{}
+	for _, cm := range cms {
+		cmObjs = append(cmObjs, cm)
+	}
+	strategies, err := marshalDocuments(cmObjs...)
+	if err != nil {
+		return nil, err
+	}
+	deployment, err := marshalDocuments(&appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      operatorName,
+			Namespace: helmReleaseNamespace,
+		},
+		Spec: buildDeploymentSpec(helmImageValue, "metadata.namespace"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	files := []*File{
+		{Path: "Chart.yaml", Content: chart},
+		{Path: "values.yaml", Content: values},
+		{Path: filepath.Join("templates", "rbac.yaml"), Content: rbac},
+		{Path: filepath.Join("templates", "strategies.yaml"), Content: strategies},
+		{Path: filepath.Join("templates", "deployment.yaml"), Content: deployment},
+	}
+	for _, name := range sortedKeys(crds) {
+		files = append(files, &File{Path: filepath.Join("crds", name), Content: crds[name]})
+	}
+	return files, nil
+}
+
+// buildRBAC returns the service account of the operator bound to the cluster and namespaced rules
+func buildRBAC(namespace string, rules *providers.Rules) []interface{} {
+	subjects := []rbacv1.Subject{
+		{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      operatorName,
+			Namespace: namespace,
+		},
+	}
+	return []interface{}{
+		&v1.ServiceAccount{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+			ObjectMeta: metav1.ObjectMeta{Name: operatorName, Namespace: namespace},
+		},
+		&rbacv1.ClusterRole{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRole"},
+			ObjectMeta: metav1.ObjectMeta{Name: operatorName},
+			Rules:      rules.Cluster,
+		},
+		&rbacv1.ClusterRoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRoleBinding"},
+			ObjectMeta: metav1.ObjectMeta{Name: operatorName},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: operatorName},
+			Subjects:   subjects,
+		},
+		&rbacv1.Role{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "Role"},
+			ObjectMeta: metav1.ObjectMeta{Name: operatorName, Namespace: namespace},
+			Rules:      rules.Namespaced,
+		},
+		&rbacv1.RoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "RoleBinding"},
+			ObjectMeta: metav1.ObjectMeta{Name: operatorName, Namespace: namespace},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: operatorName},
+			Subjects:   subjects,
+		},
+	}
+}
+
+// buildAnnotations records the options a chart or bundle was rendered with
+func buildAnnotations(opts *Options, gates map[string]bool) map[string]string {
+	var enabled []string
+	for gate, on := range gates {
+		enabled = append(enabled, fmt.Sprintf("%s=%t", gate, on))
+	}
+	sort.Strings(enabled)
+	return map[string]string{
+		"cloud-resource-operator.integreatly.org/providers":     strings.Join(opts.Providers, ","),
+		"cloud-resource-operator.integreatly.org/preset":        opts.Preset,
+		"cloud-resource-operator.integreatly.org/feature-gates": strings.Join(enabled, ","),
+	}
+}
@@ -0,0 +1,149 @@
+package bundle
+
+import (
+	"fmt"
+	"path/filepath"
+
+	semver "github.com/blang/semver/v4"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/operator-framework/api/pkg/lib/version"
+	olmv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	errorUtil "github.com/pkg/errors"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	olmPackageName      = "rhmi-cloud-resources"
+	olmChannel          = "rhmi"
+	olmTargetNamespaces = "metadata.annotations['olm.targetNamespaces']"
+)
+
+type olmAnnotations struct {
+	Annotations map[string]string `json:"annotations"`
+}
+
+// renderOLM renders a registry+v1 bundle, rbac is embedded in the install strategy of the cluster service version
+// and the strategy config maps are shipped as bundle manifests
+func renderOLM(opts *Options, rules *providers.Rules, gates map[string]bool, crds map[string][]byte) ([]*File, error) {
+	v, err := semver.Parse(opts.Version)
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to parse version %s", opts.Version)
+	}
+	owned, err := buildOwnedCRDs(crds)
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := buildAnnotations(opts, gates)
+	annotations["containerImage"] = opts.Image
+	annotations["capabilities"] = "Basic Install"
+	csv := &olmv1alpha1.ClusterServiceVersion{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: olmv1alpha1.SchemeGroupVersion.String(),
+			Kind:       olmv1alpha1.ClusterServiceVersionKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("%s.v%s", operatorName, opts.Version),
+			Namespace:   "placeholder",
+			Annotations: annotations,
+		},
+		Spec: olmv1alpha1.ClusterServiceVersionSpec{
+			DisplayName: "Cloud Resource Operator",
+			Description: "Provision and manage in-cluster and cloud provider resources (Blob Storage, Postgres, Redis)",
+			Version:     version.OperatorVersion{Version: v},
+			Maturity:    "alpha",
+			Provider:    olmv1alpha1.AppLink{Name: "Integreatly"},
+			Keywords:    []string{"integreatly"},
+			CustomResourceDefinitions: olmv1alpha1.CustomResourceDefinitions{
+				Owned: owned,
+			},
+			InstallModes: []olmv1alpha1.InstallMode{
+				{Type: olmv1alpha1.InstallModeTypeOwnNamespace, Supported: true},
+				{Type: olmv1alpha1.InstallModeTypeSingleNamespace, Supported: true},
+				{Type: olmv1alpha1.InstallModeTypeMultiNamespace, Supported: false},
+				{Type: olmv1alpha1.InstallModeTypeAllNamespaces, Supported: true},
+			},
+			InstallStrategy: olmv1alpha1.NamedInstallStrategy{
+				StrategyName: olmv1alpha1.InstallStrategyNameDeployment,
+				StrategySpec: olmv1alpha1.StrategyDetailsDeployment{
+					DeploymentSpecs: []olmv1alpha1.StrategyDeploymentSpec{
+						{
+							Name: operatorName,
+							Spec: buildDeploymentSpec(opts.Image, olmTargetNamespaces),
+						},
+					},
+					Permissions: []olmv1alpha1.StrategyDeploymentPermissions{
+						{ServiceAccountName: operatorName, Rules: rules.Namespaced},
+					},
+					ClusterPermissions: []olmv1alpha1.StrategyDeploymentPermissions{
+						{ServiceAccountName: operatorName, Rules: rules.Cluster},
+					},
+				},
+			},
+		},
+	}
+	csvContent, err := yaml.Marshal(csv)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to marshal cluster service version")
+	}
+	metadata, err := yaml.Marshal(&olmAnnotations{Annotations: map[string]string{
+		"operators.operatorframework.io.bundle.mediatype.v1":       "registry+v1",
+		"operators.operatorframework.io.bundle.manifests.v1":       "manifests/",
+		"operators.operatorframework.io.bundle.metadata.v1":        "metadata/",
+		"operators.operatorframework.io.bundle.package.v1":         olmPackageName,
+		"operators.operatorframework.io.bundle.channels.v1":        olmChannel,
+		"operators.operatorframework.io.bundle.channel.default.v1": olmChannel,
+	}})
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to marshal bundle annotations")
+	}
+
+	files := []*File{
+		{Path: filepath.Join("manifests", fmt.Sprintf("%s.clusterserviceversion.yaml", operatorName)), Content: csvContent},
+		{Path: filepath.Join("metadata", "annotations.yaml"), Content: metadata},
+	}
+	for _, name := range sortedKeys(crds) {
+		files = append(files, &File{Path: filepath.Join("manifests", name), Content: crds[name]})
+	}
+	// olm creates config maps in the namespace the operator is installed in
+	cms, err := buildStrategyConfigMaps("", opts.Providers, gates)
+	if err != nil {
+		return nil, err
+	}
+	for _, cm := range cms {
+		content, err := yaml.Marshal(cm)
+		if err != nil {
+			return nil, errorUtil.Wrapf(err, "failed to marshal config map %s", cm.Name)
+		}
+		files = append(files, &File{Path: filepath.Join("manifests", fmt.Sprintf("%s_v1_configmap.yaml", cm.Name)), Content: content})
+	}
+	return files, nil
+}
+
+// buildOwnedCRDs describes the crds owned by the cluster service version, using the storage version of each crd
+func buildOwnedCRDs(crds map[string][]byte) ([]olmv1alpha1.CRDDescription, error) {
+	var owned []olmv1alpha1.CRDDescription
+	for _, name := range sortedKeys(crds) {
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		if err := yaml.Unmarshal(crds[name], crd); err != nil {
+			return nil, errorUtil.Wrapf(err, "failed to unmarshal crd %s", name)
+		}
+		for _, v := range crd.Spec.Versions {
+			if !v.Storage {
+				continue
+			}
+			desc := olmv1alpha1.CRDDescription{
+				Name:    crd.Name,
+				Version: v.Name,
+				Kind:    crd.Spec.Names.Kind,
+			}
+			if v.Schema != nil && v.Schema.OpenAPIV3Schema != nil {
+				desc.Description = v.Schema.OpenAPIV3Schema.Description
+			}
+			owned = append(owned, desc)
+		}
+	}
+	return owned, nil
+}
@@ -0,0 +1,202 @@
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/gcp"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/openshift"
+	errorUtil "github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// FeatureRedisSentinel sets the production openshift redis strategy to the sentinel topology
+	FeatureRedisSentinel = "RedisSentinel"
+	// FeatureWorkloadIsolation sets the production openshift strategies to create workloads in dedicated namespaces
+	FeatureWorkloadIsolation = "WorkloadIsolation"
+
+	PresetDevelopment = "development"
+	PresetProduction  = "production"
+
+	tierDevelopment = "development"
+	tierProduction  = "production"
+
+	deploymentTypeManaged  = "managed"
+	deploymentTypeWorkshop = "workshop"
+)
+
+// presets are the feature gate defaults of an installation, individual gates can be overridden
+var presets = map[string]map[string]bool{
+	PresetDevelopment: {
+		FeatureRedisSentinel:     false,
+		FeatureWorkloadIsolation: false,
+	},
+	PresetProduction: {
+		FeatureRedisSentinel:     true,
+		FeatureWorkloadIsolation: true,
+	},
+}
+
+// ResolveFeatureGates returns the feature gates of the preset with the overrides applied
+func ResolveFeatureGates(preset string, overrides map[string]bool) (map[string]bool, error) {
+	defaults, ok := presets[preset]
+	if !ok {
+		return nil, fmt.Errorf("unknown preset %s, supported presets are %s, %s", preset, PresetDevelopment, PresetProduction)
+	}
+	gates := map[string]bool{}
+	for k, v := range defaults {
+		gates[k] = v
+	}
+	for k, v := range overrides {
+		if _, ok := defaults[k]; !ok {
+			return nil, fmt.Errorf("unknown feature gate %s", k)
+		}
+		gates[k] = v
+	}
+	return gates, nil
+}
+
+// ParseFeatureGates parses a comma separated list of gate=bool pairs, e.g. RedisSentinel=true,WorkloadIsolation=false
+func ParseFeatureGates(s string) (map[string]bool, error) {
+	gates := map[string]bool{}
+	if strings.TrimSpace(s) == "" {
+		return gates, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid feature gate %s, expected gate=bool", pair)
+		}
+		enabled, err := strconv.ParseBool(kv[1])
+		if err != nil {
+			return nil, errorUtil.Wrapf(err, "invalid value for feature gate %s", kv[0])
+		}
+		gates[kv[0]] = enabled
+	}
+	return gates, nil
+}
+
+// buildStrategyConfigMaps returns the provider config map mapping deployment types to the enabled providers and the
+// default strategy config map of each enabled provider
+func buildStrategyConfigMaps(namespace string, enabled []string, gates map[string]bool) ([]*v1.ConfigMap, error) {
+	mapping, err := buildProviderConfigMap(namespace, enabled)
+	if err != nil {
+		return nil, err
+	}
+	cms := []*v1.ConfigMap{mapping}
+	sorted := append([]string{}, enabled...)
+	sort.Strings(sorted)
+	for i, p := range sorted {
+		if i > 0 && sorted[i-1] == p {
+			continue
+		}
+		switch p {
+		case providers.AWSDeploymentStrategy:
+			cms = append(cms, aws.BuildDefaultConfigMap(aws.DefaultConfigMapName, namespace))
+		case providers.GCPDeploymentStrategy:
+			cms = append(cms, gcp.BuildDefaultConfigMap(gcp.DefaultConfigMapName, namespace))
+		case providers.OpenShiftDeploymentStrategy:
+			cm, err := buildOpenShiftStrategyConfigMap(namespace, gates)
+			if err != nil {
+				return nil, err
+			}
+			cms = append(cms, cm)
+		}
+	}
+	for _, cm := range cms {
+		cm.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"}
+	}
+	return cms, nil
+}
+
+// buildProviderConfigMap maps the managed deployment type to aws, or gcp when aws isn't enabled, and the workshop
+// deployment type to openshift, falling back to whichever provider is enabled
+func buildProviderConfigMap(namespace string, enabled []string) (*v1.ConfigMap, error) {
+	has := map[string]bool{}
+	for _, p := range enabled {
+		has[p] = true
+	}
+	managed, workshop := providers.AWSDeploymentStrategy, providers.OpenShiftDeploymentStrategy
+	if !has[managed] && has[providers.GCPDeploymentStrategy] {
+		managed = providers.GCPDeploymentStrategy
+	}
+	if !has[managed] {
+		managed = workshop
+	}
+	if !has[workshop] {
+		workshop = managed
+	}
+
+	data := map[string]string{}
+	for deploymentType, provider := range map[string]string{deploymentTypeManaged: managed, deploymentTypeWorkshop: workshop} {
+		// gcp only provides postgres, the other resources are provisioned in-cluster when openshift is enabled
+		other := provider
+		if provider == providers.GCPDeploymentStrategy && has[providers.OpenShiftDeploymentStrategy] {
+			other = providers.OpenShiftDeploymentStrategy
+		}
+		raw, err := json.Marshal(&providers.DeploymentStrategyMapping{
+			BlobStorage: other,
+			Redis:       other,
+			Postgres:    provider,
+		})
+		if err != nil {
+			return nil, errorUtil.Wrapf(err, "failed to marshal strategy mapping for deployment type %s", deploymentType)
+		}
+		data[deploymentType] = string(raw)
+	}
+	return &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      providers.DefaultProviderConfigMapName,
+			Namespace: namespace,
+		},
+		Data: data,
+	}, nil
+}
+
+// buildOpenShiftStrategyConfigMap builds the openshift strategies, feature gates only change the production tier so
+// development installs keep a single, unisolated instance
+func buildOpenShiftStrategyConfigMap(namespace string, gates map[string]bool) (*v1.ConfigMap, error) {
+	var isolation *openshift.WorkloadIsolation
+	if gates[FeatureWorkloadIsolation] {
+		isolation = &openshift.WorkloadIsolation{Mode: openshift.WorkloadIsolationDedicated}
+	}
+	redisStrat := &openshift.RedisStrat{Isolation: isolation}
+	if gates[FeatureRedisSentinel] {
+		redisStrat.Topology = openshift.RedisTopologySentinel
+	}
+
+	production := map[providers.ResourceType]interface{}{
+		providers.BlobStorageResourceType: struct{}{},
+		providers.RedisResourceType:       redisStrat,
+		providers.PostgresResourceType:    &openshift.PostgresStrat{Isolation: isolation},
+	}
+	data := map[string]string{}
+	for rt, strat := range production {
+		rawStrat, err := json.Marshal(strat)
+		if err != nil {
+			return nil, errorUtil.Wrapf(err, "failed to marshal openshift strategy for resource type %s", rt)
+		}
+		raw, err := json.Marshal(map[string]*openshift.StrategyConfig{
+			tierDevelopment: {RawStrategy: json.RawMessage("{}")},
+			tierProduction:  {RawStrategy: rawStrat},
+		})
+		if err != nil {
+			return nil, errorUtil.Wrapf(err, "failed to marshal openshift strategies for resource type %s", rt)
+		}
+		data[string(rt)] = string(raw)
+	}
+	return &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      openshift.DefaultConfigMapName,
+			Namespace: namespace,
+		},
+		Data: data,
+	}, nil
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GetRedisTopology reads the redis connection secret and returns the structured connection topology
+func GetRedisTopology(ctx context.Context, client client.Client, secretName, secretNs string) (*providers.RedisTopology, error) {
+	sec := &v1.Secret{}
+	if err := client.Get(ctx, types.NamespacedName{Name: secretName, Namespace: secretNs}, sec); err != nil {
+		return nil, errors.Wrapf(err, "failed to get redis connection secret %s in namespace %s", secretName, secretNs)
+	}
+	return ParseRedisTopology(sec.Data)
+}
+
+// ParseRedisTopology parses the topology key of a redis connection secret, secrets created before the topology key
+// was introduced are treated as a standalone instance reachable through the uri and port keys
+func ParseRedisTopology(data map[string][]byte) (*providers.RedisTopology, error) {
+	if raw, ok := data[providers.RedisTopologyKey]; ok && len(raw) > 0 {
+		topology := &providers.RedisTopology{}
+		if err := json.Unmarshal(raw, topology); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal redis topology")
+		}
+		return topology, nil
+	}
+
+	uri := string(data["uri"])
+	if uri == "" {
+		return nil, errors.New("redis connection secret does not contain a topology or uri")
+	}
+	port, err := strconv.ParseInt(string(data["port"]), 10, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse redis port %s", string(data["port"]))
+	}
+	return (&providers.RedisDeploymentDetails{URI: uri, Port: port}).GetTopology(), nil
+}
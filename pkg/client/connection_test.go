@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func buildTestRedisSecret(data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "test",
+		},
+		Data: data,
+	}
+}
+
+func TestGetRedisTopology(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	sentinelDetails := &providers.RedisDeploymentDetails{
+		URI:          "redis.test.svc.cluster.local",
+		Port:         6379,
+		SentinelURI:  "redis-sentinel.test.svc.cluster.local",
+		SentinelPort: 26379,
+		MasterName:   "mymaster",
+	}
+
+	type args struct {
+		ctx        context.Context
+		client     client.Client
+		secretName string
+		secretNs   string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *providers.RedisTopology
+		wantErr bool
+	}{
+		{
+			name: "test topology is read from the topology key",
+			args: args{
+				ctx:        context.TODO(),
+				client:     fake.NewFakeClientWithScheme(scheme, buildTestRedisSecret(sentinelDetails.Data())),
+				secretName: "test",
+				secretNs:   "test",
+			},
+			want: &providers.RedisTopology{
+				Type:          providers.RedisTopologyTypeSentinel,
+				ReadEndpoints: []providers.Endpoint{{Host: "redis.test.svc.cluster.local", Port: 6379}},
+				Sentinels:     []providers.Endpoint{{Host: "redis-sentinel.test.svc.cluster.local", Port: 26379}},
+				MasterName:    "mymaster",
+			},
+			wantErr: false,
+		},
+		{
+			name: "test standalone topology is derived from secrets without a topology key",
+			args: args{
+				ctx: context.TODO(),
+				client: fake.NewFakeClientWithScheme(scheme, buildTestRedisSecret(map[string][]byte{
+					"uri":  []byte("redis.test.svc.cluster.local"),
+					"port": []byte("6379"),
+				})),
+				secretName: "test",
+				secretNs:   "test",
+			},
+			want: &providers.RedisTopology{
+				Type:    providers.RedisTopologyTypeStandalone,
+				Primary: &providers.Endpoint{Host: "redis.test.svc.cluster.local", Port: 6379},
+			},
+			wantErr: false,
+		},
+		{
+			name: "test error on invalid topology",
+			args: args{
+				ctx: context.TODO(),
+				client: fake.NewFakeClientWithScheme(scheme, buildTestRedisSecret(map[string][]byte{
+					providers.RedisTopologyKey: []byte("not json"),
+				})),
+				secretName: "test",
+				secretNs:   "test",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "test error on empty secret",
+			args: args{
+				ctx:        context.TODO(),
+				client:     fake.NewFakeClientWithScheme(scheme, buildTestRedisSecret(map[string][]byte{})),
+				secretName: "test",
+				secretNs:   "test",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "test error when secret does not exist",
+			args: args{
+				ctx:        context.TODO(),
+				client:     fake.NewFakeClientWithScheme(scheme),
+				secretName: "test",
+				secretNs:   "test",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetRedisTopology(tt.args.ctx, tt.args.client, tt.args.secretName, tt.args.secretNs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetRedisTopology() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetRedisTopology() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
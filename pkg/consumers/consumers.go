@@ -0,0 +1,144 @@
+// Package consumers tracks the consumers of a postgres and the connections they're expected to open, so consumers
+// exceeding the connections the postgres accepts are found before the connections run out
+package consumers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/openshift"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// PostgresConsumerAnnotation on a secret, e.g. the binding of an application, names the postgres in the namespace
+	// of the secret the secret is a consumer of
+	PostgresConsumerAnnotation = "integreatly.org/postgres-consumer"
+	// ExpectedConnectionsAnnotation on a consumer secret is the number of connections the consumer is expected to open,
+	// e.g. the size of its connection pool times its replicas
+	ExpectedConnectionsAnnotation = "integreatly.org/expected-connections"
+	// MaxConnectionsAnnotation on a postgres sets the connections it accepts, for providers the operator can't read it
+	// from, e.g. an rds instance with a custom parameter group
+	MaxConnectionsAnnotation = "integreatly.org/max-connections"
+
+	// DefaultExpectedConnections are the connections of a consumer without the expected connections annotation, the
+	// default pool size of common connection pools
+	DefaultExpectedConnections = 10
+)
+
+// Consumer is a secret consuming a postgres
+type Consumer struct {
+	Name                string
+	ExpectedConnections int
+}
+
+// Demand is the connections expected to be opened to a postgres by its consumers
+type Demand struct {
+	Consumers           []Consumer
+	ExpectedConnections int
+}
+
+func (d Demand) String() string {
+	return fmt.Sprintf("%d consumers expecting %d connections", len(d.Consumers), d.ExpectedConnections)
+}
+
+// ExpectedConnections returns the connections a consumer secret is expected to open
+func ExpectedConnections(sec *v1.Secret) (int, error) {
+	raw, ok := sec.Annotations[ExpectedConnectionsAnnotation]
+	if !ok {
+		return DefaultExpectedConnections, nil
+	}
+	expected, err := strconv.Atoi(raw)
+	if err != nil || expected < 0 {
+		return 0, errorUtil.Errorf("%s annotation of secret %s must be a non-negative number, got %q", ExpectedConnectionsAnnotation, sec.Name, raw)
+	}
+	return expected, nil
+}
+
+// GetDemand returns the consumer secrets of the postgres and the connections they're expected to open, consumers with
+// an invalid expected connections annotation count with the default connections
+func GetDemand(ctx context.Context, c client.Client, ps *v1alpha1.Postgres) (Demand, error) {
+	secrets := &v1.SecretList{}
+	if err := c.List(ctx, secrets, client.InNamespace(ps.Namespace)); err != nil {
+		return Demand{}, errorUtil.Wrapf(err, "failed to list secrets in namespace %s", ps.Namespace)
+	}
+	demand := Demand{}
+	for i := range secrets.Items {
+		sec := &secrets.Items[i]
+		if sec.Annotations[PostgresConsumerAnnotation] != ps.Name || sec.DeletionTimestamp != nil {
+			continue
+		}
+		expected, err := ExpectedConnections(sec)
+		if err != nil {
+			expected = DefaultExpectedConnections
+		}
+		demand.Consumers = append(demand.Consumers, Consumer{Name: sec.Name, ExpectedConnections: expected})
+		demand.ExpectedConnections += expected
+	}
+	sort.Slice(demand.Consumers, func(i, j int) bool {
+		return demand.Consumers[i].Name < demand.Consumers[j].Name
+	})
+	return demand, nil
+}
+
+// GetMaxConnections returns the connections the postgres accepts with the provider strategy, from its max connections
+// annotation or from the strategy config map of the openshift provider. It returns false if the max connections of
+// the postgres are unknown
+func GetMaxConnections(ctx context.Context, c client.Client, ps *v1alpha1.Postgres, strategy string) (int, bool, error) {
+	if raw, ok := ps.Annotations[MaxConnectionsAnnotation]; ok {
+		maxConnections, err := strconv.Atoi(raw)
+		if err != nil || maxConnections <= 0 {
+			return 0, false, errorUtil.Errorf("%s annotation of postgres %s must be a positive number, got %q", MaxConnectionsAnnotation, ps.Name, raw)
+		}
+		return maxConnections, true, nil
+	}
+	if strategy != providers.OpenShiftDeploymentStrategy {
+		return 0, false, nil
+	}
+	stratCfg, err := openshift.NewConfigMapConfigManager(openshift.DefaultConfigMapName, ps.Namespace, c).ReadStorageStrategy(ctx, providers.PostgresResourceType, ps.Spec.Tier)
+	if err != nil {
+		return 0, false, errorUtil.Wrap(err, "failed to read openshift postgres strategy")
+	}
+	maxConnections, err := openshift.PostgresMaxConnections(ps, stratCfg)
+	if err != nil {
+		return 0, false, err
+	}
+	return maxConnections, true, nil
+}
+
+// ReconcileMetrics exposes the consumers of the postgres, the connections they're expected to open and the
+// connections the postgres accepts. It returns a warning if the consumers are expected to open more connections than
+// the postgres accepts, empty otherwise
+func ReconcileMetrics(ctx context.Context, c client.Client, ps *v1alpha1.Postgres, strategy string) (string, error) {
+	demand, err := GetDemand(ctx, c, ps)
+	if err != nil {
+		return "", err
+	}
+	labels := map[string]string{
+		"namespace":  ps.Namespace,
+		"resourceID": ps.Name,
+	}
+	resources.SetMetric(resources.DefaultPostgresConsumersMetricName, labels, float64(len(demand.Consumers)))
+	resources.SetMetric(resources.DefaultPostgresConnectionDemandMetricName, labels, float64(demand.ExpectedConnections))
+
+	maxConnections, known, err := GetMaxConnections(ctx, c, ps, strategy)
+	if err != nil {
+		return "", err
+	}
+	if !known {
+		resources.DeleteMetric(resources.DefaultPostgresMaxConnectionsMetricName, labels)
+		return "", nil
+	}
+	resources.SetMetric(resources.DefaultPostgresMaxConnectionsMetricName, labels, float64(maxConnections))
+	if demand.ExpectedConnections > maxConnections {
+		return fmt.Sprintf("postgres %s accepts %d connections but has %s", ps.Name, maxConnections, demand), nil
+	}
+	return "", nil
+}
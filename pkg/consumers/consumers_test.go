@@ -0,0 +1,156 @@
+package consumers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/openshift"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testNamespace = "test"
+
+func buildTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	return scheme
+}
+
+func buildTestPostgres(annotations map[string]string) *v1alpha1.Postgres {
+	return &v1alpha1.Postgres{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test",
+			Namespace:   testNamespace,
+			Annotations: annotations,
+		},
+		Spec: croType.ResourceTypeSpec{
+			Type: "workshop",
+			Tier: "development",
+		},
+	}
+}
+
+func buildTestConsumer(name, postgres, expected string) *v1.Secret {
+	annotations := map[string]string{PostgresConsumerAnnotation: postgres}
+	if expected != "" {
+		annotations[ExpectedConnectionsAnnotation] = expected
+	}
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   testNamespace,
+			Annotations: annotations,
+		},
+	}
+}
+
+func buildTestStrategyConfigMap(postgres string) *v1.ConfigMap {
+	return &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      openshift.DefaultConfigMapName,
+			Namespace: testNamespace,
+		},
+		Data: map[string]string{
+			"postgres": postgres,
+		},
+	}
+}
+
+func TestGetDemand(t *testing.T) {
+	c := fake.NewFakeClientWithScheme(buildTestScheme(t),
+		buildTestConsumer("b", "test", "40"),
+		buildTestConsumer("a", "test", ""),
+		buildTestConsumer("invalid", "test", "many"),
+		buildTestConsumer("other", "other", "100"),
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: testNamespace}},
+	)
+	demand, err := GetDemand(context.TODO(), c, buildTestPostgres(nil))
+	if err != nil {
+		t.Fatalf("GetDemand() unexpected error = %v", err)
+	}
+	if len(demand.Consumers) != 3 || demand.Consumers[0].Name != "a" {
+		t.Errorf("GetDemand() consumers = %v, want a, b and invalid", demand.Consumers)
+	}
+	if want := 40 + 2*DefaultExpectedConnections; demand.ExpectedConnections != want {
+		t.Errorf("GetDemand() expected connections = %v, want %v", demand.ExpectedConnections, want)
+	}
+}
+
+func TestGetMaxConnections(t *testing.T) {
+	scheme := buildTestScheme(t)
+	tests := []struct {
+		name      string
+		ps        *v1alpha1.Postgres
+		strategy  string
+		want      int
+		wantKnown bool
+		wantErr   bool
+	}{
+		{
+			name:      "test max connections annotation",
+			ps:        buildTestPostgres(map[string]string{MaxConnectionsAnnotation: "500"}),
+			strategy:  providers.AWSDeploymentStrategy,
+			want:      500,
+			wantKnown: true,
+		},
+		{
+			name:     "test invalid max connections annotation",
+			ps:       buildTestPostgres(map[string]string{MaxConnectionsAnnotation: "0"}),
+			strategy: providers.AWSDeploymentStrategy,
+			wantErr:  true,
+		},
+		{
+			name:     "test max connections of aws postgres are unknown",
+			ps:       buildTestPostgres(nil),
+			strategy: providers.AWSDeploymentStrategy,
+		},
+		{
+			name:      "test max connections of openshift postgres are read from the strategy",
+			ps:        buildTestPostgres(nil),
+			strategy:  providers.OpenShiftDeploymentStrategy,
+			want:      300,
+			wantKnown: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme, buildTestStrategyConfigMap(`{"development": {"strategy": {"pooler": {"maxClientConnections": 300}}}}`))
+			got, known, err := GetMaxConnections(context.TODO(), c, tt.ps, tt.strategy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetMaxConnections() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want || known != tt.wantKnown {
+				t.Errorf("GetMaxConnections() = %v, %v, want %v, %v", got, known, tt.want, tt.wantKnown)
+			}
+		})
+	}
+}
+
+func TestReconcileMetrics(t *testing.T) {
+	c := fake.NewFakeClientWithScheme(buildTestScheme(t),
+		buildTestConsumer("a", "test", "60"),
+		buildTestConsumer("b", "test", "60"),
+	)
+	msg, err := ReconcileMetrics(context.TODO(), c, buildTestPostgres(map[string]string{MaxConnectionsAnnotation: "100"}), providers.AWSDeploymentStrategy)
+	if err != nil {
+		t.Fatalf("ReconcileMetrics() unexpected error = %v", err)
+	}
+	if want := "postgres test accepts 100 connections but has 2 consumers expecting 120 connections"; msg != want {
+		t.Errorf("ReconcileMetrics() = %q, want %q", msg, want)
+	}
+	msg, err = ReconcileMetrics(context.TODO(), c, buildTestPostgres(map[string]string{MaxConnectionsAnnotation: "200"}), providers.AWSDeploymentStrategy)
+	if err != nil || msg != "" {
+		t.Errorf("ReconcileMetrics() = %q, %v, want no warning", msg, err)
+	}
+}
@@ -0,0 +1,131 @@
+package consumers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// ValidateConsumersPath is the path the consumer validator is served on
+	ValidateConsumersPath = "/validate-postgres-consumers"
+
+	// AdmissionWarn allows consumers exceeding the connections of a postgres with a warning
+	AdmissionWarn = "warn"
+	// AdmissionDeny denies consumers exceeding the connections of a postgres
+	AdmissionDeny = "deny"
+)
+
+// +kubebuilder:webhook:path=/validate-postgres-consumers,mutating=false,failurePolicy=ignore,sideEffects=None,groups="",resources=secrets,verbs=create;update,versions=v1,name=vpostgresconsumers.integreatly.org,admissionReviewVersions=v1beta1
+
+// ConsumerValidator warns about, or denies, consumer secrets of a postgres which take the connections its consumers
+// are expected to open beyond the connections the postgres accepts
+type ConsumerValidator struct {
+	Client    client.Client
+	Namespace string
+	// Mode is one of warn or deny, defaults to warn
+	Mode    string
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = (*ConsumerValidator)(nil)
+var _ admission.DecoderInjector = (*ConsumerValidator)(nil)
+
+func (v *ConsumerValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+func (v *ConsumerValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if v.Namespace != "" && req.Namespace != v.Namespace {
+		return admission.Allowed("secret isn't in the namespace of the operator")
+	}
+	sec := &v1.Secret{}
+	if err := v.decoder.DecodeRaw(req.Object, sec); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	psName, ok := sec.Annotations[PostgresConsumerAnnotation]
+	if !ok || sec.DeletionTimestamp != nil {
+		return admission.Allowed("not a postgres consumer")
+	}
+	expected, err := ExpectedConnections(sec)
+	if err != nil {
+		return admission.Denied(err.Error())
+	}
+	if req.Operation == admissionv1beta1.Update {
+		oldSec := &v1.Secret{}
+		if err := v.decoder.DecodeRaw(req.OldObject, oldSec); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		// updates which don't add to the connections of the postgres are always allowed, e.g. a credential rotation
+		if oldExpected, err := ExpectedConnections(oldSec); err == nil && oldSec.Annotations[PostgresConsumerAnnotation] == psName && expected <= oldExpected {
+			return admission.Allowed("expected connections aren't increased")
+		}
+	}
+
+	ps := &v1alpha1.Postgres{}
+	if err := v.Client.Get(ctx, types.NamespacedName{Name: psName, Namespace: req.Namespace}, ps); err != nil {
+		if k8serr.IsNotFound(err) {
+			return withWarning(admission.Allowed("postgres doesn't exist"), fmt.Sprintf("postgres %s consumed by secret %s doesn't exist, its connections aren't checked", psName, sec.Name))
+		}
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	maxConnections, known, err := GetMaxConnections(ctx, v.Client, ps, v.postgresStrategy(ctx, ps))
+	if err != nil {
+		// the postgres can't be reconciled either, e.g. its tier was removed, its status reports why
+		return withWarning(admission.Allowed("max connections can't be read"), fmt.Sprintf("max connections of postgres %s can't be read, its connections aren't checked: %v", psName, err))
+	}
+	if !known {
+		return admission.Allowed(fmt.Sprintf("max connections of postgres %s are unknown, set the %s annotation to check them", psName, MaxConnectionsAnnotation))
+	}
+
+	demand, err := GetDemand(ctx, v.Client, ps)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	// the secret replaces its previous version in the demand
+	total := expected
+	consumers := 1
+	for _, c := range demand.Consumers {
+		if c.Name != sec.Name {
+			total += c.ExpectedConnections
+			consumers++
+		}
+	}
+	if total <= maxConnections {
+		return admission.Allowed(fmt.Sprintf("%d consumers of postgres %s expect %d of %d connections", consumers, psName, total, maxConnections))
+	}
+	msg := fmt.Sprintf("secret %s expects %d connections to postgres %s, taking its %d consumers to %d connections, more than the %d connections it accepts", sec.Name, expected, psName, consumers, total, maxConnections)
+	if v.Mode == AdmissionDeny {
+		return admission.Denied(msg)
+	}
+	return withWarning(admission.Allowed("connections exceed max connections"), msg)
+}
+
+// postgresStrategy returns the provider strategy of the postgres from its status, or the provider config of its
+// deployment type if it isn't provisioned yet. An unknown deployment type leaves the strategy empty, the postgres fails
+// to reconcile anyway
+func (v *ConsumerValidator) postgresStrategy(ctx context.Context, ps *v1alpha1.Postgres) string {
+	if ps.Status.Strategy != "" {
+		return ps.Status.Strategy
+	}
+	mapping, err := providers.NewConfigManager(providers.DefaultProviderConfigMapName, ps.Namespace, v.Client).GetStrategyMappingForDeploymentType(ctx, ps.Spec.Type)
+	if err != nil {
+		return ""
+	}
+	return mapping.Postgres
+}
+
+func withWarning(resp admission.Response, warning string) admission.Response {
+	resp.Warnings = []string{warning}
+	return resp
+}
@@ -0,0 +1,122 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func buildTestRequest(t *testing.T, op admissionv1beta1.Operation, oldSec, newSec *v1.Secret) admission.Request {
+	encode := func(sec *v1.Secret) runtime.RawExtension {
+		if sec == nil {
+			return runtime.RawExtension{}
+		}
+		raw, err := json.Marshal(sec)
+		if err != nil {
+			t.Fatal("failed to encode secret", err)
+		}
+		return runtime.RawExtension{Raw: raw}
+	}
+	return admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		Operation: op,
+		Name:      newSec.Name,
+		Namespace: newSec.Namespace,
+		OldObject: encode(oldSec),
+		Object:    encode(newSec),
+	}}
+}
+
+func TestConsumerValidator_Handle(t *testing.T) {
+	scheme := buildTestScheme(t)
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		t.Fatal("failed to build decoder", err)
+	}
+	existing := buildTestConsumer("existing", "test", "60")
+
+	tests := []struct {
+		name        string
+		mode        string
+		req         admission.Request
+		wantAllowed bool
+		wantReason  string
+		wantWarning bool
+	}{
+		{
+			name:        "test secrets other than consumers are allowed",
+			req:         buildTestRequest(t, admissionv1beta1.Create, nil, &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: testNamespace}}),
+			wantAllowed: true,
+		},
+		{
+			name:        "test a consumer within the max connections is allowed",
+			req:         buildTestRequest(t, admissionv1beta1.Create, nil, buildTestConsumer("new", "test", "40")),
+			wantAllowed: true,
+		},
+		{
+			name:        "test a consumer exceeding the max connections is allowed with a warning",
+			req:         buildTestRequest(t, admissionv1beta1.Create, nil, buildTestConsumer("new", "test", "41")),
+			wantAllowed: true,
+			wantWarning: true,
+		},
+		{
+			name:       "test a consumer exceeding the max connections is denied in deny mode",
+			mode:       AdmissionDeny,
+			req:        buildTestRequest(t, admissionv1beta1.Create, nil, buildTestConsumer("new", "test", "41")),
+			wantReason: "taking its 2 consumers to 101 connections, more than the 100 connections it accepts",
+		},
+		{
+			name:        "test updating a consumer replaces its expected connections",
+			mode:        AdmissionDeny,
+			req:         buildTestRequest(t, admissionv1beta1.Update, existing, buildTestConsumer("existing", "test", "100")),
+			wantAllowed: true,
+		},
+		{
+			name:        "test updates which don't increase the expected connections are allowed",
+			mode:        AdmissionDeny,
+			req:         buildTestRequest(t, admissionv1beta1.Update, buildTestConsumer("existing", "test", "200"), buildTestConsumer("existing", "test", "150")),
+			wantAllowed: true,
+		},
+		{
+			name:       "test invalid expected connections are denied",
+			req:        buildTestRequest(t, admissionv1beta1.Create, nil, buildTestConsumer("new", "test", "-1")),
+			wantReason: "must be a non-negative number",
+		},
+		{
+			name:        "test consumers of a missing postgres are allowed with a warning",
+			mode:        AdmissionDeny,
+			req:         buildTestRequest(t, admissionv1beta1.Create, nil, buildTestConsumer("new", "missing", "1000")),
+			wantAllowed: true,
+			wantWarning: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme,
+				buildTestPostgres(map[string]string{MaxConnectionsAnnotation: "100"}),
+				existing,
+			)
+			v := &ConsumerValidator{Client: c, Namespace: testNamespace, Mode: tt.mode}
+			if err := v.InjectDecoder(decoder); err != nil {
+				t.Fatal("failed to inject decoder", err)
+			}
+			resp := v.Handle(context.TODO(), tt.req)
+			if resp.Allowed != tt.wantAllowed {
+				t.Fatalf("Handle() allowed = %v, want %v, result %v", resp.Allowed, tt.wantAllowed, resp.Result)
+			}
+			if tt.wantReason != "" && (resp.Result == nil || !strings.Contains(string(resp.Result.Reason), tt.wantReason)) {
+				t.Errorf("Handle() result = %v, want reason containing %s", resp.Result, tt.wantReason)
+			}
+			if (len(resp.Warnings) > 0) != tt.wantWarning {
+				t.Errorf("Handle() warnings = %v, want warning %v", resp.Warnings, tt.wantWarning)
+			}
+		})
+	}
+}
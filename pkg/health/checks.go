@@ -0,0 +1,91 @@
+package health
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	"github.com/integr8ly/cloud-resource-operator/pkg/tiers"
+	errorUtil "github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// certificateExpiryWarning is how long before it expires the webhook serving certificate is reported as unhealthy, so
+// it's replaced before the webhooks stop working
+const certificateExpiryWarning = 7 * 24 * time.Hour
+
+// StrategyConfigMapsCheck checks the strategy config maps of the enabled providers can be read and every tier can be
+// parsed by its provider
+func StrategyConfigMapsCheck(c client.Client, ns string) Check {
+	return Check{
+		Name: "strategy-configmaps",
+		Check: func(ctx context.Context) error {
+			enabled, err := providers.NewConfigManager(providers.DefaultProviderConfigMapName, ns, c).GetEnabledProviders(ctx)
+			if err != nil {
+				return err
+			}
+			for _, strategy := range enabled {
+				cm, err := tiers.GetStrategyConfigMap(ctx, c, strategy, ns)
+				if err != nil {
+					return err
+				}
+				if validation := tiers.ValidateStrategyConfigMap(strategy, cm.Data); len(validation.Errors) > 0 {
+					return fmt.Errorf("strategy config map %s has invalid tiers: %s", cm.Name, strings.Join(validation.Errors, ", "))
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// AWSCheck checks the operator can list rds instances with its aws credentials
+func AWSCheck(c client.Client, ns string) Check {
+	return Check{
+		Name: "aws",
+		Check: func(ctx context.Context) error {
+			return aws.CheckRDSAccess(ctx, c, ns)
+		},
+	}
+}
+
+// WebhookCertificateCheck checks the serving certificate of the webhook server is valid and isn't about to expire
+func WebhookCertificateCheck(certDir string) Check {
+	return Check{
+		Name: "webhook-certificate",
+		Check: func(ctx context.Context) error {
+			return checkCertificate(filepath.Join(certDir, "tls.crt"), time.Now())
+		},
+	}
+}
+
+func checkCertificate(path string, now time.Time) error {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errorUtil.Wrapf(err, "failed to read certificate %s", path)
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return fmt.Errorf("failed to decode certificate %s", path)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return errorUtil.Wrapf(err, "failed to parse certificate %s", path)
+	}
+	if now.Before(cert.NotBefore) {
+		return fmt.Errorf("certificate %s isn't valid until %s", path, cert.NotBefore.Format(time.RFC3339))
+	}
+	if now.After(cert.NotAfter) {
+		return fmt.Errorf("certificate %s expired at %s", path, cert.NotAfter.Format(time.RFC3339))
+	}
+	if now.Add(certificateExpiryWarning).After(cert.NotAfter) {
+		return fmt.Errorf("certificate %s expires at %s", path, cert.NotAfter.Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package health
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/openshift"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testNamespace = "test"
+
+func buildTestConfigMap(name string, data map[string]string) *v1.ConfigMap {
+	return &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+		},
+		Data: data,
+	}
+}
+
+func writeTestCertificate(t *testing.T, dir string, notBefore, notAfter time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("failed to generate key", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal("failed to create certificate", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "tls.crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal("failed to write certificate", err)
+	}
+}
+
+func TestStrategyConfigMapsCheck(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	providerCM := buildTestConfigMap(providers.DefaultProviderConfigMapName, map[string]string{
+		"workshop": `{"blobstorage": "openshift", "postgres": "openshift", "redis": "openshift"}`,
+	})
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		wantErr  bool
+	}{
+		{
+			name: "test healthy when the strategy config map parses",
+			existing: []runtime.Object{providerCM, buildTestConfigMap(openshift.DefaultConfigMapName, map[string]string{
+				"postgres": `{"development": {}}`,
+			})},
+		},
+		{
+			name:     "test healthy when the provider falls back to its default strategies",
+			existing: []runtime.Object{providerCM},
+		},
+		{
+			name: "test unhealthy when the strategy config map doesn't parse",
+			existing: []runtime.Object{providerCM, buildTestConfigMap(openshift.DefaultConfigMapName, map[string]string{
+				"postgres": `{"development": `,
+			})},
+			wantErr: true,
+		},
+		{
+			name: "test unhealthy when a tier doesn't parse into the provider strategy",
+			existing: []runtime.Object{providerCM, buildTestConfigMap(openshift.DefaultConfigMapName, map[string]string{
+				"postgres": `{"development": {"strategy": {"tls": "yes"}}}`,
+			})},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme, tt.existing...)
+			if err := StrategyConfigMapsCheck(c, testNamespace).Check(context.TODO()); (err != nil) != tt.wantErr {
+				t.Errorf("StrategyConfigMapsCheck() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWebhookCertificateCheck(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		notBefore time.Time
+		notAfter  time.Time
+		noCert    bool
+		wantErr   bool
+	}{
+		{
+			name:      "test healthy when the certificate is valid",
+			notBefore: now.Add(-time.Hour),
+			notAfter:  now.Add(90 * 24 * time.Hour),
+		},
+		{
+			name:      "test unhealthy when the certificate expired",
+			notBefore: now.Add(-48 * time.Hour),
+			notAfter:  now.Add(-time.Hour),
+			wantErr:   true,
+		},
+		{
+			name:      "test unhealthy when the certificate is about to expire",
+			notBefore: now.Add(-time.Hour),
+			notAfter:  now.Add(24 * time.Hour),
+			wantErr:   true,
+		},
+		{
+			name:    "test unhealthy when there's no certificate",
+			noCert:  true,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "webhook-certs")
+			if err != nil {
+				t.Fatal("failed to create cert dir", err)
+			}
+			defer os.RemoveAll(dir)
+			if !tt.noCert {
+				writeTestCertificate(t, dir, tt.notBefore, tt.notAfter)
+			}
+			if err := WebhookCertificateCheck(dir).Check(context.TODO()); (err != nil) != tt.wantErr {
+				t.Errorf("WebhookCertificateCheck() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// Package health checks the backends the operator depends on, so monitoring can tell an operator that is running
+// apart from one that can provision resources
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// AvailableCondition is true once the backends of the operator have been checked
+	AvailableCondition = "Available"
+	// DegradedCondition is true while any backend check fails
+	DegradedCondition = "Degraded"
+
+	// DetailPath is the path the detailed health of the operator is served on
+	DetailPath = "/healthz/detail"
+
+	defaultCheckTimeout = time.Minute
+)
+
+// Check is a named check of a backend the operator depends on, it returns an error if the backend can't be used
+type Check struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// CheckResult is the outcome of the last run of a check
+type CheckResult struct {
+	Name        string      `json:"name"`
+	Healthy     bool        `json:"healthy"`
+	Message     string      `json:"message,omitempty"`
+	LastChecked metav1.Time `json:"lastChecked"`
+}
+
+// Status is the detailed health of the operator, the conditions summarise the results of the checks in the style of
+// the conditions of a cluster operator
+type Status struct {
+	Conditions []metav1.Condition `json:"conditions"`
+	Checks     []CheckResult      `json:"checks"`
+}
+
+// Monitor runs the checks on an interval and serves their last results, so a slow backend doesn't slow down the
+// health endpoint
+type Monitor struct {
+	checks   []Check
+	interval time.Duration
+	logger   *logrus.Entry
+
+	mu      sync.RWMutex
+	results []CheckResult
+}
+
+func NewMonitor(interval time.Duration, checks ...Check) *Monitor {
+	return &Monitor{
+		checks:   checks,
+		interval: interval,
+		logger:   logrus.WithFields(logrus.Fields{"action": "health_monitor"}),
+	}
+}
+
+// Start runs the checks until the stop channel is closed, it implements the runnable interface of the manager
+func (m *Monitor) Start(stop <-chan struct{}) error {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		m.Run(context.Background())
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run runs every check once and records the results
+func (m *Monitor) Run(ctx context.Context) {
+	results := make([]CheckResult, 0, len(m.checks))
+	for _, check := range m.checks {
+		checkCtx, cancel := context.WithTimeout(ctx, defaultCheckTimeout)
+		err := check.Check(checkCtx)
+		cancel()
+		result := CheckResult{Name: check.Name, Healthy: err == nil, LastChecked: metav1.Now()}
+		if err != nil {
+			result.Message = err.Error()
+			m.logger.Warnf("health check %s failed: %v", check.Name, err)
+		}
+		results = append(results, result)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.results = results
+}
+
+// Status returns the results of the last run of the checks and the conditions summarising them
+func (m *Monitor) Status() Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	status := Status{Checks: append([]CheckResult{}, m.results...)}
+	if m.results == nil {
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:    AvailableCondition,
+			Status:  metav1.ConditionUnknown,
+			Reason:  "ChecksPending",
+			Message: "the backends haven't been checked yet",
+		})
+		return status
+	}
+
+	var failed []string
+	for _, r := range m.results {
+		if !r.Healthy {
+			failed = append(failed, r.Name)
+		}
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:    AvailableCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  "AsExpected",
+		Message: "the operator is running",
+	})
+	degraded := metav1.Condition{
+		Type:    DegradedCondition,
+		Status:  metav1.ConditionFalse,
+		Reason:  "AsExpected",
+		Message: "all backends are healthy",
+	}
+	if len(failed) > 0 {
+		degraded.Status = metav1.ConditionTrue
+		degraded.Reason = "ChecksFailed"
+		degraded.Message = fmt.Sprintf("failed checks: %s", strings.Join(failed, ", "))
+	}
+	meta.SetStatusCondition(&status.Conditions, degraded)
+	return status
+}
+
+// ServeHTTP serves the detailed health as json, with status 503 while the operator is degraded or hasn't been checked
+func (m *Monitor) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	status := m.Status()
+	code := http.StatusOK
+	if !meta.IsStatusConditionTrue(status.Conditions, AvailableCondition) || meta.IsStatusConditionTrue(status.Conditions, DegradedCondition) {
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		m.logger.Errorf("failed to write health status: %v", err)
+	}
+}
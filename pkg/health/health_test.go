@@ -0,0 +1,92 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func buildTestCheck(name string, err error) Check {
+	return Check{Name: name, Check: func(ctx context.Context) error { return err }}
+}
+
+func TestMonitor_Status(t *testing.T) {
+	tests := []struct {
+		name          string
+		checks        []Check
+		run           bool
+		wantAvailable metav1.ConditionStatus
+		wantDegraded  metav1.ConditionStatus
+		wantMessage   string
+		wantCode      int
+	}{
+		{
+			name:          "test available is unknown before the checks run",
+			checks:        []Check{buildTestCheck("aws", nil)},
+			wantAvailable: metav1.ConditionUnknown,
+			wantCode:      http.StatusServiceUnavailable,
+		},
+		{
+			name:          "test not degraded when all checks pass",
+			checks:        []Check{buildTestCheck("aws", nil), buildTestCheck("strategy-configmaps", nil)},
+			run:           true,
+			wantAvailable: metav1.ConditionTrue,
+			wantDegraded:  metav1.ConditionFalse,
+			wantCode:      http.StatusOK,
+		},
+		{
+			name:          "test degraded when a check fails",
+			checks:        []Check{buildTestCheck("aws", errors.New("access denied")), buildTestCheck("strategy-configmaps", nil)},
+			run:           true,
+			wantAvailable: metav1.ConditionTrue,
+			wantDegraded:  metav1.ConditionTrue,
+			wantMessage:   "failed checks: aws",
+			wantCode:      http.StatusServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMonitor(0, tt.checks...)
+			if tt.run {
+				m.Run(context.TODO())
+			}
+
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, DetailPath, nil))
+			if rec.Code != tt.wantCode {
+				t.Errorf("ServeHTTP() code = %d, want %d", rec.Code, tt.wantCode)
+			}
+			status := Status{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+				t.Fatalf("failed to decode status: %v", err)
+			}
+
+			available := meta.FindStatusCondition(status.Conditions, AvailableCondition)
+			if available == nil || available.Status != tt.wantAvailable {
+				t.Errorf("Status() available = %v, want %s", available, tt.wantAvailable)
+			}
+			degraded := meta.FindStatusCondition(status.Conditions, DegradedCondition)
+			if tt.wantDegraded == "" {
+				if degraded != nil {
+					t.Errorf("Status() unexpected degraded condition %v", degraded)
+				}
+				return
+			}
+			if degraded == nil || degraded.Status != tt.wantDegraded {
+				t.Fatalf("Status() degraded = %v, want %s", degraded, tt.wantDegraded)
+			}
+			if tt.wantMessage != "" && degraded.Message != tt.wantMessage {
+				t.Errorf("Status() degraded message = %s, want %s", degraded.Message, tt.wantMessage)
+			}
+			if len(status.Checks) != len(tt.checks) {
+				t.Errorf("Status() checks = %v, want %d results", status.Checks, len(tt.checks))
+			}
+		})
+	}
+}
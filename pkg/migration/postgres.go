@@ -0,0 +1,355 @@
+// Package migration moves the data of a resource to the cloud resource of another strategy when the strategy of the
+// resource is changed, e.g. from an in-cluster postgres to an rds instance, so consumers keep their data when a
+// resource is moved off the cluster
+package migration
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// Finalizer keeps a migrating resource until the cloud resources of both strategies are removed, the finalizer
+	// added by the providers is shared by both strategies and removed by the first provider deleting its resource
+	Finalizer = "cloud-resources-operator.integreatly.org/migration"
+	// MigratedCondition is the condition reporting the progress of a migration, it's only set once the strategy of
+	// the resource changed
+	MigratedCondition = "Migrated"
+	// DefaultPostgresImage is the image of the job copying the data of a postgres, its pg_dump reads all postgres
+	// versions provisioned by the openshift provider
+	DefaultPostgresImage = "registry.redhat.io/rhscl/postgresql-13-rhel7"
+
+	// postgresMigrationScript dumps the source database and restores it in the target database in one stream, objects
+	// are restored owned by the target user as the users of the source don't exist in the target
+	postgresMigrationScript = `set -o pipefail
+PGPASSWORD="$SOURCE_PASSWORD" pg_dump --format=custom --no-owner --no-acl --host="$SOURCE_HOST" --port="$SOURCE_PORT" --username="$SOURCE_USER" --dbname="$SOURCE_DATABASE" \
+  | PGPASSWORD="$TARGET_PASSWORD" pg_restore --clean --if-exists --no-owner --no-acl --exit-on-error --host="$TARGET_HOST" --port="$TARGET_PORT" --username="$TARGET_USER" --dbname="$TARGET_DATABASE"`
+)
+
+// Supported returns true if the data of a postgres can be migrated from the strategy to the other
+func Supported(from, to string) bool {
+	return from == providers.OpenShiftDeploymentStrategy && to == providers.AWSDeploymentStrategy
+}
+
+// InProgress returns true if the data of the resource is being migrated, a started migration runs to completion even
+// if the strategy is changed back
+func InProgress(status *croType.ResourceTypeStatus) bool {
+	return status.Migration != nil && status.Migration.State != croType.MigrationStateCompleted
+}
+
+// PostgresMigration moves the data of a postgres from the cloud resource of the source provider to the cloud resource
+// of the target provider. The connection secret keeps pointing at the source until the data was copied, the source is
+// only removed once the secret points at the target
+type PostgresMigration struct {
+	Client client.Client
+	Scheme *runtime.Scheme
+	// From and To are the strategies of the source and target providers
+	From   string
+	To     string
+	Source providers.PostgresProvider
+	Target providers.PostgresProvider
+	// WriteSecret writes the connection secret of the postgres from the deployment details of the target
+	WriteSecret func(ctx context.Context, pi *providers.PostgresInstance) error
+	// Image of the job copying the data, empty uses the default image
+	Image string
+}
+
+// Reconcile advances the migration of the postgres by one step and returns a message describing its progress. The
+// migration status is only changed in memory and is expected to be persisted by the caller, status.strategy and
+// status.provider are switched to the target once the migration is completed
+func (m *PostgresMigration) Reconcile(ctx context.Context, ps *v1alpha1.Postgres) (croType.StatusMessage, error) {
+	if ps.Status.Migration != nil && ps.Status.Migration.State == croType.MigrationStateCompleted {
+		return croType.StatusEmpty, nil
+	}
+
+	// finalizers are updated before the status is changed in memory, updates overwrite the status with the stored one
+	if !resources.HasFinalizer(&ps.ObjectMeta, Finalizer) {
+		ps.SetFinalizers(append(ps.GetFinalizers(), Finalizer))
+		if err := m.Client.Update(ctx, ps); err != nil {
+			msg := "failed to add migration finalizer to postgres"
+			return croType.StatusMessage(msg), errorUtil.Wrap(err, msg)
+		}
+	}
+
+	// once the data was copied the source is never reconciled again, it's removed during the cut over
+	if ps.Status.Migration != nil && ps.Status.Migration.State == croType.MigrationStateCuttingOver {
+		return m.cutOver(ctx, ps)
+	}
+
+	target, msg, err := m.Target.ReconcilePostgres(ctx, ps)
+	if err != nil {
+		return msg, errorUtil.Wrapf(err, "failed to reconcile %s postgres to migrate the data to", m.To)
+	}
+	if target == nil {
+		msg = croType.StatusMessage(fmt.Sprintf("provisioning %s postgres to migrate the data to: %s", m.To, msg))
+		m.setState(ps, croType.MigrationStateProvisioning, msg)
+		return msg, nil
+	}
+	// the source is reconciled after the target, so the status reports the cloud resource consumers are connected to
+	// until the cut over
+	source, msg, err := m.Source.ReconcilePostgres(ctx, ps)
+	if err != nil {
+		return msg, errorUtil.Wrapf(err, "failed to reconcile %s postgres to migrate the data from", m.From)
+	}
+	if source == nil {
+		msg = croType.StatusMessage(fmt.Sprintf("waiting for %s postgres to migrate the data from: %s", m.From, msg))
+		m.setState(ps, croType.MigrationStateProvisioning, msg)
+		return msg, nil
+	}
+
+	return m.reconcileCopy(ctx, ps, source, target)
+}
+
+// reconcileCopy runs the job copying the data from the source to the target, a failed job is retried once it's deleted
+func (m *PostgresMigration) reconcileCopy(ctx context.Context, ps *v1alpha1.Postgres, source, target *providers.PostgresInstance) (croType.StatusMessage, error) {
+	name := jobName(ps.Name)
+	sec := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ps.Namespace,
+		},
+	}
+	if _, err := controllerutil.CreateOrUpdate(ctx, m.Client, sec, func() error {
+		sec.Data = buildPostgresMigrationSecretData(source.DeploymentDetails, target.DeploymentDetails)
+		return controllerutil.SetControllerReference(ps, sec, m.Scheme)
+	}); err != nil {
+		msg := fmt.Sprintf("failed to reconcile migration secret %s", name)
+		return croType.StatusMessage(msg), errorUtil.Wrap(err, msg)
+	}
+
+	job := &batchv1.Job{}
+	err := m.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: ps.Namespace}, job)
+	if err != nil && !k8serr.IsNotFound(err) {
+		msg := fmt.Sprintf("failed to get migration job %s", name)
+		return croType.StatusMessage(msg), errorUtil.Wrap(err, msg)
+	}
+	if k8serr.IsNotFound(err) {
+		job = buildPostgresMigrationJob(ps, m.image())
+		if err := controllerutil.SetControllerReference(ps, job, m.Scheme); err != nil {
+			msg := fmt.Sprintf("failed to set owner of migration job %s", name)
+			return croType.StatusMessage(msg), errorUtil.Wrap(err, msg)
+		}
+		if err := m.Client.Create(ctx, job); err != nil {
+			msg := fmt.Sprintf("failed to create migration job %s", name)
+			return croType.StatusMessage(msg), errorUtil.Wrap(err, msg)
+		}
+	}
+
+	switch {
+	case jobConditionTrue(job, batchv1.JobFailed):
+		msg := croType.StatusMessage(fmt.Sprintf("migration job %s failed to copy the data to the %s postgres, the connection secret still points at the %s postgres, delete the job to retry", name, m.To, m.From))
+		m.setState(ps, croType.MigrationStateFailed, msg)
+		return msg, errorUtil.New(string(msg))
+	case jobConditionTrue(job, batchv1.JobComplete):
+		msg := croType.StatusMessage(fmt.Sprintf("data copied to the %s postgres, swapping the connection secret", m.To))
+		m.setState(ps, croType.MigrationStateCuttingOver, msg)
+		return msg, nil
+	}
+	msg := croType.StatusMessage(fmt.Sprintf("migration job %s is copying the data from the %s postgres to the %s postgres", name, m.From, m.To))
+	m.setState(ps, croType.MigrationStateCopying, msg)
+	return msg, nil
+}
+
+// cutOver points the connection secret at the target and removes the source, every step is safe to repeat if the
+// cut over is interrupted
+func (m *PostgresMigration) cutOver(ctx context.Context, ps *v1alpha1.Postgres) (croType.StatusMessage, error) {
+	target, msg, err := m.Target.ReconcilePostgres(ctx, ps)
+	if err != nil {
+		return msg, errorUtil.Wrapf(err, "failed to reconcile %s postgres to migrate the data to", m.To)
+	}
+	if target == nil {
+		return croType.StatusMessage(fmt.Sprintf("waiting for %s postgres to swap the connection secret: %s", m.To, msg)), nil
+	}
+	if err := m.WriteSecret(ctx, target); err != nil {
+		msg := "failed to point the connection secret at the migrated postgres"
+		return croType.StatusMessage(msg), errorUtil.Wrap(err, msg)
+	}
+
+	// the source removes the provider finalizer, which the target still needs
+	finalizers := append([]string{}, ps.GetFinalizers()...)
+	if msg, err := m.Source.DeletePostgres(ctx, ps); err != nil {
+		return msg, errorUtil.Wrapf(err, "failed to delete %s postgres the data was migrated from", m.From)
+	}
+	for _, f := range finalizers {
+		if f != Finalizer && !resources.HasFinalizer(&ps.ObjectMeta, f) {
+			ps.SetFinalizers(append(ps.GetFinalizers(), f))
+		}
+	}
+
+	if err := m.deleteJob(ctx, ps); err != nil {
+		msg := "failed to delete migration job"
+		return croType.StatusMessage(msg), errorUtil.Wrap(err, msg)
+	}
+	resources.RemoveFinalizer(&ps.ObjectMeta, Finalizer)
+	if err := m.Client.Update(ctx, ps); err != nil {
+		msg := "failed to remove migration finalizer from postgres"
+		return croType.StatusMessage(msg), errorUtil.Wrap(err, msg)
+	}
+
+	msg = croType.StatusMessage(fmt.Sprintf("migrated the data from the %s postgres to the %s postgres", m.From, m.To))
+	now := metav1.Now()
+	m.setState(ps, croType.MigrationStateCompleted, msg)
+	ps.Status.Migration.CompletedAt = &now
+	ps.Status.Strategy = m.To
+	ps.Status.Provider = m.Target.GetName()
+	return msg, nil
+}
+
+// Delete removes the cloud resources of both strategies of a postgres deleted while its data is migrated, it returns
+// an empty message once both are removed
+func (m *PostgresMigration) Delete(ctx context.Context, ps *v1alpha1.Postgres) (croType.StatusMessage, error) {
+	msg, err := m.Target.DeletePostgres(ctx, ps)
+	if err != nil {
+		return msg, errorUtil.Wrapf(err, "failed to delete %s postgres the data was migrated to", m.To)
+	}
+	if msg != croType.StatusEmpty {
+		return msg, nil
+	}
+	if msg, err := m.Source.DeletePostgres(ctx, ps); err != nil {
+		return msg, errorUtil.Wrapf(err, "failed to delete %s postgres the data was migrated from", m.From)
+	}
+	if err := m.deleteJob(ctx, ps); err != nil {
+		msg := "failed to delete migration job"
+		return croType.StatusMessage(msg), errorUtil.Wrap(err, msg)
+	}
+	resources.RemoveFinalizer(&ps.ObjectMeta, Finalizer)
+	if err := m.Client.Update(ctx, ps); err != nil {
+		msg := "failed to remove migration finalizer from postgres"
+		return croType.StatusMessage(msg), errorUtil.Wrap(err, msg)
+	}
+	return croType.StatusEmpty, nil
+}
+
+// deleteJob deletes the migration job, its pods and its secret
+func (m *PostgresMigration) deleteJob(ctx context.Context, ps *v1alpha1.Postgres) error {
+	name := jobName(ps.Name)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ps.Namespace,
+		},
+	}
+	if err := m.Client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !k8serr.IsNotFound(err) {
+		return errorUtil.Wrapf(err, "failed to delete job %s", name)
+	}
+	sec := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ps.Namespace,
+		},
+	}
+	if err := m.Client.Delete(ctx, sec); err != nil && !k8serr.IsNotFound(err) {
+		return errorUtil.Wrapf(err, "failed to delete secret %s", name)
+	}
+	return nil
+}
+
+// setState sets the state of the migration and the migrated condition reporting it
+func (m *PostgresMigration) setState(ps *v1alpha1.Postgres, state croType.MigrationState, msg croType.StatusMessage) {
+	if ps.Status.Migration == nil {
+		now := metav1.Now()
+		ps.Status.Migration = &croType.MigrationStatus{
+			From:      m.From,
+			To:        m.To,
+			StartedAt: &now,
+		}
+	}
+	ps.Status.Migration.State = state
+	ps.Status.Migration.Job = jobName(ps.Name)
+	condStatus := metav1.ConditionFalse
+	if state == croType.MigrationStateCompleted {
+		condStatus = metav1.ConditionTrue
+	}
+	meta.SetStatusCondition(&ps.Status.Conditions, metav1.Condition{
+		Type:               MigratedCondition,
+		Status:             condStatus,
+		ObservedGeneration: ps.GetGeneration(),
+		Reason:             string(state),
+		Message:            string(msg),
+	})
+}
+
+func (m *PostgresMigration) image() string {
+	if m.Image != "" {
+		return m.Image
+	}
+	return DefaultPostgresImage
+}
+
+// buildPostgresMigrationJob returns the job streaming a dump of the source database into the target database, the
+// connection details are read from the migration secret
+func buildPostgresMigrationJob(ps *v1alpha1.Postgres, image string) *batchv1.Job {
+	name := jobName(ps.Name)
+	backoffLimit := int32(2)
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ps.Namespace,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyNever,
+					Containers: []v1.Container{
+						{
+							Name:    "migrate",
+							Image:   image,
+							Command: []string{"/bin/bash", "-c", postgresMigrationScript},
+							EnvFrom: []v1.EnvFromSource{
+								{
+									SecretRef: &v1.SecretEnvSource{
+										LocalObjectReference: v1.LocalObjectReference{Name: name},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// buildPostgresMigrationSecretData returns the connection details of the source and target read by the migration job
+func buildPostgresMigrationSecretData(source, target providers.DeploymentDetails) map[string][]byte {
+	data := map[string][]byte{}
+	for prefix, d := range map[string]providers.DeploymentDetails{"SOURCE": source, "TARGET": target} {
+		details := d.Data()
+		data[prefix+"_HOST"] = details["host"]
+		data[prefix+"_PORT"] = details["port"]
+		data[prefix+"_USER"] = details["username"]
+		data[prefix+"_PASSWORD"] = details["password"]
+		data[prefix+"_DATABASE"] = details["database"]
+	}
+	return data
+}
+
+// jobName returns the name of the migration job and secret of the postgres
+func jobName(name string) string {
+	return fmt.Sprintf("%s-migration", name)
+}
+
+func jobConditionTrue(job *batchv1.Job, condType batchv1.JobConditionType) bool {
+	for _, c := range job.Status.Conditions {
+		if c.Type == condType && c.Status == v1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,257 @@
+package migration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testName          = "test-postgres"
+	testNamespace     = "test"
+	testProviderFinal = "cloud-resources-operator.integreatly.org/finalizers"
+)
+
+// fakePostgresProvider returns the instance it's given, deleting it removes the provider finalizer
+type fakePostgresProvider struct {
+	client   client.Client
+	name     string
+	instance *providers.PostgresInstance
+	deleted  bool
+	// deleteMsg is returned by the first deletion, the deletion is complete on the next one
+	deleteMsg croType.StatusMessage
+}
+
+func (f *fakePostgresProvider) GetName() string                { return f.name }
+func (f *fakePostgresProvider) SupportsStrategy(s string) bool { return s == f.name }
+func (f *fakePostgresProvider) GetReconcileTime(_ *v1alpha1.Postgres) time.Duration {
+	return time.Second
+}
+
+func (f *fakePostgresProvider) ReconcilePostgres(_ context.Context, _ *v1alpha1.Postgres) (*providers.PostgresInstance, croType.StatusMessage, error) {
+	if f.instance == nil {
+		return nil, "creating", nil
+	}
+	return f.instance, "created", nil
+}
+
+func (f *fakePostgresProvider) DeletePostgres(ctx context.Context, ps *v1alpha1.Postgres) (croType.StatusMessage, error) {
+	if f.deleteMsg != croType.StatusEmpty {
+		msg := f.deleteMsg
+		f.deleteMsg = croType.StatusEmpty
+		return msg, nil
+	}
+	f.deleted = true
+	resources.RemoveFinalizer(&ps.ObjectMeta, testProviderFinal)
+	return croType.StatusEmpty, f.client.Update(ctx, ps)
+}
+
+func buildTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{v1alpha1.AddToScheme, v1.AddToScheme, batchv1.AddToScheme} {
+		if err := add(scheme); err != nil {
+			t.Fatal("failed to build scheme", err)
+		}
+	}
+	return scheme
+}
+
+func buildTestPostgres() *v1alpha1.Postgres {
+	return &v1alpha1.Postgres{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       testName,
+			Namespace:  testNamespace,
+			Finalizers: []string{testProviderFinal},
+		},
+		Status: croType.ResourceTypeStatus{
+			Strategy: providers.OpenShiftDeploymentStrategy,
+			Provider: "openshift-postgres",
+		},
+	}
+}
+
+func buildTestInstance(host string) *providers.PostgresInstance {
+	return &providers.PostgresInstance{DeploymentDetails: &providers.PostgresDeploymentDetails{
+		Username: "user",
+		Password: "pass",
+		Host:     host,
+		Database: "db",
+		Port:     5432,
+	}}
+}
+
+func buildTestMigration(c client.Client, scheme *runtime.Scheme, source, target *fakePostgresProvider, written **providers.PostgresInstance) *PostgresMigration {
+	return &PostgresMigration{
+		Client: c,
+		Scheme: scheme,
+		From:   providers.OpenShiftDeploymentStrategy,
+		To:     providers.AWSDeploymentStrategy,
+		Source: source,
+		Target: target,
+		WriteSecret: func(_ context.Context, pi *providers.PostgresInstance) error {
+			*written = pi
+			return nil
+		},
+	}
+}
+
+func TestSupported(t *testing.T) {
+	if !Supported(providers.OpenShiftDeploymentStrategy, providers.AWSDeploymentStrategy) {
+		t.Error("Supported() expected openshift to aws to be supported")
+	}
+	if Supported(providers.AWSDeploymentStrategy, providers.OpenShiftDeploymentStrategy) {
+		t.Error("Supported() expected aws to openshift to be unsupported")
+	}
+}
+
+func TestPostgresMigration_Reconcile(t *testing.T) {
+	scheme := buildTestScheme(t)
+	ctx := context.TODO()
+	ps := buildTestPostgres()
+	c := fake.NewFakeClientWithScheme(scheme, ps)
+	source := &fakePostgresProvider{client: c, name: "openshift-postgres", instance: buildTestInstance("source.svc")}
+	target := &fakePostgresProvider{client: c, name: "aws-rds"}
+	var written *providers.PostgresInstance
+	m := buildTestMigration(c, scheme, source, target, &written)
+
+	// the target is provisioned first
+	if _, err := m.Reconcile(ctx, ps); err != nil {
+		t.Fatalf("Reconcile() unexpected error = %v", err)
+	}
+	if !InProgress(&ps.Status) || ps.Status.Migration.State != croType.MigrationStateProvisioning {
+		t.Fatalf("Reconcile() migration = %+v, want provisioning", ps.Status.Migration)
+	}
+	if !resources.HasFinalizer(&ps.ObjectMeta, Finalizer) || !resources.HasFinalizer(&ps.ObjectMeta, testProviderFinal) {
+		t.Fatalf("Reconcile() finalizers = %v, want the migration and provider finalizers", ps.GetFinalizers())
+	}
+
+	// the data is copied once the target is available
+	target.instance = buildTestInstance("target.rds")
+	if _, err := m.Reconcile(ctx, ps); err != nil {
+		t.Fatalf("Reconcile() unexpected error = %v", err)
+	}
+	if ps.Status.Migration.State != croType.MigrationStateCopying {
+		t.Fatalf("Reconcile() state = %s, want %s", ps.Status.Migration.State, croType.MigrationStateCopying)
+	}
+	sec := &v1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Name: jobName(testName), Namespace: testNamespace}, sec); err != nil {
+		t.Fatal("failed to get migration secret", err)
+	}
+	if string(sec.Data["SOURCE_HOST"]) != "source.svc" || string(sec.Data["TARGET_HOST"]) != "target.rds" {
+		t.Errorf("Reconcile() migration secret = %v, want the source and target hosts", sec.Data)
+	}
+	job := &batchv1.Job{}
+	if err := c.Get(ctx, types.NamespacedName{Name: jobName(testName), Namespace: testNamespace}, job); err != nil {
+		t.Fatal("failed to get migration job", err)
+	}
+	if written != nil {
+		t.Error("Reconcile() connection secret swapped before the data was copied")
+	}
+
+	// the cut over starts once the job completed
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: v1.ConditionTrue}}
+	if err := c.Update(ctx, job); err != nil {
+		t.Fatal("failed to update migration job", err)
+	}
+	if _, err := m.Reconcile(ctx, ps); err != nil {
+		t.Fatalf("Reconcile() unexpected error = %v", err)
+	}
+	if ps.Status.Migration.State != croType.MigrationStateCuttingOver || source.deleted {
+		t.Fatalf("Reconcile() state = %s, source deleted = %v, want cutting over", ps.Status.Migration.State, source.deleted)
+	}
+
+	// the secret is swapped before the source is deleted
+	if _, err := m.Reconcile(ctx, ps); err != nil {
+		t.Fatalf("Reconcile() unexpected error = %v", err)
+	}
+	if written != target.instance {
+		t.Error("Reconcile() expected the connection secret to point at the target")
+	}
+	if !source.deleted {
+		t.Error("Reconcile() expected the source to be deleted")
+	}
+	if InProgress(&ps.Status) || ps.Status.Migration.CompletedAt == nil {
+		t.Errorf("Reconcile() migration = %+v, want completed", ps.Status.Migration)
+	}
+	if ps.Status.Strategy != providers.AWSDeploymentStrategy || ps.Status.Provider != "aws-rds" {
+		t.Errorf("Reconcile() strategy = %s, provider = %s, want the target", ps.Status.Strategy, ps.Status.Provider)
+	}
+	if !meta.IsStatusConditionTrue(ps.Status.Conditions, MigratedCondition) {
+		t.Error("Reconcile() expected a true migrated condition")
+	}
+	if resources.HasFinalizer(&ps.ObjectMeta, Finalizer) || !resources.HasFinalizer(&ps.ObjectMeta, testProviderFinal) {
+		t.Errorf("Reconcile() finalizers = %v, want only the provider finalizer", ps.GetFinalizers())
+	}
+	if err := c.Get(ctx, types.NamespacedName{Name: jobName(testName), Namespace: testNamespace}, &batchv1.Job{}); !k8serr.IsNotFound(err) {
+		t.Errorf("Reconcile() expected the migration job to be deleted, got %v", err)
+	}
+}
+
+func TestPostgresMigration_ReconcileFailedJob(t *testing.T) {
+	scheme := buildTestScheme(t)
+	ctx := context.TODO()
+	ps := buildTestPostgres()
+	ps.Status.Migration = &croType.MigrationStatus{From: providers.OpenShiftDeploymentStrategy, To: providers.AWSDeploymentStrategy, State: croType.MigrationStateCopying}
+	job := buildPostgresMigrationJob(ps, DefaultPostgresImage)
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: v1.ConditionTrue}}
+	c := fake.NewFakeClientWithScheme(scheme, ps, job)
+	source := &fakePostgresProvider{client: c, name: "openshift-postgres", instance: buildTestInstance("source.svc")}
+	target := &fakePostgresProvider{client: c, name: "aws-rds", instance: buildTestInstance("target.rds")}
+	var written *providers.PostgresInstance
+	m := buildTestMigration(c, scheme, source, target, &written)
+
+	if _, err := m.Reconcile(ctx, ps); err == nil {
+		t.Fatal("Reconcile() expected an error for a failed job")
+	}
+	if ps.Status.Migration.State != croType.MigrationStateFailed {
+		t.Errorf("Reconcile() state = %s, want %s", ps.Status.Migration.State, croType.MigrationStateFailed)
+	}
+	if written != nil || source.deleted {
+		t.Error("Reconcile() expected the source to be kept after a failed job")
+	}
+}
+
+func TestPostgresMigration_Delete(t *testing.T) {
+	scheme := buildTestScheme(t)
+	ctx := context.TODO()
+	ps := buildTestPostgres()
+	ps.Finalizers = append(ps.Finalizers, Finalizer)
+	c := fake.NewFakeClientWithScheme(scheme, ps)
+	source := &fakePostgresProvider{client: c, name: "openshift-postgres"}
+	target := &fakePostgresProvider{client: c, name: "aws-rds", deleteMsg: "deleting rds instance"}
+	var written *providers.PostgresInstance
+	m := buildTestMigration(c, scheme, source, target, &written)
+
+	msg, err := m.Delete(ctx, ps)
+	if err != nil {
+		t.Fatalf("Delete() unexpected error = %v", err)
+	}
+	if msg == croType.StatusEmpty || source.deleted {
+		t.Fatalf("Delete() = %q, source deleted = %v, want the source kept until the target is deleted", msg, source.deleted)
+	}
+
+	msg, err = m.Delete(ctx, ps)
+	if err != nil {
+		t.Fatalf("Delete() unexpected error = %v", err)
+	}
+	if msg != croType.StatusEmpty || !target.deleted || !source.deleted {
+		t.Errorf("Delete() = %q, want both postgres deleted", msg)
+	}
+	if len(ps.GetFinalizers()) != 0 {
+		t.Errorf("Delete() finalizers = %v, want none", ps.GetFinalizers())
+	}
+}
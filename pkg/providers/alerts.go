@@ -0,0 +1,144 @@
+package providers
+
+import (
+	"fmt"
+
+	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	defaultAlertFor                   = "5m"
+	defaultAlertSeverity              = "warning"
+	defaultAlertFreeStoragePercent    = 10
+	defaultAlertReplicationLagSeconds = 60
+)
+
+// ResourceAlerts configures the alerts maintained alongside a postgres or redis, every field is optional and the
+// alerts are created with the defaults unless they're disabled
+type ResourceAlerts struct {
+	// Disabled stops the alerts being created, existing alerts of the resource are removed
+	Disabled bool `json:"disabled,omitempty"`
+	// For is how long a condition must hold before the alert fires, in the prometheus duration format, defaults to 5m
+	For string `json:"for,omitempty"`
+	// Severity is the severity label of the alerts, defaults to warning
+	Severity string `json:"severity,omitempty"`
+	// FreeStoragePercent is the percentage of free storage of a postgres, or free memory of a redis, below which the
+	// alert fires, defaults to 10
+	FreeStoragePercent int `json:"freeStoragePercent,omitempty"`
+	// ReplicationLagSeconds is the replication lag above which the alert fires, defaults to 60
+	ReplicationLagSeconds int `json:"replicationLagSeconds,omitempty"`
+	// Labels are added to the prometheus rule, e.g. to match the rule selector of the prometheus evaluating it
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// BuildAlertRule returns the prometheus rule alerting on the availability, free storage and replication lag of a
+// postgres or redis, or nil if the alerts are disabled. The rules are built on the metrics exposed for aws resources
+func BuildAlertRule(rt ResourceType, ns, name string, alerts *ResourceAlerts) (*monitoringv1.PrometheusRule, error) {
+	if alerts == nil {
+		alerts = &ResourceAlerts{}
+	}
+	if alerts.Disabled {
+		return nil, nil
+	}
+	forDuration := alerts.For
+	if forDuration == "" {
+		forDuration = defaultAlertFor
+	}
+	if _, err := model.ParseDuration(forDuration); err != nil {
+		return nil, errorUtil.Wrapf(err, "invalid alert duration %s", forDuration)
+	}
+	severity := alerts.Severity
+	if severity == "" {
+		severity = defaultAlertSeverity
+	}
+	freeStoragePercent := alerts.FreeStoragePercent
+	if freeStoragePercent == 0 {
+		freeStoragePercent = defaultAlertFreeStoragePercent
+	}
+	if freeStoragePercent < 0 || freeStoragePercent > 100 {
+		return nil, errorUtil.Errorf("free storage percent %d of the alerts must be between 0 and 100", freeStoragePercent)
+	}
+	replicationLagSeconds := alerts.ReplicationLagSeconds
+	if replicationLagSeconds == 0 {
+		replicationLagSeconds = defaultAlertReplicationLagSeconds
+	}
+	if replicationLagSeconds < 0 {
+		return nil, errorUtil.Errorf("replication lag seconds %d of the alerts must not be negative", replicationLagSeconds)
+	}
+
+	selector := fmt.Sprintf(`{namespace=%q,resourceID=%q}`, ns, name)
+	var rules []monitoringv1.Rule
+	switch rt {
+	case PostgresResourceType:
+		rules = []monitoringv1.Rule{
+			{
+				Alert:       "PostgresInstanceUnavailable",
+				Expr:        intstr.FromString(fmt.Sprintf("%s%s == 0", resources.DefaultPostgresAvailMetricName, selector)),
+				Annotations: map[string]string{"message": fmt.Sprintf("postgres %s in namespace %s is unavailable", name, ns)},
+			},
+			{
+				Alert:       "PostgresFreeStorageLow",
+				Expr:        intstr.FromString(fmt.Sprintf("%s%s / on(namespace, resourceID) %s%s * 100 < %d", resources.DefaultPostgresFreeStorageMetricName, selector, resources.DefaultPostgresAllocatedStorageMetricName, selector, freeStoragePercent)),
+				Annotations: map[string]string{"message": fmt.Sprintf("postgres %s in namespace %s has less than %d%% free storage", name, ns, freeStoragePercent)},
+			},
+			{
+				Alert:       "PostgresReplicationLagHigh",
+				Expr:        intstr.FromString(fmt.Sprintf("%s%s > %d", resources.DefaultPostgresReplicaLagMetricName, selector, replicationLagSeconds)),
+				Annotations: map[string]string{"message": fmt.Sprintf("a replica of postgres %s in namespace %s is more than %ds behind", name, ns, replicationLagSeconds)},
+			},
+		}
+	case RedisResourceType:
+		rules = []monitoringv1.Rule{
+			{
+				Alert:       "RedisInstanceUnavailable",
+				Expr:        intstr.FromString(fmt.Sprintf("%s%s == 0", resources.DefaultRedisAvailMetricName, selector)),
+				Annotations: map[string]string{"message": fmt.Sprintf("redis %s in namespace %s is unavailable", name, ns)},
+			},
+			{
+				Alert:       "RedisFreeMemoryLow",
+				Expr:        intstr.FromString(fmt.Sprintf("%s%s > %d", resources.DefaultRedisMemoryUsageMetricName, selector, 100-freeStoragePercent)),
+				Annotations: map[string]string{"message": fmt.Sprintf("redis %s in namespace %s has less than %d%% free memory", name, ns, freeStoragePercent)},
+			},
+			{
+				Alert:       "RedisReplicationLagHigh",
+				Expr:        intstr.FromString(fmt.Sprintf("%s%s > %d", resources.DefaultRedisReplicationLagMetricName, selector, replicationLagSeconds)),
+				Annotations: map[string]string{"message": fmt.Sprintf("a replica of redis %s in namespace %s is more than %ds behind", name, ns, replicationLagSeconds)},
+			},
+		}
+	default:
+		return nil, errorUtil.Errorf("alerts are not supported for resource type %s", rt)
+	}
+	for i := range rules {
+		rules[i].For = forDuration
+		rules[i].Labels = map[string]string{
+			"severity":   severity,
+			"namespace":  ns,
+			"resourceID": name,
+		}
+	}
+	return &monitoringv1.PrometheusRule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      AlertRuleName(rt, name),
+			Namespace: ns,
+			Labels:    alerts.Labels,
+		},
+		Spec: monitoringv1.PrometheusRuleSpec{
+			Groups: []monitoringv1.RuleGroup{
+				{
+					Name:  fmt.Sprintf("%s-%s.rules", rt, name),
+					Rules: rules,
+				},
+			},
+		},
+	}, nil
+}
+
+// AlertRuleName returns the name of the prometheus rule maintained alongside a postgres or redis
+func AlertRuleName(rt ResourceType, name string) string {
+	return fmt.Sprintf("%s-%s-alerts", rt, name)
+}
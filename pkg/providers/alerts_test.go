@@ -0,0 +1,97 @@
+package providers
+
+import (
+	"testing"
+)
+
+func TestBuildAlertRule(t *testing.T) {
+	tests := []struct {
+		name       string
+		rt         ResourceType
+		alerts     *ResourceAlerts
+		wantNil    bool
+		wantAlerts map[string]string
+		wantFor    string
+		wantErr    bool
+	}{
+		{
+			name: "test postgres alerts with the defaults",
+			rt:   PostgresResourceType,
+			wantAlerts: map[string]string{
+				"PostgresInstanceUnavailable": `cro_postgres_available{namespace="test",resourceID="db"} == 0`,
+				"PostgresFreeStorageLow":      `cro_postgres_free_storage_average{namespace="test",resourceID="db"} / on(namespace, resourceID) cro_postgres_current_allocated_storage{namespace="test",resourceID="db"} * 100 < 10`,
+				"PostgresReplicationLagHigh":  `cro_postgres_replica_lag_average{namespace="test",resourceID="db"} > 60`,
+			},
+			wantFor: "5m",
+		},
+		{
+			name:   "test redis alerts with configured thresholds",
+			rt:     RedisResourceType,
+			alerts: &ResourceAlerts{For: "10m", FreeStoragePercent: 20, ReplicationLagSeconds: 30},
+			wantAlerts: map[string]string{
+				"RedisInstanceUnavailable": `cro_redis_available{namespace="test",resourceID="db"} == 0`,
+				"RedisFreeMemoryLow":       `cro_redis_memory_usage_percentage_average{namespace="test",resourceID="db"} > 80`,
+				"RedisReplicationLagHigh":  `cro_redis_replication_lag_average{namespace="test",resourceID="db"} > 30`,
+			},
+			wantFor: "10m",
+		},
+		{
+			name:    "test no rule when the alerts are disabled",
+			rt:      PostgresResourceType,
+			alerts:  &ResourceAlerts{Disabled: true},
+			wantNil: true,
+		},
+		{
+			name:    "test error on an invalid duration",
+			rt:      PostgresResourceType,
+			alerts:  &ResourceAlerts{For: "five minutes"},
+			wantErr: true,
+		},
+		{
+			name:    "test error on an invalid free storage percent",
+			rt:      PostgresResourceType,
+			alerts:  &ResourceAlerts{FreeStoragePercent: 101},
+			wantErr: true,
+		},
+		{
+			name:    "test error on an unsupported resource type",
+			rt:      BlobStorageResourceType,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildAlertRule(tt.rt, "test", "db", tt.alerts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BuildAlertRule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (got == nil) != tt.wantNil {
+				t.Fatalf("BuildAlertRule() = %v, wantNil %v", got, tt.wantNil)
+			}
+			if tt.wantNil {
+				return
+			}
+			if got.Name != AlertRuleName(tt.rt, "db") || got.Namespace != "test" {
+				t.Errorf("BuildAlertRule() rule %s/%s, want test/%s", got.Namespace, got.Name, AlertRuleName(tt.rt, "db"))
+			}
+			rules := got.Spec.Groups[0].Rules
+			if len(rules) != len(tt.wantAlerts) {
+				t.Fatalf("BuildAlertRule() rules = %d, want %d", len(rules), len(tt.wantAlerts))
+			}
+			for _, r := range rules {
+				if r.Expr.String() != tt.wantAlerts[r.Alert] {
+					t.Errorf("BuildAlertRule() %s expr = %s, want %s", r.Alert, r.Expr.String(), tt.wantAlerts[r.Alert])
+				}
+				if r.For != tt.wantFor {
+					t.Errorf("BuildAlertRule() %s for = %s, want %s", r.Alert, r.For, tt.wantFor)
+				}
+				if r.Labels["severity"] != "warning" {
+					t.Errorf("BuildAlertRule() %s severity = %s, want warning", r.Alert, r.Labels["severity"])
+				}
+			}
+		})
+	}
+}
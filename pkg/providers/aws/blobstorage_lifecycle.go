@@ -0,0 +1,175 @@
+package aws
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	errorUtil "github.com/pkg/errors"
+)
+
+// s3ErrCodeNoSuchLifecycleConfiguration is returned when getting the lifecycle configuration of a bucket without one
+const s3ErrCodeNoSuchLifecycleConfiguration = "NoSuchLifecycleConfiguration"
+
+// BucketLifecycle configures the lifecycle rules of the s3 buckets of a blobstorage strategy. The rules replace any
+// rules set on the bucket outside of the operator, an empty list of rules removes them all
+type BucketLifecycle struct {
+	Rules []BucketLifecycleRule `json:"rules"`
+}
+
+// BucketLifecycleRule expires or transitions the objects of a bucket once they reach an age
+type BucketLifecycleRule struct {
+	// ID identifies the rule in the bucket, defaults to rule-<index>
+	ID string `json:"id,omitempty"`
+	// Prefix limits the rule to objects with keys starting with the prefix, the rule applies to all objects if unset
+	Prefix string `json:"prefix,omitempty"`
+	// ExpirationDays is the age in days at which objects are deleted
+	ExpirationDays int64 `json:"expirationDays,omitempty"`
+	// Transitions move objects to a cheaper storage class, e.g. STANDARD_IA or GLACIER, once they reach an age
+	Transitions []BucketLifecycleTransition `json:"transitions,omitempty"`
+	// AbortIncompleteMultipartUploadDays is the age in days at which incomplete multipart uploads are aborted
+	AbortIncompleteMultipartUploadDays int64 `json:"abortIncompleteMultipartUploadDays,omitempty"`
+}
+
+// BucketLifecycleTransition moves objects to the storage class once they're days old
+type BucketLifecycleTransition struct {
+	Days         int64  `json:"days"`
+	StorageClass string `json:"storageClass"`
+}
+
+// reconcileBucketLifecycle sets the lifecycle rules of the strategy on the bucket whenever they differ from the rules
+// of the bucket. The rules of the bucket are left untouched if the strategy doesn't configure a lifecycle
+func reconcileBucketLifecycle(s3svc s3iface.S3API, bucket string, lifecycle *BucketLifecycle) error {
+	if lifecycle == nil {
+		return nil
+	}
+	desired, err := buildLifecycleRules(lifecycle)
+	if err != nil {
+		return errorUtil.Wrapf(err, "invalid lifecycle configuration for bucket %s", bucket)
+	}
+
+	var current []*s3.LifecycleRule
+	output, err := s3svc.GetBucketLifecycleConfiguration(&s3.GetBucketLifecycleConfigurationInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); !ok || awsErr.Code() != s3ErrCodeNoSuchLifecycleConfiguration {
+			return errorUtil.Wrapf(err, "failed to get lifecycle configuration of bucket %s", bucket)
+		}
+	} else {
+		current = output.Rules
+	}
+
+	if lifecycleRulesEqual(desired, current) {
+		return nil
+	}
+	if len(desired) == 0 {
+		if _, err := s3svc.DeleteBucketLifecycle(&s3.DeleteBucketLifecycleInput{Bucket: aws.String(bucket)}); err != nil {
+			return errorUtil.Wrapf(err, "failed to delete lifecycle configuration of bucket %s", bucket)
+		}
+		return nil
+	}
+	if _, err := s3svc.PutBucketLifecycleConfiguration(&s3.PutBucketLifecycleConfigurationInput{
+		Bucket:                 aws.String(bucket),
+		LifecycleConfiguration: &s3.BucketLifecycleConfiguration{Rules: desired},
+	}); err != nil {
+		return errorUtil.Wrapf(err, "failed to put lifecycle configuration of bucket %s", bucket)
+	}
+	return nil
+}
+
+// buildLifecycleRules converts the lifecycle of the strategy to s3 lifecycle rules, validating each rule does something
+// and only transitions to storage classes s3 supports
+func buildLifecycleRules(lifecycle *BucketLifecycle) ([]*s3.LifecycleRule, error) {
+	storageClasses := map[string]bool{}
+	for _, sc := range s3.TransitionStorageClass_Values() {
+		storageClasses[sc] = true
+	}
+	ids := map[string]bool{}
+	var rules []*s3.LifecycleRule
+	for i, r := range lifecycle.Rules {
+		id := r.ID
+		if id == "" {
+			id = fmt.Sprintf("rule-%d", i)
+		}
+		if ids[id] {
+			return nil, errorUtil.Errorf("duplicate lifecycle rule id %s", id)
+		}
+		ids[id] = true
+		if r.ExpirationDays <= 0 && len(r.Transitions) == 0 && r.AbortIncompleteMultipartUploadDays <= 0 {
+			return nil, errorUtil.Errorf("lifecycle rule %s must set expirationDays, transitions or abortIncompleteMultipartUploadDays", id)
+		}
+
+		rule := &s3.LifecycleRule{
+			ID:     aws.String(id),
+			Status: aws.String(s3.ExpirationStatusEnabled),
+			Filter: &s3.LifecycleRuleFilter{Prefix: aws.String(r.Prefix)},
+		}
+		if r.ExpirationDays > 0 {
+			rule.Expiration = &s3.LifecycleExpiration{Days: aws.Int64(r.ExpirationDays)}
+		}
+		for _, t := range r.Transitions {
+			if !storageClasses[t.StorageClass] {
+				return nil, errorUtil.Errorf("lifecycle rule %s transitions to unsupported storage class %q", id, t.StorageClass)
+			}
+			if t.Days <= 0 {
+				return nil, errorUtil.Errorf("lifecycle rule %s transitions to %s after %d days, days must be positive", id, t.StorageClass, t.Days)
+			}
+			if r.ExpirationDays > 0 && t.Days >= r.ExpirationDays {
+				return nil, errorUtil.Errorf("lifecycle rule %s transitions to %s after objects expire", id, t.StorageClass)
+			}
+			rule.Transitions = append(rule.Transitions, &s3.Transition{
+				Days:         aws.Int64(t.Days),
+				StorageClass: aws.String(t.StorageClass),
+			})
+		}
+		if r.AbortIncompleteMultipartUploadDays > 0 {
+			rule.AbortIncompleteMultipartUpload = &s3.AbortIncompleteMultipartUpload{
+				DaysAfterInitiation: aws.Int64(r.AbortIncompleteMultipartUploadDays),
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// lifecycleRulesEqual compares the rules by the fields the operator sets, s3 returns rules with unset fields either
+// nil or empty so both are treated the same
+func lifecycleRulesEqual(desired, current []*s3.LifecycleRule) bool {
+	if len(desired) != len(current) {
+		return false
+	}
+	for i := range desired {
+		if !reflect.DeepEqual(normalizeLifecycleRule(desired[i]), normalizeLifecycleRule(current[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeLifecycleRule(rule *s3.LifecycleRule) *s3.LifecycleRule {
+	normalized := &s3.LifecycleRule{
+		ID:                             rule.ID,
+		Status:                         rule.Status,
+		Expiration:                     rule.Expiration,
+		AbortIncompleteMultipartUpload: rule.AbortIncompleteMultipartUpload,
+		NoncurrentVersionExpiration:    rule.NoncurrentVersionExpiration,
+	}
+	if aws.StringValue(rule.Prefix) != "" {
+		normalized.Prefix = rule.Prefix
+	}
+	// a filter of an empty prefix applies to every object, the same as no filter
+	if rule.Filter != nil && (aws.StringValue(rule.Filter.Prefix) != "" || rule.Filter.And != nil || rule.Filter.Tag != nil || rule.Filter.ObjectSizeGreaterThan != nil || rule.Filter.ObjectSizeLessThan != nil) {
+		normalized.Filter = rule.Filter
+	}
+	if len(rule.Transitions) > 0 {
+		normalized.Transitions = rule.Transitions
+	}
+	if len(rule.NoncurrentVersionTransitions) > 0 {
+		normalized.NoncurrentVersionTransitions = rule.NoncurrentVersionTransitions
+	}
+	return normalized
+}
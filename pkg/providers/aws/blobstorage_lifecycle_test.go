@@ -0,0 +1,142 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func TestBuildLifecycleRules(t *testing.T) {
+	tests := []struct {
+		name      string
+		lifecycle *BucketLifecycle
+		wantErr   bool
+		wantIDs   []string
+	}{
+		{
+			name: "test rules default their ids",
+			lifecycle: &BucketLifecycle{Rules: []BucketLifecycleRule{
+				{ExpirationDays: 365, Transitions: []BucketLifecycleTransition{{Days: 30, StorageClass: s3.TransitionStorageClassStandardIa}, {Days: 90, StorageClass: s3.TransitionStorageClassGlacier}}},
+				{ID: "abort-uploads", AbortIncompleteMultipartUploadDays: 7},
+			}},
+			wantIDs: []string{"rule-0", "abort-uploads"},
+		},
+		{
+			name:      "test duplicate ids fail",
+			lifecycle: &BucketLifecycle{Rules: []BucketLifecycleRule{{ID: "a", ExpirationDays: 1}, {ID: "a", ExpirationDays: 2}}},
+			wantErr:   true,
+		},
+		{
+			name:      "test rules without an action fail",
+			lifecycle: &BucketLifecycle{Rules: []BucketLifecycleRule{{ID: "a", Prefix: "logs/"}}},
+			wantErr:   true,
+		},
+		{
+			name:      "test unsupported storage classes fail",
+			lifecycle: &BucketLifecycle{Rules: []BucketLifecycleRule{{Transitions: []BucketLifecycleTransition{{Days: 30, StorageClass: "COLD"}}}}},
+			wantErr:   true,
+		},
+		{
+			name:      "test transitions after expiration fail",
+			lifecycle: &BucketLifecycle{Rules: []BucketLifecycleRule{{ExpirationDays: 30, Transitions: []BucketLifecycleTransition{{Days: 30, StorageClass: s3.TransitionStorageClassGlacier}}}}},
+			wantErr:   true,
+		},
+		{
+			name:      "test transitions without days fail",
+			lifecycle: &BucketLifecycle{Rules: []BucketLifecycleRule{{Transitions: []BucketLifecycleTransition{{StorageClass: s3.TransitionStorageClassGlacier}}}}},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildLifecycleRules(tt.lifecycle)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildLifecycleRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.wantIDs) {
+				t.Fatalf("buildLifecycleRules() got %d rules, want %d", len(got), len(tt.wantIDs))
+			}
+			for i, id := range tt.wantIDs {
+				if aws.StringValue(got[i].ID) != id {
+					t.Errorf("buildLifecycleRules() rule %d id = %s, want %s", i, aws.StringValue(got[i].ID), id)
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileBucketLifecycle(t *testing.T) {
+	lifecycle := &BucketLifecycle{Rules: []BucketLifecycleRule{
+		{ID: "expire-old", ExpirationDays: 365, Transitions: []BucketLifecycleTransition{{Days: 30, StorageClass: s3.TransitionStorageClassStandardIa}}},
+	}}
+	// s3 returns the rules it stores with an empty filter prefix and without unset fields
+	stored := []*s3.LifecycleRule{{
+		ID:          aws.String("expire-old"),
+		Status:      aws.String(s3.ExpirationStatusEnabled),
+		Filter:      &s3.LifecycleRuleFilter{},
+		Expiration:  &s3.LifecycleExpiration{Days: aws.Int64(365)},
+		Transitions: []*s3.Transition{{Days: aws.Int64(30), StorageClass: aws.String(s3.TransitionStorageClassStandardIa)}},
+	}}
+	changed := []*s3.LifecycleRule{{
+		ID:         aws.String("expire-old"),
+		Status:     aws.String(s3.ExpirationStatusEnabled),
+		Expiration: &s3.LifecycleExpiration{Days: aws.Int64(30)},
+	}}
+	tests := []struct {
+		name        string
+		lifecycle   *BucketLifecycle
+		s3svc       *mockS3Svc
+		wantPuts    int
+		wantDeletes int
+		wantErr     bool
+	}{
+		{
+			name:      "test rules are put on buckets without a lifecycle configuration",
+			lifecycle: lifecycle,
+			s3svc:     &mockS3Svc{},
+			wantPuts:  1,
+		},
+		{
+			name:      "test rules matching the bucket are left untouched",
+			lifecycle: lifecycle,
+			s3svc:     &mockS3Svc{lifecycleRules: stored},
+		},
+		{
+			name:      "test rules changed outside the operator are reverted",
+			lifecycle: lifecycle,
+			s3svc:     &mockS3Svc{lifecycleRules: changed},
+			wantPuts:  1,
+		},
+		{
+			name:        "test an empty list of rules removes the rules of the bucket",
+			lifecycle:   &BucketLifecycle{},
+			s3svc:       &mockS3Svc{lifecycleRules: stored},
+			wantDeletes: 1,
+		},
+		{
+			name:  "test buckets are left untouched without a lifecycle",
+			s3svc: &mockS3Svc{lifecycleRules: changed},
+		},
+		{
+			name:      "test invalid rules fail",
+			lifecycle: &BucketLifecycle{Rules: []BucketLifecycleRule{{ID: "nothing"}}},
+			s3svc:     &mockS3Svc{},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := reconcileBucketLifecycle(tt.s3svc, "test", tt.lifecycle)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("reconcileBucketLifecycle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.s3svc.lifecyclePuts != tt.wantPuts {
+				t.Errorf("reconcileBucketLifecycle() puts = %d, want %d", tt.s3svc.lifecyclePuts, tt.wantPuts)
+			}
+			if tt.s3svc.lifecycleDeletes != tt.wantDeletes {
+				t.Errorf("reconcileBucketLifecycle() deletes = %d, want %d", tt.s3svc.lifecycleDeletes, tt.wantDeletes)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package client
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	"github.com/aws/aws-sdk-go/service/elasticache/elasticacheiface"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+)
+
+// DescribeCacheTTL is how long the result of a describe call is shared by the reconciles making the same call, zero
+// disables the cache
+var DescribeCacheTTL = 10 * time.Second
+
+var describeResults = &describeCache{entries: map[string]*describeEntry{}}
+
+// RDS is an rds client sharing the results of DescribeDBInstances across concurrent reconciles. every reconcile of a
+// postgres lists every instance of the region, which is the call throttled first with many postgres resources
+type RDS struct {
+	rdsiface.RDSAPI
+	region string
+}
+
+var _ rdsiface.RDSAPI = &RDS{}
+
+// NewRDS returns an rds client of the session, which should be set up with Configure so the cached instances are
+// dropped once the client changes an instance
+func NewRDS(sess *session.Session) *RDS {
+	return &RDS{RDSAPI: rds.New(sess), region: aws.StringValue(sess.Config.Region)}
+}
+
+func (c *RDS) DescribeDBInstances(input *rds.DescribeDBInstancesInput) (*rds.DescribeDBInstancesOutput, error) {
+	return c.DescribeDBInstancesWithContext(aws.BackgroundContext(), input)
+}
+
+func (c *RDS) DescribeDBInstancesWithContext(ctx aws.Context, input *rds.DescribeDBInstancesInput, opts ...request.Option) (*rds.DescribeDBInstancesOutput, error) {
+	if input == nil {
+		input = &rds.DescribeDBInstancesInput{}
+	}
+	out, err := describeResults.get(ServiceRDS, c.region, input.String(), func() (interface{}, error) {
+		return c.RDSAPI.DescribeDBInstancesWithContext(ctx, input, opts...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.(*rds.DescribeDBInstancesOutput), nil
+}
+
+// ElastiCache is an elasticache client sharing the results of DescribeReplicationGroups across concurrent reconciles
+type ElastiCache struct {
+	elasticacheiface.ElastiCacheAPI
+	region string
+}
+
+var _ elasticacheiface.ElastiCacheAPI = &ElastiCache{}
+
+// NewElastiCache returns an elasticache client of the session, which should be set up with Configure so the cached
+// replication groups are dropped once the client changes a replication group
+func NewElastiCache(sess *session.Session) *ElastiCache {
+	return &ElastiCache{ElastiCacheAPI: elasticache.New(sess), region: aws.StringValue(sess.Config.Region)}
+}
+
+func (c *ElastiCache) DescribeReplicationGroups(input *elasticache.DescribeReplicationGroupsInput) (*elasticache.DescribeReplicationGroupsOutput, error) {
+	return c.DescribeReplicationGroupsWithContext(aws.BackgroundContext(), input)
+}
+
+func (c *ElastiCache) DescribeReplicationGroupsWithContext(ctx aws.Context, input *elasticache.DescribeReplicationGroupsInput, opts ...request.Option) (*elasticache.DescribeReplicationGroupsOutput, error) {
+	if input == nil {
+		input = &elasticache.DescribeReplicationGroupsInput{}
+	}
+	out, err := describeResults.get(ServiceElastiCache, c.region, input.String(), func() (interface{}, error) {
+		return c.ElastiCacheAPI.DescribeReplicationGroupsWithContext(ctx, input, opts...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.(*elasticache.DescribeReplicationGroupsOutput), nil
+}
+
+// describeCache holds the results of describe calls keyed by service, region and input. a call made while the same
+// call is in flight waits for its result instead of calling the service again
+type describeCache struct {
+	mu      sync.Mutex
+	entries map[string]*describeEntry
+}
+
+type describeEntry struct {
+	service string
+	region  string
+	done    chan struct{}
+	expires time.Time
+	out     interface{}
+	err     error
+}
+
+// get returns a copy of the cached result of the call, or makes the call. failed calls aren't cached, only shared with
+// the calls waiting for them
+func (c *describeCache) get(service, region, input string, describe func() (interface{}, error)) (interface{}, error) {
+	if DescribeCacheTTL <= 0 {
+		return describe()
+	}
+	key := fmt.Sprintf("%s/%s/%s", service, region, input)
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if !ok || entry.expired() {
+		c.pruneExpired()
+		entry = &describeEntry{service: service, region: region, done: make(chan struct{})}
+		c.entries[key] = entry
+		c.mu.Unlock()
+		entry.out, entry.err = describe()
+		c.mu.Lock()
+		entry.expires = time.Now().Add(DescribeCacheTTL)
+		if entry.err != nil && c.entries[key] == entry {
+			delete(c.entries, key)
+		}
+		close(entry.done)
+	}
+	c.mu.Unlock()
+	<-entry.done
+	if entry.err != nil {
+		return nil, entry.err
+	}
+	// callers change the results they're given, e.g. while building the status of a resource
+	return awsutil.CopyOf(entry.out), nil
+}
+
+// invalidate drops the results of the service in the region, calls in flight still return their result to the calls
+// waiting for them
+func (c *describeCache) invalidate(service, region string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, entry := range c.entries {
+		if entry.service == service && entry.region == region {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// pruneExpired drops the expired results, the caller holds the cache lock
+func (c *describeCache) pruneExpired() {
+	for key, entry := range c.entries {
+		if entry.expired() {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// expired returns true if the call is done and its result is older than the ttl, the caller holds the cache lock
+func (e *describeEntry) expired() bool {
+	select {
+	case <-e.done:
+		return time.Now().After(e.expires)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,193 @@
+// Package client configures the aws sdk clients of the aws providers to share the api quotas of the account. Requests
+// to a service are rate limited across every reconcile of the operator, throttled requests are retried with
+// exponential backoff, every request fails once its call deadline passes and the results of the describe calls polled
+// by every reconcile are cached for a short time
+package client
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	errorUtil "github.com/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+const (
+	ServiceRDS         = "rds"
+	ServiceElastiCache = "elasticache"
+
+	// MaxRetries is how often a request failing with an error other than throttling is retried, the default of the sdk
+	MaxRetries = client.DefaultRetryerMaxNumRetries
+	// MaxThrottleRetries is how often a throttled request is retried before its error is returned
+	MaxThrottleRetries = 8
+	// MinThrottleDelay and MaxThrottleDelay bound the backoff of a throttled request, the delay doubles on every retry
+	MinThrottleDelay = time.Second
+	MaxThrottleDelay = 30 * time.Second
+
+	rateLimitHandlerName  = "cro.RateLimit"
+	invalidateHandlerName = "cro.InvalidateDescribeCache"
+	deadlineHandlerName   = "cro.CallDeadline"
+)
+
+var (
+	// RateLimits are the requests per second allowed to each service by every reconcile together, keyed by the service
+	// name of the sdk, e.g. rds. Requests to services without a limit aren't rate limited
+	RateLimits = DefaultRateLimits()
+
+	limiters = &limiterSet{limiters: map[string]*rate.Limiter{}}
+)
+
+// DefaultRateLimits returns the rate limits of the services if they aren't configured, well below the api quotas of
+// the services so the operator leaves room for other clients of the account
+func DefaultRateLimits() map[string]float64 {
+	return map[string]float64{
+		ServiceRDS:         5,
+		ServiceElastiCache: 5,
+	}
+}
+
+// ParseRateLimits parses a comma separated list of service rate limits in requests per second, e.g. rds=5, over the
+// default rate limits
+func ParseRateLimits(s string) (map[string]float64, error) {
+	limits := DefaultRateLimits()
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, errorUtil.Errorf("invalid aws rate limit %s, expected service=requests per second", entry)
+		}
+		limit, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil || limit <= 0 {
+			return nil, errorUtil.Errorf("invalid aws rate limit %s, limit must be a positive number", entry)
+		}
+		limits[strings.TrimSpace(parts[0])] = limit
+	}
+	return limits, nil
+}
+
+// Configure sets up the clients built from the session to fail requests once their call deadline passes, rate limit
+// their requests, retry throttled requests with backoff and invalidate the cached describe results of a service once a
+// request changes its resources
+func Configure(sess *session.Session) *session.Session {
+	sess.Config.Retryer = NewRetryer()
+	sess.Handlers.Validate.PushFrontNamed(request.NamedHandler{Name: deadlineHandlerName, Fn: setCallDeadline})
+	sess.Handlers.Sign.PushFrontNamed(request.NamedHandler{Name: rateLimitHandlerName, Fn: waitForRateLimit})
+	sess.Handlers.Complete.PushBackNamed(request.NamedHandler{Name: invalidateHandlerName, Fn: invalidateDescribeCache})
+	return sess
+}
+
+// Retryer retries throttled requests more often and with a longer backoff than the default retryer of the sdk, as
+// throttling only clears once the requests of every reconcile slow down
+type Retryer struct {
+	client.DefaultRetryer
+}
+
+var _ request.Retryer = Retryer{}
+
+// NewRetryer returns a retryer retrying throttled requests up to MaxThrottleRetries times and other retryable errors
+// up to MaxRetries times
+func NewRetryer() Retryer {
+	return Retryer{DefaultRetryer: client.DefaultRetryer{
+		NumMaxRetries:    MaxThrottleRetries,
+		MinThrottleDelay: MinThrottleDelay,
+		MaxThrottleDelay: MaxThrottleDelay,
+	}}
+}
+
+// ShouldRetry returns true if the request is throttled and hasn't been retried MaxThrottleRetries times, or failed
+// with another retryable error and hasn't been retried MaxRetries times
+func (r Retryer) ShouldRetry(req *request.Request) bool {
+	if req.IsErrorThrottle() {
+		return req.RetryCount < MaxThrottleRetries
+	}
+	return req.RetryCount < MaxRetries && r.DefaultRetryer.ShouldRetry(req)
+}
+
+// RetryRules returns the delay before the request is retried, the backoff of the default retryer of the sdk capped at
+// the max throttle delay
+func (r Retryer) RetryRules(req *request.Request) time.Duration {
+	return time.Duration(math.Min(float64(r.DefaultRetryer.RetryRules(req)), float64(MaxThrottleDelay)))
+}
+
+// setCallDeadline bounds a request, including its retries and the waits for the rate limit, by the call deadline of the
+// operation of its service, e.g. aws-rds ModifyDBInstance. requests failing because the deadline passed are counted
+func setCallDeadline(r *request.Request) {
+	if r.Operation == nil {
+		return
+	}
+	provider, operation := callProvider(r.ClientInfo.ServiceName), r.Operation.Name
+	ctx, cancel := providers.WithCallDeadline(r.Context(), provider, operation)
+	r.SetContext(ctx)
+	r.Handlers.Complete.PushBackNamed(request.NamedHandler{Name: deadlineHandlerName, Fn: func(r *request.Request) {
+		defer cancel()
+		providers.ObserveCallDeadline(ctx, provider, operation, r.Error)
+	}})
+}
+
+// callProvider returns the provider name the call deadlines of a service are configured with, e.g. aws-rds
+func callProvider(service string) string {
+	return "aws-" + service
+}
+
+// waitForRateLimit blocks every attempt of a request, including retries, until the rate limit of its service allows
+// it. the request fails if its context is done first
+func waitForRateLimit(r *request.Request) {
+	limiter := limiters.get(r.ClientInfo.ServiceName)
+	if limiter == nil {
+		return
+	}
+	if err := limiter.Wait(r.Context()); err != nil {
+		r.Error = errorUtil.Wrapf(err, "failed to wait for the rate limit of %s", r.ClientInfo.ServiceName)
+	}
+}
+
+// invalidateDescribeCache drops the cached describe results of the service in the region of a request that succeeded
+// and may have changed its resources, so the next reconcile sees the change
+func invalidateDescribeCache(r *request.Request) {
+	if r.Error != nil || r.Operation == nil || isReadOperation(r.Operation.Name) || isTagOperation(r.Operation.Name) {
+		return
+	}
+	describeResults.invalidate(r.ClientInfo.ServiceName, aws.StringValue(r.Config.Region))
+}
+
+func isReadOperation(name string) bool {
+	return strings.HasPrefix(name, "Describe") || strings.HasPrefix(name, "List") || strings.HasPrefix(name, "Get")
+}
+
+// isTagOperation returns true for the tagging of a resource, which every reconcile of an available resource does and
+// which doesn't change the state the reconciles read from the cached results
+func isTagOperation(name string) bool {
+	return name == "AddTagsToResource" || name == "RemoveTagsFromResource"
+}
+
+type limiterSet struct {
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// get returns the limiter shared by every client of the service, nil if the service isn't rate limited. limiters are
+// rebuilt if the rate limit of their service is changed
+func (s *limiterSet) get(service string) *rate.Limiter {
+	limit, ok := RateLimits[service]
+	if !ok {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	limiter, ok := s.limiters[service]
+	if !ok || limiter.Limit() != rate.Limit(limit) {
+		limiter = rate.NewLimiter(rate.Limit(limit), int(math.Max(1, math.Ceil(limit))))
+		s.limiters[service] = limiter
+	}
+	return limiter
+}
@@ -0,0 +1,195 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+)
+
+type mockRDSClient struct {
+	rdsiface.RDSAPI
+	mu    sync.Mutex
+	calls int
+}
+
+func (m *mockRDSClient) DescribeDBInstancesWithContext(aws.Context, *rds.DescribeDBInstancesInput, ...request.Option) (*rds.DescribeDBInstancesOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	// slow enough for concurrent calls to overlap
+	time.Sleep(10 * time.Millisecond)
+	return &rds.DescribeDBInstancesOutput{DBInstances: []*rds.DBInstance{{DBInstanceIdentifier: aws.String("test")}}}, nil
+}
+
+func buildTestRequest(service, operation string, err error) *request.Request {
+	r := request.New(aws.Config{Region: aws.String("eu-west-1")}, metadata.ClientInfo{ServiceName: service}, request.Handlers{}, nil, &request.Operation{Name: operation}, nil, nil)
+	r.Error = err
+	r.HTTPResponse = &http.Response{StatusCode: http.StatusBadRequest, Header: http.Header{}}
+	return r
+}
+
+func TestParseRateLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    float64
+		wantErr bool
+	}{
+		{
+			name: "test the default rate limit of a service is kept",
+			s:    "",
+			want: 5,
+		},
+		{
+			name: "test the rate limit of a service is overridden",
+			s:    "rds=2.5, elasticache=10",
+			want: 2.5,
+		},
+		{
+			name:    "test a rate limit without a service is invalid",
+			s:       "10",
+			wantErr: true,
+		},
+		{
+			name:    "test a rate limit of zero is invalid",
+			s:       "rds=0",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRateLimits(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRateLimits() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got[ServiceRDS] != tt.want {
+				t.Errorf("ParseRateLimits() rds = %v, want %v", got[ServiceRDS], tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryer_ShouldRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		retryCount int
+		want       bool
+	}{
+		{
+			name:       "test a throttled request is retried beyond the default retries",
+			err:        awserr.New("Throttling", "rate exceeded", nil),
+			retryCount: MaxRetries,
+			want:       true,
+		},
+		{
+			name:       "test a throttled request isn't retried beyond the throttle retries",
+			err:        awserr.New("Throttling", "rate exceeded", nil),
+			retryCount: MaxThrottleRetries,
+			want:       false,
+		},
+		{
+			name:       "test other retryable errors are retried up to the default retries",
+			err:        awserr.New(request.ErrCodeSerialization, "failed to read response", nil),
+			retryCount: MaxRetries,
+			want:       false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := buildTestRequest(ServiceRDS, "DescribeDBInstances", tt.err)
+			r.RetryCount = tt.retryCount
+			if got := NewRetryer().ShouldRetry(r); got != tt.want {
+				t.Errorf("ShouldRetry() = %v, want %v", got, tt.want)
+			}
+			if delay := NewRetryer().RetryRules(r); delay > MaxThrottleDelay {
+				t.Errorf("RetryRules() = %v, want at most %v", delay, MaxThrottleDelay)
+			}
+		})
+	}
+}
+
+func TestRDS_DescribeDBInstances(t *testing.T) {
+	defer describeResults.invalidate(ServiceRDS, "eu-west-1")
+	mock := &mockRDSClient{}
+	c := &RDS{RDSAPI: mock, region: "eu-west-1"}
+
+	// concurrent reconciles share a single call
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.DescribeDBInstances(&rds.DescribeDBInstancesInput{}); err != nil {
+				t.Error("DescribeDBInstances() failed", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if mock.calls != 1 {
+		t.Fatalf("DescribeDBInstances() called rds %d times, want 1", mock.calls)
+	}
+
+	// callers get their own copy of the result
+	out, err := c.DescribeDBInstances(&rds.DescribeDBInstancesInput{})
+	if err != nil {
+		t.Fatal("DescribeDBInstances() failed", err)
+	}
+	out.DBInstances[0].DBInstanceIdentifier = aws.String("changed")
+	out, err = c.DescribeDBInstances(&rds.DescribeDBInstancesInput{})
+	if err != nil {
+		t.Fatal("DescribeDBInstances() failed", err)
+	}
+	if aws.StringValue(out.DBInstances[0].DBInstanceIdentifier) != "test" || mock.calls != 1 {
+		t.Fatalf("DescribeDBInstances() = %s after %d calls, want the cached test instance", aws.StringValue(out.DBInstances[0].DBInstanceIdentifier), mock.calls)
+	}
+
+	// reads, tagging and failed requests keep the cache, successful changes drop it
+	invalidateDescribeCache(buildTestRequest(ServiceRDS, "DescribeDBSnapshots", nil))
+	invalidateDescribeCache(buildTestRequest(ServiceRDS, "AddTagsToResource", nil))
+	invalidateDescribeCache(buildTestRequest(ServiceRDS, "ModifyDBInstance", awserr.New("InvalidParameterValue", "invalid", nil)))
+	invalidateDescribeCache(buildTestRequest(ServiceElastiCache, "ModifyReplicationGroup", nil))
+	if _, err := c.DescribeDBInstances(&rds.DescribeDBInstancesInput{}); err != nil || mock.calls != 1 {
+		t.Fatalf("DescribeDBInstances() called rds %d times, want the cache to be kept", mock.calls)
+	}
+	invalidateDescribeCache(buildTestRequest(ServiceRDS, "ModifyDBInstance", nil))
+	if _, err := c.DescribeDBInstances(&rds.DescribeDBInstancesInput{}); err != nil || mock.calls != 2 {
+		t.Fatalf("DescribeDBInstances() called rds %d times, want the cache to be dropped", mock.calls)
+	}
+}
+
+func TestSetCallDeadline(t *testing.T) {
+	defer func() {
+		providers.CallDeadlines = providers.DefaultCallDeadlines()
+	}()
+	providers.CallDeadlines = []providers.CallDeadline{{Provider: "aws-rds", Operation: "Modify*", Timeout: time.Millisecond}}
+
+	r := buildTestRequest(ServiceRDS, "ModifyDBInstance", nil)
+	setCallDeadline(r)
+	if _, ok := r.Context().Deadline(); !ok {
+		t.Fatal("setCallDeadline() didn't set the deadline of aws-rds ModifyDBInstance")
+	}
+	<-r.Context().Done()
+	r.Error = awserr.New(request.CanceledErrorCode, "request context canceled", r.Context().Err())
+	r.Handlers.Complete.Run(r)
+
+	r = buildTestRequest(ServiceElastiCache, "ModifyReplicationGroup", nil)
+	setCallDeadline(r)
+	if _, ok := r.Context().Deadline(); ok {
+		t.Error("setCallDeadline() set a deadline of aws-elasticache ModifyReplicationGroup, want none")
+	}
+	r.Handlers.Complete.Run(r)
+	if r.Context().Err() != context.Canceled {
+		t.Errorf("setCallDeadline() context error = %v, want the context canceled once the request completes", r.Context().Err())
+	}
+}
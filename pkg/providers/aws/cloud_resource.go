@@ -0,0 +1,40 @@
+package aws
+
+import (
+	"fmt"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+)
+
+// buildCloudResourceStatus returns the cloud resource status of an aws resource, the region is read from its arn
+func buildCloudResourceStatus(id, resourceARN, host *string, port *int64) *croType.CloudResourceStatus {
+	status := &croType.CloudResourceStatus{
+		InstanceID: aws.StringValue(id),
+		ARN:        aws.StringValue(resourceARN),
+	}
+	if host != nil {
+		status.Endpoint = fmt.Sprintf("%s:%d", aws.StringValue(host), aws.Int64Value(port))
+	}
+	if parsed, err := arn.Parse(status.ARN); err == nil {
+		status.Region = parsed.Region
+	}
+	return status
+}
+
+// buildBucketCloudResourceStatus returns the cloud resource status of an s3 bucket, bucket arns don't include the
+// region of the bucket so it's taken from the strategy the bucket was created with
+func buildBucketCloudResourceStatus(bucket, region string) *croType.CloudResourceStatus {
+	partition := endpoints.AwsPartitionID
+	if p, ok := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), region); ok {
+		partition = p.ID()
+	}
+	return &croType.CloudResourceStatus{
+		InstanceID: bucket,
+		ARN:        arn.ARN{Partition: partition, Service: "s3", Resource: bucket}.String(),
+		Region:     region,
+	}
+}
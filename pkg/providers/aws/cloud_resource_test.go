@@ -0,0 +1,74 @@
+package aws
+
+import (
+	"reflect"
+	"testing"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestBuildCloudResourceStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		id   *string
+		arn  *string
+		host *string
+		port *int64
+		want *croType.CloudResourceStatus
+	}{
+		{
+			name: "test region is read from the arn",
+			id:   aws.String("test-id"),
+			arn:  aws.String("arn:aws:rds:eu-west-1:123456789012:db:test-id"),
+			host: aws.String("test-id.abc.eu-west-1.rds.amazonaws.com"),
+			port: aws.Int64(5432),
+			want: &croType.CloudResourceStatus{
+				InstanceID: "test-id",
+				ARN:        "arn:aws:rds:eu-west-1:123456789012:db:test-id",
+				Endpoint:   "test-id.abc.eu-west-1.rds.amazonaws.com:5432",
+				Region:     "eu-west-1",
+			},
+		},
+		{
+			name: "test region is empty without an arn",
+			id:   aws.String("test-id"),
+			want: &croType.CloudResourceStatus{InstanceID: "test-id"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildCloudResourceStatus(tt.id, tt.arn, tt.host, tt.port); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildCloudResourceStatus() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildBucketCloudResourceStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		region  string
+		wantARN string
+	}{
+		{
+			name:    "test bucket arn in the aws partition",
+			region:  "eu-west-1",
+			wantARN: "arn:aws:s3:::test-bucket",
+		},
+		{
+			name:    "test bucket arn in the china partition",
+			region:  "cn-north-1",
+			wantARN: "arn:aws-cn:s3:::test-bucket",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildBucketCloudResourceStatus("test-bucket", tt.region)
+			if got.ARN != tt.wantARN || got.Region != tt.region || got.InstanceID != "test-bucket" {
+				t.Errorf("buildBucketCloudResourceStatus() = %v, want arn %s in region %s", got, tt.wantARN, tt.region)
+			}
+		})
+	}
+}
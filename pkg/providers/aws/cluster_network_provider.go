@@ -28,7 +28,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
-	"reflect"
 	"sort"
 	"strings"
 
@@ -118,9 +117,11 @@ type NetworkProvider struct {
 	ElasticacheApi elasticacheiface.ElastiCacheAPI
 	Logger         *logrus.Entry
 	IsSTSCluster   bool
+	// Discovery configures how the cluster vpc is found, nil to discover it from the cluster tags
+	Discovery *NetworkDiscovery
 }
 
-func NewNetworkManager(session *session.Session, client client.Client, logger *logrus.Entry, isSTSCluster bool) *NetworkProvider {
+func NewNetworkManager(session *session.Session, client client.Client, logger *logrus.Entry, isSTSCluster bool, discovery *NetworkDiscovery) *NetworkProvider {
 	if logger == nil {
 		logger = logrus.NewEntry(logrus.StandardLogger())
 	}
@@ -131,6 +132,7 @@ func NewNetworkManager(session *session.Session, client client.Client, logger *l
 		ElasticacheApi: elasticache.New(session),
 		Logger:         logger.WithField("provider", "standalone_network_provider"),
 		IsSTSCluster:   isSTSCluster,
+		Discovery:      discovery,
 	}
 }
 
@@ -161,7 +163,7 @@ func (n *NetworkProvider) CreateNetwork(ctx context.Context, vpcCidrBlock *net.I
 		//By default, `integreatly.org/clusterID`.
 		//
 		//NOTE - Once a VPC is created we do not want to update it. To avoid changing cidr block
-		clusterVPC, err := getClusterVpc(ctx, n.Client, n.Ec2Api, n.Logger)
+		clusterVPC, err := getClusterVpc(ctx, n.Client, n.Ec2Api, n.Discovery, n.Logger)
 		if err != nil {
 			return nil, errorUtil.Wrap(err, "failed to get cluster vpc")
 		}
@@ -431,7 +433,7 @@ func (n *NetworkProvider) CreateNetworkConnection(ctx context.Context, network *
 	}
 
 	// we require the cluster vpc cidr block for standalone vpc route
-	clusterVpc, err := getClusterVpc(ctx, n.Client, n.Ec2Api, logger)
+	clusterVpc, err := getClusterVpc(ctx, n.Client, n.Ec2Api, n.Discovery, logger)
 	if err != nil {
 		return nil, errorUtil.Wrap(err, "error getting standalone vpc route tables")
 	}
@@ -534,7 +536,7 @@ func (n *NetworkProvider) DeleteNetworkConnection(ctx context.Context, networkPe
 func (n *NetworkProvider) CreateNetworkPeering(ctx context.Context, network *Network) (*NetworkPeering, error) {
 	logger := resources.NewActionLogger(n.Logger, "CreateNetworkPeering")
 
-	clusterVpc, err := getClusterVpc(ctx, n.Client, n.Ec2Api, n.Logger)
+	clusterVpc, err := getClusterVpc(ctx, n.Client, n.Ec2Api, n.Discovery, n.Logger)
 	if err != nil {
 		return nil, errorUtil.Wrap(err, "failed to get cluster vpc, no vpc found")
 	}
@@ -684,11 +686,18 @@ func (n *NetworkProvider) DeleteNetworkPeering(peering *NetworkPeering) error {
 //
 //this check allows us to maintain backwards compatibility with openshift clusters that used the cloud resource operator before this standalone vpc provider was added.
 //If this function returns false, we should continue using the backwards compatible approach of bundling resources in with the openshift cluster vpc.
+//It always returns false when the network discovery selects existing subnets, or a resource vpc, for resources to be placed in.
 func (n *NetworkProvider) IsEnabled(ctx context.Context) (bool, error) {
 	logger := n.Logger.WithField("action", "isEnabled")
 
+	// resources are placed in the existing subnets of the cluster vpc selected by the network discovery
+	if n.Discovery.UsesExistingSubnets() {
+		logger.Info("network discovery selects existing subnets, standalone vpc is not used")
+		return false, nil
+	}
+
 	//check if there is a cluster vpc already created.
-	foundVpc, err := getClusterVpc(ctx, n.Client, n.Ec2Api, logger)
+	foundVpc, err := getClusterVpc(ctx, n.Client, n.Ec2Api, n.Discovery, logger)
 	if err != nil {
 		return false, errorUtil.Wrap(err, "unable to get vpc")
 	}
@@ -769,7 +778,7 @@ func (n *NetworkProvider) DeleteBundledCloudResources(ctx context.Context) error
 	if securityGroup == nil {
 		return nil
 	}
-	vpc, err := getClusterVpc(ctx, n.Client, n.Ec2Api, logger)
+	vpc, err := getClusterVpc(ctx, n.Client, n.Ec2Api, n.Discovery, logger)
 	if err != nil {
 		return errorUtil.Wrap(err, "error getting cluster vpc")
 	}
@@ -787,7 +796,7 @@ func (n *NetworkProvider) getNetworkPeering(ctx context.Context, network *Networ
 	logger := resources.NewActionLogger(n.Logger, "getNetworkPeering")
 	// we will always peer with the openshift/kubernetes cluster vpc that this operator is running on
 	logger.Info("getting cluster vpc")
-	clusterVpc, err := getClusterVpc(ctx, n.Client, n.Ec2Api, logger)
+	clusterVpc, err := getClusterVpc(ctx, n.Client, n.Ec2Api, n.Discovery, logger)
 	if err != nil {
 		return nil, errorUtil.Wrap(err, "failed to get cluster vpc")
 	}
@@ -858,7 +867,7 @@ func (n *NetworkProvider) reconcileStandaloneSecurityGroup(ctx context.Context,
 	}
 
 	// get the cluster bundled vpc
-	clusterVpc, err := getClusterVpc(ctx, n.Client, n.Ec2Api, logger)
+	clusterVpc, err := getClusterVpc(ctx, n.Client, n.Ec2Api, n.Discovery, logger)
 	if err != nil {
 		return nil, errorUtil.Wrap(err, "failed to get cluster vpc")
 	}
@@ -932,34 +941,16 @@ func (n *NetworkProvider) reconcileStandaloneSecurityGroup(ctx context.Context,
 	// see for more -> https://docs.aws.amazon.com/vpc/latest/peering/vpc-peering-security-groups.html
 	// it is recommended by aws docs to use the cidr block from the peered vpc
 
-	// build ip permission
-	ipPermission := &ec2.IpPermission{
-		IpProtocol: aws.String("-1"),
-		IpRanges: []*ec2.IpRange{
-			{
-				CidrIp: clusterVpc.CidrBlock,
-			},
-		},
-	}
-
-	// ensure ip permission correct and valid in the standalone security group
-	for _, perm := range standaloneSecGroup.IpPermissions {
-		if reflect.DeepEqual(perm, ipPermission) {
-			logger.Infof("ip permissions are correct for security group %s", *standaloneSecGroup.GroupName)
-			return standaloneSecGroup, nil
-		}
+	// build ip permissions, the ingress rules of the network discovery narrow the default rule, e.g. to node subnets
+	ipPermissions, err := buildSecurityGroupIngress(n.Discovery, aws.StringValue(clusterVpc.CidrBlock))
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "error building security group ingress")
 	}
 
-	// authorize the security group ingres if it is not as expected
-	if _, err := n.Ec2Api.AuthorizeSecurityGroupIngress(&ec2.AuthorizeSecurityGroupIngressInput{
-		GroupId: aws.String(*standaloneSecGroup.GroupId),
-		IpPermissions: []*ec2.IpPermission{
-			ipPermission,
-		},
-	}); err != nil {
-		return nil, errorUtil.Wrap(err, "error authorizing security group ingress")
+	// ensure only the expected ip permissions are in place in the standalone security group
+	if err := reconcileSecurityGroupIngress(n.Ec2Api, standaloneSecGroup, ipPermissions, logger); err != nil {
+		return nil, errorUtil.Wrapf(err, "error reconciling ingress of security group %s", *standaloneSecGroup.GroupName)
 	}
-	logger.Infof("ip permissions have been updated to expected permissions for security group %s", *standaloneSecGroup.GroupName)
 	return standaloneSecGroup, nil
 }
 
@@ -1201,7 +1192,7 @@ func (n *NetworkProvider) getClusterRouteTables(ctx context.Context) ([]*ec2.Rou
 		return nil, errorUtil.Wrap(err, "failed to get route tables")
 	}
 
-	clusterVPC, err := getClusterVpc(ctx, n.Client, n.Ec2Api, n.Logger)
+	clusterVPC, err := getClusterVpc(ctx, n.Client, n.Ec2Api, n.Discovery, n.Logger)
 	if err != nil {
 		return nil, errorUtil.Wrap(err, "failed to get cluster vpc")
 	}
@@ -1597,7 +1588,7 @@ func (n *NetworkProvider) ReconcileNetworkProviderConfig(ctx context.Context, co
 // the default mask is /26
 // for other masks the user is required to provide their own via config
 func (n *NetworkProvider) getNonOverlappingDefaultCIDR(ctx context.Context) (*net.IPNet, error) {
-	clusterVpc, err := getClusterVpc(ctx, n.Client, n.Ec2Api, n.Logger)
+	clusterVpc, err := getClusterVpc(ctx, n.Client, n.Ec2Api, n.Discovery, n.Logger)
 	if err != nil {
 		return nil, errorUtil.Wrap(err, "failed to get cluster vpc for cidr block")
 	}
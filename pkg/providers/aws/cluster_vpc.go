@@ -23,7 +23,6 @@ import (
 	"context"
 	"fmt"
 	"net"
-	"reflect"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -51,7 +50,7 @@ const (
 )
 
 // ensures a subnet group is in place for the creation of a resource
-func configureSecurityGroup(ctx context.Context, c client.Client, ec2Svc ec2iface.EC2API, logger *logrus.Entry) error {
+func configureSecurityGroup(ctx context.Context, c client.Client, ec2Svc ec2iface.EC2API, discovery *NetworkDiscovery, logger *logrus.Entry) error {
 	// get cluster id
 	clusterID, err := resources.GetClusterID(ctx, c)
 	if err != nil {
@@ -67,10 +66,14 @@ func configureSecurityGroup(ctx context.Context, c client.Client, ec2Svc ec2ifac
 	}
 
 	// get cluster cidr group
-	vpcID, cidr, err := GetCidr(ctx, c, ec2Svc, logger)
+	vpcID, cidr, err := GetCidr(ctx, c, ec2Svc, discovery, logger)
 	if err != nil {
 		return errorUtil.Wrap(err, "error finding cidr block")
 	}
+	// resources placed in a resource vpc allow the cluster vpc it's peered with to connect
+	if discovery != nil && discovery.ResourceVpcID != "" {
+		vpcID = discovery.ResourceVpcID
+	}
 
 	foundSecGroup, err := getSecurityGroup(ec2Svc, secName)
 	if err != nil {
@@ -91,33 +94,15 @@ func configureSecurityGroup(ctx context.Context, c client.Client, ec2Svc ec2ifac
 	}
 	logger.Infof("found security group %s for cluster %s", *foundSecGroup.GroupId, clusterID)
 
-	// build ip permission
-	ipPermission := &ec2.IpPermission{
-		IpProtocol: aws.String("-1"),
-		IpRanges: []*ec2.IpRange{
-			{
-				CidrIp: aws.String(cidr),
-			},
-		},
-	}
-
-	// check if correct permissions are in place
-	for _, perm := range foundSecGroup.IpPermissions {
-		if reflect.DeepEqual(perm, ipPermission) {
-			logger.Infof("ip permissions are correct for security group %s", *foundSecGroup.GroupName)
-			return nil
-		}
+	// build the expected ip permissions, all traffic from the cluster vpc unless the network discovery sets rules
+	ipPermissions, err := buildSecurityGroupIngress(discovery, cidr)
+	if err != nil {
+		return errorUtil.Wrap(err, "error building security group ingress")
 	}
 
-	// authorize ingress
-	logger.Infof("setting ingress ip permissions for %s", *foundSecGroup.GroupName)
-	if _, err := ec2Svc.AuthorizeSecurityGroupIngress(&ec2.AuthorizeSecurityGroupIngressInput{
-		GroupId: aws.String(*foundSecGroup.GroupId),
-		IpPermissions: []*ec2.IpPermission{
-			ipPermission,
-		},
-	}); err != nil {
-		return errorUtil.Wrap(err, "error authorizing security group ingress")
+	// ensure only the expected ip permissions are in place
+	if err := reconcileSecurityGroupIngress(ec2Svc, foundSecGroup, ipPermissions, logger); err != nil {
+		return errorUtil.Wrapf(err, "error reconciling ingress of security group %s", *foundSecGroup.GroupName)
 	}
 
 	return nil
@@ -152,10 +137,10 @@ func GetVPCSubnets(ec2Svc ec2iface.EC2API, logger *logrus.Entry, vpc *ec2.Vpc) (
 }
 
 // GetSubnetIDS returns a list of subnet ids associated with cluster vpc
-func GetPrivateSubnetIDS(ctx context.Context, c client.Client, ec2Svc ec2iface.EC2API, logger *logrus.Entry) ([]*string, error) {
-	logger.Info("gathering all private subnets in cluster vpc")
-	// get cluster vpc
-	foundVPC, err := getClusterVpc(ctx, c, ec2Svc, logger)
+func GetPrivateSubnetIDS(ctx context.Context, c client.Client, ec2Svc ec2iface.EC2API, discovery *NetworkDiscovery, logger *logrus.Entry) ([]*string, error) {
+	logger.Info("gathering all private subnets in resource vpc")
+	// get the resource vpc, the cluster vpc unless the network discovery sets a resource vpc
+	foundVPC, err := getResourceVpc(ctx, c, ec2Svc, discovery, logger)
 	if err != nil {
 		return nil, errorUtil.Wrap(err, "error getting vpcs")
 	}
@@ -166,6 +151,11 @@ func GetPrivateSubnetIDS(ctx context.Context, c client.Client, ec2Svc ec2iface.E
 		return nil, errorUtil.Wrap(err, "error getting vpc subnets")
 	}
 
+	// existing subnets selected by the network discovery are used as they are, no subnets are created
+	if discovery.UsesExistingSubnets() {
+		return getExistingSubnetIDs(subs, foundVPC, discovery)
+	}
+
 	// get a list of availability zones
 	azs, err := getAZs(ec2Svc)
 	if err != nil {
@@ -436,8 +426,8 @@ func incrementIP(ip net.IP, inc int) net.IP {
 }
 
 // returns vpc id and cidr block for found vpc
-func GetCidr(ctx context.Context, c client.Client, ec2Svc ec2iface.EC2API, logger *logrus.Entry) (string, string, error) {
-	foundVPC, err := getClusterVpc(ctx, c, ec2Svc, logger)
+func GetCidr(ctx context.Context, c client.Client, ec2Svc ec2iface.EC2API, discovery *NetworkDiscovery, logger *logrus.Entry) (string, string, error) {
+	foundVPC, err := getClusterVpc(ctx, c, ec2Svc, discovery, logger)
 	if err != nil {
 		return "", "", errorUtil.Wrap(err, "error getting vpcs")
 	}
@@ -493,8 +483,8 @@ func getVPCIDByClusterSubnets(ec2Svc ec2iface.EC2API, clusterID string) (string,
 	return "", errorUtil.New(fmt.Sprintf("failed to get cluster vpc id, no vpc found with osd cluster tag: could not find cluster associated subnets with clusterID %s", clusterID))
 }
 
-// function to get vpc of a cluster
-func getClusterVpc(ctx context.Context, c client.Client, ec2Svc ec2iface.EC2API, logger *logrus.Entry) (*ec2.Vpc, error) {
+// function to get vpc of a cluster, the vpc id of the network discovery is used if it's set
+func getClusterVpc(ctx context.Context, c client.Client, ec2Svc ec2iface.EC2API, discovery *NetworkDiscovery, logger *logrus.Entry) (*ec2.Vpc, error) {
 	// first call to aws api from the network provider is to get cluster vpc
 	// polling to allow credential minter time to reconcile credentials
 
@@ -504,9 +494,20 @@ func getClusterVpc(ctx context.Context, c client.Client, ec2Svc ec2iface.EC2API,
 		return nil, errorUtil.Wrap(err, "error getting clusterID")
 	}
 
-	vpcId, err := getVPCIDByClusterSubnets(ec2Svc, clusterID)
-	if err != nil {
-		return nil, errorUtil.Wrap(err, "error getting vpc id from associated subnets")
+	var vpcId string
+	if discovery != nil && discovery.VpcID != "" {
+		vpcId = discovery.VpcID
+	} else {
+		vpcId, err = getVPCIDByClusterSubnets(ec2Svc, clusterID)
+		if err != nil {
+			// the subnets of an existing vpc a cluster is installed into aren't always tagged, fall back to its instances
+			instanceVpcId, instanceErr := getVPCIDByClusterInstances(ec2Svc, clusterID)
+			if instanceErr != nil {
+				logger.Infof("failed to get vpc id from cluster instances: %v", instanceErr)
+				return nil, errorUtil.Wrap(err, "error getting vpc id from associated subnets")
+			}
+			vpcId = instanceVpcId
+		}
 	}
 
 	vpcs, err := ec2Svc.DescribeVpcs(&ec2.DescribeVpcsInput{VpcIds: []*string{aws.String(vpcId)}})
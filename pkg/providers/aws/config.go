@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -13,12 +14,14 @@ import (
 	"github.com/aws/aws-sdk-go/service/sts"
 
 	"github.com/integr8ly/cloud-resource-operator/internal/k8sutil"
+	awsclient "github.com/integr8ly/cloud-resource-operator/pkg/providers/aws/client"
 
 	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
 
 	controllerruntime "sigs.k8s.io/controller-runtime"
 
 	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providersdk"
 	errorUtil "github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -35,6 +38,15 @@ const (
 	ResourceIdentifierAnnotation = "resourceIdentifier"
 )
 
+// stsCredentialsExpiryWindow is how long before they expire the credentials of an assumed role are refreshed, so a
+// request doesn't start with credentials which expire while it runs
+const stsCredentialsExpiryWindow = 5 * time.Minute
+
+var (
+	stsCredentialsMu sync.Mutex
+	stsCredentials   = map[string]*awsCredentials.Credentials{}
+)
+
 //DefaultConfigMapNamespace is the default namespace that Configmaps will be created in
 var DefaultConfigMapNamespace, _ = k8sutil.GetWatchNamespace()
 
@@ -43,12 +55,44 @@ StrategyConfig provides the configuration necessary to create/modify/delete aws
 Region -> required to create aws sessions, if no region is provided we default to cluster infrastructure
 CreateStrategy -> maps to resource specific create parameters, uses as a source of truth to the state we expect the resource to be in
 DeleteStrategy -> maps to resource specific delete parameters
+CrossRegionSnapshotCopy -> optional, copies final and on-demand snapshots to a secondary region for region-loss recovery
 */
 type StrategyConfig struct {
-	Region         string          `json:"region"`
-	CreateStrategy json.RawMessage `json:"createStrategy"`
-	DeleteStrategy json.RawMessage `json:"deleteStrategy"`
-	ServiceUpdates json.RawMessage `json:"serviceUpdates"`
+	Region                  string                   `json:"region"`
+	CreateStrategy          json.RawMessage          `json:"createStrategy"`
+	DeleteStrategy          json.RawMessage          `json:"deleteStrategy"`
+	ServiceUpdates          json.RawMessage          `json:"serviceUpdates"`
+	CrossRegionSnapshotCopy *CrossRegionSnapshotCopy `json:"crossRegionSnapshotCopy,omitempty"`
+	// SupportedVersions maps the postgres versions a cr can request to rds engine versions, replacing the defaults
+	SupportedVersions providers.SupportedVersions `json:"supportedVersions,omitempty"`
+	// EngineUpgrade configures how a change of the engine version is applied to an existing postgres
+	EngineUpgrade *EngineUpgrade `json:"engineUpgrade,omitempty"`
+	// Network is only read from _network strategies
+	Network *NetworkDiscovery `json:"network,omitempty"`
+	// Lifecycle is only read from blobstorage strategies, it configures the lifecycle rules of the s3 bucket
+	Lifecycle *BucketLifecycle `json:"lifecycle,omitempty"`
+	// Encryption configures the customer managed kms key s3 buckets, rds instances and elasticache replication groups
+	// are encrypted with
+	Encryption *KMSEncryption `json:"encryption,omitempty"`
+	// ReservedCapacity is only read from postgres and redis strategies, new instances prefer classes covered by its
+	// unused reservations
+	ReservedCapacity *ReservedCapacity `json:"reservedCapacity,omitempty"`
+	// IAMAuthentication is only read from postgres strategies, it enables iam database authentication of the rds
+	// instance and hands out the iam user in place of the master password
+	IAMAuthentication *IAMAuthentication `json:"iamAuthentication,omitempty"`
+	// Monitoring is only read from postgres strategies, it configures performance insights and enhanced monitoring of
+	// the rds instance
+	Monitoring *RDSMonitoring `json:"monitoring,omitempty"`
+}
+
+/*
+CrossRegionSnapshotCopy provides the configuration necessary to copy snapshots to a secondary region
+Region -> required, the secondary region snapshots are copied to
+KmsKeyID -> the kms key in the secondary region used to re-encrypt the copy, required for encrypted snapshots
+*/
+type CrossRegionSnapshotCopy struct {
+	Region   string `json:"region"`
+	KmsKeyID string `json:"kmsKeyId,omitempty"`
 }
 
 //go:generate moq -out config_moq.go . ConfigManager
@@ -91,22 +135,12 @@ func (m *ConfigMapConfigManager) ReadStorageStrategy(ctx context.Context, rt pro
 }
 
 func (m *ConfigMapConfigManager) getTierStrategyForProvider(ctx context.Context, rt string, tier string) (*StrategyConfig, error) {
-	cm, err := resources.GetConfigMapOrDefault(ctx, m.client, types.NamespacedName{Name: m.configMapName, Namespace: m.configMapNamespace}, BuildDefaultConfigMap(m.configMapName, m.configMapNamespace))
-	if err != nil {
-		return nil, errorUtil.Wrapf(err, "failed to get aws strategy config map %s in namespace %s", m.configMapName, m.configMapNamespace)
-	}
-	rawStrategyMapping := cm.Data[rt]
-	if rawStrategyMapping == "" {
-		return nil, errorUtil.New(fmt.Sprintf("aws strategy for resource type %s is not defined", rt))
+	stratCfg := &StrategyConfig{}
+	key := types.NamespacedName{Name: m.configMapName, Namespace: m.configMapNamespace}
+	if err := providersdk.ReadStrategy(ctx, m.client, "aws", key, BuildDefaultConfigMap(m.configMapName, m.configMapNamespace), providers.ResourceType(rt), tier, stratCfg); err != nil {
+		return nil, err
 	}
-	var strategyMapping map[string]*StrategyConfig
-	if err = json.Unmarshal([]byte(rawStrategyMapping), &strategyMapping); err != nil {
-		return nil, errorUtil.Wrapf(err, "failed to unmarshal strategy mapping for resource type %s", rt)
-	}
-	if strategyMapping[tier] == nil {
-		return nil, errorUtil.New(fmt.Sprintf("no strategy found for deployment type %s and deployment tier %s", rt, tier))
-	}
-	return strategyMapping[tier], nil
+	return stratCfg, nil
 }
 
 func BuildDefaultConfigMap(name, namespace string) *v1.ConfigMap {
@@ -119,6 +153,7 @@ func BuildDefaultConfigMap(name, namespace string) *v1.ConfigMap {
 			"blobstorage": "{\"development\": { \"region\": \"\", \"_network\": \"\", \"createStrategy\": {}, \"deleteStrategy\": {} }, \"production\": { \"region\": \"\", \"_network\": \"\", \"createStrategy\": {}, \"deleteStrategy\": {} }}",
 			"redis":       "{\"development\": { \"region\": \"\", \"_network\": \"\", \"createStrategy\": {}, \"deleteStrategy\": {} }, \"production\": { \"region\": \"\", \"_network\": \"\",\"createStrategy\": {}, \"deleteStrategy\": {} }}",
 			"postgres":    "{\"development\": { \"region\": \"\", \"_network\": \"\", \"createStrategy\": {}, \"deleteStrategy\": {} }, \"production\": { \"region\": \"\", \"_network\": \"\",\"createStrategy\": {}, \"deleteStrategy\": {} }}",
+			"queue":       "{\"development\": { \"region\": \"\", \"createStrategy\": {}, \"deleteStrategy\": {} }, \"production\": { \"region\": \"\", \"createStrategy\": {}, \"deleteStrategy\": {} }}",
 			"_network":    "{\"development\": { \"region\": \"\", \"_network\": \"\", \"createStrategy\": {}, \"deleteStrategy\": {} }, \"production\": { \"region\": \"\", \"_network\": \"\",\"createStrategy\": {}, \"deleteStrategy\": {} }}",
 		},
 	}
@@ -151,6 +186,16 @@ func buildTimestampedInfraNameFromObject(ctx context.Context, c client.Client, o
 	return resources.ShortenString(fmt.Sprintf("%s-%s-%s-%d", clusterID, om.Namespace, om.Name, curTime), n), nil
 }
 
+// buildFinalSnapshotIdentifier returns the identifier of the final snapshot of a deleted resource from the final
+// snapshot name template of the resource
+func buildFinalSnapshotIdentifier(ctx context.Context, c client.Client, om controllerruntime.ObjectMeta, nameTemplate string) (string, error) {
+	clusterID, err := resources.GetClusterID(ctx, c)
+	if err != nil {
+		return "", errorUtil.Wrap(err, "failed to retrieve cluster identifier")
+	}
+	return providers.BuildFinalSnapshotName(nameTemplate, clusterID, &om, defaultAwsSnapshotIdentifierLength)
+}
+
 func BuildTimestampedInfraNameFromObjectCreation(ctx context.Context, c client.Client, om controllerruntime.ObjectMeta, n int) (string, error) {
 	clusterID, err := resources.GetClusterID(ctx, c)
 	if err != nil {
@@ -170,22 +215,43 @@ func CreateSessionFromStrategy(ctx context.Context, c client.Client, credentials
 	}
 	// Check if STS credentials are passed
 	if len(credentials.RoleArn) > 0 {
-		// If running locally and STS role to assume is created, assume this role locally
-		// Local IAM user must be a principle in the role created with the sts:AssumeRole action
-		// Otherwise assume running in a pod in STS cluster
-		if k8sutil.IsRunModeLocal() {
-			sess := session.Must(session.NewSession(&awsConfig))
-			awsConfig.Credentials = stscreds.NewCredentials(sess, credentials.RoleArn)
-		} else {
-			svc := sts.New(session.Must(session.NewSession(&awsConfig)))
-			credentialsProvider := stscreds.NewWebIdentityRoleProvider(svc, credentials.RoleArn, "Red-Hat-cloud-resources-operator", credentials.TokenFilePath)
-			awsConfig.Credentials = awsCredentials.NewCredentials(credentialsProvider)
-		}
+		awsConfig.Credentials = getSTSCredentials(awsConfig, credentials)
 	} else {
 		awsConfig.Credentials = awsCredentials.NewStaticCredentials(credentials.AccessKeyID, credentials.SecretAccessKey, "")
 	}
 	sess := session.Must(session.NewSession(&awsConfig))
-	return sess, nil
+	return awsclient.Configure(sess), nil
+}
+
+// getSTSCredentials returns the credentials of the role assumed with the sts credentials, shared by every session of
+// the role and region. The role is assumed again shortly before the credentials expire, with the web identity token
+// read again from its file, so a token rotated by the kubelet is picked up without restarting the operator
+func getSTSCredentials(awsConfig aws.Config, credentials *Credentials) *awsCredentials.Credentials {
+	// If running locally and STS role to assume is created, assume this role locally
+	// Local IAM user must be a principle in the role created with the sts:AssumeRole action
+	// Otherwise assume running in a pod in STS cluster
+	local := k8sutil.IsRunModeLocal()
+	key := fmt.Sprintf("%s/%s/%s/%t", aws.StringValue(awsConfig.Region), credentials.RoleArn, credentials.TokenFilePath, local)
+	stsCredentialsMu.Lock()
+	defer stsCredentialsMu.Unlock()
+	if creds, ok := stsCredentials[key]; ok {
+		return creds
+	}
+	var creds *awsCredentials.Credentials
+	if local {
+		sess := session.Must(session.NewSession(&awsConfig))
+		creds = stscreds.NewCredentials(sess, credentials.RoleArn, func(p *stscreds.AssumeRoleProvider) {
+			p.ExpiryWindow = stsCredentialsExpiryWindow
+		})
+	} else {
+		svc := sts.New(session.Must(session.NewSession(&awsConfig)))
+		credentialsProvider := stscreds.NewWebIdentityRoleProviderWithOptions(svc, credentials.RoleArn, "Red-Hat-cloud-resources-operator", stscreds.FetchTokenPath(credentials.TokenFilePath), func(p *stscreds.WebIdentityRoleProvider) {
+			p.ExpiryWindow = stsCredentialsExpiryWindow
+		})
+		creds = awsCredentials.NewCredentials(credentialsProvider)
+	}
+	stsCredentials[key] = creds
+	return creds
 }
 
 func GetRegionFromStrategyOrDefault(ctx context.Context, c client.Client, strategy *StrategyConfig) (string, error) {
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/aws/aws-sdk-go/aws"
 	configv1 "github.com/integr8ly/cloud-resource-operator/apis/config/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -616,3 +617,14 @@ func errorContains(out error, want string) bool {
 	}
 	return strings.Contains(out.Error(), want)
 }
+
+func TestGetSTSCredentials(t *testing.T) {
+	creds := &Credentials{RoleArn: "arn:aws:iam::123456789012:role/test", TokenFilePath: "/var/run/secrets/token"}
+	first := getSTSCredentials(aws.Config{Region: aws.String("eu-west-1")}, creds)
+	if got := getSTSCredentials(aws.Config{Region: aws.String("eu-west-1")}, creds); got != first {
+		t.Error("getSTSCredentials() expected the credentials of the role to be shared")
+	}
+	if got := getSTSCredentials(aws.Config{Region: aws.String("us-east-1")}, creds); got == first {
+		t.Error("getSTSCredentials() expected separate credentials for another region")
+	}
+}
@@ -0,0 +1,117 @@
+//go:build cloud
+// +build cloud
+
+package aws
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	croApis "github.com/integr8ly/cloud-resource-operator/apis"
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/conformance"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+// the scenarios run against the aws account of the cluster of the current kube config, in the namespace set with
+// CONFORMANCE_NAMESPACE and with the tier set with CONFORMANCE_TIER
+const (
+	conformanceNamespaceEnv = "CONFORMANCE_NAMESPACE"
+	conformanceTierEnv      = "CONFORMANCE_TIER"
+)
+
+func buildCloudConformanceHarness(t *testing.T) (conformance.Harness, string, string) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		t.Fatal("failed to get kube config", err)
+	}
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	if err := croApis.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		t.Fatal("failed to build client", err)
+	}
+	ns := os.Getenv(conformanceNamespaceEnv)
+	if ns == "" {
+		t.Fatalf("%s must be set to the namespace the conformance crs are created in", conformanceNamespaceEnv)
+	}
+	tier := os.Getenv(conformanceTierEnv)
+	if tier == "" {
+		tier = "development"
+	}
+	return conformance.Harness{
+		Client: c,
+		// resizes of rds instances and elasticache clusters are applied in their maintenance window
+		Unsupported: map[conformance.Scenario]string{
+			conformance.ScenarioResize: "aws applies resizes in the maintenance window of the resource",
+		},
+		Attempts: 120,
+		Interval: 30 * time.Second,
+	}, ns, tier
+}
+
+func createCloudConformanceCR(t *testing.T, c client.Client, obj conformance.Object) {
+	if err := c.Create(context.TODO(), obj); err != nil {
+		t.Fatalf("failed to create cr %s: %v", obj.GetName(), err)
+	}
+}
+
+func TestAWSPostgresProvider_CloudConformance(t *testing.T) {
+	h, ns, tier := buildCloudConformanceHarness(t)
+	p, err := NewAWSPostgresProvider(h.Client, testLogger)
+	if err != nil {
+		t.Fatal("failed to build postgres provider", err)
+	}
+	conformance.RunPostgres(t, h, p, func(t *testing.T, name string) *v1alpha1.Postgres {
+		pg := &v1alpha1.Postgres{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+			Spec:       croType.ResourceTypeSpec{Tier: tier, SecretRef: &croType.SecretRef{Name: name}},
+		}
+		createCloudConformanceCR(t, h.Client, pg)
+		return pg
+	})
+}
+
+func TestAWSRedisProvider_CloudConformance(t *testing.T) {
+	h, ns, tier := buildCloudConformanceHarness(t)
+	p, err := NewAWSRedisProvider(h.Client, testLogger)
+	if err != nil {
+		t.Fatal("failed to build redis provider", err)
+	}
+	conformance.RunRedis(t, h, p, func(t *testing.T, name string) *v1alpha1.Redis {
+		r := &v1alpha1.Redis{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+			Spec:       croType.ResourceTypeSpec{Tier: tier, SecretRef: &croType.SecretRef{Name: name}},
+		}
+		createCloudConformanceCR(t, h.Client, r)
+		return r
+	})
+}
+
+func TestAWSBlobStorageProvider_CloudConformance(t *testing.T) {
+	h, ns, tier := buildCloudConformanceHarness(t)
+	p, err := NewAWSBlobStorageProvider(h.Client, testLogger)
+	if err != nil {
+		t.Fatal("failed to build blob storage provider", err)
+	}
+	conformance.RunBlobStorage(t, h, p, func(t *testing.T, name string) *v1alpha1.BlobStorage {
+		bs := &v1alpha1.BlobStorage{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+			Spec:       croType.ResourceTypeSpec{Tier: tier, SecretRef: &croType.SecretRef{Name: name}},
+		}
+		createCloudConformanceCR(t, h.Client, bs)
+		return bs
+	})
+}
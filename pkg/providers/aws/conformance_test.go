@@ -0,0 +1,559 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/conformance"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testConformanceNamespace    = "test-conformance"
+	testConformanceAccount      = "123456789012"
+	testResizedDBInstanceClass  = "db.t3.medium"
+	testResizedCacheNodeType    = "cache.t3.small"
+	testConformanceEndpointHost = "%s.test.amazonaws.com"
+	testConformanceRedisPort    = 6379
+)
+
+// conformanceCloud is the aws account the conformance scenarios run against. Like in aws, rds instances and
+// elasticache replication groups are created and deleted asynchronously and their modifications are pending until
+// the maintenance window, all of which happens when the cloud settles
+type conformanceCloud struct {
+	instances     map[string]*rds.DBInstance
+	instanceMods  map[string][]*rds.ModifyDBInstanceInput
+	groups        map[string]*elasticache.ReplicationGroup
+	groupMods     map[string][]*elasticache.ModifyReplicationGroupInput
+	buckets       map[string]bool
+	securityGroup string
+}
+
+func newConformanceCloud(t *testing.T, c client.Client) *conformanceCloud {
+	secName, err := BuildInfraName(context.TODO(), c, defaultSecurityGroupPostfix, defaultAwsIdentifierLength)
+	if err != nil {
+		t.Fatal("failed to build security group name", err)
+	}
+	return &conformanceCloud{
+		instances:     map[string]*rds.DBInstance{},
+		instanceMods:  map[string][]*rds.ModifyDBInstanceInput{},
+		groups:        map[string]*elasticache.ReplicationGroup{},
+		groupMods:     map[string][]*elasticache.ModifyReplicationGroupInput{},
+		buckets:       map[string]bool{},
+		securityGroup: secName,
+	}
+}
+
+// settle completes the creations, deletions and pending modifications of the resources of the cloud
+func (c *conformanceCloud) settle() {
+	for id, instance := range c.instances {
+		switch aws.StringValue(instance.DBInstanceStatus) {
+		case "deleting":
+			delete(c.instances, id)
+			continue
+		case "creating":
+			instance.DBInstanceStatus = aws.String("available")
+		}
+		for _, mi := range c.instanceMods[id] {
+			applyDBInstanceModification(instance, mi)
+		}
+		delete(c.instanceMods, id)
+		instance.PendingModifiedValues = nil
+	}
+	for id, group := range c.groups {
+		switch aws.StringValue(group.Status) {
+		case "deleting":
+			delete(c.groups, id)
+			continue
+		case "creating":
+			group.Status = aws.String("available")
+			group.NodeGroups[0].Status = aws.String("available")
+		}
+		for _, mi := range c.groupMods[id] {
+			if mi.CacheNodeType != nil {
+				group.CacheNodeType = mi.CacheNodeType
+			}
+			if mi.SnapshotRetentionLimit != nil {
+				group.SnapshotRetentionLimit = mi.SnapshotRetentionLimit
+			}
+		}
+		delete(c.groupMods, id)
+	}
+}
+
+func applyDBInstanceModification(instance *rds.DBInstance, mi *rds.ModifyDBInstanceInput) {
+	if mi.DeletionProtection != nil {
+		instance.DeletionProtection = mi.DeletionProtection
+	}
+	if mi.DBInstanceClass != nil {
+		instance.DBInstanceClass = mi.DBInstanceClass
+	}
+	if mi.BackupRetentionPeriod != nil {
+		instance.BackupRetentionPeriod = mi.BackupRetentionPeriod
+	}
+	if mi.PubliclyAccessible != nil {
+		instance.PubliclyAccessible = mi.PubliclyAccessible
+	}
+	if mi.MaxAllocatedStorage != nil {
+		instance.MaxAllocatedStorage = mi.MaxAllocatedStorage
+	}
+	if mi.MultiAZ != nil {
+		instance.MultiAZ = mi.MultiAZ
+	}
+	if mi.AutoMinorVersionUpgrade != nil {
+		instance.AutoMinorVersionUpgrade = mi.AutoMinorVersionUpgrade
+	}
+	if mi.EngineVersion != nil {
+		instance.EngineVersion = mi.EngineVersion
+	}
+	if mi.DBPortNumber != nil {
+		instance.Endpoint.Port = mi.DBPortNumber
+	}
+}
+
+// conformanceRDS keeps the rds instances of the mocked rds client in the cloud
+type conformanceRDS struct {
+	*mockRdsClient
+	cloud *conformanceCloud
+}
+
+func (m *conformanceRDS) DescribeDBInstances(*rds.DescribeDBInstancesInput) (*rds.DescribeDBInstancesOutput, error) {
+	out := &rds.DescribeDBInstancesOutput{}
+	for _, instance := range m.cloud.instances {
+		out.DBInstances = append(out.DBInstances, instance)
+	}
+	return out, nil
+}
+
+func (m *conformanceRDS) CreateDBInstance(input *rds.CreateDBInstanceInput) (*rds.CreateDBInstanceOutput, error) {
+	id := aws.StringValue(input.DBInstanceIdentifier)
+	m.cloud.instances[id] = &rds.DBInstance{
+		DBInstanceIdentifier:       input.DBInstanceIdentifier,
+		DBInstanceArn:              aws.String(fmt.Sprintf("arn:aws:rds:eu-west-1:%s:db:%s", testConformanceAccount, id)),
+		DBInstanceStatus:           aws.String("creating"),
+		DBInstanceClass:            input.DBInstanceClass,
+		DBName:                     input.DBName,
+		MasterUsername:             input.MasterUsername,
+		Endpoint:                   &rds.Endpoint{Address: aws.String(fmt.Sprintf(testConformanceEndpointHost, id)), Port: input.Port},
+		DeletionProtection:         input.DeletionProtection,
+		BackupRetentionPeriod:      input.BackupRetentionPeriod,
+		PubliclyAccessible:         input.PubliclyAccessible,
+		AllocatedStorage:           input.AllocatedStorage,
+		MaxAllocatedStorage:        input.MaxAllocatedStorage,
+		MultiAZ:                    input.MultiAZ,
+		AutoMinorVersionUpgrade:    input.AutoMinorVersionUpgrade,
+		EngineVersion:              input.EngineVersion,
+		PreferredBackupWindow:      input.PreferredBackupWindow,
+		PreferredMaintenanceWindow: input.PreferredMaintenanceWindow,
+	}
+	return &rds.CreateDBInstanceOutput{}, nil
+}
+
+func (m *conformanceRDS) ModifyDBInstance(input *rds.ModifyDBInstanceInput) (*rds.ModifyDBInstanceOutput, error) {
+	id := aws.StringValue(input.DBInstanceIdentifier)
+	instance, ok := m.cloud.instances[id]
+	if !ok {
+		return nil, errorUtil.Errorf("rds instance %s not found", id)
+	}
+	m.cloud.instanceMods[id] = append(m.cloud.instanceMods[id], input)
+	// rds reports the modifications it applies in the maintenance window, or immediately, as pending until then
+	if instance.PendingModifiedValues == nil {
+		instance.PendingModifiedValues = &rds.PendingModifiedValues{}
+	}
+	if input.MasterUserPassword != nil {
+		instance.PendingModifiedValues.MasterUserPassword = aws.String("****")
+	}
+	if input.DBInstanceClass != nil {
+		instance.PendingModifiedValues.DBInstanceClass = input.DBInstanceClass
+	}
+	return &rds.ModifyDBInstanceOutput{}, nil
+}
+
+func (m *conformanceRDS) DeleteDBInstance(input *rds.DeleteDBInstanceInput) (*rds.DeleteDBInstanceOutput, error) {
+	instance, ok := m.cloud.instances[aws.StringValue(input.DBInstanceIdentifier)]
+	if !ok {
+		return nil, errorUtil.Errorf("rds instance %s not found", aws.StringValue(input.DBInstanceIdentifier))
+	}
+	instance.DBInstanceStatus = aws.String("deleting")
+	return &rds.DeleteDBInstanceOutput{}, nil
+}
+
+// conformanceElastiCache keeps the replication groups of the mocked elasticache client in the cloud
+type conformanceElastiCache struct {
+	*mockElasticacheClient
+	cloud *conformanceCloud
+}
+
+func (m *conformanceElastiCache) DescribeReplicationGroups(*elasticache.DescribeReplicationGroupsInput) (*elasticache.DescribeReplicationGroupsOutput, error) {
+	out := &elasticache.DescribeReplicationGroupsOutput{}
+	for _, group := range m.cloud.groups {
+		out.ReplicationGroups = append(out.ReplicationGroups, group)
+	}
+	return out, nil
+}
+
+func (m *conformanceElastiCache) DescribeCacheClusters(input *elasticache.DescribeCacheClustersInput) (*elasticache.DescribeCacheClustersOutput, error) {
+	out := &elasticache.DescribeCacheClustersOutput{}
+	for _, group := range m.cloud.groups {
+		for _, member := range group.NodeGroups[0].NodeGroupMembers {
+			if input.CacheClusterId != nil && aws.StringValue(input.CacheClusterId) != aws.StringValue(member.CacheClusterId) {
+				continue
+			}
+			out.CacheClusters = append(out.CacheClusters, &elasticache.CacheCluster{
+				CacheClusterId:            member.CacheClusterId,
+				CacheClusterStatus:        group.NodeGroups[0].Status,
+				ReplicationGroupId:        group.ReplicationGroupId,
+				EngineVersion:             aws.String(defaultEngineVersion),
+				PreferredAvailabilityZone: member.PreferredAvailabilityZone,
+			})
+		}
+	}
+	return out, nil
+}
+
+func (m *conformanceElastiCache) CreateReplicationGroup(input *elasticache.CreateReplicationGroupInput) (*elasticache.CreateReplicationGroupOutput, error) {
+	id := aws.StringValue(input.ReplicationGroupId)
+	address := aws.String(fmt.Sprintf(testConformanceEndpointHost, id))
+	var members []*elasticache.NodeGroupMember
+	var clusters []*string
+	for i := int64(1); i <= aws.Int64Value(input.NumCacheClusters); i++ {
+		clusterID := aws.String(fmt.Sprintf("%s-%03d", id, i))
+		members = append(members, &elasticache.NodeGroupMember{CacheClusterId: clusterID, PreferredAvailabilityZone: aws.String(defaultAzIdOne)})
+		clusters = append(clusters, clusterID)
+	}
+	m.cloud.groups[id] = &elasticache.ReplicationGroup{
+		ReplicationGroupId:     input.ReplicationGroupId,
+		ARN:                    aws.String(fmt.Sprintf("arn:aws:elasticache:eu-west-1:%s:replicationgroup:%s", testConformanceAccount, id)),
+		Status:                 aws.String("creating"),
+		CacheNodeType:          input.CacheNodeType,
+		SnapshotRetentionLimit: input.SnapshotRetentionLimit,
+		MemberClusters:         clusters,
+		NodeGroups: []*elasticache.NodeGroup{
+			{
+				NodeGroupId:      aws.String("0001"),
+				Status:           aws.String("creating"),
+				PrimaryEndpoint:  &elasticache.Endpoint{Address: address, Port: aws.Int64(testConformanceRedisPort)},
+				NodeGroupMembers: members,
+			},
+		},
+	}
+	return &elasticache.CreateReplicationGroupOutput{}, nil
+}
+
+func (m *conformanceElastiCache) ModifyReplicationGroup(input *elasticache.ModifyReplicationGroupInput) (*elasticache.ModifyReplicationGroupOutput, error) {
+	id := aws.StringValue(input.ReplicationGroupId)
+	if _, ok := m.cloud.groups[id]; !ok {
+		return nil, errorUtil.Errorf("replication group %s not found", id)
+	}
+	m.cloud.groupMods[id] = append(m.cloud.groupMods[id], input)
+	return &elasticache.ModifyReplicationGroupOutput{}, nil
+}
+
+func (m *conformanceElastiCache) DeleteReplicationGroup(input *elasticache.DeleteReplicationGroupInput) (*elasticache.DeleteReplicationGroupOutput, error) {
+	group, ok := m.cloud.groups[aws.StringValue(input.ReplicationGroupId)]
+	if !ok {
+		return nil, errorUtil.Errorf("replication group %s not found", aws.StringValue(input.ReplicationGroupId))
+	}
+	group.Status = aws.String("deleting")
+	return &elasticache.DeleteReplicationGroupOutput{}, nil
+}
+
+// conformanceS3 keeps the buckets of the mocked s3 client in the cloud, buckets are created and deleted at once
+type conformanceS3 struct {
+	*mockS3Svc
+	cloud *conformanceCloud
+}
+
+func (m *conformanceS3) ListBuckets(*s3.ListBucketsInput) (*s3.ListBucketsOutput, error) {
+	out := &s3.ListBucketsOutput{}
+	for name := range m.cloud.buckets {
+		out.Buckets = append(out.Buckets, &s3.Bucket{Name: aws.String(name)})
+	}
+	return out, nil
+}
+
+func (m *conformanceS3) CreateBucket(input *s3.CreateBucketInput) (*s3.CreateBucketOutput, error) {
+	m.cloud.buckets[aws.StringValue(input.Bucket)] = true
+	return &s3.CreateBucketOutput{}, nil
+}
+
+func (m *conformanceS3) DeleteBucket(input *s3.DeleteBucketInput) (*s3.DeleteBucketOutput, error) {
+	delete(m.cloud.buckets, aws.StringValue(input.Bucket))
+	return &s3.DeleteBucketOutput{}, nil
+}
+
+type conformanceSTS struct {
+	mockStsClient
+}
+
+func (conformanceSTS) GetCallerIdentity(*sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error) {
+	return &sts.GetCallerIdentityOutput{Account: aws.String(testConformanceAccount)}, nil
+}
+
+func (c *conformanceCloud) rds() *conformanceRDS {
+	return &conformanceRDS{
+		mockRdsClient: buildMockRdsClient(func(m *mockRdsClient) {
+			m.describeDBSubnetGroupsFn = func(*rds.DescribeDBSubnetGroupsInput) (*rds.DescribeDBSubnetGroupsOutput, error) {
+				return &rds.DescribeDBSubnetGroupsOutput{DBSubnetGroups: []*rds.DBSubnetGroup{buildTestDBSubnetGroup(defaultAzIdOne, defaultAzIdTwo)}}, nil
+			}
+			m.describePendingMaintenanceActionsFn = func(*rds.DescribePendingMaintenanceActionsInput) (*rds.DescribePendingMaintenanceActionsOutput, error) {
+				return &rds.DescribePendingMaintenanceActionsOutput{}, nil
+			}
+			m.describeDBSnapshotsFn = func(*rds.DescribeDBSnapshotsInput) (*rds.DescribeDBSnapshotsOutput, error) {
+				return &rds.DescribeDBSnapshotsOutput{}, nil
+			}
+		}),
+		cloud: c,
+	}
+}
+
+func (c *conformanceCloud) elastiCache() *conformanceElastiCache {
+	return &conformanceElastiCache{
+		mockElasticacheClient: buildMockElasticacheClient(func(m *mockElasticacheClient) {
+			m.describeCacheSubnetGroupsFn = func(*elasticache.DescribeCacheSubnetGroupsInput) (*elasticache.DescribeCacheSubnetGroupsOutput, error) {
+				return &elasticache.DescribeCacheSubnetGroupsOutput{CacheSubnetGroups: []*elasticache.CacheSubnetGroup{buildTestCacheSubnetGroup(defaultAzIdOne, defaultAzIdTwo)}}, nil
+			}
+			m.addTagsToResourceFn = func(*elasticache.AddTagsToResourceInput) (*elasticache.TagListMessage, error) {
+				return &elasticache.TagListMessage{}, nil
+			}
+			m.describeSnapshotsFn = func(*elasticache.DescribeSnapshotsInput) (*elasticache.DescribeSnapshotsOutput, error) {
+				return &elasticache.DescribeSnapshotsOutput{}, nil
+			}
+		}),
+		cloud: c,
+	}
+}
+
+func (c *conformanceCloud) ec2() *mockEc2Client {
+	return buildMockEc2Client(func(m *mockEc2Client) {
+		m.describeSecurityGroupsFn = func(*ec2.DescribeSecurityGroupsInput) (*ec2.DescribeSecurityGroupsOutput, error) {
+			return &ec2.DescribeSecurityGroupsOutput{SecurityGroups: buildSecurityGroups(c.securityGroup)}, nil
+		}
+	})
+}
+
+// conformancePostgresProvider reconciles postgres instances of the provider against the rds of the cloud, in place of
+// the aws session ReconcilePostgres and DeletePostgres create. The network of the instances isn't reconciled, it's
+// shared with the cluster
+type conformancePostgresProvider struct {
+	*PostgresProvider
+	cloud *conformanceCloud
+}
+
+func (p *conformancePostgresProvider) ReconcilePostgres(ctx context.Context, pg *v1alpha1.Postgres) (*providers.PostgresInstance, croType.StatusMessage, error) {
+	if err := resources.CreateFinalizer(ctx, p.Client, pg, DefaultFinalizer); err != nil {
+		return nil, "failed to set finalizer", err
+	}
+	rdsCfg, _, _, _, err := p.getRDSConfig(ctx, pg)
+	if err != nil {
+		return nil, "failed to retrieve aws rds cluster config for instance", err
+	}
+	sec := buildDefaultRDSSecret(pg)
+	if _, err := resources.EnsureCredentialSecret(ctx, p.Client, sec, map[string]resources.SecretValueGenerator{
+		defaultPostgresUserKey:     resources.StaticSecretValue(defaultAwsPostgresUser),
+		defaultPostgresPasswordKey: generateRDSPassword,
+	}); err != nil {
+		return nil, "failed to create or update secret", err
+	}
+	return p.reconcileRDSInstance(ctx, pg, p.cloud.rds(), p.cloud.ec2(), rdsCfg, true, rdsReconcileOptions{})
+}
+
+func (p *conformancePostgresProvider) DeletePostgres(ctx context.Context, pg *v1alpha1.Postgres) (croType.StatusMessage, error) {
+	rdsCreateConfig, rdsDeleteConfig, _, _, err := p.getRDSConfig(ctx, pg)
+	if err != nil {
+		return "failed to retrieve aws rds config", err
+	}
+	return p.deleteRDSInstance(ctx, pg, buildMockNetworkManager(), p.cloud.rds(), p.cloud.ec2(), rdsCreateConfig, rdsDeleteConfig, rdsDeleteOptions{})
+}
+
+// conformanceRedisProvider reconciles redis replication groups of the provider against the elasticache of the cloud,
+// in place of the aws session CreateRedis and DeleteRedis create
+type conformanceRedisProvider struct {
+	*RedisProvider
+	cloud *conformanceCloud
+}
+
+func (p *conformanceRedisProvider) CreateRedis(ctx context.Context, r *v1alpha1.Redis) (*providers.RedisCluster, croType.StatusMessage, error) {
+	if err := resources.CreateFinalizer(ctx, p.Client, r, DefaultFinalizer); err != nil {
+		return nil, "failed to set finalizer", err
+	}
+	elasticacheCreateConfig, _, serviceUpdates, stratCfg, err := p.getElasticacheConfig(ctx, r)
+	if err != nil {
+		return nil, "failed to retrieve aws elasticache cluster config", err
+	}
+	return p.createElasticacheCluster(ctx, r, p.cloud.elastiCache(), conformanceSTS{}, p.cloud.ec2(), elasticacheCreateConfig, stratCfg, serviceUpdates, true, nil)
+}
+
+func (p *conformanceRedisProvider) DeleteRedis(ctx context.Context, r *v1alpha1.Redis) (croType.StatusMessage, error) {
+	elasticacheCreateConfig, elasticacheDeleteConfig, _, _, err := p.getElasticacheConfig(ctx, r)
+	if err != nil {
+		return "failed to retrieve aws elasticache config", err
+	}
+	return p.deleteElasticacheCluster(ctx, buildMockNetworkManager(), p.cloud.elastiCache(), p.cloud.ec2(), elasticacheCreateConfig, elasticacheDeleteConfig, r, true, false)
+}
+
+// conformanceBlobStorageProvider reconciles buckets of the provider against the s3 of the cloud, in place of the aws
+// session CreateStorage and DeleteStorage create
+type conformanceBlobStorageProvider struct {
+	*BlobStorageProvider
+	cloud *conformanceCloud
+}
+
+func (p *conformanceBlobStorageProvider) CreateStorage(ctx context.Context, bs *v1alpha1.BlobStorage) (*providers.BlobStorageInstance, croType.StatusMessage, error) {
+	if err := resources.CreateFinalizer(ctx, p.Client, bs, DefaultFinalizer); err != nil {
+		return nil, "failed to set finalizer", err
+	}
+	bucketCreateCfg, _, stratCfg, err := p.buildS3BucketConfig(ctx, bs)
+	if err != nil {
+		return nil, "failed to build s3 bucket config", err
+	}
+	return p.createStorage(ctx, bs, &conformanceS3{mockS3Svc: &mockS3Svc{}, cloud: p.cloud}, bucketCreateCfg, stratCfg, "")
+}
+
+func (p *conformanceBlobStorageProvider) DeleteStorage(ctx context.Context, bs *v1alpha1.BlobStorage) (croType.StatusMessage, error) {
+	bucketCreateCfg, bucketDeleteCfg, _, err := p.buildS3BucketConfig(ctx, bs)
+	if err != nil {
+		return "failed to build s3 bucket config", err
+	}
+	return p.reconcileBucketDelete(ctx, bs, &conformanceS3{mockS3Svc: &mockS3Svc{}, cloud: p.cloud}, bucketCreateCfg, bucketDeleteCfg)
+}
+
+// buildConformanceHarness returns a harness running the scenarios against the mocked cloud, settling it between the
+// attempts of a scenario
+func buildConformanceHarness(t *testing.T) (conformance.Harness, *conformanceCloud) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	c := fake.NewFakeClientWithScheme(scheme, buildTestInfra())
+	cloud := newConformanceCloud(t, c)
+	return conformance.Harness{
+		Client: c,
+		Settle: func(t *testing.T, obj conformance.Object) {
+			cloud.settle()
+		},
+	}, cloud
+}
+
+func buildConformanceConfigManager() *ConfigManagerMock {
+	return buildTestConfigManager(func(m *ConfigManagerMock) {
+		m.ReadStorageStrategyFunc = func(ctx context.Context, rt providers.ResourceType, tier string) (*StrategyConfig, error) {
+			return &StrategyConfig{
+				Region:         "eu-west-1",
+				CreateStrategy: json.RawMessage("{}"),
+				DeleteStrategy: json.RawMessage("{}"),
+			}, nil
+		}
+	})
+}
+
+func createConformanceCR(t *testing.T, c client.Client, obj conformance.Object) {
+	if err := c.Create(context.TODO(), obj); err != nil {
+		t.Fatalf("failed to create cr %s: %v", obj.GetName(), err)
+	}
+}
+
+// resizeConformanceCR requests the instance class or node type of the tier on the cr
+func resizeConformanceCR(spec *croType.ResourceTypeSpec, field, value string) {
+	spec.ProviderConfig = &runtime.RawExtension{Raw: []byte(fmt.Sprintf(`{%q: %q}`, field, value))}
+}
+
+func TestAWSPostgresProvider_Conformance(t *testing.T) {
+	h, cloud := buildConformanceHarness(t)
+	p := &PostgresProvider{
+		Client:        h.Client,
+		Logger:        testLogger,
+		ConfigManager: buildConformanceConfigManager(),
+		TCPPinger:     buildMockConnectionTester(),
+	}
+	h.Resize = func(t *testing.T, obj conformance.Object) {
+		resizeConformanceCR(&obj.(*v1alpha1.Postgres).Spec, "DBInstanceClass", testResizedDBInstanceClass)
+	}
+	h.Resized = func(t *testing.T, obj conformance.Object) bool {
+		instance := cloud.instances[obj.GetAnnotations()[ResourceIdentifierAnnotation]]
+		return instance != nil && aws.StringValue(instance.DBInstanceClass) == testResizedDBInstanceClass
+	}
+	h.Gone = func(t *testing.T, obj conformance.Object) bool {
+		if _, ok := cloud.instances[obj.GetAnnotations()[ResourceIdentifierAnnotation]]; ok {
+			return false
+		}
+		err := h.Client.Get(context.TODO(), types.NamespacedName{Name: obj.GetName() + defaultCredSecSuffix, Namespace: obj.GetNamespace()}, &v1.Secret{})
+		return k8serr.IsNotFound(err)
+	}
+	conformance.RunPostgres(t, h, &conformancePostgresProvider{PostgresProvider: p, cloud: cloud}, func(t *testing.T, name string) *v1alpha1.Postgres {
+		pg := &v1alpha1.Postgres{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testConformanceNamespace}}
+		createConformanceCR(t, h.Client, pg)
+		return pg
+	})
+}
+
+func TestAWSRedisProvider_Conformance(t *testing.T) {
+	h, cloud := buildConformanceHarness(t)
+	p := &RedisProvider{
+		Client:        h.Client,
+		Logger:        testLogger,
+		ConfigManager: buildConformanceConfigManager(),
+		TCPPinger:     buildMockConnectionTester(),
+	}
+	h.Resize = func(t *testing.T, obj conformance.Object) {
+		resizeConformanceCR(&obj.(*v1alpha1.Redis).Spec, "CacheNodeType", testResizedCacheNodeType)
+	}
+	h.Resized = func(t *testing.T, obj conformance.Object) bool {
+		group := cloud.groups[obj.GetAnnotations()[ResourceIdentifierAnnotation]]
+		return group != nil && aws.StringValue(group.CacheNodeType) == testResizedCacheNodeType
+	}
+	h.Gone = func(t *testing.T, obj conformance.Object) bool {
+		_, ok := cloud.groups[obj.GetAnnotations()[ResourceIdentifierAnnotation]]
+		return !ok
+	}
+	conformance.RunRedis(t, h, &conformanceRedisProvider{RedisProvider: p, cloud: cloud}, func(t *testing.T, name string) *v1alpha1.Redis {
+		r := &v1alpha1.Redis{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testConformanceNamespace}}
+		createConformanceCR(t, h.Client, r)
+		return r
+	})
+}
+
+func TestAWSBlobStorageProvider_Conformance(t *testing.T) {
+	h, cloud := buildConformanceHarness(t)
+	p := &BlobStorageProvider{
+		Client: h.Client,
+		Logger: testLogger,
+		CredentialManager: &CredentialManagerMock{
+			ReconcileBucketOwnerCredentialsFunc: func(ctx context.Context, name string, ns string, bucket string) (*Credentials, error) {
+				return &Credentials{Username: name, AccessKeyID: "test-id-" + bucket, SecretAccessKey: "test-key-" + bucket}, nil
+			},
+		},
+		ConfigManager: buildConformanceConfigManager(),
+	}
+	// a bucket has no size to resize, the resize scenario is skipped
+	h.Gone = func(t *testing.T, obj conformance.Object) bool {
+		return !cloud.buckets[obj.GetAnnotations()[ResourceIdentifierAnnotation]]
+	}
+	conformance.RunBlobStorage(t, h, &conformanceBlobStorageProvider{BlobStorageProvider: p, cloud: cloud}, func(t *testing.T, name string) *v1alpha1.BlobStorage {
+		bs := &v1alpha1.BlobStorage{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testConformanceNamespace},
+			Spec:       croType.ResourceTypeSpec{SecretRef: &croType.SecretRef{Name: name}},
+		}
+		createConformanceCR(t, h.Client, bs)
+		return bs
+	})
+}
@@ -15,6 +15,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/integr8ly/cloud-resource-operator/internal/k8sutil"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
 	v1 "github.com/openshift/cloud-credential-operator/pkg/apis/cloudcredential/v1"
 	errorUtil "github.com/pkg/errors"
 	v12 "k8s.io/api/core/v1"
@@ -44,13 +45,19 @@ var (
 				"s3:PutBucketTagging",
 				"s3:PutBucketPublicAccessBlock",
 				"s3:PutEncryptionConfiguration",
+				"s3:GetLifecycleConfiguration",
+				"s3:PutLifecycleConfiguration",
+				"s3:ListBucketMultipartUploads",
+				"s3:AbortMultipartUpload",
 				"ec2:DescribeVpcs",
 				"ec2:DescribeSubnets",
 				"ec2:DescribeSecurityGroups",
+				"ec2:DescribeInstances",
 				"ec2:DescribeInstanceTypes",
 				"ec2:CreateSecurityGroup",
 				"ec2:DeleteSecurityGroup",
 				"ec2:AuthorizeSecurityGroupIngress",
+				"ec2:RevokeSecurityGroupIngress",
 				"ec2:AuthorizeSecurityGroupEgress",
 				"ec2:DescribeAvailabilityZones",
 				"ec2:CreateSubnet",
@@ -81,6 +88,9 @@ var (
 				"elasticache:ModifyCacheSubnetGroup",
 				"elasticache:DeleteCacheSubnetGroup",
 				"elasticache:ModifyReplicationGroup",
+				"elasticache:IncreaseReplicaCount",
+				"elasticache:DecreaseReplicaCount",
+				"elasticache:DescribeReservedCacheNodesOfferings",
 				"rds:DescribeDBInstances",
 				"rds:CreateDBInstance",
 				"rds:DeleteDBInstance",
@@ -89,6 +99,7 @@ var (
 				"rds:DescribeDBSnapshots",
 				"rds:CreateDBSnapshot",
 				"rds:DeleteDBSnapshot",
+				"rds:CopyDBSnapshot",
 				"rds:DescribePendingMaintenanceActions",
 				"rds:CreateDBSubnetGroup",
 				"rds:DescribeDBSubnetGroups",
@@ -97,10 +108,25 @@ var (
 				"rds:ListTagsForResource",
 				"rds:RemoveTagsFromResource",
 				"rds:ApplyPendingMaintenanceAction",
+				"rds:DescribeOrderableDBInstanceOptions",
+				"rds:DescribeCertificates",
 				//"sts:GetCallerIdentity",
 				"iam:CreateServiceLinkedRole",
 				"cloudwatch:ListMetrics",
 				"cloudwatch:GetMetricData",
+				"kms:CreateGrant",
+				"kms:DescribeKey",
+				"kms:CreateKey",
+				"kms:CreateAlias",
+				"kms:EnableKeyRotation",
+				"kms:ScheduleKeyDeletion",
+				"kms:TagResource",
+				"sqs:CreateQueue",
+				"sqs:DeleteQueue",
+				"sqs:GetQueueUrl",
+				"sqs:GetQueueAttributes",
+				"sqs:SetQueueAttributes",
+				"sqs:TagQueue",
 			},
 			Resource: "*",
 		},
@@ -124,9 +150,55 @@ func buildPutBucketObjectEntries(bucket string) []v1.StatementEntry {
 			},
 			Resource: fmt.Sprintf("arn:aws:s3:::%s/*", bucket),
 		},
+		// objects of buckets encrypted with a customer managed key are read and written through the key
+		{
+			Effect: "Allow",
+			Action: []string{
+				"kms:Decrypt",
+				"kms:GenerateDataKey",
+			},
+			Resource: "*",
+			PolicyCondition: v1.IAMPolicyCondition{
+				"StringLike": v1.IAMPolicyConditionKeyValue{
+					"kms:ViaService": "s3.*.amazonaws.com",
+				},
+			},
+		},
 	}
 }
 
+func buildQueueAccessEntries(queueARNs []string) []v1.StatementEntry {
+	var entries []v1.StatementEntry
+	for _, queueARN := range queueARNs {
+		entries = append(entries, v1.StatementEntry{
+			Effect: "Allow",
+			Action: []string{
+				"sqs:SendMessage",
+				"sqs:ReceiveMessage",
+				"sqs:DeleteMessage",
+				"sqs:ChangeMessageVisibility",
+				"sqs:GetQueueUrl",
+				"sqs:GetQueueAttributes",
+			},
+			Resource: queueARN,
+		})
+	}
+	// messages of queues encrypted with a customer managed key are sent and received through the key
+	return append(entries, v1.StatementEntry{
+		Effect: "Allow",
+		Action: []string{
+			"kms:Decrypt",
+			"kms:GenerateDataKey",
+		},
+		Resource: "*",
+		PolicyCondition: v1.IAMPolicyCondition{
+			"StringLike": v1.IAMPolicyConditionKeyValue{
+				"kms:ViaService": "sqs.*.amazonaws.com",
+			},
+		},
+	})
+}
+
 type Credentials struct {
 	Username        string
 	PolicyName      string
@@ -134,12 +206,15 @@ type Credentials struct {
 	SecretAccessKey string
 	RoleArn         string
 	TokenFilePath   string
+	// IssuedAt is the time the access key was issued, zero if it isn't known
+	IssuedAt time.Time
 }
 
 //go:generate moq -out credentials_moq.go . CredentialManager
 type CredentialManager interface {
 	ReconcileProviderCredentials(ctx context.Context, ns string) (*Credentials, error)
 	ReconcileBucketOwnerCredentials(ctx context.Context, name, ns, bucket string) (*Credentials, error)
+	ReconcileQueueOwnerCredentials(ctx context.Context, name, ns string, queueARNs []string) (*Credentials, error)
 }
 
 func NewCredentialManager(client client.Client) (CredentialManager, error) {
@@ -147,6 +222,12 @@ func NewCredentialManager(client client.Client) (CredentialManager, error) {
 	if err != nil {
 		return nil, err
 	}
+	switch RequestedAuthMode {
+	case AuthModeCredentialMinter:
+		return NewCredentialMinterCredentialManager(client), nil
+	case AuthModeSTS:
+		return NewSTSCredentialManager(client, ns), nil
+	}
 	secret, err := getSTSCredentialsSecret(context.TODO(), client, ns)
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -194,6 +275,15 @@ func (m *CredentialMinterCredentialManager) ReconcileBucketOwnerCredentials(ctx
 	return creds, nil
 }
 
+// ReconcileQueueOwnerCredentials Ensure credentials scoped to sending and receiving the messages of the queues are available
+func (m *CredentialMinterCredentialManager) ReconcileQueueOwnerCredentials(ctx context.Context, name, ns string, queueARNs []string) (*Credentials, error) {
+	creds, err := m.reconcileCredentials(ctx, name, ns, buildQueueAccessEntries(queueARNs))
+	if err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
 func (m *CredentialMinterCredentialManager) reconcileCredentials(ctx context.Context, name string, ns string, entries []v1.StatementEntry) (*Credentials, error) {
 	cr, err := m.reconcileCredentialRequest(ctx, name, ns, entries)
 	if err != nil {
@@ -220,7 +310,7 @@ func (m *CredentialMinterCredentialManager) reconcileCredentials(ctx context.Con
 	if err = codec.DecodeProviderSpec(cr.Status.ProviderStatus, awsProvStatus); err != nil {
 		return nil, errorUtil.Wrapf(err, "failed to decode credentials request %s", cr.Name)
 	}
-	accessKeyID, secAccessKey, err := m.reconcileAWSCredentials(ctx, cr)
+	accessKeyID, secAccessKey, issuedAt, err := m.reconcileAWSCredentials(ctx, cr)
 	if err != nil {
 		return nil, errorUtil.Wrapf(err, "failed to reconcile aws credentials from credential request %s", cr.Name)
 	}
@@ -229,6 +319,7 @@ func (m *CredentialMinterCredentialManager) reconcileCredentials(ctx context.Con
 		PolicyName:      awsProvStatus.Policy,
 		AccessKeyID:     accessKeyID,
 		SecretAccessKey: secAccessKey,
+		IssuedAt:        issuedAt,
 	}, nil
 }
 
@@ -266,19 +357,31 @@ func (m *CredentialMinterCredentialManager) reconcileCredentialRequest(ctx conte
 	return cr, nil
 }
 
-func (m *CredentialMinterCredentialManager) reconcileAWSCredentials(ctx context.Context, cr *v1.CredentialsRequest) (string, string, error) {
+// buildAccessKeyArtifacts returns the access key of the end-user credentials as an expiring artifact, none if the
+// credentials don't hold an access key or the time it was issued isn't known
+func buildAccessKeyArtifacts(name string, creds *Credentials) []providers.ExpiringArtifact {
+	if creds == nil || creds.AccessKeyID == "" || creds.IssuedAt.IsZero() {
+		return nil
+	}
+	return []providers.ExpiringArtifact{{Kind: providers.ArtifactKindAccessKey, Name: name, IssuedAt: creds.IssuedAt}}
+}
+
+// reconcileAWSCredentials returns the access key minted for the credential request and the time it was issued, the
+// credential minter issues the key when it creates the secret holding it
+func (m *CredentialMinterCredentialManager) reconcileAWSCredentials(ctx context.Context, cr *v1.CredentialsRequest) (string, string, time.Time, error) {
 	sec := &v12.Secret{}
 	err := m.Client.Get(ctx, types.NamespacedName{Name: cr.Spec.SecretRef.Name, Namespace: cr.Spec.SecretRef.Namespace}, sec)
 	if err != nil {
-		return "", "", errorUtil.Wrapf(err, "failed to get aws credentials secret %s", cr.Spec.SecretRef.Name)
+		return "", "", time.Time{}, errorUtil.Wrapf(err, "failed to get aws credentials secret %s", cr.Spec.SecretRef.Name)
 	}
 	awsAccessKeyID := string(sec.Data[defaultCredentialsKeyIDName])
 	awsSecretAccessKey := string(sec.Data[defaultCredentialsSecretKeyName])
 	if awsAccessKeyID == "" {
-		return "", "", errorUtil.New(fmt.Sprintf("aws access key id is undefined in secret %s", sec.Name))
+		return "", "", time.Time{}, errorUtil.New(fmt.Sprintf("aws access key id is undefined in secret %s", sec.Name))
 	}
 	if awsSecretAccessKey == "" {
-		return "", "", errorUtil.New(fmt.Sprintf("aws secret access key is undefined in secret %s", sec.Name))
+		return "", "", time.Time{}, errorUtil.New(fmt.Sprintf("aws secret access key is undefined in secret %s", sec.Name))
 	}
-	return awsAccessKeyID, awsSecretAccessKey, nil
+	resources.RegisterSecretValue(awsAccessKeyID, awsSecretAccessKey)
+	return awsAccessKeyID, awsSecretAccessKey, sec.CreationTimestamp.Time, nil
 }
@@ -24,6 +24,9 @@ var _ CredentialManager = &CredentialManagerMock{}
 // 			ReconcileProviderCredentialsFunc: func(ctx context.Context, ns string) (*Credentials, error) {
 // 				panic("mock out the ReconcileProviderCredentials method")
 // 			},
+// 			ReconcileQueueOwnerCredentialsFunc: func(ctx context.Context, name string, ns string, queueARNs []string) (*Credentials, error) {
+// 				panic("mock out the ReconcileQueueOwnerCredentials method")
+// 			},
 // 		}
 //
 // 		// use mockedCredentialManager in code that requires CredentialManager
@@ -37,6 +40,9 @@ type CredentialManagerMock struct {
 	// ReconcileProviderCredentialsFunc mocks the ReconcileProviderCredentials method.
 	ReconcileProviderCredentialsFunc func(ctx context.Context, ns string) (*Credentials, error)
 
+	// ReconcileQueueOwnerCredentialsFunc mocks the ReconcileQueueOwnerCredentials method.
+	ReconcileQueueOwnerCredentialsFunc func(ctx context.Context, name string, ns string, queueARNs []string) (*Credentials, error)
+
 	// calls tracks calls to the methods.
 	calls struct {
 		// ReconcileBucketOwnerCredentials holds details about calls to the ReconcileBucketOwnerCredentials method.
@@ -57,9 +63,21 @@ type CredentialManagerMock struct {
 			// Ns is the ns argument value.
 			Ns string
 		}
+		// ReconcileQueueOwnerCredentials holds details about calls to the ReconcileQueueOwnerCredentials method.
+		ReconcileQueueOwnerCredentials []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Name is the name argument value.
+			Name string
+			// Ns is the ns argument value.
+			Ns string
+			// QueueARNs is the queueARNs argument value.
+			QueueARNs []string
+		}
 	}
 	lockReconcileBucketOwnerCredentials sync.RWMutex
 	lockReconcileProviderCredentials    sync.RWMutex
+	lockReconcileQueueOwnerCredentials  sync.RWMutex
 }
 
 // ReconcileBucketOwnerCredentials calls ReconcileBucketOwnerCredentialsFunc.
@@ -139,3 +157,46 @@ func (mock *CredentialManagerMock) ReconcileProviderCredentialsCalls() []struct
 	mock.lockReconcileProviderCredentials.RUnlock()
 	return calls
 }
+
+// ReconcileQueueOwnerCredentials calls ReconcileQueueOwnerCredentialsFunc.
+func (mock *CredentialManagerMock) ReconcileQueueOwnerCredentials(ctx context.Context, name string, ns string, queueARNs []string) (*Credentials, error) {
+	if mock.ReconcileQueueOwnerCredentialsFunc == nil {
+		panic("CredentialManagerMock.ReconcileQueueOwnerCredentialsFunc: method is nil but CredentialManager.ReconcileQueueOwnerCredentials was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		Name      string
+		Ns        string
+		QueueARNs []string
+	}{
+		Ctx:       ctx,
+		Name:      name,
+		Ns:        ns,
+		QueueARNs: queueARNs,
+	}
+	mock.lockReconcileQueueOwnerCredentials.Lock()
+	mock.calls.ReconcileQueueOwnerCredentials = append(mock.calls.ReconcileQueueOwnerCredentials, callInfo)
+	mock.lockReconcileQueueOwnerCredentials.Unlock()
+	return mock.ReconcileQueueOwnerCredentialsFunc(ctx, name, ns, queueARNs)
+}
+
+// ReconcileQueueOwnerCredentialsCalls gets all the calls that were made to ReconcileQueueOwnerCredentials.
+// Check the length with:
+//     len(mockedCredentialManager.ReconcileQueueOwnerCredentialsCalls())
+func (mock *CredentialManagerMock) ReconcileQueueOwnerCredentialsCalls() []struct {
+	Ctx       context.Context
+	Name      string
+	Ns        string
+	QueueARNs []string
+} {
+	var calls []struct {
+		Ctx       context.Context
+		Name      string
+		Ns        string
+		QueueARNs []string
+	}
+	mock.lockReconcileQueueOwnerCredentials.RLock()
+	calls = mock.calls.ReconcileQueueOwnerCredentials
+	mock.lockReconcileQueueOwnerCredentials.RUnlock()
+	return calls
+}
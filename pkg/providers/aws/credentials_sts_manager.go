@@ -3,6 +3,9 @@ package aws
 import (
 	"context"
 	"fmt"
+	"os"
+
+	"k8s.io/apimachinery/pkg/api/errors"
 
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -15,8 +18,35 @@ const (
 	defaultSTSCredentialSecretName = "sts-credentials"
 	defaultRoleARNKeyName          = "role_arn"
 	defaultTokenPathKeyName        = "web_identity_token_file"
+
+	// AuthModeAuto selects the sts auth mode if the sts credentials secret exists, the credential minter otherwise
+	AuthModeAuto = "auto"
+	// AuthModeCredentialMinter is the auth mode of the provider using credentials minted by the cloud credential operator
+	AuthModeCredentialMinter = "credential-minter"
+	// AuthModeSTS is the auth mode of the provider assuming a role with the web identity token mounted in the pod
+	AuthModeSTS = "sts"
+
+	// the environment variables the eks pod identity webhook sets from the role of the service account of the pod, they
+	// are used in the sts auth mode if the sts credentials secret doesn't exist
+	roleARNEnvVar   = "AWS_ROLE_ARN"
+	tokenFileEnvVar = "AWS_WEB_IDENTITY_TOKEN_FILE"
 )
 
+// RequestedAuthMode is the auth mode of the aws providers selected by the operator, one of AuthModeAuto,
+// AuthModeCredentialMinter or AuthModeSTS
+var RequestedAuthMode = AuthModeAuto
+
+// ParseAuthMode parses the aws auth mode the operator is started with, an empty mode is AuthModeAuto
+func ParseAuthMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return AuthModeAuto, nil
+	case AuthModeAuto, AuthModeCredentialMinter, AuthModeSTS:
+		return mode, nil
+	}
+	return "", errorUtil.New(fmt.Sprintf("unknown aws auth mode %s, expected one of %s, %s or %s", mode, AuthModeAuto, AuthModeCredentialMinter, AuthModeSTS))
+}
+
 var _ CredentialManager = (*STSCredentialManager)(nil)
 
 // STSCredentialManager Implementation of CredentialManager for OpenShift Clusters that use STS
@@ -32,10 +62,14 @@ func NewSTSCredentialManager(client client.Client, ns string) *STSCredentialMana
 	}
 }
 
-//ReconcileProviderCredentials Ensure the credentials the AWS provider requires are available
+//ReconcileProviderCredentials Ensure the credentials the AWS provider requires are available, the role and token are
+//read from the sts credentials secret, or from the environment of the pod if the secret doesn't exist
 func (m *STSCredentialManager) ReconcileProviderCredentials(ctx context.Context, _ string) (*Credentials, error) {
 	secret, err := getSTSCredentialsSecret(ctx, m.Client, m.OperatorNamespace)
 	if err != nil {
+		if errors.IsNotFound(err) && os.Getenv(roleARNEnvVar) != "" {
+			return credentialsFromEnv()
+		}
 		return nil, errorUtil.Wrapf(err, "failed to get aws sts credentials secret %s", defaultSTSCredentialSecretName)
 	}
 	credentials := &Credentials{
@@ -51,10 +85,51 @@ func (m *STSCredentialManager) ReconcileProviderCredentials(ctx context.Context,
 	return credentials, nil
 }
 
+// credentialsFromEnv returns the role and token set in the environment of the pod by the eks pod identity webhook
+func credentialsFromEnv() (*Credentials, error) {
+	credentials := &Credentials{
+		RoleArn:       os.Getenv(roleARNEnvVar),
+		TokenFilePath: os.Getenv(tokenFileEnvVar),
+	}
+	if credentials.TokenFilePath == "" {
+		return nil, errorUtil.New(fmt.Sprintf("%s is set but %s is undefined", roleARNEnvVar, tokenFileEnvVar))
+	}
+	return credentials, nil
+}
+
 func (m *STSCredentialManager) ReconcileBucketOwnerCredentials(_ context.Context, _, _, _ string) (*Credentials, error) {
 	return nil, nil
 }
 
+func (m *STSCredentialManager) ReconcileQueueOwnerCredentials(_ context.Context, _, _ string, _ []string) (*Credentials, error) {
+	return nil, nil
+}
+
+// GetAuthMode returns the auth mode the provider uses with the requested auth mode and the credentials in the operator
+// namespace, the sts credentials are checked so a misconfiguration is reported at startup rather than on the first
+// reconcile
+func GetAuthMode(ctx context.Context, client client.Client, ns string) (string, error) {
+	if RequestedAuthMode == AuthModeCredentialMinter {
+		return AuthModeCredentialMinter, nil
+	}
+	if RequestedAuthMode != AuthModeSTS {
+		if _, err := getSTSCredentialsSecret(ctx, client, ns); err != nil {
+			if errors.IsNotFound(err) {
+				return AuthModeCredentialMinter, nil
+			}
+			return "", errorUtil.Wrapf(err, "failed to get aws sts credentials secret %s", defaultSTSCredentialSecretName)
+		}
+	}
+	credentials, err := NewSTSCredentialManager(client, ns).ReconcileProviderCredentials(ctx, ns)
+	if err != nil {
+		return "", err
+	}
+	if _, err = os.Stat(credentials.TokenFilePath); err != nil {
+		return "", errorUtil.Wrapf(err, "failed to read web identity token %s", credentials.TokenFilePath)
+	}
+	return AuthModeSTS, nil
+}
+
 func getSTSCredentialsSecret(ctx context.Context, client client.Client, ns string) (*corev1.Secret, error) {
 	secret := &corev1.Secret{}
 	err := client.Get(ctx, types.NamespacedName{Name: defaultSTSCredentialSecretName, Namespace: ns}, secret)
@@ -6,6 +6,7 @@ import (
 	"github.com/integr8ly/cloud-resource-operator/internal/k8sutil"
 	v12 "k8s.io/api/core/v1"
 	"os"
+	"path/filepath"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -156,3 +157,129 @@ func TestSTSCredentialManager_ReconcileBucketOwnerCredentials(t *testing.T) {
 		})
 	}
 }
+
+func TestGetAuthMode(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err = os.WriteFile(tokenFile, []byte("token"), 0600); err != nil {
+		t.Fatal("failed to write token file", err)
+	}
+	buildSecret := func(tokenPath string) *v12.Secret {
+		return &v12.Secret{
+			ObjectMeta: controllerruntime.ObjectMeta{
+				Name:      defaultSTSCredentialSecretName,
+				Namespace: "test",
+			},
+			Data: map[string][]byte{
+				defaultRoleARNKeyName:   []byte("ROLE_ARN"),
+				defaultTokenPathKeyName: []byte(tokenPath),
+			},
+		}
+	}
+	cases := []struct {
+		name    string
+		client  client.Client
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "credential minter without the sts credentials secret",
+			client: fake.NewFakeClientWithScheme(scheme),
+			want:   AuthModeCredentialMinter,
+		},
+		{
+			name:   "sts with the sts credentials secret",
+			client: fake.NewFakeClientWithScheme(scheme, buildSecret(tokenFile)),
+			want:   AuthModeSTS,
+		},
+		{
+			name:    "error when the web identity token is missing",
+			client:  fake.NewFakeClientWithScheme(scheme, buildSecret(filepath.Join(t.TempDir(), "missing"))),
+			wantErr: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := GetAuthMode(context.TODO(), tc.client, "test")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("GetAuthMode() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Fatalf("GetAuthMode() = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseAuthMode(t *testing.T) {
+	for in, want := range map[string]string{"": AuthModeAuto, AuthModeAuto: AuthModeAuto, AuthModeCredentialMinter: AuthModeCredentialMinter, AuthModeSTS: AuthModeSTS} {
+		got, err := ParseAuthMode(in)
+		if err != nil || got != want {
+			t.Errorf("ParseAuthMode(%q) = %s, %v, want %s", in, got, err, want)
+		}
+	}
+	if _, err := ParseAuthMode("static"); err == nil {
+		t.Error("ParseAuthMode() expected an error for an unknown mode")
+	}
+}
+
+func TestGetAuthMode_Requested(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err = os.WriteFile(tokenFile, []byte("token"), 0600); err != nil {
+		t.Fatal("failed to write token file", err)
+	}
+	defer func() { RequestedAuthMode = AuthModeAuto }()
+	cases := []struct {
+		name      string
+		requested string
+		env       map[string]string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "credential minter is used when requested",
+			requested: AuthModeCredentialMinter,
+			env:       map[string]string{roleARNEnvVar: "ROLE_ARN", tokenFileEnvVar: tokenFile},
+			want:      AuthModeCredentialMinter,
+		},
+		{
+			name:      "sts reads the role and token from the environment without the sts credentials secret",
+			requested: AuthModeSTS,
+			env:       map[string]string{roleARNEnvVar: "ROLE_ARN", tokenFileEnvVar: tokenFile},
+			want:      AuthModeSTS,
+		},
+		{
+			name:      "error when sts is requested without credentials",
+			requested: AuthModeSTS,
+			wantErr:   true,
+		},
+		{
+			name:      "error when the token file isn't set in the environment",
+			requested: AuthModeSTS,
+			env:       map[string]string{roleARNEnvVar: "ROLE_ARN"},
+			wantErr:   true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, key := range []string{roleARNEnvVar, tokenFileEnvVar} {
+				t.Setenv(key, tc.env[key])
+			}
+			RequestedAuthMode = tc.requested
+			got, err := GetAuthMode(context.TODO(), fake.NewFakeClientWithScheme(scheme), "test")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("GetAuthMode() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Fatalf("GetAuthMode() = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
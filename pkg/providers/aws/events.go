@@ -0,0 +1,101 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	"github.com/aws/aws-sdk-go/service/elasticache/elasticacheiface"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	errorUtil "github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// eventsDurationMinutes is how far back the events of a cloud resource are read, aws keeps them for 14 days
+	eventsDurationMinutes = 24 * 60
+	// maxEvents is the number of the most recent events of a cloud resource that are read
+	maxEvents = 20
+)
+
+var _ providers.PostgresEventReader = (*PostgresProvider)(nil)
+var _ providers.RedisEventReader = (*RedisProvider)(nil)
+
+// GetPostgresEvents returns the rds events of the last day of the instance of the postgres
+func (p *PostgresProvider) GetPostgresEvents(ctx context.Context, ps *v1alpha1.Postgres) ([]providers.ProviderEvent, error) {
+	_, _, _, stratCfg, err := p.getRDSConfig(ctx, ps)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to retrieve aws rds config")
+	}
+	providerCreds, err := p.CredentialManager.ReconcileProviderCredentials(ctx, ps.Namespace)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to reconcile aws provider credentials")
+	}
+	sess, err := CreateSessionFromStrategy(ctx, p.Client, providerCreds, stratCfg)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to create aws session to read rds events")
+	}
+	instanceName, err := p.buildInstanceName(ctx, ps)
+	if err != nil {
+		return nil, err
+	}
+	return getRDSEvents(rds.New(sess), instanceName)
+}
+
+// GetRedisEvents returns the elasticache events of the last day of the replication group of the redis
+func (p *RedisProvider) GetRedisEvents(ctx context.Context, r *v1alpha1.Redis) ([]providers.ProviderEvent, error) {
+	_, _, _, stratCfg, err := p.getElasticacheConfig(ctx, r)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to retrieve aws elasticache config")
+	}
+	providerCreds, err := p.CredentialManager.ReconcileProviderCredentials(ctx, r.Namespace)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to reconcile aws provider credentials")
+	}
+	sess, err := CreateSessionFromStrategy(ctx, p.Client, providerCreds, stratCfg)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to create aws session to read elasticache events")
+	}
+	cacheName, err := p.buildCacheName(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return getElasticacheEvents(elasticache.New(sess), cacheName)
+}
+
+func getRDSEvents(rdsSvc rdsiface.RDSAPI, instanceName string) ([]providers.ProviderEvent, error) {
+	out, err := rdsSvc.DescribeEvents(&rds.DescribeEventsInput{
+		SourceIdentifier: aws.String(instanceName),
+		SourceType:       aws.String(rds.SourceTypeDbInstance),
+		Duration:         aws.Int64(eventsDurationMinutes),
+		MaxRecords:       aws.Int64(maxEvents),
+	})
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to describe rds events of instance %s", instanceName)
+	}
+	var events []providers.ProviderEvent
+	for _, e := range out.Events {
+		events = append(events, providers.ProviderEvent{Time: metav1.NewTime(aws.TimeValue(e.Date)), Message: aws.StringValue(e.Message)})
+	}
+	return events, nil
+}
+
+func getElasticacheEvents(cacheSvc elasticacheiface.ElastiCacheAPI, cacheName string) ([]providers.ProviderEvent, error) {
+	out, err := cacheSvc.DescribeEvents(&elasticache.DescribeEventsInput{
+		SourceIdentifier: aws.String(cacheName),
+		SourceType:       aws.String(elasticache.SourceTypeReplicationGroup),
+		Duration:         aws.Int64(eventsDurationMinutes),
+		MaxRecords:       aws.Int64(maxEvents),
+	})
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to describe elasticache events of replication group %s", cacheName)
+	}
+	var events []providers.ProviderEvent
+	for _, e := range out.Events {
+		events = append(events, providers.ProviderEvent{Time: metav1.NewTime(aws.TimeValue(e.Date)), Message: aws.StringValue(e.Message)})
+	}
+	return events, nil
+}
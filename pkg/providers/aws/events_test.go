@@ -0,0 +1,62 @@
+package aws
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+)
+
+type mockRDSEventsClient struct {
+	rdsiface.RDSAPI
+	describeEventsFn func(*rds.DescribeEventsInput) (*rds.DescribeEventsOutput, error)
+}
+
+func (m *mockRDSEventsClient) DescribeEvents(input *rds.DescribeEventsInput) (*rds.DescribeEventsOutput, error) {
+	return m.describeEventsFn(input)
+}
+
+func TestGetRDSEvents(t *testing.T) {
+	date := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		client      *mockRDSEventsClient
+		wantMessage string
+		wantErr     bool
+	}{
+		{
+			name: "test events of the instance are returned",
+			client: &mockRDSEventsClient{describeEventsFn: func(input *rds.DescribeEventsInput) (*rds.DescribeEventsOutput, error) {
+				if aws.StringValue(input.SourceIdentifier) != "test" || aws.StringValue(input.SourceType) != rds.SourceTypeDbInstance {
+					t.Errorf("DescribeEvents() input = %v, want events of db instance test", input)
+				}
+				return &rds.DescribeEventsOutput{Events: []*rds.Event{{Date: aws.Time(date), Message: aws.String("DB instance restarted")}}}, nil
+			}},
+			wantMessage: "DB instance restarted",
+		},
+		{
+			name: "test error describing events is returned",
+			client: &mockRDSEventsClient{describeEventsFn: func(input *rds.DescribeEventsInput) (*rds.DescribeEventsOutput, error) {
+				return nil, errors.New("throttled")
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := getRDSEvents(tt.client, "test")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getRDSEvents() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(events) != 1 || events[0].Message != tt.wantMessage || !events[0].Time.Time.Equal(date) {
+				t.Errorf("getRDSEvents() = %v, want one event %s at %s", events, tt.wantMessage, date)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package aws
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/sirupsen/logrus"
+)
+
+func TestGetRDSCACertificateArtifacts(t *testing.T) {
+	validTill := time.Now().AddDate(1, 0, 0)
+	tests := []struct {
+		name     string
+		instance *rds.DBInstance
+		rdsSvc   *mockRdsClient
+		want     int
+	}{
+		{
+			name:     "test ca certificate of the instance is returned",
+			instance: &rds.DBInstance{DBInstanceIdentifier: aws.String("test"), CACertificateIdentifier: aws.String("rds-ca-2019")},
+			rdsSvc: buildMockRdsClient(func(m *mockRdsClient) {
+				m.describeCertificatesFn = func(input *rds.DescribeCertificatesInput) (*rds.DescribeCertificatesOutput, error) {
+					return &rds.DescribeCertificatesOutput{Certificates: []*rds.Certificate{{CertificateIdentifier: input.CertificateIdentifier, ValidTill: aws.Time(validTill)}}}, nil
+				}
+			}),
+			want: 1,
+		},
+		{
+			name:     "test failing to describe the ca certificate doesn't return artifacts",
+			instance: &rds.DBInstance{DBInstanceIdentifier: aws.String("test"), CACertificateIdentifier: aws.String("rds-ca-2019")},
+			rdsSvc: buildMockRdsClient(func(m *mockRdsClient) {
+				m.describeCertificatesFn = func(input *rds.DescribeCertificatesInput) (*rds.DescribeCertificatesOutput, error) {
+					return nil, errors.New("access denied")
+				}
+			}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getRDSCACertificateArtifacts(tt.rdsSvc, tt.instance, logrus.WithFields(logrus.Fields{}))
+			if len(got) != tt.want {
+				t.Fatalf("getRDSCACertificateArtifacts() = %v, want %d artifacts", got, tt.want)
+			}
+			if tt.want > 0 && (got[0].Kind != providers.ArtifactKindCABundle || !got[0].ExpiresAt.Equal(validTill)) {
+				t.Errorf("getRDSCACertificateArtifacts() = %v, want ca bundle expiring at %s", got[0], validTill)
+			}
+		})
+	}
+}
+
+func TestBuildAccessKeyArtifacts(t *testing.T) {
+	issuedAt := time.Now().AddDate(0, 0, -10)
+	got := buildAccessKeyArtifacts("test-creds", &Credentials{AccessKeyID: "test", IssuedAt: issuedAt})
+	if len(got) != 1 || got[0].Kind != providers.ArtifactKindAccessKey || !got[0].IssuedAt.Equal(issuedAt) {
+		t.Errorf("buildAccessKeyArtifacts() = %v, want access key issued at %s", got, issuedAt)
+	}
+	// the sts credential manager doesn't return end-user credentials
+	if got := buildAccessKeyArtifacts("test-creds", nil); got != nil {
+		t.Errorf("buildAccessKeyArtifacts() = %v, want none without credentials", got)
+	}
+}
@@ -0,0 +1,30 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+	errorUtil "github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CheckRDSAccess checks the provider credentials of the operator can list rds instances in the region of the cluster
+func CheckRDSAccess(ctx context.Context, c client.Client, ns string) error {
+	credManager, err := NewCredentialManager(c)
+	if err != nil {
+		return errorUtil.Wrap(err, "failed to create aws credential manager")
+	}
+	creds, err := credManager.ReconcileProviderCredentials(ctx, ns)
+	if err != nil {
+		return errorUtil.Wrap(err, "failed to reconcile aws provider credentials")
+	}
+	sess, err := CreateSessionFromStrategy(ctx, c, creds, &StrategyConfig{})
+	if err != nil {
+		return errorUtil.Wrap(err, "failed to create aws session")
+	}
+	if _, err := rds.New(sess).DescribeDBInstancesWithContext(ctx, &rds.DescribeDBInstancesInput{MaxRecords: aws.Int64(20)}); err != nil {
+		return errorUtil.Wrap(err, "failed to list rds instances")
+	}
+	return nil
+}
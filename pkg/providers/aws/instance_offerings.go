@@ -0,0 +1,229 @@
+package aws
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	"github.com/aws/aws-sdk-go/service/elasticache/elasticacheiface"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+)
+
+const (
+	defaultElasticacheProductDescription = "redis"
+)
+
+// validateRDSInstanceClassOffering ensures the instance class of the create strategy can be provisioned in the
+// availability zones of the subnet group, rather than relying on rds to fail the create with an opaque
+// InsufficientDBInstanceCapacity error. a single az instance is moved to a supported availability zone where the
+// requested zone, or some of the subnet group zones, don't offer the instance class
+func validateRDSInstanceClassOffering(rdsSvc rdsiface.RDSAPI, rdsCfg *rds.CreateDBInstanceInput) error {
+	instanceClass := aws.StringValue(rdsCfg.DBInstanceClass)
+	offeredAZs, multiAZCapable, err := getOrderableRDSAvailabilityZones(rdsSvc, rdsCfg)
+	if err != nil {
+		return errorUtil.Wrapf(err, "failed to get orderable options for rds instance class %s", instanceClass)
+	}
+	if len(offeredAZs) == 0 {
+		return errorUtil.New(fmt.Sprintf("rds instance class %s is not offered for engine %s version %s in this region", instanceClass, aws.StringValue(rdsCfg.Engine), aws.StringValue(rdsCfg.EngineVersion)))
+	}
+
+	// only availability zones covered by the subnet group can be used by the instance
+	subnetAZs, err := getRDSSubnetGroupAvailabilityZones(rdsSvc, aws.StringValue(rdsCfg.DBSubnetGroupName))
+	if err != nil {
+		return errorUtil.Wrapf(err, "failed to get availability zones of rds subnet group %s", aws.StringValue(rdsCfg.DBSubnetGroupName))
+	}
+	candidateAZs := offeredAZs
+	if len(subnetAZs) != 0 {
+		candidateAZs = intersectAvailabilityZones(offeredAZs, subnetAZs)
+	}
+	if len(candidateAZs) == 0 {
+		return errorUtil.New(fmt.Sprintf("rds instance class %s is not offered in any of the subnet group availability zones %s, offered in %s", instanceClass, strings.Join(subnetAZs, ", "), strings.Join(offeredAZs, ", ")))
+	}
+
+	if aws.BoolValue(rdsCfg.MultiAZ) {
+		if !multiAZCapable {
+			return errorUtil.New(fmt.Sprintf("rds instance class %s does not support multi az deployments", instanceClass))
+		}
+		if len(candidateAZs) < 2 {
+			return errorUtil.New(fmt.Sprintf("multi az rds instance class %s requires at least 2 supported availability zones, only offered in %s", instanceClass, strings.Join(candidateAZs, ", ")))
+		}
+		return nil
+	}
+
+	// a single az instance is placed in any of the subnet group zones unless one is requested, pin it to a supported
+	// zone where the requested zone, or any of the subnet group zones, don't offer the instance class
+	requestedAZ := aws.StringValue(rdsCfg.AvailabilityZone)
+	if requestedAZ != "" && resources.Contains(candidateAZs, requestedAZ) {
+		return nil
+	}
+	if requestedAZ == "" && (len(subnetAZs) == 0 || len(candidateAZs) == len(subnetAZs)) {
+		return nil
+	}
+	rdsCfg.AvailabilityZone = aws.String(candidateAZs[0])
+	return nil
+}
+
+// getOrderableRDSAvailabilityZones returns the sorted availability zones offering the instance class of the create
+// strategy and whether any of the offerings support multi az deployments
+func getOrderableRDSAvailabilityZones(rdsSvc rdsiface.RDSAPI, rdsCfg *rds.CreateDBInstanceInput) ([]string, bool, error) {
+	input := &rds.DescribeOrderableDBInstanceOptionsInput{
+		Engine:          rdsCfg.Engine,
+		EngineVersion:   rdsCfg.EngineVersion,
+		DBInstanceClass: rdsCfg.DBInstanceClass,
+		Vpc:             aws.Bool(true),
+	}
+	azSet := map[string]bool{}
+	multiAZCapable := false
+	for {
+		output, err := rdsSvc.DescribeOrderableDBInstanceOptions(input)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, option := range output.OrderableDBInstanceOptions {
+			if aws.BoolValue(option.MultiAZCapable) {
+				multiAZCapable = true
+			}
+			for _, az := range option.AvailabilityZones {
+				azSet[aws.StringValue(az.Name)] = true
+			}
+		}
+		if aws.StringValue(output.Marker) == "" {
+			break
+		}
+		input.Marker = output.Marker
+	}
+	return sortedAvailabilityZones(azSet), multiAZCapable, nil
+}
+
+// getRDSSubnetGroupAvailabilityZones returns the sorted availability zones of the subnets in the subnet group, or
+// nothing if the subnet group doesn't exist
+func getRDSSubnetGroupAvailabilityZones(rdsSvc rdsiface.RDSAPI, subnetGroupName string) ([]string, error) {
+	groups, err := rdsSvc.DescribeDBSubnetGroups(&rds.DescribeDBSubnetGroupsInput{})
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "error describing subnet groups")
+	}
+	azSet := map[string]bool{}
+	for _, group := range groups.DBSubnetGroups {
+		if aws.StringValue(group.DBSubnetGroupName) != subnetGroupName {
+			continue
+		}
+		for _, subnet := range group.Subnets {
+			if subnet.SubnetAvailabilityZone != nil {
+				azSet[aws.StringValue(subnet.SubnetAvailabilityZone.Name)] = true
+			}
+		}
+	}
+	return sortedAvailabilityZones(azSet), nil
+}
+
+// validateElasticacheNodeTypeOffering ensures the cache node type of the create strategy is offered in the region and
+// in the availability zones of the cache subnet group. where some of the subnet group zones don't support the node
+// type, the cache clusters are spread across the supported zones instead
+func validateElasticacheNodeTypeOffering(cacheSvc elasticacheiface.ElastiCacheAPI, ec2Svc ec2iface.EC2API, elasticacheConfig *elasticache.CreateReplicationGroupInput) error {
+	nodeType := aws.StringValue(elasticacheConfig.CacheNodeType)
+	offerings, err := cacheSvc.DescribeReservedCacheNodesOfferings(&elasticache.DescribeReservedCacheNodesOfferingsInput{
+		CacheNodeType:      elasticacheConfig.CacheNodeType,
+		ProductDescription: aws.String(defaultElasticacheProductDescription),
+	})
+	if err != nil {
+		return errorUtil.Wrapf(err, "failed to get offerings for cache node type %s", nodeType)
+	}
+	if len(offerings.ReservedCacheNodesOfferings) == 0 {
+		return errorUtil.New(fmt.Sprintf("cache node type %s is not offered in this region", nodeType))
+	}
+
+	// elasticache doesn't expose per zone offerings, the zones offering the matching ec2 instance type are used instead
+	instanceTypeOfferings, err := ec2Svc.DescribeInstanceTypeOfferings(&ec2.DescribeInstanceTypeOfferingsInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("instance-type"),
+				Values: aws.StringSlice([]string{strings.Replace(nodeType, "cache.", "", 1)}),
+			},
+		},
+		LocationType: aws.String(ec2.LocationTypeAvailabilityZone),
+	})
+	if err != nil {
+		return errorUtil.Wrapf(err, "failed to get instance type offerings for type %s", nodeType)
+	}
+	offeredAZSet := map[string]bool{}
+	for _, offering := range instanceTypeOfferings.InstanceTypeOfferings {
+		offeredAZSet[aws.StringValue(offering.Location)] = true
+	}
+	offeredAZs := sortedAvailabilityZones(offeredAZSet)
+
+	subnetAZs, err := getCacheSubnetGroupAvailabilityZones(cacheSvc, aws.StringValue(elasticacheConfig.CacheSubnetGroupName))
+	if err != nil {
+		return errorUtil.Wrapf(err, "failed to get availability zones of cache subnet group %s", aws.StringValue(elasticacheConfig.CacheSubnetGroupName))
+	}
+	candidateAZs := offeredAZs
+	if len(subnetAZs) != 0 {
+		candidateAZs = intersectAvailabilityZones(offeredAZs, subnetAZs)
+	}
+	if len(candidateAZs) == 0 {
+		return errorUtil.New(fmt.Sprintf("cache node type %s is not offered in any of the subnet group availability zones %s, offered in %s", nodeType, strings.Join(subnetAZs, ", "), strings.Join(offeredAZs, ", ")))
+	}
+
+	// keep the requested zones if they're all supported
+	requestedAZs := aws.StringValueSlice(elasticacheConfig.PreferredCacheClusterAZs)
+	if len(requestedAZs) != 0 && len(intersectAvailabilityZones(requestedAZs, candidateAZs)) == len(requestedAZs) {
+		return nil
+	}
+	if len(requestedAZs) == 0 && (len(subnetAZs) == 0 || len(candidateAZs) == len(subnetAZs)) {
+		return nil
+	}
+	// spread the cache clusters across the supported zones
+	var preferredAZs []string
+	for i := int64(0); i < aws.Int64Value(elasticacheConfig.NumCacheClusters); i++ {
+		preferredAZs = append(preferredAZs, candidateAZs[int(i)%len(candidateAZs)])
+	}
+	elasticacheConfig.PreferredCacheClusterAZs = aws.StringSlice(preferredAZs)
+	return nil
+}
+
+// getCacheSubnetGroupAvailabilityZones returns the sorted availability zones of the subnets in the cache subnet group,
+// or nothing if the subnet group doesn't exist
+func getCacheSubnetGroupAvailabilityZones(cacheSvc elasticacheiface.ElastiCacheAPI, subnetGroupName string) ([]string, error) {
+	groups, err := cacheSvc.DescribeCacheSubnetGroups(&elasticache.DescribeCacheSubnetGroupsInput{})
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "error describing cache subnet groups")
+	}
+	azSet := map[string]bool{}
+	for _, group := range groups.CacheSubnetGroups {
+		if aws.StringValue(group.CacheSubnetGroupName) != subnetGroupName {
+			continue
+		}
+		for _, subnet := range group.Subnets {
+			if subnet.SubnetAvailabilityZone != nil {
+				azSet[aws.StringValue(subnet.SubnetAvailabilityZone.Name)] = true
+			}
+		}
+	}
+	return sortedAvailabilityZones(azSet), nil
+}
+
+// intersectAvailabilityZones returns the zones in a which are also in b, preserving the order of a
+func intersectAvailabilityZones(a, b []string) []string {
+	var intersection []string
+	for _, az := range a {
+		if resources.Contains(b, az) {
+			intersection = append(intersection, az)
+		}
+	}
+	return intersection
+}
+
+func sortedAvailabilityZones(set map[string]bool) []string {
+	var keys []string
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
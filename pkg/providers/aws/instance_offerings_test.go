@@ -0,0 +1,344 @@
+package aws
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	"github.com/aws/aws-sdk-go/service/elasticache/elasticacheiface"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+)
+
+const (
+	defaultAzIdThree        = "test-zone-3"
+	testOfferingSubnetGroup = "test-subnet-group"
+)
+
+func buildOrderableDBInstanceOption(multiAZCapable bool, azs ...string) *rds.OrderableDBInstanceOption {
+	var availabilityZones []*rds.AvailabilityZone
+	for _, az := range azs {
+		availabilityZones = append(availabilityZones, &rds.AvailabilityZone{Name: aws.String(az)})
+	}
+	return &rds.OrderableDBInstanceOption{
+		DBInstanceClass:   aws.String(defaultAwsDBInstanceClass),
+		MultiAZCapable:    aws.Bool(multiAZCapable),
+		AvailabilityZones: availabilityZones,
+	}
+}
+
+func buildTestDBSubnetGroup(azs ...string) *rds.DBSubnetGroup {
+	var subnets []*rds.Subnet
+	for _, az := range azs {
+		subnets = append(subnets, &rds.Subnet{SubnetAvailabilityZone: &rds.AvailabilityZone{Name: aws.String(az)}})
+	}
+	return &rds.DBSubnetGroup{
+		DBSubnetGroupName: aws.String(testOfferingSubnetGroup),
+		Subnets:           subnets,
+	}
+}
+
+func buildTestCacheSubnetGroup(azs ...string) *elasticache.CacheSubnetGroup {
+	var subnets []*elasticache.Subnet
+	for _, az := range azs {
+		subnets = append(subnets, &elasticache.Subnet{SubnetAvailabilityZone: &elasticache.AvailabilityZone{Name: aws.String(az)}})
+	}
+	return &elasticache.CacheSubnetGroup{
+		CacheSubnetGroupName: aws.String(testOfferingSubnetGroup),
+		Subnets:              subnets,
+	}
+}
+
+func buildTestOfferingRdsClient(subnetGroup *rds.DBSubnetGroup, options ...*rds.OrderableDBInstanceOption) *mockRdsClient {
+	return buildMockRdsClient(func(rdsClient *mockRdsClient) {
+		rdsClient.describeOrderableDBInstanceOptionsFn = func(input *rds.DescribeOrderableDBInstanceOptionsInput) (*rds.DescribeOrderableDBInstanceOptionsOutput, error) {
+			return &rds.DescribeOrderableDBInstanceOptionsOutput{OrderableDBInstanceOptions: options}, nil
+		}
+		rdsClient.describeDBSubnetGroupsFn = func(input *rds.DescribeDBSubnetGroupsInput) (*rds.DescribeDBSubnetGroupsOutput, error) {
+			return &rds.DescribeDBSubnetGroupsOutput{DBSubnetGroups: []*rds.DBSubnetGroup{subnetGroup}}, nil
+		}
+	})
+}
+
+func buildTestOfferingEc2Client(azs ...string) *mockEc2Client {
+	return buildMockEc2Client(func(ec2Client *mockEc2Client) {
+		ec2Client.describeInstanceTypeOfferingsFn = func(input *ec2.DescribeInstanceTypeOfferingsInput) (*ec2.DescribeInstanceTypeOfferingsOutput, error) {
+			var offerings []*ec2.InstanceTypeOffering
+			for _, az := range azs {
+				offerings = append(offerings, &ec2.InstanceTypeOffering{Location: aws.String(az)})
+			}
+			return &ec2.DescribeInstanceTypeOfferingsOutput{InstanceTypeOfferings: offerings}, nil
+		}
+	})
+}
+
+func Test_validateRDSInstanceClassOffering(t *testing.T) {
+	type args struct {
+		rdsSvc rdsiface.RDSAPI
+		rdsCfg *rds.CreateDBInstanceInput
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *rds.CreateDBInstanceInput
+		wantErr string
+	}{
+		{
+			name: "test multi az instance class offered in all subnet group zones",
+			args: args{
+				rdsSvc: buildTestOfferingRdsClient(buildTestDBSubnetGroup(defaultAzIdOne, defaultAzIdTwo), buildOrderableDBInstanceOption(true, defaultAzIdOne, defaultAzIdTwo)),
+				rdsCfg: &rds.CreateDBInstanceInput{
+					DBInstanceClass:   aws.String(defaultAwsDBInstanceClass),
+					DBSubnetGroupName: aws.String(testOfferingSubnetGroup),
+					MultiAZ:           aws.Bool(true),
+				},
+			},
+			want: &rds.CreateDBInstanceInput{
+				DBInstanceClass:   aws.String(defaultAwsDBInstanceClass),
+				DBSubnetGroupName: aws.String(testOfferingSubnetGroup),
+				MultiAZ:           aws.Bool(true),
+			},
+		},
+		{
+			name: "test error when instance class is not offered",
+			args: args{
+				rdsSvc: buildTestOfferingRdsClient(buildTestDBSubnetGroup(defaultAzIdOne, defaultAzIdTwo)),
+				rdsCfg: &rds.CreateDBInstanceInput{
+					DBInstanceClass:   aws.String(defaultAwsDBInstanceClass),
+					DBSubnetGroupName: aws.String(testOfferingSubnetGroup),
+					Engine:            aws.String(defaultAwsEngine),
+					EngineVersion:     aws.String(defaultAwsEngineVersion),
+					MultiAZ:           aws.Bool(true),
+				},
+			},
+			wantErr: "rds instance class " + defaultAwsDBInstanceClass + " is not offered for engine " + defaultAwsEngine + " version " + defaultAwsEngineVersion + " in this region",
+		},
+		{
+			name: "test error when instance class is not offered in subnet group zones",
+			args: args{
+				rdsSvc: buildTestOfferingRdsClient(buildTestDBSubnetGroup(defaultAzIdOne, defaultAzIdTwo), buildOrderableDBInstanceOption(true, defaultAzIdThree)),
+				rdsCfg: &rds.CreateDBInstanceInput{
+					DBInstanceClass:   aws.String(defaultAwsDBInstanceClass),
+					DBSubnetGroupName: aws.String(testOfferingSubnetGroup),
+					MultiAZ:           aws.Bool(true),
+				},
+			},
+			wantErr: "rds instance class " + defaultAwsDBInstanceClass + " is not offered in any of the subnet group availability zones test-zone-1, test-zone-2, offered in test-zone-3",
+		},
+		{
+			name: "test error when multi az instance class is offered in a single subnet group zone",
+			args: args{
+				rdsSvc: buildTestOfferingRdsClient(buildTestDBSubnetGroup(defaultAzIdOne, defaultAzIdTwo), buildOrderableDBInstanceOption(true, defaultAzIdTwo, defaultAzIdThree)),
+				rdsCfg: &rds.CreateDBInstanceInput{
+					DBInstanceClass:   aws.String(defaultAwsDBInstanceClass),
+					DBSubnetGroupName: aws.String(testOfferingSubnetGroup),
+					MultiAZ:           aws.Bool(true),
+				},
+			},
+			wantErr: "multi az rds instance class " + defaultAwsDBInstanceClass + " requires at least 2 supported availability zones, only offered in test-zone-2",
+		},
+		{
+			name: "test error when instance class does not support multi az",
+			args: args{
+				rdsSvc: buildTestOfferingRdsClient(buildTestDBSubnetGroup(defaultAzIdOne, defaultAzIdTwo), buildOrderableDBInstanceOption(false, defaultAzIdOne, defaultAzIdTwo)),
+				rdsCfg: &rds.CreateDBInstanceInput{
+					DBInstanceClass:   aws.String(defaultAwsDBInstanceClass),
+					DBSubnetGroupName: aws.String(testOfferingSubnetGroup),
+					MultiAZ:           aws.Bool(true),
+				},
+			},
+			wantErr: "rds instance class " + defaultAwsDBInstanceClass + " does not support multi az deployments",
+		},
+		{
+			name: "test single az instance is pinned to a supported zone",
+			args: args{
+				rdsSvc: buildTestOfferingRdsClient(buildTestDBSubnetGroup(defaultAzIdOne, defaultAzIdTwo), buildOrderableDBInstanceOption(false, defaultAzIdTwo, defaultAzIdThree)),
+				rdsCfg: &rds.CreateDBInstanceInput{
+					DBInstanceClass:   aws.String(defaultAwsDBInstanceClass),
+					DBSubnetGroupName: aws.String(testOfferingSubnetGroup),
+					MultiAZ:           aws.Bool(false),
+					AvailabilityZone:  aws.String(defaultAzIdOne),
+				},
+			},
+			want: &rds.CreateDBInstanceInput{
+				DBInstanceClass:   aws.String(defaultAwsDBInstanceClass),
+				DBSubnetGroupName: aws.String(testOfferingSubnetGroup),
+				MultiAZ:           aws.Bool(false),
+				AvailabilityZone:  aws.String(defaultAzIdTwo),
+			},
+		},
+		{
+			name: "test single az instance is not pinned when offered in all subnet group zones",
+			args: args{
+				rdsSvc: buildTestOfferingRdsClient(buildTestDBSubnetGroup(defaultAzIdOne, defaultAzIdTwo), buildOrderableDBInstanceOption(false, defaultAzIdOne, defaultAzIdTwo)),
+				rdsCfg: &rds.CreateDBInstanceInput{
+					DBInstanceClass:   aws.String(defaultAwsDBInstanceClass),
+					DBSubnetGroupName: aws.String(testOfferingSubnetGroup),
+					MultiAZ:           aws.Bool(false),
+				},
+			},
+			want: &rds.CreateDBInstanceInput{
+				DBInstanceClass:   aws.String(defaultAwsDBInstanceClass),
+				DBSubnetGroupName: aws.String(testOfferingSubnetGroup),
+				MultiAZ:           aws.Bool(false),
+			},
+		},
+		{
+			name: "test error describing orderable options",
+			args: args{
+				rdsSvc: buildMockRdsClient(func(rdsClient *mockRdsClient) {
+					rdsClient.describeOrderableDBInstanceOptionsFn = func(input *rds.DescribeOrderableDBInstanceOptionsInput) (*rds.DescribeOrderableDBInstanceOptionsOutput, error) {
+						return nil, errors.New("test")
+					}
+				}),
+				rdsCfg: &rds.CreateDBInstanceInput{
+					DBInstanceClass: aws.String(defaultAwsDBInstanceClass),
+				},
+			},
+			wantErr: "failed to get orderable options for rds instance class " + defaultAwsDBInstanceClass + ": test",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRDSInstanceClassOffering(tt.args.rdsSvc, tt.args.rdsCfg)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("validateRDSInstanceClassOffering() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("validateRDSInstanceClassOffering() unexpected error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(tt.args.rdsCfg, tt.want) {
+				t.Errorf("validateRDSInstanceClassOffering() = %v, want %v", tt.args.rdsCfg, tt.want)
+			}
+		})
+	}
+}
+
+func Test_validateElasticacheNodeTypeOffering(t *testing.T) {
+	type args struct {
+		cacheSvc          elasticacheiface.ElastiCacheAPI
+		ec2Svc            ec2iface.EC2API
+		elasticacheConfig *elasticache.CreateReplicationGroupInput
+	}
+	buildCacheClient := func(subnetGroup *elasticache.CacheSubnetGroup) *mockElasticacheClient {
+		return buildMockElasticacheClient(func(cacheClient *mockElasticacheClient) {
+			cacheClient.describeCacheSubnetGroupsFn = func(input *elasticache.DescribeCacheSubnetGroupsInput) (*elasticache.DescribeCacheSubnetGroupsOutput, error) {
+				return &elasticache.DescribeCacheSubnetGroupsOutput{CacheSubnetGroups: []*elasticache.CacheSubnetGroup{subnetGroup}}, nil
+			}
+		})
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *elasticache.CreateReplicationGroupInput
+		wantErr string
+	}{
+		{
+			name: "test node type offered in all subnet group zones",
+			args: args{
+				cacheSvc: buildCacheClient(buildTestCacheSubnetGroup(defaultAzIdOne, defaultAzIdTwo)),
+				ec2Svc:   buildTestOfferingEc2Client(defaultAzIdOne, defaultAzIdTwo),
+				elasticacheConfig: &elasticache.CreateReplicationGroupInput{
+					CacheNodeType:        aws.String(defaultCacheNodeType),
+					CacheSubnetGroupName: aws.String(testOfferingSubnetGroup),
+					NumCacheClusters:     aws.Int64(2),
+				},
+			},
+			want: &elasticache.CreateReplicationGroupInput{
+				CacheNodeType:        aws.String(defaultCacheNodeType),
+				CacheSubnetGroupName: aws.String(testOfferingSubnetGroup),
+				NumCacheClusters:     aws.Int64(2),
+			},
+		},
+		{
+			name: "test error when node type is not offered in region",
+			args: args{
+				cacheSvc: buildMockElasticacheClient(func(cacheClient *mockElasticacheClient) {
+					cacheClient.describeReservedCacheNodesOfferingsFn = func(input *elasticache.DescribeReservedCacheNodesOfferingsInput) (*elasticache.DescribeReservedCacheNodesOfferingsOutput, error) {
+						return &elasticache.DescribeReservedCacheNodesOfferingsOutput{}, nil
+					}
+				}),
+				ec2Svc: buildTestOfferingEc2Client(defaultAzIdOne, defaultAzIdTwo),
+				elasticacheConfig: &elasticache.CreateReplicationGroupInput{
+					CacheNodeType: aws.String(defaultCacheNodeType),
+				},
+			},
+			wantErr: "cache node type " + defaultCacheNodeType + " is not offered in this region",
+		},
+		{
+			name: "test error when node type is not offered in subnet group zones",
+			args: args{
+				cacheSvc: buildCacheClient(buildTestCacheSubnetGroup(defaultAzIdOne, defaultAzIdTwo)),
+				ec2Svc:   buildTestOfferingEc2Client(defaultAzIdThree),
+				elasticacheConfig: &elasticache.CreateReplicationGroupInput{
+					CacheNodeType:        aws.String(defaultCacheNodeType),
+					CacheSubnetGroupName: aws.String(testOfferingSubnetGroup),
+				},
+			},
+			wantErr: "cache node type " + defaultCacheNodeType + " is not offered in any of the subnet group availability zones test-zone-1, test-zone-2, offered in test-zone-3",
+		},
+		{
+			name: "test cache clusters are spread across supported zones",
+			args: args{
+				cacheSvc: buildCacheClient(buildTestCacheSubnetGroup(defaultAzIdOne, defaultAzIdTwo, defaultAzIdThree)),
+				ec2Svc:   buildTestOfferingEc2Client(defaultAzIdOne, defaultAzIdThree),
+				elasticacheConfig: &elasticache.CreateReplicationGroupInput{
+					CacheNodeType:        aws.String(defaultCacheNodeType),
+					CacheSubnetGroupName: aws.String(testOfferingSubnetGroup),
+					NumCacheClusters:     aws.Int64(3),
+				},
+			},
+			want: &elasticache.CreateReplicationGroupInput{
+				CacheNodeType:            aws.String(defaultCacheNodeType),
+				CacheSubnetGroupName:     aws.String(testOfferingSubnetGroup),
+				NumCacheClusters:         aws.Int64(3),
+				PreferredCacheClusterAZs: aws.StringSlice([]string{defaultAzIdOne, defaultAzIdThree, defaultAzIdOne}),
+			},
+		},
+		{
+			name: "test supported preferred zones are kept",
+			args: args{
+				cacheSvc: buildCacheClient(buildTestCacheSubnetGroup(defaultAzIdOne, defaultAzIdTwo, defaultAzIdThree)),
+				ec2Svc:   buildTestOfferingEc2Client(defaultAzIdOne, defaultAzIdThree),
+				elasticacheConfig: &elasticache.CreateReplicationGroupInput{
+					CacheNodeType:            aws.String(defaultCacheNodeType),
+					CacheSubnetGroupName:     aws.String(testOfferingSubnetGroup),
+					NumCacheClusters:         aws.Int64(2),
+					PreferredCacheClusterAZs: aws.StringSlice([]string{defaultAzIdThree, defaultAzIdThree}),
+				},
+			},
+			want: &elasticache.CreateReplicationGroupInput{
+				CacheNodeType:            aws.String(defaultCacheNodeType),
+				CacheSubnetGroupName:     aws.String(testOfferingSubnetGroup),
+				NumCacheClusters:         aws.Int64(2),
+				PreferredCacheClusterAZs: aws.StringSlice([]string{defaultAzIdThree, defaultAzIdThree}),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateElasticacheNodeTypeOffering(tt.args.cacheSvc, tt.args.ec2Svc, tt.args.elasticacheConfig)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("validateElasticacheNodeTypeOffering() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("validateElasticacheNodeTypeOffering() unexpected error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(tt.args.elasticacheConfig, tt.want) {
+				t.Errorf("validateElasticacheNodeTypeOffering() = %v, want %v", tt.args.elasticacheConfig, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+// utility to resolve the customer managed kms key s3 buckets, rds instances and elasticache replication groups are
+// encrypted with, either a key referenced by the strategy or a key created by the operator for the cluster.
+//
+// used by the blob storage, postgres and redis providers
+
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// kmsKeyDeletionPendingDays is the shortest waiting period kms allows before deleting a key
+	kmsKeyDeletionPendingDays = 7
+)
+
+/*
+KMSEncryption configures the customer managed kms key resources are encrypted with, instead of the aws managed key of
+the account
+KmsKeyID -> an existing customer managed key, as a key id, key arn, alias name or alias arn
+Create -> if no key is referenced, create a key for the cluster. the key is shared by every resource of the cluster
+encrypted with it and is never deleted by the operator, as final snapshots can outlive the resources
+*/
+type KMSEncryption struct {
+	KmsKeyID string `json:"kmsKeyId,omitempty"`
+	Create   bool   `json:"create,omitempty"`
+}
+
+// buildClusterKMSKeyAlias returns the alias of the key the operator creates for the cluster
+func buildClusterKMSKeyAlias(clusterID string) string {
+	return fmt.Sprintf("alias/%s-cloud-resources", clusterID)
+}
+
+// reconcileKMSKey returns the arn of the customer managed key resources are encrypted with, or an empty string if the
+// strategy doesn't configure encryption with a customer managed key
+func reconcileKMSKey(ctx context.Context, c client.Client, kmsSvc kmsAPI, encryption *KMSEncryption) (string, error) {
+	if encryption == nil || (encryption.KmsKeyID == "" && !encryption.Create) {
+		return "", nil
+	}
+	if encryption.KmsKeyID != "" {
+		key, err := describeKMSKey(kmsSvc, encryption.KmsKeyID)
+		if err != nil {
+			return "", err
+		}
+		if key == nil {
+			return "", errorUtil.New(fmt.Sprintf("kms key %s not found", encryption.KmsKeyID))
+		}
+		return validateKMSKey(encryption.KmsKeyID, key)
+	}
+
+	clusterID, err := resources.GetClusterID(ctx, c)
+	if err != nil {
+		return "", errorUtil.Wrap(err, "failed to get cluster id")
+	}
+	alias := buildClusterKMSKeyAlias(clusterID)
+	key, err := describeKMSKey(kmsSvc, alias)
+	if err != nil {
+		return "", err
+	}
+	if key != nil {
+		return validateKMSKey(alias, key)
+	}
+
+	tags, err := buildKMSKeyTags(ctx, c, clusterID)
+	if err != nil {
+		return "", errorUtil.Wrap(err, "failed to build kms key tags")
+	}
+	created, err := kmsSvc.CreateKey(&kmsCreateKeyInput{
+		Description: aws.String(fmt.Sprintf("cloud resources of cluster %s", clusterID)),
+		KeyUsage:    aws.String(kmsKeyUsageEncryptDecrypt),
+		KeySpec:     aws.String(kmsKeySpecSymmetricDefault),
+		Tags:        tags,
+	})
+	if err != nil {
+		return "", errorUtil.Wrapf(err, "failed to create kms key %s", alias)
+	}
+	keyID := created.KeyMetadata.KeyId
+	if err = kmsSvc.EnableKeyRotation(&kmsEnableKeyRotationInput{KeyId: keyID}); err != nil {
+		return "", errorUtil.Wrapf(err, "failed to enable rotation of kms key %s", aws.StringValue(keyID))
+	}
+	if err = kmsSvc.CreateAlias(&kmsCreateAliasInput{AliasName: aws.String(alias), TargetKeyId: keyID}); err != nil {
+		awsErr, ok := err.(awserr.Error)
+		if !ok || awsErr.Code() != kmsErrCodeAlreadyExistsException {
+			return "", errorUtil.Wrapf(err, "failed to create alias %s of kms key %s", alias, aws.StringValue(keyID))
+		}
+		// another resource created the key of the cluster at the same time, the key created here is never used
+		if err = kmsSvc.ScheduleKeyDeletion(&kmsScheduleKeyDeletionInput{KeyId: keyID, PendingWindowInDays: aws.Int64(kmsKeyDeletionPendingDays)}); err != nil {
+			return "", errorUtil.Wrapf(err, "failed to delete unused kms key %s", aws.StringValue(keyID))
+		}
+		key, err = describeKMSKey(kmsSvc, alias)
+		if err != nil {
+			return "", err
+		}
+		if key == nil {
+			return "", errorUtil.New(fmt.Sprintf("kms key %s not found", alias))
+		}
+		return validateKMSKey(alias, key)
+	}
+	return aws.StringValue(created.KeyMetadata.Arn), nil
+}
+
+// describeKMSKey returns the metadata of the key, or nil if the key doesn't exist
+func describeKMSKey(kmsSvc kmsAPI, keyID string) (*kmsKeyMetadata, error) {
+	output, err := kmsSvc.DescribeKey(&kmsDescribeKeyInput{KeyId: aws.String(keyID)})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == kmsErrCodeNotFoundException {
+			return nil, nil
+		}
+		return nil, errorUtil.Wrapf(err, "failed to describe kms key %s", keyID)
+	}
+	return output.KeyMetadata, nil
+}
+
+// validateKMSKey returns the arn of the key if it's an enabled customer managed key, aws managed keys such as
+// alias/aws/rds are the account default keys and can't be used
+func validateKMSKey(keyID string, key *kmsKeyMetadata) (string, error) {
+	if aws.StringValue(key.KeyManager) != kmsKeyManagerCustomer {
+		return "", errorUtil.New(fmt.Sprintf("kms key %s is not a customer managed key", keyID))
+	}
+	if aws.StringValue(key.KeyState) != kmsKeyStateEnabled {
+		return "", errorUtil.New(fmt.Sprintf("kms key %s is %s, expected %s", keyID, aws.StringValue(key.KeyState), kmsKeyStateEnabled))
+	}
+	return aws.StringValue(key.Arn), nil
+}
+
+// buildKMSKeyTags tags the key of the cluster with the cluster id and the user infrastructure tags
+func buildKMSKeyTags(ctx context.Context, c client.Client, clusterID string) ([]*kmsTag, error) {
+	tags := []*tag{
+		{
+			key:   resources.GetOrganizationTag() + "clusterID",
+			value: clusterID,
+		},
+		buildManagedTag(),
+	}
+	infraTags, err := getUserInfraTags(ctx, c)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to get user infrastructure tags")
+	}
+	if infraTags != nil {
+		tags = mergeTags(infraTags, tags)
+	}
+	var kmsTags []*kmsTag
+	for _, t := range tags {
+		kmsTags = append(kmsTags, &kmsTag{TagKey: aws.String(t.key), TagValue: aws.String(t.value)})
+	}
+	return kmsTags, nil
+}
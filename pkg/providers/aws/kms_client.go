@@ -0,0 +1,163 @@
+// minimal client of the aws key management service, covering the operations used to resolve the customer managed key
+// resources are encrypted with. the kms package of the aws sdk isn't vendored, the client is built on the json rpc
+// protocol of the sdk the same way the generated clients of the sdk are
+
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/aws/aws-sdk-go/private/protocol/jsonrpc"
+)
+
+const (
+	kmsEndpointsID  = "kms"
+	kmsServiceID    = "KMS"
+	kmsAPIVersion   = "2014-11-01"
+	kmsTargetPrefix = "TrentService"
+
+	kmsErrCodeNotFoundException      = "NotFoundException"
+	kmsErrCodeAlreadyExistsException = "AlreadyExistsException"
+
+	kmsKeyManagerCustomer        = "CUSTOMER"
+	kmsKeyStateEnabled           = "Enabled"
+	kmsKeyUsageEncryptDecrypt    = "ENCRYPT_DECRYPT"
+	kmsKeySpecSymmetricDefault   = "SYMMETRIC_DEFAULT"
+	kmsOperationDescribeKey      = "DescribeKey"
+	kmsOperationCreateKey        = "CreateKey"
+	kmsOperationCreateAlias      = "CreateAlias"
+	kmsOperationEnableRotation   = "EnableKeyRotation"
+	kmsOperationScheduleDeletion = "ScheduleKeyDeletion"
+)
+
+type kmsAPI interface {
+	DescribeKey(input *kmsDescribeKeyInput) (*kmsDescribeKeyOutput, error)
+	CreateKey(input *kmsCreateKeyInput) (*kmsCreateKeyOutput, error)
+	CreateAlias(input *kmsCreateAliasInput) error
+	EnableKeyRotation(input *kmsEnableKeyRotationInput) error
+	ScheduleKeyDeletion(input *kmsScheduleKeyDeletionInput) error
+}
+
+type kmsKeyMetadata struct {
+	Arn        *string
+	KeyId      *string
+	KeyManager *string
+	KeyState   *string
+}
+
+type kmsTag struct {
+	TagKey   *string
+	TagValue *string
+}
+
+type kmsDescribeKeyInput struct {
+	KeyId *string
+}
+
+type kmsDescribeKeyOutput struct {
+	KeyMetadata *kmsKeyMetadata
+}
+
+type kmsCreateKeyInput struct {
+	Description *string
+	KeyUsage    *string
+	KeySpec     *string
+	Tags        []*kmsTag
+}
+
+type kmsCreateKeyOutput struct {
+	KeyMetadata *kmsKeyMetadata
+}
+
+type kmsCreateAliasInput struct {
+	AliasName   *string
+	TargetKeyId *string
+}
+
+type kmsEnableKeyRotationInput struct {
+	KeyId *string
+}
+
+type kmsScheduleKeyDeletionInput struct {
+	KeyId               *string
+	PendingWindowInDays *int64
+}
+
+var _ kmsAPI = (*kmsClient)(nil)
+
+type kmsClient struct {
+	*client.Client
+}
+
+// newKMSClient creates a kms client from a session, resolving the endpoint and signing the requests like the clients
+// of the sdk
+func newKMSClient(p client.ConfigProvider, cfgs ...*aws.Config) *kmsClient {
+	c := p.ClientConfig(kmsEndpointsID, cfgs...)
+	if c.SigningNameDerived || len(c.SigningName) == 0 {
+		c.SigningName = kmsEndpointsID
+	}
+	svc := &kmsClient{
+		Client: client.New(
+			*c.Config,
+			metadata.ClientInfo{
+				ServiceName:    kmsEndpointsID,
+				ServiceID:      kmsServiceID,
+				SigningName:    c.SigningName,
+				SigningRegion:  c.SigningRegion,
+				PartitionID:    c.PartitionID,
+				Endpoint:       c.Endpoint,
+				APIVersion:     kmsAPIVersion,
+				ResolvedRegion: c.ResolvedRegion,
+				JSONVersion:    "1.1",
+				TargetPrefix:   kmsTargetPrefix,
+			},
+			c.Handlers,
+		),
+	}
+	svc.Handlers.Sign.PushBackNamed(v4.SignRequestHandler)
+	svc.Handlers.Build.PushBackNamed(jsonrpc.BuildHandler)
+	svc.Handlers.Unmarshal.PushBackNamed(jsonrpc.UnmarshalHandler)
+	svc.Handlers.UnmarshalMeta.PushBackNamed(jsonrpc.UnmarshalMetaHandler)
+	svc.Handlers.UnmarshalError.PushBackNamed(jsonrpc.UnmarshalErrorHandler)
+	return svc
+}
+
+func (c *kmsClient) send(operation string, input, output interface{}) error {
+	op := &request.Operation{
+		Name:       operation,
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}
+	return c.NewRequest(op, input, output).Send()
+}
+
+func (c *kmsClient) DescribeKey(input *kmsDescribeKeyInput) (*kmsDescribeKeyOutput, error) {
+	output := &kmsDescribeKeyOutput{}
+	if err := c.send(kmsOperationDescribeKey, input, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (c *kmsClient) CreateKey(input *kmsCreateKeyInput) (*kmsCreateKeyOutput, error) {
+	output := &kmsCreateKeyOutput{}
+	if err := c.send(kmsOperationCreateKey, input, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (c *kmsClient) CreateAlias(input *kmsCreateAliasInput) error {
+	return c.send(kmsOperationCreateAlias, input, &struct{}{})
+}
+
+func (c *kmsClient) EnableKeyRotation(input *kmsEnableKeyRotationInput) error {
+	return c.send(kmsOperationEnableRotation, input, &struct{}{})
+}
+
+func (c *kmsClient) ScheduleKeyDeletion(input *kmsScheduleKeyDeletionInput) error {
+	return c.send(kmsOperationScheduleDeletion, input, &struct{}{})
+}
@@ -0,0 +1,57 @@
+package aws
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+func TestKMSClient_DescribeKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if target := r.Header.Get("X-Amz-Target"); target != "TrentService.DescribeKey" {
+			t.Errorf("DescribeKey() X-Amz-Target = %s, want TrentService.DescribeKey", target)
+		}
+		input := map[string]string{}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		if input["KeyId"] != "alias/test" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"NotFoundException","message":"Alias is not found."}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"KeyMetadata":{"Arn":"` + testKmsKeyArn + `","KeyId":"test-key","KeyManager":"CUSTOMER","KeyState":"Enabled","CreationDate":1.6E9}}`))
+	}))
+	defer server.Close()
+
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("eu-west-1"),
+		Endpoint:    aws.String(server.URL),
+		Credentials: credentials.NewStaticCredentials("test", "test", ""),
+		MaxRetries:  aws.Int(0),
+	})
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	kmsSvc := newKMSClient(sess)
+
+	output, err := kmsSvc.DescribeKey(&kmsDescribeKeyInput{KeyId: aws.String("alias/test")})
+	if err != nil {
+		t.Fatalf("DescribeKey() unexpected error = %v", err)
+	}
+	if aws.StringValue(output.KeyMetadata.Arn) != testKmsKeyArn || aws.StringValue(output.KeyMetadata.KeyManager) != kmsKeyManagerCustomer {
+		t.Errorf("DescribeKey() = %+v, want key %s", output.KeyMetadata, testKmsKeyArn)
+	}
+
+	_, err = kmsSvc.DescribeKey(&kmsDescribeKeyInput{KeyId: aws.String("alias/missing")})
+	if awsErr, ok := err.(awserr.Error); !ok || awsErr.Code() != kmsErrCodeNotFoundException {
+		t.Errorf("DescribeKey() error = %v, want %s", err, kmsErrCodeNotFoundException)
+	}
+}
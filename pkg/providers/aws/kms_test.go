@@ -0,0 +1,190 @@
+package aws
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testKmsKeyArn      = "arn:aws:kms:eu-west-1:123456789012:key/test-key"
+	testKmsOtherKeyArn = "arn:aws:kms:eu-west-1:123456789012:key/other-key"
+)
+
+type mockKmsClient struct {
+	keys             map[string]*kmsKeyMetadata
+	createAliasErr   error
+	createdKeys      []*kmsCreateKeyInput
+	createdAliases   []string
+	rotatedKeys      []string
+	deletionSchedule []string
+}
+
+func (m *mockKmsClient) DescribeKey(input *kmsDescribeKeyInput) (*kmsDescribeKeyOutput, error) {
+	key, ok := m.keys[aws.StringValue(input.KeyId)]
+	if !ok {
+		return nil, awserr.New(kmsErrCodeNotFoundException, "key not found", nil)
+	}
+	return &kmsDescribeKeyOutput{KeyMetadata: key}, nil
+}
+
+func (m *mockKmsClient) CreateKey(input *kmsCreateKeyInput) (*kmsCreateKeyOutput, error) {
+	m.createdKeys = append(m.createdKeys, input)
+	return &kmsCreateKeyOutput{KeyMetadata: &kmsKeyMetadata{
+		Arn:        aws.String(testKmsKeyArn),
+		KeyId:      aws.String("test-key"),
+		KeyManager: aws.String(kmsKeyManagerCustomer),
+		KeyState:   aws.String(kmsKeyStateEnabled),
+	}}, nil
+}
+
+func (m *mockKmsClient) CreateAlias(input *kmsCreateAliasInput) error {
+	if m.createAliasErr != nil {
+		return m.createAliasErr
+	}
+	m.createdAliases = append(m.createdAliases, aws.StringValue(input.AliasName))
+	return nil
+}
+
+func (m *mockKmsClient) EnableKeyRotation(input *kmsEnableKeyRotationInput) error {
+	m.rotatedKeys = append(m.rotatedKeys, aws.StringValue(input.KeyId))
+	return nil
+}
+
+func (m *mockKmsClient) ScheduleKeyDeletion(input *kmsScheduleKeyDeletionInput) error {
+	m.deletionSchedule = append(m.deletionSchedule, aws.StringValue(input.KeyId))
+	return nil
+}
+
+func buildTestKmsKey(arn, manager, state string) *kmsKeyMetadata {
+	return &kmsKeyMetadata{
+		Arn:        aws.String(arn),
+		KeyManager: aws.String(manager),
+		KeyState:   aws.String(state),
+	}
+}
+
+func Test_reconcileKMSKey(t *testing.T) {
+	scheme, err := buildTestSchemePostgresql()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	clusterAlias := buildClusterKMSKeyAlias(defaultInfraName)
+	tests := []struct {
+		name       string
+		client     client.Client
+		kmsSvc     *mockKmsClient
+		encryption *KMSEncryption
+		want       string
+		wantErr    bool
+		wantFn     func(m *mockKmsClient) string
+	}{
+		{
+			name:   "test no key is used without encryption",
+			kmsSvc: &mockKmsClient{},
+			want:   "",
+		},
+		{
+			name: "test a referenced customer managed key is used",
+			kmsSvc: &mockKmsClient{keys: map[string]*kmsKeyMetadata{
+				"alias/test": buildTestKmsKey(testKmsKeyArn, kmsKeyManagerCustomer, kmsKeyStateEnabled),
+			}},
+			encryption: &KMSEncryption{KmsKeyID: "alias/test"},
+			want:       testKmsKeyArn,
+		},
+		{
+			name: "test a referenced aws managed key fails",
+			kmsSvc: &mockKmsClient{keys: map[string]*kmsKeyMetadata{
+				"alias/aws/rds": buildTestKmsKey(testKmsKeyArn, "AWS", kmsKeyStateEnabled),
+			}},
+			encryption: &KMSEncryption{KmsKeyID: "alias/aws/rds"},
+			wantErr:    true,
+		},
+		{
+			name: "test a referenced disabled key fails",
+			kmsSvc: &mockKmsClient{keys: map[string]*kmsKeyMetadata{
+				"alias/test": buildTestKmsKey(testKmsKeyArn, kmsKeyManagerCustomer, "Disabled"),
+			}},
+			encryption: &KMSEncryption{KmsKeyID: "alias/test"},
+			wantErr:    true,
+		},
+		{
+			name:       "test a referenced key that doesn't exist fails",
+			kmsSvc:     &mockKmsClient{},
+			encryption: &KMSEncryption{KmsKeyID: "alias/test", Create: true},
+			wantErr:    true,
+		},
+		{
+			name:   "test the existing key of the cluster is used",
+			client: fake.NewFakeClientWithScheme(scheme, buildTestInfra()),
+			kmsSvc: &mockKmsClient{keys: map[string]*kmsKeyMetadata{
+				clusterAlias: buildTestKmsKey(testKmsOtherKeyArn, kmsKeyManagerCustomer, kmsKeyStateEnabled),
+			}},
+			encryption: &KMSEncryption{Create: true},
+			want:       testKmsOtherKeyArn,
+			wantFn: func(m *mockKmsClient) string {
+				if len(m.createdKeys) != 0 {
+					return "expected no key to be created"
+				}
+				return ""
+			},
+		},
+		{
+			name:       "test the key of the cluster is created with rotation and an alias",
+			client:     fake.NewFakeClientWithScheme(scheme, buildTestInfra()),
+			kmsSvc:     &mockKmsClient{},
+			encryption: &KMSEncryption{Create: true},
+			want:       testKmsKeyArn,
+			wantFn: func(m *mockKmsClient) string {
+				if len(m.createdKeys) != 1 || len(m.rotatedKeys) != 1 {
+					return "expected a key to be created with rotation enabled"
+				}
+				if len(m.createdAliases) != 1 || m.createdAliases[0] != clusterAlias {
+					return "expected the key to be created with the alias of the cluster"
+				}
+				for _, tag := range m.createdKeys[0].Tags {
+					if aws.StringValue(tag.TagValue) == defaultInfraName {
+						return ""
+					}
+				}
+				return "expected the key to be tagged with the cluster id"
+			},
+		},
+		{
+			name:   "test a key created at the same time as the key of the cluster is deleted",
+			client: fake.NewFakeClientWithScheme(scheme, buildTestInfra()),
+			kmsSvc: &mockKmsClient{
+				keys:           map[string]*kmsKeyMetadata{},
+				createAliasErr: awserr.New(kmsErrCodeAlreadyExistsException, "alias exists", nil),
+			},
+			encryption: &KMSEncryption{Create: true},
+			wantErr:    true,
+			wantFn: func(m *mockKmsClient) string {
+				if len(m.deletionSchedule) != 1 {
+					return "expected the unused key to be scheduled for deletion"
+				}
+				return ""
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := reconcileKMSKey(context.TODO(), tt.client, tt.kmsSvc, tt.encryption)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("reconcileKMSKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("reconcileKMSKey() = %s, want %s", got, tt.want)
+			}
+			if tt.wantFn != nil {
+				if msg := tt.wantFn(tt.kmsSvc); msg != "" {
+					t.Error(msg)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	"github.com/aws/aws-sdk-go/service/rds"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	errorUtil "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+/*
+NetworkDiscovery configures how the cluster vpc, and the subnets resources are placed in, are found for clusters installed
+into an existing vpc. It's read from the _network strategy of the resource tier and should be the same for every tier,
+as the subnet groups and security group are shared by all resources
+VpcID -> the id of the cluster vpc, used instead of discovering it from the subnets or instances tagged with the cluster id
+ResourceVpcID -> an existing vpc resources are placed in instead of the cluster vpc, it must already be peered, and
+routed, with the cluster vpc
+SubnetIDs -> existing subnets of the resource vpc, or cluster vpc, resources are placed in, instead of a standalone vpc
+SubnetTags -> tags of existing subnets of the resource vpc, or cluster vpc, resources are placed in, used if subnetIds
+isn't set
+CreateSubnetGroups -> whether the rds and elasticache subnet groups of the subnets are created, defaults to true
+RDSSubnetGroupName -> the existing rds subnet group used when subnet groups aren't created
+ElastiCacheSubnetGroupName -> the existing elasticache subnet group used when subnet groups aren't created
+SecurityGroupIngress -> the ingress rules of the resource security group, replacing the default rule allowing all
+traffic from the cluster vpc cidr block
+*/
+type NetworkDiscovery struct {
+	VpcID                      string                     `json:"vpcId,omitempty"`
+	ResourceVpcID              string                     `json:"resourceVpcId,omitempty"`
+	SubnetIDs                  []string                   `json:"subnetIds,omitempty"`
+	SubnetTags                 map[string]string          `json:"subnetTags,omitempty"`
+	CreateSubnetGroups         *bool                      `json:"createSubnetGroups,omitempty"`
+	RDSSubnetGroupName         string                     `json:"rdsSubnetGroupName,omitempty"`
+	ElastiCacheSubnetGroupName string                     `json:"elasticacheSubnetGroupName,omitempty"`
+	SecurityGroupIngress       []SecurityGroupIngressRule `json:"securityGroupIngress,omitempty"`
+}
+
+// UsesExistingSubnets returns true if resources are placed in existing subnets of the resource vpc or cluster vpc, all
+// subnets of the resource vpc are used if it's set without selecting subnets
+func (d *NetworkDiscovery) UsesExistingSubnets() bool {
+	return d != nil && (len(d.SubnetIDs) > 0 || len(d.SubnetTags) > 0 || d.ResourceVpcID != "")
+}
+
+// CreatesSubnetGroups returns true unless the network discovery uses existing subnet groups
+func (d *NetworkDiscovery) CreatesSubnetGroups() bool {
+	return d == nil || d.CreateSubnetGroups == nil || *d.CreateSubnetGroups
+}
+
+// applyRDSSubnetGroupName sets the existing rds subnet group of the network discovery on a create strategy that doesn't
+// set its subnet group, when subnet groups aren't created
+func (d *NetworkDiscovery) applyRDSSubnetGroupName(rdsCfg *rds.CreateDBInstanceInput) {
+	if !d.CreatesSubnetGroups() && d.RDSSubnetGroupName != "" && rdsCfg.DBSubnetGroupName == nil {
+		rdsCfg.DBSubnetGroupName = aws.String(d.RDSSubnetGroupName)
+	}
+}
+
+// applyElastiCacheSubnetGroupName sets the existing elasticache subnet group of the network discovery on a create
+// strategy that doesn't set its subnet group, when subnet groups aren't created
+func (d *NetworkDiscovery) applyElastiCacheSubnetGroupName(elasticacheConfig *elasticache.CreateReplicationGroupInput) {
+	if !d.CreatesSubnetGroups() && d.ElastiCacheSubnetGroupName != "" && elasticacheConfig.CacheSubnetGroupName == nil {
+		elasticacheConfig.CacheSubnetGroupName = aws.String(d.ElastiCacheSubnetGroupName)
+	}
+}
+
+// getResourceVpc returns the vpc resources are placed in, the resource vpc of the network discovery if it's set,
+// otherwise the cluster vpc
+func getResourceVpc(ctx context.Context, c client.Client, ec2Svc ec2iface.EC2API, discovery *NetworkDiscovery, logger *logrus.Entry) (*ec2.Vpc, error) {
+	if discovery == nil || discovery.ResourceVpcID == "" {
+		return getClusterVpc(ctx, c, ec2Svc, discovery, logger)
+	}
+	vpcs, err := ec2Svc.DescribeVpcs(&ec2.DescribeVpcsInput{VpcIds: []*string{aws.String(discovery.ResourceVpcID)}})
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "error getting resource vpc with id %s", discovery.ResourceVpcID)
+	}
+	if len(vpcs.Vpcs) != 1 {
+		return nil, errorUtil.New(fmt.Sprintf("resource vpc %s not found", discovery.ResourceVpcID))
+	}
+	logger.Infof("found resource vpc %s", aws.StringValue(vpcs.Vpcs[0].VpcId))
+	return vpcs.Vpcs[0], nil
+}
+
+// getNetworkDiscovery returns the network discovery of the _network strategy of the tier, the cluster vpc is discovered
+// from the cluster tags if the strategy can't be read
+func getNetworkDiscovery(ctx context.Context, configManager ConfigManager, tier string, logger *logrus.Entry) *NetworkDiscovery {
+	stratCfg, err := configManager.ReadStorageStrategy(ctx, providers.NetworkResourceType, tier)
+	if err != nil {
+		logger.Warnf("failed to read _network strategy for tier %s, discovering the cluster vpc from the cluster tags: %v", tier, err)
+		return nil
+	}
+	return stratCfg.Network
+}
+
+// getVPCIDByClusterInstances returns the vpc of the instances owned by the cluster, the installer doesn't always tag the
+// subnets of an existing vpc a cluster is installed into but always tags the instances it creates
+func getVPCIDByClusterInstances(ec2Svc ec2iface.EC2API, clusterID string) (string, error) {
+	listOutput, err := ec2Svc.DescribeInstances(&ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String(fmt.Sprintf("tag:%s", getOSDClusterTagKey(clusterID))),
+				Values: aws.StringSlice([]string{clusterOwnedTagValue}),
+			},
+		},
+	})
+	if err != nil {
+		return "", errorUtil.Wrap(err, "failed to describe cluster instances")
+	}
+	for _, reservation := range listOutput.Reservations {
+		for _, instance := range reservation.Instances {
+			if instance.VpcId != nil {
+				return *instance.VpcId, nil
+			}
+		}
+	}
+	return "", errorUtil.New(fmt.Sprintf("failed to get cluster vpc id, no instances found with cluster tag for clusterID %s", clusterID))
+}
+
+// getExistingSubnetIDs returns the ids of the subnets of the vpc selected by the network discovery, by id or by tags
+func getExistingSubnetIDs(subnets []*ec2.Subnet, vpc *ec2.Vpc, discovery *NetworkDiscovery) ([]*string, error) {
+	var subIDs []*string
+	if len(discovery.SubnetIDs) > 0 {
+		for _, subnetID := range discovery.SubnetIDs {
+			sub := findSubnet(subnets, subnetID)
+			if sub == nil || aws.StringValue(sub.VpcId) != aws.StringValue(vpc.VpcId) {
+				return nil, errorUtil.New(fmt.Sprintf("subnet %s not found in vpc %s", subnetID, aws.StringValue(vpc.VpcId)))
+			}
+			subIDs = append(subIDs, sub.SubnetId)
+		}
+		return subIDs, nil
+	}
+	for _, sub := range subnets {
+		if aws.StringValue(sub.VpcId) == aws.StringValue(vpc.VpcId) && subnetHasTags(sub, discovery.SubnetTags) {
+			subIDs = append(subIDs, sub.SubnetId)
+		}
+	}
+	if subIDs == nil {
+		return nil, errorUtil.New(fmt.Sprintf("no subnets with tags %v found in vpc %s", discovery.SubnetTags, aws.StringValue(vpc.VpcId)))
+	}
+	return subIDs, nil
+}
+
+func findSubnet(subnets []*ec2.Subnet, subnetID string) *ec2.Subnet {
+	for _, sub := range subnets {
+		if aws.StringValue(sub.SubnetId) == subnetID {
+			return sub
+		}
+	}
+	return nil
+}
+
+// subnetHasTags returns true if the subnet has every tag, with the same value
+func subnetHasTags(sub *ec2.Subnet, tags map[string]string) bool {
+	for key, value := range tags {
+		found := false
+		for _, tag := range sub.Tags {
+			if aws.StringValue(tag.Key) == key && aws.StringValue(tag.Value) == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// buildNetworkStatus returns the network status of a resource placed in the subnets of the vpc
+func buildNetworkStatus(vpcID *string, subnetIDs []string) *croType.NetworkStatus {
+	sort.Strings(subnetIDs)
+	return &croType.NetworkStatus{
+		VpcID:     aws.StringValue(vpcID),
+		SubnetIDs: subnetIDs,
+	}
+}
@@ -0,0 +1,247 @@
+package aws
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func buildTestDiscoverySubnets() []*ec2.Subnet {
+	return []*ec2.Subnet{
+		{
+			SubnetId: aws.String("subnet-shared-a"),
+			VpcId:    aws.String(defaultVpcId),
+			Tags:     []*ec2.Tag{{Key: aws.String("network"), Value: aws.String("shared")}},
+		},
+		{
+			SubnetId: aws.String("subnet-shared-b"),
+			VpcId:    aws.String(defaultVpcId),
+			Tags:     []*ec2.Tag{{Key: aws.String("network"), Value: aws.String("shared")}},
+		},
+		{
+			SubnetId: aws.String("subnet-private"),
+			VpcId:    aws.String(defaultVpcId),
+			Tags:     []*ec2.Tag{{Key: aws.String("network"), Value: aws.String("private")}},
+		},
+		{
+			SubnetId: aws.String("subnet-other-vpc"),
+			VpcId:    aws.String("other"),
+			Tags:     []*ec2.Tag{{Key: aws.String("network"), Value: aws.String("shared")}},
+		},
+	}
+}
+
+func Test_getExistingSubnetIDs(t *testing.T) {
+	tests := []struct {
+		name      string
+		discovery *NetworkDiscovery
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name:      "test explicit subnet ids are used",
+			discovery: &NetworkDiscovery{SubnetIDs: []string{"subnet-private", "subnet-shared-a"}},
+			want:      []string{"subnet-private", "subnet-shared-a"},
+		},
+		{
+			name:      "test error when an explicit subnet isn't in the cluster vpc",
+			discovery: &NetworkDiscovery{SubnetIDs: []string{"subnet-shared-a", "subnet-other-vpc"}},
+			wantErr:   true,
+		},
+		{
+			name:      "test error when an explicit subnet doesn't exist",
+			discovery: &NetworkDiscovery{SubnetIDs: []string{"subnet-missing"}},
+			wantErr:   true,
+		},
+		{
+			name:      "test subnets of the cluster vpc with the subnet tags are used",
+			discovery: &NetworkDiscovery{SubnetTags: map[string]string{"network": "shared"}},
+			want:      []string{"subnet-shared-a", "subnet-shared-b"},
+		},
+		{
+			name:      "test explicit subnet ids take precedence over subnet tags",
+			discovery: &NetworkDiscovery{SubnetIDs: []string{"subnet-private"}, SubnetTags: map[string]string{"network": "shared"}},
+			want:      []string{"subnet-private"},
+		},
+		{
+			name:      "test error when no subnets have the subnet tags",
+			discovery: &NetworkDiscovery{SubnetTags: map[string]string{"network": "missing"}},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getExistingSubnetIDs(buildTestDiscoverySubnets(), &ec2.Vpc{VpcId: aws.String(defaultVpcId)}, tt.discovery)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getExistingSubnetIDs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(aws.StringValueSlice(got), tt.want) {
+				t.Errorf("getExistingSubnetIDs() = %v, want %v", aws.StringValueSlice(got), tt.want)
+			}
+		})
+	}
+}
+
+func Test_getClusterVpc_Discovery(t *testing.T) {
+	scheme, err := buildTestSchemePostgresql()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	tests := []struct {
+		name      string
+		discovery *NetworkDiscovery
+		instances []*ec2.Instance
+		wantVpcID string
+		wantErr   bool
+	}{
+		{
+			name:      "test vpc id of the network discovery is used",
+			discovery: &NetworkDiscovery{VpcID: "vpc-explicit"},
+			wantVpcID: "vpc-explicit",
+		},
+		{
+			name:      "test vpc of the cluster instances is used when no subnets are tagged for the cluster",
+			instances: []*ec2.Instance{{InstanceId: aws.String("master-0"), VpcId: aws.String("vpc-instances")}},
+			wantVpcID: "vpc-instances",
+		},
+		{
+			name:    "test error when neither subnets nor instances are tagged for the cluster",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var describedVpcIDs []string
+			ec2Svc := buildMockEc2Client(func(ec2Client *mockEc2Client) {
+				ec2Client.describeInstancesFn = func(input *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+					return &ec2.DescribeInstancesOutput{
+						Reservations: []*ec2.Reservation{{Instances: tt.instances}},
+					}, nil
+				}
+				ec2Client.describeVpcsFn = func(input *ec2.DescribeVpcsInput) (*ec2.DescribeVpcsOutput, error) {
+					describedVpcIDs = aws.StringValueSlice(input.VpcIds)
+					return &ec2.DescribeVpcsOutput{
+						Vpcs: []*ec2.Vpc{{VpcId: input.VpcIds[0]}},
+					}, nil
+				}
+			})
+			got, err := getClusterVpc(context.TODO(), fake.NewFakeClientWithScheme(scheme, buildTestInfra()), ec2Svc, tt.discovery, logrus.NewEntry(logrus.StandardLogger()))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getClusterVpc() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if aws.StringValue(got.VpcId) != tt.wantVpcID || !reflect.DeepEqual(describedVpcIDs, []string{tt.wantVpcID}) {
+				t.Errorf("getClusterVpc() = %s, described %v, want %s", aws.StringValue(got.VpcId), describedVpcIDs, tt.wantVpcID)
+			}
+		})
+	}
+}
+
+func Test_getResourceVpc(t *testing.T) {
+	tests := []struct {
+		name      string
+		vpcs      []*ec2.Vpc
+		wantVpcID string
+		wantErr   bool
+	}{
+		{
+			name:      "test resource vpc of the network discovery is used",
+			vpcs:      []*ec2.Vpc{{VpcId: aws.String("vpc-resource")}},
+			wantVpcID: "vpc-resource",
+		},
+		{
+			name:    "test error when the resource vpc doesn't exist",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ec2Svc := buildMockEc2Client(func(ec2Client *mockEc2Client) {
+				ec2Client.describeVpcsFn = func(input *ec2.DescribeVpcsInput) (*ec2.DescribeVpcsOutput, error) {
+					return &ec2.DescribeVpcsOutput{Vpcs: tt.vpcs}, nil
+				}
+			})
+			got, err := getResourceVpc(context.TODO(), nil, ec2Svc, &NetworkDiscovery{ResourceVpcID: "vpc-resource"}, logrus.NewEntry(logrus.StandardLogger()))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getResourceVpc() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && aws.StringValue(got.VpcId) != tt.wantVpcID {
+				t.Errorf("getResourceVpc() = %s, want %s", aws.StringValue(got.VpcId), tt.wantVpcID)
+			}
+		})
+	}
+}
+
+func TestNetworkDiscovery_SubnetGroupNames(t *testing.T) {
+	tests := []struct {
+		name            string
+		discovery       *NetworkDiscovery
+		rdsCfg          *rds.CreateDBInstanceInput
+		wantRDS         string
+		wantElastiCache string
+	}{
+		{
+			name:   "test subnet groups are created without a network discovery",
+			rdsCfg: &rds.CreateDBInstanceInput{},
+		},
+		{
+			name:      "test subnet group names are ignored while subnet groups are created",
+			discovery: &NetworkDiscovery{RDSSubnetGroupName: "rds-group", ElastiCacheSubnetGroupName: "cache-group"},
+			rdsCfg:    &rds.CreateDBInstanceInput{},
+		},
+		{
+			name:            "test existing subnet groups are used",
+			discovery:       &NetworkDiscovery{CreateSubnetGroups: aws.Bool(false), RDSSubnetGroupName: "rds-group", ElastiCacheSubnetGroupName: "cache-group"},
+			rdsCfg:          &rds.CreateDBInstanceInput{},
+			wantRDS:         "rds-group",
+			wantElastiCache: "cache-group",
+		},
+		{
+			name:            "test subnet group of the create strategy takes precedence",
+			discovery:       &NetworkDiscovery{CreateSubnetGroups: aws.Bool(false), RDSSubnetGroupName: "rds-group", ElastiCacheSubnetGroupName: "cache-group"},
+			rdsCfg:          &rds.CreateDBInstanceInput{DBSubnetGroupName: aws.String("strategy-group")},
+			wantRDS:         "strategy-group",
+			wantElastiCache: "cache-group",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			elasticacheConfig := &elasticache.CreateReplicationGroupInput{}
+			tt.discovery.applyRDSSubnetGroupName(tt.rdsCfg)
+			tt.discovery.applyElastiCacheSubnetGroupName(elasticacheConfig)
+			if got := aws.StringValue(tt.rdsCfg.DBSubnetGroupName); got != tt.wantRDS {
+				t.Errorf("applyRDSSubnetGroupName() = %q, want %q", got, tt.wantRDS)
+			}
+			if got := aws.StringValue(elasticacheConfig.CacheSubnetGroupName); got != tt.wantElastiCache {
+				t.Errorf("applyElastiCacheSubnetGroupName() = %q, want %q", got, tt.wantElastiCache)
+			}
+		})
+	}
+}
+
+func TestNetworkProvider_IsEnabled_ExistingSubnets(t *testing.T) {
+	n := &NetworkProvider{
+		Logger:    logrus.NewEntry(logrus.StandardLogger()),
+		Ec2Api:    &mockEc2Client{},
+		Discovery: &NetworkDiscovery{SubnetTags: map[string]string{"network": "shared"}},
+	}
+	got, err := n.IsEnabled(context.TODO())
+	if err != nil {
+		t.Fatalf("IsEnabled() unexpected error = %v", err)
+	}
+	if got {
+		t.Error("IsEnabled() = true, want standalone vpc disabled when existing subnets are selected")
+	}
+}
@@ -0,0 +1,82 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/annotations"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+)
+
+// credentialRotationAppliedAnnotation is set on the rds credential secret once the pending password has been sent to rds
+const credentialRotationAppliedAnnotation = "integreatly.org/credential-rotation-applied"
+
+// rotateRDSCredentials replaces the master password of the rds instance when a credential rotation is due. The new
+// password is kept as the pending password in the credential secret until rds has applied it, so the old password stays
+// in the connection secret until the instance accepts the new one. Returns true while the rotation is in progress
+func (p *PostgresProvider) rotateRDSCredentials(ctx context.Context, cr *v1alpha1.Postgres, rdsSvc rdsiface.RDSAPI, foundInstance *rds.DBInstance, credSec *v1.Secret) (bool, croType.StatusMessage, error) {
+	pendingPass := string(credSec.Data[providers.PendingPasswordKey])
+	if pendingPass == "" {
+		if !providers.IsCredentialRotationDue(cr, time.Now()) {
+			return false, "", nil
+		}
+		// a pending password generated by a concurrent reconcile is kept, so the rotation uses a single password
+		stored, err := resources.EnsureCredentialSecret(ctx, p.Client, credSec, map[string]resources.SecretValueGenerator{
+			providers.PendingPasswordKey: generateRDSPassword,
+		})
+		if err != nil {
+			errMsg := fmt.Sprintf("failed to store pending password in secret %s", credSec.Name)
+			return false, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+		stored.DeepCopyInto(credSec)
+		pendingPass = string(credSec.Data[providers.PendingPasswordKey])
+	}
+
+	// rds reports the password as a pending modification until it's been applied
+	if foundInstance.PendingModifiedValues != nil && foundInstance.PendingModifiedValues.MasterUserPassword != nil {
+		return true, croType.StatusMessage(fmt.Sprintf("waiting for rotated master password of rds instance %s to be applied", aws.StringValue(foundInstance.DBInstanceIdentifier))), nil
+	}
+
+	if !annotations.Has(credSec, credentialRotationAppliedAnnotation) {
+		if _, err := rdsSvc.ModifyDBInstance(&rds.ModifyDBInstanceInput{
+			DBInstanceIdentifier: foundInstance.DBInstanceIdentifier,
+			MasterUserPassword:   aws.String(pendingPass),
+			ApplyImmediately:     aws.Bool(true),
+		}); err != nil {
+			errMsg := fmt.Sprintf("failed to rotate master password of rds instance %s", aws.StringValue(foundInstance.DBInstanceIdentifier))
+			return false, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+		annotations.Add(credSec, credentialRotationAppliedAnnotation, "true")
+		if err := p.Client.Update(ctx, credSec); err != nil {
+			errMsg := fmt.Sprintf("failed to update secret %s", credSec.Name)
+			return false, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+		return true, croType.StatusMessage(fmt.Sprintf("started rotation of master password of rds instance %s", aws.StringValue(foundInstance.DBInstanceIdentifier))), nil
+	}
+
+	// the instance uses the pending password, promote it so it's returned in the connection secret
+	oldPass := string(credSec.Data[defaultPostgresPasswordKey])
+	credSec.Data[defaultPostgresPasswordKey] = []byte(pendingPass)
+	delete(credSec.Data, providers.PendingPasswordKey)
+	annotations.Remove(credSec, credentialRotationAppliedAnnotation)
+	if err := p.Client.Update(ctx, credSec); err != nil {
+		errMsg := fmt.Sprintf("failed to promote pending password in secret %s", credSec.Name)
+		return false, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	// the old password no longer grants access, stop redacting it so the registered values do not grow with rotations
+	resources.ForgetSecretValue(oldPass)
+	if err := providers.CompleteCredentialRotation(ctx, p.Client, cr, time.Now()); err != nil {
+		errMsg := "failed to complete credential rotation"
+		return false, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	return false, croType.StatusMessage(fmt.Sprintf("rotated master password of rds instance %s", aws.StringValue(foundInstance.DBInstanceIdentifier))), nil
+}
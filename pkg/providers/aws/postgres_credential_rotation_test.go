@@ -0,0 +1,126 @@
+package aws
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/annotations"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func buildTestRotatePostgresCR() *v1alpha1.Postgres {
+	pg := buildTestPostgresCR()
+	annotations.Add(pg, providers.RotateCredentialsAnnotation, "true")
+	return pg
+}
+
+func buildTestPendingCredSecret(applied bool) *v1.Secret {
+	sec := builtTestCredSecret()
+	sec.Data[providers.PendingPasswordKey] = []byte("pending")
+	if applied {
+		annotations.Add(sec, credentialRotationAppliedAnnotation, "true")
+	}
+	return sec
+}
+
+func TestAWSPostgresProvider_rotateRDSCredentials(t *testing.T) {
+	scheme, err := buildTestSchemePostgresql()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	tests := []struct {
+		name         string
+		cr           *v1alpha1.Postgres
+		credSec      *v1.Secret
+		instance     *rds.DBInstance
+		want         bool
+		wantPassword string
+		wantPending  bool
+		wantApplied  bool
+		wantRotated  bool
+	}{
+		{
+			name:         "test credentials are not rotated when a rotation isn't due",
+			cr:           buildTestPostgresCR(),
+			credSec:      builtTestCredSecret(),
+			instance:     buildAvailableDBInstance("test")[0],
+			want:         false,
+			wantPassword: "test",
+		},
+		{
+			name:         "test pending password is applied when a rotation is requested",
+			cr:           buildTestRotatePostgresCR(),
+			credSec:      builtTestCredSecret(),
+			instance:     buildAvailableDBInstance("test")[0],
+			want:         true,
+			wantPassword: "test",
+			wantPending:  true,
+			wantApplied:  true,
+		},
+		{
+			name:    "test old password is kept while rds applies the pending password",
+			cr:      buildTestRotatePostgresCR(),
+			credSec: buildTestPendingCredSecret(true),
+			instance: func() *rds.DBInstance {
+				instance := buildAvailableDBInstance("test")[0]
+				instance.PendingModifiedValues = &rds.PendingModifiedValues{MasterUserPassword: aws.String("****")}
+				return instance
+			}(),
+			want:         true,
+			wantPassword: "test",
+			wantPending:  true,
+			wantApplied:  true,
+		},
+		{
+			name:         "test pending password is promoted once rds has applied it",
+			cr:           buildTestRotatePostgresCR(),
+			credSec:      buildTestPendingCredSecret(true),
+			instance:     buildAvailableDBInstance("test")[0],
+			want:         false,
+			wantPassword: "pending",
+			wantRotated:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme, tt.cr, tt.credSec)
+			p := &PostgresProvider{
+				Client: c,
+				Logger: testLogger,
+			}
+			credSec := &v1.Secret{}
+			if err := c.Get(context.TODO(), client.ObjectKey{Name: tt.credSec.Name, Namespace: tt.credSec.Namespace}, credSec); err != nil {
+				t.Fatal("failed to get credential secret", err)
+			}
+			got, _, err := p.rotateRDSCredentials(context.TODO(), tt.cr, buildMockRdsClient(nil), tt.instance, credSec)
+			if err != nil {
+				t.Fatalf("rotateRDSCredentials() unexpected error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("rotateRDSCredentials() = %v, want %v", got, tt.want)
+			}
+			stored := &v1.Secret{}
+			if err := c.Get(context.TODO(), client.ObjectKey{Name: tt.credSec.Name, Namespace: tt.credSec.Namespace}, stored); err != nil {
+				t.Fatal("failed to get credential secret", err)
+			}
+			if password := string(stored.Data[defaultPostgresPasswordKey]); password != tt.wantPassword {
+				t.Errorf("rotateRDSCredentials() password = %s, want %s", password, tt.wantPassword)
+			}
+			if pending := string(stored.Data[providers.PendingPasswordKey]) != ""; pending != tt.wantPending {
+				t.Errorf("rotateRDSCredentials() pending password set %v, want %v", pending, tt.wantPending)
+			}
+			if applied := annotations.Has(stored, credentialRotationAppliedAnnotation); applied != tt.wantApplied {
+				t.Errorf("rotateRDSCredentials() applied annotation set %v, want %v", applied, tt.wantApplied)
+			}
+			if done := tt.cr.Status.CredentialsRotatedAt != "" && !annotations.Has(tt.cr, providers.RotateCredentialsAnnotation); done != tt.wantRotated {
+				t.Errorf("rotateRDSCredentials() rotation completed %v, want %v", done, tt.wantRotated)
+			}
+		})
+	}
+}
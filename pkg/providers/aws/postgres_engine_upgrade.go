@@ -0,0 +1,170 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// EngineUpgradedCondition is the condition reporting the progress of an upgrade of the engine version of an rds
+	// instance, it's only set once the engine version of the strategy differs from the engine version of the instance
+	EngineUpgradedCondition = "EngineUpgraded"
+	// EngineUpgradedReason is the reason of a true engine upgraded condition
+	EngineUpgradedReason = "Upgraded"
+	// EngineUpgradePendingReason is the reason of a false engine upgraded condition before the upgrade is requested
+	EngineUpgradePendingReason = "UpgradePending"
+	// EngineUpgradeSnapshotInProgressReason is the reason of a false engine upgraded condition while the snapshot taken
+	// before the upgrade is created
+	EngineUpgradeSnapshotInProgressReason = "SnapshotInProgress"
+	// EngineUpgradeScheduledReason is the reason of a false engine upgraded condition while the upgrade waits for the
+	// maintenance window of the instance
+	EngineUpgradeScheduledReason = "UpgradeScheduled"
+	// EngineUpgradeInProgressReason is the reason of a false engine upgraded condition while the instance is upgraded
+	EngineUpgradeInProgressReason = "UpgradeInProgress"
+
+	rdsInstanceStatusUpgrading = "upgrading"
+)
+
+// EngineUpgrade configures how a change to the engine version of the strategy is applied to an existing rds instance
+type EngineUpgrade struct {
+	// SkipSnapshot upgrades the instance without taking a snapshot first
+	SkipSnapshot bool `json:"skipSnapshot,omitempty"`
+	// ApplyImmediately upgrades the instance as soon as the snapshot is available rather than in the next maintenance
+	// window, as does applyImmediately of the postgres cr
+	ApplyImmediately bool `json:"applyImmediately,omitempty"`
+}
+
+// rdsEngineUpgradeNeeded reports if the engine version of the strategy is newer than the engine version of the instance
+func rdsEngineUpgradeNeeded(rdsCfg *rds.CreateDBInstanceInput, foundInstance *rds.DBInstance) (bool, error) {
+	if rdsCfg.EngineVersion == nil || foundInstance.EngineVersion == nil {
+		return false, nil
+	}
+	upgradeNeeded, err := resources.VerifyVersionUpgradeNeeded(*foundInstance.EngineVersion, *rdsCfg.EngineVersion)
+	if err != nil {
+		return false, errorUtil.Wrap(err, "invalid postgres version")
+	}
+	return upgradeNeeded, nil
+}
+
+// rdsEngineUpgradeRequested reports if rds has accepted the upgrade to the engine version of the strategy, either as a
+// pending modification or because the upgrade is running
+func rdsEngineUpgradeRequested(rdsCfg *rds.CreateDBInstanceInput, foundInstance *rds.DBInstance) bool {
+	if aws.StringValue(foundInstance.DBInstanceStatus) == rdsInstanceStatusUpgrading {
+		return true
+	}
+	pending := foundInstance.PendingModifiedValues
+	return pending != nil && pending.EngineVersion != nil && *pending.EngineVersion == aws.StringValue(rdsCfg.EngineVersion)
+}
+
+// preUpgradeSnapshotName returns the name of the snapshot taken before the instance is upgraded to the engine version
+func preUpgradeSnapshotName(instanceID, engineVersion string) string {
+	return fmt.Sprintf("%s-pre-upgrade-%s", instanceID, strings.ReplaceAll(engineVersion, ".", "-"))
+}
+
+// setRDSEngineUpgradeCondition reports the progress of an upgrade of the engine version of the instance in the engine
+// upgraded condition of the postgres
+func setRDSEngineUpgradeCondition(cr *v1alpha1.Postgres, rdsCfg *rds.CreateDBInstanceInput, foundInstance *rds.DBInstance) {
+	upgradeNeeded, err := rdsEngineUpgradeNeeded(rdsCfg, foundInstance)
+	if err != nil {
+		// the invalid version is reported when the update strategy is built
+		return
+	}
+	instanceID := aws.StringValue(foundInstance.DBInstanceIdentifier)
+	current := aws.StringValue(foundInstance.EngineVersion)
+	desired := aws.StringValue(rdsCfg.EngineVersion)
+	existing := meta.FindStatusCondition(cr.Status.Conditions, EngineUpgradedCondition)
+	// nothing was ever upgraded, there's nothing to report
+	if existing == nil && !upgradeNeeded {
+		return
+	}
+
+	cond := metav1.Condition{
+		Type:               EngineUpgradedCondition,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: cr.GetGeneration(),
+	}
+	switch {
+	case !upgradeNeeded:
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = EngineUpgradedReason
+		cond.Message = fmt.Sprintf("rds instance %s runs engine version %s", instanceID, current)
+	case aws.StringValue(foundInstance.DBInstanceStatus) == rdsInstanceStatusUpgrading:
+		cond.Reason = EngineUpgradeInProgressReason
+		cond.Message = fmt.Sprintf("rds instance %s is upgrading from engine version %s to %s", instanceID, current, desired)
+	case rdsEngineUpgradeRequested(rdsCfg, foundInstance):
+		cond.Reason = EngineUpgradeScheduledReason
+		cond.Message = fmt.Sprintf("upgrade of rds instance %s from engine version %s to %s is scheduled for the maintenance window %s", instanceID, current, desired, aws.StringValue(foundInstance.PreferredMaintenanceWindow))
+	default:
+		// the snapshot reports its own progress, keep it until the upgrade is requested
+		if existing != nil && existing.Reason == EngineUpgradeSnapshotInProgressReason {
+			return
+		}
+		cond.Reason = EngineUpgradePendingReason
+		cond.Message = fmt.Sprintf("rds instance %s is waiting to be upgraded from engine version %s to %s", instanceID, current, desired)
+	}
+	meta.SetStatusCondition(&cr.Status.Conditions, cond)
+}
+
+// reconcileRDSPreUpgradeSnapshot takes a snapshot of the instance before its engine version is upgraded, so the data can
+// be restored if the upgrade fails. The snapshot is kept after the upgrade. Returns true while the snapshot is created
+func (p *PostgresProvider) reconcileRDSPreUpgradeSnapshot(ctx context.Context, cr *v1alpha1.Postgres, rdsSvc rdsiface.RDSAPI, rdsCfg *rds.CreateDBInstanceInput, foundInstance *rds.DBInstance, upgradeCfg *EngineUpgrade) (bool, croType.StatusMessage, error) {
+	if upgradeCfg != nil && upgradeCfg.SkipSnapshot {
+		return false, "", nil
+	}
+	upgradeNeeded, err := rdsEngineUpgradeNeeded(rdsCfg, foundInstance)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to check engine version of rds instance %s", aws.StringValue(foundInstance.DBInstanceIdentifier))
+		return false, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	if !upgradeNeeded || rdsEngineUpgradeRequested(rdsCfg, foundInstance) {
+		return false, "", nil
+	}
+
+	instanceID := aws.StringValue(foundInstance.DBInstanceIdentifier)
+	snapshotName := preUpgradeSnapshotName(instanceID, aws.StringValue(rdsCfg.EngineVersion))
+	foundSnapshot, err := getRDSSnapshot(rdsSvc, snapshotName)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to describe snapshot %s", snapshotName)
+		return false, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	if foundSnapshot != nil && aws.StringValue(foundSnapshot.Status) == rdsSnapshotStatusAvailable {
+		return false, "", nil
+	}
+
+	msg := fmt.Sprintf("waiting for snapshot %s of rds instance %s to be available before upgrading to engine version %s", snapshotName, instanceID, aws.StringValue(rdsCfg.EngineVersion))
+	if foundSnapshot == nil {
+		tags, _, err := getDefaultResourceTags(ctx, p.Client, cr.Spec.Type, snapshotName, cr.ObjectMeta.Labels["productName"])
+		if err != nil {
+			errMsg := "failed to get default postgres tags"
+			return false, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+		if _, err := rdsSvc.CreateDBSnapshot(&rds.CreateDBSnapshotInput{
+			DBInstanceIdentifier: aws.String(instanceID),
+			DBSnapshotIdentifier: aws.String(snapshotName),
+			Tags:                 genericToRdsTags(tags),
+		}); err != nil {
+			errMsg := fmt.Sprintf("failed to create snapshot %s of rds instance %s", snapshotName, instanceID)
+			return false, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+		msg = fmt.Sprintf("started snapshot %s of rds instance %s before upgrading to engine version %s", snapshotName, instanceID, aws.StringValue(rdsCfg.EngineVersion))
+	}
+	meta.SetStatusCondition(&cr.Status.Conditions, metav1.Condition{
+		Type:               EngineUpgradedCondition,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: cr.GetGeneration(),
+		Reason:             EngineUpgradeSnapshotInProgressReason,
+		Message:            msg,
+	})
+	return true, croType.StatusMessage(msg), nil
+}
@@ -0,0 +1,200 @@
+package aws
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func buildTestUpgradeDBInstance(status string, pendingVersion *string) *rds.DBInstance {
+	instance := buildAvailableDBInstance("test")[0]
+	instance.DBInstanceStatus = aws.String(status)
+	if pendingVersion != nil {
+		instance.PendingModifiedValues = &rds.PendingModifiedValues{EngineVersion: pendingVersion}
+	}
+	return instance
+}
+
+func Test_setRDSEngineUpgradeCondition(t *testing.T) {
+	tests := []struct {
+		name       string
+		existing   *metav1.Condition
+		version    string
+		instance   *rds.DBInstance
+		wantNil    bool
+		wantReason string
+		wantStatus metav1.ConditionStatus
+	}{
+		{
+			name:     "test no condition when the instance was never upgraded",
+			version:  defaultAwsEngineVersion,
+			instance: buildTestUpgradeDBInstance("available", nil),
+			wantNil:  true,
+		},
+		{
+			name:       "test pending upgrade",
+			version:    "13.7",
+			instance:   buildTestUpgradeDBInstance("available", nil),
+			wantReason: EngineUpgradePendingReason,
+			wantStatus: metav1.ConditionFalse,
+		},
+		{
+			name:       "test snapshot progress is kept until the upgrade is requested",
+			existing:   &metav1.Condition{Type: EngineUpgradedCondition, Status: metav1.ConditionFalse, Reason: EngineUpgradeSnapshotInProgressReason},
+			version:    "13.7",
+			instance:   buildTestUpgradeDBInstance("available", nil),
+			wantReason: EngineUpgradeSnapshotInProgressReason,
+			wantStatus: metav1.ConditionFalse,
+		},
+		{
+			name:       "test upgrade scheduled for the maintenance window",
+			version:    "13.7",
+			instance:   buildTestUpgradeDBInstance("available", aws.String("13.7")),
+			wantReason: EngineUpgradeScheduledReason,
+			wantStatus: metav1.ConditionFalse,
+		},
+		{
+			name:       "test upgrade in progress",
+			version:    "13.7",
+			instance:   buildTestUpgradeDBInstance(rdsInstanceStatusUpgrading, nil),
+			wantReason: EngineUpgradeInProgressReason,
+			wantStatus: metav1.ConditionFalse,
+		},
+		{
+			name:       "test upgrade complete",
+			existing:   &metav1.Condition{Type: EngineUpgradedCondition, Status: metav1.ConditionFalse, Reason: EngineUpgradeInProgressReason},
+			version:    defaultAwsEngineVersion,
+			instance:   buildTestUpgradeDBInstance("available", nil),
+			wantReason: EngineUpgradedReason,
+			wantStatus: metav1.ConditionTrue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := buildTestPostgresCR()
+			if tt.existing != nil {
+				meta.SetStatusCondition(&cr.Status.Conditions, *tt.existing)
+			}
+			setRDSEngineUpgradeCondition(cr, &rds.CreateDBInstanceInput{EngineVersion: aws.String(tt.version)}, tt.instance)
+			cond := meta.FindStatusCondition(cr.Status.Conditions, EngineUpgradedCondition)
+			if tt.wantNil {
+				if cond != nil {
+					t.Fatalf("setRDSEngineUpgradeCondition() unexpected condition %+v", cond)
+				}
+				return
+			}
+			if cond == nil {
+				t.Fatal("setRDSEngineUpgradeCondition() expected a condition")
+			}
+			if cond.Reason != tt.wantReason || cond.Status != tt.wantStatus {
+				t.Errorf("setRDSEngineUpgradeCondition() got %s %s, want %s %s", cond.Status, cond.Reason, tt.wantStatus, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestAWSPostgresProvider_reconcileRDSPreUpgradeSnapshot(t *testing.T) {
+	scheme, err := buildTestSchemePostgresql()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	snapshotName := preUpgradeSnapshotName("test", "13.7")
+	tests := []struct {
+		name         string
+		version      string
+		instance     *rds.DBInstance
+		upgradeCfg   *EngineUpgrade
+		snapshots    []*rds.DBSnapshot
+		want         bool
+		wantCreated  bool
+		wantDescribe bool
+	}{
+		{
+			name:     "test no snapshot when no upgrade is needed",
+			version:  defaultAwsEngineVersion,
+			instance: buildTestUpgradeDBInstance("available", nil),
+			want:     false,
+		},
+		{
+			name:       "test no snapshot when the snapshot is skipped",
+			version:    "13.7",
+			instance:   buildTestUpgradeDBInstance("available", nil),
+			upgradeCfg: &EngineUpgrade{SkipSnapshot: true},
+			want:       false,
+		},
+		{
+			name:     "test no snapshot once the upgrade is scheduled",
+			version:  "13.7",
+			instance: buildTestUpgradeDBInstance("available", aws.String("13.7")),
+			want:     false,
+		},
+		{
+			name:         "test snapshot is created before the upgrade",
+			version:      "13.7",
+			instance:     buildTestUpgradeDBInstance("available", nil),
+			want:         true,
+			wantCreated:  true,
+			wantDescribe: true,
+		},
+		{
+			name:         "test upgrade waits for the snapshot",
+			version:      "13.7",
+			instance:     buildTestUpgradeDBInstance("available", nil),
+			snapshots:    []*rds.DBSnapshot{{DBSnapshotIdentifier: aws.String(snapshotName), Status: aws.String("creating")}},
+			want:         true,
+			wantDescribe: true,
+		},
+		{
+			name:         "test upgrade continues once the snapshot is available",
+			version:      "13.7",
+			instance:     buildTestUpgradeDBInstance("available", nil),
+			snapshots:    []*rds.DBSnapshot{{DBSnapshotIdentifier: aws.String(snapshotName), Status: aws.String(rdsSnapshotStatusAvailable)}},
+			want:         false,
+			wantDescribe: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := buildTestPostgresCR()
+			p := &PostgresProvider{
+				Client: fake.NewFakeClientWithScheme(scheme, cr, buildTestInfra()),
+				Logger: testLogger,
+			}
+			rdsSvc := buildRdsClientMock(func(mock *rdsClientMock) {
+				mock.DescribeDBSnapshotsFunc = func(in *rds.DescribeDBSnapshotsInput) (*rds.DescribeDBSnapshotsOutput, error) {
+					return &rds.DescribeDBSnapshotsOutput{DBSnapshots: tt.snapshots}, nil
+				}
+				mock.CreateDBSnapshotFunc = func(in *rds.CreateDBSnapshotInput) (*rds.CreateDBSnapshotOutput, error) {
+					return &rds.CreateDBSnapshotOutput{}, nil
+				}
+			})
+			got, _, err := p.reconcileRDSPreUpgradeSnapshot(context.TODO(), cr, rdsSvc, &rds.CreateDBInstanceInput{EngineVersion: aws.String(tt.version)}, tt.instance, tt.upgradeCfg)
+			if err != nil {
+				t.Fatalf("reconcileRDSPreUpgradeSnapshot() unexpected error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("reconcileRDSPreUpgradeSnapshot() = %v, want %v", got, tt.want)
+			}
+			if created := len(rdsSvc.calls.CreateDBSnapshot) > 0; created != tt.wantCreated {
+				t.Errorf("reconcileRDSPreUpgradeSnapshot() snapshot created %v, want %v", created, tt.wantCreated)
+			}
+			if described := len(rdsSvc.calls.DescribeDBSnapshots) > 0; described != tt.wantDescribe {
+				t.Errorf("reconcileRDSPreUpgradeSnapshot() snapshot described %v, want %v", described, tt.wantDescribe)
+			}
+			if tt.wantCreated && aws.StringValue(rdsSvc.calls.CreateDBSnapshot[0].In1.DBSnapshotIdentifier) != snapshotName {
+				t.Errorf("reconcileRDSPreUpgradeSnapshot() created snapshot %s, want %s", aws.StringValue(rdsSvc.calls.CreateDBSnapshot[0].In1.DBSnapshotIdentifier), snapshotName)
+			}
+			if tt.want {
+				cond := meta.FindStatusCondition(cr.Status.Conditions, EngineUpgradedCondition)
+				if cond == nil || cond.Reason != EngineUpgradeSnapshotInProgressReason {
+					t.Errorf("reconcileRDSPreUpgradeSnapshot() expected a %s condition, got %+v", EngineUpgradeSnapshotInProgressReason, cond)
+				}
+			}
+		})
+	}
+}
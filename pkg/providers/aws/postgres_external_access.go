@@ -0,0 +1,196 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/annotations"
+	errorUtil "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// ExternalAccessSecurityGroupAnnotation marks a resource with a security group allowing external access, so the
+	// security group is removed once external access is no longer allowed
+	ExternalAccessSecurityGroupAnnotation = "externalAccessSecurityGroup"
+
+	ec2ErrCodeDependencyViolation = "DependencyViolation"
+)
+
+func buildExternalAccessSecurityGroupName(instanceID string) string {
+	return fmt.Sprintf("%s-external-access", instanceID)
+}
+
+// reconcileRDSExternalAccess allows the source ranges to connect to the rds instance through a security group
+// dedicated to its external access, revoking source ranges no longer allowed. The shared security group of the
+// resources of the cluster is left as is. Once no source ranges are allowed the dedicated security group is detached
+// from the instance and deleted. It returns a status message while the security groups of the instance are modified
+func (p *PostgresProvider) reconcileRDSExternalAccess(ctx context.Context, cr *v1alpha1.Postgres, rdsSvc rdsiface.RDSAPI, ec2Svc ec2iface.EC2API, instance *rds.DBInstance, cidrs []string, logger *logrus.Entry) (croType.StatusMessage, error) {
+	if len(cidrs) == 0 && !annotations.Has(cr, ExternalAccessSecurityGroupAnnotation) {
+		return croType.StatusEmpty, nil
+	}
+	secName := buildExternalAccessSecurityGroupName(aws.StringValue(instance.DBInstanceIdentifier))
+	secGroup, err := getSecurityGroup(ec2Svc, secName)
+	if err != nil {
+		return "failed to get external access security group", errorUtil.Wrap(err, "failed to get external access security group")
+	}
+
+	if len(cidrs) == 0 {
+		return p.removeRDSExternalAccess(ctx, cr, rdsSvc, ec2Svc, instance, secGroup, logger)
+	}
+
+	if secGroup == nil {
+		if instance.DBSubnetGroup == nil {
+			return "waiting for rds instance network", nil
+		}
+		logger.Infof("creating external access security group %s", secName)
+		created, err := ec2Svc.CreateSecurityGroup(&ec2.CreateSecurityGroupInput{
+			Description: aws.String(fmt.Sprintf("external access to postgres %s/%s", cr.Namespace, cr.Name)),
+			GroupName:   aws.String(secName),
+			VpcId:       instance.DBSubnetGroup.VpcId,
+		})
+		if err != nil {
+			return "failed to create external access security group", errorUtil.Wrap(err, "failed to create external access security group")
+		}
+		secGroup = &ec2.SecurityGroup{GroupId: created.GroupId, GroupName: aws.String(secName)}
+	}
+	if !annotations.Has(cr, ExternalAccessSecurityGroupAnnotation) {
+		// updating the cr replaces its status, which is kept to be updated with the rest of the reconcile
+		status := cr.Status.DeepCopy()
+		annotations.Add(cr, ExternalAccessSecurityGroupAnnotation, aws.StringValue(secGroup.GroupId))
+		if err := p.Client.Update(ctx, cr); err != nil {
+			return "failed to add annotation", errorUtil.Wrap(err, "failed to annotate postgres with its external access security group")
+		}
+		cr.Status = *status
+	}
+
+	port := aws.Int64Value(instance.Endpoint.Port)
+	revoke, authorize := buildExternalAccessIpPermissionChanges(secGroup.IpPermissions, port, cidrs)
+	if len(revoke) > 0 {
+		if _, err := ec2Svc.RevokeSecurityGroupIngress(&ec2.RevokeSecurityGroupIngressInput{GroupId: secGroup.GroupId, IpPermissions: revoke}); err != nil {
+			return "failed to revoke external access", errorUtil.Wrapf(err, "failed to revoke ingress of security group %s", secName)
+		}
+	}
+	if len(authorize) > 0 {
+		if _, err := ec2Svc.AuthorizeSecurityGroupIngress(&ec2.AuthorizeSecurityGroupIngressInput{GroupId: secGroup.GroupId, IpPermissions: authorize}); err != nil {
+			return "failed to allow external access", errorUtil.Wrapf(err, "failed to authorize ingress of security group %s", secName)
+		}
+	}
+
+	if !rdsInstanceHasSecurityGroup(instance, aws.StringValue(secGroup.GroupId)) {
+		ids := append(rdsInstanceSecurityGroupIDs(instance, ""), secGroup.GroupId)
+		if _, err := rdsSvc.ModifyDBInstance(&rds.ModifyDBInstanceInput{
+			DBInstanceIdentifier: instance.DBInstanceIdentifier,
+			VpcSecurityGroupIds:  ids,
+			ApplyImmediately:     aws.Bool(true),
+		}); err != nil {
+			return "failed to attach external access security group", errorUtil.Wrap(err, "failed to attach external access security group")
+		}
+		return croType.StatusMessage(fmt.Sprintf("attaching external access security group %s to rds instance %s", secName, aws.StringValue(instance.DBInstanceIdentifier))), nil
+	}
+	return croType.StatusEmpty, nil
+}
+
+// removeRDSExternalAccess revokes the external access to the rds instance, then detaches and deletes its dedicated
+// security group
+func (p *PostgresProvider) removeRDSExternalAccess(ctx context.Context, cr *v1alpha1.Postgres, rdsSvc rdsiface.RDSAPI, ec2Svc ec2iface.EC2API, instance *rds.DBInstance, secGroup *ec2.SecurityGroup, logger *logrus.Entry) (croType.StatusMessage, error) {
+	if secGroup != nil {
+		if len(secGroup.IpPermissions) > 0 {
+			if _, err := ec2Svc.RevokeSecurityGroupIngress(&ec2.RevokeSecurityGroupIngressInput{GroupId: secGroup.GroupId, IpPermissions: secGroup.IpPermissions}); err != nil {
+				return "failed to revoke external access", errorUtil.Wrapf(err, "failed to revoke ingress of security group %s", aws.StringValue(secGroup.GroupName))
+			}
+		}
+		if instance != nil && rdsInstanceHasSecurityGroup(instance, aws.StringValue(secGroup.GroupId)) {
+			if _, err := rdsSvc.ModifyDBInstance(&rds.ModifyDBInstanceInput{
+				DBInstanceIdentifier: instance.DBInstanceIdentifier,
+				VpcSecurityGroupIds:  rdsInstanceSecurityGroupIDs(instance, aws.StringValue(secGroup.GroupId)),
+				ApplyImmediately:     aws.Bool(true),
+			}); err != nil {
+				return "failed to detach external access security group", errorUtil.Wrap(err, "failed to detach external access security group")
+			}
+			return croType.StatusMessage(fmt.Sprintf("detaching external access security group %s from rds instance %s", aws.StringValue(secGroup.GroupName), aws.StringValue(instance.DBInstanceIdentifier))), nil
+		}
+		logger.Infof("deleting external access security group %s", aws.StringValue(secGroup.GroupName))
+		if _, err := ec2Svc.DeleteSecurityGroup(&ec2.DeleteSecurityGroupInput{GroupId: secGroup.GroupId}); err != nil {
+			// the security group is in use until the instance modification detaching it completes
+			if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == ec2ErrCodeDependencyViolation {
+				return croType.StatusMessage(fmt.Sprintf("waiting for external access security group %s to be detached", aws.StringValue(secGroup.GroupName))), nil
+			}
+			return "failed to delete external access security group", errorUtil.Wrap(err, "failed to delete external access security group")
+		}
+	}
+	status := cr.Status.DeepCopy()
+	annotations.Remove(cr, ExternalAccessSecurityGroupAnnotation)
+	if err := p.Client.Update(ctx, cr); err != nil {
+		return "failed to remove annotation", errorUtil.Wrap(err, "failed to remove external access security group annotation")
+	}
+	cr.Status = *status
+	return croType.StatusEmpty, nil
+}
+
+// buildExternalAccessIpPermissionChanges returns the ingress to revoke from, and authorize on, the external access
+// security group so only the source ranges can connect to the port
+func buildExternalAccessIpPermissionChanges(current []*ec2.IpPermission, port int64, cidrs []string) ([]*ec2.IpPermission, []*ec2.IpPermission) {
+	desired := map[string]bool{}
+	for _, c := range cidrs {
+		desired[c] = true
+	}
+	existing := map[string]bool{}
+	var revoke []*ec2.IpPermission
+	for _, perm := range current {
+		if aws.StringValue(perm.IpProtocol) != "tcp" || aws.Int64Value(perm.FromPort) != port || aws.Int64Value(perm.ToPort) != port || len(perm.Ipv6Ranges) > 0 || len(perm.UserIdGroupPairs) > 0 || len(perm.PrefixListIds) > 0 {
+			revoke = append(revoke, perm)
+			continue
+		}
+		var stale []*ec2.IpRange
+		for _, r := range perm.IpRanges {
+			if desired[aws.StringValue(r.CidrIp)] {
+				existing[aws.StringValue(r.CidrIp)] = true
+				continue
+			}
+			stale = append(stale, &ec2.IpRange{CidrIp: r.CidrIp})
+		}
+		if len(stale) > 0 {
+			revoke = append(revoke, &ec2.IpPermission{IpProtocol: aws.String("tcp"), FromPort: aws.Int64(port), ToPort: aws.Int64(port), IpRanges: stale})
+		}
+	}
+	var missing []*ec2.IpRange
+	for _, c := range cidrs {
+		if !existing[c] {
+			missing = append(missing, &ec2.IpRange{CidrIp: aws.String(c), Description: aws.String("external access allowed by cloud resource operator")})
+		}
+	}
+	var authorize []*ec2.IpPermission
+	if len(missing) > 0 {
+		authorize = append(authorize, &ec2.IpPermission{IpProtocol: aws.String("tcp"), FromPort: aws.Int64(port), ToPort: aws.Int64(port), IpRanges: missing})
+	}
+	return revoke, authorize
+}
+
+func rdsInstanceHasSecurityGroup(instance *rds.DBInstance, groupID string) bool {
+	for _, sg := range instance.VpcSecurityGroups {
+		if aws.StringValue(sg.VpcSecurityGroupId) == groupID {
+			return true
+		}
+	}
+	return false
+}
+
+// rdsInstanceSecurityGroupIDs returns the ids of the security groups of the rds instance, except the excluded one
+func rdsInstanceSecurityGroupIDs(instance *rds.DBInstance, exclude string) []*string {
+	var ids []*string
+	for _, sg := range instance.VpcSecurityGroups {
+		if aws.StringValue(sg.VpcSecurityGroupId) != exclude {
+			ids = append(ids, sg.VpcSecurityGroupId)
+		}
+	}
+	return ids
+}
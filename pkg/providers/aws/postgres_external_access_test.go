@@ -0,0 +1,190 @@
+package aws
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/annotations"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func buildTestExternalAccessDBInstance(secGroupIDs ...string) *rds.DBInstance {
+	instance := buildAvailableDBInstance("test")[0]
+	instance.DBSubnetGroup = &rds.DBSubnetGroup{VpcId: aws.String("test-vpc")}
+	for _, id := range secGroupIDs {
+		instance.VpcSecurityGroups = append(instance.VpcSecurityGroups, &rds.VpcSecurityGroupMembership{VpcSecurityGroupId: aws.String(id)})
+	}
+	return instance
+}
+
+func TestBuildExternalAccessIpPermissionChanges(t *testing.T) {
+	port := int64(defaultAwsPostgresPort)
+	perm := func(cidrs ...string) *ec2.IpPermission {
+		p := &ec2.IpPermission{IpProtocol: aws.String("tcp"), FromPort: aws.Int64(port), ToPort: aws.Int64(port)}
+		for _, c := range cidrs {
+			p.IpRanges = append(p.IpRanges, &ec2.IpRange{CidrIp: aws.String(c)})
+		}
+		return p
+	}
+	tests := []struct {
+		name          string
+		current       []*ec2.IpPermission
+		cidrs         []string
+		wantRevoke    []string
+		wantAuthorize []string
+	}{
+		{
+			name:          "test missing source ranges are authorized",
+			current:       []*ec2.IpPermission{perm("10.0.0.0/24")},
+			cidrs:         []string{"10.0.0.0/24", "192.168.1.0/24"},
+			wantAuthorize: []string{"192.168.1.0/24"},
+		},
+		{
+			name:       "test source ranges no longer allowed are revoked",
+			current:    []*ec2.IpPermission{perm("10.0.0.0/24", "192.168.1.0/24")},
+			cidrs:      []string{"10.0.0.0/24"},
+			wantRevoke: []string{"192.168.1.0/24"},
+		},
+		{
+			name:    "test allowed source ranges are left as is",
+			current: []*ec2.IpPermission{perm("10.0.0.0/24")},
+			cidrs:   []string{"10.0.0.0/24"},
+		},
+	}
+	ranges := func(perms []*ec2.IpPermission) []string {
+		var cidrs []string
+		for _, p := range perms {
+			for _, r := range p.IpRanges {
+				cidrs = append(cidrs, aws.StringValue(r.CidrIp))
+			}
+		}
+		return cidrs
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revoke, authorize := buildExternalAccessIpPermissionChanges(tt.current, port, tt.cidrs)
+			if got := ranges(revoke); len(got) != len(tt.wantRevoke) || (len(got) > 0 && got[0] != tt.wantRevoke[0]) {
+				t.Errorf("buildExternalAccessIpPermissionChanges() revoke = %v, want %v", got, tt.wantRevoke)
+			}
+			if got := ranges(authorize); len(got) != len(tt.wantAuthorize) || (len(got) > 0 && got[0] != tt.wantAuthorize[0]) {
+				t.Errorf("buildExternalAccessIpPermissionChanges() authorize = %v, want %v", got, tt.wantAuthorize)
+			}
+		})
+	}
+}
+
+func TestAWSPostgresProvider_reconcileRDSExternalAccess(t *testing.T) {
+	scheme, err := buildTestSchemePostgresql()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	secName := buildExternalAccessSecurityGroupName("test")
+	externalAccessSecGroup := buildSecurityGroup(func(sg *ec2.SecurityGroup) {
+		sg.GroupId = aws.String("sg-external")
+		sg.GroupName = aws.String(secName)
+	})
+	tests := []struct {
+		name           string
+		cr             *v1alpha1.Postgres
+		instance       *rds.DBInstance
+		secGroups      []*ec2.SecurityGroup
+		cidrs          []string
+		wantMsg        bool
+		wantAnnotation bool
+		wantCreated    bool
+		wantDeleted    bool
+	}{
+		{
+			name:     "test nothing is done without external access",
+			cr:       buildTestPostgresCR(),
+			instance: buildTestExternalAccessDBInstance("sg-cluster"),
+		},
+		{
+			name:           "test security group is created and attached to the instance",
+			cr:             buildTestPostgresCR(),
+			instance:       buildTestExternalAccessDBInstance("sg-cluster"),
+			cidrs:          []string{"192.168.1.0/24"},
+			wantMsg:        true,
+			wantAnnotation: true,
+			wantCreated:    true,
+		},
+		{
+			name: "test attached security group is as expected",
+			cr: func() *v1alpha1.Postgres {
+				pg := buildTestPostgresCR()
+				annotations.Add(pg, ExternalAccessSecurityGroupAnnotation, "sg-external")
+				return pg
+			}(),
+			instance:       buildTestExternalAccessDBInstance("sg-cluster", "sg-external"),
+			secGroups:      []*ec2.SecurityGroup{externalAccessSecGroup},
+			cidrs:          []string{"192.168.1.0/24"},
+			wantAnnotation: true,
+		},
+		{
+			name: "test security group is detached once external access is removed",
+			cr: func() *v1alpha1.Postgres {
+				pg := buildTestPostgresCR()
+				annotations.Add(pg, ExternalAccessSecurityGroupAnnotation, "sg-external")
+				return pg
+			}(),
+			instance:       buildTestExternalAccessDBInstance("sg-cluster", "sg-external"),
+			secGroups:      []*ec2.SecurityGroup{externalAccessSecGroup},
+			wantMsg:        true,
+			wantAnnotation: true,
+		},
+		{
+			name: "test detached security group is deleted",
+			cr: func() *v1alpha1.Postgres {
+				pg := buildTestPostgresCR()
+				annotations.Add(pg, ExternalAccessSecurityGroupAnnotation, "sg-external")
+				return pg
+			}(),
+			instance:    buildTestExternalAccessDBInstance("sg-cluster"),
+			secGroups:   []*ec2.SecurityGroup{externalAccessSecGroup},
+			wantDeleted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created, deleted bool
+			ec2Svc := buildMockEc2Client(func(m *mockEc2Client) {
+				m.describeSecurityGroupsFn = func(input *ec2.DescribeSecurityGroupsInput) (*ec2.DescribeSecurityGroupsOutput, error) {
+					return &ec2.DescribeSecurityGroupsOutput{SecurityGroups: tt.secGroups}, nil
+				}
+				m.createSecurityGroupFn = func(input *ec2.CreateSecurityGroupInput) (*ec2.CreateSecurityGroupOutput, error) {
+					created = true
+					return &ec2.CreateSecurityGroupOutput{GroupId: aws.String("sg-external")}, nil
+				}
+				m.deleteSecurityGroupFn = func(input *ec2.DeleteSecurityGroupInput) (*ec2.DeleteSecurityGroupOutput, error) {
+					deleted = true
+					return &ec2.DeleteSecurityGroupOutput{}, nil
+				}
+			})
+			p := &PostgresProvider{
+				Client: fake.NewFakeClientWithScheme(scheme, tt.cr),
+				Logger: testLogger,
+			}
+			msg, err := p.reconcileRDSExternalAccess(context.TODO(), tt.cr, buildMockRdsClient(nil), ec2Svc, tt.instance, tt.cidrs, testLogger)
+			if err != nil {
+				t.Fatalf("reconcileRDSExternalAccess() unexpected error = %v", err)
+			}
+			if (msg != croType.StatusEmpty) != tt.wantMsg {
+				t.Errorf("reconcileRDSExternalAccess() msg = %q, want message %v", msg, tt.wantMsg)
+			}
+			if annotations.Has(tt.cr, ExternalAccessSecurityGroupAnnotation) != tt.wantAnnotation {
+				t.Errorf("reconcileRDSExternalAccess() annotation set %v, want %v", !tt.wantAnnotation, tt.wantAnnotation)
+			}
+			if created != tt.wantCreated {
+				t.Errorf("reconcileRDSExternalAccess() security group created %v, want %v", created, tt.wantCreated)
+			}
+			if deleted != tt.wantDeleted {
+				t.Errorf("reconcileRDSExternalAccess() security group deleted %v, want %v", deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
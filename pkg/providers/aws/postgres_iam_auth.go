@@ -0,0 +1,74 @@
+package aws
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	errorUtil "github.com/pkg/errors"
+)
+
+const (
+	// RDSAuthTokenLifetime is how long an rds iam authentication token can be used to open a connection, connections
+	// opened with a token aren't closed once it expires
+	RDSAuthTokenLifetime = 15 * time.Minute
+
+	rdsAuthTokenService = "rds-db"
+)
+
+// IAMAuthentication configures iam database authentication of an rds instance, applications connect with a token
+// generated from their aws credentials in place of the master password
+type IAMAuthentication struct {
+	// Enabled enables iam database authentication on new and existing instances
+	Enabled bool `json:"enabled"`
+	// Username is the database user applications connect as, it must be created in the database and granted the
+	// rds_iam role. Defaults to the master user
+	Username string `json:"username,omitempty"`
+}
+
+// applyRDSIAMAuthentication sets iam database authentication of the create config to the strategy, an explicit
+// EnableIAMDatabaseAuthentication of the create strategy is kept if the strategy doesn't configure it
+func applyRDSIAMAuthentication(rdsCfg *rds.CreateDBInstanceInput, iamAuth *IAMAuthentication) {
+	if iamAuth == nil {
+		return
+	}
+	rdsCfg.EnableIAMDatabaseAuthentication = aws.Bool(iamAuth.Enabled)
+}
+
+// setRDSIAMAuthDetails replaces the master password of the deployment details with the region and user to generate
+// an iam authentication token for, once iam database authentication is enabled on the instance
+func setRDSIAMAuthDetails(pdd *providers.PostgresDeploymentDetails, foundInstance *rds.DBInstance, region string, iamAuth *IAMAuthentication) {
+	if foundInstance == nil || !aws.BoolValue(foundInstance.IAMDatabaseAuthenticationEnabled) || iamAuth == nil || !iamAuth.Enabled {
+		return
+	}
+	pdd.AuthMode = providers.PostgresAuthModeIAM
+	pdd.Region = region
+	pdd.Password = ""
+	if iamAuth.Username != "" {
+		pdd.Username = iamAuth.Username
+	}
+}
+
+// BuildRDSAuthToken returns an iam authentication token for the user of the rds instance at the endpoint, a
+// host:port pair, signed with the credentials. The token is used as the password of the connection and can be used
+// to open connections for RDSAuthTokenLifetime
+func BuildRDSAuthToken(endpoint, region, user string, creds *credentials.Credentials, now time.Time) (string, error) {
+	if endpoint == "" || region == "" || user == "" {
+		return "", errorUtil.New("endpoint, region and user are required to build an rds auth token")
+	}
+	req, err := http.NewRequest(http.MethodGet, "https://"+endpoint, nil)
+	if err != nil {
+		return "", errorUtil.Wrap(err, "failed to build rds auth token request")
+	}
+	req.URL.RawQuery = url.Values{"Action": {"connect"}, "DBUser": {user}}.Encode()
+	if _, err := v4.NewSigner(creds).Presign(req, nil, rdsAuthTokenService, region, RDSAuthTokenLifetime, now); err != nil {
+		return "", errorUtil.Wrap(err, "failed to sign rds auth token")
+	}
+	return strings.TrimPrefix(req.URL.String(), "https://"), nil
+}
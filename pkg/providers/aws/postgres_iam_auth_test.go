@@ -0,0 +1,117 @@
+package aws
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+)
+
+func TestBuildRDSAuthToken(t *testing.T) {
+	creds := credentials.NewStaticCredentials("AKID", "SECRET", "")
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	token, err := BuildRDSAuthToken("db.example.com:5432", "eu-west-1", "app user", creds, now)
+	if err != nil {
+		t.Fatalf("BuildRDSAuthToken() unexpected error = %v", err)
+	}
+	if !strings.HasPrefix(token, "db.example.com:5432?") {
+		t.Fatalf("BuildRDSAuthToken() = %s, want a token for the endpoint without a scheme", token)
+	}
+	query, err := url.ParseQuery(strings.SplitN(token, "?", 2)[1])
+	if err != nil {
+		t.Fatalf("failed to parse token query: %v", err)
+	}
+	want := map[string]string{
+		"Action":           "connect",
+		"DBUser":           "app user",
+		"X-Amz-Expires":    "900",
+		"X-Amz-Date":       "20220601T120000Z",
+		"X-Amz-Credential": "AKID/20220601/eu-west-1/rds-db/aws4_request",
+	}
+	for k, v := range want {
+		if got := query.Get(k); got != v {
+			t.Errorf("BuildRDSAuthToken() %s = %s, want %s", k, got, v)
+		}
+	}
+	if query.Get("X-Amz-Signature") == "" {
+		t.Errorf("BuildRDSAuthToken() = %s, want a signed token", token)
+	}
+
+	if _, err := BuildRDSAuthToken("db.example.com:5432", "", "app", creds, now); err == nil {
+		t.Error("BuildRDSAuthToken() expected an error without a region")
+	}
+}
+
+func Test_setRDSIAMAuthDetails(t *testing.T) {
+	buildInstance := func(enabled bool) *rds.DBInstance {
+		return &rds.DBInstance{IAMDatabaseAuthenticationEnabled: aws.Bool(enabled)}
+	}
+	tests := []struct {
+		name     string
+		instance *rds.DBInstance
+		iamAuth  *IAMAuthentication
+		want     providers.PostgresDeploymentDetails
+	}{
+		{
+			name:     "test password is kept without iam authentication",
+			instance: buildInstance(false),
+			want:     providers.PostgresDeploymentDetails{Username: "postgres", Password: "pass"},
+		},
+		{
+			name:     "test password is kept until iam authentication is enabled on the instance",
+			instance: buildInstance(false),
+			iamAuth:  &IAMAuthentication{Enabled: true},
+			want:     providers.PostgresDeploymentDetails{Username: "postgres", Password: "pass"},
+		},
+		{
+			name:     "test master user connects with a token",
+			instance: buildInstance(true),
+			iamAuth:  &IAMAuthentication{Enabled: true},
+			want:     providers.PostgresDeploymentDetails{Username: "postgres", AuthMode: providers.PostgresAuthModeIAM, Region: "eu-west-1"},
+		},
+		{
+			name:     "test iam user connects with a token",
+			instance: buildInstance(true),
+			iamAuth:  &IAMAuthentication{Enabled: true, Username: "app"},
+			want:     providers.PostgresDeploymentDetails{Username: "app", AuthMode: providers.PostgresAuthModeIAM, Region: "eu-west-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pdd := &providers.PostgresDeploymentDetails{Username: "postgres", Password: "pass"}
+			setRDSIAMAuthDetails(pdd, tt.instance, "eu-west-1", tt.iamAuth)
+			if *pdd != tt.want {
+				t.Errorf("setRDSIAMAuthDetails() = %+v, want %+v", *pdd, tt.want)
+			}
+			data := pdd.Data()
+			if _, ok := data["password"]; ok == (tt.want.AuthMode == providers.PostgresAuthModeIAM) {
+				t.Errorf("Data() = %v, want the password only without iam authentication", data)
+			}
+		})
+	}
+}
+
+func Test_buildRDSUpdateStrategy_IAMAuthentication(t *testing.T) {
+	rdsCfg := buildAvailableCreateInput("test")
+	applyRDSIAMAuthentication(rdsCfg, &IAMAuthentication{Enabled: true})
+	foundInstance := buildAvailableDBInstance("test")[0]
+
+	mi, err := buildRDSUpdateStrategy(rdsCfg, foundInstance, buildTestPostgresCR())
+	if err != nil {
+		t.Fatalf("buildRDSUpdateStrategy() unexpected error = %v", err)
+	}
+	if mi == nil || !aws.BoolValue(mi.EnableIAMDatabaseAuthentication) {
+		t.Fatalf("buildRDSUpdateStrategy() = %v, want iam authentication enabled", mi)
+	}
+
+	foundInstance.PendingModifiedValues = &rds.PendingModifiedValues{IAMDatabaseAuthenticationEnabled: aws.Bool(true)}
+	if mi, err := buildRDSUpdateStrategy(rdsCfg, foundInstance, buildTestPostgresCR()); err != nil || mi != nil {
+		t.Errorf("buildRDSUpdateStrategy() = %v, %v, want no modification while it's pending", mi, err)
+	}
+}
@@ -0,0 +1,119 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+	errorUtil "github.com/pkg/errors"
+)
+
+const (
+	// rds keeps performance insights data for 7 days for free, 731 days or any number of months of 31 days in between
+	rdsPerformanceInsightsFreeRetention = 7
+	rdsPerformanceInsightsMaxRetention  = 731
+	rdsPerformanceInsightsMonth         = 31
+)
+
+// rdsMonitoringIntervals are the enhanced monitoring intervals in seconds accepted by rds, 0 disables it
+var rdsMonitoringIntervals = []int64{0, 1, 5, 10, 15, 30, 60}
+
+// RDSMonitoring configures performance insights and enhanced monitoring of an rds instance, the settings are
+// reconciled onto existing instances so changes made to them outside the operator are reverted
+type RDSMonitoring struct {
+	// PerformanceInsights enables performance insights
+	PerformanceInsights bool `json:"performanceInsights"`
+	// PerformanceInsightsRetentionPeriod is the number of days performance insights data is kept, 7 (the default),
+	// 731 or a multiple of 31
+	PerformanceInsightsRetentionPeriod int64 `json:"performanceInsightsRetentionPeriod,omitempty"`
+	// MonitoringInterval is the number of seconds between enhanced monitoring metrics, 0 disables enhanced monitoring
+	MonitoringInterval int64 `json:"monitoringInterval"`
+	// MonitoringRoleArn is the role rds publishes enhanced monitoring metrics to cloudwatch logs with, it's required if
+	// MonitoringInterval is set
+	MonitoringRoleArn string `json:"monitoringRoleArn,omitempty"`
+}
+
+// applyRDSMonitoring sets performance insights and enhanced monitoring of the create config to the strategy, the
+// settings of the create strategy are kept if the strategy doesn't configure them
+func applyRDSMonitoring(rdsCfg *rds.CreateDBInstanceInput, monitoring *RDSMonitoring) {
+	if monitoring == nil {
+		return
+	}
+	rdsCfg.EnablePerformanceInsights = aws.Bool(monitoring.PerformanceInsights)
+	rdsCfg.PerformanceInsightsRetentionPeriod = nil
+	if monitoring.PerformanceInsights {
+		rdsCfg.PerformanceInsightsRetentionPeriod = aws.Int64(rdsPerformanceInsightsFreeRetention)
+		if monitoring.PerformanceInsightsRetentionPeriod != 0 {
+			rdsCfg.PerformanceInsightsRetentionPeriod = aws.Int64(monitoring.PerformanceInsightsRetentionPeriod)
+		}
+	}
+	rdsCfg.MonitoringInterval = aws.Int64(monitoring.MonitoringInterval)
+	rdsCfg.MonitoringRoleArn = nil
+	if monitoring.MonitoringInterval != 0 {
+		rdsCfg.MonitoringRoleArn = aws.String(monitoring.MonitoringRoleArn)
+	}
+}
+
+// validateRDSMonitoring returns an error if rds would reject the performance insights or enhanced monitoring settings
+// of the create config
+func validateRDSMonitoring(rdsCfg *rds.CreateDBInstanceInput) error {
+	if retention := rdsCfg.PerformanceInsightsRetentionPeriod; retention != nil {
+		if !aws.BoolValue(rdsCfg.EnablePerformanceInsights) {
+			return errorUtil.New("performance insights retention period is set but performance insights isn't enabled")
+		}
+		months := *retention > 0 && *retention < rdsPerformanceInsightsMaxRetention && *retention%rdsPerformanceInsightsMonth == 0
+		if *retention != rdsPerformanceInsightsFreeRetention && *retention != rdsPerformanceInsightsMaxRetention && !months {
+			return errorUtil.Errorf("invalid performance insights retention period %d, expected 7, 731 or a multiple of 31", *retention)
+		}
+	}
+	if interval := rdsCfg.MonitoringInterval; interval != nil {
+		valid := false
+		for _, i := range rdsMonitoringIntervals {
+			if *interval == i {
+				valid = true
+			}
+		}
+		if !valid {
+			return errorUtil.Errorf("invalid enhanced monitoring interval %d, expected one of %v", *interval, rdsMonitoringIntervals)
+		}
+		if *interval != 0 && aws.StringValue(rdsCfg.MonitoringRoleArn) == "" {
+			return errorUtil.New("enhanced monitoring requires a monitoring role arn")
+		}
+		if *interval == 0 && aws.StringValue(rdsCfg.MonitoringRoleArn) != "" {
+			return errorUtil.New("monitoring role arn is set but enhanced monitoring is disabled")
+		}
+	}
+	return nil
+}
+
+// buildRDSMonitoringUpdate sets the performance insights and enhanced monitoring settings of the create config that
+// differ from the instance on the modify input, settings the create config doesn't set are left untouched. Returns
+// true if an update is found
+func buildRDSMonitoringUpdate(mi *rds.ModifyDBInstanceInput, rdsCfg *rds.CreateDBInstanceInput, foundInstance *rds.DBInstance) bool {
+	updateFound := false
+	if rdsCfg.EnablePerformanceInsights != nil && *rdsCfg.EnablePerformanceInsights != aws.BoolValue(foundInstance.PerformanceInsightsEnabled) {
+		mi.EnablePerformanceInsights = rdsCfg.EnablePerformanceInsights
+		updateFound = true
+	}
+	if rdsCfg.PerformanceInsightsRetentionPeriod != nil && *rdsCfg.PerformanceInsightsRetentionPeriod != aws.Int64Value(foundInstance.PerformanceInsightsRetentionPeriod) {
+		mi.EnablePerformanceInsights = aws.Bool(true)
+		mi.PerformanceInsightsRetentionPeriod = rdsCfg.PerformanceInsightsRetentionPeriod
+		updateFound = true
+	}
+	if rdsCfg.MonitoringInterval != nil && *rdsCfg.MonitoringInterval != aws.Int64Value(foundInstance.MonitoringInterval) {
+		mi.MonitoringInterval = rdsCfg.MonitoringInterval
+		updateFound = true
+	}
+	if rdsCfg.MonitoringRoleArn != nil && *rdsCfg.MonitoringRoleArn != aws.StringValue(foundInstance.MonitoringRoleArn) {
+		mi.MonitoringRoleArn = rdsCfg.MonitoringRoleArn
+		updateFound = true
+	}
+	// rds rejects a new role without the interval, and a new interval other than 0 without the role
+	if mi.MonitoringInterval != nil || mi.MonitoringRoleArn != nil {
+		if mi.MonitoringInterval == nil {
+			mi.MonitoringInterval = foundInstance.MonitoringInterval
+		}
+		if mi.MonitoringRoleArn == nil && aws.Int64Value(mi.MonitoringInterval) != 0 {
+			mi.MonitoringRoleArn = foundInstance.MonitoringRoleArn
+		}
+	}
+	return updateFound
+}
@@ -0,0 +1,158 @@
+package aws
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+)
+
+func Test_validateRDSMonitoring(t *testing.T) {
+	tests := []struct {
+		name       string
+		monitoring *RDSMonitoring
+		rdsCfg     *rds.CreateDBInstanceInput
+		wantErr    bool
+	}{
+		{
+			name:   "test nothing configured",
+			rdsCfg: &rds.CreateDBInstanceInput{},
+		},
+		{
+			name:       "test performance insights with the default retention",
+			monitoring: &RDSMonitoring{PerformanceInsights: true},
+			rdsCfg:     &rds.CreateDBInstanceInput{},
+		},
+		{
+			name:       "test performance insights kept for months",
+			monitoring: &RDSMonitoring{PerformanceInsights: true, PerformanceInsightsRetentionPeriod: 93},
+			rdsCfg:     &rds.CreateDBInstanceInput{},
+		},
+		{
+			name:       "test invalid performance insights retention",
+			monitoring: &RDSMonitoring{PerformanceInsights: true, PerformanceInsightsRetentionPeriod: 30},
+			rdsCfg:     &rds.CreateDBInstanceInput{},
+			wantErr:    true,
+		},
+		{
+			name:    "test retention of the create strategy without performance insights",
+			rdsCfg:  &rds.CreateDBInstanceInput{PerformanceInsightsRetentionPeriod: aws.Int64(7)},
+			wantErr: true,
+		},
+		{
+			name:       "test enhanced monitoring with a role",
+			monitoring: &RDSMonitoring{MonitoringInterval: 60, MonitoringRoleArn: "arn:aws:iam::123456789012:role/rds-monitoring"},
+			rdsCfg:     &rds.CreateDBInstanceInput{},
+		},
+		{
+			name:       "test enhanced monitoring without a role",
+			monitoring: &RDSMonitoring{MonitoringInterval: 60},
+			rdsCfg:     &rds.CreateDBInstanceInput{},
+			wantErr:    true,
+		},
+		{
+			name:       "test invalid enhanced monitoring interval",
+			monitoring: &RDSMonitoring{MonitoringInterval: 20, MonitoringRoleArn: "arn:aws:iam::123456789012:role/rds-monitoring"},
+			rdsCfg:     &rds.CreateDBInstanceInput{},
+			wantErr:    true,
+		},
+		{
+			name:       "test role of a disabled enhanced monitoring is dropped",
+			monitoring: &RDSMonitoring{MonitoringRoleArn: "arn:aws:iam::123456789012:role/rds-monitoring"},
+			rdsCfg:     &rds.CreateDBInstanceInput{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applyRDSMonitoring(tt.rdsCfg, tt.monitoring)
+			if err := validateRDSMonitoring(tt.rdsCfg); (err != nil) != tt.wantErr {
+				t.Errorf("validateRDSMonitoring() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_buildRDSUpdateStrategy_Monitoring(t *testing.T) {
+	roleArn := "arn:aws:iam::123456789012:role/rds-monitoring"
+	tests := []struct {
+		name       string
+		monitoring *RDSMonitoring
+		instance   func(*rds.DBInstance)
+		want       *rds.ModifyDBInstanceInput
+	}{
+		{
+			name: "test instance settings are kept without monitoring configured",
+			instance: func(i *rds.DBInstance) {
+				i.PerformanceInsightsEnabled = aws.Bool(true)
+				i.MonitoringInterval = aws.Int64(60)
+			},
+		},
+		{
+			name:       "test performance insights and enhanced monitoring are enabled",
+			monitoring: &RDSMonitoring{PerformanceInsights: true, PerformanceInsightsRetentionPeriod: 31, MonitoringInterval: 30, MonitoringRoleArn: roleArn},
+			want: &rds.ModifyDBInstanceInput{
+				DBInstanceIdentifier:               aws.String("test"),
+				EnablePerformanceInsights:          aws.Bool(true),
+				PerformanceInsightsRetentionPeriod: aws.Int64(31),
+				MonitoringInterval:                 aws.Int64(30),
+				MonitoringRoleArn:                  aws.String(roleArn),
+			},
+		},
+		{
+			name:       "test settings changed outside the operator are reverted",
+			monitoring: &RDSMonitoring{},
+			instance: func(i *rds.DBInstance) {
+				i.PerformanceInsightsEnabled = aws.Bool(true)
+				i.PerformanceInsightsRetentionPeriod = aws.Int64(7)
+				i.MonitoringInterval = aws.Int64(60)
+				i.MonitoringRoleArn = aws.String(roleArn)
+			},
+			want: &rds.ModifyDBInstanceInput{
+				DBInstanceIdentifier:      aws.String("test"),
+				EnablePerformanceInsights: aws.Bool(false),
+				MonitoringInterval:        aws.Int64(0),
+			},
+		},
+		{
+			name:       "test changed interval keeps the role of the instance",
+			monitoring: &RDSMonitoring{MonitoringInterval: 5, MonitoringRoleArn: roleArn},
+			instance: func(i *rds.DBInstance) {
+				i.MonitoringInterval = aws.Int64(60)
+				i.MonitoringRoleArn = aws.String(roleArn)
+			},
+			want: &rds.ModifyDBInstanceInput{
+				DBInstanceIdentifier: aws.String("test"),
+				MonitoringInterval:   aws.Int64(5),
+				MonitoringRoleArn:    aws.String(roleArn),
+			},
+		},
+		{
+			name:       "test matching settings",
+			monitoring: &RDSMonitoring{PerformanceInsights: true, MonitoringInterval: 60, MonitoringRoleArn: roleArn},
+			instance: func(i *rds.DBInstance) {
+				i.PerformanceInsightsEnabled = aws.Bool(true)
+				i.PerformanceInsightsRetentionPeriod = aws.Int64(7)
+				i.MonitoringInterval = aws.Int64(60)
+				i.MonitoringRoleArn = aws.String(roleArn)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rdsCfg := buildAvailableCreateInput("test")
+			applyRDSMonitoring(rdsCfg, tt.monitoring)
+			foundInstance := buildAvailableDBInstance("test")[0]
+			if tt.instance != nil {
+				tt.instance(foundInstance)
+			}
+			got, err := buildRDSUpdateStrategy(rdsCfg, foundInstance, buildTestPostgresCR())
+			if err != nil {
+				t.Fatalf("buildRDSUpdateStrategy() unexpected error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildRDSUpdateStrategy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
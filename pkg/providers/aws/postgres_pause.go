@@ -0,0 +1,76 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	errorUtil "github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	rdsInstanceStatusAvailable = "available"
+	rdsInstanceStatusStopped   = "stopped"
+	rdsInstanceStatusStopping  = "stopping"
+)
+
+// reconcileRDSPause stops the rds instance while the postgres is paused and starts it once the postgres is resumed,
+// reporting the progress in the stopped condition of the postgres. It returns true while the instance is stopped or
+// being stopped or started, the instance isn't modified until it's available again. rds starts an instance that was
+// stopped for seven days, it's stopped again on the next reconcile
+func (p *PostgresProvider) reconcileRDSPause(cr *v1alpha1.Postgres, rdsSvc rdsiface.RDSAPI, foundInstance *rds.DBInstance) (bool, croType.StatusMessage, error) {
+	instanceID := aws.StringValue(foundInstance.DBInstanceIdentifier)
+	status := aws.StringValue(foundInstance.DBInstanceStatus)
+	setCondition := func(condStatus metav1.ConditionStatus, reason string, msg string) {
+		meta.SetStatusCondition(&cr.Status.Conditions, metav1.Condition{
+			Type:               providers.StoppedCondition,
+			Status:             condStatus,
+			ObservedGeneration: cr.GetGeneration(),
+			Reason:             reason,
+			Message:            msg,
+		})
+	}
+
+	if cr.Spec.Paused {
+		switch status {
+		case rdsInstanceStatusStopped:
+			msg := fmt.Sprintf("rds instance %s is stopped while the postgres is paused", instanceID)
+			setCondition(metav1.ConditionTrue, providers.StoppedReason, msg)
+			return true, croType.StatusMessage(msg), nil
+		case rdsInstanceStatusStopping:
+			msg := fmt.Sprintf("stopping rds instance %s while the postgres is paused", instanceID)
+			setCondition(metav1.ConditionFalse, providers.StoppingReason, msg)
+			return true, croType.StatusMessage(msg), nil
+		case rdsInstanceStatusAvailable:
+			if _, err := rdsSvc.StopDBInstance(&rds.StopDBInstanceInput{DBInstanceIdentifier: foundInstance.DBInstanceIdentifier}); err != nil {
+				errMsg := fmt.Sprintf("failed to stop rds instance %s", instanceID)
+				return true, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+			}
+			msg := fmt.Sprintf("stopping rds instance %s while the postgres is paused", instanceID)
+			setCondition(metav1.ConditionFalse, providers.StoppingReason, msg)
+			return true, croType.StatusMessage(msg), nil
+		}
+		// an instance is only stopped once it's available
+		return false, croType.StatusEmpty, nil
+	}
+
+	switch status {
+	case rdsInstanceStatusStopped:
+		if _, err := rdsSvc.StartDBInstance(&rds.StartDBInstanceInput{DBInstanceIdentifier: foundInstance.DBInstanceIdentifier}); err != nil {
+			errMsg := fmt.Sprintf("failed to start rds instance %s", instanceID)
+			return true, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+		msg := fmt.Sprintf("starting rds instance %s as the postgres was resumed", instanceID)
+		setCondition(metav1.ConditionFalse, providers.StartingReason, msg)
+		return true, croType.StatusMessage(msg), nil
+	case rdsInstanceStatusAvailable:
+		meta.RemoveStatusCondition(&cr.Status.Conditions, providers.StoppedCondition)
+	}
+	return false, croType.StatusEmpty, nil
+}
@@ -0,0 +1,104 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAWSPostgresProvider_reconcileRDSPause(t *testing.T) {
+	tests := []struct {
+		name        string
+		paused      bool
+		status      string
+		existing    *metav1.Condition
+		want        bool
+		wantStopped bool
+		wantStarted bool
+		wantReason  string
+	}{
+		{
+			name:        "test available instance of a paused postgres is stopped",
+			paused:      true,
+			status:      rdsInstanceStatusAvailable,
+			want:        true,
+			wantStopped: true,
+			wantReason:  providers.StoppingReason,
+		},
+		{
+			name:       "test stopped instance of a paused postgres is reported",
+			paused:     true,
+			status:     rdsInstanceStatusStopped,
+			want:       true,
+			wantReason: providers.StoppedReason,
+		},
+		{
+			name:   "test paused postgres waits for the instance to be available",
+			paused: true,
+			status: "modifying",
+		},
+		{
+			name:        "test stopped instance of a resumed postgres is started",
+			status:      rdsInstanceStatusStopped,
+			existing:    &metav1.Condition{Type: providers.StoppedCondition, Status: metav1.ConditionTrue, Reason: providers.StoppedReason},
+			want:        true,
+			wantStarted: true,
+			wantReason:  providers.StartingReason,
+		},
+		{
+			name:     "test condition is removed once the instance is available",
+			status:   rdsInstanceStatusAvailable,
+			existing: &metav1.Condition{Type: providers.StoppedCondition, Status: metav1.ConditionFalse, Reason: providers.StartingReason},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := buildTestPostgresCR()
+			cr.Spec.Paused = tt.paused
+			if tt.existing != nil {
+				meta.SetStatusCondition(&cr.Status.Conditions, *tt.existing)
+			}
+			var stopped, started bool
+			rdsSvc := buildMockRdsClient(func(mock *mockRdsClient) {
+				mock.stopDBInstanceFn = func(*rds.StopDBInstanceInput) (*rds.StopDBInstanceOutput, error) {
+					stopped = true
+					return &rds.StopDBInstanceOutput{}, nil
+				}
+				mock.startDBInstanceFn = func(*rds.StartDBInstanceInput) (*rds.StartDBInstanceOutput, error) {
+					started = true
+					return &rds.StartDBInstanceOutput{}, nil
+				}
+			})
+			instance := buildAvailableDBInstance("test")[0]
+			instance.DBInstanceStatus = aws.String(tt.status)
+			p := &PostgresProvider{Logger: testLogger}
+			got, _, err := p.reconcileRDSPause(cr, rdsSvc, instance)
+			if err != nil {
+				t.Fatalf("reconcileRDSPause() unexpected error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("reconcileRDSPause() = %v, want %v", got, tt.want)
+			}
+			if stopped != tt.wantStopped || started != tt.wantStarted {
+				t.Errorf("reconcileRDSPause() stopped %v started %v, want %v %v", stopped, started, tt.wantStopped, tt.wantStarted)
+			}
+			cond := meta.FindStatusCondition(cr.Status.Conditions, providers.StoppedCondition)
+			if tt.wantReason == "" {
+				if cond != nil {
+					t.Errorf("reconcileRDSPause() unexpected condition %+v", cond)
+				}
+				return
+			}
+			if cond == nil || cond.Reason != tt.wantReason {
+				t.Errorf("reconcileRDSPause() expected a %s condition, got %+v", tt.wantReason, cond)
+			}
+			if providers.IsStopped(&cr.Status) != tt.paused {
+				t.Errorf("IsStopped() = %v, want %v", providers.IsStopped(&cr.Status), tt.paused)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/annotations"
+	errorUtil "github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// RestorePasswordPendingAnnotation is set on a postgres cr restored from a snapshot until the master password of the
+// restored instance, which is copied from the snapshot, is reset to the password in the credential secret
+const RestorePasswordPendingAnnotation = "integreatly.org/restore-password-pending"
+
+// restoreRDSInstance restores the rds instance from the snapshot referenced by the postgres cr, instead of creating an
+// empty instance
+func (p *PostgresProvider) restoreRDSInstance(ctx context.Context, cr *v1alpha1.Postgres, rdsSvc rdsiface.RDSAPI, rdsCfg *rds.CreateDBInstanceInput) (croType.StatusMessage, error) {
+	snapshotID, err := p.getRestoreSnapshotIdentifier(ctx, cr)
+	if err != nil {
+		errMsg := "failed to find snapshot to restore from"
+		return croType.StatusMessage(fmt.Sprintf("%s: %s", errMsg, err)), errorUtil.Wrap(err, errMsg)
+	}
+	p.Logger.Infof("restoring rds instance from snapshot %s", snapshotID)
+	if _, err := rdsSvc.RestoreDBInstanceFromDBSnapshot(buildRDSRestoreInput(rdsCfg, snapshotID)); err != nil {
+		errMsg := fmt.Sprintf("error restoring rds instance from snapshot %s", snapshotID)
+		return croType.StatusMessage(fmt.Sprintf("%s: %s", errMsg, err)), errorUtil.Wrap(err, errMsg)
+	}
+	annotations.Add(cr, RestorePasswordPendingAnnotation, snapshotID)
+	return croType.StatusMessage(fmt.Sprintf("started rds restore from snapshot %s", snapshotID)), nil
+}
+
+// getRestoreSnapshotIdentifier returns the rds identifier of the snapshot the postgres cr is restored from
+func (p *PostgresProvider) getRestoreSnapshotIdentifier(ctx context.Context, cr *v1alpha1.Postgres) (string, error) {
+	restoreFrom := cr.Spec.RestoreFrom
+	if restoreFrom.SnapshotName == "" {
+		if restoreFrom.SnapshotID == "" {
+			return "", errorUtil.New("one of snapshotName or snapshotID must be set")
+		}
+		return restoreFrom.SnapshotID, nil
+	}
+	snapshot := &v1alpha1.PostgresSnapshot{}
+	if err := p.Client.Get(ctx, types.NamespacedName{Name: restoreFrom.SnapshotName, Namespace: cr.Namespace}, snapshot); err != nil {
+		return "", errorUtil.Wrapf(err, "failed to get postgres snapshot %s", restoreFrom.SnapshotName)
+	}
+	if snapshot.Status.Phase != croType.PhaseComplete || snapshot.Status.SnapshotID == "" {
+		return "", errorUtil.New(fmt.Sprintf("postgres snapshot %s is not complete", restoreFrom.SnapshotName))
+	}
+	return snapshot.Status.SnapshotID, nil
+}
+
+// resetRestoredRDSPassword sets the master password of an instance restored from a snapshot to the password in the
+// credential secret, returning true if the password was reset
+func (p *PostgresProvider) resetRestoredRDSPassword(ctx context.Context, cr *v1alpha1.Postgres, rdsSvc rdsiface.RDSAPI, foundInstance *rds.DBInstance, postgresPass string) (bool, error) {
+	if !annotations.Has(cr, RestorePasswordPendingAnnotation) {
+		return false, nil
+	}
+	if _, err := rdsSvc.ModifyDBInstance(&rds.ModifyDBInstanceInput{
+		DBInstanceIdentifier: foundInstance.DBInstanceIdentifier,
+		MasterUserPassword:   aws.String(postgresPass),
+		ApplyImmediately:     aws.Bool(true),
+	}); err != nil {
+		return false, errorUtil.Wrapf(err, "failed to reset master password of rds instance %s", aws.StringValue(foundInstance.DBInstanceIdentifier))
+	}
+	annotations.Remove(cr, RestorePasswordPendingAnnotation)
+	if err := p.Client.Update(ctx, cr); err != nil {
+		return false, errorUtil.Wrapf(err, "failed to remove %s annotation", RestorePasswordPendingAnnotation)
+	}
+	return true, nil
+}
+
+// buildRDSRestoreInput builds the restore input from the create config, the storage, engine version, master username
+// and database name come from the snapshot, other differences are modified once the instance is available
+func buildRDSRestoreInput(rdsCfg *rds.CreateDBInstanceInput, snapshotID string) *rds.RestoreDBInstanceFromDBSnapshotInput {
+	return &rds.RestoreDBInstanceFromDBSnapshotInput{
+		DBSnapshotIdentifier:    aws.String(snapshotID),
+		DBInstanceIdentifier:    rdsCfg.DBInstanceIdentifier,
+		DBInstanceClass:         rdsCfg.DBInstanceClass,
+		DBSubnetGroupName:       rdsCfg.DBSubnetGroupName,
+		VpcSecurityGroupIds:     rdsCfg.VpcSecurityGroupIds,
+		Engine:                  rdsCfg.Engine,
+		Port:                    rdsCfg.Port,
+		MultiAZ:                 rdsCfg.MultiAZ,
+		AvailabilityZone:        rdsCfg.AvailabilityZone,
+		PubliclyAccessible:      rdsCfg.PubliclyAccessible,
+		AutoMinorVersionUpgrade: rdsCfg.AutoMinorVersionUpgrade,
+		DeletionProtection:      rdsCfg.DeletionProtection,
+		CopyTagsToSnapshot:      rdsCfg.CopyTagsToSnapshot,
+		DBParameterGroupName:    rdsCfg.DBParameterGroupName,
+		OptionGroupName:         rdsCfg.OptionGroupName,
+		StorageType:             rdsCfg.StorageType,
+		Iops:                    rdsCfg.Iops,
+		Tags:                    rdsCfg.Tags,
+	}
+}
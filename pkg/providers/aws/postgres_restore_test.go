@@ -0,0 +1,154 @@
+package aws
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/annotations"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func buildTestRestorePostgresCR(restoreFrom *croType.RestoreFrom) *v1alpha1.Postgres {
+	pg := buildTestPostgresCR()
+	pg.Spec.RestoreFrom = restoreFrom
+	return pg
+}
+
+func buildTestCompletePostgresSnapshotCr() *v1alpha1.PostgresSnapshot {
+	snapshot := buildTestPostgresSnapshotCr()
+	snapshot.Status.Phase = croType.PhaseComplete
+	return snapshot
+}
+
+func TestAWSPostgresProvider_restoreRDSInstance(t *testing.T) {
+	scheme, err := buildTestSchemePostgresql()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	tests := []struct {
+		name           string
+		cr             *v1alpha1.Postgres
+		existing       []runtime.Object
+		wantSnapshotID string
+		wantErr        bool
+	}{
+		{
+			name:           "test instance is restored from the snapshot id",
+			cr:             buildTestRestorePostgresCR(&croType.RestoreFrom{SnapshotID: "test-snapshot-id"}),
+			wantSnapshotID: "test-snapshot-id",
+		},
+		{
+			name:           "test instance is restored from the snapshot of a complete snapshot cr",
+			cr:             buildTestRestorePostgresCR(&croType.RestoreFrom{SnapshotName: "test", SnapshotID: "ignored"}),
+			existing:       []runtime.Object{buildTestCompletePostgresSnapshotCr()},
+			wantSnapshotID: "test-identifier",
+		},
+		{
+			name:     "test error when the snapshot cr is not complete",
+			cr:       buildTestRestorePostgresCR(&croType.RestoreFrom{SnapshotName: "test"}),
+			existing: []runtime.Object{buildTestPostgresSnapshotCr()},
+			wantErr:  true,
+		},
+		{
+			name:    "test error when the snapshot cr does not exist",
+			cr:      buildTestRestorePostgresCR(&croType.RestoreFrom{SnapshotName: "test"}),
+			wantErr: true,
+		},
+		{
+			name:    "test error when no snapshot is referenced",
+			cr:      buildTestRestorePostgresCR(&croType.RestoreFrom{}),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &PostgresProvider{
+				Client: fake.NewFakeClientWithScheme(scheme, append(tt.existing, tt.cr)...),
+				Logger: testLogger,
+			}
+			var gotInput *rds.RestoreDBInstanceFromDBSnapshotInput
+			rdsSvc := buildMockRdsClient(func(rdsClient *mockRdsClient) {
+				rdsClient.restoreDBInstanceFromDBSnapshotFn = func(input *rds.RestoreDBInstanceFromDBSnapshotInput) (*rds.RestoreDBInstanceFromDBSnapshotOutput, error) {
+					gotInput = input
+					return &rds.RestoreDBInstanceFromDBSnapshotOutput{}, nil
+				}
+			})
+			rdsCfg := &rds.CreateDBInstanceInput{
+				DBInstanceIdentifier: aws.String("test-instance"),
+				DBInstanceClass:      aws.String(defaultAwsDBInstanceClass),
+			}
+			_, err := p.restoreRDSInstance(context.TODO(), tt.cr, rdsSvc, rdsCfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("restoreRDSInstance() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if gotInput != nil {
+					t.Error("restoreRDSInstance() restored instance on error")
+				}
+				return
+			}
+			if gotInput == nil {
+				t.Fatal("restoreRDSInstance() did not restore instance")
+			}
+			if aws.StringValue(gotInput.DBSnapshotIdentifier) != tt.wantSnapshotID {
+				t.Errorf("restoreRDSInstance() snapshot = %s, want %s", aws.StringValue(gotInput.DBSnapshotIdentifier), tt.wantSnapshotID)
+			}
+			if aws.StringValue(gotInput.DBInstanceIdentifier) != "test-instance" || aws.StringValue(gotInput.DBInstanceClass) != defaultAwsDBInstanceClass {
+				t.Errorf("restoreRDSInstance() create config not used %+v", gotInput)
+			}
+			if !annotations.Has(tt.cr, RestorePasswordPendingAnnotation) {
+				t.Errorf("restoreRDSInstance() %s annotation not set", RestorePasswordPendingAnnotation)
+			}
+		})
+	}
+}
+
+func TestAWSPostgresProvider_resetRestoredRDSPassword(t *testing.T) {
+	scheme, err := buildTestSchemePostgresql()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	tests := []struct {
+		name string
+		cr   *v1alpha1.Postgres
+		want bool
+	}{
+		{
+			name: "test password is not reset for an instance which wasn't restored",
+			cr:   buildTestPostgresCR(),
+			want: false,
+		},
+		{
+			name: "test password is reset for a restored instance",
+			cr: func() *v1alpha1.Postgres {
+				pg := buildTestPostgresCR()
+				annotations.Add(pg, RestorePasswordPendingAnnotation, "test-snapshot-id")
+				return pg
+			}(),
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &PostgresProvider{
+				Client: fake.NewFakeClientWithScheme(scheme, tt.cr),
+				Logger: testLogger,
+			}
+			got, err := p.resetRestoredRDSPassword(context.TODO(), tt.cr, buildMockRdsClient(nil), buildAvailableDBInstance("test-instance")[0], "test")
+			if err != nil {
+				t.Fatalf("resetRestoredRDSPassword() unexpected error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("resetRestoredRDSPassword() = %v, want %v", got, tt.want)
+			}
+			if annotations.Has(tt.cr, RestorePasswordPendingAnnotation) {
+				t.Errorf("resetRestoredRDSPassword() %s annotation not removed", RestorePasswordPendingAnnotation)
+			}
+		})
+	}
+}
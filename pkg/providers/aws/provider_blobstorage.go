@@ -148,13 +148,33 @@ func (p *BlobStorageProvider) CreateStorage(ctx context.Context, bs *v1alpha1.Bl
 	}
 	s3Client := s3.New(sess)
 
+	// resolve the customer managed key the bucket is encrypted with, if the strategy configures one
+	kmsKeyARN, err := reconcileKMSKey(ctx, p.Client, newKMSClient(sess), stratCfg.Encryption)
+	if err != nil {
+		errMsg := "failed to reconcile kms key to encrypt s3 bucket"
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+
+	return p.createStorage(ctx, bs, s3Client, bucketCreateCfg, stratCfg, kmsKeyARN)
+}
+
+// createStorage reconciles the bucket with the s3 client, along with its lifecycle rules, its tags and the credentials
+// of its end-user
+func (p *BlobStorageProvider) createStorage(ctx context.Context, bs *v1alpha1.BlobStorage, s3Client s3iface.S3API, bucketCreateCfg *s3.CreateBucketInput, stratCfg *StrategyConfig, kmsKeyARN string) (*providers.BlobStorageInstance, croType.StatusMessage, error) {
 	// create bucket if it doesn't already exist, if it does exist then use the existing bucket
 	p.Logger.Infof("reconciling aws s3 bucket %s", *bucketCreateCfg.Bucket)
-	msg, err := p.reconcileBucketCreate(ctx, bs, s3Client, bucketCreateCfg)
+	msg, err := p.reconcileBucketCreate(ctx, bs, s3Client, bucketCreateCfg, kmsKeyARN)
 	if err != nil {
 		return nil, msg, errorUtil.Wrapf(err, string(msg))
 	}
 
+	// apply the lifecycle rules of the strategy, reverting any changes made to them outside the operator
+	p.Logger.Infof("reconciling lifecycle configuration of aws s3 bucket %s", *bucketCreateCfg.Bucket)
+	if err = reconcileBucketLifecycle(s3Client, *bucketCreateCfg.Bucket, stratCfg.Lifecycle); err != nil {
+		errMsg := fmt.Sprintf("failed to reconcile lifecycle configuration of s3 bucket %s", *bucketCreateCfg.Bucket)
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+
 	// create the credentials to be used by the end-user, whoever created the blobstorage instance
 	endUserCredsName := buildEndUserCredentialsNameFromBucket(*bucketCreateCfg.Bucket)
 	p.Logger.Infof("creating end-user credentials with name %s for managing s3 bucket %s", endUserCredsName, *bucketCreateCfg.Bucket)
@@ -182,6 +202,7 @@ func (p *BlobStorageProvider) CreateStorage(ctx context.Context, bs *v1alpha1.Bl
 				CredentialKeyID:     endUserCreds.AccessKeyID,
 				CredentialSecretKey: endUserCreds.SecretAccessKey,
 			},
+			Artifacts: buildAccessKeyArtifacts(endUserCredsName, endUserCreds),
 		}
 	}
 
@@ -192,6 +213,8 @@ func (p *BlobStorageProvider) CreateStorage(ctx context.Context, bs *v1alpha1.Bl
 		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 	}
 
+	bs.Status.CloudResource = buildBucketCloudResourceStatus(*bucketCreateCfg.Bucket, stratCfg.Region)
+	bs.Status.CloudResource.KmsKeyARN = kmsKeyARN
 	p.Logger.Infof("creation handler for blob storage instance %s in namespace %s finished successfully", bs.Name, bs.Namespace)
 	return bsi, msg, nil
 }
@@ -282,6 +305,12 @@ func (p *BlobStorageProvider) reconcileBucketDelete(ctx context.Context, bs *v1a
 	}
 
 	if *bucketDeleteCfg.ForceBucketDeletion || bucketSize == 0 {
+		// incomplete multipart uploads, e.g. of an interrupted copy, are aborted so their parts aren't left behind
+		if err := abortMultipartUploads(s3svc, bucketCfg); err != nil {
+			errMsg := fmt.Sprintf("unable to abort multipart uploads of bucket : %q", *bucketCfg.Bucket)
+			return croType.StatusMessage(errMsg), errorUtil.Wrapf(err, errMsg)
+		}
+
 		if err := emptyBucket(s3svc, bucketCfg); err != nil {
 			errMsg := fmt.Sprintf("unable to empty bucket : %q", *bucketCfg.Bucket)
 			return croType.StatusMessage(errMsg), errorUtil.Wrapf(err, errMsg)
@@ -377,6 +406,37 @@ func emptyBucket(s3svc s3iface.S3API, bucketCfg *s3.CreateBucketInput) error {
 	return nil
 }
 
+// abortMultipartUploads aborts every incomplete multipart upload of the bucket, freeing the uploaded parts
+func abortMultipartUploads(s3svc s3iface.S3API, bucketCfg *s3.CreateBucketInput) error {
+	input := &s3.ListMultipartUploadsInput{Bucket: bucketCfg.Bucket}
+	for {
+		resp, err := s3svc.ListMultipartUploads(input)
+		if err != nil {
+			s3err, isAwsErr := err.(awserr.Error)
+			if isAwsErr && s3err.Code() == s3.ErrCodeNoSuchBucket {
+				return nil
+			}
+			return errorUtil.Wrapf(err, "unable to list multipart uploads of bucket %q", *bucketCfg.Bucket)
+		}
+		for _, upload := range resp.Uploads {
+			_, err := s3svc.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+				Bucket:   bucketCfg.Bucket,
+				Key:      upload.Key,
+				UploadId: upload.UploadId,
+			})
+			s3err, isAwsErr := err.(awserr.Error)
+			if err != nil && (!isAwsErr || s3err.Code() != s3.ErrCodeNoSuchUpload) {
+				return errorUtil.Wrapf(err, "unable to abort multipart upload %s of bucket %q", aws.StringValue(upload.UploadId), *bucketCfg.Bucket)
+			}
+		}
+		if !aws.BoolValue(resp.IsTruncated) {
+			return nil
+		}
+		input.KeyMarker = resp.NextKeyMarker
+		input.UploadIdMarker = resp.NextUploadIdMarker
+	}
+}
+
 func getBucketSize(s3svc s3iface.S3API, bucketCfg *s3.CreateBucketInput) (int, error) {
 	// get bucket items
 	resp, err := s3svc.ListObjectsV2(&s3.ListObjectsV2Input{Bucket: aws.String(*bucketCfg.Bucket)})
@@ -387,7 +447,7 @@ func getBucketSize(s3svc s3iface.S3API, bucketCfg *s3.CreateBucketInput) (int, e
 	return len(resp.Contents), nil
 }
 
-func (p *BlobStorageProvider) reconcileBucketCreate(ctx context.Context, bs *v1alpha1.BlobStorage, s3svc s3iface.S3API, bucketCfg *s3.CreateBucketInput) (croType.StatusMessage, error) {
+func (p *BlobStorageProvider) reconcileBucketCreate(ctx context.Context, bs *v1alpha1.BlobStorage, s3svc s3iface.S3API, bucketCfg *s3.CreateBucketInput, kmsKeyARN string) (croType.StatusMessage, error) {
 	// the aws access key can sometimes still not be registered in aws on first try, so loop
 	p.Logger.Infof("listing existing aws s3 buckets")
 	buckets, err := getS3buckets(s3svc)
@@ -409,7 +469,7 @@ func (p *BlobStorageProvider) reconcileBucketCreate(ctx context.Context, bs *v1a
 	defer p.exposeBlobStorageMetrics(ctx, bs)
 
 	if foundBucket != nil {
-		if err = reconcileS3BucketSettings(aws.StringValue(foundBucket.Name), s3svc); err != nil {
+		if err = reconcileS3BucketSettings(aws.StringValue(foundBucket.Name), s3svc, kmsKeyARN); err != nil {
 			errMsg := fmt.Sprintf("failed to set s3 bucket settings %s", *foundBucket.Name)
 			return croType.StatusMessage(errMsg), errorUtil.Wrapf(err, errMsg)
 		}
@@ -440,7 +500,7 @@ func (p *BlobStorageProvider) reconcileBucketCreate(ctx context.Context, bs *v1a
 		return croType.StatusMessage(errMsg), errorUtil.Wrapf(err, errMsg)
 	}
 
-	if err = reconcileS3BucketSettings(aws.StringValue(bucketCfg.Bucket), s3svc); err != nil {
+	if err = reconcileS3BucketSettings(aws.StringValue(bucketCfg.Bucket), s3svc, kmsKeyARN); err != nil {
 		errMsg := fmt.Sprintf("failed to set s3 bucket settings on bucket creation %s", aws.StringValue(bucketCfg.Bucket))
 		return croType.StatusMessage(errMsg), errorUtil.Wrapf(err, errMsg)
 	}
@@ -465,7 +525,9 @@ func getS3buckets(s3svc s3iface.S3API) ([]*s3.Bucket, error) {
 	return existingBuckets, nil
 }
 
-func reconcileS3BucketSettings(bucket string, s3svc s3iface.S3API) error {
+// reconcileS3BucketSettings blocks public access to the bucket and encrypts it by default, with the kms key if set or
+// with s3 managed keys otherwise
+func reconcileS3BucketSettings(bucket string, s3svc s3iface.S3API, kmsKeyARN string) error {
 	_, err := s3svc.PutPublicAccessBlock(&s3.PutPublicAccessBlockInput{
 		Bucket: aws.String(bucket),
 		PublicAccessBlockConfiguration: &s3.PublicAccessBlockConfiguration{
@@ -478,16 +540,25 @@ func reconcileS3BucketSettings(bucket string, s3svc s3iface.S3API) error {
 	if err != nil {
 		return errorUtil.Wrapf(err, "failed to set client access settings on bucket %s", bucket)
 	}
+	encryptionRule := &s3.ServerSideEncryptionRule{
+		ApplyServerSideEncryptionByDefault: &s3.ServerSideEncryptionByDefault{
+			SSEAlgorithm: aws.String(defaultEncryptionSSEAlgorithm),
+		},
+	}
+	if kmsKeyARN != "" {
+		encryptionRule = &s3.ServerSideEncryptionRule{
+			ApplyServerSideEncryptionByDefault: &s3.ServerSideEncryptionByDefault{
+				SSEAlgorithm:   aws.String(s3.ServerSideEncryptionAwsKms),
+				KMSMasterKeyID: aws.String(kmsKeyARN),
+			},
+			// bucket keys reduce the requests s3 makes to kms, and the cost of them
+			BucketKeyEnabled: aws.Bool(true),
+		}
+	}
 	_, err = s3svc.PutBucketEncryption(&s3.PutBucketEncryptionInput{
 		Bucket: aws.String(bucket),
 		ServerSideEncryptionConfiguration: &s3.ServerSideEncryptionConfiguration{
-			Rules: []*s3.ServerSideEncryptionRule{
-				{
-					ApplyServerSideEncryptionByDefault: &s3.ServerSideEncryptionByDefault{
-						SSEAlgorithm: aws.String(defaultEncryptionSSEAlgorithm),
-					},
-				},
-			},
+			Rules: []*s3.ServerSideEncryptionRule{encryptionRule},
 		},
 	})
 	if err != nil {
@@ -526,6 +597,11 @@ func (p *BlobStorageProvider) getS3BucketConfig(ctx context.Context, bs *v1alpha
 	if err != nil {
 		return nil, nil, nil, errorUtil.Wrap(err, "failed to read aws strategy config")
 	}
+	// values of the tier changed by the blob storage itself
+	stratCfg.CreateStrategy, err = providers.MergeProviderConfig(stratCfg.CreateStrategy, bs.Spec.ProviderConfig)
+	if err != nil {
+		return nil, nil, nil, errorUtil.Wrap(err, "failed to merge provider config of blob storage")
+	}
 
 	defRegion, err := GetRegionFromStrategyOrDefault(ctx, p.Client, stratCfg)
 	if err != nil {
@@ -7,6 +7,7 @@ import (
 	moqClient "github.com/integr8ly/cloud-resource-operator/pkg/client/fake"
 	k8sTypes "k8s.io/apimachinery/pkg/types"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
@@ -24,6 +25,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
 	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -42,6 +44,11 @@ type mockS3Svc struct {
 	wantErrDelete     bool
 	wantErrWaitDelete bool
 	bucketNames       []string
+	lifecycleRules    []*s3.LifecycleRule
+	lifecyclePuts     int
+	lifecycleDeletes  int
+	multipartUploads  []*s3.MultipartUpload
+	abortedUploads    []string
 }
 
 func buildTestScheme() (*runtime.Scheme, error) {
@@ -119,6 +126,34 @@ func (s *mockS3Svc) PutPublicAccessBlock(*s3.PutPublicAccessBlockInput) (*s3.Put
 	return &s3.PutPublicAccessBlockOutput{}, nil
 }
 
+func (s *mockS3Svc) GetBucketLifecycleConfiguration(*s3.GetBucketLifecycleConfigurationInput) (*s3.GetBucketLifecycleConfigurationOutput, error) {
+	if s.lifecycleRules == nil {
+		return nil, awserr.New(s3ErrCodeNoSuchLifecycleConfiguration, "The lifecycle configuration does not exist", nil)
+	}
+	return &s3.GetBucketLifecycleConfigurationOutput{Rules: s.lifecycleRules}, nil
+}
+
+func (s *mockS3Svc) PutBucketLifecycleConfiguration(input *s3.PutBucketLifecycleConfigurationInput) (*s3.PutBucketLifecycleConfigurationOutput, error) {
+	s.lifecyclePuts++
+	s.lifecycleRules = input.LifecycleConfiguration.Rules
+	return &s3.PutBucketLifecycleConfigurationOutput{}, nil
+}
+
+func (s *mockS3Svc) DeleteBucketLifecycle(*s3.DeleteBucketLifecycleInput) (*s3.DeleteBucketLifecycleOutput, error) {
+	s.lifecycleDeletes++
+	s.lifecycleRules = nil
+	return &s3.DeleteBucketLifecycleOutput{}, nil
+}
+
+func (s *mockS3Svc) ListMultipartUploads(*s3.ListMultipartUploadsInput) (*s3.ListMultipartUploadsOutput, error) {
+	return &s3.ListMultipartUploadsOutput{Uploads: s.multipartUploads}, nil
+}
+
+func (s *mockS3Svc) AbortMultipartUpload(input *s3.AbortMultipartUploadInput) (*s3.AbortMultipartUploadOutput, error) {
+	s.abortedUploads = append(s.abortedUploads, aws.StringValue(input.UploadId))
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
 func (s *mockS3Svc) PutBucketEncryption(*s3.PutBucketEncryptionInput) (*s3.PutBucketEncryptionOutput, error) {
 	return &s3.PutBucketEncryptionOutput{}, nil
 }
@@ -203,7 +238,7 @@ func TestBlobStorageProvider_reconcileBucket(t *testing.T) {
 				ConfigManager:     tt.fields.ConfigManager,
 			}
 			dummyBlobStorage := &v1alpha1.BlobStorage{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test", ResourceVersion: fakeResourceVersion}}
-			if _, err := p.reconcileBucketCreate(tt.args.ctx, dummyBlobStorage, tt.args.s3svc, tt.args.bucketCfg); (err != nil) != tt.wantErr {
+			if _, err := p.reconcileBucketCreate(tt.args.ctx, dummyBlobStorage, tt.args.s3svc, tt.args.bucketCfg, ""); (err != nil) != tt.wantErr {
 				t.Errorf("reconcileBucket() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -296,6 +331,21 @@ func TestBlobStorageProvider_reconcileBucketDelete(t *testing.T) {
 	}
 }
 
+func TestAbortMultipartUploads(t *testing.T) {
+	s3svc := &mockS3Svc{
+		multipartUploads: []*s3.MultipartUpload{
+			{Key: aws.String("backup-1"), UploadId: aws.String("upload-1")},
+			{Key: aws.String("backup-2"), UploadId: aws.String("upload-2")},
+		},
+	}
+	if err := abortMultipartUploads(s3svc, &s3.CreateBucketInput{Bucket: aws.String("test")}); err != nil {
+		t.Fatalf("abortMultipartUploads() error = %v", err)
+	}
+	if !reflect.DeepEqual(s3svc.abortedUploads, []string{"upload-1", "upload-2"}) {
+		t.Errorf("abortMultipartUploads() aborted = %v, want [upload-1 upload-2]", s3svc.abortedUploads)
+	}
+}
+
 func TestBlobStorageProvider_GetReconcileTime(t *testing.T) {
 	type args struct {
 		b *v1alpha1.BlobStorage
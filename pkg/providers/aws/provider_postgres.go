@@ -3,6 +3,7 @@ package aws
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -14,11 +15,11 @@ import (
 
 	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
 	"github.com/integr8ly/cloud-resource-operator/pkg/annotations"
+	awsclient "github.com/integr8ly/cloud-resource-operator/pkg/providers/aws/client"
 
 	"k8s.io/apimachinery/pkg/types"
 
 	v1 "k8s.io/api/core/v1"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"k8s.io/apimachinery/pkg/util/wait"
 
@@ -46,6 +47,7 @@ const (
 	defaultAwsEngine                     = "postgres"
 	defaultAwsEngineVersion              = "13.4"
 	defaultAwsIdentifierLength           = 40
+	defaultAwsSnapshotIdentifierLength   = 255
 	defaultAwsMaxAllocatedStorage        = 100
 	defaultAwsMultiAZ                    = true
 	defaultAwsPostgresDatabase           = "postgres"
@@ -84,6 +86,17 @@ var (
 	}
 )
 
+// RDSMasterPasswordConstraints are the rules rds imposes on the master password of an instance
+var RDSMasterPasswordConstraints = resources.PasswordConstraints{Name: "rds master passwords", MinLength: 8, MaxLength: 128, Forbidden: "/\"@ "}
+
+// generateRDSPassword generates a master password for an rds instance following the password policy of the operator
+func generateRDSPassword() (string, error) {
+	return resources.CurrentPasswordPolicy.Generate(RDSMasterPasswordConstraints)
+}
+
+// defaultSupportedPostgresVersions maps the postgres versions a cr can request to rds engine versions
+var defaultSupportedPostgresVersions = providers.SupportedVersions{"10": "10.18", "13": defaultAwsEngineVersion}
+
 var _ providers.PostgresProvider = (*PostgresProvider)(nil)
 
 type PostgresProvider struct {
@@ -139,6 +152,15 @@ func (p *PostgresProvider) ReconcilePostgres(ctx context.Context, pg *v1alpha1.P
 		msg := "failed to retrieve aws rds cluster config for instance"
 		return nil, croType.StatusMessage(msg), errorUtil.Wrapf(err, msg)
 	}
+	// a version requested by the cr replaces the engine version of the strategy
+	engineVersion, err := providers.ResolveVersion(pg.Spec.Version, strategyConfig.SupportedVersions, defaultSupportedPostgresVersions)
+	if err != nil {
+		msg := fmt.Sprintf("unsupported postgres version for instance %s: %v", pg.Name, err)
+		return nil, croType.StatusMessage(msg), errorUtil.Wrap(err, "failed to resolve postgres version")
+	}
+	if engineVersion != "" {
+		rdsCfg.EngineVersion = aws.String(engineVersion)
+	}
 
 	// create the credentials to be used by the aws resource providers, not to be used by end-user
 	providerCreds, err := p.CredentialManager.ReconcileProviderCredentials(ctx, pg.Namespace)
@@ -147,13 +169,13 @@ func (p *PostgresProvider) ReconcilePostgres(ctx context.Context, pg *v1alpha1.P
 		return nil, croType.StatusMessage(msg), errorUtil.Wrap(err, msg)
 	}
 
-	// create credentials secret
+	// create credentials secret, the password is only generated once even if reconciles race to create it
 	sec := buildDefaultRDSSecret(pg)
-	or, err := controllerutil.CreateOrUpdate(ctx, p.Client, sec, func() error {
-		return nil
-	})
-	if err != nil {
-		errMsg := fmt.Sprintf("failed to create or update secret %s, action was %s", sec.Name, or)
+	if _, err := resources.EnsureCredentialSecret(ctx, p.Client, sec, map[string]resources.SecretValueGenerator{
+		defaultPostgresUserKey:     resources.StaticSecretValue(defaultAwsPostgresUser),
+		defaultPostgresPasswordKey: generateRDSPassword,
+	}); err != nil {
+		errMsg := fmt.Sprintf("failed to create or update secret %s", sec.Name)
 		return nil, croType.StatusMessage(errMsg), errorUtil.Wrapf(err, errMsg)
 	}
 
@@ -165,7 +187,8 @@ func (p *PostgresProvider) ReconcilePostgres(ctx context.Context, pg *v1alpha1.P
 	}
 
 	// check is a standalone network is required
-	networkManager := NewNetworkManager(sess, p.Client, logger, isSTSCluster(ctx, p.Client))
+	discovery := getNetworkDiscovery(ctx, p.ConfigManager, pg.Spec.Tier, logger)
+	networkManager := NewNetworkManager(sess, p.Client, logger, isSTSCluster(ctx, p.Client), discovery)
 	isEnabled, err := networkManager.IsEnabled(ctx)
 	if err != nil {
 		errMsg := "failed to check cluster vpc subnets"
@@ -213,9 +236,24 @@ func (p *PostgresProvider) ReconcilePostgres(ctx context.Context, pg *v1alpha1.P
 		logger.Infof("created security group %s", aws.StringValue(securityGroup.StandaloneSecurityGroup.GroupName))
 	}
 
-	session := rds.New(sess)
+	// encrypt the storage of the instance with the customer managed key, if the strategy configures one
+	kmsKeyARN, err := reconcileKMSKey(ctx, p.Client, newKMSClient(sess), strategyConfig.Encryption)
+	if err != nil {
+		errMsg := "failed to reconcile kms key to encrypt rds instance"
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	if kmsKeyARN != "" {
+		rdsCfg.StorageEncrypted = aws.Bool(true)
+		rdsCfg.KmsKeyId = aws.String(kmsKeyARN)
+	}
+
+	session := awsclient.NewRDS(sess)
 	// create the aws RDS instance
-	postgres, reconcileStatus, err := p.reconcileRDSInstance(ctx, pg, session, ec2.New(sess), rdsCfg, isEnabled)
+	postgres, reconcileStatus, err := p.reconcileRDSInstance(ctx, pg, session, ec2.New(sess), rdsCfg, isEnabled, rdsReconcileOptions{
+		discovery:        discovery,
+		engineUpgrade:    strategyConfig.EngineUpgrade,
+		reservedCapacity: strategyConfig.ReservedCapacity,
+	})
 	if err != nil {
 		errMsg := "failed to reconcile rds instance"
 		return nil, reconcileStatus, errorUtil.Wrap(err, errMsg)
@@ -242,11 +280,24 @@ func (p *PostgresProvider) ReconcilePostgres(ctx context.Context, pg *v1alpha1.P
 		return nil, message, nil
 	}
 
+	// connect with an iam authentication token in place of the master password once the instance allows it
+	if pdd, ok := postgres.DeploymentDetails.(*providers.PostgresDeploymentDetails); ok {
+		setRDSIAMAuthDetails(pdd, foundInstance, strategyConfig.Region, strategyConfig.IAMAuthentication)
+	}
+
 	return postgres, reconcileStatus, nil
 
 }
 
-func (p *PostgresProvider) reconcileRDSInstance(ctx context.Context, cr *v1alpha1.Postgres, rdsSvc rdsiface.RDSAPI, ec2Svc ec2iface.EC2API, rdsCfg *rds.CreateDBInstanceInput, standaloneNetworkExists bool) (*providers.PostgresInstance, croType.StatusMessage, error) {
+// rdsReconcileOptions are the optional inputs of reconciling an rds instance, the zero value reconciles the instance
+// without network discovery, engine upgrade or reserved capacity config
+type rdsReconcileOptions struct {
+	discovery        *NetworkDiscovery
+	engineUpgrade    *EngineUpgrade
+	reservedCapacity *ReservedCapacity
+}
+
+func (p *PostgresProvider) reconcileRDSInstance(ctx context.Context, cr *v1alpha1.Postgres, rdsSvc rdsiface.RDSAPI, ec2Svc ec2iface.EC2API, rdsCfg *rds.CreateDBInstanceInput, standaloneNetworkExists bool, opts rdsReconcileOptions) (*providers.PostgresInstance, croType.StatusMessage, error) {
 	logger := p.Logger.WithField("action", "reconcileRDSInstance")
 	// the aws access key can sometimes still not be registered in aws on first try, so loop
 	pi, err := getRDSInstances(rdsSvc)
@@ -263,13 +314,13 @@ func (p *PostgresProvider) reconcileRDSInstance(ctx context.Context, cr *v1alpha
 	// standaloneNetworkExists if no bundled resources are found in the cluster vpc
 	if !standaloneNetworkExists {
 		// setup networking in cluster vpc rds vpc
-		if err := p.configureRDSVpc(ctx, rdsSvc, ec2Svc); err != nil {
+		if err := p.configureRDSVpc(ctx, rdsSvc, ec2Svc, opts.discovery); err != nil {
 			msg := "error setting up resource vpc"
 			return nil, croType.StatusMessage(msg), errorUtil.Wrap(err, msg)
 		}
 
 		// setup security group for cluster vpc
-		if err := configureSecurityGroup(ctx, p.Client, ec2Svc, logger); err != nil {
+		if err := configureSecurityGroup(ctx, p.Client, ec2Svc, opts.discovery, logger); err != nil {
 			msg := "error setting up security group"
 			return nil, croType.StatusMessage(msg), errorUtil.Wrap(err, msg)
 		}
@@ -289,11 +340,19 @@ func (p *PostgresProvider) reconcileRDSInstance(ctx context.Context, cr *v1alpha
 	}
 
 	// verify and build rds create config
+	opts.discovery.applyRDSSubnetGroupName(rdsCfg)
 	if err := p.buildRDSCreateStrategy(ctx, cr, ec2Svc, rdsCfg, postgresPass); err != nil {
 		msg := "failed to build and verify aws rds instance configuration"
 		return nil, croType.StatusMessage(msg), errorUtil.Wrap(err, msg)
 	}
 
+	// an instance provisioned with a class covered by reserved capacity keeps it
+	strategyClass := aws.StringValue(rdsCfg.DBInstanceClass)
+	inUse := countRDSInstanceClasses(pi)
+	if class := cr.Annotations[ReservedInstanceClassAnnotation]; class != "" {
+		rdsCfg.DBInstanceClass = aws.String(class)
+	}
+
 	// check if the cluster has already been created
 	foundInstance, err := getFoundInstance(pi, rdsCfg)
 
@@ -317,15 +376,62 @@ func (p *PostgresProvider) reconcileRDSInstance(ctx context.Context, cr *v1alpha
 		if foundInstance.EngineVersion != nil && cr.Status.Version != *foundInstance.EngineVersion {
 			cr.Status.Version = *foundInstance.EngineVersion
 		}
+		// report the progress of an upgrade to the engine version of the strategy
+		setRDSEngineUpgradeCondition(cr, rdsCfg, foundInstance)
 		if *foundInstance.DBInstanceStatus == "failed" {
 			logger.Error(msg)
 			return nil, croType.StatusMessage(msg), errorUtil.New(msg)
 		}
+		// stop the instance while the postgres is paused, and start it once it's resumed
+		pauseHandled, pauseMsg, err := p.reconcileRDSPause(cr, rdsSvc, foundInstance)
+		if err != nil {
+			return nil, pauseMsg, err
+		}
+		if pauseHandled {
+			logger.Info(pauseMsg)
+			return nil, pauseMsg, nil
+		}
 		if *foundInstance.DBInstanceStatus != "available" {
 			logger.Infof(msg)
 			return nil, croType.StatusMessage(fmt.Sprintf("reconcileRDSInstance() in progress, current aws rds resource status is %s", *foundInstance.DBInstanceStatus)), nil
 		}
 
+		// an instance restored from a snapshot has the master password of the snapshot
+		passwordReset, err := p.resetRestoredRDSPassword(ctx, cr, rdsSvc, foundInstance, postgresPass)
+		if err != nil {
+			errMsg := fmt.Sprintf("failed to reset password of restored rds instance: %s", *foundInstance.DBInstanceIdentifier)
+			return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+		if passwordReset {
+			statusMsg := fmt.Sprintf("reset master password of restored rds instance: %s", *foundInstance.DBInstanceIdentifier)
+			logger.Info(statusMsg)
+			return nil, croType.StatusMessage(statusMsg), nil
+		}
+
+		// replace the master password when a credential rotation is due
+		rotating, rotationMsg, err := p.rotateRDSCredentials(ctx, cr, rdsSvc, foundInstance, credSec)
+		if err != nil {
+			return nil, rotationMsg, errorUtil.Wrap(err, "failed to rotate rds credentials")
+		}
+		if rotating {
+			logger.Info(rotationMsg)
+			return nil, rotationMsg, nil
+		}
+		if rotationMsg != "" {
+			logger.Info(rotationMsg)
+		}
+		postgresPass = string(credSec.Data[defaultPostgresPasswordKey])
+
+		// snapshot the instance before its engine version is upgraded
+		snapshotting, snapshotMsg, err := p.reconcileRDSPreUpgradeSnapshot(ctx, cr, rdsSvc, rdsCfg, foundInstance, opts.engineUpgrade)
+		if err != nil {
+			return nil, snapshotMsg, errorUtil.Wrap(err, "failed to snapshot rds instance before engine upgrade")
+		}
+		if snapshotting {
+			logger.Info(snapshotMsg)
+			return nil, snapshotMsg, nil
+		}
+
 		// check if found instance and user strategy differs, and modify instance
 		logger.Infof("found existing rds instance: %s", *foundInstance.DBInstanceIdentifier)
 		mi, err := buildRDSUpdateStrategy(rdsCfg, foundInstance, cr)
@@ -334,6 +440,10 @@ func (p *PostgresProvider) reconcileRDSInstance(ctx context.Context, cr *v1alpha
 			return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 		}
 		if mi != nil {
+			// the strategy can apply engine upgrades immediately rather than in the maintenance window
+			if mi.EngineVersion != nil && opts.engineUpgrade != nil && opts.engineUpgrade.ApplyImmediately {
+				mi.ApplyImmediately = aws.Bool(true)
+			}
 			_, err := rdsSvc.ModifyDBInstance(mi)
 			if err != nil {
 				errMsg := fmt.Sprintf("error experienced trying to modify db instance: %s", *foundInstance.DBInstanceIdentifier)
@@ -352,8 +462,41 @@ func (p *PostgresProvider) reconcileRDSInstance(ctx context.Context, cr *v1alpha
 			}
 		}
 
+		// allow the external access source ranges to connect to the instance
+		cidrs, err := providers.ParseExternalAccess(cr.Spec.ExternalAccess)
+		if err != nil {
+			errMsg := "invalid external access"
+			return nil, croType.StatusMessage(fmt.Sprintf("%s: %s", errMsg, err)), errorUtil.Wrap(err, errMsg)
+		}
+		externalAccessMsg, err := p.reconcileRDSExternalAccess(ctx, cr, rdsSvc, ec2Svc, foundInstance, cidrs, logger)
+		if err != nil {
+			return nil, externalAccessMsg, errorUtil.Wrap(err, string(externalAccessMsg))
+		}
+		var endpoint string
+		if len(cidrs) > 0 && foundInstance.Endpoint != nil {
+			endpoint = fmt.Sprintf("%s:%d", aws.StringValue(foundInstance.Endpoint.Address), aws.Int64Value(foundInstance.Endpoint.Port))
+		}
+		providers.SetExternalAccessStatus(logger, providers.PostgresResourceType, cr, &cr.Status, cidrs, endpoint, time.Now())
+		setOnDemandPricingCondition(&cr.Status, cr.Generation, opts.reservedCapacity, strategyClass, aws.StringValue(foundInstance.DBInstanceClass), 1, inUse)
+		if externalAccessMsg != croType.StatusEmpty {
+			logger.Info(externalAccessMsg)
+			return nil, externalAccessMsg, nil
+		}
+
 		msg = fmt.Sprintf("rds instance %s is as expected", *foundInstance.DBInstanceIdentifier)
 		logger.Infof(msg)
+		if foundInstance.DBSubnetGroup != nil {
+			var subnetIDs []string
+			for _, sub := range foundInstance.DBSubnetGroup.Subnets {
+				subnetIDs = append(subnetIDs, aws.StringValue(sub.SubnetIdentifier))
+			}
+			cr.Status.Network = buildNetworkStatus(foundInstance.DBSubnetGroup.VpcId, subnetIDs)
+		}
+		cr.Status.CloudResource = buildCloudResourceStatus(foundInstance.DBInstanceIdentifier, foundInstance.DBInstanceArn, foundInstance.Endpoint.Address, foundInstance.Endpoint.Port)
+		cr.Status.CloudResource.KmsKeyARN = aws.StringValue(foundInstance.KmsKeyId)
+		if rdsCfg.KmsKeyId != nil && aws.StringValue(rdsCfg.KmsKeyId) != aws.StringValue(foundInstance.KmsKeyId) {
+			logger.Warnf("rds instance %s is encrypted with kms key %s instead of %s, the key of an existing instance can't be changed", *foundInstance.DBInstanceIdentifier, aws.StringValue(foundInstance.KmsKeyId), aws.StringValue(rdsCfg.KmsKeyId))
+		}
 		pdd := &providers.PostgresDeploymentDetails{
 			Username: *foundInstance.MasterUsername,
 			Password: postgresPass,
@@ -361,8 +504,13 @@ func (p *PostgresProvider) reconcileRDSInstance(ctx context.Context, cr *v1alpha
 			Database: *foundInstance.DBName,
 			Port:     int(*foundInstance.Endpoint.Port),
 		}
+		// estimate the cost of the instance, an instance class missing from the price table doesn't block provisioning
+		cost, err := providers.EstimateRDSCost(aws.StringValue(foundInstance.DBInstanceClass), aws.Int64Value(foundInstance.AllocatedStorage), aws.BoolValue(foundInstance.MultiAZ))
+		if err != nil {
+			logger.Warnf("failed to estimate rds instance cost: %v", err)
+		}
 		// return secret information
-		return &providers.PostgresInstance{DeploymentDetails: pdd}, croType.StatusMessage(fmt.Sprintf("%s, aws rds status is %s", msg, *foundInstance.DBInstanceStatus)), nil
+		return &providers.PostgresInstance{DeploymentDetails: pdd, Cost: cost, Artifacts: getRDSCACertificateArtifacts(rdsSvc, foundInstance, logger)}, croType.StatusMessage(fmt.Sprintf("%s, aws rds status is %s", msg, *foundInstance.DBInstanceStatus)), nil
 	}
 
 	// create the rds if it doesn't exist
@@ -381,16 +529,42 @@ func (p *PostgresProvider) reconcileRDSInstance(ctx context.Context, cr *v1alpha
 		}
 	}
 
-	logger.Info("creating rds instance")
-	if _, err := rdsSvc.CreateDBInstance(rdsCfg); err != nil {
-		return nil, croType.StatusMessage(fmt.Sprintf("error creating rds instance %s", err)), err
+	// prefer an instance class covered by unused reserved capacity over on-demand pricing
+	if class := chooseReservedClass(opts.reservedCapacity, strategyClass, 1, inUse); class != strategyClass {
+		logger.Infof("provisioning rds instance with class %s covered by unused reservations in place of %s", class, strategyClass)
+		rdsCfg.DBInstanceClass = aws.String(class)
+		annotations.Add(cr, ReservedInstanceClassAnnotation, class)
+	}
+
+	// fail early with a precise message if the instance class can't be provisioned in the subnet group zones
+	if err := validateRDSInstanceClassOffering(rdsSvc, rdsCfg); err != nil {
+		errMsg := fmt.Sprintf("rds instance class %s can not be provisioned", aws.StringValue(rdsCfg.DBInstanceClass))
+		return nil, croType.StatusMessage(fmt.Sprintf("%s: %s", errMsg, err)), errorUtil.Wrap(err, errMsg)
+	}
+
+	// the instance is created without deletion protection so its creation can be aborted, the deletion protection of
+	// the strategy is set by the update strategy once the instance is available
+	rdsCfg.DeletionProtection = aws.Bool(false)
+
+	statusMsg := croType.StatusMessage("started rds provision")
+	if cr.Spec.RestoreFrom != nil {
+		msg, err := p.restoreRDSInstance(ctx, cr, rdsSvc, rdsCfg)
+		if err != nil {
+			return nil, msg, err
+		}
+		statusMsg = msg
+	} else {
+		logger.Info("creating rds instance")
+		if _, err := rdsSvc.CreateDBInstance(rdsCfg); err != nil {
+			return nil, croType.StatusMessage(fmt.Sprintf("error creating rds instance %s", err)), err
+		}
 	}
 
 	annotations.Add(cr, ResourceIdentifierAnnotation, *rdsCfg.DBInstanceIdentifier)
 	if err := p.Client.Update(ctx, cr); err != nil {
 		return nil, "failed to add annotation", err
 	}
-	return nil, "started rds provision", nil
+	return nil, statusMsg, nil
 }
 
 // buildRDSTagCreateStrategy Tags RDS resources
@@ -494,8 +668,15 @@ func (p *PostgresProvider) DeletePostgres(ctx context.Context, r *v1alpha1.Postg
 		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 	}
 
+	// the final snapshot is copied to the secondary region, if configured
+	copyTarget, err := buildRDSSnapshotCopyTarget(ctx, p.Client, providerCreds, stratCfg)
+	if err != nil {
+		errMsg := "failed to create aws session to copy the final rds snapshot"
+		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+
 	// network manager required for cleaning up network vpc, subnet and subnet groups.
-	networkManager := NewNetworkManager(sess, p.Client, logger, isSTSCluster(ctx, p.Client))
+	networkManager := NewNetworkManager(sess, p.Client, logger, isSTSCluster(ctx, p.Client), getNetworkDiscovery(ctx, p.ConfigManager, r.Spec.Tier, logger))
 
 	isEnabled, err := networkManager.IsEnabled(ctx)
 	if err != nil {
@@ -509,10 +690,25 @@ func (p *PostgresProvider) DeletePostgres(ctx context.Context, r *v1alpha1.Postg
 		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 	}
 
-	return p.deleteRDSInstance(ctx, r, networkManager, rds.New(sess), ec2.New(sess), rdsCreateConfig, rdsDeleteConfig, isEnabled, isLastResource)
+	return p.deleteRDSInstance(ctx, r, networkManager, awsclient.NewRDS(sess), ec2.New(sess), rdsCreateConfig, rdsDeleteConfig, rdsDeleteOptions{
+		copyTarget:              copyTarget,
+		standaloneNetworkExists: isEnabled,
+		isLastResource:          isLastResource,
+	})
+}
+
+// rdsDeleteOptions are the optional inputs of deleting an rds instance, the zero value deletes the instance without
+// copying its final snapshot or cleaning up its network
+type rdsDeleteOptions struct {
+	// copyTarget is the secondary region the final snapshot is copied to
+	copyTarget *rdsSnapshotCopyTarget
+	// standaloneNetworkExists is true if no bundled resources are found in the cluster vpc, the network is cleaned up
+	// with the last resource
+	standaloneNetworkExists bool
+	isLastResource          bool
 }
 
-func (p *PostgresProvider) deleteRDSInstance(ctx context.Context, pg *v1alpha1.Postgres, networkManager NetworkManager, instanceSvc rdsiface.RDSAPI, ec2Svc ec2iface.EC2API, rdsCreateConfig *rds.CreateDBInstanceInput, rdsDeleteConfig *rds.DeleteDBInstanceInput, isEnabled bool, isLastResource bool) (croType.StatusMessage, error) {
+func (p *PostgresProvider) deleteRDSInstance(ctx context.Context, pg *v1alpha1.Postgres, networkManager NetworkManager, instanceSvc rdsiface.RDSAPI, ec2Svc ec2iface.EC2API, rdsCreateConfig *rds.CreateDBInstanceInput, rdsDeleteConfig *rds.DeleteDBInstanceInput, opts rdsDeleteOptions) (croType.StatusMessage, error) {
 	logger := p.Logger.WithField("action", "deleteRDSInstance")
 
 	// the aws access key can sometimes still not be registered in aws on first try, so loop
@@ -542,8 +738,24 @@ func (p *PostgresProvider) deleteRDSInstance(ctx context.Context, pg *v1alpha1.P
 		// set status metric
 		p.exposePostgresMetrics(ctx, pg, foundInstance, ec2Svc)
 
-		// return if rds instance is not available
-		if *foundInstance.DBInstanceStatus != "available" {
+		// an instance still being created has no data worth a final snapshot, its creation is aborted rather than
+		// waiting for it to be available
+		if *foundInstance.DBInstanceStatus == "creating" && !aws.BoolValue(foundInstance.DeletionProtection) {
+			_, err = instanceSvc.DeleteDBInstance(&rds.DeleteDBInstanceInput{
+				DBInstanceIdentifier:   foundInstance.DBInstanceIdentifier,
+				SkipFinalSnapshot:      aws.Bool(true),
+				DeleteAutomatedBackups: aws.Bool(true),
+			})
+			rdsErr, isAwsErr := err.(awserr.Error)
+			if err != nil && (!isAwsErr || rdsErr.Code() != rds.ErrCodeDBInstanceNotFoundFault) {
+				msg := fmt.Sprintf("failed to abort creation of rds instance : %s", err)
+				return croType.StatusMessage(msg), errorUtil.Wrapf(err, msg)
+			}
+			return "delete detected, creation of rds instance aborted, deleteDBInstance() started", nil
+		}
+
+		// return if rds instance is not available, a stopped instance of a paused postgres can be deleted as is
+		if *foundInstance.DBInstanceStatus != "available" && *foundInstance.DBInstanceStatus != rdsInstanceStatusStopped {
 			statusMessage := fmt.Sprintf("delete detected, deleteDBInstance() in progress, current aws rds status is %s", *foundInstance.DBInstanceStatus)
 			logger.Info(statusMessage)
 			return croType.StatusMessage(statusMessage), nil
@@ -551,6 +763,29 @@ func (p *PostgresProvider) deleteRDSInstance(ctx context.Context, pg *v1alpha1.P
 
 		// delete rds instance if deletion protection is false
 		if !*foundInstance.DeletionProtection {
+			// the final snapshot is checkpointed if the operator shuts down before the deletion started, so it isn't
+			// taken under another identifier on restart
+			done, err := providers.BeginCheckpointedOperation(p.Client, pg, croType.OperationCheckpoint{
+				Operation:  providers.OperationFinalSnapshot,
+				Step:       "DeleteDBInstance",
+				SnapshotID: aws.StringValue(rdsDeleteConfig.FinalDBSnapshotIdentifier),
+			})
+			if err != nil {
+				if errors.Is(err, resources.ErrShuttingDown) {
+					return providers.ShuttingDownMessage, nil
+				}
+				msg := "failed to start rds instance deletion"
+				return croType.StatusMessage(msg), errorUtil.Wrap(err, msg)
+			}
+			defer done()
+			// the final snapshot is recorded before it's taken, as the instance isn't available again once its
+			// deletion started
+			if !aws.BoolValue(rdsDeleteConfig.SkipFinalSnapshot) {
+				if err := providers.RecordFinalSnapshot(ctx, p.Client, providers.PostgresResourceType, pg, aws.StringValue(rdsDeleteConfig.FinalDBSnapshotIdentifier)); err != nil {
+					msg := "failed to record final rds snapshot"
+					return croType.StatusMessage(msg), errorUtil.Wrap(err, msg)
+				}
+			}
 			_, err = instanceSvc.DeleteDBInstance(rdsDeleteConfig)
 			rdsErr, isAwsErr := err.(awserr.Error)
 			if err != nil && (!isAwsErr || rdsErr.Code() != rds.ErrCodeDBInstanceNotFoundFault) {
@@ -573,8 +808,29 @@ func (p *PostgresProvider) deleteRDSInstance(ctx context.Context, pg *v1alpha1.P
 		return croType.StatusMessage(fmt.Sprintf("deletion protection detected, modifyDBInstance() in progress, current aws rds status is %s", *foundInstance.DBInstanceStatus)), nil
 	}
 
-	// isEnabled is true if no bundled resources are found in the cluster vpc
-	if isEnabled && isLastResource {
+	// the security group allowing external access is only in use by the instance, delete it once the instance is gone
+	if annotations.Has(pg, ExternalAccessSecurityGroupAnnotation) {
+		secName := buildExternalAccessSecurityGroupName(*rdsDeleteConfig.DBInstanceIdentifier)
+		secGroup, err := getSecurityGroup(ec2Svc, secName)
+		if err != nil {
+			msg := "failed to get external access security group"
+			return croType.StatusMessage(msg), errorUtil.Wrap(err, msg)
+		}
+		if msg, err := p.removeRDSExternalAccess(ctx, pg, instanceSvc, ec2Svc, nil, secGroup, logger); err != nil || msg != croType.StatusEmpty {
+			return msg, err
+		}
+	}
+
+	// the final snapshot must be copied to the secondary region before the finalizer is removed
+	if opts.copyTarget != nil && !aws.BoolValue(rdsDeleteConfig.SkipFinalSnapshot) {
+		msg, err := reconcileFinalRDSSnapshotCopy(opts.copyTarget, instanceSvc, *rdsDeleteConfig.DBInstanceIdentifier)
+		if err != nil || msg != "" {
+			return msg, err
+		}
+		logger.Infof("final snapshot of rds instance %s copied to region %s", *rdsDeleteConfig.DBInstanceIdentifier, opts.copyTarget.config.Region)
+	}
+
+	if opts.standaloneNetworkExists && opts.isLastResource {
 		saVPC, err := getStandaloneVpc(ctx, p.Client, ec2Svc, logger)
 		if err != nil {
 			msg := "failed to get standalone VPC"
@@ -609,7 +865,7 @@ func (p *PostgresProvider) deleteRDSInstance(ctx context.Context, pg *v1alpha1.P
 	// in the case of standalone network not existing and the last resource is being deleted the
 	// bundled networking resources should be cleaned up similarly to standalone networking resources
 	// this involves the deletion of bundled elasticace and rds subnet group and ec2 security group
-	if !isEnabled && isLastResource {
+	if !opts.standaloneNetworkExists && opts.isLastResource {
 		err := networkManager.DeleteBundledCloudResources(ctx)
 		if err != nil {
 			msg := "failed to delete bundled networking resources"
@@ -672,6 +928,11 @@ func (p *PostgresProvider) getRDSConfig(ctx context.Context, r *v1alpha1.Postgre
 	if err != nil {
 		return nil, nil, nil, nil, errorUtil.Wrap(err, "failed to read aws strategy config")
 	}
+	// values of the tier changed by the postgres itself
+	stratCfg.CreateStrategy, err = providers.MergeProviderConfig(stratCfg.CreateStrategy, r.Spec.ProviderConfig)
+	if err != nil {
+		return nil, nil, nil, nil, errorUtil.Wrap(err, "failed to merge provider config of postgres")
+	}
 
 	defRegion, err := GetRegionFromStrategyOrDefault(ctx, p.Client, stratCfg)
 	if err != nil {
@@ -686,6 +947,11 @@ func (p *PostgresProvider) getRDSConfig(ctx context.Context, r *v1alpha1.Postgre
 	if err := json.Unmarshal(stratCfg.CreateStrategy, rdsCreateConfig); err != nil {
 		return nil, nil, nil, nil, errorUtil.Wrap(err, "failed to unmarshal aws rds cluster configuration")
 	}
+	applyRDSIAMAuthentication(rdsCreateConfig, stratCfg.IAMAuthentication)
+	applyRDSMonitoring(rdsCreateConfig, stratCfg.Monitoring)
+	if err := validateRDSMonitoring(rdsCreateConfig); err != nil {
+		return nil, nil, nil, nil, errorUtil.Wrap(err, "invalid rds monitoring configuration")
+	}
 
 	rdsDeleteConfig := &rds.DeleteDBInstanceInput{}
 	if err := json.Unmarshal(stratCfg.DeleteStrategy, rdsDeleteConfig); err != nil {
@@ -763,6 +1029,13 @@ func buildRDSUpdateStrategy(rdsConfig *rds.CreateDBInstanceInput, foundConfig *r
 		mi.MultiAZ = rdsConfig.MultiAZ
 		updateFound = true
 	}
+	if rdsConfig.EnableIAMDatabaseAuthentication != nil && *rdsConfig.EnableIAMDatabaseAuthentication != aws.BoolValue(foundConfig.IAMDatabaseAuthenticationEnabled) {
+		mi.EnableIAMDatabaseAuthentication = rdsConfig.EnableIAMDatabaseAuthentication
+		updateFound = true
+	}
+	if buildRDSMonitoringUpdate(mi, rdsConfig, foundConfig) {
+		updateFound = true
+	}
 	if rdsConfig.AutoMinorVersionUpgrade != nil && *rdsConfig.AutoMinorVersionUpgrade != *foundConfig.AutoMinorVersionUpgrade {
 		mi.AutoMinorVersionUpgrade = rdsConfig.AutoMinorVersionUpgrade
 		updateFound = true
@@ -829,6 +1102,11 @@ func verifyPendingModification(mi *rds.ModifyDBInstanceInput, pm *rds.PendingMod
 			pendingModifications = false
 		}
 	}
+	if mi.EnableIAMDatabaseAuthentication != nil && pm.IAMDatabaseAuthenticationEnabled != nil {
+		if *mi.EnableIAMDatabaseAuthentication == *pm.IAMDatabaseAuthenticationEnabled {
+			pendingModifications = false
+		}
+	}
 	return pendingModifications
 }
 
@@ -837,6 +1115,10 @@ func (p *PostgresProvider) buildRDSCreateStrategy(ctx context.Context, pg *v1alp
 	if rdsCreateConfig.DeletionProtection == nil {
 		rdsCreateConfig.DeletionProtection = aws.Bool(defaultAwsPostgresDeletionProtection)
 	}
+	// a protected postgres always protects its instance, whatever the strategy
+	if pg.Spec.DeletionProtection {
+		rdsCreateConfig.DeletionProtection = aws.Bool(true)
+	}
 	if rdsCreateConfig.MasterUsername == nil {
 		rdsCreateConfig.MasterUsername = aws.String(defaultAwsPostgresUser)
 	}
@@ -873,7 +1155,8 @@ func (p *PostgresProvider) buildRDSCreateStrategy(ctx context.Context, pg *v1alp
 	if rdsCreateConfig.StorageEncrypted == nil {
 		rdsCreateConfig.StorageEncrypted = aws.Bool(defaultStorageEncrypted)
 	}
-	if rdsCreateConfig.EngineVersion != nil {
+	// a version requested by the cr has already been checked against the supported versions of the strategy
+	if rdsCreateConfig.EngineVersion != nil && pg.Spec.Version == "" {
 		if !resources.Contains(defaultSupportedEngineVersions, *rdsCreateConfig.EngineVersion) {
 			rdsCreateConfig.EngineVersion = aws.String(defaultAwsEngineVersion)
 		}
@@ -940,38 +1223,56 @@ func (p *PostgresProvider) buildRDSDeleteConfig(ctx context.Context, pg *v1alpha
 	if rdsDeleteConfig.SkipFinalSnapshot == nil {
 		rdsDeleteConfig.SkipFinalSnapshot = aws.Bool(defaultAwsSkipFinalSnapshot)
 	}
+	// the final snapshot of the postgres cr overrides the strategy
+	if pg.Spec.ApplyFinalSnapshot != nil {
+		rdsDeleteConfig.SkipFinalSnapshot = aws.Bool(!*pg.Spec.ApplyFinalSnapshot)
+	}
+	if *rdsDeleteConfig.SkipFinalSnapshot {
+		rdsDeleteConfig.FinalDBSnapshotIdentifier = nil
+		return nil
+	}
+	// the final snapshot of a deletion in flight when the operator shut down keeps its identifier
+	if resumed := providers.ResumedSnapshotID(pg.Status.Checkpoint, providers.OperationFinalSnapshot); resumed != "" {
+		rdsDeleteConfig.FinalDBSnapshotIdentifier = aws.String(resumed)
+		return nil
+	}
+	if pg.Spec.FinalSnapshotNameTemplate != "" {
+		snapshotIdentifier, err := buildFinalSnapshotIdentifier(ctx, p.Client, pg.ObjectMeta, pg.Spec.FinalSnapshotNameTemplate)
+		if err != nil {
+			return errorUtil.Wrap(err, "failed to build final rds snapshot identifier")
+		}
+		rdsDeleteConfig.FinalDBSnapshotIdentifier = aws.String(snapshotIdentifier)
+	}
 	snapshotIdentifier, err := buildTimestampedInfraNameFromObject(ctx, p.Client, pg.ObjectMeta, defaultAwsIdentifierLength)
 	if err != nil {
 		return errorUtil.Wrap(err, "failed to retrieve timestamped rds config")
 	}
-	if rdsDeleteConfig.FinalDBSnapshotIdentifier == nil && !*rdsDeleteConfig.SkipFinalSnapshot {
+	if rdsDeleteConfig.FinalDBSnapshotIdentifier == nil {
 		rdsDeleteConfig.FinalDBSnapshotIdentifier = aws.String(snapshotIdentifier)
 	}
 	return nil
 }
 
+// buildDefaultRDSSecret returns the credential secret of the instance, its user and password are generated when it's
+// created
 func buildDefaultRDSSecret(ps *v1alpha1.Postgres) *v1.Secret {
-	password, err := resources.GeneratePassword()
-	if err != nil {
-		return nil
-	}
 	return &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      ps.Name + defaultCredSecSuffix,
 			Namespace: ps.Namespace,
 		},
-		StringData: map[string]string{
-			defaultPostgresUserKey:     defaultAwsPostgresUser,
-			defaultPostgresPasswordKey: password,
-		},
 		Type: v1.SecretTypeOpaque,
 	}
 }
 
 // ensures a subnet group is in place to configure the resource to be in the same vpc as the cluster
-func (p *PostgresProvider) configureRDSVpc(ctx context.Context, rdsSvc rdsiface.RDSAPI, ec2Svc ec2iface.EC2API) error {
+func (p *PostgresProvider) configureRDSVpc(ctx context.Context, rdsSvc rdsiface.RDSAPI, ec2Svc ec2iface.EC2API, discovery *NetworkDiscovery) error {
 	logger := p.Logger.WithField("action", "configureRDSVpc")
 	logger.Info("ensuring vpc is as expected for resource")
+	if !discovery.CreatesSubnetGroups() {
+		logger.Info("network discovery uses existing subnet groups, rds subnet group is not created")
+		return nil
+	}
 	// get subnet group id
 	sgID, err := BuildInfraName(ctx, p.Client, defaultSubnetPostfix, defaultAwsIdentifierLength)
 	if err != nil {
@@ -1003,7 +1304,7 @@ func (p *PostgresProvider) configureRDSVpc(ctx context.Context, rdsSvc rdsiface.
 	}
 
 	// get cluster vpc subnets
-	subIDs, err := GetPrivateSubnetIDS(ctx, p.Client, ec2Svc, logger)
+	subIDs, err := GetPrivateSubnetIDS(ctx, p.Client, ec2Svc, discovery, logger)
 	if err != nil {
 		return errorUtil.Wrap(err, "error getting vpc subnets")
 	}
@@ -1306,3 +1607,29 @@ func (p *PostgresProvider) rdsApplyStatusUpdate(rdsSvc rdsiface.RDSAPI, serviceU
 	}
 	return upgrading, "completed check for service updates", nil
 }
+
+// getRDSCACertificateArtifacts returns the ca certificate clients verify the rds instance with as an expiring artifact,
+// failing to describe it doesn't block provisioning
+func getRDSCACertificateArtifacts(rdsSvc rdsiface.RDSAPI, instance *rds.DBInstance, logger *logrus.Entry) []providers.ExpiringArtifact {
+	if instance.CACertificateIdentifier == nil {
+		return nil
+	}
+	certs, err := rdsSvc.DescribeCertificates(&rds.DescribeCertificatesInput{CertificateIdentifier: instance.CACertificateIdentifier})
+	if err != nil {
+		logger.Warnf("failed to describe ca certificate %s of rds instance %s: %v", aws.StringValue(instance.CACertificateIdentifier), aws.StringValue(instance.DBInstanceIdentifier), err)
+		return nil
+	}
+	var artifacts []providers.ExpiringArtifact
+	for _, cert := range certs.Certificates {
+		if cert.ValidTill == nil {
+			continue
+		}
+		artifacts = append(artifacts, providers.ExpiringArtifact{
+			Kind:      providers.ArtifactKindCABundle,
+			Name:      aws.StringValue(cert.CertificateIdentifier),
+			IssuedAt:  aws.TimeValue(cert.ValidFrom),
+			ExpiresAt: aws.TimeValue(cert.ValidTill),
+		})
+	}
+	return artifacts
+}
@@ -59,16 +59,21 @@ var (
 
 type mockRdsClient struct {
 	rdsiface.RDSAPI
-	modifyDBSubnetGroupFn               func(*rds.ModifyDBSubnetGroupInput) (*rds.ModifyDBSubnetGroupOutput, error)
-	listTagsForResourceFn               func(*rds.ListTagsForResourceInput) (*rds.ListTagsForResourceOutput, error)
-	removeTagsFromResourceFn            func(*rds.RemoveTagsFromResourceInput) (*rds.RemoveTagsFromResourceOutput, error)
-	deleteDBSubnetGroupFn               func(*rds.DeleteDBSubnetGroupInput) (*rds.DeleteDBSubnetGroupOutput, error)
-	addTagsToResourceFn                 func(*rds.AddTagsToResourceInput) (*rds.AddTagsToResourceOutput, error)
-	describeDBSnapshotsFn               func(*rds.DescribeDBSnapshotsInput) (*rds.DescribeDBSnapshotsOutput, error)
-	describeDBInstancesFn               func(*rds.DescribeDBInstancesInput) (*rds.DescribeDBInstancesOutput, error)
-	describeDBSubnetGroupsFn            func(*rds.DescribeDBSubnetGroupsInput) (*rds.DescribeDBSubnetGroupsOutput, error)
-	describePendingMaintenanceActionsFn func(*rds.DescribePendingMaintenanceActionsInput) (*rds.DescribePendingMaintenanceActionsOutput, error)
-	applyPendingMaintenanceActionFn     func(*rds.ApplyPendingMaintenanceActionInput) (*rds.ApplyPendingMaintenanceActionOutput, error)
+	modifyDBSubnetGroupFn                func(*rds.ModifyDBSubnetGroupInput) (*rds.ModifyDBSubnetGroupOutput, error)
+	listTagsForResourceFn                func(*rds.ListTagsForResourceInput) (*rds.ListTagsForResourceOutput, error)
+	removeTagsFromResourceFn             func(*rds.RemoveTagsFromResourceInput) (*rds.RemoveTagsFromResourceOutput, error)
+	deleteDBSubnetGroupFn                func(*rds.DeleteDBSubnetGroupInput) (*rds.DeleteDBSubnetGroupOutput, error)
+	addTagsToResourceFn                  func(*rds.AddTagsToResourceInput) (*rds.AddTagsToResourceOutput, error)
+	describeDBSnapshotsFn                func(*rds.DescribeDBSnapshotsInput) (*rds.DescribeDBSnapshotsOutput, error)
+	describeDBInstancesFn                func(*rds.DescribeDBInstancesInput) (*rds.DescribeDBInstancesOutput, error)
+	describeDBSubnetGroupsFn             func(*rds.DescribeDBSubnetGroupsInput) (*rds.DescribeDBSubnetGroupsOutput, error)
+	describePendingMaintenanceActionsFn  func(*rds.DescribePendingMaintenanceActionsInput) (*rds.DescribePendingMaintenanceActionsOutput, error)
+	applyPendingMaintenanceActionFn      func(*rds.ApplyPendingMaintenanceActionInput) (*rds.ApplyPendingMaintenanceActionOutput, error)
+	describeOrderableDBInstanceOptionsFn func(*rds.DescribeOrderableDBInstanceOptionsInput) (*rds.DescribeOrderableDBInstanceOptionsOutput, error)
+	restoreDBInstanceFromDBSnapshotFn    func(*rds.RestoreDBInstanceFromDBSnapshotInput) (*rds.RestoreDBInstanceFromDBSnapshotOutput, error)
+	describeCertificatesFn               func(*rds.DescribeCertificatesInput) (*rds.DescribeCertificatesOutput, error)
+	stopDBInstanceFn                     func(*rds.StopDBInstanceInput) (*rds.StopDBInstanceOutput, error)
+	startDBInstanceFn                    func(*rds.StartDBInstanceInput) (*rds.StartDBInstanceOutput, error)
 }
 
 type mockEc2Client struct {
@@ -103,6 +108,7 @@ type mockEc2Client struct {
 	describeSubnetsFn               func(*ec2.DescribeSubnetsInput) (*ec2.DescribeSubnetsOutput, error)
 	describeAvailabilityZonesFn     func(*ec2.DescribeAvailabilityZonesInput) (*ec2.DescribeAvailabilityZonesOutput, error)
 	createSecurityGroupFn           func(*ec2.CreateSecurityGroupInput) (*ec2.CreateSecurityGroupOutput, error)
+	describeInstancesFn             func(*ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error)
 	calls                           struct {
 		DescribeRouteTables []struct {
 			Tables *ec2.DescribeRouteTablesInput
@@ -156,6 +162,13 @@ func buildMockEc2Client(modifyFn func(*mockEc2Client)) *mockEc2Client {
 
 func buildMockRdsClient(modifyFn func(*mockRdsClient)) *mockRdsClient {
 	mock := &mockRdsClient{}
+	mock.describeOrderableDBInstanceOptionsFn = func(input *rds.DescribeOrderableDBInstanceOptionsInput) (*rds.DescribeOrderableDBInstanceOptionsOutput, error) {
+		return &rds.DescribeOrderableDBInstanceOptionsOutput{
+			OrderableDBInstanceOptions: []*rds.OrderableDBInstanceOption{
+				buildOrderableDBInstanceOption(true, defaultAzIdOne, defaultAzIdTwo),
+			},
+		}, nil
+	}
 	if modifyFn != nil {
 		modifyFn(mock)
 	}
@@ -197,6 +210,13 @@ func (m *mockRdsClient) ModifyDBInstance(*rds.ModifyDBInstanceInput) (*rds.Modif
 	return &rds.ModifyDBInstanceOutput{}, nil
 }
 
+func (m *mockRdsClient) RestoreDBInstanceFromDBSnapshot(input *rds.RestoreDBInstanceFromDBSnapshotInput) (*rds.RestoreDBInstanceFromDBSnapshotOutput, error) {
+	if m.restoreDBInstanceFromDBSnapshotFn == nil {
+		panic("mockRdsClient.RestoreDBInstanceFromDBSnapshot: method is nil")
+	}
+	return m.restoreDBInstanceFromDBSnapshotFn(input)
+}
+
 func (m *mockRdsClient) DeleteDBInstance(*rds.DeleteDBInstanceInput) (*rds.DeleteDBInstanceOutput, error) {
 	return &rds.DeleteDBInstanceOutput{}, nil
 }
@@ -234,6 +254,34 @@ func (m *mockRdsClient) DescribeDBSubnetGroups(input *rds.DescribeDBSubnetGroups
 	return m.describeDBSubnetGroupsFn(input)
 }
 
+func (m *mockRdsClient) DescribeOrderableDBInstanceOptions(input *rds.DescribeOrderableDBInstanceOptionsInput) (*rds.DescribeOrderableDBInstanceOptionsOutput, error) {
+	if m.describeOrderableDBInstanceOptionsFn == nil {
+		panic("mockRdsClient.DescribeOrderableDBInstanceOptions: method is nil")
+	}
+	return m.describeOrderableDBInstanceOptionsFn(input)
+}
+
+func (m *mockRdsClient) DescribeCertificates(input *rds.DescribeCertificatesInput) (*rds.DescribeCertificatesOutput, error) {
+	if m.describeCertificatesFn == nil {
+		return &rds.DescribeCertificatesOutput{}, nil
+	}
+	return m.describeCertificatesFn(input)
+}
+
+func (m *mockRdsClient) StopDBInstance(input *rds.StopDBInstanceInput) (*rds.StopDBInstanceOutput, error) {
+	if m.stopDBInstanceFn == nil {
+		panic("mockRdsClient.StopDBInstance: method is nil")
+	}
+	return m.stopDBInstanceFn(input)
+}
+
+func (m *mockRdsClient) StartDBInstance(input *rds.StartDBInstanceInput) (*rds.StartDBInstanceOutput, error) {
+	if m.startDBInstanceFn == nil {
+		panic("mockRdsClient.StartDBInstance: method is nil")
+	}
+	return m.startDBInstanceFn(input)
+}
+
 func (m *mockRdsClient) CreateDBSubnetGroup(*rds.CreateDBSubnetGroupInput) (*rds.CreateDBSubnetGroupOutput, error) {
 	return &rds.CreateDBSubnetGroupOutput{}, nil
 }
@@ -409,6 +457,10 @@ func (m *mockEc2Client) AuthorizeSecurityGroupIngress(*ec2.AuthorizeSecurityGrou
 	return &ec2.AuthorizeSecurityGroupIngressOutput{}, nil
 }
 
+func (m *mockEc2Client) RevokeSecurityGroupIngress(*ec2.RevokeSecurityGroupIngressInput) (*ec2.RevokeSecurityGroupIngressOutput, error) {
+	return &ec2.RevokeSecurityGroupIngressOutput{}, nil
+}
+
 func (m *mockEc2Client) DescribeAvailabilityZones(input *ec2.DescribeAvailabilityZonesInput) (*ec2.DescribeAvailabilityZonesOutput, error) {
 	if m.describeAvailabilityZonesFn == nil {
 		panic("mockEc2Client.DescribeAvailabilityZones: method is nil")
@@ -455,6 +507,14 @@ func (m *mockEc2Client) DescribeInstanceTypes(*ec2.DescribeInstanceTypesInput) (
 	return &ec2.DescribeInstanceTypesOutput{}, nil
 }
 
+// cluster instances are only described when no subnets are tagged for the cluster, return empty result by default
+func (m *mockEc2Client) DescribeInstances(input *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+	if m.describeInstancesFn == nil {
+		return &ec2.DescribeInstancesOutput{}, nil
+	}
+	return m.describeInstancesFn(input)
+}
+
 func buildMockNetworkManager() *NetworkManagerMock {
 	return &NetworkManagerMock{
 		DeleteNetworkConnectionFunc: func(ctx context.Context, np *NetworkPeering) error {
@@ -651,6 +711,14 @@ func buildPendingDBInstance(testID string) []*rds.DBInstance {
 	}
 }
 
+func buildTestRDSCost(t *testing.T) *providers.Cost {
+	cost, err := providers.EstimateRDSCost(defaultAwsDBInstanceClass, defaultAwsAllocatedStorage, true)
+	if err != nil {
+		t.Fatal("failed to estimate rds cost", err)
+	}
+	return cost
+}
+
 func buildAvailableCreateInput(testID string) *rds.CreateDBInstanceInput {
 	return &rds.CreateDBInstanceInput{
 		DBInstanceIdentifier:       aws.String(testID),
@@ -823,6 +891,13 @@ func TestAWSPostgresProvider_createPostgresInstance(t *testing.T) {
 					describeDBInstancesFn: func(input *rds.DescribeDBInstancesInput) (*rds.DescribeDBInstancesOutput, error) {
 						return &rds.DescribeDBInstancesOutput{}, nil
 					},
+					describeOrderableDBInstanceOptionsFn: func(input *rds.DescribeOrderableDBInstanceOptionsInput) (*rds.DescribeOrderableDBInstanceOptionsOutput, error) {
+						return &rds.DescribeOrderableDBInstanceOptionsOutput{
+							OrderableDBInstanceOptions: []*rds.OrderableDBInstanceOption{
+								buildOrderableDBInstanceOption(true, defaultAzIdOne, defaultAzIdTwo),
+							},
+						}, nil
+					},
 				},
 				ec2Svc: &mockEc2Client{
 					describeVpcsFn: func(input *ec2.DescribeVpcsInput) (*ec2.DescribeVpcsOutput, error) {
@@ -934,7 +1009,7 @@ func TestAWSPostgresProvider_createPostgresInstance(t *testing.T) {
 				Host:     "blob",
 				Database: defaultAwsEngine,
 				Port:     defaultAwsPostgresPort,
-			}},
+			}, Cost: buildTestRDSCost(t)},
 			wantErr: false,
 		},
 		{
@@ -1314,7 +1389,7 @@ func TestAWSPostgresProvider_createPostgresInstance(t *testing.T) {
 				Host:     "blob",
 				Database: defaultAwsEngine,
 				Port:     defaultAwsPostgresPort,
-			}},
+			}, Cost: buildTestRDSCost(t)},
 			wantErr: false,
 		},
 		{
@@ -1520,7 +1595,7 @@ func TestAWSPostgresProvider_createPostgresInstance(t *testing.T) {
 				ConfigManager:     tt.fields.ConfigManager,
 				TCPPinger:         tt.fields.TCPPinger,
 			}
-			got, _, err := p.reconcileRDSInstance(tt.args.ctx, tt.args.cr, tt.args.rdsSvc, tt.args.ec2Svc, tt.args.postgresCfg, tt.args.standaloneNetworkExists)
+			got, _, err := p.reconcileRDSInstance(tt.args.ctx, tt.args.cr, tt.args.rdsSvc, tt.args.ec2Svc, tt.args.postgresCfg, tt.args.standaloneNetworkExists, rdsReconcileOptions{})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("reconcileRDSInstance() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -1551,6 +1626,7 @@ func TestAWSPostgresProvider_deletePostgresInstance(t *testing.T) {
 		networkManager          NetworkManager
 		instanceSvc             rdsiface.RDSAPI
 		ec2Svc                  ec2iface.EC2API
+		copyTarget              *rdsSnapshotCopyTarget
 		postgresCreateConfig    *rds.CreateDBInstanceInput
 		postgresDeleteConfig    *rds.DeleteDBInstanceInput
 		standaloneNetworkExists bool
@@ -1666,6 +1742,40 @@ func TestAWSPostgresProvider_deletePostgresInstance(t *testing.T) {
 			want:    croType.StatusMessage("deletion protection detected, modifyDBInstance() in progress, current aws rds status is available"),
 			wantErr: false,
 		},
+		{
+			name: "test creation of postgres being created is aborted on delete",
+			args: args{
+				postgresDeleteConfig: &rds.DeleteDBInstanceInput{DBInstanceIdentifier: aws.String(testIdentifier)},
+				postgresCreateConfig: &rds.CreateDBInstanceInput{DBInstanceIdentifier: aws.String(testIdentifier)},
+				pg:                   buildTestPostgresCR(),
+				networkManager:       buildMockNetworkManager(),
+				instanceSvc: &mockRdsClient{
+					describeDBInstancesFn: func(input *rds.DescribeDBInstancesInput) (*rds.DescribeDBInstancesOutput, error) {
+						return &rds.DescribeDBInstancesOutput{
+							DBInstances: []*rds.DBInstance{
+								{
+									DBInstanceIdentifier: aws.String(testIdentifier),
+									DBInstanceStatus:     aws.String("creating"),
+									DeletionProtection:   aws.Bool(false),
+									DBInstanceClass:      aws.String(defaultAwsDBInstanceClass),
+								},
+							},
+						}, nil
+					},
+				},
+				ec2Svc:                  &mockEc2Client{},
+				standaloneNetworkExists: false,
+				isLastResource:          false,
+			},
+			fields: fields{
+				Client:            fake.NewFakeClientWithScheme(scheme, buildTestPostgresCR(), buildTestInfra(), buildTestPostgresqlPrometheusRule()),
+				Logger:            testLogger,
+				CredentialManager: &CredentialManagerMock{},
+				ConfigManager:     &ConfigManagerMock{},
+			},
+			want:    croType.StatusMessage("delete detected, creation of rds instance aborted, deleteDBInstance() started"),
+			wantErr: false,
+		},
 		{
 			name: "test successful delete with no postgres and deletion of standalone network",
 			args: args{
@@ -1733,7 +1843,11 @@ func TestAWSPostgresProvider_deletePostgresInstance(t *testing.T) {
 				CredentialManager: tt.fields.CredentialManager,
 				ConfigManager:     tt.fields.ConfigManager,
 			}
-			got, err := p.deleteRDSInstance(tt.args.ctx, tt.args.pg, tt.args.networkManager, tt.args.instanceSvc, tt.args.ec2Svc, tt.args.postgresCreateConfig, tt.args.postgresDeleteConfig, tt.args.standaloneNetworkExists, tt.args.isLastResource)
+			got, err := p.deleteRDSInstance(tt.args.ctx, tt.args.pg, tt.args.networkManager, tt.args.instanceSvc, tt.args.ec2Svc, tt.args.postgresCreateConfig, tt.args.postgresDeleteConfig, rdsDeleteOptions{
+				copyTarget:              tt.args.copyTarget,
+				standaloneNetworkExists: tt.args.standaloneNetworkExists,
+				isLastResource:          tt.args.isLastResource,
+			})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("deleteRDSInstance() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -1973,6 +2087,37 @@ func Test_buildRDSUpdateStrategy(t *testing.T) {
 				DBInstanceIdentifier:       aws.String("test"),
 			},
 		},
+		{
+			name: "test single az instance is modified to multi az",
+			args: args{
+				rdsConfig: &rds.CreateDBInstanceInput{
+					DeletionProtection:    aws.Bool(true),
+					BackupRetentionPeriod: aws.Int64(1),
+					DBInstanceClass:       aws.String("test"),
+					PubliclyAccessible:    aws.Bool(false),
+					MaxAllocatedStorage:   aws.Int64(1),
+					MultiAZ:               aws.Bool(true),
+					Port:                  aws.Int64(1),
+				},
+				foundConfig: &rds.DBInstance{
+					DeletionProtection:    aws.Bool(true),
+					BackupRetentionPeriod: aws.Int64(1),
+					DBInstanceClass:       aws.String("test"),
+					PubliclyAccessible:    aws.Bool(false),
+					MaxAllocatedStorage:   aws.Int64(1),
+					MultiAZ:               aws.Bool(false),
+					Endpoint: &rds.Endpoint{
+						Port: aws.Int64(1),
+					},
+					DBInstanceIdentifier: aws.String("test"),
+				},
+				cr: buildTestPostgresCR(),
+			},
+			want: &rds.ModifyDBInstanceInput{
+				MultiAZ:              aws.Bool(true),
+				DBInstanceIdentifier: aws.String("test"),
+			},
+		},
 		{
 			name: "test modification not required when instance engine version is higher than configured",
 			args: args{
@@ -2516,6 +2661,35 @@ func TestReconcilePostgres(t *testing.T) {
 			statusMessage: "failed to retrieve aws rds cluster config for instance",
 			wantErr:       true,
 		},
+		{
+			name: "unsupported postgres version",
+			fields: fields{
+				Client:            fake.NewFakeClientWithScheme(scheme, buildTestInfra(), buildTestPostgresCR()),
+				Logger:            testLogger,
+				CredentialManager: &CredentialManagerMock{},
+				ConfigManager: &ConfigManagerMock{
+					ReadStorageStrategyFunc: func(ctx context.Context, rt providers.ResourceType, tier string) (*StrategyConfig, error) {
+						return &StrategyConfig{
+							CreateStrategy:    json.RawMessage("{}"),
+							DeleteStrategy:    json.RawMessage("{}"),
+							SupportedVersions: providers.SupportedVersions{"13": "13.7", "15": "15.2"},
+						}, nil
+					},
+				},
+				TCPPinger: buildMockConnectionTester(),
+			},
+			args: args{
+				ctx: context.TODO(),
+				pg: func() *v1alpha1.Postgres {
+					pg := buildTestPostgresCR()
+					pg.Spec.Version = "10"
+					return pg
+				}(),
+			},
+			want:          nil,
+			statusMessage: "unsupported postgres version for instance test: version 10 is not supported, supported versions are 13, 15",
+			wantErr:       true,
+		},
 		{
 			name: "failed to reconcile rds credentials",
 			fields: fields{
@@ -7,6 +7,7 @@ package aws
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -70,7 +71,7 @@ func (p *PostgresSnapshotProvider) CreatePostgresSnapshot(ctx context.Context, s
 		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 	}
 
-	session, err := p.createSessionForResource(ctx, postgres.Namespace, providers.PostgresResourceType, postgres.Spec.Tier)
+	session, copyTarget, err := p.createSessionForResource(ctx, postgres.Namespace, providers.PostgresResourceType, postgres.Spec.Tier, snapshot.Status.CrossRegionSnapshotRegion)
 
 	if err != nil {
 		errMsg := "failed to create AWS session"
@@ -79,13 +80,13 @@ func (p *PostgresSnapshotProvider) CreatePostgresSnapshot(ctx context.Context, s
 
 	rdsSvc := rds.New(session)
 
-	return p.createPostgresSnapshot(ctx, snapshot, postgres, rdsSvc)
+	return p.createPostgresSnapshot(ctx, snapshot, postgres, rdsSvc, copyTarget)
 }
 
 func (p *PostgresSnapshotProvider) DeletePostgresSnapshot(ctx context.Context, snapshot *v1alpha1.PostgresSnapshot, postgres *v1alpha1.Postgres) (croType.StatusMessage, error) {
 
 	// create the credentials to be used by the aws resource providers, not to be used by end-user
-	session, err := p.createSessionForResource(ctx, postgres.Namespace, providers.PostgresResourceType, postgres.Spec.Tier)
+	session, copyTarget, err := p.createSessionForResource(ctx, postgres.Namespace, providers.PostgresResourceType, postgres.Spec.Tier, snapshot.Status.CrossRegionSnapshotRegion)
 
 	if err != nil {
 		errMsg := "failed to create AWS session"
@@ -94,23 +95,28 @@ func (p *PostgresSnapshotProvider) DeletePostgresSnapshot(ctx context.Context, s
 
 	rdsSvc := rds.New(session)
 
-	return p.deletePostgresSnapshot(ctx, snapshot, postgres, rdsSvc)
+	return p.deletePostgresSnapshot(ctx, snapshot, postgres, rdsSvc, copyTarget)
 }
 
-func (p *PostgresSnapshotProvider) createPostgresSnapshot(ctx context.Context, snapshot *v1alpha1.PostgresSnapshot, postgres *v1alpha1.Postgres, rdsSvc rdsiface.RDSAPI) (*providers.PostgresSnapshotInstance, croType.StatusMessage, error) {
+func (p *PostgresSnapshotProvider) createPostgresSnapshot(ctx context.Context, snapshot *v1alpha1.PostgresSnapshot, postgres *v1alpha1.Postgres, rdsSvc rdsiface.RDSAPI, copyTarget *rdsSnapshotCopyTarget) (*providers.PostgresSnapshotInstance, croType.StatusMessage, error) {
 	logger := resources.NewActionLogger(p.logger, "createPostgresSnapshot")
 
 	// generate snapshot name
-	snapshotName, err := BuildTimestampedInfraNameFromObjectCreation(ctx, p.client, snapshot.ObjectMeta, defaultAwsIdentifierLength)
-	if err != nil {
-		errMsg := "failed to generate snapshot name"
-		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	// a snapshot whose creation was in flight when the operator shut down is resumed
+	snapshotName := providers.ResumedSnapshotID(snapshot.Status.Checkpoint, providers.OperationCreateSnapshot)
+	if snapshotName == "" {
+		var err error
+		snapshotName, err = BuildTimestampedInfraNameFromObjectCreation(ctx, p.client, snapshot.ObjectMeta, defaultAwsIdentifierLength)
+		if err != nil {
+			errMsg := "failed to generate snapshot name"
+			return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
 	}
 
 	// update cr with snapshot name
 	snapshot.Status.SnapshotID = snapshotName
 
-	if err = p.client.Status().Update(ctx, snapshot); err != nil {
+	if err := p.client.Status().Update(ctx, snapshot); err != nil {
 		errMsg := fmt.Sprintf("failed to update instance %s in namespace %s", snapshot.Name, snapshot.Namespace)
 		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 	}
@@ -148,11 +154,28 @@ func (p *PostgresSnapshotProvider) createPostgresSnapshot(ctx context.Context, s
 			msg := "failed to get default postgres tags"
 			return nil, "", errorUtil.Wrapf(err, msg)
 		}
+		done, err := providers.BeginCheckpointedOperation(p.client, snapshot, croType.OperationCheckpoint{
+			Operation:  providers.OperationCreateSnapshot,
+			Step:       "CreateDBSnapshot",
+			SnapshotID: snapshotName,
+		})
+		if err != nil {
+			if errors.Is(err, resources.ErrShuttingDown) {
+				return nil, providers.ShuttingDownMessage, nil
+			}
+			errMsg := "failed to start rds snapshot creation"
+			return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+		defer done()
 		_, err = rdsSvc.CreateDBSnapshot(&rds.CreateDBSnapshotInput{
 			DBInstanceIdentifier: aws.String(instanceName),
 			DBSnapshotIdentifier: aws.String(snapshotName),
 			Tags:                 genericToRdsTags(tags),
 		})
+		// a snapshot requested before the operator shut down may not be described yet
+		if rdsErr, ok := err.(awserr.Error); ok && rdsErr.Code() == rds.ErrCodeDBSnapshotAlreadyExistsFault {
+			return nil, "snapshot started", nil
+		}
 		if err != nil {
 			errMsg := "error creating rds snapshot"
 			return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
@@ -160,8 +183,46 @@ func (p *PostgresSnapshotProvider) createPostgresSnapshot(ctx context.Context, s
 		return nil, "snapshot started", nil
 	}
 
+	// the snapshot exists, so the checkpoint of its creation is no longer needed
+	snapshot.Status.Checkpoint = nil
+
 	// if snapshot status complete update status
-	if *foundSnapshot.Status == "available" {
+	if *foundSnapshot.Status == rdsSnapshotStatusAvailable {
+		// record the arn and, if the snapshot has a retention period, when it's deleted
+		createTime := aws.TimeValue(foundSnapshot.SnapshotCreateTime)
+		if createTime.IsZero() {
+			createTime = snapshot.CreationTimestamp.Time
+		}
+		retainUntil := providers.BuildSnapshotRetainUntil(createTime, snapshot.Spec.RetentionDays)
+		if snapshot.Status.SnapshotARN != aws.StringValue(foundSnapshot.DBSnapshotArn) || snapshot.Status.RetainUntil != retainUntil {
+			snapshot.Status.SnapshotARN = aws.StringValue(foundSnapshot.DBSnapshotArn)
+			snapshot.Status.RetainUntil = retainUntil
+			if err = p.client.Status().Update(ctx, snapshot); err != nil {
+				errMsg := fmt.Sprintf("failed to update instance %s in namespace %s", snapshot.Name, snapshot.Namespace)
+				return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+			}
+		}
+		// the snapshot is only complete once it's been copied to the secondary region, if configured
+		if copyTarget != nil {
+			foundCopy, err := reconcileRDSSnapshotCopy(copyTarget, foundSnapshot)
+			if err != nil {
+				errMsg := fmt.Sprintf("failed to copy snapshot to region %s", copyTarget.config.Region)
+				return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+			}
+			if snapshot.Status.CrossRegionSnapshotID == "" {
+				snapshot.Status.CrossRegionSnapshotID = *foundSnapshot.DBSnapshotIdentifier
+				snapshot.Status.CrossRegionSnapshotRegion = copyTarget.config.Region
+				if err = p.client.Status().Update(ctx, snapshot); err != nil {
+					errMsg := fmt.Sprintf("failed to update instance %s in namespace %s", snapshot.Name, snapshot.Namespace)
+					return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+				}
+			}
+			if foundCopy == nil || aws.StringValue(foundCopy.Status) != rdsSnapshotStatusAvailable {
+				msg := fmt.Sprintf("snapshot created, copying to region %s", copyTarget.config.Region)
+				logger.Info(msg)
+				return nil, croType.StatusMessage(msg), nil
+			}
+		}
 		return &providers.PostgresSnapshotInstance{
 			Name: *foundSnapshot.DBSnapshotIdentifier,
 		}, "snapshot created", nil
@@ -173,8 +234,31 @@ func (p *PostgresSnapshotProvider) createPostgresSnapshot(ctx context.Context, s
 	return nil, croType.StatusMessage(msg), nil
 }
 
-func (p *PostgresSnapshotProvider) deletePostgresSnapshot(ctx context.Context, snapshot *v1alpha1.PostgresSnapshot, postgres *v1alpha1.Postgres, rdsSvc rdsiface.RDSAPI) (croType.StatusMessage, error) {
+func (p *PostgresSnapshotProvider) deletePostgresSnapshot(ctx context.Context, snapshot *v1alpha1.PostgresSnapshot, postgres *v1alpha1.Postgres, rdsSvc rdsiface.RDSAPI, copyTarget *rdsSnapshotCopyTarget) (croType.StatusMessage, error) {
 	snapshotName := snapshot.Status.SnapshotID
+
+	// delete the copy in the secondary region before the source snapshot
+	if copyTarget != nil && snapshot.Status.CrossRegionSnapshotID != "" {
+		foundCopy, err := getRDSSnapshot(copyTarget.rdsSvc, snapshot.Status.CrossRegionSnapshotID)
+		if err != nil {
+			errMsg := fmt.Sprintf("failed to describe snapshot copy in region %s", copyTarget.config.Region)
+			return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+		if foundCopy != nil {
+			// a snapshot copy can't be deleted while it's being created
+			if aws.StringValue(foundCopy.Status) != rdsSnapshotStatusAvailable {
+				return croType.StatusMessage(fmt.Sprintf("waiting for snapshot copy in region %s to be available before deletion, current status : %s", copyTarget.config.Region, aws.StringValue(foundCopy.Status))), nil
+			}
+			if _, err = copyTarget.rdsSvc.DeleteDBSnapshot(&rds.DeleteDBSnapshotInput{
+				DBSnapshotIdentifier: foundCopy.DBSnapshotIdentifier,
+			}); err != nil {
+				errMsg := fmt.Sprintf("failed to delete snapshot copy %s in region %s", snapshot.Status.CrossRegionSnapshotID, copyTarget.config.Region)
+				return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+			}
+			return "snapshot copy deletion started", nil
+		}
+	}
+
 	foundSnapshot, err := p.findSnapshotInstance(rdsSvc, snapshotName)
 
 	if err != nil {
@@ -208,41 +292,42 @@ func (p *PostgresSnapshotProvider) deletePostgresSnapshot(ctx context.Context, s
 }
 
 func (p *PostgresSnapshotProvider) findSnapshotInstance(rdsSvc rdsiface.RDSAPI, snapshotName string) (*rds.DBSnapshot, error) {
-	// check snapshot exists
-	listOutput, err := rdsSvc.DescribeDBSnapshots(&rds.DescribeDBSnapshotsInput{
-		DBSnapshotIdentifier: aws.String(snapshotName),
-	})
-	if err != nil {
-		rdsErr, isAwsErr := err.(awserr.Error)
-		if isAwsErr && rdsErr.Code() == "DBSnapshotNotFound" {
-			return nil, nil
-		}
-		return nil, err
-	}
-	var foundSnapshot *rds.DBSnapshot
-	for _, c := range listOutput.DBSnapshots {
-		if *c.DBSnapshotIdentifier == snapshotName {
-			foundSnapshot = c
-			break
-		}
-	}
-	return foundSnapshot, nil
+	return getRDSSnapshot(rdsSvc, snapshotName)
 }
 
-func (p *PostgresSnapshotProvider) createSessionForResource(ctx context.Context, namespace string, resourceType providers.ResourceType, tier string) (*session.Session, error) {
+// createSessionForResource returns the aws session for the resource region and, if cross region snapshot copy is
+// configured or the snapshot has already been copied to copyRegion, the secondary region snapshots are copied to
+func (p *PostgresSnapshotProvider) createSessionForResource(ctx context.Context, namespace string, resourceType providers.ResourceType, tier string, copyRegion string) (*session.Session, *rdsSnapshotCopyTarget, error) {
 
 	// create the credentials to be used by the aws resource providers, not to be used by end-user
 	providerCreds, err := p.CredentialManager.ReconcileProviderCredentials(ctx, namespace)
 	if err != nil {
-		return nil, errorUtil.Wrap(err, "failed to reconcile aws credentials")
+		return nil, nil, errorUtil.Wrap(err, "failed to reconcile aws credentials")
 	}
 
 	// get resource region
 	stratCfg, err := p.ConfigManager.ReadStorageStrategy(ctx, resourceType, tier)
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	sess, err := CreateSessionFromStrategy(ctx, p.client, providerCreds, stratCfg)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return CreateSessionFromStrategy(ctx, p.client, providerCreds, stratCfg)
+	// a snapshot which has been copied is always reconciled against the region it was copied to
+	copyStratCfg := *stratCfg
+	if copyRegion != "" {
+		copyStratCfg.CrossRegionSnapshotCopy = &CrossRegionSnapshotCopy{Region: copyRegion}
+		if stratCfg.CrossRegionSnapshotCopy != nil {
+			copyStratCfg.CrossRegionSnapshotCopy.KmsKeyID = stratCfg.CrossRegionSnapshotCopy.KmsKeyID
+		}
+	}
+	copyTarget, err := buildRDSSnapshotCopyTarget(ctx, p.client, providerCreds, &copyStratCfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sess, copyTarget, nil
 }
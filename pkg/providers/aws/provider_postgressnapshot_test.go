@@ -12,6 +12,7 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
 
@@ -32,10 +33,12 @@ type rdsClientMock struct {
 	DescribeDBSnapshotsFunc func(in1 *rds.DescribeDBSnapshotsInput) (*rds.DescribeDBSnapshotsOutput, error)
 	CreateDBSnapshotFunc    func(in1 *rds.CreateDBSnapshotInput) (*rds.CreateDBSnapshotOutput, error)
 	DeleteDBSnapshotFunc    func(in1 *rds.DeleteDBSnapshotInput) (*rds.DeleteDBSnapshotOutput, error)
+	CopyDBSnapshotFunc      func(in1 *rds.CopyDBSnapshotInput) (*rds.CopyDBSnapshotOutput, error)
 	calls                   struct {
 		DescribeDBSnapshots []struct{ In1 *rds.DescribeDBSnapshotsInput }
 		CreateDBSnapshot    []struct{ In1 *rds.CreateDBSnapshotInput }
 		DeleteDBSnapshot    []struct{ In1 *rds.DeleteDBSnapshotInput }
+		CopyDBSnapshot      []struct{ In1 *rds.CopyDBSnapshotInput }
 	}
 }
 
@@ -65,6 +68,19 @@ func (mock *rdsClientMock) DeleteDBSnapshot(in1 *rds.DeleteDBSnapshotInput) (*rd
 	return mock.DeleteDBSnapshotFunc(in1)
 }
 
+func (mock *rdsClientMock) CopyDBSnapshot(in1 *rds.CopyDBSnapshotInput) (*rds.CopyDBSnapshotOutput, error) {
+	if mock.CopyDBSnapshotFunc == nil {
+		panic("rdsClientMock.CopyDBSnapshot: method is nil but rdsClient.CopyDBSnapshot was just called")
+	}
+	callInfo := struct {
+		In1 *rds.CopyDBSnapshotInput
+	}{
+		In1: in1,
+	}
+	mock.calls.CopyDBSnapshot = append(mock.calls.CopyDBSnapshot, callInfo)
+	return mock.CopyDBSnapshotFunc(in1)
+}
+
 func (mock *rdsClientMock) DescribeDBSnapshots(in1 *rds.DescribeDBSnapshotsInput) (*rds.DescribeDBSnapshotsOutput, error) {
 	if mock.DescribeDBSnapshotsFunc == nil {
 		panic("rdsClientMock.DescribeDBSnapshotsFunc: method is nil but rdsClient.DescribeDBSnapshots was just called")
@@ -128,6 +144,7 @@ func TestAWSPostgresSnapshotProvider_createPostgresSnapshot(t *testing.T) {
 		snapshotCr *v1alpha1.PostgresSnapshot
 		postgresCr *v1alpha1.Postgres
 		rdsSvc     *rdsClientMock
+		copyTarget *rdsSnapshotCopyTarget
 	}
 	tests := []struct {
 		name         string
@@ -137,6 +154,7 @@ func TestAWSPostgresSnapshotProvider_createPostgresSnapshot(t *testing.T) {
 		wantMsg      croType.StatusMessage
 		wantErr      string
 		wantFn       func(mock *rdsClientMock) error
+		wantStatusFn func(status croType.ResourceTypeSnapshotStatus) error
 	}{
 		{
 			name: "test rds CreateDBSnapshot is called",
@@ -237,6 +255,135 @@ func TestAWSPostgresSnapshotProvider_createPostgresSnapshot(t *testing.T) {
 			},
 			wantMsg: "snapshot created",
 		},
+		{
+			name: "test snapshot arn and retention are recorded when the snapshot is available",
+			args: args{
+				ctx: context.TODO(),
+				snapshotCr: func() *v1alpha1.PostgresSnapshot {
+					snapshot := buildTestPostgresSnapshotCr()
+					snapshot.Spec.RetentionDays = 7
+					return snapshot
+				}(),
+				postgresCr: buildTestPostgresCR(),
+				rdsSvc: buildRdsClientMock(func(mock *rdsClientMock) {
+					mock.DescribeDBSnapshotsFunc = func(in *rds.DescribeDBSnapshotsInput) (*rds.DescribeDBSnapshotsOutput, error) {
+						return &rds.DescribeDBSnapshotsOutput{
+							DBSnapshots: []*rds.DBSnapshot{
+								{
+									DBSnapshotIdentifier: &testTimestampedIdentifier,
+									DBSnapshotArn:        aws.String(testSnapshotArn),
+									SnapshotCreateTime:   aws.Time(time.Date(2021, time.June, 16, 10, 30, 0, 0, time.UTC)),
+									Status:               aws.String("available"),
+								},
+							},
+						}, nil
+					}
+				}),
+			},
+			fields: fields{
+				Client:            fake.NewFakeClientWithScheme(scheme, buildTestPostgresCR(), buildTestPostgresSnapshotCr(), builtTestCredSecret(), buildTestInfra()),
+				Logger:            testLogger,
+				CredentialManager: nil,
+				ConfigManager:     nil,
+			},
+			wantSnapshot: &providers.PostgresSnapshotInstance{
+				Name: testTimestampedIdentifier,
+			},
+			wantMsg: "snapshot created",
+			wantStatusFn: func(status croType.ResourceTypeSnapshotStatus) error {
+				if status.SnapshotARN != testSnapshotArn {
+					return errors.New(fmt.Sprintf("wrong snapshot arn got = %s, want = %s", status.SnapshotARN, testSnapshotArn))
+				}
+				if status.RetainUntil != "2021-06-23T10:30:00Z" {
+					return errors.New(fmt.Sprintf("wrong retain until got = %s, want = 2021-06-23T10:30:00Z", status.RetainUntil))
+				}
+				return nil
+			},
+		},
+		{
+			name: "test snapshot is copied to the secondary region when cross region snapshot copy is configured",
+			args: args{
+				ctx:        context.TODO(),
+				snapshotCr: buildTestPostgresSnapshotCr(),
+				postgresCr: buildTestPostgresCR(),
+				rdsSvc: buildRdsClientMock(func(mock *rdsClientMock) {
+					mock.DescribeDBSnapshotsFunc = func(in *rds.DescribeDBSnapshotsInput) (*rds.DescribeDBSnapshotsOutput, error) {
+						return &rds.DescribeDBSnapshotsOutput{
+							DBSnapshots: []*rds.DBSnapshot{
+								{
+									DBSnapshotIdentifier: &testTimestampedIdentifier,
+									DBSnapshotArn:        aws.String(testSnapshotArn),
+									Status:               aws.String("available"),
+								},
+							},
+						}, nil
+					}
+				}),
+				copyTarget: buildTestRDSSnapshotCopyTarget(func(mock *rdsClientMock) {
+					mock.DescribeDBSnapshotsFunc = func(in *rds.DescribeDBSnapshotsInput) (*rds.DescribeDBSnapshotsOutput, error) {
+						return &rds.DescribeDBSnapshotsOutput{}, nil
+					}
+					mock.CopyDBSnapshotFunc = func(in *rds.CopyDBSnapshotInput) (*rds.CopyDBSnapshotOutput, error) {
+						return &rds.CopyDBSnapshotOutput{
+							DBSnapshot: &rds.DBSnapshot{
+								DBSnapshotIdentifier: in.TargetDBSnapshotIdentifier,
+								Status:               aws.String("creating"),
+							},
+						}, nil
+					}
+				}),
+			},
+			fields: fields{
+				Client:            fake.NewFakeClientWithScheme(scheme, buildTestPostgresCR(), buildTestPostgresSnapshotCr(), builtTestCredSecret(), buildTestInfra()),
+				Logger:            testLogger,
+				CredentialManager: nil,
+				ConfigManager:     nil,
+			},
+			wantSnapshot: nil,
+			wantMsg:      "snapshot created, copying to region " + testCopyRegion,
+		},
+		{
+			name: "test DBSnapshotInstance is returned when the snapshot copy is available",
+			args: args{
+				ctx:        context.TODO(),
+				snapshotCr: buildTestPostgresSnapshotCr(),
+				postgresCr: buildTestPostgresCR(),
+				rdsSvc: buildRdsClientMock(func(mock *rdsClientMock) {
+					mock.DescribeDBSnapshotsFunc = func(in *rds.DescribeDBSnapshotsInput) (*rds.DescribeDBSnapshotsOutput, error) {
+						return &rds.DescribeDBSnapshotsOutput{
+							DBSnapshots: []*rds.DBSnapshot{
+								{
+									DBSnapshotIdentifier: &testTimestampedIdentifier,
+									Status:               aws.String("available"),
+								},
+							},
+						}, nil
+					}
+				}),
+				copyTarget: buildTestRDSSnapshotCopyTarget(func(mock *rdsClientMock) {
+					mock.DescribeDBSnapshotsFunc = func(in *rds.DescribeDBSnapshotsInput) (*rds.DescribeDBSnapshotsOutput, error) {
+						return &rds.DescribeDBSnapshotsOutput{
+							DBSnapshots: []*rds.DBSnapshot{
+								{
+									DBSnapshotIdentifier: &testTimestampedIdentifier,
+									Status:               aws.String("available"),
+								},
+							},
+						}, nil
+					}
+				}),
+			},
+			fields: fields{
+				Client:            fake.NewFakeClientWithScheme(scheme, buildTestPostgresCR(), buildTestPostgresSnapshotCr(), builtTestCredSecret(), buildTestInfra()),
+				Logger:            testLogger,
+				CredentialManager: nil,
+				ConfigManager:     nil,
+			},
+			wantSnapshot: &providers.PostgresSnapshotInstance{
+				Name: testTimestampedIdentifier,
+			},
+			wantMsg: "snapshot created",
+		},
 		{
 			name: "test snapshot instance not returned when status is not available",
 			args: args{
@@ -288,6 +435,50 @@ func TestAWSPostgresSnapshotProvider_createPostgresSnapshot(t *testing.T) {
 			wantMsg: "failed to describe snaphots in AWS",
 			wantErr: "failed to describe snaphots in AWS: ",
 		},
+		{
+			name: "test a snapshot requested before the operator shut down is resumed",
+			args: args{
+				ctx: context.TODO(),
+				snapshotCr: func() *v1alpha1.PostgresSnapshot {
+					snapshot := buildTestPostgresSnapshotCr()
+					snapshot.Status.Checkpoint = &croType.OperationCheckpoint{
+						Operation:  providers.OperationCreateSnapshot,
+						Step:       "CreateDBSnapshot",
+						SnapshotID: "resumed-snapshot",
+					}
+					return snapshot
+				}(),
+				postgresCr: buildTestPostgresCR(),
+				rdsSvc: buildRdsClientMock(func(mock *rdsClientMock) {
+					mock.DescribeDBSnapshotsFunc = func(in *rds.DescribeDBSnapshotsInput) (*rds.DescribeDBSnapshotsOutput, error) {
+						return &rds.DescribeDBSnapshotsOutput{}, nil
+					}
+					mock.CreateDBSnapshotFunc = func(in *rds.CreateDBSnapshotInput) (*rds.CreateDBSnapshotOutput, error) {
+						return nil, awserr.New(rds.ErrCodeDBSnapshotAlreadyExistsFault, "already exists", nil)
+					}
+				}),
+			},
+			fields: fields{
+				Client:            fake.NewFakeClientWithScheme(scheme, buildTestPostgresCR(), buildTestPostgresSnapshotCr(), builtTestCredSecret(), buildTestInfra()),
+				Logger:            testLogger,
+				CredentialManager: nil,
+				ConfigManager:     nil,
+			},
+			wantSnapshot: nil,
+			wantMsg:      "snapshot started",
+			wantFn: func(mock *rdsClientMock) error {
+				if len(mock.calls.CreateDBSnapshot) != 1 || aws.StringValue(mock.calls.CreateDBSnapshot[0].In1.DBSnapshotIdentifier) != "resumed-snapshot" {
+					return errors.New("CreateDBSnapshot was not called for the checkpointed snapshot")
+				}
+				return nil
+			},
+			wantStatusFn: func(status croType.ResourceTypeSnapshotStatus) error {
+				if status.SnapshotID != "resumed-snapshot" {
+					return fmt.Errorf("snapshot id = %s, want the checkpointed snapshot", status.SnapshotID)
+				}
+				return nil
+			},
+		},
 		{
 			name: "test an error occurs when CreateDbSnapshot fails",
 			args: args{
@@ -384,7 +575,7 @@ func TestAWSPostgresSnapshotProvider_createPostgresSnapshot(t *testing.T) {
 				CredentialManager: tt.fields.CredentialManager,
 				ConfigManager:     tt.fields.ConfigManager,
 			}
-			gotSnapshot, gotMsg, err := p.createPostgresSnapshot(tt.args.ctx, tt.args.snapshotCr, tt.args.postgresCr, tt.args.rdsSvc)
+			gotSnapshot, gotMsg, err := p.createPostgresSnapshot(tt.args.ctx, tt.args.snapshotCr, tt.args.postgresCr, tt.args.rdsSvc, tt.args.copyTarget)
 			if err != nil && err.Error() != tt.wantErr {
 				t.Errorf("createPostgresSnapshot() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -400,6 +591,11 @@ func TestAWSPostgresSnapshotProvider_createPostgresSnapshot(t *testing.T) {
 					t.Errorf("createPostgresSnapshot() err = %v", err)
 				}
 			}
+			if tt.wantStatusFn != nil {
+				if err := tt.wantStatusFn(tt.args.snapshotCr.Status); err != nil {
+					t.Errorf("createPostgresSnapshot() err = %v", err)
+				}
+			}
 		})
 	}
 }
@@ -432,6 +628,7 @@ func TestAWSPostgresSnapshotProvider_deletePostgresSnapshot(t *testing.T) {
 		snapshotCr *v1alpha1.PostgresSnapshot
 		postgresCr *v1alpha1.Postgres
 		rdsSvc     *rdsClientMock
+		copyTarget *rdsSnapshotCopyTarget
 	}
 	tests := []struct {
 		name    string
@@ -492,6 +689,53 @@ func TestAWSPostgresSnapshotProvider_deletePostgresSnapshot(t *testing.T) {
 				return nil
 			},
 		},
+		{
+			name: "test snapshot copy DeleteDBSnapshot is called before the source snapshot is deleted",
+			args: args{
+				ctx: context.TODO(),
+				snapshotCr: &v1alpha1.PostgresSnapshot{
+					ObjectMeta: controllerruntime.ObjectMeta{
+						Name:      "test",
+						Namespace: "test",
+					},
+					Status: croType.ResourceTypeSnapshotStatus{
+						SnapshotID:                testTimestampedIdentifier,
+						CrossRegionSnapshotID:     testTimestampedIdentifier,
+						CrossRegionSnapshotRegion: testCopyRegion,
+					},
+				},
+				postgresCr: buildTestPostgresCR(),
+				rdsSvc:     buildRdsClientMock(nil),
+				copyTarget: buildTestRDSSnapshotCopyTarget(func(mock *rdsClientMock) {
+					mock.DescribeDBSnapshotsFunc = func(in *rds.DescribeDBSnapshotsInput) (*rds.DescribeDBSnapshotsOutput, error) {
+						return &rds.DescribeDBSnapshotsOutput{
+							DBSnapshots: []*rds.DBSnapshot{
+								{
+									DBSnapshotIdentifier: &testTimestampedIdentifier,
+									Status:               aws.String("available"),
+								},
+							},
+						}, nil
+					}
+					mock.DeleteDBSnapshotFunc = func(in *rds.DeleteDBSnapshotInput) (*rds.DeleteDBSnapshotOutput, error) {
+						return &rds.DeleteDBSnapshotOutput{}, nil
+					}
+				}),
+			},
+			fields: fields{
+				Client:            fakeClient,
+				Logger:            testLogger,
+				CredentialManager: nil,
+				ConfigManager:     nil,
+			},
+			want: "snapshot copy deletion started",
+			wantFn: func(mock *rdsClientMock) error {
+				if len(mock.calls.DeleteDBSnapshot) != 0 {
+					return errors.New("DeleteDBSnapshot was called for the source snapshot")
+				}
+				return nil
+			},
+		},
 		{
 			name: "test returns snapshot deleted when snapshot instance is not found",
 			args: args{
@@ -588,7 +832,7 @@ func TestAWSPostgresSnapshotProvider_deletePostgresSnapshot(t *testing.T) {
 				CredentialManager: tt.fields.CredentialManager,
 				ConfigManager:     tt.fields.ConfigManager,
 			}
-			got, err := p.deletePostgresSnapshot(tt.args.ctx, tt.args.snapshotCr, tt.args.postgresCr, tt.args.rdsSvc)
+			got, err := p.deletePostgresSnapshot(tt.args.ctx, tt.args.snapshotCr, tt.args.postgresCr, tt.args.rdsSvc, tt.args.copyTarget)
 			if err != nil && err.Error() != tt.wantErr {
 				t.Errorf("deletePostgresSnapshot() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -0,0 +1,470 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/annotations"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	v1 "github.com/openshift/cloud-credential-operator/pkg/apis/cloudcredential/v1"
+	errorUtil "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//provider name and default create options
+const (
+	queueProviderName                  = "aws-sqs"
+	defaultAwsQueueNameLength          = 40
+	DetailsQueueURL                    = "queueURL"
+	DetailsQueueARN                    = "queueARN"
+	DetailsQueueRegion                 = "queueRegion"
+	DetailsQueueDeadLetterQueueURL     = "deadLetterQueueURL"
+	DetailsQueueCredentialKeyID        = "credentialKeyID"
+	DetailsQueueCredentialSecretKey    = "credentialSecretKey"
+	deadLetterQueueNameSuffix          = "-dlq"
+	defaultDeadLetterQueueMaxReceives  = 5
+	defaultDeadLetterQueueRetentionSec = 1209600
+)
+
+// QueueDeploymentDetails Provider-specific details about the AWS SQS queue created
+type QueueDeploymentDetails struct {
+	QueueURL            string
+	QueueARN            string
+	QueueRegion         string
+	DeadLetterQueueURL  string
+	CredentialKeyID     string
+	CredentialSecretKey string
+}
+
+func (d *QueueDeploymentDetails) Data() map[string][]byte {
+	return map[string][]byte{
+		DetailsQueueURL:                 []byte(d.QueueURL),
+		DetailsQueueARN:                 []byte(d.QueueARN),
+		DetailsQueueRegion:              []byte(d.QueueRegion),
+		DetailsQueueDeadLetterQueueURL:  []byte(d.DeadLetterQueueURL),
+		DetailsQueueCredentialKeyID:     []byte(d.CredentialKeyID),
+		DetailsQueueCredentialSecretKey: []byte(d.CredentialSecretKey),
+	}
+}
+
+/*
+SQSCreateStrat custom sqs create strat
+VisibilityTimeout -> seconds a received message is hidden from other consumers, the sqs default of 30 is used if unset
+MessageRetentionPeriod -> seconds a message is kept for, the sqs default of 4 days is used if unset
+DeadLetterQueue -> the queue messages are moved to once they're received too many times, enabled by default
+*/
+type SQSCreateStrat struct {
+	VisibilityTimeout      *int64                   `json:"visibilityTimeout,omitempty"`
+	MessageRetentionPeriod *int64                   `json:"messageRetentionPeriod,omitempty"`
+	DeadLetterQueue        *SQSDeadLetterQueueStrat `json:"deadLetterQueue,omitempty"`
+}
+
+/*
+SQSDeadLetterQueueStrat configures the dead letter queue of a queue
+Disabled -> don't create a dead letter queue, messages are received until they expire
+MaxReceiveCount -> times a message is received before it's moved to the dead letter queue, defaults to 5
+MessageRetentionPeriod -> seconds a message is kept in the dead letter queue for, defaults to the maximum of 14 days so
+failed messages can be inspected
+*/
+type SQSDeadLetterQueueStrat struct {
+	Disabled               bool   `json:"disabled,omitempty"`
+	MaxReceiveCount        *int64 `json:"maxReceiveCount,omitempty"`
+	MessageRetentionPeriod *int64 `json:"messageRetentionPeriod,omitempty"`
+}
+
+// sqsQueue identifies a queue reconciled by the provider
+type sqsQueue struct {
+	URL string
+	ARN string
+}
+
+var _ providers.QueueProvider = (*QueueProvider)(nil)
+
+// QueueProvider implementation for AWS SQS
+type QueueProvider struct {
+	Client            client.Client
+	Logger            *logrus.Entry
+	CredentialManager CredentialManager
+	ConfigManager     ConfigManager
+}
+
+func NewAWSQueueProvider(client client.Client, logger *logrus.Entry) (*QueueProvider, error) {
+	cm, err := NewCredentialManager(client)
+	if err != nil {
+		return nil, err
+	}
+	return &QueueProvider{
+		Client:            client,
+		Logger:            logger.WithFields(logrus.Fields{"provider": queueProviderName}),
+		CredentialManager: cm,
+		ConfigManager:     NewDefaultConfigMapConfigManager(client),
+	}, nil
+}
+
+func (p *QueueProvider) GetName() string {
+	return queueProviderName
+}
+
+func (p *QueueProvider) SupportsStrategy(d string) bool {
+	return d == providers.AWSDeploymentStrategy
+}
+
+func (p *QueueProvider) GetReconcileTime(q *v1alpha1.Queue) time.Duration {
+	if q.Status.Phase != croType.PhaseComplete {
+		return time.Second * 60
+	}
+	return resources.GetForcedReconcileTimeOrDefault(defaultReconcileTime)
+}
+
+// CreateQueue Create SQS queue and its dead letter queue from strategy config and credentials to send and receive
+// their messages
+func (p *QueueProvider) CreateQueue(ctx context.Context, q *v1alpha1.Queue) (*providers.QueueInstance, croType.StatusMessage, error) {
+	// handle provider-specific finalizer
+	if err := resources.CreateFinalizer(ctx, p.Client, q, DefaultFinalizer); err != nil {
+		return nil, "failed to set finalizer", err
+	}
+
+	// info about the queue to be created
+	p.Logger.Infof("getting aws sqs queue config for queue instance %s", q.Name)
+	queueName, queueCreateCfg, stratCfg, err := p.buildSQSQueueConfig(ctx, q)
+	if err != nil {
+		errMsg := "failed to build sqs queue config"
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+
+	// create the credentials to be used by the aws resource providers, not to be used by end-user
+	p.Logger.Infof("creating provider credentials for creating sqs queues, in namespace %s", q.Namespace)
+	providerCreds, err := p.CredentialManager.ReconcileProviderCredentials(ctx, q.Namespace)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to reconcile aws queue provider credentials for queue instance %s", q.Name)
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrapf(err, errMsg)
+	}
+
+	// setup aws sqs sdk session
+	p.Logger.Infof("creating new aws sdk session in region %s", stratCfg.Region)
+	sess, err := CreateSessionFromStrategy(ctx, p.Client, providerCreds, stratCfg)
+	if err != nil {
+		errMsg := "failed to create aws session to create sqs queue"
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+
+	// resolve the customer managed key the queues are encrypted with, if the strategy configures one
+	kmsKeyARN, err := reconcileKMSKey(ctx, p.Client, newKMSClient(sess), stratCfg.Encryption)
+	if err != nil {
+		errMsg := "failed to reconcile kms key to encrypt sqs queue"
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+
+	tags, _, err := getDefaultResourceTags(ctx, p.Client, q.Spec.Type, q.Name, q.ObjectMeta.Labels["productName"])
+	if err != nil {
+		errMsg := "failed to build default tags"
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+
+	// create the queues if they don't already exist, if they do exist then update their attributes
+	p.Logger.Infof("reconciling aws sqs queue %s", queueName)
+	queue, deadLetterQueue, msg, err := p.reconcileQueueCreate(ctx, q, newSQSClient(sess), queueName, queueCreateCfg, kmsKeyARN, genericToSQSTags(tags))
+	if err != nil {
+		return nil, msg, errorUtil.Wrapf(err, string(msg))
+	}
+
+	// create the credentials to be used by the end-user, scoped to the messages of the queues
+	queueARNs := []string{queue.ARN}
+	if deadLetterQueue != nil {
+		queueARNs = append(queueARNs, deadLetterQueue.ARN)
+	}
+	endUserCredsName := buildEndUserCredentialsNameFromQueue(queueName)
+	p.Logger.Infof("creating end-user credentials with name %s for using sqs queue %s", endUserCredsName, queueName)
+	endUserCreds, err := p.CredentialManager.ReconcileQueueOwnerCredentials(ctx, endUserCredsName, q.Namespace, queueARNs)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to reconcile sqs end-user credentials for queue instance %s", q.Name)
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrapf(err, errMsg)
+	}
+
+	details := &QueueDeploymentDetails{
+		QueueURL:    queue.URL,
+		QueueARN:    queue.ARN,
+		QueueRegion: stratCfg.Region,
+	}
+	if deadLetterQueue != nil {
+		details.DeadLetterQueueURL = deadLetterQueue.URL
+	}
+	// the sts credential manager doesn't create end-user credentials, the workloads use their own role
+	if endUserCreds != nil {
+		details.CredentialKeyID = endUserCreds.AccessKeyID
+		details.CredentialSecretKey = endUserCreds.SecretAccessKey
+	}
+
+	q.Status.CloudResource = buildCloudResourceStatus(aws.String(queueName), aws.String(queue.ARN), nil, nil)
+	q.Status.CloudResource.KmsKeyARN = kmsKeyARN
+	p.Logger.Infof("creation handler for queue instance %s in namespace %s finished successfully", q.Name, q.Namespace)
+	return &providers.QueueInstance{DeploymentDetails: details, Artifacts: buildAccessKeyArtifacts(endUserCredsName, endUserCreds)}, msg, nil
+}
+
+func (p *QueueProvider) reconcileQueueCreate(ctx context.Context, q *v1alpha1.Queue, sqsSvc sqsAPI, queueName string, queueCfg *SQSCreateStrat, kmsKeyARN string, tags map[string]*string) (*sqsQueue, *sqsQueue, croType.StatusMessage, error) {
+	found, err := getSQSQueueURL(sqsSvc, queueName)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to get sqs queue %s", queueName)
+		return nil, nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+
+	// if the queue isn't found but the CR already has a resourceIdentifier
+	// annotation, then we expect it to be there. We shouldn't create it again, its messages are lost.
+	if found == "" && annotations.Has(q, ResourceIdentifierAnnotation) {
+		errMsg := fmt.Sprintf("Queue CR %s in %s namespace has %s annotation with value %s, but no corresponding SQS Queue was found",
+			q.Name, q.Namespace, ResourceIdentifierAnnotation, q.ObjectMeta.Annotations[ResourceIdentifierAnnotation])
+		return nil, nil, croType.StatusMessage(errMsg), fmt.Errorf(errMsg)
+	}
+
+	// the dead letter queue is reconciled first, the redrive policy of the queue references it
+	var deadLetterQueue *sqsQueue
+	redrivePolicy := ""
+	if !queueCfg.DeadLetterQueue.Disabled {
+		deadLetterQueueName := buildDeadLetterQueueName(queueName)
+		attributes := buildSQSQueueAttributes(kmsKeyARN)
+		attributes[sqsAttributeMessageRetentionPeriod] = aws.String(strconv.FormatInt(*queueCfg.DeadLetterQueue.MessageRetentionPeriod, 10))
+		dlq, err := reconcileSQSQueue(sqsSvc, deadLetterQueueName, attributes, tags)
+		if err != nil {
+			errMsg := fmt.Sprintf("failed to reconcile sqs dead letter queue %s", deadLetterQueueName)
+			return nil, nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+		deadLetterQueue = dlq
+		policy, err := json.Marshal(map[string]string{
+			"deadLetterTargetArn": dlq.ARN,
+			"maxReceiveCount":     strconv.FormatInt(*queueCfg.DeadLetterQueue.MaxReceiveCount, 10),
+		})
+		if err != nil {
+			errMsg := "failed to marshal sqs redrive policy"
+			return nil, nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+		redrivePolicy = string(policy)
+	}
+
+	// an empty redrive policy removes the dead letter queue of an existing queue
+	attributes := buildSQSQueueAttributes(kmsKeyARN)
+	attributes[sqsAttributeRedrivePolicy] = aws.String(redrivePolicy)
+	if queueCfg.VisibilityTimeout != nil {
+		attributes[sqsAttributeVisibilityTimeout] = aws.String(strconv.FormatInt(*queueCfg.VisibilityTimeout, 10))
+	}
+	if queueCfg.MessageRetentionPeriod != nil {
+		attributes[sqsAttributeMessageRetentionPeriod] = aws.String(strconv.FormatInt(*queueCfg.MessageRetentionPeriod, 10))
+	}
+
+	queue, err := reconcileSQSQueue(sqsSvc, queueName, attributes, tags)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to reconcile sqs queue %s", queueName)
+		return nil, nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+
+	if !annotations.Has(q, ResourceIdentifierAnnotation) {
+		annotations.Add(q, ResourceIdentifierAnnotation, queueName)
+		if err := p.Client.Update(ctx, q); err != nil {
+			errMsg := "failed to add annotation"
+			return nil, nil, croType.StatusMessage(errMsg), errorUtil.Wrapf(err, errMsg)
+		}
+	}
+
+	p.Logger.Infof("reconcile for aws sqs queue completed successfully")
+	return queue, deadLetterQueue, "successfully reconciled", nil
+}
+
+// DeleteQueue Delete SQS queue, its dead letter queue and the credentials to use them
+func (p *QueueProvider) DeleteQueue(ctx context.Context, q *v1alpha1.Queue) (croType.StatusMessage, error) {
+	p.Logger.Infof("deleting queue instance %s via aws sqs", q.Name)
+
+	// resolve queue information for queue created by provider
+	p.Logger.Infof("getting aws sqs queue config for queue instance %s", q.Name)
+	queueName, _, stratCfg, err := p.buildSQSQueueConfig(ctx, q)
+	if err != nil {
+		errMsg := "failed to build sqs queue config"
+		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+
+	// get provider aws creds so the queues can be deleted
+	p.Logger.Infof("creating provider credentials for deleting sqs queues, in namespace %s", q.Namespace)
+	providerCreds, err := p.CredentialManager.ReconcileProviderCredentials(ctx, q.Namespace)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to reconcile aws provider credentials for queue instance %s", q.Name)
+		return croType.StatusMessage(errMsg), errorUtil.Wrapf(err, errMsg)
+	}
+
+	// create new sqs session
+	p.Logger.Infof("creating new aws sdk session in region %s", stratCfg.Region)
+	sess, err := CreateSessionFromStrategy(ctx, p.Client, providerCreds, stratCfg)
+	if err != nil {
+		errMsg := "failed to create aws session to delete sqs queue"
+		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+
+	return p.reconcileQueueDelete(ctx, q, newSQSClient(sess), queueName)
+}
+
+func (p *QueueProvider) reconcileQueueDelete(ctx context.Context, q *v1alpha1.Queue, sqsSvc sqsAPI, queueName string) (croType.StatusMessage, error) {
+	// the dead letter queue is deleted even if the strategy no longer configures one, it may have been created earlier
+	for _, name := range []string{queueName, buildDeadLetterQueueName(queueName)} {
+		queueURL, err := getSQSQueueURL(sqsSvc, name)
+		if err != nil {
+			errMsg := fmt.Sprintf("failed to get sqs queue %s", name)
+			return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+		if queueURL == "" {
+			p.Logger.Infof("could not find sqs queue %s, already deleted, continuing", name)
+			continue
+		}
+		p.Logger.Infof("deleting sqs queue %s", name)
+		if err := sqsSvc.DeleteQueue(&sqsDeleteQueueInput{QueueUrl: aws.String(queueURL)}); err != nil {
+			errMsg := fmt.Sprintf("failed to delete sqs queue %s", name)
+			return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+	}
+
+	// remove the credentials request created by the provider
+	endUserCredsName := buildEndUserCredentialsNameFromQueue(queueName)
+	p.Logger.Infof("deleting end-user credential request %s in namespace %s", endUserCredsName, q.Namespace)
+	endUserCredsReq := &v1.CredentialsRequest{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name:      endUserCredsName,
+			Namespace: q.Namespace,
+		},
+	}
+	if err := p.Client.Delete(ctx, endUserCredsReq); err != nil {
+		if !errors.IsNotFound(err) {
+			errMsg := fmt.Sprintf("failed to delete credential request %s", endUserCredsName)
+			return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+		p.Logger.Infof("could not find credential request %s, already deleted, continuing", endUserCredsName)
+	}
+
+	// remove the finalizer
+	resources.RemoveFinalizer(&q.ObjectMeta, DefaultFinalizer)
+	if err := p.Client.Update(ctx, q); err != nil {
+		errMsg := "failed to update queue cr as part of finalizer reconcile"
+		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	return croType.StatusEmpty, nil
+}
+
+func (p *QueueProvider) buildSQSQueueConfig(ctx context.Context, q *v1alpha1.Queue) (string, *SQSCreateStrat, *StrategyConfig, error) {
+	stratCfg, err := p.ConfigManager.ReadStorageStrategy(ctx, providers.QueueResourceType, q.Spec.Tier)
+	if err != nil {
+		return "", nil, nil, errorUtil.Wrap(err, "failed to read aws strategy config")
+	}
+
+	defRegion, err := GetRegionFromStrategyOrDefault(ctx, p.Client, stratCfg)
+	if err != nil {
+		return "", nil, nil, errorUtil.Wrap(err, "failed to get default region")
+	}
+	if stratCfg.Region == "" {
+		p.Logger.Debugf("region not set in deployment strategy configuration, using default region %s", defRegion)
+		stratCfg.Region = defRegion
+	}
+
+	// create sqs queue config created by the provider
+	sqsCreateConfig := &SQSCreateStrat{}
+	if err = json.Unmarshal(stratCfg.CreateStrategy, sqsCreateConfig); err != nil {
+		return "", nil, nil, errorUtil.Wrap(err, "failed to unmarshal aws sqs create strat configuration")
+	}
+	if sqsCreateConfig.DeadLetterQueue == nil {
+		sqsCreateConfig.DeadLetterQueue = &SQSDeadLetterQueueStrat{}
+	}
+	if sqsCreateConfig.DeadLetterQueue.MaxReceiveCount == nil {
+		sqsCreateConfig.DeadLetterQueue.MaxReceiveCount = aws.Int64(defaultDeadLetterQueueMaxReceives)
+	}
+	if sqsCreateConfig.DeadLetterQueue.MessageRetentionPeriod == nil {
+		sqsCreateConfig.DeadLetterQueue.MessageRetentionPeriod = aws.Int64(defaultDeadLetterQueueRetentionSec)
+	}
+
+	// cluster infra info
+	queueName, err := BuildInfraNameFromObject(ctx, p.Client, q.ObjectMeta, defaultAwsQueueNameLength)
+	if err != nil {
+		return "", nil, nil, errorUtil.Wrapf(err, "failed to build sqs queue name for queue instance %s", q.Name)
+	}
+	return queueName, sqsCreateConfig, stratCfg, nil
+}
+
+// buildSQSQueueAttributes returns the attributes every queue is created with, queues are encrypted with the customer
+// managed key if the strategy configures one, otherwise with sqs managed keys
+func buildSQSQueueAttributes(kmsKeyARN string) map[string]*string {
+	if kmsKeyARN != "" {
+		return map[string]*string{sqsAttributeKmsMasterKeyID: aws.String(kmsKeyARN)}
+	}
+	return map[string]*string{sqsAttributeSqsManagedSseEnabled: aws.String("true")}
+}
+
+// reconcileSQSQueue creates the queue if it doesn't exist, otherwise its attributes and tags are updated, reverting
+// any changes made outside the operator
+func reconcileSQSQueue(sqsSvc sqsAPI, name string, attributes, tags map[string]*string) (*sqsQueue, error) {
+	queueURL, err := getSQSQueueURL(sqsSvc, name)
+	if err != nil {
+		return nil, err
+	}
+	if queueURL == "" {
+		out, err := sqsSvc.CreateQueue(&sqsCreateQueueInput{
+			QueueName:  aws.String(name),
+			Attributes: withoutEmptyAttributes(attributes),
+			Tags:       tags,
+		})
+		if err != nil {
+			return nil, errorUtil.Wrapf(err, "failed to create sqs queue %s", name)
+		}
+		queueURL = aws.StringValue(out.QueueUrl)
+	} else {
+		if err := sqsSvc.SetQueueAttributes(&sqsSetQueueAttributesInput{QueueUrl: aws.String(queueURL), Attributes: attributes}); err != nil {
+			return nil, errorUtil.Wrapf(err, "failed to set attributes of sqs queue %s", name)
+		}
+		if err := sqsSvc.TagQueue(&sqsTagQueueInput{QueueUrl: aws.String(queueURL), Tags: tags}); err != nil {
+			return nil, errorUtil.Wrapf(err, "failed to tag sqs queue %s", name)
+		}
+	}
+
+	out, err := sqsSvc.GetQueueAttributes(&sqsGetQueueAttributesInput{
+		QueueUrl:       aws.String(queueURL),
+		AttributeNames: aws.StringSlice([]string{sqsAttributeQueueArn}),
+	})
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to get arn of sqs queue %s", name)
+	}
+	return &sqsQueue{URL: queueURL, ARN: aws.StringValue(out.Attributes[sqsAttributeQueueArn])}, nil
+}
+
+// getSQSQueueURL returns the url of the queue, or an empty string if it doesn't exist
+func getSQSQueueURL(sqsSvc sqsAPI, name string) (string, error) {
+	out, err := sqsSvc.GetQueueUrl(&sqsGetQueueURLInput{QueueName: aws.String(name)})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == sqsErrCodeQueueDoesNotExist {
+			return "", nil
+		}
+		return "", err
+	}
+	return aws.StringValue(out.QueueUrl), nil
+}
+
+// withoutEmptyAttributes removes the attributes only set to be cleared on existing queues
+func withoutEmptyAttributes(attributes map[string]*string) map[string]*string {
+	filtered := map[string]*string{}
+	for k, v := range attributes {
+		if aws.StringValue(v) != "" {
+			filtered[k] = v
+		}
+	}
+	return filtered
+}
+
+func buildDeadLetterQueueName(queueName string) string {
+	return queueName + deadLetterQueueNameSuffix
+}
+
+func buildEndUserCredentialsNameFromQueue(q string) string {
+	return fmt.Sprintf("cro-aws-sqs-%s-creds", q)
+}
@@ -0,0 +1,225 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type mockSQSQueue struct {
+	attributes map[string]*string
+	tags       map[string]*string
+}
+
+type mockSQSSvc struct {
+	queues  map[string]*mockSQSQueue
+	created []string
+	deleted []string
+}
+
+func buildTestSQSQueueURL(name string) string {
+	return "https://sqs.eu-west-1.amazonaws.com/123456789012/" + name
+}
+
+func (s *mockSQSSvc) queueName(queueURL *string) string {
+	return aws.StringValue(queueURL)[len(buildTestSQSQueueURL("")):]
+}
+
+func (s *mockSQSSvc) CreateQueue(input *sqsCreateQueueInput) (*sqsCreateQueueOutput, error) {
+	name := aws.StringValue(input.QueueName)
+	s.queues[name] = &mockSQSQueue{attributes: input.Attributes, tags: input.Tags}
+	s.created = append(s.created, name)
+	return &sqsCreateQueueOutput{QueueUrl: aws.String(buildTestSQSQueueURL(name))}, nil
+}
+
+func (s *mockSQSSvc) GetQueueUrl(input *sqsGetQueueURLInput) (*sqsGetQueueURLOutput, error) {
+	name := aws.StringValue(input.QueueName)
+	if _, ok := s.queues[name]; !ok {
+		return nil, awserr.New(sqsErrCodeQueueDoesNotExist, "The specified queue does not exist.", nil)
+	}
+	return &sqsGetQueueURLOutput{QueueUrl: aws.String(buildTestSQSQueueURL(name))}, nil
+}
+
+func (s *mockSQSSvc) GetQueueAttributes(input *sqsGetQueueAttributesInput) (*sqsGetQueueAttributesOutput, error) {
+	name := s.queueName(input.QueueUrl)
+	return &sqsGetQueueAttributesOutput{Attributes: map[string]*string{
+		sqsAttributeQueueArn: aws.String(fmt.Sprintf("arn:aws:sqs:eu-west-1:123456789012:%s", name)),
+	}}, nil
+}
+
+func (s *mockSQSSvc) SetQueueAttributes(input *sqsSetQueueAttributesInput) error {
+	queue := s.queues[s.queueName(input.QueueUrl)]
+	for k, v := range input.Attributes {
+		queue.attributes[k] = v
+	}
+	return nil
+}
+
+func (s *mockSQSSvc) TagQueue(input *sqsTagQueueInput) error {
+	s.queues[s.queueName(input.QueueUrl)].tags = input.Tags
+	return nil
+}
+
+func (s *mockSQSSvc) DeleteQueue(input *sqsDeleteQueueInput) error {
+	name := s.queueName(input.QueueUrl)
+	delete(s.queues, name)
+	s.deleted = append(s.deleted, name)
+	return nil
+}
+
+func buildTestQueueCR() *v1alpha1.Queue {
+	return &v1alpha1.Queue{
+		ObjectMeta: v1.ObjectMeta{
+			Name:            "test",
+			Namespace:       "test",
+			ResourceVersion: fakeResourceVersion,
+			Finalizers:      []string{DefaultFinalizer},
+		},
+	}
+}
+
+func buildTestSQSCreateStrat(deadLetterQueueDisabled bool) *SQSCreateStrat {
+	return &SQSCreateStrat{
+		VisibilityTimeout: aws.Int64(120),
+		DeadLetterQueue: &SQSDeadLetterQueueStrat{
+			Disabled:               deadLetterQueueDisabled,
+			MaxReceiveCount:        aws.Int64(3),
+			MessageRetentionPeriod: aws.Int64(defaultDeadLetterQueueRetentionSec),
+		},
+	}
+}
+
+func TestQueueProvider_reconcileQueueCreate(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build test scheme", err)
+	}
+	tests := []struct {
+		name              string
+		queue             *v1alpha1.Queue
+		sqsSvc            *mockSQSSvc
+		queueCfg          *SQSCreateStrat
+		kmsKeyARN         string
+		wantCreated       []string
+		wantRedrivePolicy string
+		wantErr           bool
+	}{
+		{
+			name:              "test queue and dead letter queue are created",
+			queue:             buildTestQueueCR(),
+			sqsSvc:            &mockSQSSvc{queues: map[string]*mockSQSQueue{}},
+			queueCfg:          buildTestSQSCreateStrat(false),
+			wantCreated:       []string{"test-queue-dlq", "test-queue"},
+			wantRedrivePolicy: `{"deadLetterTargetArn":"arn:aws:sqs:eu-west-1:123456789012:test-queue-dlq","maxReceiveCount":"3"}`,
+		},
+		{
+			name:  "test existing queue attributes are updated and its dead letter queue removed",
+			queue: buildTestQueueCR(),
+			sqsSvc: &mockSQSSvc{queues: map[string]*mockSQSQueue{
+				"test-queue": {attributes: map[string]*string{
+					sqsAttributeVisibilityTimeout: aws.String("30"),
+					sqsAttributeRedrivePolicy:     aws.String(`{"deadLetterTargetArn":"arn:aws:sqs:eu-west-1:123456789012:test-queue-dlq","maxReceiveCount":"3"}`),
+				}},
+			}},
+			queueCfg:          buildTestSQSCreateStrat(true),
+			kmsKeyARN:         testKmsKeyArn,
+			wantRedrivePolicy: "",
+		},
+		{
+			name: "test error if the identified queue doesn't exist",
+			queue: func() *v1alpha1.Queue {
+				q := buildTestQueueCR()
+				q.Annotations = map[string]string{ResourceIdentifierAnnotation: "test-queue"}
+				return q
+			}(),
+			sqsSvc:   &mockSQSSvc{queues: map[string]*mockSQSQueue{}},
+			queueCfg: buildTestSQSCreateStrat(false),
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &QueueProvider{
+				Client:            fake.NewFakeClientWithScheme(scheme, tt.queue),
+				Logger:            logrus.WithFields(logrus.Fields{}),
+				CredentialManager: &CredentialManagerMock{},
+				ConfigManager:     &ConfigManagerMock{},
+			}
+			queue, deadLetterQueue, _, err := p.reconcileQueueCreate(context.TODO(), tt.queue, tt.sqsSvc, "test-queue", tt.queueCfg, tt.kmsKeyARN, map[string]*string{tagManagedKey: aws.String(tagManagedVal)})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("reconcileQueueCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if len(tt.sqsSvc.created) != 0 {
+					t.Errorf("reconcileQueueCreate() created queues %v, want none", tt.sqsSvc.created)
+				}
+				return
+			}
+			if fmt.Sprint(tt.sqsSvc.created) != fmt.Sprint(tt.wantCreated) {
+				t.Errorf("reconcileQueueCreate() created queues %v, want %v", tt.sqsSvc.created, tt.wantCreated)
+			}
+			if queue.URL != buildTestSQSQueueURL("test-queue") {
+				t.Errorf("reconcileQueueCreate() queue url = %s", queue.URL)
+			}
+			if (deadLetterQueue != nil) == tt.queueCfg.DeadLetterQueue.Disabled {
+				t.Errorf("reconcileQueueCreate() dead letter queue = %v, disabled %v", deadLetterQueue, tt.queueCfg.DeadLetterQueue.Disabled)
+			}
+			attributes := tt.sqsSvc.queues["test-queue"].attributes
+			if got := aws.StringValue(attributes[sqsAttributeRedrivePolicy]); got != tt.wantRedrivePolicy {
+				t.Errorf("reconcileQueueCreate() redrive policy = %s, want %s", got, tt.wantRedrivePolicy)
+			}
+			if got := aws.StringValue(attributes[sqsAttributeVisibilityTimeout]); got != "120" {
+				t.Errorf("reconcileQueueCreate() visibility timeout = %s, want 120", got)
+			}
+			if tt.kmsKeyARN != "" && aws.StringValue(attributes[sqsAttributeKmsMasterKeyID]) != tt.kmsKeyARN {
+				t.Errorf("reconcileQueueCreate() kms key = %s, want %s", aws.StringValue(attributes[sqsAttributeKmsMasterKeyID]), tt.kmsKeyARN)
+			}
+			if tt.kmsKeyARN == "" && aws.StringValue(attributes[sqsAttributeSqsManagedSseEnabled]) != "true" {
+				t.Error("reconcileQueueCreate() expected queue to be encrypted with sqs managed keys")
+			}
+			if tt.queue.Annotations[ResourceIdentifierAnnotation] != "test-queue" {
+				t.Errorf("reconcileQueueCreate() expected %s annotation to be set", ResourceIdentifierAnnotation)
+			}
+		})
+	}
+}
+
+func TestQueueProvider_reconcileQueueDelete(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build test scheme", err)
+	}
+	queue := buildTestQueueCR()
+	sqsSvc := &mockSQSSvc{queues: map[string]*mockSQSQueue{
+		"test-queue":     {attributes: map[string]*string{}},
+		"test-queue-dlq": {attributes: map[string]*string{}},
+	}}
+	p := &QueueProvider{
+		Client:            fake.NewFakeClientWithScheme(scheme, queue),
+		Logger:            logrus.WithFields(logrus.Fields{}),
+		CredentialManager: &CredentialManagerMock{},
+		ConfigManager:     &ConfigManagerMock{},
+	}
+	if _, err := p.reconcileQueueDelete(context.TODO(), queue, sqsSvc, "test-queue"); err != nil {
+		t.Fatalf("reconcileQueueDelete() unexpected error = %v", err)
+	}
+	if fmt.Sprint(sqsSvc.deleted) != "[test-queue test-queue-dlq]" {
+		t.Errorf("reconcileQueueDelete() deleted queues %v, want the queue then its dead letter queue", sqsSvc.deleted)
+	}
+	if resources.HasFinalizer(&queue.ObjectMeta, DefaultFinalizer) {
+		t.Error("reconcileQueueDelete() expected finalizer to be removed")
+	}
+
+	// deleting again is a no-op once the queues are gone
+	if _, err := p.reconcileQueueDelete(context.TODO(), queue, sqsSvc, "test-queue"); err != nil {
+		t.Fatalf("reconcileQueueDelete() unexpected error on repeated delete = %v", err)
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/sts/stsiface"
 	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
 	"github.com/integr8ly/cloud-resource-operator/pkg/annotations"
+	awsclient "github.com/integr8ly/cloud-resource-operator/pkg/providers/aws/client"
 
 	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
 
@@ -132,7 +133,8 @@ func (p *RedisProvider) CreateRedis(ctx context.Context, r *v1alpha1.Redis) (*pr
 	}
 
 	// check if a standalone network is required
-	networkManager := NewNetworkManager(sess, p.Client, logger, isSTSCluster(ctx, p.Client))
+	discovery := getNetworkDiscovery(ctx, p.ConfigManager, r.Spec.Tier, logger)
+	networkManager := NewNetworkManager(sess, p.Client, logger, isSTSCluster(ctx, p.Client), discovery)
 	isEnabled, err := networkManager.IsEnabled(ctx)
 	if err != nil {
 		errMsg := "failed to check cluster vpc subnets"
@@ -178,11 +180,22 @@ func (p *RedisProvider) CreateRedis(ctx context.Context, r *v1alpha1.Redis) (*pr
 		logger.Infof("created security group %s", aws.StringValue(securityGroup.StandaloneSecurityGroup.GroupName))
 	}
 
+	// encrypt the replication group at rest with the customer managed key, if the strategy configures one
+	kmsKeyARN, err := reconcileKMSKey(ctx, p.Client, newKMSClient(sess), stratCfg.Encryption)
+	if err != nil {
+		errMsg := "failed to reconcile kms key to encrypt elasticache replication group"
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	if kmsKeyARN != "" {
+		elasticacheCreateConfig.AtRestEncryptionEnabled = aws.Bool(true)
+		elasticacheCreateConfig.KmsKeyId = aws.String(kmsKeyARN)
+	}
+
 	// create the aws elasticache cluster
-	return p.createElasticacheCluster(ctx, r, elasticache.New(sess), sts.New(sess), ec2.New(sess), elasticacheCreateConfig, stratCfg, serviceUpdates, isEnabled)
+	return p.createElasticacheCluster(ctx, r, awsclient.NewElastiCache(sess), sts.New(sess), ec2.New(sess), elasticacheCreateConfig, stratCfg, serviceUpdates, isEnabled, discovery)
 }
 
-func (p *RedisProvider) createElasticacheCluster(ctx context.Context, r *v1alpha1.Redis, cacheSvc elasticacheiface.ElastiCacheAPI, stsSvc stsiface.STSAPI, ec2Svc ec2iface.EC2API, elasticacheConfig *elasticache.CreateReplicationGroupInput, _ *StrategyConfig, serviceUpdates *ServiceUpdate, standaloneNetworkExists bool) (*providers.RedisCluster, types.StatusMessage, error) {
+func (p *RedisProvider) createElasticacheCluster(ctx context.Context, r *v1alpha1.Redis, cacheSvc elasticacheiface.ElastiCacheAPI, stsSvc stsiface.STSAPI, ec2Svc ec2iface.EC2API, elasticacheConfig *elasticache.CreateReplicationGroupInput, stratCfg *StrategyConfig, serviceUpdates *ServiceUpdate, standaloneNetworkExists bool, discovery *NetworkDiscovery) (*providers.RedisCluster, types.StatusMessage, error) {
 	logger := p.Logger.WithField("action", "createElasticacheCluster")
 	// the aws access key can sometimes still not be registered in aws on first try, so loop
 	rgs, err := getReplicationGroups(cacheSvc)
@@ -200,24 +213,38 @@ func (p *RedisProvider) createElasticacheCluster(ctx context.Context, r *v1alpha
 	// standaloneNetworkExists if no bundled subnets (created by this operator) are found in the cluster vpc
 	if !standaloneNetworkExists {
 		// setup networking in cluster vpc
-		if err := p.configureElasticacheVpc(ctx, cacheSvc, ec2Svc); err != nil {
+		if err := p.configureElasticacheVpc(ctx, cacheSvc, ec2Svc, discovery); err != nil {
 			errMsg := "error setting up resource vpc"
 			return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 		}
 
 		// setup security group for cluster vpc
-		if err := configureSecurityGroup(ctx, p.Client, ec2Svc, logger); err != nil {
+		if err := configureSecurityGroup(ctx, p.Client, ec2Svc, discovery, logger); err != nil {
 			errMsg := "error setting up security group"
 			return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 		}
 	}
 
 	// verify and build elasticache create config
+	discovery.applyElastiCacheSubnetGroupName(elasticacheConfig)
 	if err := p.buildElasticacheCreateStrategy(ctx, r, ec2Svc, elasticacheConfig); err != nil {
 		errMsg := "failed to build and verify aws elasticache create strategy"
 		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 	}
 
+	// a replication group provisioned with a node type covered by reserved capacity keeps it, unless the sizing of the
+	// cr sets the node type
+	var reservedCapacity *ReservedCapacity
+	if stratCfg != nil {
+		reservedCapacity = stratCfg.ReservedCapacity
+	}
+	sizedNodeType := r.Spec.Sizing != nil && r.Spec.Sizing.NodeType != ""
+	strategyNodeType := aws.StringValue(elasticacheConfig.CacheNodeType)
+	inUse := countElasticacheNodeTypes(rgs)
+	if nodeType := r.Annotations[ReservedInstanceClassAnnotation]; nodeType != "" && !sizedNodeType {
+		elasticacheConfig.CacheNodeType = aws.String(nodeType)
+	}
+
 	// check if the cluster has already been created
 	var foundCache *elasticache.ReplicationGroup
 	for _, c := range rgs {
@@ -256,6 +283,22 @@ func (p *RedisProvider) createElasticacheCluster(ctx context.Context, r *v1alpha
 			}
 		}
 
+		// prefer a node type covered by unused reserved capacity over on-demand pricing
+		if !sizedNodeType {
+			nodes := int(aws.Int64Value(elasticacheConfig.NumCacheClusters))
+			if nodeType := chooseReservedClass(reservedCapacity, strategyNodeType, nodes, inUse); nodeType != strategyNodeType {
+				logger.Infof("provisioning elasticache replication group with node type %s covered by unused reservations in place of %s", nodeType, strategyNodeType)
+				elasticacheConfig.CacheNodeType = aws.String(nodeType)
+				annotations.Add(r, ReservedInstanceClassAnnotation, nodeType)
+			}
+		}
+
+		// fail early with a precise message if the node type can't be provisioned in the subnet group zones
+		if err := validateElasticacheNodeTypeOffering(cacheSvc, ec2Svc, elasticacheConfig); err != nil {
+			errMsg := fmt.Sprintf("cache node type %s can not be provisioned", aws.StringValue(elasticacheConfig.CacheNodeType))
+			return nil, croType.StatusMessage(fmt.Sprintf("%s: %s", errMsg, err)), errorUtil.Wrap(err, errMsg)
+		}
+
 		logrus.Info("creating elasticache cluster")
 		if _, err := cacheSvc.CreateReplicationGroup(elasticacheConfig); err != nil {
 			errMsg := fmt.Sprintf("error creating elasticache cluster %s", err)
@@ -287,6 +330,9 @@ func (p *RedisProvider) createElasticacheCluster(ctx context.Context, r *v1alpha
 		}
 	}
 
+	// report the progress of a change to the size of the replication group
+	setElasticacheScaledCondition(r, elasticacheConfig, foundCache)
+
 	// check elasticache phase
 	if *foundCache.Status != "available" {
 		logger.Infof("found instance %s current status %s", *foundCache.ReplicationGroupId, *foundCache.Status)
@@ -306,6 +352,10 @@ func (p *RedisProvider) createElasticacheCluster(ctx context.Context, r *v1alpha
 
 	// modifications are required to bring the elasticache instance up to date with the strategy map, perform updates.
 	if modifyInput != nil {
+		// a node type set by the sizing of the cr is applied without waiting for the maintenance window
+		if r.Spec.Sizing != nil && r.Spec.Sizing.NodeType != "" && modifyInput.CacheNodeType != nil {
+			modifyInput.SetApplyImmediately(true)
+		}
 		logger.Infof("%s differs from expected strategy, applying pending modifications :\n%s", *foundCache.ReplicationGroupId, modifyInput)
 		if _, err := cacheSvc.ModifyReplicationGroup(modifyInput); err != nil {
 			errMsg := "failed to modify elasticache cluster"
@@ -314,6 +364,34 @@ func (p *RedisProvider) createElasticacheCluster(ctx context.Context, r *v1alpha
 		logger.Infof("set pending modifications to elasticache replication group %s", *foundCache.ReplicationGroupId)
 	}
 
+	// elasticache modifies one thing at a time, the replica count is changed once modifications applied immediately are done
+	if modifyInput == nil || !aws.BoolValue(modifyInput.ApplyImmediately) {
+		scaling, err := reconcileElasticacheReplicaCount(cacheSvc, r, foundCache)
+		if err != nil {
+			errMsg := fmt.Sprintf("failed to scale elasticache replication group %s", *foundCache.ReplicationGroupId)
+			return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+		if scaling {
+			msg := fmt.Sprintf("scaling elasticache replication group %s to %d cache clusters", *foundCache.ReplicationGroupId, desiredNumCacheClusters(r, foundCache))
+			logger.Info(msg)
+			return nil, croType.StatusMessage(msg), nil
+		}
+	}
+
+	// record the network the replication group was placed in
+	subnetGroup, err := getElasticacheSubnetByGroup(cacheSvc, aws.StringValue(elasticacheConfig.CacheSubnetGroupName))
+	if err != nil {
+		errMsg := "failed to get elasticache subnet group"
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	if subnetGroup != nil {
+		var subnetIDs []string
+		for _, sub := range subnetGroup.Subnets {
+			subnetIDs = append(subnetIDs, aws.StringValue(sub.SubnetIdentifier))
+		}
+		r.Status.Network = buildNetworkStatus(subnetGroup.VpcId, subnetIDs)
+	}
+
 	if !isSTS {
 		// add tags to cache nodes
 		cacheInstance := *foundCache.NodeGroups[0]
@@ -337,13 +415,47 @@ func (p *RedisProvider) createElasticacheCluster(ctx context.Context, r *v1alpha
 	}
 
 	primaryEndpoint := foundCache.NodeGroups[0].PrimaryEndpoint
+	r.Status.CloudResource = buildCloudResourceStatus(foundCache.ReplicationGroupId, foundCache.ARN, primaryEndpoint.Address, primaryEndpoint.Port)
+	r.Status.CloudResource.KmsKeyARN = aws.StringValue(foundCache.KmsKeyId)
+	if elasticacheConfig.KmsKeyId != nil && aws.StringValue(elasticacheConfig.KmsKeyId) != aws.StringValue(foundCache.KmsKeyId) {
+		logger.Warnf("elasticache replication group %s is encrypted with kms key %s instead of %s, the key of an existing replication group can't be changed", aws.StringValue(foundCache.ReplicationGroupId), aws.StringValue(foundCache.KmsKeyId), aws.StringValue(elasticacheConfig.KmsKeyId))
+	}
 	rdd := &providers.RedisDeploymentDetails{
-		URI:  *primaryEndpoint.Address,
-		Port: *primaryEndpoint.Port,
+		URI:      *primaryEndpoint.Address,
+		Port:     *primaryEndpoint.Port,
+		Topology: buildRedisTopology(foundCache),
 	}
 
+	setOnDemandPricingCondition(&r.Status, r.Generation, reservedCapacity, strategyNodeType, aws.StringValue(foundCache.CacheNodeType), len(foundCache.MemberClusters), inUse)
+
+	// estimate the cost of the replication group, a node type missing from the price table doesn't block provisioning
+	cost, err := providers.EstimateElasticacheCost(aws.StringValue(foundCache.CacheNodeType), len(foundCache.MemberClusters))
+	if err != nil {
+		logger.Warnf("failed to estimate elasticache cost: %v", err)
+	}
 	// return secret information
-	return &providers.RedisCluster{DeploymentDetails: rdd}, croType.StatusMessage(fmt.Sprintf("successfully created and tagged, aws elasticache status is %s", *foundCache.Status)), nil
+	return &providers.RedisCluster{DeploymentDetails: rdd, Cost: cost}, croType.StatusMessage(fmt.Sprintf("successfully created and tagged, aws elasticache status is %s", *foundCache.Status)), nil
+}
+
+// buildRedisTopology describes the replication group endpoints, the reader endpoint balances across the replicas
+func buildRedisTopology(cache *elasticache.ReplicationGroup) *providers.RedisTopology {
+	primaryEndpoint := cache.NodeGroups[0].PrimaryEndpoint
+	topology := &providers.RedisTopology{
+		Type: providers.RedisTopologyTypeReplication,
+		Primary: &providers.Endpoint{
+			Host: aws.StringValue(primaryEndpoint.Address),
+			Port: aws.Int64Value(primaryEndpoint.Port),
+		},
+	}
+	if readerEndpoint := cache.NodeGroups[0].ReaderEndpoint; readerEndpoint != nil {
+		topology.ReadEndpoints = []providers.Endpoint{
+			{
+				Host: aws.StringValue(readerEndpoint.Address),
+				Port: aws.Int64Value(readerEndpoint.Port),
+			},
+		}
+	}
+	return topology
 }
 
 // buildRedisTagCreateStrategy Tags RDS resources
@@ -503,7 +615,7 @@ func (p *RedisProvider) DeleteRedis(ctx context.Context, r *v1alpha1.Redis) (cro
 	}
 
 	// network manager required for cleaning up network.
-	networkManager := NewNetworkManager(sess, p.Client, logger, isSTSCluster(ctx, p.Client))
+	networkManager := NewNetworkManager(sess, p.Client, logger, isSTSCluster(ctx, p.Client), getNetworkDiscovery(ctx, p.ConfigManager, r.Spec.Tier, logger))
 
 	isEnabled, err := networkManager.IsEnabled(ctx)
 	if err != nil {
@@ -518,7 +630,7 @@ func (p *RedisProvider) DeleteRedis(ctx context.Context, r *v1alpha1.Redis) (cro
 	}
 
 	// delete the elasticache cluster
-	return p.deleteElasticacheCluster(ctx, networkManager, elasticache.New(sess), ec2.New(sess), elasticacheCreateConfig, elasticacheDeleteConfig, r, isEnabled, isLastResource)
+	return p.deleteElasticacheCluster(ctx, networkManager, awsclient.NewElastiCache(sess), ec2.New(sess), elasticacheCreateConfig, elasticacheDeleteConfig, r, isEnabled, isLastResource)
 }
 
 func (p *RedisProvider) deleteElasticacheCluster(ctx context.Context, networkManager NetworkManager, cacheSvc elasticacheiface.ElastiCacheAPI, ec2Svc ec2iface.EC2API, elasticacheCreateConfig *elasticache.CreateReplicationGroupInput, elasticacheDeleteConfig *elasticache.DeleteReplicationGroupInput, r *v1alpha1.Redis, isEnabled bool, isLastResource bool) (croType.StatusMessage, error) {
@@ -555,6 +667,31 @@ func (p *RedisProvider) deleteElasticacheCluster(ctx context.Context, networkMan
 			return croType.StatusMessage(fmt.Sprintf("delete detected, deleteReplicationGroup() in progress, current aws elasticache status is %s", *foundCache.Status)), nil
 		}
 
+		// the final snapshot is checkpointed if the operator shuts down before the deletion started, so it isn't taken
+		// under another identifier on restart
+		done, err := providers.BeginCheckpointedOperation(p.Client, r, croType.OperationCheckpoint{
+			Operation:  providers.OperationFinalSnapshot,
+			Step:       "DeleteReplicationGroup",
+			SnapshotID: aws.StringValue(elasticacheDeleteConfig.FinalSnapshotIdentifier),
+		})
+		if err != nil {
+			if errors.Is(err, resources.ErrShuttingDown) {
+				return providers.ShuttingDownMessage, nil
+			}
+			errMsg := "failed to start elasticache cluster deletion"
+			return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+		defer done()
+
+		// the final snapshot is recorded before it's taken, as the replication group isn't available again once its
+		// deletion started
+		if elasticacheDeleteConfig.FinalSnapshotIdentifier != nil {
+			if err := providers.RecordFinalSnapshot(ctx, p.Client, providers.RedisResourceType, r, aws.StringValue(elasticacheDeleteConfig.FinalSnapshotIdentifier)); err != nil {
+				errMsg := "failed to record final elasticache snapshot"
+				return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+			}
+		}
+
 		// delete elasticache cluster
 		_, err = cacheSvc.DeleteReplicationGroup(elasticacheDeleteConfig)
 		elasticacheErr, isAwsErr := err.(awserr.Error)
@@ -640,6 +777,11 @@ func (p *RedisProvider) getElasticacheConfig(ctx context.Context, r *v1alpha1.Re
 	if err != nil {
 		return nil, nil, nil, nil, errorUtil.Wrap(err, "failed to read aws strategy config")
 	}
+	// values of the tier changed by the redis itself
+	stratCfg.CreateStrategy, err = providers.MergeProviderConfig(stratCfg.CreateStrategy, r.Spec.ProviderConfig)
+	if err != nil {
+		return nil, nil, nil, nil, errorUtil.Wrap(err, "failed to merge provider config of redis")
+	}
 	defRegion, err := GetRegionFromStrategyOrDefault(ctx, p.Client, stratCfg)
 	if err != nil {
 		return nil, nil, nil, nil, errorUtil.Wrap(err, "failed to get default region")
@@ -811,6 +953,8 @@ func buildElasticacheUpdateStrategy(ec2Client ec2iface.EC2API, elasticacheConfig
 
 // verifyRedisConfig checks elasticache config, if none exist sets values to default
 func (p *RedisProvider) buildElasticacheCreateStrategy(ctx context.Context, r *v1alpha1.Redis, ec2Svc ec2iface.EC2API, elasticacheConfig *elasticache.CreateReplicationGroupInput) error {
+	// the sizing of the cr overrides the strategy
+	applyRedisSizing(r, elasticacheConfig)
 
 	elasticacheConfig.AutomaticFailoverEnabled = aws.Bool(true)
 	elasticacheConfig.Engine = aws.String("redis")
@@ -885,6 +1029,23 @@ func (p *RedisProvider) buildElasticacheDeleteConfig(ctx context.Context, r v1al
 	if elasticacheDeleteConfig.RetainPrimaryCluster == nil {
 		elasticacheDeleteConfig.RetainPrimaryCluster = aws.Bool(false)
 	}
+	// the final snapshot of the redis cr overrides the strategy
+	if r.Spec.ApplyFinalSnapshot != nil && !*r.Spec.ApplyFinalSnapshot {
+		elasticacheDeleteConfig.FinalSnapshotIdentifier = nil
+		return nil
+	}
+	// the final snapshot of a deletion in flight when the operator shut down keeps its identifier
+	if resumed := providers.ResumedSnapshotID(r.Status.Checkpoint, providers.OperationFinalSnapshot); resumed != "" {
+		elasticacheDeleteConfig.FinalSnapshotIdentifier = aws.String(resumed)
+		return nil
+	}
+	if r.Spec.FinalSnapshotNameTemplate != "" {
+		snapshotIdentifier, err := buildFinalSnapshotIdentifier(ctx, p.Client, r.ObjectMeta, r.Spec.FinalSnapshotNameTemplate)
+		if err != nil {
+			return errorUtil.Wrap(err, "failed to build final elasticache snapshot identifier")
+		}
+		elasticacheDeleteConfig.FinalSnapshotIdentifier = aws.String(snapshotIdentifier)
+	}
 	snapshotIdentifier, err := buildTimestampedInfraNameFromObject(ctx, p.Client, r.ObjectMeta, defaultAwsIdentifierLength)
 	if err != nil {
 		return errorUtil.Wrapf(err, "failed to retrieve rds config")
@@ -896,8 +1057,12 @@ func (p *RedisProvider) buildElasticacheDeleteConfig(ctx context.Context, r v1al
 }
 
 // ensures a subnet group is in place to configure the resource, so that it is in the same vpc as the cluster
-func (p *RedisProvider) configureElasticacheVpc(ctx context.Context, cacheSvc elasticacheiface.ElastiCacheAPI, ec2Svc ec2iface.EC2API) error {
+func (p *RedisProvider) configureElasticacheVpc(ctx context.Context, cacheSvc elasticacheiface.ElastiCacheAPI, ec2Svc ec2iface.EC2API, discovery *NetworkDiscovery) error {
 	logrus.Info("configuring cluster vpc for redis resource")
+	if !discovery.CreatesSubnetGroups() {
+		logrus.Info("network discovery uses existing subnet groups, elasticache subnet group is not created")
+		return nil
+	}
 	// get subnet group id
 	sgName, err := BuildInfraName(ctx, p.Client, defaultSubnetPostfix, defaultAwsIdentifierLength)
 	if err != nil {
@@ -922,7 +1087,7 @@ func (p *RedisProvider) configureElasticacheVpc(ctx context.Context, cacheSvc el
 	}
 
 	// get cluster vpc subnets
-	subIDs, err := GetPrivateSubnetIDS(ctx, p.Client, ec2Svc, p.Logger)
+	subIDs, err := GetPrivateSubnetIDS(ctx, p.Client, ec2Svc, discovery, p.Logger)
 	if err != nil {
 		return errorUtil.Wrap(err, "error getting vpc subnets")
 	}
@@ -11,6 +11,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/elasticache/elasticacheiface"
 	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
 	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	awsclient "github.com/integr8ly/cloud-resource-operator/pkg/providers/aws/client"
 	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
 	errorUtil "github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -75,7 +76,7 @@ func (r *RedisMetricsProvider) ScrapeRedisMetrics(ctx context.Context, redis *v1
 	}
 
 	// scrape metric data from cloud watch
-	cloudMetrics, err := r.scrapeRedisCloudWatchMetricData(ctx, cloudwatch.New(sess), redis, elasticache.New(sess), metricTypes)
+	cloudMetrics, err := r.scrapeRedisCloudWatchMetricData(ctx, cloudwatch.New(sess), redis, awsclient.NewElastiCache(sess), metricTypes)
 	if err != nil {
 		return nil, errorUtil.Wrap(err, "failed to scrape elasticache cloud watch metrics")
 	}
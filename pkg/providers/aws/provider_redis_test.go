@@ -52,20 +52,23 @@ var (
 
 type mockElasticacheClient struct {
 	elasticacheiface.ElastiCacheAPI
-	modifyCacheSubnetGroupFn    func(*elasticache.ModifyCacheSubnetGroupInput) (*elasticache.ModifyCacheSubnetGroupOutput, error)
-	deleteCacheSubnetGroupFn    func(*elasticache.DeleteCacheSubnetGroupInput) (*elasticache.DeleteCacheSubnetGroupOutput, error)
-	describeCacheSubnetGroupsFn func(*elasticache.DescribeCacheSubnetGroupsInput) (*elasticache.DescribeCacheSubnetGroupsOutput, error)
-	describeCacheClustersFn     func(*elasticache.DescribeCacheClustersInput) (*elasticache.DescribeCacheClustersOutput, error)
-	describeReplicationGroupsFn func(*elasticache.DescribeReplicationGroupsInput) (*elasticache.DescribeReplicationGroupsOutput, error)
-	describeSnapshotsFn         func(*elasticache.DescribeSnapshotsInput) (*elasticache.DescribeSnapshotsOutput, error)
-	createSnapshotFn            func(*elasticache.CreateSnapshotInput) (*elasticache.CreateSnapshotOutput, error)
-	deleteSnapshotFn            func(*elasticache.DeleteSnapshotInput) (*elasticache.DeleteSnapshotOutput, error)
-	describeUpdateActionsFn     func(*elasticache.DescribeUpdateActionsInput) (*elasticache.DescribeUpdateActionsOutput, error)
-	modifyReplicationGroupFn    func(*elasticache.ModifyReplicationGroupInput) (*elasticache.ModifyReplicationGroupOutput, error)
-	batchApplyUpdateActionFn    func(*elasticache.BatchApplyUpdateActionInput) (*elasticache.BatchApplyUpdateActionOutput, error)
-	addTagsToResourceFn         func(*elasticache.AddTagsToResourceInput) (*elasticache.TagListMessage, error)
-	createReplicationGroupFn    func(*elasticache.CreateReplicationGroupInput) (*elasticache.CreateReplicationGroupOutput, error)
-	calls                       struct {
+	modifyCacheSubnetGroupFn              func(*elasticache.ModifyCacheSubnetGroupInput) (*elasticache.ModifyCacheSubnetGroupOutput, error)
+	deleteCacheSubnetGroupFn              func(*elasticache.DeleteCacheSubnetGroupInput) (*elasticache.DeleteCacheSubnetGroupOutput, error)
+	describeCacheSubnetGroupsFn           func(*elasticache.DescribeCacheSubnetGroupsInput) (*elasticache.DescribeCacheSubnetGroupsOutput, error)
+	describeCacheClustersFn               func(*elasticache.DescribeCacheClustersInput) (*elasticache.DescribeCacheClustersOutput, error)
+	describeReplicationGroupsFn           func(*elasticache.DescribeReplicationGroupsInput) (*elasticache.DescribeReplicationGroupsOutput, error)
+	describeSnapshotsFn                   func(*elasticache.DescribeSnapshotsInput) (*elasticache.DescribeSnapshotsOutput, error)
+	createSnapshotFn                      func(*elasticache.CreateSnapshotInput) (*elasticache.CreateSnapshotOutput, error)
+	deleteSnapshotFn                      func(*elasticache.DeleteSnapshotInput) (*elasticache.DeleteSnapshotOutput, error)
+	describeUpdateActionsFn               func(*elasticache.DescribeUpdateActionsInput) (*elasticache.DescribeUpdateActionsOutput, error)
+	modifyReplicationGroupFn              func(*elasticache.ModifyReplicationGroupInput) (*elasticache.ModifyReplicationGroupOutput, error)
+	batchApplyUpdateActionFn              func(*elasticache.BatchApplyUpdateActionInput) (*elasticache.BatchApplyUpdateActionOutput, error)
+	addTagsToResourceFn                   func(*elasticache.AddTagsToResourceInput) (*elasticache.TagListMessage, error)
+	createReplicationGroupFn              func(*elasticache.CreateReplicationGroupInput) (*elasticache.CreateReplicationGroupOutput, error)
+	describeReservedCacheNodesOfferingsFn func(*elasticache.DescribeReservedCacheNodesOfferingsInput) (*elasticache.DescribeReservedCacheNodesOfferingsOutput, error)
+	increaseReplicaCountFn                func(*elasticache.IncreaseReplicaCountInput) (*elasticache.IncreaseReplicaCountOutput, error)
+	decreaseReplicaCountFn                func(*elasticache.DecreaseReplicaCountInput) (*elasticache.DecreaseReplicaCountOutput, error)
+	calls                                 struct {
 		DescribeSnapshots []struct {
 			In1 *elasticache.DescribeSnapshotsInput
 		}
@@ -90,6 +93,12 @@ type mockElasticacheClient struct {
 		CreateReplicationGroup []struct {
 			In1 *elasticache.CreateReplicationGroupInput
 		}
+		IncreaseReplicaCount []struct {
+			In1 *elasticache.IncreaseReplicaCountInput
+		}
+		DecreaseReplicaCount []struct {
+			In1 *elasticache.DecreaseReplicaCountInput
+		}
 	}
 }
 
@@ -107,6 +116,15 @@ func buildMockElasticacheClient(modifyFn func(*mockElasticacheClient)) *mockElas
 		modifyReplicationGroupFn: func(input *elasticache.ModifyReplicationGroupInput) (*elasticache.ModifyReplicationGroupOutput, error) {
 			return &elasticache.ModifyReplicationGroupOutput{}, nil
 		},
+		describeReservedCacheNodesOfferingsFn: func(input *elasticache.DescribeReservedCacheNodesOfferingsInput) (*elasticache.DescribeReservedCacheNodesOfferingsOutput, error) {
+			return &elasticache.DescribeReservedCacheNodesOfferingsOutput{
+				ReservedCacheNodesOfferings: []*elasticache.ReservedCacheNodesOffering{
+					{
+						CacheNodeType: input.CacheNodeType,
+					},
+				},
+			}, nil
+		},
 	}
 	if modifyFn != nil {
 		modifyFn(mock)
@@ -269,6 +287,13 @@ func (m *mockElasticacheClient) DescribeCacheSubnetGroups(input *elasticache.Des
 	return m.describeCacheSubnetGroupsFn(input)
 }
 
+func (m *mockElasticacheClient) DescribeReservedCacheNodesOfferings(input *elasticache.DescribeReservedCacheNodesOfferingsInput) (*elasticache.DescribeReservedCacheNodesOfferingsOutput, error) {
+	if m.describeReservedCacheNodesOfferingsFn == nil {
+		panic("mockElasticacheClient.DescribeReservedCacheNodesOfferings: method is nil")
+	}
+	return m.describeReservedCacheNodesOfferingsFn(input)
+}
+
 func (m *mockElasticacheClient) CreateCacheSubnetGroup(*elasticache.CreateCacheSubnetGroupInput) (*elasticache.CreateCacheSubnetGroupOutput, error) {
 	return &elasticache.CreateCacheSubnetGroupOutput{}, nil
 }
@@ -277,6 +302,32 @@ func (m *mockElasticacheClient) DeleteCacheSubnetGroup(input *elasticache.Delete
 	return m.deleteCacheSubnetGroupFn(input)
 }
 
+func (m *mockElasticacheClient) IncreaseReplicaCount(input *elasticache.IncreaseReplicaCountInput) (*elasticache.IncreaseReplicaCountOutput, error) {
+	if m.increaseReplicaCountFn == nil {
+		panic("increaseReplicaCountFn: method is nil but elasticacheClient.IncreaseReplicaCount was just called")
+	}
+	callInfo := struct {
+		In1 *elasticache.IncreaseReplicaCountInput
+	}{
+		In1: input,
+	}
+	m.calls.IncreaseReplicaCount = append(m.calls.IncreaseReplicaCount, callInfo)
+	return m.increaseReplicaCountFn(input)
+}
+
+func (m *mockElasticacheClient) DecreaseReplicaCount(input *elasticache.DecreaseReplicaCountInput) (*elasticache.DecreaseReplicaCountOutput, error) {
+	if m.decreaseReplicaCountFn == nil {
+		panic("decreaseReplicaCountFn: method is nil but elasticacheClient.DecreaseReplicaCount was just called")
+	}
+	callInfo := struct {
+		In1 *elasticache.DecreaseReplicaCountInput
+	}{
+		In1: input,
+	}
+	m.calls.DecreaseReplicaCount = append(m.calls.DecreaseReplicaCount, callInfo)
+	return m.decreaseReplicaCountFn(input)
+}
+
 func (m *mockElasticacheClient) ModifyCacheSubnetGroup(input *elasticache.ModifyCacheSubnetGroupInput) (*elasticache.ModifyCacheSubnetGroupOutput, error) {
 	return m.modifyCacheSubnetGroupFn(input)
 }
@@ -319,6 +370,10 @@ func buildTestRedisCluster() *providers.RedisCluster {
 	return &providers.RedisCluster{DeploymentDetails: &providers.RedisDeploymentDetails{
 		URI:  *testAddress,
 		Port: *testPort,
+		Topology: &providers.RedisTopology{
+			Type:    providers.RedisTopologyTypeReplication,
+			Primary: &providers.Endpoint{Host: *testAddress, Port: *testPort},
+		},
 	}}
 }
 
@@ -1100,6 +1155,15 @@ func Test_createRedisCluster(t *testing.T) {
 							},
 						}, nil
 					},
+					describeInstanceTypeOfferingsFn: func(input *ec2.DescribeInstanceTypeOfferingsInput) (*ec2.DescribeInstanceTypeOfferingsOutput, error) {
+						return &ec2.DescribeInstanceTypeOfferingsOutput{
+							InstanceTypeOfferings: []*ec2.InstanceTypeOffering{
+								{
+									Location: aws.String("test"),
+								},
+							},
+						}, nil
+					},
 				},
 				r:                       buildTestRedisCR(),
 				stsSvc:                  &mockStsClient{},
@@ -1484,7 +1548,7 @@ func Test_createRedisCluster(t *testing.T) {
 				ConfigManager:     tt.fields.ConfigManager,
 				TCPPinger:         tt.fields.TCPPinger,
 			}
-			got, _, err := p.createElasticacheCluster(tt.args.ctx, tt.args.r, tt.args.cacheSvc, tt.args.stsSvc, tt.args.ec2Svc, tt.args.redisConfig, tt.args.stratCfg, tt.args.ServiceUpdate, tt.args.standaloneNetworkExists)
+			got, _, err := p.createElasticacheCluster(tt.args.ctx, tt.args.r, tt.args.cacheSvc, tt.args.stsSvc, tt.args.ec2Svc, tt.args.redisConfig, tt.args.stratCfg, tt.args.ServiceUpdate, tt.args.standaloneNetworkExists, nil)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("createElasticacheCluster() error = %v, wantErr %v", err, tt.wantErr)
 				return
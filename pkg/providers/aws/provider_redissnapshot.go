@@ -7,6 +7,7 @@ package aws
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
 	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
 	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	awsclient "github.com/integr8ly/cloud-resource-operator/pkg/providers/aws/client"
 	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
 	errorUtil "github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -77,7 +79,7 @@ func (p *RedisSnapshotProvider) CreateRedisSnapshot(ctx context.Context, snapsho
 		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 	}
 
-	cacheSvc := elasticache.New(session)
+	cacheSvc := awsclient.NewElastiCache(session)
 
 	return p.createRedisSnapshot(ctx, snapshot, redis, cacheSvc)
 }
@@ -85,16 +87,21 @@ func (p *RedisSnapshotProvider) CreateRedisSnapshot(ctx context.Context, snapsho
 func (p *RedisSnapshotProvider) createRedisSnapshot(ctx context.Context, snapshot *v1alpha1.RedisSnapshot, redis *v1alpha1.Redis, cacheSvc elasticacheiface.ElastiCacheAPI) (*providers.RedisSnapshotInstance, croType.StatusMessage, error) {
 	logger := resources.NewActionLogger(p.logger, "createRedisSnapshot")
 	// generate snapshot name
-	snapshotName, err := BuildTimestampedInfraNameFromObjectCreation(ctx, p.client, snapshot.ObjectMeta, defaultAwsIdentifierLength)
-	if err != nil {
-		errMsg := "failed to generate snapshot name"
-		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	// a snapshot whose creation was in flight when the operator shut down is resumed
+	snapshotName := providers.ResumedSnapshotID(snapshot.Status.Checkpoint, providers.OperationCreateSnapshot)
+	if snapshotName == "" {
+		var err error
+		snapshotName, err = BuildTimestampedInfraNameFromObjectCreation(ctx, p.client, snapshot.ObjectMeta, defaultAwsIdentifierLength)
+		if err != nil {
+			errMsg := "failed to generate snapshot name"
+			return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
 	}
 
 	// update cr with snapshot name
 	snapshot.Status.SnapshotID = snapshotName
 
-	if err = p.client.Status().Update(ctx, snapshot); err != nil {
+	if err := p.client.Status().Update(ctx, snapshot); err != nil {
 		errMsg := fmt.Sprintf("failed to update instance %s in namespace %s", snapshot.Name, snapshot.Namespace)
 		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 	}
@@ -146,11 +153,28 @@ func (p *RedisSnapshotProvider) createRedisSnapshot(ctx context.Context, snapsho
 			msg := "failed to get default redis tags"
 			return nil, "", errorUtil.Wrapf(err, msg)
 		}
+		done, err := providers.BeginCheckpointedOperation(p.client, snapshot, croType.OperationCheckpoint{
+			Operation:  providers.OperationCreateSnapshot,
+			Step:       "CreateSnapshot",
+			SnapshotID: snapshotName,
+		})
+		if err != nil {
+			if errors.Is(err, resources.ErrShuttingDown) {
+				return nil, providers.ShuttingDownMessage, nil
+			}
+			errMsg := "failed to start elasticache snapshot creation"
+			return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+		defer done()
 		_, err = cacheSvc.CreateSnapshot(&elasticache.CreateSnapshotInput{
 			CacheClusterId: aws.String(cacheName),
 			SnapshotName:   aws.String(snapshotName),
 			Tags:           genericToElasticacheTags(tags),
 		})
+		// a snapshot requested before the operator shut down may not be described yet
+		if cacheErr, ok := err.(awserr.Error); ok && cacheErr.Code() == elasticache.ErrCodeSnapshotAlreadyExistsFault {
+			return nil, "snapshot started", nil
+		}
 		if err != nil {
 			errMsg := "error creating elasticache snapshot"
 			return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
@@ -158,8 +182,31 @@ func (p *RedisSnapshotProvider) createRedisSnapshot(ctx context.Context, snapsho
 		return nil, "snapshot started", nil
 	}
 
+	// the snapshot exists, so the checkpoint of its creation is no longer needed
+	snapshot.Status.Checkpoint = nil
+
 	// if snapshot status complete update status
 	if *foundSnapshot.SnapshotStatus == "available" {
+		// record the arn and, if the snapshot has a retention period, when it's deleted
+		var createTime time.Time
+		for _, nodeSnapshot := range foundSnapshot.NodeSnapshots {
+			nodeCreateTime := aws.TimeValue(nodeSnapshot.SnapshotCreateTime)
+			if !nodeCreateTime.IsZero() && (createTime.IsZero() || nodeCreateTime.Before(createTime)) {
+				createTime = nodeCreateTime
+			}
+		}
+		if createTime.IsZero() {
+			createTime = snapshot.CreationTimestamp.Time
+		}
+		retainUntil := providers.BuildSnapshotRetainUntil(createTime, snapshot.Spec.RetentionDays)
+		if snapshot.Status.SnapshotARN != aws.StringValue(foundSnapshot.ARN) || snapshot.Status.RetainUntil != retainUntil {
+			snapshot.Status.SnapshotARN = aws.StringValue(foundSnapshot.ARN)
+			snapshot.Status.RetainUntil = retainUntil
+			if err = p.client.Status().Update(ctx, snapshot); err != nil {
+				errMsg := fmt.Sprintf("failed to update instance %s in namespace %s", snapshot.Name, snapshot.Namespace)
+				return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+			}
+		}
 		return &providers.RedisSnapshotInstance{
 			Name: *foundSnapshot.SnapshotName,
 		}, "snapshot created", nil
@@ -12,6 +12,7 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
 
@@ -31,6 +32,7 @@ var (
 	testReplicationGroupStatusAvailable    = "available"
 	testReplicationGroupStatusNotAvailable = "not available"
 	fakeResourceVersion                    = "1000"
+	testRedisSnapshotArn                   = "arn:aws:elasticache:eu-west-1:123456789012:snapshot:test-snapshot"
 )
 
 func buildTestRedisSnapshotCR() *v1alpha1.RedisSnapshot {
@@ -104,6 +106,7 @@ func TestAWSRedisSnapshotProvider_createRedisSnapshot(t *testing.T) {
 		wantMsg      croType.StatusMessage
 		wantErr      string
 		wantFn       func(mock *mockElasticacheClient) error
+		wantStatusFn func(status croType.ResourceTypeSnapshotStatus) error
 	}{
 		{
 			name: "test elasticache CreateSnapshot is called",
@@ -206,6 +209,57 @@ func TestAWSRedisSnapshotProvider_createRedisSnapshot(t *testing.T) {
 			},
 			wantMsg: "snapshot created",
 		},
+		{
+			name: "test snapshot arn and retention are recorded when the snapshot is available",
+			args: args{
+				ctx: context.TODO(),
+				snapshotCr: func() *v1alpha1.RedisSnapshot {
+					snapshot := buildTestRedisSnapshotCR()
+					snapshot.Spec.RetentionDays = 7
+					return snapshot
+				}(),
+				redisCr: buildTestRedisCR(),
+				cacheSvc: buildMockElasticacheClient(func(mock *mockElasticacheClient) {
+					mock.describeSnapshotsFn = func(input *elasticache.DescribeSnapshotsInput) (*elasticache.DescribeSnapshotsOutput, error) {
+						return &elasticache.DescribeSnapshotsOutput{
+							Snapshots: []*elasticache.Snapshot{
+								{
+									ARN:            aws.String(testRedisSnapshotArn),
+									SnapshotName:   &testTimestampedIdentifier,
+									SnapshotStatus: aws.String("available"),
+									NodeSnapshots: []*elasticache.NodeSnapshot{
+										{SnapshotCreateTime: aws.Time(time.Date(2021, time.June, 16, 10, 45, 0, 0, time.UTC))},
+										{SnapshotCreateTime: aws.Time(time.Date(2021, time.June, 16, 10, 30, 0, 0, time.UTC))},
+									},
+								},
+							},
+						}, nil
+					}
+					mock.describeReplicationGroupsFn = func(input *elasticache.DescribeReplicationGroupsInput) (*elasticache.DescribeReplicationGroupsOutput, error) {
+						return buildDescribeReplicationGroupsOutput(testReplicationGroupStatusAvailable), nil
+					}
+				}),
+			},
+			fields: fields{
+				Client:            fake.NewFakeClientWithScheme(scheme, buildTestRedisCR(), buildTestRedisSnapshotCR(), builtTestCredSecret(), buildTestInfra()),
+				Logger:            testLogger,
+				CredentialManager: nil,
+				ConfigManager:     nil,
+			},
+			wantSnapshot: &providers.RedisSnapshotInstance{
+				Name: testTimestampedIdentifier,
+			},
+			wantMsg: "snapshot created",
+			wantStatusFn: func(status croType.ResourceTypeSnapshotStatus) error {
+				if status.SnapshotARN != testRedisSnapshotArn {
+					return errors.New(fmt.Sprintf("wrong snapshot arn got = %s, want = %s", status.SnapshotARN, testRedisSnapshotArn))
+				}
+				if status.RetainUntil != "2021-06-23T10:30:00Z" {
+					return errors.New(fmt.Sprintf("wrong retain until got = %s, want = 2021-06-23T10:30:00Z", status.RetainUntil))
+				}
+				return nil
+			},
+		},
 		{
 			name: "test snapshot instance not returned when status is not available",
 			args: args{
@@ -347,6 +401,11 @@ func TestAWSRedisSnapshotProvider_createRedisSnapshot(t *testing.T) {
 					t.Errorf("createPostgresSnapshot() err = %v", err)
 				}
 			}
+			if tt.wantStatusFn != nil {
+				if err := tt.wantStatusFn(tt.args.snapshotCr.Status); err != nil {
+					t.Errorf("createRedisSnapshot() err = %v", err)
+				}
+			}
 		})
 	}
 }
@@ -0,0 +1,119 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	"github.com/aws/aws-sdk-go/service/elasticache/elasticacheiface"
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	errorUtil "github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ScaledCondition is the condition reporting the progress of a change to the size of an elasticache replication
+	// group, it's only set once the node type or number of cache clusters differs from the replication group
+	ScaledCondition = "Scaled"
+	// ScaledReason is the reason of a true scaled condition
+	ScaledReason = "Scaled"
+	// ScalingPendingReason is the reason of a false scaled condition before elasticache starts the change, e.g. while
+	// a node type change waits for the maintenance window
+	ScalingPendingReason = "ScalingPending"
+	// ScalingInProgressReason is the reason of a false scaled condition while the replication group is modified
+	ScalingInProgressReason = "ScalingInProgress"
+
+	elasticacheStatusModifying = "modifying"
+)
+
+// applyRedisSizing overrides the node type and number of cache clusters of the strategy with the sizing of the redis cr
+func applyRedisSizing(r *v1alpha1.Redis, elasticacheConfig *elasticache.CreateReplicationGroupInput) {
+	sizing := r.Spec.Sizing
+	if sizing == nil {
+		return
+	}
+	if sizing.NodeType != "" {
+		elasticacheConfig.CacheNodeType = aws.String(sizing.NodeType)
+	}
+	if sizing.NumCacheClusters > 0 {
+		elasticacheConfig.NumCacheClusters = aws.Int64(sizing.NumCacheClusters)
+	}
+}
+
+// desiredNumCacheClusters returns the number of cache clusters the replication group is scaled to. Only the sizing of
+// the redis cr changes the number of an existing replication group, the strategy only applies when it's created
+func desiredNumCacheClusters(r *v1alpha1.Redis, foundCache *elasticache.ReplicationGroup) int64 {
+	if r.Spec.Sizing != nil && r.Spec.Sizing.NumCacheClusters > 0 {
+		return r.Spec.Sizing.NumCacheClusters
+	}
+	return int64(len(foundCache.MemberClusters))
+}
+
+// reconcileElasticacheReplicaCount adds or removes replicas of the replication group until it has the number of cache
+// clusters of the sizing of the redis cr. Returns true if a change to the number of replicas was requested
+func reconcileElasticacheReplicaCount(cacheSvc elasticacheiface.ElastiCacheAPI, r *v1alpha1.Redis, foundCache *elasticache.ReplicationGroup) (bool, error) {
+	current := int64(len(foundCache.MemberClusters))
+	desired := desiredNumCacheClusters(r, foundCache)
+	if current == desired {
+		return false, nil
+	}
+	// a replication group without cluster mode has a single node group, with a primary and its replicas
+	replicas := aws.Int64(desired - 1)
+	if desired > current {
+		if _, err := cacheSvc.IncreaseReplicaCount(&elasticache.IncreaseReplicaCountInput{
+			ReplicationGroupId: foundCache.ReplicationGroupId,
+			NewReplicaCount:    replicas,
+			ApplyImmediately:   aws.Bool(true),
+		}); err != nil {
+			return false, errorUtil.Wrapf(err, "failed to increase replica count of %s to %d", aws.StringValue(foundCache.ReplicationGroupId), *replicas)
+		}
+		return true, nil
+	}
+	if _, err := cacheSvc.DecreaseReplicaCount(&elasticache.DecreaseReplicaCountInput{
+		ReplicationGroupId: foundCache.ReplicationGroupId,
+		NewReplicaCount:    replicas,
+		ApplyImmediately:   aws.Bool(true),
+	}); err != nil {
+		return false, errorUtil.Wrapf(err, "failed to decrease replica count of %s to %d", aws.StringValue(foundCache.ReplicationGroupId), *replicas)
+	}
+	return true, nil
+}
+
+// setElasticacheScaledCondition reports the progress of a change to the node type or number of cache clusters of the
+// replication group in the scaled condition of the redis
+func setElasticacheScaledCondition(r *v1alpha1.Redis, elasticacheConfig *elasticache.CreateReplicationGroupInput, foundCache *elasticache.ReplicationGroup) {
+	groupID := aws.StringValue(foundCache.ReplicationGroupId)
+	currentType := aws.StringValue(foundCache.CacheNodeType)
+	desiredType := aws.StringValue(elasticacheConfig.CacheNodeType)
+	if desiredType == "" {
+		desiredType = currentType
+	}
+	current := int64(len(foundCache.MemberClusters))
+	desired := desiredNumCacheClusters(r, foundCache)
+	scaled := currentType == desiredType && current == desired
+	existing := meta.FindStatusCondition(r.Status.Conditions, ScaledCondition)
+	// nothing was ever scaled, there's nothing to report
+	if existing == nil && scaled {
+		return
+	}
+
+	cond := metav1.Condition{
+		Type:               ScaledCondition,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: r.GetGeneration(),
+	}
+	switch {
+	case scaled:
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = ScaledReason
+		cond.Message = fmt.Sprintf("replication group %s has %d cache clusters of node type %s", groupID, current, currentType)
+	case aws.StringValue(foundCache.Status) == elasticacheStatusModifying:
+		cond.Reason = ScalingInProgressReason
+		cond.Message = fmt.Sprintf("replication group %s is scaling from %d cache clusters of node type %s to %d of node type %s", groupID, current, currentType, desired, desiredType)
+	default:
+		cond.Reason = ScalingPendingReason
+		cond.Message = fmt.Sprintf("replication group %s is waiting to be scaled from %d cache clusters of node type %s to %d of node type %s", groupID, current, currentType, desired, desiredType)
+	}
+	meta.SetStatusCondition(&r.Status.Conditions, cond)
+}
@@ -0,0 +1,192 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func buildTestSizingReplicationGroup(status, nodeType string, numCacheClusters int) *elasticache.ReplicationGroup {
+	return buildReplicationGroup(func(group *elasticache.ReplicationGroup) {
+		group.ReplicationGroupId = aws.String("test-id")
+		group.Status = aws.String(status)
+		group.CacheNodeType = aws.String(nodeType)
+		for i := 0; i < numCacheClusters; i++ {
+			group.MemberClusters = append(group.MemberClusters, aws.String("test-member"))
+		}
+	})
+}
+
+func Test_applyRedisSizing(t *testing.T) {
+	tests := []struct {
+		name                 string
+		sizing               *croType.RedisSizing
+		wantNodeType         *string
+		wantNumCacheClusters *int64
+	}{
+		{
+			name:                 "test strategy is kept without sizing",
+			wantNodeType:         aws.String("cache.t3.micro"),
+			wantNumCacheClusters: aws.Int64(2),
+		},
+		{
+			name:                 "test sizing overrides the strategy",
+			sizing:               &croType.RedisSizing{NodeType: "cache.t3.small", NumCacheClusters: 3},
+			wantNodeType:         aws.String("cache.t3.small"),
+			wantNumCacheClusters: aws.Int64(3),
+		},
+		{
+			name:                 "test fields not set in the sizing are kept",
+			sizing:               &croType.RedisSizing{NumCacheClusters: 4},
+			wantNodeType:         aws.String("cache.t3.micro"),
+			wantNumCacheClusters: aws.Int64(4),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := buildTestRedisCR()
+			r.Spec.Sizing = tt.sizing
+			cfg := &elasticache.CreateReplicationGroupInput{CacheNodeType: aws.String("cache.t3.micro"), NumCacheClusters: aws.Int64(2)}
+			applyRedisSizing(r, cfg)
+			if aws.StringValue(cfg.CacheNodeType) != aws.StringValue(tt.wantNodeType) {
+				t.Errorf("applyRedisSizing() node type = %s, want %s", aws.StringValue(cfg.CacheNodeType), aws.StringValue(tt.wantNodeType))
+			}
+			if aws.Int64Value(cfg.NumCacheClusters) != aws.Int64Value(tt.wantNumCacheClusters) {
+				t.Errorf("applyRedisSizing() num cache clusters = %d, want %d", aws.Int64Value(cfg.NumCacheClusters), aws.Int64Value(tt.wantNumCacheClusters))
+			}
+		})
+	}
+}
+
+func Test_reconcileElasticacheReplicaCount(t *testing.T) {
+	tests := []struct {
+		name         string
+		sizing       *croType.RedisSizing
+		group        *elasticache.ReplicationGroup
+		want         bool
+		wantIncrease int64
+		wantDecrease int64
+	}{
+		{
+			name:  "test no change without sizing",
+			group: buildTestSizingReplicationGroup("available", "cache.t3.micro", 3),
+		},
+		{
+			name:   "test no change when the number of cache clusters matches",
+			sizing: &croType.RedisSizing{NumCacheClusters: 3},
+			group:  buildTestSizingReplicationGroup("available", "cache.t3.micro", 3),
+		},
+		{
+			name:         "test replicas are added",
+			sizing:       &croType.RedisSizing{NumCacheClusters: 4},
+			group:        buildTestSizingReplicationGroup("available", "cache.t3.micro", 2),
+			want:         true,
+			wantIncrease: 3,
+		},
+		{
+			name:         "test replicas are removed",
+			sizing:       &croType.RedisSizing{NumCacheClusters: 2},
+			group:        buildTestSizingReplicationGroup("available", "cache.t3.micro", 3),
+			want:         true,
+			wantDecrease: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := buildTestRedisCR()
+			r.Spec.Sizing = tt.sizing
+			cacheSvc := buildMockElasticacheClient(func(client *mockElasticacheClient) {
+				client.increaseReplicaCountFn = func(input *elasticache.IncreaseReplicaCountInput) (*elasticache.IncreaseReplicaCountOutput, error) {
+					return &elasticache.IncreaseReplicaCountOutput{}, nil
+				}
+				client.decreaseReplicaCountFn = func(input *elasticache.DecreaseReplicaCountInput) (*elasticache.DecreaseReplicaCountOutput, error) {
+					return &elasticache.DecreaseReplicaCountOutput{}, nil
+				}
+			})
+			got, err := reconcileElasticacheReplicaCount(cacheSvc, r, tt.group)
+			if err != nil {
+				t.Fatalf("reconcileElasticacheReplicaCount() unexpected error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("reconcileElasticacheReplicaCount() = %v, want %v", got, tt.want)
+			}
+			if tt.wantIncrease > 0 && (len(cacheSvc.calls.IncreaseReplicaCount) != 1 || aws.Int64Value(cacheSvc.calls.IncreaseReplicaCount[0].In1.NewReplicaCount) != tt.wantIncrease) {
+				t.Errorf("reconcileElasticacheReplicaCount() expected replica count to be increased to %d, calls %+v", tt.wantIncrease, cacheSvc.calls.IncreaseReplicaCount)
+			}
+			if tt.wantDecrease > 0 && (len(cacheSvc.calls.DecreaseReplicaCount) != 1 || aws.Int64Value(cacheSvc.calls.DecreaseReplicaCount[0].In1.NewReplicaCount) != tt.wantDecrease) {
+				t.Errorf("reconcileElasticacheReplicaCount() expected replica count to be decreased to %d, calls %+v", tt.wantDecrease, cacheSvc.calls.DecreaseReplicaCount)
+			}
+		})
+	}
+}
+
+func Test_setElasticacheScaledCondition(t *testing.T) {
+	tests := []struct {
+		name       string
+		existing   *metav1.Condition
+		sizing     *croType.RedisSizing
+		nodeType   string
+		group      *elasticache.ReplicationGroup
+		wantNil    bool
+		wantReason string
+		wantStatus metav1.ConditionStatus
+	}{
+		{
+			name:     "test no condition when the replication group was never scaled",
+			nodeType: "cache.t3.micro",
+			group:    buildTestSizingReplicationGroup("available", "cache.t3.micro", 2),
+			wantNil:  true,
+		},
+		{
+			name:       "test pending node type change",
+			nodeType:   "cache.t3.small",
+			group:      buildTestSizingReplicationGroup("available", "cache.t3.micro", 2),
+			wantReason: ScalingPendingReason,
+			wantStatus: metav1.ConditionFalse,
+		},
+		{
+			name:       "test scaling in progress",
+			sizing:     &croType.RedisSizing{NumCacheClusters: 3},
+			nodeType:   "cache.t3.micro",
+			group:      buildTestSizingReplicationGroup(elasticacheStatusModifying, "cache.t3.micro", 2),
+			wantReason: ScalingInProgressReason,
+			wantStatus: metav1.ConditionFalse,
+		},
+		{
+			name:       "test scaling complete",
+			existing:   &metav1.Condition{Type: ScaledCondition, Status: metav1.ConditionFalse, Reason: ScalingInProgressReason},
+			sizing:     &croType.RedisSizing{NumCacheClusters: 3},
+			nodeType:   "cache.t3.micro",
+			group:      buildTestSizingReplicationGroup("available", "cache.t3.micro", 3),
+			wantReason: ScaledReason,
+			wantStatus: metav1.ConditionTrue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := buildTestRedisCR()
+			r.Spec.Sizing = tt.sizing
+			if tt.existing != nil {
+				meta.SetStatusCondition(&r.Status.Conditions, *tt.existing)
+			}
+			setElasticacheScaledCondition(r, &elasticache.CreateReplicationGroupInput{CacheNodeType: aws.String(tt.nodeType)}, tt.group)
+			cond := meta.FindStatusCondition(r.Status.Conditions, ScaledCondition)
+			if tt.wantNil {
+				if cond != nil {
+					t.Fatalf("setElasticacheScaledCondition() unexpected condition %+v", cond)
+				}
+				return
+			}
+			if cond == nil {
+				t.Fatal("setElasticacheScaledCondition() expected a condition")
+			}
+			if cond.Reason != tt.wantReason || cond.Status != tt.wantStatus {
+				t.Errorf("setElasticacheScaledCondition() got %s %s, want %s %s", cond.Status, cond.Reason, tt.wantStatus, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	"github.com/aws/aws-sdk-go/service/rds"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ReservedInstanceClassAnnotation records the rds instance class, or elasticache node type, a resource was
+	// provisioned with in place of the class of its strategy because it was covered by unused reserved capacity. The
+	// resource keeps the class rather than being modified to the class of the strategy
+	ReservedInstanceClassAnnotation = "integreatly.org/reserved-instance-class"
+
+	// OnDemandPricingCondition is the condition reporting if a resource runs on on-demand pricing while reserved
+	// capacity it could use is unused, it's only set for tiers with reserved capacity
+	OnDemandPricingCondition = "OnDemandPricing"
+	// UnusedReservationsReason is the reason of a true on-demand pricing condition
+	UnusedReservationsReason = "UnusedReservations"
+	// CoveredByReservationReason is the reason of a false on-demand pricing condition for a resource of a class
+	// covered by reserved capacity
+	CoveredByReservationReason = "CoveredByReservation"
+	// NoUnusedReservationsReason is the reason of a false on-demand pricing condition for a resource running on
+	// on-demand pricing without reserved capacity it could use
+	NoUnusedReservationsReason = "NoUnusedReservations"
+)
+
+/*
+ReservedCapacity is the inventory of reserved instances, or savings plans, covering rds instance classes or elasticache
+node types in the region of the strategy. It's provided by the operator, the reserved capacity in the account is not
+looked up
+Reservations -> the number of instances, or cache nodes, of each class the reserved capacity covers
+AlternativeClasses -> classes a new resource can be provisioned with in place of the class of the create strategy, in
+order of preference, when the class of the strategy has no unused reservations
+*/
+type ReservedCapacity struct {
+	Reservations       []Reservation `json:"reservations"`
+	AlternativeClasses []string      `json:"alternativeClasses,omitempty"`
+}
+
+// Reservation is reserved capacity covering a number of instances, or cache nodes, of a class
+type Reservation struct {
+	Class string `json:"class"`
+	Count int    `json:"count"`
+}
+
+// reserved returns the number of instances, or cache nodes, of the class covered by the reserved capacity
+func (rc *ReservedCapacity) reserved(class string) int {
+	count := 0
+	for _, r := range rc.Reservations {
+		if r.Class == class {
+			count += r.Count
+		}
+	}
+	return count
+}
+
+// unusedClass returns the first of the class of the strategy and the alternative classes with enough unused
+// reservations for the number of nodes, given the number of instances, or cache nodes, of each class in use
+func (rc *ReservedCapacity) unusedClass(strategyClass string, nodes int, inUse map[string]int) (string, bool) {
+	if rc == nil {
+		return "", false
+	}
+	for _, class := range append([]string{strategyClass}, rc.AlternativeClasses...) {
+		if rc.reserved(class)-inUse[class] >= nodes {
+			return class, true
+		}
+	}
+	return "", false
+}
+
+// chooseReservedClass returns the class a new resource is provisioned with, the class of the strategy unless it has
+// no unused reservations and one of the alternative classes does
+func chooseReservedClass(rc *ReservedCapacity, strategyClass string, nodes int, inUse map[string]int) string {
+	if class, ok := rc.unusedClass(strategyClass, nodes, inUse); ok {
+		return class
+	}
+	return strategyClass
+}
+
+// setOnDemandPricingCondition reports in the status of a resource whether it runs on on-demand pricing while
+// reservations of the class of its strategy, or an alternative class, are unused. inUse includes the resource itself
+func setOnDemandPricingCondition(status *croType.ResourceTypeStatus, generation int64, rc *ReservedCapacity, strategyClass, class string, nodes int, inUse map[string]int) {
+	if rc == nil || len(rc.Reservations) == 0 {
+		meta.RemoveStatusCondition(&status.Conditions, OnDemandPricingCondition)
+		return
+	}
+	cond := metav1.Condition{
+		Type:               OnDemandPricingCondition,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+		Reason:             NoUnusedReservationsReason,
+		Message:            fmt.Sprintf("%s runs on on-demand pricing, no reservations it could use are unused", class),
+	}
+	// the reservations of a class cover any of its instances, the resource is covered while there are no more
+	// instances of its class than reserved
+	if reserved := rc.reserved(class); reserved > 0 && inUse[class] <= reserved {
+		cond.Reason = CoveredByReservationReason
+		cond.Message = fmt.Sprintf("%s is covered by reservations, %d of %d reserved in use", class, inUse[class], reserved)
+	} else {
+		var unused []string
+		for _, c := range append([]string{strategyClass}, rc.AlternativeClasses...) {
+			if c != class && rc.reserved(c)-inUse[c] >= nodes && !resources.Contains(unused, c) {
+				unused = append(unused, c)
+			}
+		}
+		if len(unused) > 0 {
+			cond.Status = metav1.ConditionTrue
+			cond.Reason = UnusedReservationsReason
+			cond.Message = fmt.Sprintf("%s runs on on-demand pricing while reservations of %s are unused", class, strings.Join(unused, ", "))
+		}
+	}
+	meta.SetStatusCondition(&status.Conditions, cond)
+}
+
+// countRDSInstanceClasses returns the number of rds instances of each instance class
+func countRDSInstanceClasses(instances []*rds.DBInstance) map[string]int {
+	inUse := map[string]int{}
+	for _, i := range instances {
+		inUse[aws.StringValue(i.DBInstanceClass)]++
+	}
+	return inUse
+}
+
+// countElasticacheNodeTypes returns the number of cache nodes of each node type
+func countElasticacheNodeTypes(replicationGroups []*elasticache.ReplicationGroup) map[string]int {
+	inUse := map[string]int{}
+	for _, rg := range replicationGroups {
+		inUse[aws.StringValue(rg.CacheNodeType)] += len(rg.MemberClusters)
+	}
+	return inUse
+}
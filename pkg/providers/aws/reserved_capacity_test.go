@@ -0,0 +1,131 @@
+package aws
+
+import (
+	"testing"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func buildTestReservedCapacity() *ReservedCapacity {
+	return &ReservedCapacity{
+		Reservations: []Reservation{
+			{Class: "db.t3.small", Count: 2},
+			{Class: "db.m5.large", Count: 1},
+		},
+		AlternativeClasses: []string{"db.m5.large"},
+	}
+}
+
+func TestChooseReservedClass(t *testing.T) {
+	tests := []struct {
+		name          string
+		rc            *ReservedCapacity
+		strategyClass string
+		nodes         int
+		inUse         map[string]int
+		want          string
+	}{
+		{
+			name:          "test class of the strategy without reserved capacity",
+			strategyClass: "db.t3.micro",
+			nodes:         1,
+			want:          "db.t3.micro",
+		},
+		{
+			name:          "test class of the strategy is kept while it has unused reservations",
+			rc:            buildTestReservedCapacity(),
+			strategyClass: "db.t3.small",
+			nodes:         1,
+			inUse:         map[string]int{"db.t3.small": 1},
+			want:          "db.t3.small",
+		},
+		{
+			name:          "test alternative class with unused reservations is preferred",
+			rc:            buildTestReservedCapacity(),
+			strategyClass: "db.t3.micro",
+			nodes:         1,
+			want:          "db.m5.large",
+		},
+		{
+			name:          "test class of the strategy when reservations are used",
+			rc:            buildTestReservedCapacity(),
+			strategyClass: "db.t3.micro",
+			nodes:         1,
+			inUse:         map[string]int{"db.m5.large": 1},
+			want:          "db.t3.micro",
+		},
+		{
+			name:          "test alternative class needs unused reservations for every node",
+			rc:            buildTestReservedCapacity(),
+			strategyClass: "db.t3.micro",
+			nodes:         2,
+			want:          "db.t3.micro",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chooseReservedClass(tt.rc, tt.strategyClass, tt.nodes, tt.inUse); got != tt.want {
+				t.Errorf("chooseReservedClass() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetOnDemandPricingCondition(t *testing.T) {
+	tests := []struct {
+		name       string
+		rc         *ReservedCapacity
+		class      string
+		inUse      map[string]int
+		wantStatus metav1.ConditionStatus
+		wantReason string
+	}{
+		{
+			name:  "test no condition without reserved capacity",
+			class: "db.t3.micro",
+			inUse: map[string]int{"db.t3.micro": 1},
+		},
+		{
+			name:       "test resource covered by reservations",
+			rc:         buildTestReservedCapacity(),
+			class:      "db.t3.small",
+			inUse:      map[string]int{"db.t3.small": 2},
+			wantStatus: metav1.ConditionFalse,
+			wantReason: CoveredByReservationReason,
+		},
+		{
+			name:       "test resource on on-demand pricing with unused reservations",
+			rc:         buildTestReservedCapacity(),
+			class:      "db.t3.micro",
+			inUse:      map[string]int{"db.t3.micro": 1},
+			wantStatus: metav1.ConditionTrue,
+			wantReason: UnusedReservationsReason,
+		},
+		{
+			name:       "test resource on on-demand pricing without unused reservations",
+			rc:         buildTestReservedCapacity(),
+			class:      "db.t3.micro",
+			inUse:      map[string]int{"db.t3.micro": 1, "db.t3.small": 2, "db.m5.large": 1},
+			wantStatus: metav1.ConditionFalse,
+			wantReason: NoUnusedReservationsReason,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := &croType.ResourceTypeStatus{}
+			setOnDemandPricingCondition(status, 1, tt.rc, "db.t3.small", tt.class, 1, tt.inUse)
+			cond := meta.FindStatusCondition(status.Conditions, OnDemandPricingCondition)
+			if tt.wantReason == "" {
+				if cond != nil {
+					t.Errorf("setOnDemandPricingCondition() condition = %v, want none", cond)
+				}
+				return
+			}
+			if cond == nil || cond.Status != tt.wantStatus || cond.Reason != tt.wantReason {
+				t.Errorf("setOnDemandPricingCondition() condition = %v, want status %s reason %s", cond, tt.wantStatus, tt.wantReason)
+			}
+		})
+	}
+}
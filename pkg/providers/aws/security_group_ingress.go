@@ -0,0 +1,177 @@
+package aws
+
+import (
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	errorUtil "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// allProtocols is the ip protocol of an ingress rule allowing all traffic
+const allProtocols = "-1"
+
+/*
+SecurityGroupIngressRule is an ingress rule of the security group shared by the rds instances and elasticache clusters,
+allowing traffic from source ranges or security groups
+CIDRs -> source ranges allowed, e.g. the node subnets of the cluster
+SourceSecurityGroupIDs -> security groups allowed, they must be in the same vpc, or a peered vpc of the same region
+Protocol -> the ip protocol allowed, tcp, udp or -1 for all protocols, defaults to -1
+FromPort, ToPort -> the port range allowed, required unless all protocols are allowed, e.g. 5432 to allow only rds
+instances to be reached, or 6379 for elasticache clusters
+*/
+type SecurityGroupIngressRule struct {
+	CIDRs                  []string `json:"cidrs,omitempty"`
+	SourceSecurityGroupIDs []string `json:"sourceSecurityGroupIds,omitempty"`
+	Protocol               string   `json:"protocol,omitempty"`
+	FromPort               *int64   `json:"fromPort,omitempty"`
+	ToPort                 *int64   `json:"toPort,omitempty"`
+}
+
+// ingressSource is a single source allowed by an ingress rule, the unit ingress is compared, authorized and revoked in
+type ingressSource struct {
+	protocol string
+	fromPort int64
+	toPort   int64
+	cidr     string
+	groupID  string
+}
+
+func (s ingressSource) key() string {
+	return fmt.Sprintf("%s/%d/%d/%s/%s", s.protocol, s.fromPort, s.toPort, s.cidr, s.groupID)
+}
+
+func (s ingressSource) ipPermission() *ec2.IpPermission {
+	perm := &ec2.IpPermission{IpProtocol: aws.String(s.protocol)}
+	if s.protocol != allProtocols {
+		perm.FromPort = aws.Int64(s.fromPort)
+		perm.ToPort = aws.Int64(s.toPort)
+	}
+	if s.cidr != "" {
+		perm.IpRanges = []*ec2.IpRange{{CidrIp: aws.String(s.cidr)}}
+	}
+	if s.groupID != "" {
+		perm.UserIdGroupPairs = []*ec2.UserIdGroupPair{{GroupId: aws.String(s.groupID)}}
+	}
+	return perm
+}
+
+// buildSecurityGroupIngress returns the ingress expected on the resource security group, the ingress rules of the
+// network discovery if it sets any, otherwise all traffic from the cluster vpc cidr block
+func buildSecurityGroupIngress(discovery *NetworkDiscovery, clusterCidr string) ([]*ec2.IpPermission, error) {
+	if discovery == nil || len(discovery.SecurityGroupIngress) == 0 {
+		return []*ec2.IpPermission{
+			{
+				IpProtocol: aws.String(allProtocols),
+				IpRanges:   []*ec2.IpRange{{CidrIp: aws.String(clusterCidr)}},
+			},
+		}, nil
+	}
+	var perms []*ec2.IpPermission
+	for i, rule := range discovery.SecurityGroupIngress {
+		if len(rule.CIDRs) == 0 && len(rule.SourceSecurityGroupIDs) == 0 {
+			return nil, errorUtil.Errorf("security group ingress rule %d allows no cidrs or source security groups", i)
+		}
+		protocol := rule.Protocol
+		if protocol == "" {
+			protocol = allProtocols
+		}
+		perm := &ec2.IpPermission{IpProtocol: aws.String(protocol)}
+		if protocol != allProtocols {
+			if rule.FromPort == nil || rule.ToPort == nil {
+				return nil, errorUtil.Errorf("security group ingress rule %d for protocol %s requires fromPort and toPort", i, protocol)
+			}
+			perm.FromPort = rule.FromPort
+			perm.ToPort = rule.ToPort
+		}
+		for _, cidr := range rule.CIDRs {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return nil, errorUtil.Wrapf(err, "invalid cidr %s in security group ingress rule %d", cidr, i)
+			}
+			perm.IpRanges = append(perm.IpRanges, &ec2.IpRange{CidrIp: aws.String(cidr)})
+		}
+		for _, id := range rule.SourceSecurityGroupIDs {
+			perm.UserIdGroupPairs = append(perm.UserIdGroupPairs, &ec2.UserIdGroupPair{GroupId: aws.String(id)})
+		}
+		perms = append(perms, perm)
+	}
+	return perms, nil
+}
+
+// splitIngressSources returns the single sources allowed by ip permissions, keyed so they can be compared
+func splitIngressSources(perms []*ec2.IpPermission) map[string]ingressSource {
+	sources := map[string]ingressSource{}
+	for _, perm := range perms {
+		base := ingressSource{protocol: aws.StringValue(perm.IpProtocol)}
+		if base.protocol != allProtocols {
+			base.fromPort = aws.Int64Value(perm.FromPort)
+			base.toPort = aws.Int64Value(perm.ToPort)
+		}
+		for _, r := range perm.IpRanges {
+			s := base
+			s.cidr = aws.StringValue(r.CidrIp)
+			sources[s.key()] = s
+		}
+		for _, pair := range perm.UserIdGroupPairs {
+			s := base
+			s.groupID = aws.StringValue(pair.GroupId)
+			sources[s.key()] = s
+		}
+	}
+	return sources
+}
+
+// buildSecurityGroupIngressChanges returns the ingress to revoke from, and authorize on, a security group so it allows
+// exactly the expected ingress
+func buildSecurityGroupIngressChanges(current, expected []*ec2.IpPermission) ([]*ec2.IpPermission, []*ec2.IpPermission) {
+	currentSources := splitIngressSources(current)
+	expectedSources := splitIngressSources(expected)
+	diff := func(from, to map[string]ingressSource) []*ec2.IpPermission {
+		var keys []string
+		for k := range from {
+			if _, ok := to[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		var perms []*ec2.IpPermission
+		for _, k := range keys {
+			perms = append(perms, from[k].ipPermission())
+		}
+		return perms
+	}
+	return diff(currentSources, expectedSources), diff(expectedSources, currentSources)
+}
+
+// reconcileSecurityGroupIngress revokes the ingress of the security group that isn't expected, e.g. rules changed in
+// the aws console, and authorizes the expected ingress it's missing
+func reconcileSecurityGroupIngress(ec2Svc ec2iface.EC2API, secGroup *ec2.SecurityGroup, expected []*ec2.IpPermission, logger *logrus.Entry) error {
+	revoke, authorize := buildSecurityGroupIngressChanges(secGroup.IpPermissions, expected)
+	if len(revoke) == 0 && len(authorize) == 0 {
+		logger.Infof("ip permissions are correct for security group %s", aws.StringValue(secGroup.GroupName))
+		return nil
+	}
+	if len(revoke) > 0 {
+		logger.Infof("revoking %d unexpected ingress ip permissions of security group %s", len(revoke), aws.StringValue(secGroup.GroupName))
+		if _, err := ec2Svc.RevokeSecurityGroupIngress(&ec2.RevokeSecurityGroupIngressInput{
+			GroupId:       secGroup.GroupId,
+			IpPermissions: revoke,
+		}); err != nil {
+			return errorUtil.Wrap(err, "error revoking security group ingress")
+		}
+	}
+	if len(authorize) > 0 {
+		logger.Infof("setting ingress ip permissions for %s", aws.StringValue(secGroup.GroupName))
+		if _, err := ec2Svc.AuthorizeSecurityGroupIngress(&ec2.AuthorizeSecurityGroupIngressInput{
+			GroupId:       secGroup.GroupId,
+			IpPermissions: authorize,
+		}); err != nil {
+			return errorUtil.Wrap(err, "error authorizing security group ingress")
+		}
+	}
+	return nil
+}
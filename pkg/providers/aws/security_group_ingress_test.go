@@ -0,0 +1,106 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestBuildSecurityGroupIngress(t *testing.T) {
+	tests := []struct {
+		name      string
+		discovery *NetworkDiscovery
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name: "test all traffic from the cluster vpc by default",
+			want: []string{"-1/0/0/10.0.0.0/16/"},
+		},
+		{
+			name: "test ingress rules replace the default rule",
+			discovery: &NetworkDiscovery{SecurityGroupIngress: []SecurityGroupIngressRule{
+				{CIDRs: []string{"10.0.1.0/24", "10.0.2.0/24"}, Protocol: "tcp", FromPort: aws.Int64(5432), ToPort: aws.Int64(5432)},
+				{SourceSecurityGroupIDs: []string{"sg-nodes"}},
+			}},
+			want: []string{"-1/0/0//sg-nodes", "tcp/5432/5432/10.0.1.0/24/", "tcp/5432/5432/10.0.2.0/24/"},
+		},
+		{
+			name:      "test error on a rule without sources",
+			discovery: &NetworkDiscovery{SecurityGroupIngress: []SecurityGroupIngressRule{{Protocol: "tcp", FromPort: aws.Int64(6379), ToPort: aws.Int64(6379)}}},
+			wantErr:   true,
+		},
+		{
+			name:      "test error on a protocol rule without ports",
+			discovery: &NetworkDiscovery{SecurityGroupIngress: []SecurityGroupIngressRule{{CIDRs: []string{"10.0.1.0/24"}, Protocol: "tcp"}}},
+			wantErr:   true,
+		},
+		{
+			name:      "test error on an invalid cidr",
+			discovery: &NetworkDiscovery{SecurityGroupIngress: []SecurityGroupIngressRule{{CIDRs: []string{"10.0.1.0"}}}},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildSecurityGroupIngress(tt.discovery, "10.0.0.0/16")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildSecurityGroupIngress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			sources := splitIngressSources(got)
+			if len(sources) != len(tt.want) {
+				t.Fatalf("buildSecurityGroupIngress() = %v, want %v", sources, tt.want)
+			}
+			for _, key := range tt.want {
+				if _, ok := sources[key]; !ok {
+					t.Errorf("buildSecurityGroupIngress() = %v, want %s", sources, key)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildSecurityGroupIngressChanges(t *testing.T) {
+	clusterRule := &ec2.IpPermission{IpProtocol: aws.String("-1"), IpRanges: []*ec2.IpRange{{CidrIp: aws.String("10.0.0.0/16")}}}
+	nodeRule := &ec2.IpPermission{
+		IpProtocol: aws.String("tcp"),
+		FromPort:   aws.Int64(5432),
+		ToPort:     aws.Int64(5432),
+		IpRanges:   []*ec2.IpRange{{CidrIp: aws.String("10.0.1.0/24")}, {CidrIp: aws.String("10.0.2.0/24")}},
+	}
+	tests := []struct {
+		name          string
+		current       []*ec2.IpPermission
+		expected      []*ec2.IpPermission
+		wantRevoke    int
+		wantAuthorize int
+	}{
+		{
+			name:     "test security group with the expected ingress is left as is",
+			current:  []*ec2.IpPermission{nodeRule},
+			expected: []*ec2.IpPermission{nodeRule},
+		},
+		{
+			name:          "test default rule is replaced by narrower rules",
+			current:       []*ec2.IpPermission{clusterRule},
+			expected:      []*ec2.IpPermission{nodeRule},
+			wantRevoke:    1,
+			wantAuthorize: 2,
+		},
+		{
+			name:       "test ingress added outside the operator is revoked",
+			current:    []*ec2.IpPermission{nodeRule, {IpProtocol: aws.String("tcp"), FromPort: aws.Int64(5432), ToPort: aws.Int64(5432), IpRanges: []*ec2.IpRange{{CidrIp: aws.String("0.0.0.0/0")}}}},
+			expected:   []*ec2.IpPermission{nodeRule},
+			wantRevoke: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revoke, authorize := buildSecurityGroupIngressChanges(tt.current, tt.expected)
+			if len(revoke) != tt.wantRevoke || len(authorize) != tt.wantAuthorize {
+				t.Errorf("buildSecurityGroupIngressChanges() revoke = %v, authorize = %v, want %d revoked and %d authorized", revoke, authorize, tt.wantRevoke, tt.wantAuthorize)
+			}
+		})
+	}
+}
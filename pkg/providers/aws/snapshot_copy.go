@@ -0,0 +1,149 @@
+// utility to copy rds snapshots to a secondary region, with kms re-encryption, to allow recovery from the loss of the
+// region the resources are provisioned in.
+//
+// used by the postgres snapshot provider for on-demand snapshots and by the postgres provider for final snapshots
+
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	errorUtil "github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	rdsSnapshotStatusAvailable = "available"
+	rdsSnapshotTypeManual      = "manual"
+)
+
+// rdsSnapshotCopyTarget is the secondary region snapshots are copied to
+type rdsSnapshotCopyTarget struct {
+	rdsSvc       rdsiface.RDSAPI
+	sourceRegion string
+	config       *CrossRegionSnapshotCopy
+}
+
+// buildRDSSnapshotCopyTarget returns the secondary region rds client snapshots are copied to, or nil if cross region
+// snapshot copy isn't configured in the strategy
+func buildRDSSnapshotCopyTarget(ctx context.Context, c client.Client, credentials *Credentials, stratCfg *StrategyConfig) (*rdsSnapshotCopyTarget, error) {
+	if stratCfg.CrossRegionSnapshotCopy == nil || stratCfg.CrossRegionSnapshotCopy.Region == "" {
+		return nil, nil
+	}
+	sourceRegion, err := GetRegionFromStrategyOrDefault(ctx, c, stratCfg)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to get snapshot source region")
+	}
+	if sourceRegion == stratCfg.CrossRegionSnapshotCopy.Region {
+		return nil, errorUtil.New(fmt.Sprintf("cross region snapshot copy region %s must differ from the resource region", sourceRegion))
+	}
+	sess, err := CreateSessionFromStrategy(ctx, c, credentials, &StrategyConfig{Region: stratCfg.CrossRegionSnapshotCopy.Region})
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to create aws session for cross region snapshot copy")
+	}
+	return &rdsSnapshotCopyTarget{
+		rdsSvc:       rds.New(sess),
+		sourceRegion: sourceRegion,
+		config:       stratCfg.CrossRegionSnapshotCopy,
+	}, nil
+}
+
+// reconcileRDSSnapshotCopy ensures an available source snapshot is copied to the secondary region, keeping the
+// snapshot identifier. the copy is returned once it exists, whether or not it's available yet
+func reconcileRDSSnapshotCopy(target *rdsSnapshotCopyTarget, sourceSnapshot *rds.DBSnapshot) (*rds.DBSnapshot, error) {
+	snapshotID := aws.StringValue(sourceSnapshot.DBSnapshotIdentifier)
+	foundCopy, err := getRDSSnapshot(target.rdsSvc, snapshotID)
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to describe snapshot %s in region %s", snapshotID, target.config.Region)
+	}
+	if foundCopy != nil {
+		return foundCopy, nil
+	}
+
+	// encrypted snapshots must be re-encrypted with a key from the secondary region
+	if aws.BoolValue(sourceSnapshot.Encrypted) && target.config.KmsKeyID == "" {
+		return nil, errorUtil.New(fmt.Sprintf("kms key id is required to copy encrypted snapshot %s to region %s", snapshotID, target.config.Region))
+	}
+	copyInput := &rds.CopyDBSnapshotInput{
+		SourceDBSnapshotIdentifier: sourceSnapshot.DBSnapshotArn,
+		TargetDBSnapshotIdentifier: sourceSnapshot.DBSnapshotIdentifier,
+		SourceRegion:               aws.String(target.sourceRegion),
+		CopyTags:                   aws.Bool(true),
+	}
+	if target.config.KmsKeyID != "" {
+		copyInput.KmsKeyId = aws.String(target.config.KmsKeyID)
+	}
+	output, err := target.rdsSvc.CopyDBSnapshot(copyInput)
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to copy snapshot %s to region %s", snapshotID, target.config.Region)
+	}
+	return output.DBSnapshot, nil
+}
+
+// getLatestRDSSnapshot returns the most recently created manual snapshot of the rds instance, or nil if there are none
+func getLatestRDSSnapshot(rdsSvc rdsiface.RDSAPI, instanceID string) (*rds.DBSnapshot, error) {
+	listOutput, err := rdsSvc.DescribeDBSnapshots(&rds.DescribeDBSnapshotsInput{
+		DBInstanceIdentifier: aws.String(instanceID),
+		SnapshotType:         aws.String(rdsSnapshotTypeManual),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var latest *rds.DBSnapshot
+	for _, s := range listOutput.DBSnapshots {
+		if s.SnapshotCreateTime == nil {
+			continue
+		}
+		if latest == nil || s.SnapshotCreateTime.After(*latest.SnapshotCreateTime) {
+			latest = s
+		}
+	}
+	return latest, nil
+}
+
+// getRDSSnapshot returns the snapshot with the identifier, or nil if it doesn't exist
+func getRDSSnapshot(rdsSvc rdsiface.RDSAPI, snapshotName string) (*rds.DBSnapshot, error) {
+	listOutput, err := rdsSvc.DescribeDBSnapshots(&rds.DescribeDBSnapshotsInput{
+		DBSnapshotIdentifier: aws.String(snapshotName),
+	})
+	if err != nil {
+		rdsErr, isAwsErr := err.(awserr.Error)
+		if isAwsErr && rdsErr.Code() == rds.ErrCodeDBSnapshotNotFoundFault {
+			return nil, nil
+		}
+		return nil, err
+	}
+	for _, s := range listOutput.DBSnapshots {
+		if aws.StringValue(s.DBSnapshotIdentifier) == snapshotName {
+			return s, nil
+		}
+	}
+	return nil, nil
+}
+
+// reconcileFinalRDSSnapshotCopy copies the final snapshot of a deleted rds instance to the secondary region. an empty
+// status message is returned once the copy has been started, or if the instance has no final snapshot
+func reconcileFinalRDSSnapshotCopy(target *rdsSnapshotCopyTarget, rdsSvc rdsiface.RDSAPI, instanceID string) (croType.StatusMessage, error) {
+	finalSnapshot, err := getLatestRDSSnapshot(rdsSvc, instanceID)
+	if err != nil {
+		msg := fmt.Sprintf("failed to describe final snapshot of rds instance %s", instanceID)
+		return croType.StatusMessage(msg), errorUtil.Wrap(err, msg)
+	}
+	if finalSnapshot == nil {
+		return "", nil
+	}
+	if aws.StringValue(finalSnapshot.Status) != rdsSnapshotStatusAvailable {
+		return croType.StatusMessage(fmt.Sprintf("waiting for final snapshot %s to be available before copying to region %s, current status is %s", aws.StringValue(finalSnapshot.DBSnapshotIdentifier), target.config.Region, aws.StringValue(finalSnapshot.Status))), nil
+	}
+	if _, err := reconcileRDSSnapshotCopy(target, finalSnapshot); err != nil {
+		msg := fmt.Sprintf("failed to copy final snapshot %s to region %s", aws.StringValue(finalSnapshot.DBSnapshotIdentifier), target.config.Region)
+		return croType.StatusMessage(msg), errorUtil.Wrap(err, msg)
+	}
+	return "", nil
+}
@@ -0,0 +1,275 @@
+package aws
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+)
+
+const (
+	testCopyRegion     = "test-copy-region"
+	testSourceRegion   = "test-source-region"
+	testCopyKmsKeyID   = "test-kms-key"
+	testSnapshotArn    = "arn:aws:rds:test-source-region:123456789012:snapshot:test-snapshot"
+	testCopySnapshotID = "test-snapshot"
+)
+
+func buildTestRDSSnapshotCopyTarget(modifyFn func(*rdsClientMock)) *rdsSnapshotCopyTarget {
+	return &rdsSnapshotCopyTarget{
+		rdsSvc:       buildRdsClientMock(modifyFn),
+		sourceRegion: testSourceRegion,
+		config: &CrossRegionSnapshotCopy{
+			Region:   testCopyRegion,
+			KmsKeyID: testCopyKmsKeyID,
+		},
+	}
+}
+
+func Test_reconcileRDSSnapshotCopy(t *testing.T) {
+	type args struct {
+		target         *rdsSnapshotCopyTarget
+		sourceSnapshot *rds.DBSnapshot
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *rds.DBSnapshot
+		wantErr string
+		wantFn  func(mock *rdsClientMock) error
+	}{
+		{
+			name: "test existing copy is returned",
+			args: args{
+				target: buildTestRDSSnapshotCopyTarget(func(mock *rdsClientMock) {
+					mock.DescribeDBSnapshotsFunc = func(in *rds.DescribeDBSnapshotsInput) (*rds.DescribeDBSnapshotsOutput, error) {
+						return &rds.DescribeDBSnapshotsOutput{
+							DBSnapshots: []*rds.DBSnapshot{
+								{
+									DBSnapshotIdentifier: aws.String(testCopySnapshotID),
+									Status:               aws.String("available"),
+								},
+							},
+						}, nil
+					}
+				}),
+				sourceSnapshot: &rds.DBSnapshot{
+					DBSnapshotIdentifier: aws.String(testCopySnapshotID),
+					DBSnapshotArn:        aws.String(testSnapshotArn),
+				},
+			},
+			want: &rds.DBSnapshot{
+				DBSnapshotIdentifier: aws.String(testCopySnapshotID),
+				Status:               aws.String("available"),
+			},
+			wantFn: func(mock *rdsClientMock) error {
+				if len(mock.calls.CopyDBSnapshot) != 0 {
+					return errors.New("CopyDBSnapshot was called for an existing copy")
+				}
+				return nil
+			},
+		},
+		{
+			name: "test snapshot is copied and re-encrypted with the secondary region key",
+			args: args{
+				target: buildTestRDSSnapshotCopyTarget(func(mock *rdsClientMock) {
+					mock.DescribeDBSnapshotsFunc = func(in *rds.DescribeDBSnapshotsInput) (*rds.DescribeDBSnapshotsOutput, error) {
+						return &rds.DescribeDBSnapshotsOutput{}, nil
+					}
+					mock.CopyDBSnapshotFunc = func(in *rds.CopyDBSnapshotInput) (*rds.CopyDBSnapshotOutput, error) {
+						return &rds.CopyDBSnapshotOutput{
+							DBSnapshot: &rds.DBSnapshot{
+								DBSnapshotIdentifier: in.TargetDBSnapshotIdentifier,
+								Status:               aws.String("creating"),
+							},
+						}, nil
+					}
+				}),
+				sourceSnapshot: &rds.DBSnapshot{
+					DBSnapshotIdentifier: aws.String(testCopySnapshotID),
+					DBSnapshotArn:        aws.String(testSnapshotArn),
+					Encrypted:            aws.Bool(true),
+				},
+			},
+			want: &rds.DBSnapshot{
+				DBSnapshotIdentifier: aws.String(testCopySnapshotID),
+				Status:               aws.String("creating"),
+			},
+			wantFn: func(mock *rdsClientMock) error {
+				if len(mock.calls.CopyDBSnapshot) != 1 {
+					return errors.New("CopyDBSnapshot was not called")
+				}
+				wantCopyInput := &rds.CopyDBSnapshotInput{
+					SourceDBSnapshotIdentifier: aws.String(testSnapshotArn),
+					TargetDBSnapshotIdentifier: aws.String(testCopySnapshotID),
+					SourceRegion:               aws.String(testSourceRegion),
+					KmsKeyId:                   aws.String(testCopyKmsKeyID),
+					CopyTags:                   aws.Bool(true),
+				}
+				if gotCopyInput := mock.calls.CopyDBSnapshot[0].In1; !reflect.DeepEqual(gotCopyInput, wantCopyInput) {
+					return errors.New("wrong CopyDBSnapshotInput")
+				}
+				return nil
+			},
+		},
+		{
+			name: "test error when encrypted snapshot is copied without a kms key",
+			args: args{
+				target: &rdsSnapshotCopyTarget{
+					rdsSvc: buildRdsClientMock(func(mock *rdsClientMock) {
+						mock.DescribeDBSnapshotsFunc = func(in *rds.DescribeDBSnapshotsInput) (*rds.DescribeDBSnapshotsOutput, error) {
+							return &rds.DescribeDBSnapshotsOutput{}, nil
+						}
+					}),
+					sourceRegion: testSourceRegion,
+					config:       &CrossRegionSnapshotCopy{Region: testCopyRegion},
+				},
+				sourceSnapshot: &rds.DBSnapshot{
+					DBSnapshotIdentifier: aws.String(testCopySnapshotID),
+					DBSnapshotArn:        aws.String(testSnapshotArn),
+					Encrypted:            aws.Bool(true),
+				},
+			},
+			wantErr: "kms key id is required to copy encrypted snapshot " + testCopySnapshotID + " to region " + testCopyRegion,
+		},
+		{
+			name: "test error when CopyDBSnapshot fails",
+			args: args{
+				target: buildTestRDSSnapshotCopyTarget(func(mock *rdsClientMock) {
+					mock.DescribeDBSnapshotsFunc = func(in *rds.DescribeDBSnapshotsInput) (*rds.DescribeDBSnapshotsOutput, error) {
+						return &rds.DescribeDBSnapshotsOutput{}, nil
+					}
+					mock.CopyDBSnapshotFunc = func(in *rds.CopyDBSnapshotInput) (*rds.CopyDBSnapshotOutput, error) {
+						return nil, errors.New("test")
+					}
+				}),
+				sourceSnapshot: &rds.DBSnapshot{
+					DBSnapshotIdentifier: aws.String(testCopySnapshotID),
+					DBSnapshotArn:        aws.String(testSnapshotArn),
+				},
+			},
+			wantErr: "failed to copy snapshot " + testCopySnapshotID + " to region " + testCopyRegion + ": test",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := reconcileRDSSnapshotCopy(tt.args.target, tt.args.sourceSnapshot)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("reconcileRDSSnapshotCopy() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("reconcileRDSSnapshotCopy() unexpected error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("reconcileRDSSnapshotCopy() = %v, want %v", got, tt.want)
+			}
+			if tt.wantFn != nil {
+				if err := tt.wantFn(tt.args.target.rdsSvc.(*rdsClientMock)); err != nil {
+					t.Errorf("reconcileRDSSnapshotCopy() err = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func Test_reconcileFinalRDSSnapshotCopy(t *testing.T) {
+	now := time.Now()
+	buildFinalSnapshotRdsClient := func(snapshots ...*rds.DBSnapshot) rdsiface.RDSAPI {
+		return buildRdsClientMock(func(mock *rdsClientMock) {
+			mock.DescribeDBSnapshotsFunc = func(in *rds.DescribeDBSnapshotsInput) (*rds.DescribeDBSnapshotsOutput, error) {
+				return &rds.DescribeDBSnapshotsOutput{DBSnapshots: snapshots}, nil
+			}
+		})
+	}
+	type args struct {
+		target     *rdsSnapshotCopyTarget
+		rdsSvc     rdsiface.RDSAPI
+		instanceID string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    croType.StatusMessage
+		wantErr string
+	}{
+		{
+			name: "test nothing is copied when the instance has no final snapshot",
+			args: args{
+				target:     buildTestRDSSnapshotCopyTarget(nil),
+				rdsSvc:     buildFinalSnapshotRdsClient(),
+				instanceID: "test-id",
+			},
+			want: "",
+		},
+		{
+			name: "test waits for the final snapshot to be available",
+			args: args{
+				target: buildTestRDSSnapshotCopyTarget(nil),
+				rdsSvc: buildFinalSnapshotRdsClient(&rds.DBSnapshot{
+					DBSnapshotIdentifier: aws.String(testCopySnapshotID),
+					SnapshotCreateTime:   aws.Time(now),
+					Status:               aws.String("creating"),
+				}),
+				instanceID: "test-id",
+			},
+			want: "waiting for final snapshot " + testCopySnapshotID + " to be available before copying to region " + testCopyRegion + ", current status is creating",
+		},
+		{
+			name: "test latest final snapshot is copied",
+			args: args{
+				target: buildTestRDSSnapshotCopyTarget(func(mock *rdsClientMock) {
+					mock.DescribeDBSnapshotsFunc = func(in *rds.DescribeDBSnapshotsInput) (*rds.DescribeDBSnapshotsOutput, error) {
+						return &rds.DescribeDBSnapshotsOutput{}, nil
+					}
+					mock.CopyDBSnapshotFunc = func(in *rds.CopyDBSnapshotInput) (*rds.CopyDBSnapshotOutput, error) {
+						if aws.StringValue(in.TargetDBSnapshotIdentifier) != testCopySnapshotID {
+							return nil, errors.New("wrong snapshot copied")
+						}
+						return &rds.CopyDBSnapshotOutput{}, nil
+					}
+				}),
+				rdsSvc: buildFinalSnapshotRdsClient(
+					&rds.DBSnapshot{
+						DBSnapshotIdentifier: aws.String("test-older-snapshot"),
+						SnapshotCreateTime:   aws.Time(now.Add(-time.Hour)),
+						Status:               aws.String("available"),
+					},
+					&rds.DBSnapshot{
+						DBSnapshotIdentifier: aws.String(testCopySnapshotID),
+						SnapshotCreateTime:   aws.Time(now),
+						Status:               aws.String("available"),
+					},
+				),
+				instanceID: "test-id",
+			},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := reconcileFinalRDSSnapshotCopy(tt.args.target, tt.args.rdsSvc, tt.args.instanceID)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("reconcileFinalRDSSnapshotCopy() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("reconcileFinalRDSSnapshotCopy() unexpected error = %v", err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("reconcileFinalRDSSnapshotCopy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
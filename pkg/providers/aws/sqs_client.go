@@ -0,0 +1,177 @@
+// minimal client of the aws simple queue service, covering the operations used to provision queues. the sqs package
+// of the aws sdk isn't vendored, the client is built on the query protocol of the sdk the same way the generated
+// clients of the sdk are
+
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/aws/aws-sdk-go/private/protocol/query"
+)
+
+const (
+	sqsEndpointsID = "sqs"
+	sqsServiceID   = "SQS"
+	sqsAPIVersion  = "2012-11-05"
+
+	sqsErrCodeQueueDoesNotExist = "AWS.SimpleQueueService.NonExistentQueue"
+
+	sqsOperationCreateQueue        = "CreateQueue"
+	sqsOperationGetQueueURL        = "GetQueueUrl"
+	sqsOperationGetQueueAttributes = "GetQueueAttributes"
+	sqsOperationSetQueueAttributes = "SetQueueAttributes"
+	sqsOperationTagQueue           = "TagQueue"
+	sqsOperationDeleteQueue        = "DeleteQueue"
+
+	sqsAttributeQueueArn               = "QueueArn"
+	sqsAttributeVisibilityTimeout      = "VisibilityTimeout"
+	sqsAttributeMessageRetentionPeriod = "MessageRetentionPeriod"
+	sqsAttributeRedrivePolicy          = "RedrivePolicy"
+	sqsAttributeKmsMasterKeyID         = "KmsMasterKeyId"
+	sqsAttributeSqsManagedSseEnabled   = "SqsManagedSseEnabled"
+)
+
+type sqsAPI interface {
+	CreateQueue(input *sqsCreateQueueInput) (*sqsCreateQueueOutput, error)
+	GetQueueUrl(input *sqsGetQueueURLInput) (*sqsGetQueueURLOutput, error)
+	GetQueueAttributes(input *sqsGetQueueAttributesInput) (*sqsGetQueueAttributesOutput, error)
+	SetQueueAttributes(input *sqsSetQueueAttributesInput) error
+	TagQueue(input *sqsTagQueueInput) error
+	DeleteQueue(input *sqsDeleteQueueInput) error
+}
+
+type sqsCreateQueueInput struct {
+	_          struct{}           `type:"structure"`
+	Attributes map[string]*string `locationName:"Attribute" locationNameKey:"Name" locationNameValue:"Value" type:"map" flattened:"true"`
+	QueueName  *string            `type:"string" required:"true"`
+	Tags       map[string]*string `locationName:"Tag" locationNameKey:"Key" locationNameValue:"Value" type:"map" flattened:"true"`
+}
+
+type sqsCreateQueueOutput struct {
+	_        struct{} `type:"structure"`
+	QueueUrl *string  `type:"string"`
+}
+
+type sqsGetQueueURLInput struct {
+	_         struct{} `type:"structure"`
+	QueueName *string  `type:"string" required:"true"`
+}
+
+type sqsGetQueueURLOutput struct {
+	_        struct{} `type:"structure"`
+	QueueUrl *string  `type:"string"`
+}
+
+type sqsGetQueueAttributesInput struct {
+	_              struct{}  `type:"structure"`
+	AttributeNames []*string `locationNameList:"AttributeName" type:"list" flattened:"true"`
+	QueueUrl       *string   `type:"string" required:"true"`
+}
+
+type sqsGetQueueAttributesOutput struct {
+	_          struct{}           `type:"structure"`
+	Attributes map[string]*string `locationName:"Attribute" locationNameKey:"Name" locationNameValue:"Value" type:"map" flattened:"true"`
+}
+
+type sqsSetQueueAttributesInput struct {
+	_          struct{}           `type:"structure"`
+	Attributes map[string]*string `locationName:"Attribute" locationNameKey:"Name" locationNameValue:"Value" type:"map" flattened:"true" required:"true"`
+	QueueUrl   *string            `type:"string" required:"true"`
+}
+
+type sqsTagQueueInput struct {
+	_        struct{}           `type:"structure"`
+	QueueUrl *string            `type:"string" required:"true"`
+	Tags     map[string]*string `locationName:"Tag" locationNameKey:"Key" locationNameValue:"Value" type:"map" flattened:"true" required:"true"`
+}
+
+type sqsDeleteQueueInput struct {
+	_        struct{} `type:"structure"`
+	QueueUrl *string  `type:"string" required:"true"`
+}
+
+var _ sqsAPI = (*sqsClient)(nil)
+
+type sqsClient struct {
+	*client.Client
+}
+
+// newSQSClient creates an sqs client from a session, resolving the endpoint and signing the requests like the clients
+// of the sdk
+func newSQSClient(p client.ConfigProvider, cfgs ...*aws.Config) *sqsClient {
+	c := p.ClientConfig(sqsEndpointsID, cfgs...)
+	if c.SigningNameDerived || len(c.SigningName) == 0 {
+		c.SigningName = sqsEndpointsID
+	}
+	svc := &sqsClient{
+		Client: client.New(
+			*c.Config,
+			metadata.ClientInfo{
+				ServiceName:    sqsEndpointsID,
+				ServiceID:      sqsServiceID,
+				SigningName:    c.SigningName,
+				SigningRegion:  c.SigningRegion,
+				PartitionID:    c.PartitionID,
+				Endpoint:       c.Endpoint,
+				APIVersion:     sqsAPIVersion,
+				ResolvedRegion: c.ResolvedRegion,
+			},
+			c.Handlers,
+		),
+	}
+	svc.Handlers.Sign.PushBackNamed(v4.SignRequestHandler)
+	svc.Handlers.Build.PushBackNamed(query.BuildHandler)
+	svc.Handlers.Unmarshal.PushBackNamed(query.UnmarshalHandler)
+	svc.Handlers.UnmarshalMeta.PushBackNamed(query.UnmarshalMetaHandler)
+	svc.Handlers.UnmarshalError.PushBackNamed(query.UnmarshalErrorHandler)
+	return svc
+}
+
+func (c *sqsClient) send(operation string, input, output interface{}) error {
+	op := &request.Operation{
+		Name:       operation,
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}
+	return c.NewRequest(op, input, output).Send()
+}
+
+func (c *sqsClient) CreateQueue(input *sqsCreateQueueInput) (*sqsCreateQueueOutput, error) {
+	output := &sqsCreateQueueOutput{}
+	if err := c.send(sqsOperationCreateQueue, input, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (c *sqsClient) GetQueueUrl(input *sqsGetQueueURLInput) (*sqsGetQueueURLOutput, error) {
+	output := &sqsGetQueueURLOutput{}
+	if err := c.send(sqsOperationGetQueueURL, input, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (c *sqsClient) GetQueueAttributes(input *sqsGetQueueAttributesInput) (*sqsGetQueueAttributesOutput, error) {
+	output := &sqsGetQueueAttributesOutput{}
+	if err := c.send(sqsOperationGetQueueAttributes, input, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (c *sqsClient) SetQueueAttributes(input *sqsSetQueueAttributesInput) error {
+	return c.send(sqsOperationSetQueueAttributes, input, &struct{}{})
+}
+
+func (c *sqsClient) TagQueue(input *sqsTagQueueInput) error {
+	return c.send(sqsOperationTagQueue, input, &struct{}{})
+}
+
+func (c *sqsClient) DeleteQueue(input *sqsDeleteQueueInput) error {
+	return c.send(sqsOperationDeleteQueue, input, &struct{}{})
+}
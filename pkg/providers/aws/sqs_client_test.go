@@ -0,0 +1,101 @@
+package aws
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+const testSQSQueueURL = "https://sqs.eu-west-1.amazonaws.com/123456789012/test-queue"
+
+func buildTestSQSClient(t *testing.T, handler http.HandlerFunc) (*sqsClient, func()) {
+	server := httptest.NewServer(handler)
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("eu-west-1"),
+		Endpoint:    aws.String(server.URL),
+		Credentials: credentials.NewStaticCredentials("test", "test", ""),
+		MaxRetries:  aws.Int(0),
+	})
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	return newSQSClient(sess), server.Close
+}
+
+func TestSQSClient_CreateQueue(t *testing.T) {
+	sqsSvc, closeServer := buildTestSQSClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("failed to parse request: %v", err)
+		}
+		want := map[string]string{
+			"Action":            sqsOperationCreateQueue,
+			"Version":           sqsAPIVersion,
+			"QueueName":         "test-queue",
+			"Attribute.1.Name":  sqsAttributeVisibilityTimeout,
+			"Attribute.1.Value": "60",
+			"Tag.1.Key":         "red-hat-managed",
+			"Tag.1.Value":       "true",
+		}
+		for k, v := range want {
+			if got := r.PostForm.Get(k); got != v {
+				t.Errorf("CreateQueue() request %s = %q, want %q", k, got, v)
+			}
+		}
+		_, _ = w.Write([]byte(`<CreateQueueResponse><CreateQueueResult><QueueUrl>` + testSQSQueueURL + `</QueueUrl></CreateQueueResult></CreateQueueResponse>`))
+	})
+	defer closeServer()
+
+	output, err := sqsSvc.CreateQueue(&sqsCreateQueueInput{
+		QueueName:  aws.String("test-queue"),
+		Attributes: map[string]*string{sqsAttributeVisibilityTimeout: aws.String("60")},
+		Tags:       map[string]*string{"red-hat-managed": aws.String("true")},
+	})
+	if err != nil {
+		t.Fatalf("CreateQueue() unexpected error = %v", err)
+	}
+	if aws.StringValue(output.QueueUrl) != testSQSQueueURL {
+		t.Errorf("CreateQueue() queue url = %s, want %s", aws.StringValue(output.QueueUrl), testSQSQueueURL)
+	}
+}
+
+func TestSQSClient_GetQueueAttributes(t *testing.T) {
+	sqsSvc, closeServer := buildTestSQSClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("failed to parse request: %v", err)
+		}
+		if got := r.PostForm.Get("AttributeName.1"); got != sqsAttributeQueueArn {
+			t.Errorf("GetQueueAttributes() request AttributeName.1 = %q, want %q", got, sqsAttributeQueueArn)
+		}
+		_, _ = w.Write([]byte(`<GetQueueAttributesResponse><GetQueueAttributesResult><Attribute><Name>QueueArn</Name><Value>arn:aws:sqs:eu-west-1:123456789012:test-queue</Value></Attribute></GetQueueAttributesResult></GetQueueAttributesResponse>`))
+	})
+	defer closeServer()
+
+	output, err := sqsSvc.GetQueueAttributes(&sqsGetQueueAttributesInput{
+		QueueUrl:       aws.String(testSQSQueueURL),
+		AttributeNames: aws.StringSlice([]string{sqsAttributeQueueArn}),
+	})
+	if err != nil {
+		t.Fatalf("GetQueueAttributes() unexpected error = %v", err)
+	}
+	if got := aws.StringValue(output.Attributes[sqsAttributeQueueArn]); got != "arn:aws:sqs:eu-west-1:123456789012:test-queue" {
+		t.Errorf("GetQueueAttributes() queue arn = %s", got)
+	}
+}
+
+func TestSQSClient_GetQueueUrl(t *testing.T) {
+	sqsSvc, closeServer := buildTestSQSClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<ErrorResponse><Error><Type>Sender</Type><Code>AWS.SimpleQueueService.NonExistentQueue</Code><Message>The specified queue does not exist.</Message></Error><RequestId>test</RequestId></ErrorResponse>`))
+	})
+	defer closeServer()
+
+	_, err := sqsSvc.GetQueueUrl(&sqsGetQueueURLInput{QueueName: aws.String("missing")})
+	if awsErr, ok := err.(awserr.Error); !ok || awsErr.Code() != sqsErrCodeQueueDoesNotExist {
+		t.Errorf("GetQueueUrl() error = %v, want %s", err, sqsErrCodeQueueDoesNotExist)
+	}
+}
@@ -2,6 +2,7 @@ package aws
 
 import (
 	"context"
+	"sort"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
@@ -82,6 +83,15 @@ func genericToElasticacheTags(tags []*tag) []*elasticache.Tag {
 	return cacheTags
 }
 
+// sqs queues are tagged with a map of tag keys to values
+func genericToSQSTags(tags []*tag) map[string]*string {
+	sqsTags := map[string]*string{}
+	for _, tag := range tags {
+		sqsTags[tag.key] = aws.String(tag.value)
+	}
+	return sqsTags
+}
+
 func rdsTagstoGeneric(rdsTags []*rds.Tag) []*tag {
 	var genericTags []*tag
 	for _, rdsTag := range rdsTags {
@@ -122,6 +132,15 @@ func tagsContains(tags []*tag, key, value string) bool {
 	return false
 }
 
+func tagsContainsKey(tags []*tag, key string) bool {
+	for _, tag := range tags {
+		if tag.key == key {
+			return true
+		}
+	}
+	return false
+}
+
 // Checks whether all tags in first parameter are contained within second parameter
 func tagsContainsAll(as []*tag, bs []*tag) bool {
 	for _, a := range as {
@@ -175,6 +194,13 @@ func getDefaultResourceTags(ctx context.Context, c client.Client, specType strin
 		msg := "Failed to get user infrastructure tags"
 		return nil, "", errorUtil.Wrapf(err, msg)
 	}
+	metadataTags, err := getMetadataTags(ctx, c, tags)
+	if err != nil {
+		msg := "Failed to get metadata tags"
+		return nil, "", errorUtil.Wrapf(err, msg)
+	}
+	// the operator wide metadata is preferred over the user infrastructure tags, but never replaces the default tags
+	infraTags = mergeTags(metadataTags, infraTags)
 	if infraTags != nil {
 		// merge tags into single array, where any duplicate
 		// values in infra are overwritten by the default tags
@@ -202,6 +228,26 @@ func getUserInfraTags(ctx context.Context, c client.Client) ([]*tag, error) {
 	return tags, nil
 }
 
+// getMetadataTags returns the labels of the operator wide metadata as tags, except for the keys of the default tags
+func getMetadataTags(ctx context.Context, c client.Client, defaultTags []*tag) ([]*tag, error) {
+	md, err := resources.GetMetadata(ctx, c)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to get metadata")
+	}
+	var tags []*tag
+	for k, v := range md.Labels {
+		if tagsContainsKey(defaultTags, k) {
+			continue
+		}
+		tags = append(tags, &tag{key: k, value: v})
+	}
+	// sorted so the tags compare equal on every reconcile
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].key < tags[j].key
+	})
+	return tags, nil
+}
+
 func buildManagedTag() *tag {
 	return &tag{
 		key:   tagManagedKey,
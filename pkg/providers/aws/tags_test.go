@@ -3,6 +3,9 @@ package aws
 import (
 	"context"
 	configv1 "github.com/integr8ly/cloud-resource-operator/apis/config/v1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"reflect"
 	controllerruntime "sigs.k8s.io/controller-runtime"
@@ -577,6 +580,11 @@ func Test_getDefaultResourceTags(t *testing.T) {
 	if err != nil {
 		t.Fatal("failed to build scheme", err)
 	}
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	defer func(ns string) { resources.MetadataConfigMapNamespace = ns }(resources.MetadataConfigMapNamespace)
+	resources.MetadataConfigMapNamespace = "test"
 	type args struct {
 		ctx      context.Context
 		client   client.Client
@@ -658,6 +666,65 @@ func Test_getDefaultResourceTags(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "metadata labels are preferred over user infrastructure tags but not over default tags",
+			args: args{
+				ctx: context.TODO(),
+				client: fake.NewFakeClientWithScheme(scheme, &configv1.Infrastructure{
+					ObjectMeta: controllerruntime.ObjectMeta{
+						Name: "cluster",
+					},
+					Status: configv1.InfrastructureStatus{
+						InfrastructureName: defaultInfraName,
+						PlatformStatus: &configv1.PlatformStatus{
+							Type: configv1.AWSPlatformType,
+							AWS: &configv1.AWSPlatformStatus{
+								Region: "eu-west-1",
+								ResourceTags: []configv1.AWSResourceTag{
+									{
+										Key:   "cost-center",
+										Value: "infra",
+									},
+								},
+							},
+						},
+					},
+				}, &v1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      resources.DefaultMetadataConfigMapName,
+						Namespace: "test",
+					},
+					Data: map[string]string{
+						resources.MetadataLabelsKey: `{"cost-center": "1234", "red-hat-managed": "false"}`,
+					},
+				}),
+				specType: "specType",
+				name:     "name",
+			},
+			want: []*tag{
+				{
+					key:   "cost-center",
+					value: "1234",
+				},
+				{
+					key:   "integreatly.org/clusterID",
+					value: "test",
+				},
+				{
+					key:   "integreatly.org/resource-type",
+					value: "specType",
+				},
+				{
+					key:   "integreatly.org/resource-name",
+					value: "name",
+				},
+				{
+					key:   "red-hat-managed",
+					value: "true",
+				},
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,101 @@
+package providers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources/metrics"
+	errorUtil "github.com/pkg/errors"
+)
+
+// CallDeadline is how long a call of a provider to an operation of its cloud may take, including its retries, so a
+// call hanging in the sdk of the cloud fails instead of stalling the reconcile making it
+type CallDeadline struct {
+	// Provider is the provider making the call, e.g. aws-rds, ending in * to match every provider with the prefix
+	Provider string
+	// Operation is the operation called, e.g. ModifyDBInstance, ending in * to match every operation with the prefix
+	Operation string
+	// Timeout is the deadline of the call, zero for calls without a deadline
+	Timeout time.Duration
+}
+
+// CallDeadlines are the deadlines of the calls of the providers to their clouds, the first deadline matching a call
+// applies to it
+var CallDeadlines = DefaultCallDeadlines()
+
+// DefaultCallDeadlines returns the deadlines of the calls if they aren't configured. Reads return in seconds, while
+// calls changing a resource may wait for the cloud to accept the change
+func DefaultCallDeadlines() []CallDeadline {
+	return []CallDeadline{
+		{Provider: "*", Operation: "Describe*", Timeout: 30 * time.Second},
+		{Provider: "*", Operation: "List*", Timeout: 30 * time.Second},
+		{Provider: "*", Operation: "Get*", Timeout: 30 * time.Second},
+		{Provider: "*", Operation: "*", Timeout: 2 * time.Minute},
+	}
+}
+
+// ParseCallDeadlines parses a comma separated list of call deadlines, e.g. aws-rds/Modify*=5m, before the default
+// deadlines. A deadline without a provider, e.g. Describe*=10s, applies to every provider
+func ParseCallDeadlines(s string) ([]CallDeadline, error) {
+	var deadlines []CallDeadline
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, errorUtil.Errorf("invalid call deadline %s, expected provider/operation=timeout", entry)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+		if err != nil || timeout < 0 {
+			return nil, errorUtil.Errorf("invalid call deadline %s, timeout must be a duration of at least 0", entry)
+		}
+		deadline := CallDeadline{Provider: "*", Operation: strings.TrimSpace(parts[0]), Timeout: timeout}
+		if call := strings.SplitN(deadline.Operation, "/", 2); len(call) == 2 {
+			deadline.Provider, deadline.Operation = strings.TrimSpace(call[0]), strings.TrimSpace(call[1])
+		}
+		if deadline.Provider == "" || deadline.Operation == "" {
+			return nil, errorUtil.Errorf("invalid call deadline %s, expected provider/operation=timeout", entry)
+		}
+		deadlines = append(deadlines, deadline)
+	}
+	return append(deadlines, DefaultCallDeadlines()...), nil
+}
+
+// CallTimeout returns the deadline of a call of the provider to the operation, zero if the call has no deadline
+func CallTimeout(provider, operation string) time.Duration {
+	for _, d := range CallDeadlines {
+		if matchCallPattern(d.Provider, provider) && matchCallPattern(d.Operation, operation) {
+			return d.Timeout
+		}
+	}
+	return 0
+}
+
+// WithCallDeadline returns the context of a call of the provider to the operation, done once the deadline of the call
+// passes. The deadline of the parent context is kept if it's earlier
+func WithCallDeadline(ctx context.Context, provider, operation string) (context.Context, context.CancelFunc) {
+	timeout := CallTimeout(provider, operation)
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// ObserveCallDeadline counts the call of the provider to the operation if it failed because the deadline of its context
+// passed, it returns true if it did
+func ObserveCallDeadline(ctx context.Context, provider, operation string, err error) bool {
+	if err == nil || ctx.Err() != context.DeadlineExceeded {
+		return false
+	}
+	metrics.IncCallTimeouts(provider, operation)
+	return true
+}
+
+func matchCallPattern(pattern, s string) bool {
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(s, strings.TrimSuffix(pattern, "*"))
+	}
+	return pattern == s
+}
@@ -0,0 +1,131 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseCallDeadlines(t *testing.T) {
+	tests := []struct {
+		name      string
+		s         string
+		provider  string
+		operation string
+		want      time.Duration
+		wantErr   bool
+	}{
+		{
+			name:      "test the default deadline of a read is kept",
+			s:         "",
+			provider:  "aws-rds",
+			operation: "DescribeDBInstances",
+			want:      30 * time.Second,
+		},
+		{
+			name:      "test the default deadline of a change is kept",
+			s:         "",
+			provider:  "aws-rds",
+			operation: "ModifyDBInstance",
+			want:      2 * time.Minute,
+		},
+		{
+			name:      "test the deadline of an operation of a provider is overridden",
+			s:         "aws-rds/Modify*=5m, aws-elasticache/Modify*=10m",
+			provider:  "aws-rds",
+			operation: "ModifyDBInstance",
+			want:      5 * time.Minute,
+		},
+		{
+			name:      "test a deadline of another provider doesn't apply",
+			s:         "aws-elasticache/Modify*=10m",
+			provider:  "aws-rds",
+			operation: "ModifyDBInstance",
+			want:      2 * time.Minute,
+		},
+		{
+			name:      "test a deadline without a provider applies to every provider",
+			s:         "DescribeDBInstances=10s",
+			provider:  "aws-rds",
+			operation: "DescribeDBInstances",
+			want:      10 * time.Second,
+		},
+		{
+			name:      "test a deadline of zero disables the deadline",
+			s:         "gcp-*/*=0",
+			provider:  "gcp-cloudsql",
+			operation: "GetInstance",
+			want:      0,
+		},
+		{
+			name:    "test a deadline without a timeout is invalid",
+			s:       "aws-rds/Modify*",
+			wantErr: true,
+		},
+		{
+			name:    "test a deadline without an operation is invalid",
+			s:       "aws-rds/=1m",
+			wantErr: true,
+		},
+		{
+			name:    "test a negative deadline is invalid",
+			s:       "aws-rds/Modify*=-1m",
+			wantErr: true,
+		},
+	}
+	defer func() {
+		CallDeadlines = DefaultCallDeadlines()
+	}()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCallDeadlines(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCallDeadlines() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			CallDeadlines = got
+			if timeout := CallTimeout(tt.provider, tt.operation); timeout != tt.want {
+				t.Errorf("CallTimeout() %s %s = %v, want %v", tt.provider, tt.operation, timeout, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithCallDeadline(t *testing.T) {
+	defer func() {
+		CallDeadlines = DefaultCallDeadlines()
+	}()
+	CallDeadlines = []CallDeadline{
+		{Provider: "test", Operation: "Slow", Timeout: time.Millisecond},
+		{Provider: "test", Operation: "Unbounded", Timeout: 0},
+		{Provider: "test", Operation: "*", Timeout: time.Hour},
+	}
+
+	ctx, cancel := WithCallDeadline(context.TODO(), "test", "Slow")
+	defer cancel()
+	<-ctx.Done()
+	if !ObserveCallDeadline(ctx, "test", "Slow", errors.New("request canceled")) {
+		t.Error("ObserveCallDeadline() = false, want the call past its deadline observed")
+	}
+	if ObserveCallDeadline(ctx, "test", "Slow", nil) {
+		t.Error("ObserveCallDeadline() = true, want a call succeeding before its deadline ignored")
+	}
+
+	ctx, cancel = WithCallDeadline(context.TODO(), "test", "Unbounded")
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("WithCallDeadline() set a deadline, want none for a timeout of zero")
+	}
+
+	parent, cancelParent := context.WithTimeout(context.TODO(), time.Minute)
+	defer cancelParent()
+	ctx, cancel = WithCallDeadline(parent, "test", "Fast")
+	defer cancel()
+	want, _ := parent.Deadline()
+	if got, _ := ctx.Deadline(); !got.Equal(want) {
+		t.Errorf("WithCallDeadline() deadline = %v, want the earlier deadline of the parent %v", got, want)
+	}
+}
@@ -0,0 +1,65 @@
+package providers
+
+import (
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/annotations"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// CancelAnnotation cancels the creation of the annotated resource, or the version upgrade of an openshift postgres
+	// while its data is backed up. The partial cloud resource is removed and nothing is created until it's removed
+	CancelAnnotation = "integreatly.org/cancel"
+	// StatusProvisioningCancelled is the message of a resource whose creation was cancelled
+	StatusProvisioningCancelled croType.StatusMessage = "provisioning cancelled, remove the integreatly.org/cancel annotation to provision the resource"
+
+	// providerFinalizer is the finalizer every provider adds before creating a cloud resource and removes once the cloud
+	// resource is deleted
+	providerFinalizer = "cloud-resources-operator.integreatly.org/finalizers"
+)
+
+// IsProvisioningCancelled returns true if the cancel annotation is set on a resource which hasn't been created yet.
+// Created resources are never removed by the annotation, they're deleted by deleting the resource
+func IsProvisioningCancelled(inst metav1.Object, status *croType.ResourceTypeStatus) bool {
+	if !annotations.Has(inst, CancelAnnotation) {
+		return false
+	}
+	job := status.Provisioning
+	if job == nil {
+		return status.Phase != croType.PhaseComplete
+	}
+	return job.State != croType.ProvisioningStateSucceeded
+}
+
+// ReconcileCancelledProvisioning removes the partial cloud resource of a resource whose creation was cancelled using
+// the delete function of its provider, then marks its provisioning job as cancelled, freeing its slot. The status is
+// persisted with the rest of the resource status. It returns true once the cancellation is complete, with a message
+// describing the progress
+func ReconcileCancelledProvisioning(inst metav1.Object, status *croType.ResourceTypeStatus, deleteFn func() (croType.StatusMessage, error)) (bool, croType.StatusMessage, error) {
+	if status.Provisioning != nil && status.Provisioning.State == croType.ProvisioningStateCancelled {
+		return true, StatusProvisioningCancelled, nil
+	}
+	// nothing was created for a resource without the finalizer of its provider
+	if resources.Contains(inst.GetFinalizers(), providerFinalizer) {
+		msg, err := deleteFn()
+		if err != nil {
+			return false, msg, err
+		}
+		if resources.Contains(inst.GetFinalizers(), providerFinalizer) {
+			return false, croType.StatusMessage("cancelling provisioning, " + string(msg)), nil
+		}
+	}
+
+	now := metav1.Now()
+	cancelled := &croType.ProvisioningJob{State: croType.ProvisioningStateCancelled, CompletedAt: &now}
+	if job := status.Provisioning; job != nil {
+		cancelled.Pool = job.Pool
+		cancelled.QueuedAt = job.QueuedAt
+		cancelled.StartedAt = job.StartedAt
+		startedJobs.remove(job.Pool, provisioningKey(inst))
+	}
+	status.Provisioning = cancelled
+	status.CloudResource = nil
+	return true, StatusProvisioningCancelled, nil
+}
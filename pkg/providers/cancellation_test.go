@@ -0,0 +1,90 @@
+package providers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+)
+
+func TestIsProvisioningCancelled(t *testing.T) {
+	tests := []struct {
+		name     string
+		annotate bool
+		status   croType.ResourceTypeStatus
+		want     bool
+	}{
+		{name: "test not cancelled without annotation", status: croType.ResourceTypeStatus{Provisioning: buildTestRunningJob(time.Now())}},
+		{name: "test running job is cancelled", annotate: true, status: croType.ResourceTypeStatus{Provisioning: buildTestRunningJob(time.Now())}, want: true},
+		{name: "test new resource is cancelled", annotate: true, want: true},
+		{name: "test created resource is not cancelled", annotate: true, status: croType.ResourceTypeStatus{Provisioning: &croType.ProvisioningJob{Pool: testPool, State: croType.ProvisioningStateSucceeded}}},
+		{name: "test resource created before jobs were tracked is not cancelled", annotate: true, status: croType.ResourceTypeStatus{Phase: croType.PhaseComplete}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pg := buildTestProvisioningPostgres("test", nil)
+			if tt.annotate {
+				pg.Annotations = map[string]string{CancelAnnotation: "true"}
+			}
+			if got := IsProvisioningCancelled(pg, &tt.status); got != tt.want {
+				t.Errorf("IsProvisioningCancelled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconcileCancelledProvisioning(t *testing.T) {
+	tests := []struct {
+		name          string
+		finalizers    []string
+		removeOnFirst bool
+		deleteErr     error
+		job           *croType.ProvisioningJob
+		wantCancelled bool
+		wantDeletes   int
+		wantErr       bool
+	}{
+		{name: "test resource without finalizer is cancelled without deleting", job: buildTestRunningJob(time.Now()), wantCancelled: true},
+		{name: "test partial resource is deleted before cancelling", finalizers: []string{providerFinalizer}, removeOnFirst: true, job: buildTestRunningJob(time.Now()), wantCancelled: true, wantDeletes: 1},
+		{name: "test waiting for partial resource to be deleted", finalizers: []string{providerFinalizer}, job: buildTestRunningJob(time.Now()), wantDeletes: 1},
+		{name: "test error on failed delete", finalizers: []string{providerFinalizer}, deleteErr: errors.New("delete failed"), job: buildTestRunningJob(time.Now()), wantDeletes: 1, wantErr: true},
+		{name: "test cancelled job isn't deleted again", finalizers: []string{providerFinalizer}, job: &croType.ProvisioningJob{Pool: testPool, State: croType.ProvisioningStateCancelled}, wantCancelled: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pg := buildTestProvisioningPostgres("test", tt.job)
+			pg.Finalizers = tt.finalizers
+			if tt.job.State == croType.ProvisioningStateRunning {
+				startedJobs.add(testPool, provisioningKey(pg), time.Now())
+				defer startedJobs.remove(testPool, provisioningKey(pg))
+			}
+			deletes := 0
+			cancelled, _, err := ReconcileCancelledProvisioning(pg, &pg.Status, func() (croType.StatusMessage, error) {
+				deletes++
+				if tt.removeOnFirst {
+					pg.Finalizers = nil
+				}
+				return "deleting", tt.deleteErr
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReconcileCancelledProvisioning() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if cancelled != tt.wantCancelled {
+				t.Errorf("ReconcileCancelledProvisioning() cancelled = %v, want %v", cancelled, tt.wantCancelled)
+			}
+			if deletes != tt.wantDeletes {
+				t.Errorf("ReconcileCancelledProvisioning() deletes = %d, want %d", deletes, tt.wantDeletes)
+			}
+			if !tt.wantCancelled {
+				return
+			}
+			if pg.Status.Provisioning == nil || pg.Status.Provisioning.State != croType.ProvisioningStateCancelled {
+				t.Errorf("ReconcileCancelledProvisioning() job = %v, want cancelled", pg.Status.Provisioning)
+			}
+			if len(startedJobs.recent(testPool, time.Now())) != 0 {
+				t.Errorf("ReconcileCancelledProvisioning() didn't free the slot of the job")
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package providers
+
+import (
+	"context"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// OperationCreateSnapshot is the creation of the snapshot of a snapshot cr
+	OperationCreateSnapshot = "CreateSnapshot"
+	// OperationFinalSnapshot is the deletion of a resource taking its final snapshot
+	OperationFinalSnapshot = "FinalSnapshot"
+
+	// ShuttingDownMessage is the status message of a resource whose operation wasn't started as the operator is
+	// shutting down
+	ShuttingDownMessage croType.StatusMessage = "operator is shutting down, the operation is started on restart"
+)
+
+// beginOperation tracks the operation with the operations of the operator, replaced in tests
+var beginOperation = resources.BeginOperation
+
+// BeginCheckpointedOperation starts an operation of the cr, if the operator shuts down while the operation is in flight
+// the checkpoint is persisted in the status of the cr. The returned function ends the operation, resources.ErrShuttingDown
+// is returned if the operator is already shutting down
+func BeginCheckpointedOperation(c client.Client, inst runtime.Object, checkpoint croType.OperationCheckpoint) (func(), error) {
+	accessor, err := meta.Accessor(inst)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to read metadata of object")
+	}
+	key := types.NamespacedName{Name: accessor.GetName(), Namespace: accessor.GetNamespace()}
+	return beginOperation(func(ctx context.Context) error {
+		// the reconcile owning the operation may still write the cr, so the checkpoint is set on its latest version
+		latest := inst.DeepCopyObject()
+		if err := c.Get(ctx, key, latest); err != nil {
+			return errorUtil.Wrapf(err, "failed to get %s to checkpoint %s", key, checkpoint.Operation)
+		}
+		now := metav1.NewTime(time.Now())
+		cp := checkpoint
+		cp.CheckpointedAt = &now
+		if !setCheckpoint(latest, &cp) {
+			return errorUtil.Errorf("operations of %s can't be checkpointed", key)
+		}
+		if err := c.Status().Update(ctx, latest); err != nil {
+			return errorUtil.Wrapf(err, "failed to checkpoint %s of %s", checkpoint.Operation, key)
+		}
+		return nil
+	})
+}
+
+// ResumedSnapshotID returns the id of the snapshot an operation was taking when the operator shut down, or an empty
+// string if the checkpoint isn't of the operation
+func ResumedSnapshotID(checkpoint *croType.OperationCheckpoint, operation string) string {
+	if checkpoint == nil || checkpoint.Operation != operation {
+		return ""
+	}
+	return checkpoint.SnapshotID
+}
+
+func setCheckpoint(inst runtime.Object, checkpoint *croType.OperationCheckpoint) bool {
+	switch o := inst.(type) {
+	case *v1alpha1.Postgres:
+		o.Status.Checkpoint = checkpoint
+	case *v1alpha1.Redis:
+		o.Status.Checkpoint = checkpoint
+	case *v1alpha1.PostgresSnapshot:
+		o.Status.Checkpoint = checkpoint
+	case *v1alpha1.RedisSnapshot:
+		o.Status.Checkpoint = checkpoint
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,61 @@
+package providers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestBeginCheckpointedOperation(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	snapshot := &v1alpha1.PostgresSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"},
+		Status:     croType.ResourceTypeSnapshotStatus{Phase: croType.PhaseInProgress},
+	}
+	c := fake.NewFakeClientWithScheme(scheme, snapshot)
+	ctx := context.TODO()
+
+	var persisted resources.CheckpointFunc
+	defer func(begin func(resources.CheckpointFunc) (func(), error)) { beginOperation = begin }(beginOperation)
+	beginOperation = func(checkpoint resources.CheckpointFunc) (func(), error) {
+		persisted = checkpoint
+		return func() {}, nil
+	}
+	done, err := BeginCheckpointedOperation(c, snapshot, croType.OperationCheckpoint{
+		Operation:  OperationCreateSnapshot,
+		Step:       "CreateDBSnapshot",
+		SnapshotID: "test-snapshot",
+	})
+	if err != nil {
+		t.Fatal("BeginCheckpointedOperation() failed", err)
+	}
+	defer done()
+
+	// the operator shuts down while the operation is in flight
+	if err := persisted(ctx); err != nil {
+		t.Fatal("checkpoint failed", err)
+	}
+	found := &v1alpha1.PostgresSnapshot{}
+	if err := c.Get(ctx, types.NamespacedName{Name: "test", Namespace: "test"}, found); err != nil {
+		t.Fatal("failed to get snapshot", err)
+	}
+	if got := ResumedSnapshotID(found.Status.Checkpoint, OperationCreateSnapshot); got != "test-snapshot" {
+		t.Errorf("ResumedSnapshotID() = %s, want test-snapshot", got)
+	}
+	if found.Status.Checkpoint.CheckpointedAt == nil || found.Status.Phase != croType.PhaseInProgress {
+		t.Errorf("checkpoint = %v, want the time it was taken and the rest of the status kept", found.Status)
+	}
+	if got := ResumedSnapshotID(found.Status.Checkpoint, OperationFinalSnapshot); got != "" {
+		t.Errorf("ResumedSnapshotID() = %s, want no snapshot of other operations", got)
+	}
+}
@@ -3,6 +3,7 @@ package providers
 import (
 	"context"
 	"encoding/json"
+	"sort"
 
 	"github.com/integr8ly/cloud-resource-operator/internal/k8sutil"
 
@@ -30,6 +31,8 @@ type DeploymentStrategyMapping struct {
 	BlobStorage string `json:"blobstorage"`
 	Redis       string `json:"redis"`
 	Postgres    string `json:"postgres"`
+	// Queue is optional, deployment types without a queue strategy don't support queues
+	Queue string `json:"queue,omitempty"`
 }
 
 //go:generate moq -out config_moq.go . ConfigManager
@@ -72,6 +75,44 @@ func (m *ConfigMapConfigManager) GetStrategyMappingForDeploymentType(ctx context
 	return dsm, nil
 }
 
+//GetEnabledProviders Get the providers referenced by any deployment type, sorted by name
+func (m *ConfigMapConfigManager) GetEnabledProviders(ctx context.Context) ([]string, error) {
+	cm, err := resources.GetConfigMapOrDefault(ctx, m.client, types.NamespacedName{Name: m.providerConfigMapName, Namespace: m.providerConfigMapNamespace}, m.buildDefaultConfigMap())
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to read provider config from configmap %s in namespace %s", m.providerConfigMapName, m.providerConfigMapNamespace)
+	}
+	seen := map[string]bool{}
+	var enabled []string
+	for t, raw := range cm.Data {
+		dsm := &DeploymentStrategyMapping{}
+		if err = json.Unmarshal([]byte(raw), dsm); err != nil {
+			return nil, errorUtil.Wrapf(err, "failed to unmarshal config for deployment type %s", t)
+		}
+		for _, p := range []string{dsm.BlobStorage, dsm.Redis, dsm.Postgres, dsm.Queue} {
+			if p != "" && !seen[p] {
+				seen[p] = true
+				enabled = append(enabled, p)
+			}
+		}
+	}
+	sort.Strings(enabled)
+	return enabled, nil
+}
+
+//GetDeploymentTypes Get the deployment types defined in the provider config, sorted by name
+func (m *ConfigMapConfigManager) GetDeploymentTypes(ctx context.Context) ([]string, error) {
+	cm, err := resources.GetConfigMapOrDefault(ctx, m.client, types.NamespacedName{Name: m.providerConfigMapName, Namespace: m.providerConfigMapNamespace}, m.buildDefaultConfigMap())
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to read provider config from configmap %s in namespace %s", m.providerConfigMapName, m.providerConfigMapNamespace)
+	}
+	var deploymentTypes []string
+	for t := range cm.Data {
+		deploymentTypes = append(deploymentTypes, t)
+	}
+	sort.Strings(deploymentTypes)
+	return deploymentTypes, nil
+}
+
 func (m *ConfigMapConfigManager) buildDefaultConfigMap() *v1.ConfigMap {
 	return &v1.ConfigMap{
 		ObjectMeta: controllerruntime.ObjectMeta{
@@ -79,7 +120,7 @@ func (m *ConfigMapConfigManager) buildDefaultConfigMap() *v1.ConfigMap {
 			Namespace: m.providerConfigMapNamespace,
 		},
 		Data: map[string]string{
-			"managed":  "{\"blobstorage\":\"aws\", \"redis\":\"aws\", \"postgres\":\"aws\"}",
+			"managed":  "{\"blobstorage\":\"aws\", \"redis\":\"aws\", \"postgres\":\"aws\", \"queue\":\"aws\"}",
 			"workshop": "{\"blobstorage\":\"openshift\", \"redis\":\"openshift\", \"postgres\":\"openshift\"}",
 		},
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"reflect"
 	"testing"
 
 	v1 "k8s.io/api/core/v1"
@@ -126,3 +127,107 @@ func TestConfigManager_GetStrategyMappingForDeploymentType(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigManager_GetEnabledProviders(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	buildConfigMap := func(data map[string]string) *v1.ConfigMap {
+		return &v1.ConfigMap{
+			ObjectMeta: controllerruntime.ObjectMeta{
+				Name:      "test",
+				Namespace: "test",
+			},
+			Data: data,
+		}
+	}
+	cases := []struct {
+		name        string
+		client      client.Client
+		expected    []string
+		expectError bool
+	}{
+		{
+			name: "test providers are collected from every deployment type",
+			client: fake.NewFakeClientWithScheme(scheme, buildConfigMap(map[string]string{
+				"managed":  "{\"blobstorage\":\"aws\", \"redis\":\"aws\", \"postgres\":\"openshift\"}",
+				"workshop": "{\"blobstorage\":\"openshift\", \"redis\":\"openshift\", \"postgres\":\"openshift\"}",
+			})),
+			expected: []string{AWSDeploymentStrategy, OpenShiftDeploymentStrategy},
+		},
+		{
+			name: "test single provider",
+			client: fake.NewFakeClientWithScheme(scheme, buildConfigMap(map[string]string{
+				"workshop": "{\"blobstorage\":\"openshift\", \"redis\":\"openshift\", \"postgres\":\"openshift\"}",
+			})),
+			expected: []string{OpenShiftDeploymentStrategy},
+		},
+		{
+			name:     "test default config map enables all providers",
+			client:   fake.NewFakeClientWithScheme(scheme),
+			expected: []string{AWSDeploymentStrategy, OpenShiftDeploymentStrategy},
+		},
+		{
+			name: "test error when deployment type is malformed",
+			client: fake.NewFakeClientWithScheme(scheme, buildConfigMap(map[string]string{
+				"managed": "not json",
+			})),
+			expectError: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			enabled, err := NewConfigManager("test", "test", tc.client).GetEnabledProviders(context.TODO())
+			if (err != nil) != tc.expectError {
+				t.Fatalf("GetEnabledProviders() error = %v, expectError %v", err, tc.expectError)
+			}
+			if !tc.expectError && !reflect.DeepEqual(enabled, tc.expected) {
+				t.Fatalf("GetEnabledProviders() got = %v, expected %v", enabled, tc.expected)
+			}
+		})
+	}
+}
+
+func TestConfigManager_GetDeploymentTypes(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	cases := []struct {
+		name     string
+		client   client.Client
+		expected []string
+	}{
+		{
+			name: "test deployment types of the config map are sorted",
+			client: fake.NewFakeClientWithScheme(scheme, &v1.ConfigMap{
+				ObjectMeta: controllerruntime.ObjectMeta{
+					Name:      "test",
+					Namespace: "test",
+				},
+				Data: map[string]string{
+					"workshop": "{\"postgres\":\"openshift\"}",
+					"custom":   "{\"postgres\":\"aws\"}",
+				},
+			}),
+			expected: []string{"custom", "workshop"},
+		},
+		{
+			name:     "test default config map deployment types",
+			client:   fake.NewFakeClientWithScheme(scheme),
+			expected: []string{"managed", "workshop"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			deploymentTypes, err := NewConfigManager("test", "test", tc.client).GetDeploymentTypes(context.TODO())
+			if err != nil {
+				t.Fatalf("GetDeploymentTypes() unexpected error = %v", err)
+			}
+			if !reflect.DeepEqual(deploymentTypes, tc.expected) {
+				t.Fatalf("GetDeploymentTypes() got = %v, expected %v", deploymentTypes, tc.expected)
+			}
+		})
+	}
+}
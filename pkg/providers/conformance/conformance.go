@@ -0,0 +1,438 @@
+// Package conformance holds the lifecycle scenarios every postgres, redis and blob storage provider must pass, so the
+// controllers can rely on a provider behaving the same whichever cloud it provisions in. Each provider runs the
+// scenarios against its mocked cloud in its tests, and against the real cloud when the tests are built with the cloud
+// build tag
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/annotations"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	errorUtil "github.com/pkg/errors"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Scenario is a step in the lifecycle of a resource which a provider must handle
+type Scenario string
+
+const (
+	// ScenarioCreate reconciles a new resource, the provider must accept it and report its progress
+	ScenarioCreate Scenario = "create"
+	// ScenarioBecomeReady reconciles a new resource until the provider returns its deployment details
+	ScenarioBecomeReady Scenario = "become-ready"
+	// ScenarioRotate requests a credential rotation of a ready resource, the provider must return the new credentials
+	ScenarioRotate Scenario = "rotate"
+	// ScenarioResize requests a new size for a ready resource, the provider must apply it and keep the resource ready
+	ScenarioResize Scenario = "resize"
+	// ScenarioDelete deletes a ready resource, the provider must remove it and release its finalizer
+	ScenarioDelete Scenario = "delete"
+	// ScenarioDeleteWhileCreating deletes a resource before it's ready, the provider must not wait for it forever
+	ScenarioDeleteWhileCreating Scenario = "delete-while-creating"
+	// ScenarioSideBySide provisions two resources in the same namespace, the provider must keep them apart and keep one
+	// ready while the other is deleted
+	ScenarioSideBySide Scenario = "side-by-side"
+
+	defaultAttempts = 10
+)
+
+// Object is a cr of a resource
+type Object interface {
+	runtime.Object
+	metav1.Object
+}
+
+// Harness adapts the cloud of a provider to the scenarios
+type Harness struct {
+	// Client is the client of the provider, the scenarios write the changes they make to crs with it
+	Client client.Client
+	// Settle moves the cloud forward between attempts, e.g. marks mocked resources ready. It's nil against a real cloud
+	Settle func(t *testing.T, obj Object)
+	// Resize requests a new size for the resource on its cr, the resize scenario is skipped if it's nil
+	Resize func(t *testing.T, obj Object)
+	// Resized returns true once the provider applied the size requested by Resize
+	Resized func(t *testing.T, obj Object) bool
+	// Gone returns true once the provider removed everything it created for the resource. A deletion is complete once
+	// the cr has no finalizers left if it's nil
+	Gone func(t *testing.T, obj Object) bool
+	// Unsupported maps the scenarios the provider doesn't support to the reason, they're skipped
+	Unsupported map[Scenario]string
+	// Attempts is how often a scenario reconciles or deletes a resource before failing, defaults to 10
+	Attempts int
+	// Interval is how long a scenario waits between attempts, real clouds take minutes to provision resources
+	Interval time.Duration
+}
+
+// subject is a resource of a provider the scenarios run against
+type subject interface {
+	object() Object
+	reconcile(ctx context.Context) (providers.DeploymentDetails, croType.StatusMessage, error)
+	delete(ctx context.Context) (croType.StatusMessage, error)
+	// validate returns an error if the deployment details of a ready resource can't be connected with
+	validate(dd providers.DeploymentDetails) error
+}
+
+// rotatable is a subject with credentials the provider rotates on request
+type rotatable interface {
+	requestRotation()
+	rotationComplete() bool
+	// rotated returns an error if the deployment details after a rotation still hold the old credentials
+	rotated(before, after providers.DeploymentDetails) error
+}
+
+// isolated is a subject whose deployment details must differ from those of another resource of the provider
+type isolated interface {
+	// distinct returns an error if the deployment details of two ready resources share what one of them connects to
+	distinct(a, b providers.DeploymentDetails) error
+}
+
+var scenarios = []struct {
+	name Scenario
+	run  func(t *testing.T, h Harness, s subject)
+	// pair runs the scenario against two resources instead of run
+	pair func(t *testing.T, h Harness, a, b subject)
+}{
+	{name: ScenarioCreate, run: runCreate},
+	{name: ScenarioBecomeReady, run: func(t *testing.T, h Harness, s subject) { becomeReady(t, h, s) }},
+	{name: ScenarioRotate, run: runRotate},
+	{name: ScenarioResize, run: runResize},
+	{name: ScenarioDelete, run: runDelete},
+	{name: ScenarioDeleteWhileCreating, run: runDeleteWhileCreating},
+	{name: ScenarioSideBySide, pair: runSideBySide},
+}
+
+// RunPostgres runs the scenarios against a postgres provider, each with a new postgres cr returned by newCR, which
+// must exist in the cluster of the harness client
+func RunPostgres(t *testing.T, h Harness, p providers.PostgresProvider, newCR func(t *testing.T, name string) *v1alpha1.Postgres) {
+	run(t, h, func(t *testing.T, name string) subject {
+		return &postgresSubject{provider: p, cr: newCR(t, name)}
+	})
+}
+
+// RunRedis runs the scenarios against a redis provider, each with a new redis cr returned by newCR, which must exist
+// in the cluster of the harness client
+func RunRedis(t *testing.T, h Harness, p providers.RedisProvider, newCR func(t *testing.T, name string) *v1alpha1.Redis) {
+	run(t, h, func(t *testing.T, name string) subject {
+		return &redisSubject{provider: p, cr: newCR(t, name)}
+	})
+}
+
+// RunBlobStorage runs the scenarios against a blob storage provider, each with a new blob storage cr returned by newCR,
+// which must exist in the cluster of the harness client
+func RunBlobStorage(t *testing.T, h Harness, p providers.BlobStorageProvider, newCR func(t *testing.T, name string) *v1alpha1.BlobStorage) {
+	run(t, h, func(t *testing.T, name string) subject {
+		return &blobStorageSubject{provider: p, cr: newCR(t, name)}
+	})
+}
+
+func run(t *testing.T, h Harness, newSubject func(t *testing.T, name string) subject) {
+	for _, sc := range scenarios {
+		sc := sc
+		t.Run(string(sc.name), func(t *testing.T) {
+			if reason, ok := h.Unsupported[sc.name]; ok {
+				t.Skipf("%s is not supported: %s", sc.name, reason)
+			}
+			if sc.pair != nil {
+				a := newSubject(t, fmt.Sprintf("conformance-%s-a", sc.name))
+				t.Cleanup(func() { cleanup(t, h, a) })
+				b := newSubject(t, fmt.Sprintf("conformance-%s-b", sc.name))
+				t.Cleanup(func() { cleanup(t, h, b) })
+				sc.pair(t, h, a, b)
+				return
+			}
+			s := newSubject(t, fmt.Sprintf("conformance-%s", sc.name))
+			t.Cleanup(func() { cleanup(t, h, s) })
+			sc.run(t, h, s)
+		})
+	}
+}
+
+func runCreate(t *testing.T, h Harness, s subject) {
+	dd, msg, err := s.reconcile(context.TODO())
+	if err != nil {
+		t.Fatalf("failed to create resource: %s: %v", msg, err)
+	}
+	if dd == nil && msg == croType.StatusEmpty {
+		t.Fatal("create returned neither deployment details nor the progress of the resource")
+	}
+	// the resource is reconciled again before it's ready, which must not fail
+	if _, msg, err := s.reconcile(context.TODO()); err != nil {
+		t.Fatalf("failed to reconcile created resource: %s: %v", msg, err)
+	}
+}
+
+func runRotate(t *testing.T, h Harness, s subject) {
+	r, ok := s.(rotatable)
+	if !ok {
+		t.Skip("the resource has no credentials the provider rotates")
+	}
+	before := becomeReady(t, h, s)
+	r.requestRotation()
+	h.update(t, s.object())
+	var after providers.DeploymentDetails
+	h.eventually(t, s.object(), "credentials are not rotated", func() bool {
+		after = h.reconcile(t, s)
+		return after != nil && r.rotationComplete()
+	})
+	if err := r.rotated(before, after); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func runResize(t *testing.T, h Harness, s subject) {
+	if h.Resize == nil || h.Resized == nil {
+		t.Skip("the harness doesn't resize resources of the provider")
+	}
+	becomeReady(t, h, s)
+	h.Resize(t, s.object())
+	h.update(t, s.object())
+	h.eventually(t, s.object(), "resource is not resized", func() bool {
+		return h.reconcile(t, s) != nil && h.Resized(t, s.object())
+	})
+}
+
+func runDelete(t *testing.T, h Harness, s subject) {
+	becomeReady(t, h, s)
+	deleteResource(t, h, s)
+}
+
+func runDeleteWhileCreating(t *testing.T, h Harness, s subject) {
+	if dd := h.reconcile(t, s); dd != nil {
+		t.Log("the resource was ready once created, it's deleted while ready")
+	}
+	deleteResource(t, h, s)
+}
+
+func runSideBySide(t *testing.T, h Harness, a, b subject) {
+	ddA := becomeReady(t, h, a)
+	ddB := becomeReady(t, h, b)
+	if i, ok := a.(isolated); ok {
+		if err := i.distinct(ddA, ddB); err != nil {
+			t.Fatalf("resources in the same namespace are not kept apart: %v", err)
+		}
+	}
+	// deleting one of the resources must leave nothing of the other behind
+	deleteResource(t, h, a)
+	after := becomeReady(t, h, b)
+	if !reflect.DeepEqual(ddB.Data(), after.Data()) {
+		t.Fatalf("deployment details of the remaining resource changed when the other was deleted, %v became %v", ddB.Data(), after.Data())
+	}
+}
+
+// becomeReady reconciles the resource until the provider returns its deployment details
+func becomeReady(t *testing.T, h Harness, s subject) providers.DeploymentDetails {
+	var dd providers.DeploymentDetails
+	h.eventually(t, s.object(), "resource is not ready", func() bool {
+		dd = h.reconcile(t, s)
+		return dd != nil
+	})
+	if err := s.validate(dd); err != nil {
+		t.Fatalf("invalid deployment details of ready resource: %v", err)
+	}
+	return dd
+}
+
+// deleteResource deletes the resource until the provider released its finalizer and removed everything it created
+func deleteResource(t *testing.T, h Harness, s subject) {
+	h.eventually(t, s.object(), "resource is not deleted", func() bool {
+		if msg, err := s.delete(context.TODO()); err != nil {
+			t.Fatalf("failed to delete resource: %s: %v", msg, err)
+		}
+		if len(s.object().GetFinalizers()) > 0 {
+			return false
+		}
+		return h.Gone == nil || h.Gone(t, s.object())
+	})
+}
+
+// cleanup deletes the cr of a scenario, the resource is deleted once more first if the scenario failed, so a failed
+// run against a real cloud leaves as little behind as possible
+func cleanup(t *testing.T, h Harness, s subject) {
+	if t.Failed() {
+		if msg, err := s.delete(context.TODO()); err != nil {
+			t.Logf("failed to delete resource of failed scenario, it must be deleted manually: %s: %v", msg, err)
+		}
+	}
+	if err := h.Client.Delete(context.TODO(), s.object()); err != nil && !k8serr.IsNotFound(err) {
+		t.Logf("failed to delete cr %s: %v", s.object().GetName(), err)
+	}
+}
+
+func (h Harness) reconcile(t *testing.T, s subject) providers.DeploymentDetails {
+	dd, msg, err := s.reconcile(context.TODO())
+	if err != nil {
+		t.Fatalf("failed to reconcile resource: %s: %v", msg, err)
+	}
+	return dd
+}
+
+func (h Harness) update(t *testing.T, obj Object) {
+	if err := h.Client.Update(context.TODO(), obj); err != nil {
+		t.Fatalf("failed to update cr %s: %v", obj.GetName(), err)
+	}
+}
+
+// eventually calls step until it returns true, settling the cloud between the attempts
+func (h Harness) eventually(t *testing.T, obj Object, failure string, step func() bool) {
+	attempts := h.Attempts
+	if attempts <= 0 {
+		attempts = defaultAttempts
+	}
+	for i := 0; i < attempts; i++ {
+		if step() {
+			return
+		}
+		if h.Settle != nil {
+			h.Settle(t, obj)
+		}
+		time.Sleep(h.Interval)
+	}
+	t.Fatalf("%s after %d attempts", failure, attempts)
+}
+
+type postgresSubject struct {
+	provider providers.PostgresProvider
+	cr       *v1alpha1.Postgres
+}
+
+var (
+	_ rotatable = (*postgresSubject)(nil)
+	_ isolated  = (*postgresSubject)(nil)
+)
+
+func (s *postgresSubject) object() Object {
+	return s.cr
+}
+
+func (s *postgresSubject) reconcile(ctx context.Context) (providers.DeploymentDetails, croType.StatusMessage, error) {
+	pg, msg, err := s.provider.ReconcilePostgres(ctx, s.cr)
+	if pg == nil {
+		return nil, msg, err
+	}
+	return pg.DeploymentDetails, msg, err
+}
+
+func (s *postgresSubject) delete(ctx context.Context) (croType.StatusMessage, error) {
+	return s.provider.DeletePostgres(ctx, s.cr)
+}
+
+func (s *postgresSubject) validate(dd providers.DeploymentDetails) error {
+	d, ok := dd.(*providers.PostgresDeploymentDetails)
+	if !ok {
+		return errorUtil.Errorf("expected postgres deployment details, got %T", dd)
+	}
+	if d.Host == "" || d.Port == 0 || d.Username == "" || d.Database == "" {
+		return errorUtil.Errorf("host, port, username and database are required, got %s:%d, user %q, database %q", d.Host, d.Port, d.Username, d.Database)
+	}
+	if d.Password == "" && d.AuthMode != providers.PostgresAuthModeIAM {
+		return errorUtil.New("a password is required unless users log in with an iam token")
+	}
+	return nil
+}
+
+func (s *postgresSubject) requestRotation() {
+	annotations.Add(s.cr, providers.RotateCredentialsAnnotation, "true")
+}
+
+func (s *postgresSubject) rotationComplete() bool {
+	return !annotations.Has(s.cr, providers.RotateCredentialsAnnotation) && s.cr.Status.CredentialsRotatedAt != ""
+}
+
+func (s *postgresSubject) rotated(before, after providers.DeploymentDetails) error {
+	oldPass := before.(*providers.PostgresDeploymentDetails).Password
+	newPass := after.(*providers.PostgresDeploymentDetails).Password
+	if newPass == "" || newPass == oldPass {
+		return errorUtil.New("the rotated credentials still hold the password used before the rotation")
+	}
+	return nil
+}
+
+func (s *postgresSubject) distinct(a, b providers.DeploymentDetails) error {
+	pgA, pgB := a.(*providers.PostgresDeploymentDetails), b.(*providers.PostgresDeploymentDetails)
+	if pgA.Host == pgB.Host {
+		return errorUtil.Errorf("both resources connect to host %s", pgA.Host)
+	}
+	if pgA.AuthMode != providers.PostgresAuthModeIAM && pgA.Password == pgB.Password {
+		return errorUtil.New("both resources share a password")
+	}
+	return nil
+}
+
+type redisSubject struct {
+	provider providers.RedisProvider
+	cr       *v1alpha1.Redis
+}
+
+var _ isolated = (*redisSubject)(nil)
+
+func (s *redisSubject) object() Object {
+	return s.cr
+}
+
+func (s *redisSubject) reconcile(ctx context.Context) (providers.DeploymentDetails, croType.StatusMessage, error) {
+	r, msg, err := s.provider.CreateRedis(ctx, s.cr)
+	if r == nil {
+		return nil, msg, err
+	}
+	return r.DeploymentDetails, msg, err
+}
+
+func (s *redisSubject) delete(ctx context.Context) (croType.StatusMessage, error) {
+	return s.provider.DeleteRedis(ctx, s.cr)
+}
+
+func (s *redisSubject) validate(dd providers.DeploymentDetails) error {
+	d, ok := dd.(*providers.RedisDeploymentDetails)
+	if !ok {
+		return errorUtil.Errorf("expected redis deployment details, got %T", dd)
+	}
+	if d.URI == "" || d.Port == 0 {
+		return errorUtil.Errorf("uri and port are required, got %s:%d", d.URI, d.Port)
+	}
+	return nil
+}
+
+func (s *redisSubject) distinct(a, b providers.DeploymentDetails) error {
+	uriA, uriB := a.(*providers.RedisDeploymentDetails).URI, b.(*providers.RedisDeploymentDetails).URI
+	if uriA == uriB {
+		return errorUtil.Errorf("both resources connect to %s", uriA)
+	}
+	return nil
+}
+
+type blobStorageSubject struct {
+	provider providers.BlobStorageProvider
+	cr       *v1alpha1.BlobStorage
+}
+
+func (s *blobStorageSubject) object() Object {
+	return s.cr
+}
+
+func (s *blobStorageSubject) reconcile(ctx context.Context) (providers.DeploymentDetails, croType.StatusMessage, error) {
+	bs, msg, err := s.provider.CreateStorage(ctx, s.cr)
+	if bs == nil {
+		return nil, msg, err
+	}
+	return bs.DeploymentDetails, msg, err
+}
+
+func (s *blobStorageSubject) delete(ctx context.Context) (croType.StatusMessage, error) {
+	return s.provider.DeleteStorage(ctx, s.cr)
+}
+
+func (s *blobStorageSubject) validate(dd providers.DeploymentDetails) error {
+	if len(dd.Data()) == 0 {
+		return errorUtil.New("the blob storage details hold no data")
+	}
+	return nil
+}
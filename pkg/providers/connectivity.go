@@ -0,0 +1,46 @@
+package providers
+
+import (
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+)
+
+// ConnectivityProbe returns the probe of the deployment details of a resource, false if its connectivity isn't checked,
+// e.g. for blob storage and queues
+func ConnectivityProbe(dd DeploymentDetails) (resources.ConnectivityProbe, bool) {
+	switch d := dd.(type) {
+	case *PostgresDeploymentDetails:
+		return PostgresConnectivityProbe(d), true
+	case *RedisDeploymentDetails:
+		return RedisConnectivityProbe(d), true
+	}
+	return resources.ConnectivityProbe{}, false
+}
+
+// PostgresConnectivityProbe returns the probe checking applications can log in to the postgres with the details of its
+// connection secret. Users connecting with an iam token can't be logged in as by the operator, only a tcp connection
+// is opened to the postgres then
+func PostgresConnectivityProbe(d *PostgresDeploymentDetails) resources.ConnectivityProbe {
+	if d.AuthMode == PostgresAuthModeIAM {
+		return resources.TCPProbe(d.Host, d.Port)
+	}
+	useTLS := d.SSLMode != "" && d.SSLMode != "disable"
+	return resources.PostgresProbe(d.Host, d.Port, d.Database, d.Username, d.Password, useTLS)
+}
+
+// RedisConnectivityProbe returns the probe pinging the endpoint applications connect to first for the topology of the
+// redis, its primary, a replica, a sentinel or a node of the cluster
+func RedisConnectivityProbe(d *RedisDeploymentDetails) resources.ConnectivityProbe {
+	topology := d.GetTopology()
+	endpoint := Endpoint{Host: d.URI, Port: d.Port}
+	switch {
+	case topology.Primary != nil:
+		endpoint = *topology.Primary
+	case len(topology.Sentinels) > 0:
+		endpoint = topology.Sentinels[0]
+	case len(topology.ClusterNodes) > 0:
+		endpoint = topology.ClusterNodes[0]
+	case len(topology.ReadEndpoints) > 0:
+		endpoint = topology.ReadEndpoints[0]
+	}
+	return resources.RedisProbe(endpoint.Host, int(endpoint.Port))
+}
@@ -0,0 +1,48 @@
+package providers
+
+import (
+	"testing"
+)
+
+func TestConnectivityProbe(t *testing.T) {
+	tests := []struct {
+		name         string
+		dd           DeploymentDetails
+		wantOK       bool
+		wantEndpoint string
+	}{
+		{
+			name:         "test postgres is probed at its host",
+			dd:           &PostgresDeploymentDetails{Host: "db.example.com", Port: 5432, Username: "user", Password: "pass", Database: "postgres"},
+			wantOK:       true,
+			wantEndpoint: "db.example.com:5432",
+		},
+		{
+			name:         "test standalone redis is probed at its primary",
+			dd:           &RedisDeploymentDetails{URI: "redis.example.com", Port: 6379},
+			wantOK:       true,
+			wantEndpoint: "redis.example.com:6379",
+		},
+		{
+			name:         "test sentinel redis is probed at its sentinel",
+			dd:           &RedisDeploymentDetails{URI: "redis.example.com", Port: 6379, SentinelURI: "sentinel.example.com", SentinelPort: 26379, MasterName: "master"},
+			wantOK:       true,
+			wantEndpoint: "sentinel.example.com:26379",
+		},
+		{
+			name: "test other resources are not probed",
+			dd:   &DeploymentDetailsMock{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probe, ok := ConnectivityProbe(tt.dd)
+			if ok != tt.wantOK {
+				t.Fatalf("ConnectivityProbe() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if probe.Endpoint != tt.wantEndpoint {
+				t.Errorf("ConnectivityProbe() endpoint = %s, want %s", probe.Endpoint, tt.wantEndpoint)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package providers
+
+import (
+	"fmt"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	errorUtil "github.com/pkg/errors"
+)
+
+// CostClass is a coarse indication of the monthly cost of a provisioned resource
+type CostClass string
+
+const (
+	CostClassS  CostClass = "S"
+	CostClassM  CostClass = "M"
+	CostClassL  CostClass = "L"
+	CostClassXL CostClass = "XL"
+
+	hoursPerMonth = 730
+)
+
+// costClassLimits are the upper monthly cost limits in USD of each cost class, anything above the last limit is XL
+var costClassLimits = []struct {
+	class CostClass
+	limit float64
+}{
+	{class: CostClassS, limit: 50},
+	{class: CostClassM, limit: 200},
+	{class: CostClassL, limit: 800},
+}
+
+// the price table holds approximate on-demand prices in USD, based on us-east-1 for aws. they're used to give an
+// indication of the cost impact of a tier, not to reproduce a bill
+var (
+	// awsRDSHourlyPrices are the single-az postgres prices per instance class
+	awsRDSHourlyPrices = map[string]float64{
+		"db.t3.micro":   0.018,
+		"db.t3.small":   0.036,
+		"db.t3.medium":  0.072,
+		"db.t3.large":   0.145,
+		"db.t3.xlarge":  0.290,
+		"db.t3.2xlarge": 0.579,
+		"db.t4g.micro":  0.016,
+		"db.t4g.small":  0.032,
+		"db.t4g.medium": 0.065,
+		"db.t4g.large":  0.129,
+		"db.m5.large":   0.178,
+		"db.m5.xlarge":  0.356,
+		"db.m5.2xlarge": 0.712,
+		"db.m5.4xlarge": 1.424,
+		"db.m6g.large":  0.159,
+		"db.m6g.xlarge": 0.318,
+		"db.r5.large":   0.250,
+		"db.r5.xlarge":  0.500,
+		"db.r5.2xlarge": 1.000,
+		"db.r6g.large":  0.225,
+		"db.r6g.xlarge": 0.449,
+	}
+	// awsRDSStorageGiBMonthlyPrice is the price of general purpose storage, doubled along with the instance for multi-az
+	awsRDSStorageGiBMonthlyPrice = 0.115
+
+	// awsElasticacheHourlyPrices are the prices per cache node type
+	awsElasticacheHourlyPrices = map[string]float64{
+		"cache.t2.micro":    0.017,
+		"cache.t2.small":    0.034,
+		"cache.t2.medium":   0.068,
+		"cache.t3.micro":    0.017,
+		"cache.t3.small":    0.034,
+		"cache.t3.medium":   0.068,
+		"cache.t4g.micro":   0.016,
+		"cache.t4g.small":   0.032,
+		"cache.t4g.medium":  0.065,
+		"cache.m5.large":    0.156,
+		"cache.m5.xlarge":   0.311,
+		"cache.m5.2xlarge":  0.623,
+		"cache.m6g.large":   0.149,
+		"cache.m6g.xlarge":  0.298,
+		"cache.r5.large":    0.216,
+		"cache.r5.xlarge":   0.431,
+		"cache.r5.2xlarge":  0.862,
+		"cache.r6g.large":   0.206,
+		"cache.r6g.xlarge":  0.411,
+		"cache.r6g.2xlarge": 0.821,
+	}
+
+	// openshift workloads are priced by the share of cluster capacity they request
+	openShiftCPUCoreMonthlyPrice    = 25.0
+	openShiftMemoryGiBMonthlyPrice  = 3.5
+	openShiftStorageGiBMonthlyPrice = 0.1
+)
+
+// Cost is the approximate monthly cost of a provisioned resource
+type Cost struct {
+	Class CostClass
+	// MonthlyCost is in USD
+	MonthlyCost float64
+}
+
+// NewCost returns the cost with the cost class matching the monthly cost
+func NewCost(monthlyCost float64) *Cost {
+	for _, c := range costClassLimits {
+		if monthlyCost < c.limit {
+			return &Cost{Class: c.class, MonthlyCost: monthlyCost}
+		}
+	}
+	return &Cost{Class: CostClassXL, MonthlyCost: monthlyCost}
+}
+
+// FormatMonthlyCost returns the monthly cost as presented on resource status
+func (c *Cost) FormatMonthlyCost() string {
+	return fmt.Sprintf("%.2f USD", c.MonthlyCost)
+}
+
+// SetCostStatus records the cost on the resource status, it's cleared if the cost can't be estimated
+func SetCostStatus(status *croType.ResourceTypeStatus, cost *Cost) {
+	if cost == nil {
+		status.CostClass = ""
+		status.EstimatedMonthlyCost = ""
+		return
+	}
+	status.CostClass = string(cost.Class)
+	status.EstimatedMonthlyCost = cost.FormatMonthlyCost()
+}
+
+// EstimateRDSCost returns the cost of an rds postgres instance, an error is returned if the instance class isn't in
+// the price table
+func EstimateRDSCost(instanceClass string, storageGiB int64, multiAZ bool) (*Cost, error) {
+	hourly, ok := awsRDSHourlyPrices[instanceClass]
+	if !ok {
+		return nil, errorUtil.Errorf("no price found for rds instance class %s", instanceClass)
+	}
+	monthly := hourly*hoursPerMonth + float64(storageGiB)*awsRDSStorageGiBMonthlyPrice
+	if multiAZ {
+		monthly *= 2
+	}
+	return NewCost(monthly), nil
+}
+
+// EstimateElasticacheCost returns the cost of an elasticache replication group, an error is returned if the node type
+// isn't in the price table
+func EstimateElasticacheCost(nodeType string, nodes int) (*Cost, error) {
+	hourly, ok := awsElasticacheHourlyPrices[nodeType]
+	if !ok {
+		return nil, errorUtil.Errorf("no price found for elasticache node type %s", nodeType)
+	}
+	if nodes < 1 {
+		nodes = 1
+	}
+	return NewCost(hourly * hoursPerMonth * float64(nodes)), nil
+}
+
+// EstimateOpenShiftCost returns the cost of an in-cluster workload from the cpu, memory and storage it requests
+func EstimateOpenShiftCost(cpuCores, memoryGiB, storageGiB float64) *Cost {
+	return NewCost(cpuCores*openShiftCPUCoreMonthlyPrice + memoryGiB*openShiftMemoryGiBMonthlyPrice + storageGiB*openShiftStorageGiBMonthlyPrice)
+}
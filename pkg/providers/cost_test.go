@@ -0,0 +1,147 @@
+package providers
+
+import (
+	"math"
+	"testing"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+)
+
+func TestNewCost(t *testing.T) {
+	tests := []struct {
+		name        string
+		monthlyCost float64
+		want        CostClass
+	}{
+		{name: "test free resources are small", monthlyCost: 0, want: CostClassS},
+		{name: "test class limits are exclusive", monthlyCost: 50, want: CostClassM},
+		{name: "test large", monthlyCost: 799.99, want: CostClassL},
+		{name: "test anything above the last limit is extra large", monthlyCost: 5000, want: CostClassXL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewCost(tt.monthlyCost); got.Class != tt.want {
+				t.Errorf("NewCost() class = %v, want %v", got.Class, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimateRDSCost(t *testing.T) {
+	tests := []struct {
+		name          string
+		instanceClass string
+		storageGiB    int64
+		multiAZ       bool
+		want          float64
+		wantClass     CostClass
+		wantErr       bool
+	}{
+		{
+			name:          "test single az instance and storage are priced",
+			instanceClass: "db.t3.small",
+			storageGiB:    20,
+			want:          0.036*hoursPerMonth + 20*awsRDSStorageGiBMonthlyPrice,
+			wantClass:     CostClassS,
+		},
+		{
+			name:          "test multi az doubles the cost",
+			instanceClass: "db.m5.xlarge",
+			storageGiB:    100,
+			multiAZ:       true,
+			want:          2 * (0.356*hoursPerMonth + 100*awsRDSStorageGiBMonthlyPrice),
+			wantClass:     CostClassL,
+		},
+		{
+			name:          "test error when the instance class is not in the price table",
+			instanceClass: "db.unknown",
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EstimateRDSCost(tt.instanceClass, tt.storageGiB, tt.multiAZ)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EstimateRDSCost() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if math.Abs(got.MonthlyCost-tt.want) > 0.001 || got.Class != tt.wantClass {
+				t.Errorf("EstimateRDSCost() = %+v, want %v %v", got, tt.want, tt.wantClass)
+			}
+		})
+	}
+}
+
+func TestEstimateElasticacheCost(t *testing.T) {
+	tests := []struct {
+		name      string
+		nodeType  string
+		nodes     int
+		want      float64
+		wantClass CostClass
+		wantErr   bool
+	}{
+		{
+			name:      "test each node is priced",
+			nodeType:  "cache.r5.large",
+			nodes:     2,
+			want:      2 * 0.216 * hoursPerMonth,
+			wantClass: CostClassL,
+		},
+		{
+			name:      "test at least one node is priced",
+			nodeType:  "cache.t3.micro",
+			want:      0.017 * hoursPerMonth,
+			wantClass: CostClassS,
+		},
+		{
+			name:     "test error when the node type is not in the price table",
+			nodeType: "cache.unknown",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EstimateElasticacheCost(tt.nodeType, tt.nodes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EstimateElasticacheCost() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if math.Abs(got.MonthlyCost-tt.want) > 0.001 || got.Class != tt.wantClass {
+				t.Errorf("EstimateElasticacheCost() = %+v, want %v %v", got, tt.want, tt.wantClass)
+			}
+		})
+	}
+}
+
+func TestSetCostStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		cost      *Cost
+		wantClass string
+		wantCost  string
+	}{
+		{
+			name:      "test cost is recorded on the status",
+			cost:      NewCost(26.28),
+			wantClass: "S",
+			wantCost:  "26.28 USD",
+		},
+		{
+			name: "test cost is cleared when it can not be estimated",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := &croType.ResourceTypeStatus{CostClass: "XL", EstimatedMonthlyCost: "1000.00 USD"}
+			SetCostStatus(status, tt.cost)
+			if status.CostClass != tt.wantClass || status.EstimatedMonthlyCost != tt.wantCost {
+				t.Errorf("SetCostStatus() = %s %s, want %s %s", status.CostClass, status.EstimatedMonthlyCost, tt.wantClass, tt.wantCost)
+			}
+		})
+	}
+}
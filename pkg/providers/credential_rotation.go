@@ -0,0 +1,48 @@
+package providers
+
+import (
+	"context"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/annotations"
+	errorUtil "github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// RotateCredentialsAnnotation requests a credential rotation of the annotated postgres cr, it's removed once the
+	// new credentials are in use
+	RotateCredentialsAnnotation = "integreatly.org/rotate-credentials"
+	// PendingPasswordKey is the credential secret key holding the new password while a rotation is in progress
+	PendingPasswordKey = "pendingPassword"
+)
+
+// IsCredentialRotationDue returns true if a credential rotation was requested with the rotate credentials annotation,
+// or if the rotation interval has passed since the credentials were last rotated, or the postgres cr was created
+func IsCredentialRotationDue(pg *v1alpha1.Postgres, now time.Time) bool {
+	if annotations.Has(pg, RotateCredentialsAnnotation) {
+		return true
+	}
+	if pg.Spec.CredentialRotation == nil || pg.Spec.CredentialRotation.IntervalDays <= 0 {
+		return false
+	}
+	lastRotated := pg.CreationTimestamp.Time
+	if rotatedAt, err := time.Parse(time.RFC3339, pg.Status.CredentialsRotatedAt); err == nil {
+		lastRotated = rotatedAt
+	}
+	return !now.Before(lastRotated.AddDate(0, 0, pg.Spec.CredentialRotation.IntervalDays))
+}
+
+// CompleteCredentialRotation removes the rotate credentials annotation from the postgres cr and records the rotation
+// time in its status, the status is persisted with the rest of the postgres cr status
+func CompleteCredentialRotation(ctx context.Context, c client.Client, pg *v1alpha1.Postgres, now time.Time) error {
+	if annotations.Has(pg, RotateCredentialsAnnotation) {
+		annotations.Remove(pg, RotateCredentialsAnnotation)
+		if err := c.Update(ctx, pg); err != nil {
+			return errorUtil.Wrapf(err, "failed to remove %s annotation", RotateCredentialsAnnotation)
+		}
+	}
+	pg.Status.CredentialsRotatedAt = now.UTC().Format(time.RFC3339)
+	return nil
+}
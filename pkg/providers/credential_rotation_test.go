@@ -0,0 +1,103 @@
+package providers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/annotations"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func buildTestRotatedPostgres(intervalDays int, rotatedAt string) *v1alpha1.Postgres {
+	return &v1alpha1.Postgres{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "test",
+			Namespace:         "test",
+			CreationTimestamp: metav1.NewTime(testScheduleTime.AddDate(0, 0, -10)),
+		},
+		Spec: croType.ResourceTypeSpec{
+			CredentialRotation: &croType.CredentialRotation{IntervalDays: intervalDays},
+		},
+		Status: croType.ResourceTypeStatus{
+			CredentialsRotatedAt: rotatedAt,
+		},
+	}
+}
+
+func TestIsCredentialRotationDue(t *testing.T) {
+	tests := []struct {
+		name     string
+		postgres *v1alpha1.Postgres
+		want     bool
+	}{
+		{
+			name: "test rotation is not due without a rotation interval",
+			postgres: func() *v1alpha1.Postgres {
+				pg := buildTestRotatedPostgres(0, "")
+				pg.Spec.CredentialRotation = nil
+				return pg
+			}(),
+			want: false,
+		},
+		{
+			name: "test rotation is due on request",
+			postgres: func() *v1alpha1.Postgres {
+				pg := buildTestRotatedPostgres(0, "")
+				annotations.Add(pg, RotateCredentialsAnnotation, "true")
+				return pg
+			}(),
+			want: true,
+		},
+		{
+			name:     "test rotation is due when the interval has passed since the postgres cr was created",
+			postgres: buildTestRotatedPostgres(7, ""),
+			want:     true,
+		},
+		{
+			name:     "test rotation is not due before the interval has passed since the postgres cr was created",
+			postgres: buildTestRotatedPostgres(30, ""),
+			want:     false,
+		},
+		{
+			name:     "test rotation is not due before the interval has passed since the last rotation",
+			postgres: buildTestRotatedPostgres(7, testScheduleTime.AddDate(0, 0, -2).Format(time.RFC3339)),
+			want:     false,
+		},
+		{
+			name:     "test rotation is due when the interval has passed since the last rotation",
+			postgres: buildTestRotatedPostgres(7, testScheduleTime.AddDate(0, 0, -7).Format(time.RFC3339)),
+			want:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsCredentialRotationDue(tt.postgres, testScheduleTime); got != tt.want {
+				t.Errorf("IsCredentialRotationDue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompleteCredentialRotation(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	pg := buildTestRotatedPostgres(0, "")
+	annotations.Add(pg, RotateCredentialsAnnotation, "true")
+	c := fake.NewFakeClientWithScheme(scheme, pg)
+	if err := CompleteCredentialRotation(context.TODO(), c, pg, testScheduleTime); err != nil {
+		t.Fatalf("CompleteCredentialRotation() unexpected error = %v", err)
+	}
+	if annotations.Has(pg, RotateCredentialsAnnotation) {
+		t.Errorf("CompleteCredentialRotation() %s annotation not removed", RotateCredentialsAnnotation)
+	}
+	if want := testScheduleTime.Format(time.RFC3339); pg.Status.CredentialsRotatedAt != want {
+		t.Errorf("CompleteCredentialRotation() rotated at = %s, want %s", pg.Status.CredentialsRotatedAt, want)
+	}
+}
@@ -0,0 +1,212 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/annotations"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources/metrics"
+	errorUtil "github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ConfirmDeletionAnnotation lets a resource whose deletion was paused by the deletion rate limit be deleted
+	ConfirmDeletionAnnotation = "integreatly.org/confirm-deletion"
+
+	// DeletionThrottledCondition is the condition reporting if the deletion of a resource is paused because too many
+	// resources were deleted within the window of the deletion rate limit, it's only set for deleted resources
+	DeletionThrottledCondition = "DeletionThrottled"
+	// MassDeletionReason is the reason of a true deletion throttled condition
+	MassDeletionReason = "MassDeletion"
+	// WithinDeletionRateReason is the reason of a false deletion throttled condition for a resource deleted within
+	// the deletion rate limit
+	WithinDeletionRateReason = "WithinDeletionRate"
+	// DeletionConfirmedReason is the reason of a false deletion throttled condition for a resource whose deletion was
+	// confirmed
+	DeletionConfirmedReason = "DeletionConfirmed"
+)
+
+// DeletionRateLimit caps the number of resources whose cloud resources are deleted within a window, deletions beyond
+// it are paused until they're confirmed. Deletions aren't capped if it's nil
+var DeletionRateLimit *DeletionRate
+
+// AllowMassDeletion confirms every deletion paused by the deletion rate limit
+var AllowMassDeletion bool
+
+// admittedDeletions are the deletions admitted by this process, the resources may be gone, or their status showing
+// the admission may not be in the cache yet
+var admittedDeletions = &deletionSet{deletions: map[string]time.Time{}}
+
+// DeletionRate is the maximum number of resources deleted within a window
+type DeletionRate struct {
+	Max    int
+	Window time.Duration
+}
+
+type deletionSet struct {
+	mu        sync.Mutex
+	deletions map[string]time.Time
+}
+
+func (s *deletionSet) add(key string, admittedAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deletions[key] = admittedAt
+}
+
+// recent returns the deletions admitted within the window, forgetting older ones
+func (s *deletionSet) recent(window time.Duration, now time.Time) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []string
+	for key, admittedAt := range s.deletions {
+		if now.Sub(admittedAt) > window {
+			delete(s.deletions, key)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// ParseDeletionRateLimit parses a deletion rate limit of a number of deletions per window, e.g. 10/1h, it returns nil
+// for an empty limit
+func ParseDeletionRateLimit(s string) (*DeletionRate, error) {
+	if s = strings.TrimSpace(s); s == "" {
+		return nil, nil
+	}
+	parts := strings.SplitN(s, "/", 2)
+	if len(parts) != 2 {
+		return nil, errorUtil.Errorf("invalid deletion rate limit %s, expected deletions/window", s)
+	}
+	max, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || max < 1 {
+		return nil, errorUtil.Errorf("invalid deletion rate limit %s, deletions must be a positive number", s)
+	}
+	window, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+	if err != nil || window <= 0 {
+		return nil, errorUtil.Errorf("invalid deletion rate limit %s, window must be a positive duration", s)
+	}
+	return &DeletionRate{Max: max, Window: window}, nil
+}
+
+// ReconcileDeletionGuard checks the deletion of a resource against the deletion rate limit before its cloud resource is
+// deleted, reporting it in the deletion throttled condition of the resource status, the status is persisted with the
+// rest of the resource status. It returns a message if the deletion is paused, empty if the cloud resource can be
+// deleted. A paused deletion stays paused until the resource has the confirm deletion annotation, or mass deletion is
+// allowed, and an admitted deletion is never paused later on
+func ReconcileDeletionGuard(ctx context.Context, c client.Client, rt ResourceType, inst metav1.Object, status *croType.ResourceTypeStatus) (croType.StatusMessage, error) {
+	rate := DeletionRateLimit
+	if rate == nil {
+		return croType.StatusEmpty, nil
+	}
+	key := provisioningKey(inst)
+	nsName := types.NamespacedName{Namespace: inst.GetNamespace(), Name: inst.GetName()}
+	cond := meta.FindStatusCondition(status.Conditions, DeletionThrottledCondition)
+	if cond != nil && cond.Status == metav1.ConditionFalse {
+		return croType.StatusEmpty, nil
+	}
+	if annotations.Has(inst, ConfirmDeletionAnnotation) || AllowMassDeletion {
+		setDeletionThrottledCondition(status, inst.GetGeneration(), metav1.ConditionFalse, DeletionConfirmedReason, "deletion was confirmed")
+		metrics.SetDeletionPaused(string(rt), nsName, false)
+		admittedDeletions.add(key, time.Now())
+		return croType.StatusEmpty, nil
+	}
+	if cond != nil && cond.Status == metav1.ConditionTrue {
+		metrics.SetDeletionPaused(string(rt), nsName, true)
+		return croType.StatusMessage(cond.Message), nil
+	}
+
+	// the admission of a deletion already started by this process may not be persisted, e.g. if the provider updated
+	// the resource before its status
+	now := time.Now()
+	recent := admittedDeletions.recent(rate.Window, now)
+	if resources.Contains(recent, key) {
+		setDeletionThrottledCondition(status, inst.GetGeneration(), metav1.ConditionFalse, WithinDeletionRateReason, "deletion was admitted within the deletion rate limit")
+		return croType.StatusEmpty, nil
+	}
+	deleted, err := countAdmittedDeletions(ctx, c, key, recent, rate.Window, now)
+	if err != nil {
+		errMsg := "failed to count recently deleted resources"
+		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	if deleted >= rate.Max {
+		msg := fmt.Sprintf("deletion paused, %d resources were deleted in the last %s, add the %s annotation to delete the %s", deleted, rate.Window, ConfirmDeletionAnnotation, rt)
+		setDeletionThrottledCondition(status, inst.GetGeneration(), metav1.ConditionTrue, MassDeletionReason, msg)
+		metrics.SetDeletionPaused(string(rt), nsName, true)
+		return croType.StatusMessage(msg), nil
+	}
+	setDeletionThrottledCondition(status, inst.GetGeneration(), metav1.ConditionFalse, WithinDeletionRateReason, fmt.Sprintf("%d of %d deletions allowed in %s used", deleted+1, rate.Max, rate.Window))
+	admittedDeletions.add(key, now)
+	return croType.StatusEmpty, nil
+}
+
+func setDeletionThrottledCondition(status *croType.ResourceTypeStatus, generation int64, condStatus metav1.ConditionStatus, reason, msg string) {
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               DeletionThrottledCondition,
+		Status:             condStatus,
+		ObservedGeneration: generation,
+		Reason:             reason,
+		Message:            msg,
+	})
+}
+
+// countAdmittedDeletions returns the number of resources, other than the resource with the key, whose deletion was
+// admitted within the window, from the resource statuses and the deletions recently admitted by this process
+func countAdmittedDeletions(ctx context.Context, c client.Client, key string, recent []string, window time.Duration, now time.Time) (int, error) {
+	deleted := map[string]bool{}
+	add := func(om metav1.ObjectMeta, status croType.ResourceTypeStatus) {
+		if om.DeletionTimestamp == nil || now.Sub(om.DeletionTimestamp.Time) > window {
+			return
+		}
+		if meta.IsStatusConditionFalse(status.Conditions, DeletionThrottledCondition) {
+			deleted[provisioningKey(&om)] = true
+		}
+	}
+
+	bsList := &v1alpha1.BlobStorageList{}
+	if err := c.List(ctx, bsList); err != nil {
+		return 0, errorUtil.Wrap(err, "failed to list blob storages")
+	}
+	for _, bs := range bsList.Items {
+		add(bs.ObjectMeta, bs.Status)
+	}
+	pgList := &v1alpha1.PostgresList{}
+	if err := c.List(ctx, pgList); err != nil {
+		return 0, errorUtil.Wrap(err, "failed to list postgres")
+	}
+	for _, pg := range pgList.Items {
+		add(pg.ObjectMeta, pg.Status)
+	}
+	redisList := &v1alpha1.RedisList{}
+	if err := c.List(ctx, redisList); err != nil {
+		return 0, errorUtil.Wrap(err, "failed to list redis")
+	}
+	for _, r := range redisList.Items {
+		add(r.ObjectMeta, r.Status)
+	}
+	queueList := &v1alpha1.QueueList{}
+	if err := c.List(ctx, queueList); err != nil {
+		return 0, errorUtil.Wrap(err, "failed to list queues")
+	}
+	for _, q := range queueList.Items {
+		add(q.ObjectMeta, q.Status)
+	}
+
+	for _, k := range recent {
+		deleted[k] = true
+	}
+	delete(deleted, key)
+	return len(deleted), nil
+}
@@ -0,0 +1,184 @@
+package providers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func buildTestDeletedPostgres(name string, deletedAt time.Time, throttled metav1.ConditionStatus) *v1alpha1.Postgres {
+	t := metav1.NewTime(deletedAt)
+	pg := &v1alpha1.Postgres{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "test",
+			DeletionTimestamp: &t,
+		},
+	}
+	if throttled != "" {
+		setDeletionThrottledCondition(&pg.Status, 0, throttled, WithinDeletionRateReason, "test")
+	}
+	return pg
+}
+
+func TestParseDeletionRateLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   string
+		want    *DeletionRate
+		wantErr bool
+	}{
+		{name: "test empty limit", limit: ""},
+		{name: "test limit is parsed", limit: "10/1h", want: &DeletionRate{Max: 10, Window: time.Hour}},
+		{name: "test error on missing window", limit: "10", wantErr: true},
+		{name: "test error on non positive deletions", limit: "0/1h", wantErr: true},
+		{name: "test error on invalid window", limit: "10/hour", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDeletionRateLimit(tt.limit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDeletionRateLimit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("ParseDeletionRateLimit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconcileDeletionGuard(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	now := time.Now()
+	tests := []struct {
+		name          string
+		rate          *DeletionRate
+		allowAll      bool
+		pg            *v1alpha1.Postgres
+		existing      []runtime.Object
+		wantPaused    bool
+		wantCondition metav1.ConditionStatus
+		wantReason    string
+	}{
+		{
+			name: "test deletion without a rate limit",
+			pg:   buildTestDeletedPostgres("test", now, ""),
+		},
+		{
+			name:          "test deletion within the rate limit is admitted",
+			rate:          &DeletionRate{Max: 2, Window: time.Hour},
+			pg:            buildTestDeletedPostgres("test", now, ""),
+			existing:      []runtime.Object{buildTestDeletedPostgres("deleted", now, metav1.ConditionFalse)},
+			wantCondition: metav1.ConditionFalse,
+			wantReason:    WithinDeletionRateReason,
+		},
+		{
+			name:          "test deletion beyond the rate limit is paused",
+			rate:          &DeletionRate{Max: 1, Window: time.Hour},
+			pg:            buildTestDeletedPostgres("test", now, ""),
+			existing:      []runtime.Object{buildTestDeletedPostgres("deleted", now, metav1.ConditionFalse)},
+			wantPaused:    true,
+			wantCondition: metav1.ConditionTrue,
+			wantReason:    MassDeletionReason,
+		},
+		{
+			name:          "test deletions outside the window and paused deletions aren't counted",
+			rate:          &DeletionRate{Max: 1, Window: time.Hour},
+			pg:            buildTestDeletedPostgres("test", now, ""),
+			existing:      []runtime.Object{buildTestDeletedPostgres("old", now.Add(-2*time.Hour), metav1.ConditionFalse), buildTestDeletedPostgres("paused", now, metav1.ConditionTrue)},
+			wantCondition: metav1.ConditionFalse,
+			wantReason:    WithinDeletionRateReason,
+		},
+		{
+			name:          "test paused deletion stays paused once below the rate limit",
+			rate:          &DeletionRate{Max: 1, Window: time.Hour},
+			pg:            buildTestDeletedPostgres("test", now, metav1.ConditionTrue),
+			wantPaused:    true,
+			wantCondition: metav1.ConditionTrue,
+		},
+		{
+			name: "test paused deletion is confirmed by the annotation",
+			rate: &DeletionRate{Max: 1, Window: time.Hour},
+			pg: func() *v1alpha1.Postgres {
+				pg := buildTestDeletedPostgres("test", now, metav1.ConditionTrue)
+				pg.Annotations = map[string]string{ConfirmDeletionAnnotation: "true"}
+				return pg
+			}(),
+			wantCondition: metav1.ConditionFalse,
+			wantReason:    DeletionConfirmedReason,
+		},
+		{
+			name:          "test paused deletion is confirmed when mass deletion is allowed",
+			rate:          &DeletionRate{Max: 1, Window: time.Hour},
+			allowAll:      true,
+			pg:            buildTestDeletedPostgres("test", now, metav1.ConditionTrue),
+			wantCondition: metav1.ConditionFalse,
+			wantReason:    DeletionConfirmedReason,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			DeletionRateLimit = tt.rate
+			AllowMassDeletion = tt.allowAll
+			defer func() {
+				DeletionRateLimit = nil
+				AllowMassDeletion = false
+			}()
+			admittedDeletions = &deletionSet{deletions: map[string]time.Time{}}
+
+			c := fake.NewFakeClientWithScheme(scheme, tt.existing...)
+			msg, err := ReconcileDeletionGuard(context.TODO(), c, PostgresResourceType, tt.pg, &tt.pg.Status)
+			if err != nil {
+				t.Fatalf("ReconcileDeletionGuard() unexpected error = %v", err)
+			}
+			if (msg != croType.StatusEmpty) != tt.wantPaused {
+				t.Errorf("ReconcileDeletionGuard() msg = %q, want paused %v", msg, tt.wantPaused)
+			}
+			cond := meta.FindStatusCondition(tt.pg.Status.Conditions, DeletionThrottledCondition)
+			if tt.wantCondition == "" {
+				if cond != nil {
+					t.Errorf("ReconcileDeletionGuard() condition = %v, want none", cond)
+				}
+				return
+			}
+			if cond == nil || cond.Status != tt.wantCondition || (tt.wantReason != "" && cond.Reason != tt.wantReason) {
+				t.Errorf("ReconcileDeletionGuard() condition = %v, want status %s reason %s", cond, tt.wantCondition, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestReconcileDeletionGuard_CountsDeletionsNotYetCached(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	DeletionRateLimit = &DeletionRate{Max: 1, Window: time.Hour}
+	defer func() { DeletionRateLimit = nil }()
+	admittedDeletions = &deletionSet{deletions: map[string]time.Time{}}
+	c := fake.NewFakeClientWithScheme(scheme)
+
+	first := buildTestDeletedPostgres("first", time.Now(), "")
+	if msg, err := ReconcileDeletionGuard(context.TODO(), c, PostgresResourceType, first, &first.Status); err != nil || msg != "" {
+		t.Fatalf("ReconcileDeletionGuard() msg = %s, error = %v, want the first deletion admitted", msg, err)
+	}
+	second := buildTestDeletedPostgres("second", time.Now(), "")
+	if msg, _ := ReconcileDeletionGuard(context.TODO(), c, PostgresResourceType, second, &second.Status); msg == "" {
+		t.Fatalf("ReconcileDeletionGuard() expected the second deletion to be paused")
+	}
+	// the admission of the first deletion isn't lost if its status wasn't persisted
+	first.Status = croType.ResourceTypeStatus{}
+	if msg, _ := ReconcileDeletionGuard(context.TODO(), c, PostgresResourceType, first, &first.Status); msg != "" {
+		t.Errorf("ReconcileDeletionGuard() msg = %s, want the first deletion to stay admitted", msg)
+	}
+}
@@ -0,0 +1,17 @@
+package providers
+
+import (
+	"fmt"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+)
+
+// CheckDeletionProtection returns a message if the deletion protection of a deleted resource blocks the deletion of its
+// cloud resource, empty if it can be deleted. The resource is paused, which sets its deletion blocked condition, until
+// the deletion protection is unset, so deleting the namespace of a protected resource keeps its cloud resource
+func CheckDeletionProtection(rt ResourceType, spec *croType.ResourceTypeSpec) croType.StatusMessage {
+	if !spec.DeletionProtection {
+		return croType.StatusEmpty
+	}
+	return croType.StatusMessage(fmt.Sprintf("deletion blocked by deletion protection, set deletionProtection to false to delete the %s", rt))
+}
@@ -0,0 +1,33 @@
+package providers
+
+import (
+	"testing"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+)
+
+func TestCheckDeletionProtection(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        *croType.ResourceTypeSpec
+		wantBlocked bool
+	}{
+		{
+			name: "test unprotected resource can be deleted",
+			spec: &croType.ResourceTypeSpec{},
+		},
+		{
+			name:        "test protected resource is blocked",
+			spec:        &croType.ResourceTypeSpec{DeletionProtection: true},
+			wantBlocked: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := CheckDeletionProtection(PostgresResourceType, tt.spec)
+			if (msg != croType.StatusEmpty) != tt.wantBlocked {
+				t.Errorf("CheckDeletionProtection() = %q, want blocked %v", msg, tt.wantBlocked)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	errorUtil "github.com/pkg/errors"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DependenciesReadyCondition is the condition reporting if the resources a resource depends on are complete
+	DependenciesReadyCondition = "DependenciesReady"
+	// DependenciesCompleteReason is the reason of a true dependencies ready condition
+	DependenciesCompleteReason = "DependenciesComplete"
+	// WaitingForDependencyReason is the reason of a false dependencies ready condition
+	WaitingForDependencyReason = "WaitingForDependency"
+)
+
+// ReconcileDependencies checks the resources in the depends on list of a resource are complete and reports it in the
+// dependencies ready condition of the resource status, the status is persisted with the rest of the resource status.
+// It returns a message naming the first dependency that isn't complete, empty if the resource can be provisioned.
+// Dependencies are only waited for until they're first complete, so a provisioned resource isn't held back by changes
+// to its dependencies
+func ReconcileDependencies(ctx context.Context, c client.Client, inst metav1.Object, spec *croType.ResourceTypeSpec, status *croType.ResourceTypeStatus) (croType.StatusMessage, error) {
+	if len(spec.DependsOn) == 0 {
+		meta.RemoveStatusCondition(&status.Conditions, DependenciesReadyCondition)
+		return croType.StatusEmpty, nil
+	}
+	if meta.IsStatusConditionTrue(status.Conditions, DependenciesReadyCondition) {
+		return croType.StatusEmpty, nil
+	}
+	for _, dep := range spec.DependsOn {
+		phase, err := getDependencyPhase(ctx, c, inst.GetNamespace(), dep)
+		if err != nil {
+			errMsg := fmt.Sprintf("failed to get %s dependency %s", dep.Kind, dep.Name)
+			return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+		if phase != croType.PhaseComplete {
+			msg := fmt.Sprintf("waiting for %s %s to be complete", dep.Kind, dep.Name)
+			meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+				Type:               DependenciesReadyCondition,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: inst.GetGeneration(),
+				Reason:             WaitingForDependencyReason,
+				Message:            msg,
+			})
+			return croType.StatusMessage(msg), nil
+		}
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               DependenciesReadyCondition,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: inst.GetGeneration(),
+		Reason:             DependenciesCompleteReason,
+		Message:            "all dependencies are complete",
+	})
+	return croType.StatusEmpty, nil
+}
+
+// getDependencyPhase returns the phase of the dependency, empty if it doesn't exist yet
+func getDependencyPhase(ctx context.Context, c client.Client, ns string, dep croType.Dependency) (croType.StatusPhase, error) {
+	key := client.ObjectKey{Name: dep.Name, Namespace: ns}
+	var err error
+	var phase croType.StatusPhase
+	switch dep.Kind {
+	case "Postgres":
+		pg := &v1alpha1.Postgres{}
+		err = c.Get(ctx, key, pg)
+		phase = pg.Status.Phase
+	case "Redis":
+		r := &v1alpha1.Redis{}
+		err = c.Get(ctx, key, r)
+		phase = r.Status.Phase
+	case "BlobStorage":
+		bs := &v1alpha1.BlobStorage{}
+		err = c.Get(ctx, key, bs)
+		phase = bs.Status.Phase
+	default:
+		return "", errorUtil.New(fmt.Sprintf("unsupported dependency kind %s", dep.Kind))
+	}
+	if k8serr.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return phase, nil
+}
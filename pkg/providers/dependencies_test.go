@@ -0,0 +1,119 @@
+package providers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func buildTestDependentRedis(deps ...croType.Dependency) *v1alpha1.Redis {
+	return &v1alpha1.Redis{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test",
+			Namespace:  "test",
+			Generation: 1,
+		},
+		Spec: croType.ResourceTypeSpec{
+			DependsOn: deps,
+		},
+	}
+}
+
+func buildTestDependencyPostgres(phase croType.StatusPhase) *v1alpha1.Postgres {
+	return &v1alpha1.Postgres{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-postgres",
+			Namespace: "test",
+		},
+		Status: croType.ResourceTypeStatus{
+			Phase: phase,
+		},
+	}
+}
+
+func TestReconcileDependencies(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	postgresDep := croType.Dependency{Kind: "Postgres", Name: "test-postgres"}
+	tests := []struct {
+		name          string
+		redis         *v1alpha1.Redis
+		existing      []runtime.Object
+		wantMsg       croType.StatusMessage
+		wantErr       bool
+		wantCondition metav1.ConditionStatus
+	}{
+		{
+			name:  "test resource without dependencies has no condition",
+			redis: buildTestDependentRedis(),
+		},
+		{
+			name:          "test waits for a dependency that doesn't exist",
+			redis:         buildTestDependentRedis(postgresDep),
+			wantMsg:       "waiting for Postgres test-postgres to be complete",
+			wantCondition: metav1.ConditionFalse,
+		},
+		{
+			name:          "test waits for a dependency that isn't complete",
+			redis:         buildTestDependentRedis(postgresDep),
+			existing:      []runtime.Object{buildTestDependencyPostgres(croType.PhaseInProgress)},
+			wantMsg:       "waiting for Postgres test-postgres to be complete",
+			wantCondition: metav1.ConditionFalse,
+		},
+		{
+			name:          "test ready when all dependencies are complete",
+			redis:         buildTestDependentRedis(postgresDep),
+			existing:      []runtime.Object{buildTestDependencyPostgres(croType.PhaseComplete)},
+			wantCondition: metav1.ConditionTrue,
+		},
+		{
+			name: "test dependencies are not checked again once ready",
+			redis: func() *v1alpha1.Redis {
+				r := buildTestDependentRedis(postgresDep)
+				meta.SetStatusCondition(&r.Status.Conditions, metav1.Condition{
+					Type:   DependenciesReadyCondition,
+					Status: metav1.ConditionTrue,
+					Reason: DependenciesCompleteReason,
+				})
+				return r
+			}(),
+			wantCondition: metav1.ConditionTrue,
+		},
+		{
+			name:    "test error on unsupported dependency kind",
+			redis:   buildTestDependentRedis(croType.Dependency{Kind: "NetworkPeering", Name: "test"}),
+			wantMsg: "failed to get NetworkPeering dependency test",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme, tt.existing...)
+			msg, err := ReconcileDependencies(context.TODO(), c, tt.redis, &tt.redis.Spec, &tt.redis.Status)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReconcileDependencies() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if msg != tt.wantMsg {
+				t.Errorf("ReconcileDependencies() msg = %s, want %s", msg, tt.wantMsg)
+			}
+			cond := meta.FindStatusCondition(tt.redis.Status.Conditions, DependenciesReadyCondition)
+			if tt.wantCondition == "" {
+				if cond != nil {
+					t.Errorf("ReconcileDependencies() unexpected condition %v", cond)
+				}
+				return
+			}
+			if cond == nil || cond.Status != tt.wantCondition {
+				t.Errorf("ReconcileDependencies() condition = %v, want status %s", cond, tt.wantCondition)
+			}
+		})
+	}
+}
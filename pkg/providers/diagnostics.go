@@ -0,0 +1,26 @@
+package providers
+
+import (
+	"context"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ProviderEvent is an event the provider reported for the cloud resource of a resource, e.g. an rds event
+type ProviderEvent struct {
+	Time    metav1.Time `json:"time"`
+	Message string      `json:"message"`
+}
+
+// PostgresEventReader is implemented by postgres providers which can read the recent events of their cloud resources,
+// they're added to the support bundle of a failed postgres
+type PostgresEventReader interface {
+	GetPostgresEvents(ctx context.Context, ps *v1alpha1.Postgres) ([]ProviderEvent, error)
+}
+
+// RedisEventReader is implemented by redis providers which can read the recent events of their cloud resources, they're
+// added to the support bundle of a failed redis
+type RedisEventReader interface {
+	GetRedisEvents(ctx context.Context, r *v1alpha1.Redis) ([]ProviderEvent, error)
+}
@@ -0,0 +1,143 @@
+package providers
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources/metrics"
+	errorUtil "github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// ExpiryWarningCondition is the condition reporting if an artifact a resource is provisioned with expires soon
+	ExpiryWarningCondition = "ExpiryWarning"
+	// ArtifactsValidReason is the reason of a false expiry warning condition
+	ArtifactsValidReason = "ArtifactsValid"
+	// ArtifactExpiringReason is the reason of a true expiry warning condition for an artifact within the warning period
+	ArtifactExpiringReason = "ArtifactExpiring"
+	// ArtifactExpiredReason is the reason of a true expiry warning condition for an artifact that has expired
+	ArtifactExpiredReason = "ArtifactExpired"
+
+	// ArtifactKindTLSCertificate is the serving certificate clients verify the resource with
+	ArtifactKindTLSCertificate = "TLSCertificate"
+	// ArtifactKindCABundle is the ca certificate clients verify the serving certificate of the resource with
+	ArtifactKindCABundle = "CABundle"
+	// ArtifactKindAccessKey is an access key of the credentials to use the resource, it doesn't expire but is due for
+	// rotation once it reaches the maximum age
+	ArtifactKindAccessKey = "AccessKey"
+
+	DefaultExpiryWarningDays   = 30
+	DefaultAccessKeyMaxAgeDays = 90
+)
+
+// ExpiringArtifact is an artifact a resource is provisioned with that expires, or ages, and must be rotated before
+// clients of the resource stop working
+type ExpiringArtifact struct {
+	// Kind is one of TLSCertificate, CABundle or AccessKey
+	Kind string
+	// Name identifies the artifact, e.g. the secret holding it
+	Name string
+	// ExpiresAt is the time the artifact expires, for access keys it's derived from IssuedAt
+	ExpiresAt time.Time
+	// IssuedAt is the time the artifact was issued
+	IssuedAt time.Time
+}
+
+// ExpiryConfig is the part of a tier configuring when the artifacts of its resources are reported as expiring
+type ExpiryConfig struct {
+	// WarningDays is the number of days before an artifact expires the expiry warning condition is set, defaults to 30
+	WarningDays int `json:"warningDays,omitempty"`
+	// AccessKeyMaxAgeDays is the age access keys are due for rotation at, defaults to 90
+	AccessKeyMaxAgeDays int `json:"accessKeyMaxAgeDays,omitempty"`
+}
+
+// DefaultExpiryConfig returns the expiry configuration of a tier that doesn't configure it
+func DefaultExpiryConfig() *ExpiryConfig {
+	return &ExpiryConfig{WarningDays: DefaultExpiryWarningDays, AccessKeyMaxAgeDays: DefaultAccessKeyMaxAgeDays}
+}
+
+// expiresAt returns the time the artifact expires, access keys expire once they reach the maximum age
+func (c *ExpiryConfig) expiresAt(a ExpiringArtifact) time.Time {
+	if a.Kind == ArtifactKindAccessKey && a.ExpiresAt.IsZero() {
+		return a.IssuedAt.AddDate(0, 0, c.AccessKeyMaxAgeDays)
+	}
+	return a.ExpiresAt
+}
+
+// BuildCertificateArtifact returns the pem encoded certificates, e.g. a serving certificate chain or a ca bundle, as an
+// expiring artifact that expires with the first of the certificates
+func BuildCertificateArtifact(kind, name string, data []byte) (*ExpiringArtifact, error) {
+	var artifact *ExpiringArtifact
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errorUtil.Wrapf(err, "failed to parse certificate of %s", name)
+		}
+		if artifact == nil || cert.NotAfter.Before(artifact.ExpiresAt) {
+			artifact = &ExpiringArtifact{Kind: kind, Name: name, IssuedAt: cert.NotBefore, ExpiresAt: cert.NotAfter}
+		}
+	}
+	if artifact == nil {
+		return nil, errorUtil.Errorf("no certificates found in %s", name)
+	}
+	return artifact, nil
+}
+
+// ReconcileExpiry exposes the days until each artifact of a resource expires and reports the artifact expiring first
+// in the expiry warning condition of the resource status, the status is persisted with the rest of the resource status.
+// It returns a warning naming the artifact if it expires within the warning period of the tier, empty otherwise
+func ReconcileExpiry(rt ResourceType, inst metav1.Object, status *croType.ResourceTypeStatus, artifacts []ExpiringArtifact, cfg *ExpiryConfig, now time.Time) croType.StatusMessage {
+	key := types.NamespacedName{Namespace: inst.GetNamespace(), Name: inst.GetName()}
+	if len(artifacts) == 0 {
+		metrics.DeleteArtifactExpiry(string(rt), key)
+		meta.RemoveStatusCondition(&status.Conditions, ExpiryWarningCondition)
+		return croType.StatusEmpty
+	}
+	if cfg == nil {
+		cfg = DefaultExpiryConfig()
+	}
+
+	var expiries []metrics.ArtifactExpiry
+	var first ExpiringArtifact
+	var firstExpiresAt time.Time
+	for _, a := range artifacts {
+		expiresAt := cfg.expiresAt(a)
+		expiries = append(expiries, metrics.ArtifactExpiry{Kind: a.Kind, Name: a.Name, Days: expiresAt.Sub(now).Hours() / 24})
+		if firstExpiresAt.IsZero() || expiresAt.Before(firstExpiresAt) {
+			first, firstExpiresAt = a, expiresAt
+		}
+	}
+	metrics.SetArtifactExpiry(string(rt), key, expiries)
+
+	cond := metav1.Condition{
+		Type:               ExpiryWarningCondition,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: inst.GetGeneration(),
+		Reason:             ArtifactsValidReason,
+		Message:            fmt.Sprintf("%s %s expires first, at %s", first.Kind, first.Name, firstExpiresAt.UTC().Format(time.RFC3339)),
+	}
+	switch {
+	case !now.Before(firstExpiresAt):
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = ArtifactExpiredReason
+		cond.Message = fmt.Sprintf("%s %s expired at %s", first.Kind, first.Name, firstExpiresAt.UTC().Format(time.RFC3339))
+	case now.AddDate(0, 0, cfg.WarningDays).After(firstExpiresAt):
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = ArtifactExpiringReason
+		cond.Message = fmt.Sprintf("%s %s expires in %d days, at %s", first.Kind, first.Name, int(firstExpiresAt.Sub(now).Hours()/24), firstExpiresAt.UTC().Format(time.RFC3339))
+	}
+	meta.SetStatusCondition(&status.Conditions, cond)
+	if cond.Status == metav1.ConditionTrue {
+		return croType.StatusMessage(cond.Message)
+	}
+	return croType.StatusEmpty
+}
@@ -0,0 +1,113 @@
+package providers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func buildTestCertificatePEM(t *testing.T, notBefore, notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("failed to generate key", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal("failed to create certificate", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestBuildCertificateArtifact(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	bundle := append(buildTestCertificatePEM(t, now, now.AddDate(1, 0, 0)), buildTestCertificatePEM(t, now, now.AddDate(0, 0, 10))...)
+
+	got, err := BuildCertificateArtifact(ArtifactKindCABundle, "test-service-ca", bundle)
+	if err != nil {
+		t.Fatalf("BuildCertificateArtifact() unexpected error = %v", err)
+	}
+	if !got.ExpiresAt.Equal(now.AddDate(0, 0, 10)) {
+		t.Errorf("BuildCertificateArtifact() expires at %s, want the first certificate of the bundle to expire", got.ExpiresAt)
+	}
+	if _, err := BuildCertificateArtifact(ArtifactKindCABundle, "test-service-ca", []byte("not a certificate")); err == nil {
+		t.Error("BuildCertificateArtifact() expected error without certificates")
+	}
+}
+
+func TestReconcileExpiry(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name       string
+		artifacts  []ExpiringArtifact
+		cfg        *ExpiryConfig
+		wantStatus metav1.ConditionStatus
+		wantReason string
+		wantMsg    bool
+	}{
+		{
+			name:       "test no warning for artifacts expiring after the warning period",
+			artifacts:  []ExpiringArtifact{{Kind: ArtifactKindTLSCertificate, Name: "test-tls", ExpiresAt: now.AddDate(0, 0, 60)}},
+			wantStatus: metav1.ConditionFalse,
+			wantReason: ArtifactsValidReason,
+		},
+		{
+			name: "test warning for the first artifact expiring within the warning period",
+			artifacts: []ExpiringArtifact{
+				{Kind: ArtifactKindCABundle, Name: "test-service-ca", ExpiresAt: now.AddDate(1, 0, 0)},
+				{Kind: ArtifactKindTLSCertificate, Name: "test-tls", ExpiresAt: now.AddDate(0, 0, 10)},
+			},
+			wantStatus: metav1.ConditionTrue,
+			wantReason: ArtifactExpiringReason,
+			wantMsg:    true,
+		},
+		{
+			name:       "test warning for access keys older than the maximum age of the tier",
+			artifacts:  []ExpiringArtifact{{Kind: ArtifactKindAccessKey, Name: "test-creds", IssuedAt: now.AddDate(0, 0, -20)}},
+			cfg:        &ExpiryConfig{WarningDays: 7, AccessKeyMaxAgeDays: 14},
+			wantStatus: metav1.ConditionTrue,
+			wantReason: ArtifactExpiredReason,
+			wantMsg:    true,
+		},
+		{
+			name: "test no condition without artifacts",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := &v1alpha1.Postgres{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test", Generation: 1}}
+			meta.SetStatusCondition(&ps.Status.Conditions, metav1.Condition{Type: ExpiryWarningCondition, Status: metav1.ConditionTrue, Reason: ArtifactExpiredReason})
+
+			msg := ReconcileExpiry(PostgresResourceType, ps, &ps.Status, tt.artifacts, tt.cfg, now)
+			if (msg != croType.StatusEmpty) != tt.wantMsg {
+				t.Errorf("ReconcileExpiry() msg = %q, wantMsg %v", msg, tt.wantMsg)
+			}
+			cond := meta.FindStatusCondition(ps.Status.Conditions, ExpiryWarningCondition)
+			if tt.wantStatus == "" {
+				if cond != nil {
+					t.Errorf("ReconcileExpiry() condition = %v, want none", cond)
+				}
+				return
+			}
+			if cond == nil || cond.Status != tt.wantStatus || cond.Reason != tt.wantReason {
+				t.Errorf("ReconcileExpiry() condition = %v, want status %s reason %s", cond, tt.wantStatus, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package providers
+
+import (
+	"net"
+	"sort"
+	"time"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources/metrics"
+	errorUtil "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ExternalAccessAuditField is the field set on the log entries auditing changes of the external access of a resource
+const ExternalAccessAuditField = "audit"
+
+// ParseExternalAccess returns the source ranges the external access of a resource allows in their canonical form,
+// sorted and without duplicates, none if the resource doesn't allow external access
+func ParseExternalAccess(externalAccess *croType.ExternalAccess) ([]string, error) {
+	if externalAccess == nil {
+		return nil, nil
+	}
+	if len(externalAccess.AllowedCIDRs) == 0 {
+		return nil, errorUtil.New("external access must allow at least one cidr")
+	}
+	seen := map[string]bool{}
+	var cidrs []string
+	for _, c := range externalAccess.AllowedCIDRs {
+		_, ipNet, err := net.ParseCIDR(c)
+		if err != nil {
+			return nil, errorUtil.Wrapf(err, "invalid external access cidr %s", c)
+		}
+		if ipNet.IP.To4() == nil {
+			return nil, errorUtil.Errorf("invalid external access cidr %s, only ipv4 cidrs are supported", c)
+		}
+		if !seen[ipNet.String()] {
+			seen[ipNet.String()] = true
+			cidrs = append(cidrs, ipNet.String())
+		}
+	}
+	sort.Strings(cidrs)
+	return cidrs, nil
+}
+
+// SetExternalAccessStatus records the source ranges allowed to connect to a resource from outside the cluster, and the
+// endpoint they connect to, in the resource status. Every change of the allowed source ranges is audited, logged with
+// the source ranges allowed and revoked and counted in the cro_resource_external_access_changes_total metric
+func SetExternalAccessStatus(logger *logrus.Entry, rt ResourceType, inst metav1.Object, status *croType.ResourceTypeStatus, cidrs []string, endpoint string, now time.Time) {
+	var previous []string
+	if status.ExternalAccess != nil {
+		previous = status.ExternalAccess.AllowedCIDRs
+	}
+	allowed, revoked := diffCIDRs(previous, cidrs)
+	if len(allowed) > 0 || len(revoked) > 0 {
+		logger.WithFields(logrus.Fields{
+			ExternalAccessAuditField: "externalAccess",
+			"resourceType":           rt,
+			"namespace":              inst.GetNamespace(),
+			"name":                   inst.GetName(),
+			"generation":             inst.GetGeneration(),
+			"allowed":                allowed,
+			"revoked":                revoked,
+		}).Info("external access changed")
+		metrics.CountExternalAccessChange(string(rt), types.NamespacedName{Namespace: inst.GetNamespace(), Name: inst.GetName()}, len(allowed), len(revoked))
+	}
+
+	if len(cidrs) == 0 {
+		status.ExternalAccess = nil
+		return
+	}
+	if status.ExternalAccess == nil {
+		status.ExternalAccess = &croType.ExternalAccessStatus{}
+	}
+	if len(allowed) > 0 || len(revoked) > 0 || status.ExternalAccess.LastChangedAt == nil {
+		changedAt := metav1.NewTime(now)
+		status.ExternalAccess.LastChangedAt = &changedAt
+	}
+	status.ExternalAccess.AllowedCIDRs = cidrs
+	status.ExternalAccess.Endpoint = endpoint
+}
+
+// diffCIDRs returns the source ranges in desired but not current, and those in current but not desired
+func diffCIDRs(current, desired []string) ([]string, []string) {
+	inCurrent := map[string]bool{}
+	for _, c := range current {
+		inCurrent[c] = true
+	}
+	inDesired := map[string]bool{}
+	var allowed []string
+	for _, c := range desired {
+		inDesired[c] = true
+		if !inCurrent[c] {
+			allowed = append(allowed, c)
+		}
+	}
+	var revoked []string
+	for _, c := range current {
+		if !inDesired[c] {
+			revoked = append(revoked, c)
+		}
+	}
+	return allowed, revoked
+}
@@ -0,0 +1,117 @@
+package providers
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseExternalAccess(t *testing.T) {
+	tests := []struct {
+		name           string
+		externalAccess *croType.ExternalAccess
+		want           []string
+		wantErr        bool
+	}{
+		{
+			name: "test no external access allows no source ranges",
+		},
+		{
+			name:           "test source ranges are canonical, sorted and unique",
+			externalAccess: &croType.ExternalAccess{AllowedCIDRs: []string{"192.168.1.10/24", "10.0.0.0/16", "192.168.1.0/24"}},
+			want:           []string{"10.0.0.0/16", "192.168.1.0/24"},
+		},
+		{
+			name:           "test invalid source range",
+			externalAccess: &croType.ExternalAccess{AllowedCIDRs: []string{"10.0.0.0"}},
+			wantErr:        true,
+		},
+		{
+			name:           "test ipv6 source range",
+			externalAccess: &croType.ExternalAccess{AllowedCIDRs: []string{"2001:db8::/32"}},
+			wantErr:        true,
+		},
+		{
+			name:           "test external access without source ranges",
+			externalAccess: &croType.ExternalAccess{},
+			wantErr:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseExternalAccess(tt.externalAccess)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseExternalAccess() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseExternalAccess() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("ParseExternalAccess() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSetExternalAccessStatus(t *testing.T) {
+	now := time.Now()
+	changedAt := metav1.NewTime(now.Add(-time.Hour))
+	pg := &v1alpha1.Postgres{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"}}
+	tests := []struct {
+		name          string
+		status        croType.ResourceTypeStatus
+		cidrs         []string
+		wantAudit     bool
+		wantChangedAt bool
+	}{
+		{
+			name:          "test allowing source ranges is audited",
+			cidrs:         []string{"10.0.0.0/16"},
+			wantAudit:     true,
+			wantChangedAt: true,
+		},
+		{
+			name:   "test unchanged source ranges aren't audited",
+			status: croType.ResourceTypeStatus{ExternalAccess: &croType.ExternalAccessStatus{AllowedCIDRs: []string{"10.0.0.0/16"}, LastChangedAt: &changedAt}},
+			cidrs:  []string{"10.0.0.0/16"},
+		},
+		{
+			name:      "test revoking source ranges is audited",
+			status:    croType.ResourceTypeStatus{ExternalAccess: &croType.ExternalAccessStatus{AllowedCIDRs: []string{"10.0.0.0/16"}, LastChangedAt: &changedAt}},
+			wantAudit: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			logger := logrus.New()
+			logger.Out = out
+			SetExternalAccessStatus(logrus.NewEntry(logger), PostgresResourceType, pg, &tt.status, tt.cidrs, "test:5432", now)
+
+			audited := strings.Contains(out.String(), ExternalAccessAuditField+"=externalAccess")
+			if audited != tt.wantAudit {
+				t.Errorf("SetExternalAccessStatus() audited %v, want %v", audited, tt.wantAudit)
+			}
+			if len(tt.cidrs) == 0 {
+				if tt.status.ExternalAccess != nil {
+					t.Errorf("SetExternalAccessStatus() status = %v, want none", tt.status.ExternalAccess)
+				}
+				return
+			}
+			if tt.status.ExternalAccess == nil || tt.status.ExternalAccess.Endpoint != "test:5432" {
+				t.Fatalf("SetExternalAccessStatus() status = %v, want endpoint test:5432", tt.status.ExternalAccess)
+			}
+			if changed := tt.status.ExternalAccess.LastChangedAt.Time.Equal(now); changed != tt.wantChangedAt {
+				t.Errorf("SetExternalAccessStatus() last changed at %s, want changed %v", tt.status.ExternalAccess.LastChangedAt, tt.wantChangedAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	errorUtil "github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultFinalSnapshotsConfigMapName is the config map the final snapshots of deleted resources are recorded in, in
+	// the namespace of the provider config map
+	DefaultFinalSnapshotsConfigMapName = "cloud-resource-final-snapshots"
+
+	// finalSnapshotTimeFormat is the format of the timestamp of a final snapshot name template
+	finalSnapshotTimeFormat = "20060102150405"
+)
+
+// invalidSnapshotNameChars are the characters replaced in a final snapshot name, snapshot identifiers only allow
+// letters, digits and single hyphens
+var invalidSnapshotNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// FinalSnapshotNameData is the data of a final snapshot name template, e.g. {{.Namespace}}-{{.Name}}-{{.Timestamp}}
+type FinalSnapshotNameData struct {
+	ClusterID string
+	Namespace string
+	Name      string
+	// Timestamp is the time the resource was deleted, so the name is the same on every reconcile
+	Timestamp string
+}
+
+// BuildFinalSnapshotName renders the final snapshot name template of a deleted resource, cut to n characters. The name
+// is lower cased and characters a snapshot identifier doesn't allow are replaced with hyphens
+func BuildFinalSnapshotName(nameTemplate string, clusterID string, inst metav1.Object, n int) (string, error) {
+	tmpl, err := template.New("finalSnapshotName").Option("missingkey=error").Parse(nameTemplate)
+	if err != nil {
+		return "", errorUtil.Wrapf(err, "failed to parse final snapshot name template %s", nameTemplate)
+	}
+	deletedAt := time.Now()
+	if ts := inst.GetDeletionTimestamp(); ts != nil {
+		deletedAt = ts.Time
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, FinalSnapshotNameData{
+		ClusterID: clusterID,
+		Namespace: inst.GetNamespace(),
+		Name:      inst.GetName(),
+		Timestamp: deletedAt.UTC().Format(finalSnapshotTimeFormat),
+	}); err != nil {
+		return "", errorUtil.Wrapf(err, "failed to render final snapshot name template %s", nameTemplate)
+	}
+	name := strings.Trim(invalidSnapshotNameChars.ReplaceAllString(strings.ToLower(buf.String()), "-"), "-")
+	if name == "" || name[0] < 'a' || name[0] > 'z' {
+		return "", errorUtil.Errorf("final snapshot name %q rendered from template %s must start with a letter", name, nameTemplate)
+	}
+	if len(name) > n {
+		name = strings.TrimRight(name[:n], "-")
+	}
+	return name, nil
+}
+
+// FinalSnapshotKey returns the key of the final snapshot of a resource in the final snapshots config map
+func FinalSnapshotKey(rt ResourceType, ns string, name string) string {
+	return fmt.Sprintf("%s.%s.%s", rt, ns, name)
+}
+
+// RecordFinalSnapshot records the identifier of the final snapshot of a deleted resource in the final snapshots config
+// map, so the resource can be restored from it once its cloud resource is gone. The record of an earlier resource with
+// the same name is replaced. Writes racing another write of the config map are retried against its latest version
+func RecordFinalSnapshot(ctx context.Context, c client.Client, rt ResourceType, inst metav1.Object, snapshotID string) error {
+	key := types.NamespacedName{Name: DefaultFinalSnapshotsConfigMapName, Namespace: DefaultConfigNamespace}
+	recordKey := FinalSnapshotKey(rt, inst.GetNamespace(), inst.GetName())
+	err := retry.OnError(retry.DefaultRetry, isConfigMapRace, func() error {
+		cm := &v1.ConfigMap{}
+		if err := c.Get(ctx, key, cm); err != nil {
+			if !k8serr.IsNotFound(err) {
+				return err
+			}
+			return c.Create(ctx, &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+				Data:       map[string]string{recordKey: snapshotID},
+			})
+		}
+		if cm.Data[recordKey] == snapshotID {
+			return nil
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[recordKey] = snapshotID
+		return c.Update(ctx, cm)
+	})
+	if err != nil {
+		return errorUtil.Wrapf(err, "failed to record final snapshot %s of %s %s", snapshotID, rt, inst.GetName())
+	}
+	return nil
+}
+
+// isConfigMapRace returns true if the write of a config map lost a race with another write of it
+func isConfigMapRace(err error) bool {
+	return k8serr.IsConflict(err) || k8serr.IsAlreadyExists(err)
+}
@@ -0,0 +1,101 @@
+package providers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestBuildFinalSnapshotName(t *testing.T) {
+	deletedAt := metav1.NewTime(time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC))
+	pg := &v1alpha1.Postgres{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "my_postgres",
+			Namespace:         "team",
+			DeletionTimestamp: &deletedAt,
+		},
+	}
+	tests := []struct {
+		name     string
+		template string
+		n        int
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "test template is rendered with the deletion time",
+			template: "{{.ClusterID}}-{{.Namespace}}-{{.Name}}-{{.Timestamp}}",
+			n:        255,
+			want:     "cluster-team-my-postgres-20261016093000",
+		},
+		{
+			name:     "test name is cut without a trailing hyphen",
+			template: "final-{{.Namespace}}-{{.Name}}",
+			n:        11,
+			want:     "final-team",
+		},
+		{
+			name:     "test name must start with a letter",
+			template: "{{.Timestamp}}-{{.Name}}",
+			n:        255,
+			wantErr:  true,
+		},
+		{
+			name:     "test unknown fields fail",
+			template: "{{.Region}}-{{.Name}}",
+			n:        255,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildFinalSnapshotName(tt.template, "cluster", pg, tt.n)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BuildFinalSnapshotName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("BuildFinalSnapshotName() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordFinalSnapshot(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	c := fake.NewFakeClientWithScheme(scheme)
+	ctx := context.TODO()
+	pg := &v1alpha1.Postgres{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "team"}}
+	redis := &v1alpha1.Redis{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "team"}}
+
+	if err := RecordFinalSnapshot(ctx, c, PostgresResourceType, pg, "first"); err != nil {
+		t.Fatal("RecordFinalSnapshot() failed", err)
+	}
+	if err := RecordFinalSnapshot(ctx, c, RedisResourceType, redis, "cache"); err != nil {
+		t.Fatal("RecordFinalSnapshot() failed", err)
+	}
+	// a later resource with the same name replaces the record
+	if err := RecordFinalSnapshot(ctx, c, PostgresResourceType, pg, "second"); err != nil {
+		t.Fatal("RecordFinalSnapshot() failed", err)
+	}
+
+	cm := &v1.ConfigMap{}
+	if err := c.Get(ctx, types.NamespacedName{Name: DefaultFinalSnapshotsConfigMapName, Namespace: DefaultConfigNamespace}, cm); err != nil {
+		t.Fatal("failed to get final snapshots config map", err)
+	}
+	if got := cm.Data["postgres.team.test"]; got != "second" {
+		t.Errorf("RecordFinalSnapshot() recorded %s for the postgres, want second", got)
+	}
+	if got := cm.Data["redis.team.test"]; got != "cache" {
+		t.Errorf("RecordFinalSnapshot() recorded %s for the redis, want cache", got)
+	}
+}
@@ -0,0 +1,138 @@
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/internal/k8sutil"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providersdk"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	DefaultConfigMapName = "cloud-resources-gcp-strategies"
+
+	DefaultFinalizer = "cloud-resources-operator.integreatly.org/finalizers"
+
+	defaultReconcileTime = time.Second * 30
+)
+
+// DefaultConfigMapNamespace is the default namespace that Configmaps will be created in
+var DefaultConfigMapNamespace, _ = k8sutil.GetWatchNamespace()
+
+/*
+StrategyConfig provides the configuration necessary to create/modify/delete gcp resources
+Region -> region resources are created in, if no region is provided we default to cluster infrastructure
+ProjectID -> project resources are created in, if no project is provided we default to cluster infrastructure
+CreateStrategy -> maps to resource specific create parameters, uses as a source of truth to the state we expect the resource to be in
+DeleteStrategy -> maps to resource specific delete parameters
+*/
+type StrategyConfig struct {
+	Region         string          `json:"region"`
+	ProjectID      string          `json:"projectID"`
+	CreateStrategy json.RawMessage `json:"createStrategy"`
+	DeleteStrategy json.RawMessage `json:"deleteStrategy"`
+	// SupportedVersions maps the postgres versions a cr can request to cloud sql database versions, replacing the defaults
+	SupportedVersions providers.SupportedVersions `json:"supportedVersions,omitempty"`
+}
+
+//go:generate moq -out config_moq.go . ConfigManager
+type ConfigManager interface {
+	ReadStorageStrategy(ctx context.Context, rt providers.ResourceType, tier string) (*StrategyConfig, error)
+}
+
+var _ ConfigManager = (*ConfigMapConfigManager)(nil)
+
+type ConfigMapConfigManager struct {
+	configMapName      string
+	configMapNamespace string
+	client             client.Client
+}
+
+func NewConfigMapConfigManager(cm string, namespace string, client client.Client) *ConfigMapConfigManager {
+	if cm == "" {
+		cm = DefaultConfigMapName
+	}
+	if namespace == "" {
+		namespace = DefaultConfigMapNamespace
+	}
+	return &ConfigMapConfigManager{
+		configMapName:      cm,
+		configMapNamespace: namespace,
+		client:             client,
+	}
+}
+
+func NewDefaultConfigMapConfigManager(client client.Client) *ConfigMapConfigManager {
+	return NewConfigMapConfigManager(DefaultConfigMapName, DefaultConfigMapNamespace, client)
+}
+
+func (m *ConfigMapConfigManager) ReadStorageStrategy(ctx context.Context, rt providers.ResourceType, tier string) (*StrategyConfig, error) {
+	stratCfg := &StrategyConfig{}
+	key := types.NamespacedName{Name: m.configMapName, Namespace: m.configMapNamespace}
+	if err := providersdk.ReadStrategy(ctx, m.client, "gcp", key, BuildDefaultConfigMap(m.configMapName, m.configMapNamespace), rt, tier, stratCfg); err != nil {
+		return nil, err
+	}
+	return stratCfg, nil
+}
+
+func BuildDefaultConfigMap(name, namespace string) *v1.ConfigMap {
+	return &v1.ConfigMap{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Data: map[string]string{
+			"postgres": "{\"development\": { \"region\": \"\", \"projectID\": \"\", \"createStrategy\": {}, \"deleteStrategy\": {} }, \"production\": { \"region\": \"\", \"projectID\": \"\", \"createStrategy\": {}, \"deleteStrategy\": {} }}",
+		},
+	}
+}
+
+// setStrategyDefaults sets the project and region of the strategy to those of the cluster when they're not set
+func setStrategyDefaults(ctx context.Context, c client.Client, strategy *StrategyConfig) error {
+	if strategy.ProjectID != "" && strategy.Region != "" {
+		return nil
+	}
+	platform, err := resources.GetGCPPlatformStatus(ctx, c)
+	if err != nil {
+		return errorUtil.Wrap(err, "failed to retrieve default project and region from cluster")
+	}
+	if strategy.ProjectID == "" {
+		strategy.ProjectID = platform.ProjectID
+	}
+	if strategy.Region == "" {
+		strategy.Region = platform.Region
+	}
+	if strategy.ProjectID == "" || strategy.Region == "" {
+		return errorUtil.New("failed to retrieve default project and region from cluster, they are not defined")
+	}
+	return nil
+}
+
+// buildInfraNameFromObject builds a name unique to the cluster for the gcp resource of a cr
+func buildInfraNameFromObject(ctx context.Context, c client.Client, om controllerruntime.ObjectMeta, n int) (string, error) {
+	clusterID, err := resources.GetClusterID(ctx, c)
+	if err != nil {
+		return "", errorUtil.Wrap(err, "failed to retrieve cluster identifier")
+	}
+	// instance names may only contain lowercase letters, numbers and hyphens
+	return strings.ToLower(resources.ShortenString(fmt.Sprintf("%s-%s-%s", clusterID, om.Namespace, om.Name), n)), nil
+}
+
+// buildClusterNetwork returns the vpc network the cluster was installed into by the installer
+func buildClusterNetwork(ctx context.Context, c client.Client, projectID string) (string, error) {
+	clusterID, err := resources.GetClusterID(ctx, c)
+	if err != nil {
+		return "", errorUtil.Wrap(err, "failed to retrieve cluster identifier")
+	}
+	return fmt.Sprintf("projects/%s/global/networks/%s-network", projectID, clusterID), nil
+}
@@ -0,0 +1,123 @@
+package gcp
+
+import (
+	"context"
+	"testing"
+
+	configv1 "github.com/integr8ly/cloud-resource-operator/apis/config/v1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	corev1 "k8s.io/api/core/v1"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestConfigMapConfigManager_ReadStorageStrategy(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	tests := []struct {
+		name        string
+		client      client.Client
+		tier        string
+		wantProject string
+		wantErr     bool
+	}{
+		{
+			name:   "test default strategy is used when the config map does not exist",
+			client: fake.NewFakeClientWithScheme(scheme),
+			tier:   "production",
+		},
+		{
+			name: "test strategy is read from the config map",
+			client: fake.NewFakeClientWithScheme(scheme, &corev1.ConfigMap{
+				ObjectMeta: controllerruntime.ObjectMeta{
+					Name:      DefaultConfigMapName,
+					Namespace: "test",
+				},
+				Data: map[string]string{
+					"postgres": `{"production": {"projectID": "other-project", "createStrategy": {"settings": {"tier": "db-custom-2-7680"}}}}`,
+				},
+			}),
+			tier:        "production",
+			wantProject: "other-project",
+		},
+		{
+			name:    "test error when the tier is not defined",
+			client:  fake.NewFakeClientWithScheme(scheme),
+			tier:    "unknown",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewConfigMapConfigManager(DefaultConfigMapName, "test", tt.client)
+			got, err := m.ReadStorageStrategy(context.TODO(), providers.PostgresResourceType, tt.tier)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadStorageStrategy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.ProjectID != tt.wantProject {
+				t.Errorf("ReadStorageStrategy() project = %v, want %v", got.ProjectID, tt.wantProject)
+			}
+		})
+	}
+}
+
+func TestSetStrategyDefaults(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	tests := []struct {
+		name        string
+		client      client.Client
+		strategy    *StrategyConfig
+		wantProject string
+		wantRegion  string
+		wantErr     bool
+	}{
+		{
+			name:        "test project and region default to the cluster",
+			client:      fake.NewFakeClientWithScheme(scheme, buildTestInfrastructure()),
+			strategy:    &StrategyConfig{},
+			wantProject: testProjectID,
+			wantRegion:  testRegion,
+		},
+		{
+			name:        "test strategy values are kept",
+			client:      fake.NewFakeClientWithScheme(scheme, buildTestInfrastructure()),
+			strategy:    &StrategyConfig{ProjectID: "other-project"},
+			wantProject: "other-project",
+			wantRegion:  testRegion,
+		},
+		{
+			name: "test error when the cluster is not on gcp",
+			client: fake.NewFakeClientWithScheme(scheme, &configv1.Infrastructure{
+				ObjectMeta: controllerruntime.ObjectMeta{Name: "cluster"},
+				Status: configv1.InfrastructureStatus{
+					PlatformStatus: &configv1.PlatformStatus{Type: configv1.AWSPlatformType},
+				},
+			}),
+			strategy: &StrategyConfig{},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := setStrategyDefaults(context.TODO(), tt.client, tt.strategy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("setStrategyDefaults() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.strategy.ProjectID != tt.wantProject || tt.strategy.Region != tt.wantRegion {
+				t.Errorf("setStrategyDefaults() = %s %s, want %s %s", tt.strategy.ProjectID, tt.strategy.Region, tt.wantProject, tt.wantRegion)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/conformance"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providersdk"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testConformanceNamespace = "test-conformance"
+	testResizedTier          = "db-custom-2-7680"
+
+	sqlInstanceStatePendingCreate = "PENDING_CREATE"
+	sqlInstanceStatePendingDelete = "PENDING_DELETE"
+)
+
+// conformanceCloud is the gcp project the conformance scenarios run against. Like in cloud sql, instances are created
+// and deleted asynchronously and patches are applied by an operation running in the background, all of which happens
+// when the cloud settles
+type conformanceCloud struct {
+	instances map[string]*DatabaseInstance
+	patches   map[string][]*DatabaseInstance
+	addresses int
+}
+
+func newConformanceCloud() *conformanceCloud {
+	return &conformanceCloud{
+		instances: map[string]*DatabaseInstance{},
+		patches:   map[string][]*DatabaseInstance{},
+	}
+}
+
+// settle completes the creations, deletions and patches of the instances of the cloud
+func (c *conformanceCloud) settle() {
+	for name, instance := range c.instances {
+		switch instance.State {
+		case sqlInstanceStatePendingDelete:
+			delete(c.instances, name)
+			continue
+		case sqlInstanceStatePendingCreate:
+			instance.State = sqlInstanceStateRunnable
+		}
+		for _, patch := range c.patches[name] {
+			applyInstancePatch(instance, patch)
+		}
+		delete(c.patches, name)
+	}
+}
+
+func applyInstancePatch(instance *DatabaseInstance, patch *DatabaseInstance) {
+	if patch.Settings == nil {
+		return
+	}
+	if patch.Settings.Tier != "" {
+		instance.Settings.Tier = patch.Settings.Tier
+	}
+	if patch.Settings.AvailabilityType != "" {
+		instance.Settings.AvailabilityType = patch.Settings.AvailabilityType
+	}
+	if patch.Settings.DataDiskSizeGb != 0 {
+		instance.Settings.DataDiskSizeGb = patch.Settings.DataDiskSizeGb
+	}
+	if patch.Settings.DeletionProtectionEnabled != nil {
+		instance.Settings.DeletionProtectionEnabled = patch.Settings.DeletionProtectionEnabled
+	}
+	if patch.Settings.UserLabels != nil {
+		instance.Settings.UserLabels = patch.Settings.UserLabels
+	}
+}
+
+// sqlAdmin returns a mocked cloud sql admin client keeping its instances in the cloud
+func (c *conformanceCloud) sqlAdmin() *SQLAdminServiceMock {
+	return &SQLAdminServiceMock{
+		GetInstanceFunc: func(ctx context.Context, project string, name string) (*DatabaseInstance, error) {
+			instance, ok := c.instances[name]
+			if !ok {
+				return nil, buildNotFoundError()
+			}
+			return instance, nil
+		},
+		InsertInstanceFunc: func(ctx context.Context, project string, instance *DatabaseInstance) error {
+			if _, ok := c.instances[instance.Name]; ok {
+				return fmt.Errorf("cloud sql instance %s already exists", instance.Name)
+			}
+			settings := *instance.Settings
+			c.addresses++
+			c.instances[instance.Name] = &DatabaseInstance{
+				Name:            instance.Name,
+				Project:         project,
+				Region:          instance.Region,
+				DatabaseVersion: instance.DatabaseVersion,
+				State:           sqlInstanceStatePendingCreate,
+				Settings:        &settings,
+				IPAddresses: []*IPMapping{
+					{IPAddress: fmt.Sprintf("10.0.0.%d", c.addresses), Type: sqlIPAddressTypePrivate},
+				},
+			}
+			return nil
+		},
+		PatchInstanceFunc: func(ctx context.Context, project string, name string, instance *DatabaseInstance) error {
+			if _, ok := c.instances[name]; !ok {
+				return buildNotFoundError()
+			}
+			c.patches[name] = append(c.patches[name], instance)
+			return nil
+		},
+		DeleteInstanceFunc: func(ctx context.Context, project string, name string) error {
+			instance, ok := c.instances[name]
+			if !ok {
+				return buildNotFoundError()
+			}
+			instance.State = sqlInstanceStatePendingDelete
+			return nil
+		},
+	}
+}
+
+// conformancePostgresProvider reconciles cloud sql instances of the provider against the cloud, in place of the cloud
+// sql admin client ReconcilePostgres and DeletePostgres create from the provider credentials
+type conformancePostgresProvider struct {
+	*PostgresProvider
+	cloud *conformanceCloud
+}
+
+func (p *conformancePostgresProvider) ReconcilePostgres(ctx context.Context, pg *v1alpha1.Postgres) (*providers.PostgresInstance, croType.StatusMessage, error) {
+	if err := resources.CreateFinalizer(ctx, p.Client, pg, DefaultFinalizer); err != nil {
+		return nil, "failed to set finalizer", err
+	}
+	instanceCfg, strategyConfig, err := p.getCloudSQLConfig(ctx, pg)
+	if err != nil {
+		return nil, "failed to retrieve gcp cloud sql config for instance", err
+	}
+	if _, msg, err := providersdk.EnsureCredentialSecret(ctx, p.Client, buildDefaultCloudSQLSecret(pg), map[string]resources.SecretValueGenerator{
+		defaultPostgresUserKey:     resources.StaticSecretValue(defaultGCPPostgresUser),
+		defaultPostgresPasswordKey: resources.GeneratePassword,
+	}); err != nil {
+		return nil, msg, err
+	}
+	return p.reconcileCloudSQLInstance(ctx, pg, p.cloud.sqlAdmin(), strategyConfig, instanceCfg)
+}
+
+func (p *conformancePostgresProvider) DeletePostgres(ctx context.Context, pg *v1alpha1.Postgres) (croType.StatusMessage, error) {
+	instanceCfg, strategyConfig, err := p.getCloudSQLConfig(ctx, pg)
+	if err != nil {
+		return "failed to retrieve gcp cloud sql config", err
+	}
+	return p.deleteCloudSQLInstance(ctx, pg, p.cloud.sqlAdmin(), strategyConfig, instanceCfg)
+}
+
+func TestGCPPostgresProvider_Conformance(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	c := fake.NewFakeClientWithScheme(scheme, buildTestInfrastructure())
+	cloud := newConformanceCloud()
+	p := &PostgresProvider{
+		Client: c,
+		Logger: logrus.NewEntry(logrus.StandardLogger()),
+		ConfigManager: &ConfigManagerMock{
+			ReadStorageStrategyFunc: func(ctx context.Context, rt providers.ResourceType, tier string) (*StrategyConfig, error) {
+				return &StrategyConfig{
+					Region:         testRegion,
+					ProjectID:      testProjectID,
+					CreateStrategy: json.RawMessage("{}"),
+					DeleteStrategy: json.RawMessage("{}"),
+				}, nil
+			},
+		},
+	}
+	h := conformance.Harness{
+		Client: c,
+		Settle: func(t *testing.T, obj conformance.Object) {
+			cloud.settle()
+		},
+		Resize: func(t *testing.T, obj conformance.Object) {
+			obj.(*v1alpha1.Postgres).Spec.ProviderConfig = &runtime.RawExtension{Raw: []byte(fmt.Sprintf(`{"settings": {"tier": %q}}`, testResizedTier))}
+		},
+		Resized: func(t *testing.T, obj conformance.Object) bool {
+			instance := cloud.instances[obj.GetAnnotations()[ResourceIdentifierAnnotation]]
+			return instance != nil && instance.Settings.Tier == testResizedTier
+		},
+		Gone: func(t *testing.T, obj conformance.Object) bool {
+			if _, ok := cloud.instances[obj.GetAnnotations()[ResourceIdentifierAnnotation]]; ok {
+				return false
+			}
+			err := c.Get(context.TODO(), types.NamespacedName{Name: obj.GetName() + defaultCredSecSuffix, Namespace: obj.GetNamespace()}, &corev1.Secret{})
+			return k8serr.IsNotFound(err)
+		},
+		Unsupported: map[conformance.Scenario]string{
+			conformance.ScenarioRotate: "the gcp provider doesn't rotate the credentials of cloud sql instances",
+		},
+	}
+	conformance.RunPostgres(t, h, &conformancePostgresProvider{PostgresProvider: p, cloud: cloud}, func(t *testing.T, name string) *v1alpha1.Postgres {
+		pg := &v1alpha1.Postgres{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testConformanceNamespace}}
+		if err := c.Create(context.TODO(), pg); err != nil {
+			t.Fatalf("failed to create cr %s: %v", name, err)
+		}
+		return pg
+	})
+}
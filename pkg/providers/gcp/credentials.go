@@ -0,0 +1,179 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/internal/k8sutil"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	v1 "github.com/openshift/cloud-credential-operator/pkg/apis/cloudcredential/v1"
+	errorUtil "github.com/pkg/errors"
+	v12 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	defaultProviderCredentialName = "cloud-resources-gcp-credentials"
+
+	// #nosec G101
+	defaultCredentialsServiceAccountKeyName = "service_account.json"
+
+	// AuthModeCredentialMinter is the auth mode of the provider using credentials minted by the cloud credential operator
+	AuthModeCredentialMinter = "credential-minter"
+	// AuthModeWorkloadIdentity is the auth mode of the provider using workload identity federation
+	AuthModeWorkloadIdentity = "workload-identity"
+)
+
+var (
+	operatorRoles = []string{
+		"roles/cloudsql.admin",
+	}
+	timeOut = time.Minute * 5
+)
+
+type Credentials struct {
+	ServiceAccountID   string
+	ServiceAccountJSON []byte
+}
+
+//go:generate moq -out credentials_moq.go . CredentialManager
+type CredentialManager interface {
+	ReconcileProviderCredentials(ctx context.Context, ns string) (*Credentials, error)
+}
+
+// NewCredentialManager returns the workload identity credential manager if the workload identity credentials secret
+// exists in the operator namespace, otherwise the credential minter credential manager
+func NewCredentialManager(client client.Client) (CredentialManager, error) {
+	ns, err := k8sutil.GetOperatorNamespace()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = getWorkloadIdentityCredentialsSecret(context.TODO(), client, ns); err != nil {
+		if errors.IsNotFound(err) {
+			return NewCredentialMinterCredentialManager(client), nil
+		}
+		return nil, errorUtil.Wrapf(err, "failed to get gcp workload identity credentials secret %s", defaultWorkloadIdentityCredentialSecretName)
+	}
+	return NewWorkloadIdentityCredentialManager(client, ns), nil
+}
+
+// GetAuthMode returns the auth mode the provider uses with the credentials in the operator namespace, the workload
+// identity credentials are checked so a misconfiguration is reported at startup rather than on the first reconcile
+func GetAuthMode(ctx context.Context, client client.Client, ns string) (string, error) {
+	if _, err := getWorkloadIdentityCredentialsSecret(ctx, client, ns); err != nil {
+		if errors.IsNotFound(err) {
+			return AuthModeCredentialMinter, nil
+		}
+		return "", errorUtil.Wrapf(err, "failed to get gcp workload identity credentials secret %s", defaultWorkloadIdentityCredentialSecretName)
+	}
+	if _, err := NewWorkloadIdentityCredentialManager(client, ns).ReconcileProviderCredentials(ctx, ns); err != nil {
+		return "", err
+	}
+	return AuthModeWorkloadIdentity, nil
+}
+
+var _ CredentialManager = (*CredentialMinterCredentialManager)(nil)
+
+// CredentialMinterCredentialManager Implementation of CredentialManager using the openshift cloud credential minter
+type CredentialMinterCredentialManager struct {
+	ProviderCredentialName string
+	Client                 client.Client
+}
+
+func NewCredentialMinterCredentialManager(client client.Client) *CredentialMinterCredentialManager {
+	return &CredentialMinterCredentialManager{
+		ProviderCredentialName: defaultProviderCredentialName,
+		Client:                 client,
+	}
+}
+
+// ReconcileProviderCredentials Ensure the credentials the GCP provider requires are available
+func (m *CredentialMinterCredentialManager) ReconcileProviderCredentials(ctx context.Context, ns string) (*Credentials, error) {
+	cr, err := m.reconcileCredentialRequest(ctx, m.ProviderCredentialName, ns, operatorRoles)
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to reconcile gcp credential request %s", m.ProviderCredentialName)
+	}
+	err = wait.PollImmediate(time.Second*5, timeOut, func() (done bool, err error) {
+		if err = m.Client.Get(ctx, types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, cr); err != nil {
+			if errors.IsNotFound(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		return cr.Status.Provisioned, nil
+	})
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "timed out waiting for credential request to provision")
+	}
+
+	codec, err := v1.NewCodec()
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to create credentials codec")
+	}
+	gcpProvStatus := &v1.GCPProviderStatus{}
+	if err = codec.DecodeProviderSpec(cr.Status.ProviderStatus, gcpProvStatus); err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to decode credentials request %s", cr.Name)
+	}
+	serviceAccountJSON, err := m.reconcileGCPCredentials(ctx, cr)
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to reconcile gcp credentials from credential request %s", cr.Name)
+	}
+	return &Credentials{
+		ServiceAccountID:   gcpProvStatus.ServiceAccountID,
+		ServiceAccountJSON: serviceAccountJSON,
+	}, nil
+}
+
+func (m *CredentialMinterCredentialManager) reconcileCredentialRequest(ctx context.Context, name string, ns string, roles []string) (*v1.CredentialsRequest, error) {
+	codec, err := v1.NewCodec()
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to create provider codec")
+	}
+	providerSpec, err := codec.EncodeProviderSpec(&v1.GCPProviderSpec{
+		TypeMeta: controllerruntime.TypeMeta{
+			Kind: "GCPProviderSpec",
+		},
+		PredefinedRoles: roles,
+	})
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to encode provider spec")
+	}
+	cr := &v1.CredentialsRequest{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+		},
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, m.Client, cr, func() error {
+		cr.Spec.ProviderSpec = providerSpec
+		cr.Spec.SecretRef = v12.ObjectReference{
+			Name:      name,
+			Namespace: ns,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to reconcile credential request %s in namespace %s", cr.Name, cr.Namespace)
+	}
+	return cr, nil
+}
+
+func (m *CredentialMinterCredentialManager) reconcileGCPCredentials(ctx context.Context, cr *v1.CredentialsRequest) ([]byte, error) {
+	sec := &v12.Secret{}
+	err := m.Client.Get(ctx, types.NamespacedName{Name: cr.Spec.SecretRef.Name, Namespace: cr.Spec.SecretRef.Namespace}, sec)
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to get gcp credentials secret %s", cr.Spec.SecretRef.Name)
+	}
+	serviceAccountJSON := sec.Data[defaultCredentialsServiceAccountKeyName]
+	if len(serviceAccountJSON) == 0 {
+		return nil, errorUtil.New(fmt.Sprintf("gcp service account key is undefined in secret %s", sec.Name))
+	}
+	resources.RegisterSecretValue(string(serviceAccountJSON))
+	return serviceAccountJSON, nil
+}
@@ -0,0 +1,95 @@
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	errorUtil "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultWorkloadIdentityCredentialSecretName = "gcp-workload-identity-credentials"
+
+	externalAccountCredentialsType = "external_account"
+)
+
+var _ CredentialManager = (*WorkloadIdentityCredentialManager)(nil)
+
+// WorkloadIdentityCredentialManager Implementation of CredentialManager for clusters using GCP workload identity
+// federation, the credentials exchange the service account token mounted in the operator pod for a gcp access token
+// and are refreshed from the mounted token when they expire
+type WorkloadIdentityCredentialManager struct {
+	OperatorNamespace string
+	Client            client.Client
+}
+
+func NewWorkloadIdentityCredentialManager(client client.Client, ns string) *WorkloadIdentityCredentialManager {
+	return &WorkloadIdentityCredentialManager{
+		OperatorNamespace: ns,
+		Client:            client,
+	}
+}
+
+// externalAccountCredentials is the part of an external account credential configuration checked before use
+type externalAccountCredentials struct {
+	Type                           string `json:"type"`
+	Audience                       string `json:"audience"`
+	ServiceAccountImpersonationURL string `json:"service_account_impersonation_url"`
+	CredentialSource               struct {
+		File string `json:"file"`
+	} `json:"credential_source"`
+}
+
+// ReconcileProviderCredentials Ensure the credentials the GCP provider requires are available
+func (m *WorkloadIdentityCredentialManager) ReconcileProviderCredentials(ctx context.Context, _ string) (*Credentials, error) {
+	secret, err := getWorkloadIdentityCredentialsSecret(ctx, m.Client, m.OperatorNamespace)
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to get gcp workload identity credentials secret %s", defaultWorkloadIdentityCredentialSecretName)
+	}
+	serviceAccountJSON := secret.Data[defaultCredentialsServiceAccountKeyName]
+	if len(serviceAccountJSON) == 0 {
+		return nil, errorUtil.New(fmt.Sprintf("%s key is undefined in secret %s", defaultCredentialsServiceAccountKeyName, secret.Name))
+	}
+	creds := &externalAccountCredentials{}
+	if err = json.Unmarshal(serviceAccountJSON, creds); err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to parse %s key of secret %s", defaultCredentialsServiceAccountKeyName, secret.Name)
+	}
+	if creds.Type != externalAccountCredentialsType {
+		return nil, errorUtil.New(fmt.Sprintf("credentials in secret %s are of type %q, expected %q", secret.Name, creds.Type, externalAccountCredentialsType))
+	}
+	if creds.Audience == "" {
+		return nil, errorUtil.New(fmt.Sprintf("credentials in secret %s have no audience", secret.Name))
+	}
+	if creds.CredentialSource.File == "" {
+		return nil, errorUtil.New(fmt.Sprintf("credentials in secret %s have no credential source file", secret.Name))
+	}
+	if _, err = os.Stat(creds.CredentialSource.File); err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to read service account token %s of the credentials in secret %s", creds.CredentialSource.File, secret.Name)
+	}
+	return &Credentials{
+		ServiceAccountID:   serviceAccountFromImpersonationURL(creds.ServiceAccountImpersonationURL),
+		ServiceAccountJSON: serviceAccountJSON,
+	}, nil
+}
+
+// serviceAccountFromImpersonationURL returns the email of the service account impersonated by the credentials, e.g.
+// operator@project.iam.gserviceaccount.com from .../serviceAccounts/operator@project.iam.gserviceaccount.com:generateAccessToken
+func serviceAccountFromImpersonationURL(url string) string {
+	i := strings.LastIndex(url, "/serviceAccounts/")
+	if i < 0 {
+		return ""
+	}
+	return strings.TrimSuffix(url[i+len("/serviceAccounts/"):], ":generateAccessToken")
+}
+
+func getWorkloadIdentityCredentialsSecret(ctx context.Context, client client.Client, ns string) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	err := client.Get(ctx, types.NamespacedName{Name: defaultWorkloadIdentityCredentialSecretName, Namespace: ns}, secret)
+	return secret, err
+}
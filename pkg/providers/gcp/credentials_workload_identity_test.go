@@ -0,0 +1,141 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func buildTestWorkloadIdentitySecret(serviceAccountJSON string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name:      defaultWorkloadIdentityCredentialSecretName,
+			Namespace: "test",
+		},
+		Data: map[string][]byte{
+			defaultCredentialsServiceAccountKeyName: []byte(serviceAccountJSON),
+		},
+	}
+}
+
+func buildTestExternalAccountJSON(tokenFile string) string {
+	return fmt.Sprintf(`{
+  "type": "external_account",
+  "audience": "//iam.googleapis.com/projects/123/locations/global/workloadIdentityPools/pool/providers/provider",
+  "subject_token_type": "urn:ietf:params:oauth:token-type:jwt",
+  "token_url": "https://sts.googleapis.com/v1/token",
+  "service_account_impersonation_url": "https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/cro@project.iam.gserviceaccount.com:generateAccessToken",
+  "credential_source": {"file": %q}
+}`, tokenFile)
+}
+
+func TestWorkloadIdentityCredentialManager_ReconcileProviderCredentials(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err = os.WriteFile(tokenFile, []byte("token"), 0600); err != nil {
+		t.Fatal("failed to write token file", err)
+	}
+	tests := []struct {
+		name                 string
+		secret               *corev1.Secret
+		wantServiceAccountID string
+		wantErr              bool
+	}{
+		{
+			name:                 "test external account credentials are returned",
+			secret:               buildTestWorkloadIdentitySecret(buildTestExternalAccountJSON(tokenFile)),
+			wantServiceAccountID: "cro@project.iam.gserviceaccount.com",
+		},
+		{
+			name:    "test error on a missing token file",
+			secret:  buildTestWorkloadIdentitySecret(buildTestExternalAccountJSON(filepath.Join(t.TempDir(), "missing"))),
+			wantErr: true,
+		},
+		{
+			name:    "test error on a service account key",
+			secret:  buildTestWorkloadIdentitySecret(`{"type": "service_account"}`),
+			wantErr: true,
+		},
+		{
+			name:    "test error on an undefined key",
+			secret:  buildTestWorkloadIdentitySecret(""),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewWorkloadIdentityCredentialManager(fake.NewFakeClientWithScheme(scheme, tt.secret), "test")
+			got, err := m.ReconcileProviderCredentials(context.TODO(), "test")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReconcileProviderCredentials() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.ServiceAccountID != tt.wantServiceAccountID {
+				t.Errorf("ReconcileProviderCredentials() service account = %s, want %s", got.ServiceAccountID, tt.wantServiceAccountID)
+			}
+			if len(got.ServiceAccountJSON) == 0 {
+				t.Errorf("ReconcileProviderCredentials() expected the credential configuration")
+			}
+		})
+	}
+}
+
+func TestGetAuthMode(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err = os.WriteFile(tokenFile, []byte("token"), 0600); err != nil {
+		t.Fatal("failed to write token file", err)
+	}
+	tests := []struct {
+		name     string
+		existing []*corev1.Secret
+		want     string
+		wantErr  bool
+	}{
+		{
+			name: "test credential minter without the workload identity secret",
+			want: AuthModeCredentialMinter,
+		},
+		{
+			name:     "test workload identity with the workload identity secret",
+			existing: []*corev1.Secret{buildTestWorkloadIdentitySecret(buildTestExternalAccountJSON(tokenFile))},
+			want:     AuthModeWorkloadIdentity,
+		},
+		{
+			name:     "test error on invalid workload identity credentials",
+			existing: []*corev1.Secret{buildTestWorkloadIdentitySecret(`{"type": "service_account"}`)},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme)
+			for _, s := range tt.existing {
+				if err := c.Create(context.TODO(), s); err != nil {
+					t.Fatal("failed to create secret", err)
+				}
+			}
+			got, err := GetAuthMode(context.TODO(), c, "test")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetAuthMode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetAuthMode() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
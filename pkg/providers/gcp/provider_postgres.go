@@ -0,0 +1,509 @@
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/annotations"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providersdk"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultGCPAvailabilityType       = "REGIONAL"
+	defaultGCPDataDiskSizeGb         = 20
+	defaultGCPDatabaseVersion        = "POSTGRES_13"
+	defaultGCPIdentifierLength       = 40
+	defaultGCPPostgresDatabase       = "postgres"
+	defaultGCPPostgresPort           = 5432
+	defaultGCPPostgresUser           = "postgres"
+	defaultGCPStorageAutoResizeLimit = 100
+	defaultGCPTier                   = "db-custom-1-3840"
+	defaultCredSecSuffix             = "-gcp-cloudsql-credentials"
+	defaultPostgresPasswordKey       = "password"
+	defaultPostgresUserKey           = "user"
+	postgresProviderName             = "gcp-cloudsql"
+
+	// ResourceIdentifierAnnotation is set to the name of the cloud sql instance once it has been requested
+	ResourceIdentifierAnnotation = "resourceIdentifier"
+
+	// CloudSQLInstanceCreatedEventReason is recorded on a postgres when its cloud sql instance is requested
+	CloudSQLInstanceCreatedEventReason = "CloudSQLInstanceCreated"
+	// CloudSQLInstanceDeletedEventReason is recorded on a postgres when the deletion of its cloud sql instance starts
+	CloudSQLInstanceDeletedEventReason = "CloudSQLInstanceDeleted"
+)
+
+// defaultSupportedPostgresVersions maps the postgres versions a cr can request to cloud sql database versions
+var defaultSupportedPostgresVersions = providers.SupportedVersions{
+	"11": "POSTGRES_11",
+	"12": "POSTGRES_12",
+	"13": defaultGCPDatabaseVersion,
+	"14": "POSTGRES_14",
+}
+
+var _ providers.PostgresProvider = (*PostgresProvider)(nil)
+
+type PostgresProvider struct {
+	Client            client.Client
+	Logger            *logrus.Entry
+	CredentialManager CredentialManager
+	ConfigManager     ConfigManager
+}
+
+func NewGCPPostgresProvider(client client.Client, logger *logrus.Entry) (*PostgresProvider, error) {
+	cm, err := NewCredentialManager(client)
+	if err != nil {
+		return nil, err
+	}
+	return &PostgresProvider{
+		Client:            client,
+		Logger:            logger.WithFields(logrus.Fields{"provider": postgresProviderName}),
+		CredentialManager: cm,
+		ConfigManager:     NewDefaultConfigMapConfigManager(client),
+	}, nil
+}
+
+func (p *PostgresProvider) GetName() string {
+	return postgresProviderName
+}
+
+func (p *PostgresProvider) SupportsStrategy(d string) bool {
+	return d == providers.GCPDeploymentStrategy
+}
+
+func (p *PostgresProvider) GetReconcileTime(pg *v1alpha1.Postgres) time.Duration {
+	if pg.Status.Phase != croType.PhaseComplete {
+		return time.Second * 60
+	}
+	return resources.GetForcedReconcileTimeOrDefault(defaultReconcileTime)
+}
+
+// ReconcilePostgres creates a Cloud SQL instance from strategy config
+func (p *PostgresProvider) ReconcilePostgres(ctx context.Context, pg *v1alpha1.Postgres) (*providers.PostgresInstance, croType.StatusMessage, error) {
+	logger := p.Logger.WithField("action", "ReconcilePostgres")
+	logger.Infof("reconciling postgres %s", pg.Name)
+
+	// handle provider-specific finalizer
+	if err := resources.CreateFinalizer(ctx, p.Client, pg, DefaultFinalizer); err != nil {
+		return nil, "failed to set finalizer", err
+	}
+
+	// info about the cloud sql instance to be created
+	instanceCfg, strategyConfig, err := p.getCloudSQLConfig(ctx, pg)
+	if err != nil {
+		msg, err := providersdk.Failed(err, "failed to retrieve gcp cloud sql config for instance")
+		return nil, msg, err
+	}
+	// a version requested by the cr replaces the database version of the strategy
+	databaseVersion, err := providers.ResolveVersion(pg.Spec.Version, strategyConfig.SupportedVersions, defaultSupportedPostgresVersions)
+	if err != nil {
+		msg := fmt.Sprintf("unsupported postgres version for instance %s: %v", pg.Name, err)
+		return nil, croType.StatusMessage(msg), errorUtil.Wrap(err, "failed to resolve postgres version")
+	}
+	if databaseVersion != "" {
+		instanceCfg.DatabaseVersion = databaseVersion
+	}
+
+	// create the credentials to be used by the gcp resource providers, not to be used by end-user
+	providerCreds, err := p.CredentialManager.ReconcileProviderCredentials(ctx, pg.Namespace)
+	if err != nil {
+		msg, err := providersdk.Failed(err, "failed to reconcile cloud sql credentials")
+		return nil, msg, err
+	}
+
+	// create credentials secret, the password is only generated once even if reconciles race to create it
+	sec := buildDefaultCloudSQLSecret(pg)
+	if _, msg, err := providersdk.EnsureCredentialSecret(ctx, p.Client, sec, map[string]resources.SecretValueGenerator{
+		defaultPostgresUserKey:     resources.StaticSecretValue(defaultGCPPostgresUser),
+		defaultPostgresPasswordKey: resources.GeneratePassword,
+	}); err != nil {
+		return nil, msg, err
+	}
+
+	sqlSvc, err := NewSQLAdminService(ctx, providerCreds)
+	if err != nil {
+		msg, err := providersdk.Failed(err, "failed to create gcp cloud sql admin client")
+		return nil, msg, err
+	}
+
+	return p.reconcileCloudSQLInstance(ctx, pg, sqlSvc, strategyConfig, instanceCfg)
+}
+
+func (p *PostgresProvider) reconcileCloudSQLInstance(ctx context.Context, cr *v1alpha1.Postgres, sqlSvc SQLAdminService, strategyConfig *StrategyConfig, instanceCfg *DatabaseInstance) (*providers.PostgresInstance, croType.StatusMessage, error) {
+	logger := p.Logger.WithField("action", "reconcileCloudSQLInstance")
+
+	// getting postgres user password from created secret
+	credSec := &v1.Secret{}
+	if err := p.Client.Get(ctx, types.NamespacedName{Name: cr.Name + defaultCredSecSuffix, Namespace: cr.Namespace}, credSec); err != nil {
+		msg, err := providersdk.Failed(err, "failed to retrieve cloud sql credential secret")
+		return nil, msg, err
+	}
+	postgresPass := string(credSec.Data[defaultPostgresPasswordKey])
+	if postgresPass == "" {
+		msg, err := providersdk.Failed(nil, "unable to retrieve cloud sql password")
+		return nil, msg, err
+	}
+
+	// verify and build cloud sql create config
+	if err := p.buildCloudSQLCreateStrategy(ctx, cr, strategyConfig, instanceCfg, postgresPass); err != nil {
+		msg, err := providersdk.Failed(err, "failed to build and verify gcp cloud sql instance configuration")
+		return nil, msg, err
+	}
+
+	foundInstance, err := sqlSvc.GetInstance(ctx, strategyConfig.ProjectID, instanceCfg.Name)
+	if err != nil && !isNotFound(err) {
+		msg, err := providersdk.Failed(err, "failed to get cloud sql instance %s", instanceCfg.Name)
+		return nil, msg, err
+	}
+
+	// create the cloud sql instance if it doesn't exist
+	if foundInstance == nil {
+		if annotations.Has(cr, ResourceIdentifierAnnotation) {
+			msg, err := providersdk.Failed(nil, "Postgres CR %s in %s namespace has %s annotation with value %s, but no corresponding Cloud SQL instance was found",
+				cr.Name, cr.Namespace, ResourceIdentifierAnnotation, cr.ObjectMeta.Annotations[ResourceIdentifierAnnotation])
+			return nil, msg, err
+		}
+		logger.Info("creating cloud sql instance")
+		if err := sqlSvc.InsertInstance(ctx, strategyConfig.ProjectID, instanceCfg); err != nil {
+			return nil, croType.StatusMessage(fmt.Sprintf("error creating cloud sql instance %s", err)), err
+		}
+		annotations.Add(cr, ResourceIdentifierAnnotation, instanceCfg.Name)
+		if err := p.Client.Update(ctx, cr); err != nil {
+			return nil, "failed to add annotation", err
+		}
+		providersdk.Event(cr, v1.EventTypeNormal, CloudSQLInstanceCreatedEventReason, "created cloud sql instance %s", instanceCfg.Name)
+		return nil, "started cloud sql provision", nil
+	}
+
+	// check cloud sql instance state
+	msg := fmt.Sprintf("found instance %s current state %s", foundInstance.Name, foundInstance.State)
+	if foundInstance.DatabaseVersion != "" && cr.Status.Version != foundInstance.DatabaseVersion {
+		cr.Status.Version = foundInstance.DatabaseVersion
+	}
+	if foundInstance.State == sqlInstanceStateFailed || foundInstance.State == sqlInstanceStateSuspended {
+		logger.Error(msg)
+		return nil, croType.StatusMessage(msg), errorUtil.New(msg)
+	}
+	if foundInstance.State != sqlInstanceStateRunnable {
+		logger.Infof(msg)
+		return nil, providersdk.InProgress("reconcileCloudSQLInstance() in progress, current gcp cloud sql state is %s", foundInstance.State), nil
+	}
+
+	// check if found instance and user strategy differs, and patch instance
+	if patch := buildCloudSQLUpdateStrategy(instanceCfg, foundInstance); patch != nil {
+		if err := sqlSvc.PatchInstance(ctx, strategyConfig.ProjectID, foundInstance.Name, patch); err != nil {
+			msg, err := providersdk.Failed(err, "error experienced trying to patch cloud sql instance: %s", foundInstance.Name)
+			return nil, msg, err
+		}
+		statusMsg := fmt.Sprintf("set pending modifications for cloud sql instance: %s", foundInstance.Name)
+		logger.Info(statusMsg)
+		return nil, croType.StatusMessage(statusMsg), nil
+	}
+
+	host := getInstanceHost(foundInstance)
+	if host == "" {
+		msg, err := providersdk.Failed(nil, "no ip address found for cloud sql instance %s", foundInstance.Name)
+		return nil, msg, err
+	}
+
+	msg = fmt.Sprintf("cloud sql instance %s is as expected", foundInstance.Name)
+	logger.Infof(msg)
+	providersdk.SetCloudResource(&cr.Status, foundInstance.Name, fmt.Sprintf("%s:%d", host, defaultGCPPostgresPort), foundInstance.Region)
+	pdd := &providers.PostgresDeploymentDetails{
+		Username: defaultGCPPostgresUser,
+		Password: postgresPass,
+		Host:     host,
+		Database: defaultGCPPostgresDatabase,
+		Port:     defaultGCPPostgresPort,
+	}
+	// return secret information
+	return &providers.PostgresInstance{DeploymentDetails: pdd}, croType.StatusMessage(fmt.Sprintf("%s, gcp cloud sql state is %s", msg, foundInstance.State)), nil
+}
+
+// DeletePostgres removes the Cloud SQL instance, its credential secret and the finalizer
+func (p *PostgresProvider) DeletePostgres(ctx context.Context, r *v1alpha1.Postgres) (croType.StatusMessage, error) {
+	logger := p.Logger.WithField("action", "DeletePostgres")
+	logger.Infof("reconciling postgres %s", r.Name)
+
+	// resolve postgres information for postgres created by provider
+	instanceCfg, strategyConfig, err := p.getCloudSQLConfig(ctx, r)
+	if err != nil {
+		return "failed to retrieve gcp cloud sql config", err
+	}
+
+	// get provider gcp creds so the postgres instance can be deleted
+	providerCreds, err := p.CredentialManager.ReconcileProviderCredentials(ctx, r.Namespace)
+	if err != nil {
+		return providersdk.Failed(err, "failed to reconcile gcp provider credentials")
+	}
+
+	sqlSvc, err := NewSQLAdminService(ctx, providerCreds)
+	if err != nil {
+		return providersdk.Failed(err, "failed to create gcp cloud sql admin client")
+	}
+
+	return p.deleteCloudSQLInstance(ctx, r, sqlSvc, strategyConfig, instanceCfg)
+}
+
+func (p *PostgresProvider) deleteCloudSQLInstance(ctx context.Context, pg *v1alpha1.Postgres, sqlSvc SQLAdminService, strategyConfig *StrategyConfig, instanceCfg *DatabaseInstance) (croType.StatusMessage, error) {
+	logger := p.Logger.WithField("action", "deleteCloudSQLInstance")
+
+	if instanceCfg.Name == "" {
+		instanceName, err := p.buildInstanceName(ctx, pg)
+		if err != nil {
+			return providersdk.Failed(err, "failed to build cloud sql instance name")
+		}
+		instanceCfg.Name = instanceName
+	}
+
+	foundInstance, err := sqlSvc.GetInstance(ctx, strategyConfig.ProjectID, instanceCfg.Name)
+	if err != nil && !isNotFound(err) {
+		return providersdk.Failed(err, "failed to get cloud sql instance %s", instanceCfg.Name)
+	}
+
+	// check if instance exists, if it does attempt to delete it
+	// if not delete finalizer and credential secret
+	if foundInstance != nil {
+		// return if cloud sql instance is not runnable
+		if foundInstance.State != sqlInstanceStateRunnable {
+			statusMessage := fmt.Sprintf("delete detected, deleteCloudSQLInstance() in progress, current gcp cloud sql state is %s", foundInstance.State)
+			logger.Info(statusMessage)
+			return croType.StatusMessage(statusMessage), nil
+		}
+
+		// delete cloud sql instance if deletion protection is false
+		if foundInstance.Settings == nil || foundInstance.Settings.DeletionProtectionEnabled == nil || !*foundInstance.Settings.DeletionProtectionEnabled {
+			if err := sqlSvc.DeleteInstance(ctx, strategyConfig.ProjectID, foundInstance.Name); err != nil && !isNotFound(err) {
+				return providersdk.Failed(err, "failed to delete cloud sql instance : %s", err)
+			}
+			providersdk.Event(pg, v1.EventTypeNormal, CloudSQLInstanceDeletedEventReason, "deleting cloud sql instance %s", foundInstance.Name)
+			return "delete detected, deleteCloudSQLInstance() started", nil
+		}
+
+		// patch cloud sql instance to turn off deletion protection
+		err = sqlSvc.PatchInstance(ctx, strategyConfig.ProjectID, foundInstance.Name, &DatabaseInstance{
+			Settings: &Settings{
+				DeletionProtectionEnabled: boolPtr(false),
+			},
+		})
+		if err != nil {
+			return providersdk.Failed(err, "failed to remove deletion protection")
+		}
+		return providersdk.InProgress("deletion protection detected, patchInstance() in progress, current gcp cloud sql state is %s", foundInstance.State), nil
+	}
+
+	// delete credential secret
+	logger.Info("deleting cloud sql secret")
+	if err := providersdk.DeleteSecret(ctx, p.Client, pg.Name+defaultCredSecSuffix, pg.Namespace); err != nil {
+		return providersdk.Failed(err, "failed to deleted cloud sql secrets")
+	}
+
+	resources.RemoveFinalizer(&pg.ObjectMeta, DefaultFinalizer)
+	if err := p.Client.Update(ctx, pg); err != nil {
+		return providersdk.Failed(err, "failed to update instance as part of finalizer reconcile")
+	}
+	return croType.StatusEmpty, nil
+}
+
+func (p *PostgresProvider) getCloudSQLConfig(ctx context.Context, r *v1alpha1.Postgres) (*DatabaseInstance, *StrategyConfig, error) {
+	logger := p.Logger.WithField("action", "getCloudSQLConfig")
+	stratCfg, err := p.ConfigManager.ReadStorageStrategy(ctx, providers.PostgresResourceType, r.Spec.Tier)
+	if err != nil {
+		return nil, nil, errorUtil.Wrap(err, "failed to read gcp strategy config")
+	}
+	// values of the tier changed by the postgres itself
+	stratCfg.CreateStrategy, err = providers.MergeProviderConfig(stratCfg.CreateStrategy, r.Spec.ProviderConfig)
+	if err != nil {
+		return nil, nil, errorUtil.Wrap(err, "failed to merge provider config of postgres")
+	}
+
+	if stratCfg.ProjectID == "" || stratCfg.Region == "" {
+		logger.Info("project or region not set in deployment strategy configuration, using cluster defaults")
+		if err := setStrategyDefaults(ctx, p.Client, stratCfg); err != nil {
+			return nil, nil, errorUtil.Wrap(err, "failed to set default project and region")
+		}
+	}
+
+	instanceCfg := &DatabaseInstance{}
+	if len(stratCfg.CreateStrategy) > 0 {
+		if err := json.Unmarshal(stratCfg.CreateStrategy, instanceCfg); err != nil {
+			return nil, nil, errorUtil.Wrap(err, "failed to unmarshal gcp cloud sql instance configuration")
+		}
+	}
+	return instanceCfg, stratCfg, nil
+}
+
+// buildCloudSQLCreateStrategy sets the defaults of any field of the create strategy which isn't set, the name, project,
+// region and root password are always set by the operator
+func (p *PostgresProvider) buildCloudSQLCreateStrategy(ctx context.Context, pg *v1alpha1.Postgres, strategyConfig *StrategyConfig, instanceCfg *DatabaseInstance, postgresPassword string) error {
+	instanceName, err := p.buildInstanceName(ctx, pg)
+	if err != nil {
+		return errorUtil.Wrap(err, "failed to build cloud sql instance name")
+	}
+	instanceCfg.Name = instanceName
+	instanceCfg.Project = strategyConfig.ProjectID
+	instanceCfg.Region = strategyConfig.Region
+	instanceCfg.RootPassword = postgresPassword
+	if instanceCfg.DatabaseVersion == "" {
+		instanceCfg.DatabaseVersion = defaultGCPDatabaseVersion
+	}
+
+	if instanceCfg.Settings == nil {
+		instanceCfg.Settings = &Settings{}
+	}
+	settings := instanceCfg.Settings
+	if settings.Tier == "" {
+		settings.Tier = defaultGCPTier
+	}
+	if settings.AvailabilityType == "" {
+		settings.AvailabilityType = defaultGCPAvailabilityType
+	}
+	if settings.DataDiskSizeGb == 0 {
+		settings.DataDiskSizeGb = defaultGCPDataDiskSizeGb
+	}
+	if settings.StorageAutoResize == nil {
+		settings.StorageAutoResize = boolPtr(true)
+	}
+	// the labels of the operator wide metadata are set unless the strategy sets the same label
+	md, err := resources.GetMetadata(ctx, p.Client)
+	if err != nil {
+		return errorUtil.Wrap(err, "failed to get metadata")
+	}
+	for k, v := range md.Labels {
+		if settings.UserLabels == nil {
+			settings.UserLabels = map[string]string{}
+		}
+		if _, ok := settings.UserLabels[k]; !ok {
+			settings.UserLabels[k] = v
+		}
+	}
+	if settings.StorageAutoResizeLimit == 0 {
+		settings.StorageAutoResizeLimit = defaultGCPStorageAutoResizeLimit
+	}
+	if settings.DeletionProtectionEnabled == nil {
+		settings.DeletionProtectionEnabled = boolPtr(true)
+	}
+	// a protected postgres always protects its instance, whatever the strategy
+	if pg.Spec.DeletionProtection {
+		settings.DeletionProtectionEnabled = boolPtr(true)
+	}
+	if settings.BackupConfiguration == nil {
+		settings.BackupConfiguration = &BackupConfiguration{}
+	}
+	if settings.BackupConfiguration.Enabled == nil {
+		settings.BackupConfiguration.Enabled = boolPtr(true)
+	}
+	if settings.BackupConfiguration.PointInTimeRecoveryEnabled == nil {
+		settings.BackupConfiguration.PointInTimeRecoveryEnabled = boolPtr(true)
+	}
+
+	// the instance is only reachable from the cluster network by default
+	if settings.IPConfiguration == nil {
+		settings.IPConfiguration = &IPConfiguration{}
+	}
+	if settings.IPConfiguration.IPv4Enabled == nil {
+		settings.IPConfiguration.IPv4Enabled = boolPtr(false)
+	}
+	if settings.IPConfiguration.PrivateNetwork == "" {
+		network, err := buildClusterNetwork(ctx, p.Client, strategyConfig.ProjectID)
+		if err != nil {
+			return errorUtil.Wrap(err, "failed to build cluster network")
+		}
+		settings.IPConfiguration.PrivateNetwork = network
+	}
+	return nil
+}
+
+// buildCloudSQLUpdateStrategy returns the settings of the create strategy which differ from the found instance, nil is
+// returned if no changes are required. the data disk can only grow, so a smaller size is ignored
+func buildCloudSQLUpdateStrategy(instanceCfg *DatabaseInstance, foundInstance *DatabaseInstance) *DatabaseInstance {
+	if foundInstance.Settings == nil {
+		return nil
+	}
+	found := foundInstance.Settings
+	want := instanceCfg.Settings
+	patch := &Settings{}
+	updateFound := false
+	if want.Tier != found.Tier {
+		patch.Tier = want.Tier
+		updateFound = true
+	}
+	if want.AvailabilityType != found.AvailabilityType {
+		patch.AvailabilityType = want.AvailabilityType
+		updateFound = true
+	}
+	if want.DataDiskSizeGb > found.DataDiskSizeGb {
+		patch.DataDiskSizeGb = want.DataDiskSizeGb
+		updateFound = true
+	}
+	if want.DeletionProtectionEnabled != nil && (found.DeletionProtectionEnabled == nil || *want.DeletionProtectionEnabled != *found.DeletionProtectionEnabled) {
+		patch.DeletionProtectionEnabled = want.DeletionProtectionEnabled
+		updateFound = true
+	}
+	// labels not set by the operator are kept, as the patch replaces every label of the instance
+	for k, v := range want.UserLabels {
+		if fv, ok := found.UserLabels[k]; ok && fv == v {
+			continue
+		}
+		if patch.UserLabels == nil {
+			patch.UserLabels = map[string]string{}
+			for fk, fv := range found.UserLabels {
+				patch.UserLabels[fk] = fv
+			}
+		}
+		patch.UserLabels[k] = v
+		updateFound = true
+	}
+	if !updateFound {
+		return nil
+	}
+	return &DatabaseInstance{Settings: patch}
+}
+
+func (p *PostgresProvider) buildInstanceName(ctx context.Context, pg *v1alpha1.Postgres) (string, error) {
+	instanceName, err := buildInfraNameFromObject(ctx, p.Client, pg.ObjectMeta, defaultGCPIdentifierLength)
+	if err != nil {
+		return "", errorUtil.Errorf("error occurred building instance name: %v", err)
+	}
+	return instanceName, nil
+}
+
+// getInstanceHost returns the private ip address of the instance, or any ip address if it has no private ip
+func getInstanceHost(instance *DatabaseInstance) string {
+	for _, ip := range instance.IPAddresses {
+		if ip.Type == sqlIPAddressTypePrivate {
+			return ip.IPAddress
+		}
+	}
+	if len(instance.IPAddresses) > 0 {
+		return instance.IPAddresses[0].IPAddress
+	}
+	return ""
+}
+
+// buildDefaultCloudSQLSecret returns the credential secret of the instance, its user and password are generated when
+// it's created
+func buildDefaultCloudSQLSecret(ps *v1alpha1.Postgres) *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ps.Name + defaultCredSecSuffix,
+			Namespace: ps.Namespace,
+		},
+		Type: v1.SecretTypeOpaque,
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
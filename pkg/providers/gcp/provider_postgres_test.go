@@ -0,0 +1,419 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	croApis "github.com/integr8ly/cloud-resource-operator/apis"
+	configv1 "github.com/integr8ly/cloud-resource-operator/apis/config/v1"
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/api/googleapi"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testProjectID = "test-project"
+	testRegion    = "europe-west1"
+	testPassword  = "test-password"
+)
+
+func buildTestScheme() (*runtime.Scheme, error) {
+	scheme := runtime.NewScheme()
+	if err := croApis.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	return scheme, nil
+}
+
+func buildTestInfrastructure() *configv1.Infrastructure {
+	return &configv1.Infrastructure{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name: "cluster",
+		},
+		Status: configv1.InfrastructureStatus{
+			InfrastructureName: "test",
+			PlatformStatus: &configv1.PlatformStatus{
+				Type: configv1.GCPPlatformType,
+				GCP: &configv1.GCPPlatformStatus{
+					ProjectID: testProjectID,
+					Region:    testRegion,
+				},
+			},
+		},
+	}
+}
+
+func buildTestPostgresCR() *v1alpha1.Postgres {
+	return &v1alpha1.Postgres{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name:      "test",
+			Namespace: "test",
+		},
+	}
+}
+
+func buildTestCredSecret() *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name:      "test" + defaultCredSecSuffix,
+			Namespace: "test",
+		},
+		Data: map[string][]byte{
+			defaultPostgresUserKey:     []byte(defaultGCPPostgresUser),
+			defaultPostgresPasswordKey: []byte(testPassword),
+		},
+	}
+}
+
+func buildTestStrategyConfig() *StrategyConfig {
+	return &StrategyConfig{
+		Region:    testRegion,
+		ProjectID: testProjectID,
+	}
+}
+
+func buildTestInstance(state string, tier string) *DatabaseInstance {
+	return &DatabaseInstance{
+		Name:            "testtesttest",
+		DatabaseVersion: defaultGCPDatabaseVersion,
+		State:           state,
+		Settings: &Settings{
+			Tier:                      tier,
+			AvailabilityType:          defaultGCPAvailabilityType,
+			DataDiskSizeGb:            defaultGCPDataDiskSizeGb,
+			DeletionProtectionEnabled: boolPtr(true),
+		},
+		IPAddresses: []*IPMapping{
+			{IPAddress: "34.1.1.1", Type: "PRIMARY"},
+			{IPAddress: "10.1.1.1", Type: sqlIPAddressTypePrivate},
+		},
+	}
+}
+
+func buildNotFoundError() error {
+	return &googleapi.Error{Code: http.StatusNotFound}
+}
+
+func TestPostgresProvider_reconcileCloudSQLInstance(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	tests := []struct {
+		name           string
+		client         client.Client
+		instanceCfg    *DatabaseInstance
+		sqlSvc         *SQLAdminServiceMock
+		want           *providers.PostgresInstance
+		wantInserted   *DatabaseInstance
+		wantPatched    *DatabaseInstance
+		wantStatusMsg  croType.StatusMessage
+		wantAnnotation bool
+		wantErr        bool
+	}{
+		{
+			name:        "test instance is created with defaults when it does not exist",
+			client:      fake.NewFakeClientWithScheme(scheme, buildTestInfrastructure(), buildTestPostgresCR(), buildTestCredSecret()),
+			instanceCfg: &DatabaseInstance{},
+			sqlSvc: &SQLAdminServiceMock{
+				GetInstanceFunc: func(ctx context.Context, project string, name string) (*DatabaseInstance, error) {
+					return nil, buildNotFoundError()
+				},
+				InsertInstanceFunc: func(ctx context.Context, project string, instance *DatabaseInstance) error {
+					return nil
+				},
+			},
+			wantInserted: &DatabaseInstance{
+				Name:            "testtesttest",
+				Project:         testProjectID,
+				Region:          testRegion,
+				DatabaseVersion: defaultGCPDatabaseVersion,
+				RootPassword:    testPassword,
+				Settings: &Settings{
+					Tier:                      defaultGCPTier,
+					AvailabilityType:          defaultGCPAvailabilityType,
+					DataDiskSizeGb:            defaultGCPDataDiskSizeGb,
+					StorageAutoResize:         boolPtr(true),
+					StorageAutoResizeLimit:    defaultGCPStorageAutoResizeLimit,
+					DeletionProtectionEnabled: boolPtr(true),
+					BackupConfiguration: &BackupConfiguration{
+						Enabled:                    boolPtr(true),
+						PointInTimeRecoveryEnabled: boolPtr(true),
+					},
+					IPConfiguration: &IPConfiguration{
+						IPv4Enabled:    boolPtr(false),
+						PrivateNetwork: "projects/test-project/global/networks/test-network",
+					},
+				},
+			},
+			wantStatusMsg:  "started cloud sql provision",
+			wantAnnotation: true,
+		},
+		{
+			name:        "test tier overrides from the create strategy are used",
+			client:      fake.NewFakeClientWithScheme(scheme, buildTestInfrastructure(), buildTestPostgresCR(), buildTestCredSecret()),
+			instanceCfg: &DatabaseInstance{DatabaseVersion: "POSTGRES_14", Settings: &Settings{Tier: "db-custom-2-7680", AvailabilityType: "ZONAL"}},
+			sqlSvc: &SQLAdminServiceMock{
+				GetInstanceFunc: func(ctx context.Context, project string, name string) (*DatabaseInstance, error) {
+					return nil, buildNotFoundError()
+				},
+				InsertInstanceFunc: func(ctx context.Context, project string, instance *DatabaseInstance) error {
+					return nil
+				},
+			},
+			wantInserted: &DatabaseInstance{
+				Name:            "testtesttest",
+				Project:         testProjectID,
+				Region:          testRegion,
+				DatabaseVersion: "POSTGRES_14",
+				RootPassword:    testPassword,
+				Settings: &Settings{
+					Tier:                      "db-custom-2-7680",
+					AvailabilityType:          "ZONAL",
+					DataDiskSizeGb:            defaultGCPDataDiskSizeGb,
+					StorageAutoResize:         boolPtr(true),
+					StorageAutoResizeLimit:    defaultGCPStorageAutoResizeLimit,
+					DeletionProtectionEnabled: boolPtr(true),
+					BackupConfiguration: &BackupConfiguration{
+						Enabled:                    boolPtr(true),
+						PointInTimeRecoveryEnabled: boolPtr(true),
+					},
+					IPConfiguration: &IPConfiguration{
+						IPv4Enabled:    boolPtr(false),
+						PrivateNetwork: "projects/test-project/global/networks/test-network",
+					},
+				},
+			},
+			wantStatusMsg:  "started cloud sql provision",
+			wantAnnotation: true,
+		},
+		{
+			name:        "test instance in progress while it is not runnable",
+			client:      fake.NewFakeClientWithScheme(scheme, buildTestInfrastructure(), buildTestPostgresCR(), buildTestCredSecret()),
+			instanceCfg: &DatabaseInstance{},
+			sqlSvc: &SQLAdminServiceMock{
+				GetInstanceFunc: func(ctx context.Context, project string, name string) (*DatabaseInstance, error) {
+					return buildTestInstance("PENDING_CREATE", defaultGCPTier), nil
+				},
+			},
+			wantStatusMsg: "reconcileCloudSQLInstance() in progress, current gcp cloud sql state is PENDING_CREATE",
+		},
+		{
+			name:        "test instance is patched when the tier changes",
+			client:      fake.NewFakeClientWithScheme(scheme, buildTestInfrastructure(), buildTestPostgresCR(), buildTestCredSecret()),
+			instanceCfg: &DatabaseInstance{Settings: &Settings{Tier: "db-custom-2-7680"}},
+			sqlSvc: &SQLAdminServiceMock{
+				GetInstanceFunc: func(ctx context.Context, project string, name string) (*DatabaseInstance, error) {
+					return buildTestInstance(sqlInstanceStateRunnable, defaultGCPTier), nil
+				},
+				PatchInstanceFunc: func(ctx context.Context, project string, name string, instance *DatabaseInstance) error {
+					return nil
+				},
+			},
+			wantPatched:   &DatabaseInstance{Settings: &Settings{Tier: "db-custom-2-7680"}},
+			wantStatusMsg: "set pending modifications for cloud sql instance: testtesttest",
+		},
+		{
+			name:        "test connection details use the private ip of a runnable instance",
+			client:      fake.NewFakeClientWithScheme(scheme, buildTestInfrastructure(), buildTestPostgresCR(), buildTestCredSecret()),
+			instanceCfg: &DatabaseInstance{},
+			sqlSvc: &SQLAdminServiceMock{
+				GetInstanceFunc: func(ctx context.Context, project string, name string) (*DatabaseInstance, error) {
+					return buildTestInstance(sqlInstanceStateRunnable, defaultGCPTier), nil
+				},
+			},
+			want: &providers.PostgresInstance{
+				DeploymentDetails: &providers.PostgresDeploymentDetails{
+					Username: defaultGCPPostgresUser,
+					Password: testPassword,
+					Host:     "10.1.1.1",
+					Database: defaultGCPPostgresDatabase,
+					Port:     defaultGCPPostgresPort,
+				},
+			},
+			wantStatusMsg: "cloud sql instance testtesttest is as expected, gcp cloud sql state is RUNNABLE",
+		},
+		{
+			name:        "test error when the instance failed",
+			client:      fake.NewFakeClientWithScheme(scheme, buildTestInfrastructure(), buildTestPostgresCR(), buildTestCredSecret()),
+			instanceCfg: &DatabaseInstance{},
+			sqlSvc: &SQLAdminServiceMock{
+				GetInstanceFunc: func(ctx context.Context, project string, name string) (*DatabaseInstance, error) {
+					return buildTestInstance(sqlInstanceStateFailed, defaultGCPTier), nil
+				},
+			},
+			wantStatusMsg: "found instance testtesttest current state FAILED",
+			wantErr:       true,
+		},
+		{
+			name:        "test error when the instance can not be retrieved",
+			client:      fake.NewFakeClientWithScheme(scheme, buildTestInfrastructure(), buildTestPostgresCR(), buildTestCredSecret()),
+			instanceCfg: &DatabaseInstance{},
+			sqlSvc: &SQLAdminServiceMock{
+				GetInstanceFunc: func(ctx context.Context, project string, name string) (*DatabaseInstance, error) {
+					return nil, errors.New("test error")
+				},
+			},
+			wantStatusMsg: "failed to get cloud sql instance testtesttest",
+			wantErr:       true,
+		},
+		{
+			name:          "test error when the credential secret does not exist",
+			client:        fake.NewFakeClientWithScheme(scheme, buildTestInfrastructure(), buildTestPostgresCR()),
+			instanceCfg:   &DatabaseInstance{},
+			sqlSvc:        &SQLAdminServiceMock{},
+			wantStatusMsg: "failed to retrieve cloud sql credential secret",
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &PostgresProvider{
+				Client: tt.client,
+				Logger: logrus.NewEntry(logrus.StandardLogger()),
+			}
+			pg := buildTestPostgresCR()
+			if err := tt.client.Get(context.TODO(), types.NamespacedName{Name: pg.Name, Namespace: pg.Namespace}, pg); err != nil {
+				t.Fatalf("failed to get postgres: %v", err)
+			}
+			got, statusMsg, err := p.reconcileCloudSQLInstance(context.TODO(), pg, tt.sqlSvc, buildTestStrategyConfig(), tt.instanceCfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("reconcileCloudSQLInstance() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if statusMsg != tt.wantStatusMsg {
+				t.Errorf("reconcileCloudSQLInstance() statusMsg = %v, want %v", statusMsg, tt.wantStatusMsg)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("reconcileCloudSQLInstance() got = %+v, want %+v", got, tt.want)
+			}
+			if tt.wantInserted != nil {
+				if len(tt.sqlSvc.InsertInstanceCalls()) != 1 {
+					t.Fatalf("reconcileCloudSQLInstance() expected instance to be inserted once, got %d", len(tt.sqlSvc.InsertInstanceCalls()))
+				}
+				if inserted := tt.sqlSvc.InsertInstanceCalls()[0].Instance; !reflect.DeepEqual(inserted, tt.wantInserted) {
+					t.Errorf("reconcileCloudSQLInstance() inserted = %+v, want %+v", inserted, tt.wantInserted)
+				}
+			}
+			if tt.wantPatched != nil {
+				if len(tt.sqlSvc.PatchInstanceCalls()) != 1 {
+					t.Fatalf("reconcileCloudSQLInstance() expected instance to be patched once, got %d", len(tt.sqlSvc.PatchInstanceCalls()))
+				}
+				if patched := tt.sqlSvc.PatchInstanceCalls()[0].Instance; !reflect.DeepEqual(patched, tt.wantPatched) {
+					t.Errorf("reconcileCloudSQLInstance() patched = %+v, want %+v", patched.Settings, tt.wantPatched.Settings)
+				}
+			}
+			if tt.wantAnnotation && pg.Annotations[ResourceIdentifierAnnotation] != "testtesttest" {
+				t.Errorf("reconcileCloudSQLInstance() expected annotation %s to be set, got %v", ResourceIdentifierAnnotation, pg.Annotations)
+			}
+		})
+	}
+}
+
+func TestPostgresProvider_deleteCloudSQLInstance(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	tests := []struct {
+		name          string
+		client        client.Client
+		sqlSvc        *SQLAdminServiceMock
+		wantStatusMsg croType.StatusMessage
+		wantDeleted   bool
+		wantPatched   bool
+		wantCleanup   bool
+		wantErr       bool
+	}{
+		{
+			name:   "test deletion protection is removed before deleting",
+			client: fake.NewFakeClientWithScheme(scheme, buildTestInfrastructure(), buildTestPostgresCR(), buildTestCredSecret()),
+			sqlSvc: &SQLAdminServiceMock{
+				GetInstanceFunc: func(ctx context.Context, project string, name string) (*DatabaseInstance, error) {
+					return buildTestInstance(sqlInstanceStateRunnable, defaultGCPTier), nil
+				},
+				PatchInstanceFunc: func(ctx context.Context, project string, name string, instance *DatabaseInstance) error {
+					return nil
+				},
+			},
+			wantStatusMsg: "deletion protection detected, patchInstance() in progress, current gcp cloud sql state is RUNNABLE",
+			wantPatched:   true,
+		},
+		{
+			name:   "test instance is deleted without deletion protection",
+			client: fake.NewFakeClientWithScheme(scheme, buildTestInfrastructure(), buildTestPostgresCR(), buildTestCredSecret()),
+			sqlSvc: &SQLAdminServiceMock{
+				GetInstanceFunc: func(ctx context.Context, project string, name string) (*DatabaseInstance, error) {
+					instance := buildTestInstance(sqlInstanceStateRunnable, defaultGCPTier)
+					instance.Settings.DeletionProtectionEnabled = boolPtr(false)
+					return instance, nil
+				},
+				DeleteInstanceFunc: func(ctx context.Context, project string, name string) error {
+					return nil
+				},
+			},
+			wantStatusMsg: "delete detected, deleteCloudSQLInstance() started",
+			wantDeleted:   true,
+		},
+		{
+			name:   "test secret and finalizer are removed once the instance is gone",
+			client: fake.NewFakeClientWithScheme(scheme, buildTestInfrastructure(), buildTestPostgresCR(), buildTestCredSecret()),
+			sqlSvc: &SQLAdminServiceMock{
+				GetInstanceFunc: func(ctx context.Context, project string, name string) (*DatabaseInstance, error) {
+					return nil, buildNotFoundError()
+				},
+			},
+			wantStatusMsg: croType.StatusEmpty,
+			wantCleanup:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &PostgresProvider{
+				Client: tt.client,
+				Logger: logrus.NewEntry(logrus.StandardLogger()),
+			}
+			pg := buildTestPostgresCR()
+			if err := tt.client.Get(context.TODO(), types.NamespacedName{Name: pg.Name, Namespace: pg.Namespace}, pg); err != nil {
+				t.Fatalf("failed to get postgres: %v", err)
+			}
+			pg.Finalizers = []string{DefaultFinalizer}
+			statusMsg, err := p.deleteCloudSQLInstance(context.TODO(), pg, tt.sqlSvc, buildTestStrategyConfig(), &DatabaseInstance{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("deleteCloudSQLInstance() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if statusMsg != tt.wantStatusMsg {
+				t.Errorf("deleteCloudSQLInstance() statusMsg = %v, want %v", statusMsg, tt.wantStatusMsg)
+			}
+			if tt.wantDeleted != (len(tt.sqlSvc.DeleteInstanceCalls()) == 1) {
+				t.Errorf("deleteCloudSQLInstance() deleted = %v, want %v", len(tt.sqlSvc.DeleteInstanceCalls()) == 1, tt.wantDeleted)
+			}
+			if tt.wantPatched != (len(tt.sqlSvc.PatchInstanceCalls()) == 1) {
+				t.Errorf("deleteCloudSQLInstance() patched = %v, want %v", len(tt.sqlSvc.PatchInstanceCalls()) == 1, tt.wantPatched)
+			}
+			if tt.wantCleanup {
+				if resources.Contains(pg.Finalizers, DefaultFinalizer) {
+					t.Errorf("deleteCloudSQLInstance() expected finalizer to be removed")
+				}
+				sec := &corev1.Secret{}
+				if err := tt.client.Get(context.TODO(), types.NamespacedName{Name: "test" + defaultCredSecSuffix, Namespace: "test"}, sec); err == nil {
+					t.Errorf("deleteCloudSQLInstance() expected credential secret to be deleted")
+				}
+			}
+		})
+	}
+}
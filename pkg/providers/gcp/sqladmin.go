@@ -0,0 +1,175 @@
+package gcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	errorUtil "github.com/pkg/errors"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	sqlAdminBasePath = "https://sqladmin.googleapis.com/v1/"
+	sqlAdminScope    = "https://www.googleapis.com/auth/sqlservice.admin"
+
+	// cloud sql instance states, see https://cloud.google.com/sql/docs/postgres/admin-api/rest/v1/instances#sqlinstancestate
+	sqlInstanceStateRunnable  = "RUNNABLE"
+	sqlInstanceStateFailed    = "FAILED"
+	sqlInstanceStateSuspended = "SUSPENDED"
+
+	sqlIPAddressTypePrivate = "PRIVATE"
+)
+
+// DatabaseInstance is the subset of the cloud sql admin DatabaseInstance resource managed by the operator, it's used as
+// the create strategy of the gcp postgres provider
+type DatabaseInstance struct {
+	Name            string       `json:"name,omitempty"`
+	Project         string       `json:"project,omitempty"`
+	Region          string       `json:"region,omitempty"`
+	DatabaseVersion string       `json:"databaseVersion,omitempty"`
+	RootPassword    string       `json:"rootPassword,omitempty"`
+	State           string       `json:"state,omitempty"`
+	Settings        *Settings    `json:"settings,omitempty"`
+	IPAddresses     []*IPMapping `json:"ipAddresses,omitempty"`
+}
+
+type Settings struct {
+	Tier                      string               `json:"tier,omitempty"`
+	AvailabilityType          string               `json:"availabilityType,omitempty"`
+	DataDiskSizeGb            int64                `json:"dataDiskSizeGb,omitempty,string"`
+	DataDiskType              string               `json:"dataDiskType,omitempty"`
+	StorageAutoResize         *bool                `json:"storageAutoResize,omitempty"`
+	StorageAutoResizeLimit    int64                `json:"storageAutoResizeLimit,omitempty,string"`
+	DeletionProtectionEnabled *bool                `json:"deletionProtectionEnabled,omitempty"`
+	UserLabels                map[string]string    `json:"userLabels,omitempty"`
+	BackupConfiguration       *BackupConfiguration `json:"backupConfiguration,omitempty"`
+	IPConfiguration           *IPConfiguration     `json:"ipConfiguration,omitempty"`
+}
+
+type BackupConfiguration struct {
+	Enabled                    *bool  `json:"enabled,omitempty"`
+	StartTime                  string `json:"startTime,omitempty"`
+	PointInTimeRecoveryEnabled *bool  `json:"pointInTimeRecoveryEnabled,omitempty"`
+}
+
+type IPConfiguration struct {
+	IPv4Enabled    *bool  `json:"ipv4Enabled,omitempty"`
+	PrivateNetwork string `json:"privateNetwork,omitempty"`
+	RequireSSL     *bool  `json:"requireSsl,omitempty"`
+}
+
+type IPMapping struct {
+	IPAddress string `json:"ipAddress"`
+	Type      string `json:"type"`
+}
+
+//go:generate moq -out sqladmin_moq.go . SQLAdminService
+type SQLAdminService interface {
+	GetInstance(ctx context.Context, project, name string) (*DatabaseInstance, error)
+	InsertInstance(ctx context.Context, project string, instance *DatabaseInstance) error
+	PatchInstance(ctx context.Context, project, name string, instance *DatabaseInstance) error
+	DeleteInstance(ctx context.Context, project, name string) error
+}
+
+var _ SQLAdminService = (*sqlAdminClient)(nil)
+
+// sqlAdminClient calls the cloud sql admin rest api, changes to instances are asynchronous so the returned operations
+// aren't waited on, the instance state is checked on the next reconcile instead
+type sqlAdminClient struct {
+	httpClient *http.Client
+	basePath   string
+}
+
+// NewSQLAdminService returns a cloud sql admin client authenticated as the service account
+func NewSQLAdminService(ctx context.Context, credentials *Credentials) (SQLAdminService, error) {
+	creds, err := google.CredentialsFromJSON(ctx, credentials.ServiceAccountJSON, sqlAdminScope)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to read gcp service account credentials")
+	}
+	return &sqlAdminClient{
+		httpClient: oauth2.NewClient(ctx, creds.TokenSource),
+		basePath:   sqlAdminBasePath,
+	}, nil
+}
+
+func (c *sqlAdminClient) GetInstance(ctx context.Context, project, name string) (*DatabaseInstance, error) {
+	instance := &DatabaseInstance{}
+	if err := c.do(ctx, "GetInstance", http.MethodGet, c.instancePath(project, name), nil, instance); err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to get cloud sql instance %s", name)
+	}
+	return instance, nil
+}
+
+func (c *sqlAdminClient) InsertInstance(ctx context.Context, project string, instance *DatabaseInstance) error {
+	if err := c.do(ctx, "InsertInstance", http.MethodPost, c.instancePath(project, ""), instance, nil); err != nil {
+		return errorUtil.Wrapf(err, "failed to insert cloud sql instance %s", instance.Name)
+	}
+	return nil
+}
+
+func (c *sqlAdminClient) PatchInstance(ctx context.Context, project, name string, instance *DatabaseInstance) error {
+	if err := c.do(ctx, "PatchInstance", http.MethodPatch, c.instancePath(project, name), instance, nil); err != nil {
+		return errorUtil.Wrapf(err, "failed to patch cloud sql instance %s", name)
+	}
+	return nil
+}
+
+func (c *sqlAdminClient) DeleteInstance(ctx context.Context, project, name string) error {
+	if err := c.do(ctx, "DeleteInstance", http.MethodDelete, c.instancePath(project, name), nil, nil); err != nil {
+		return errorUtil.Wrapf(err, "failed to delete cloud sql instance %s", name)
+	}
+	return nil
+}
+
+func (c *sqlAdminClient) instancePath(project, name string) string {
+	path := fmt.Sprintf("%sprojects/%s/instances", c.basePath, url.PathEscape(project))
+	if name != "" {
+		path = fmt.Sprintf("%s/%s", path, url.PathEscape(name))
+	}
+	return path
+}
+
+// do sends a request of the operation to the api, failing it if the deadline of the operation passes first
+func (c *sqlAdminClient) do(ctx context.Context, operation, method, path string, body, out interface{}) (err error) {
+	ctx, cancel := providers.WithCallDeadline(ctx, postgresProviderName, operation)
+	defer cancel()
+	defer func() {
+		providers.ObserveCallDeadline(ctx, postgresProviderName, operation, err)
+	}()
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			return errorUtil.Wrap(err, "failed to encode request")
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, path, &reqBody)
+	if err != nil {
+		return errorUtil.Wrap(err, "failed to build request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := googleapi.CheckResponse(resp); err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// isNotFound returns true if the error was returned by the api because the resource doesn't exist
+func isNotFound(err error) bool {
+	var apiErr *googleapi.Error
+	return errorUtil.As(err, &apiErr) && apiErr.Code == http.StatusNotFound
+}
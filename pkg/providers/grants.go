@@ -0,0 +1,96 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// GrantedCondition is the condition reporting if the namespace a resource is requested on behalf of allows it
+	GrantedCondition = "Granted"
+	// ResourceGrantedReason is the reason of a true granted condition
+	ResourceGrantedReason = "ResourceGranted"
+	// GrantMissingReason is the reason of a false granted condition
+	GrantMissingReason = "GrantMissing"
+)
+
+// FindResourceGrant returns a resource grant in the namespace a resource is requested on behalf of allowing the
+// namespace of the resource to request a resource of the kind and tier, nil if there's none
+func FindResourceGrant(ctx context.Context, c client.Reader, onBehalfOf string, ns string, kind string, tier string) (*v1alpha1.ResourceGrant, error) {
+	grants := &v1alpha1.ResourceGrantList{}
+	if err := c.List(ctx, grants, client.InNamespace(onBehalfOf)); err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to list resource grants in namespace %s", onBehalfOf)
+	}
+	for i := range grants.Items {
+		if ResourceGrantAllows(grants.Items[i].Spec, ns, kind, tier) {
+			return &grants.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// ResourceGrantAllows returns true if the grant allows the namespace to request a resource of the kind and tier
+func ResourceGrantAllows(grant v1alpha1.ResourceGrantSpec, ns string, kind string, tier string) bool {
+	if !resources.Contains(grant.Namespaces, ns) {
+		return false
+	}
+	if len(grant.Tiers) > 0 && !resources.Contains(grant.Tiers, tier) {
+		return false
+	}
+	if len(grant.Kinds) == 0 {
+		return true
+	}
+	for _, k := range grant.Kinds {
+		if string(k) == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// ReconcileGrant checks the namespace a resource is requested on behalf of allows the namespace of the resource with
+// a resource grant and reports it in the granted condition of the resource status, the status is persisted with the
+// rest of the resource status. It returns a message if the resource isn't granted, empty if it can be provisioned.
+// Like dependencies, grants are only required until the resource is first granted, so removing a grant doesn't remove
+// the resources provisioned with it
+func ReconcileGrant(ctx context.Context, c client.Reader, inst metav1.Object, kind string, spec *croType.ResourceTypeSpec, status *croType.ResourceTypeStatus) (croType.StatusMessage, error) {
+	if spec.OnBehalfOf == "" || spec.OnBehalfOf == inst.GetNamespace() {
+		meta.RemoveStatusCondition(&status.Conditions, GrantedCondition)
+		return croType.StatusEmpty, nil
+	}
+	if meta.IsStatusConditionTrue(status.Conditions, GrantedCondition) {
+		return croType.StatusEmpty, nil
+	}
+	grant, err := FindResourceGrant(ctx, c, spec.OnBehalfOf, inst.GetNamespace(), kind, spec.Tier)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to find resource grant in namespace %s", spec.OnBehalfOf)
+		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	if grant == nil {
+		msg := fmt.Sprintf("no resource grant in namespace %s allows namespace %s to request %s resources of tier %s", spec.OnBehalfOf, inst.GetNamespace(), kind, spec.Tier)
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               GrantedCondition,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: inst.GetGeneration(),
+			Reason:             GrantMissingReason,
+			Message:            msg,
+		})
+		return croType.StatusMessage(msg), nil
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               GrantedCondition,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: inst.GetGeneration(),
+		Reason:             ResourceGrantedReason,
+		Message:            fmt.Sprintf("requested on behalf of namespace %s with resource grant %s", spec.OnBehalfOf, grant.Name),
+	})
+	return croType.StatusEmpty, nil
+}
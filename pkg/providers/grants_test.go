@@ -0,0 +1,113 @@
+package providers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func buildTestGrantedRedis(onBehalfOf string) *v1alpha1.Redis {
+	return &v1alpha1.Redis{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test",
+			Namespace:  "team",
+			Generation: 1,
+		},
+		Spec: croType.ResourceTypeSpec{
+			Tier:       "production",
+			OnBehalfOf: onBehalfOf,
+		},
+	}
+}
+
+func buildTestResourceGrant(ns string, spec v1alpha1.ResourceGrantSpec) *v1alpha1.ResourceGrant {
+	return &v1alpha1.ResourceGrant{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-grant",
+			Namespace: ns,
+		},
+		Spec: spec,
+	}
+}
+
+func TestReconcileGrant(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	tests := []struct {
+		name          string
+		redis         *v1alpha1.Redis
+		existing      []runtime.Object
+		wantMsg       croType.StatusMessage
+		wantCondition metav1.ConditionStatus
+	}{
+		{
+			name:  "test resource requested for its own namespace has no condition",
+			redis: buildTestGrantedRedis(""),
+		},
+		{
+			name:          "test resource is granted by a grant of every kind",
+			redis:         buildTestGrantedRedis("data"),
+			existing:      []runtime.Object{buildTestResourceGrant("data", v1alpha1.ResourceGrantSpec{Namespaces: []string{"team"}})},
+			wantCondition: metav1.ConditionTrue,
+		},
+		{
+			name:          "test resource without a grant isn't granted",
+			redis:         buildTestGrantedRedis("data"),
+			wantMsg:       "no resource grant in namespace data allows namespace team to request Redis resources of tier production",
+			wantCondition: metav1.ConditionFalse,
+		},
+		{
+			name:  "test grants of other namespaces, kinds and tiers don't grant the resource",
+			redis: buildTestGrantedRedis("data"),
+			existing: []runtime.Object{
+				buildTestResourceGrant("team", v1alpha1.ResourceGrantSpec{Namespaces: []string{"team"}}),
+				buildTestResourceGrant("data", v1alpha1.ResourceGrantSpec{Namespaces: []string{"team"}, Kinds: []v1alpha1.ResourceGrantKind{"Postgres"}}),
+			},
+			wantMsg:       "no resource grant in namespace data allows namespace team to request Redis resources of tier production",
+			wantCondition: metav1.ConditionFalse,
+		},
+		{
+			name: "test grants are not checked again once granted",
+			redis: func() *v1alpha1.Redis {
+				r := buildTestGrantedRedis("data")
+				meta.SetStatusCondition(&r.Status.Conditions, metav1.Condition{
+					Type:   GrantedCondition,
+					Status: metav1.ConditionTrue,
+					Reason: ResourceGrantedReason,
+				})
+				return r
+			}(),
+			wantCondition: metav1.ConditionTrue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme, tt.existing...)
+			msg, err := ReconcileGrant(context.TODO(), c, tt.redis, "Redis", &tt.redis.Spec, &tt.redis.Status)
+			if err != nil {
+				t.Fatal("ReconcileGrant() failed", err)
+			}
+			if msg != tt.wantMsg {
+				t.Errorf("ReconcileGrant() msg = %s, want %s", msg, tt.wantMsg)
+			}
+			cond := meta.FindStatusCondition(tt.redis.Status.Conditions, GrantedCondition)
+			if tt.wantCondition == "" {
+				if cond != nil {
+					t.Errorf("ReconcileGrant() unexpected condition %v", cond)
+				}
+				return
+			}
+			if cond == nil || cond.Status != tt.wantCondition {
+				t.Errorf("ReconcileGrant() condition = %v, want status %s", cond, tt.wantCondition)
+			}
+		})
+	}
+}
@@ -3,15 +3,12 @@ package openshift
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"time"
 
 	"github.com/integr8ly/cloud-resource-operator/internal/k8sutil"
 
-	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
-
 	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
-	errorUtil "github.com/pkg/errors"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providersdk"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	controllerruntime "sigs.k8s.io/controller-runtime"
@@ -31,6 +28,8 @@ var DefaultConfigMapNamespace, _ = k8sutil.GetWatchNamespace()
 
 type StrategyConfig struct {
 	RawStrategy json.RawMessage `json:"strategy"`
+	// SupportedVersions maps the postgres versions a cr can request to postgres images, replacing the defaults
+	SupportedVersions providers.SupportedVersions `json:"supportedVersions,omitempty"`
 }
 
 //go:generate moq -out config_moq.go . ConfigManager
@@ -65,30 +64,33 @@ func NewDefaultConfigManager(client client.Client) *ConfigMapConfigManager {
 }
 
 func (m *ConfigMapConfigManager) ReadStorageStrategy(ctx context.Context, rt providers.ResourceType, tier string) (*StrategyConfig, error) {
-	cm, err := resources.GetConfigMapOrDefault(ctx, m.client, types.NamespacedName{Name: m.configMapName, Namespace: m.configMapNamespace}, m.buildDefaultConfigMap())
-	if err != nil {
-		return nil, errorUtil.Wrapf(err, "failed to get openshift strategy config map %s in namespace %s", m.configMapName, m.configMapNamespace)
-	}
-	rawStrategyCfg := cm.Data[string(rt)]
-	if rawStrategyCfg == "" {
-		return nil, errorUtil.New(fmt.Sprintf("openshift strategy for resource type %s is not defined", rt))
+	stratCfg := &StrategyConfig{}
+	key := types.NamespacedName{Name: m.configMapName, Namespace: m.configMapNamespace}
+	if err := providersdk.ReadStrategy(ctx, m.client, "openshift", key, m.buildDefaultConfigMap(), rt, tier, stratCfg); err != nil {
+		return nil, err
 	}
+	return stratCfg, nil
+}
 
-	var strategies map[string]*StrategyConfig
-	if err = json.Unmarshal([]byte(rawStrategyCfg), &strategies); err != nil {
-		return nil, errorUtil.Wrapf(err, "failed to unmarshal strategy mapping for resource type %s", rt)
+// StrategyFromConfigMap returns the strategy config of a resource type and tier from an openshift strategy config map,
+// merged over the tiers it inherits from or read from the default tier if the tier isn't defined
+func StrategyFromConfigMap(cm *v1.ConfigMap, rt providers.ResourceType, tier string) (*StrategyConfig, error) {
+	stratCfg := &StrategyConfig{}
+	if err := providersdk.StrategyFromConfigMap("openshift", cm, rt, tier, stratCfg); err != nil {
+		return nil, err
 	}
-	if strategies[tier] == nil {
-		return nil, errorUtil.New(fmt.Sprintf("no strategy found for deployment type %s and deployment tier %s", rt, tier))
-	}
-	return strategies[tier], nil
+	return stratCfg, nil
 }
 
 func (m *ConfigMapConfigManager) buildDefaultConfigMap() *v1.ConfigMap {
+	return BuildDefaultConfigMap(m.configMapName, m.configMapNamespace)
+}
+
+func BuildDefaultConfigMap(name, namespace string) *v1.ConfigMap {
 	return &v1.ConfigMap{
 		ObjectMeta: controllerruntime.ObjectMeta{
-			Name:      m.configMapName,
-			Namespace: m.configMapNamespace,
+			Name:      name,
+			Namespace: namespace,
 		},
 		Data: map[string]string{
 			"postgres":    "{\"development\": { \"strategy\": {} }, \"production\": { \"strategy\": {} } }",
@@ -78,7 +78,7 @@ func TestConfigManager_ReadBlobStorageStrategy(t *testing.T) {
 			Namespace: "test",
 		},
 		Data: map[string]string{
-			"blobstorage": fmt.Sprintf("{\"test\": %s}", string(rawStratCfg)),
+			"blobstorage": fmt.Sprintf("{\"test\": %s, \"inherited\": {\"inherits\": \"test\"}}", string(rawStratCfg)),
 		},
 	})
 	cases := []struct {
@@ -98,6 +98,14 @@ func TestConfigManager_ReadBlobStorageStrategy(t *testing.T) {
 			expectedRawStrategy: string(sc.RawStrategy),
 			client:              fakeClient,
 		},
+		{
+			name:                "test strategy is inherited from the tier named by inherits",
+			cmName:              "test",
+			cmNamespace:         "test",
+			tier:                "inherited",
+			expectedRawStrategy: string(sc.RawStrategy),
+			client:              fakeClient,
+		},
 		{
 			name:        "test error returned when tier does not exist",
 			cmName:      "test",
@@ -0,0 +1,157 @@
+package openshift
+
+import (
+	"context"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/conformance"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testConformanceNamespace = "test-conformance"
+	testResizedStorage       = "2Gi"
+)
+
+// buildConformanceHarness returns a harness running the scenarios against a fake cluster, in which pvcs are bound and
+// deployments become available when the cloud settles
+func buildConformanceHarness(t *testing.T) conformance.Harness {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	c := fake.NewFakeClientWithScheme(scheme)
+	return conformance.Harness{
+		Client: c,
+		Settle: func(t *testing.T, obj conformance.Object) {
+			settleWorkload(t, c, obj.GetNamespace())
+		},
+		Resize: func(t *testing.T, obj conformance.Object) {
+			spec := resourceSpec(obj)
+			spec.ProviderConfig = &runtime.RawExtension{Raw: []byte(`{"pvcSpec": {"resources": {"requests": {"storage": "` + testResizedStorage + `"}}}}`)}
+		},
+		Resized: func(t *testing.T, obj conformance.Object) bool {
+			pvc := &v1.PersistentVolumeClaim{}
+			if err := c.Get(context.TODO(), types.NamespacedName{Name: obj.GetName(), Namespace: obj.GetNamespace()}, pvc); err != nil {
+				t.Fatalf("failed to get pvc: %v", err)
+			}
+			size := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+			return size.Cmp(resource.MustParse(testResizedStorage)) == 0
+		},
+		Gone: func(t *testing.T, obj conformance.Object) bool {
+			key := types.NamespacedName{Name: obj.GetName(), Namespace: obj.GetNamespace()}
+			for _, o := range []runtime.Object{&appsv1.Deployment{}, &v1.Service{}, &v1.PersistentVolumeClaim{}} {
+				if err := c.Get(context.TODO(), key, o); !k8serr.IsNotFound(err) {
+					return false
+				}
+			}
+			// the secrets and config maps of a resource are named after it, so another resource in the namespace keeps its own
+			switch obj.(type) {
+			case *v1alpha1.Postgres:
+				key.Name = postgresCredentialsSecretName(obj.GetName())
+				err := c.Get(context.TODO(), key, &v1.Secret{})
+				return k8serr.IsNotFound(err)
+			case *v1alpha1.Redis:
+				key.Name = redisConfigMapName(obj.GetName())
+				err := c.Get(context.TODO(), key, &v1.ConfigMap{})
+				return k8serr.IsNotFound(err)
+			}
+			return true
+		},
+	}
+}
+
+// settleWorkload binds the pvcs and makes the deployments of the namespace available
+func settleWorkload(t *testing.T, c client.Client, ns string) {
+	pvcs := &v1.PersistentVolumeClaimList{}
+	if err := c.List(context.TODO(), pvcs, client.InNamespace(ns)); err != nil {
+		t.Fatalf("failed to list pvcs: %v", err)
+	}
+	for i := range pvcs.Items {
+		pvcs.Items[i].Status.Phase = v1.ClaimBound
+		if err := c.Update(context.TODO(), &pvcs.Items[i]); err != nil {
+			t.Fatalf("failed to bind pvc: %v", err)
+		}
+	}
+	dpls := &appsv1.DeploymentList{}
+	if err := c.List(context.TODO(), dpls, client.InNamespace(ns)); err != nil {
+		t.Fatalf("failed to list deployments: %v", err)
+	}
+	for i := range dpls.Items {
+		dpls.Items[i].Status.Conditions = []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable, Status: v1.ConditionTrue}}
+		if err := c.Update(context.TODO(), &dpls.Items[i]); err != nil {
+			t.Fatalf("failed to make deployment available: %v", err)
+		}
+	}
+}
+
+func resourceSpec(obj conformance.Object) *croType.ResourceTypeSpec {
+	switch cr := obj.(type) {
+	case *v1alpha1.Postgres:
+		return &cr.Spec
+	case *v1alpha1.Redis:
+		return &cr.Spec
+	}
+	return nil
+}
+
+func createConformanceCR(t *testing.T, c client.Client, obj conformance.Object) {
+	if err := c.Create(context.TODO(), obj); err != nil {
+		t.Fatalf("failed to create cr %s: %v", obj.GetName(), err)
+	}
+}
+
+func TestOpenShiftPostgresProvider_Conformance(t *testing.T) {
+	h := buildConformanceHarness(t)
+	p := &PostgresProvider{
+		Client:        h.Client,
+		Logger:        testLogger,
+		ConfigManager: buildDefaultConfigManager(),
+		PodCommander:  buildTestPodCommander(),
+	}
+	conformance.RunPostgres(t, h, p, func(t *testing.T, name string) *v1alpha1.Postgres {
+		ps := &v1alpha1.Postgres{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testConformanceNamespace}}
+		createConformanceCR(t, h.Client, ps)
+		return ps
+	})
+}
+
+func TestOpenShiftRedisProvider_Conformance(t *testing.T) {
+	h := buildConformanceHarness(t)
+	p := &RedisProvider{
+		Client:        h.Client,
+		Logger:        testLogger,
+		ConfigManager: buildDefaultConfigManager(),
+	}
+	conformance.RunRedis(t, h, p, func(t *testing.T, name string) *v1alpha1.Redis {
+		r := &v1alpha1.Redis{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testConformanceNamespace}}
+		createConformanceCR(t, h.Client, r)
+		return r
+	})
+}
+
+func TestBlobStorageProvider_Conformance(t *testing.T) {
+	h := buildConformanceHarness(t)
+	// the blob storage is a placeholder secret, there's nothing to resize
+	h.Resize = nil
+	h.Gone = nil
+	p := NewBlobStorageProvider(h.Client, testLogger)
+	conformance.RunBlobStorage(t, h, p, func(t *testing.T, name string) *v1alpha1.BlobStorage {
+		bs := &v1alpha1.BlobStorage{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testConformanceNamespace},
+			Spec:       croType.ResourceTypeSpec{SecretRef: &croType.SecretRef{Name: name}},
+		}
+		createConformanceCR(t, h.Client, bs)
+		return bs
+	})
+}
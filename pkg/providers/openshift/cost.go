@@ -0,0 +1,52 @@
+package openshift
+
+import (
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// workloadUsage is the cluster capacity requested by the workload objects of a resource
+type workloadUsage struct {
+	cpuCores   float64
+	memoryGiB  float64
+	storageGiB float64
+}
+
+// addPods adds the cpu and memory requested by each replica of the pod, the limits are used for containers without
+// requests
+func (u *workloadUsage) addPods(replicas *int32, spec v1.PodSpec) {
+	count := float64(1)
+	if replicas != nil {
+		count = float64(*replicas)
+	}
+	for _, c := range spec.Containers {
+		u.cpuCores += count * requestedQuantity(c.Resources, v1.ResourceCPU).AsApproximateFloat64()
+		u.memoryGiB += count * requestedQuantity(c.Resources, v1.ResourceMemory).AsApproximateFloat64() / resources.BytesInGibiBytes
+	}
+}
+
+// addStorage adds the storage requested by each copy of the claim
+func (u *workloadUsage) addStorage(copies *int32, pvc v1.PersistentVolumeClaim) {
+	count := float64(1)
+	if copies != nil {
+		count = float64(*copies)
+	}
+	storage := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+	u.storageGiB += count * storage.AsApproximateFloat64() / resources.BytesInGibiBytes
+}
+
+func (u *workloadUsage) cost() *providers.Cost {
+	return providers.EstimateOpenShiftCost(u.cpuCores, u.memoryGiB, u.storageGiB)
+}
+
+func requestedQuantity(r v1.ResourceRequirements, name v1.ResourceName) *resource.Quantity {
+	if q, ok := r.Requests[name]; ok {
+		return &q
+	}
+	if q, ok := r.Limits[name]; ok {
+		return &q
+	}
+	return resource.NewQuantity(0, resource.DecimalSI)
+}
@@ -0,0 +1,293 @@
+package openshift
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources/metrics"
+	errorUtil "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/diff"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// lastAppliedAnnotation holds the desired state last applied to an object, used to work out which fields the operator
+// owns and which were set by defaults or other controllers
+const lastAppliedAnnotation = "integreatly.org/last-applied-configuration"
+
+// appliedStates tracks the state last applied to each object, to detect when another controller has changed an object
+// and the update is reverting its change
+var appliedStates = struct {
+	sync.Mutex
+	states map[string]map[string]interface{}
+}{states: map[string]map[string]interface{}{}}
+
+// controllerutil.CreateOrUpdate without mutating the original runtime.Object provided
+//
+// the changes made to an existing object are logged as a diff, and updates reverting an object to the state last
+// applied are counted by cro_resource_unexpected_reverts_total, making fights with other controllers visible.
+// an object reverted too often is reported in the cro_resource_reconcile_conflict metric and isn't updated for a
+// backoff period, so the operator doesn't churn against the other controller
+func immutableCreateOrUpdate(ctx context.Context, c client.Client, logger *logrus.Entry, o runtime.Object, cb func(existing runtime.Object) error) (controllerutil.OperationResult, error) {
+	key, err := objectKey(o)
+	if err != nil {
+		return controllerutil.OperationResultNone, errorUtil.Wrap(err, "failed to get metadata of object")
+	}
+	backingOff := conflictBackoffActive(key, time.Now())
+	copiedObj := o.DeepCopyObject()
+	var existingObj runtime.Object
+	or, err := controllerutil.CreateOrUpdate(ctx, c, copiedObj.(runtime.Object), func() error {
+		existingObj = copiedObj.DeepCopyObject()
+		existingMeta, err := meta.Accessor(existingObj)
+		if err != nil {
+			return errorUtil.Wrap(err, "failed to get metadata of object")
+		}
+		// an object in conflict is left as the other controller set it until the backoff ends
+		if backingOff && existingMeta.GetResourceVersion() != "" {
+			logger.Debugf("updates of %s are backed off because of a reconcile conflict", key)
+			return nil
+		}
+		if err := cb(copiedObj); err != nil {
+			return err
+		}
+		// generated objects are left alone by the gitops tools syncing the custom resources
+		accessor, err := meta.Accessor(copiedObj)
+		if err != nil {
+			return errorUtil.Wrap(err, "failed to get metadata of object")
+		}
+		resources.AddGitOpsAnnotations(accessor)
+		return resources.ApplyMetadata(ctx, c, accessor)
+	})
+	if err != nil {
+		return or, err
+	}
+	if or == controllerutil.OperationResultUpdated {
+		logUpdateDiff(logger, existingObj, copiedObj)
+	}
+	if or == controllerutil.OperationResultCreated || or == controllerutil.OperationResultUpdated {
+		trackAppliedState(ctx, c, logger, copiedObj, existingObj)
+	}
+	if or == controllerutil.OperationResultNone && !backingOff {
+		clearConflict(logger, copiedObj)
+	}
+	return or, nil
+}
+
+// deleteObject deletes an object applied with immutableCreateOrUpdate and forgets the state applied to it, so the
+// states of deleted objects aren't kept for the lifetime of the operator. a not found error is returned as is
+func deleteObject(ctx context.Context, c client.Client, o runtime.Object) error {
+	err := c.Delete(ctx, o)
+	if err != nil && !k8serr.IsNotFound(err) {
+		return err
+	}
+	forgetObject(o)
+	return err
+}
+
+// forgetObject removes the applied state and conflict tracked for the object
+func forgetObject(o runtime.Object) {
+	key, err := objectKey(o)
+	if err != nil {
+		return
+	}
+	appliedStates.Lock()
+	delete(appliedStates.states, key)
+	appliedStates.Unlock()
+
+	conflicts.Lock()
+	defer conflicts.Unlock()
+	if oc, ok := conflicts.objects[key]; ok {
+		if oc.fieldManager != "" {
+			metrics.DeleteReconcileConflict(oc.kind, oc.namespace, oc.name, oc.fieldManager)
+		}
+		delete(conflicts.objects, key)
+	}
+}
+
+// logUpdateDiff logs the fields changed by an update of the object
+func logUpdateDiff(logger *logrus.Entry, existing runtime.Object, updated runtime.Object) {
+	accessor, err := meta.Accessor(updated)
+	if err != nil {
+		logger.Warnf("failed to log update diff: %v", err)
+		return
+	}
+	fields := logrus.Fields{
+		"kind":      fmt.Sprintf("%T", updated),
+		"name":      accessor.GetName(),
+		"namespace": accessor.GetNamespace(),
+	}
+	// the values of a secret never reach the logs, only the keys changed are logged
+	if sec, ok := updated.(*v1.Secret); ok {
+		fields["changedKeys"] = changedSecretKeys(existing.(*v1.Secret), sec)
+	} else {
+		fields["diff"] = diff.ObjectReflectDiff(existing, updated)
+	}
+	resources.NewActionLoggerWithFields(logger, fields).Info("updated object to match the desired state")
+}
+
+// changedSecretKeys returns the sorted keys of the data and string data added, removed or changed between the secrets
+func changedSecretKeys(existing, updated *v1.Secret) []string {
+	changed := map[string]bool{}
+	for _, data := range []struct{ a, b map[string][]byte }{{existing.Data, updated.Data}, {updated.Data, existing.Data}} {
+		for k, v := range data.a {
+			if other, ok := data.b[k]; !ok || !bytes.Equal(v, other) {
+				changed[k] = true
+			}
+		}
+	}
+	for _, data := range []struct{ a, b map[string]string }{{existing.StringData, updated.StringData}, {updated.StringData, existing.StringData}} {
+		for k, v := range data.a {
+			if other, ok := data.b[k]; !ok || v != other {
+				changed[k] = true
+			}
+		}
+	}
+	keys := make([]string, 0, len(changed))
+	for k := range changed {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// trackAppliedState records the state applied to the object. applying the same state as the previous create or update
+// means the object was changed or deleted by something else since, and is counted as an unexpected revert
+func trackAppliedState(ctx context.Context, c client.Client, logger *logrus.Entry, applied runtime.Object, existing runtime.Object) {
+	accessor, err := meta.Accessor(applied)
+	if err != nil {
+		logger.Warnf("failed to track applied state: %v", err)
+		return
+	}
+	state, err := appliedState(applied)
+	if err != nil {
+		logger.Warnf("failed to track applied state of %s: %v", accessor.GetName(), err)
+		return
+	}
+	kind := fmt.Sprintf("%T", applied)
+	key, err := objectKey(applied)
+	if err != nil {
+		logger.Warnf("failed to track applied state of %s: %v", accessor.GetName(), err)
+		return
+	}
+
+	appliedStates.Lock()
+	lastState, found := appliedStates.states[key]
+	appliedStates.states[key] = state
+	appliedStates.Unlock()
+
+	// an object is only created or updated when it differs from the desired state, so applying the same state again
+	// means it was changed by something else
+	if !found || !equality.Semantic.DeepEqual(lastState, state) {
+		return
+	}
+	logger.Warnf("%s %s in namespace %s was changed outside of the operator, reverted to the desired state", kind, accessor.GetName(), accessor.GetNamespace())
+	metrics.IncUnexpectedReverts(kind, accessor.GetNamespace(), accessor.GetName())
+	recordRevert(ctx, c, logger, applied, existing, time.Now())
+}
+
+// appliedState returns the object without its type, status or the metadata set by the api server, so the states
+// applied in different reconciles can be compared
+func appliedState(o runtime.Object) (map[string]interface{}, error) {
+	state, err := runtime.DefaultUnstructuredConverter.ToUnstructured(o)
+	if err != nil {
+		return nil, err
+	}
+	// the type meta is only set on objects read from the api server
+	unstructured.RemoveNestedField(state, "apiVersion")
+	unstructured.RemoveNestedField(state, "kind")
+	unstructured.RemoveNestedField(state, "status")
+	for _, field := range []string{"resourceVersion", "generation", "uid", "creationTimestamp", "managedFields"} {
+		unstructured.RemoveNestedField(state, "metadata", field)
+	}
+	return state, nil
+}
+
+// threeWayMerge merges the desired state into the existing object, in the same way as kubectl apply. only the fields set
+// in the desired state, or removed from it since it was last applied, are changed. fields set by defaults or other
+// controllers, e.g. the cluster ip of a service, are kept
+func threeWayMerge(existing runtime.Object, desired runtime.Object) error {
+	existingMeta, err := meta.Accessor(existing)
+	if err != nil {
+		return errorUtil.Wrap(err, "failed to get metadata of existing object")
+	}
+	desiredCopy := desired.DeepCopyObject()
+	desiredMeta, err := meta.Accessor(desiredCopy)
+	if err != nil {
+		return errorUtil.Wrap(err, "failed to get metadata of desired object")
+	}
+
+	// the desired state, without the last applied annotation, is recorded on the object for the next merge
+	annotations := map[string]string{}
+	for k, v := range desiredMeta.GetAnnotations() {
+		annotations[k] = v
+	}
+	delete(annotations, lastAppliedAnnotation)
+	desiredMeta.SetAnnotations(annotations)
+	lastApplied, err := marshalDesiredState(desiredCopy)
+	if err != nil {
+		return errorUtil.Wrap(err, "failed to marshal desired state")
+	}
+	annotations[lastAppliedAnnotation] = string(lastApplied)
+	desiredMeta.SetAnnotations(annotations)
+	modified, err := marshalDesiredState(desiredCopy)
+	if err != nil {
+		return errorUtil.Wrap(err, "failed to marshal desired state")
+	}
+
+	// an object that doesn't exist yet is a copy of the desired object, and is created with only the desired state
+	current := []byte("{}")
+	if !equality.Semantic.DeepEqual(existing, desired) {
+		if current, err = json.Marshal(existing); err != nil {
+			return errorUtil.Wrap(err, "failed to marshal existing object")
+		}
+	}
+	var original []byte
+	if value, ok := existingMeta.GetAnnotations()[lastAppliedAnnotation]; ok {
+		original = []byte(value)
+	}
+	patchMeta, err := strategicpatch.NewPatchMetaFromStruct(existing)
+	if err != nil {
+		return errorUtil.Wrap(err, "failed to get patch metadata")
+	}
+	patch, err := strategicpatch.CreateThreeWayMergePatch(original, modified, current, patchMeta, true)
+	if err != nil {
+		return errorUtil.Wrap(err, "failed to create three way merge patch")
+	}
+	merged, err := strategicpatch.StrategicMergePatchUsingLookupPatchMeta(current, patch, patchMeta)
+	if err != nil {
+		return errorUtil.Wrap(err, "failed to apply three way merge patch")
+	}
+
+	// reset the existing object so fields removed by the patch aren't kept when unmarshalling
+	existingValue := reflect.ValueOf(existing).Elem()
+	existingValue.Set(reflect.Zero(existingValue.Type()))
+	if err := json.Unmarshal(merged, existing); err != nil {
+		return errorUtil.Wrap(err, "failed to unmarshal merged object")
+	}
+	return nil
+}
+
+// marshalDesiredState marshals the desired state without the fields that are only set by the api server, as the zero
+// values would otherwise remove them from the existing object
+func marshalDesiredState(o runtime.Object) ([]byte, error) {
+	state, err := appliedState(o)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(state)
+}
@@ -0,0 +1,337 @@
+package openshift
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources/metrics"
+	"github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	customMetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+func buildTestRevertConfigMap(namespace string, value string) *apiv1.ConfigMap {
+	return &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: namespace,
+		},
+		Data: map[string]string{
+			"key": value,
+		},
+	}
+}
+
+func getRevertMetricValue(t *testing.T, namespace string) float64 {
+	families, err := customMetrics.Registry.Gather()
+	if err != nil {
+		t.Fatal("failed to gather metrics", err)
+	}
+	for _, f := range families {
+		if f.GetName() != metrics.UnexpectedRevertsMetricName {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["namespace"] == namespace && labels["name"] == "test" && labels["kind"] == "*v1.ConfigMap" {
+				return m.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func Test_immutableCreateOrUpdate(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	applyDesired := func(ctx context.Context, c client.Client, namespace string) (controllerutil.OperationResult, error) {
+		desired := buildTestRevertConfigMap(namespace, "desired")
+		return immutableCreateOrUpdate(ctx, c, testLogger, desired, func(existing runtime.Object) error {
+			existing.(*apiv1.ConfigMap).Data = desired.Data
+			return nil
+		})
+	}
+	tests := []struct {
+		name       string
+		namespace  string
+		steps      func(ctx context.Context, c client.Client) error
+		wantResult controllerutil.OperationResult
+		wantValue  float64
+	}{
+		{
+			name:      "test unchanged object is not updated or counted as a revert",
+			namespace: "test-unchanged",
+			steps: func(ctx context.Context, c client.Client) error {
+				_, err := applyDesired(ctx, c, "test-unchanged")
+				return err
+			},
+			wantResult: controllerutil.OperationResultNone,
+			wantValue:  0,
+		},
+		{
+			name:      "test change of desired state is not counted as a revert",
+			namespace: "test-desired-change",
+			steps: func(ctx context.Context, c client.Client) error {
+				_, err := immutableCreateOrUpdate(ctx, c, testLogger, buildTestRevertConfigMap("test-desired-change", "previous"), func(existing runtime.Object) error {
+					existing.(*apiv1.ConfigMap).Data = map[string]string{"key": "previous"}
+					return nil
+				})
+				return err
+			},
+			wantResult: controllerutil.OperationResultUpdated,
+			wantValue:  0,
+		},
+		{
+			name:      "test external change is reverted and counted",
+			namespace: "test-external-change",
+			steps: func(ctx context.Context, c client.Client) error {
+				if _, err := applyDesired(ctx, c, "test-external-change"); err != nil {
+					return err
+				}
+				cm := buildTestRevertConfigMap("test-external-change", "")
+				if err := c.Get(ctx, client.ObjectKey{Name: cm.Name, Namespace: cm.Namespace}, cm); err != nil {
+					return err
+				}
+				cm.Data["key"] = "external"
+				return c.Update(ctx, cm)
+			},
+			wantResult: controllerutil.OperationResultUpdated,
+			wantValue:  1,
+		},
+		{
+			name:      "test external delete is reverted and counted",
+			namespace: "test-external-delete",
+			steps: func(ctx context.Context, c client.Client) error {
+				if _, err := applyDesired(ctx, c, "test-external-delete"); err != nil {
+					return err
+				}
+				return c.Delete(ctx, buildTestRevertConfigMap("test-external-delete", "desired"))
+			},
+			wantResult: controllerutil.OperationResultCreated,
+			wantValue:  1,
+		},
+		{
+			name:      "test delete by the operator forgets the applied state and isn't counted",
+			namespace: "test-operator-delete",
+			steps: func(ctx context.Context, c client.Client) error {
+				if _, err := applyDesired(ctx, c, "test-operator-delete"); err != nil {
+					return err
+				}
+				cm := buildTestRevertConfigMap("test-operator-delete", "desired")
+				if err := deleteObject(ctx, c, cm); err != nil {
+					return err
+				}
+				key, err := objectKey(cm)
+				if err != nil {
+					return err
+				}
+				appliedStates.Lock()
+				defer appliedStates.Unlock()
+				if _, ok := appliedStates.states[key]; ok {
+					return fmt.Errorf("applied state of %s was kept after it was deleted", key)
+				}
+				return nil
+			},
+			wantResult: controllerutil.OperationResultCreated,
+			wantValue:  0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			c := fake.NewFakeClientWithScheme(scheme)
+			if err := tt.steps(ctx, c); err != nil {
+				t.Fatal("failed to run test steps", err)
+			}
+			got, err := applyDesired(ctx, c, tt.namespace)
+			if err != nil {
+				t.Fatalf("immutableCreateOrUpdate() unexpected error = %v", err)
+			}
+			if got != tt.wantResult {
+				t.Errorf("immutableCreateOrUpdate() = %v, want %v", got, tt.wantResult)
+			}
+			if value := getRevertMetricValue(t, tt.namespace); value != tt.wantValue {
+				t.Errorf("immutableCreateOrUpdate() revert metric = %v, want %v", value, tt.wantValue)
+			}
+		})
+	}
+}
+
+func Test_immutableCreateOrUpdate_secretValuesNotLogged(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	out := &bytes.Buffer{}
+	logger := logrus.New()
+	logger.SetOutput(out)
+	logger.SetLevel(logrus.DebugLevel)
+	c := fake.NewFakeClientWithScheme(scheme)
+	apply := func(password string) {
+		desired := &apiv1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test-secret"},
+			Data:       map[string][]byte{"user": []byte("test-user"), "password": []byte(password)},
+			StringData: map[string]string{"token": password},
+		}
+		if _, err := immutableCreateOrUpdate(context.TODO(), c, logrus.NewEntry(logger), desired, func(existing runtime.Object) error {
+			existing.(*apiv1.Secret).Data = desired.Data
+			existing.(*apiv1.Secret).StringData = desired.StringData
+			return nil
+		}); err != nil {
+			t.Fatalf("immutableCreateOrUpdate() unexpected error = %v", err)
+		}
+	}
+	apply("old-secret-value")
+	apply("new-secret-value")
+
+	logs := out.String()
+	if !strings.Contains(logs, "updated object to match the desired state") {
+		t.Fatalf("logs = %s, want the update logged", logs)
+	}
+	for _, value := range []string{"old-secret-value", "new-secret-value", "test-user", "diff="} {
+		if strings.Contains(logs, value) {
+			t.Errorf("logs = %s, want no %q logged for a secret", logs, value)
+		}
+	}
+	if !strings.Contains(logs, "changedKeys=\"[password token]\"") {
+		t.Errorf("logs = %s, want the changed keys logged", logs)
+	}
+}
+
+func Test_immutableCreateOrUpdate_metadata(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	defer func(ns string) { resources.MetadataConfigMapNamespace = ns }(resources.MetadataConfigMapNamespace)
+	resources.MetadataConfigMapNamespace = "test-metadata"
+	metadataCM := &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: resources.DefaultMetadataConfigMapName, Namespace: "test-metadata"},
+		Data:       map[string]string{resources.MetadataLabelsKey: `{"cost-center": "1234"}`},
+	}
+	// an existing object without an owner is given the metadata once it's written, even if nothing else changed
+	existing := buildTestRevertConfigMap("test-metadata", "desired")
+	c := fake.NewFakeClientWithScheme(scheme, metadataCM, existing)
+	if _, err := immutableCreateOrUpdate(context.TODO(), c, testLogger, buildTestRevertConfigMap("test-metadata", "desired"), func(existing runtime.Object) error {
+		existing.(*apiv1.ConfigMap).Data = map[string]string{"key": "desired"}
+		return nil
+	}); err != nil {
+		t.Fatalf("immutableCreateOrUpdate() unexpected error = %v", err)
+	}
+	cm := &apiv1.ConfigMap{}
+	if err := c.Get(context.TODO(), client.ObjectKey{Name: "test", Namespace: "test-metadata"}, cm); err != nil {
+		t.Fatal("failed to get config map", err)
+	}
+	if cm.Labels["cost-center"] != "1234" {
+		t.Errorf("immutableCreateOrUpdate() labels = %v, want the metadata", cm.Labels)
+	}
+}
+
+func buildTestMergeService(selector map[string]string) *apiv1.Service {
+	return &apiv1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "test",
+		},
+		Spec: apiv1.ServiceSpec{
+			Ports: []apiv1.ServicePort{
+				{Name: "test", Port: 5432},
+			},
+			Selector: selector,
+		},
+	}
+}
+
+// buildTestAppliedService returns the service as it exists after the desired service was applied, with a cluster ip
+// and annotation set by something else
+func buildTestAppliedService(t *testing.T, applied *apiv1.Service) *apiv1.Service {
+	existing := applied.DeepCopy()
+	if err := threeWayMerge(existing, applied); err != nil {
+		t.Fatal("failed to apply service", err)
+	}
+	existing.ResourceVersion = "1"
+	existing.Spec.ClusterIP = "172.30.0.1"
+	existing.Annotations["test-annotation"] = "test"
+	return existing
+}
+
+func Test_threeWayMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing func(t *testing.T) *apiv1.Service
+		desired  *apiv1.Service
+		want     func(got *apiv1.Service) error
+	}{
+		{
+			name: "test new object is created with the desired state",
+			existing: func(t *testing.T) *apiv1.Service {
+				return buildTestMergeService(map[string]string{"deployment": "test"})
+			},
+			desired: buildTestMergeService(map[string]string{"deployment": "test"}),
+			want: func(got *apiv1.Service) error {
+				if !reflect.DeepEqual(got.Spec, buildTestMergeService(map[string]string{"deployment": "test"}).Spec) {
+					return fmt.Errorf("unexpected spec %+v", got.Spec)
+				}
+				if got.Annotations[lastAppliedAnnotation] == "" {
+					return fmt.Errorf("last applied annotation not set")
+				}
+				return nil
+			},
+		},
+		{
+			name: "test fields set by something else are kept",
+			existing: func(t *testing.T) *apiv1.Service {
+				return buildTestAppliedService(t, buildTestMergeService(map[string]string{"deployment": "test"}))
+			},
+			desired: buildTestMergeService(map[string]string{"deployment": "updated"}),
+			want: func(got *apiv1.Service) error {
+				if got.Spec.ClusterIP != "172.30.0.1" || got.Annotations["test-annotation"] != "test" {
+					return fmt.Errorf("fields set by something else were not kept %+v", got)
+				}
+				if !reflect.DeepEqual(got.Spec.Selector, map[string]string{"deployment": "updated"}) {
+					return fmt.Errorf("unexpected selector %v", got.Spec.Selector)
+				}
+				return nil
+			},
+		},
+		{
+			name: "test fields removed from the desired state are removed",
+			existing: func(t *testing.T) *apiv1.Service {
+				return buildTestAppliedService(t, buildTestMergeService(map[string]string{"deployment": "test", "removed": "test"}))
+			},
+			desired: buildTestMergeService(map[string]string{"deployment": "test"}),
+			want: func(got *apiv1.Service) error {
+				if !reflect.DeepEqual(got.Spec.Selector, map[string]string{"deployment": "test"}) {
+					return fmt.Errorf("unexpected selector %v", got.Spec.Selector)
+				}
+				return nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.existing(t)
+			if err := threeWayMerge(got, tt.desired); err != nil {
+				t.Fatalf("threeWayMerge() unexpected error = %v", err)
+			}
+			if err := tt.want(got); err != nil {
+				t.Errorf("threeWayMerge() %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package openshift
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	errorUtil "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func externalAccessServiceName(name string) string {
+	return fmt.Sprintf("%s-external", name)
+}
+
+// reconcileExternalAccessService exposes the port of the pods matching the selector on a load balancer service only
+// reachable from the allowed source ranges, the service is deleted when no source ranges are allowed. It returns the
+// endpoint of the load balancer, empty until the load balancer is assigned one
+func reconcileExternalAccessService(ctx context.Context, c client.Client, logger *logrus.Entry, name, ns string, port int, selector map[string]string, cidrs []string) (string, error) {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      externalAccessServiceName(name),
+			Namespace: ns,
+		},
+	}
+	if len(cidrs) == 0 {
+		if err := deleteObject(ctx, c, svc); err != nil && !k8serr.IsNotFound(err) {
+			return "", errorUtil.Wrapf(err, "failed to delete external access service %s", svc.Name)
+		}
+		return "", nil
+	}
+
+	or, err := immutableCreateOrUpdate(ctx, c, logger, svc, func(existing runtime.Object) error {
+		e := existing.(*v1.Service)
+		e.Spec.Type = v1.ServiceTypeLoadBalancer
+		e.Spec.Selector = selector
+		e.Spec.LoadBalancerSourceRanges = cidrs
+		e.Spec.Ports = []v1.ServicePort{
+			{
+				Name:       "external",
+				Protocol:   v1.ProtocolTCP,
+				Port:       int32(port),
+				TargetPort: intstr.FromInt(port),
+			},
+		}
+		return nil
+	})
+	if err != nil {
+		return "", errorUtil.Wrapf(err, "failed to create or update external access service %s, action was %s", svc.Name, or)
+	}
+	if err := c.Get(ctx, client.ObjectKey{Name: svc.Name, Namespace: svc.Namespace}, svc); err != nil {
+		return "", errorUtil.Wrapf(err, "failed to get external access service %s", svc.Name)
+	}
+	for _, ingress := range svc.Status.LoadBalancer.Ingress {
+		host := ingress.Hostname
+		if host == "" {
+			host = ingress.IP
+		}
+		if host != "" {
+			return net.JoinHostPort(host, strconv.Itoa(port)), nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,80 @@
+package openshift
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcileExternalAccessService(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	selector := map[string]string{"deployment": "test"}
+	tests := []struct {
+		name         string
+		existing     []*v1.Service
+		cidrs        []string
+		wantService  bool
+		wantEndpoint string
+	}{
+		{
+			name:        "test load balancer service is restricted to the source ranges",
+			cidrs:       []string{"10.0.0.0/16"},
+			wantService: true,
+		},
+		{
+			name: "test endpoint of the load balancer is returned",
+			existing: []*v1.Service{{
+				ObjectMeta: metav1.ObjectMeta{Name: externalAccessServiceName("test"), Namespace: "test"},
+				Status:     v1.ServiceStatus{LoadBalancer: v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{Hostname: "test.elb.example.com"}}}},
+			}},
+			cidrs:        []string{"10.0.0.0/16"},
+			wantService:  true,
+			wantEndpoint: "test.elb.example.com:5432",
+		},
+		{
+			name: "test load balancer service is deleted without source ranges",
+			existing: []*v1.Service{{
+				ObjectMeta: metav1.ObjectMeta{Name: externalAccessServiceName("test"), Namespace: "test"},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme)
+			for _, svc := range tt.existing {
+				if err := c.Create(context.TODO(), svc); err != nil {
+					t.Fatal("failed to create service", err)
+				}
+			}
+			got, err := reconcileExternalAccessService(context.TODO(), c, testLogger, "test", "test", defaultPostgresPort, selector, tt.cidrs)
+			if err != nil {
+				t.Fatalf("reconcileExternalAccessService() unexpected error = %v", err)
+			}
+			if got != tt.wantEndpoint {
+				t.Errorf("reconcileExternalAccessService() = %s, want %s", got, tt.wantEndpoint)
+			}
+			svc := &v1.Service{}
+			err = c.Get(context.TODO(), client.ObjectKey{Name: externalAccessServiceName("test"), Namespace: "test"}, svc)
+			if !tt.wantService {
+				if !k8serr.IsNotFound(err) {
+					t.Errorf("reconcileExternalAccessService() service = %v, want none", svc)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("failed to get service", err)
+			}
+			if svc.Spec.Type != v1.ServiceTypeLoadBalancer || len(svc.Spec.LoadBalancerSourceRanges) != 1 || svc.Spec.LoadBalancerSourceRanges[0] != tt.cidrs[0] {
+				t.Errorf("reconcileExternalAccessService() service spec = %v, want load balancer restricted to %v", svc.Spec, tt.cidrs)
+			}
+		})
+	}
+}
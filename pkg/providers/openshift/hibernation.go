@@ -0,0 +1,57 @@
+package openshift
+
+import (
+	"fmt"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// workloadReplicas returns the replicas of a deployment of the resource, zero while the resource is hibernated. The
+// deployment is applied with the replicas it had before once the resource is woken up
+func workloadReplicas(spec croType.ResourceTypeSpec, replicas *int32) *int32 {
+	if spec.Hibernate {
+		return int32Ptr(0)
+	}
+	return replicas
+}
+
+// reconcileHibernation reports the hibernation of the workload deployment in the stopped condition of the resource. It
+// returns a status message while the resource is hibernated, the deployment isn't checked for readiness until the
+// resource is woken up
+func reconcileHibernation(inst metav1.Object, spec *croType.ResourceTypeSpec, status *croType.ResourceTypeStatus, dpl *appsv1.Deployment) croType.StatusMessage {
+	setCondition := func(condStatus metav1.ConditionStatus, reason string, msg string) {
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               providers.StoppedCondition,
+			Status:             condStatus,
+			ObservedGeneration: inst.GetGeneration(),
+			Reason:             reason,
+			Message:            msg,
+		})
+	}
+
+	if spec.Hibernate {
+		// terminating pods still hold the pvc
+		if dpl.Status.Replicas > 0 {
+			msg := fmt.Sprintf("scaling deployment %s to zero replicas while hibernated", dpl.Name)
+			setCondition(metav1.ConditionFalse, providers.StoppingReason, msg)
+			return croType.StatusMessage(msg)
+		}
+		msg := fmt.Sprintf("deployment %s is scaled to zero replicas while hibernated, its pvc and secret are kept", dpl.Name)
+		setCondition(metav1.ConditionTrue, providers.StoppedReason, msg)
+		return croType.StatusMessage(msg)
+	}
+
+	if meta.FindStatusCondition(status.Conditions, providers.StoppedCondition) == nil {
+		return croType.StatusEmpty
+	}
+	if deploymentAvailable(dpl) {
+		meta.RemoveStatusCondition(&status.Conditions, providers.StoppedCondition)
+		return croType.StatusEmpty
+	}
+	setCondition(metav1.ConditionFalse, providers.StartingReason, fmt.Sprintf("restoring replicas of deployment %s as the resource was woken up", dpl.Name))
+	return croType.StatusEmpty
+}
@@ -0,0 +1,119 @@
+package openshift
+
+import (
+	"context"
+	"testing"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcileHibernation(t *testing.T) {
+	tests := []struct {
+		name       string
+		hibernate  bool
+		existing   *metav1.Condition
+		replicas   int32
+		available  bool
+		wantMsg    bool
+		wantReason string
+	}{
+		{
+			name:      "test running deployment is not reported",
+			replicas:  1,
+			available: true,
+		},
+		{
+			name:       "test hibernated deployment is scaling down",
+			hibernate:  true,
+			replicas:   1,
+			wantMsg:    true,
+			wantReason: providers.StoppingReason,
+		},
+		{
+			name:       "test hibernated deployment is scaled to zero",
+			hibernate:  true,
+			wantMsg:    true,
+			wantReason: providers.StoppedReason,
+		},
+		{
+			name:       "test woken up deployment is starting",
+			existing:   &metav1.Condition{Type: providers.StoppedCondition, Status: metav1.ConditionTrue, Reason: providers.StoppedReason},
+			replicas:   1,
+			wantReason: providers.StartingReason,
+		},
+		{
+			name:      "test condition is removed once the woken up deployment is available",
+			existing:  &metav1.Condition{Type: providers.StoppedCondition, Status: metav1.ConditionFalse, Reason: providers.StartingReason},
+			replicas:  1,
+			available: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := buildTestRedisCR()
+			r.Spec.Hibernate = tt.hibernate
+			if tt.existing != nil {
+				meta.SetStatusCondition(&r.Status.Conditions, *tt.existing)
+			}
+			dpl := buildTestDeploymentReady()
+			dpl.Status.Replicas = tt.replicas
+			if !tt.available {
+				dpl.Status.Conditions = nil
+			}
+			msg := reconcileHibernation(r, &r.Spec, &r.Status, dpl)
+			if (msg != croType.StatusEmpty) != tt.wantMsg {
+				t.Errorf("reconcileHibernation() = %q, want a message %v", msg, tt.wantMsg)
+			}
+			cond := meta.FindStatusCondition(r.Status.Conditions, providers.StoppedCondition)
+			if tt.wantReason == "" {
+				if cond != nil {
+					t.Errorf("reconcileHibernation() unexpected condition %+v", cond)
+				}
+				return
+			}
+			if cond == nil || cond.Reason != tt.wantReason {
+				t.Errorf("reconcileHibernation() expected a %s condition, got %+v", tt.wantReason, cond)
+			}
+		})
+	}
+}
+
+func TestOpenShiftRedisProvider_CreateRedisHibernated(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	ready := buildTestDeploymentReady()
+	ready.Status.Replicas = 1
+	c := fake.NewFakeClientWithScheme(scheme, ready, buildTestRedisCR())
+	p := &RedisProvider{Client: c, Logger: testLogger, ConfigManager: buildDefaultConfigManager()}
+	r := buildTestRedisCR()
+	r.Spec.Hibernate = true
+
+	got, msg, err := p.CreateRedis(context.TODO(), r)
+	if err != nil {
+		t.Fatalf("CreateRedis() unexpected error = %v", err)
+	}
+	if got != nil {
+		t.Errorf("CreateRedis() = %v, want no redis while hibernated", got)
+	}
+	if msg == croType.StatusEmpty {
+		t.Error("CreateRedis() expected a hibernation message")
+	}
+	dpl := &appsv1.Deployment{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: testRedisName, Namespace: testRedisNamespace}, dpl); err != nil {
+		t.Fatal("failed to get redis deployment", err)
+	}
+	if dpl.Spec.Replicas == nil || *dpl.Spec.Replicas != 0 {
+		t.Errorf("CreateRedis() deployment replicas = %v, want 0", dpl.Spec.Replicas)
+	}
+	if !providers.IsStopped(&r.Status) {
+		t.Error("CreateRedis() expected the redis to be reported as stopped")
+	}
+}
@@ -0,0 +1,239 @@
+package openshift
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// WorkloadIsolationNone creates workloads in the namespace of the custom resource
+	WorkloadIsolationNone = ""
+	// WorkloadIsolationDedicated creates workloads in an operator managed namespace per custom resource
+	WorkloadIsolationDedicated = "dedicated"
+	// WorkloadIsolationShared creates workloads in a single operator managed namespace shared by all custom resources
+	WorkloadIsolationShared = "shared"
+
+	defaultSharedWorkloadNamespace   = "cloud-resources-workloads"
+	dedicatedWorkloadNamespacePrefix = "cro"
+	workloadNetworkPolicyName        = "cloud-resources-ingress"
+	workloadResourceQuotaName        = "cloud-resources-quota"
+	namespaceNameLabel               = "kubernetes.io/metadata.name"
+
+	LabelWorkloadManaged = "cloud-resource-operator.integreatly.org/workload-namespace"
+	LabelOwnerName       = "cloud-resource-operator.integreatly.org/owner-name"
+	LabelOwnerNamespace  = "cloud-resource-operator.integreatly.org/owner-namespace"
+)
+
+// WorkloadIsolation configures the namespace in-cluster workloads are created in, separating data infrastructure
+// from application workloads
+type WorkloadIsolation struct {
+	// Mode is one of dedicated or shared, workloads are created in the namespace of the custom resource if unset
+	Mode string `json:"mode,omitempty"`
+	// SharedNamespace is the namespace used by the shared mode, defaults to cloud-resources-workloads
+	SharedNamespace string `json:"sharedNamespace,omitempty"`
+	// ResourceQuota is applied to dedicated namespaces
+	ResourceQuota *v1.ResourceQuotaSpec `json:"resourceQuota,omitempty"`
+}
+
+// workloadNamespace returns the namespace in which the workloads of the custom resource are created. Once recorded in
+// the status, the namespace the workloads were created in is kept, so changing the isolation doesn't orphan them
+func workloadNamespace(cr metav1.Object, status *croType.ResourceTypeStatus, isolation *WorkloadIsolation) string {
+	if ns := recordedWorkloadNamespace(status); ns != "" {
+		return ns
+	}
+	return isolatedWorkloadNamespace(cr, isolation)
+}
+
+// isolatedWorkloadNamespace returns the namespace the isolation creates the workloads of the custom resource in
+func isolatedWorkloadNamespace(cr metav1.Object, isolation *WorkloadIsolation) string {
+	if isolation == nil {
+		return cr.GetNamespace()
+	}
+	switch isolation.Mode {
+	case WorkloadIsolationDedicated:
+		// custom resource names may contain dots, which namespace names can't
+		ns := fmt.Sprintf("%s-%s-%s", dedicatedWorkloadNamespacePrefix, cr.GetNamespace(), cr.GetName())
+		if len(ns) > 63 {
+			return resources.ShortenString(ns, 63)
+		}
+		return strings.ReplaceAll(ns, ".", "-")
+	case WorkloadIsolationShared:
+		return resources.StringOrDefault(isolation.SharedNamespace, defaultSharedWorkloadNamespace)
+	}
+	return cr.GetNamespace()
+}
+
+// recordedWorkloadNamespace returns the namespace of the workloads recorded in the cloud resource status, empty if
+// none is recorded yet
+func recordedWorkloadNamespace(status *croType.ResourceTypeStatus) string {
+	if status == nil || status.CloudResource == nil {
+		return ""
+	}
+	parts := strings.SplitN(status.CloudResource.InstanceID, "/", 2)
+	if len(parts) != 2 {
+		return ""
+	}
+	return parts[0]
+}
+
+// recordWorkloadNamespace records the workload in the cloud resource status before its objects are created, so the
+// namespace is known to later reconciles and to the deletion of the custom resource
+func recordWorkloadNamespace(status *croType.ResourceTypeStatus, workload metav1.Object) {
+	if recordedWorkloadNamespace(status) != "" {
+		return
+	}
+	status.CloudResource = &croType.CloudResourceStatus{
+		InstanceID: fmt.Sprintf("%s/%s", workload.GetNamespace(), workload.GetName()),
+	}
+}
+
+// buildWorkloadCloudResourceStatus returns the cloud resource status of an in-cluster workload, identified by its
+// namespace and name as it may not be in the namespace of the custom resource
+func buildWorkloadCloudResourceStatus(workload metav1.Object, host string, port int) *croType.CloudResourceStatus {
+	return &croType.CloudResourceStatus{
+		InstanceID: fmt.Sprintf("%s/%s", workload.GetNamespace(), workload.GetName()),
+		Endpoint:   fmt.Sprintf("%s:%d", host, port),
+	}
+}
+
+// deletedWorkloadNamespace returns the namespace to delete the workloads of the custom resource from. Custom resources
+// without a recorded namespace fall back to the isolation of their strategy, or to their own namespace if the strategy
+// can't be read, so a missing tier or strategy never blocks their deletion
+func deletedWorkloadNamespace(logger *logrus.Entry, cr metav1.Object, status *croType.ResourceTypeStatus, getIsolation func() (*WorkloadIsolation, error)) string {
+	if ns := recordedWorkloadNamespace(status); ns != "" {
+		return ns
+	}
+	isolation, err := getIsolation()
+	if err != nil {
+		logger.Warnf("failed to get the workload isolation of %s, deleting its workloads from its namespace: %v", cr.GetName(), err)
+		return cr.GetNamespace()
+	}
+	return isolatedWorkloadNamespace(cr, isolation)
+}
+
+// reconcileWorkloadNamespace ensures the workload namespace of the custom resource exists and returns its name.
+// operator managed namespaces only accept ingress from the namespace of the custom resources they serve. Workloads
+// created before the isolation changed are left in the namespace recorded in the status
+func reconcileWorkloadNamespace(ctx context.Context, c client.Client, cr metav1.Object, status *croType.ResourceTypeStatus, isolation *WorkloadIsolation) (string, error) {
+	ns := workloadNamespace(cr, status, isolation)
+	if ns == cr.GetNamespace() || ns != isolatedWorkloadNamespace(cr, isolation) {
+		return ns, nil
+	}
+
+	namespace := &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: ns,
+		},
+	}
+	if _, err := controllerutil.CreateOrUpdate(ctx, c, namespace, func() error {
+		if namespace.Labels == nil {
+			namespace.Labels = map[string]string{}
+		}
+		namespace.Labels[LabelWorkloadManaged] = "true"
+		resources.AddGitOpsAnnotations(namespace)
+		if isolation.Mode == WorkloadIsolationDedicated {
+			namespace.Labels[LabelOwnerName] = cr.GetName()
+			namespace.Labels[LabelOwnerNamespace] = cr.GetNamespace()
+		}
+		return resources.ApplyMetadata(ctx, c, namespace)
+	}); err != nil {
+		return "", errorUtil.Wrapf(err, "failed to reconcile workload namespace %s", ns)
+	}
+
+	if isolation.Mode == WorkloadIsolationDedicated && isolation.ResourceQuota != nil {
+		quota := &v1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      workloadResourceQuotaName,
+				Namespace: ns,
+			},
+		}
+		if _, err := controllerutil.CreateOrUpdate(ctx, c, quota, func() error {
+			quota.Spec = *isolation.ResourceQuota
+			resources.AddGitOpsAnnotations(quota)
+			return resources.ApplyMetadata(ctx, c, quota)
+		}); err != nil {
+			return "", errorUtil.Wrapf(err, "failed to reconcile resource quota in workload namespace %s", ns)
+		}
+	}
+
+	// allow ingress from within the workload namespace and from the namespace of the custom resource, shared
+	// namespaces accumulate a peer for every namespace they serve
+	policy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      workloadNetworkPolicyName,
+			Namespace: ns,
+		},
+	}
+	if _, err := controllerutil.CreateOrUpdate(ctx, c, policy, func() error {
+		policy.Spec.PodSelector = metav1.LabelSelector{}
+		resources.AddGitOpsAnnotations(policy)
+		policy.Spec.PolicyTypes = []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}
+		if len(policy.Spec.Ingress) == 0 {
+			policy.Spec.Ingress = []networkingv1.NetworkPolicyIngressRule{{}}
+		}
+		for _, allowed := range []string{ns, cr.GetNamespace()} {
+			if !hasNamespacePeer(policy.Spec.Ingress[0].From, allowed) {
+				policy.Spec.Ingress[0].From = append(policy.Spec.Ingress[0].From, buildNamespacePeer(allowed))
+			}
+		}
+		return resources.ApplyMetadata(ctx, c, policy)
+	}); err != nil {
+		return "", errorUtil.Wrapf(err, "failed to reconcile network policy in workload namespace %s", ns)
+	}
+	return ns, nil
+}
+
+// deleteWorkloadNamespace removes the workload namespace if it's the dedicated namespace of the custom resource, shared
+// namespaces are left in place as they may hold the workloads of other custom resources. Dedicated namespaces are
+// identified by their labels, so they're removed whatever the isolation is now
+func deleteWorkloadNamespace(ctx context.Context, c client.Client, cr metav1.Object, ns string) error {
+	if ns == cr.GetNamespace() {
+		return nil
+	}
+	namespace := &v1.Namespace{}
+	if err := c.Get(ctx, types.NamespacedName{Name: ns}, namespace); err != nil {
+		if k8serr.IsNotFound(err) {
+			return nil
+		}
+		return errorUtil.Wrapf(err, "failed to get workload namespace %s", ns)
+	}
+	if namespace.Labels[LabelOwnerName] != cr.GetName() || namespace.Labels[LabelOwnerNamespace] != cr.GetNamespace() {
+		return nil
+	}
+	if err := c.Delete(ctx, namespace); err != nil && !k8serr.IsNotFound(err) {
+		return errorUtil.Wrapf(err, "failed to delete workload namespace %s", namespace.Name)
+	}
+	return nil
+}
+
+func buildNamespacePeer(ns string) networkingv1.NetworkPolicyPeer {
+	return networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				namespaceNameLabel: ns,
+			},
+		},
+	}
+}
+
+func hasNamespacePeer(peers []networkingv1.NetworkPolicyPeer, ns string) bool {
+	for _, peer := range peers {
+		if peer.NamespaceSelector != nil && peer.NamespaceSelector.MatchLabels[namespaceNameLabel] == ns {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,218 @@
+package openshift
+
+import (
+	"context"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestWorkloadNamespace(t *testing.T) {
+	cr := &v1alpha1.Redis{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "test-ns",
+		},
+	}
+	tests := []struct {
+		name      string
+		cr        *v1alpha1.Redis
+		status    *croType.ResourceTypeStatus
+		isolation *WorkloadIsolation
+		want      string
+	}{
+		{
+			name:      "test cr namespace is used when isolation is not set",
+			isolation: nil,
+			want:      "test-ns",
+		},
+		{
+			name:      "test cr namespace is used when isolation mode is not set",
+			isolation: &WorkloadIsolation{},
+			want:      "test-ns",
+		},
+		{
+			name:      "test dedicated namespace per cr",
+			isolation: &WorkloadIsolation{Mode: WorkloadIsolationDedicated},
+			want:      "cro-test-ns-test",
+		},
+		{
+			name:      "test default shared namespace",
+			isolation: &WorkloadIsolation{Mode: WorkloadIsolationShared},
+			want:      defaultSharedWorkloadNamespace,
+		},
+		{
+			name:      "test configured shared namespace",
+			isolation: &WorkloadIsolation{Mode: WorkloadIsolationShared, SharedNamespace: "data"},
+			want:      "data",
+		},
+		{
+			name:      "test dots of the cr name are replaced in the dedicated namespace",
+			cr:        &v1alpha1.Redis{ObjectMeta: metav1.ObjectMeta{Name: "test.cache", Namespace: "test-ns"}},
+			isolation: &WorkloadIsolation{Mode: WorkloadIsolationDedicated},
+			want:      "cro-test-ns-test-cache",
+		},
+		{
+			name:      "test namespace recorded in the status is kept when the isolation changes",
+			status:    &croType.ResourceTypeStatus{CloudResource: &croType.CloudResourceStatus{InstanceID: "cro-test-ns-test/test"}},
+			isolation: &WorkloadIsolation{Mode: WorkloadIsolationShared},
+			want:      "cro-test-ns-test",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := cr
+			if tt.cr != nil {
+				cr = tt.cr
+			}
+			if got := workloadNamespace(cr, tt.status, tt.isolation); got != tt.want {
+				t.Errorf("workloadNamespace() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconcileWorkloadNamespace(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	cr := &v1alpha1.Redis{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "test-ns",
+		},
+	}
+
+	tests := []struct {
+		name      string
+		client    client.Client
+		isolation *WorkloadIsolation
+		want      string
+		wantQuota bool
+		wantErr   bool
+	}{
+		{
+			name:      "test no namespace is created without isolation",
+			client:    fake.NewFakeClientWithScheme(scheme),
+			isolation: nil,
+			want:      "test-ns",
+		},
+		{
+			name:   "test dedicated namespace is created with quota and network policy",
+			client: fake.NewFakeClientWithScheme(scheme),
+			isolation: &WorkloadIsolation{
+				Mode: WorkloadIsolationDedicated,
+				ResourceQuota: &v1.ResourceQuotaSpec{
+					Hard: v1.ResourceList{
+						v1.ResourceRequestsStorage: resource.MustParse("10Gi"),
+					},
+				},
+			},
+			want:      "cro-test-ns-test",
+			wantQuota: true,
+		},
+		{
+			name:      "test shared namespace is created without quota",
+			client:    fake.NewFakeClientWithScheme(scheme),
+			isolation: &WorkloadIsolation{Mode: WorkloadIsolationShared},
+			want:      defaultSharedWorkloadNamespace,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := reconcileWorkloadNamespace(context.TODO(), tt.client, cr, &croType.ResourceTypeStatus{}, tt.isolation)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("reconcileWorkloadNamespace() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("reconcileWorkloadNamespace() got = %v, want %v", got, tt.want)
+			}
+			if got == cr.Namespace {
+				return
+			}
+
+			ns := &v1.Namespace{}
+			if err := tt.client.Get(context.TODO(), types.NamespacedName{Name: got}, ns); err != nil {
+				t.Fatalf("reconcileWorkloadNamespace() expected namespace %s, got error = %v", got, err)
+			}
+			policy := &networkingv1.NetworkPolicy{}
+			if err := tt.client.Get(context.TODO(), types.NamespacedName{Name: workloadNetworkPolicyName, Namespace: got}, policy); err != nil {
+				t.Fatalf("reconcileWorkloadNamespace() expected network policy, got error = %v", err)
+			}
+			if !hasNamespacePeer(policy.Spec.Ingress[0].From, cr.Namespace) {
+				t.Errorf("reconcileWorkloadNamespace() expected ingress to be allowed from %s", cr.Namespace)
+			}
+			quota := &v1.ResourceQuota{}
+			err = tt.client.Get(context.TODO(), types.NamespacedName{Name: workloadResourceQuotaName, Namespace: got}, quota)
+			if tt.wantQuota && err != nil {
+				t.Errorf("reconcileWorkloadNamespace() expected resource quota, got error = %v", err)
+			}
+			if !tt.wantQuota && !k8serr.IsNotFound(err) {
+				t.Errorf("reconcileWorkloadNamespace() expected no resource quota, got error = %v", err)
+			}
+		})
+	}
+}
+
+func TestDeleteWorkloadNamespace(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	cr := &v1alpha1.Redis{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "test-ns",
+		},
+	}
+	dedicated := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "cro-test-ns-test", Labels: map[string]string{
+		LabelWorkloadManaged: "true",
+		LabelOwnerName:       cr.Name,
+		LabelOwnerNamespace:  cr.Namespace,
+	}}}
+	shared := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: defaultSharedWorkloadNamespace, Labels: map[string]string{
+		LabelWorkloadManaged: "true",
+	}}}
+
+	tests := []struct {
+		name        string
+		ns          string
+		wantDeleted bool
+	}{
+		{
+			name:        "test dedicated namespace of the cr is deleted",
+			ns:          dedicated.Name,
+			wantDeleted: true,
+		},
+		{
+			name: "test shared namespace is kept",
+			ns:   shared.Name,
+		},
+		{
+			name: "test missing namespace is ignored",
+			ns:   "missing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme, dedicated.DeepCopy(), shared.DeepCopy())
+			if err := deleteWorkloadNamespace(context.TODO(), c, cr, tt.ns); err != nil {
+				t.Fatalf("deleteWorkloadNamespace() unexpected error = %v", err)
+			}
+			err := c.Get(context.TODO(), types.NamespacedName{Name: tt.ns}, &v1.Namespace{})
+			if deleted := k8serr.IsNotFound(err); deleted != tt.wantDeleted && tt.ns != "missing" {
+				t.Errorf("deleteWorkloadNamespace() deleted = %v, want %v", deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package openshift
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/integr8ly/cloud-resource-operator/internal/k8sutil"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// WorkloadNetworkPolicy restricts the ingress to the pods of an in-cluster workload to the pods of the allowed
+// namespaces. The pods of the workload, the operator and the clients allowed by the external access of the custom
+// resource are always allowed
+type WorkloadNetworkPolicy struct {
+	// AllowedNamespaces are the names of the namespaces whose pods may connect, defaults to the namespace of the custom
+	// resource if no namespace selector is set
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
+	// NamespaceSelector selects the labels of further namespaces whose pods may connect
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// PodSelector restricts the pods of the allowed namespaces that may connect, every pod of them if unset
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+}
+
+func ingressNetworkPolicyName(name string) string {
+	return fmt.Sprintf("%s-ingress", name)
+}
+
+// validateWorkloadNetworkPolicy returns an error if the allowed namespaces or selectors of the policy are invalid
+func validateWorkloadNetworkPolicy(policy *WorkloadNetworkPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	for _, ns := range policy.AllowedNamespaces {
+		if ns == "" {
+			return errorUtil.New("allowed namespaces must not be empty")
+		}
+	}
+	for _, selector := range []*metav1.LabelSelector{policy.NamespaceSelector, policy.PodSelector} {
+		if selector == nil {
+			continue
+		}
+		if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+			return errorUtil.Wrap(err, "invalid label selector")
+		}
+	}
+	return nil
+}
+
+// reconcileWorkloadNetworkPolicy restricts the ingress to the pods of the deployments of a workload to the peers
+// allowed by the policy, the network policy is deleted when no policy is set so the workload accepts any ingress.
+// the deployments of the workload connect to each other, e.g. a pooler to its postgres, and are always allowed
+func reconcileWorkloadNetworkPolicy(ctx context.Context, c client.Client, logger *logrus.Entry, cr metav1.Object, name, ns string, deployments []string, policy *WorkloadNetworkPolicy, cidrs []string) error {
+	np := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ingressNetworkPolicyName(name),
+			Namespace: ns,
+		},
+	}
+	if policy == nil {
+		if err := c.Delete(ctx, np); err != nil && !k8serr.IsNotFound(err) {
+			return errorUtil.Wrapf(err, "failed to delete network policy %s", np.Name)
+		}
+		return nil
+	}
+
+	workloadPods := metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "deployment", Operator: metav1.LabelSelectorOpIn, Values: deployments},
+		},
+	}
+	peers := []networkingv1.NetworkPolicyPeer{{PodSelector: workloadPods.DeepCopy()}}
+	// the operator connects to the workload to check its connectivity, it's not a pod when run locally
+	if operatorNs, err := k8sutil.GetOperatorNamespace(); err == nil {
+		peers = append(peers, buildNamespacePeer(operatorNs))
+	} else {
+		logger.Debugf("operator namespace not found, it's not allowed by network policy %s: %v", np.Name, err)
+	}
+	allowed := policy.AllowedNamespaces
+	if len(allowed) == 0 && policy.NamespaceSelector == nil {
+		allowed = []string{cr.GetNamespace()}
+	}
+	for _, allowedNs := range allowed {
+		peer := buildNamespacePeer(allowedNs)
+		peer.PodSelector = policy.PodSelector.DeepCopy()
+		peers = append(peers, peer)
+	}
+	if policy.NamespaceSelector != nil {
+		peers = append(peers, networkingv1.NetworkPolicyPeer{
+			NamespaceSelector: policy.NamespaceSelector.DeepCopy(),
+			PodSelector:       policy.PodSelector.DeepCopy(),
+		})
+	}
+	for _, cidr := range cidrs {
+		peers = append(peers, networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: cidr}})
+	}
+
+	or, err := controllerutil.CreateOrUpdate(ctx, c, np, func() error {
+		resources.AddGitOpsAnnotations(np)
+		np.Spec.PodSelector = workloadPods
+		np.Spec.PolicyTypes = []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}
+		np.Spec.Ingress = []networkingv1.NetworkPolicyIngressRule{{From: peers}}
+		return resources.ApplyMetadata(ctx, c, np)
+	})
+	if err != nil {
+		return errorUtil.Wrapf(err, "failed to create or update network policy %s, action was %s", np.Name, or)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package openshift
+
+import (
+	"context"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/internal/k8sutil"
+	"github.com/spf13/afero"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestValidateWorkloadNetworkPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  *WorkloadNetworkPolicy
+		wantErr bool
+	}{
+		{
+			name:   "test no policy is valid",
+			policy: nil,
+		},
+		{
+			name: "test allowed namespaces and selectors are valid",
+			policy: &WorkloadNetworkPolicy{
+				AllowedNamespaces: []string{"app"},
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}},
+				PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}},
+			},
+		},
+		{
+			name:    "test an empty allowed namespace is invalid",
+			policy:  &WorkloadNetworkPolicy{AllowedNamespaces: []string{""}},
+			wantErr: true,
+		},
+		{
+			name: "test an invalid selector is invalid",
+			policy: &WorkloadNetworkPolicy{PodSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "app", Operator: "Unknown"},
+			}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateWorkloadNetworkPolicy(tt.policy); (err != nil) != tt.wantErr {
+				t.Errorf("validateWorkloadNetworkPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReconcileWorkloadNetworkPolicy(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	// run the operator locally in the watched namespace
+	defer func(fs afero.Fs) { k8sutil.AppFS = fs }(k8sutil.AppFS)
+	k8sutil.AppFS = afero.NewMemMapFs()
+	t.Setenv("WATCH_NAMESPACE", "test-operator")
+
+	cr := &v1alpha1.Postgres{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test-ns"}}
+	deployments := []string{"test", postgresPoolerName("test")}
+	key := types.NamespacedName{Name: ingressNetworkPolicyName(cr.Name), Namespace: cr.Namespace}
+	tests := []struct {
+		name      string
+		policy    *WorkloadNetworkPolicy
+		cidrs     []string
+		wantPeers int
+		verify    func(t *testing.T, peers []networkingv1.NetworkPolicyPeer)
+	}{
+		{
+			name:      "test the namespace of the cr is allowed by default",
+			policy:    &WorkloadNetworkPolicy{},
+			wantPeers: 3,
+			verify: func(t *testing.T, peers []networkingv1.NetworkPolicyPeer) {
+				if !hasNamespacePeer(peers, cr.Namespace) {
+					t.Errorf("peers = %v, want the namespace of the cr allowed", peers)
+				}
+			},
+		},
+		{
+			name: "test allowed namespaces and selected namespaces are restricted to the selected pods",
+			policy: &WorkloadNetworkPolicy{
+				AllowedNamespaces: []string{"app"},
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}},
+				PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}},
+			},
+			wantPeers: 4,
+			verify: func(t *testing.T, peers []networkingv1.NetworkPolicyPeer) {
+				if hasNamespacePeer(peers, cr.Namespace) {
+					t.Errorf("peers = %v, want the namespace of the cr not allowed", peers)
+				}
+				for _, peer := range peers[2:] {
+					if peer.PodSelector == nil || peer.PodSelector.MatchLabels["app"] != "api" {
+						t.Errorf("peer = %v, want it restricted to the selected pods", peer)
+					}
+				}
+			},
+		},
+		{
+			name:      "test external access cidrs are allowed",
+			policy:    &WorkloadNetworkPolicy{AllowedNamespaces: []string{"app"}},
+			cidrs:     []string{"10.0.0.0/16"},
+			wantPeers: 4,
+			verify: func(t *testing.T, peers []networkingv1.NetworkPolicyPeer) {
+				if last := peers[len(peers)-1]; last.IPBlock == nil || last.IPBlock.CIDR != "10.0.0.0/16" {
+					t.Errorf("peer = %v, want the external access cidr allowed", last)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme)
+			if err := reconcileWorkloadNetworkPolicy(context.TODO(), c, testLogger, cr, cr.Name, cr.Namespace, deployments, tt.policy, tt.cidrs); err != nil {
+				t.Fatalf("reconcileWorkloadNetworkPolicy() unexpected error = %v", err)
+			}
+			np := &networkingv1.NetworkPolicy{}
+			if err := c.Get(context.TODO(), key, np); err != nil {
+				t.Fatalf("failed to get network policy: %v", err)
+			}
+			if got := np.Spec.PodSelector.MatchExpressions[0].Values; len(got) != len(deployments) {
+				t.Errorf("network policy selects deployments %v, want %v", got, deployments)
+			}
+			if len(np.Spec.Ingress) != 1 || len(np.Spec.Ingress[0].From) != tt.wantPeers {
+				t.Fatalf("network policy ingress = %v, want %d peers", np.Spec.Ingress, tt.wantPeers)
+			}
+			// the pods of the workload connect to each other
+			if self := np.Spec.Ingress[0].From[0]; self.NamespaceSelector != nil || self.PodSelector == nil {
+				t.Errorf("first peer = %v, want the pods of the workload", self)
+			}
+			// the operator checks the connectivity of the workload
+			if !hasNamespacePeer(np.Spec.Ingress[0].From, "test-operator") {
+				t.Errorf("network policy ingress = %v, want the operator namespace allowed", np.Spec.Ingress)
+			}
+			tt.verify(t, np.Spec.Ingress[0].From)
+
+			if err := reconcileWorkloadNetworkPolicy(context.TODO(), c, testLogger, cr, cr.Name, cr.Namespace, nil, nil, nil); err != nil {
+				t.Fatalf("reconcileWorkloadNetworkPolicy() unexpected error deleting the policy = %v", err)
+			}
+			if err := c.Get(context.TODO(), key, np); !k8serr.IsNotFound(err) {
+				t.Errorf("network policy error = %v, want the policy deleted without a policy", err)
+			}
+		})
+	}
+}
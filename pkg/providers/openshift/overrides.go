@@ -0,0 +1,95 @@
+package openshift
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WorkloadOverrides are merged onto the generated defaults of an in-cluster workload, so a single value can be changed
+// without copying the whole spec into the strategy
+type WorkloadOverrides struct {
+	// Image replaces the image of the database container
+	Image string `json:"image,omitempty"`
+	// Resources replaces the limits and requests set, limits and requests which aren't set keep their defaults
+	Resources *v1.ResourceRequirements `json:"resources,omitempty"`
+	// Env is added to the database container, a variable with the same name as a default variable replaces it
+	Env []v1.EnvVar `json:"env,omitempty"`
+	// StorageSize replaces the storage requested by the persistent volume claim, including one set by pvcSpec
+	StorageSize *resource.Quantity `json:"storageSize,omitempty"`
+	// Labels are added to the workload objects and the pod template
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// applyPodTemplateOverrides merges the overrides onto the pod template and its container with the name
+func applyPodTemplateOverrides(template *v1.PodTemplateSpec, containerName string, o *WorkloadOverrides) {
+	if o == nil {
+		return
+	}
+	applyLabelOverrides(&template.ObjectMeta, o)
+	for i := range template.Spec.Containers {
+		if template.Spec.Containers[i].Name == containerName {
+			applyContainerOverrides(&template.Spec.Containers[i], o)
+		}
+	}
+}
+
+func applyContainerOverrides(c *v1.Container, o *WorkloadOverrides) {
+	if o.Image != "" {
+		c.Image = o.Image
+	}
+	if o.Resources != nil {
+		c.Resources.Limits = mergeResourceList(c.Resources.Limits, o.Resources.Limits)
+		c.Resources.Requests = mergeResourceList(c.Resources.Requests, o.Resources.Requests)
+	}
+	for _, env := range o.Env {
+		c.Env = mergeEnvVar(c.Env, env)
+	}
+}
+
+func mergeResourceList(defaults v1.ResourceList, overrides v1.ResourceList) v1.ResourceList {
+	if len(overrides) == 0 {
+		return defaults
+	}
+	merged := v1.ResourceList{}
+	for name, quantity := range defaults {
+		merged[name] = quantity
+	}
+	for name, quantity := range overrides {
+		merged[name] = quantity
+	}
+	return merged
+}
+
+func mergeEnvVar(envs []v1.EnvVar, env v1.EnvVar) []v1.EnvVar {
+	for i := range envs {
+		if envs[i].Name == env.Name {
+			envs[i] = env
+			return envs
+		}
+	}
+	return append(envs, env)
+}
+
+// applyLabelOverrides adds the override labels to the object meta
+func applyLabelOverrides(obj metav1.Object, o *WorkloadOverrides) {
+	if o == nil || len(o.Labels) == 0 {
+		return
+	}
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for k, v := range o.Labels {
+		labels[k] = v
+	}
+	obj.SetLabels(labels)
+}
+
+// overrideStorageRequests returns the storage requests with the storage size override applied
+func overrideStorageRequests(requests v1.ResourceList, o *WorkloadOverrides) v1.ResourceList {
+	if o == nil || o.StorageSize == nil {
+		return requests
+	}
+	return mergeResourceList(requests, v1.ResourceList{v1.ResourceStorage: *o.StorageSize})
+}
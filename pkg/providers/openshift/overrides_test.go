@@ -0,0 +1,160 @@
+package openshift
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func buildTestOverridesPodTemplate() v1.PodTemplateSpec {
+	return v1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{"deployment": "test"},
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name:  "test",
+					Image: "default",
+					Env:   []v1.EnvVar{{Name: "DEFAULT", Value: "default"}},
+					Resources: v1.ResourceRequirements{
+						Limits: v1.ResourceList{
+							v1.ResourceCPU:    resource.MustParse("250m"),
+							v1.ResourceMemory: resource.MustParse("2Gi"),
+						},
+					},
+				},
+				{
+					Name:  "sidecar",
+					Image: "sidecar",
+				},
+			},
+		},
+	}
+}
+
+func Test_applyPodTemplateOverrides(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides *WorkloadOverrides
+		want      func() v1.PodTemplateSpec
+	}{
+		{
+			name:      "test pod template is unchanged without overrides",
+			overrides: nil,
+			want:      buildTestOverridesPodTemplate,
+		},
+		{
+			name: "test overrides are merged onto the database container and pod labels",
+			overrides: &WorkloadOverrides{
+				Image: "custom",
+				Resources: &v1.ResourceRequirements{
+					Limits: v1.ResourceList{
+						v1.ResourceMemory: resource.MustParse("4Gi"),
+					},
+				},
+				Env: []v1.EnvVar{
+					{Name: "DEFAULT", Value: "custom"},
+					{Name: "EXTRA", Value: "extra"},
+				},
+				Labels: map[string]string{"team": "test"},
+			},
+			want: func() v1.PodTemplateSpec {
+				template := buildTestOverridesPodTemplate()
+				template.Labels["team"] = "test"
+				template.Spec.Containers[0].Image = "custom"
+				template.Spec.Containers[0].Resources.Limits[v1.ResourceMemory] = resource.MustParse("4Gi")
+				template.Spec.Containers[0].Env = []v1.EnvVar{
+					{Name: "DEFAULT", Value: "custom"},
+					{Name: "EXTRA", Value: "extra"},
+				}
+				return template
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildTestOverridesPodTemplate()
+			applyPodTemplateOverrides(&got, "test", tt.overrides)
+			if want := tt.want(); !reflect.DeepEqual(got, want) {
+				t.Errorf("applyPodTemplateOverrides() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestPostgresProvider_CreatePVC_Overrides(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	storageSize := resource.MustParse("5Gi")
+	tests := []struct {
+		name     string
+		existing *v1.PersistentVolumeClaim
+		cfg      *PostgresStrat
+	}{
+		{
+			name: "test new pvc is created with the storage size override",
+			cfg: &PostgresStrat{
+				Overrides: &WorkloadOverrides{StorageSize: &storageSize, Labels: map[string]string{"team": "test"}},
+			},
+		},
+		{
+			name: "test bound pvc is resized to the storage size override",
+			existing: func() *v1.PersistentVolumeClaim {
+				pvc := buildDefaultPostgresPVC(buildTestPostgresCR())
+				pvc.Status.Phase = v1.ClaimBound
+				return pvc
+			}(),
+			cfg: &PostgresStrat{
+				Overrides: &WorkloadOverrides{StorageSize: &storageSize, Labels: map[string]string{"team": "test"}},
+			},
+		},
+		{
+			name: "test storage size override takes precedence over pvc spec",
+			existing: func() *v1.PersistentVolumeClaim {
+				pvc := buildDefaultPostgresPVC(buildTestPostgresCR())
+				pvc.Status.Phase = v1.ClaimBound
+				return pvc
+			}(),
+			cfg: &PostgresStrat{
+				PostgresPVCSpec: &v1.PersistentVolumeClaimSpec{
+					Resources: v1.ResourceRequirements{
+						Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse("2Gi")},
+					},
+				},
+				Overrides: &WorkloadOverrides{StorageSize: &storageSize, Labels: map[string]string{"team": "test"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme)
+			if tt.existing != nil {
+				c = fake.NewFakeClientWithScheme(scheme, tt.existing)
+			}
+			p := PostgresProvider{Client: c, Logger: testLogger}
+			pvc := buildDefaultPostgresPVC(buildTestPostgresCR())
+			if err := p.CreatePVC(context.TODO(), pvc, tt.cfg); err != nil {
+				t.Fatalf("CreatePVC() unexpected error = %v", err)
+			}
+			got := &v1.PersistentVolumeClaim{}
+			if err := c.Get(context.TODO(), client.ObjectKey{Name: pvc.Name, Namespace: pvc.Namespace}, got); err != nil {
+				t.Fatal("failed to get pvc", err)
+			}
+			if size := got.Spec.Resources.Requests[v1.ResourceStorage]; size.Cmp(storageSize) != 0 {
+				t.Errorf("CreatePVC() storage = %s, want %s", size.String(), storageSize.String())
+			}
+			if got.Labels["team"] != "test" {
+				t.Errorf("CreatePVC() labels = %v, want team label", got.Labels)
+			}
+		})
+	}
+}
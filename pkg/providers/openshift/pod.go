@@ -0,0 +1,47 @@
+package openshift
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+// PodExtensions are merged into the default pod template of an in-cluster workload, e.g. to run a metrics exporter, log
+// shipper or backup agent alongside the database without overriding the whole deployment spec
+type PodExtensions struct {
+	// Containers are added to the pod, a container with the same name as a default container replaces it
+	Containers []v1.Container `json:"containers,omitempty"`
+	// Volumes are added to the pod, a volume with the same name as a default volume replaces it
+	Volumes []v1.Volume `json:"volumes,omitempty"`
+}
+
+// mergePodExtensions adds the containers and volumes of the extensions to the pod spec
+func mergePodExtensions(spec *v1.PodSpec, ext *PodExtensions) {
+	if ext == nil {
+		return
+	}
+	for _, c := range ext.Containers {
+		spec.Containers = mergeContainer(spec.Containers, c)
+	}
+	for _, vol := range ext.Volumes {
+		spec.Volumes = mergeVolume(spec.Volumes, vol)
+	}
+}
+
+func mergeContainer(containers []v1.Container, c v1.Container) []v1.Container {
+	for i := range containers {
+		if containers[i].Name == c.Name {
+			containers[i] = c
+			return containers
+		}
+	}
+	return append(containers, c)
+}
+
+func mergeVolume(volumes []v1.Volume, vol v1.Volume) []v1.Volume {
+	for i := range volumes {
+		if volumes[i].Name == vol.Name {
+			volumes[i] = vol
+			return volumes
+		}
+	}
+	return append(volumes, vol)
+}
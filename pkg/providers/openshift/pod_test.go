@@ -0,0 +1,108 @@
+package openshift
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func buildTestPodSpec() v1.PodSpec {
+	return v1.PodSpec{
+		Containers: []v1.Container{
+			{Name: "database", Image: "database"},
+		},
+		Volumes: []v1.Volume{
+			{Name: "data"},
+		},
+	}
+}
+
+func buildTestExporterContainer() v1.Container {
+	return v1.Container{Name: "exporter", Image: "exporter"}
+}
+
+func Test_mergePodExtensions(t *testing.T) {
+	tests := []struct {
+		name string
+		ext  *PodExtensions
+		want v1.PodSpec
+	}{
+		{
+			name: "test pod spec is unchanged without extensions",
+			ext:  nil,
+			want: buildTestPodSpec(),
+		},
+		{
+			name: "test containers and volumes are added",
+			ext: &PodExtensions{
+				Containers: []v1.Container{buildTestExporterContainer()},
+				Volumes:    []v1.Volume{{Name: "exporter-config"}},
+			},
+			want: v1.PodSpec{
+				Containers: []v1.Container{
+					{Name: "database", Image: "database"},
+					buildTestExporterContainer(),
+				},
+				Volumes: []v1.Volume{
+					{Name: "data"},
+					{Name: "exporter-config"},
+				},
+			},
+		},
+		{
+			name: "test containers and volumes with a default name replace the default",
+			ext: &PodExtensions{
+				Containers: []v1.Container{{Name: "database", Image: "custom"}},
+				Volumes:    []v1.Volume{{Name: "data", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}}},
+			},
+			want: v1.PodSpec{
+				Containers: []v1.Container{
+					{Name: "database", Image: "custom"},
+				},
+				Volumes: []v1.Volume{
+					{Name: "data", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildTestPodSpec()
+			mergePodExtensions(&got, tt.ext)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergePodExtensions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPostgresProvider_CreateDeployment_PodExtensions(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	c := fake.NewFakeClientWithScheme(scheme)
+	p := PostgresProvider{Client: c, Logger: testLogger}
+	d := buildDefaultPostgresDeployment(buildTestPostgresCR())
+	cfg := &PostgresStrat{
+		PodExtensions: &PodExtensions{
+			Containers: []v1.Container{buildTestExporterContainer()},
+		},
+	}
+	if err := p.CreateDeployment(context.TODO(), d, cfg); err != nil {
+		t.Fatalf("CreateDeployment() unexpected error = %v", err)
+	}
+	got := &appsv1.Deployment{}
+	if err := c.Get(context.TODO(), client.ObjectKey{Name: d.Name, Namespace: d.Namespace}, got); err != nil {
+		t.Fatal("failed to get deployment", err)
+	}
+	containers := got.Spec.Template.Spec.Containers
+	if len(containers) != 2 || containers[0].Name != d.Spec.Template.Spec.Containers[0].Name || !reflect.DeepEqual(containers[1], buildTestExporterContainer()) {
+		t.Errorf("CreateDeployment() unexpected containers %+v", containers)
+	}
+}
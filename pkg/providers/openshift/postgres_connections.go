@@ -0,0 +1,55 @@
+package openshift
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	errorUtil "github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// postgresMaxConnectionsEnv sets max_connections of the rhscl postgres images
+	postgresMaxConnectionsEnv = "POSTGRESQL_MAX_CONNECTIONS"
+	// defaultPostgresMaxConnections is max_connections of the rhscl postgres images if it isn't set
+	defaultPostgresMaxConnections = 100
+)
+
+// PostgresMaxConnections returns the client connections a postgres with the strategy config accepts. Clients connect
+// to the pooler if it's configured, otherwise max_connections of the postgres container applies
+func PostgresMaxConnections(ps *v1alpha1.Postgres, stratCfg *StrategyConfig) (int, error) {
+	postgresCfg := &PostgresStrat{}
+	if err := json.Unmarshal(stratCfg.RawStrategy, postgresCfg); err != nil {
+		return 0, errorUtil.Wrap(err, "failed to unmarshal openshift postgres configuration")
+	}
+	if pooler := postgresCfg.Pooler; pooler != nil {
+		maxClientConnections := pooler.MaxClientConnections
+		if maxClientConnections == 0 {
+			maxClientConnections = defaultPoolerMaxClientConnections
+		}
+		replicas := 1
+		if pooler.Replicas != nil {
+			replicas = int(*pooler.Replicas)
+		}
+		return maxClientConnections * replicas, nil
+	}
+
+	// max_connections is read from the deployment as it's created, so it's found in an overriding deploymentSpec as
+	// well as in the env overrides
+	workload := &v1alpha1.Postgres{ObjectMeta: metav1.ObjectMeta{Name: ps.Name, Namespace: ps.Namespace}}
+	dpl := desiredPostgresDeployment(buildDefaultPostgresDeployment(workload), postgresCfg)
+	for _, c := range dpl.Spec.Template.Spec.Containers {
+		for _, env := range c.Env {
+			if env.Name != postgresMaxConnectionsEnv {
+				continue
+			}
+			maxConnections, err := strconv.Atoi(env.Value)
+			if err != nil {
+				return 0, errorUtil.Wrapf(err, "invalid %s of container %s", postgresMaxConnectionsEnv, c.Name)
+			}
+			return maxConnections, nil
+		}
+	}
+	return defaultPostgresMaxConnections, nil
+}
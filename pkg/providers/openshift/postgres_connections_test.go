@@ -0,0 +1,56 @@
+package openshift
+
+import (
+	"testing"
+)
+
+func TestPostgresMaxConnections(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		want     int
+		wantErr  bool
+	}{
+		{
+			name:     "test max connections default to the image default",
+			strategy: `{}`,
+			want:     defaultPostgresMaxConnections,
+		},
+		{
+			name:     "test max connections are read from the env overrides",
+			strategy: `{"overrides": {"env": [{"name": "POSTGRESQL_MAX_CONNECTIONS", "value": "300"}]}}`,
+			want:     300,
+		},
+		{
+			name:     "test max connections are read from an overriding deployment spec",
+			strategy: `{"deploymentSpec": {"template": {"spec": {"containers": [{"name": "postgres", "env": [{"name": "POSTGRESQL_MAX_CONNECTIONS", "value": "50"}]}]}}}}`,
+			want:     50,
+		},
+		{
+			name:     "test clients of the pooler are limited by its client connections",
+			strategy: `{"pooler": {"maxClientConnections": 200, "replicas": 2}}`,
+			want:     400,
+		},
+		{
+			name:     "test pooler client connections default",
+			strategy: `{"pooler": {}}`,
+			want:     defaultPoolerMaxClientConnections,
+		},
+		{
+			name:     "test invalid max connections",
+			strategy: `{"overrides": {"env": [{"name": "POSTGRESQL_MAX_CONNECTIONS", "value": "many"}]}}`,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PostgresMaxConnections(buildTestPostgresCR(), &StrategyConfig{RawStrategy: []byte(tt.strategy)})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PostgresMaxConnections() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("PostgresMaxConnections() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
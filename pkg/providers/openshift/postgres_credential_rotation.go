@@ -0,0 +1,65 @@
+package openshift
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// rotatePostgresCredentials replaces the password of the database user when a credential rotation is due. The new
+// password is kept as the pending password in the credential secret until the database uses it, so a failed rotation
+// is retried with the same password. Secrets still holding the legacy fixed password are always rotated. Credentials
+// set with postgresSecretData in the strategy are never rotated
+func (p *PostgresProvider) rotatePostgresCredentials(ctx context.Context, ps *v1alpha1.Postgres, dpl *appsv1.Deployment, sec *v1.Secret, postgresCfg *PostgresStrat) (croType.StatusMessage, error) {
+	pendingPass := string(sec.Data[providers.PendingPasswordKey])
+	if pendingPass == "" {
+		legacyPass := string(sec.Data[defaultPostgresPasswordKey]) == legacyPostgresPassword
+		if !legacyPass && !providers.IsCredentialRotationDue(ps, time.Now()) {
+			return "", nil
+		}
+		if postgresCfg.PostgresSecretData != nil {
+			return "credentials set in the strategy are not rotated", nil
+		}
+		// a pending password generated by a concurrent reconcile is kept, so the rotation uses a single password
+		stored, err := resources.EnsureCredentialSecret(ctx, p.Client, sec, map[string]resources.SecretValueGenerator{
+			providers.PendingPasswordKey: resources.GeneratePassword,
+		})
+		if err != nil {
+			errMsg := fmt.Sprintf("failed to store pending password in secret %s", sec.Name)
+			return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+		stored.DeepCopyInto(sec)
+		pendingPass = string(sec.Data[providers.PendingPasswordKey])
+	}
+
+	dbUser := string(sec.Data["user"])
+	cmd := "psql -c \"ALTER USER \\\"" + dbUser + "\\\" WITH PASSWORD '" + pendingPass + "';\""
+	if err := p.PodCommander.ExecIntoPod(dpl, cmd); err != nil {
+		errMsg := "failed to set rotated password of database user"
+		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+
+	// the database uses the pending password, promote it so it's used by the deployment and the connection secret
+	oldPass := string(sec.Data[defaultPostgresPasswordKey])
+	sec.Data[defaultPostgresPasswordKey] = []byte(pendingPass)
+	delete(sec.Data, providers.PendingPasswordKey)
+	if err := p.Client.Update(ctx, sec); err != nil {
+		errMsg := fmt.Sprintf("failed to promote pending password in secret %s", sec.Name)
+		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	// the old password no longer grants access, stop redacting it so the registered values do not grow with rotations
+	resources.ForgetSecretValue(oldPass)
+	if err := providers.CompleteCredentialRotation(ctx, p.Client, ps, time.Now()); err != nil {
+		errMsg := "failed to complete credential rotation"
+		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	return croType.StatusMessage(fmt.Sprintf("rotated password of database user %s", dbUser)), nil
+}
@@ -0,0 +1,128 @@
+package openshift
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/annotations"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPostgresProvider_rotatePostgresCredentials(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	rotateCR := func() *v1alpha1.Postgres {
+		pg := buildTestPostgresCR()
+		annotations.Add(pg, providers.RotateCredentialsAnnotation, "true")
+		return pg
+	}
+	tests := []struct {
+		name         string
+		cr           *v1alpha1.Postgres
+		credSec      *v1.Secret
+		cfg          *PostgresStrat
+		wantExec     bool
+		wantPassword string
+		wantRotated  bool
+	}{
+		{
+			name:         "test credentials are not rotated when a rotation isn't due",
+			cr:           buildTestPostgresCR(),
+			credSec:      buildTestCredsSecret(),
+			cfg:          &PostgresStrat{},
+			wantPassword: testPostgresPassword,
+		},
+		{
+			name:         "test credentials set in the strategy are not rotated",
+			cr:           rotateCR(),
+			credSec:      buildTestCredsSecret(),
+			cfg:          &PostgresStrat{PostgresSecretData: map[string]string{"password": testPostgresPassword}},
+			wantPassword: testPostgresPassword,
+		},
+		{
+			name:        "test credentials are rotated on request",
+			cr:          rotateCR(),
+			credSec:     buildTestCredsSecret(),
+			cfg:         &PostgresStrat{},
+			wantExec:    true,
+			wantRotated: true,
+		},
+		{
+			name: "test legacy fixed password is rotated",
+			cr:   buildTestPostgresCR(),
+			credSec: func() *v1.Secret {
+				sec := buildTestCredsSecret()
+				sec.Data[defaultPostgresPasswordKey] = []byte(legacyPostgresPassword)
+				return sec
+			}(),
+			cfg:         &PostgresStrat{},
+			wantExec:    true,
+			wantRotated: true,
+		},
+		{
+			name: "test pending password of a failed rotation is applied",
+			cr:   buildTestPostgresCR(),
+			credSec: func() *v1.Secret {
+				sec := buildTestCredsSecret()
+				sec.Data[providers.PendingPasswordKey] = []byte("pending")
+				return sec
+			}(),
+			cfg:          &PostgresStrat{},
+			wantExec:     true,
+			wantPassword: "pending",
+			wantRotated:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme, tt.cr, tt.credSec)
+			var execCmds []string
+			p := PostgresProvider{
+				Client: c,
+				Logger: testLogger,
+				PodCommander: &resources.PodCommanderMock{
+					ExecIntoPodFunc: func(dpl *appsv1.Deployment, cmd string) error {
+						execCmds = append(execCmds, cmd)
+						return nil
+					},
+				},
+			}
+			sec := &v1.Secret{}
+			if err := c.Get(context.TODO(), client.ObjectKey{Name: tt.credSec.Name, Namespace: tt.credSec.Namespace}, sec); err != nil {
+				t.Fatal("failed to get credential secret", err)
+			}
+			if _, err := p.rotatePostgresCredentials(context.TODO(), tt.cr, buildTestPostgresDeployment(), sec, tt.cfg); err != nil {
+				t.Fatalf("rotatePostgresCredentials() unexpected error = %v", err)
+			}
+			stored := &v1.Secret{}
+			if err := c.Get(context.TODO(), client.ObjectKey{Name: tt.credSec.Name, Namespace: tt.credSec.Namespace}, stored); err != nil {
+				t.Fatal("failed to get credential secret", err)
+			}
+			password := string(stored.Data[defaultPostgresPasswordKey])
+			if tt.wantPassword != "" && password != tt.wantPassword {
+				t.Errorf("rotatePostgresCredentials() password = %s, want %s", password, tt.wantPassword)
+			}
+			if tt.wantRotated && password == string(tt.credSec.Data[defaultPostgresPasswordKey]) {
+				t.Error("rotatePostgresCredentials() password was not rotated")
+			}
+			if _, ok := stored.Data[providers.PendingPasswordKey]; ok {
+				t.Error("rotatePostgresCredentials() pending password not removed")
+			}
+			if exec := len(execCmds) == 1 && strings.Contains(execCmds[0], password); exec != tt.wantExec {
+				t.Errorf("rotatePostgresCredentials() exec commands %v, want password set %v", execCmds, tt.wantExec)
+			}
+			if rotated := tt.cr.Status.CredentialsRotatedAt != ""; rotated != tt.wantRotated {
+				t.Errorf("rotatePostgresCredentials() rotation recorded %v, want %v", rotated, tt.wantRotated)
+			}
+		})
+	}
+}
@@ -0,0 +1,409 @@
+package openshift
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources/metrics"
+	// registers the postgres driver the pool statistics are read with
+	_ "github.com/lib/pq"
+	errorUtil "github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultPoolerImage                = "docker.io/bitnami/pgbouncer:1.17.0"
+	defaultPoolerPort                 = 6432
+	defaultPoolerPoolMode             = "transaction"
+	defaultPoolerDefaultPoolSize      = 20
+	defaultPoolerMaxClientConnections = 1000
+
+	// poolerAdminDatabase is the admin console of pgbouncer, the pool statistics are read from
+	poolerAdminDatabase = "pgbouncer"
+
+	// poolerCredentialsAnnotation holds a checksum of the credentials used by the pooler, so the pooler is restarted
+	// with the new password after a credential rotation
+	poolerCredentialsAnnotation = "integreatly.org/pooler-credentials"
+)
+
+// PostgresPooler configures a pgbouncer connection pooler in front of the postgres, the connection secret points at
+// the pooler instead of the database. Setting the block enables the pooler, every field is optional
+type PostgresPooler struct {
+	// Image replaces the default pgbouncer image, the image is configured with the bitnami pgbouncer environment
+	Image string `json:"image,omitempty"`
+	// Replicas is the number of pooler pods, defaults to 1
+	Replicas *int32 `json:"replicas,omitempty"`
+	// PoolMode is one of session, transaction or statement, defaults to transaction
+	PoolMode string `json:"poolMode,omitempty"`
+	// DefaultPoolSize is the number of server connections of each pooler pod, defaults to 20
+	DefaultPoolSize int `json:"defaultPoolSize,omitempty"`
+	// MaxClientConnections is the number of client connections each pooler pod accepts, defaults to 1000
+	MaxClientConnections int `json:"maxClientConnections,omitempty"`
+	// Resources replaces the limits and requests of the pooler container
+	Resources *v1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+func postgresPoolerName(name string) string {
+	return fmt.Sprintf("%s-pooler", name)
+}
+
+// validatePostgresPooler returns an error if the pooler can't be deployed with the postgres config
+func validatePostgresPooler(postgresCfg *PostgresStrat) error {
+	pooler := postgresCfg.Pooler
+	if pooler == nil {
+		return nil
+	}
+	if postgresCfg.TLS {
+		return errorUtil.New("the pooler can't be used with tls")
+	}
+	switch pooler.PoolMode {
+	case "", "session", "transaction", "statement":
+	default:
+		return errorUtil.Errorf("unknown pool mode %s", pooler.PoolMode)
+	}
+	if pooler.DefaultPoolSize < 0 || pooler.MaxClientConnections < 0 {
+		return errorUtil.New("pool size and client connections of the pooler must not be negative")
+	}
+	return nil
+}
+
+// reconcilePostgresPooler creates or updates the pooler of the workload, or removes it if the pooler isn't
+// configured, returning the pooler deployment if it's configured
+func (p *PostgresProvider) reconcilePostgresPooler(ctx context.Context, workload *v1alpha1.Postgres, sec *v1.Secret, postgresCfg *PostgresStrat) (*appsv1.Deployment, error) {
+	if postgresCfg.Pooler == nil {
+		if err := p.deletePostgresPooler(ctx, workload.Name, workload.Namespace); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	dpl := buildDefaultPostgresPoolerDeployment(workload, sec, postgresCfg.Pooler)
+	applyLabelOverrides(dpl, postgresCfg.Overrides)
+	applyLabelOverrides(&dpl.Spec.Template.ObjectMeta, postgresCfg.Overrides)
+	or, err := immutableCreateOrUpdate(ctx, p.Client, p.Logger, dpl, func(existing runtime.Object) error {
+		return threeWayMerge(existing, dpl)
+	})
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to create or update pooler deployment %s, action was %s", dpl.Name, or)
+	}
+	svc := buildDefaultPostgresPoolerService(workload)
+	applyLabelOverrides(svc, postgresCfg.Overrides)
+	or, err = immutableCreateOrUpdate(ctx, p.Client, p.Logger, svc, func(existing runtime.Object) error {
+		return threeWayMerge(existing, svc)
+	})
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to create or update pooler service %s, action was %s", svc.Name, or)
+	}
+
+	existing := &appsv1.Deployment{}
+	if err := p.Client.Get(ctx, types.NamespacedName{Name: dpl.Name, Namespace: dpl.Namespace}, existing); err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to get pooler deployment %s", dpl.Name)
+	}
+	return existing, nil
+}
+
+// deletePostgresPooler removes the pooler deployment and service of the postgres with the name
+func (p *PostgresProvider) deletePostgresPooler(ctx context.Context, name, ns string) error {
+	objects := []runtime.Object{
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: postgresPoolerName(name), Namespace: ns}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: postgresPoolerName(name), Namespace: ns}},
+	}
+	for _, o := range objects {
+		if err := deleteObject(ctx, p.Client, o); err != nil && !k8serr.IsNotFound(err) {
+			return errorUtil.Wrapf(err, "failed to delete pooler %T %s", o, postgresPoolerName(name))
+		}
+	}
+	return nil
+}
+
+// PoolerStatsReader reads the pool statistics of a pooler pod
+type PoolerStatsReader interface {
+	ReadPoolerStats(ctx context.Context, endpoint, user, password string) (*metrics.PoolerStats, error)
+}
+
+// PgBouncerStatsReader reads the pool statistics from the admin console of pgbouncer, the postgres user is one of the
+// stats users of the pooler
+type PgBouncerStatsReader struct{}
+
+var _ PoolerStatsReader = (*PgBouncerStatsReader)(nil)
+
+func (r *PgBouncerStatsReader) ReadPoolerStats(ctx context.Context, endpoint, user, password string) (*metrics.PoolerStats, error) {
+	dsn := (&url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(user, password),
+		Host:     endpoint,
+		Path:     poolerAdminDatabase,
+		RawQuery: url.Values{"sslmode": []string{"disable"}}.Encode(),
+	}).String()
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to open pooler connection to %s", endpoint)
+	}
+	defer db.Close()
+	// the admin console only supports the simple query protocol, used for queries without arguments
+	rows, err := db.QueryContext(ctx, "SHOW POOLS")
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to show pools of pooler %s", endpoint)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to get pool columns of pooler %s", endpoint)
+	}
+	var pools [][]string
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, errorUtil.Wrapf(err, "failed to read pools of pooler %s", endpoint)
+		}
+		pool := make([]string, len(values))
+		for i, v := range values {
+			pool[i] = v.String
+		}
+		pools = append(pools, pool)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to read pools of pooler %s", endpoint)
+	}
+	return parsePoolerStats(columns, pools)
+}
+
+// parsePoolerStats sums the clients and server connections of the pools shown by pgbouncer, except the pool of its
+// admin console, and takes the longest wait of them. the columns are looked up by name as they differ between versions
+func parsePoolerStats(columns []string, pools [][]string) (*metrics.PoolerStats, error) {
+	index := map[string]int{}
+	for i, c := range columns {
+		index[c] = i
+	}
+	for _, c := range []string{"database", "cl_active", "cl_waiting", "maxwait"} {
+		if _, ok := index[c]; !ok {
+			return nil, errorUtil.Errorf("pools are missing the %s column", c)
+		}
+	}
+	stats := &metrics.PoolerStats{}
+	for _, pool := range pools {
+		if pool[index["database"]] == poolerAdminDatabase {
+			continue
+		}
+		value := func(column string) (float64, error) {
+			i, ok := index[column]
+			if !ok || pool[i] == "" {
+				return 0, nil
+			}
+			v, err := strconv.ParseFloat(pool[i], 64)
+			if err != nil {
+				return 0, errorUtil.Wrapf(err, "failed to parse %s of pool %s", column, pool[index["database"]])
+			}
+			return v, nil
+		}
+		values := map[string]float64{}
+		for _, c := range []string{"cl_active", "cl_waiting", "sv_active", "sv_idle", "sv_used", "sv_tested", "sv_login", "maxwait", "maxwait_us"} {
+			v, err := value(c)
+			if err != nil {
+				return nil, err
+			}
+			values[c] = v
+		}
+		stats.ActiveClients += values["cl_active"]
+		stats.WaitingClients += values["cl_waiting"]
+		stats.ServerConnections += values["sv_active"] + values["sv_idle"] + values["sv_used"] + values["sv_tested"] + values["sv_login"]
+		wait := time.Duration(values["maxwait"])*time.Second + time.Duration(values["maxwait_us"])*time.Microsecond
+		if wait > stats.MaxWait {
+			stats.MaxWait = wait
+		}
+	}
+	return stats, nil
+}
+
+// observePoolerStats exports the pool statistics of the pooler of the postgres, summed over the running pooler pods.
+// failing to read them is logged and keeps the statistics last exported, the statistics of a postgres without a
+// pooler are removed
+func (p *PostgresProvider) observePoolerStats(ctx context.Context, ps *v1alpha1.Postgres, poolerDpl *appsv1.Deployment, user, password string) {
+	key := types.NamespacedName{Namespace: ps.Namespace, Name: ps.Name}
+	// the operator connects to the pooler pods like the connectivity checks, which are disabled when it can't
+	if poolerDpl == nil || p.PoolerStats == nil || resources.ConnectivityCheckTimeout <= 0 {
+		metrics.DeletePoolerStats(key)
+		return
+	}
+	pods := &v1.PodList{}
+	if err := p.Client.List(ctx, pods, client.InNamespace(poolerDpl.Namespace), client.MatchingLabels{"deployment": poolerDpl.Name}); err != nil {
+		p.Logger.Warnf("failed to list pooler pods of postgres %s: %v", ps.Name, err)
+		return
+	}
+	total := metrics.PoolerStats{}
+	running := 0
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != v1.PodRunning || pod.Status.PodIP == "" {
+			continue
+		}
+		readCtx, cancel := context.WithTimeout(ctx, resources.ConnectivityCheckTimeout)
+		stats, err := p.PoolerStats.ReadPoolerStats(readCtx, net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(defaultPoolerPort)), user, password)
+		cancel()
+		if err != nil {
+			p.Logger.Warnf("failed to read pool statistics of pooler pod %s: %v", pod.Name, resources.Redact(err.Error()))
+			return
+		}
+		running++
+		total.ActiveClients += stats.ActiveClients
+		total.WaitingClients += stats.WaitingClients
+		total.ServerConnections += stats.ServerConnections
+		if stats.MaxWait > total.MaxWait {
+			total.MaxWait = stats.MaxWait
+		}
+	}
+	if running == 0 {
+		metrics.DeletePoolerStats(key)
+		return
+	}
+	metrics.SetPoolerStats(key, total)
+}
+
+func buildDefaultPostgresPoolerService(ps *v1alpha1.Postgres) *v1.Service {
+	name := postgresPoolerName(ps.Name)
+	return &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ps.Namespace,
+		},
+		Spec: v1.ServiceSpec{
+			// the pooler is served on the postgres port so only the host of the connection secret changes
+			Ports: []v1.ServicePort{
+				{
+					Name:       "postgresql",
+					Protocol:   v1.ProtocolTCP,
+					Port:       int32(defaultPostgresPort),
+					TargetPort: intstr.FromInt(defaultPoolerPort),
+				},
+			},
+			Selector: map[string]string{"deployment": name},
+		},
+	}
+}
+
+func buildDefaultPostgresPoolerDeployment(ps *v1alpha1.Postgres, sec *v1.Secret, pooler *PostgresPooler) *appsv1.Deployment {
+	name := postgresPoolerName(ps.Name)
+	credentialsSec := postgresCredentialsSecretName(ps.Name)
+	image := pooler.Image
+	if image == "" {
+		image = defaultPoolerImage
+	}
+	replicas := pooler.Replicas
+	if replicas == nil {
+		replicas = int32Ptr(1)
+	}
+	poolMode := pooler.PoolMode
+	if poolMode == "" {
+		poolMode = defaultPoolerPoolMode
+	}
+	poolSize := pooler.DefaultPoolSize
+	if poolSize == 0 {
+		poolSize = defaultPoolerDefaultPoolSize
+	}
+	maxClientConnections := pooler.MaxClientConnections
+	if maxClientConnections == 0 {
+		maxClientConnections = defaultPoolerMaxClientConnections
+	}
+	container := v1.Container{
+		Name:  name,
+		Image: image,
+		Ports: []v1.ContainerPort{
+			{
+				ContainerPort: int32(defaultPoolerPort),
+				Protocol:      v1.ProtocolTCP,
+			},
+		},
+		Env: []v1.EnvVar{
+			{Name: "POSTGRESQL_HOST", Value: fmt.Sprintf("%s.%s.svc.cluster.local", ps.Name, ps.Namespace)},
+			{Name: "POSTGRESQL_PORT", Value: strconv.Itoa(defaultPostgresPort)},
+			envVarFromSecret("POSTGRESQL_USERNAME", credentialsSec, defaultPostgresUserKey),
+			envVarFromSecret("POSTGRESQL_PASSWORD", credentialsSec, defaultPostgresPasswordKey),
+			envVarFromSecret("POSTGRESQL_DATABASE", credentialsSec, defaultPostgresDatabaseKey),
+			envVarFromSecret("PGBOUNCER_DATABASE", credentialsSec, defaultPostgresDatabaseKey),
+			{Name: "PGBOUNCER_PORT", Value: strconv.Itoa(defaultPoolerPort)},
+			{Name: "PGBOUNCER_POOL_MODE", Value: poolMode},
+			{Name: "PGBOUNCER_DEFAULT_POOL_SIZE", Value: strconv.Itoa(poolSize)},
+			{Name: "PGBOUNCER_MAX_CLIENT_CONN", Value: strconv.Itoa(maxClientConnections)},
+			// the operator reads the pool statistics from the admin console as the postgres user, with lib/pq which
+			// sends the extra_float_digits startup parameter
+			envVarFromSecret("PGBOUNCER_STATS_USERS", credentialsSec, defaultPostgresUserKey),
+			{Name: "PGBOUNCER_IGNORE_STARTUP_PARAMETERS", Value: "extra_float_digits"},
+		},
+		Resources: v1.ResourceRequirements{
+			Limits: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("250m"),
+				v1.ResourceMemory: resource.MustParse("256Mi"),
+			},
+			Requests: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("25m"),
+				v1.ResourceMemory: resource.MustParse("64Mi"),
+			},
+		},
+		ReadinessProbe: &v1.Probe{
+			Handler: v1.Handler{
+				TCPSocket: &v1.TCPSocketAction{
+					Port: intstr.FromInt(defaultPoolerPort),
+				},
+			},
+			InitialDelaySeconds: 5,
+			PeriodSeconds:       10,
+		},
+		ImagePullPolicy: v1.PullIfNotPresent,
+	}
+	if pooler.Resources != nil {
+		container.Resources.Limits = mergeResourceList(container.Resources.Limits, pooler.Resources.Limits)
+		container.Resources.Requests = mergeResourceList(container.Resources.Requests, pooler.Resources.Requests)
+	}
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ps.Namespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: workloadReplicas(ps.Spec, replicas),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"deployment": name,
+				},
+			},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"deployment": name,
+					},
+					Annotations: map[string]string{
+						poolerCredentialsAnnotation: poolerCredentialsChecksum(sec),
+					},
+				},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{container},
+				},
+			},
+		},
+	}
+}
+
+// poolerCredentialsChecksum returns a checksum of the user and password the pooler connects with
+func poolerCredentialsChecksum(sec *v1.Secret) string {
+	sum := sha256.Sum256([]byte(string(sec.Data[defaultPostgresUserKey]) + ":" + string(sec.Data[defaultPostgresPasswordKey])))
+	return fmt.Sprintf("%x", sum[:8])
+}
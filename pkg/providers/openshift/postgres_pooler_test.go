@@ -0,0 +1,296 @@
+package openshift
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources/metrics"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	customMetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// fakePoolerStatsReader returns the stats of each pooler pod endpoint
+type fakePoolerStatsReader struct {
+	stats map[string]*metrics.PoolerStats
+}
+
+func (f *fakePoolerStatsReader) ReadPoolerStats(_ context.Context, endpoint, _, _ string) (*metrics.PoolerStats, error) {
+	stats, ok := f.stats[endpoint]
+	if !ok {
+		return nil, errors.New("connection refused")
+	}
+	return stats, nil
+}
+
+func buildTestPoolerPod(name, ip string, phase v1.PodPhase) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testPostgresNamespace,
+			Labels:    map[string]string{"deployment": postgresPoolerName(testPostgresName)},
+		},
+		Status: v1.PodStatus{Phase: phase, PodIP: ip},
+	}
+}
+
+// getPoolerStatsValues returns the pool statistics exported for the test postgres, by metric name
+func getPoolerStatsValues(t *testing.T) map[string]float64 {
+	families, err := customMetrics.Registry.Gather()
+	if err != nil {
+		t.Fatal("failed to gather metrics", err)
+	}
+	names := map[string]bool{
+		metrics.PoolerActiveClientsMetricName:  true,
+		metrics.PoolerWaitingClientsMetricName: true,
+		metrics.PoolerServerConnsMetricName:    true,
+		metrics.PoolerMaxWaitMetricName:        true,
+	}
+	values := map[string]float64{}
+	for _, f := range families {
+		if !names[f.GetName()] {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["namespace"] == testPostgresNamespace && labels["name"] == testPostgresName {
+				values[f.GetName()] = m.GetGauge().GetValue()
+			}
+		}
+	}
+	return values
+}
+
+func TestBuildDefaultPostgresPoolerDeployment(t *testing.T) {
+	ps := buildTestPostgresCR()
+	tests := []struct {
+		name         string
+		pooler       *PostgresPooler
+		wantImage    string
+		wantPoolMode string
+		wantReplicas int32
+	}{
+		{
+			name:         "test pooler defaults",
+			pooler:       &PostgresPooler{},
+			wantImage:    defaultPoolerImage,
+			wantPoolMode: defaultPoolerPoolMode,
+			wantReplicas: 1,
+		},
+		{
+			name:         "test pooler config",
+			pooler:       &PostgresPooler{Image: "pgbouncer:test", PoolMode: "session", Replicas: int32Ptr(2)},
+			wantImage:    "pgbouncer:test",
+			wantPoolMode: "session",
+			wantReplicas: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpl := buildDefaultPostgresPoolerDeployment(ps, buildTestCredsSecret(), tt.pooler)
+			container := dpl.Spec.Template.Spec.Containers[0]
+			if container.Image != tt.wantImage {
+				t.Errorf("buildDefaultPostgresPoolerDeployment() image = %s, want %s", container.Image, tt.wantImage)
+			}
+			if env := findEnvVar(container.Env, "PGBOUNCER_POOL_MODE"); env == nil || env.Value != tt.wantPoolMode {
+				t.Errorf("buildDefaultPostgresPoolerDeployment() pool mode = %v, want %s", env, tt.wantPoolMode)
+			}
+			if *dpl.Spec.Replicas != tt.wantReplicas {
+				t.Errorf("buildDefaultPostgresPoolerDeployment() replicas = %d, want %d", *dpl.Spec.Replicas, tt.wantReplicas)
+			}
+		})
+	}
+}
+
+func TestBuildDefaultPostgresPoolerDeployment_restartsOnRotation(t *testing.T) {
+	ps := buildTestPostgresCR()
+	sec := buildTestCredsSecret()
+	before := buildDefaultPostgresPoolerDeployment(ps, sec, &PostgresPooler{}).Spec.Template.Annotations[poolerCredentialsAnnotation]
+	sec.Data[defaultPostgresPasswordKey] = []byte("rotated")
+	after := buildDefaultPostgresPoolerDeployment(ps, sec, &PostgresPooler{}).Spec.Template.Annotations[poolerCredentialsAnnotation]
+	if before == after {
+		t.Errorf("buildDefaultPostgresPoolerDeployment() credentials annotation %s unchanged after rotation", after)
+	}
+}
+
+func TestValidatePostgresPooler(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *PostgresStrat
+		wantErr bool
+	}{
+		{
+			name: "test no pooler",
+			cfg:  &PostgresStrat{},
+		},
+		{
+			name: "test pooler",
+			cfg:  &PostgresStrat{Pooler: &PostgresPooler{PoolMode: "statement"}},
+		},
+		{
+			name:    "test error with tls",
+			cfg:     &PostgresStrat{TLS: true, Pooler: &PostgresPooler{}},
+			wantErr: true,
+		},
+		{
+			name:    "test error on an unknown pool mode",
+			cfg:     &PostgresStrat{Pooler: &PostgresPooler{PoolMode: "pooled"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validatePostgresPooler(tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("validatePostgresPooler() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func findEnvVar(envs []v1.EnvVar, name string) *v1.EnvVar {
+	for i := range envs {
+		if envs[i].Name == name {
+			return &envs[i]
+		}
+	}
+	return nil
+}
+
+func TestParsePoolerStats(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns []string
+		pools   [][]string
+		want    *metrics.PoolerStats
+		wantErr bool
+	}{
+		{
+			name:    "test pools are summed without the admin console",
+			columns: []string{"database", "user", "cl_active", "cl_waiting", "sv_active", "sv_idle", "sv_used", "sv_tested", "sv_login", "maxwait", "maxwait_us", "pool_mode"},
+			pools: [][]string{
+				{"pgbouncer", "pgbouncer", "1", "0", "0", "0", "0", "0", "0", "0", "0", "statement"},
+				{"testdb", "user", "4", "2", "3", "1", "1", "0", "0", "1", "500000", "transaction"},
+				{"otherdb", "user", "1", "1", "1", "0", "0", "0", "1", "0", "250000", "transaction"},
+			},
+			want: &metrics.PoolerStats{ActiveClients: 5, WaitingClients: 3, ServerConnections: 7, MaxWait: 1500 * time.Millisecond},
+		},
+		{
+			name:    "test columns missing from older versions are ignored",
+			columns: []string{"database", "user", "cl_active", "cl_waiting", "sv_active", "sv_idle", "maxwait"},
+			pools:   [][]string{{"testdb", "user", "2", "0", "2", "3", "0"}},
+			want:    &metrics.PoolerStats{ActiveClients: 2, ServerConnections: 5},
+		},
+		{
+			name:    "test error on missing client columns",
+			columns: []string{"database", "user"},
+			wantErr: true,
+		},
+		{
+			name:    "test error on unparseable value",
+			columns: []string{"database", "cl_active", "cl_waiting", "maxwait"},
+			pools:   [][]string{{"testdb", "many", "0", "0"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePoolerStats(tt.columns, tt.pools)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePoolerStats() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePoolerStats() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPostgresProvider_observePoolerStats(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	endpoint := func(ip string) string { return fmt.Sprintf("%s:%d", ip, defaultPoolerPort) }
+	poolerDpl := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: postgresPoolerName(testPostgresName), Namespace: testPostgresNamespace}}
+	tests := []struct {
+		name      string
+		poolerDpl *appsv1.Deployment
+		pods      []*v1.Pod
+		stats     map[string]*metrics.PoolerStats
+		want      map[string]float64
+	}{
+		{
+			name:      "test stats are summed over the running pooler pods",
+			poolerDpl: poolerDpl,
+			pods: []*v1.Pod{
+				buildTestPoolerPod("pooler-a", "10.0.0.1", v1.PodRunning),
+				buildTestPoolerPod("pooler-b", "10.0.0.2", v1.PodRunning),
+				buildTestPoolerPod("pooler-c", "", v1.PodPending),
+			},
+			stats: map[string]*metrics.PoolerStats{
+				endpoint("10.0.0.1"): {ActiveClients: 3, WaitingClients: 1, ServerConnections: 4, MaxWait: time.Second},
+				endpoint("10.0.0.2"): {ActiveClients: 2, ServerConnections: 2, MaxWait: 2 * time.Second},
+			},
+			want: map[string]float64{
+				metrics.PoolerActiveClientsMetricName:  5,
+				metrics.PoolerWaitingClientsMetricName: 1,
+				metrics.PoolerServerConnsMetricName:    6,
+				metrics.PoolerMaxWaitMetricName:        2,
+			},
+		},
+		{
+			name:      "test stats are kept when a pooler pod can't be read",
+			poolerDpl: poolerDpl,
+			pods: []*v1.Pod{
+				buildTestPoolerPod("pooler-a", "10.0.0.1", v1.PodRunning),
+				buildTestPoolerPod("pooler-b", "10.0.0.3", v1.PodRunning),
+			},
+			stats: map[string]*metrics.PoolerStats{
+				endpoint("10.0.0.1"): {ActiveClients: 1},
+			},
+			want: map[string]float64{
+				metrics.PoolerActiveClientsMetricName:  5,
+				metrics.PoolerWaitingClientsMetricName: 1,
+				metrics.PoolerServerConnsMetricName:    6,
+				metrics.PoolerMaxWaitMetricName:        2,
+			},
+		},
+		{
+			name: "test stats are removed without a pooler",
+			want: map[string]float64{},
+		},
+	}
+	// the cases run in order, each starting from the stats exported by the previous case
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme)
+			for _, pod := range tt.pods {
+				if err := c.Create(context.TODO(), pod); err != nil {
+					t.Fatal("failed to create pod", err)
+				}
+			}
+			p := &PostgresProvider{Client: c, Logger: testLogger, PoolerStats: &fakePoolerStatsReader{stats: tt.stats}}
+			p.observePoolerStats(context.TODO(), buildTestPostgresCR(), tt.poolerDpl, "user", "password")
+			if got := getPoolerStatsValues(t); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("observePoolerStats() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildDefaultPostgresPoolerDeployment_statsUser(t *testing.T) {
+	dpl := buildDefaultPostgresPoolerDeployment(buildTestPostgresCR(), buildTestCredsSecret(), &PostgresPooler{})
+	env := findEnvVar(dpl.Spec.Template.Spec.Containers[0].Env, "PGBOUNCER_STATS_USERS")
+	if env == nil || env.ValueFrom == nil || env.ValueFrom.SecretKeyRef.Key != defaultPostgresUserKey {
+		t.Errorf("buildDefaultPostgresPoolerDeployment() stats users = %v, want the postgres user", env)
+	}
+}
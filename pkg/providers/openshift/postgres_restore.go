@@ -0,0 +1,77 @@
+package openshift
+
+import (
+	"context"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	errorUtil "github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// reconcilePostgresRestore restores a new postgres pvc from the volume snapshot referenced by the postgres cr, copying
+// the annotations of the snapshot to the pvc. Returns the user and database of the restored data, which are empty if
+// the postgres isn't restored or the snapshot wasn't taken by the operator
+func (p *PostgresProvider) reconcilePostgresRestore(ctx context.Context, ps *v1alpha1.Postgres, pvc *v1.PersistentVolumeClaim) (string, string, error) {
+	if ps.Spec.RestoreFrom == nil {
+		return "", "", nil
+	}
+	existing := &v1.PersistentVolumeClaim{}
+	err := p.Client.Get(ctx, client.ObjectKey{Name: pvc.Name, Namespace: pvc.Namespace}, existing)
+	if err == nil {
+		return existing.Annotations[postgresUserAnnotation], existing.Annotations[postgresDatabaseAnnotation], nil
+	}
+	if !k8serr.IsNotFound(err) {
+		return "", "", errorUtil.Wrap(err, "failed to get postgres pvc")
+	}
+
+	snapshotName, err := p.getRestoreVolumeSnapshotName(ctx, ps)
+	if err != nil {
+		return "", "", err
+	}
+	vs, err := getVolumeSnapshot(ctx, p.Client, snapshotName, pvc.Namespace)
+	if err != nil {
+		return "", "", err
+	}
+	if vs == nil {
+		return "", "", errorUtil.Errorf("volume snapshot %s not found in namespace %s", snapshotName, pvc.Namespace)
+	}
+	pvc.Spec.DataSource = volumeSnapshotDataSource(snapshotName)
+	// the restored volume can't be smaller than the snapshotted volume
+	if size, ok := volumeSnapshotRestoreSize(vs); ok {
+		if requested, ok := pvc.Spec.Resources.Requests[v1.ResourceStorage]; !ok || requested.Cmp(size) < 0 {
+			pvc.Spec.Resources.Requests[v1.ResourceStorage] = size
+		}
+	}
+	if pvc.Annotations == nil {
+		pvc.Annotations = map[string]string{}
+	}
+	for _, key := range []string{postgresUserAnnotation, postgresDatabaseAnnotation, postgresVersionAnnotation} {
+		if value := vs.GetAnnotations()[key]; value != "" {
+			pvc.Annotations[key] = value
+		}
+	}
+	p.Logger.Infof("restoring postgres pvc %s from volume snapshot %s", pvc.Name, snapshotName)
+	return pvc.Annotations[postgresUserAnnotation], pvc.Annotations[postgresDatabaseAnnotation], nil
+}
+
+// getRestoreVolumeSnapshotName returns the name of the volume snapshot the postgres cr is restored from
+func (p *PostgresProvider) getRestoreVolumeSnapshotName(ctx context.Context, ps *v1alpha1.Postgres) (string, error) {
+	restoreFrom := ps.Spec.RestoreFrom
+	if restoreFrom.SnapshotName == "" {
+		if restoreFrom.SnapshotID == "" {
+			return "", errorUtil.New("one of snapshotName or snapshotID must be set")
+		}
+		return restoreFrom.SnapshotID, nil
+	}
+	snapshot := &v1alpha1.PostgresSnapshot{}
+	if err := p.Client.Get(ctx, client.ObjectKey{Name: restoreFrom.SnapshotName, Namespace: ps.Namespace}, snapshot); err != nil {
+		return "", errorUtil.Wrapf(err, "failed to get postgres snapshot %s", restoreFrom.SnapshotName)
+	}
+	if snapshot.Status.Phase != croType.PhaseComplete || snapshot.Status.SnapshotID == "" {
+		return "", errorUtil.Errorf("postgres snapshot %s is not complete", restoreFrom.SnapshotName)
+	}
+	return snapshot.Status.SnapshotID, nil
+}
@@ -0,0 +1,147 @@
+package openshift
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	errorUtil "github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// StorageExpandedCondition is the condition reporting if the postgres pvc has the storage requested by the strategy,
+	// it's only set once the requested storage differs from the storage the pvc was created with
+	StorageExpandedCondition = "StorageExpanded"
+	// StorageExpandedReason is the reason of a true storage expanded condition
+	StorageExpandedReason = "Expanded"
+	// StorageExpansionInProgressReason is the reason of a false storage expanded condition while the volume is resized
+	StorageExpansionInProgressReason = "ExpansionInProgress"
+	// StorageExpansionNotSupportedReason is the reason of a false storage expanded condition when the storage class of
+	// the pvc doesn't allow volume expansion, the pvc has to be resized manually
+	StorageExpansionNotSupportedReason = "ExpansionNotSupported"
+	// StorageShrinkNotSupportedReason is the reason of a false storage expanded condition when less storage is
+	// requested than the pvc has, pvcs can't be shrunk so the pvc has to be replaced manually
+	StorageShrinkNotSupportedReason = "ShrinkNotSupported"
+
+	defaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+)
+
+// desiredPostgresStorageRequests returns the storage the postgres pvc should request, the pvc spec of the strategy
+// replaces the default requests and the storage size override takes precedence over both
+func desiredPostgresStorageRequests(pvc *v1.PersistentVolumeClaim, postgresCfg *PostgresStrat) v1.ResourceList {
+	if postgresCfg.PostgresPVCSpec == nil {
+		return overrideStorageRequests(pvc.Spec.Resources.Requests, postgresCfg.Overrides)
+	}
+	return overrideStorageRequests(postgresCfg.PostgresPVCSpec.Resources.Requests, postgresCfg.Overrides)
+}
+
+// expandStorageRequests returns the requests of an existing pvc updated to the desired requests, the storage request is
+// never shrunk as pvcs can't be shrunk, and only grown if the storage class allows volume expansion
+func expandStorageRequests(current, desired v1.ResourceList, expandable bool) v1.ResourceList {
+	requests := mergeResourceList(nil, desired)
+	cur, hasCur := current[v1.ResourceStorage]
+	want, hasWant := desired[v1.ResourceStorage]
+	if !hasCur || !hasWant {
+		return requests
+	}
+	if want.Cmp(cur) < 0 || (want.Cmp(cur) > 0 && !expandable) {
+		requests[v1.ResourceStorage] = cur
+	}
+	return requests
+}
+
+// storageClassAllowsExpansion reports if the storage class of the pvc allows volume expansion. When the storage class
+// can't be found the expansion is attempted, the api server rejects it if it isn't allowed
+func storageClassAllowsExpansion(ctx context.Context, c client.Client, pvc *v1.PersistentVolumeClaim) (bool, error) {
+	sc, err := getStorageClass(ctx, c, pvc.Spec.StorageClassName)
+	if err != nil {
+		return false, err
+	}
+	if sc == nil {
+		return true, nil
+	}
+	return sc.AllowVolumeExpansion != nil && *sc.AllowVolumeExpansion, nil
+}
+
+// getStorageClass returns the named storage class, or the default storage class if no name is given, nil if it doesn't
+// exist
+func getStorageClass(ctx context.Context, c client.Client, name *string) (*storagev1.StorageClass, error) {
+	if name != nil && *name != "" {
+		sc := &storagev1.StorageClass{}
+		if err := c.Get(ctx, client.ObjectKey{Name: *name}, sc); err != nil {
+			if k8serr.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, errorUtil.Wrapf(err, "failed to get storage class %s", *name)
+		}
+		return sc, nil
+	}
+	scList := &storagev1.StorageClassList{}
+	if err := c.List(ctx, scList); err != nil {
+		return nil, errorUtil.Wrap(err, "failed to list storage classes")
+	}
+	for i := range scList.Items {
+		if scList.Items[i].Annotations[defaultStorageClassAnnotation] == "true" {
+			return &scList.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// setPostgresStorageCondition reports in the storage expanded condition of the postgres whether its pvc has the storage
+// requested by the strategy, or why it can't be resized without manual intervention
+func setPostgresStorageCondition(ctx context.Context, c client.Client, ps *v1alpha1.Postgres, pvc *v1.PersistentVolumeClaim, postgresCfg *PostgresStrat) error {
+	desired, ok := desiredPostgresStorageRequests(buildDefaultPostgresPVC(ps), postgresCfg)[v1.ResourceStorage]
+	if !ok {
+		return nil
+	}
+	requested := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+	capacity, hasCapacity := pvc.Status.Capacity[v1.ResourceStorage]
+	existing := meta.FindStatusCondition(ps.Status.Conditions, StorageExpandedCondition)
+	// nothing was ever resized, there's nothing to report
+	if existing == nil && desired.Cmp(requested) == 0 && (!hasCapacity || capacity.Cmp(requested) >= 0) {
+		return nil
+	}
+
+	cond := metav1.Condition{
+		Type:               StorageExpandedCondition,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: ps.GetGeneration(),
+	}
+	switch {
+	case desired.Cmp(requested) < 0:
+		cond.Reason = StorageShrinkNotSupportedReason
+		cond.Message = fmt.Sprintf("requested storage %s is less than the %s of pvc %s, pvcs can't be shrunk, the pvc must be replaced manually", desired.String(), requested.String(), pvc.Name)
+	case desired.Cmp(requested) > 0:
+		expandable, err := storageClassAllowsExpansion(ctx, c, pvc)
+		if err != nil {
+			return err
+		}
+		cond.Reason = StorageExpansionInProgressReason
+		cond.Message = fmt.Sprintf("waiting for pvc %s to request %s", pvc.Name, desired.String())
+		if !expandable {
+			cond.Reason = StorageExpansionNotSupportedReason
+			cond.Message = fmt.Sprintf("the storage class of pvc %s doesn't allow volume expansion, the pvc must be resized to %s manually", pvc.Name, desired.String())
+		}
+	case hasCapacity && capacity.Cmp(requested) < 0:
+		cond.Reason = StorageExpansionInProgressReason
+		cond.Message = fmt.Sprintf("pvc %s is being resized from %s to %s", pvc.Name, capacity.String(), requested.String())
+		for _, pvcCond := range pvc.Status.Conditions {
+			if pvcCond.Type == v1.PersistentVolumeClaimFileSystemResizePending && pvcCond.Status == v1.ConditionTrue {
+				cond.Message = fmt.Sprintf("pvc %s is waiting for the postgres pod to restart to finish resizing the file system to %s", pvc.Name, requested.String())
+			}
+		}
+	default:
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = StorageExpandedReason
+		cond.Message = fmt.Sprintf("pvc %s has the requested storage %s", pvc.Name, requested.String())
+	}
+	meta.SetStatusCondition(&ps.Status.Conditions, cond)
+	return nil
+}
@@ -0,0 +1,178 @@
+package openshift
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func buildTestStorageClass(name string, expandable, isDefault bool) *storagev1.StorageClass {
+	sc := &storagev1.StorageClass{
+		ObjectMeta:           metav1.ObjectMeta{Name: name},
+		AllowVolumeExpansion: &expandable,
+	}
+	if isDefault {
+		sc.Annotations = map[string]string{defaultStorageClassAnnotation: "true"}
+	}
+	return sc
+}
+
+func buildTestBoundPostgresPVC(storageClass string, size string) *v1.PersistentVolumeClaim {
+	pvc := buildDefaultPostgresPVC(buildTestPostgresCR())
+	if storageClass != "" {
+		pvc.Spec.StorageClassName = &storageClass
+	}
+	pvc.Spec.Resources.Requests[v1.ResourceStorage] = resource.MustParse(size)
+	pvc.Status.Phase = v1.ClaimBound
+	pvc.Status.Capacity = v1.ResourceList{v1.ResourceStorage: resource.MustParse(size)}
+	return pvc
+}
+
+func buildTestStorageStrat(size string) *PostgresStrat {
+	storageSize := resource.MustParse(size)
+	return &PostgresStrat{Overrides: &WorkloadOverrides{StorageSize: &storageSize}}
+}
+
+func TestPostgresProvider_CreatePVC_Expansion(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		cfg      *PostgresStrat
+		want     string
+	}{
+		{
+			name:     "test pvc is expanded when the storage class allows it",
+			existing: []runtime.Object{buildTestBoundPostgresPVC("gp2", "1Gi"), buildTestStorageClass("gp2", true, false)},
+			cfg:      buildTestStorageStrat("5Gi"),
+			want:     "5Gi",
+		},
+		{
+			name:     "test pvc is expanded when the default storage class allows it",
+			existing: []runtime.Object{buildTestBoundPostgresPVC("", "1Gi"), buildTestStorageClass("gp2", true, true)},
+			cfg:      buildTestStorageStrat("5Gi"),
+			want:     "5Gi",
+		},
+		{
+			name:     "test pvc isn't expanded when the storage class doesn't allow it",
+			existing: []runtime.Object{buildTestBoundPostgresPVC("gp2", "1Gi"), buildTestStorageClass("gp2", false, false)},
+			cfg:      buildTestStorageStrat("5Gi"),
+			want:     "1Gi",
+		},
+		{
+			name:     "test pvc isn't shrunk",
+			existing: []runtime.Object{buildTestBoundPostgresPVC("gp2", "5Gi"), buildTestStorageClass("gp2", true, false)},
+			cfg:      buildTestStorageStrat("1Gi"),
+			want:     "5Gi",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme, tt.existing...)
+			p := PostgresProvider{Client: c, Logger: testLogger}
+			pvc := buildDefaultPostgresPVC(buildTestPostgresCR())
+			if err := p.CreatePVC(context.TODO(), pvc, tt.cfg); err != nil {
+				t.Fatalf("CreatePVC() unexpected error = %v", err)
+			}
+			got := &v1.PersistentVolumeClaim{}
+			if err := c.Get(context.TODO(), client.ObjectKey{Name: pvc.Name, Namespace: pvc.Namespace}, got); err != nil {
+				t.Fatal("failed to get pvc", err)
+			}
+			want := resource.MustParse(tt.want)
+			if size := got.Spec.Resources.Requests[v1.ResourceStorage]; size.Cmp(want) != 0 {
+				t.Errorf("CreatePVC() storage = %s, want %s", size.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestSetPostgresStorageCondition(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	resizing := buildTestBoundPostgresPVC("gp2", "5Gi")
+	resizing.Status.Capacity[v1.ResourceStorage] = resource.MustParse("1Gi")
+	resizing.Status.Conditions = []v1.PersistentVolumeClaimCondition{{Type: v1.PersistentVolumeClaimFileSystemResizePending, Status: v1.ConditionTrue}}
+
+	tests := []struct {
+		name       string
+		pvc        *v1.PersistentVolumeClaim
+		cfg        *PostgresStrat
+		resizing   bool
+		wantStatus metav1.ConditionStatus
+		wantReason string
+	}{
+		{
+			name: "test no condition when the pvc was never resized",
+			pvc:  buildTestBoundPostgresPVC("gp2", "1Gi"),
+			cfg:  &PostgresStrat{},
+		},
+		{
+			name:       "test manual intervention when the storage class doesn't allow expansion",
+			pvc:        buildTestBoundPostgresPVC("gp2", "1Gi"),
+			cfg:        buildTestStorageStrat("5Gi"),
+			wantStatus: metav1.ConditionFalse,
+			wantReason: StorageExpansionNotSupportedReason,
+		},
+		{
+			name:       "test manual intervention when the storage is shrunk",
+			pvc:        buildTestBoundPostgresPVC("gp2", "5Gi"),
+			cfg:        buildTestStorageStrat("1Gi"),
+			wantStatus: metav1.ConditionFalse,
+			wantReason: StorageShrinkNotSupportedReason,
+		},
+		{
+			name:       "test in progress while the file system is resized",
+			pvc:        resizing,
+			cfg:        buildTestStorageStrat("5Gi"),
+			wantStatus: metav1.ConditionFalse,
+			wantReason: StorageExpansionInProgressReason,
+		},
+		{
+			name:       "test expanded once the pvc has the requested storage",
+			pvc:        buildTestBoundPostgresPVC("gp2", "5Gi"),
+			cfg:        buildTestStorageStrat("5Gi"),
+			resizing:   true,
+			wantStatus: metav1.ConditionTrue,
+			wantReason: StorageExpandedReason,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme, buildTestStorageClass("gp2", false, false))
+			ps := buildTestPostgresCR()
+			if tt.resizing {
+				meta.SetStatusCondition(&ps.Status.Conditions, metav1.Condition{
+					Type:   StorageExpandedCondition,
+					Status: metav1.ConditionFalse,
+					Reason: StorageExpansionInProgressReason,
+				})
+			}
+			if err := setPostgresStorageCondition(context.TODO(), c, ps, tt.pvc, tt.cfg); err != nil {
+				t.Fatalf("setPostgresStorageCondition() unexpected error = %v", err)
+			}
+			cond := meta.FindStatusCondition(ps.Status.Conditions, StorageExpandedCondition)
+			if tt.wantStatus == "" {
+				if cond != nil {
+					t.Errorf("setPostgresStorageCondition() unexpected condition %v", cond)
+				}
+				return
+			}
+			if cond == nil || cond.Status != tt.wantStatus || cond.Reason != tt.wantReason {
+				t.Errorf("setPostgresStorageCondition() condition = %v, want status %s and reason %s", cond, tt.wantStatus, tt.wantReason)
+			}
+		})
+	}
+}
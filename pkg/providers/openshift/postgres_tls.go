@@ -0,0 +1,129 @@
+package openshift
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	errorUtil "github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// servingCertSecretAnnotation requests a serving certificate for a service from the openshift service ca, stored
+	// in the named secret as tls.crt and tls.key
+	servingCertSecretAnnotation = "service.beta.openshift.io/serving-cert-secret-name"
+	// injectCABundleAnnotation requests the openshift service ca bundle is injected into a configmap as service-ca.crt
+	injectCABundleAnnotation = "service.beta.openshift.io/inject-cabundle"
+	serviceCABundleKey       = "service-ca.crt"
+
+	postgresTLSVolumeName = "tls"
+	postgresTLSMountPath  = "/opt/app-root/src/certs"
+	postgresTLSSSLMode    = "verify-full"
+)
+
+func postgresTLSSecretName(name string) string {
+	return fmt.Sprintf("%s-tls", name)
+}
+
+func postgresServiceCAName(name string) string {
+	return fmt.Sprintf("%s-service-ca", name)
+}
+
+// enablePostgresServingCert requests a serving certificate for the postgres service from the openshift service ca
+func enablePostgresServingCert(svc *v1.Service) {
+	if svc.Annotations == nil {
+		svc.Annotations = map[string]string{}
+	}
+	svc.Annotations[servingCertSecretAnnotation] = postgresTLSSecretName(svc.Name)
+}
+
+// enablePostgresTLS mounts the serving certificate of the postgres service into the postgres container and starts
+// postgres with ssl enabled. Clients without ssl can still connect, so existing consumers keep working
+func enablePostgresTLS(podSpec *v1.PodSpec, name string) {
+	keyMode := int32(0640)
+	podSpec.Volumes = append(podSpec.Volumes, v1.Volume{
+		Name: postgresTLSVolumeName,
+		VolumeSource: v1.VolumeSource{
+			Secret: &v1.SecretVolumeSource{
+				SecretName:  postgresTLSSecretName(name),
+				DefaultMode: &keyMode,
+			},
+		},
+	})
+	if len(podSpec.Containers) == 0 {
+		return
+	}
+	container := &podSpec.Containers[0]
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == name {
+			container = &podSpec.Containers[i]
+		}
+	}
+	container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{
+		Name:      postgresTLSVolumeName,
+		MountPath: postgresTLSMountPath,
+		ReadOnly:  true,
+	})
+	// the image entrypoint passes the arguments of run-postgresql on to postgres
+	container.Args = []string{
+		"run-postgresql",
+		"-c", "ssl=on",
+		"-c", fmt.Sprintf("ssl_cert_file=%s/tls.crt", postgresTLSMountPath),
+		"-c", fmt.Sprintf("ssl_key_file=%s/tls.key", postgresTLSMountPath),
+	}
+}
+
+// reconcilePostgresServiceCA creates the configmap the openshift service ca bundle is injected into and returns the
+// bundle, empty until it's injected
+func (p *PostgresProvider) reconcilePostgresServiceCA(ctx context.Context, ps *v1alpha1.Postgres) (string, error) {
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      postgresServiceCAName(ps.Name),
+			Namespace: ps.Namespace,
+		},
+	}
+	or, err := immutableCreateOrUpdate(ctx, p.Client, p.Logger, cm, func(existing runtime.Object) error {
+		e := existing.(*v1.ConfigMap)
+		if e.Annotations == nil {
+			e.Annotations = map[string]string{}
+		}
+		e.Annotations[injectCABundleAnnotation] = "true"
+		return nil
+	})
+	if err != nil {
+		return "", errorUtil.Wrapf(err, "failed to create or update service ca configmap %s, action was %s", cm.Name, or)
+	}
+	if err := p.Client.Get(ctx, types.NamespacedName{Name: cm.Name, Namespace: cm.Namespace}, cm); err != nil {
+		return "", errorUtil.Wrapf(err, "failed to get service ca configmap %s", cm.Name)
+	}
+	return cm.Data[serviceCABundleKey], nil
+}
+
+// getPostgresTLSArtifacts returns the serving certificate of the postgres service and the service ca bundle consumers
+// verify it with as expiring artifacts, a serving certificate that isn't issued yet is skipped
+func (p *PostgresProvider) getPostgresTLSArtifacts(ctx context.Context, ps *v1alpha1.Postgres, caBundle string) ([]providers.ExpiringArtifact, error) {
+	var artifacts []providers.ExpiringArtifact
+	sec := &v1.Secret{}
+	err := p.Client.Get(ctx, types.NamespacedName{Name: postgresTLSSecretName(ps.Name), Namespace: ps.Namespace}, sec)
+	if err != nil && !k8serr.IsNotFound(err) {
+		return nil, errorUtil.Wrapf(err, "failed to get serving certificate secret %s", postgresTLSSecretName(ps.Name))
+	}
+	if err == nil {
+		cert, err := providers.BuildCertificateArtifact(providers.ArtifactKindTLSCertificate, sec.Name, sec.Data[v1.TLSCertKey])
+		if err != nil {
+			return nil, err
+		}
+		artifacts = append(artifacts, *cert)
+	}
+	ca, err := providers.BuildCertificateArtifact(providers.ArtifactKindCABundle, postgresServiceCAName(ps.Name), []byte(caBundle))
+	if err != nil {
+		return nil, err
+	}
+	return append(artifacts, *ca), nil
+}
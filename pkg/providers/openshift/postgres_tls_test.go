@@ -0,0 +1,131 @@
+package openshift
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func buildTestCertificatePEM(t *testing.T, notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("failed to generate key", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    notAfter.AddDate(-1, 0, 0),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal("failed to create certificate", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestEnablePostgresTLS(t *testing.T) {
+	ps := buildTestPostgresCR()
+	tests := []struct {
+		name     string
+		podSpec  *v1.PodSpec
+		wantArgs int
+	}{
+		{
+			name:     "test tls is enabled for the postgres container",
+			podSpec:  &buildDefaultPostgresDeployment(ps).Spec.Template.Spec,
+			wantArgs: 0,
+		},
+		{
+			name: "test tls is enabled for the postgres container when it isn't the first container",
+			podSpec: &v1.PodSpec{
+				Containers: []v1.Container{{Name: "sidecar"}, {Name: ps.Name}},
+			},
+			wantArgs: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enablePostgresTLS(tt.podSpec, ps.Name)
+			volume := tt.podSpec.Volumes[len(tt.podSpec.Volumes)-1]
+			if volume.Secret == nil || volume.Secret.SecretName != postgresTLSSecretName(ps.Name) {
+				t.Errorf("enablePostgresTLS() volume = %v, want serving certificate secret", volume)
+			}
+			for i, container := range tt.podSpec.Containers {
+				enabled := len(container.Args) > 0
+				if enabled != (i == tt.wantArgs) {
+					t.Errorf("enablePostgresTLS() container %s args = %v", container.Name, container.Args)
+				}
+				if enabled && container.VolumeMounts[len(container.VolumeMounts)-1].MountPath != postgresTLSMountPath {
+					t.Errorf("enablePostgresTLS() container %s not mounting the serving certificate", container.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestPostgresProvider_getPostgresTLSArtifacts(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	ps := buildTestPostgresCR()
+	caBundle := string(buildTestCertificatePEM(t, time.Now().AddDate(2, 0, 0)))
+	tests := []struct {
+		name      string
+		existing  []runtime.Object
+		caBundle  string
+		wantKinds []string
+		wantErr   bool
+	}{
+		{
+			name: "test serving certificate and ca bundle are returned",
+			existing: []runtime.Object{&v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: postgresTLSSecretName(ps.Name), Namespace: ps.Namespace},
+				Data:       map[string][]byte{v1.TLSCertKey: buildTestCertificatePEM(t, time.Now().AddDate(1, 0, 0))},
+			}},
+			caBundle:  caBundle,
+			wantKinds: []string{providers.ArtifactKindTLSCertificate, providers.ArtifactKindCABundle},
+		},
+		{
+			name:      "test serving certificate is skipped until it's issued",
+			caBundle:  caBundle,
+			wantKinds: []string{providers.ArtifactKindCABundle},
+		},
+		{
+			name:     "test error on an invalid ca bundle",
+			caBundle: "invalid",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &PostgresProvider{Client: fake.NewFakeClientWithScheme(scheme, tt.existing...), Logger: testLogger}
+			got, err := p.getPostgresTLSArtifacts(context.TODO(), ps, tt.caBundle)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getPostgresTLSArtifacts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.wantKinds) {
+				t.Fatalf("getPostgresTLSArtifacts() = %v, want kinds %v", got, tt.wantKinds)
+			}
+			for i, a := range got {
+				if a.Kind != tt.wantKinds[i] || a.ExpiresAt.IsZero() {
+					t.Errorf("getPostgresTLSArtifacts() artifact %d = %v, want kind %s", i, a, tt.wantKinds[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,431 @@
+package openshift
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/annotations"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// VersionUpgradedCondition is the condition reporting the progress of an upgrade of the data of an openshift postgres
+	// to a newer major version, it's only set once spec.version is newer than the version of the data
+	VersionUpgradedCondition = "VersionUpgraded"
+	// VersionUpgradedReason is the reason of a true version upgraded condition
+	VersionUpgradedReason = "Upgraded"
+	// VersionUpgradeBackupInProgressReason is the reason of a false version upgraded condition while the data is
+	// dumped before the upgrade
+	VersionUpgradeBackupInProgressReason = "BackupInProgress"
+	// VersionUpgradeBackupFailedReason is the reason of a false version upgraded condition when the data couldn't be
+	// dumped, the upgrade is retried once the backup job is deleted
+	VersionUpgradeBackupFailedReason = "BackupFailed"
+	// VersionUpgradeInProgressReason is the reason of a false version upgraded condition while pg_upgrade runs
+	VersionUpgradeInProgressReason = "UpgradeInProgress"
+	// VersionUpgradeCancelledReason is the reason of a false version upgraded condition when the upgrade was cancelled
+	// with the cancel annotation before pg_upgrade ran, the data keeps its version
+	VersionUpgradeCancelledReason = "UpgradeCancelled"
+
+	// postgresVersionAnnotation records the major version of the data in the postgres pvc
+	postgresVersionAnnotation = "integreatly.org/postgres-version"
+	// legacyPostgresVersion is the version of the data in pvcs created before the version was recorded, when the
+	// deployment image doesn't tell otherwise
+	legacyPostgresVersion = "10"
+	// postgresUpgradeEnvVar makes the rhscl postgres image run pg_upgrade on data of the previous major version
+	postgresUpgradeEnvVar = "POSTGRESQL_UPGRADE"
+	// copy keeps the old data directory intact until the upgrade completes
+	postgresUpgradeMode = "copy"
+	// postgresBackupMountPath is where the backup pvc is mounted in the backup job
+	postgresBackupMountPath = "/var/lib/pgsql/backup"
+)
+
+// postgresVersionPlan is the version of the postgres deployment for the current step of an upgrade
+type postgresVersionPlan struct {
+	// Image of the postgres container, empty keeps the default image
+	Image string
+	// Upgrade starts the postgres container with pg_upgrade of the data in the pvc
+	Upgrade bool
+	// From is the version of the data in the pvc
+	From string
+	// To is the version requested by the cr
+	To string
+}
+
+// apply sets the image and upgrade mode of the plan on the postgres deployment
+func (vp *postgresVersionPlan) apply(dpl *appsv1.Deployment) {
+	if vp.Image != "" {
+		dpl.Spec.Template.Spec.Containers[0].Image = vp.Image
+	}
+	if vp.Upgrade {
+		dpl.Spec.Template.Spec.Containers[0].Env = append(dpl.Spec.Template.Spec.Containers[0].Env, v1.EnvVar{Name: postgresUpgradeEnvVar, Value: postgresUpgradeMode})
+	}
+}
+
+// postgresDataVersion returns the major version of the data in the postgres pvc, from its annotation or, for pvcs
+// created before versions were recorded, from the image of the existing deployment
+func postgresDataVersion(pvc *v1.PersistentVolumeClaim, dpl *appsv1.Deployment, supported providers.SupportedVersions) string {
+	if version := pvc.Annotations[postgresVersionAnnotation]; version != "" {
+		return version
+	}
+	if dpl == nil || len(dpl.Spec.Template.Spec.Containers) == 0 {
+		return legacyPostgresVersion
+	}
+	image := dpl.Spec.Template.Spec.Containers[0].Image
+	for _, versions := range []providers.SupportedVersions{supported, defaultSupportedPostgresVersions} {
+		for version, versionImage := range versions {
+			if versionImage == image {
+				return version
+			}
+		}
+	}
+	return legacyPostgresVersion
+}
+
+// postgresVersionImage returns the image of a version from the supported versions of the strategy, falling back to
+// the defaults so data of a version dropped from the strategy can still be upgraded
+func postgresVersionImage(version string, supported providers.SupportedVersions) (string, error) {
+	image, err := providers.ResolveVersion(version, supported, defaultSupportedPostgresVersions)
+	if err == nil {
+		return image, nil
+	}
+	if image, ok := defaultSupportedPostgresVersions[version]; ok && image != "" {
+		return image, nil
+	}
+	return "", err
+}
+
+// validatePostgresUpgrade returns an error unless the requested version is the next supported version after the
+// version of the data, pg_upgrade in the rhscl images only upgrades from the previous major version
+func validatePostgresUpgrade(from, to string, supported providers.SupportedVersions) error {
+	upgradeNeeded, err := resources.VerifyVersionUpgradeNeeded(from, to)
+	if err != nil {
+		return errorUtil.Wrap(err, "invalid postgres version")
+	}
+	if !upgradeNeeded {
+		return errorUtil.Errorf("postgres can't be downgraded from version %s to %s", from, to)
+	}
+	if len(supported) == 0 {
+		supported = defaultSupportedPostgresVersions
+	}
+	for version := range supported {
+		afterFrom, err := resources.VerifyVersionUpgradeNeeded(from, version)
+		if err != nil {
+			return errorUtil.Wrap(err, "invalid postgres version")
+		}
+		beforeTo, err := resources.VerifyVersionUpgradeNeeded(version, to)
+		if err != nil {
+			return errorUtil.Wrap(err, "invalid postgres version")
+		}
+		if afterFrom && beforeTo {
+			return errorUtil.Errorf("postgres can't be upgraded from version %s to %s directly, upgrade to version %s first", from, to, version)
+		}
+	}
+	return nil
+}
+
+// reconcilePostgresVersion returns the version plan of the postgres deployment, running the image of the version
+// requested by the cr. When spec.version is newer than the version of the data, the data is dumped to a backup pvc by
+// a job running the old version before the deployment is upgraded
+func (p *PostgresProvider) reconcilePostgresVersion(ctx context.Context, ps *v1alpha1.Postgres, workload *v1alpha1.Postgres, image string, stratCfg *StrategyConfig, postgresCfg *PostgresStrat) (*postgresVersionPlan, croType.StatusMessage, error) {
+	plan := &postgresVersionPlan{Image: image, From: ps.Spec.Version, To: ps.Spec.Version}
+	// without a version the image is left to the strategy and the data is never upgraded
+	if ps.Spec.Version == "" {
+		return plan, "", nil
+	}
+
+	pvc := &v1.PersistentVolumeClaim{}
+	if err := p.Client.Get(ctx, client.ObjectKey{Name: workload.Name, Namespace: workload.Namespace}, pvc); err != nil {
+		errMsg := fmt.Sprintf("failed to get postgres pvc for instance %s", ps.Name)
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	dpl := &appsv1.Deployment{}
+	if err := p.Client.Get(ctx, client.ObjectKey{Name: workload.Name, Namespace: workload.Namespace}, dpl); err != nil {
+		if !k8serr.IsNotFound(err) {
+			errMsg := fmt.Sprintf("failed to get postgres deployment for instance %s", ps.Name)
+			return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+		dpl = nil
+	}
+	plan.From = postgresDataVersion(pvc, dpl, stratCfg.SupportedVersions)
+	if plan.From == plan.To {
+		return plan, "", nil
+	}
+	if err := validatePostgresUpgrade(plan.From, plan.To, stratCfg.SupportedVersions); err != nil {
+		errMsg := fmt.Sprintf("failed to upgrade postgres instance %s: %v", ps.Name, err)
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, "failed to validate postgres upgrade")
+	}
+	fromImage, err := postgresVersionImage(plan.From, stratCfg.SupportedVersions)
+	if err != nil {
+		errMsg := fmt.Sprintf("unsupported version %s of the data of postgres instance %s: %v", plan.From, ps.Name, err)
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, "failed to resolve postgres data version")
+	}
+
+	// the old version keeps running until the backup is complete
+	backupPlan := &postgresVersionPlan{Image: fromImage, From: plan.From, To: plan.To}
+	jobName := postgresUpgradeBackupName(workload.Name, plan.To)
+
+	// a cancelled upgrade removes its backup and keeps the old version, once pg_upgrade runs it has to complete
+	if annotations.Has(ps, providers.CancelAnnotation) {
+		if deploymentUpgrading(dpl) {
+			p.Logger.Warnf("postgres %s is upgrading from version %s to %s, the upgrade can't be cancelled", ps.Name, plan.From, plan.To)
+		} else {
+			if err := p.deletePostgresUpgradeBackup(ctx, workload.Namespace, jobName); err != nil {
+				errMsg := fmt.Sprintf("failed to cancel upgrade of postgres instance %s", ps.Name)
+				return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+			}
+			setPostgresVersionUpgradeCondition(ps, VersionUpgradeCancelledReason, fmt.Sprintf("upgrade of postgres %s from version %s to %s was cancelled, remove the %s annotation to upgrade", ps.Name, plan.From, plan.To, providers.CancelAnnotation))
+			return backupPlan, "", nil
+		}
+	}
+
+	job := &batchv1.Job{}
+	if err := p.Client.Get(ctx, client.ObjectKey{Name: jobName, Namespace: workload.Namespace}, job); err != nil {
+		if !k8serr.IsNotFound(err) {
+			errMsg := fmt.Sprintf("failed to get postgres backup job %s", jobName)
+			return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+		if dpl == nil || !deploymentAvailable(dpl) {
+			msg := fmt.Sprintf("waiting for postgres %s to be available before backing up version %s", ps.Name, plan.From)
+			setPostgresVersionUpgradeCondition(ps, VersionUpgradeBackupInProgressReason, msg)
+			return backupPlan, croType.StatusMessage(msg), nil
+		}
+		if err := p.createPostgresUpgradeBackup(ctx, workload, pvc, fromImage, plan.To, postgresCfg); err != nil {
+			errMsg := fmt.Sprintf("failed to create backup of postgres instance %s", ps.Name)
+			return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+		msg := fmt.Sprintf("started backup %s of postgres %s before upgrading from version %s to %s", jobName, ps.Name, plan.From, plan.To)
+		setPostgresVersionUpgradeCondition(ps, VersionUpgradeBackupInProgressReason, msg)
+		return backupPlan, croType.StatusMessage(msg), nil
+	}
+	if jobFailed(job) {
+		errMsg := fmt.Sprintf("backup %s of postgres %s failed, delete the job to retry the upgrade to version %s", jobName, ps.Name, plan.To)
+		setPostgresVersionUpgradeCondition(ps, VersionUpgradeBackupFailedReason, errMsg)
+		return nil, croType.StatusMessage(errMsg), errorUtil.New(errMsg)
+	}
+	if job.Status.Succeeded == 0 {
+		msg := fmt.Sprintf("waiting for backup %s of postgres %s to complete before upgrading from version %s to %s", jobName, ps.Name, plan.From, plan.To)
+		setPostgresVersionUpgradeCondition(ps, VersionUpgradeBackupInProgressReason, msg)
+		return backupPlan, croType.StatusMessage(msg), nil
+	}
+
+	plan.Upgrade = true
+	setPostgresVersionUpgradeCondition(ps, VersionUpgradeInProgressReason, fmt.Sprintf("postgres %s is upgrading from version %s to %s", ps.Name, plan.From, plan.To))
+	return plan, "", nil
+}
+
+// completePostgresVersionUpgrade records the new version of the data once the upgraded deployment is available and
+// removes the backup job, the backup pvc is kept. Returns false while the upgrade is running
+func (p *PostgresProvider) completePostgresVersionUpgrade(ctx context.Context, ps *v1alpha1.Postgres, dpl *appsv1.Deployment, plan *postgresVersionPlan) (bool, croType.StatusMessage, error) {
+	if !deploymentRolledOut(dpl) {
+		return false, croType.StatusMessage(fmt.Sprintf("upgrading postgres %s from version %s to %s", ps.Name, plan.From, plan.To)), nil
+	}
+	pvc := &v1.PersistentVolumeClaim{}
+	if err := p.Client.Get(ctx, client.ObjectKey{Name: dpl.Name, Namespace: dpl.Namespace}, pvc); err != nil {
+		errMsg := fmt.Sprintf("failed to get postgres pvc for instance %s", ps.Name)
+		return false, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	if pvc.Annotations == nil {
+		pvc.Annotations = map[string]string{}
+	}
+	pvc.Annotations[postgresVersionAnnotation] = plan.To
+	if err := p.Client.Update(ctx, pvc); err != nil {
+		errMsg := fmt.Sprintf("failed to record version %s of postgres instance %s", plan.To, ps.Name)
+		return false, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      postgresUpgradeBackupName(dpl.Name, plan.To),
+			Namespace: dpl.Namespace,
+		},
+	}
+	if err := p.Client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !k8serr.IsNotFound(err) {
+		errMsg := fmt.Sprintf("failed to delete postgres backup job %s", job.Name)
+		return false, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	meta.SetStatusCondition(&ps.Status.Conditions, metav1.Condition{
+		Type:               VersionUpgradedCondition,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: ps.GetGeneration(),
+		Reason:             VersionUpgradedReason,
+		Message:            fmt.Sprintf("postgres %s was upgraded from version %s to %s", ps.Name, plan.From, plan.To),
+	})
+	return true, "", nil
+}
+
+// createPostgresUpgradeBackup creates the backup pvc, with the storage of the postgres pvc, and the job dumping the data
+// of the running postgres to it
+func (p *PostgresProvider) createPostgresUpgradeBackup(ctx context.Context, workload *v1alpha1.Postgres, pvc *v1.PersistentVolumeClaim, image, version string, postgresCfg *PostgresStrat) error {
+	name := postgresUpgradeBackupName(workload.Name, version)
+	backupPVC := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: workload.Namespace,
+		},
+		Spec: v1.PersistentVolumeClaimSpec{
+			AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceStorage: pvc.Spec.Resources.Requests[v1.ResourceStorage]},
+			},
+		},
+	}
+	applyWorkloadStorage(&backupPVC.Spec, postgresCfg.Storage)
+	applyLabelOverrides(backupPVC, postgresCfg.Overrides)
+	if or, err := immutableCreateOrUpdate(ctx, p.Client, p.Logger, backupPVC, func(existing runtime.Object) error {
+		return nil
+	}); err != nil {
+		return errorUtil.Wrapf(err, "failed to create or update persistent volume claim %s, action was %s", name, or)
+	}
+	job := buildPostgresUpgradeBackupJob(workload, image, version)
+	if err := resources.ApplyMetadata(ctx, p.Client, job); err != nil {
+		return errorUtil.Wrapf(err, "failed to set metadata of job %s", name)
+	}
+	if err := p.Client.Create(ctx, job); err != nil {
+		return errorUtil.Wrapf(err, "failed to create job %s", name)
+	}
+	return nil
+}
+
+// deletePostgresUpgradeBackup deletes the backup job and pvc of an upgrade, stopping a running backup
+func (p *PostgresProvider) deletePostgresUpgradeBackup(ctx context.Context, namespace, name string) error {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	if err := p.Client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !k8serr.IsNotFound(err) {
+		return errorUtil.Wrapf(err, "failed to delete job %s", name)
+	}
+	backupPVC := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	if err := deleteObject(ctx, p.Client, backupPVC); err != nil && !k8serr.IsNotFound(err) {
+		return errorUtil.Wrapf(err, "failed to delete persistent volume claim %s", name)
+	}
+	return nil
+}
+
+// buildPostgresUpgradeBackupJob returns the job dumping all databases of the postgres to the backup pvc with
+// pg_dumpall of the version being upgraded
+func buildPostgresUpgradeBackupJob(workload *v1alpha1.Postgres, image, version string) *batchv1.Job {
+	name := postgresUpgradeBackupName(workload.Name, version)
+	credentialsSec := postgresCredentialsSecretName(workload.Name)
+	backoffLimit := int32(2)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: workload.Namespace,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyNever,
+					Volumes: []v1.Volume{
+						{
+							Name: name,
+							VolumeSource: v1.VolumeSource{
+								PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+									ClaimName: name,
+								},
+							},
+						},
+					},
+					Containers: []v1.Container{
+						{
+							Name:    "backup",
+							Image:   image,
+							Command: []string{"/bin/sh", "-c", fmt.Sprintf("pg_dumpall -h %s -p %d -f %s/dumpall.sql", workload.Name, defaultPostgresPort, postgresBackupMountPath)},
+							Env: []v1.EnvVar{
+								envVarFromSecret("PGUSER", credentialsSec, defaultPostgresUserKey),
+								envVarFromSecret("PGPASSWORD", credentialsSec, defaultPostgresPasswordKey),
+							},
+							VolumeMounts: []v1.VolumeMount{
+								{
+									Name:      name,
+									MountPath: postgresBackupMountPath,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	// required for restricted namespace
+	if strings.HasPrefix(workload.Namespace, NamespacePrefixOpenShift) {
+		userGroupId := int64(26)
+		job.Spec.Template.Spec.SecurityContext = &v1.PodSecurityContext{
+			FSGroup:            &userGroupId,
+			SupplementalGroups: []int64{userGroupId},
+		}
+	}
+	return job
+}
+
+// postgresUpgradeBackupName returns the name of the backup job and pvc of an upgrade to the version
+func postgresUpgradeBackupName(name, version string) string {
+	return fmt.Sprintf("%s-pre-upgrade-%s", name, strings.ReplaceAll(version, ".", "-"))
+}
+
+// setPostgresVersionUpgradeCondition sets a false version upgraded condition with the reason and message
+func setPostgresVersionUpgradeCondition(ps *v1alpha1.Postgres, reason, msg string) {
+	meta.SetStatusCondition(&ps.Status.Conditions, metav1.Condition{
+		Type:               VersionUpgradedCondition,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: ps.GetGeneration(),
+		Reason:             reason,
+		Message:            msg,
+	})
+}
+
+// deploymentRolledOut returns true once all replicas of the latest generation of the deployment are available
+func deploymentRolledOut(dpl *appsv1.Deployment) bool {
+	replicas := int32(1)
+	if dpl.Spec.Replicas != nil {
+		replicas = *dpl.Spec.Replicas
+	}
+	return deploymentAvailable(dpl) &&
+		dpl.Status.ObservedGeneration >= dpl.Generation &&
+		dpl.Status.UpdatedReplicas == replicas &&
+		dpl.Status.AvailableReplicas == replicas
+}
+
+// deploymentUpgrading returns true if the postgres container of the deployment runs pg_upgrade on start
+func deploymentUpgrading(dpl *appsv1.Deployment) bool {
+	if dpl == nil || len(dpl.Spec.Template.Spec.Containers) == 0 {
+		return false
+	}
+	for _, env := range dpl.Spec.Template.Spec.Containers[0].Env {
+		if env.Name == postgresUpgradeEnvVar {
+			return true
+		}
+	}
+	return false
+}
+
+// jobFailed returns true if the job has the failed condition
+func jobFailed(job *batchv1.Job) bool {
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == v1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,294 @@
+package openshift
+
+import (
+	"context"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func buildTestVersionedPostgresPVC(version string) *v1.PersistentVolumeClaim {
+	pvc := buildTestBoundPostgresPVC("", "1Gi")
+	if version != "" {
+		pvc.Annotations = map[string]string{postgresVersionAnnotation: version}
+	}
+	return pvc
+}
+
+func buildTestVersionedPostgresDeployment(image string, available bool) *appsv1.Deployment {
+	dpl := buildDefaultPostgresDeployment(buildTestPostgresCR())
+	dpl.Spec.Template.Spec.Containers[0].Image = image
+	if available {
+		dpl.Status = appsv1.DeploymentStatus{
+			UpdatedReplicas:   1,
+			AvailableReplicas: 1,
+			Conditions:        []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable, Status: v1.ConditionTrue}},
+		}
+	}
+	return dpl
+}
+
+func buildTestUpgradingPostgresDeployment(image string) *appsv1.Deployment {
+	dpl := buildTestVersionedPostgresDeployment(image, false)
+	(&postgresVersionPlan{Upgrade: true}).apply(dpl)
+	return dpl
+}
+
+func buildTestBackupJob(version string, succeeded bool, failed bool) *batchv1.Job {
+	job := buildPostgresUpgradeBackupJob(buildTestPostgresCR(), defaultPostgresImage, version)
+	if succeeded {
+		job.Status.Succeeded = 1
+	}
+	if failed {
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: v1.ConditionTrue}}
+	}
+	return job
+}
+
+func Test_validatePostgresUpgrade(t *testing.T) {
+	tests := []struct {
+		name      string
+		from      string
+		to        string
+		supported providers.SupportedVersions
+		wantErr   bool
+	}{
+		{
+			name: "test upgrade to the next version is valid",
+			from: "9.6",
+			to:   "10",
+		},
+		{
+			name:      "test upgrade to the next version of the strategy is valid",
+			from:      "10",
+			to:        "13",
+			supported: providers.SupportedVersions{"10": "postgres:10", "13": "postgres:13"},
+		},
+		{
+			name:    "test upgrade skipping a version is invalid",
+			from:    "10",
+			to:      "13",
+			wantErr: true,
+		},
+		{
+			name:    "test downgrade is invalid",
+			from:    "12",
+			to:      "10",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validatePostgresUpgrade(tt.from, tt.to, tt.supported); (err != nil) != tt.wantErr {
+				t.Errorf("validatePostgresUpgrade() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_postgresDataVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		pvc  *v1.PersistentVolumeClaim
+		dpl  *appsv1.Deployment
+		want string
+	}{
+		{
+			name: "test version of the pvc annotation",
+			pvc:  buildTestVersionedPostgresPVC("12"),
+			dpl:  buildTestVersionedPostgresDeployment(defaultPostgresImage, true),
+			want: "12",
+		},
+		{
+			name: "test version of the deployment image",
+			pvc:  buildTestVersionedPostgresPVC(""),
+			dpl:  buildTestVersionedPostgresDeployment(defaultSupportedPostgresVersions["9.6"], true),
+			want: "9.6",
+		},
+		{
+			name: "test legacy version without a deployment",
+			pvc:  buildTestVersionedPostgresPVC(""),
+			want: legacyPostgresVersion,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := postgresDataVersion(tt.pvc, tt.dpl, nil); got != tt.want {
+				t.Errorf("postgresDataVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPostgresProvider_reconcilePostgresVersion(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	image10 := defaultSupportedPostgresVersions["10"]
+	image12 := defaultSupportedPostgresVersions["12"]
+	tests := []struct {
+		name        string
+		existing    []runtime.Object
+		cancel      bool
+		wantImage   string
+		wantUpgrade bool
+		wantJob     bool
+		wantReason  string
+		wantErr     bool
+	}{
+		{
+			name:      "test no upgrade when the data has the requested version",
+			existing:  []runtime.Object{buildTestVersionedPostgresPVC("12"), buildTestVersionedPostgresDeployment(image12, true)},
+			wantImage: image12,
+		},
+		{
+			name:       "test old version keeps running until the deployment is available",
+			existing:   []runtime.Object{buildTestVersionedPostgresPVC("10"), buildTestVersionedPostgresDeployment(image10, false)},
+			wantImage:  image10,
+			wantReason: VersionUpgradeBackupInProgressReason,
+		},
+		{
+			name:       "test backup job is created before the upgrade",
+			existing:   []runtime.Object{buildTestVersionedPostgresPVC(""), buildTestVersionedPostgresDeployment(image10, true)},
+			wantImage:  image10,
+			wantJob:    true,
+			wantReason: VersionUpgradeBackupInProgressReason,
+		},
+		{
+			name:       "test upgrade waits for the backup job",
+			existing:   []runtime.Object{buildTestVersionedPostgresPVC("10"), buildTestVersionedPostgresDeployment(image10, true), buildTestBackupJob("12", false, false)},
+			wantImage:  image10,
+			wantJob:    true,
+			wantReason: VersionUpgradeBackupInProgressReason,
+		},
+		{
+			name:       "test error when the backup job failed",
+			existing:   []runtime.Object{buildTestVersionedPostgresPVC("10"), buildTestVersionedPostgresDeployment(image10, true), buildTestBackupJob("12", false, true)},
+			wantJob:    true,
+			wantReason: VersionUpgradeBackupFailedReason,
+			wantErr:    true,
+		},
+		{
+			name:        "test upgrade once the backup is complete",
+			existing:    []runtime.Object{buildTestVersionedPostgresPVC("10"), buildTestVersionedPostgresDeployment(image10, true), buildTestBackupJob("12", true, false)},
+			wantImage:   image12,
+			wantUpgrade: true,
+			wantJob:     true,
+			wantReason:  VersionUpgradeInProgressReason,
+		},
+		{
+			name:       "test cancelled upgrade removes the backup and keeps the old version",
+			existing:   []runtime.Object{buildTestVersionedPostgresPVC("10"), buildTestVersionedPostgresDeployment(image10, true), buildTestBackupJob("12", false, false)},
+			cancel:     true,
+			wantImage:  image10,
+			wantReason: VersionUpgradeCancelledReason,
+		},
+		{
+			name:        "test running upgrade isn't cancelled",
+			existing:    []runtime.Object{buildTestVersionedPostgresPVC("10"), buildTestUpgradingPostgresDeployment(image12), buildTestBackupJob("12", true, false)},
+			cancel:      true,
+			wantImage:   image12,
+			wantUpgrade: true,
+			wantJob:     true,
+			wantReason:  VersionUpgradeInProgressReason,
+		},
+		{
+			name:     "test error when the data has a newer version",
+			existing: []runtime.Object{buildTestVersionedPostgresPVC("13"), buildTestVersionedPostgresDeployment(image12, true)},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme, tt.existing...)
+			p := &PostgresProvider{Client: c, Logger: testLogger}
+			ps := buildTestPostgresCR()
+			ps.Spec.Version = "12"
+			if tt.cancel {
+				ps.Annotations = map[string]string{providers.CancelAnnotation: "true"}
+			}
+			got, _, err := p.reconcilePostgresVersion(context.TODO(), ps, ps.DeepCopy(), image12, &StrategyConfig{}, &PostgresStrat{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("reconcilePostgresVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != nil && (got.Image != tt.wantImage || got.Upgrade != tt.wantUpgrade) {
+				t.Errorf("reconcilePostgresVersion() = %+v, want image %s and upgrade %v", got, tt.wantImage, tt.wantUpgrade)
+			}
+			err = c.Get(context.TODO(), client.ObjectKey{Name: postgresUpgradeBackupName(ps.Name, "12"), Namespace: ps.Namespace}, &batchv1.Job{})
+			if gotJob := err == nil; gotJob != tt.wantJob {
+				t.Errorf("reconcilePostgresVersion() backup job exists %v, want %v", gotJob, tt.wantJob)
+			}
+			cond := meta.FindStatusCondition(ps.Status.Conditions, VersionUpgradedCondition)
+			if tt.wantReason == "" {
+				if cond != nil {
+					t.Errorf("reconcilePostgresVersion() unexpected condition %+v", cond)
+				}
+				return
+			}
+			if cond == nil || cond.Reason != tt.wantReason {
+				t.Errorf("reconcilePostgresVersion() expected a %s condition, got %+v", tt.wantReason, cond)
+			}
+		})
+	}
+}
+
+func TestPostgresProvider_completePostgresVersionUpgrade(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	image12 := defaultSupportedPostgresVersions["12"]
+	tests := []struct {
+		name        string
+		dpl         *appsv1.Deployment
+		want        bool
+		wantVersion string
+	}{
+		{
+			name:        "test upgrade in progress until the deployment is rolled out",
+			dpl:         buildTestVersionedPostgresDeployment(image12, false),
+			want:        false,
+			wantVersion: "10",
+		},
+		{
+			name:        "test version is recorded once the deployment is rolled out",
+			dpl:         buildTestVersionedPostgresDeployment(image12, true),
+			want:        true,
+			wantVersion: "12",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme, buildTestVersionedPostgresPVC("10"), tt.dpl, buildTestBackupJob("12", true, false))
+			p := &PostgresProvider{Client: c, Logger: testLogger}
+			ps := buildTestPostgresCR()
+			got, _, err := p.completePostgresVersionUpgrade(context.TODO(), ps, tt.dpl, &postgresVersionPlan{Image: image12, Upgrade: true, From: "10", To: "12"})
+			if err != nil {
+				t.Fatalf("completePostgresVersionUpgrade() unexpected error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("completePostgresVersionUpgrade() = %v, want %v", got, tt.want)
+			}
+			pvc := &v1.PersistentVolumeClaim{}
+			if err := c.Get(context.TODO(), client.ObjectKey{Name: ps.Name, Namespace: ps.Namespace}, pvc); err != nil {
+				t.Fatal("failed to get pvc", err)
+			}
+			if pvc.Annotations[postgresVersionAnnotation] != tt.wantVersion {
+				t.Errorf("completePostgresVersionUpgrade() pvc version = %s, want %s", pvc.Annotations[postgresVersionAnnotation], tt.wantVersion)
+			}
+			cond := meta.FindStatusCondition(ps.Status.Conditions, VersionUpgradedCondition)
+			if tt.want && (cond == nil || cond.Status != metav1.ConditionTrue) {
+				t.Errorf("completePostgresVersionUpgrade() expected a true condition, got %+v", cond)
+			}
+		})
+	}
+}
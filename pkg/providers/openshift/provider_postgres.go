@@ -14,6 +14,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources/metrics"
 
 	errorUtil "github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -35,23 +36,57 @@ import (
 var (
 	postgresProviderName = "openshift-postgres-template"
 	// default openshift create paramaters
-	defaultPostgresPort        = 5432
-	defaultPostgresUser        = "postgresuser"
-	defaultPostgresPassword    = "password"
+	defaultPostgresPort       = 5432
+	defaultPostgresImage      = "registry.redhat.io/rhscl/postgresql-10-rhel7"
+	defaultPostgresUserPrefix = "user"
+	// password used by secrets created before credentials were generated, rotated on the next reconcile
+	legacyPostgresPassword     = "password"
 	defaultPostgresUserKey     = "user"
 	defaultPostgresPasswordKey = "password"
 	defaultPostgresDatabaseKey = "database"
 	defaultCredentialsSec      = "postgres-credentials"
 )
 
+// postgresCredentialsSecretName returns the name of the credentials secret of the postgres, scoped to the postgres so
+// every postgres of a namespace has its own credentials
+func postgresCredentialsSecretName(name string) string {
+	return fmt.Sprintf("%s-%s", name, defaultCredentialsSec)
+}
+
 // PostgresStrat to be used to unmarshal strat map
 type PostgresStrat struct {
 	_ struct{} `type:"structure"`
 
-	PostgresDeploymentSpec *appsv1.DeploymentSpec        `json:"deploymentSpec"`
-	PostgresServiceSpec    *v1.ServiceSpec               `json:"serviceSpec"`
-	PostgresPVCSpec        *v1.PersistentVolumeClaimSpec `json:"pvcSpec"`
-	PostgresSecretData     map[string]string             `json:"secretData"`
+	PostgresDeploymentSpec *appsv1.DeploymentSpec        `json:"deploymentSpec,omitempty"`
+	PostgresServiceSpec    *v1.ServiceSpec               `json:"serviceSpec,omitempty"`
+	PostgresPVCSpec        *v1.PersistentVolumeClaimSpec `json:"pvcSpec,omitempty"`
+	PostgresSecretData     map[string]string             `json:"secretData,omitempty"`
+	// Isolation configures the namespace the postgres workload is created in
+	Isolation *WorkloadIsolation `json:"isolation,omitempty"`
+	// PodExtensions are merged into the postgres pod template, including an overriding deploymentSpec
+	PodExtensions *PodExtensions `json:"podExtensions,omitempty"`
+	// Overrides are merged onto the generated postgres objects, including overriding specs
+	Overrides *WorkloadOverrides `json:"overrides,omitempty"`
+	// TLS serves postgres over tls with a serving certificate from the openshift service ca
+	TLS bool `json:"tls,omitempty"`
+	// Pooler puts a pgbouncer connection pooler in front of the postgres
+	Pooler *PostgresPooler `json:"pooler,omitempty"`
+	// Storage selects the storage class, volume mode and access modes of the postgres pvc
+	Storage *WorkloadStorage `json:"storage,omitempty"`
+	// VolumeSnapshot configures the csi volume snapshots of the postgres pvc taken for postgres snapshots
+	VolumeSnapshot *PostgresVolumeSnapshot `json:"volumeSnapshot,omitempty"`
+	// NetworkPolicy restricts the ingress to the postgres and its pooler to the allowed namespaces
+	NetworkPolicy *WorkloadNetworkPolicy `json:"networkPolicy,omitempty"`
+	// Scheduling places the postgres pod on nodes and creates its pod disruption budget
+	Scheduling *WorkloadScheduling `json:"scheduling,omitempty"`
+}
+
+// defaultSupportedPostgresVersions maps the postgres versions a cr can request to postgres images
+var defaultSupportedPostgresVersions = providers.SupportedVersions{
+	"9.6": "registry.redhat.io/rhscl/postgresql-96-rhel7",
+	"10":  defaultPostgresImage,
+	"12":  "registry.redhat.io/rhscl/postgresql-12-rhel7",
+	"13":  "registry.redhat.io/rhscl/postgresql-13-rhel7",
 }
 
 var _ providers.PostgresProvider = (*PostgresProvider)(nil)
@@ -61,12 +96,14 @@ type PostgresProvider struct {
 	Logger        *logrus.Entry
 	ConfigManager ConfigManager
 	PodCommander  resources.PodCommander
+	PoolerStats   PoolerStatsReader
 }
 
 func NewOpenShiftPostgresProvider(client client.Client, cs *kubernetes.Clientset, logger *logrus.Entry) *PostgresProvider {
 	return &PostgresProvider{
 		Client:        client,
 		PodCommander:  &resources.OpenShiftPodCommander{ClientSet: cs},
+		PoolerStats:   &PgBouncerStatsReader{},
 		Logger:        logger.WithFields(logrus.Fields{"provider": postgresProviderName}),
 		ConfigManager: NewDefaultConfigManager(client),
 	}
@@ -95,41 +132,151 @@ func (p *PostgresProvider) ReconcilePostgres(ctx context.Context, ps *v1alpha1.P
 	}
 
 	// get postgres config
-	postgresCfg, _, err := p.getPostgresConfig(ctx, ps)
+	postgresCfg, stratCfg, err := p.getPostgresConfig(ctx, ps)
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to retrieve openshift postgres config for instance %s", ps.Name)
 		return nil, croType.StatusMessage(errMsg), errorUtil.Wrapf(err, errMsg)
 	}
-
-	// deploy pvc
-	if err := p.CreatePVC(ctx, buildDefaultPostgresPVC(ps), postgresCfg); err != nil {
-		errMsg := fmt.Sprintf("failed to create or update postgres PVC for instance %s", ps.Name)
+	// a version requested by the cr replaces the default postgres image
+	image, err := providers.ResolveVersion(ps.Spec.Version, stratCfg.SupportedVersions, defaultSupportedPostgresVersions)
+	if err != nil {
+		errMsg := fmt.Sprintf("unsupported postgres version for instance %s: %v", ps.Name, err)
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, "failed to resolve postgres version")
+	}
+	// tls can be enabled for a single instance, regardless of the strategy
+	postgresCfg.TLS = postgresCfg.TLS || ps.Spec.TLS
+	if err := validatePostgresPooler(postgresCfg); err != nil {
+		errMsg := fmt.Sprintf("invalid openshift postgres pooler config for instance %s", ps.Name)
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	if err := validateWorkloadStorage(ctx, p.Client, postgresCfg.Storage); err != nil {
+		errMsg := fmt.Sprintf("invalid openshift postgres storage config for instance %s", ps.Name)
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	if err := validateWorkloadScheduling(postgresCfg.Scheduling); err != nil {
+		errMsg := fmt.Sprintf("invalid openshift postgres scheduling config for instance %s", ps.Name)
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	if err := validateWorkloadNetworkPolicy(postgresCfg.NetworkPolicy); err != nil {
+		errMsg := fmt.Sprintf("invalid openshift postgres network policy config for instance %s", ps.Name)
 		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 	}
-	// deploy credentials secret
-	password, err := resources.GeneratePassword()
+	externalCIDRs, err := providers.ParseExternalAccess(ps.Spec.ExternalAccess)
 	if err != nil {
-		errMsg := "failed to generate potential postgres password"
+		errMsg := fmt.Sprintf("invalid external access for instance %s", ps.Name)
 		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 	}
-	if err := p.CreateSecret(ctx, buildDefaultPostgresSecret(ps, password), postgresCfg); err != nil {
-		errMsg := fmt.Sprintf("failed to create or update postgres secret for instance %s", ps.Name)
+
+	// workload objects are built from a copy of the cr in the namespace they're created in
+	ns, err := reconcileWorkloadNamespace(ctx, p.Client, ps, &ps.Status, postgresCfg.Isolation)
+	if err != nil {
+		errMsg := "failed to reconcile postgres workload namespace"
 		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 	}
-	// deploy deployment
-	if err := p.CreateDeployment(ctx, buildDefaultPostgresDeployment(ps), postgresCfg); err != nil {
-		errMsg := fmt.Sprintf("failed to create or update postgres deployment for instance %s", ps.Name)
+	workload := ps.DeepCopy()
+	workload.Namespace = ns
+	recordWorkloadNamespace(&ps.Status, workload)
+	// restrict the ingress to postgres and its pooler before either is deployed
+	if err := reconcileWorkloadNetworkPolicy(ctx, p.Client, p.Logger, ps, workload.Name, ns, []string{workload.Name, postgresPoolerName(workload.Name)}, postgresCfg.NetworkPolicy, externalCIDRs); err != nil {
+		errMsg := fmt.Sprintf("failed to reconcile network policy for instance %s", ps.Name)
 		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 	}
-	// deploy service
-	if err := p.CreateService(ctx, buildDefaultPostgresService(ps), postgresCfg); err != nil {
-		errMsg := fmt.Sprintf("failed to create or update postgres service for instance %s", ps.Name)
+	// keep node drains from evicting the postgres pod
+	if err := reconcileWorkloadDisruptionBudget(ctx, p.Client, p.Logger, workload.Name, ns, false, postgresCfg.Scheduling); err != nil {
+		errMsg := fmt.Sprintf("failed to reconcile pod disruption budget for instance %s", ps.Name)
 		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 	}
 
+	// a new pvc holds data of the requested version unless it's restored from a snapshot
+	postgresPVC := buildDefaultPostgresPVC(workload)
+	if ps.Spec.Version != "" {
+		postgresPVC.Annotations = map[string]string{postgresVersionAnnotation: ps.Spec.Version}
+	}
+	restoredUser, restoredDatabase, err := p.reconcilePostgresRestore(ctx, ps, postgresPVC)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to find snapshot to restore postgres instance %s from: %v", ps.Name, err)
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, "failed to reconcile postgres restore")
+	}
+	// the user and password are only generated when the secret is created, restored data already has the user and
+	// database of the snapshotted postgres
+	userGenerator := func() (string, error) { return resources.GenerateUsername(defaultPostgresUserPrefix) }
+	if restoredUser != "" {
+		userGenerator = resources.StaticSecretValue(restoredUser)
+	}
+	postgresSec := buildDefaultPostgresSecret(workload, "", "")
+	if restoredDatabase != "" {
+		postgresSec.Data[defaultPostgresDatabaseKey] = []byte(restoredDatabase)
+	}
+	secretGenerators := map[string]resources.SecretValueGenerator{
+		defaultPostgresUserKey:     userGenerator,
+		defaultPostgresPasswordKey: resources.GeneratePassword,
+		defaultPostgresDatabaseKey: resources.StaticSecretValue(string(postgresSec.Data[defaultPostgresDatabaseKey])),
+	}
+	postgresDpl := buildDefaultPostgresDeployment(workload)
+	postgresSvc := buildDefaultPostgresService(workload)
+	key := types.NamespacedName{Name: workload.Name, Namespace: workload.Namespace}
+
+	// deploy the credentials secret, pvc, deployment and service in order, a new postgres only gets the next object
+	// once the previous one is ready
+	var versionPlan *postgresVersionPlan
+	var versionMsg, versionErrMsg croType.StatusMessage
+	stepsMsg, err := reconcileWorkloadSteps(ctx, p.Client, p.Logger, &ps.Status, []workloadStep{
+		{
+			name: SecretStep,
+			obj:  postgresSec.DeepCopy(),
+			create: func(ctx context.Context) error {
+				return p.CreateSecret(ctx, postgresSec, secretGenerators, postgresCfg)
+			},
+		},
+		{
+			name:   PersistentVolumeClaimStep,
+			obj:    postgresPVC.DeepCopy(),
+			create: func(ctx context.Context) error { return p.CreatePVC(ctx, postgresPVC, postgresCfg) },
+			ready:  func(ctx context.Context) (string, error) { return pvcReady(ctx, p.Client, key) },
+		},
+		{
+			name: DeploymentStep,
+			obj:  postgresDpl.DeepCopy(),
+			create: func(ctx context.Context) error {
+				// resolve the image of the deployment, backing up and upgrading the data when a newer version is requested
+				plan, msg, err := p.reconcilePostgresVersion(ctx, ps, workload, image, stratCfg, postgresCfg)
+				if err != nil {
+					versionErrMsg = msg
+					return errorUtil.Wrap(err, "failed to reconcile postgres version")
+				}
+				versionPlan, versionMsg = plan, msg
+				versionPlan.apply(postgresDpl)
+				return p.CreateDeployment(ctx, postgresDpl, postgresCfg)
+			},
+			ready: func(ctx context.Context) (string, error) { return deploymentReady(ctx, p.Client, key) },
+		},
+		{
+			name:   ServiceStep,
+			obj:    postgresSvc.DeepCopy(),
+			create: func(ctx context.Context) error { return p.CreateService(ctx, postgresSvc, postgresCfg) },
+		},
+	})
+	if err != nil {
+		if versionErrMsg != "" {
+			stepsMsg = versionErrMsg
+		}
+		return nil, stepsMsg, errorUtil.Wrapf(err, "failed to create or update postgres objects for instance %s", ps.Name)
+	}
+	if stepsMsg != croType.StatusEmpty {
+		p.Logger.Info(stepsMsg)
+		return nil, stepsMsg, nil
+	}
+	// expose postgres to the external clients allowed by the cr, revoking access no longer allowed
+	externalEndpoint, err := reconcileExternalAccessService(ctx, p.Client, p.Logger, workload.Name, workload.Namespace, defaultPostgresPort, map[string]string{"deployment": workload.Name}, externalCIDRs)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to reconcile external access for instance %s", ps.Name)
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	providers.SetExternalAccessStatus(p.Logger, providers.PostgresResourceType, ps, &ps.Status, externalCIDRs, externalEndpoint, time.Now())
+
 	// check deployment status
 	dpl := &appsv1.Deployment{}
-	err = p.Client.Get(ctx, types.NamespacedName{Name: ps.Name, Namespace: ps.Namespace}, dpl)
+	err = p.Client.Get(ctx, types.NamespacedName{Name: workload.Name, Namespace: workload.Namespace}, dpl)
 	if err != nil {
 		errMsg := "failed to get postgres deployment"
 		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
@@ -137,24 +284,40 @@ func (p *PostgresProvider) ReconcilePostgres(ctx context.Context, ps *v1alpha1.P
 
 	// get the cred secret
 	sec := &v1.Secret{}
-	credentialsSec := fmt.Sprintf("%s-%s", ps.Name, defaultCredentialsSec)
-	err = p.Client.Get(ctx, types.NamespacedName{Name: credentialsSec, Namespace: ps.Namespace}, sec)
+	credentialsSec := postgresCredentialsSecretName(ps.Name)
+	err = p.Client.Get(ctx, types.NamespacedName{Name: credentialsSec, Namespace: workload.Namespace}, sec)
 	if err != nil {
 		errMsg := "failed to get postgres creds"
 		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 	}
 
-	// check if deployment is ready and return connection details
-	dplAvailable := false
-	for _, s := range dpl.Status.Conditions {
-		if s.Type == appsv1.DeploymentAvailable && s.Status == "True" {
-			dplAvailable = true
-			break
+	// a hibernated postgres is scaled to zero replicas with its pooler, keeping its pvc and secret
+	if hibernationMsg := reconcileHibernation(ps, &ps.Spec, &ps.Status, dpl); hibernationMsg != croType.StatusEmpty {
+		if _, err := p.reconcilePostgresPooler(ctx, workload, sec, postgresCfg); err != nil {
+			errMsg := "failed to reconcile postgres pooler"
+			return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 		}
+		metrics.DeletePoolerStats(types.NamespacedName{Namespace: ps.Namespace, Name: ps.Name})
+		p.Logger.Info(hibernationMsg)
+		return nil, hibernationMsg, nil
 	}
 
-	// deployment is in progress
-	if !dplAvailable {
+	// the version upgrade reports its own progress
+	if versionPlan.Upgrade {
+		upgraded, msg, err := p.completePostgresVersionUpgrade(ctx, ps, dpl, versionPlan)
+		if err != nil {
+			return nil, msg, errorUtil.Wrap(err, "failed to complete postgres version upgrade")
+		}
+		if !upgraded {
+			return nil, msg, nil
+		}
+	}
+	if versionMsg != "" {
+		return nil, versionMsg, nil
+	}
+
+	// check if deployment is ready and return connection details
+	if !deploymentAvailable(dpl) {
 		p.Logger.Info("postgres deployment is not ready")
 		return nil, "creation in progress", nil
 	}
@@ -166,42 +329,155 @@ func (p *PostgresProvider) ReconcilePostgres(ctx context.Context, ps *v1alpha1.P
 		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 	}
 
+	// replace the user password when a credential rotation is due
+	rotationMsg, err := p.rotatePostgresCredentials(ctx, ps, dpl, sec, postgresCfg)
+	if err != nil {
+		return nil, rotationMsg, errorUtil.Wrap(err, "failed to rotate postgres credentials")
+	}
+	if rotationMsg != "" {
+		p.Logger.Info(rotationMsg)
+	}
+
+	// put the pooler in front of the database once it's running, restarting it with the rotated credentials
+	poolerDpl, err := p.reconcilePostgresPooler(ctx, workload, sec, postgresCfg)
+	if err != nil {
+		errMsg := "failed to reconcile postgres pooler"
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	if poolerDpl != nil && !deploymentAvailable(poolerDpl) {
+		p.Logger.Info("postgres pooler deployment is not ready")
+		return nil, "pooler creation in progress", nil
+	}
+	p.observePoolerStats(ctx, ps, poolerDpl, dbUser, string(sec.Data["password"]))
+
+	// estimate the cost from the cluster capacity requested by the deployment and its storage
+	usage := &workloadUsage{}
+	usage.addPods(dpl.Spec.Replicas, dpl.Spec.Template.Spec)
+	if poolerDpl != nil {
+		usage.addPods(poolerDpl.Spec.Replicas, poolerDpl.Spec.Template.Spec)
+	}
+	pvc := &v1.PersistentVolumeClaim{}
+	if err := p.Client.Get(ctx, types.NamespacedName{Name: workload.Name, Namespace: workload.Namespace}, pvc); err != nil {
+		p.Logger.Warnf("failed to get postgres pvc, storage is not included in the cost estimate: %v", err)
+	} else {
+		usage.addStorage(nil, *pvc)
+		// report resizes of the pvc, including those which need manual intervention
+		if err := setPostgresStorageCondition(ctx, p.Client, ps, pvc, postgresCfg); err != nil {
+			errMsg := "failed to check postgres pvc storage"
+			return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+	}
+
+	deploymentDetails := &providers.PostgresDeploymentDetails{
+		Username: dbUser,
+		Password: string(sec.Data["password"]),
+		Database: string(sec.Data["database"]),
+		Host:     fmt.Sprintf("%s.%s.svc.cluster.local", workload.Name, workload.Namespace),
+		Port:     defaultPostgresPort,
+	}
+	// consumers connect through the pooler, which is served on the postgres port
+	if poolerDpl != nil {
+		deploymentDetails.Host = fmt.Sprintf("%s.%s.svc.cluster.local", poolerDpl.Name, poolerDpl.Namespace)
+	}
+	// consumers verify the serving certificate with the service ca bundle
+	var artifacts []providers.ExpiringArtifact
+	if postgresCfg.TLS {
+		caBundle, err := p.reconcilePostgresServiceCA(ctx, workload)
+		if err != nil {
+			errMsg := "failed to reconcile postgres service ca bundle"
+			return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+		if caBundle == "" {
+			return nil, "waiting for service ca bundle to be injected", nil
+		}
+		deploymentDetails.SSLMode = postgresTLSSSLMode
+		deploymentDetails.CACert = caBundle
+		// expiring certificates are reported, failing to read them doesn't block provisioning
+		if artifacts, err = p.getPostgresTLSArtifacts(ctx, workload, caBundle); err != nil {
+			p.Logger.Warnf("failed to get expiry of postgres certificates: %v", err)
+		}
+	}
+
+	ps.Status.CloudResource = buildWorkloadCloudResourceStatus(workload, deploymentDetails.Host, deploymentDetails.Port)
 	p.Logger.Info("found postgres deployment")
 	return &providers.PostgresInstance{
-		DeploymentDetails: &providers.PostgresDeploymentDetails{
-			Username: dbUser,
-			Password: string(sec.Data["password"]),
-			Database: string(sec.Data["database"]),
-			Host:     fmt.Sprintf("%s.%s.svc.cluster.local", ps.Name, ps.Namespace),
-			Port:     defaultPostgresPort,
-		},
+		DeploymentDetails: deploymentDetails,
+		Cost:              usage.cost(),
+		Artifacts:         artifacts,
 	}, "creation successful", nil
 }
 
 func (p *PostgresProvider) DeletePostgres(ctx context.Context, ps *v1alpha1.Postgres) (croType.StatusMessage, error) {
+	ns := deletedWorkloadNamespace(p.Logger, ps, &ps.Status, func() (*WorkloadIsolation, error) {
+		postgresCfg, _, err := p.getPostgresConfig(ctx, ps)
+		if err != nil {
+			return nil, err
+		}
+		return postgresCfg.Isolation, nil
+	})
+
+	// delete the pooler, whether or not it's still configured
+	p.Logger.Info("deleting postgres pooler")
+	if err := p.deletePostgresPooler(ctx, ps.Name, ns); err != nil {
+		errMsg := "failed to delete postgres pooler"
+		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	metrics.DeletePoolerStats(types.NamespacedName{Namespace: ps.Namespace, Name: ps.Name})
+
+	// delete the external access service, whether or not external access is still allowed
+	if _, err := reconcileExternalAccessService(ctx, p.Client, p.Logger, ps.Name, ns, defaultPostgresPort, nil, nil); err != nil {
+		errMsg := "failed to delete postgres external access service"
+		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+
+	// delete the network policy, whether or not it's still configured
+	if err := reconcileWorkloadNetworkPolicy(ctx, p.Client, p.Logger, ps, ps.Name, ns, nil, nil, nil); err != nil {
+		errMsg := "failed to delete postgres network policy"
+		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+
+	// delete the pod disruption budget, whether or not it's still configured
+	if err := reconcileWorkloadDisruptionBudget(ctx, p.Client, p.Logger, ps.Name, ns, false, nil); err != nil {
+		errMsg := "failed to delete postgres pod disruption budget"
+		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+
 	// delete service
 	p.Logger.Info("deleting postgres service")
 	svc := &v1.Service{
 		ObjectMeta: controllerruntime.ObjectMeta{
 			Name:      ps.Name,
-			Namespace: ps.Namespace,
+			Namespace: ns,
 		},
 	}
-	err := p.Client.Delete(ctx, svc)
+	err := deleteObject(ctx, p.Client, svc)
 	if err != nil && !k8serr.IsNotFound(err) {
 		errMsg := "failed to delete postgres service"
 		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 	}
 
+	// delete service ca configmap
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      postgresServiceCAName(ps.Name),
+			Namespace: ns,
+		},
+	}
+	err = deleteObject(ctx, p.Client, cm)
+	if err != nil && !k8serr.IsNotFound(err) {
+		errMsg := "failed to delete postgres service ca configmap"
+		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+
 	// delete pvc
 	p.Logger.Info("deleting postgres persistent volume claim")
 	pvc := &v1.PersistentVolumeClaim{
 		ObjectMeta: controllerruntime.ObjectMeta{
 			Name:      ps.Name,
-			Namespace: ps.Namespace,
+			Namespace: ns,
 		},
 	}
-	err = p.Client.Delete(ctx, pvc)
+	err = deleteObject(ctx, p.Client, pvc)
 	if err != nil && !k8serr.IsNotFound(err) {
 		errMsg := "failed to delete postgres persistent volume claim"
 		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
@@ -211,11 +487,11 @@ func (p *PostgresProvider) DeletePostgres(ctx context.Context, ps *v1alpha1.Post
 	p.Logger.Info("Deleting postgres secret")
 	sec := &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      defaultCredentialsSec,
-			Namespace: ps.Namespace,
+			Name:      postgresCredentialsSecretName(ps.Name),
+			Namespace: ns,
 		},
 	}
-	err = p.Client.Delete(ctx, sec)
+	err = deleteObject(ctx, p.Client, sec)
 	if err != nil && !k8serr.IsNotFound(err) {
 		errMsg := "failed to deleted postgres secrets"
 		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
@@ -226,15 +502,21 @@ func (p *PostgresProvider) DeletePostgres(ctx context.Context, ps *v1alpha1.Post
 	dpl := &appsv1.Deployment{
 		ObjectMeta: controllerruntime.ObjectMeta{
 			Name:      ps.Name,
-			Namespace: ps.Namespace,
+			Namespace: ns,
 		},
 	}
-	err = p.Client.Delete(ctx, dpl)
+	err = deleteObject(ctx, p.Client, dpl)
 	if err != nil && !k8serr.IsNotFound(err) {
 		errMsg := "failed to delete postgres deployment"
 		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 	}
 
+	// delete the dedicated workload namespace
+	if err := deleteWorkloadNamespace(ctx, p.Client, ps, ns); err != nil {
+		errMsg := "failed to delete postgres workload namespace"
+		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+
 	// remove the finalizer added by the provider
 	p.Logger.Info("Removing postgres finalizer")
 	resources.RemoveFinalizer(&ps.ObjectMeta, DefaultFinalizer)
@@ -249,10 +531,20 @@ func (p *PostgresProvider) DeletePostgres(ctx context.Context, ps *v1alpha1.Post
 
 // getPostgresConfig retrieves the postgres config from the cloud-resources-openshift-strategies configmap
 func (p *PostgresProvider) getPostgresConfig(ctx context.Context, ps *v1alpha1.Postgres) (*PostgresStrat, *StrategyConfig, error) {
-	stratCfg, err := p.ConfigManager.ReadStorageStrategy(ctx, providers.PostgresResourceType, ps.Spec.Tier)
+	return readPostgresConfig(ctx, p.ConfigManager, ps)
+}
+
+// readPostgresConfig reads the postgres config of the tier of the postgres from the strategy config
+func readPostgresConfig(ctx context.Context, cm ConfigManager, ps *v1alpha1.Postgres) (*PostgresStrat, *StrategyConfig, error) {
+	stratCfg, err := cm.ReadStorageStrategy(ctx, providers.PostgresResourceType, ps.Spec.Tier)
 	if err != nil {
 		return nil, nil, errorUtil.Wrap(err, "failed to read openshift strategy config")
 	}
+	// values of the tier changed by the postgres itself
+	stratCfg.RawStrategy, err = providers.MergeProviderConfig(stratCfg.RawStrategy, ps.Spec.ProviderConfig)
+	if err != nil {
+		return nil, nil, errorUtil.Wrap(err, "failed to merge provider config of postgres")
+	}
 	// unmarshal the postgres config
 	postgresCfg := &PostgresStrat{}
 	if err := json.Unmarshal(stratCfg.RawStrategy, postgresCfg); err != nil {
@@ -263,16 +555,9 @@ func (p *PostgresProvider) getPostgresConfig(ctx context.Context, ps *v1alpha1.P
 }
 
 func (p *PostgresProvider) CreateDeployment(ctx context.Context, d *appsv1.Deployment, postgresCfg *PostgresStrat) error {
-	or, err := immutableCreateOrUpdate(ctx, p.Client, d, func(existing runtime.Object) error {
-		e := existing.(*appsv1.Deployment)
-
-		if postgresCfg.PostgresDeploymentSpec == nil {
-			e.Spec = d.Spec
-			return nil
-		}
-
-		e.Spec = *postgresCfg.PostgresDeploymentSpec
-		return nil
+	desired := desiredPostgresDeployment(d, postgresCfg)
+	or, err := immutableCreateOrUpdate(ctx, p.Client, p.Logger, desired, func(existing runtime.Object) error {
+		return threeWayMerge(existing, desired)
 	})
 	if err != nil {
 		return errorUtil.Wrapf(err, "failed to create or update deployment %s, action was %s", d.Name, or)
@@ -281,18 +566,9 @@ func (p *PostgresProvider) CreateDeployment(ctx context.Context, d *appsv1.Deplo
 }
 
 func (p *PostgresProvider) CreateService(ctx context.Context, s *v1.Service, postgresCfg *PostgresStrat) error {
-	or, err := immutableCreateOrUpdate(ctx, p.Client, s, func(existing runtime.Object) error {
-		e := existing.(*v1.Service)
-
-		if postgresCfg.PostgresServiceSpec == nil {
-			clusterIP := e.Spec.ClusterIP
-			e.Spec = s.Spec
-			e.Spec.ClusterIP = clusterIP
-			return nil
-		}
-
-		e.Spec = *postgresCfg.PostgresServiceSpec
-		return nil
+	desired := desiredPostgresService(s, postgresCfg)
+	or, err := immutableCreateOrUpdate(ctx, p.Client, p.Logger, desired, func(existing runtime.Object) error {
+		return threeWayMerge(existing, desired)
 	})
 	if err != nil {
 		return errorUtil.Wrapf(err, "failed to create or update service %s, action was %s", s.Name, or)
@@ -300,44 +576,45 @@ func (p *PostgresProvider) CreateService(ctx context.Context, s *v1.Service, pos
 	return nil
 }
 
-func (p *PostgresProvider) CreateSecret(ctx context.Context, s *v1.Secret, postgresCfg *PostgresStrat) error {
-	or, err := immutableCreateOrUpdate(ctx, p.Client, s, func(existing runtime.Object) error {
-		e := existing.(*v1.Secret)
-
-		if postgresCfg.PostgresSecretData == nil {
-			// only update password if it isn't already set, to avoid constant password churn
-			if string(e.Data["password"]) == "" {
-				e.Data["password"] = s.Data["password"]
-			}
-			if string(e.Data["user"]) == "" {
-				e.Data["user"] = s.Data["user"]
-			}
-			e.Data["database"] = s.Data["database"]
+// CreateSecret creates the credentials secret of the instance, generating the keys missing from it. Credentials already
+// in the secret are never replaced, unless credentials are set in the strategy
+func (p *PostgresProvider) CreateSecret(ctx context.Context, s *v1.Secret, generators map[string]resources.SecretValueGenerator, postgresCfg *PostgresStrat) error {
+	if postgresCfg.PostgresSecretData != nil {
+		or, err := immutableCreateOrUpdate(ctx, p.Client, p.Logger, s, func(existing runtime.Object) error {
+			e := existing.(*v1.Secret)
+			e.StringData = postgresCfg.PostgresSecretData
 			return nil
+		})
+		if err != nil {
+			return errorUtil.Wrapf(err, "failed to create or update secret %s, action was %s", s.Name, or)
 		}
-
-		e.StringData = postgresCfg.PostgresSecretData
+		resources.RegisterSecretValue(postgresCfg.PostgresSecretData[defaultPostgresPasswordKey])
 		return nil
-	})
-	if err != nil {
-		return errorUtil.Wrapf(err, "failed to create or update secret %s, action was %s", s.Name, or)
+	}
+
+	// generated objects are left alone by the gitops tools syncing the custom resources
+	desired := s.DeepCopy()
+	resources.AddGitOpsAnnotations(desired)
+	if _, err := resources.EnsureCredentialSecret(ctx, p.Client, desired, generators); err != nil {
+		return errorUtil.Wrapf(err, "failed to create or update secret %s", s.Name)
 	}
 	return nil
 }
 
 func (p *PostgresProvider) CreatePVC(ctx context.Context, pvc *v1.PersistentVolumeClaim, postgresCfg *PostgresStrat) error {
-	or, err := immutableCreateOrUpdate(ctx, p.Client, pvc, func(existing runtime.Object) error {
+	desired := desiredPostgresPVC(pvc, postgresCfg)
+	or, err := immutableCreateOrUpdate(ctx, p.Client, p.Logger, desired, func(existing runtime.Object) error {
 		e := existing.(*v1.PersistentVolumeClaim)
+		applyLabelOverrides(e, postgresCfg.Overrides)
 
 		if strings.ToLower(string(e.Status.Phase)) != "bound" {
 			return nil
 		}
-		if postgresCfg.PostgresPVCSpec == nil {
-			e.Spec.Resources.Requests = pvc.Spec.Resources.Requests
-			return nil
+		expandable, err := storageClassAllowsExpansion(ctx, p.Client, e)
+		if err != nil {
+			return err
 		}
-
-		e.Spec.Resources.Requests = postgresCfg.PostgresPVCSpec.Resources.Requests
+		e.Spec.Resources.Requests = expandStorageRequests(e.Spec.Resources.Requests, desiredPostgresStorageRequests(pvc, postgresCfg), expandable)
 		return nil
 	})
 	if err != nil {
@@ -401,7 +678,7 @@ func buildDefaultPostgresDeployment(ps *v1alpha1.Postgres) *appsv1.Deployment {
 			Strategy: appsv1.DeploymentStrategy{
 				Type: appsv1.RecreateDeploymentStrategyType,
 			},
-			Replicas: int32Ptr(1),
+			Replicas: workloadReplicas(ps.Spec, int32Ptr(1)),
 			Selector: &metav1.LabelSelector{
 				MatchLabels: map[string]string{
 					"deployment": ps.Name,
@@ -441,12 +718,12 @@ func buildDefaultPostgresDeployment(ps *v1alpha1.Postgres) *appsv1.Deployment {
 }
 
 func buildDefaultPostgresPodContainers(ps *v1alpha1.Postgres) []v1.Container {
-	credentialsSec := fmt.Sprintf("%s-%s", ps.Name, defaultCredentialsSec)
+	credentialsSec := postgresCredentialsSecretName(ps.Name)
 
 	return []v1.Container{
 		{
 			Name:  ps.Name,
-			Image: "registry.redhat.io/rhscl/postgresql-10-rhel7",
+			Image: defaultPostgresImage,
 			Ports: []v1.ContainerPort{
 				{
 					ContainerPort: int32(defaultPostgresPort),
@@ -505,24 +782,39 @@ func buildDefaultPostgresPodContainers(ps *v1alpha1.Postgres) []v1.Container {
 	}
 }
 
-func buildDefaultPostgresSecret(ps *v1alpha1.Postgres, password string) *v1.Secret {
-	credentialsSec := fmt.Sprintf("%s-%s", ps.Name, defaultCredentialsSec)
+// buildDefaultPostgresSecret returns the credentials secret of the instance, the user and password are generated per
+// instance and only stored in this secret. An empty user or password is left out, to be generated when the secret is
+// created
+func buildDefaultPostgresSecret(ps *v1alpha1.Postgres, user, password string) *v1.Secret {
+	credentialsSec := postgresCredentialsSecretName(ps.Name)
 
-	if password == "" {
-		password = defaultPostgresPassword
-	}
-	return &v1.Secret{
+	sec := &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      credentialsSec,
 			Namespace: ps.Namespace,
 		},
 		Data: map[string][]byte{
-			"user":     []byte(defaultPostgresUser),
-			"password": []byte(password),
-			"database": []byte(ps.Name),
+			defaultPostgresDatabaseKey: []byte(ps.Name),
 		},
 		Type: v1.SecretTypeOpaque,
 	}
+	if user != "" {
+		sec.Data[defaultPostgresUserKey] = []byte(user)
+	}
+	if password != "" {
+		sec.Data[defaultPostgresPasswordKey] = []byte(password)
+	}
+	return sec
+}
+
+// deploymentAvailable returns true if the deployment has the available condition
+func deploymentAvailable(dpl *appsv1.Deployment) bool {
+	for _, s := range dpl.Status.Conditions {
+		if s.Type == appsv1.DeploymentAvailable && s.Status == "True" {
+			return true
+		}
+	}
+	return false
 }
 
 // create an environment variable referencing a secret
@@ -17,8 +17,10 @@ import (
 	"github.com/sirupsen/logrus"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -101,6 +103,12 @@ func buildTestPostgresDeploymentReady() *appsv1.Deployment {
 	}
 }
 
+func buildTestPostgresPoolerDeploymentReady() *appsv1.Deployment {
+	dpl := buildTestPostgresDeploymentReady()
+	dpl.Name = postgresPoolerName(testPostgresName)
+	return dpl
+}
+
 func buildTestCredsSecret() *v1.Secret {
 	return &v1.Secret{
 		TypeMeta: metav1.TypeMeta{
@@ -108,7 +116,7 @@ func buildTestCredsSecret() *v1.Secret {
 			APIVersion: "v1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("%s-%s", testPostgresName, defaultCredentialsSec),
+			Name:      postgresCredentialsSecretName(testPostgresName),
 			Namespace: testPostgresNamespace,
 		},
 		Data: map[string][]byte{
@@ -131,6 +139,19 @@ func buildTestPostgresInstance() *providers.PostgresInstance {
 	}
 }
 
+func buildTestPostgresServiceCA() *v1.ConfigMap {
+	return &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        postgresServiceCAName(testPostgresName),
+			Namespace:   testPostgresNamespace,
+			Annotations: map[string]string{injectCABundleAnnotation: "true"},
+		},
+		Data: map[string]string{
+			serviceCABundleKey: "test-ca",
+		},
+	}
+}
+
 func buildTestConfigManager(strategy string) *ConfigManagerMock {
 	return &ConfigManagerMock{
 		ReadStorageStrategyFunc: func(ctx context.Context, rt providers.ResourceType, tier string) (config *StrategyConfig, e error) {
@@ -166,11 +187,12 @@ func TestOpenShiftPostgresProvider_CreatePostgres(t *testing.T) {
 		postgres *v1alpha1.Postgres
 	}
 	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		want    *providers.PostgresInstance
-		wantErr bool
+		name      string
+		fields    fields
+		args      args
+		want      *providers.PostgresInstance
+		wantImage string
+		wantErr   bool
 	}{
 		{
 			name: "test successful creation",
@@ -202,6 +224,162 @@ func TestOpenShiftPostgresProvider_CreatePostgres(t *testing.T) {
 			want:    buildTestPostgresInstance(),
 			wantErr: false,
 		},
+		{
+			name: "test creation with tls waits for the service ca bundle",
+			fields: fields{
+				Client:        fake.NewFakeClientWithScheme(scheme, buildTestPostgresDeploymentReady(), buildTestPostgresCR(), buildTestCredsSecret()),
+				Logger:        testLogger,
+				ConfigManager: buildTestConfigManager(`{"tls": true}`),
+				PodCommander:  buildTestPodCommander(),
+			},
+			args: args{
+				ctx:      context.TODO(),
+				postgres: buildTestPostgresCR(),
+			},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name: "test creation with tls enabled on the cr returns the ssl mode and ca",
+			fields: fields{
+				Client:        fake.NewFakeClientWithScheme(scheme, buildTestPostgresDeploymentReady(), buildTestPostgresCR(), buildTestCredsSecret(), buildTestPostgresServiceCA()),
+				Logger:        testLogger,
+				ConfigManager: buildDefaultConfigManager(),
+				PodCommander:  buildTestPodCommander(),
+			},
+			args: args{
+				ctx: context.TODO(),
+				postgres: func() *v1alpha1.Postgres {
+					ps := buildTestPostgresCR()
+					ps.Spec.TLS = true
+					return ps
+				}(),
+			},
+			want: func() *providers.PostgresInstance {
+				ps := buildTestPostgresInstance()
+				details := ps.DeploymentDetails.(*providers.PostgresDeploymentDetails)
+				details.SSLMode = postgresTLSSSLMode
+				details.CACert = "test-ca"
+				return ps
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "test creation with a pooler waits for the pooler deployment",
+			fields: fields{
+				Client:        fake.NewFakeClientWithScheme(scheme, buildTestPostgresDeploymentReady(), buildTestPostgresCR(), buildTestCredsSecret()),
+				Logger:        testLogger,
+				ConfigManager: buildTestConfigManager(`{"pooler": {}}`),
+				PodCommander:  buildTestPodCommander(),
+			},
+			args: args{
+				ctx:      context.TODO(),
+				postgres: buildTestPostgresCR(),
+			},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name: "test creation with a ready pooler returns the pooler host",
+			fields: fields{
+				Client:        fake.NewFakeClientWithScheme(scheme, buildTestPostgresDeploymentReady(), buildTestPostgresPoolerDeploymentReady(), buildTestPostgresCR(), buildTestCredsSecret()),
+				Logger:        testLogger,
+				ConfigManager: buildTestConfigManager(`{"pooler": {}}`),
+				PodCommander:  buildTestPodCommander(),
+			},
+			args: args{
+				ctx:      context.TODO(),
+				postgres: buildTestPostgresCR(),
+			},
+			want: func() *providers.PostgresInstance {
+				ps := buildTestPostgresInstance()
+				details := ps.DeploymentDetails.(*providers.PostgresDeploymentDetails)
+				details.Host = fmt.Sprintf("%s.%s.svc.cluster.local", postgresPoolerName(testPostgresName), testPostgresNamespace)
+				return ps
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "test error with a pooler and tls",
+			fields: fields{
+				Client:        fake.NewFakeClientWithScheme(scheme, buildTestPostgresDeploymentReady(), buildTestPostgresCR(), buildTestCredsSecret()),
+				Logger:        testLogger,
+				ConfigManager: buildTestConfigManager(`{"tls": true, "pooler": {}}`),
+				PodCommander:  buildTestPodCommander(),
+			},
+			args: args{
+				ctx:      context.TODO(),
+				postgres: buildTestPostgresCR(),
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "test creation with a version uses the image of the version",
+			fields: fields{
+				Client:        fake.NewFakeClientWithScheme(scheme, buildTestPostgresCR()),
+				Logger:        testLogger,
+				ConfigManager: buildDefaultConfigManager(),
+				PodCommander:  buildTestPodCommander(),
+			},
+			args: args{
+				ctx: context.TODO(),
+				postgres: func() *v1alpha1.Postgres {
+					ps := buildTestPostgresCR()
+					ps.Spec.Version = "13"
+					return ps
+				}(),
+			},
+			want:      nil,
+			wantImage: "registry.redhat.io/rhscl/postgresql-13-rhel7",
+			wantErr:   false,
+		},
+		{
+			name: "test creation with a version uses the image of the strategy",
+			fields: fields{
+				Client: fake.NewFakeClientWithScheme(scheme, buildTestPostgresCR()),
+				Logger: testLogger,
+				ConfigManager: &ConfigManagerMock{
+					ReadStorageStrategyFunc: func(ctx context.Context, rt providers.ResourceType, tier string) (*StrategyConfig, error) {
+						return &StrategyConfig{
+							RawStrategy:       []byte("{}"),
+							SupportedVersions: providers.SupportedVersions{"15": "quay.io/test/postgresql-15"},
+						}, nil
+					},
+				},
+				PodCommander: buildTestPodCommander(),
+			},
+			args: args{
+				ctx: context.TODO(),
+				postgres: func() *v1alpha1.Postgres {
+					ps := buildTestPostgresCR()
+					ps.Spec.Version = "15"
+					return ps
+				}(),
+			},
+			want:      nil,
+			wantImage: "quay.io/test/postgresql-15",
+			wantErr:   false,
+		},
+		{
+			name: "test error with an unsupported version",
+			fields: fields{
+				Client:        fake.NewFakeClientWithScheme(scheme, buildTestPostgresCR()),
+				Logger:        testLogger,
+				ConfigManager: buildDefaultConfigManager(),
+				PodCommander:  buildTestPodCommander(),
+			},
+			args: args{
+				ctx: context.TODO(),
+				postgres: func() *v1alpha1.Postgres {
+					ps := buildTestPostgresCR()
+					ps.Spec.Version = "9"
+					return ps
+				}(),
+			},
+			want:    nil,
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -216,9 +394,21 @@ func TestOpenShiftPostgresProvider_CreatePostgres(t *testing.T) {
 				t.Errorf("ReconcilePostgres() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if !reflect.DeepEqual(got, tt.want) {
+			if (got == nil) != (tt.want == nil) || got != nil && !reflect.DeepEqual(got.DeploymentDetails, tt.want.DeploymentDetails) {
 				t.Errorf("ReconcilePostgres() got = %+v, want %+v", got.DeploymentDetails, tt.want.DeploymentDetails)
 			}
+			if got != nil && got.Cost == nil {
+				t.Errorf("ReconcilePostgres() expected a cost estimate")
+			}
+			if tt.wantImage != "" {
+				dpl := &appsv1.Deployment{}
+				if err := tt.fields.Client.Get(tt.args.ctx, types.NamespacedName{Name: tt.args.postgres.Name, Namespace: tt.args.postgres.Namespace}, dpl); err != nil {
+					t.Fatalf("failed to get postgres deployment: %v", err)
+				}
+				if image := dpl.Spec.Template.Spec.Containers[0].Image; image != tt.wantImage {
+					t.Errorf("ReconcilePostgres() got image = %s, want %s", image, tt.wantImage)
+				}
+			}
 		})
 	}
 }
@@ -249,7 +439,7 @@ func TestOpenShiftPostgresProvider_DeletePostgres(t *testing.T) {
 			fields: fields{
 				Client:        fake.NewFakeClientWithScheme(scheme, buildTestPostgresDeploymentReady(), buildTestPostgresCR()),
 				Logger:        testLogger,
-				ConfigManager: nil,
+				ConfigManager: buildDefaultConfigManager(),
 			},
 			args: args{
 				ctx:      context.TODO(),
@@ -261,7 +451,23 @@ func TestOpenShiftPostgresProvider_DeletePostgres(t *testing.T) {
 			fields: fields{
 				Client:        fake.NewFakeClientWithScheme(scheme, buildTestPostgresDeployment(), buildTestPostgresCR()),
 				Logger:        testLogger,
-				ConfigManager: nil,
+				ConfigManager: buildDefaultConfigManager(),
+			},
+			args: args{
+				ctx:      context.TODO(),
+				postgres: buildTestPostgresCR(),
+			},
+		},
+		{
+			name: "test delete when the strategy of the tier can't be read",
+			fields: fields{
+				Client: fake.NewFakeClientWithScheme(scheme, buildTestPostgresDeploymentReady(), buildTestPostgresCR()),
+				Logger: testLogger,
+				ConfigManager: &ConfigManagerMock{
+					ReadStorageStrategyFunc: func(ctx context.Context, rt providers.ResourceType, tier string) (*StrategyConfig, error) {
+						return nil, fmt.Errorf("tier %s not found", tier)
+					},
+				},
 			},
 			args: args{
 				ctx:      context.TODO(),
@@ -283,6 +489,87 @@ func TestOpenShiftPostgresProvider_DeletePostgres(t *testing.T) {
 	}
 }
 
+func TestOpenShiftPostgresProvider_DeletePostgresCredentials(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+
+	// another postgres in the namespace keeps its credentials
+	other := buildTestCredsSecret()
+	other.Name = postgresCredentialsSecretName("other-postgres")
+	c := fake.NewFakeClientWithScheme(scheme, buildTestPostgresDeploymentReady(), buildTestPostgresCR(), buildTestCredsSecret(), other)
+	p := &PostgresProvider{
+		Client:        c,
+		Logger:        testLogger,
+		ConfigManager: buildDefaultConfigManager(),
+	}
+	if _, err := p.DeletePostgres(context.TODO(), buildTestPostgresCR()); err != nil {
+		t.Fatalf("DeletePostgres() unexpected error = %v", err)
+	}
+	secret := &v1.Secret{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: postgresCredentialsSecretName(testPostgresName), Namespace: testPostgresNamespace}, secret); !k8serr.IsNotFound(err) {
+		t.Errorf("credentials secret error = %v, want the credentials of the deleted postgres deleted", err)
+	}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: other.Name, Namespace: other.Namespace}, secret); err != nil {
+		t.Errorf("failed to get the credentials of the other postgres: %v", err)
+	}
+}
+
+func TestOpenShiftPostgresProvider_CreateSecret(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	// an existing secret of an older operator is missing the database
+	legacySec := buildTestCredsSecret()
+	delete(legacySec.Data, defaultPostgresDatabaseKey)
+
+	tests := []struct {
+		name         string
+		existing     []runtime.Object
+		wantUser     string
+		wantPassword string
+	}{
+		{
+			name: "test credentials are generated when the secret is created",
+		},
+		{
+			name:         "test credentials of the existing secret are kept",
+			existing:     []runtime.Object{legacySec},
+			wantUser:     testPostgresUser,
+			wantPassword: testPostgresPassword,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			c := fake.NewFakeClientWithScheme(scheme, tt.existing...)
+			p := &PostgresProvider{Client: c, Logger: testLogger}
+			generators := map[string]resources.SecretValueGenerator{
+				defaultPostgresUserKey:     resources.StaticSecretValue("generated-user"),
+				defaultPostgresPasswordKey: resources.StaticSecretValue("generated-password"),
+				defaultPostgresDatabaseKey: resources.StaticSecretValue(testPostgresDatabase),
+			}
+			if err := p.CreateSecret(ctx, buildDefaultPostgresSecret(buildTestPostgresCR(), "", ""), generators, &PostgresStrat{}); err != nil {
+				t.Fatalf("CreateSecret() unexpected error = %v", err)
+			}
+			sec := &v1.Secret{}
+			if err := c.Get(ctx, types.NamespacedName{Name: postgresCredentialsSecretName(testPostgresName), Namespace: testPostgresNamespace}, sec); err != nil {
+				t.Fatal("failed to get secret", err)
+			}
+			want := map[string][]byte{
+				defaultPostgresUserKey:     []byte(resources.StringOrDefault(tt.wantUser, "generated-user")),
+				defaultPostgresPasswordKey: []byte(resources.StringOrDefault(tt.wantPassword, "generated-password")),
+				defaultPostgresDatabaseKey: []byte(testPostgresDatabase),
+			}
+			if !reflect.DeepEqual(sec.Data, want) {
+				t.Errorf("CreateSecret() data = %s, want %s", sec.Data, want)
+			}
+		})
+	}
+}
+
 func TestOpenShiftPostgresProvider_overrideDefaults(t *testing.T) {
 	scheme, err := buildTestScheme()
 	if err != nil {
@@ -357,7 +644,7 @@ func TestOpenShiftPostgresProvider_overrideDefaults(t *testing.T) {
 			},
 			getTestableSpec: func(ctx context.Context, c client.Client) (interface{}, error) {
 				sec := &v1.Secret{}
-				err := c.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-%s", testPostgresName, defaultCredentialsSec), Namespace: testPostgresNamespace}, sec)
+				err := c.Get(ctx, types.NamespacedName{Name: postgresCredentialsSecretName(testPostgresName), Namespace: testPostgresNamespace}, sec)
 				return sec.StringData, err
 			},
 			wantErr: false,
@@ -474,3 +761,24 @@ func TestOpenShiftPostgresProvider_GetReconcileTime(t *testing.T) {
 		})
 	}
 }
+
+func TestReadPostgresConfig_ProviderConfig(t *testing.T) {
+	cm := &ConfigManagerMock{
+		ReadStorageStrategyFunc: func(ctx context.Context, rt providers.ResourceType, tier string) (*StrategyConfig, error) {
+			return &StrategyConfig{RawStrategy: []byte(`{"overrides":{"image":"tier-image"},"isolation":{"mode":"shared"}}`)}, nil
+		},
+	}
+	ps := buildTestPostgresCR()
+	ps.Spec.ProviderConfig = &runtime.RawExtension{Raw: []byte(`{"overrides":{"storageSize":"20Gi"}}`)}
+
+	postgresCfg, _, err := readPostgresConfig(context.TODO(), cm, ps)
+	if err != nil {
+		t.Fatalf("readPostgresConfig() unexpected error = %v", err)
+	}
+	if postgresCfg.Overrides == nil || postgresCfg.Overrides.Image != "tier-image" || postgresCfg.Overrides.StorageSize == nil || postgresCfg.Overrides.StorageSize.String() != "20Gi" {
+		t.Errorf("readPostgresConfig() overrides = %+v, want the tier image and the storage size of the postgres", postgresCfg.Overrides)
+	}
+	if postgresCfg.Isolation == nil || postgresCfg.Isolation.Mode != "shared" {
+		t.Errorf("readPostgresConfig() isolation = %+v, want the isolation of the tier", postgresCfg.Isolation)
+	}
+}
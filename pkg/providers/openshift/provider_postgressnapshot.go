@@ -0,0 +1,282 @@
+// utility to manage the creation and deletion of snapshots of in-cluster Postgres instances as csi volume snapshots of
+// their persistent volume claim.
+//
+// used by the postgres snapshot controller to reconcile PostgresSnapshot custom resources
+// A snapshot CR must reference an existing Postgres CR
+
+package openshift
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	postgresSnapshotProviderName = "openshift-postgres-volume-snapshots"
+
+	// postgresUserAnnotation and postgresDatabaseAnnotation record the user and database of the data in a volume snapshot
+	// and in the pvc restored from it, the restored postgres keeps them as the data already has them
+	postgresUserAnnotation     = "integreatly.org/postgres-user"
+	postgresDatabaseAnnotation = "integreatly.org/postgres-database"
+	// postgresBackupModeAnnotation is set on a volume snapshot while postgres is in backup mode for it
+	postgresBackupModeAnnotation = "integreatly.org/postgres-backup-mode"
+)
+
+// PostgresVolumeSnapshot configures the csi volume snapshots of the postgres pvc
+type PostgresVolumeSnapshot struct {
+	// VolumeSnapshotClassName is the class of the volume snapshots, defaults to the default class of the csi driver
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+	// BackupMode puts postgres in backup mode with pg_start_backup until the snapshot is taken, otherwise the snapshot
+	// is crash consistent and postgres recovers from its write ahead log when restored
+	BackupMode bool `json:"backupMode,omitempty"`
+}
+
+var _ providers.PostgresSnapshotProvider = (*PostgresSnapshotProvider)(nil)
+
+type PostgresSnapshotProvider struct {
+	Client        client.Client
+	Logger        *logrus.Entry
+	ConfigManager ConfigManager
+	PodCommander  resources.PodCommander
+}
+
+func NewOpenShiftPostgresSnapshotProvider(client client.Client, cs *kubernetes.Clientset, logger *logrus.Entry) *PostgresSnapshotProvider {
+	return &PostgresSnapshotProvider{
+		Client:        client,
+		Logger:        logger.WithFields(logrus.Fields{"provider": postgresSnapshotProviderName}),
+		ConfigManager: NewDefaultConfigManager(client),
+		PodCommander:  &resources.OpenShiftPodCommander{ClientSet: cs},
+	}
+}
+
+func (p *PostgresSnapshotProvider) GetName() string {
+	return postgresSnapshotProviderName
+}
+
+func (p *PostgresSnapshotProvider) SupportsStrategy(s string) bool {
+	return s == providers.OpenShiftDeploymentStrategy
+}
+
+func (p *PostgresSnapshotProvider) GetReconcileTime(snapshot *v1alpha1.PostgresSnapshot) time.Duration {
+	if snapshot.Status.Phase != croType.PhaseComplete {
+		return time.Second * 10
+	}
+	return resources.GetForcedReconcileTimeOrDefault(defaultReconcileTime)
+}
+
+func (p *PostgresSnapshotProvider) CreatePostgresSnapshot(ctx context.Context, snapshot *v1alpha1.PostgresSnapshot, postgres *v1alpha1.Postgres) (*providers.PostgresSnapshotInstance, croType.StatusMessage, error) {
+	logger := resources.NewActionLogger(p.Logger, "createPostgresSnapshot")
+
+	// add finalizer to the snapshot cr
+	if err := resources.CreateFinalizer(ctx, p.Client, snapshot, DefaultFinalizer); err != nil {
+		errMsg := fmt.Sprintf("failed to set finalizer for snapshot %s", snapshot.Name)
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	postgresCfg, _, err := readPostgresConfig(ctx, p.ConfigManager, postgres)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to retrieve openshift postgres config for instance %s", postgres.Name)
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	snapshotCfg := postgresCfg.VolumeSnapshot
+	if snapshotCfg == nil {
+		snapshotCfg = &PostgresVolumeSnapshot{}
+	}
+	ns := workloadNamespace(postgres, &postgres.Status, postgresCfg.Isolation)
+
+	// update cr with snapshot name
+	snapshotName := postgresVolumeSnapshotName(postgres, snapshot)
+	if snapshot.Status.SnapshotID != snapshotName {
+		snapshot.Status.SnapshotID = snapshotName
+		if err := p.Client.Status().Update(ctx, snapshot); err != nil {
+			errMsg := fmt.Sprintf("failed to update instance %s in namespace %s", snapshot.Name, snapshot.Namespace)
+			return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+	}
+
+	vs, err := getVolumeSnapshot(ctx, p.Client, snapshotName, ns)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to get volume snapshot %s", snapshotName)
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+
+	// create a volume snapshot of the postgres pvc
+	if vs == nil {
+		// postgres instance has either just been created
+		// or is already backing up
+		if postgres.Status.Phase == croType.PhaseInProgress {
+			return nil, croType.StatusMessage("waiting for postgres instance to be available"), nil
+		}
+		// postgres instance is being deleted
+		// impossible to create a snapshot
+		if postgres.Status.Phase == croType.PhaseDeleteInProgress {
+			errMsg := "cannot create snapshot when instance deletion is in progress"
+			return nil, croType.StatusMessage(errMsg), errorUtil.New(errMsg)
+		}
+		vs, err = p.buildPostgresVolumeSnapshot(ctx, postgres, snapshotName, ns, snapshotCfg)
+		if err != nil {
+			errMsg := fmt.Sprintf("failed to build volume snapshot %s", snapshotName)
+			return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+		if snapshotCfg.BackupMode {
+			logger.Infof("starting backup mode of postgres %s", postgres.Name)
+			if err := p.execPostgres(ctx, postgres.Name, ns, fmt.Sprintf("psql -c \"SELECT pg_start_backup('%s', true)\"", snapshotName)); err != nil {
+				errMsg := fmt.Sprintf("failed to start backup mode of postgres %s", postgres.Name)
+				return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+			}
+		}
+		logger.Infof("creating volume snapshot %s", snapshotName)
+		if err := resources.ApplyMetadata(ctx, p.Client, vs); err != nil {
+			errMsg := fmt.Sprintf("failed to set metadata of volume snapshot %s", snapshotName)
+			return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+		if err := p.Client.Create(ctx, vs); err != nil {
+			errMsg := fmt.Sprintf("error creating volume snapshot %s", snapshotName)
+			return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+		return nil, "snapshot started", nil
+	}
+
+	// leave backup mode as soon as the point in time of the snapshot is cut, or it failed
+	if vs.GetAnnotations()[postgresBackupModeAnnotation] != "" && (volumeSnapshotTaken(vs) || volumeSnapshotError(vs) != "") {
+		if err := p.stopPostgresBackupMode(ctx, postgres.Name, vs); err != nil {
+			errMsg := fmt.Sprintf("failed to stop backup mode of postgres %s", postgres.Name)
+			return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+	}
+	if msg := volumeSnapshotError(vs); msg != "" {
+		errMsg := fmt.Sprintf("volume snapshot %s failed: %s", snapshotName, msg)
+		return nil, croType.StatusMessage(errMsg), errorUtil.New(errMsg)
+	}
+	if !volumeSnapshotReady(vs) {
+		msg := fmt.Sprintf("waiting for volume snapshot %s to be ready to use", snapshotName)
+		logger.Info(msg)
+		return nil, croType.StatusMessage(msg), nil
+	}
+
+	// record, if the snapshot has a retention period, when it's deleted
+	retainUntil := providers.BuildSnapshotRetainUntil(vs.GetCreationTimestamp().Time, snapshot.Spec.RetentionDays)
+	if snapshot.Status.RetainUntil != retainUntil {
+		snapshot.Status.RetainUntil = retainUntil
+		if err := p.Client.Status().Update(ctx, snapshot); err != nil {
+			errMsg := fmt.Sprintf("failed to update instance %s in namespace %s", snapshot.Name, snapshot.Namespace)
+			return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+	}
+	return &providers.PostgresSnapshotInstance{
+		Name: snapshotName,
+	}, "snapshot created", nil
+}
+
+func (p *PostgresSnapshotProvider) DeletePostgresSnapshot(ctx context.Context, snapshot *v1alpha1.PostgresSnapshot, postgres *v1alpha1.Postgres) (croType.StatusMessage, error) {
+	postgresCfg, _, err := readPostgresConfig(ctx, p.ConfigManager, postgres)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to retrieve openshift postgres config for instance %s", postgres.Name)
+		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	ns := workloadNamespace(postgres, &postgres.Status, postgresCfg.Isolation)
+
+	var vs *unstructured.Unstructured
+	if snapshot.Status.SnapshotID != "" {
+		vs, err = getVolumeSnapshot(ctx, p.Client, snapshot.Status.SnapshotID, ns)
+		if err != nil {
+			errMsg := fmt.Sprintf("failed to get volume snapshot %s", snapshot.Status.SnapshotID)
+			return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+	}
+
+	// snapshot is deleted
+	if vs == nil {
+		resources.RemoveFinalizer(&snapshot.ObjectMeta, DefaultFinalizer)
+		if err := p.Client.Update(ctx, snapshot); err != nil {
+			msg := "failed to update instance as part of finalizer reconcile"
+			return croType.StatusMessage(msg), errorUtil.Wrapf(err, msg)
+		}
+		return "snapshot deleted", nil
+	}
+
+	// don't leave postgres in backup mode for a snapshot which is never taken
+	if vs.GetAnnotations()[postgresBackupModeAnnotation] != "" {
+		if err := p.stopPostgresBackupMode(ctx, postgres.Name, vs); err != nil {
+			errMsg := fmt.Sprintf("failed to stop backup mode of postgres %s", postgres.Name)
+			return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+	}
+	if err := p.Client.Delete(ctx, vs); err != nil && !k8serr.IsNotFound(err) {
+		errMsg := fmt.Sprintf("failed to delete volume snapshot %s", vs.GetName())
+		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	return "snapshot deletion started", nil
+}
+
+// buildPostgresVolumeSnapshot returns the volume snapshot of the postgres pvc, annotated with the user, database and
+// version of the data so a postgres restored from it can use them
+func (p *PostgresSnapshotProvider) buildPostgresVolumeSnapshot(ctx context.Context, postgres *v1alpha1.Postgres, name, ns string, snapshotCfg *PostgresVolumeSnapshot) (*unstructured.Unstructured, error) {
+	sec := &v1.Secret{}
+	if err := p.Client.Get(ctx, client.ObjectKey{Name: postgresCredentialsSecretName(postgres.Name), Namespace: ns}, sec); err != nil {
+		return nil, errorUtil.Wrap(err, "failed to get postgres creds")
+	}
+	pvc := &v1.PersistentVolumeClaim{}
+	if err := p.Client.Get(ctx, client.ObjectKey{Name: postgres.Name, Namespace: ns}, pvc); err != nil {
+		return nil, errorUtil.Wrap(err, "failed to get postgres pvc")
+	}
+
+	vs := buildVolumeSnapshot(name, ns, pvc.Name, snapshotCfg.VolumeSnapshotClassName)
+	annotations := map[string]string{
+		postgresUserAnnotation:     string(sec.Data[defaultPostgresUserKey]),
+		postgresDatabaseAnnotation: string(sec.Data[defaultPostgresDatabaseKey]),
+	}
+	if version := pvc.Annotations[postgresVersionAnnotation]; version != "" {
+		annotations[postgresVersionAnnotation] = version
+	}
+	if snapshotCfg.BackupMode {
+		annotations[postgresBackupModeAnnotation] = "true"
+	}
+	vs.SetAnnotations(annotations)
+	return vs, nil
+}
+
+// stopPostgresBackupMode stops the backup mode started for the volume snapshot and removes its annotation
+func (p *PostgresSnapshotProvider) stopPostgresBackupMode(ctx context.Context, postgresName string, vs *unstructured.Unstructured) error {
+	p.Logger.Infof("stopping backup mode of postgres %s", postgresName)
+	if err := p.execPostgres(ctx, postgresName, vs.GetNamespace(), "psql -c \"SELECT pg_stop_backup()\""); err != nil {
+		return err
+	}
+	annotations := vs.GetAnnotations()
+	delete(annotations, postgresBackupModeAnnotation)
+	vs.SetAnnotations(annotations)
+	if err := p.Client.Update(ctx, vs); err != nil {
+		return errorUtil.Wrapf(err, "failed to remove %s annotation", postgresBackupModeAnnotation)
+	}
+	return nil
+}
+
+// execPostgres runs the command in the postgres pod
+func (p *PostgresSnapshotProvider) execPostgres(ctx context.Context, name, ns, cmd string) error {
+	dpl := &appsv1.Deployment{}
+	if err := p.Client.Get(ctx, client.ObjectKey{Name: name, Namespace: ns}, dpl); err != nil {
+		return errorUtil.Wrap(err, "failed to get postgres deployment")
+	}
+	if err := p.PodCommander.ExecIntoPod(dpl, cmd); err != nil {
+		return errorUtil.Wrap(err, "failed to perform exec on database pod")
+	}
+	return nil
+}
+
+// postgresVolumeSnapshotName returns the name of the volume snapshot of the postgres snapshot
+func postgresVolumeSnapshotName(postgres *v1alpha1.Postgres, snapshot *v1alpha1.PostgresSnapshot) string {
+	return resources.ShortenString(fmt.Sprintf("%s-%s", postgres.Name, snapshot.Name), 253)
+}
@@ -0,0 +1,279 @@
+package openshift
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testPostgresSnapshotName = "test-snapshot"
+
+func buildTestPostgresSnapshotCR() *v1alpha1.PostgresSnapshot {
+	return &v1alpha1.PostgresSnapshot{
+		ObjectMeta: controllerruntime.ObjectMeta{
+			Name:      testPostgresSnapshotName,
+			Namespace: testPostgresNamespace,
+		},
+		Spec: v1alpha1.PostgresSnapshotSpec{
+			ResourceName: testPostgresName,
+		},
+	}
+}
+
+func buildTestVolumeSnapshot(annotations map[string]string, status map[string]interface{}) *unstructured.Unstructured {
+	vs := buildVolumeSnapshot(postgresVolumeSnapshotName(buildTestPostgresCR(), buildTestPostgresSnapshotCR()), testPostgresNamespace, testPostgresName, "")
+	vs.SetAnnotations(annotations)
+	if status != nil {
+		vs.Object["status"] = status
+	}
+	return vs
+}
+
+func TestPostgresSnapshotProvider_CreatePostgresSnapshot(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	snapshotName := postgresVolumeSnapshotName(buildTestPostgresCR(), buildTestPostgresSnapshotCR())
+	tests := []struct {
+		name            string
+		strategy        string
+		existing        []runtime.Object
+		want            bool
+		wantErr         bool
+		wantCommands    []string
+		wantAnnotations map[string]string
+	}{
+		{
+			name:            "test volume snapshot of the postgres pvc is created",
+			strategy:        "{}",
+			wantAnnotations: map[string]string{postgresUserAnnotation: testPostgresUser, postgresDatabaseAnnotation: testPostgresDatabase},
+		},
+		{
+			name:            "test postgres is put in backup mode before the volume snapshot is created",
+			strategy:        `{"volumeSnapshot": {"backupMode": true}}`,
+			wantCommands:    []string{"pg_start_backup"},
+			wantAnnotations: map[string]string{postgresUserAnnotation: testPostgresUser, postgresDatabaseAnnotation: testPostgresDatabase, postgresBackupModeAnnotation: "true"},
+		},
+		{
+			name:            "test backup mode is stopped once the volume snapshot is taken",
+			strategy:        `{"volumeSnapshot": {"backupMode": true}}`,
+			existing:        []runtime.Object{buildTestVolumeSnapshot(map[string]string{postgresBackupModeAnnotation: "true"}, map[string]interface{}{"creationTime": "2022-01-01T00:00:00Z"})},
+			wantCommands:    []string{"pg_stop_backup"},
+			wantAnnotations: map[string]string{},
+		},
+		{
+			name:     "test snapshot is complete once the volume snapshot is ready to use",
+			strategy: "{}",
+			existing: []runtime.Object{buildTestVolumeSnapshot(nil, map[string]interface{}{"readyToUse": true})},
+			want:     true,
+		},
+		{
+			name:     "test error when the volume snapshot failed",
+			strategy: "{}",
+			existing: []runtime.Object{buildTestVolumeSnapshot(nil, map[string]interface{}{"error": map[string]interface{}{"message": "failed"}})},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshot := buildTestPostgresSnapshotCR()
+			existing := append([]runtime.Object{snapshot, buildTestCredsSecret(), buildTestPostgresPVC(), buildTestPostgresDeploymentReady()}, tt.existing...)
+			c := fake.NewFakeClientWithScheme(scheme, existing...)
+			podCommander := &resources.PodCommanderMock{
+				ExecIntoPodFunc: func(dpl *appsv1.Deployment, cmd string) error {
+					return nil
+				},
+			}
+			p := &PostgresSnapshotProvider{
+				Client:        c,
+				Logger:        testLogger,
+				ConfigManager: buildTestConfigManager(tt.strategy),
+				PodCommander:  podCommander,
+			}
+			got, _, err := p.CreatePostgresSnapshot(context.TODO(), snapshot, buildTestPostgresCR())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreatePostgresSnapshot() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (got != nil) != tt.want {
+				t.Errorf("CreatePostgresSnapshot() = %v, want snapshot %v", got, tt.want)
+			}
+			calls := podCommander.ExecIntoPodCalls()
+			if len(calls) != len(tt.wantCommands) {
+				t.Fatalf("CreatePostgresSnapshot() ran %d commands, want %d", len(calls), len(tt.wantCommands))
+			}
+			for i, cmd := range tt.wantCommands {
+				if !strings.Contains(calls[i].Cmd, cmd) {
+					t.Errorf("CreatePostgresSnapshot() command %s, want %s", calls[i].Cmd, cmd)
+				}
+			}
+			if snapshot.Status.SnapshotID != snapshotName {
+				t.Errorf("CreatePostgresSnapshot() snapshot id = %s, want %s", snapshot.Status.SnapshotID, snapshotName)
+			}
+			if tt.wantAnnotations == nil {
+				return
+			}
+			vs, err := getVolumeSnapshot(context.TODO(), c, snapshotName, testPostgresNamespace)
+			if err != nil || vs == nil {
+				t.Fatalf("failed to get volume snapshot %v", err)
+			}
+			if source, _, _ := unstructured.NestedString(vs.Object, "spec", "source", "persistentVolumeClaimName"); source != testPostgresName {
+				t.Errorf("CreatePostgresSnapshot() volume snapshot source = %s, want %s", source, testPostgresName)
+			}
+			if len(vs.GetAnnotations()) != len(tt.wantAnnotations) {
+				t.Errorf("CreatePostgresSnapshot() volume snapshot annotations = %v, want %v", vs.GetAnnotations(), tt.wantAnnotations)
+			}
+			for k, v := range tt.wantAnnotations {
+				if vs.GetAnnotations()[k] != v {
+					t.Errorf("CreatePostgresSnapshot() volume snapshot annotation %s = %s, want %s", k, vs.GetAnnotations()[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestPostgresSnapshotProvider_DeletePostgresSnapshot(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	tests := []struct {
+		name          string
+		existing      []runtime.Object
+		wantFinalizer bool
+	}{
+		{
+			name:          "test volume snapshot is deleted",
+			existing:      []runtime.Object{buildTestVolumeSnapshot(nil, nil)},
+			wantFinalizer: true,
+		},
+		{
+			name:          "test finalizer is removed once the volume snapshot is deleted",
+			wantFinalizer: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshot := buildTestPostgresSnapshotCR()
+			snapshot.Finalizers = []string{DefaultFinalizer}
+			snapshot.Status.SnapshotID = postgresVolumeSnapshotName(buildTestPostgresCR(), snapshot)
+			c := fake.NewFakeClientWithScheme(scheme, append([]runtime.Object{snapshot}, tt.existing...)...)
+			p := &PostgresSnapshotProvider{
+				Client:        c,
+				Logger:        testLogger,
+				ConfigManager: buildDefaultConfigManager(),
+			}
+			if _, err := p.DeletePostgresSnapshot(context.TODO(), snapshot, buildTestPostgresCR()); err != nil {
+				t.Fatalf("DeletePostgresSnapshot() unexpected error = %v", err)
+			}
+			vs, err := getVolumeSnapshot(context.TODO(), c, snapshot.Status.SnapshotID, testPostgresNamespace)
+			if err != nil {
+				t.Fatal("failed to get volume snapshot", err)
+			}
+			if vs != nil {
+				t.Error("DeletePostgresSnapshot() expected the volume snapshot to be deleted")
+			}
+			if hasFinalizer := resources.Contains(snapshot.Finalizers, DefaultFinalizer); hasFinalizer != tt.wantFinalizer {
+				t.Errorf("DeletePostgresSnapshot() finalizer %v, want %v", hasFinalizer, tt.wantFinalizer)
+			}
+		})
+	}
+}
+
+func TestPostgresProvider_reconcilePostgresRestore(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	snapshotName := postgresVolumeSnapshotName(buildTestPostgresCR(), buildTestPostgresSnapshotCR())
+	completeSnapshot := buildTestPostgresSnapshotCR()
+	completeSnapshot.Status = croType.ResourceTypeSnapshotStatus{Phase: croType.PhaseComplete, SnapshotID: snapshotName}
+	restoredPVC := buildDefaultPostgresPVC(buildTestPostgresCR())
+	restoredPVC.Annotations = map[string]string{postgresUserAnnotation: "restored-user", postgresDatabaseAnnotation: "restored-db"}
+	snapshotAnnotations := map[string]string{postgresUserAnnotation: testPostgresUser, postgresDatabaseAnnotation: testPostgresDatabase, postgresVersionAnnotation: "12"}
+	tests := []struct {
+		name           string
+		restoreFrom    *croType.RestoreFrom
+		existing       []runtime.Object
+		wantUser       string
+		wantDatabase   string
+		wantDataSource bool
+		wantSize       string
+		wantErr        bool
+	}{
+		{
+			name:     "test no restore without restore from",
+			wantSize: "1Gi",
+		},
+		{
+			name:           "test pvc is restored from the volume snapshot of a postgres snapshot",
+			restoreFrom:    &croType.RestoreFrom{SnapshotName: testPostgresSnapshotName},
+			existing:       []runtime.Object{completeSnapshot, buildTestVolumeSnapshot(snapshotAnnotations, map[string]interface{}{"readyToUse": true, "restoreSize": "5Gi"})},
+			wantUser:       testPostgresUser,
+			wantDatabase:   testPostgresDatabase,
+			wantDataSource: true,
+			wantSize:       "5Gi",
+		},
+		{
+			name:           "test pvc is restored from a volume snapshot id",
+			restoreFrom:    &croType.RestoreFrom{SnapshotID: snapshotName},
+			existing:       []runtime.Object{buildTestVolumeSnapshot(nil, nil)},
+			wantDataSource: true,
+			wantSize:       "1Gi",
+		},
+		{
+			name:         "test existing pvc keeps the user and database it was restored with",
+			restoreFrom:  &croType.RestoreFrom{SnapshotName: testPostgresSnapshotName},
+			existing:     []runtime.Object{restoredPVC},
+			wantUser:     "restored-user",
+			wantDatabase: "restored-db",
+			wantSize:     "1Gi",
+		},
+		{
+			name:        "test error when the postgres snapshot isn't complete",
+			restoreFrom: &croType.RestoreFrom{SnapshotName: testPostgresSnapshotName},
+			existing:    []runtime.Object{buildTestPostgresSnapshotCR()},
+			wantErr:     true,
+		},
+		{
+			name:        "test error when the volume snapshot doesn't exist",
+			restoreFrom: &croType.RestoreFrom{SnapshotID: snapshotName},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &PostgresProvider{Client: fake.NewFakeClientWithScheme(scheme, tt.existing...), Logger: testLogger}
+			ps := buildTestPostgresCR()
+			ps.Spec.RestoreFrom = tt.restoreFrom
+			pvc := buildDefaultPostgresPVC(ps)
+			user, database, err := p.reconcilePostgresRestore(context.TODO(), ps, pvc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("reconcilePostgresRestore() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if user != tt.wantUser || database != tt.wantDatabase {
+				t.Errorf("reconcilePostgresRestore() = %s, %s, want %s, %s", user, database, tt.wantUser, tt.wantDatabase)
+			}
+			if (pvc.Spec.DataSource != nil) != tt.wantDataSource {
+				t.Errorf("reconcilePostgresRestore() data source = %v, want data source %v", pvc.Spec.DataSource, tt.wantDataSource)
+			}
+			if size := pvc.Spec.Resources.Requests[v1.ResourceStorage]; size.Cmp(resource.MustParse(tt.wantSize)) != 0 {
+				t.Errorf("reconcilePostgresRestore() storage = %s, want %s", size.String(), tt.wantSize)
+			}
+		})
+	}
+}
@@ -27,12 +27,12 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
 	errorUtil "github.com/pkg/errors"
 
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -40,11 +40,21 @@ const (
 	redisProviderName = "openshift-redis-template"
 	// default create options
 	redisConfigVolumeName = "redis-config"
-	redisConfigMapName    = "redis-config"
-	redisConfigMapKey     = "redis.conf"
-	redisContainerName    = "redis"
-	redisPort             = 6379
-	redisContainerCommand = "/opt/rh/rh-redis32/root/usr/bin/redis-server"
+	// legacyRedisConfigMapName is the config map shared by every redis of a namespace before config maps were scoped to
+	// a redis, it's deleted once the last redis of the namespace mounting it is migrated
+	legacyRedisConfigMapName = "redis-config"
+	redisConfigMapKey        = "redis.conf"
+	redisContainerName       = "redis"
+	redisPort                = 6379
+	redisContainerCommand    = "/opt/rh/rh-redis32/root/usr/bin/redis-server"
+)
+
+const (
+	// ConfigMigratedCondition is the condition set once a redis no longer uses the config map shared by the redis of its
+	// namespace before config maps were scoped to a redis
+	ConfigMigratedCondition = "ConfigMigrated"
+	// ConfigMigratedReason is the reason of a true config migrated condition
+	ConfigMigratedReason = "Migrated"
 )
 
 var _ providers.RedisProvider = (*RedisProvider)(nil)
@@ -90,43 +100,149 @@ func (p *RedisProvider) CreateRedis(ctx context.Context, r *v1alpha1.Redis) (*pr
 		errMsg := fmt.Sprintf("failed to retrieve openshift redis cluster config for instance %s", r.Name)
 		return nil, croType.StatusMessage(errMsg), errorUtil.Wrapf(err, errMsg)
 	}
-
-	// deploy pvc
-	if err := p.CreatePVC(ctx, buildDefaultRedisPVC(r), redisConfig); err != nil {
-		errMsg := "failed to create or update redis PVC"
+	if err := validateWorkloadStorage(ctx, p.Client, redisConfig.Storage); err != nil {
+		errMsg := fmt.Sprintf("invalid openshift redis storage config for instance %s", r.Name)
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	if err := validateWorkloadScheduling(redisConfig.Scheduling); err != nil {
+		errMsg := fmt.Sprintf("invalid openshift redis scheduling config for instance %s", r.Name)
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	if err := validateWorkloadNetworkPolicy(redisConfig.NetworkPolicy); err != nil {
+		errMsg := fmt.Sprintf("invalid openshift redis network policy config for instance %s", r.Name)
 		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 	}
-	// deploy configmap
-	if err := p.CreateConfigMap(ctx, buildDefaultRedisConfigMap(r), redisConfig); err != nil {
-		errMsg := "failed to create or update redis config map"
+	externalCIDRs, err := providers.ParseExternalAccess(r.Spec.ExternalAccess)
+	if err != nil {
+		errMsg := fmt.Sprintf("invalid external access for instance %s", r.Name)
 		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 	}
-	// deploy deployment
-	if err := p.CreateDeployment(ctx, buildDefaultRedisDeployment(r), redisConfig); err != nil {
-		errMsg := "failed to create or update redis deployment"
+
+	// workload objects are built from a copy of the cr in the namespace they're created in
+	ns, err := reconcileWorkloadNamespace(ctx, p.Client, r, &r.Status, redisConfig.Isolation)
+	if err != nil {
+		errMsg := "failed to reconcile redis workload namespace"
 		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 	}
-	// deploy service
-	if err := p.CreateService(ctx, buildDefaultRedisService(r), redisConfig); err != nil {
-		errMsg := "failed to create or update redis service"
+	workload := r.DeepCopy()
+	workload.Namespace = ns
+	recordWorkloadNamespace(&r.Status, workload)
+	// restrict the ingress to redis and its sentinels before either is deployed
+	if err := reconcileWorkloadNetworkPolicy(ctx, p.Client, p.Logger, r, workload.Name, ns, []string{workload.Name, redisSentinelName(workload)}, redisConfig.NetworkPolicy, externalCIDRs); err != nil {
+		errMsg := fmt.Sprintf("failed to reconcile network policy for instance %s", r.Name)
 		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 	}
+	// keep node drains from evicting the redis pod, or more than one replica or sentinel of the sentinel topology
+	sentinel := redisConfig.Topology == RedisTopologySentinel
+	sentinelScheduling := redisConfig.Scheduling
+	if !sentinel {
+		sentinelScheduling = nil
+	}
+	for name, scheduling := range map[string]*WorkloadScheduling{workload.Name: redisConfig.Scheduling, redisSentinelName(workload): sentinelScheduling} {
+		if err := reconcileWorkloadDisruptionBudget(ctx, p.Client, p.Logger, name, ns, sentinel, scheduling); err != nil {
+			errMsg := fmt.Sprintf("failed to reconcile pod disruption budget for instance %s", r.Name)
+			return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+	}
+
+	// move off the config map shared by the redis of the namespace before config maps were scoped to a redis
+	if err := migrateLegacyRedisConfigMap(ctx, p.Client, r, ns); err != nil {
+		p.Logger.Warnf("failed to migrate redis %s off the legacy config map: %v", r.Name, err)
+	}
+
+	// sentinel topology is provisioned as a statefulset with a separate set of sentinels
+	if redisConfig.Topology == RedisTopologySentinel {
+		// clients discover the master through the sentinels, which only advertise addresses inside the cluster
+		if len(externalCIDRs) > 0 {
+			errMsg := fmt.Sprintf("external access is not supported for the sentinel topology of instance %s", r.Name)
+			return nil, croType.StatusMessage(errMsg), errorUtil.New(errMsg)
+		}
+		if r.Spec.Hibernate {
+			errMsg := fmt.Sprintf("hibernation is not supported for the sentinel topology of instance %s", r.Name)
+			return nil, croType.StatusMessage(errMsg), errorUtil.New(errMsg)
+		}
+		return p.createSentinelRedis(ctx, workload, redisConfig)
+	}
+
+	// deploy the config map, pvc, deployment and service in order, a new redis only gets the next object once the
+	// previous one is ready
+	redisCM := buildDefaultRedisConfigMap(workload)
+	redisPVC := buildDefaultRedisPVC(workload)
+	redisDpl := buildDefaultRedisDeployment(workload)
+	redisSvc := buildDefaultRedisService(workload)
+	key := types.NamespacedName{Name: workload.Name, Namespace: workload.Namespace}
+	stepsMsg, err := reconcileWorkloadSteps(ctx, p.Client, p.Logger, &r.Status, []workloadStep{
+		{
+			name:   ConfigMapStep,
+			obj:    redisCM.DeepCopy(),
+			create: func(ctx context.Context) error { return p.CreateConfigMap(ctx, redisCM, redisConfig) },
+		},
+		{
+			name:   PersistentVolumeClaimStep,
+			obj:    redisPVC.DeepCopy(),
+			create: func(ctx context.Context) error { return p.CreatePVC(ctx, redisPVC, redisConfig) },
+			ready:  func(ctx context.Context) (string, error) { return pvcReady(ctx, p.Client, key) },
+		},
+		{
+			name:   DeploymentStep,
+			obj:    redisDpl.DeepCopy(),
+			create: func(ctx context.Context) error { return p.CreateDeployment(ctx, redisDpl, redisConfig) },
+			ready:  func(ctx context.Context) (string, error) { return deploymentReady(ctx, p.Client, key) },
+		},
+		{
+			name:   ServiceStep,
+			obj:    redisSvc.DeepCopy(),
+			create: func(ctx context.Context) error { return p.CreateService(ctx, redisSvc, redisConfig) },
+		},
+	})
+	if err != nil {
+		return nil, stepsMsg, errorUtil.Wrapf(err, "failed to create or update redis objects for instance %s", r.Name)
+	}
+	if stepsMsg != croType.StatusEmpty {
+		p.Logger.Info(stepsMsg)
+		return nil, stepsMsg, nil
+	}
+	// expose redis to the external clients allowed by the cr, revoking access no longer allowed
+	externalEndpoint, err := reconcileExternalAccessService(ctx, p.Client, p.Logger, workload.Name, workload.Namespace, redisPort, map[string]string{"deployment": workload.Name}, externalCIDRs)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to reconcile external access for instance %s", r.Name)
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	providers.SetExternalAccessStatus(p.Logger, providers.RedisResourceType, r, &r.Status, externalCIDRs, externalEndpoint, time.Now())
 
 	// check deployment status
 	dpl := &appsv1.Deployment{}
-	err = p.Client.Get(ctx, types.NamespacedName{Name: r.Name, Namespace: r.Namespace}, dpl)
+	err = p.Client.Get(ctx, types.NamespacedName{Name: workload.Name, Namespace: workload.Namespace}, dpl)
 	if err != nil {
 		errMsg := "failed to get redis deployment"
 		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 	}
 
+	// a hibernated redis is scaled to zero replicas, keeping its pvc
+	if hibernationMsg := reconcileHibernation(r, &r.Spec, &r.Status, dpl); hibernationMsg != croType.StatusEmpty {
+		p.Logger.Info(hibernationMsg)
+		return nil, hibernationMsg, nil
+	}
+
 	// check if deployment is ready and return connection details
 	for _, s := range dpl.Status.Conditions {
 		if s.Type == appsv1.DeploymentAvailable && s.Status == "True" {
+			// estimate the cost from the cluster capacity requested by the deployment and its storage
+			usage := &workloadUsage{}
+			usage.addPods(dpl.Spec.Replicas, dpl.Spec.Template.Spec)
+			pvc := &apiv1.PersistentVolumeClaim{}
+			if err := p.Client.Get(ctx, types.NamespacedName{Name: workload.Name, Namespace: workload.Namespace}, pvc); err != nil {
+				p.Logger.Warnf("failed to get redis pvc, storage is not included in the cost estimate: %v", err)
+			} else {
+				usage.addStorage(nil, *pvc)
+			}
+
 			p.Logger.Info("found redis deployment")
+			host := fmt.Sprintf("%s.%s.svc.cluster.local", workload.Name, workload.Namespace)
+			r.Status.CloudResource = buildWorkloadCloudResourceStatus(workload, host, redisPort)
 			return &providers.RedisCluster{DeploymentDetails: &providers.RedisDeploymentDetails{
-				URI:  fmt.Sprintf("%s.%s.svc.cluster.local", r.Name, r.Namespace),
-				Port: redisPort}}, "redis deployment available", nil
+				URI:  host,
+				Port: redisPort}, Cost: usage.cost()}, "redis deployment available", nil
 		}
 	}
 
@@ -136,15 +252,49 @@ func (p *RedisProvider) CreateRedis(ctx context.Context, r *v1alpha1.Redis) (*pr
 }
 
 func (p *RedisProvider) DeleteRedis(ctx context.Context, r *v1alpha1.Redis) (croType.StatusMessage, error) {
+	workload := r.DeepCopy()
+	workload.Namespace = deletedWorkloadNamespace(p.Logger, r, &r.Status, func() (*WorkloadIsolation, error) {
+		redisConfig, _, err := p.getRedisConfig(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		return redisConfig.Isolation, nil
+	})
+
+	// delete sentinel topology objects, these are no-ops for standalone instances
+	if msg, err := p.deleteSentinelRedis(ctx, workload); err != nil {
+		return msg, err
+	}
+
+	// delete the external access service, whether or not external access is still allowed
+	if _, err := reconcileExternalAccessService(ctx, p.Client, p.Logger, r.Name, workload.Namespace, redisPort, nil, nil); err != nil {
+		errMsg := "failed to delete redis external access service"
+		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+
+	// delete the network policy, whether or not it's still configured
+	if err := reconcileWorkloadNetworkPolicy(ctx, p.Client, p.Logger, r, r.Name, workload.Namespace, nil, nil, nil); err != nil {
+		errMsg := "failed to delete redis network policy"
+		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+
+	// delete the pod disruption budgets, whether or not they're still configured
+	for _, name := range []string{r.Name, redisSentinelName(r)} {
+		if err := reconcileWorkloadDisruptionBudget(ctx, p.Client, p.Logger, name, workload.Namespace, false, nil); err != nil {
+			errMsg := "failed to delete redis pod disruption budget"
+			return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+	}
+
 	// delete service
 	p.Logger.Info("Deleting redis service")
 	svc := &apiv1.Service{
 		ObjectMeta: controllerruntime.ObjectMeta{
 			Name:      r.Name,
-			Namespace: r.Namespace,
+			Namespace: workload.Namespace,
 		},
 	}
-	err := p.Client.Delete(ctx, svc)
+	err := deleteObject(ctx, p.Client, svc)
 	if err != nil && !k8serr.IsNotFound(err) {
 		errMsg := "failed to delete service"
 		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
@@ -155,10 +305,10 @@ func (p *RedisProvider) DeleteRedis(ctx context.Context, r *v1alpha1.Redis) (cro
 	pvc := &apiv1.PersistentVolumeClaim{
 		ObjectMeta: controllerruntime.ObjectMeta{
 			Name:      r.Name,
-			Namespace: r.Namespace,
+			Namespace: workload.Namespace,
 		},
 	}
-	err = p.Client.Delete(ctx, pvc)
+	err = deleteObject(ctx, p.Client, pvc)
 	if err != nil && !k8serr.IsNotFound(err) {
 		errMsg := "failed to delete persistent volume claim"
 		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
@@ -168,11 +318,11 @@ func (p *RedisProvider) DeleteRedis(ctx context.Context, r *v1alpha1.Redis) (cro
 	p.Logger.Info("Deleting redis configmap")
 	cm := &apiv1.ConfigMap{
 		ObjectMeta: controllerruntime.ObjectMeta{
-			Name:      redisConfigMapName,
-			Namespace: r.Namespace,
+			Name:      redisConfigMapName(r.Name),
+			Namespace: workload.Namespace,
 		},
 	}
-	err = p.Client.Delete(ctx, cm)
+	err = deleteObject(ctx, p.Client, cm)
 	if err != nil && !k8serr.IsNotFound(err) {
 		errMsg := "failed to delete configmap"
 		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
@@ -183,15 +333,26 @@ func (p *RedisProvider) DeleteRedis(ctx context.Context, r *v1alpha1.Redis) (cro
 	dpl := &appsv1.Deployment{
 		ObjectMeta: controllerruntime.ObjectMeta{
 			Name:      r.Name,
-			Namespace: r.Namespace,
+			Namespace: workload.Namespace,
 		},
 	}
-	err = p.Client.Delete(ctx, dpl)
+	err = deleteObject(ctx, p.Client, dpl)
 	if err != nil && !k8serr.IsNotFound(err) {
 		errMsg := "failed to delete deployment"
 		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
 	}
 
+	// a redis deleted before it was migrated may have been the last one mounting the legacy config map
+	if err := migrateLegacyRedisConfigMap(ctx, p.Client, r, workload.Namespace); err != nil {
+		p.Logger.Warnf("failed to migrate redis %s off the legacy config map: %v", r.Name, err)
+	}
+
+	// delete the dedicated workload namespace
+	if err := deleteWorkloadNamespace(ctx, p.Client, r, workload.Namespace); err != nil {
+		errMsg := "failed to delete redis workload namespace"
+		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+
 	// remove the finalizer added by the provider
 	p.Logger.Info("Removing finalizer")
 	resources.RemoveFinalizer(&r.ObjectMeta, DefaultFinalizer)
@@ -210,6 +371,11 @@ func (p *RedisProvider) getRedisConfig(ctx context.Context, r *v1alpha1.Redis) (
 	if err != nil {
 		return nil, nil, errorUtil.Wrap(err, "failed to read openshift strategy config")
 	}
+	// values of the tier changed by the redis itself
+	stratCfg.RawStrategy, err = providers.MergeProviderConfig(stratCfg.RawStrategy, r.Spec.ProviderConfig)
+	if err != nil {
+		return nil, nil, errorUtil.Wrap(err, "failed to merge provider config of redis")
+	}
 
 	// unmarshal the redis cluster config
 	redisConfig := &RedisStrat{}
@@ -220,14 +386,9 @@ func (p *RedisProvider) getRedisConfig(ctx context.Context, r *v1alpha1.Redis) (
 }
 
 func (p *RedisProvider) CreateDeployment(ctx context.Context, d *appsv1.Deployment, redisCfg *RedisStrat) error {
-	or, err := immutableCreateOrUpdate(ctx, p.Client, d, func(existing runtime.Object) error {
-		e := existing.(*appsv1.Deployment)
-		if redisCfg.RedisDeploymentSpec == nil {
-			e.Spec = d.Spec
-			return nil
-		}
-		e.Spec = *redisCfg.RedisDeploymentSpec
-		return nil
+	desired := desiredRedisDeployment(d, redisCfg)
+	or, err := immutableCreateOrUpdate(ctx, p.Client, p.Logger, desired, func(existing runtime.Object) error {
+		return threeWayMerge(existing, desired)
 	})
 	if err != nil {
 		return errorUtil.Wrapf(err, "failed to create or update deployment %s, action was %s", d.Name, or)
@@ -236,18 +397,9 @@ func (p *RedisProvider) CreateDeployment(ctx context.Context, d *appsv1.Deployme
 }
 
 func (p *RedisProvider) CreateService(ctx context.Context, s *apiv1.Service, redisCfg *RedisStrat) error {
-	or, err := immutableCreateOrUpdate(ctx, p.Client, s, func(existing runtime.Object) error {
-		e := existing.(*apiv1.Service)
-
-		if redisCfg.RedisServiceSpec == nil {
-			clusterIP := e.Spec.ClusterIP
-			e.Spec = s.Spec
-			e.Spec.ClusterIP = clusterIP
-			return nil
-		}
-
-		e.Spec = *redisCfg.RedisServiceSpec
-		return nil
+	desired := desiredRedisService(s, redisCfg)
+	or, err := immutableCreateOrUpdate(ctx, p.Client, p.Logger, desired, func(existing runtime.Object) error {
+		return threeWayMerge(existing, desired)
 	})
 	if err != nil {
 		return errorUtil.Wrapf(err, "failed to create or update service %s, action was %s", s.Name, or)
@@ -256,15 +408,9 @@ func (p *RedisProvider) CreateService(ctx context.Context, s *apiv1.Service, red
 }
 
 func (p *RedisProvider) CreateConfigMap(ctx context.Context, cm *apiv1.ConfigMap, redisCfg *RedisStrat) error {
-	or, err := immutableCreateOrUpdate(ctx, p.Client, cm, func(existing runtime.Object) error {
+	or, err := immutableCreateOrUpdate(ctx, p.Client, p.Logger, cm, func(existing runtime.Object) error {
 		e := existing.(*apiv1.ConfigMap)
-
-		if redisCfg.RedisConfigMapData == nil {
-			e.Data = cm.Data
-			return nil
-		}
-
-		e.Data = redisCfg.RedisConfigMapData
+		e.Data = desiredRedisConfigMap(cm, redisCfg).Data
 		return nil
 	})
 	if err != nil {
@@ -274,17 +420,30 @@ func (p *RedisProvider) CreateConfigMap(ctx context.Context, cm *apiv1.ConfigMap
 }
 
 func (p *RedisProvider) CreatePVC(ctx context.Context, pvc *apiv1.PersistentVolumeClaim, redisCfg *RedisStrat) error {
-	or, err := immutableCreateOrUpdate(ctx, p.Client, pvc, func(existing runtime.Object) error {
+	desired := desiredRedisPVC(pvc, redisCfg)
+	or, err := immutableCreateOrUpdate(ctx, p.Client, p.Logger, desired, func(existing runtime.Object) error {
 		e := existing.(*apiv1.PersistentVolumeClaim)
+		applyLabelOverrides(e, redisCfg.Overrides)
 		// resources.requests is only mutable on bound claims
 		if strings.ToLower(string(e.Status.Phase)) != "bound" {
 			return nil
 		}
-		if redisCfg.RedisPVCSpec == nil {
-			e.Spec.Resources.Requests = pvc.Spec.Resources.Requests
-			return nil
+		requests := desired.Spec.Resources.Requests
+		if redisCfg.RedisPVCSpec != nil {
+			requests = overrideStorageRequests(redisCfg.RedisPVCSpec.Resources.Requests, redisCfg.Overrides)
 		}
-		e.Spec.Resources.Requests = redisCfg.RedisPVCSpec.Resources.Requests
+		// a claim can only be grown if its storage class allows volume expansion
+		cur, want := e.Spec.Resources.Requests[apiv1.ResourceStorage], requests[apiv1.ResourceStorage]
+		if want.Cmp(cur) > 0 {
+			expandable, err := storageClassAllowsExpansion(ctx, p.Client, e)
+			if err != nil {
+				return err
+			}
+			if !expandable {
+				return errorUtil.Errorf("the storage class of pvc %s doesn't allow volume expansion, the pvc must be resized to %s manually", e.Name, want.String())
+			}
+		}
+		e.Spec.Resources.Requests = requests
 		return nil
 	})
 	if err != nil {
@@ -297,10 +456,29 @@ func (p *RedisProvider) CreatePVC(ctx context.Context, pvc *apiv1.PersistentVolu
 type RedisStrat struct {
 	_ struct{} `type:"structure"`
 
-	RedisDeploymentSpec *appsv1.DeploymentSpec           `json:"deploymentSpec"`
-	RedisServiceSpec    *apiv1.ServiceSpec               `json:"serviceSpec"`
-	RedisPVCSpec        *apiv1.PersistentVolumeClaimSpec `json:"pvcSpec"`
-	RedisConfigMapData  map[string]string                `json:"configMapData"`
+	RedisDeploymentSpec *appsv1.DeploymentSpec           `json:"deploymentSpec,omitempty"`
+	RedisServiceSpec    *apiv1.ServiceSpec               `json:"serviceSpec,omitempty"`
+	RedisPVCSpec        *apiv1.PersistentVolumeClaimSpec `json:"pvcSpec,omitempty"`
+	RedisConfigMapData  map[string]string                `json:"configMapData,omitempty"`
+	// Topology is one of standalone or sentinel, defaults to standalone
+	Topology string `json:"topology,omitempty"`
+	// Isolation configures the namespace the redis workload is created in
+	Isolation *WorkloadIsolation `json:"isolation,omitempty"`
+	// PodExtensions are merged into the redis pod template, including an overriding deploymentSpec, sentinel pods are
+	// not extended
+	PodExtensions *PodExtensions `json:"podExtensions,omitempty"`
+	// Overrides are merged onto the generated redis objects, including overriding specs, sentinel objects are not
+	// overridden
+	Overrides *WorkloadOverrides `json:"overrides,omitempty"`
+	// Storage selects the storage class, volume mode and access modes of the redis pvc, including the pvcs of the
+	// sentinel topology
+	Storage *WorkloadStorage `json:"storage,omitempty"`
+	// NetworkPolicy restricts the ingress to the redis, including the sentinels of the sentinel topology, to the allowed
+	// namespaces
+	NetworkPolicy *WorkloadNetworkPolicy `json:"networkPolicy,omitempty"`
+	// Scheduling places the redis pods, including the sentinels of the sentinel topology, on nodes and creates their
+	// pod disruption budgets
+	Scheduling *WorkloadScheduling `json:"scheduling,omitempty"`
 }
 
 func buildDefaultRedisDeployment(r *v1alpha1.Redis) *appsv1.Deployment {
@@ -333,7 +511,7 @@ func buildDefaultRedisDeployment(r *v1alpha1.Redis) *appsv1.Deployment {
 					"deployment": r.Name,
 				},
 			},
-			Replicas: int32Ptr(1),
+			Replicas: workloadReplicas(r.Spec, int32Ptr(1)),
 		},
 	}
 	// required for restricted namespace
@@ -424,7 +602,7 @@ func buildDefaultRedisPodVolumes(r *v1alpha1.Redis) []apiv1.Volume {
 			VolumeSource: apiv1.VolumeSource{
 				ConfigMap: &apiv1.ConfigMapVolumeSource{
 					LocalObjectReference: apiv1.LocalObjectReference{
-						Name: redisConfigMapName, // the name of the ConfigMap
+						Name: redisConfigMapName(r.Name), // the name of the ConfigMap
 					},
 					Items: []apiv1.KeyToPath{
 						{
@@ -463,10 +641,128 @@ func buildDefaultRedisService(r *v1alpha1.Redis) *apiv1.Service {
 	}
 }
 
+// redisConfigMapName returns the name of the config map of the redis, scoped to the redis so deleting a redis doesn't
+// remove the config of the other redis of the namespace
+func redisConfigMapName(name string) string {
+	return fmt.Sprintf("%s-%s", name, legacyRedisConfigMapName)
+}
+
+// migrateLegacyRedisConfigMap sets the config migrated condition of the redis once its workload in the namespace no
+// longer mounts the config map shared by the redis of the namespace before their config maps were scoped to them. The
+// shared config map is deleted when the operator created it and no other workload of the namespace mounts it, so the
+// last redis migrated deletes it. A migrated redis is skipped, so the workloads are only listed once per redis
+func migrateLegacyRedisConfigMap(ctx context.Context, c client.Client, r *v1alpha1.Redis, ns string) error {
+	if meta.IsStatusConditionTrue(r.Status.Conditions, ConfigMigratedCondition) {
+		return nil
+	}
+	// the redis is migrated once its own deployment or statefulset is updated to mount its own config map
+	for _, o := range []runtime.Object{&appsv1.Deployment{}, &appsv1.StatefulSet{}} {
+		if err := c.Get(ctx, types.NamespacedName{Name: r.Name, Namespace: ns}, o); err != nil {
+			if k8serr.IsNotFound(err) {
+				continue
+			}
+			return errorUtil.Wrapf(err, "failed to get workload of redis %s", r.Name)
+		}
+		if mountsConfigMap(podTemplateSpec(o), legacyRedisConfigMapName) {
+			return nil
+		}
+	}
+
+	legacy := &apiv1.ConfigMap{}
+	err := c.Get(ctx, types.NamespacedName{Name: legacyRedisConfigMapName, Namespace: ns}, legacy)
+	if err != nil && !k8serr.IsNotFound(err) {
+		return errorUtil.Wrapf(err, "failed to get legacy config map %s", legacyRedisConfigMapName)
+	}
+	// a config map of the same name the operator didn't create, e.g. one of the workloads of the user, is left alone
+	if err == nil && (createdByOperator(legacy) || isBaselineRedisConfigMap(legacy)) {
+		inUse, err := configMapInUse(ctx, c, ns, legacyRedisConfigMapName)
+		if err != nil {
+			return err
+		}
+		if !inUse {
+			if err := deleteObject(ctx, c, legacy); err != nil && !k8serr.IsNotFound(err) {
+				return errorUtil.Wrapf(err, "failed to delete legacy config map %s", legacyRedisConfigMapName)
+			}
+		}
+	}
+	meta.SetStatusCondition(&r.Status.Conditions, metav1.Condition{
+		Type:               ConfigMigratedCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             ConfigMigratedReason,
+		Message:            fmt.Sprintf("redis uses its own config map %s", redisConfigMapName(r.Name)),
+		ObservedGeneration: r.GetGeneration(),
+	})
+	return nil
+}
+
+// createdByOperator returns true if the object is owned by a resource of the operator, or carries the gitops
+// annotations the operator sets on every object it generates
+func createdByOperator(obj metav1.Object) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if strings.HasPrefix(ref.APIVersion, v1alpha1.GroupVersion.Group+"/") {
+			return true
+		}
+	}
+	for k, v := range resources.GitOpsAnnotations() {
+		if obj.GetAnnotations()[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// isBaselineRedisConfigMap returns true if the config map is the legacy config map as the operator generated it before
+// it set owner references or annotations on the objects it creates, i.e. it holds nothing but the generated redis.conf
+func isBaselineRedisConfigMap(cm *apiv1.ConfigMap) bool {
+	return cm.Name == legacyRedisConfigMapName && len(cm.Data) == 1 && cm.Data[redisConfigMapKey] == getRedisConfData()
+}
+
+// configMapInUse returns true if a deployment or statefulset of the namespace mounts the config map
+func configMapInUse(ctx context.Context, c client.Client, ns, name string) (bool, error) {
+	dpls := &appsv1.DeploymentList{}
+	if err := c.List(ctx, dpls, client.InNamespace(ns)); err != nil {
+		return false, errorUtil.Wrap(err, "failed to list deployments")
+	}
+	for i := range dpls.Items {
+		if mountsConfigMap(dpls.Items[i].Spec.Template.Spec, name) {
+			return true, nil
+		}
+	}
+	stss := &appsv1.StatefulSetList{}
+	if err := c.List(ctx, stss, client.InNamespace(ns)); err != nil {
+		return false, errorUtil.Wrap(err, "failed to list statefulsets")
+	}
+	for i := range stss.Items {
+		if mountsConfigMap(stss.Items[i].Spec.Template.Spec, name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func podTemplateSpec(o runtime.Object) apiv1.PodSpec {
+	switch w := o.(type) {
+	case *appsv1.Deployment:
+		return w.Spec.Template.Spec
+	case *appsv1.StatefulSet:
+		return w.Spec.Template.Spec
+	}
+	return apiv1.PodSpec{}
+}
+
+func mountsConfigMap(spec apiv1.PodSpec, name string) bool {
+	for _, vol := range spec.Volumes {
+		if vol.ConfigMap != nil && vol.ConfigMap.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 func buildDefaultRedisConfigMap(r *v1alpha1.Redis) *apiv1.ConfigMap {
 	return &apiv1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      redisConfigMapName,
+			Name:      redisConfigMapName(r.Name),
 			Namespace: r.Namespace,
 		},
 		TypeMeta: metav1.TypeMeta{
@@ -536,11 +832,3 @@ func buildDefaultRedisPVC(r *v1alpha1.Redis) *apiv1.PersistentVolumeClaim {
 }
 
 func int32Ptr(i int32) *int32 { return &i }
-
-// controllerutil.CreateOrUpdate without mutating the original runtime.Object provided
-func immutableCreateOrUpdate(ctx context.Context, c client.Client, o runtime.Object, cb func(existing runtime.Object) error) (controllerutil.OperationResult, error) {
-	copiedObj := o.DeepCopyObject()
-	return controllerutil.CreateOrUpdate(ctx, c, copiedObj.(runtime.Object), func() error {
-		return cb(copiedObj)
-	})
-}
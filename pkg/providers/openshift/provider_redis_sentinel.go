@@ -0,0 +1,412 @@
+package openshift
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	errorUtil "github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// RedisTopologyStandalone is the default single pod redis deployment
+	RedisTopologyStandalone = "standalone"
+	// RedisTopologySentinel deploys a primary with replicas, monitored by a set of redis sentinels
+	RedisTopologySentinel = "sentinel"
+
+	redisSentinelMasterName     = "mymaster"
+	redisSentinelPort           = 26379
+	redisSentinelQuorum         = 2
+	redisSentinelContainerName  = "sentinel"
+	redisSentinelDataVolumeName = "sentinel-data"
+	redisSentinelNameSuffix     = "sentinel"
+	redisDataVolumeName         = "data"
+	defaultRedisSentinelCount   = 3
+	defaultRedisReplicaCount    = 3
+)
+
+// createSentinelRedis provisions a redis statefulset (a primary and replicas) along with a sentinel deployment
+// which monitors the primary and promotes a replica on failure
+func (p *RedisProvider) createSentinelRedis(ctx context.Context, r *v1alpha1.Redis, redisConfig *RedisStrat) (*providers.RedisCluster, croType.StatusMessage, error) {
+	// deploy configmap
+	if err := p.CreateConfigMap(ctx, buildDefaultRedisConfigMap(r), redisConfig); err != nil {
+		errMsg := "failed to create or update redis config map"
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	// deploy headless redis service, required for stable statefulset pod dns names
+	if err := p.CreateService(ctx, buildDefaultRedisHeadlessService(r), &RedisStrat{}); err != nil {
+		errMsg := "failed to create or update redis service"
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	// deploy sentinel service
+	if err := p.CreateService(ctx, buildDefaultRedisSentinelService(r), &RedisStrat{}); err != nil {
+		errMsg := "failed to create or update redis sentinel service"
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	// deploy redis statefulset
+	if err := p.CreateStatefulSet(ctx, buildDefaultRedisStatefulSet(r, redisConfig)); err != nil {
+		errMsg := "failed to create or update redis statefulset"
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	// deploy sentinels, on the nodes of the redis pods
+	sentinels := desiredRedisSentinelDeployment(buildDefaultRedisSentinelDeployment(r), redisConfig)
+	if err := p.CreateDeployment(ctx, sentinels, &RedisStrat{}); err != nil {
+		errMsg := "failed to create or update redis sentinel deployment"
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+
+	// check statefulset status
+	sts := &appsv1.StatefulSet{}
+	if err := p.Client.Get(ctx, types.NamespacedName{Name: r.Name, Namespace: r.Namespace}, sts); err != nil {
+		errMsg := "failed to get redis statefulset"
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	if sts.Spec.Replicas == nil || sts.Status.ReadyReplicas < *sts.Spec.Replicas {
+		p.Logger.Infof("redis statefulset is not ready, %d replicas ready", sts.Status.ReadyReplicas)
+		return nil, "creation in progress", nil
+	}
+
+	// check sentinel deployment status
+	dpl := &appsv1.Deployment{}
+	if err := p.Client.Get(ctx, types.NamespacedName{Name: redisSentinelName(r), Namespace: r.Namespace}, dpl); err != nil {
+		errMsg := "failed to get redis sentinel deployment"
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	for _, s := range dpl.Status.Conditions {
+		if s.Type == appsv1.DeploymentAvailable && s.Status == "True" {
+			// estimate the cost from the cluster capacity requested by the redis replicas, their storage and the sentinels
+			usage := &workloadUsage{}
+			usage.addPods(sts.Spec.Replicas, sts.Spec.Template.Spec)
+			for _, pvc := range sts.Spec.VolumeClaimTemplates {
+				usage.addStorage(sts.Spec.Replicas, pvc)
+			}
+			usage.addPods(dpl.Spec.Replicas, dpl.Spec.Template.Spec)
+
+			p.Logger.Info("found redis sentinel deployment")
+			host := fmt.Sprintf("%s.%s.svc.cluster.local", r.Name, r.Namespace)
+			r.Status.CloudResource = buildWorkloadCloudResourceStatus(r, host, redisPort)
+			return &providers.RedisCluster{DeploymentDetails: &providers.RedisDeploymentDetails{
+				URI:          host,
+				Port:         redisPort,
+				SentinelURI:  fmt.Sprintf("%s.%s.svc.cluster.local", redisSentinelName(r), r.Namespace),
+				SentinelPort: redisSentinelPort,
+				MasterName:   redisSentinelMasterName,
+			}, Cost: usage.cost()}, "redis sentinel deployment available", nil
+		}
+	}
+
+	p.Logger.Info("redis sentinel deployment is not ready")
+	return nil, "creation in progress", nil
+}
+
+// deleteSentinelRedis removes the sentinel specific objects, the objects shared with the standalone topology are
+// removed as part of the standard delete flow
+func (p *RedisProvider) deleteSentinelRedis(ctx context.Context, r *v1alpha1.Redis) (croType.StatusMessage, error) {
+	// delete sentinel service
+	p.Logger.Info("deleting redis sentinel service")
+	svc := &apiv1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      redisSentinelName(r),
+			Namespace: r.Namespace,
+		},
+	}
+	if err := deleteObject(ctx, p.Client, svc); err != nil && !k8serr.IsNotFound(err) {
+		errMsg := "failed to delete redis sentinel service"
+		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+
+	// delete sentinel deployment
+	p.Logger.Info("deleting redis sentinel deployment")
+	dpl := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      redisSentinelName(r),
+			Namespace: r.Namespace,
+		},
+	}
+	if err := deleteObject(ctx, p.Client, dpl); err != nil && !k8serr.IsNotFound(err) {
+		errMsg := "failed to delete redis sentinel deployment"
+		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+
+	// delete statefulset
+	p.Logger.Info("deleting redis statefulset")
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      r.Name,
+			Namespace: r.Namespace,
+		},
+	}
+	if err := deleteObject(ctx, p.Client, sts); err != nil && !k8serr.IsNotFound(err) {
+		errMsg := "failed to delete redis statefulset"
+		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+
+	// claims created from the statefulset volume claim template are not garbage collected with the statefulset
+	if err := p.Client.DeleteAllOf(ctx, &apiv1.PersistentVolumeClaim{}, client.InNamespace(r.Namespace), client.MatchingLabels{"deployment": r.Name}); err != nil && !k8serr.IsNotFound(err) {
+		errMsg := "failed to delete redis statefulset persistent volume claims"
+		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	return "", nil
+}
+
+func (p *RedisProvider) CreateStatefulSet(ctx context.Context, s *appsv1.StatefulSet) error {
+	or, err := immutableCreateOrUpdate(ctx, p.Client, p.Logger, s, func(existing runtime.Object) error {
+		e := existing.(*appsv1.StatefulSet)
+		// volume claim templates, selector and service name are immutable once created
+		if e.CreationTimestamp.IsZero() {
+			e.Spec = s.Spec
+			return nil
+		}
+		e.Spec.Replicas = s.Spec.Replicas
+		e.Spec.Template = s.Spec.Template
+		return nil
+	})
+	if err != nil {
+		return errorUtil.Wrapf(err, "failed to create or update statefulset %s, action was %s", s.Name, or)
+	}
+	return nil
+}
+
+func redisSentinelName(r *v1alpha1.Redis) string {
+	return fmt.Sprintf("%s-%s", r.Name, redisSentinelNameSuffix)
+}
+
+// redisPrimaryHost is the dns name of the initial primary, the first pod of the statefulset
+func redisPrimaryHost(r *v1alpha1.Redis) string {
+	return fmt.Sprintf("%s-0.%s.%s.svc.cluster.local", r.Name, r.Name, r.Namespace)
+}
+
+func buildDefaultRedisHeadlessService(r *v1alpha1.Redis) *apiv1.Service {
+	svc := buildDefaultRedisService(r)
+	svc.Spec.ClusterIP = apiv1.ClusterIPNone
+	svc.Spec.PublishNotReadyAddresses = true
+	return svc
+}
+
+func buildDefaultRedisSentinelService(r *v1alpha1.Redis) *apiv1.Service {
+	return &apiv1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      redisSentinelName(r),
+			Namespace: r.Namespace,
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: "v1",
+		},
+		Spec: apiv1.ServiceSpec{
+			Ports: []apiv1.ServicePort{
+				{
+					Name:       redisSentinelContainerName,
+					Port:       redisSentinelPort,
+					TargetPort: intstr.FromInt(redisSentinelPort),
+					Protocol:   apiv1.ProtocolTCP,
+				},
+			},
+			Selector: map[string]string{
+				"deployment": redisSentinelName(r),
+			},
+		},
+	}
+}
+
+func buildDefaultRedisStatefulSet(r *v1alpha1.Redis, redisCfg *RedisStrat) *appsv1.StatefulSet {
+	pvc := buildDefaultRedisPVC(r)
+	if redisCfg.RedisPVCSpec != nil {
+		pvc.Spec = *redisCfg.RedisPVCSpec
+	}
+	applyWorkloadStorage(&pvc.Spec, redisCfg.Storage)
+	pvc.ObjectMeta = metav1.ObjectMeta{
+		Name: redisDataVolumeName,
+		Labels: map[string]string{
+			"deployment": r.Name,
+		},
+	}
+	pvc.Spec.Resources.Requests = overrideStorageRequests(pvc.Spec.Resources.Requests, redisCfg.Overrides)
+
+	// the redis container is started through a script which joins the current primary, as reported by the
+	// sentinels, or falls back to the first pod of the statefulset when no primary has been elected yet
+	containers := buildDefaultRedisPodContainers(r)
+	containers[0].Command = []string{"/bin/sh", "-c"}
+	containers[0].Args = []string{buildRedisSentinelReplicaScript(r)}
+	containers[0].VolumeMounts[0].Name = redisDataVolumeName
+
+	sts := &appsv1.StatefulSet{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "StatefulSet",
+			APIVersion: "apps/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      r.Name,
+			Namespace: r.Namespace,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			ServiceName: r.Name,
+			Replicas:    int32Ptr(defaultRedisReplicaCount),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"deployment": r.Name,
+				},
+			},
+			Template: apiv1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"deployment": r.Name,
+					},
+				},
+				Spec: apiv1.PodSpec{
+					Volumes:    buildDefaultRedisPodVolumes(r)[1:],
+					Containers: containers,
+				},
+			},
+			VolumeClaimTemplates: []apiv1.PersistentVolumeClaim{*pvc},
+		},
+	}
+	// the default deployment holds the security context required for restricted namespaces
+	sts.Spec.Template.Spec.SecurityContext = buildDefaultRedisDeployment(r).Spec.Template.Spec.SecurityContext
+	mergePodExtensions(&sts.Spec.Template.Spec, redisCfg.PodExtensions)
+	applyWorkloadScheduling(&sts.Spec.Template.Spec, redisCfg.Scheduling, r.Name)
+	applyLabelOverrides(sts, redisCfg.Overrides)
+	applyPodTemplateOverrides(&sts.Spec.Template, redisContainerName, redisCfg.Overrides)
+	return sts
+}
+
+func buildDefaultRedisSentinelDeployment(r *v1alpha1.Redis) *appsv1.Deployment {
+	depl := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Deployment",
+			APIVersion: "apps/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      redisSentinelName(r),
+			Namespace: r.Namespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: int32Ptr(defaultRedisSentinelCount),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"deployment": redisSentinelName(r),
+				},
+			},
+			Template: apiv1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"deployment": redisSentinelName(r),
+					},
+				},
+				Spec: apiv1.PodSpec{
+					Volumes: []apiv1.Volume{
+						{
+							// sentinels rewrite their config file at runtime so it can't be mounted from a configmap
+							Name: redisSentinelDataVolumeName,
+							VolumeSource: apiv1.VolumeSource{
+								EmptyDir: &apiv1.EmptyDirVolumeSource{},
+							},
+						},
+					},
+					Containers: []apiv1.Container{
+						{
+							Image:           "registry.redhat.io/rhscl/redis-32-rhel7",
+							ImagePullPolicy: apiv1.PullIfNotPresent,
+							Name:            redisSentinelContainerName,
+							Command:         []string{"/bin/sh", "-c"},
+							Args:            []string{buildRedisSentinelScript(r)},
+							Ports: []apiv1.ContainerPort{
+								{
+									ContainerPort: redisSentinelPort,
+									Protocol:      apiv1.ProtocolTCP,
+								},
+							},
+							Resources: apiv1.ResourceRequirements{
+								Limits: apiv1.ResourceList{
+									apiv1.ResourceCPU:    resource.MustParse("100m"),
+									apiv1.ResourceMemory: resource.MustParse("128Mi"),
+								},
+								Requests: apiv1.ResourceList{
+									apiv1.ResourceCPU:    resource.MustParse("20m"),
+									apiv1.ResourceMemory: resource.MustParse("32Mi"),
+								},
+							},
+							ReadinessProbe: &apiv1.Probe{
+								Handler: apiv1.Handler{
+									Exec: &apiv1.ExecAction{
+										Command: []string{
+											"container-entrypoint",
+											"bash",
+											"-c",
+											fmt.Sprintf("redis-cli -p %d ping | grep PONG", redisSentinelPort),
+										},
+									},
+								},
+								InitialDelaySeconds: 10,
+								PeriodSeconds:       10,
+								TimeoutSeconds:      1,
+							},
+							LivenessProbe: &apiv1.Probe{
+								InitialDelaySeconds: 10,
+								PeriodSeconds:       10,
+								Handler: apiv1.Handler{
+									TCPSocket: &apiv1.TCPSocketAction{
+										Port: intstr.FromInt(redisSentinelPort),
+									},
+								},
+							},
+							VolumeMounts: []apiv1.VolumeMount{
+								{
+									Name:      redisSentinelDataVolumeName,
+									MountPath: "/var/lib/redis/sentinel",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	depl.Spec.Template.Spec.SecurityContext = buildDefaultRedisDeployment(r).Spec.Template.Spec.SecurityContext
+	return depl
+}
+
+// buildRedisSentinelReplicaScript starts redis as a replica of the current primary, redis 3.2 requires the
+// primary ip address to be used rather than a host name
+func buildRedisSentinelReplicaScript(r *v1alpha1.Redis) string {
+	return fmt.Sprintf(`cp /etc/redis.d/%[1]s /tmp/%[1]s
+MASTER=$(redis-cli -h %[2]s -p %[3]d sentinel get-master-addr-by-name %[4]s | head -n 1)
+if [ -z "$MASTER" ] && [ "${HOSTNAME##*-}" != "0" ]; then
+  MASTER=$(getent hosts %[5]s | awk '{ print $1 }')
+fi
+if [ -n "$MASTER" ] && [ "$MASTER" != "$(hostname -i)" ]; then
+  echo "slaveof $MASTER %[6]d" >> /tmp/%[1]s
+fi
+exec %[7]s /tmp/%[1]s --daemonize no
+`, redisConfigMapKey, redisSentinelName(r), redisSentinelPort, redisSentinelMasterName, redisPrimaryHost(r), redisPort, redisContainerCommand)
+}
+
+// buildRedisSentinelScript generates the sentinel config, monitoring the current primary if the sentinels have
+// already elected one, otherwise monitoring the first pod of the statefulset
+func buildRedisSentinelScript(r *v1alpha1.Redis) string {
+	return fmt.Sprintf(`MASTER=$(redis-cli -h %[1]s -p %[2]d sentinel get-master-addr-by-name %[3]s | head -n 1)
+until [ -n "$MASTER" ]; do
+  MASTER=$(getent hosts %[4]s | awk '{ print $1 }')
+  [ -z "$MASTER" ] && sleep 2
+done
+cat > /var/lib/redis/sentinel/sentinel.conf <<EOF
+port %[2]d
+sentinel monitor %[3]s $MASTER %[5]d %[6]d
+sentinel down-after-milliseconds %[3]s 5000
+sentinel failover-timeout %[3]s 60000
+sentinel parallel-syncs %[3]s 1
+EOF
+exec %[7]s /var/lib/redis/sentinel/sentinel.conf --sentinel
+`, redisSentinelName(r), redisSentinelPort, redisSentinelMasterName, redisPrimaryHost(r), redisPort, redisSentinelQuorum, redisContainerCommand)
+}
@@ -0,0 +1,213 @@
+package openshift
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func buildSentinelConfigManager() *ConfigManagerMock {
+	return &ConfigManagerMock{
+		ReadStorageStrategyFunc: func(ctx context.Context, rt providers.ResourceType, tier string) (config *StrategyConfig, e error) {
+			return &StrategyConfig{RawStrategy: []byte(fmt.Sprintf("{\"topology\": \"%s\"}", RedisTopologySentinel))}, nil
+		},
+	}
+}
+
+func buildTestStatefulSet(readyReplicas int32) *appsv1.StatefulSet {
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testRedisName,
+			Namespace: testRedisNamespace,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: int32Ptr(defaultRedisReplicaCount),
+		},
+		Status: appsv1.StatefulSetStatus{
+			ReadyReplicas: readyReplicas,
+		},
+	}
+}
+
+func buildTestSentinelDeploymentReady() *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", testRedisName, redisSentinelNameSuffix),
+			Namespace: testRedisNamespace,
+		},
+		Status: appsv1.DeploymentStatus{
+			Conditions: []appsv1.DeploymentCondition{
+				{
+					Type:   appsv1.DeploymentAvailable,
+					Status: "True",
+				},
+			},
+		},
+	}
+}
+
+func buildTestSentinelRedisCluster() *providers.RedisCluster {
+	return &providers.RedisCluster{DeploymentDetails: &providers.RedisDeploymentDetails{
+		URI:          fmt.Sprintf("%s.%s.svc.cluster.local", testRedisName, testRedisNamespace),
+		Port:         redisPort,
+		SentinelURI:  fmt.Sprintf("%s-%s.%s.svc.cluster.local", testRedisName, redisSentinelNameSuffix, testRedisNamespace),
+		SentinelPort: redisSentinelPort,
+		MasterName:   redisSentinelMasterName,
+	}}
+}
+
+func TestOpenShiftRedisProvider_CreateRedisSentinel(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+
+	type fields struct {
+		Client        client.Client
+		Logger        *logrus.Entry
+		ConfigManager ConfigManager
+	}
+	type args struct {
+		ctx   context.Context
+		redis *v1alpha1.Redis
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    *providers.RedisCluster
+		wantErr bool
+	}{
+		{
+			name: "test sentinel creation in progress",
+			fields: fields{
+				Client:        fake.NewFakeClientWithScheme(scheme, buildTestRedisCR()),
+				Logger:        testLogger,
+				ConfigManager: buildSentinelConfigManager(),
+			},
+			args: args{
+				ctx:   context.TODO(),
+				redis: buildTestRedisCR(),
+			},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name: "test sentinel creation in progress when replicas are not ready",
+			fields: fields{
+				Client:        fake.NewFakeClientWithScheme(scheme, buildTestRedisCR(), buildTestStatefulSet(1), buildTestSentinelDeploymentReady()),
+				Logger:        testLogger,
+				ConfigManager: buildSentinelConfigManager(),
+			},
+			args: args{
+				ctx:   context.TODO(),
+				redis: buildTestRedisCR(),
+			},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name: "test successful sentinel creation with replicas and sentinels ready",
+			fields: fields{
+				Client:        fake.NewFakeClientWithScheme(scheme, buildTestRedisCR(), buildTestStatefulSet(defaultRedisReplicaCount), buildTestSentinelDeploymentReady()),
+				Logger:        testLogger,
+				ConfigManager: buildSentinelConfigManager(),
+			},
+			args: args{
+				ctx:   context.TODO(),
+				redis: buildTestRedisCR(),
+			},
+			want:    buildTestSentinelRedisCluster(),
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &RedisProvider{
+				Client:        tt.fields.Client,
+				Logger:        tt.fields.Logger,
+				ConfigManager: tt.fields.ConfigManager,
+			}
+			got, _, err := p.CreateRedis(tt.args.ctx, tt.args.redis)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CreateRedis() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if (got == nil) != (tt.want == nil) || got != nil && !reflect.DeepEqual(got.DeploymentDetails, tt.want.DeploymentDetails) {
+				t.Errorf("CreateRedis() got = %v, want %v", got, tt.want)
+			}
+			if got != nil && got.Cost == nil {
+				t.Errorf("CreateRedis() expected a cost estimate")
+			}
+			sentinelSvc := &corev1.Service{}
+			if err := tt.fields.Client.Get(tt.args.ctx, types.NamespacedName{Name: redisSentinelName(tt.args.redis), Namespace: testRedisNamespace}, sentinelSvc); err != nil {
+				t.Errorf("CreateRedis() expected sentinel service to exist, got error = %v", err)
+			}
+		})
+	}
+}
+
+func TestOpenShiftRedisProvider_DeleteRedisSentinel(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+
+	type fields struct {
+		Client        client.Client
+		Logger        *logrus.Entry
+		ConfigManager ConfigManager
+	}
+	type args struct {
+		ctx   context.Context
+		redis *v1alpha1.Redis
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "test successful deletion of sentinel objects",
+			fields: fields{
+				Client:        fake.NewFakeClientWithScheme(scheme, buildTestRedisCR(), buildTestStatefulSet(defaultRedisReplicaCount), buildTestSentinelDeploymentReady()),
+				Logger:        testLogger,
+				ConfigManager: buildSentinelConfigManager(),
+			},
+			args: args{
+				ctx:   context.TODO(),
+				redis: buildTestRedisCR(),
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &RedisProvider{
+				Client:        tt.fields.Client,
+				Logger:        tt.fields.Logger,
+				ConfigManager: tt.fields.ConfigManager,
+			}
+			if _, err := p.DeleteRedis(tt.args.ctx, tt.args.redis); (err != nil) != tt.wantErr {
+				t.Errorf("DeleteRedis() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			sts := &appsv1.StatefulSet{}
+			if err := tt.fields.Client.Get(tt.args.ctx, types.NamespacedName{Name: testRedisName, Namespace: testRedisNamespace}, sts); !k8serr.IsNotFound(err) {
+				t.Errorf("DeleteRedis() expected statefulset to be deleted, got error = %v", err)
+			}
+		})
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"testing"
 
 	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -18,10 +20,15 @@ import (
 	"github.com/integr8ly/cloud-resource-operator/apis"
 	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	storagev1 "k8s.io/api/storage/v1"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 
 	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
 	"github.com/sirupsen/logrus"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -37,6 +44,10 @@ func buildTestScheme() (*runtime.Scheme, error) {
 	err := apis.AddToScheme(scheme)
 	err = corev1.AddToScheme(scheme)
 	err = appsv1.AddToScheme(scheme)
+	err = networkingv1.AddToScheme(scheme)
+	err = policyv1beta1.AddToScheme(scheme)
+	err = storagev1.AddToScheme(scheme)
+	err = batchv1.AddToScheme(scheme)
 	if err != nil {
 		return nil, err
 	}
@@ -204,9 +215,12 @@ func TestOpenShiftRedisProvider_CreateRedis(t *testing.T) {
 				t.Errorf("CreateRedis() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if !reflect.DeepEqual(got, tt.want) {
+			if (got == nil) != (tt.want == nil) || got != nil && !reflect.DeepEqual(got.DeploymentDetails, tt.want.DeploymentDetails) {
 				t.Errorf("CreateRedis() got = %v, want %v", got, tt.want)
 			}
+			if got != nil && got.Cost == nil {
+				t.Errorf("CreateRedis() expected a cost estimate")
+			}
 		})
 	}
 }
@@ -261,6 +275,103 @@ func TestOpenShiftRedisProvider_DeleteRedis(t *testing.T) {
 	}
 }
 
+func TestMigrateLegacyRedisConfigMap(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	operatorCM := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: legacyRedisConfigMapName, Namespace: testRedisNamespace}}
+	resources.AddGitOpsAnnotations(operatorCM)
+	baselineCM := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: legacyRedisConfigMapName, Namespace: testRedisNamespace},
+		Data:       map[string]string{redisConfigMapKey: getRedisConfData()},
+	}
+	userCM := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: legacyRedisConfigMapName, Namespace: testRedisNamespace},
+		Data:       map[string]string{redisConfigMapKey: "maxmemory 2mb"},
+	}
+	mountingLegacy := func(name string) *appsv1.Deployment {
+		dpl := buildTestDeploymentReady()
+		dpl.Name = name
+		dpl.Spec.Template.Spec.Volumes = []corev1.Volume{{
+			Name: redisConfigVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: legacyRedisConfigMapName}},
+			},
+		}}
+		return dpl
+	}
+	migrated := buildTestRedisCR()
+	migrated.Status.Conditions = []metav1.Condition{{Type: ConfigMigratedCondition, Status: metav1.ConditionTrue}}
+	tests := []struct {
+		name         string
+		redis        *v1alpha1.Redis
+		objs         []runtime.Object
+		wantDeleted  bool
+		wantMigrated bool
+	}{
+		{
+			name:         "test the legacy config map is deleted once no redis mounts it",
+			redis:        buildTestRedisCR(),
+			objs:         []runtime.Object{operatorCM.DeepCopy(), buildTestDeploymentReady()},
+			wantDeleted:  true,
+			wantMigrated: true,
+		},
+		{
+			name:         "test the legacy config map generated without owner or annotations is deleted once no redis mounts it",
+			redis:        buildTestRedisCR(),
+			objs:         []runtime.Object{baselineCM.DeepCopy(), buildTestDeploymentReady()},
+			wantDeleted:  true,
+			wantMigrated: true,
+		},
+		{
+			name:         "test the legacy config map is kept while another redis mounts it",
+			redis:        buildTestRedisCR(),
+			objs:         []runtime.Object{operatorCM.DeepCopy(), buildTestDeploymentReady(), mountingLegacy("other-redis")},
+			wantMigrated: true,
+		},
+		{
+			name:  "test a redis still mounting the legacy config map isn't migrated",
+			redis: buildTestRedisCR(),
+			objs:  []runtime.Object{operatorCM.DeepCopy(), mountingLegacy(testRedisName)},
+		},
+		{
+			name:         "test a config map of the same name not created by the operator is kept",
+			redis:        buildTestRedisCR(),
+			objs:         []runtime.Object{userCM.DeepCopy(), buildTestDeploymentReady()},
+			wantMigrated: true,
+		},
+		{
+			name:         "test a migrated redis is skipped",
+			redis:        migrated,
+			objs:         []runtime.Object{operatorCM.DeepCopy(), buildTestDeploymentReady()},
+			wantMigrated: true,
+		},
+		{
+			name:         "test a missing legacy config map is ignored",
+			redis:        buildTestRedisCR(),
+			objs:         []runtime.Object{buildTestDeploymentReady()},
+			wantDeleted:  true,
+			wantMigrated: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme, tt.objs...)
+			if err := migrateLegacyRedisConfigMap(context.TODO(), c, tt.redis, testRedisNamespace); err != nil {
+				t.Fatalf("migrateLegacyRedisConfigMap() unexpected error = %v", err)
+			}
+			err := c.Get(context.TODO(), client.ObjectKey{Name: legacyRedisConfigMapName, Namespace: testRedisNamespace}, &corev1.ConfigMap{})
+			if deleted := k8serr.IsNotFound(err); deleted != tt.wantDeleted {
+				t.Errorf("legacy config map error = %v, want deleted %v", err, tt.wantDeleted)
+			}
+			if got := meta.IsStatusConditionTrue(tt.redis.Status.Conditions, ConfigMigratedCondition); got != tt.wantMigrated {
+				t.Errorf("migrateLegacyRedisConfigMap() migrated = %v, want %v", got, tt.wantMigrated)
+			}
+		})
+	}
+}
+
 func TestOpenShiftRedisProvider_GetReconcileTime(t *testing.T) {
 	type args struct {
 		r *v1alpha1.Redis
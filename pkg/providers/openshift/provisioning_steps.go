@@ -0,0 +1,264 @@
+package openshift
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	errorUtil "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Names of the steps creating the workload objects of a resource
+const (
+	SecretStep                = "Secret"
+	ConfigMapStep             = "ConfigMap"
+	PersistentVolumeClaimStep = "PersistentVolumeClaim"
+	DeploymentStep            = "Deployment"
+	ServiceStep               = "Service"
+
+	deploymentProgressDeadlineExceededReason = "ProgressDeadlineExceeded"
+)
+
+// workloadStep creates a workload object of a resource, the objects of a resource are created in order
+type workloadStep struct {
+	name string
+	// obj identifies the object created by the step, it's deleted if the provisioning is rolled back
+	obj runtime.Object
+	// create creates or updates the object
+	create func(ctx context.Context) error
+	// ready returns why the object isn't ready yet, empty once it's ready. A stepFailedError is returned if the object
+	// will never be ready. A step without a ready check is ready once its object is created
+	ready func(ctx context.Context) (string, error)
+}
+
+// stepFailedError is returned by a step that failed for good, retrying it without changes won't succeed
+type stepFailedError struct {
+	msg string
+}
+
+func (e *stepFailedError) Error() string {
+	return e.msg
+}
+
+// reconcileWorkloadSteps creates the objects of the steps in order. While the resource is provisioned, the object of a
+// step is only created once the object of the previous step is ready, the state of each step is recorded in the
+// provisioning job of the resource, and the objects created by the job are deleted if a step fails for good so the
+// next reconcile starts over. Objects of provisioned resources are all created or updated in order on every reconcile
+func reconcileWorkloadSteps(ctx context.Context, c client.Client, logger *logrus.Entry, status *croType.ResourceTypeStatus, steps []workloadStep) (croType.StatusMessage, error) {
+	job := status.Provisioning
+	if job == nil || job.State != croType.ProvisioningStateRunning {
+		for _, step := range steps {
+			if err := step.create(ctx); err != nil {
+				return croType.StatusMessage(fmt.Sprintf("failed to create or update %s", step.name)), err
+			}
+		}
+		return croType.StatusEmpty, nil
+	}
+
+	setStepStates(job, steps)
+	for i, step := range steps {
+		// objects that existed before the job, e.g. of a resource provisioned by an older operator, aren't created by
+		// it and are never rolled back
+		created := false
+		if s := findStep(job, step.name); s != nil && !s.Created {
+			exists, err := objectExists(ctx, c, step.obj)
+			if err != nil {
+				setStepState(job, step.name, croType.ProvisioningStepFailed, err.Error())
+				return croType.StatusMessage(fmt.Sprintf("failed to get %s", step.name)), err
+			}
+			created = !exists
+		}
+		err := step.create(ctx)
+		if err != nil && !isPermanentCreateError(err) {
+			setStepState(job, step.name, croType.ProvisioningStepFailed, err.Error())
+			return croType.StatusMessage(fmt.Sprintf("failed to create or update %s", step.name)), err
+		}
+		if created && err == nil {
+			findStep(job, step.name).Created = true
+		}
+		msg := ""
+		if err == nil && step.ready != nil {
+			msg, err = step.ready(ctx)
+		}
+		var failed *stepFailedError
+		if err != nil && (isPermanentCreateError(err) || errors.As(err, &failed)) {
+			setStepState(job, step.name, croType.ProvisioningStepFailed, err.Error())
+			if rollbackErr := rollbackWorkloadSteps(ctx, c, logger, job, steps[:i+1]); rollbackErr != nil {
+				return croType.StatusMessage(fmt.Sprintf("failed to roll back objects created before %s failed", step.name)), rollbackErr
+			}
+			return croType.StatusMessage(fmt.Sprintf("%s failed, rolled back the objects created before it", step.name)), errorUtil.Wrapf(err, "%s failed", step.name)
+		}
+		if err != nil {
+			setStepState(job, step.name, croType.ProvisioningStepFailed, err.Error())
+			return croType.StatusMessage(fmt.Sprintf("failed to check if %s is ready", step.name)), err
+		}
+		if msg != "" {
+			setStepState(job, step.name, croType.ProvisioningStepWaiting, msg)
+			return croType.StatusMessage(fmt.Sprintf("waiting for %s: %s", step.name, msg)), nil
+		}
+		setStepState(job, step.name, croType.ProvisioningStepReady, "")
+	}
+	return croType.StatusEmpty, nil
+}
+
+// rollbackWorkloadSteps deletes the objects created by the provisioning job in reverse order. Objects the job didn't
+// create are kept, and a bound claim is kept with the objects before it, e.g. the credentials its data was written
+// with, so rolling back never loses data
+func rollbackWorkloadSteps(ctx context.Context, c client.Client, logger *logrus.Entry, job *croType.ProvisioningJob, steps []workloadStep) error {
+	for i := len(steps) - 1; i >= 0; i-- {
+		step := steps[i]
+		s := findStep(job, step.name)
+		if s == nil || !s.Created {
+			continue
+		}
+		bound, err := isBoundClaim(ctx, c, step.obj)
+		if err != nil {
+			return errorUtil.Wrapf(err, "failed to check if %s is bound", step.name)
+		}
+		if bound {
+			logger.Infof("keeping bound %s and the objects created before it", step.name)
+			return nil
+		}
+		logger.Infof("rolling back %s", step.name)
+		if err := deleteObject(ctx, c, step.obj); err != nil && !k8serr.IsNotFound(err) {
+			return errorUtil.Wrapf(err, "failed to delete %s", step.name)
+		}
+		s.Created = false
+		if i < len(steps)-1 {
+			setStepState(job, step.name, croType.ProvisioningStepRolledBack, "")
+		}
+	}
+	return nil
+}
+
+// objectExists returns true if the object is found in the cluster
+func objectExists(ctx context.Context, c client.Client, o runtime.Object) (bool, error) {
+	accessor, err := meta.Accessor(o)
+	if err != nil {
+		return false, errorUtil.Wrap(err, "failed to get metadata of object")
+	}
+	key := types.NamespacedName{Name: accessor.GetName(), Namespace: accessor.GetNamespace()}
+	if err := c.Get(ctx, key, o.DeepCopyObject()); err != nil {
+		if k8serr.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// isBoundClaim returns true if the object is a persistent volume claim bound to a volume, which may hold data
+func isBoundClaim(ctx context.Context, c client.Client, o runtime.Object) (bool, error) {
+	claim, ok := o.(*v1.PersistentVolumeClaim)
+	if !ok {
+		return false, nil
+	}
+	pvc := &v1.PersistentVolumeClaim{}
+	if err := c.Get(ctx, types.NamespacedName{Name: claim.Name, Namespace: claim.Namespace}, pvc); err != nil {
+		if k8serr.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return pvc.Status.Phase == v1.ClaimBound, nil
+}
+
+// isPermanentCreateError returns true for errors rejecting an object, which are returned again until the object is
+// changed
+func isPermanentCreateError(err error) bool {
+	return k8serr.IsInvalid(err) || k8serr.IsForbidden(err) || k8serr.IsBadRequest(err)
+}
+
+// setStepStates lists the steps in the provisioning job, keeping the state of steps already listed
+func setStepStates(job *croType.ProvisioningJob, steps []workloadStep) {
+	existing := map[string]croType.ProvisioningStep{}
+	for _, s := range job.Steps {
+		existing[s.Name] = s
+	}
+	job.Steps = make([]croType.ProvisioningStep, 0, len(steps))
+	for _, step := range steps {
+		s, ok := existing[step.name]
+		if !ok {
+			s = croType.ProvisioningStep{Name: step.name, State: croType.ProvisioningStepPending}
+		}
+		job.Steps = append(job.Steps, s)
+	}
+}
+
+// findStep returns the step of the provisioning job with the name, nil if the job has no such step
+func findStep(job *croType.ProvisioningJob, name string) *croType.ProvisioningStep {
+	for i := range job.Steps {
+		if job.Steps[i].Name == name {
+			return &job.Steps[i]
+		}
+	}
+	return nil
+}
+
+func setStepState(job *croType.ProvisioningJob, name string, state croType.ProvisioningStepState, msg string) {
+	for i := range job.Steps {
+		s := &job.Steps[i]
+		if s.Name != name {
+			continue
+		}
+		if s.State != state {
+			now := metav1.Now()
+			s.LastTransitionTime = &now
+		}
+		s.State = state
+		s.Message = msg
+		return
+	}
+}
+
+// pvcReady returns why the claim isn't ready, a claim is ready once it's bound or if its storage class only binds it
+// once its first consumer is scheduled
+func pvcReady(ctx context.Context, c client.Client, key types.NamespacedName) (string, error) {
+	pvc := &v1.PersistentVolumeClaim{}
+	if err := c.Get(ctx, key, pvc); err != nil {
+		return "", errorUtil.Wrapf(err, "failed to get persistent volume claim %s", key.Name)
+	}
+	switch strings.ToLower(string(pvc.Status.Phase)) {
+	case "bound":
+		return "", nil
+	case "lost":
+		return "", &stepFailedError{msg: fmt.Sprintf("persistent volume claim %s lost its volume", key.Name)}
+	}
+	sc, err := getStorageClass(ctx, c, pvc.Spec.StorageClassName)
+	if err != nil {
+		return "", err
+	}
+	if sc != nil && sc.VolumeBindingMode != nil && *sc.VolumeBindingMode == storagev1.VolumeBindingWaitForFirstConsumer {
+		return "", nil
+	}
+	return fmt.Sprintf("persistent volume claim %s is not bound", key.Name), nil
+}
+
+// deploymentReady returns why the deployment isn't ready, a deployment is ready once it's available
+func deploymentReady(ctx context.Context, c client.Client, key types.NamespacedName) (string, error) {
+	dpl := &appsv1.Deployment{}
+	if err := c.Get(ctx, key, dpl); err != nil {
+		return "", errorUtil.Wrapf(err, "failed to get deployment %s", key.Name)
+	}
+	for _, cond := range dpl.Status.Conditions {
+		if cond.Type == appsv1.DeploymentProgressing && cond.Status == v1.ConditionFalse && cond.Reason == deploymentProgressDeadlineExceededReason {
+			return "", &stepFailedError{msg: fmt.Sprintf("deployment %s exceeded its progress deadline: %s", key.Name, cond.Message)}
+		}
+	}
+	if !deploymentAvailable(dpl) {
+		return fmt.Sprintf("deployment %s is not available", key.Name), nil
+	}
+	return "", nil
+}
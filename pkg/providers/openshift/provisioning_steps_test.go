@@ -0,0 +1,179 @@
+package openshift
+
+import (
+	"context"
+	"testing"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// buildTestWorkloadSteps returns steps creating the postgres secret, pvc, deployment and service of the test postgres
+// as they are in the cluster, so the readiness of the steps is set by the objects passed in
+func buildTestWorkloadSteps(c client.Client, pvc *v1.PersistentVolumeClaim, dpl *appsv1.Deployment) []workloadStep {
+	ps := buildTestPostgresCR()
+	key := types.NamespacedName{Name: ps.Name, Namespace: ps.Namespace}
+	create := func(obj runtime.Object) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			err := c.Create(ctx, obj.DeepCopyObject())
+			if k8serr.IsAlreadyExists(err) {
+				return nil
+			}
+			return err
+		}
+	}
+	sec := buildDefaultPostgresSecret(ps, "user", "password")
+	svc := buildDefaultPostgresService(ps)
+	return []workloadStep{
+		{name: SecretStep, obj: sec, create: create(sec)},
+		{name: PersistentVolumeClaimStep, obj: pvc, create: create(pvc), ready: func(ctx context.Context) (string, error) { return pvcReady(ctx, c, key) }},
+		{name: DeploymentStep, obj: dpl, create: create(dpl), ready: func(ctx context.Context) (string, error) { return deploymentReady(ctx, c, key) }},
+		{name: ServiceStep, obj: svc, create: create(svc)},
+	}
+}
+
+func buildTestRunningStatus() *croType.ResourceTypeStatus {
+	return &croType.ResourceTypeStatus{Provisioning: &croType.ProvisioningJob{Pool: "openshift-postgres", State: croType.ProvisioningStateRunning}}
+}
+
+func TestReconcileWorkloadSteps(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	pendingPVC := buildTestPostgresPVC()
+	pendingPVC.Status.Phase = v1.ClaimPending
+	waitingPVC := pendingPVC.DeepCopy()
+	waitingPVC.Spec.StorageClassName = &[]string{"gp3"}[0]
+	waitForConsumer := storagev1.VolumeBindingWaitForFirstConsumer
+	boundPVC := buildTestPostgresPVC()
+	boundPVC.Status.Phase = v1.ClaimBound
+	failedDpl := buildTestPostgresDeployment()
+	failedDpl.Status.Conditions = []appsv1.DeploymentCondition{{Type: appsv1.DeploymentProgressing, Status: v1.ConditionFalse, Reason: deploymentProgressDeadlineExceededReason}}
+
+	tests := []struct {
+		name       string
+		status     *croType.ResourceTypeStatus
+		existing   []runtime.Object
+		pvc        *v1.PersistentVolumeClaim
+		dpl        *appsv1.Deployment
+		wantMsg    croType.StatusMessage
+		wantErr    bool
+		wantStates []croType.ProvisioningStepState
+		wantExist  map[string]bool
+	}{
+		{
+			name:       "test deployment is not created before the pvc is bound",
+			status:     buildTestRunningStatus(),
+			pvc:        pendingPVC,
+			dpl:        buildTestPostgresDeploymentReady(),
+			wantMsg:    "waiting for PersistentVolumeClaim: persistent volume claim test-postgres is not bound",
+			wantStates: []croType.ProvisioningStepState{croType.ProvisioningStepReady, croType.ProvisioningStepWaiting, croType.ProvisioningStepPending, croType.ProvisioningStepPending},
+			wantExist:  map[string]bool{SecretStep: true, PersistentVolumeClaimStep: true, DeploymentStep: false, ServiceStep: false},
+		},
+		{
+			name:       "test pvc waiting for its first consumer is ready",
+			status:     buildTestRunningStatus(),
+			existing:   []runtime.Object{&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "gp3"}, VolumeBindingMode: &waitForConsumer}},
+			pvc:        waitingPVC,
+			dpl:        buildTestPostgresDeployment(),
+			wantMsg:    "waiting for Deployment: deployment test-postgres is not available",
+			wantStates: []croType.ProvisioningStepState{croType.ProvisioningStepReady, croType.ProvisioningStepReady, croType.ProvisioningStepWaiting, croType.ProvisioningStepPending},
+			wantExist:  map[string]bool{SecretStep: true, PersistentVolumeClaimStep: true, DeploymentStep: true, ServiceStep: false},
+		},
+		{
+			name:       "test every object is created once the previous is ready",
+			status:     buildTestRunningStatus(),
+			pvc:        boundPVC,
+			dpl:        buildTestPostgresDeploymentReady(),
+			wantStates: []croType.ProvisioningStepState{croType.ProvisioningStepReady, croType.ProvisioningStepReady, croType.ProvisioningStepReady, croType.ProvisioningStepReady},
+			wantExist:  map[string]bool{SecretStep: true, PersistentVolumeClaimStep: true, DeploymentStep: true, ServiceStep: true},
+		},
+		{
+			name:       "test objects are rolled back when the deployment fails",
+			status:     buildTestRunningStatus(),
+			existing:   []runtime.Object{&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "gp3"}, VolumeBindingMode: &waitForConsumer}},
+			pvc:        waitingPVC,
+			dpl:        failedDpl,
+			wantMsg:    "Deployment failed, rolled back the objects created before it",
+			wantErr:    true,
+			wantStates: []croType.ProvisioningStepState{croType.ProvisioningStepRolledBack, croType.ProvisioningStepRolledBack, croType.ProvisioningStepFailed, croType.ProvisioningStepPending},
+			wantExist:  map[string]bool{SecretStep: false, PersistentVolumeClaimStep: false, DeploymentStep: false, ServiceStep: false},
+		},
+		{
+			name:       "test a bound pvc and the objects before it are kept when the deployment fails",
+			status:     buildTestRunningStatus(),
+			pvc:        boundPVC,
+			dpl:        failedDpl,
+			wantMsg:    "Deployment failed, rolled back the objects created before it",
+			wantErr:    true,
+			wantStates: []croType.ProvisioningStepState{croType.ProvisioningStepReady, croType.ProvisioningStepReady, croType.ProvisioningStepFailed, croType.ProvisioningStepPending},
+			wantExist:  map[string]bool{SecretStep: true, PersistentVolumeClaimStep: true, DeploymentStep: false, ServiceStep: false},
+		},
+		{
+			name:       "test objects not created by the job are kept when the deployment fails",
+			status:     buildTestRunningStatus(),
+			existing:   []runtime.Object{&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "gp3"}, VolumeBindingMode: &waitForConsumer}, buildDefaultPostgresSecret(buildTestPostgresCR(), "user", "password")},
+			pvc:        waitingPVC,
+			dpl:        failedDpl,
+			wantMsg:    "Deployment failed, rolled back the objects created before it",
+			wantErr:    true,
+			wantStates: []croType.ProvisioningStepState{croType.ProvisioningStepReady, croType.ProvisioningStepRolledBack, croType.ProvisioningStepFailed, croType.ProvisioningStepPending},
+			wantExist:  map[string]bool{SecretStep: true, PersistentVolumeClaimStep: false, DeploymentStep: false, ServiceStep: false},
+		},
+		{
+			name:      "test objects of a provisioned resource are created without waiting",
+			status:    &croType.ResourceTypeStatus{},
+			pvc:       pendingPVC,
+			dpl:       buildTestPostgresDeployment(),
+			wantExist: map[string]bool{SecretStep: true, PersistentVolumeClaimStep: true, DeploymentStep: true, ServiceStep: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme, tt.existing...)
+			steps := buildTestWorkloadSteps(c, tt.pvc, tt.dpl)
+			msg, err := reconcileWorkloadSteps(context.TODO(), c, logrus.NewEntry(logrus.StandardLogger()), tt.status, steps)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("reconcileWorkloadSteps() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if msg != tt.wantMsg {
+				t.Errorf("reconcileWorkloadSteps() msg = %s, want %s", msg, tt.wantMsg)
+			}
+			var states []croType.ProvisioningStepState
+			if tt.status.Provisioning != nil {
+				for _, s := range tt.status.Provisioning.Steps {
+					states = append(states, s.State)
+				}
+			}
+			if len(states) != len(tt.wantStates) {
+				t.Fatalf("reconcileWorkloadSteps() states = %v, want %v", states, tt.wantStates)
+			}
+			for i := range states {
+				if states[i] != tt.wantStates[i] {
+					t.Errorf("reconcileWorkloadSteps() states = %v, want %v", states, tt.wantStates)
+					break
+				}
+			}
+			for _, step := range steps {
+				key, err := client.ObjectKeyFromObject(step.obj)
+				if err != nil {
+					t.Fatal("failed to get object key", err)
+				}
+				err = c.Get(context.TODO(), key, step.obj.DeepCopyObject())
+				if exists := err == nil; exists != tt.wantExist[step.name] {
+					t.Errorf("reconcileWorkloadSteps() %s exists = %v, want %v", step.name, exists, tt.wantExist[step.name])
+				}
+			}
+		})
+	}
+}
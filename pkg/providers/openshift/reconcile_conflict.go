@@ -0,0 +1,200 @@
+package openshift
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources/metrics"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+const (
+	// ReconcileConflictEventReason is the reason of the event recorded on an object another controller keeps reverting
+	ReconcileConflictEventReason = "ReconcileConflict"
+	// unknownFieldManager is reported when the manager of the reverted change isn't recorded on the object
+	unknownFieldManager = "unknown"
+)
+
+var (
+	// ConflictRevertThreshold is the number of reverts of an object within the conflict window at which the operator
+	// stops reverting it, another controller is assumed to be reverting the changes of the operator
+	ConflictRevertThreshold = 3
+	// ConflictWindow is the window the reverts of an object are counted in
+	ConflictWindow = 10 * time.Minute
+	// ConflictBackoff is how long the operator stops updating an object in conflict, doubled each time the conflict
+	// resumes up to maxConflictBackoff
+	ConflictBackoff    = 5 * time.Minute
+	maxConflictBackoff = time.Hour
+)
+
+// objectConflict tracks the reverts of an object and the backoff of updates to it while it's in conflict with another
+// controller
+type objectConflict struct {
+	reverts      []time.Time
+	backoff      time.Duration
+	backoffUntil time.Time
+	// fieldManager is the manager the conflict is reported against, empty if no conflict is reported
+	fieldManager string
+	namespace    string
+	name         string
+	kind         string
+}
+
+var conflicts = struct {
+	sync.Mutex
+	objects map[string]*objectConflict
+}{objects: map[string]*objectConflict{}}
+
+// objectKey returns the key the applied state and conflicts of an object are tracked with
+func objectKey(o runtime.Object) (string, error) {
+	accessor, err := meta.Accessor(o)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%T/%s/%s", o, accessor.GetNamespace(), accessor.GetName()), nil
+}
+
+// conflictBackoffActive returns true while updates of the object are backed off because of a conflict
+func conflictBackoffActive(key string, now time.Time) bool {
+	conflicts.Lock()
+	defer conflicts.Unlock()
+	oc, ok := conflicts.objects[key]
+	return ok && now.Before(oc.backoffUntil)
+}
+
+// recordRevert counts a revert of the object. Once the reverts within the conflict window reach the threshold, the
+// conflict is reported with the field manager of the reverted change, and updates of the object are backed off
+func recordRevert(ctx context.Context, c client.Client, logger *logrus.Entry, applied, existing runtime.Object, now time.Time) {
+	key, err := objectKey(applied)
+	if err != nil {
+		logger.Warnf("failed to track reverts: %v", err)
+		return
+	}
+	accessor, _ := meta.Accessor(applied)
+
+	conflicts.Lock()
+	oc, ok := conflicts.objects[key]
+	if !ok {
+		oc = &objectConflict{namespace: accessor.GetNamespace(), name: accessor.GetName(), kind: fmt.Sprintf("%T", applied)}
+		conflicts.objects[key] = oc
+	}
+	var reverts []time.Time
+	for _, t := range oc.reverts {
+		if now.Sub(t) < ConflictWindow {
+			reverts = append(reverts, t)
+		}
+	}
+	oc.reverts = append(reverts, now)
+	if len(oc.reverts) < ConflictRevertThreshold {
+		conflicts.Unlock()
+		return
+	}
+	oc.reverts = nil
+	oc.backoff *= 2
+	if oc.backoff == 0 {
+		oc.backoff = ConflictBackoff
+	}
+	if oc.backoff > maxConflictBackoff {
+		oc.backoff = maxConflictBackoff
+	}
+	oc.backoffUntil = now.Add(oc.backoff)
+	if oc.fieldManager != "" {
+		metrics.DeleteReconcileConflict(oc.kind, oc.namespace, oc.name, oc.fieldManager)
+	}
+	oc.fieldManager = lastFieldManager(existing)
+	fieldManager := oc.fieldManager
+	msg := fmt.Sprintf("%s %s in namespace %s was reverted %d times in %s, most recently changed by field manager %s, updates are paused for %s", oc.kind, oc.name, oc.namespace, ConflictRevertThreshold, ConflictWindow, oc.fieldManager, oc.backoff)
+	conflicts.Unlock()
+
+	logger.Warn(msg)
+	metrics.SetReconcileConflict(oc.kind, oc.namespace, oc.name, fieldManager)
+	if err := recordConflictEvent(ctx, c, applied, msg, now); err != nil {
+		logger.Warnf("failed to record reconcile conflict event: %v", err)
+	}
+}
+
+// clearConflict stops reporting the conflict of an object once it's left as the operator last applied it
+func clearConflict(logger *logrus.Entry, o runtime.Object) {
+	key, err := objectKey(o)
+	if err != nil {
+		return
+	}
+	conflicts.Lock()
+	defer conflicts.Unlock()
+	oc, ok := conflicts.objects[key]
+	if !ok || oc.fieldManager == "" {
+		return
+	}
+	logger.Infof("%s %s in namespace %s is no longer reverted by field manager %s", oc.kind, oc.name, oc.namespace, oc.fieldManager)
+	metrics.DeleteReconcileConflict(oc.kind, oc.namespace, oc.name, oc.fieldManager)
+	oc.fieldManager = ""
+	oc.backoff = 0
+}
+
+// lastFieldManager returns the manager of the most recent change to the object, from its managed fields
+func lastFieldManager(o runtime.Object) string {
+	accessor, err := meta.Accessor(o)
+	if err != nil {
+		return unknownFieldManager
+	}
+	var latest *metav1.ManagedFieldsEntry
+	fields := accessor.GetManagedFields()
+	for i := range fields {
+		entry := &fields[i]
+		if latest == nil || (entry.Time != nil && (latest.Time == nil || !entry.Time.Before(latest.Time))) {
+			latest = entry
+		}
+	}
+	if latest == nil || latest.Manager == "" {
+		return unknownFieldManager
+	}
+	return latest.Manager
+}
+
+// recordConflictEvent records a warning event on the object in conflict
+func recordConflictEvent(ctx context.Context, c client.Client, o runtime.Object, msg string, now time.Time) error {
+	accessor, err := meta.Accessor(o)
+	if err != nil {
+		return err
+	}
+	gvk, err := apiutil.GVKForObject(o, k8sscheme.Scheme)
+	if err != nil {
+		return err
+	}
+	apiVersion, kind := gvk.ToAPIVersionAndKind()
+	// events of cluster scoped objects, e.g. workload namespaces, are recorded in the default namespace
+	ns := accessor.GetNamespace()
+	if ns == "" {
+		ns = metav1.NamespaceDefault
+	}
+	return c.Create(ctx, &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", accessor.GetName(), now.UnixNano()),
+			Namespace: ns,
+		},
+		InvolvedObject: v1.ObjectReference{
+			APIVersion:      apiVersion,
+			Kind:            kind,
+			Name:            accessor.GetName(),
+			Namespace:       accessor.GetNamespace(),
+			UID:             accessor.GetUID(),
+			ResourceVersion: accessor.GetResourceVersion(),
+		},
+		Reason:         ReconcileConflictEventReason,
+		Message:        msg,
+		Type:           v1.EventTypeWarning,
+		Source:         v1.EventSource{Component: "cloud-resource-operator"},
+		FirstTimestamp: metav1.NewTime(now),
+		LastTimestamp:  metav1.NewTime(now),
+		Count:          1,
+	})
+}
@@ -0,0 +1,112 @@
+package openshift
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources/metrics"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	customMetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+func getConflictMetricValue(t *testing.T, namespace, fieldManager string) float64 {
+	families, err := customMetrics.Registry.Gather()
+	if err != nil {
+		t.Fatal("failed to gather metrics", err)
+	}
+	for _, f := range families {
+		if f.GetName() != metrics.ReconcileConflictMetricName {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["namespace"] == namespace && labels["name"] == "test" && labels["kind"] == "*v1.ConfigMap" && labels["field_manager"] == fieldManager {
+				return m.GetGauge().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func Test_immutableCreateOrUpdate_reconcileConflict(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	ctx := context.TODO()
+	ns := "test-conflict"
+	c := fake.NewFakeClientWithScheme(scheme)
+	applyDesired := func() controllerutil.OperationResult {
+		desired := buildTestRevertConfigMap(ns, "desired")
+		or, err := immutableCreateOrUpdate(ctx, c, testLogger, desired, func(existing runtime.Object) error {
+			existing.(*apiv1.ConfigMap).Data = desired.Data
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("immutableCreateOrUpdate() unexpected error = %v", err)
+		}
+		return or
+	}
+	revertExternally := func() {
+		cm := &apiv1.ConfigMap{}
+		if err := c.Get(ctx, client.ObjectKey{Name: "test", Namespace: ns}, cm); err != nil {
+			t.Fatal("failed to get config map", err)
+		}
+		now := metav1.Now()
+		cm.Data["key"] = "external"
+		cm.ManagedFields = []metav1.ManagedFieldsEntry{
+			{Manager: "cloud-resource-operator", Operation: metav1.ManagedFieldsOperationUpdate, Time: &metav1.Time{Time: now.Add(-time.Minute)}},
+			{Manager: "other-controller", Operation: metav1.ManagedFieldsOperationUpdate, Time: &now},
+		}
+		if err := c.Update(ctx, cm); err != nil {
+			t.Fatal("failed to update config map", err)
+		}
+	}
+
+	applyDesired()
+	for i := 0; i < ConflictRevertThreshold; i++ {
+		revertExternally()
+		if or := applyDesired(); or != controllerutil.OperationResultUpdated {
+			t.Fatalf("immutableCreateOrUpdate() = %v, want the external change reverted", or)
+		}
+	}
+	if value := getConflictMetricValue(t, ns, "other-controller"); value != 1 {
+		t.Errorf("immutableCreateOrUpdate() conflict metric = %v, want 1", value)
+	}
+	events := &apiv1.EventList{}
+	if err := c.List(ctx, events, client.InNamespace(ns)); err != nil {
+		t.Fatal("failed to list events", err)
+	}
+	if len(events.Items) != 1 || events.Items[0].Reason != ReconcileConflictEventReason {
+		t.Errorf("immutableCreateOrUpdate() events = %v, want a %s event", events.Items, ReconcileConflictEventReason)
+	}
+
+	// the change of the other controller is kept while updates are backed off
+	revertExternally()
+	if or := applyDesired(); or != controllerutil.OperationResultNone {
+		t.Errorf("immutableCreateOrUpdate() = %v, want no update during the backoff", or)
+	}
+
+	// once the backoff ends the object is reverted, and the conflict is cleared when it's left alone
+	conflicts.Lock()
+	conflicts.objects["*v1.ConfigMap/"+ns+"/test"].backoffUntil = time.Now().Add(-time.Second)
+	conflicts.Unlock()
+	if or := applyDesired(); or != controllerutil.OperationResultUpdated {
+		t.Errorf("immutableCreateOrUpdate() = %v, want the object reverted after the backoff", or)
+	}
+	if or := applyDesired(); or != controllerutil.OperationResultNone {
+		t.Errorf("immutableCreateOrUpdate() = %v, want no update", or)
+	}
+	if value := getConflictMetricValue(t, ns, "other-controller"); value != 0 {
+		t.Errorf("immutableCreateOrUpdate() conflict metric = %v, want the conflict cleared", value)
+	}
+}
@@ -0,0 +1,205 @@
+package openshift
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// RenderPlaceholder replaces the values generated while provisioning, e.g. passwords, in rendered objects
+const RenderPlaceholder = "<generated>"
+
+// desiredPostgresDeployment returns the postgres deployment with the strategy config applied
+func desiredPostgresDeployment(d *appsv1.Deployment, postgresCfg *PostgresStrat) *appsv1.Deployment {
+	desired := d.DeepCopy()
+	if postgresCfg.PostgresDeploymentSpec != nil {
+		desired.Spec = *postgresCfg.PostgresDeploymentSpec
+	}
+	mergePodExtensions(&desired.Spec.Template.Spec, postgresCfg.PodExtensions)
+	applyWorkloadScheduling(&desired.Spec.Template.Spec, postgresCfg.Scheduling, "")
+	if postgresCfg.TLS {
+		enablePostgresTLS(&desired.Spec.Template.Spec, d.Name)
+	}
+	applyLabelOverrides(desired, postgresCfg.Overrides)
+	applyPodTemplateOverrides(&desired.Spec.Template, d.Name, postgresCfg.Overrides)
+	return desired
+}
+
+// desiredPostgresService returns the postgres service with the strategy config applied
+func desiredPostgresService(s *v1.Service, postgresCfg *PostgresStrat) *v1.Service {
+	desired := s.DeepCopy()
+	if postgresCfg.PostgresServiceSpec != nil {
+		desired.Spec = *postgresCfg.PostgresServiceSpec
+	}
+	if postgresCfg.TLS {
+		enablePostgresServingCert(desired)
+	}
+	applyLabelOverrides(desired, postgresCfg.Overrides)
+	return desired
+}
+
+// desiredPostgresPVC returns the postgres pvc with the strategy config applied
+func desiredPostgresPVC(pvc *v1.PersistentVolumeClaim, postgresCfg *PostgresStrat) *v1.PersistentVolumeClaim {
+	desired := pvc.DeepCopy()
+	applyWorkloadStorage(&desired.Spec, postgresCfg.Storage)
+	applyLabelOverrides(desired, postgresCfg.Overrides)
+	desired.Spec.Resources.Requests = overrideStorageRequests(desired.Spec.Resources.Requests, postgresCfg.Overrides)
+	return desired
+}
+
+// desiredRedisDeployment returns the redis deployment with the strategy config applied
+func desiredRedisDeployment(d *appsv1.Deployment, redisCfg *RedisStrat) *appsv1.Deployment {
+	desired := d.DeepCopy()
+	if redisCfg.RedisDeploymentSpec != nil {
+		desired.Spec = *redisCfg.RedisDeploymentSpec
+	}
+	mergePodExtensions(&desired.Spec.Template.Spec, redisCfg.PodExtensions)
+	applyWorkloadScheduling(&desired.Spec.Template.Spec, redisCfg.Scheduling, "")
+	applyLabelOverrides(desired, redisCfg.Overrides)
+	applyPodTemplateOverrides(&desired.Spec.Template, redisContainerName, redisCfg.Overrides)
+	return desired
+}
+
+// desiredRedisSentinelDeployment returns the redis sentinel deployment with the scheduling of the redis strategy config
+// applied, so the sentinels run on the nodes of the redis pods
+func desiredRedisSentinelDeployment(d *appsv1.Deployment, redisCfg *RedisStrat) *appsv1.Deployment {
+	desired := d.DeepCopy()
+	applyWorkloadScheduling(&desired.Spec.Template.Spec, redisCfg.Scheduling, d.Name)
+	return desired
+}
+
+// desiredRedisService returns the redis service with the strategy config applied
+func desiredRedisService(s *v1.Service, redisCfg *RedisStrat) *v1.Service {
+	desired := s.DeepCopy()
+	if redisCfg.RedisServiceSpec != nil {
+		desired.Spec = *redisCfg.RedisServiceSpec
+	}
+	applyLabelOverrides(desired, redisCfg.Overrides)
+	return desired
+}
+
+// desiredRedisConfigMap returns the redis config map with the strategy config applied
+func desiredRedisConfigMap(cm *v1.ConfigMap, redisCfg *RedisStrat) *v1.ConfigMap {
+	desired := cm.DeepCopy()
+	if redisCfg.RedisConfigMapData != nil {
+		desired.Data = redisCfg.RedisConfigMapData
+	}
+	return desired
+}
+
+// desiredRedisPVC returns the redis pvc with the strategy config applied
+func desiredRedisPVC(pvc *v1.PersistentVolumeClaim, redisCfg *RedisStrat) *v1.PersistentVolumeClaim {
+	desired := pvc.DeepCopy()
+	applyWorkloadStorage(&desired.Spec, redisCfg.Storage)
+	applyLabelOverrides(desired, redisCfg.Overrides)
+	desired.Spec.Resources.Requests = overrideStorageRequests(desired.Spec.Resources.Requests, redisCfg.Overrides)
+	return desired
+}
+
+// RenderPostgres returns the objects created in the cluster for a postgres cr with the strategy config of its tier,
+// without a cluster. Generated credentials are replaced with a placeholder. The pooler and the external access
+// service depend on the state of the cluster and aren't rendered
+func RenderPostgres(ps *v1alpha1.Postgres, stratCfg *StrategyConfig) ([]runtime.Object, error) {
+	postgresCfg := &PostgresStrat{}
+	if err := json.Unmarshal(stratCfg.RawStrategy, postgresCfg); err != nil {
+		return nil, errorUtil.Wrap(err, "failed to unmarshal openshift postgres configuration")
+	}
+	image, err := providers.ResolveVersion(ps.Spec.Version, stratCfg.SupportedVersions, defaultSupportedPostgresVersions)
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to resolve postgres version")
+	}
+	postgresCfg.TLS = postgresCfg.TLS || ps.Spec.TLS
+
+	workload := ps.DeepCopy()
+	workload.Namespace = workloadNamespace(ps, &ps.Status, postgresCfg.Isolation)
+
+	pvc := buildDefaultPostgresPVC(workload)
+	if ps.Spec.Version != "" {
+		pvc.Annotations = map[string]string{postgresVersionAnnotation: ps.Spec.Version}
+	}
+	// secrets are rendered with string data so they can be reviewed
+	sec := buildDefaultPostgresSecret(workload, RenderPlaceholder, RenderPlaceholder)
+	sec.StringData = map[string]string{}
+	for k, v := range sec.Data {
+		sec.StringData[k] = string(v)
+	}
+	sec.Data = nil
+	if postgresCfg.PostgresSecretData != nil {
+		sec.StringData = postgresCfg.PostgresSecretData
+	}
+	dpl := buildDefaultPostgresDeployment(workload)
+	if image != "" {
+		dpl.Spec.Template.Spec.Containers[0].Image = image
+	}
+	return renderObjects(
+		desiredPostgresPVC(pvc, postgresCfg),
+		sec,
+		desiredPostgresDeployment(dpl, postgresCfg),
+		desiredPostgresService(buildDefaultPostgresService(workload), postgresCfg),
+	)
+}
+
+// RenderRedis returns the objects created in the cluster for a redis cr with the strategy config of its tier, without
+// a cluster. The external access service depends on the state of the cluster and isn't rendered
+func RenderRedis(r *v1alpha1.Redis, stratCfg *StrategyConfig) ([]runtime.Object, error) {
+	redisCfg := &RedisStrat{}
+	if err := json.Unmarshal(stratCfg.RawStrategy, redisCfg); err != nil {
+		return nil, errorUtil.Wrap(err, "failed to unmarshal openshift redis cluster configuration")
+	}
+
+	workload := r.DeepCopy()
+	workload.Namespace = workloadNamespace(r, &r.Status, redisCfg.Isolation)
+
+	if redisCfg.Topology == RedisTopologySentinel {
+		return renderObjects(
+			desiredRedisConfigMap(buildDefaultRedisConfigMap(workload), redisCfg),
+			desiredRedisService(buildDefaultRedisHeadlessService(workload), &RedisStrat{}),
+			desiredRedisService(buildDefaultRedisSentinelService(workload), &RedisStrat{}),
+			buildDefaultRedisStatefulSet(workload, redisCfg),
+			desiredRedisDeployment(desiredRedisSentinelDeployment(buildDefaultRedisSentinelDeployment(workload), redisCfg), &RedisStrat{}),
+		)
+	}
+	return renderObjects(
+		desiredRedisPVC(buildDefaultRedisPVC(workload), redisCfg),
+		desiredRedisConfigMap(buildDefaultRedisConfigMap(workload), redisCfg),
+		desiredRedisDeployment(buildDefaultRedisDeployment(workload), redisCfg),
+		desiredRedisService(buildDefaultRedisService(workload), redisCfg),
+	)
+}
+
+// renderObjects sets the kind and gitops annotations of the objects as they're created in the cluster and sorts them
+// by kind and name, so rendering the same cr and strategy config always gives the same output
+func renderObjects(objs ...runtime.Object) ([]runtime.Object, error) {
+	for _, obj := range objs {
+		gvk, err := apiutil.GVKForObject(obj, k8sscheme.Scheme)
+		if err != nil {
+			return nil, errorUtil.Wrap(err, "failed to get kind of rendered object")
+		}
+		obj.GetObjectKind().SetGroupVersionKind(gvk)
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, errorUtil.Wrap(err, "failed to get metadata of rendered object")
+		}
+		resources.AddGitOpsAnnotations(accessor)
+	}
+	sort.SliceStable(objs, func(i, j int) bool {
+		return renderSortKey(objs[i]) < renderSortKey(objs[j])
+	})
+	return objs, nil
+}
+
+func renderSortKey(obj runtime.Object) string {
+	accessor, _ := meta.Accessor(obj)
+	return fmt.Sprintf("%s/%s/%s", obj.GetObjectKind().GroupVersionKind().Kind, accessor.GetNamespace(), accessor.GetName())
+}
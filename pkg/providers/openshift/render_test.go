@@ -0,0 +1,123 @@
+package openshift
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func renderedKinds(t *testing.T, objs []runtime.Object) []string {
+	var kinds []string
+	for _, obj := range objs {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			t.Fatalf("unexpected error getting metadata of rendered object: %v", err)
+		}
+		for k, v := range resources.GitOpsAnnotations() {
+			if accessor.GetAnnotations()[k] != v {
+				t.Errorf("rendered %s is missing gitops annotation %s", accessor.GetName(), k)
+			}
+		}
+		kinds = append(kinds, fmt.Sprintf("%s/%s", obj.GetObjectKind().GroupVersionKind().Kind, accessor.GetName()))
+	}
+	return kinds
+}
+
+func TestRenderPostgres(t *testing.T) {
+	ps := buildTestPostgresCR()
+	ps.Spec.Version = "12"
+	stratCfg := &StrategyConfig{RawStrategy: []byte(`{"tls": true, "secretData": {"user": "admin"}}`)}
+
+	objs, err := RenderPostgres(ps, stratCfg)
+	if err != nil {
+		t.Fatalf("RenderPostgres() unexpected error = %v", err)
+	}
+	want := []string{
+		fmt.Sprintf("Deployment/%s", testPostgresName),
+		fmt.Sprintf("PersistentVolumeClaim/%s", testPostgresName),
+		fmt.Sprintf("Secret/%s", postgresCredentialsSecretName(testPostgresName)),
+		fmt.Sprintf("Service/%s", testPostgresName),
+	}
+	if got := renderedKinds(t, objs); !reflect.DeepEqual(got, want) {
+		t.Fatalf("RenderPostgres() = %v, want %v", got, want)
+	}
+	if image := objs[0].(*appsv1.Deployment).Spec.Template.Spec.Containers[0].Image; image != defaultSupportedPostgresVersions["12"] {
+		t.Errorf("RenderPostgres() deployment image = %s, want the image of the requested version", image)
+	}
+	if _, ok := objs[3].(*v1.Service).Annotations[servingCertSecretAnnotation]; !ok {
+		t.Errorf("RenderPostgres() service isn't annotated for a serving certificate")
+	}
+	if sec := objs[2].(*v1.Secret); sec.StringData["user"] != "admin" || len(sec.Data) != 0 {
+		t.Errorf("RenderPostgres() secret = %v, want the secret data of the strategy", sec.StringData)
+	}
+
+	again, err := RenderPostgres(ps, stratCfg)
+	if err != nil {
+		t.Fatalf("RenderPostgres() unexpected error = %v", err)
+	}
+	if !reflect.DeepEqual(objs, again) {
+		t.Errorf("RenderPostgres() isn't deterministic")
+	}
+}
+
+func TestRenderRedis(t *testing.T) {
+	tests := []struct {
+		name             string
+		strategy         string
+		want             []string
+		wantNodeSelector map[string]string
+	}{
+		{
+			name:     "test standalone topology",
+			strategy: "{}",
+			want: []string{
+				fmt.Sprintf("ConfigMap/%s", redisConfigMapName(testRedisName)),
+				fmt.Sprintf("Deployment/%s", testRedisName),
+				fmt.Sprintf("PersistentVolumeClaim/%s", testRedisName),
+				fmt.Sprintf("Service/%s", testRedisName),
+			},
+		},
+		{
+			name:     "test sentinel topology",
+			strategy: fmt.Sprintf(`{"topology": "%s", "scheduling": {"nodeSelector": {"node-role": "redis"}}}`, RedisTopologySentinel),
+			want: []string{
+				fmt.Sprintf("ConfigMap/%s", redisConfigMapName(testRedisName)),
+				fmt.Sprintf("Deployment/%s-sentinel", testRedisName),
+				fmt.Sprintf("Service/%s", testRedisName),
+				fmt.Sprintf("Service/%s-sentinel", testRedisName),
+				fmt.Sprintf("StatefulSet/%s", testRedisName),
+			},
+			wantNodeSelector: map[string]string{"node-role": "redis"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs, err := RenderRedis(buildTestRedisCR(), &StrategyConfig{RawStrategy: []byte(tt.strategy)})
+			if err != nil {
+				t.Fatalf("RenderRedis() unexpected error = %v", err)
+			}
+			if got := renderedKinds(t, objs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RenderRedis() = %v, want %v", got, tt.want)
+			}
+			// the sentinels are scheduled on the nodes of the redis pods
+			for _, obj := range objs {
+				dpl, ok := obj.(*appsv1.Deployment)
+				if !ok || dpl.Name != redisSentinelName(buildTestRedisCR()) {
+					continue
+				}
+				if !reflect.DeepEqual(dpl.Spec.Template.Spec.NodeSelector, tt.wantNodeSelector) {
+					t.Errorf("RenderRedis() sentinel node selector = %v, want %v", dpl.Spec.Template.Spec.NodeSelector, tt.wantNodeSelector)
+				}
+				if dpl.Spec.Template.Spec.Affinity == nil || dpl.Spec.Template.Spec.Affinity.PodAntiAffinity == nil {
+					t.Errorf("RenderRedis() sentinel isn't spread across nodes")
+				}
+			}
+		})
+	}
+}
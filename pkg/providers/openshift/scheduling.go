@@ -0,0 +1,120 @@
+package openshift
+
+import (
+	"context"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// WorkloadScheduling places the pods of an in-cluster workload on nodes, e.g. pinning them to infra nodes, and keeps
+// them running through voluntary disruptions such as the node drains of a cluster upgrade
+type WorkloadScheduling struct {
+	// NodeSelector restricts the pods to the nodes with the labels
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Tolerations allow the pods on nodes with matching taints
+	Tolerations []v1.Toleration `json:"tolerations,omitempty"`
+	// Affinity of the pods, the replicas and sentinels of the sentinel topology prefer to be spread across nodes if unset
+	Affinity *v1.Affinity `json:"affinity,omitempty"`
+	// DisruptionBudget creates a pod disruption budget for the pods, no budget is created if unset
+	DisruptionBudget *WorkloadDisruptionBudget `json:"disruptionBudget,omitempty"`
+}
+
+// WorkloadDisruptionBudget limits the pods of a workload evicted at once. A budget without minAvailable or
+// maxUnavailable keeps a single replica workload from being evicted, and allows one replica of a replicated workload
+// to be evicted at a time
+type WorkloadDisruptionBudget struct {
+	// MinAvailable is the number or percentage of pods that must stay available
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+	// MaxUnavailable is the number or percentage of pods that may be evicted at once
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// validateWorkloadScheduling returns an error if the disruption budget of the scheduling sets both minAvailable and
+// maxUnavailable, which a pod disruption budget doesn't allow
+func validateWorkloadScheduling(scheduling *WorkloadScheduling) error {
+	if scheduling == nil || scheduling.DisruptionBudget == nil {
+		return nil
+	}
+	if scheduling.DisruptionBudget.MinAvailable != nil && scheduling.DisruptionBudget.MaxUnavailable != nil {
+		return errorUtil.New("disruption budget must set only one of minAvailable and maxUnavailable")
+	}
+	return nil
+}
+
+// applyWorkloadScheduling sets the node selector, tolerations and affinity of the scheduling on the pod spec. the pods
+// of a replicated deployment prefer nodes not running another of its pods unless the scheduling sets an affinity
+func applyWorkloadScheduling(spec *v1.PodSpec, scheduling *WorkloadScheduling, replicatedDeployment string) {
+	if scheduling == nil {
+		return
+	}
+	if scheduling.NodeSelector != nil {
+		spec.NodeSelector = scheduling.NodeSelector
+	}
+	if scheduling.Tolerations != nil {
+		spec.Tolerations = scheduling.Tolerations
+	}
+	if scheduling.Affinity != nil {
+		spec.Affinity = scheduling.Affinity
+		return
+	}
+	if replicatedDeployment != "" {
+		spec.Affinity = &v1.Affinity{PodAntiAffinity: &v1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []v1.WeightedPodAffinityTerm{{
+				Weight: 100,
+				PodAffinityTerm: v1.PodAffinityTerm{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"deployment": replicatedDeployment}},
+					TopologyKey:   v1.LabelHostname,
+				},
+			}},
+		}}
+	}
+}
+
+// reconcileWorkloadDisruptionBudget creates or updates the pod disruption budget of the pods of the deployment, or
+// deletes it when the scheduling has no disruption budget. replicated workloads default to one pod evicted at a time
+func reconcileWorkloadDisruptionBudget(ctx context.Context, c client.Client, logger *logrus.Entry, name, ns string, replicated bool, scheduling *WorkloadScheduling) error {
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+		},
+	}
+	if scheduling == nil || scheduling.DisruptionBudget == nil {
+		if err := c.Delete(ctx, pdb); err != nil && !k8serr.IsNotFound(err) {
+			return errorUtil.Wrapf(err, "failed to delete pod disruption budget %s", name)
+		}
+		return nil
+	}
+
+	budget := scheduling.DisruptionBudget
+	minAvailable, maxUnavailable := budget.MinAvailable, budget.MaxUnavailable
+	if minAvailable == nil && maxUnavailable == nil {
+		one := intstr.FromInt(1)
+		if replicated {
+			maxUnavailable = &one
+		} else {
+			minAvailable = &one
+		}
+	}
+	or, err := controllerutil.CreateOrUpdate(ctx, c, pdb, func() error {
+		resources.AddGitOpsAnnotations(pdb)
+		pdb.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"deployment": name}}
+		pdb.Spec.MinAvailable = minAvailable
+		pdb.Spec.MaxUnavailable = maxUnavailable
+		return resources.ApplyMetadata(ctx, c, pdb)
+	})
+	if err != nil {
+		return errorUtil.Wrapf(err, "failed to create or update pod disruption budget %s, action was %s", name, or)
+	}
+	logger.Debugf("pod disruption budget %s %s", name, or)
+	return nil
+}
@@ -0,0 +1,165 @@
+package openshift
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestValidateWorkloadScheduling(t *testing.T) {
+	one := intstr.FromInt(1)
+	tests := []struct {
+		name       string
+		scheduling *WorkloadScheduling
+		wantErr    bool
+	}{
+		{
+			name:       "test no scheduling is valid",
+			scheduling: nil,
+		},
+		{
+			name:       "test a budget with defaults is valid",
+			scheduling: &WorkloadScheduling{DisruptionBudget: &WorkloadDisruptionBudget{}},
+		},
+		{
+			name:       "test a budget with min available and max unavailable is invalid",
+			scheduling: &WorkloadScheduling{DisruptionBudget: &WorkloadDisruptionBudget{MinAvailable: &one, MaxUnavailable: &one}},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateWorkloadScheduling(tt.scheduling); (err != nil) != tt.wantErr {
+				t.Errorf("validateWorkloadScheduling() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyWorkloadScheduling(t *testing.T) {
+	infra := &WorkloadScheduling{
+		NodeSelector: map[string]string{"node-role.kubernetes.io/infra": ""},
+		Tolerations:  []v1.Toleration{{Key: "node-role.kubernetes.io/infra", Effect: v1.TaintEffectNoSchedule}},
+	}
+	tests := []struct {
+		name         string
+		scheduling   *WorkloadScheduling
+		replicated   string
+		wantSelector bool
+		wantSpread   bool
+	}{
+		{
+			name:       "test no scheduling leaves the pod unchanged",
+			scheduling: nil,
+			replicated: "test",
+		},
+		{
+			name:         "test a single pod is pinned to nodes",
+			scheduling:   infra,
+			wantSelector: true,
+		},
+		{
+			name:         "test the pods of a replicated deployment are spread across nodes",
+			scheduling:   infra,
+			replicated:   "test",
+			wantSelector: true,
+			wantSpread:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &v1.PodSpec{}
+			applyWorkloadScheduling(spec, tt.scheduling, tt.replicated)
+			if got := spec.NodeSelector != nil && len(spec.Tolerations) == 1; got != tt.wantSelector {
+				t.Errorf("applyWorkloadScheduling() node selector = %v, tolerations = %v", spec.NodeSelector, spec.Tolerations)
+			}
+			if got := spec.Affinity != nil && spec.Affinity.PodAntiAffinity != nil; got != tt.wantSpread {
+				t.Errorf("applyWorkloadScheduling() affinity = %v, want spread %v", spec.Affinity, tt.wantSpread)
+			}
+		})
+	}
+
+	// an affinity of the scheduling replaces the spread of the replicas
+	affinity := &v1.Affinity{NodeAffinity: &v1.NodeAffinity{}}
+	spec := &v1.PodSpec{}
+	applyWorkloadScheduling(spec, &WorkloadScheduling{Affinity: affinity}, "test")
+	if spec.Affinity != affinity {
+		t.Errorf("applyWorkloadScheduling() affinity = %v, want the affinity of the scheduling", spec.Affinity)
+	}
+}
+
+func TestReconcileWorkloadDisruptionBudget(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	two := intstr.FromInt(2)
+	key := types.NamespacedName{Name: "test", Namespace: "test-ns"}
+	tests := []struct {
+		name               string
+		budget             *WorkloadDisruptionBudget
+		replicated         bool
+		wantMinAvailable   *intstr.IntOrString
+		wantMaxUnavailable *intstr.IntOrString
+	}{
+		{
+			name:             "test a single pod isn't evicted by default",
+			budget:           &WorkloadDisruptionBudget{},
+			wantMinAvailable: intstrPtr(intstr.FromInt(1)),
+		},
+		{
+			name:               "test one replica is evicted at a time by default",
+			budget:             &WorkloadDisruptionBudget{},
+			replicated:         true,
+			wantMaxUnavailable: intstrPtr(intstr.FromInt(1)),
+		},
+		{
+			name:             "test the configured budget is kept",
+			budget:           &WorkloadDisruptionBudget{MinAvailable: &two},
+			replicated:       true,
+			wantMinAvailable: &two,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme)
+			if err := reconcileWorkloadDisruptionBudget(context.TODO(), c, testLogger, key.Name, key.Namespace, tt.replicated, &WorkloadScheduling{DisruptionBudget: tt.budget}); err != nil {
+				t.Fatalf("reconcileWorkloadDisruptionBudget() unexpected error = %v", err)
+			}
+			pdb := &policyv1beta1.PodDisruptionBudget{}
+			if err := c.Get(context.TODO(), key, pdb); err != nil {
+				t.Fatalf("failed to get pod disruption budget: %v", err)
+			}
+			if pdb.Spec.Selector.MatchLabels["deployment"] != key.Name {
+				t.Errorf("pod disruption budget selector = %v, want the pods of deployment %s", pdb.Spec.Selector, key.Name)
+			}
+			if !intstrEqual(pdb.Spec.MinAvailable, tt.wantMinAvailable) || !intstrEqual(pdb.Spec.MaxUnavailable, tt.wantMaxUnavailable) {
+				t.Errorf("pod disruption budget = %v, want min available %v and max unavailable %v", pdb.Spec, tt.wantMinAvailable, tt.wantMaxUnavailable)
+			}
+
+			if err := reconcileWorkloadDisruptionBudget(context.TODO(), c, testLogger, key.Name, key.Namespace, tt.replicated, &WorkloadScheduling{}); err != nil {
+				t.Fatalf("reconcileWorkloadDisruptionBudget() unexpected error deleting the budget = %v", err)
+			}
+			if err := c.Get(context.TODO(), key, pdb); !k8serr.IsNotFound(err) {
+				t.Errorf("pod disruption budget error = %v, want the budget deleted without a budget", err)
+			}
+		})
+	}
+}
+
+func intstrPtr(v intstr.IntOrString) *intstr.IntOrString {
+	return &v
+}
+
+func intstrEqual(a, b *intstr.IntOrString) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,70 @@
+package openshift
+
+import (
+	"context"
+
+	errorUtil "github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WorkloadStorage selects the volume claimed by an in-cluster workload instead of a volume of the default storage
+// class of the cluster. Claims can't be changed once they're created, so the storage only applies to new claims
+type WorkloadStorage struct {
+	// StorageClassName is the storage class of the claim, defaults to the default storage class of the cluster
+	StorageClassName string `json:"storageClassName,omitempty"`
+	// VolumeMode of the claim, only Filesystem is supported as the volume is mounted as the data directory
+	VolumeMode *v1.PersistentVolumeMode `json:"volumeMode,omitempty"`
+	// AccessModes of the claim, they must include ReadWriteOnce, defaults to ReadWriteOnce
+	AccessModes []v1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+}
+
+// validateWorkloadStorage returns an error if the storage can't hold the data of a workload, the storage class must
+// exist and the volume must be a file system written by a single pod
+func validateWorkloadStorage(ctx context.Context, c client.Client, storage *WorkloadStorage) error {
+	if storage == nil {
+		return nil
+	}
+	if storage.VolumeMode != nil && *storage.VolumeMode != v1.PersistentVolumeFilesystem {
+		return errorUtil.Errorf("volume mode %s is not supported, the volume is mounted as a file system", *storage.VolumeMode)
+	}
+	if len(storage.AccessModes) > 0 && !hasAccessMode(storage.AccessModes, v1.ReadWriteOnce) {
+		return errorUtil.Errorf("access modes %v must include %s, the volume is written by a single pod", storage.AccessModes, v1.ReadWriteOnce)
+	}
+	if storage.StorageClassName != "" {
+		sc, err := getStorageClass(ctx, c, &storage.StorageClassName)
+		if err != nil {
+			return err
+		}
+		if sc == nil {
+			return errorUtil.Errorf("storage class %s not found", storage.StorageClassName)
+		}
+	}
+	return nil
+}
+
+// applyWorkloadStorage sets the storage class, volume mode and access modes of the storage on the claim spec
+func applyWorkloadStorage(spec *v1.PersistentVolumeClaimSpec, storage *WorkloadStorage) {
+	if storage == nil {
+		return
+	}
+	if storage.StorageClassName != "" {
+		storageClassName := storage.StorageClassName
+		spec.StorageClassName = &storageClassName
+	}
+	if storage.VolumeMode != nil {
+		spec.VolumeMode = storage.VolumeMode
+	}
+	if len(storage.AccessModes) > 0 {
+		spec.AccessModes = storage.AccessModes
+	}
+}
+
+func hasAccessMode(modes []v1.PersistentVolumeAccessMode, mode v1.PersistentVolumeAccessMode) bool {
+	for _, m := range modes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
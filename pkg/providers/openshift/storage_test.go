@@ -0,0 +1,152 @@
+package openshift
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestValidateWorkloadStorage(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	block := v1.PersistentVolumeBlock
+	filesystem := v1.PersistentVolumeFilesystem
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		storage  *WorkloadStorage
+		wantErr  bool
+	}{
+		{
+			name:    "test no storage is valid",
+			storage: nil,
+		},
+		{
+			name:     "test existing storage class with a file system is valid",
+			existing: []runtime.Object{buildTestStorageClass("gp3", true, false)},
+			storage:  &WorkloadStorage{StorageClassName: "gp3", VolumeMode: &filesystem, AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce}},
+		},
+		{
+			name:    "test access modes including read write once are valid",
+			storage: &WorkloadStorage{AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce, v1.ReadWriteMany}},
+		},
+		{
+			name:    "test missing storage class is invalid",
+			storage: &WorkloadStorage{StorageClassName: "gp3"},
+			wantErr: true,
+		},
+		{
+			name:    "test block volume mode is invalid",
+			storage: &WorkloadStorage{VolumeMode: &block},
+			wantErr: true,
+		},
+		{
+			name:    "test access modes without read write once are invalid",
+			storage: &WorkloadStorage{AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadOnlyMany}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme, tt.existing...)
+			if err := validateWorkloadStorage(context.TODO(), c, tt.storage); (err != nil) != tt.wantErr {
+				t.Errorf("validateWorkloadStorage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPostgresProvider_CreatePVC_Storage(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	storageClassName := "gp3"
+	filesystem := v1.PersistentVolumeFilesystem
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		storage  *WorkloadStorage
+		want     v1.PersistentVolumeClaimSpec
+	}{
+		{
+			name:    "test new pvc uses the storage of the tier",
+			storage: &WorkloadStorage{StorageClassName: storageClassName, VolumeMode: &filesystem, AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce, v1.ReadWriteMany}},
+			want: v1.PersistentVolumeClaimSpec{
+				StorageClassName: &storageClassName,
+				VolumeMode:       &filesystem,
+				AccessModes:      []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce, v1.ReadWriteMany},
+				Resources:        v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse("1Gi")}},
+			},
+		},
+		{
+			name:     "test existing pvc keeps its storage class",
+			existing: []runtime.Object{buildTestBoundPostgresPVC("gp2", "1Gi")},
+			storage:  &WorkloadStorage{StorageClassName: storageClassName},
+			want:     buildTestBoundPostgresPVC("gp2", "1Gi").Spec,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme, tt.existing...)
+			p := &PostgresProvider{Client: c, Logger: testLogger}
+			pvc := buildDefaultPostgresPVC(buildTestPostgresCR())
+			if err := p.CreatePVC(context.TODO(), pvc, &PostgresStrat{Storage: tt.storage}); err != nil {
+				t.Fatalf("CreatePVC() unexpected error = %v", err)
+			}
+			got := &v1.PersistentVolumeClaim{}
+			if err := c.Get(context.TODO(), client.ObjectKey{Name: pvc.Name, Namespace: pvc.Namespace}, got); err != nil {
+				t.Fatal("failed to get pvc", err)
+			}
+			if !reflect.DeepEqual(got.Spec, tt.want) {
+				t.Errorf("CreatePVC() spec = %+v, want %+v", got.Spec, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedisProvider_CreatePVC_Expansion(t *testing.T) {
+	scheme, err := buildTestScheme()
+	if err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	buildBoundRedisPVC := func(storageClass string) *v1.PersistentVolumeClaim {
+		pvc := buildDefaultRedisPVC(buildTestRedisCR())
+		pvc.Spec.StorageClassName = &storageClass
+		pvc.Status.Phase = v1.ClaimBound
+		return pvc
+	}
+	storageSize := resource.MustParse("5Gi")
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		wantErr  bool
+	}{
+		{
+			name:     "test pvc is expanded when the storage class allows it",
+			existing: []runtime.Object{buildBoundRedisPVC("gp2"), buildTestStorageClass("gp2", true, false)},
+		},
+		{
+			name:     "test error when the storage class doesn't allow expansion",
+			existing: []runtime.Object{buildBoundRedisPVC("local"), buildTestStorageClass("local", false, false)},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &RedisProvider{Client: fake.NewFakeClientWithScheme(scheme, tt.existing...), Logger: testLogger}
+			err := p.CreatePVC(context.TODO(), buildDefaultRedisPVC(buildTestRedisCR()), &RedisStrat{Overrides: &WorkloadOverrides{StorageSize: &storageSize}})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CreatePVC() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package openshift
+
+import (
+	"context"
+
+	errorUtil "github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// csi volume snapshots are read and written as unstructured objects, the external snapshotter api isn't a dependency of
+// the operator and is only installed on clusters with a csi snapshot controller
+const (
+	volumeSnapshotGroup = "snapshot.storage.k8s.io"
+	volumeSnapshotKind  = "VolumeSnapshot"
+)
+
+var volumeSnapshotGVK = schema.GroupVersionKind{Group: volumeSnapshotGroup, Version: "v1", Kind: volumeSnapshotKind}
+
+// newVolumeSnapshot returns an empty volume snapshot with the name and namespace
+func newVolumeSnapshot(name, namespace string) *unstructured.Unstructured {
+	vs := &unstructured.Unstructured{}
+	vs.SetGroupVersionKind(volumeSnapshotGVK)
+	vs.SetName(name)
+	vs.SetNamespace(namespace)
+	return vs
+}
+
+// buildVolumeSnapshot returns a volume snapshot of the pvc, using the default volume snapshot class of the csi driver
+// if no class is given
+func buildVolumeSnapshot(name, namespace, pvcName, className string) *unstructured.Unstructured {
+	vs := newVolumeSnapshot(name, namespace)
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{
+			"persistentVolumeClaimName": pvcName,
+		},
+	}
+	if className != "" {
+		spec["volumeSnapshotClassName"] = className
+	}
+	vs.Object["spec"] = spec
+	return vs
+}
+
+// getVolumeSnapshot returns the volume snapshot, nil if it doesn't exist
+func getVolumeSnapshot(ctx context.Context, c client.Client, name, namespace string) (*unstructured.Unstructured, error) {
+	vs := newVolumeSnapshot(name, namespace)
+	if err := c.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, vs); err != nil {
+		if k8serr.IsNotFound(err) {
+			return nil, nil
+		}
+		if meta.IsNoMatchError(err) {
+			return nil, errorUtil.Wrap(err, "volume snapshots aren't available, a csi snapshot controller must be installed")
+		}
+		return nil, errorUtil.Wrapf(err, "failed to get volume snapshot %s", name)
+	}
+	return vs, nil
+}
+
+// volumeSnapshotTaken returns true once the point in time of the snapshot has been cut, the volume can be written to
+// from then on even if the snapshot isn't ready to use yet
+func volumeSnapshotTaken(vs *unstructured.Unstructured) bool {
+	creationTime, _, _ := unstructured.NestedString(vs.Object, "status", "creationTime")
+	return creationTime != "" || volumeSnapshotReady(vs)
+}
+
+// volumeSnapshotReady returns true once the snapshot can be restored from
+func volumeSnapshotReady(vs *unstructured.Unstructured) bool {
+	ready, _, _ := unstructured.NestedBool(vs.Object, "status", "readyToUse")
+	return ready
+}
+
+// volumeSnapshotError returns the error the csi driver reported for the snapshot, empty if there's none
+func volumeSnapshotError(vs *unstructured.Unstructured) string {
+	msg, _, _ := unstructured.NestedString(vs.Object, "status", "error", "message")
+	return msg
+}
+
+// volumeSnapshotRestoreSize returns the minimum size of a volume restored from the snapshot, if the csi driver reported
+// it
+func volumeSnapshotRestoreSize(vs *unstructured.Unstructured) (resource.Quantity, bool) {
+	size, found, _ := unstructured.NestedString(vs.Object, "status", "restoreSize")
+	if !found || size == "" {
+		return resource.Quantity{}, false
+	}
+	q, err := resource.ParseQuantity(size)
+	if err != nil {
+		return resource.Quantity{}, false
+	}
+	return q, true
+}
+
+// volumeSnapshotDataSource returns the data source restoring a pvc from the volume snapshot
+func volumeSnapshotDataSource(name string) *v1.TypedLocalObjectReference {
+	group := volumeSnapshotGroup
+	return &v1.TypedLocalObjectReference{
+		APIGroup: &group,
+		Kind:     volumeSnapshotKind,
+		Name:     name,
+	}
+}
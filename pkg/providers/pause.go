@@ -0,0 +1,30 @@
+package providers
+
+import (
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+const (
+	// StoppedCondition is the condition reporting if the cloud resource of a paused or hibernated resource is stopped,
+	// it's only set once the resource was paused or hibernated
+	StoppedCondition = "Stopped"
+	// StoppedReason is the reason of a true stopped condition
+	StoppedReason = "Stopped"
+	// StoppingReason is the reason of a false stopped condition while the cloud resource is stopped
+	StoppingReason = "Stopping"
+	// StartingReason is the reason of a false stopped condition while the cloud resource of a resumed resource is started
+	StartingReason = "Starting"
+)
+
+// IsStopped returns true if the cloud resource of the resource is stopped, or being stopped, because the resource is
+// paused or hibernated
+func IsStopped(status *croType.ResourceTypeStatus) bool {
+	cond := meta.FindStatusCondition(status.Conditions, StoppedCondition)
+	return cond != nil && cond.Reason != StartingReason
+}
+
+// StopRequested returns true if the resource asks for its cloud resource to be stopped, by being paused or hibernated
+func StopRequested(spec *croType.ResourceTypeSpec) bool {
+	return spec.Paused || spec.Hibernate
+}
@@ -0,0 +1,53 @@
+package providers
+
+import (
+	"encoding/json"
+
+	errorUtil "github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// MergeProviderConfig merges the provider config of a resource over the strategy of its tier as a json merge patch,
+// objects are merged recursively, a null value removes the value of the tier and any other value replaces it
+func MergeProviderConfig(strategy json.RawMessage, providerConfig *runtime.RawExtension) (json.RawMessage, error) {
+	if providerConfig == nil || len(providerConfig.Raw) == 0 {
+		return strategy, nil
+	}
+	var patch interface{}
+	if err := json.Unmarshal(providerConfig.Raw, &patch); err != nil {
+		return nil, errorUtil.Wrap(err, "failed to unmarshal provider config")
+	}
+	if _, ok := patch.(map[string]interface{}); !ok {
+		return nil, errorUtil.New("provider config must be a json object")
+	}
+	var base interface{} = map[string]interface{}{}
+	if len(strategy) > 0 {
+		if err := json.Unmarshal(strategy, &base); err != nil {
+			return nil, errorUtil.Wrap(err, "failed to unmarshal strategy")
+		}
+	}
+	merged, err := json.Marshal(mergePatch(base, patch))
+	if err != nil {
+		return nil, errorUtil.Wrap(err, "failed to marshal strategy merged with provider config")
+	}
+	return merged, nil
+}
+
+func mergePatch(base, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	baseObj, ok := base.(map[string]interface{})
+	if !ok {
+		baseObj = map[string]interface{}{}
+	}
+	for k, v := range patchObj {
+		if v == nil {
+			delete(baseObj, k)
+			continue
+		}
+		baseObj[k] = mergePatch(baseObj[k], v)
+	}
+	return baseObj
+}
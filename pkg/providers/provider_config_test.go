@@ -0,0 +1,69 @@
+package providers
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestMergeProviderConfig(t *testing.T) {
+	tests := []struct {
+		name           string
+		strategy       string
+		providerConfig *runtime.RawExtension
+		want           string
+		wantErr        bool
+	}{
+		{
+			name:     "test strategy is kept without a provider config",
+			strategy: `{"DBInstanceClass":"db.t3.small"}`,
+			want:     `{"DBInstanceClass":"db.t3.small"}`,
+		},
+		{
+			name:           "test provider config replaces single values of the strategy",
+			strategy:       `{"DBInstanceClass":"db.t3.small","AllocatedStorage":20,"MultiAZ":true}`,
+			providerConfig: &runtime.RawExtension{Raw: []byte(`{"DBInstanceClass":"db.m5.large","AllocatedStorage":100}`)},
+			want:           `{"DBInstanceClass":"db.m5.large","AllocatedStorage":100,"MultiAZ":true}`,
+		},
+		{
+			name:           "test nested objects are merged and null removes a value",
+			strategy:       `{"overrides":{"image":"postgres:10","labels":{"a":"b"}},"isolation":{"enabled":true}}`,
+			providerConfig: &runtime.RawExtension{Raw: []byte(`{"overrides":{"labels":{"c":"d"}},"isolation":null}`)},
+			want:           `{"overrides":{"image":"postgres:10","labels":{"a":"b","c":"d"}}}`,
+		},
+		{
+			name:           "test provider config is merged over an empty strategy",
+			providerConfig: &runtime.RawExtension{Raw: []byte(`{"CacheNodeType":"cache.t3.small"}`)},
+			want:           `{"CacheNodeType":"cache.t3.small"}`,
+		},
+		{
+			name:           "test provider config must be an object",
+			strategy:       `{}`,
+			providerConfig: &runtime.RawExtension{Raw: []byte(`["db.m5.large"]`)},
+			wantErr:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergeProviderConfig(json.RawMessage(tt.strategy), tt.providerConfig)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MergeProviderConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var gotObj, wantObj interface{}
+			if err := json.Unmarshal(got, &gotObj); err != nil {
+				t.Fatalf("MergeProviderConfig() returned invalid json %s", got)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &wantObj); err != nil {
+				t.Fatal("invalid test json", err)
+			}
+			if !reflect.DeepEqual(gotObj, wantObj) {
+				t.Errorf("MergeProviderConfig() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
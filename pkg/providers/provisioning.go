@@ -0,0 +1,203 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	errorUtil "github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// provisioningJobTimeout is how long a running job holds a slot, so a resource which never completes doesn't block
+	// its provider forever
+	provisioningJobTimeout = 2 * time.Hour
+	// provisioningCacheGrace is how long a slot given out by this process is counted before the resource status
+	// showing it is expected in the cache
+	provisioningCacheGrace = time.Minute
+)
+
+// ProvisioningLimits caps the number of resources each provider creates at once, keyed by provider name, e.g.
+// aws-rds. Providers without a limit aren't capped
+var ProvisioningLimits = map[string]int{}
+
+// startedJobs are the slots given out by this process, the resource status they're persisted in may not be in the
+// cache yet
+var startedJobs = &jobSet{jobs: map[string]map[string]time.Time{}}
+
+type jobSet struct {
+	mu   sync.Mutex
+	jobs map[string]map[string]time.Time
+}
+
+func (s *jobSet) add(pool, key string, startedAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.jobs[pool] == nil {
+		s.jobs[pool] = map[string]time.Time{}
+	}
+	s.jobs[pool][key] = startedAt
+}
+
+func (s *jobSet) remove(pool, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.jobs[pool], key)
+}
+
+// recent returns the jobs of the pool started within the cache grace period, forgetting older ones
+func (s *jobSet) recent(pool string, now time.Time) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []string
+	for key, startedAt := range s.jobs[pool] {
+		if now.Sub(startedAt) > provisioningCacheGrace {
+			delete(s.jobs[pool], key)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// ParseProvisioningLimits parses a comma separated list of provider limits, e.g. aws-rds=5,aws-elasticache=5
+func ParseProvisioningLimits(s string) (map[string]int, error) {
+	limits := map[string]int{}
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, errorUtil.Errorf("invalid provisioning limit %s, expected provider=limit", entry)
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || limit < 1 {
+			return nil, errorUtil.Errorf("invalid provisioning limit %s, limit must be a positive number", entry)
+		}
+		limits[strings.TrimSpace(parts[0])] = limit
+	}
+	return limits, nil
+}
+
+// ReconcileProvisioning gives a resource a slot of its provider before its cloud resource is created, recording the
+// job in the resource status, the status is persisted with the rest of the resource status. It returns a message if
+// the resource has to wait for a slot, empty if it can be created. Resources keep their slot until
+// CompleteProvisioning is called once they're created, slots are only needed for creation so later reconciles of a
+// created resource are never held back
+func ReconcileProvisioning(ctx context.Context, c client.Client, pool string, inst metav1.Object, status *croType.ResourceTypeStatus) (croType.StatusMessage, error) {
+	job := status.Provisioning
+	if job != nil && job.State == croType.ProvisioningStateSucceeded {
+		return croType.StatusEmpty, nil
+	}
+	// resources created before jobs were tracked don't need a slot
+	if job == nil && status.Phase == croType.PhaseComplete {
+		status.Provisioning = &croType.ProvisioningJob{Pool: pool, State: croType.ProvisioningStateSucceeded}
+		return croType.StatusEmpty, nil
+	}
+	key := provisioningKey(inst)
+	if job != nil && job.State == croType.ProvisioningStateRunning && job.Pool == pool {
+		return croType.StatusEmpty, nil
+	}
+
+	now := metav1.Now()
+	if limit, ok := ProvisioningLimits[pool]; ok {
+		running, err := countRunningJobs(ctx, c, pool, key, now.Time)
+		if err != nil {
+			errMsg := fmt.Sprintf("failed to count running provisioning jobs of %s", pool)
+			return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+		}
+		if running >= limit {
+			queued := &croType.ProvisioningJob{Pool: pool, State: croType.ProvisioningStateQueued, QueuedAt: &now}
+			if job != nil && job.State == croType.ProvisioningStateQueued && job.QueuedAt != nil {
+				queued.QueuedAt = job.QueuedAt
+			}
+			status.Provisioning = queued
+			return croType.StatusMessage(fmt.Sprintf("waiting for a provisioning slot, %d of %d %s creations running", running, limit, pool)), nil
+		}
+	}
+
+	started := &croType.ProvisioningJob{Pool: pool, State: croType.ProvisioningStateRunning, StartedAt: &now}
+	if job != nil && job.State == croType.ProvisioningStateQueued {
+		started.QueuedAt = job.QueuedAt
+	}
+	status.Provisioning = started
+	startedJobs.add(pool, key, now.Time)
+	return croType.StatusEmpty, nil
+}
+
+// CompleteProvisioning marks the provisioning job of a created resource as succeeded, freeing its slot. It returns true
+// if a running job was completed, false if the job had already succeeded
+func CompleteProvisioning(inst metav1.Object, status *croType.ResourceTypeStatus) bool {
+	job := status.Provisioning
+	if job == nil || job.State != croType.ProvisioningStateRunning {
+		return false
+	}
+	now := metav1.Now()
+	job.State = croType.ProvisioningStateSucceeded
+	job.CompletedAt = &now
+	startedJobs.remove(job.Pool, provisioningKey(inst))
+	return true
+}
+
+// countRunningJobs returns the number of jobs of the pool holding a slot, other than the job of the resource with the
+// key, from the resource statuses and the slots recently given out by this process
+func countRunningJobs(ctx context.Context, c client.Client, pool, key string, now time.Time) (int, error) {
+	running := map[string]bool{}
+	add := func(om metav1.ObjectMeta, status croType.ResourceTypeStatus) {
+		job := status.Provisioning
+		if job == nil || job.Pool != pool || job.State != croType.ProvisioningStateRunning || om.DeletionTimestamp != nil {
+			return
+		}
+		if job.StartedAt != nil && now.Sub(job.StartedAt.Time) > provisioningJobTimeout {
+			return
+		}
+		running[provisioningKey(&om)] = true
+	}
+
+	bsList := &v1alpha1.BlobStorageList{}
+	if err := c.List(ctx, bsList); err != nil {
+		return 0, errorUtil.Wrap(err, "failed to list blob storages")
+	}
+	for _, bs := range bsList.Items {
+		add(bs.ObjectMeta, bs.Status)
+	}
+	pgList := &v1alpha1.PostgresList{}
+	if err := c.List(ctx, pgList); err != nil {
+		return 0, errorUtil.Wrap(err, "failed to list postgres")
+	}
+	for _, pg := range pgList.Items {
+		add(pg.ObjectMeta, pg.Status)
+	}
+	redisList := &v1alpha1.RedisList{}
+	if err := c.List(ctx, redisList); err != nil {
+		return 0, errorUtil.Wrap(err, "failed to list redis")
+	}
+	for _, r := range redisList.Items {
+		add(r.ObjectMeta, r.Status)
+	}
+	queueList := &v1alpha1.QueueList{}
+	if err := c.List(ctx, queueList); err != nil {
+		return 0, errorUtil.Wrap(err, "failed to list queues")
+	}
+	for _, q := range queueList.Items {
+		add(q.ObjectMeta, q.Status)
+	}
+
+	for _, k := range startedJobs.recent(pool, now) {
+		running[k] = true
+	}
+	delete(running, key)
+	return len(running), nil
+}
+
+func provisioningKey(inst metav1.Object) string {
+	return fmt.Sprintf("%s/%s", inst.GetNamespace(), inst.GetName())
+}
@@ -0,0 +1,176 @@
+package providers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testPool = "aws-rds"
+
+func buildTestProvisioningPostgres(name string, job *croType.ProvisioningJob) *v1alpha1.Postgres {
+	return &v1alpha1.Postgres{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "test",
+		},
+		Status: croType.ResourceTypeStatus{
+			Provisioning: job,
+		},
+	}
+}
+
+func buildTestRunningJob(startedAt time.Time) *croType.ProvisioningJob {
+	t := metav1.NewTime(startedAt)
+	return &croType.ProvisioningJob{Pool: testPool, State: croType.ProvisioningStateRunning, StartedAt: &t}
+}
+
+func TestParseProvisioningLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  string
+		want    map[string]int
+		wantErr bool
+	}{
+		{name: "test empty limits", limits: "", want: map[string]int{}},
+		{name: "test limits are parsed", limits: "aws-rds=5, aws-elasticache=2", want: map[string]int{"aws-rds": 5, "aws-elasticache": 2}},
+		{name: "test error on missing limit", limits: "aws-rds", wantErr: true},
+		{name: "test error on non positive limit", limits: "aws-rds=0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseProvisioningLimits(tt.limits)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseProvisioningLimits() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseProvisioningLimits() = %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("ParseProvisioningLimits() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileProvisioning(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	now := time.Now()
+	tests := []struct {
+		name      string
+		limits    map[string]int
+		status    croType.ResourceTypeStatus
+		existing  []runtime.Object
+		wantMsg   croType.StatusMessage
+		wantState croType.ProvisioningState
+	}{
+		{
+			name:      "test job is started without a limit",
+			wantState: croType.ProvisioningStateRunning,
+		},
+		{
+			name:      "test job is started below the limit",
+			limits:    map[string]int{testPool: 2},
+			existing:  []runtime.Object{buildTestProvisioningPostgres("running", buildTestRunningJob(now))},
+			wantState: croType.ProvisioningStateRunning,
+		},
+		{
+			name:      "test job is queued at the limit",
+			limits:    map[string]int{testPool: 1},
+			existing:  []runtime.Object{buildTestProvisioningPostgres("running", buildTestRunningJob(now))},
+			wantMsg:   "waiting for a provisioning slot, 1 of 1 aws-rds creations running",
+			wantState: croType.ProvisioningStateQueued,
+		},
+		{
+			name:      "test timed out jobs don't hold a slot",
+			limits:    map[string]int{testPool: 1},
+			existing:  []runtime.Object{buildTestProvisioningPostgres("stuck", buildTestRunningJob(now.Add(-3*time.Hour)))},
+			wantState: croType.ProvisioningStateRunning,
+		},
+		{
+			name:      "test running job keeps its slot",
+			limits:    map[string]int{testPool: 1},
+			status:    croType.ResourceTypeStatus{Provisioning: buildTestRunningJob(now)},
+			existing:  []runtime.Object{buildTestProvisioningPostgres("running", buildTestRunningJob(now))},
+			wantState: croType.ProvisioningStateRunning,
+		},
+		{
+			name:      "test complete resource without a job doesn't take a slot",
+			limits:    map[string]int{testPool: 1},
+			status:    croType.ResourceTypeStatus{Phase: croType.PhaseComplete},
+			existing:  []runtime.Object{buildTestProvisioningPostgres("running", buildTestRunningJob(now))},
+			wantState: croType.ProvisioningStateSucceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ProvisioningLimits = tt.limits
+			defer func() { ProvisioningLimits = map[string]int{} }()
+			startedJobs = &jobSet{jobs: map[string]map[string]time.Time{}}
+
+			c := fake.NewFakeClientWithScheme(scheme, tt.existing...)
+			pg := buildTestProvisioningPostgres("test", nil)
+			pg.Status = tt.status
+			msg, err := ReconcileProvisioning(context.TODO(), c, testPool, pg, &pg.Status)
+			if err != nil {
+				t.Fatalf("ReconcileProvisioning() unexpected error = %v", err)
+			}
+			if msg != tt.wantMsg {
+				t.Errorf("ReconcileProvisioning() msg = %s, want %s", msg, tt.wantMsg)
+			}
+			if pg.Status.Provisioning == nil || pg.Status.Provisioning.State != tt.wantState {
+				t.Errorf("ReconcileProvisioning() job = %v, want state %s", pg.Status.Provisioning, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestReconcileProvisioning_CountsSlotsNotYetCached(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	ProvisioningLimits = map[string]int{testPool: 1}
+	defer func() { ProvisioningLimits = map[string]int{} }()
+	startedJobs = &jobSet{jobs: map[string]map[string]time.Time{}}
+	c := fake.NewFakeClientWithScheme(scheme)
+
+	first := buildTestProvisioningPostgres("first", nil)
+	if msg, err := ReconcileProvisioning(context.TODO(), c, testPool, first, &first.Status); err != nil || msg != "" {
+		t.Fatalf("ReconcileProvisioning() msg = %s, error = %v, want the first job started", msg, err)
+	}
+	second := buildTestProvisioningPostgres("second", nil)
+	if msg, _ := ReconcileProvisioning(context.TODO(), c, testPool, second, &second.Status); msg == "" {
+		t.Fatalf("ReconcileProvisioning() expected the second job to be queued")
+	}
+
+	if !CompleteProvisioning(first, &first.Status) {
+		t.Errorf("CompleteProvisioning() expected the running job to be completed")
+	}
+	if CompleteProvisioning(first, &first.Status) {
+		t.Errorf("CompleteProvisioning() expected the succeeded job not to be completed again")
+	}
+	if first.Status.Provisioning.State != croType.ProvisioningStateSucceeded || first.Status.Provisioning.CompletedAt == nil {
+		t.Errorf("CompleteProvisioning() job = %v, want succeeded", first.Status.Provisioning)
+	}
+	if msg, _ := ReconcileProvisioning(context.TODO(), c, testPool, second, &second.Status); msg != "" {
+		t.Errorf("ReconcileProvisioning() msg = %s, want the second job started once the first completed", msg)
+	}
+	if second.Status.Provisioning.QueuedAt == nil {
+		t.Errorf("ReconcileProvisioning() expected the queued time to be kept, job = %v", second.Status.Provisioning)
+	}
+}
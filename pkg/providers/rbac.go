@@ -0,0 +1,233 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	errorUtil "github.com/pkg/errors"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Rules holds the permissions required by the operator, cluster rules are bound through a cluster role and namespaced
+// rules through a role in the namespace the operator watches
+type Rules struct {
+	Cluster    []rbacv1.PolicyRule
+	Namespaced []rbacv1.PolicyRule
+}
+
+// commonRules are required regardless of the providers that are enabled
+var commonRules = Rules{
+	Cluster: []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{""},
+			Resources: []string{"configmaps"},
+			Verbs:     []string{"get", "list", "watch"},
+		},
+		{
+			APIGroups: []string{"config.openshift.io"},
+			Resources: []string{"infrastructures", "networks"},
+			Verbs:     []string{"get", "list", "watch"},
+		},
+		{
+			APIGroups: []string{"integreatly.org"},
+			Resources: []string{"postgres", "postgressnapshots", "redis", "redissnapshots", "blobstorages", "smoketests", "loadtests", "productresources", "restoredrills", "restoredrillreports", "queues", "resourcegrants", "resourcegroupstatuses", "operatorconfigs"},
+			Verbs:     []string{"list", "watch"},
+		},
+		{
+			APIGroups: []string{"monitoring.coreos.com"},
+			Resources: []string{"prometheusrules"},
+			Verbs:     []string{"*"},
+		},
+	},
+	Namespaced: []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{""},
+			Resources: []string{"configmaps", "endpoints", "events", "secrets", "services", "services/finalizers"},
+			Verbs:     []string{"*"},
+		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"pods"},
+			Verbs:     []string{"get", "list"},
+		},
+		{
+			APIGroups: []string{"batch"},
+			Resources: []string{"jobs"},
+			Verbs:     []string{"*"},
+		},
+		{
+			APIGroups: []string{"integreatly.org"},
+			Resources: []string{"*"},
+			Verbs:     []string{"*"},
+		},
+		{
+			APIGroups: []string{"monitoring.coreos.com"},
+			Resources: []string{"servicemonitors"},
+			Verbs:     []string{"create", "get"},
+		},
+	},
+}
+
+// providerRules are only required when the provider is enabled, aws and gcp need to request cloud credentials while
+// openshift runs workloads and their storage in-cluster
+var providerRules = map[string]Rules{
+	AWSDeploymentStrategy: {
+		Namespaced: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{"cloudcredential.openshift.io"},
+				Resources: []string{"credentialsrequests"},
+				Verbs:     []string{"*"},
+			},
+		},
+	},
+	GCPDeploymentStrategy: {
+		Namespaced: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{"cloudcredential.openshift.io"},
+				Resources: []string{"credentialsrequests"},
+				Verbs:     []string{"*"},
+			},
+		},
+	},
+	OpenShiftDeploymentStrategy: {
+		Cluster: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{""},
+				Resources: []string{"persistentvolumes"},
+				Verbs:     []string{"*"},
+			},
+			{
+				APIGroups: []string{""},
+				Resources: []string{"namespaces", "resourcequotas"},
+				Verbs:     []string{"create", "delete", "get", "list", "update", "watch"},
+			},
+			{
+				APIGroups: []string{"networking.k8s.io"},
+				Resources: []string{"networkpolicies"},
+				Verbs:     []string{"create", "delete", "get", "list", "update", "watch"},
+			},
+			{
+				APIGroups: []string{"policy"},
+				Resources: []string{"poddisruptionbudgets"},
+				Verbs:     []string{"create", "delete", "get", "list", "update", "watch"},
+			},
+			{
+				APIGroups: []string{"storage.k8s.io"},
+				Resources: []string{"storageclasses"},
+				Verbs:     []string{"get", "list", "watch"},
+			},
+		},
+		Namespaced: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{""},
+				Resources: []string{"persistentvolumeclaims", "pods", "pods/exec"},
+				Verbs:     []string{"*"},
+			},
+			{
+				APIGroups: []string{"apps"},
+				Resources: []string{"*"},
+				Verbs:     []string{"*"},
+			},
+			{
+				APIGroups: []string{"snapshot.storage.k8s.io"},
+				Resources: []string{"volumesnapshots"},
+				Verbs:     []string{"*"},
+			},
+		},
+	},
+}
+
+// SupportedProviders returns the providers rules can be generated for
+func SupportedProviders() []string {
+	var supported []string
+	for p := range providerRules {
+		supported = append(supported, p)
+	}
+	sort.Strings(supported)
+	return supported
+}
+
+// RulesForProviders returns the common rules combined with the rules of each enabled provider
+func RulesForProviders(enabled []string) (*Rules, error) {
+	if len(enabled) == 0 {
+		return nil, fmt.Errorf("at least one provider must be enabled, supported providers are %v", SupportedProviders())
+	}
+	rules := &Rules{
+		Cluster:    append([]rbacv1.PolicyRule{}, commonRules.Cluster...),
+		Namespaced: append([]rbacv1.PolicyRule{}, commonRules.Namespaced...),
+	}
+	seen := map[string]bool{}
+	for _, p := range enabled {
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		pr, ok := providerRules[p]
+		if !ok {
+			return nil, fmt.Errorf("unsupported provider %s, supported providers are %v", p, SupportedProviders())
+		}
+		rules.Cluster = append(rules.Cluster, pr.Cluster...)
+		rules.Namespaced = append(rules.Namespaced, pr.Namespaced...)
+	}
+	return rules, nil
+}
+
+// CheckPermissions verifies the operator has been granted every rule, namespaced rules are checked in the namespace the
+// operator watches. the returned error lists all missing permissions so they can be granted at once
+func CheckPermissions(ctx context.Context, c client.Client, namespace string, rules *Rules) error {
+	var missing []string
+	check := func(rule rbacv1.PolicyRule, ns string) error {
+		for _, group := range rule.APIGroups {
+			for _, resource := range rule.Resources {
+				for _, verb := range rule.Verbs {
+					review := &authorizationv1.SelfSubjectAccessReview{
+						Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+							ResourceAttributes: &authorizationv1.ResourceAttributes{
+								Namespace: ns,
+								Verb:      verb,
+								Group:     group,
+								Resource:  resource,
+							},
+						},
+					}
+					if err := c.Create(ctx, review); err != nil {
+						return errorUtil.Wrapf(err, "failed to review access to %s", resource)
+					}
+					if !review.Status.Allowed {
+						missing = append(missing, describeAccess(review.Spec.ResourceAttributes))
+					}
+				}
+			}
+		}
+		return nil
+	}
+	for _, rule := range rules.Cluster {
+		if err := check(rule, ""); err != nil {
+			return err
+		}
+	}
+	for _, rule := range rules.Namespaced {
+		if err := check(rule, namespace); err != nil {
+			return err
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("operator is missing %d permissions required by the enabled providers: %s", len(missing), strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func describeAccess(attrs *authorizationv1.ResourceAttributes) string {
+	resource := attrs.Resource
+	if attrs.Group != "" {
+		resource = fmt.Sprintf("%s.%s", attrs.Resource, attrs.Group)
+	}
+	if attrs.Namespace == "" {
+		return fmt.Sprintf("%s %s", attrs.Verb, resource)
+	}
+	return fmt.Sprintf("%s %s in namespace %s", attrs.Verb, resource, attrs.Namespace)
+}
@@ -0,0 +1,239 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	moqClient "github.com/integr8ly/cloud-resource-operator/pkg/client/fake"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+func hasResource(rules []rbacv1.PolicyRule, resource string) bool {
+	for _, rule := range rules {
+		for _, r := range rule.Resources {
+			if r == resource {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestRulesForProviders(t *testing.T) {
+	tests := []struct {
+		name        string
+		providers   []string
+		wantPresent []string
+		wantAbsent  []string
+		wantErr     bool
+	}{
+		{
+			name:        "test openshift only does not require cloud credentials",
+			providers:   []string{OpenShiftDeploymentStrategy},
+			wantPresent: []string{"persistentvolumeclaims", "secrets"},
+			wantAbsent:  []string{"credentialsrequests"},
+		},
+		{
+			name:        "test aws only does not require persistent volume claims",
+			providers:   []string{AWSDeploymentStrategy},
+			wantPresent: []string{"credentialsrequests", "secrets"},
+			wantAbsent:  []string{"persistentvolumeclaims", "networkpolicies"},
+		},
+		{
+			name:        "test all providers",
+			providers:   []string{AWSDeploymentStrategy, OpenShiftDeploymentStrategy},
+			wantPresent: []string{"credentialsrequests", "persistentvolumeclaims"},
+		},
+		{
+			name:        "test gcp requests cloud credentials",
+			providers:   []string{GCPDeploymentStrategy},
+			wantPresent: []string{"credentialsrequests"},
+			wantAbsent:  []string{"persistentvolumeclaims", "networkpolicies"},
+		},
+		{
+			name:      "test error on unsupported provider",
+			providers: []string{"azure"},
+			wantErr:   true,
+		},
+		{
+			name:      "test error when no provider is enabled",
+			providers: nil,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RulesForProviders(tt.providers)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RulesForProviders() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			all := append(append([]rbacv1.PolicyRule{}, got.Cluster...), got.Namespaced...)
+			for _, r := range tt.wantPresent {
+				if !hasResource(all, r) {
+					t.Errorf("RulesForProviders() expected rule for %s", r)
+				}
+			}
+			for _, r := range tt.wantAbsent {
+				if hasResource(all, r) {
+					t.Errorf("RulesForProviders() expected no rule for %s", r)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckPermissions(t *testing.T) {
+	rules := &Rules{
+		Cluster: []rbacv1.PolicyRule{
+			{APIGroups: []string{"cloudcredential.openshift.io"}, Resources: []string{"credentialsrequests"}, Verbs: []string{"*"}},
+		},
+		Namespaced: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"persistentvolumeclaims"}, Verbs: []string{"*"}},
+		},
+	}
+	buildClient := func(allowed func(attrs *authorizationv1.ResourceAttributes) bool, createErr error) client.Client {
+		mockClient := moqClient.NewSigsClientMoqWithScheme(runtime.NewScheme())
+		mockClient.CreateFunc = func(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+			if createErr != nil {
+				return createErr
+			}
+			review := obj.(*authorizationv1.SelfSubjectAccessReview)
+			review.Status.Allowed = allowed(review.Spec.ResourceAttributes)
+			return nil
+		}
+		return mockClient
+	}
+	tests := []struct {
+		name        string
+		client      client.Client
+		wantErr     bool
+		wantMessage string
+	}{
+		{
+			name:   "test all permissions granted",
+			client: buildClient(func(*authorizationv1.ResourceAttributes) bool { return true }, nil),
+		},
+		{
+			name: "test missing namespaced permission is reported",
+			client: buildClient(func(attrs *authorizationv1.ResourceAttributes) bool {
+				return attrs.Resource != "persistentvolumeclaims"
+			}, nil),
+			wantErr:     true,
+			wantMessage: "* persistentvolumeclaims in namespace test",
+		},
+		{
+			name:        "test error when access can not be reviewed",
+			client:      buildClient(nil, errors.New("generic error")),
+			wantErr:     true,
+			wantMessage: "failed to review access",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckPermissions(context.TODO(), tt.client, "test", rules)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckPermissions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), tt.wantMessage) {
+				t.Errorf("CheckPermissions() error = %v, expected to contain %s", err, tt.wantMessage)
+			}
+		})
+	}
+}
+
+// readManagerRoles reads the cluster role and role generated from the kubebuilder rbac markers
+func readManagerRoles(t *testing.T) (*rbacv1.ClusterRole, *rbacv1.Role) {
+	data, err := ioutil.ReadFile(filepath.Join("..", "..", "config", "rbac", "role.yaml"))
+	if err != nil {
+		t.Fatal("failed to read role.yaml", err)
+	}
+	clusterRole, role := &rbacv1.ClusterRole{}, &rbacv1.Role{}
+	for _, doc := range strings.Split(string(data), "\n---\n") {
+		switch {
+		case strings.Contains(doc, "kind: ClusterRole"):
+			err = yaml.Unmarshal([]byte(doc), clusterRole)
+		case strings.Contains(doc, "kind: Role"):
+			err = yaml.Unmarshal([]byte(doc), role)
+		}
+		if err != nil {
+			t.Fatal("failed to parse role.yaml", err)
+		}
+	}
+	return clusterRole, role
+}
+
+// grants returns true if one of the rules allows the verb on the resource of the group
+func grants(rules []rbacv1.PolicyRule, group, resource, verb string) bool {
+	for _, rule := range rules {
+		if hasValue(rule.APIGroups, group) && hasValue(rule.Resources, resource) && hasValue(rule.Verbs, verb) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value || v == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRulesForProviders_MatchRole(t *testing.T) {
+	rules, err := RulesForProviders(SupportedProviders())
+	if err != nil {
+		t.Fatal("failed to get rules", err)
+	}
+	clusterRole, role := readManagerRoles(t)
+
+	// every rule checked at startup must be granted by the generated roles, or the operator refuses to start
+	for _, tc := range []struct {
+		kind      string
+		rules     []rbacv1.PolicyRule
+		roleRules []rbacv1.PolicyRule
+	}{
+		{kind: "cluster role", rules: rules.Cluster, roleRules: clusterRole.Rules},
+		{kind: "role", rules: rules.Namespaced, roleRules: role.Rules},
+	} {
+		for _, rule := range tc.rules {
+			for _, group := range rule.APIGroups {
+				for _, resource := range rule.Resources {
+					for _, verb := range rule.Verbs {
+						if !grants(tc.roleRules, group, resource, verb) {
+							t.Errorf("%s in role.yaml doesn't grant %s %s.%s", tc.kind, verb, resource, group)
+						}
+					}
+				}
+			}
+		}
+	}
+	// every resource of the generated cluster role must be checked, so a cluster wide watch isn't missed
+	for _, roleRule := range clusterRole.Rules {
+		for _, group := range roleRule.APIGroups {
+			for _, resource := range roleRule.Resources {
+				found := false
+				for _, rule := range rules.Cluster {
+					if hasValue(rule.APIGroups, group) && hasValue(rule.Resources, resource) {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("cluster rules are missing %s.%s, granted by the cluster role in role.yaml", resource, group)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package providers
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	errorUtil "github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// RequeueStrategy is how a provider polls a resource whose cloud resource isn't ready yet, e.g. an rds instance being
+// created. The first poll is after Min, and the interval doubles on every poll finding the resource still not ready,
+// up to Max
+type RequeueStrategy struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+var (
+	// RequeueStrategies are the requeue strategies of the providers, keyed by provider name, e.g. aws-rds. Providers
+	// without one poll at the fixed interval of their reconcile time
+	RequeueStrategies = DefaultRequeueStrategies()
+	// MinRequeueInterval and MaxRequeueInterval bound the intervals of every provider, zero leaves them unbounded
+	MinRequeueInterval time.Duration
+	MaxRequeueInterval time.Duration
+)
+
+// DefaultRequeueStrategies returns the requeue strategies of the providers if they aren't configured. Openshift
+// deployments are ready within seconds, while cloud resources take minutes and every poll is a provider api call
+func DefaultRequeueStrategies() map[string]RequeueStrategy {
+	cloud := RequeueStrategy{Min: 30 * time.Second, Max: 5 * time.Minute}
+	openshift := RequeueStrategy{Min: 2 * time.Second, Max: 30 * time.Second}
+	return map[string]RequeueStrategy{
+		"aws-rds":                     cloud,
+		"aws-elasticache":             cloud,
+		"gcp-cloudsql":                cloud,
+		"openshift-postgres-template": openshift,
+		"openshift-redis-template":    openshift,
+	}
+}
+
+// requeueStates are the polls of the resources that aren't ready yet, keyed by resource type, namespace and name
+var requeueStates = &requeueStateSet{states: map[string]*requeueState{}}
+
+type requeueState struct {
+	phase croType.StatusPhase
+	polls int
+}
+
+type requeueStateSet struct {
+	mu     sync.Mutex
+	states map[string]*requeueState
+}
+
+// ParseRequeueStrategies parses a comma separated list of provider requeue strategies, e.g. aws-rds=30s-5m, over the
+// default strategies
+func ParseRequeueStrategies(s string) (map[string]RequeueStrategy, error) {
+	strategies := DefaultRequeueStrategies()
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, errorUtil.Errorf("invalid requeue strategy %s, expected provider=min-max", entry)
+		}
+		intervals := strings.SplitN(parts[1], "-", 2)
+		if len(intervals) != 2 {
+			return nil, errorUtil.Errorf("invalid requeue strategy %s, expected provider=min-max", entry)
+		}
+		min, err := time.ParseDuration(strings.TrimSpace(intervals[0]))
+		if err != nil || min <= 0 {
+			return nil, errorUtil.Errorf("invalid requeue strategy %s, min must be a positive duration", entry)
+		}
+		max, err := time.ParseDuration(strings.TrimSpace(intervals[1]))
+		if err != nil || max < min {
+			return nil, errorUtil.Errorf("invalid requeue strategy %s, max must be a duration of at least min", entry)
+		}
+		strategies[strings.TrimSpace(parts[0])] = RequeueStrategy{Min: min, Max: max}
+	}
+	return strategies, nil
+}
+
+// NextRequeue returns when to reconcile a resource again whose cloud resource isn't ready yet, backing off with the
+// requeue strategy of the provider on every call for the resource in the same phase. hint is the reconcile time of the
+// provider, the fixed interval of providers without a strategy
+func NextRequeue(provider string, rt ResourceType, key types.NamespacedName, phase croType.StatusPhase, hint time.Duration) time.Duration {
+	strategy, ok := RequeueStrategies[provider]
+	if !ok {
+		strategy = RequeueStrategy{Min: hint, Max: hint}
+	}
+	polls := requeueStates.poll(requeueKey(rt, key), phase)
+	interval := strategy.Min
+	for i := 0; i < polls && interval < strategy.Max; i++ {
+		interval *= 2
+	}
+	if interval > strategy.Max {
+		interval = strategy.Max
+	}
+	if MinRequeueInterval > 0 && interval < MinRequeueInterval {
+		interval = MinRequeueInterval
+	}
+	if MaxRequeueInterval > 0 && interval > MaxRequeueInterval {
+		interval = MaxRequeueInterval
+	}
+	return interval
+}
+
+// ResetRequeue forgets the polls of a resource once its cloud resource is ready, or the resource is gone
+func ResetRequeue(rt ResourceType, key types.NamespacedName) {
+	requeueStates.mu.Lock()
+	defer requeueStates.mu.Unlock()
+	delete(requeueStates.states, requeueKey(rt, key))
+}
+
+// poll records a poll of the resource in the phase, it returns the number of polls before it in the phase
+func (s *requeueStateSet) poll(key string, phase croType.StatusPhase) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.states[key]
+	if !ok || state.phase != phase {
+		state = &requeueState{phase: phase}
+		s.states[key] = state
+	}
+	polls := state.polls
+	state.polls++
+	return polls
+}
+
+func requeueKey(rt ResourceType, key types.NamespacedName) string {
+	return fmt.Sprintf("%s/%s", rt, key)
+}
@@ -0,0 +1,86 @@
+package providers
+
+import (
+	"testing"
+	"time"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestParseRequeueStrategies(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    RequeueStrategy
+		wantErr bool
+	}{
+		{
+			name: "test the default strategy of a provider is kept",
+			s:    "",
+			want: RequeueStrategy{Min: 30 * time.Second, Max: 5 * time.Minute},
+		},
+		{
+			name: "test the strategy of a provider is overridden",
+			s:    "aws-rds=10s-1m, aws-elasticache=1m-10m",
+			want: RequeueStrategy{Min: 10 * time.Second, Max: time.Minute},
+		},
+		{
+			name:    "test a strategy without intervals is invalid",
+			s:       "aws-rds=10s",
+			wantErr: true,
+		},
+		{
+			name:    "test a max below min is invalid",
+			s:       "aws-rds=1m-10s",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRequeueStrategies(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRequeueStrategies() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got["aws-rds"] != tt.want {
+				t.Errorf("ParseRequeueStrategies() aws-rds = %v, want %v", got["aws-rds"], tt.want)
+			}
+		})
+	}
+}
+
+func TestNextRequeue(t *testing.T) {
+	defer func() {
+		RequeueStrategies = DefaultRequeueStrategies()
+		MinRequeueInterval, MaxRequeueInterval = 0, 0
+	}()
+	RequeueStrategies = map[string]RequeueStrategy{"test": {Min: time.Second, Max: 5 * time.Second}}
+	key := types.NamespacedName{Name: "test", Namespace: "test"}
+	defer ResetRequeue(PostgresResourceType, key)
+
+	var got []time.Duration
+	for i := 0; i < 5; i++ {
+		got = append(got, NextRequeue("test", PostgresResourceType, key, croType.PhaseInProgress, time.Minute))
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("NextRequeue() = %v, want %v", got, want)
+		}
+	}
+
+	// a new phase, or a reset once the resource is ready, starts from the minimum again
+	if got := NextRequeue("test", PostgresResourceType, key, croType.PhaseDeleteInProgress, time.Minute); got != time.Second {
+		t.Errorf("NextRequeue() in a new phase = %v, want 1s", got)
+	}
+	ResetRequeue(PostgresResourceType, key)
+	if got := NextRequeue("test", PostgresResourceType, key, croType.PhaseDeleteInProgress, time.Minute); got != time.Second {
+		t.Errorf("NextRequeue() after reset = %v, want 1s", got)
+	}
+
+	// providers without a strategy poll at their reconcile time, within the operator bounds
+	MaxRequeueInterval = 30 * time.Second
+	if got := NextRequeue("other", PostgresResourceType, key, croType.PhaseInProgress, time.Minute); got != 30*time.Second {
+		t.Errorf("NextRequeue() of a provider without a strategy = %v, want 30s", got)
+	}
+}
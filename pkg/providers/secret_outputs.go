@@ -0,0 +1,147 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	errorUtil "github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// SecretOutputFormatPgpass renders a .pgpass line from the postgres connection details
+	SecretOutputFormatPgpass = "pgpass"
+	// SecretOutputFormatPostgresURI renders a postgresql:// connection uri from the postgres connection details
+	SecretOutputFormatPostgresURI = "postgres-uri"
+	// SecretOutputFormatRedisURI renders a redis:// connection uri from the redis connection details
+	SecretOutputFormatRedisURI = "redis-uri"
+	// SecretOutputFormatEnv renders every key of the secret as a KEY="value" line, e.g. for a .env file
+	SecretOutputFormatEnv = "env"
+)
+
+var envKeyInvalidChars = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// SecretOutput renders an extra key of the connection secret of a resource from the keys set by its provider, for
+// consumers needing a file or a differently encoded value, e.g. a .pgpass file or a redis.conf snippet
+type SecretOutput struct {
+	// Key is the key of the secret the output is written to, the file name when the secret is mounted
+	Key string `json:"key"`
+	// Format is one of pgpass, postgres-uri, redis-uri or env, either a format or a template must be set
+	Format string `json:"format,omitempty"`
+	// Template is a go template rendered with the keys set by the provider, e.g. {{ .host }}:{{ .port }}
+	Template string `json:"template,omitempty"`
+	// Base64 is the number of times the rendered value is base64 encoded, for consumers decoding it themselves
+	Base64 int `json:"base64,omitempty"`
+}
+
+// RenderSecretOutputs returns the secret data with the outputs added, the outputs are rendered from the keys set by
+// the provider and can't replace them
+func RenderSecretOutputs(data map[string][]byte, outputs []SecretOutput) (map[string][]byte, error) {
+	if len(outputs) == 0 {
+		return data, nil
+	}
+	values := map[string]string{}
+	for k, v := range data {
+		values[k] = string(v)
+	}
+	rendered := map[string][]byte{}
+	for k, v := range data {
+		rendered[k] = v
+	}
+	for _, o := range outputs {
+		if errs := validation.IsConfigMapKey(o.Key); len(errs) > 0 {
+			return nil, errorUtil.Errorf("invalid secret output key %q: %s", o.Key, strings.Join(errs, ", "))
+		}
+		if _, ok := rendered[o.Key]; ok {
+			return nil, errorUtil.Errorf("secret output key %s is already set", o.Key)
+		}
+		if o.Base64 < 0 {
+			return nil, errorUtil.Errorf("base64 of secret output %s must not be negative", o.Key)
+		}
+		value, err := renderSecretOutput(values, o)
+		if err != nil {
+			return nil, errorUtil.Wrapf(err, "failed to render secret output %s", o.Key)
+		}
+		for i := 0; i < o.Base64; i++ {
+			value = base64.StdEncoding.EncodeToString([]byte(value))
+		}
+		rendered[o.Key] = []byte(value)
+	}
+	return rendered, nil
+}
+
+func renderSecretOutput(values map[string]string, o SecretOutput) (string, error) {
+	if (o.Format == "") == (o.Template == "") {
+		return "", errorUtil.New("exactly one of format or template must be set")
+	}
+	if o.Template != "" {
+		tmpl, err := template.New(o.Key).Option("missingkey=error").Parse(o.Template)
+		if err != nil {
+			return "", errorUtil.Wrap(err, "failed to parse template")
+		}
+		buf := &bytes.Buffer{}
+		if err := tmpl.Execute(buf, values); err != nil {
+			return "", errorUtil.Wrap(err, "failed to execute template")
+		}
+		return buf.String(), nil
+	}
+
+	switch o.Format {
+	case SecretOutputFormatPgpass:
+		if err := requireKeys(values, "host", "port", "database", "username", "password"); err != nil {
+			return "", err
+		}
+		fields := []string{values["host"], values["port"], values["database"], values["username"], values["password"]}
+		for i := range fields {
+			fields[i] = strings.NewReplacer(`\`, `\\`, `:`, `\:`).Replace(fields[i])
+		}
+		return strings.Join(fields, ":") + "\n", nil
+	case SecretOutputFormatPostgresURI:
+		if err := requireKeys(values, "host", "port", "database", "username", "password"); err != nil {
+			return "", err
+		}
+		u := url.URL{
+			Scheme: "postgresql",
+			User:   url.UserPassword(values["username"], values["password"]),
+			Host:   fmt.Sprintf("%s:%s", values["host"], values["port"]),
+			Path:   "/" + values["database"],
+		}
+		if sslMode := values["sslmode"]; sslMode != "" {
+			u.RawQuery = url.Values{"sslmode": []string{sslMode}}.Encode()
+		}
+		return u.String(), nil
+	case SecretOutputFormatRedisURI:
+		if err := requireKeys(values, "uri", "port"); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("redis://%s:%s", values["uri"], values["port"]), nil
+	case SecretOutputFormatEnv:
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf := &strings.Builder{}
+		for _, k := range keys {
+			fmt.Fprintf(buf, "%s=%s\n", envKeyInvalidChars.ReplaceAllString(strings.ToUpper(k), "_"), strconv.Quote(values[k]))
+		}
+		return buf.String(), nil
+	}
+	return "", errorUtil.Errorf("unknown format %s", o.Format)
+}
+
+func requireKeys(values map[string]string, keys ...string) error {
+	for _, k := range keys {
+		if _, ok := values[k]; !ok {
+			return errorUtil.Errorf("secret has no %s key", k)
+		}
+	}
+	return nil
+}
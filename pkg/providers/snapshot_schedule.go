@@ -0,0 +1,150 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// SnapshotScheduleLabel is set on snapshot crs created by a snapshot schedule, to the name of the resource
+	SnapshotScheduleLabel = "integreatly.org/scheduled-snapshot-of"
+	// SnapshotScheduledTimeAnnotation is the time, in RFC3339 format, a scheduled snapshot cr was created for
+	SnapshotScheduledTimeAnnotation = "integreatly.org/snapshot-scheduled-time"
+)
+
+// ReconcilePostgresSnapshotSchedule creates a PostgresSnapshot cr for the postgres cr whenever its snapshot schedule is
+// due, returning the time until the next scheduled snapshot
+func ReconcilePostgresSnapshotSchedule(ctx context.Context, c client.Client, pg *v1alpha1.Postgres, now time.Time) (time.Duration, croType.StatusMessage, error) {
+	return reconcileSnapshotSchedule(ctx, c, pg.ObjectMeta, pg.Spec.SnapshotSchedule, now, &v1alpha1.PostgresSnapshotList{}, &v1alpha1.PostgresSnapshot{
+		ObjectMeta: buildScheduledSnapshotMeta(pg.ObjectMeta, now),
+		Spec: v1alpha1.PostgresSnapshotSpec{
+			ResourceName:  pg.Name,
+			RetentionDays: pg.Spec.SnapshotSchedule.RetentionDays,
+		},
+	})
+}
+
+// ReconcileRedisSnapshotSchedule creates a RedisSnapshot cr for the redis cr whenever its snapshot schedule is due,
+// returning the time until the next scheduled snapshot
+func ReconcileRedisSnapshotSchedule(ctx context.Context, c client.Client, r *v1alpha1.Redis, now time.Time) (time.Duration, croType.StatusMessage, error) {
+	return reconcileSnapshotSchedule(ctx, c, r.ObjectMeta, r.Spec.SnapshotSchedule, now, &v1alpha1.RedisSnapshotList{}, &v1alpha1.RedisSnapshot{
+		ObjectMeta: buildScheduledSnapshotMeta(r.ObjectMeta, now),
+		Spec: v1alpha1.RedisSnapshotSpec{
+			ResourceName:  r.Name,
+			RetentionDays: r.Spec.SnapshotSchedule.RetentionDays,
+		},
+	})
+}
+
+// reconcileSnapshotSchedule creates the snapshot cr if the schedule has been due since the latest scheduled snapshot cr
+// of the resource, of the type of snapshotList, was created
+func reconcileSnapshotSchedule(ctx context.Context, c client.Client, resource metav1.ObjectMeta, snapshotSchedule *croType.SnapshotSchedule, now time.Time, snapshotList runtime.Object, snapshot runtime.Object) (time.Duration, croType.StatusMessage, error) {
+	schedule, err := resources.ParseCronSchedule(snapshotSchedule.Schedule)
+	if err != nil {
+		errMsg := "failed to parse snapshot schedule"
+		return 0, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	if err := c.List(ctx, snapshotList, client.InNamespace(resource.Namespace), client.MatchingLabels{SnapshotScheduleLabel: resource.Name}); err != nil {
+		errMsg := "failed to list scheduled snapshots"
+		return 0, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	scheduled, err := meta.ExtractList(snapshotList)
+	if err != nil {
+		errMsg := "failed to read scheduled snapshots"
+		return 0, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	if !isSnapshotDue(schedule, resource, scheduled, now) {
+		return nextSnapshotIn(schedule, now), "", nil
+	}
+	snapshotMeta, err := meta.Accessor(snapshot)
+	if err != nil {
+		errMsg := "failed to read scheduled snapshot metadata"
+		return 0, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	if err := c.Create(ctx, snapshot); err != nil {
+		errMsg := fmt.Sprintf("failed to create scheduled snapshot %s", snapshotMeta.GetName())
+		return 0, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	return nextSnapshotIn(schedule, now), croType.StatusMessage(fmt.Sprintf("created scheduled snapshot %s", snapshotMeta.GetName())), nil
+}
+
+// isSnapshotDue returns true if the schedule has been due since the latest scheduled snapshot was created, or since the
+// resource was created if there are no scheduled snapshots
+func isSnapshotDue(schedule *resources.CronSchedule, resource metav1.ObjectMeta, scheduled []runtime.Object, now time.Time) bool {
+	last := resource.CreationTimestamp.Time
+	for _, s := range scheduled {
+		accessor, err := meta.Accessor(s)
+		if err != nil {
+			continue
+		}
+		scheduledTime, err := time.Parse(time.RFC3339, accessor.GetAnnotations()[SnapshotScheduledTimeAnnotation])
+		if err != nil {
+			continue
+		}
+		if scheduledTime.After(last) {
+			last = scheduledTime
+		}
+	}
+	if last.IsZero() {
+		last = now
+	}
+	due := schedule.Next(last)
+	return !due.IsZero() && !due.After(now)
+}
+
+// nextSnapshotIn returns the time until the schedule is next due, or zero if it's never due
+func nextSnapshotIn(schedule *resources.CronSchedule, now time.Time) time.Duration {
+	next := schedule.Next(now)
+	if next.IsZero() {
+		return 0
+	}
+	return next.Sub(now)
+}
+
+func buildScheduledSnapshotMeta(resource metav1.ObjectMeta, now time.Time) metav1.ObjectMeta {
+	labels := map[string]string{
+		SnapshotScheduleLabel: resource.Name,
+	}
+	if productName, ok := resource.Labels["productName"]; ok {
+		labels["productName"] = productName
+	}
+	return metav1.ObjectMeta{
+		Name:      fmt.Sprintf("%s-scheduled-%s", resource.Name, now.UTC().Format("20060102-1504")),
+		Namespace: resource.Namespace,
+		Labels:    labels,
+		Annotations: map[string]string{
+			SnapshotScheduledTimeAnnotation: now.UTC().Format(time.RFC3339),
+		},
+	}
+}
+
+// BuildSnapshotRetainUntil returns the time, in RFC3339 format, a snapshot created at the time is kept until, or an
+// empty string if it has no retention period
+func BuildSnapshotRetainUntil(created time.Time, retentionDays int) string {
+	if retentionDays <= 0 {
+		return ""
+	}
+	return created.UTC().AddDate(0, 0, retentionDays).Format(time.RFC3339)
+}
+
+// IsSnapshotExpired returns true if the snapshot retention period has passed
+func IsSnapshotExpired(status croType.ResourceTypeSnapshotStatus, now time.Time) bool {
+	if status.RetainUntil == "" {
+		return false
+	}
+	retainUntil, err := time.Parse(time.RFC3339, status.RetainUntil)
+	if err != nil {
+		return false
+	}
+	return now.After(retainUntil)
+}
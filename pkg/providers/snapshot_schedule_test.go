@@ -0,0 +1,175 @@
+package providers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var testScheduleTime = time.Date(2021, time.June, 16, 10, 30, 0, 0, time.UTC)
+
+func buildTestScheduledPostgres() *v1alpha1.Postgres {
+	return &v1alpha1.Postgres{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "test",
+			Namespace:         "test",
+			Labels:            map[string]string{"productName": "test-product"},
+			CreationTimestamp: metav1.NewTime(testScheduleTime.Add(-48 * time.Hour)),
+		},
+		Spec: croType.ResourceTypeSpec{
+			SnapshotSchedule: &croType.SnapshotSchedule{
+				Schedule:      "0 2 * * *",
+				RetentionDays: 7,
+			},
+		},
+	}
+}
+
+func buildTestScheduledPostgresSnapshot(scheduledTime time.Time) *v1alpha1.PostgresSnapshot {
+	return &v1alpha1.PostgresSnapshot{
+		ObjectMeta: buildScheduledSnapshotMeta(buildTestScheduledPostgres().ObjectMeta, scheduledTime),
+	}
+}
+
+func TestReconcilePostgresSnapshotSchedule(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	tests := []struct {
+		name             string
+		postgres         *v1alpha1.Postgres
+		existing         []runtime.Object
+		want             time.Duration
+		wantSnapshotName string
+		wantErr          bool
+	}{
+		{
+			name:             "test snapshot is created when the schedule is due since the postgres cr was created",
+			postgres:         buildTestScheduledPostgres(),
+			want:             15*time.Hour + 30*time.Minute,
+			wantSnapshotName: "test-scheduled-20210616-1030",
+		},
+		{
+			name:             "test snapshot is created when the schedule is due since the last scheduled snapshot",
+			postgres:         buildTestScheduledPostgres(),
+			existing:         []runtime.Object{buildTestScheduledPostgresSnapshot(testScheduleTime.Add(-24 * time.Hour))},
+			want:             15*time.Hour + 30*time.Minute,
+			wantSnapshotName: "test-scheduled-20210616-1030",
+		},
+		{
+			name:     "test snapshot is not created when the schedule isn't due since the last scheduled snapshot",
+			postgres: buildTestScheduledPostgres(),
+			existing: []runtime.Object{buildTestScheduledPostgresSnapshot(testScheduleTime.Add(-time.Hour))},
+			want:     15*time.Hour + 30*time.Minute,
+		},
+		{
+			name: "test error on invalid schedule",
+			postgres: func() *v1alpha1.Postgres {
+				pg := buildTestScheduledPostgres()
+				pg.Spec.SnapshotSchedule.Schedule = "invalid"
+				return pg
+			}(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme, append(tt.existing, tt.postgres)...)
+			got, _, err := ReconcilePostgresSnapshotSchedule(context.TODO(), c, tt.postgres, testScheduleTime)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReconcilePostgresSnapshotSchedule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ReconcilePostgresSnapshotSchedule() = %v, want %v", got, tt.want)
+			}
+			snapshots := &v1alpha1.PostgresSnapshotList{}
+			if err := c.List(context.TODO(), snapshots, client.InNamespace("test")); err != nil {
+				t.Fatal("failed to list snapshots", err)
+			}
+			wantSnapshots := len(tt.existing)
+			if tt.wantSnapshotName != "" {
+				wantSnapshots++
+				snapshot := &v1alpha1.PostgresSnapshot{}
+				if err := c.Get(context.TODO(), client.ObjectKey{Name: tt.wantSnapshotName, Namespace: "test"}, snapshot); err != nil {
+					t.Fatalf("ReconcilePostgresSnapshotSchedule() scheduled snapshot not created: %v", err)
+				}
+				if snapshot.Spec.ResourceName != "test" || snapshot.Spec.RetentionDays != 7 || snapshot.Labels["productName"] != "test-product" {
+					t.Errorf("ReconcilePostgresSnapshotSchedule() wrong scheduled snapshot %+v", snapshot)
+				}
+			}
+			if len(snapshots.Items) != wantSnapshots {
+				t.Errorf("ReconcilePostgresSnapshotSchedule() found %d snapshots, want %d", len(snapshots.Items), wantSnapshots)
+			}
+		})
+	}
+}
+
+func TestReconcileRedisSnapshotSchedule(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	pg := buildTestScheduledPostgres()
+	redis := &v1alpha1.Redis{
+		ObjectMeta: pg.ObjectMeta,
+		Spec:       pg.Spec,
+	}
+	// a scheduled postgres snapshot of a postgres cr with the same name shouldn't stop the redis snapshot
+	c := fake.NewFakeClientWithScheme(scheme, redis, buildTestScheduledPostgresSnapshot(testScheduleTime.Add(-time.Hour)))
+	got, _, err := ReconcileRedisSnapshotSchedule(context.TODO(), c, redis, testScheduleTime)
+	if err != nil {
+		t.Fatalf("ReconcileRedisSnapshotSchedule() unexpected error = %v", err)
+	}
+	if want := 15*time.Hour + 30*time.Minute; got != want {
+		t.Errorf("ReconcileRedisSnapshotSchedule() = %v, want %v", got, want)
+	}
+	snapshot := &v1alpha1.RedisSnapshot{}
+	if err := c.Get(context.TODO(), client.ObjectKey{Name: "test-scheduled-20210616-1030", Namespace: "test"}, snapshot); err != nil {
+		t.Fatalf("ReconcileRedisSnapshotSchedule() scheduled snapshot not created: %v", err)
+	}
+	if snapshot.Spec.ResourceName != "test" || snapshot.Spec.RetentionDays != 7 {
+		t.Errorf("ReconcileRedisSnapshotSchedule() wrong scheduled snapshot %+v", snapshot)
+	}
+}
+
+func TestIsSnapshotExpired(t *testing.T) {
+	tests := []struct {
+		name   string
+		status croType.ResourceTypeSnapshotStatus
+		want   bool
+	}{
+		{
+			name:   "test snapshot without retention period never expires",
+			status: croType.ResourceTypeSnapshotStatus{},
+			want:   false,
+		},
+		{
+			name:   "test snapshot within retention period is not expired",
+			status: croType.ResourceTypeSnapshotStatus{RetainUntil: BuildSnapshotRetainUntil(testScheduleTime, 1)},
+			want:   false,
+		},
+		{
+			name:   "test snapshot after retention period is expired",
+			status: croType.ResourceTypeSnapshotStatus{RetainUntil: BuildSnapshotRetainUntil(testScheduleTime.AddDate(0, 0, -2), 1)},
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsSnapshotExpired(tt.status, testScheduleTime); got != tt.want {
+				t.Errorf("IsSnapshotExpired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	errorUtil "github.com/pkg/errors"
+)
+
+const (
+	// TierInheritsKey is the field of a tier naming the tier of the same resource type it inherits from, the fields
+	// of the tier are merged over the fields of the inherited tier
+	TierInheritsKey = "inherits"
+	// DefaultTierKey is the key of a strategy config map naming the tier resources of an undefined tier fall back to
+	DefaultTierKey = "_defaultTier"
+
+	// maxTierInheritanceDepth caps the chain of inherited tiers, so a cycle fails rather than looping
+	maxTierInheritanceDepth = 10
+)
+
+// ResolveTier returns the strategy of a tier of the resource type in the data of a strategy config map, merged over
+// the tiers it inherits from, and the name of the tier it was read from. A tier that isn't defined falls back to the
+// default tier of the config map. It returns a nil strategy if neither the tier nor a default tier is defined
+func ResolveTier(data map[string]string, rt ResourceType, tier string) (json.RawMessage, string, error) {
+	tiers := map[string]json.RawMessage{}
+	if raw := data[string(rt)]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &tiers); err != nil {
+			return nil, "", errorUtil.Wrapf(err, "failed to unmarshal strategy mapping for resource type %s", rt)
+		}
+	}
+	resolved := tier
+	if !tierDefined(tiers, tier) {
+		resolved = data[DefaultTierKey]
+		if resolved == "" {
+			return nil, "", nil
+		}
+		if !tierDefined(tiers, resolved) {
+			return nil, "", errorUtil.New(fmt.Sprintf("default tier %s is not defined for resource type %s", resolved, rt))
+		}
+	}
+	strat, err := resolveInheritance(tiers, rt, resolved)
+	if err != nil {
+		return nil, "", err
+	}
+	return strat, resolved, nil
+}
+
+// resolveInheritance merges a tier over the chain of tiers it inherits from, the inherits field is removed from the
+// merged strategy
+func resolveInheritance(tiers map[string]json.RawMessage, rt ResourceType, tier string) (json.RawMessage, error) {
+	var chain []map[string]interface{}
+	seen := map[string]bool{}
+	for name := tier; name != ""; {
+		if seen[name] || len(chain) == maxTierInheritanceDepth {
+			return nil, errorUtil.New(fmt.Sprintf("tier %s of resource type %s has an inheritance cycle", tier, rt))
+		}
+		seen[name] = true
+		if !tierDefined(tiers, name) {
+			return nil, errorUtil.New(fmt.Sprintf("tier %s of resource type %s inherits from undefined tier %s", tier, rt, name))
+		}
+		strat := map[string]interface{}{}
+		if err := json.Unmarshal(tiers[name], &strat); err != nil {
+			return nil, errorUtil.Wrapf(err, "failed to unmarshal tier %s of resource type %s", name, rt)
+		}
+		chain = append(chain, strat)
+		parent, ok := strat[TierInheritsKey].(string)
+		if _, set := strat[TierInheritsKey]; set && !ok {
+			return nil, errorUtil.New(fmt.Sprintf("inherits of tier %s of resource type %s must be a tier name", name, rt))
+		}
+		name = parent
+	}
+	if len(chain) == 1 {
+		return tiers[tier], nil
+	}
+
+	var merged interface{} = map[string]interface{}{}
+	for i := len(chain) - 1; i >= 0; i-- {
+		merged = mergePatch(merged, chain[i])
+	}
+	delete(merged.(map[string]interface{}), TierInheritsKey)
+	raw, err := json.Marshal(merged)
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to marshal tier %s of resource type %s", tier, rt)
+	}
+	return raw, nil
+}
+
+func tierDefined(tiers map[string]json.RawMessage, tier string) bool {
+	strat, ok := tiers[tier]
+	return ok && string(strat) != "null"
+}
@@ -0,0 +1,100 @@
+package providers
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestResolveTier(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         map[string]string
+		tier         string
+		want         string
+		wantResolved string
+		wantErr      bool
+	}{
+		{
+			name:         "test tier without inheritance is returned as is",
+			data:         map[string]string{"postgres": `{"production": {"strategy": {"storage": 10}}}`},
+			tier:         "production",
+			want:         `{"strategy": {"storage": 10}}`,
+			wantResolved: "production",
+		},
+		{
+			name: "test tier is merged over the chain of tiers it inherits from",
+			data: map[string]string{"postgres": `{
+				"production": {"strategy": {"storage": 10, "image": "postgres:13"}, "region": "eu-west-1"},
+				"staging": {"inherits": "production", "strategy": {"storage": 5}},
+				"preview": {"inherits": "staging", "region": null}
+			}`},
+			tier:         "preview",
+			want:         `{"strategy": {"storage": 5, "image": "postgres:13"}}`,
+			wantResolved: "preview",
+		},
+		{
+			name: "test undefined tier falls back to the default tier",
+			data: map[string]string{
+				"postgres":     `{"production": {"strategy": {"storage": 10}}}`,
+				DefaultTierKey: "production",
+			},
+			tier:         "unknown",
+			want:         `{"strategy": {"storage": 10}}`,
+			wantResolved: "production",
+		},
+		{
+			name: "test undefined tier without a default tier isn't resolved",
+			data: map[string]string{"postgres": `{"production": {"strategy": {}}}`},
+			tier: "unknown",
+		},
+		{
+			name: "test error on an undefined default tier",
+			data: map[string]string{
+				"postgres":     `{"production": {"strategy": {}}}`,
+				DefaultTierKey: "development",
+			},
+			tier:    "unknown",
+			wantErr: true,
+		},
+		{
+			name:    "test error on an inheritance cycle",
+			data:    map[string]string{"postgres": `{"a": {"inherits": "b"}, "b": {"inherits": "a"}}`},
+			tier:    "a",
+			wantErr: true,
+		},
+		{
+			name:    "test error on inheriting from an undefined tier",
+			data:    map[string]string{"postgres": `{"a": {"inherits": "b"}}`},
+			tier:    "a",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, resolved, err := ResolveTier(tt.data, PostgresResourceType, tt.tier)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveTier() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if resolved != tt.wantResolved {
+				t.Errorf("ResolveTier() resolved = %s, want %s", resolved, tt.wantResolved)
+			}
+			if tt.want == "" {
+				if got != nil {
+					t.Errorf("ResolveTier() = %s, want nil", got)
+				}
+				return
+			}
+			var gotObj, wantObj interface{}
+			if err := json.Unmarshal(got, &gotObj); err != nil {
+				t.Fatalf("ResolveTier() returned invalid json %s", got)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &wantObj); err != nil {
+				t.Fatal("invalid test json", err)
+			}
+			if !reflect.DeepEqual(gotObj, wantObj) {
+				t.Errorf("ResolveTier() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
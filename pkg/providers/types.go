@@ -2,6 +2,7 @@ package providers
 
 import (
 	"context"
+	"encoding/json"
 	"strconv"
 	"time"
 
@@ -16,12 +17,22 @@ const (
 	ManagedDeploymentType = "managed"
 
 	AWSDeploymentStrategy       = "aws"
+	GCPDeploymentStrategy       = "gcp"
 	OpenShiftDeploymentStrategy = "openshift"
 
 	BlobStorageResourceType ResourceType = "blobstorage"
 	PostgresResourceType    ResourceType = "postgres"
 	RedisResourceType       ResourceType = "redis"
+	QueueResourceType       ResourceType = "queue"
 	NetworkResourceType     ResourceType = "_network"
+
+	// RedisTopologyKey is the connection secret key holding the json encoded RedisTopology
+	RedisTopologyKey = "topology"
+
+	RedisTopologyTypeStandalone  = "standalone"
+	RedisTopologyTypeReplication = "replication"
+	RedisTopologyTypeSentinel    = "sentinel"
+	RedisTopologyTypeCluster     = "cluster"
 )
 
 type DeploymentDetails interface {
@@ -30,14 +41,28 @@ type DeploymentDetails interface {
 
 type BlobStorageInstance struct {
 	DeploymentDetails DeploymentDetails
+	// Artifacts are the artifacts the blob storage is provisioned with that expire, e.g. its access key
+	Artifacts []ExpiringArtifact
+}
+
+type QueueInstance struct {
+	DeploymentDetails DeploymentDetails
+	// Artifacts are the artifacts the queue is provisioned with that expire, e.g. its access key
+	Artifacts []ExpiringArtifact
 }
 
 type RedisCluster struct {
 	DeploymentDetails DeploymentDetails
+	// Cost is the estimated cost of the cluster, nil if it can't be estimated
+	Cost *Cost
 }
 
 type PostgresInstance struct {
 	DeploymentDetails DeploymentDetails
+	// Cost is the estimated cost of the instance, nil if it can't be estimated
+	Cost *Cost
+	// Artifacts are the artifacts the instance is provisioned with that expire, e.g. its tls certificate
+	Artifacts []ExpiringArtifact
 }
 
 type PostgresSnapshotInstance struct {
@@ -56,6 +81,14 @@ type BlobStorageProvider interface {
 	DeleteStorage(ctx context.Context, bs *v1alpha1.BlobStorage) (croType.StatusMessage, error)
 }
 
+type QueueProvider interface {
+	GetName() string
+	SupportsStrategy(s string) bool
+	GetReconcileTime(q *v1alpha1.Queue) time.Duration
+	CreateQueue(ctx context.Context, q *v1alpha1.Queue) (*QueueInstance, croType.StatusMessage, error)
+	DeleteQueue(ctx context.Context, q *v1alpha1.Queue) (croType.StatusMessage, error)
+}
+
 type RedisProvider interface {
 	GetName() string
 	SupportsStrategy(s string) bool
@@ -88,36 +121,115 @@ type RedisSnapshotProvider interface {
 	DeleteRedisSnapshot(ctx context.Context, snapshot *v1alpha1.RedisSnapshot, redis *v1alpha1.Redis) (croType.StatusMessage, error)
 }
 
+// Endpoint a host and port a client can connect to
+type Endpoint struct {
+	Host string `json:"host"`
+	Port int64  `json:"port"`
+}
+
+// RedisTopology describes how clients should connect to a redis deployment, it is stored as json in the
+// connection secret so sentinel and cluster aware clients don't need to infer it from a single uri
+type RedisTopology struct {
+	// Type is one of standalone, replication, sentinel or cluster
+	Type string `json:"type"`
+	// Primary is the endpoint accepting writes, not set for sentinel topologies where it must be discovered
+	Primary *Endpoint `json:"primary,omitempty"`
+	// ReadEndpoints are endpoints which can serve reads
+	ReadEndpoints []Endpoint `json:"readEndpoints,omitempty"`
+	// Sentinels and MasterName are used to discover the current primary in sentinel topologies
+	Sentinels  []Endpoint `json:"sentinels,omitempty"`
+	MasterName string     `json:"masterName,omitempty"`
+	// ClusterNodes are the seed nodes of a redis cluster
+	ClusterNodes []Endpoint `json:"clusterNodes,omitempty"`
+}
+
 // RedisDeploymentDetails provider specific details about the AWS Redis Cluster created
 type RedisDeploymentDetails struct {
 	URI  string
 	Port int64
+	// SentinelURI, SentinelPort and MasterName are only set for sentinel based topologies
+	SentinelURI  string
+	SentinelPort int64
+	MasterName   string
+	// Topology overrides the topology derived from the fields above
+	Topology *RedisTopology
 }
 
 //Data Redis provider Data function
 func (r *RedisDeploymentDetails) Data() map[string][]byte {
-	return map[string][]byte{
+	data := map[string][]byte{
 		"uri":  []byte(r.URI),
 		"port": []byte(strconv.FormatInt(r.Port, 10)),
 	}
+	if r.SentinelURI != "" {
+		data["sentinelUri"] = []byte(r.SentinelURI)
+		data["sentinelPort"] = []byte(strconv.FormatInt(r.SentinelPort, 10))
+		data["masterName"] = []byte(r.MasterName)
+	}
+	if topology, err := json.Marshal(r.GetTopology()); err == nil {
+		data[RedisTopologyKey] = topology
+	}
+	return data
+}
+
+// GetTopology returns the topology of the redis deployment, derived from the connection details if not set
+func (r *RedisDeploymentDetails) GetTopology() *RedisTopology {
+	if r.Topology != nil {
+		return r.Topology
+	}
+	if r.SentinelURI != "" {
+		return &RedisTopology{
+			Type:          RedisTopologyTypeSentinel,
+			ReadEndpoints: []Endpoint{{Host: r.URI, Port: r.Port}},
+			Sentinels:     []Endpoint{{Host: r.SentinelURI, Port: r.SentinelPort}},
+			MasterName:    r.MasterName,
+		}
+	}
+	return &RedisTopology{
+		Type:    RedisTopologyTypeStandalone,
+		Primary: &Endpoint{Host: r.URI, Port: r.Port},
+	}
 }
 
+// PostgresAuthModeIAM is the auth mode of a postgres whose users connect with an aws iam authentication token in place
+// of a password
+const PostgresAuthModeIAM = "iam"
+
 type PostgresDeploymentDetails struct {
 	Username string
 	Password string
 	Host     string
 	Database string
 	Port     int
+	// SSLMode and CACert are only set when the postgres is served over tls
+	SSLMode string
+	CACert  string
+	// AuthMode and Region are only set when users connect with a token in place of the password, the password isn't
+	// handed out then
+	AuthMode string
+	Region   string
 }
 
 func (d *PostgresDeploymentDetails) Data() map[string][]byte {
-	return map[string][]byte{
+	data := map[string][]byte{
 		"username": []byte(d.Username),
 		"password": []byte(d.Password),
 		"host":     []byte(d.Host),
 		"database": []byte(d.Database),
 		"port":     []byte(strconv.Itoa(d.Port)),
 	}
+	if d.AuthMode != "" {
+		delete(data, "password")
+		data["authMode"] = []byte(d.AuthMode)
+		data["region"] = []byte(d.Region)
+	}
+	if d.SSLMode != "" {
+		data["sslmode"] = []byte(d.SSLMode)
+	}
+	if d.CACert != "" {
+		data["ca.crt"] = []byte(d.CACert)
+	}
+	return data
 }
 
 // GenericCloudMetric is a wrapper to represent provider specific metrics generically
@@ -0,0 +1,44 @@
+package providers
+
+import (
+	"sort"
+	"strings"
+
+	errorUtil "github.com/pkg/errors"
+)
+
+// SupportedVersions maps the major versions a cr can request, e.g. "13", to the engine version of a provider, e.g. an
+// rds engine version, a cloud sql database version or a container image
+type SupportedVersions map[string]string
+
+// ResolveVersion returns the engine version of the version requested by a cr, from the supported versions of the
+// strategy or, if the strategy defines none, the defaults of the provider. An empty version resolves to an empty engine
+// version, leaving the engine version of the strategy in place
+func ResolveVersion(version string, supported, defaults SupportedVersions) (string, error) {
+	if version == "" {
+		return "", nil
+	}
+	if len(supported) == 0 {
+		supported = defaults
+	}
+	engineVersion, ok := supported[version]
+	if !ok || engineVersion == "" {
+		return "", errorUtil.Errorf("version %s is not supported, supported versions are %s", version, supported.versions())
+	}
+	return engineVersion, nil
+}
+
+// versions returns the sorted versions of the matrix as a comma separated list
+func (s SupportedVersions) versions() string {
+	versions := make([]string, 0, len(s))
+	for v, engineVersion := range s {
+		if engineVersion != "" {
+			versions = append(versions, v)
+		}
+	}
+	if len(versions) == 0 {
+		return "none"
+	}
+	sort.Strings(versions)
+	return strings.Join(versions, ", ")
+}
@@ -0,0 +1,68 @@
+package providers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveVersion(t *testing.T) {
+	defaults := SupportedVersions{"10": "10.18", "13": "13.4"}
+	tests := []struct {
+		name      string
+		version   string
+		supported SupportedVersions
+		want      string
+		wantErr   string
+	}{
+		{
+			name:    "test no engine version when no version is requested",
+			version: "",
+			want:    "",
+		},
+		{
+			name:    "test version is resolved from the defaults",
+			version: "13",
+			want:    "13.4",
+		},
+		{
+			name:      "test version is resolved from the strategy",
+			version:   "15",
+			supported: SupportedVersions{"15": "15.2"},
+			want:      "15.2",
+		},
+		{
+			name:      "test strategy replaces the defaults",
+			version:   "10",
+			supported: SupportedVersions{"13": "13.7", "15": "15.2"},
+			wantErr:   "version 10 is not supported, supported versions are 13, 15",
+		},
+		{
+			name:    "test unsupported version lists the defaults",
+			version: "9",
+			wantErr: "version 9 is not supported, supported versions are 10, 13",
+		},
+		{
+			name:      "test empty engine version is not supported",
+			version:   "13",
+			supported: SupportedVersions{"13": ""},
+			wantErr:   "version 13 is not supported, supported versions are none",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveVersion(tt.version, tt.supported, defaults)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ResolveVersion() error = %v, wantErr %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveVersion() unexpected error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveVersion() got = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package providersdk
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// eventRecorder records the events of the providers, set from the manager of the operator
+var eventRecorder record.EventRecorder
+
+// SetEventRecorder sets the recorder the events of the providers are recorded with
+func SetEventRecorder(recorder record.EventRecorder) {
+	eventRecorder = recorder
+}
+
+// Event records an event of a provider on a resource, nothing is recorded if the operator didn't set a recorder, e.g.
+// in tests
+func Event(inst runtime.Object, eventType, reason, format string, args ...interface{}) {
+	if eventRecorder == nil {
+		return
+	}
+	eventRecorder.Eventf(inst, eventType, reason, format, args...)
+}
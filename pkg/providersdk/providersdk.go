@@ -0,0 +1,46 @@
+// Package providersdk holds the patterns every provider implementation repeats, reading its strategy for a tier,
+// writing and removing secrets, reporting status and recording events, so a new provider only implements the calls to
+// its cloud and behaves like the existing providers
+package providersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ReadStrategy reads the strategy of a resource type and tier from the strategy config map of a provider into out,
+// the default config map is read if it doesn't exist
+func ReadStrategy(ctx context.Context, c client.Client, provider string, key types.NamespacedName, defaultCM *v1.ConfigMap, rt providers.ResourceType, tier string, out interface{}) error {
+	cm, err := resources.GetConfigMapOrDefault(ctx, c, key, defaultCM)
+	if err != nil {
+		return errorUtil.Wrapf(err, "failed to get %s strategy config map %s in namespace %s", provider, key.Name, key.Namespace)
+	}
+	return StrategyFromConfigMap(provider, cm, rt, tier, out)
+}
+
+// StrategyFromConfigMap reads the strategy of a resource type and tier from a strategy config map into out, merged over
+// the tiers it inherits from or read from the default tier if the tier isn't defined
+func StrategyFromConfigMap(provider string, cm *v1.ConfigMap, rt providers.ResourceType, tier string, out interface{}) error {
+	if cm.Data[string(rt)] == "" {
+		return errorUtil.New(fmt.Sprintf("%s strategy for resource type %s is not defined", provider, rt))
+	}
+	rawStrategy, _, err := providers.ResolveTier(cm.Data, rt, tier)
+	if err != nil {
+		return err
+	}
+	if rawStrategy == nil {
+		return errorUtil.New(fmt.Sprintf("no strategy found for deployment type %s and deployment tier %s", rt, tier))
+	}
+	if err := json.Unmarshal(rawStrategy, out); err != nil {
+		return errorUtil.Wrapf(err, "failed to unmarshal strategy of tier %s for resource type %s", tier, rt)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package providersdk
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	errorUtil "github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testFinalizer = "test.integreatly.org/finalizer"
+
+type testStrategy struct {
+	Region         string `json:"region"`
+	CreateStrategy struct {
+		Size int `json:"size"`
+	} `json:"createStrategy"`
+}
+
+func buildTestScheme(t *testing.T) *runtime.Scheme {
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	if err := v1alpha1.AddToScheme(s); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	return s
+}
+
+func TestStrategyFromConfigMap(t *testing.T) {
+	cm := &v1.ConfigMap{Data: map[string]string{
+		"postgres": `{"production": {"region": "eu-west-1", "createStrategy": {"size": 20}}, "custom": {"inherits": "production", "createStrategy": {"size": 50}}}`,
+	}}
+	tests := []struct {
+		name    string
+		rt      providers.ResourceType
+		tier    string
+		want    testStrategy
+		wantErr string
+	}{
+		{
+			name: "test tier is read",
+			rt:   providers.PostgresResourceType,
+			tier: "production",
+			want: testStrategy{Region: "eu-west-1", CreateStrategy: struct {
+				Size int `json:"size"`
+			}{Size: 20}},
+		},
+		{
+			name: "test tier is merged over the tier it inherits from",
+			rt:   providers.PostgresResourceType,
+			tier: "custom",
+			want: testStrategy{Region: "eu-west-1", CreateStrategy: struct {
+				Size int `json:"size"`
+			}{Size: 50}},
+		},
+		{
+			name:    "test resource type without strategies",
+			rt:      providers.RedisResourceType,
+			tier:    "production",
+			wantErr: "test strategy for resource type redis is not defined",
+		},
+		{
+			name:    "test undefined tier",
+			rt:      providers.PostgresResourceType,
+			tier:    "development",
+			wantErr: "no strategy found for deployment type postgres and deployment tier development",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := testStrategy{}
+			err := StrategyFromConfigMap("test", cm, tt.rt, tt.tier, &got)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("StrategyFromConfigMap() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("StrategyFromConfigMap() unexpected error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("StrategyFromConfigMap() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadStrategy_DefaultConfigMap(t *testing.T) {
+	c := fake.NewFakeClientWithScheme(buildTestScheme(t))
+	key := types.NamespacedName{Name: "test-strategies", Namespace: "test"}
+	defaultCM := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+		Data:       map[string]string{"postgres": `{"production": {"region": "eu-west-1"}}`},
+	}
+	got := testStrategy{}
+	if err := ReadStrategy(context.TODO(), c, "test", key, defaultCM, providers.PostgresResourceType, "production", &got); err != nil {
+		t.Fatalf("ReadStrategy() unexpected error = %v", err)
+	}
+	if got.Region != "eu-west-1" {
+		t.Errorf("ReadStrategy() = %+v, want the strategy of the default config map", got)
+	}
+}
+
+func TestDeleteSecret(t *testing.T) {
+	sec := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "test-credentials", Namespace: "test"}}
+	c := fake.NewFakeClientWithScheme(buildTestScheme(t), sec)
+
+	for i := 0; i < 2; i++ {
+		if err := DeleteSecret(context.TODO(), c, sec.Name, sec.Namespace); err != nil {
+			t.Fatalf("DeleteSecret() unexpected error = %v", err)
+		}
+	}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: sec.Name, Namespace: sec.Namespace}, &v1.Secret{}); err == nil {
+		t.Error("DeleteSecret() expected the secret to be deleted")
+	}
+}
+
+func TestFailed(t *testing.T) {
+	msg, err := Failed(errorUtil.New("timeout"), "failed to get instance %s", "test")
+	if msg != "failed to get instance test" || err.Error() != "failed to get instance test: timeout" {
+		t.Errorf("Failed() = %s, %v", msg, err)
+	}
+	msg, err = Failed(nil, "no ip address found")
+	if msg != "no ip address found" || err == nil || err.Error() != "no ip address found" {
+		t.Errorf("Failed() without a cause = %s, %v", msg, err)
+	}
+}
+
+func TestEvent(t *testing.T) {
+	defer SetEventRecorder(nil)
+	Event(&v1alpha1.Postgres{}, v1.EventTypeNormal, "Ignored", "nothing is recorded without a recorder")
+
+	recorder := record.NewFakeRecorder(1)
+	SetEventRecorder(recorder)
+	Event(&v1alpha1.Postgres{}, v1.EventTypeNormal, "InstanceCreated", "created instance %s", "test")
+	if got := <-recorder.Events; got != "Normal InstanceCreated created instance test" {
+		t.Errorf("Event() recorded %q", got)
+	}
+}
@@ -0,0 +1,33 @@
+package providersdk
+
+import (
+	"context"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// EnsureCredentialSecret creates the credential secret of a cloud resource, the values of the generators are only
+// generated once, when the secret is created. The status message is set if the secret can't be created
+func EnsureCredentialSecret(ctx context.Context, c client.Client, sec *v1.Secret, generators map[string]resources.SecretValueGenerator) (*v1.Secret, croType.StatusMessage, error) {
+	found, err := resources.EnsureCredentialSecret(ctx, c, sec, generators)
+	if err != nil {
+		msg, err := Failed(err, "failed to create or update secret %s", sec.Name)
+		return nil, msg, err
+	}
+	return found, croType.StatusEmpty, nil
+}
+
+// DeleteSecret removes a secret written by a provider, a secret which doesn't exist is already deleted
+func DeleteSecret(ctx context.Context, c client.Client, name, namespace string) error {
+	sec := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	if err := c.Delete(ctx, sec); err != nil && !k8serr.IsNotFound(err) {
+		return errorUtil.Wrapf(err, "failed to delete secret %s", name)
+	}
+	return nil
+}
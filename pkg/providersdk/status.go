@@ -0,0 +1,32 @@
+package providersdk
+
+import (
+	"fmt"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	errorUtil "github.com/pkg/errors"
+)
+
+// Failed returns the status message and error of a failed step of a reconcile, the message is the cause the error is
+// wrapped with
+func Failed(err error, format string, args ...interface{}) (croType.StatusMessage, error) {
+	msg := fmt.Sprintf(format, args...)
+	if err == nil {
+		return croType.StatusMessage(msg), errorUtil.New(msg)
+	}
+	return croType.StatusMessage(msg), errorUtil.Wrap(err, msg)
+}
+
+// InProgress returns the status message of a reconcile waiting for the cloud resource
+func InProgress(format string, args ...interface{}) croType.StatusMessage {
+	return croType.StatusMessage(fmt.Sprintf(format, args...))
+}
+
+// SetCloudResource records the cloud resource a resource is provisioned with in its status
+func SetCloudResource(status *croType.ResourceTypeStatus, instanceID, endpoint, region string) {
+	status.CloudResource = &croType.CloudResourceStatus{
+		InstanceID: instanceID,
+		Endpoint:   endpoint,
+		Region:     region,
+	}
+}
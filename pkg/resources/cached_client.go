@@ -0,0 +1,66 @@
+package resources
+
+import (
+	"context"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources/metrics"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CachedClient reads config maps and secrets, e.g. strategy config maps and connection secrets, from the shared
+// informer cache of the manager instead of the api server, so reconciling a large number of resources doesn't get them
+// from the api server on every reconcile. All other reads and every write go to the api server.
+//
+// Reads the cache can't serve, e.g. before the manager is started, of objects outside the namespace the cache watches
+// or of objects created so recently the cache hasn't seen them yet, fall back to the api server, so they behave as if
+// the client wasn't cached
+type CachedClient struct {
+	client.Client
+	cache client.Reader
+}
+
+var _ client.Client = (*CachedClient)(nil)
+
+// NewCachedClient returns a client reading config maps and secrets from the cache and everything else with c
+func NewCachedClient(c client.Client, cache client.Reader) *CachedClient {
+	return &CachedClient{Client: c, cache: cache}
+}
+
+func (c *CachedClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	kind, ok := cachedKind(obj)
+	if !ok {
+		return c.Client.Get(ctx, key, obj)
+	}
+	if err := c.cache.Get(ctx, key, obj); err == nil {
+		metrics.IncCachedReads(kind, false)
+		return nil
+	}
+	metrics.IncCachedReads(kind, true)
+	return c.Client.Get(ctx, key, obj)
+}
+
+func (c *CachedClient) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	kind, ok := cachedKind(list)
+	if !ok {
+		return c.Client.List(ctx, list, opts...)
+	}
+	if err := c.cache.List(ctx, list, opts...); err == nil {
+		metrics.IncCachedReads(kind, false)
+		return nil
+	}
+	metrics.IncCachedReads(kind, true)
+	return c.Client.List(ctx, list, opts...)
+}
+
+// cachedKind returns the kind of the object if it's read from the cache
+func cachedKind(obj runtime.Object) (string, bool) {
+	switch obj.(type) {
+	case *v1.ConfigMap, *v1.ConfigMapList:
+		return "ConfigMap", true
+	case *v1.Secret, *v1.SecretList:
+		return "Secret", true
+	}
+	return "", false
+}
@@ -0,0 +1,86 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources/metrics"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	customMetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+func getCachedReadsMetricValue(t *testing.T, name, kind string) float64 {
+	families, err := customMetrics.Registry.Gather()
+	if err != nil {
+		t.Fatal("failed to gather metrics", err)
+	}
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "kind" && l.GetValue() == kind {
+					return m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func TestCachedClient(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	cached := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "cached", Namespace: "test"},
+		Data:       map[string]string{"source": "cache"},
+	}
+	created := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "created", Namespace: "test"},
+	}
+	apiCached := cached.DeepCopy()
+	apiCached.Data["source"] = "api"
+	cache := fake.NewFakeClientWithScheme(scheme, cached)
+	api := fake.NewFakeClientWithScheme(scheme, apiCached, created, &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "test"}})
+	c := NewCachedClient(api, cache)
+	ctx := context.TODO()
+
+	reads, misses := getCachedReadsMetricValue(t, metrics.CachedReadsMetricName, "ConfigMap"), getCachedReadsMetricValue(t, metrics.CachedReadMissesMetricName, "ConfigMap")
+	cm := &v1.ConfigMap{}
+	if err := c.Get(ctx, types.NamespacedName{Name: "cached", Namespace: "test"}, cm); err != nil {
+		t.Fatal("failed to get config map", err)
+	}
+	if cm.Data["source"] != "cache" {
+		t.Errorf("Get() read config map from %s, want cache", cm.Data["source"])
+	}
+	// a config map the cache hasn't seen yet is read from the api server
+	if err := c.Get(ctx, types.NamespacedName{Name: "created", Namespace: "test"}, &v1.ConfigMap{}); err != nil {
+		t.Errorf("Get() error = %v for a config map missing from the cache, want it read from the api server", err)
+	}
+	cms := &v1.ConfigMapList{}
+	if err := c.List(ctx, cms, client.InNamespace("test")); err != nil {
+		t.Fatal("failed to list config maps", err)
+	}
+	if len(cms.Items) != 1 {
+		t.Errorf("List() returned %d config maps, want the 1 in the cache", len(cms.Items))
+	}
+	if got := getCachedReadsMetricValue(t, metrics.CachedReadsMetricName, "ConfigMap") - reads; got != 3 {
+		t.Errorf("cached reads = %v, want 3", got)
+	}
+	if got := getCachedReadsMetricValue(t, metrics.CachedReadMissesMetricName, "ConfigMap") - misses; got != 1 {
+		t.Errorf("cached reads misses = %v, want 1", got)
+	}
+
+	// other kinds aren't read from the cache
+	if err := c.Get(ctx, types.NamespacedName{Name: "pod", Namespace: "test"}, &v1.Pod{}); err != nil {
+		t.Errorf("Get() error = %v for a pod, want it read from the api server", err)
+	}
+}
@@ -39,6 +39,18 @@ func GetAWSRegion(ctx context.Context, c client.Client) (string, error) {
 	return "", errorUtil.New("infrastructure does not container aws region")
 }
 
+// GetGCPPlatformStatus returns the project and region the cluster runs in
+func GetGCPPlatformStatus(ctx context.Context, c client.Client) (*v1.GCPPlatformStatus, error) {
+	infra, err := GetClusterInfrastructure(ctx, c)
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failure happened while retrieving cluster infrastructure")
+	}
+	if infra.Status.PlatformStatus != nil && infra.Status.PlatformStatus.Type == v1.GCPPlatformType && infra.Status.PlatformStatus.GCP != nil {
+		return infra.Status.PlatformStatus.GCP, nil
+	}
+	return nil, errorUtil.New("infrastructure does not contain gcp platform status")
+}
+
 func GetClusterInfrastructure(ctx context.Context, c client.Client) (*v1.Infrastructure, error) {
 	infra := &v1.Infrastructure{}
 	if err := c.Get(ctx, types.NamespacedName{Name: "cluster"}, infra); err != nil {
@@ -0,0 +1,85 @@
+package resources
+
+import (
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// the standard conditions of every resource, derived from its phase, so tools which only understand conditions, e.g.
+// kubectl wait or argo cd health checks, treat every resource type the same way
+const (
+	// ReadyCondition is true once the resource is provisioned and its connection secret is written
+	ReadyCondition = "Ready"
+	// ProvisioningCondition is true while the resource is being provisioned or updated
+	ProvisioningCondition = "Provisioning"
+	// DegradedCondition is true while the resource failed to reconcile, or can't be connected to once it's complete
+	DegradedCondition = "Degraded"
+	// DeletionBlockedCondition is true while the deletion of the resource is paused or failing
+	DeletionBlockedCondition = "DeletionBlocked"
+
+	// deletionThrottledCondition is set by the deletion rate limit, see providers.DeletionThrottledCondition
+	deletionThrottledCondition = "DeletionThrottled"
+)
+
+// phaseReasons are the reasons of the standard conditions for each phase
+var phaseReasons = map[croType.StatusPhase]string{
+	croType.PhaseComplete:         "Complete",
+	croType.PhaseInProgress:       "InProgress",
+	croType.PhaseDeleteInProgress: "DeleteInProgress",
+	croType.PhasePaused:           "Paused",
+	croType.PhaseFailed:           "Failed",
+}
+
+// SetPhaseConditions sets the ready, provisioning, degraded and deletion blocked conditions of the resource from its
+// phase and message. The status is persisted with the rest of the resource status
+func SetPhaseConditions(inst metav1.Object, status *croType.ResourceTypeStatus) {
+	reason, ok := phaseReasons[status.Phase]
+	if !ok {
+		reason = "Pending"
+	}
+	msg := string(status.Message)
+	set := func(condType string, isTrue bool, falseReason string) {
+		cond := metav1.Condition{
+			Type:               condType,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: inst.GetGeneration(),
+			Reason:             reason,
+			Message:            msg,
+		}
+		if isTrue {
+			cond.Status = metav1.ConditionTrue
+		} else if falseReason != "" {
+			cond.Reason = falseReason
+			cond.Message = ""
+		}
+		meta.SetStatusCondition(&status.Conditions, cond)
+	}
+
+	deleting := inst.GetDeletionTimestamp() != nil
+	set(ReadyCondition, status.Phase == croType.PhaseComplete && !deleting, "")
+	set(ProvisioningCondition, status.Phase == croType.PhaseInProgress && !deleting, "")
+	// a complete resource which can't be connected to is degraded, with the reason of the failed connectivity check
+	if failed := connectionFailed(status); failed != nil && status.Phase == croType.PhaseComplete && !deleting {
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               DegradedCondition,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: inst.GetGeneration(),
+			Reason:             failed.Reason,
+			Message:            failed.Message,
+		})
+	} else {
+		set(DegradedCondition, status.Phase == croType.PhaseFailed, "AsExpected")
+	}
+
+	if !deleting {
+		set(DeletionBlockedCondition, false, "NotDeleting")
+		return
+	}
+	if throttled := meta.FindStatusCondition(status.Conditions, deletionThrottledCondition); throttled != nil && throttled.Status == metav1.ConditionTrue {
+		reason, msg = throttled.Reason, throttled.Message
+		set(DeletionBlockedCondition, true, "")
+		return
+	}
+	set(DeletionBlockedCondition, status.Phase == croType.PhasePaused || status.Phase == croType.PhaseFailed, "Deleting")
+}
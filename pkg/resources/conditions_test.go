@@ -0,0 +1,135 @@
+package resources
+
+import (
+	"testing"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetPhaseConditions(t *testing.T) {
+	now := metav1.Now()
+	deleting := &metav1.ObjectMeta{Name: "test", DeletionTimestamp: &now}
+	tests := []struct {
+		name     string
+		inst     metav1.Object
+		status   croType.ResourceTypeStatus
+		expected map[string]metav1.ConditionStatus
+		reason   string
+	}{
+		{
+			name:   "test complete resource is ready",
+			inst:   &metav1.ObjectMeta{Name: "test"},
+			status: croType.ResourceTypeStatus{Phase: croType.PhaseComplete},
+			expected: map[string]metav1.ConditionStatus{
+				ReadyCondition:           metav1.ConditionTrue,
+				ProvisioningCondition:    metav1.ConditionFalse,
+				DegradedCondition:        metav1.ConditionFalse,
+				DeletionBlockedCondition: metav1.ConditionFalse,
+			},
+			reason: "Complete",
+		},
+		{
+			name:   "test resource in progress is provisioning",
+			inst:   &metav1.ObjectMeta{Name: "test"},
+			status: croType.ResourceTypeStatus{Phase: croType.PhaseInProgress},
+			expected: map[string]metav1.ConditionStatus{
+				ReadyCondition:        metav1.ConditionFalse,
+				ProvisioningCondition: metav1.ConditionTrue,
+				DegradedCondition:     metav1.ConditionFalse,
+			},
+			reason: "InProgress",
+		},
+		{
+			name:   "test failed resource is degraded",
+			inst:   &metav1.ObjectMeta{Name: "test"},
+			status: croType.ResourceTypeStatus{Phase: croType.PhaseFailed},
+			expected: map[string]metav1.ConditionStatus{
+				ReadyCondition:        metav1.ConditionFalse,
+				ProvisioningCondition: metav1.ConditionFalse,
+				DegradedCondition:     metav1.ConditionTrue,
+			},
+			reason: "Failed",
+		},
+		{
+			name:   "test resource without a phase is pending",
+			inst:   &metav1.ObjectMeta{Name: "test"},
+			status: croType.ResourceTypeStatus{},
+			expected: map[string]metav1.ConditionStatus{
+				ReadyCondition: metav1.ConditionFalse,
+			},
+			reason: "Pending",
+		},
+		{
+			name:   "test deleted resource isn't ready",
+			inst:   deleting,
+			status: croType.ResourceTypeStatus{Phase: croType.PhaseDeleteInProgress},
+			expected: map[string]metav1.ConditionStatus{
+				ReadyCondition:           metav1.ConditionFalse,
+				DeletionBlockedCondition: metav1.ConditionFalse,
+			},
+			reason: "DeleteInProgress",
+		},
+		{
+			name: "test throttled deletion is blocked",
+			inst: deleting,
+			status: croType.ResourceTypeStatus{
+				Phase: croType.PhasePaused,
+				Conditions: []metav1.Condition{
+					{Type: "DeletionThrottled", Status: metav1.ConditionTrue, Reason: "MassDeletion"},
+				},
+			},
+			expected: map[string]metav1.ConditionStatus{
+				ReadyCondition:           metav1.ConditionFalse,
+				DeletionBlockedCondition: metav1.ConditionTrue,
+			},
+			reason: "Paused",
+		},
+		{
+			name: "test complete resource which can't be connected to is degraded",
+			inst: &metav1.ObjectMeta{Name: "test"},
+			status: croType.ResourceTypeStatus{
+				Phase:      croType.PhaseComplete,
+				Conditions: []metav1.Condition{{Type: ConnectedCondition, Status: metav1.ConditionFalse, Reason: connectionFailedReason}},
+			},
+			expected: map[string]metav1.ConditionStatus{
+				ReadyCondition:    metav1.ConditionTrue,
+				DegradedCondition: metav1.ConditionTrue,
+			},
+			reason: "Complete",
+		},
+		{
+			name:   "test failing deletion is blocked",
+			inst:   deleting,
+			status: croType.ResourceTypeStatus{Phase: croType.PhaseFailed},
+			expected: map[string]metav1.ConditionStatus{
+				DegradedCondition:        metav1.ConditionTrue,
+				DeletionBlockedCondition: metav1.ConditionTrue,
+			},
+			reason: "Failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetPhaseConditions(tt.inst, &tt.status)
+			for condType, want := range tt.expected {
+				cond := meta.FindStatusCondition(tt.status.Conditions, condType)
+				if cond == nil || cond.Status != want {
+					t.Errorf("SetPhaseConditions() %s = %v, want %s", condType, cond, want)
+				}
+			}
+			if ready := meta.FindStatusCondition(tt.status.Conditions, ReadyCondition); ready.Reason != tt.reason {
+				t.Errorf("SetPhaseConditions() ready reason = %s, want %s", ready.Reason, tt.reason)
+			}
+		})
+	}
+	status := &croType.ResourceTypeStatus{
+		Phase:      croType.PhasePaused,
+		Conditions: []metav1.Condition{{Type: "DeletionThrottled", Status: metav1.ConditionTrue, Reason: "MassDeletion", Message: "deletion paused"}},
+	}
+	SetPhaseConditions(deleting, status)
+	if blocked := meta.FindStatusCondition(status.Conditions, DeletionBlockedCondition); blocked.Reason != "MassDeletion" || blocked.Message != "deletion paused" {
+		t.Errorf("SetPhaseConditions() deletion blocked = %v, want the reason of the throttled deletion", blocked)
+	}
+}
@@ -1,13 +1,12 @@
 package resources
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"os"
 	"strconv"
-	"strings"
 	"time"
 
-	"github.com/google/uuid"
-
 	errorUtil "github.com/pkg/errors"
 )
 
@@ -46,12 +45,19 @@ func GetMetricReconcileTimeOrDefault(defaultTo time.Duration) time.Duration {
 	return defaultTo
 }
 
+// GeneratePassword returns a password following the password policy of the operator
 func GeneratePassword() (string, error) {
-	generatedPassword, err := uuid.NewRandom()
-	if err != nil {
-		return "", errorUtil.Wrap(err, "error generating password")
+	return CurrentPasswordPolicy.Generate()
+}
+
+// GenerateUsername returns the prefix followed by random hex characters, so the username is valid for databases as
+// long as the prefix starts with a letter
+func GenerateUsername(prefix string) (string, error) {
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", errorUtil.Wrap(err, "error generating username")
 	}
-	return strings.Replace(generatedPassword.String(), "-", "", 10), nil
+	return prefix + hex.EncodeToString(suffix), nil
 }
 
 func GetOrganizationTag() string {
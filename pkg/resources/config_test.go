@@ -2,6 +2,7 @@ package resources
 
 import (
 	"os"
+	"regexp"
 	"testing"
 	"time"
 )
@@ -64,3 +65,20 @@ func TestGetOrganizationTag(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateUsername(t *testing.T) {
+	first, err := GenerateUsername("user")
+	if err != nil {
+		t.Fatalf("GenerateUsername() unexpected error = %v", err)
+	}
+	second, err := GenerateUsername("user")
+	if err != nil {
+		t.Fatalf("GenerateUsername() unexpected error = %v", err)
+	}
+	if !regexp.MustCompile("^user[0-9a-f]{12}$").MatchString(first) {
+		t.Errorf("GenerateUsername() = %s, want prefix followed by 12 hex characters", first)
+	}
+	if first == second {
+		t.Errorf("GenerateUsername() returned %s twice", first)
+	}
+}
@@ -0,0 +1,188 @@
+package resources
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	// registers the postgres driver the postgres probe connects with
+	_ "github.com/lib/pq"
+	errorUtil "github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ConnectedCondition is true while a connection can be opened to the endpoint of the resource, a complete resource
+	// which can't be connected to is degraded
+	ConnectedCondition = "Connected"
+
+	// DefaultConnectivityCheckTimeout is how long a connectivity check waits for the endpoint of a resource to answer
+	DefaultConnectivityCheckTimeout = 10 * time.Second
+
+	connectedReason         = "ConnectionSucceeded"
+	connectionFailedReason  = "ConnectionFailed"
+	connectionSkippedReason = "CheckDisabled"
+)
+
+// ConnectivityCheckTimeout is how long connectivity checks wait, set from the flags of the operator. 0 disables the
+// checks, resources are then complete once their provider reports them ready
+var ConnectivityCheckTimeout = DefaultConnectivityCheckTimeout
+
+// ConnectivityProbe opens a connection to the endpoint of a resource and returns an error if the endpoint doesn't
+// answer as expected
+type ConnectivityProbe struct {
+	// Endpoint is the host:port pair the probe connects to
+	Endpoint string
+	Probe    func(ctx context.Context) error
+}
+
+// Observed returns the probe calling observe with how long each run of the probe took and its error, e.g. to export
+// the latency and availability of the resource
+func (p ConnectivityProbe) Observed(observe func(duration time.Duration, err error)) ConnectivityProbe {
+	probe := p.Probe
+	p.Probe = func(ctx context.Context) error {
+		start := time.Now()
+		err := probe(ctx)
+		observe(time.Since(start), err)
+		return err
+	}
+	return p
+}
+
+// TCPProbe returns a probe opening a tcp connection to the endpoint
+func TCPProbe(host string, port int) ConnectivityProbe {
+	endpoint := net.JoinHostPort(host, strconv.Itoa(port))
+	return ConnectivityProbe{Endpoint: endpoint, Probe: func(ctx context.Context) error {
+		conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", endpoint)
+		if err != nil {
+			return errorUtil.Wrapf(err, "failed to open tcp connection to %s", endpoint)
+		}
+		return conn.Close()
+	}}
+}
+
+// PostgresProbe returns a probe logging in to the database of the postgres with the user and password, so wrong
+// credentials fail the probe too. Postgres served over tls is connected to over tls, without verifying its certificate
+func PostgresProbe(host string, port int, database, user, password string, useTLS bool) ConnectivityProbe {
+	endpoint := net.JoinHostPort(host, strconv.Itoa(port))
+	sslMode := "disable"
+	if useTLS {
+		sslMode = "require"
+	}
+	dsn := (&url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(user, password),
+		Host:     endpoint,
+		Path:     database,
+		RawQuery: url.Values{"sslmode": []string{sslMode}}.Encode(),
+	}).String()
+	return ConnectivityProbe{Endpoint: endpoint, Probe: func(ctx context.Context) error {
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			return errorUtil.Wrapf(err, "failed to open postgres connection to %s", endpoint)
+		}
+		defer db.Close()
+		if err := db.PingContext(ctx); err != nil {
+			return errorUtil.Wrapf(err, "failed to ping postgres %s", endpoint)
+		}
+		return nil
+	}}
+}
+
+// RedisProbe returns a probe sending a PING to the redis, over tls if the redis doesn't answer in plain text. A redis
+// requiring authentication answers the PING with an error, which still shows it's serving
+func RedisProbe(host string, port int) ConnectivityProbe {
+	endpoint := net.JoinHostPort(host, strconv.Itoa(port))
+	return ConnectivityProbe{Endpoint: endpoint, Probe: func(ctx context.Context) error {
+		dialer := &net.Dialer{}
+		if deadline, ok := ctx.Deadline(); ok {
+			dialer.Deadline = deadline
+		}
+		err := redisPing(ctx, func() (net.Conn, error) { return dialer.DialContext(ctx, "tcp", endpoint) })
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+		// a redis encrypted in transit closes plain text connections
+		tlsErr := redisPing(ctx, func() (net.Conn, error) {
+			return tls.DialWithDialer(dialer, "tcp", endpoint, &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12})
+		})
+		if tlsErr != nil {
+			return err
+		}
+		return nil
+	}}
+}
+
+func redisPing(ctx context.Context, dial func() (net.Conn, error)) error {
+	conn, err := dial()
+	if err != nil {
+		return errorUtil.Wrap(err, "failed to open redis connection")
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return errorUtil.Wrap(err, "failed to set redis connection deadline")
+		}
+	}
+	if _, err := conn.Write([]byte("*1\r\n$4\r\nPING\r\n")); err != nil {
+		return errorUtil.Wrap(err, "failed to send redis ping")
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return errorUtil.Wrap(err, "failed to read redis ping reply")
+	}
+	reply = strings.TrimSpace(reply)
+	if reply == "+PONG" || strings.HasPrefix(reply, "-NOAUTH") {
+		return nil
+	}
+	return errorUtil.Errorf("unexpected redis ping reply %s", reply)
+}
+
+// CheckConnectivity runs the probe of the resource and records the result in its connected condition. It returns a
+// message and false if the resource can't be connected to. The probe isn't run if the checks are disabled
+func CheckConnectivity(ctx context.Context, inst metav1.Object, status *croType.ResourceTypeStatus, probe ConnectivityProbe) (croType.StatusMessage, bool) {
+	cond := metav1.Condition{
+		Type:               ConnectedCondition,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: inst.GetGeneration(),
+		Reason:             connectedReason,
+		Message:            fmt.Sprintf("connected to %s", probe.Endpoint),
+	}
+	if ConnectivityCheckTimeout <= 0 {
+		cond.Status = metav1.ConditionUnknown
+		cond.Reason = connectionSkippedReason
+		cond.Message = ""
+		meta.SetStatusCondition(&status.Conditions, cond)
+		return croType.StatusEmpty, true
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, ConnectivityCheckTimeout)
+	defer cancel()
+	if err := probe.Probe(probeCtx); err != nil {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = connectionFailedReason
+		cond.Message = Redact(err.Error())
+		meta.SetStatusCondition(&status.Conditions, cond)
+		return croType.StatusMessage(fmt.Sprintf("failed to connect to %s: %s", probe.Endpoint, cond.Message)), false
+	}
+	meta.SetStatusCondition(&status.Conditions, cond)
+	return croType.StatusEmpty, true
+}
+
+// connectionFailed returns the connected condition of the resource if its last connectivity check failed
+func connectionFailed(status *croType.ResourceTypeStatus) *metav1.Condition {
+	cond := meta.FindStatusCondition(status.Conditions, ConnectedCondition)
+	if cond == nil || cond.Status != metav1.ConditionFalse {
+		return nil
+	}
+	return cond
+}
@@ -0,0 +1,163 @@
+package resources
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// serveTestRedis serves a redis answering every command with the reply, it returns the host and port of the redis
+func serveTestRedis(t *testing.T, reply string) (string, int) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("failed to listen", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				// *1, $4 and PING
+				for i := 0; i < 3; i++ {
+					if _, err := r.ReadString('\n'); err != nil {
+						return
+					}
+				}
+				_, _ = conn.Write([]byte(reply + "\r\n"))
+			}()
+		}
+	}()
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return host, p
+}
+
+// closedTestPort returns a port nothing listens on
+func closedTestPort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("failed to listen", err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+	return port
+}
+
+func TestRedisProbe(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   string
+		wantErr bool
+	}{
+		{
+			name:  "test redis answering the ping",
+			reply: "+PONG",
+		},
+		{
+			name:  "test redis requiring authentication is serving",
+			reply: "-NOAUTH Authentication required.",
+		},
+		{
+			name:    "test redis loading its data",
+			reply:   "-LOADING Redis is loading the dataset in memory",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, port := serveTestRedis(t, tt.reply)
+			ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+			defer cancel()
+			if err := RedisProbe(host, port).Probe(ctx); (err != nil) != tt.wantErr {
+				t.Errorf("RedisProbe() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckConnectivity(t *testing.T) {
+	host, port := serveTestRedis(t, "+PONG")
+	closedPort := closedTestPort(t)
+	tests := []struct {
+		name          string
+		probe         ConnectivityProbe
+		timeout       time.Duration
+		wantConnected bool
+		wantStatus    metav1.ConditionStatus
+	}{
+		{
+			name:          "test reachable endpoint is connected",
+			probe:         TCPProbe(host, port),
+			timeout:       DefaultConnectivityCheckTimeout,
+			wantConnected: true,
+			wantStatus:    metav1.ConditionTrue,
+		},
+		{
+			name:       "test unreachable endpoint is not connected",
+			probe:      TCPProbe("127.0.0.1", closedPort),
+			timeout:    DefaultConnectivityCheckTimeout,
+			wantStatus: metav1.ConditionFalse,
+		},
+		{
+			name:          "test disabled checks don't probe the endpoint",
+			probe:         TCPProbe("127.0.0.1", closedPort),
+			wantConnected: true,
+			wantStatus:    metav1.ConditionUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(timeout time.Duration) { ConnectivityCheckTimeout = timeout }(ConnectivityCheckTimeout)
+			ConnectivityCheckTimeout = tt.timeout
+			status := &croType.ResourceTypeStatus{}
+			msg, connected := CheckConnectivity(context.TODO(), &metav1.ObjectMeta{Name: "test"}, status, tt.probe)
+			if connected != tt.wantConnected {
+				t.Fatalf("CheckConnectivity() = %s, %v, want connected %v", msg, connected, tt.wantConnected)
+			}
+			if cond := meta.FindStatusCondition(status.Conditions, ConnectedCondition); cond == nil || cond.Status != tt.wantStatus {
+				t.Errorf("CheckConnectivity() connected condition = %v, want %s", cond, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestConnectivityProbe_Observed(t *testing.T) {
+	var observed []error
+	probe := ConnectivityProbe{Endpoint: "test:5432", Probe: func(ctx context.Context) error {
+		if len(observed) > 0 {
+			return errors.New("connection refused")
+		}
+		return nil
+	}}.Observed(func(duration time.Duration, err error) {
+		if duration < 0 {
+			t.Errorf("Observed() duration = %s", duration)
+		}
+		observed = append(observed, err)
+	})
+
+	if err := probe.Probe(context.TODO()); err != nil {
+		t.Fatalf("Probe() unexpected error = %v", err)
+	}
+	if err := probe.Probe(context.TODO()); err == nil {
+		t.Fatal("Probe() expected the error of the probe")
+	}
+	if len(observed) != 2 || observed[0] != nil || observed[1] == nil {
+		t.Errorf("Observed() observed %v, want a successful and a failed run", observed)
+	}
+	if probe.Endpoint != "test:5432" {
+		t.Errorf("Observed() endpoint = %s, want the endpoint of the probe", probe.Endpoint)
+	}
+}
@@ -0,0 +1,103 @@
+package resources
+
+import (
+	"context"
+
+	errorUtil "github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SecretValueGenerator generates the value of a key of a credential secret, e.g. a password
+type SecretValueGenerator func() (string, error)
+
+// StaticSecretValue returns a generator of a fixed value, e.g. a default user
+func StaticSecretValue(value string) SecretValueGenerator {
+	return func() (string, error) {
+		return value, nil
+	}
+}
+
+// EnsureCredentialSecret creates the credential secret with a generated value for each of the keys, or generates the
+// keys missing from the existing secret. Values already in the secret are never replaced, so the credentials are only
+// generated once and concurrent reconciles, which generate different values, converge on the values written first.
+//
+// The secret is created, or updated with the resource version it was read at, and a create racing another create or
+// an update racing another update is retried against the latest version of the secret. The returned secret holds the
+// credentials that were stored, which aren't necessarily the ones generated by this call.
+//
+// The operator wide metadata is set on the secret, whether or not keys were missing.
+//
+// The stored values of the secret keys of the generators, e.g. passwords, are registered as secret values so they're
+// redacted from logs. Values that are generated but lose the race are never registered
+func EnsureCredentialSecret(ctx context.Context, c client.Client, desired *v1.Secret, generators map[string]SecretValueGenerator) (*v1.Secret, error) {
+	key := types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}
+	sec := &v1.Secret{}
+	err := retry.OnError(retry.DefaultRetry, isCredentialSecretRace, func() error {
+		sec = &v1.Secret{}
+		if err := c.Get(ctx, key, sec); err != nil {
+			if !k8serr.IsNotFound(err) {
+				return err
+			}
+			sec = desired.DeepCopy()
+			sec.ResourceVersion = ""
+			if _, err := generateMissingSecretValues(sec, generators); err != nil {
+				return err
+			}
+			if err := ApplyMetadata(ctx, c, sec); err != nil {
+				return err
+			}
+			return c.Create(ctx, sec)
+		}
+		generated, err := generateMissingSecretValues(sec, generators)
+		if err != nil {
+			return err
+		}
+		md, err := GetMetadata(ctx, c)
+		if err != nil {
+			return err
+		}
+		if !md.Apply(sec) && !generated {
+			return nil
+		}
+		return c.Update(ctx, sec)
+	})
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to ensure credential secret %s", key.Name)
+	}
+	for k := range generators {
+		if IsSecretKey(k) {
+			RegisterSecretValue(string(sec.Data[k]))
+		}
+	}
+	return sec, nil
+}
+
+// generateMissingSecretValues sets the keys missing from the secret to generated values, it returns true if any were
+// missing
+func generateMissingSecretValues(sec *v1.Secret, generators map[string]SecretValueGenerator) (bool, error) {
+	if sec.Data == nil {
+		sec.Data = map[string][]byte{}
+	}
+	generated := false
+	for k, generate := range generators {
+		if len(sec.Data[k]) > 0 {
+			continue
+		}
+		value, err := generate()
+		if err != nil {
+			return false, errorUtil.Wrapf(err, "failed to generate %s", k)
+		}
+		sec.Data[k] = []byte(value)
+		generated = true
+	}
+	return generated, nil
+}
+
+// isCredentialSecretRace returns true if the write of a credential secret lost a race with another write of it
+func isCredentialSecretRace(err error) bool {
+	return k8serr.IsConflict(err) || k8serr.IsAlreadyExists(err)
+}
@@ -0,0 +1,120 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// racingClient writes the secret of a concurrent reconcile before the first create or update of the secret, which
+// then loses the race
+type racingClient struct {
+	client.Client
+	concurrent func(ctx context.Context, c client.Client) error
+}
+
+func (c *racingClient) race(ctx context.Context, lost error) error {
+	if c.concurrent == nil {
+		return nil
+	}
+	concurrent := c.concurrent
+	c.concurrent = nil
+	if err := concurrent(ctx, c.Client); err != nil {
+		return err
+	}
+	return lost
+}
+
+func (c *racingClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	if err := c.race(ctx, k8serr.NewAlreadyExists(schema.GroupResource{Resource: "secrets"}, "test")); err != nil {
+		return err
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *racingClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if err := c.race(ctx, k8serr.NewConflict(schema.GroupResource{Resource: "secrets"}, "test", nil)); err != nil {
+		return err
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func TestEnsureCredentialSecret(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	buildSecret := func(data map[string]string) *v1.Secret {
+		sec := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"}, Data: map[string][]byte{}}
+		for k, v := range data {
+			sec.Data[k] = []byte(v)
+		}
+		return sec
+	}
+	generators := map[string]SecretValueGenerator{
+		"user":     StaticSecretValue("postgres"),
+		"password": StaticSecretValue("generated"),
+	}
+
+	tests := []struct {
+		name         string
+		existing     []runtime.Object
+		concurrent   func(ctx context.Context, c client.Client) error
+		wantPassword string
+	}{
+		{
+			name:         "test the secret is created with generated credentials",
+			wantPassword: "generated",
+		},
+		{
+			name:         "test credentials in the existing secret are kept",
+			existing:     []runtime.Object{buildSecret(map[string]string{"user": "postgres", "password": "existing"})},
+			wantPassword: "existing",
+		},
+		{
+			name: "test the credentials of a concurrent create are kept",
+			concurrent: func(ctx context.Context, c client.Client) error {
+				return c.Create(ctx, buildSecret(map[string]string{"user": "postgres", "password": "concurrent"}))
+			},
+			wantPassword: "concurrent",
+		},
+		{
+			name:     "test the credentials of a concurrent update are kept",
+			existing: []runtime.Object{buildSecret(map[string]string{"user": "postgres"})},
+			concurrent: func(ctx context.Context, c client.Client) error {
+				return c.Update(ctx, buildSecret(map[string]string{"user": "postgres", "password": "concurrent"}))
+			},
+			wantPassword: "concurrent",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			c := &racingClient{Client: fake.NewFakeClientWithScheme(scheme, tt.existing...), concurrent: tt.concurrent}
+			sec, err := EnsureCredentialSecret(ctx, c, buildSecret(nil), generators)
+			if err != nil {
+				t.Fatal("EnsureCredentialSecret() failed", err)
+			}
+			stored := &v1.Secret{}
+			if err := c.Client.Get(ctx, client.ObjectKey{Name: "test", Namespace: "test"}, stored); err != nil {
+				t.Fatal("failed to get secret", err)
+			}
+			if string(sec.Data["password"]) != tt.wantPassword || string(stored.Data["password"]) != tt.wantPassword {
+				t.Errorf("EnsureCredentialSecret() password = %s, stored %s, want %s", sec.Data["password"], stored.Data["password"], tt.wantPassword)
+			}
+			if string(stored.Data["user"]) != "postgres" {
+				t.Errorf("EnsureCredentialSecret() user = %s, want postgres", stored.Data["user"])
+			}
+			if got := Redact(tt.wantPassword + " postgres"); got != "REDACTED postgres" {
+				t.Errorf("EnsureCredentialSecret() registered secret values, redacted %s, want REDACTED postgres", got)
+			}
+		})
+	}
+}
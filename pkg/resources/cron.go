@@ -0,0 +1,143 @@
+package resources
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	errorUtil "github.com/pkg/errors"
+)
+
+// cronDescriptors are the supported shorthand schedules
+var cronDescriptors = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+	"@yearly":  "0 0 1 1 *",
+}
+
+// CronSchedule is a standard five field cron expression, minute hour day-of-month month day-of-week, evaluated in UTC
+type CronSchedule struct {
+	minutes     map[int]bool
+	hours       map[int]bool
+	daysOfMonth map[int]bool
+	months      map[int]bool
+	daysOfWeek  map[int]bool
+	// when both day fields are restricted a day matching either is scheduled, as in cron
+	daysOfMonthAny bool
+	daysOfWeekAny  bool
+}
+
+// ParseCronSchedule parses a five field cron expression, supporting *, ranges, steps and lists of numeric values, or
+// one of the @hourly, @daily, @weekly, @monthly and @yearly descriptors
+func ParseCronSchedule(expr string) (*CronSchedule, error) {
+	if descriptor, ok := cronDescriptors[strings.TrimSpace(expr)]; ok {
+		expr = descriptor
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, errorUtil.New(fmt.Sprintf("cron expression %q must have 5 fields, found %d", expr, len(fields)))
+	}
+	var err error
+	s := &CronSchedule{
+		daysOfMonthAny: fields[2] == "*",
+		daysOfWeekAny:  fields[4] == "*",
+	}
+	if s.minutes, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, errorUtil.Wrapf(err, "invalid minute field in cron expression %q", expr)
+	}
+	if s.hours, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, errorUtil.Wrapf(err, "invalid hour field in cron expression %q", expr)
+	}
+	if s.daysOfMonth, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, errorUtil.Wrapf(err, "invalid day of month field in cron expression %q", expr)
+	}
+	if s.months, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, errorUtil.Wrapf(err, "invalid month field in cron expression %q", expr)
+	}
+	if s.daysOfWeek, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, errorUtil.Wrapf(err, "invalid day of week field in cron expression %q", expr)
+	}
+	// sunday is both 0 and 7
+	if s.daysOfWeek[7] {
+		s.daysOfWeek[0] = true
+	}
+	return s, nil
+}
+
+// parseCronField returns the set of values matched by a comma separated cron field
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	values := map[int]bool{}
+	for _, item := range strings.Split(field, ",") {
+		rangeExpr, step := item, 1
+		if i := strings.Index(item, "/"); i != -1 {
+			var err error
+			rangeExpr = item[:i]
+			if step, err = strconv.Atoi(item[i+1:]); err != nil || step < 1 {
+				return nil, errorUtil.New(fmt.Sprintf("invalid step in %q", item))
+			}
+		}
+		start, end := min, max
+		if rangeExpr != "*" {
+			bounds := strings.SplitN(rangeExpr, "-", 2)
+			var err error
+			if start, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, errorUtil.New(fmt.Sprintf("invalid value in %q", item))
+			}
+			end = start
+			if len(bounds) == 2 {
+				if end, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, errorUtil.New(fmt.Sprintf("invalid value in %q", item))
+				}
+			} else if step != 1 {
+				// a single value with a step, e.g. 5/15, runs from the value to the end of the range
+				end = max
+			}
+		}
+		if start < min || end > max || start > end {
+			return nil, errorUtil.New(fmt.Sprintf("%q is out of range %d-%d", item, min, max))
+		}
+		for v := start; v <= end; v += step {
+			values[v] = true
+		}
+	}
+	return values, nil
+}
+
+// Next returns the first scheduled time after t, or the zero time if nothing is scheduled in the next 5 years, e.g. for
+// the 30th of February
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	next := t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := next.AddDate(5, 0, 0)
+	for next.Before(limit) {
+		if !s.months[int(next.Month())] {
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !s.matchesDay(next) {
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !s.hours[next.Hour()] {
+			next = next.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if !s.minutes[next.Minute()] {
+			next = next.Add(time.Minute)
+			continue
+		}
+		return next
+	}
+	return time.Time{}
+}
+
+func (s *CronSchedule) matchesDay(t time.Time) bool {
+	domMatch := s.daysOfMonth[t.Day()]
+	dowMatch := s.daysOfWeek[int(t.Weekday())]
+	if !s.daysOfMonthAny && !s.daysOfWeekAny {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
@@ -0,0 +1,107 @@
+package resources
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronSchedule_Next(t *testing.T) {
+	// a wednesday
+	testTime := time.Date(2021, time.June, 16, 10, 30, 15, 0, time.UTC)
+	tests := []struct {
+		name    string
+		expr    string
+		after   time.Time
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name:  "test every minute",
+			expr:  "* * * * *",
+			after: testTime,
+			want:  time.Date(2021, time.June, 16, 10, 31, 0, 0, time.UTC),
+		},
+		{
+			name:  "test daily descriptor is scheduled the next day",
+			expr:  "@daily",
+			after: testTime,
+			want:  time.Date(2021, time.June, 17, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "test hour and minute later the same day",
+			expr:  "15 22 * * *",
+			after: testTime,
+			want:  time.Date(2021, time.June, 16, 22, 15, 0, 0, time.UTC),
+		},
+		{
+			name:  "test step of minutes",
+			expr:  "*/20 * * * *",
+			after: testTime,
+			want:  time.Date(2021, time.June, 16, 10, 40, 0, 0, time.UTC),
+		},
+		{
+			name:  "test range and list of hours",
+			expr:  "0 1-3,9 * * *",
+			after: testTime,
+			want:  time.Date(2021, time.June, 17, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "test day of week with sunday as 7",
+			expr:  "0 2 * * 7",
+			after: testTime,
+			want:  time.Date(2021, time.June, 20, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "test day of month or day of week when both are restricted",
+			expr:  "0 0 1 * 5",
+			after: testTime,
+			want:  time.Date(2021, time.June, 18, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "test month rolls over the year",
+			expr:  "0 0 1 3 *",
+			after: testTime,
+			want:  time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "test impossible date is never scheduled",
+			expr:  "0 0 30 2 *",
+			after: testTime,
+			want:  time.Time{},
+		},
+		{
+			name:    "test error on wrong number of fields",
+			expr:    "0 0 * *",
+			wantErr: true,
+		},
+		{
+			name:    "test error on out of range value",
+			expr:    "60 * * * *",
+			wantErr: true,
+		},
+		{
+			name:    "test error on invalid step",
+			expr:    "*/0 * * * *",
+			wantErr: true,
+		},
+		{
+			name:    "test error on non numeric value",
+			expr:    "0 0 * * MON",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseCronSchedule(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCronSchedule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := s.Next(tt.after); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package resources
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -14,18 +15,27 @@ const (
 	DefaultBlobStorageStatusMetricName        = "cro_blobstorage_status_phase"
 	DefaultPostgresAllocatedStorageMetricName = "cro_postgres_current_allocated_storage"
 	DefaultPostgresAvailMetricName            = "cro_postgres_available"
+	DefaultPostgresConnectionDemandMetricName = "cro_postgres_connection_demand"
 	DefaultPostgresConnectionMetricName       = "cro_postgres_connection"
+	DefaultPostgresConsumersMetricName        = "cro_postgres_consumers"
+	DefaultPostgresCostMetricName             = "cro_postgres_estimated_monthly_cost"
 	DefaultPostgresDeletionMetricName         = "cro_postgres_deletion_timestamp"
+	DefaultPostgresFreeStorageMetricName      = "cro_postgres_free_storage_average"
 	DefaultPostgresInfoMetricName             = "cro_postgres_info"
 	DefaultPostgresMaintenanceMetricName      = "cro_postgres_service_maintenance"
+	DefaultPostgresMaxConnectionsMetricName   = "cro_postgres_max_connections"
 	DefaultPostgresMaxMemoryMetricName        = "cro_postgres_max_memory"
+	DefaultPostgresReplicaLagMetricName       = "cro_postgres_replica_lag_average"
 	DefaultPostgresSnapshotStatusMetricName   = "cro_postgres_snapshot_status_phase"
 	DefaultPostgresStatusMetricName           = "cro_postgres_status_phase"
 	DefaultRedisAvailMetricName               = "cro_redis_available"
 	DefaultRedisConnectionMetricName          = "cro_redis_connection"
+	DefaultRedisCostMetricName                = "cro_redis_estimated_monthly_cost"
 	DefaultRedisDeletionMetricName            = "cro_redis_deletion_timestamp"
 	DefaultRedisInfoMetricName                = "cro_redis_info"
 	DefaultRedisMaintenanceMetricName         = "cro_redis_service_maintenance"
+	DefaultRedisMemoryUsageMetricName         = "cro_redis_memory_usage_percentage_average"
+	DefaultRedisReplicationLagMetricName      = "cro_redis_replication_lag_average"
 	DefaultRedisSnapshotNotAvailable          = "cro_redis_snapshot_not_found"
 	DefaultRedisSnapshotStatusMetricName      = "cro_redis_snapshot_status_phase"
 	DefaultRedisStatusMetricName              = "cro_redis_status_phase"
@@ -34,9 +44,10 @@ const (
 )
 
 var (
-	// MetricVecs create the map of vectors
-	MetricVecs map[string]prometheus.GaugeVec
-	logger     *logrus.Entry
+	// MetricVecs create the map of vectors, guarded by metricVecsLock as metrics are set by concurrent reconciles
+	MetricVecs     map[string]prometheus.GaugeVec
+	metricVecsLock sync.RWMutex
+	logger         *logrus.Entry
 )
 
 func init() {
@@ -45,39 +56,42 @@ func init() {
 
 // StartGaugeVector periodic loop that is wiping all known vectors.
 func StartGaugeVector() {
+	metricVecsLock.Lock()
 	MetricVecs = map[string]prometheus.GaugeVec{}
+	metricVecsLock.Unlock()
 	logger = logrus.WithFields(logrus.Fields{"custom_metrics": "StartGaugeVector"})
 
 	go func() {
 		for {
 			logger.Info("calling reset on all prometheus gauge vectors")
+			metricVecsLock.RLock()
 			for _, val := range MetricVecs {
 				val.Reset()
 			}
+			metricVecsLock.RUnlock()
 			time.Sleep(time.Duration(3600) * time.Second)
 		}
 	}()
 }
 
-func ResetMetric(name string) {
-	logrus.Info(fmt.Sprintf("Resetting metric %s", name))
-	// set vector value
+// GetMetricVec returns the gauge vector with the name, if it has been created
+func GetMetricVec(name string) (prometheus.GaugeVec, bool) {
+	metricVecsLock.RLock()
+	defer metricVecsLock.RUnlock()
 	gv, ok := MetricVecs[name]
-	if ok {
-		gv.Reset()
-		logrus.Info(fmt.Sprintf("successfully reset metric value for %s", name))
-		return
-	}
+	return gv, ok
 }
 
-//SetMetric Set exports a Prometheus Gauge
-func SetMetric(name string, labels map[string]string, value float64) {
-	// set vector value
-	gv, ok := MetricVecs[name]
-	if ok {
-		gv.With(labels).Set(value)
-		logrus.Info(fmt.Sprintf("successfully set metric value for %s", name))
-		return
+// getOrCreateMetricVec returns the gauge vector with the name, creating and registering it with the label names of
+// labels if it doesn't exist. it's created under the write lock so concurrent callers don't register it twice
+func getOrCreateMetricVec(name string, labels map[string]string) prometheus.GaugeVec {
+	if gv, ok := GetMetricVec(name); ok {
+		return gv
+	}
+	metricVecsLock.Lock()
+	defer metricVecsLock.Unlock()
+	if gv, ok := MetricVecs[name]; ok {
+		return gv
 	}
 
 	// create label array for vector creation
@@ -86,12 +100,37 @@ func SetMetric(name string, labels map[string]string, value float64) {
 		keys = append(keys, k)
 	}
 
-	// the vector does not exist, create it, register and then add this gauge metric to the gauge vector
-	gv = *prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name}, keys)
+	// the vector does not exist, create it and register it
+	gv := *prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name}, keys)
 	customMetrics.Registry.MustRegister(gv)
 	MetricVecs[name] = gv
-
 	logrus.Info(fmt.Sprintf("successfully created new gauge vector metric %s", name))
+	return gv
+}
+
+func ResetMetric(name string) {
+	logrus.Info(fmt.Sprintf("Resetting metric %s", name))
+	// set vector value
+	gv, ok := GetMetricVec(name)
+	if ok {
+		gv.Reset()
+		logrus.Info(fmt.Sprintf("successfully reset metric value for %s", name))
+		return
+	}
+}
+
+//SetMetric Set exports a Prometheus Gauge
+func SetMetric(name string, labels map[string]string, value float64) {
+	gv := getOrCreateMetricVec(name, labels)
+	gv.With(labels).Set(value)
+	logrus.Info(fmt.Sprintf("successfully set metric value for %s", name))
+}
+
+// DeleteMetric removes the gauge with the labels from a Prometheus Gauge vector, if it exists
+func DeleteMetric(name string, labels map[string]string) {
+	if gv, ok := GetMetricVec(name); ok {
+		gv.Delete(labels)
+	}
 }
 
 //SetMetricCurrentTime Set current time wraps set metric
@@ -99,6 +138,17 @@ func SetMetricCurrentTime(name string, labels map[string]string) {
 	SetMetric(name, labels, float64(time.Now().UnixNano())/1e9)
 }
 
+// SetCostMetric sets the estimated monthly cost metric of a resource, labelled with its cost class
+func SetCostMetric(name string, namespace string, resourceName string, tier string, costClass string, monthlyCost float64) {
+	SetMetric(name,
+		map[string]string{
+			"namespace":  namespace,
+			"resourceID": resourceName,
+			"tier":       tier,
+			"costClass":  costClass,
+		}, monthlyCost)
+}
+
 // SetVpcAction sets cro_vpc_action metric
 func SetVpcAction(action string, status string, err string, code float64) {
 	SetMetric(DefaultVpcActionMetricName,
@@ -111,7 +161,7 @@ func SetVpcAction(action string, status string, err string, code float64) {
 
 // ResetVpcAction resets cro_vpc_action metric
 func ResetVpcAction() {
-	if val, ok := MetricVecs[DefaultVpcActionMetricName]; ok {
+	if val, ok := GetMetricVec(DefaultVpcActionMetricName); ok {
 		val.Reset()
 	}
 }
@@ -127,7 +177,7 @@ func SetSTSCredentialsSecretMetric(ns string, err error) {
 
 // ResetSTSCredentialsSecretMetric resets cro_sts_credentials_secret metric
 func ResetSTSCredentialsSecretMetric() {
-	if val, ok := MetricVecs[DefaultSTSCredentialsSecretMetricName]; ok {
+	if val, ok := GetMetricVec(DefaultSTSCredentialsSecretMetricName); ok {
 		val.Reset()
 	}
 }
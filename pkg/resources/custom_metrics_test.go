@@ -0,0 +1,35 @@
+package resources
+
+import (
+	"sync"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestSetMetric_concurrent(t *testing.T) {
+	name := "cro_test_concurrent_metric"
+	labels := map[string]string{"namespace": "test"}
+	// reconciles creating the same vector at once must register it only once
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			SetMetric(name, labels, 1)
+		}()
+	}
+	wg.Wait()
+
+	gv, ok := GetMetricVec(name)
+	if !ok {
+		t.Fatalf("GetMetricVec() found no vector %s", name)
+	}
+	metric := &dto.Metric{}
+	if err := gv.With(labels).Write(metric); err != nil {
+		t.Fatal("failed to read metric", err)
+	}
+	if value := metric.GetGauge().GetValue(); value != 1 {
+		t.Errorf("SetMetric() value = %v, want 1", value)
+	}
+}
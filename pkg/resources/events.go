@@ -0,0 +1,97 @@
+package resources
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	// EventSource is the component the events of the operator are recorded by
+	EventSource = "cloud-resource-operator"
+
+	// ProvisioningStartedEventReason is recorded when the operator first reconciles a resource
+	ProvisioningStartedEventReason = "ProvisioningStarted"
+	// ProvisioningCompletedEventReason is recorded when a resource becomes complete
+	ProvisioningCompletedEventReason = "ProvisioningCompleted"
+	// ReconcileFailedEventReason is recorded when a resource fails, unless an aws api error caused the failure
+	ReconcileFailedEventReason = "ReconcileFailed"
+	// AWSAPIErrorEventReason is recorded when an aws api call made to reconcile a resource fails
+	AWSAPIErrorEventReason = "AWSAPIError"
+	// DeletionBlockedEventReason is recorded when the deletion of a resource is paused, e.g. by the deletion rate limit
+	DeletionBlockedEventReason = "DeletionBlocked"
+	// CredentialRotatedEventReason is recorded when the credentials of a resource are rotated
+	CredentialRotatedEventReason = "CredentialRotated"
+	// SnapshotTakenEventReason is recorded on a snapshot when the snapshot is complete
+	SnapshotTakenEventReason = "SnapshotTaken"
+	// EndpointChangedEventReason is recorded when the endpoint of the cloud resource of a resource changes, e.g. after
+	// the instance was renamed or replaced by the provider
+	EndpointChangedEventReason = "EndpointChanged"
+	// ConnectionFailedEventReason is recorded when a connection can no longer be opened to a resource
+	ConnectionFailedEventReason = "ConnectionFailed"
+)
+
+// RecordReconcileEvents records the events of a reconcile of a resource on it, from the status of the resource before
+// and after the reconcile and the error the reconcile returned. Events are only recorded for changes, e.g. a resource
+// becoming complete, so a resource reconciled repeatedly doesn't record the same event on every reconcile, except for
+// aws api errors which are aggregated by the recorder
+func RecordReconcileEvents(recorder record.EventRecorder, inst runtime.Object, deleting bool, before, after croType.ResourceTypeStatus, err error) {
+	if recorder == nil {
+		return
+	}
+	if !RecordAWSAPIError(recorder, inst, err) && after.Phase == croType.PhaseFailed && before.Phase != croType.PhaseFailed {
+		recorder.Event(inst, v1.EventTypeWarning, ReconcileFailedEventReason, string(after.Message))
+	}
+	if before.Phase == "" && after.Phase != "" && !deleting {
+		recorder.Eventf(inst, v1.EventTypeNormal, ProvisioningStartedEventReason, "provisioning started with the %s provider", after.Provider)
+	}
+	if after.Phase == croType.PhaseComplete && before.Phase != croType.PhaseComplete {
+		recorder.Event(inst, v1.EventTypeNormal, ProvisioningCompletedEventReason, string(after.Message))
+	}
+	if deleting && after.Phase == croType.PhasePaused && before.Phase != croType.PhasePaused {
+		recorder.Event(inst, v1.EventTypeWarning, DeletionBlockedEventReason, string(after.Message))
+	}
+	if after.CredentialsRotatedAt != "" && after.CredentialsRotatedAt != before.CredentialsRotatedAt {
+		recorder.Event(inst, v1.EventTypeNormal, CredentialRotatedEventReason, fmt.Sprintf("credentials rotated at %s", after.CredentialsRotatedAt))
+	}
+	if failed := connectionFailed(&after); failed != nil && connectionFailed(&before) == nil {
+		recorder.Event(inst, v1.EventTypeWarning, ConnectionFailedEventReason, failed.Message)
+	}
+	// a resource is only complete once its connection secret was written with the endpoint of the cloud resource
+	oldEndpoint, newEndpoint := cloudResourceEndpoint(before), cloudResourceEndpoint(after)
+	if after.Phase == croType.PhaseComplete && oldEndpoint != "" && newEndpoint != "" && oldEndpoint != newEndpoint {
+		recorder.Eventf(inst, v1.EventTypeWarning, EndpointChangedEventReason, "endpoint changed from %s to %s, the connection secret was refreshed", oldEndpoint, newEndpoint)
+	}
+}
+
+func cloudResourceEndpoint(status croType.ResourceTypeStatus) string {
+	if status.CloudResource == nil {
+		return ""
+	}
+	return status.CloudResource.Endpoint
+}
+
+// RecordAWSAPIError records a warning event on the resource if there's an aws api error in the chain of the error. It
+// returns false if there isn't one
+func RecordAWSAPIError(recorder record.EventRecorder, inst runtime.Object, err error) bool {
+	awsErr, ok := AWSError(err)
+	if !ok || recorder == nil {
+		return ok
+	}
+	recorder.Eventf(inst, v1.EventTypeWarning, AWSAPIErrorEventReason, "aws api error %s: %s", awsErr.Code(), Redact(awsErr.Message()))
+	return true
+}
+
+// AWSError returns the aws api error in the chain of the error, if there's one
+func AWSError(err error) (awserr.Error, bool) {
+	var awsErr awserr.Error
+	if err == nil || !errors.As(err, &awsErr) {
+		return nil, false
+	}
+	return awsErr, true
+}
@@ -0,0 +1,104 @@
+package resources
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	errorUtil "github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestRecordReconcileEvents(t *testing.T) {
+	tests := []struct {
+		name       string
+		deleting   bool
+		before     croType.ResourceTypeStatus
+		after      croType.ResourceTypeStatus
+		err        error
+		wantEvents []string
+	}{
+		{
+			name:       "test provisioning started is recorded on the first reconcile",
+			after:      croType.ResourceTypeStatus{Provider: "aws-rds", Phase: croType.PhaseInProgress},
+			wantEvents: []string{"Normal ProvisioningStarted provisioning started with the aws-rds provider"},
+		},
+		{
+			name:       "test provisioning completed is recorded once",
+			before:     croType.ResourceTypeStatus{Phase: croType.PhaseInProgress},
+			after:      croType.ResourceTypeStatus{Phase: croType.PhaseComplete, Message: "completed"},
+			wantEvents: []string{"Normal ProvisioningCompleted completed"},
+		},
+		{
+			name:   "test nothing is recorded for an unchanged resource",
+			before: croType.ResourceTypeStatus{Phase: croType.PhaseComplete},
+			after:  croType.ResourceTypeStatus{Phase: croType.PhaseComplete},
+		},
+		{
+			name:       "test an aws api error is recorded instead of the failure",
+			before:     croType.ResourceTypeStatus{Phase: croType.PhaseInProgress},
+			after:      croType.ResourceTypeStatus{Phase: croType.PhaseFailed, Message: "failed to create rds instance"},
+			err:        errorUtil.Wrap(awserr.New("InvalidParameterValue", "invalid instance class", nil), "failed to create rds instance"),
+			wantEvents: []string{"Warning AWSAPIError aws api error InvalidParameterValue: invalid instance class"},
+		},
+		{
+			name:       "test other failures are recorded",
+			before:     croType.ResourceTypeStatus{Phase: croType.PhaseInProgress},
+			after:      croType.ResourceTypeStatus{Phase: croType.PhaseFailed, Message: "tier removed"},
+			err:        errorUtil.New("tier removed"),
+			wantEvents: []string{"Warning ReconcileFailed tier removed"},
+		},
+		{
+			name:       "test a paused deletion is recorded as blocked",
+			deleting:   true,
+			before:     croType.ResourceTypeStatus{Phase: croType.PhaseComplete},
+			after:      croType.ResourceTypeStatus{Phase: croType.PhasePaused, Message: "deletion rate limit reached"},
+			wantEvents: []string{"Warning DeletionBlocked deletion rate limit reached"},
+		},
+		{
+			name:       "test a credential rotation is recorded",
+			before:     croType.ResourceTypeStatus{Phase: croType.PhaseComplete},
+			after:      croType.ResourceTypeStatus{Phase: croType.PhaseComplete, CredentialsRotatedAt: "2020-01-01T00:00:00Z"},
+			wantEvents: []string{"Normal CredentialRotated credentials rotated at 2020-01-01T00:00:00Z"},
+		},
+		{
+			name:       "test an endpoint change is recorded",
+			before:     croType.ResourceTypeStatus{Phase: croType.PhaseComplete, CloudResource: &croType.CloudResourceStatus{Endpoint: "old.example.com:5432"}},
+			after:      croType.ResourceTypeStatus{Phase: croType.PhaseComplete, CloudResource: &croType.CloudResourceStatus{Endpoint: "new.example.com:5432"}},
+			wantEvents: []string{"Warning EndpointChanged endpoint changed from old.example.com:5432 to new.example.com:5432, the connection secret was refreshed"},
+		},
+		{
+			name:       "test a failed connection is recorded once",
+			before:     croType.ResourceTypeStatus{Phase: croType.PhaseComplete, Conditions: []metav1.Condition{{Type: ConnectedCondition, Status: metav1.ConditionTrue}}},
+			after:      croType.ResourceTypeStatus{Phase: croType.PhaseComplete, Conditions: []metav1.Condition{{Type: ConnectedCondition, Status: metav1.ConditionFalse, Message: "connection refused"}}},
+			wantEvents: []string{"Warning ConnectionFailed connection refused"},
+		},
+		{
+			name:   "test a connection failing again is not recorded",
+			before: croType.ResourceTypeStatus{Phase: croType.PhaseComplete, Conditions: []metav1.Condition{{Type: ConnectedCondition, Status: metav1.ConditionFalse}}},
+			after:  croType.ResourceTypeStatus{Phase: croType.PhaseComplete, Conditions: []metav1.Condition{{Type: ConnectedCondition, Status: metav1.ConditionFalse}}},
+		},
+		{
+			name:   "test the first endpoint is not recorded as a change",
+			before: croType.ResourceTypeStatus{Phase: croType.PhaseComplete},
+			after:  croType.ResourceTypeStatus{Phase: croType.PhaseComplete, CloudResource: &croType.CloudResourceStatus{Endpoint: "new.example.com:5432"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			RecordReconcileEvents(recorder, &v1alpha1.Postgres{}, tt.deleting, tt.before, tt.after, tt.err)
+			close(recorder.Events)
+			var got []string
+			for e := range recorder.Events {
+				got = append(got, e)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.wantEvents, "\n") {
+				t.Errorf("RecordReconcileEvents() recorded %q, want %q", got, tt.wantEvents)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package resources
+
+import (
+	"github.com/integr8ly/cloud-resource-operator/pkg/annotations"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ArgoCDCompareOptionsAnnotation stops argo cd reporting an application out of sync because of an object it doesn't
+	// manage, e.g. an object the operator generated in a namespace synced by argo cd
+	ArgoCDCompareOptionsAnnotation = "argocd.argoproj.io/compare-options"
+	// ArgoCDSyncOptionsAnnotation stops argo cd pruning an object it doesn't manage
+	ArgoCDSyncOptionsAnnotation = "argocd.argoproj.io/sync-options"
+	// FluxReconcileAnnotation stops flux reverting the changes the operator makes to an object
+	FluxReconcileAnnotation = "kustomize.toolkit.fluxcd.io/reconcile"
+	// FluxPruneAnnotation stops flux pruning an object it doesn't manage
+	FluxPruneAnnotation = "kustomize.toolkit.fluxcd.io/prune"
+)
+
+// GitOpsAnnotations returns the annotations telling argo cd and flux to leave the objects generated by the operator
+// alone, so the applications syncing the custom resources aren't reported out of sync and don't fight the operator
+func GitOpsAnnotations() map[string]string {
+	return map[string]string{
+		ArgoCDCompareOptionsAnnotation: "IgnoreExtraneous",
+		ArgoCDSyncOptionsAnnotation:    "Prune=false",
+		FluxReconcileAnnotation:        "disabled",
+		FluxPruneAnnotation:            "disabled",
+	}
+}
+
+// AddGitOpsAnnotations sets the gitops annotations on an object generated by the operator
+func AddGitOpsAnnotations(obj metav1.Object) {
+	for k, v := range GitOpsAnnotations() {
+		annotations.Add(obj, k, v)
+	}
+}
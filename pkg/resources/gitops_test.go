@@ -0,0 +1,21 @@
+package resources
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAddGitOpsAnnotations(t *testing.T) {
+	sec := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"existing": "true"}}}
+	AddGitOpsAnnotations(sec)
+	if sec.Annotations["existing"] != "true" {
+		t.Errorf("AddGitOpsAnnotations() removed existing annotation, annotations = %v", sec.Annotations)
+	}
+	for k, v := range GitOpsAnnotations() {
+		if sec.Annotations[k] != v {
+			t.Errorf("AddGitOpsAnnotations() annotation %s = %s, want %s", k, sec.Annotations[k], v)
+		}
+	}
+}
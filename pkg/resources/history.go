@@ -0,0 +1,74 @@
+package resources
+
+import (
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// MaxHistory is the number of transitions kept in the history of a resource, older transitions are dropped
+	MaxHistory = 10
+
+	// StrategyAppliedHistoryReason is recorded when a resource is first reconciled with the configuration of its tier,
+	// or with a changed configuration
+	StrategyAppliedHistoryReason = "StrategyApplied"
+	// DeletionStartedHistoryReason is recorded when the deletion of a resource starts
+	DeletionStartedHistoryReason = "DeletionStarted"
+	// PausedHistoryReason is recorded when the reconcile of a resource is paused, e.g. for maintenance
+	PausedHistoryReason = "Paused"
+	// ReconcileResumedHistoryReason is recorded when a resource goes back in progress after it was complete, paused or
+	// failed
+	ReconcileResumedHistoryReason = "ReconcileResumed"
+)
+
+// RecordPhaseTransition records a transition in the history of the resource if the phase changed, the message is the
+// message of the resource after the transition. The status is persisted with the rest of the resource status
+func RecordPhaseTransition(status *croType.ResourceTypeStatus, before, after croType.StatusPhase, msg croType.StatusMessage) {
+	if before == after {
+		return
+	}
+	RecordHistory(status, croType.HistoryEntry{
+		Time:    metav1.Now(),
+		Reason:  phaseTransitionReason(before, after),
+		Phase:   after,
+		Message: msg,
+	})
+}
+
+// RecordHistory appends a transition to the history of the resource, keeping the last MaxHistory transitions. A
+// transition without a strategy hash keeps the hash of the previous transition
+func RecordHistory(status *croType.ResourceTypeStatus, entry croType.HistoryEntry) {
+	if entry.StrategyHash == "" {
+		entry.StrategyHash = LastStrategyHash(status)
+	}
+	status.History = append(status.History, entry)
+	if len(status.History) > MaxHistory {
+		status.History = status.History[len(status.History)-MaxHistory:]
+	}
+}
+
+// LastStrategyHash returns the strategy hash of the last transition in the history of the resource, empty if none
+// was recorded
+func LastStrategyHash(status *croType.ResourceTypeStatus) string {
+	if len(status.History) == 0 {
+		return ""
+	}
+	return status.History[len(status.History)-1].StrategyHash
+}
+
+func phaseTransitionReason(before, after croType.StatusPhase) string {
+	switch after {
+	case croType.PhaseComplete:
+		return ProvisioningCompletedEventReason
+	case croType.PhaseFailed:
+		return ReconcileFailedEventReason
+	case croType.PhaseDeleteInProgress:
+		return DeletionStartedHistoryReason
+	case croType.PhasePaused:
+		return PausedHistoryReason
+	}
+	if before == "" {
+		return ProvisioningStartedEventReason
+	}
+	return ReconcileResumedHistoryReason
+}
@@ -0,0 +1,77 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRecordHistory(t *testing.T) {
+	status := &croType.ResourceTypeStatus{}
+	RecordHistory(status, croType.HistoryEntry{Reason: StrategyAppliedHistoryReason, StrategyHash: "abc"})
+	for i := 0; i < MaxHistory+2; i++ {
+		RecordHistory(status, croType.HistoryEntry{Reason: fmt.Sprintf("Reason%d", i)})
+	}
+	if len(status.History) != MaxHistory {
+		t.Fatalf("RecordHistory() kept %d entries, want %d", len(status.History), MaxHistory)
+	}
+	if status.History[0].Reason != "Reason2" || status.History[MaxHistory-1].Reason != fmt.Sprintf("Reason%d", MaxHistory+1) {
+		t.Errorf("RecordHistory() history = %+v, want the oldest entries dropped", status.History)
+	}
+	if LastStrategyHash(status) != "abc" {
+		t.Errorf("RecordHistory() strategy hash = %s, want the hash of the previous entry kept", LastStrategyHash(status))
+	}
+}
+
+func TestRecordPhaseTransition(t *testing.T) {
+	tests := []struct {
+		before     croType.StatusPhase
+		after      croType.StatusPhase
+		wantReason string
+	}{
+		{before: "", after: croType.PhaseInProgress, wantReason: ProvisioningStartedEventReason},
+		{before: croType.PhaseInProgress, after: croType.PhaseComplete, wantReason: ProvisioningCompletedEventReason},
+		{before: croType.PhaseComplete, after: croType.PhaseFailed, wantReason: ReconcileFailedEventReason},
+		{before: croType.PhaseFailed, after: croType.PhaseInProgress, wantReason: ReconcileResumedHistoryReason},
+		{before: croType.PhaseComplete, after: croType.PhasePaused, wantReason: PausedHistoryReason},
+		{before: croType.PhaseComplete, after: croType.PhaseDeleteInProgress, wantReason: DeletionStartedHistoryReason},
+		{before: croType.PhaseComplete, after: croType.PhaseComplete},
+	}
+	for _, tt := range tests {
+		status := &croType.ResourceTypeStatus{}
+		RecordPhaseTransition(status, tt.before, tt.after, "msg")
+		if tt.wantReason == "" {
+			if len(status.History) != 0 {
+				t.Errorf("RecordPhaseTransition(%q, %q) history = %+v, want none", tt.before, tt.after, status.History)
+			}
+			continue
+		}
+		if len(status.History) != 1 || status.History[0].Reason != tt.wantReason || status.History[0].Phase != tt.after {
+			t.Errorf("RecordPhaseTransition(%q, %q) history = %+v, want reason %s", tt.before, tt.after, status.History, tt.wantReason)
+		}
+	}
+}
+
+func TestUpdatePhase_RecordsHistory(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	pg := &v1alpha1.Postgres{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"}}
+	client := fake.NewFakeClientWithScheme(scheme, pg)
+
+	for _, phase := range []croType.StatusPhase{croType.PhaseInProgress, croType.PhaseInProgress, croType.PhaseFailed} {
+		if err := UpdatePhase(context.TODO(), client, pg, phase, "msg"); err != nil {
+			t.Fatalf("UpdatePhase() unexpected error = %v", err)
+		}
+	}
+	if len(pg.Status.History) != 2 || pg.Status.History[1].Reason != ReconcileFailedEventReason {
+		t.Errorf("UpdatePhase() history = %+v, want the two phase changes", pg.Status.History)
+	}
+}
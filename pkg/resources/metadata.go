@@ -0,0 +1,146 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/integr8ly/cloud-resource-operator/internal/k8sutil"
+	errorUtil "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// DefaultMetadataConfigMapName is the config map holding the metadata set on every object and cloud resource the
+	// operator creates, e.g. a cost center, environment or owner
+	DefaultMetadataConfigMapName = "cloud-resource-metadata"
+	// MetadataLabelsKey and MetadataAnnotationsKey are the keys of the config map holding a json map of the labels and
+	// annotations. labels are also set as tags or labels of the cloud resources
+	MetadataLabelsKey      = "labels"
+	MetadataAnnotationsKey = "annotations"
+)
+
+// MetadataConfigMapNamespace is the namespace of the metadata config map
+var MetadataConfigMapNamespace, _ = k8sutil.GetWatchNamespace()
+
+// Metadata is the operator wide set of labels and annotations
+type Metadata struct {
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// GetMetadata returns the metadata of the metadata config map, empty metadata if there's no config map
+func GetMetadata(ctx context.Context, c client.Reader) (*Metadata, error) {
+	md := &Metadata{Labels: map[string]string{}, Annotations: map[string]string{}}
+	if MetadataConfigMapNamespace == "" {
+		return md, nil
+	}
+	cm := &v1.ConfigMap{}
+	if err := c.Get(ctx, types.NamespacedName{Name: DefaultMetadataConfigMapName, Namespace: MetadataConfigMapNamespace}, cm); err != nil {
+		if errors.IsNotFound(err) {
+			return md, nil
+		}
+		return nil, errorUtil.Wrapf(err, "failed to get metadata config map %s", DefaultMetadataConfigMapName)
+	}
+	if raw, ok := cm.Data[MetadataLabelsKey]; ok && strings.TrimSpace(raw) != "" {
+		if err := json.Unmarshal([]byte(raw), &md.Labels); err != nil {
+			return nil, errorUtil.Wrapf(err, "failed to unmarshal %s of metadata config map %s", MetadataLabelsKey, DefaultMetadataConfigMapName)
+		}
+	}
+	if raw, ok := cm.Data[MetadataAnnotationsKey]; ok && strings.TrimSpace(raw) != "" {
+		if err := json.Unmarshal([]byte(raw), &md.Annotations); err != nil {
+			return nil, errorUtil.Wrapf(err, "failed to unmarshal %s of metadata config map %s", MetadataAnnotationsKey, DefaultMetadataConfigMapName)
+		}
+	}
+	return md, nil
+}
+
+// Applied returns true if the object has every label and annotation of the metadata
+func (m *Metadata) Applied(obj metav1.Object) bool {
+	return containsAll(obj.GetLabels(), m.Labels) && containsAll(obj.GetAnnotations(), m.Annotations)
+}
+
+// Apply sets the labels and annotations of the metadata on the object, returning true if the object changed. labels
+// and annotations removed from the metadata are kept on the object
+func (m *Metadata) Apply(obj metav1.Object) bool {
+	if m.Applied(obj) {
+		return false
+	}
+	obj.SetLabels(mergeMetadata(obj.GetLabels(), m.Labels))
+	obj.SetAnnotations(mergeMetadata(obj.GetAnnotations(), m.Annotations))
+	return true
+}
+
+func containsAll(found, want map[string]string) bool {
+	for k, v := range want {
+		if fv, ok := found[k]; !ok || fv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeMetadata returns a copy of found with the wanted labels or annotations, so maps shared with other objects are
+// left untouched
+func mergeMetadata(found, want map[string]string) map[string]string {
+	if len(want) == 0 {
+		return found
+	}
+	merged := make(map[string]string, len(found)+len(want))
+	for k, v := range found {
+		merged[k] = v
+	}
+	for k, v := range want {
+		merged[k] = v
+	}
+	return merged
+}
+
+// ApplyMetadata sets the operator wide metadata on an object the operator builds, before it's created or updated. The
+// metadata is read on every write, so the next reconcile sets a changed metadata config map on the objects
+func ApplyMetadata(ctx context.Context, c client.Reader, obj metav1.Object) error {
+	md, err := GetMetadata(ctx, c)
+	if err != nil {
+		return errorUtil.Wrap(err, "failed to get metadata")
+	}
+	md.Apply(obj)
+	return nil
+}
+
+// EnqueueOnMetadataChange returns an event handler enqueueing every resource of the list type once the metadata config
+// map changes, so the metadata is set on their children and cloud resources
+func EnqueueOnMetadataChange(c client.Reader, list runtime.Object) handler.EventHandler {
+	return &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(func(o handler.MapObject) []reconcile.Request {
+		if o.Meta.GetName() != DefaultMetadataConfigMapName || o.Meta.GetNamespace() != MetadataConfigMapNamespace {
+			return nil
+		}
+		l := list.DeepCopyObject()
+		if err := c.List(context.TODO(), l); err != nil {
+			logrus.Errorf("failed to list resources to apply the changed metadata to: %v", err)
+			return nil
+		}
+		items, err := meta.ExtractList(l)
+		if err != nil {
+			logrus.Errorf("failed to extract resources to apply the changed metadata to: %v", err)
+			return nil
+		}
+		var requests []reconcile.Request
+		for _, item := range items {
+			accessor, err := meta.Accessor(item)
+			if err != nil {
+				continue
+			}
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: accessor.GetName(), Namespace: accessor.GetNamespace()}})
+		}
+		return requests
+	})}
+}
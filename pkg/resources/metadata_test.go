@@ -0,0 +1,130 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+)
+
+func buildTestMetadataConfigMap(labels, annotations string) *v1.ConfigMap {
+	return &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: DefaultMetadataConfigMapName, Namespace: "test"},
+		Data: map[string]string{
+			MetadataLabelsKey:      labels,
+			MetadataAnnotationsKey: annotations,
+		},
+	}
+}
+
+func buildTestChildSecret(name string, labels map[string]string) *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "test",
+			Labels:    labels,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "integreatly.org/v1alpha1",
+				Kind:       "Postgres",
+				Name:       "test",
+			}},
+		},
+	}
+}
+
+func TestGetMetadata(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	defer func(ns string) { MetadataConfigMapNamespace = ns }(MetadataConfigMapNamespace)
+	MetadataConfigMapNamespace = "test"
+	tests := []struct {
+		name            string
+		existing        []runtime.Object
+		wantLabels      map[string]string
+		wantAnnotations map[string]string
+		wantErr         bool
+	}{
+		{
+			name:            "test metadata is empty without a config map",
+			wantLabels:      map[string]string{},
+			wantAnnotations: map[string]string{},
+		},
+		{
+			name:            "test metadata is read from the config map",
+			existing:        []runtime.Object{buildTestMetadataConfigMap(`{"cost-center": "1234"}`, `{"owner": "team@example.com"}`)},
+			wantLabels:      map[string]string{"cost-center": "1234"},
+			wantAnnotations: map[string]string{"owner": "team@example.com"},
+		},
+		{
+			name:     "test invalid metadata fails",
+			existing: []runtime.Object{buildTestMetadataConfigMap(`cost-center: 1234`, "")},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md, err := GetMetadata(context.TODO(), fake.NewFakeClientWithScheme(scheme, tt.existing...))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetMetadata() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !containsAll(md.Labels, tt.wantLabels) || len(md.Labels) != len(tt.wantLabels) {
+				t.Errorf("GetMetadata() labels = %v, want %v", md.Labels, tt.wantLabels)
+			}
+			if !containsAll(md.Annotations, tt.wantAnnotations) || len(md.Annotations) != len(tt.wantAnnotations) {
+				t.Errorf("GetMetadata() annotations = %v, want %v", md.Annotations, tt.wantAnnotations)
+			}
+		})
+	}
+}
+
+func TestApplyMetadata(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	defer func(ns string) { MetadataConfigMapNamespace = ns }(MetadataConfigMapNamespace)
+	MetadataConfigMapNamespace = "test"
+	c := fake.NewFakeClientWithScheme(scheme, buildTestMetadataConfigMap(`{"cost-center": "1234"}`, `{"owner": "team@example.com"}`))
+
+	// objects keep their own labels, and maps shared with other objects are left untouched
+	labels := map[string]string{"app": "test"}
+	sec := buildTestChildSecret("test", labels)
+	if err := ApplyMetadata(context.TODO(), c, sec); err != nil {
+		t.Fatal("ApplyMetadata() unexpected error", err)
+	}
+	if sec.Labels["cost-center"] != "1234" || sec.Labels["app"] != "test" || sec.Annotations["owner"] != "team@example.com" {
+		t.Errorf("ApplyMetadata() metadata = %v %v, want the metadata and the labels of the secret", sec.Labels, sec.Annotations)
+	}
+	if len(labels) != 1 {
+		t.Errorf("ApplyMetadata() changed the labels passed to the secret to %v", labels)
+	}
+}
+
+func TestEnqueueOnMetadataChange(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	defer func(ns string) { MetadataConfigMapNamespace = ns }(MetadataConfigMapNamespace)
+	MetadataConfigMapNamespace = "test"
+	c := fake.NewFakeClientWithScheme(scheme, buildTestChildSecret("one", nil), buildTestChildSecret("two", nil))
+	mapper := EnqueueOnMetadataChange(c, &v1.SecretList{}).(*handler.EnqueueRequestsFromMapFunc).ToRequests
+
+	cm := buildTestMetadataConfigMap("", "")
+	if got := mapper.Map(handler.MapObject{Meta: cm, Object: cm}); len(got) != 2 {
+		t.Errorf("Map() = %v, want every resource enqueued", got)
+	}
+	cm.Name = "other"
+	if got := mapper.Map(handler.MapObject{Meta: cm, Object: cm}); len(got) != 0 {
+		t.Errorf("Map() = %v, want no resource enqueued for other config maps", got)
+	}
+}
@@ -0,0 +1,396 @@
+// Package metrics exposes metrics about the provisioning lifecycle of the postgres, redis and blobstorage resources
+// of the operator, the expiry of the artifacts they're provisioned with, changes of their external access, paused
+// deletions and the results of their connectivity checks, the calls of the providers to their clouds timing out, and the
+// objects of the resources reverted after something else changed them along with the conflicts with whatever keeps
+// changing them, for all providers. The reads of the operator
+// served by its informer cache are counted too, and the pool statistics of the connection poolers of postgres resources
+// are exported
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	customMetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	ProvisioningDurationMetricName  = "cro_resource_provisioning_duration_seconds"
+	ReconcileErrorsMetricName       = "cro_resource_reconcile_errors_total"
+	ResourcePhaseMetricName         = "cro_resource_phase"
+	ArtifactExpiryDaysMetricName    = "cro_resource_artifact_expiry_days"
+	ExternalAccessChangesMetricName = "cro_resource_external_access_changes_total"
+	DeletionPausedMetricName        = "cro_resource_deletion_paused"
+	ProbeDurationMetricName         = "cro_resource_probe_duration_seconds"
+	AvailableMetricName             = "cro_resource_available"
+	CallTimeoutsMetricName          = "cro_provider_call_timeouts_total"
+	UnexpectedRevertsMetricName     = "cro_resource_unexpected_reverts_total"
+	ReconcileConflictMetricName     = "cro_resource_reconcile_conflict"
+	CachedReadsMetricName           = "cro_cached_reads_total"
+	CachedReadMissesMetricName      = "cro_cached_read_misses_total"
+	PoolerActiveClientsMetricName   = "cro_postgres_pooler_active_clients"
+	PoolerWaitingClientsMetricName  = "cro_postgres_pooler_waiting_clients"
+	PoolerServerConnsMetricName     = "cro_postgres_pooler_server_connections"
+	PoolerMaxWaitMetricName         = "cro_postgres_pooler_max_wait_seconds"
+)
+
+var (
+	// provisioningDuration is the time from a resource being given a provisioning slot, or queued for one, until it's
+	// complete
+	provisioningDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    ProvisioningDurationMetricName,
+		Help:    "Time taken to provision a resource, from its provisioning job starting until the resource is complete",
+		Buckets: []float64{30, 60, 120, 300, 600, 900, 1200, 1800, 2700, 3600, 5400, 7200},
+	}, []string{"resource_type", "provider", "tier"})
+
+	reconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: ReconcileErrorsMetricName,
+		Help: "Number of reconciles of a resource returning an error",
+	}, []string{"resource_type", "provider"})
+
+	// resourcePhase is 1 for the current phase of each resource, the series of its previous phase is removed
+	resourcePhase = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: ResourcePhaseMetricName,
+		Help: "Current phase of a resource, 1 for the phase the resource is in",
+	}, []string{"resource_type", "namespace", "name", "provider", "tier", "phase"})
+
+	// phases are the labels of the phase series of each resource, so it can be removed when the phase changes
+	phases = &phaseSeries{labels: map[resourceKey]prometheus.Labels{}}
+
+	// artifactExpiryDays is the number of days until each expiring artifact of a resource expires, e.g. its tls
+	// certificate, negative once it has expired
+	artifactExpiryDays = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: ArtifactExpiryDaysMetricName,
+		Help: "Days until an expiring artifact of a resource, e.g. a certificate or access key, expires",
+	}, []string{"resource_type", "namespace", "name", "kind", "artifact"})
+
+	// artifacts are the labels of the artifact expiry series of each resource, so the series of artifacts no longer
+	// reported can be removed
+	artifacts = &artifactSeries{labels: map[resourceKey][]prometheus.Labels{}}
+
+	// externalAccessChanges counts the source ranges allowed and revoked by changes of the external access of a
+	// resource, the series are kept after the resource is deleted as a record of the changes
+	externalAccessChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: ExternalAccessChangesMetricName,
+		Help: "Number of source ranges allowed or revoked by changes of the external access of a resource",
+	}, []string{"resource_type", "namespace", "name", "change"})
+
+	// deletionPaused is 1 for each resource whose deletion is paused by the deletion rate limit, the series is removed
+	// once its deletion is confirmed
+	deletionPaused = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: DeletionPausedMetricName,
+		Help: "Resources whose deletion is paused by the deletion rate limit, 1 until the deletion is confirmed",
+	}, []string{"resource_type", "namespace", "name"})
+
+	// probeDuration is how long the last connectivity check of each resource took, including failed checks which took
+	// until the check timed out
+	probeDuration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: ProbeDurationMetricName,
+		Help: "Time taken by the last connectivity check of a resource",
+	}, []string{"resource_type", "namespace", "name", "provider"})
+
+	// available is 1 for each resource whose last connectivity check succeeded, 0 if it failed
+	available = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: AvailableMetricName,
+		Help: "Result of the last connectivity check of a resource, 1 if a connection could be opened to it",
+	}, []string{"resource_type", "namespace", "name", "provider"})
+
+	// callTimeouts counts the calls of a provider to an operation of its cloud failing because their deadline passed
+	callTimeouts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: CallTimeoutsMetricName,
+		Help: "Number of calls of a provider to its cloud failing because their deadline passed",
+	}, []string{"provider", "operation"})
+
+	// probes are the labels of the connectivity check series of each resource, so they can be removed when the
+	// resource is deleted or moves to another provider
+	probes = &probeSeries{labels: map[resourceKey]prometheus.Labels{}}
+
+	// unexpectedReverts counts the updates of an object of a resource reverting a change made by something else, e.g.
+	// another controller or a manual edit
+	unexpectedReverts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: UnexpectedRevertsMetricName,
+		Help: "Number of updates of an object reverting a change made outside of the operator",
+	}, []string{"namespace", "name", "kind"})
+
+	// reconcileConflict is 1 for each object the operator stopped reverting because it keeps being reverted, labelled
+	// with the field manager that last changed it. The series is removed once the conflict is cleared
+	reconcileConflict = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: ReconcileConflictMetricName,
+		Help: "Objects in conflict with another field manager reverting the changes of the operator, 1 while in conflict",
+	}, []string{"namespace", "name", "kind", "field_manager"})
+
+	// cachedReads counts the reads of the kinds read from the informer cache, and cachedReadMisses the reads of them the
+	// cache couldn't serve, which went to the api server
+	cachedReads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: CachedReadsMetricName,
+		Help: "Number of reads of a kind read from the informer cache",
+	}, []string{"kind"})
+	cachedReadMisses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: CachedReadMissesMetricName,
+		Help: "Number of reads of a kind the informer cache couldn't serve, which were read from the api server",
+	}, []string{"kind"})
+
+	// poolerActiveClients, poolerWaitingClients and poolerServerConns are the client connections paired with a server
+	// connection, the client connections waiting for one and the server connections open to the database, summed over
+	// the pods of the connection pooler of a postgres. poolerMaxWait is how long the oldest waiting client has waited
+	poolerActiveClients = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: PoolerActiveClientsMetricName,
+		Help: "Client connections of the connection pooler of a postgres paired with a server connection",
+	}, []string{"namespace", "name"})
+	poolerWaitingClients = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: PoolerWaitingClientsMetricName,
+		Help: "Client connections of the connection pooler of a postgres waiting for a server connection",
+	}, []string{"namespace", "name"})
+	poolerServerConns = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: PoolerServerConnsMetricName,
+		Help: "Server connections opened by the connection pooler of a postgres to the database",
+	}, []string{"namespace", "name"})
+	poolerMaxWait = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: PoolerMaxWaitMetricName,
+		Help: "Time the oldest client connection of the connection pooler of a postgres has waited for a server connection",
+	}, []string{"namespace", "name"})
+)
+
+func init() {
+	customMetrics.Registry.MustRegister(provisioningDuration, reconcileErrors, resourcePhase, artifactExpiryDays, externalAccessChanges, deletionPaused, probeDuration, available, callTimeouts, unexpectedReverts, reconcileConflict, cachedReads, cachedReadMisses, poolerActiveClients, poolerWaitingClients, poolerServerConns, poolerMaxWait)
+}
+
+type resourceKey struct {
+	resourceType string
+	key          types.NamespacedName
+}
+
+type phaseSeries struct {
+	mu     sync.Mutex
+	labels map[resourceKey]prometheus.Labels
+}
+
+type probeSeries struct {
+	mu     sync.Mutex
+	labels map[resourceKey]prometheus.Labels
+}
+
+type artifactSeries struct {
+	mu     sync.Mutex
+	labels map[resourceKey][]prometheus.Labels
+}
+
+// ArtifactExpiry is the number of days until an expiring artifact of a resource expires
+type ArtifactExpiry struct {
+	// Kind is the kind of artifact, e.g. TLSCertificate
+	Kind string
+	// Name identifies the artifact, e.g. the secret holding it
+	Name string
+	Days float64
+}
+
+// PoolerStats are the pool statistics of the connection pooler of a postgres
+type PoolerStats struct {
+	ActiveClients     float64
+	WaitingClients    float64
+	ServerConnections float64
+	MaxWait           time.Duration
+}
+
+// SetResourcePhase sets the current phase of a resource, replacing the series of its previous phase
+func SetResourcePhase(resourceType string, key types.NamespacedName, provider, tier string, phase croType.StatusPhase) {
+	if phase == "" {
+		return
+	}
+	labels := prometheus.Labels{
+		"resource_type": resourceType,
+		"namespace":     key.Namespace,
+		"name":          key.Name,
+		"provider":      provider,
+		"tier":          tier,
+		"phase":         string(phase),
+	}
+	phases.mu.Lock()
+	defer phases.mu.Unlock()
+	pk := resourceKey{resourceType: resourceType, key: key}
+	if prev, ok := phases.labels[pk]; ok {
+		resourcePhase.Delete(prev)
+	}
+	resourcePhase.With(labels).Set(1)
+	phases.labels[pk] = labels
+}
+
+// DeleteResourcePhase removes the phase series of a deleted resource
+func DeleteResourcePhase(resourceType string, key types.NamespacedName) {
+	phases.mu.Lock()
+	defer phases.mu.Unlock()
+	pk := resourceKey{resourceType: resourceType, key: key}
+	if prev, ok := phases.labels[pk]; ok {
+		resourcePhase.Delete(prev)
+		delete(phases.labels, pk)
+	}
+}
+
+// SetArtifactExpiry sets the days until each expiring artifact of a resource expires, replacing the series of the
+// artifacts it previously had
+func SetArtifactExpiry(resourceType string, key types.NamespacedName, expiries []ArtifactExpiry) {
+	artifacts.mu.Lock()
+	defer artifacts.mu.Unlock()
+	rk := resourceKey{resourceType: resourceType, key: key}
+	for _, prev := range artifacts.labels[rk] {
+		artifactExpiryDays.Delete(prev)
+	}
+	delete(artifacts.labels, rk)
+	for _, e := range expiries {
+		labels := prometheus.Labels{
+			"resource_type": resourceType,
+			"namespace":     key.Namespace,
+			"name":          key.Name,
+			"kind":          e.Kind,
+			"artifact":      e.Name,
+		}
+		artifactExpiryDays.With(labels).Set(e.Days)
+		artifacts.labels[rk] = append(artifacts.labels[rk], labels)
+	}
+}
+
+// DeleteArtifactExpiry removes the artifact expiry series of a deleted resource
+func DeleteArtifactExpiry(resourceType string, key types.NamespacedName) {
+	SetArtifactExpiry(resourceType, key, nil)
+}
+
+// IncReconcileErrors counts a reconcile of a resource returning an error
+func IncReconcileErrors(resourceType, provider string) {
+	reconcileErrors.With(prometheus.Labels{"resource_type": resourceType, "provider": provider}).Inc()
+}
+
+// ObserveReconcile records the phase of a reconciled resource and counts the reconcile if it returned an error
+func ObserveReconcile(resourceType string, key types.NamespacedName, provider, tier string, phase croType.StatusPhase, err error) {
+	SetResourcePhase(resourceType, key, provider, tier, phase)
+	if err != nil {
+		IncReconcileErrors(resourceType, provider)
+	}
+}
+
+// ObserveProvisioningDuration records how long a completed provisioning job took, from when it was queued, or started
+// if it was never queued
+func ObserveProvisioningDuration(resourceType, provider, tier string, job *croType.ProvisioningJob) {
+	if job == nil || job.State != croType.ProvisioningStateSucceeded || job.CompletedAt == nil {
+		return
+	}
+	start := job.StartedAt
+	if job.QueuedAt != nil {
+		start = job.QueuedAt
+	}
+	if start == nil {
+		return
+	}
+	provisioningDuration.With(prometheus.Labels{"resource_type": resourceType, "provider": provider, "tier": tier}).
+		Observe(job.CompletedAt.Sub(start.Time).Seconds())
+}
+
+// CountExternalAccessChange counts the source ranges allowed and revoked by a change of the external access of a
+// resource
+func CountExternalAccessChange(resourceType string, key types.NamespacedName, allowed, revoked int) {
+	labels := prometheus.Labels{"resource_type": resourceType, "namespace": key.Namespace, "name": key.Name}
+	if allowed > 0 {
+		labels["change"] = "allowed"
+		externalAccessChanges.With(labels).Add(float64(allowed))
+	}
+	if revoked > 0 {
+		labels["change"] = "revoked"
+		externalAccessChanges.With(labels).Add(float64(revoked))
+	}
+}
+
+// SetDeletionPaused sets whether the deletion of a resource is paused, the series of a resource whose deletion isn't
+// paused is removed
+func SetDeletionPaused(resourceType string, key types.NamespacedName, paused bool) {
+	labels := prometheus.Labels{"resource_type": resourceType, "namespace": key.Namespace, "name": key.Name}
+	if !paused {
+		deletionPaused.Delete(labels)
+		return
+	}
+	deletionPaused.With(labels).Set(1)
+}
+
+// IncCallTimeouts counts a call of a provider to an operation of its cloud failing because its deadline passed
+func IncCallTimeouts(provider, operation string) {
+	callTimeouts.With(prometheus.Labels{"provider": provider, "operation": operation}).Inc()
+}
+
+// ObserveProbe records the duration and result of a connectivity check of a resource, replacing the series of its
+// previous check
+func ObserveProbe(resourceType string, key types.NamespacedName, provider string, duration time.Duration, succeeded bool) {
+	labels := prometheus.Labels{
+		"resource_type": resourceType,
+		"namespace":     key.Namespace,
+		"name":          key.Name,
+		"provider":      provider,
+	}
+	probes.mu.Lock()
+	defer probes.mu.Unlock()
+	pk := resourceKey{resourceType: resourceType, key: key}
+	if prev, ok := probes.labels[pk]; ok {
+		probeDuration.Delete(prev)
+		available.Delete(prev)
+	}
+	probeDuration.With(labels).Set(duration.Seconds())
+	value := 0.0
+	if succeeded {
+		value = 1
+	}
+	available.With(labels).Set(value)
+	probes.labels[pk] = labels
+}
+
+// DeleteProbe removes the connectivity check series of a deleted resource
+func DeleteProbe(resourceType string, key types.NamespacedName) {
+	probes.mu.Lock()
+	defer probes.mu.Unlock()
+	pk := resourceKey{resourceType: resourceType, key: key}
+	if prev, ok := probes.labels[pk]; ok {
+		probeDuration.Delete(prev)
+		available.Delete(prev)
+		delete(probes.labels, pk)
+	}
+}
+
+// IncUnexpectedReverts counts an update of the object of the kind reverting a change made outside of the operator
+func IncUnexpectedReverts(kind, namespace, name string) {
+	unexpectedReverts.With(prometheus.Labels{"namespace": namespace, "name": name, "kind": kind}).Inc()
+}
+
+// SetReconcileConflict reports the object of the kind in conflict with the field manager reverting it
+func SetReconcileConflict(kind, namespace, name, fieldManager string) {
+	reconcileConflict.With(prometheus.Labels{"namespace": namespace, "name": name, "kind": kind, "field_manager": fieldManager}).Set(1)
+}
+
+// DeleteReconcileConflict removes the conflict series of the object of the kind with the field manager
+func DeleteReconcileConflict(kind, namespace, name, fieldManager string) {
+	reconcileConflict.Delete(prometheus.Labels{"namespace": namespace, "name": name, "kind": kind, "field_manager": fieldManager})
+}
+
+// IncCachedReads counts a read of the kind from the informer cache, and the miss if the cache couldn't serve it
+func IncCachedReads(kind string, missed bool) {
+	cachedReads.With(prometheus.Labels{"kind": kind}).Inc()
+	if missed {
+		cachedReadMisses.With(prometheus.Labels{"kind": kind}).Inc()
+	}
+}
+
+// SetPoolerStats sets the pool statistics of the connection pooler of a postgres
+func SetPoolerStats(key types.NamespacedName, stats PoolerStats) {
+	labels := prometheus.Labels{"namespace": key.Namespace, "name": key.Name}
+	poolerActiveClients.With(labels).Set(stats.ActiveClients)
+	poolerWaitingClients.With(labels).Set(stats.WaitingClients)
+	poolerServerConns.With(labels).Set(stats.ServerConnections)
+	poolerMaxWait.With(labels).Set(stats.MaxWait.Seconds())
+}
+
+// DeletePoolerStats removes the pool statistics series of a postgres whose pooler was removed or isn't running
+func DeletePoolerStats(key types.NamespacedName) {
+	labels := prometheus.Labels{"namespace": key.Namespace, "name": key.Name}
+	poolerActiveClients.Delete(labels)
+	poolerWaitingClients.Delete(labels)
+	poolerServerConns.Delete(labels)
+	poolerMaxWait.Delete(labels)
+}
@@ -0,0 +1,248 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	customMetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// gatherSeries returns the series of the metric matching the labels
+func gatherSeries(t *testing.T, name string, labels prometheus.Labels) []*dto.Metric {
+	families, err := customMetrics.Registry.Gather()
+	if err != nil {
+		t.Fatal("failed to gather metrics", err)
+	}
+	var series []*dto.Metric
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, m := range f.GetMetric() {
+			got := map[string]string{}
+			for _, l := range m.GetLabel() {
+				got[l.GetName()] = l.GetValue()
+			}
+			for k, v := range labels {
+				if got[k] != v {
+					continue metrics
+				}
+			}
+			series = append(series, m)
+		}
+	}
+	return series
+}
+
+func TestSetResourcePhase(t *testing.T) {
+	key := types.NamespacedName{Namespace: "test", Name: "test-phase"}
+	resource := prometheus.Labels{"resource_type": "postgres", "namespace": key.Namespace, "name": key.Name}
+
+	SetResourcePhase("postgres", key, "aws-rds", "production", croType.PhaseInProgress)
+	SetResourcePhase("postgres", key, "aws-rds", "production", croType.PhaseComplete)
+	series := gatherSeries(t, ResourcePhaseMetricName, resource)
+	if len(series) != 1 {
+		t.Fatalf("SetResourcePhase() series = %v, want only the current phase", series)
+	}
+	for _, l := range series[0].GetLabel() {
+		if l.GetName() == "phase" && l.GetValue() != string(croType.PhaseComplete) {
+			t.Errorf("SetResourcePhase() phase = %s, want %s", l.GetValue(), croType.PhaseComplete)
+		}
+	}
+
+	DeleteResourcePhase("postgres", key)
+	if series := gatherSeries(t, ResourcePhaseMetricName, resource); len(series) != 0 {
+		t.Errorf("DeleteResourcePhase() series = %v, want none", series)
+	}
+}
+
+func TestObserveReconcile(t *testing.T) {
+	key := types.NamespacedName{Namespace: "test", Name: "test-errors"}
+	labels := prometheus.Labels{"resource_type": "redis", "provider": "test-provider"}
+
+	ObserveReconcile("redis", key, "test-provider", "development", croType.PhaseInProgress, nil)
+	ObserveReconcile("redis", key, "test-provider", "development", croType.PhaseFailed, errors.New("failed"))
+	ObserveReconcile("redis", key, "test-provider", "development", croType.PhaseFailed, errors.New("failed"))
+	series := gatherSeries(t, ReconcileErrorsMetricName, labels)
+	if len(series) != 1 || series[0].GetCounter().GetValue() != 2 {
+		t.Errorf("ObserveReconcile() errors = %v, want 2", series)
+	}
+	DeleteResourcePhase("redis", key)
+}
+
+func TestObserveProvisioningDuration(t *testing.T) {
+	now := time.Now()
+	at := func(d time.Duration) *metav1.Time {
+		t := metav1.NewTime(now.Add(d))
+		return &t
+	}
+	tests := []struct {
+		name      string
+		tier      string
+		job       *croType.ProvisioningJob
+		wantCount uint64
+		wantSum   float64
+	}{
+		{
+			name:      "test duration is from the job starting",
+			tier:      "started",
+			job:       &croType.ProvisioningJob{State: croType.ProvisioningStateSucceeded, StartedAt: at(-10 * time.Minute), CompletedAt: at(0)},
+			wantCount: 1,
+			wantSum:   600,
+		},
+		{
+			name:      "test duration includes the time queued",
+			tier:      "queued",
+			job:       &croType.ProvisioningJob{State: croType.ProvisioningStateSucceeded, QueuedAt: at(-20 * time.Minute), StartedAt: at(-10 * time.Minute), CompletedAt: at(0)},
+			wantCount: 1,
+			wantSum:   1200,
+		},
+		{
+			name: "test running jobs aren't observed",
+			tier: "running",
+			job:  &croType.ProvisioningJob{State: croType.ProvisioningStateRunning, StartedAt: at(-10 * time.Minute)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ObserveProvisioningDuration("blobstorage", "test-provider", tt.tier, tt.job)
+			series := gatherSeries(t, ProvisioningDurationMetricName, prometheus.Labels{"provider": "test-provider", "tier": tt.tier})
+			if tt.wantCount == 0 {
+				if len(series) != 0 {
+					t.Errorf("ObserveProvisioningDuration() series = %v, want none", series)
+				}
+				return
+			}
+			if len(series) != 1 {
+				t.Fatalf("ObserveProvisioningDuration() series = %v, want 1", series)
+			}
+			h := series[0].GetHistogram()
+			if h.GetSampleCount() != tt.wantCount || h.GetSampleSum() != tt.wantSum {
+				t.Errorf("ObserveProvisioningDuration() count = %d sum = %f, want count %d sum %f", h.GetSampleCount(), h.GetSampleSum(), tt.wantCount, tt.wantSum)
+			}
+		})
+	}
+}
+
+func TestSetArtifactExpiry(t *testing.T) {
+	key := types.NamespacedName{Namespace: "test", Name: "test-expiry"}
+	resource := prometheus.Labels{"resource_type": "postgres", "namespace": key.Namespace, "name": key.Name}
+
+	SetArtifactExpiry("postgres", key, []ArtifactExpiry{
+		{Kind: "TLSCertificate", Name: "test-tls", Days: 12},
+		{Kind: "CABundle", Name: "test-service-ca", Days: 400},
+	})
+	SetArtifactExpiry("postgres", key, []ArtifactExpiry{{Kind: "TLSCertificate", Name: "test-tls", Days: 11}})
+	series := gatherSeries(t, ArtifactExpiryDaysMetricName, resource)
+	if len(series) != 1 || series[0].GetGauge().GetValue() != 11 {
+		t.Fatalf("SetArtifactExpiry() series = %v, want only the reported artifact", series)
+	}
+
+	DeleteArtifactExpiry("postgres", key)
+	if series := gatherSeries(t, ArtifactExpiryDaysMetricName, resource); len(series) != 0 {
+		t.Errorf("DeleteArtifactExpiry() series = %v, want none", series)
+	}
+}
+
+func TestObserveProbe(t *testing.T) {
+	key := types.NamespacedName{Namespace: "test", Name: "test-probe"}
+	resource := prometheus.Labels{"resource_type": "redis", "namespace": key.Namespace, "name": key.Name}
+
+	ObserveProbe("redis", key, "openshift-redis", 2*time.Second, true)
+	ObserveProbe("redis", key, "aws-elasticache", 500*time.Millisecond, false)
+	duration := gatherSeries(t, ProbeDurationMetricName, resource)
+	if len(duration) != 1 || duration[0].GetGauge().GetValue() != 0.5 {
+		t.Fatalf("ObserveProbe() duration series = %v, want only the last check taking 0.5s", duration)
+	}
+	avail := gatherSeries(t, AvailableMetricName, resource)
+	if len(avail) != 1 || avail[0].GetGauge().GetValue() != 0 {
+		t.Fatalf("ObserveProbe() available series = %v, want only the failed last check", avail)
+	}
+	for _, l := range avail[0].GetLabel() {
+		if l.GetName() == "provider" && l.GetValue() != "aws-elasticache" {
+			t.Errorf("ObserveProbe() provider = %s, want aws-elasticache", l.GetValue())
+		}
+	}
+
+	DeleteProbe("redis", key)
+	if series := gatherSeries(t, AvailableMetricName, resource); len(series) != 0 {
+		t.Errorf("DeleteProbe() available series = %v, want none", series)
+	}
+	if series := gatherSeries(t, ProbeDurationMetricName, resource); len(series) != 0 {
+		t.Errorf("DeleteProbe() duration series = %v, want none", series)
+	}
+}
+
+func TestIncCallTimeouts(t *testing.T) {
+	labels := prometheus.Labels{"provider": "test-provider", "operation": "ModifyDBInstance"}
+	IncCallTimeouts("test-provider", "ModifyDBInstance")
+	IncCallTimeouts("test-provider", "ModifyDBInstance")
+	series := gatherSeries(t, CallTimeoutsMetricName, labels)
+	if len(series) != 1 || series[0].GetCounter().GetValue() != 2 {
+		t.Errorf("IncCallTimeouts() series = %v, want 2 timeouts", series)
+	}
+}
+
+func TestIncUnexpectedReverts(t *testing.T) {
+	labels := prometheus.Labels{"namespace": "test-ns", "name": "test", "kind": "*v1.ConfigMap"}
+	IncUnexpectedReverts("*v1.ConfigMap", "test-ns", "test")
+	series := gatherSeries(t, UnexpectedRevertsMetricName, labels)
+	if len(series) != 1 || series[0].GetCounter().GetValue() != 1 {
+		t.Errorf("IncUnexpectedReverts() series = %v, want 1 revert", series)
+	}
+}
+
+func TestSetReconcileConflict(t *testing.T) {
+	labels := prometheus.Labels{"namespace": "test-ns", "name": "test", "kind": "*v1.ConfigMap"}
+	SetReconcileConflict("*v1.ConfigMap", "test-ns", "test", "other-controller")
+	series := gatherSeries(t, ReconcileConflictMetricName, labels)
+	if len(series) != 1 || series[0].GetGauge().GetValue() != 1 {
+		t.Fatalf("SetReconcileConflict() series = %v, want 1 conflict", series)
+	}
+	DeleteReconcileConflict("*v1.ConfigMap", "test-ns", "test", "other-controller")
+	if series := gatherSeries(t, ReconcileConflictMetricName, labels); len(series) != 0 {
+		t.Errorf("DeleteReconcileConflict() series = %v, want none", series)
+	}
+}
+
+func TestIncCachedReads(t *testing.T) {
+	labels := prometheus.Labels{"kind": "TestKind"}
+	IncCachedReads("TestKind", false)
+	IncCachedReads("TestKind", true)
+	if series := gatherSeries(t, CachedReadsMetricName, labels); len(series) != 1 || series[0].GetCounter().GetValue() != 2 {
+		t.Errorf("IncCachedReads() reads = %v, want 2 reads", series)
+	}
+	if series := gatherSeries(t, CachedReadMissesMetricName, labels); len(series) != 1 || series[0].GetCounter().GetValue() != 1 {
+		t.Errorf("IncCachedReads() misses = %v, want 1 miss", series)
+	}
+}
+
+func TestSetPoolerStats(t *testing.T) {
+	key := types.NamespacedName{Namespace: "test", Name: "test-pooler"}
+	labels := prometheus.Labels{"namespace": key.Namespace, "name": key.Name}
+	SetPoolerStats(key, PoolerStats{ActiveClients: 4, WaitingClients: 2, ServerConnections: 5, MaxWait: 1500 * time.Millisecond})
+	for name, want := range map[string]float64{
+		PoolerActiveClientsMetricName:  4,
+		PoolerWaitingClientsMetricName: 2,
+		PoolerServerConnsMetricName:    5,
+		PoolerMaxWaitMetricName:        1.5,
+	} {
+		if series := gatherSeries(t, name, labels); len(series) != 1 || series[0].GetGauge().GetValue() != want {
+			t.Errorf("SetPoolerStats() %s = %v, want %v", name, series, want)
+		}
+	}
+
+	DeletePoolerStats(key)
+	for _, name := range []string{PoolerActiveClientsMetricName, PoolerWaitingClientsMetricName, PoolerServerConnsMetricName, PoolerMaxWaitMetricName} {
+		if series := gatherSeries(t, name, labels); len(series) != 0 {
+			t.Errorf("DeletePoolerStats() %s = %v, want none", name, series)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package resources
+
+import (
+	"crypto/rand"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	errorUtil "github.com/pkg/errors"
+)
+
+const (
+	passwordLowerChars  = "abcdefghijklmnopqrstuvwxyz"
+	passwordUpperChars  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	passwordDigitChars  = "0123456789"
+	passwordSymbolChars = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+)
+
+// PasswordExclusions are the named sets of characters a password policy can exclude
+var PasswordExclusions = map[string]string{
+	// shell excludes characters with a meaning in shells, so passwords can be pasted in commands unquoted
+	"shell": " !\"#$&'()*;<>?[\\]`{|}~",
+	// uri excludes characters reserved in uris, so passwords can be put in connection uris without being escaped
+	"uri": " !#$%&'()*+,/:;=?@[]",
+	// ambiguous excludes characters that are easily confused when a password is read
+	"ambiguous": "0O1lI",
+}
+
+// PasswordPolicy configures the passwords generated for the credentials of every provider
+type PasswordPolicy struct {
+	// Length is the number of characters of a password
+	Length int
+	// MinLower, MinUpper and MinDigits are the minimum number of characters of each class, the classes are always
+	// used
+	MinLower  int
+	MinUpper  int
+	MinDigits int
+	// MinSymbols is the minimum number of punctuation characters, symbols are only used if it's set
+	MinSymbols int
+	// Exclude are the characters never used
+	Exclude string
+	// MinEntropyBits is the minimum entropy of a password, estimated from its length and the number of characters it
+	// can be generated from
+	MinEntropyBits float64
+}
+
+// PasswordConstraints are the rules a provider imposes on passwords, e.g. rds master passwords
+type PasswordConstraints struct {
+	Name      string
+	MinLength int
+	MaxLength int
+	// Forbidden are the characters the provider doesn't accept
+	Forbidden string
+}
+
+// DefaultPasswordPolicy generates alphanumeric passwords, which are valid for every provider and safe in shells and
+// uris
+var DefaultPasswordPolicy = PasswordPolicy{
+	Length:         32,
+	MinLower:       1,
+	MinUpper:       1,
+	MinDigits:      1,
+	MinEntropyBits: 128,
+}
+
+// CurrentPasswordPolicy is the policy generated passwords follow, set from the flags of the operator
+var CurrentPasswordPolicy = DefaultPasswordPolicy
+
+// ParsePasswordPolicy parses a comma separated list of settings over the default policy, e.g.
+// length=40,symbols=2,exclude=shell,exclude=uri,min-entropy=160. exclude takes the name of a set of PasswordExclusions
+// and can be repeated
+func ParsePasswordPolicy(s string) (PasswordPolicy, error) {
+	policy := DefaultPasswordPolicy
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return PasswordPolicy{}, errorUtil.Errorf("invalid password policy setting %s, expected key=value", entry)
+		}
+		key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if key == "exclude" {
+			chars, ok := PasswordExclusions[value]
+			if !ok {
+				return PasswordPolicy{}, errorUtil.Errorf("unknown password exclusion %s, expected shell, uri or ambiguous", value)
+			}
+			policy.Exclude += chars
+			continue
+		}
+		if key == "min-entropy" {
+			bits, err := strconv.ParseFloat(value, 64)
+			if err != nil || bits < 0 {
+				return PasswordPolicy{}, errorUtil.Errorf("invalid password policy setting %s, min-entropy must be a positive number", entry)
+			}
+			policy.MinEntropyBits = bits
+			continue
+		}
+		fields := map[string]*int{"length": &policy.Length, "lower": &policy.MinLower, "upper": &policy.MinUpper, "digits": &policy.MinDigits, "symbols": &policy.MinSymbols}
+		field, ok := fields[key]
+		if !ok {
+			return PasswordPolicy{}, errorUtil.Errorf("unknown password policy setting %s", key)
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return PasswordPolicy{}, errorUtil.Errorf("invalid password policy setting %s, value must be a positive number", entry)
+		}
+		*field = n
+	}
+	if err := policy.Validate(); err != nil {
+		return PasswordPolicy{}, err
+	}
+	return policy, nil
+}
+
+// Validate returns an error if the policy can't generate passwords, or generates passwords breaking the constraints
+// of a provider
+func (p PasswordPolicy) Validate(constraints ...PasswordConstraints) error {
+	if p.Length < 1 {
+		return errorUtil.New("password length must be positive")
+	}
+	if min := p.MinLower + p.MinUpper + p.MinDigits + p.MinSymbols; min > p.Length {
+		return errorUtil.Errorf("password length %d is less than the %d characters required by the minimum of each class", p.Length, min)
+	}
+	for _, class := range p.classes() {
+		if class.min > 0 && class.chars == "" {
+			return errorUtil.Errorf("password policy requires %s characters but excludes all of them", class.name)
+		}
+	}
+	if entropy := p.EntropyBits(); entropy < p.MinEntropyBits {
+		return errorUtil.Errorf("password entropy of %.0f bits is less than the minimum of %.0f bits", entropy, p.MinEntropyBits)
+	}
+	for _, c := range constraints {
+		if c.MinLength > 0 && p.Length < c.MinLength || c.MaxLength > 0 && p.Length > c.MaxLength {
+			return errorUtil.Errorf("password length %d is outside the %d to %d characters accepted for %s", p.Length, c.MinLength, c.MaxLength, c.Name)
+		}
+		if i := strings.IndexAny(p.alphabet(), c.Forbidden); i >= 0 {
+			return errorUtil.Errorf("password policy allows %q which isn't accepted for %s, exclude it", p.alphabet()[i], c.Name)
+		}
+	}
+	return nil
+}
+
+// EntropyBits estimates the entropy of a password from its length and the number of characters it's generated from
+func (p PasswordPolicy) EntropyBits() float64 {
+	n := len(p.alphabet())
+	if n < 2 {
+		return 0
+	}
+	return float64(p.Length) * math.Log2(float64(n))
+}
+
+// Generate returns a random password following the policy, it must satisfy the constraints of the provider the password
+// is generated for. The password is only registered as a secret value once it's stored, see EnsureCredentialSecret
+func (p PasswordPolicy) Generate(constraints ...PasswordConstraints) (string, error) {
+	if err := p.Validate(constraints...); err != nil {
+		return "", errorUtil.Wrap(err, "invalid password policy")
+	}
+	var password []byte
+	for _, class := range p.classes() {
+		for i := 0; i < class.min; i++ {
+			c, err := randomChar(class.chars)
+			if err != nil {
+				return "", err
+			}
+			password = append(password, c)
+		}
+	}
+	alphabet := p.alphabet()
+	for len(password) < p.Length {
+		c, err := randomChar(alphabet)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+	// shuffle so the characters required of each class aren't at the start
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", errorUtil.Wrap(err, "error generating password")
+		}
+		password[i], password[j.Int64()] = password[j.Int64()], password[i]
+	}
+	return string(password), nil
+}
+
+type passwordClass struct {
+	name  string
+	chars string
+	min   int
+}
+
+// classes returns the character classes of the policy without the excluded characters, symbols are only a class if
+// they're required
+func (p PasswordPolicy) classes() []passwordClass {
+	classes := []passwordClass{
+		{name: "lower case", chars: p.without(passwordLowerChars), min: p.MinLower},
+		{name: "upper case", chars: p.without(passwordUpperChars), min: p.MinUpper},
+		{name: "digit", chars: p.without(passwordDigitChars), min: p.MinDigits},
+	}
+	if p.MinSymbols > 0 {
+		classes = append(classes, passwordClass{name: "symbol", chars: p.without(passwordSymbolChars), min: p.MinSymbols})
+	}
+	return classes
+}
+
+func (p PasswordPolicy) alphabet() string {
+	var alphabet string
+	for _, class := range p.classes() {
+		alphabet += class.chars
+	}
+	return alphabet
+}
+
+func (p PasswordPolicy) without(chars string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(p.Exclude, r) {
+			return -1
+		}
+		return r
+	}, chars)
+}
+
+func randomChar(chars string) (byte, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(len(chars))))
+	if err != nil {
+		return 0, errorUtil.Wrap(err, "error generating password")
+	}
+	return chars[i.Int64()], nil
+}
@@ -0,0 +1,145 @@
+package resources
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePasswordPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		want    PasswordPolicy
+		wantErr bool
+	}{
+		{
+			name:   "test empty policy is the default",
+			policy: "",
+			want:   DefaultPasswordPolicy,
+		},
+		{
+			name:   "test settings override the default",
+			policy: "length=40, symbols=2, exclude=shell, exclude=ambiguous, min-entropy=160",
+			want: PasswordPolicy{
+				Length:         40,
+				MinLower:       1,
+				MinUpper:       1,
+				MinDigits:      1,
+				MinSymbols:     2,
+				Exclude:        PasswordExclusions["shell"] + PasswordExclusions["ambiguous"],
+				MinEntropyBits: 160,
+			},
+		},
+		{
+			name:    "test unknown setting",
+			policy:  "size=40",
+			wantErr: true,
+		},
+		{
+			name:    "test unknown exclusion",
+			policy:  "exclude=json",
+			wantErr: true,
+		},
+		{
+			name:    "test invalid length",
+			policy:  "length=-1",
+			wantErr: true,
+		},
+		{
+			name:    "test entropy below the minimum",
+			policy:  "length=12",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePasswordPolicy(tt.policy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePasswordPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParsePasswordPolicy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	rds := PasswordConstraints{Name: "rds", MinLength: 8, MaxLength: 128, Forbidden: "/\"@ "}
+	tests := []struct {
+		name    string
+		policy  PasswordPolicy
+		wantErr string
+	}{
+		{
+			name:   "test default policy meets the constraints",
+			policy: DefaultPasswordPolicy,
+		},
+		{
+			name:    "test class minimums longer than the password",
+			policy:  PasswordPolicy{Length: 2, MinLower: 1, MinUpper: 1, MinDigits: 1},
+			wantErr: "less than the 3 characters",
+		},
+		{
+			name:    "test class fully excluded",
+			policy:  PasswordPolicy{Length: 32, MinDigits: 1, Exclude: "0123456789"},
+			wantErr: "requires digit characters",
+		},
+		{
+			name:    "test password longer than the constraints",
+			policy:  PasswordPolicy{Length: 200},
+			wantErr: "outside the 8 to 128 characters",
+		},
+		{
+			name:    "test symbols forbidden by the constraints",
+			policy:  PasswordPolicy{Length: 32, MinSymbols: 1},
+			wantErr: "isn't accepted for rds",
+		},
+		{
+			name:   "test forbidden symbols excluded",
+			policy: PasswordPolicy{Length: 32, MinSymbols: 1, Exclude: PasswordExclusions["uri"] + "\""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate(rds)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Validate() unexpected error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Validate() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicy_Generate(t *testing.T) {
+	policy := PasswordPolicy{Length: 24, MinLower: 2, MinUpper: 2, MinDigits: 2, MinSymbols: 2, Exclude: PasswordExclusions["shell"] + PasswordExclusions["uri"]}
+	for i := 0; i < 50; i++ {
+		password, err := policy.Generate()
+		if err != nil {
+			t.Fatalf("Generate() unexpected error = %v", err)
+		}
+		if len(password) != policy.Length {
+			t.Fatalf("Generate() = %s, want %d characters", password, policy.Length)
+		}
+		if strings.ContainsAny(password, policy.Exclude) {
+			t.Fatalf("Generate() = %s, want no excluded characters", password)
+		}
+		for _, class := range []string{passwordLowerChars, passwordUpperChars, passwordDigitChars, passwordSymbolChars} {
+			n := 0
+			for _, c := range password {
+				if strings.ContainsRune(class, c) {
+					n++
+				}
+			}
+			if n < 2 {
+				t.Fatalf("Generate() = %s, want at least 2 characters of %s", password, class)
+			}
+		}
+	}
+
+	if _, err := (PasswordPolicy{Length: 4, MinEntropyBits: 128}).Generate(); err == nil {
+		t.Error("Generate() expected an error for a policy below its entropy minimum")
+	}
+}
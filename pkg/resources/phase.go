@@ -6,6 +6,7 @@ import (
 
 	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -13,7 +14,8 @@ import (
 	errorUtil "github.com/pkg/errors"
 )
 
-//UpdatePhase Updates the custom resource with the current phase
+//UpdatePhase Updates the custom resource with the current phase and the standard conditions derived from it, a change
+//of phase is recorded in the history of the resource. Secrets in the message are redacted
 func UpdatePhase(ctx context.Context, client client.Client, inst runtime.Object, phase croType.StatusPhase, msg croType.StatusMessage) error {
 	if msg == croType.StatusEmpty {
 		return nil
@@ -22,8 +24,14 @@ func UpdatePhase(ctx context.Context, client client.Client, inst runtime.Object,
 	if err := runtime.Field(reflect.ValueOf(inst).Elem(), "Status", rts); err != nil {
 		return errorUtil.Wrap(err, "failed to retrieve status block from object")
 	}
-	rts.Message = msg
+	rts.Message = croType.StatusMessage(Redact(string(msg)))
+	RecordPhaseTransition(rts, rts.Phase, phase, rts.Message)
 	rts.Phase = phase
+	accessor, err := meta.Accessor(inst)
+	if err != nil {
+		return errorUtil.Wrap(err, "failed to retrieve metadata of object")
+	}
+	SetPhaseConditions(accessor, rts)
 	if err := runtime.SetField(*rts, reflect.ValueOf(inst).Elem(), "Status"); err != nil {
 		return errorUtil.Wrap(err, "failed to set status block of object")
 	}
@@ -33,7 +41,7 @@ func UpdatePhase(ctx context.Context, client client.Client, inst runtime.Object,
 	return nil
 }
 
-//UpdateSnapshotPhase Updates the snapshot custom resource with the current phase
+//UpdateSnapshotPhase Updates the snapshot custom resource with the current phase, secrets in the message are redacted
 func UpdateSnapshotPhase(ctx context.Context, client client.Client, inst runtime.Object, phase croType.StatusPhase, msg croType.StatusMessage) error {
 	if msg == croType.StatusEmpty {
 		return nil
@@ -42,7 +50,7 @@ func UpdateSnapshotPhase(ctx context.Context, client client.Client, inst runtime
 	if err := runtime.Field(reflect.ValueOf(inst).Elem(), "Status", rts); err != nil {
 		return errorUtil.Wrap(err, "failed to retrieve status block from object")
 	}
-	rts.Message = msg
+	rts.Message = croType.StatusMessage(Redact(string(msg)))
 	rts.Phase = phase
 	if err := runtime.SetField(*rts, reflect.ValueOf(inst).Elem(), "Status"); err != nil {
 		return errorUtil.Wrap(err, "failed to set status block of object")
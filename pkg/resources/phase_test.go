@@ -0,0 +1,43 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	errorUtil "github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestUpdatePhase_RedactsMessage(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	pg := &v1alpha1.Postgres{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"}}
+	client := fake.NewFakeClientWithScheme(scheme, pg)
+
+	msg := croType.StatusMessage("failed to create rds instance").WrapError(errorUtil.New(`InvalidParameterValue MasterUserPassword: "abc123xyz"`))
+	if err := UpdatePhase(context.TODO(), client, pg, croType.PhaseFailed, msg); err != nil {
+		t.Fatalf("UpdatePhase() unexpected error = %v", err)
+	}
+
+	got := &v1alpha1.Postgres{}
+	if err := client.Get(context.TODO(), types.NamespacedName{Name: "test", Namespace: "test"}, got); err != nil {
+		t.Fatalf("failed to get postgres: %v", err)
+	}
+	if leaks := FindSecrets(string(got.Status.Message)); len(leaks) > 0 {
+		t.Errorf("UpdatePhase() leaked %v in status message %q", leaks, got.Status.Message)
+	}
+	if got.Status.Phase != croType.PhaseFailed {
+		t.Errorf("UpdatePhase() phase = %s, want %s", got.Status.Phase, croType.PhaseFailed)
+	}
+	if cond := meta.FindStatusCondition(got.Status.Conditions, DegradedCondition); cond == nil || cond.Status != metav1.ConditionTrue || len(FindSecrets(cond.Message)) > 0 {
+		t.Errorf("UpdatePhase() degraded condition = %v, want a true condition with the redacted message", cond)
+	}
+}
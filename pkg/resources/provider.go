@@ -10,8 +10,10 @@ import (
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	controllerruntime "sigs.k8s.io/controller-runtime"
@@ -55,9 +57,10 @@ func (r *ReconcileResourceProvider) ReconcileResultSecret(ctx context.Context, o
 			}
 			return errors.Wrapf(ownerRefErr, "failed to set owner on secret %s", sec.Name)
 		}
+		AddGitOpsAnnotations(sec)
 		sec.Data = d
 		sec.Type = v1.SecretTypeOpaque
-		return nil
+		return ApplyMetadata(ctx, r.Client, sec)
 	})
 	if err != nil {
 		if updateErr := UpdatePhase(ctx, r.Client, o, croType.PhaseFailed, "failed to reconcile instance secret"); updateErr != nil {
@@ -67,3 +70,40 @@ func (r *ReconcileResourceProvider) ReconcileResultSecret(ctx context.Context, o
 	}
 	return nil
 }
+
+// ReconcileAlertRule creates or updates the prometheus rule of the alerts of the instance, owned by the instance so
+// it's removed with it. A nil rule removes the existing rule with the name
+func (r *ReconcileResourceProvider) ReconcileAlertRule(ctx context.Context, o runtime.Object, name string, rule *monitoringv1.PrometheusRule) error {
+	obj := o.(metav1.Object)
+	if rule == nil {
+		existing := &monitoringv1.PrometheusRule{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: obj.GetNamespace(),
+			},
+		}
+		if err := r.Client.Delete(ctx, existing); err != nil && !k8serr.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete prometheus rule %s", name)
+		}
+		return nil
+	}
+	pr := &monitoringv1.PrometheusRule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rule.Name,
+			Namespace: rule.Namespace,
+		},
+	}
+	_, err := controllerruntime.CreateOrUpdate(ctx, r.Client, pr, func() error {
+		if err := controllerutil.SetControllerReference(obj, pr, r.Scheme); err != nil {
+			return errors.Wrapf(err, "failed to set owner on prometheus rule %s", pr.Name)
+		}
+		AddGitOpsAnnotations(pr)
+		pr.Labels = rule.Labels
+		pr.Spec = rule.Spec
+		return ApplyMetadata(ctx, r.Client, pr)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to reconcile prometheus rule %s", pr.Name)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package resources
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	errorUtil "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultShutdownGracePeriod is how long in-flight operations are given to finish once the operator is asked to
+	// shut down, before their state is checkpointed. With the checkpoint timeout it's within the default termination
+	// grace period of a pod
+	DefaultShutdownGracePeriod = time.Second * 20
+
+	// checkpointTimeout is how long the state of the in-flight operations is given to be persisted
+	checkpointTimeout = time.Second * 5
+)
+
+// ErrShuttingDown is returned when an operation is started after the operator was asked to shut down
+var ErrShuttingDown = errorUtil.New("operator is shutting down")
+
+// CheckpointFunc persists the state of an in-flight operation so it can be resumed once the operator restarts
+type CheckpointFunc func(ctx context.Context) error
+
+// operations tracks the long running operations the reconcilers of the operator have in flight
+var operations = newOperationTracker()
+
+type operationTracker struct {
+	mu           sync.Mutex
+	shuttingDown bool
+	next         int
+	inFlight     map[int]CheckpointFunc
+	done         chan struct{}
+}
+
+func newOperationTracker() *operationTracker {
+	return &operationTracker{inFlight: map[int]CheckpointFunc{}}
+}
+
+// BeginOperation tracks an operation whose state is persisted with checkpoint if the operator shuts down while it's in
+// flight, the returned function ends the operation. Operations can't be started once the operator is shutting down, so
+// they're started by the next operator instead of being interrupted
+func BeginOperation(checkpoint CheckpointFunc) (func(), error) {
+	return operations.begin(checkpoint)
+}
+
+// SetupGracefulSignalHandler replaces the signal handler of controller runtime. The returned channel is closed once
+// the operator received a termination signal and its in-flight operations either finished within the grace period or
+// were checkpointed. A second signal exits immediately
+func SetupGracefulSignalHandler(gracePeriod time.Duration) <-chan struct{} {
+	stop := make(chan struct{})
+	c := make(chan os.Signal, 2)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-c
+		logrus.Infof("shutdown requested, waiting up to %s for in-flight operations", gracePeriod)
+		operations.shutdown(gracePeriod)
+		close(stop)
+		<-c
+		os.Exit(1)
+	}()
+	return stop
+}
+
+func (t *operationTracker) begin(checkpoint CheckpointFunc) (func(), error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.shuttingDown {
+		return nil, ErrShuttingDown
+	}
+	id := t.next
+	t.next++
+	t.inFlight[id] = checkpoint
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			delete(t.inFlight, id)
+			if len(t.inFlight) == 0 && t.done != nil {
+				close(t.done)
+				t.done = nil
+			}
+		})
+	}, nil
+}
+
+// shutdown stops operations from being started and waits up to the grace period for the in-flight operations to end,
+// the state of the operations still in flight after it is checkpointed
+func (t *operationTracker) shutdown(gracePeriod time.Duration) {
+	t.mu.Lock()
+	t.shuttingDown = true
+	var done chan struct{}
+	if len(t.inFlight) > 0 {
+		done = make(chan struct{})
+		t.done = done
+	}
+	t.mu.Unlock()
+
+	if done != nil {
+		select {
+		case <-done:
+		case <-time.After(gracePeriod):
+		}
+	}
+
+	t.mu.Lock()
+	var checkpoints []CheckpointFunc
+	for _, checkpoint := range t.inFlight {
+		checkpoints = append(checkpoints, checkpoint)
+	}
+	t.done = nil
+	t.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), checkpointTimeout)
+	defer cancel()
+	for _, checkpoint := range checkpoints {
+		if err := checkpoint(ctx); err != nil {
+			logrus.Errorf("failed to checkpoint in-flight operation: %v", err)
+		}
+	}
+	if len(checkpoints) > 0 {
+		logrus.Infof("checkpointed %d in-flight operations, they're resumed on restart", len(checkpoints))
+	}
+}
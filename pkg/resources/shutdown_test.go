@@ -0,0 +1,55 @@
+package resources
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestOperationTracker_shutdown(t *testing.T) {
+	tracker := newOperationTracker()
+	checkpointed := map[string]bool{}
+	checkpoint := func(name string) CheckpointFunc {
+		return func(ctx context.Context) error {
+			checkpointed[name] = true
+			return nil
+		}
+	}
+
+	endFinished, err := tracker.begin(checkpoint("finished"))
+	if err != nil {
+		t.Fatal("begin() failed", err)
+	}
+	if _, err := tracker.begin(checkpoint("interrupted")); err != nil {
+		t.Fatal("begin() failed", err)
+	}
+	endEarly, err := tracker.begin(checkpoint("ended"))
+	if err != nil {
+		t.Fatal("begin() failed", err)
+	}
+	endEarly()
+	// ending an operation twice is harmless
+	endEarly()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		endFinished()
+	}()
+	tracker.shutdown(50 * time.Millisecond)
+
+	if !checkpointed["interrupted"] || checkpointed["finished"] || checkpointed["ended"] {
+		t.Errorf("shutdown() checkpointed %v, want only the operation still in flight", checkpointed)
+	}
+	if _, err := tracker.begin(checkpoint("late")); err != ErrShuttingDown {
+		t.Errorf("begin() error = %v, want %v once shutting down", err, ErrShuttingDown)
+	}
+}
+
+func TestOperationTracker_shutdownWithoutOperations(t *testing.T) {
+	tracker := newOperationTracker()
+	start := time.Now()
+	tracker.shutdown(time.Minute)
+	if time.Since(start) > time.Second {
+		t.Errorf("shutdown() waited for the grace period without operations in flight")
+	}
+}
@@ -0,0 +1,176 @@
+// Package supportbundle writes a diagnostic bundle of a failed resource to a config map next to it, so support cases
+// start with the errors, provider events, strategy and timeline of the resource instead of a request for them
+package supportbundle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	"github.com/integr8ly/cloud-resource-operator/pkg/tiers"
+	errorUtil "github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// DataKey is the key of the bundle in the support bundle config map
+	DataKey = "bundle.json"
+	// MaxErrors is the number of the most recent errors kept in a bundle
+	MaxErrors = 10
+)
+
+// EventReader reads the recent events of the cloud resource of a resource from its provider
+type EventReader func(ctx context.Context) ([]providers.ProviderEvent, error)
+
+// Bundle is the diagnostic context of a failed resource
+type Bundle struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Type      string `json:"type"`
+	Tier      string `json:"tier"`
+	Strategy  string `json:"strategy"`
+	Provider  string `json:"provider"`
+	// StrategyHash identifies the tier configuration the resource failed with, it changes when the tier is edited
+	StrategyHash string `json:"strategyHash,omitempty"`
+	// Errors are the most recent distinct failures of the resource, oldest first
+	Errors []ErrorEntry `json:"errors"`
+	// ProviderEvents are the recent events of the cloud resource, read when a new error is recorded
+	ProviderEvents      []providers.ProviderEvent `json:"providerEvents,omitempty"`
+	ProviderEventsError string                    `json:"providerEventsError,omitempty"`
+	Timeline            []TimelineEntry           `json:"timeline"`
+	GeneratedAt         metav1.Time               `json:"generatedAt"`
+}
+
+// ErrorEntry is a failure of a resource, repeated failures with the same message are counted in one entry
+type ErrorEntry struct {
+	Time    metav1.Time `json:"time"`
+	Message string      `json:"message"`
+	Count   int         `json:"count"`
+}
+
+// TimelineEntry is a point in the life of a resource, e.g. its creation or a condition changing
+type TimelineEntry struct {
+	Time  metav1.Time `json:"time"`
+	Event string      `json:"event"`
+}
+
+// ConfigMapName returns the name of the support bundle config map of a resource
+func ConfigMapName(rt providers.ResourceType, name string) string {
+	return fmt.Sprintf("%s-%s-support-bundle", rt, name)
+}
+
+// Reconcile records the failure of the resource in its support bundle config map, which is owned by the resource and
+// removed with it. Provider events are only read when the failure differs from the last one recorded, so a resource
+// failing on every reconcile doesn't call the provider api each time. readEvents may be nil
+func Reconcile(ctx context.Context, c client.Client, scheme *runtime.Scheme, rt providers.ResourceType, owner metav1.Object, spec croType.ResourceTypeSpec, status croType.ResourceTypeStatus, readEvents EventReader) error {
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ConfigMapName(rt, owner.GetName()),
+			Namespace: owner.GetNamespace(),
+		},
+	}
+	bundle := &Bundle{}
+	if err := c.Get(ctx, types.NamespacedName{Name: cm.Name, Namespace: cm.Namespace}, cm); err != nil && !k8serr.IsNotFound(err) {
+		return errorUtil.Wrapf(err, "failed to get support bundle config map %s", cm.Name)
+	}
+	if raw, ok := cm.Data[DataKey]; ok {
+		// a bundle that can't be read is replaced, it only holds diagnostics
+		_ = json.Unmarshal([]byte(raw), bundle)
+	}
+
+	now := metav1.NewTime(time.Now())
+	newError := recordError(bundle, string(status.Message), now)
+	if readEvents != nil && (newError || bundle.ProviderEvents == nil && bundle.ProviderEventsError == "") {
+		events, err := readEvents(ctx)
+		bundle.ProviderEvents, bundle.ProviderEventsError = events, ""
+		if err != nil {
+			bundle.ProviderEventsError = resources.Redact(err.Error())
+		}
+	}
+	bundle.Kind = string(rt)
+	bundle.Name = owner.GetName()
+	bundle.Namespace = owner.GetNamespace()
+	bundle.Type = spec.Type
+	bundle.Tier = spec.Tier
+	bundle.Strategy = status.Strategy
+	bundle.Provider = status.Provider
+	bundle.StrategyHash = strategyHash(ctx, c, rt, spec, status.Strategy, owner.GetNamespace())
+	bundle.Timeline = buildTimeline(owner, status, bundle.Errors)
+	bundle.GeneratedAt = now
+
+	raw, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return errorUtil.Wrap(err, "failed to marshal support bundle")
+	}
+	if _, err := controllerutil.CreateOrUpdate(ctx, c, cm, func() error {
+		resources.AddGitOpsAnnotations(cm)
+		cm.Data = map[string]string{DataKey: string(raw)}
+		return controllerutil.SetControllerReference(owner, cm, scheme)
+	}); err != nil {
+		return errorUtil.Wrapf(err, "failed to create or update support bundle config map %s", cm.Name)
+	}
+	return nil
+}
+
+// recordError adds the message to the errors of the bundle, or counts it if it's the last error recorded. It returns
+// true if a new error was added
+func recordError(bundle *Bundle, msg string, now metav1.Time) bool {
+	msg = resources.Redact(msg)
+	if n := len(bundle.Errors); n > 0 && bundle.Errors[n-1].Message == msg {
+		bundle.Errors[n-1].Count++
+		bundle.Errors[n-1].Time = now
+		return false
+	}
+	bundle.Errors = append(bundle.Errors, ErrorEntry{Time: now, Message: msg, Count: 1})
+	if len(bundle.Errors) > MaxErrors {
+		bundle.Errors = bundle.Errors[len(bundle.Errors)-MaxErrors:]
+	}
+	return true
+}
+
+// strategyHash returns the sha256 of the tier configuration of the resource, empty if it can't be read
+func strategyHash(ctx context.Context, c client.Client, rt providers.ResourceType, spec croType.ResourceTypeSpec, strategy, ns string) string {
+	if spec.Tier == "" || strategy == "" {
+		return ""
+	}
+	cm, err := tiers.GetStrategyConfigMap(ctx, c, strategy, ns)
+	if err != nil {
+		return ""
+	}
+	return tiers.StrategyHash(cm.Data, rt, &spec)
+}
+
+// buildTimeline returns the creation, deletion, condition changes and errors of the resource in the order they happened
+func buildTimeline(owner metav1.Object, status croType.ResourceTypeStatus, errs []ErrorEntry) []TimelineEntry {
+	timeline := []TimelineEntry{{Time: owner.GetCreationTimestamp(), Event: "created"}}
+	if ts := owner.GetDeletionTimestamp(); ts != nil {
+		timeline = append(timeline, TimelineEntry{Time: *ts, Event: "deletion requested"})
+	}
+	for _, cond := range status.Conditions {
+		timeline = append(timeline, TimelineEntry{Time: cond.LastTransitionTime, Event: fmt.Sprintf("condition %s is %s: %s", cond.Type, cond.Status, cond.Reason)})
+	}
+	for _, e := range errs {
+		event := "failed: " + e.Message
+		if e.Count > 1 {
+			event = fmt.Sprintf("failed %d times: %s", e.Count, e.Message)
+		}
+		timeline = append(timeline, TimelineEntry{Time: e.Time, Event: strings.TrimSpace(event)})
+	}
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].Time.Before(&timeline[j].Time)
+	})
+	return timeline
+}
@@ -0,0 +1,137 @@
+package supportbundle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testNamespace = "test"
+
+func buildTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	return scheme
+}
+
+func buildTestPostgres(msg string) *v1alpha1.Postgres {
+	return &v1alpha1.Postgres{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: testNamespace,
+			UID:       "test-uid",
+		},
+		Spec: croType.ResourceTypeSpec{
+			Type: "workshop",
+			Tier: "development",
+		},
+		Status: croType.ResourceTypeStatus{
+			Strategy: providers.OpenShiftDeploymentStrategy,
+			Provider: "openshift-postgres",
+			Phase:    croType.PhaseFailed,
+			Message:  croType.StatusMessage(msg),
+		},
+	}
+}
+
+func getTestBundle(t *testing.T, c client.Client) (*v1.ConfigMap, *Bundle) {
+	cm := &v1.ConfigMap{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: "postgres-test-support-bundle", Namespace: testNamespace}, cm); err != nil {
+		t.Fatal("failed to get support bundle config map", err)
+	}
+	bundle := &Bundle{}
+	if err := json.Unmarshal([]byte(cm.Data[DataKey]), bundle); err != nil {
+		t.Fatal("failed to unmarshal support bundle", err)
+	}
+	return cm, bundle
+}
+
+func TestReconcile(t *testing.T) {
+	scheme := buildTestScheme(t)
+	strategies := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "cloud-resources-openshift-strategies", Namespace: testNamespace},
+		Data:       map[string]string{"postgres": `{"development": {"deploymentSpec": {}}}`},
+	}
+	ps := buildTestPostgres("failed to create deployment")
+	c := fake.NewFakeClientWithScheme(scheme, ps, strategies)
+
+	eventReads := 0
+	readEvents := func(ctx context.Context) ([]providers.ProviderEvent, error) {
+		eventReads++
+		if eventReads > 1 {
+			return nil, errors.New("throttled")
+		}
+		return []providers.ProviderEvent{{Message: "instance restarted"}}, nil
+	}
+
+	if err := Reconcile(context.TODO(), c, scheme, providers.PostgresResourceType, ps, ps.Spec, ps.Status, readEvents); err != nil {
+		t.Fatal("Reconcile() failed", err)
+	}
+	cm, bundle := getTestBundle(t, c)
+	if len(cm.OwnerReferences) != 1 || cm.OwnerReferences[0].Name != ps.Name {
+		t.Errorf("Reconcile() owner references = %v, want postgres %s", cm.OwnerReferences, ps.Name)
+	}
+	if bundle.StrategyHash == "" || bundle.Tier != "development" || bundle.Provider != "openshift-postgres" {
+		t.Errorf("Reconcile() bundle = %+v, want strategy hash, tier and provider", bundle)
+	}
+	if len(bundle.ProviderEvents) != 1 || bundle.ProviderEvents[0].Message != "instance restarted" {
+		t.Errorf("Reconcile() provider events = %v, want instance restarted", bundle.ProviderEvents)
+	}
+
+	// the same failure is counted without reading the provider events again
+	if err := Reconcile(context.TODO(), c, scheme, providers.PostgresResourceType, ps, ps.Spec, ps.Status, readEvents); err != nil {
+		t.Fatal("Reconcile() failed", err)
+	}
+	_, bundle = getTestBundle(t, c)
+	if len(bundle.Errors) != 1 || bundle.Errors[0].Count != 2 || eventReads != 1 {
+		t.Errorf("Reconcile() errors = %v after %d event reads, want one error counted twice after one read", bundle.Errors, eventReads)
+	}
+
+	// a new failure is recorded and the provider events are read again
+	ps.Status.Message = "failed to create service"
+	if err := Reconcile(context.TODO(), c, scheme, providers.PostgresResourceType, ps, ps.Spec, ps.Status, readEvents); err != nil {
+		t.Fatal("Reconcile() failed", err)
+	}
+	_, bundle = getTestBundle(t, c)
+	if len(bundle.Errors) != 2 || bundle.Errors[1].Message != "failed to create service" {
+		t.Errorf("Reconcile() errors = %v, want the new failure last", bundle.Errors)
+	}
+	if bundle.ProviderEvents != nil || bundle.ProviderEventsError != "throttled" {
+		t.Errorf("Reconcile() provider events = %v, error %s, want error throttled", bundle.ProviderEvents, bundle.ProviderEventsError)
+	}
+}
+
+func TestRecordError(t *testing.T) {
+	bundle := &Bundle{}
+	for i := 0; i < MaxErrors+2; i++ {
+		if !recordError(bundle, fmt.Sprintf("failure %d", i), metav1.Now()) {
+			t.Fatalf("recordError() = false for failure %d, want true", i)
+		}
+	}
+	if len(bundle.Errors) != MaxErrors || bundle.Errors[0].Message != "failure 2" {
+		t.Errorf("recordError() kept %d errors starting with %s, want %d starting with failure 2", len(bundle.Errors), bundle.Errors[0].Message, MaxErrors)
+	}
+	if recordError(bundle, fmt.Sprintf("failure %d", MaxErrors+1), metav1.Now()) {
+		t.Errorf("recordError() = true for a repeated failure, want false")
+	}
+	if bundle.Errors[MaxErrors-1].Count != 2 {
+		t.Errorf("recordError() count = %d, want 2", bundle.Errors[MaxErrors-1].Count)
+	}
+}
@@ -0,0 +1,296 @@
+// Package telemetry posts anonymous usage reports of the operator to a configured endpoint, so maintainers can tell
+// which providers and tiers are used and which errors are common. Reports are opt-in with the OperatorConfig of the
+// operator and only hold counts, no names, namespaces, messages or cluster identifiers
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/version"
+	errorUtil "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ConfigName is the name of the OperatorConfig read by the operator, in the namespace of the operator
+	ConfigName = "cloud-resource-operator"
+	// DefaultInterval is how often a report is posted if the OperatorConfig doesn't set an interval
+	DefaultInterval = 24 * time.Hour
+
+	// checkInterval is how often the OperatorConfig is read, so enabling reports doesn't wait for a full interval
+	checkInterval      = time.Hour
+	defaultPostTimeout = 30 * time.Second
+)
+
+// Error categories of failed resources, a failed resource is counted in the first category its message matches
+const (
+	ErrorCategoryCredentials   = "credentials"
+	ErrorCategoryQuota         = "quota"
+	ErrorCategoryConfiguration = "configuration"
+	ErrorCategoryNetwork       = "network"
+	ErrorCategoryProvider      = "provider-unavailable"
+	ErrorCategoryOther         = "other"
+)
+
+// errorCategoryKeywords are matched against the lower case status message of a failed resource, in order
+var errorCategoryKeywords = []struct {
+	category string
+	keywords []string
+}{
+	{ErrorCategoryCredentials, []string{"credential", "permission", "unauthorized", "forbidden", "access denied", "accessdenied"}},
+	{ErrorCategoryQuota, []string{"quota", "limit exceeded", "limitexceeded", "insufficient"}},
+	{ErrorCategoryConfiguration, []string{"config", "strategy", "tier", "invalid"}},
+	{ErrorCategoryNetwork, []string{"network", "vpc", "subnet", "security group", "cidr"}},
+	{ErrorCategoryProvider, []string{"timeout", "timed out", "throttl", "unavailable", "rate exceeded"}},
+}
+
+// ResourceCount is the number of resources of a kind using a provider and tier
+type ResourceCount struct {
+	Kind     string `json:"kind"`
+	Provider string `json:"provider"`
+	Tier     string `json:"tier"`
+	Count    int    `json:"count"`
+}
+
+// ErrorCount is the number of failed resources of a kind in an error category
+type ErrorCount struct {
+	Kind     string `json:"kind"`
+	Category string `json:"category"`
+	Count    int    `json:"count"`
+}
+
+// Report is an anonymous usage report of an installation of the operator
+type Report struct {
+	InstallationID  string          `json:"installationID"`
+	OperatorVersion string          `json:"operatorVersion"`
+	GeneratedAt     metav1.Time     `json:"generatedAt"`
+	Resources       []ResourceCount `json:"resources"`
+	Errors          []ErrorCount    `json:"errors"`
+}
+
+// Reporter posts a report on the interval set in the OperatorConfig while reports are enabled
+type Reporter struct {
+	client     client.Client
+	namespace  string
+	httpClient *http.Client
+	logger     *logrus.Entry
+}
+
+func NewReporter(c client.Client, namespace string) *Reporter {
+	return &Reporter{
+		client:     c,
+		namespace:  namespace,
+		httpClient: &http.Client{Timeout: defaultPostTimeout},
+		logger:     logrus.WithFields(logrus.Fields{"action": "telemetry"}),
+	}
+}
+
+// Start posts reports until the stop channel is closed, it implements the runnable interface of the manager
+func (r *Reporter) Start(stop <-chan struct{}) error {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		if err := r.Run(context.Background(), time.Now()); err != nil {
+			r.logger.Warnf("failed to post usage report: %v", err)
+		}
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run posts a report if reports are enabled and the interval passed since the last one, the outcome is recorded in
+// the status of the OperatorConfig
+func (r *Reporter) Run(ctx context.Context, now time.Time) error {
+	cfg := &v1alpha1.OperatorConfig{}
+	if err := r.client.Get(ctx, types.NamespacedName{Name: ConfigName, Namespace: r.namespace}, cfg); err != nil {
+		if k8serr.IsNotFound(err) {
+			return nil
+		}
+		return errorUtil.Wrapf(err, "failed to get operator config %s", ConfigName)
+	}
+	spec := cfg.Spec.Telemetry
+	if spec == nil || !spec.Enabled {
+		return nil
+	}
+	if spec.Endpoint == "" {
+		return r.updateStatus(ctx, cfg, nil, "telemetry is enabled without an endpoint")
+	}
+	interval := DefaultInterval
+	if spec.Interval != nil && spec.Interval.Duration > 0 {
+		interval = spec.Interval.Duration
+	}
+	status := cfg.Status.Telemetry
+	if status != nil && status.LastReportedAt != nil && now.Before(status.LastReportedAt.Add(interval)) {
+		return nil
+	}
+
+	installationID := ""
+	if status != nil {
+		installationID = status.InstallationID
+	}
+	if installationID == "" {
+		id, err := newInstallationID()
+		if err != nil {
+			return err
+		}
+		installationID = id
+	}
+	report, err := BuildReport(ctx, r.client, installationID, now)
+	if err != nil {
+		return err
+	}
+	if err := r.post(ctx, spec.Endpoint, report); err != nil {
+		if updateErr := r.updateStatus(ctx, cfg, &installationID, err.Error()); updateErr != nil {
+			return updateErr
+		}
+		return err
+	}
+	reportedAt := metav1.NewTime(now)
+	cfg.Status.Telemetry = &v1alpha1.TelemetryStatus{InstallationID: installationID, LastReportedAt: &reportedAt}
+	if err := r.client.Status().Update(ctx, cfg); err != nil {
+		return errorUtil.Wrapf(err, "failed to update status of operator config %s", ConfigName)
+	}
+	return nil
+}
+
+// updateStatus records the error of a failed attempt, keeping the time of the last report
+func (r *Reporter) updateStatus(ctx context.Context, cfg *v1alpha1.OperatorConfig, installationID *string, msg string) error {
+	if cfg.Status.Telemetry == nil {
+		cfg.Status.Telemetry = &v1alpha1.TelemetryStatus{}
+	}
+	if installationID != nil {
+		cfg.Status.Telemetry.InstallationID = *installationID
+	}
+	cfg.Status.Telemetry.Message = msg
+	if err := r.client.Status().Update(ctx, cfg); err != nil {
+		return errorUtil.Wrapf(err, "failed to update status of operator config %s", ConfigName)
+	}
+	return nil
+}
+
+func (r *Reporter) post(ctx context.Context, endpoint string, report *Report) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return errorUtil.Wrap(err, "failed to marshal usage report")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return errorUtil.Wrap(err, "failed to build usage report request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return errorUtil.Wrapf(err, "failed to post usage report to %s", endpoint)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("usage report endpoint %s returned status %d", endpoint, resp.StatusCode)
+	}
+	return nil
+}
+
+// BuildReport counts the resources of every kind by provider and tier, and the failed resources by error category
+func BuildReport(ctx context.Context, c client.Client, installationID string, now time.Time) (*Report, error) {
+	resourceCounts := map[ResourceCount]int{}
+	errorCounts := map[ErrorCount]int{}
+	count := func(kind string, spec croType.ResourceTypeSpec, status croType.ResourceTypeStatus) {
+		resourceCounts[ResourceCount{Kind: kind, Provider: status.Provider, Tier: spec.Tier}]++
+		if status.Phase == croType.PhaseFailed {
+			errorCounts[ErrorCount{Kind: kind, Category: ErrorCategory(status.Message)}]++
+		}
+	}
+
+	postgres := &v1alpha1.PostgresList{}
+	if err := c.List(ctx, postgres); err != nil {
+		return nil, errorUtil.Wrap(err, "failed to list postgres")
+	}
+	for _, ps := range postgres.Items {
+		count("Postgres", ps.Spec, ps.Status)
+	}
+	redis := &v1alpha1.RedisList{}
+	if err := c.List(ctx, redis); err != nil {
+		return nil, errorUtil.Wrap(err, "failed to list redis")
+	}
+	for _, rd := range redis.Items {
+		count("Redis", rd.Spec, rd.Status)
+	}
+	blobStorages := &v1alpha1.BlobStorageList{}
+	if err := c.List(ctx, blobStorages); err != nil {
+		return nil, errorUtil.Wrap(err, "failed to list blob storages")
+	}
+	for _, bs := range blobStorages.Items {
+		count("BlobStorage", bs.Spec, bs.Status)
+	}
+	queues := &v1alpha1.QueueList{}
+	if err := c.List(ctx, queues); err != nil {
+		return nil, errorUtil.Wrap(err, "failed to list queues")
+	}
+	for _, q := range queues.Items {
+		count("Queue", q.Spec, q.Status)
+	}
+
+	report := &Report{
+		InstallationID:  installationID,
+		OperatorVersion: version.Version,
+		GeneratedAt:     metav1.NewTime(now),
+		Resources:       []ResourceCount{},
+		Errors:          []ErrorCount{},
+	}
+	for rc, n := range resourceCounts {
+		rc.Count = n
+		report.Resources = append(report.Resources, rc)
+	}
+	for ec, n := range errorCounts {
+		ec.Count = n
+		report.Errors = append(report.Errors, ec)
+	}
+	sort.Slice(report.Resources, func(i, j int) bool {
+		a, b := report.Resources[i], report.Resources[j]
+		return a.Kind+"/"+a.Provider+"/"+a.Tier < b.Kind+"/"+b.Provider+"/"+b.Tier
+	})
+	sort.Slice(report.Errors, func(i, j int) bool {
+		a, b := report.Errors[i], report.Errors[j]
+		return a.Kind+"/"+a.Category < b.Kind+"/"+b.Category
+	})
+	return report, nil
+}
+
+// ErrorCategory returns the category of the status message of a failed resource, the message itself isn't reported
+func ErrorCategory(msg croType.StatusMessage) string {
+	m := strings.ToLower(string(msg))
+	for _, c := range errorCategoryKeywords {
+		for _, k := range c.keywords {
+			if strings.Contains(m, k) {
+				return c.category
+			}
+		}
+	}
+	return ErrorCategoryOther
+}
+
+// newInstallationID returns a random identifier, it isn't derived from the cluster so it can't identify it
+func newInstallationID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errorUtil.Wrap(err, "failed to generate installation id")
+	}
+	return hex.EncodeToString(b), nil
+}
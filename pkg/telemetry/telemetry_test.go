@@ -0,0 +1,182 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testNamespace = "test"
+
+func buildTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	return scheme
+}
+
+func buildTestOperatorConfig(telemetry *v1alpha1.TelemetrySpec) *v1alpha1.OperatorConfig {
+	return &v1alpha1.OperatorConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ConfigName,
+			Namespace: testNamespace,
+		},
+		Spec: v1alpha1.OperatorConfigSpec{Telemetry: telemetry},
+	}
+}
+
+func buildTestPostgres(name, provider, tier string, phase croType.StatusPhase, msg croType.StatusMessage) *v1alpha1.Postgres {
+	return &v1alpha1.Postgres{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "product"},
+		Spec:       croType.ResourceTypeSpec{Tier: tier},
+		Status:     croType.ResourceTypeStatus{Provider: provider, Phase: phase, Message: msg},
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	c := fake.NewFakeClientWithScheme(buildTestScheme(t),
+		buildTestPostgres("a", "aws-rds", "production", croType.PhaseComplete, ""),
+		buildTestPostgres("b", "aws-rds", "production", croType.PhaseFailed, "failed to retrieve aws credentials"),
+		buildTestPostgres("c", "openshift-postgres", "development", croType.PhaseComplete, ""),
+	)
+	report, err := BuildReport(context.TODO(), c, "id", time.Now())
+	if err != nil {
+		t.Fatalf("BuildReport() unexpected error = %v", err)
+	}
+	wantResources := []ResourceCount{
+		{Kind: "Postgres", Provider: "aws-rds", Tier: "production", Count: 2},
+		{Kind: "Postgres", Provider: "openshift-postgres", Tier: "development", Count: 1},
+	}
+	if len(report.Resources) != len(wantResources) {
+		t.Fatalf("BuildReport() resources = %+v, want %+v", report.Resources, wantResources)
+	}
+	for i := range wantResources {
+		if report.Resources[i] != wantResources[i] {
+			t.Errorf("BuildReport() resources = %+v, want %+v", report.Resources, wantResources)
+		}
+	}
+	wantErrors := []ErrorCount{{Kind: "Postgres", Category: ErrorCategoryCredentials, Count: 1}}
+	if len(report.Errors) != 1 || report.Errors[0] != wantErrors[0] {
+		t.Errorf("BuildReport() errors = %+v, want %+v", report.Errors, wantErrors)
+	}
+}
+
+func TestErrorCategory(t *testing.T) {
+	tests := []struct {
+		msg  croType.StatusMessage
+		want string
+	}{
+		{msg: "failed to retrieve aws credentials", want: ErrorCategoryCredentials},
+		{msg: "DBInstanceQuotaExceeded: instance quota exceeded", want: ErrorCategoryQuota},
+		{msg: "tier production isn't in the strategy config map", want: ErrorCategoryConfiguration},
+		{msg: "failed to create vpc peering connection", want: ErrorCategoryNetwork},
+		{msg: "Throttling: rate exceeded", want: ErrorCategoryProvider},
+		{msg: "something unexpected", want: ErrorCategoryOther},
+	}
+	for _, tt := range tests {
+		if got := ErrorCategory(tt.msg); got != tt.want {
+			t.Errorf("ErrorCategory(%q) = %s, want %s", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestReporter_Run(t *testing.T) {
+	var received []Report
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := Report{}
+		if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+			t.Errorf("failed to decode report: %v", err)
+		}
+		received = append(received, report)
+	}))
+	defer srv.Close()
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		config     *v1alpha1.OperatorConfig
+		wantReport bool
+	}{
+		{
+			name: "test no report without an operator config",
+		},
+		{
+			name:   "test no report while telemetry is disabled",
+			config: buildTestOperatorConfig(&v1alpha1.TelemetrySpec{Endpoint: srv.URL}),
+		},
+		{
+			name:       "test report is posted once enabled",
+			config:     buildTestOperatorConfig(&v1alpha1.TelemetrySpec{Enabled: true, Endpoint: srv.URL}),
+			wantReport: true,
+		},
+		{
+			name: "test no report before the interval passed",
+			config: func() *v1alpha1.OperatorConfig {
+				cfg := buildTestOperatorConfig(&v1alpha1.TelemetrySpec{Enabled: true, Endpoint: srv.URL})
+				last := metav1.NewTime(now.Add(-time.Hour))
+				cfg.Status.Telemetry = &v1alpha1.TelemetryStatus{InstallationID: "id", LastReportedAt: &last}
+				return cfg
+			}(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received = nil
+			var objs []runtime.Object
+			if tt.config != nil {
+				objs = append(objs, tt.config)
+			}
+			c := fake.NewFakeClientWithScheme(buildTestScheme(t), objs...)
+			if err := NewReporter(c, testNamespace).Run(context.TODO(), now); err != nil {
+				t.Fatalf("Run() unexpected error = %v", err)
+			}
+			if (len(received) == 1) != tt.wantReport {
+				t.Fatalf("Run() posted %d reports, want a report %v", len(received), tt.wantReport)
+			}
+			if !tt.wantReport {
+				return
+			}
+			cfg := &v1alpha1.OperatorConfig{}
+			if err := c.Get(context.TODO(), types.NamespacedName{Name: ConfigName, Namespace: testNamespace}, cfg); err != nil {
+				t.Fatal("failed to get operator config", err)
+			}
+			status := cfg.Status.Telemetry
+			if status == nil || status.LastReportedAt == nil || status.InstallationID == "" {
+				t.Fatalf("Run() status = %+v, want the report recorded", status)
+			}
+			if received[0].InstallationID != status.InstallationID {
+				t.Errorf("Run() report installation id = %s, want %s", received[0].InstallationID, status.InstallationID)
+			}
+		})
+	}
+}
+
+func TestReporter_RunFailedPost(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	c := fake.NewFakeClientWithScheme(buildTestScheme(t), buildTestOperatorConfig(&v1alpha1.TelemetrySpec{Enabled: true, Endpoint: srv.URL}))
+
+	if err := NewReporter(c, testNamespace).Run(context.TODO(), time.Now()); err == nil {
+		t.Fatal("Run() expected an error for a failed post")
+	}
+	cfg := &v1alpha1.OperatorConfig{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: ConfigName, Namespace: testNamespace}, cfg); err != nil {
+		t.Fatal("failed to get operator config", err)
+	}
+	if cfg.Status.Telemetry == nil || cfg.Status.Telemetry.Message == "" || cfg.Status.Telemetry.LastReportedAt != nil {
+		t.Errorf("Run() status = %+v, want the error recorded without a report time", cfg.Status.Telemetry)
+	}
+}
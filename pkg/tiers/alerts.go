@@ -0,0 +1,38 @@
+package tiers
+
+import (
+	"context"
+	"fmt"
+
+	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	errorUtil "github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GetAlerts returns the alerts configuration of the tier of the resource type in the strategy config map of the
+// provider strategy, nil if the tier doesn't configure its alerts
+func GetAlerts(ctx context.Context, c client.Client, strategy, ns string, rt providers.ResourceType, tier string) (*providers.ResourceAlerts, error) {
+	outputs, err := getTierOutputs(ctx, c, strategy, ns, rt, tier)
+	if err != nil || outputs == nil {
+		return nil, err
+	}
+	return outputs.Alerts, nil
+}
+
+// BuildAlertRule returns the prometheus rule of the alerts of a resource configured by its tier, nil if the tier
+// disables the alerts
+func BuildAlertRule(ctx context.Context, c client.Client, ns string, rt providers.ResourceType, strategy, tier, name string) (*monitoringv1.PrometheusRule, croType.StatusMessage, error) {
+	alerts, err := GetAlerts(ctx, c, strategy, ns, rt, tier)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to get alerts of tier %s", tier)
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	rule, err := providers.BuildAlertRule(rt, ns, name, alerts)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to build alerts of tier %s", tier)
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	return rule, croType.StatusEmpty, nil
+}
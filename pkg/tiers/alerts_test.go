@@ -0,0 +1,69 @@
+package tiers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestBuildAlertRule(t *testing.T) {
+	scheme := buildTestScheme(t)
+	tests := []struct {
+		name       string
+		existing   []runtime.Object
+		tier       string
+		wantNil    bool
+		wantLabels map[string]string
+		wantErr    bool
+	}{
+		{
+			name:     "test alerts with the defaults when the tier doesn't configure them",
+			existing: []runtime.Object{buildTestStrategyConfigMap(`{"production": {"strategy": {}}}`)},
+			tier:     "production",
+		},
+		{
+			name:       "test labels of the tier are set on the rule",
+			existing:   []runtime.Object{buildTestStrategyConfigMap(`{"production": {"strategy": {}, "alerts": {"labels": {"monitoring-key": "middleware"}}}}`)},
+			tier:       "production",
+			wantLabels: map[string]string{"monitoring-key": "middleware"},
+		},
+		{
+			name:     "test no rule when the tier disables the alerts",
+			existing: []runtime.Object{buildTestStrategyConfigMap(`{"development": {"strategy": {}, "alerts": {"disabled": true}}}`)},
+			tier:     "development",
+			wantNil:  true,
+		},
+		{
+			name:     "test error on invalid alerts",
+			existing: []runtime.Object{buildTestStrategyConfigMap(`{"production": {"strategy": {}, "alerts": {"for": "soon"}}}`)},
+			tier:     "production",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme, tt.existing...)
+			got, msg, err := BuildAlertRule(context.TODO(), c, testNamespace, providers.PostgresResourceType, providers.OpenShiftDeploymentStrategy, tt.tier, "db")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BuildAlertRule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if msg == "" {
+					t.Errorf("BuildAlertRule() expected a status message with the error")
+				}
+				return
+			}
+			if (got == nil) != tt.wantNil {
+				t.Fatalf("BuildAlertRule() = %v, wantNil %v", got, tt.wantNil)
+			}
+			for k, v := range tt.wantLabels {
+				if got.Labels[k] != v {
+					t.Errorf("BuildAlertRule() label %s = %s, want %s", k, got.Labels[k], v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package tiers
+
+import (
+	"context"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GetExpiryConfig returns the expiry configuration of the tier of the resource type in the strategy config map of the
+// provider strategy, the defaults fill in what the tier doesn't configure
+func GetExpiryConfig(ctx context.Context, c client.Client, strategy, ns string, rt providers.ResourceType, tier string) (*providers.ExpiryConfig, error) {
+	cfg := providers.DefaultExpiryConfig()
+	outputs, err := getTierOutputs(ctx, c, strategy, ns, rt, tier)
+	if err != nil {
+		return nil, err
+	}
+	if outputs == nil || outputs.Expiry == nil {
+		return cfg, nil
+	}
+	if outputs.Expiry.WarningDays > 0 {
+		cfg.WarningDays = outputs.Expiry.WarningDays
+	}
+	if outputs.Expiry.AccessKeyMaxAgeDays > 0 {
+		cfg.AccessKeyMaxAgeDays = outputs.Expiry.AccessKeyMaxAgeDays
+	}
+	return cfg, nil
+}
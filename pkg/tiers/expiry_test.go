@@ -0,0 +1,51 @@
+package tiers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetExpiryConfig(t *testing.T) {
+	scheme := buildTestScheme(t)
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		want     providers.ExpiryConfig
+		wantErr  bool
+	}{
+		{
+			name:     "test defaults when the tier doesn't configure expiry",
+			existing: []runtime.Object{buildTestStrategyConfigMap(`{"production": {"strategy": {}}}`)},
+			want:     providers.ExpiryConfig{WarningDays: providers.DefaultExpiryWarningDays, AccessKeyMaxAgeDays: providers.DefaultAccessKeyMaxAgeDays},
+		},
+		{
+			name:     "test expiry of the tier overrides the defaults it sets",
+			existing: []runtime.Object{buildTestStrategyConfigMap(`{"production": {"strategy": {}, "expiry": {"warningDays": 14}}}`)},
+			want:     providers.ExpiryConfig{WarningDays: 14, AccessKeyMaxAgeDays: providers.DefaultAccessKeyMaxAgeDays},
+		},
+		{
+			name:     "test error on invalid expiry",
+			existing: []runtime.Object{buildTestStrategyConfigMap(`{"production": {"strategy": {}, "expiry": {"warningDays": "soon"}}}`)},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme, tt.existing...)
+			got, err := GetExpiryConfig(context.TODO(), c, providers.OpenShiftDeploymentStrategy, testNamespace, providers.PostgresResourceType, "production")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetExpiryConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if *got != tt.want {
+				t.Errorf("GetExpiryConfig() = %v, want %v", *got, tt.want)
+			}
+		})
+	}
+}
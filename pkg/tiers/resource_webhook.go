@@ -0,0 +1,150 @@
+package tiers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// ValidateResourcesPath is the path the resource validator is served on
+const ValidateResourcesPath = "/validate-resources"
+
+// +kubebuilder:webhook:path=/validate-resources,mutating=false,failurePolicy=ignore,sideEffects=None,groups=integreatly.org,resources=postgres;redis;blobstorages,verbs=create;update,versions=v1alpha1,name=vresources.integreatly.org,admissionReviewVersions=v1beta1
+
+// ResourceValidator denies postgres, redis and blob storage resources whose type isn't a deployment type of the
+// provider config, or whose tier isn't defined in the strategy config map of the provider strategy of the type. Such
+// resources are accepted by the api server but can never be reconciled. The type of a resource can't be changed once
+// it's created, the resources provisioned by the previous provider would be left behind.
+//
+// Resources requested on behalf of another namespace are denied unless a resource grant in that namespace allows it,
+// and the namespace they're requested on behalf of can't be changed
+type ResourceValidator struct {
+	Client    client.Client
+	Namespace string
+	// GrantReader reads the resource grants of other namespaces than the one the cache of the client is restricted
+	// to, the client is used if it's nil
+	GrantReader client.Reader
+	decoder     *admission.Decoder
+}
+
+var _ admission.Handler = (*ResourceValidator)(nil)
+var _ admission.DecoderInjector = (*ResourceValidator)(nil)
+
+func (v *ResourceValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+func (v *ResourceValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if v.Namespace != "" && req.Namespace != v.Namespace {
+		return admission.Allowed("resource isn't reconciled by the operator")
+	}
+	rt, newObj := resourceForKind(req.Kind.Kind)
+	if newObj == nil {
+		return admission.Allowed("not a postgres, redis or blob storage resource")
+	}
+	if err := v.decoder.DecodeRaw(req.Object, newObj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	newMeta, newSpec := resourceSpec(newObj)
+
+	if req.Operation == admissionv1beta1.Update {
+		_, oldObj := resourceForKind(req.Kind.Kind)
+		if err := v.decoder.DecodeRaw(req.OldObject, oldObj); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		_, oldSpec := resourceSpec(oldObj)
+		if newSpec.Type != oldSpec.Type {
+			return admission.Denied(fmt.Sprintf("type of %s %s can't be changed from %s to %s", req.Kind.Kind, req.Name, oldSpec.Type, newSpec.Type))
+		}
+		if newSpec.OnBehalfOf != oldSpec.OnBehalfOf {
+			return admission.Denied(fmt.Sprintf("namespace %s %s is requested on behalf of can't be changed from %q to %q", req.Kind.Kind, req.Name, oldSpec.OnBehalfOf, newSpec.OnBehalfOf))
+		}
+		// resources are updated while they're deleted, e.g. to remove their finalizers, and keep being updated by the
+		// operator after their tier was removed with the allow tier removal annotation
+		if newMeta.GetDeletionTimestamp() != nil || newSpec.Tier == oldSpec.Tier {
+			return admission.Allowed("type and tier are unchanged")
+		}
+	}
+
+	if newSpec.OnBehalfOf != "" && newSpec.OnBehalfOf != req.Namespace {
+		grantReader := v.GrantReader
+		if grantReader == nil {
+			grantReader = v.Client
+		}
+		grant, err := providers.FindResourceGrant(ctx, grantReader, newSpec.OnBehalfOf, req.Namespace, req.Kind.Kind, newSpec.Tier)
+		if err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		if grant == nil {
+			return admission.Denied(fmt.Sprintf("no resource grant in namespace %s allows namespace %s to request %s resources of tier %s", newSpec.OnBehalfOf, req.Namespace, req.Kind.Kind, newSpec.Tier))
+		}
+	}
+
+	cfgMgr := providers.NewConfigManager(providers.DefaultProviderConfigMapName, req.Namespace, v.Client)
+	deploymentTypes, err := cfgMgr.GetDeploymentTypes(ctx)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if !resources.Contains(deploymentTypes, newSpec.Type) {
+		return admission.Denied(fmt.Sprintf("type %s of %s %s isn't defined in the provider config map %s, defined types: %s", newSpec.Type, req.Kind.Kind, req.Name, providers.DefaultProviderConfigMapName, strings.Join(deploymentTypes, ", ")))
+	}
+	mapping, err := cfgMgr.GetStrategyMappingForDeploymentType(ctx, newSpec.Type)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	strategy := strategyForResourceType(mapping, rt)
+	if StrategyConfigMapName(strategy) == "" {
+		return admission.Denied(fmt.Sprintf("type %s doesn't map %s resources to a known provider strategy", newSpec.Type, rt))
+	}
+	cm, err := GetStrategyConfigMap(ctx, v.Client, strategy, req.Namespace)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if !TierDefined(cm.Data, rt, newSpec.Tier) {
+		if def := cm.Data[providers.DefaultTierKey]; def != "" && TierDefined(cm.Data, rt, def) {
+			resp := admission.Allowed("type is defined and the tier falls back to the default tier")
+			return withWarnings(resp, []string{fmt.Sprintf("tier %s of %s %s isn't defined for %s in the strategy config map %s, the default tier %s is used", newSpec.Tier, req.Kind.Kind, req.Name, rt, cm.Name, def)})
+		}
+		return admission.Denied(fmt.Sprintf("tier %s of %s %s isn't defined for %s in the strategy config map %s, defined tiers: %s", newSpec.Tier, req.Kind.Kind, req.Name, rt, cm.Name, strings.Join(DefinedTiers(cm.Data, rt), ", ")))
+	}
+	return admission.Allowed("type and tier are defined")
+}
+
+// resourceForKind returns the resource type and an empty object of a kind validated by the resource validator, nil if
+// the kind isn't validated
+func resourceForKind(kind string) (providers.ResourceType, runtime.Object) {
+	switch kind {
+	case "Postgres":
+		return providers.PostgresResourceType, &v1alpha1.Postgres{}
+	case "Redis":
+		return providers.RedisResourceType, &v1alpha1.Redis{}
+	case "BlobStorage":
+		return providers.BlobStorageResourceType, &v1alpha1.BlobStorage{}
+	}
+	return "", nil
+}
+
+// resourceSpec returns the metadata and spec of an object returned by resourceForKind
+func resourceSpec(obj runtime.Object) (metav1.Object, croType.ResourceTypeSpec) {
+	switch cr := obj.(type) {
+	case *v1alpha1.Postgres:
+		return cr, cr.Spec
+	case *v1alpha1.Redis:
+		return cr, cr.Spec
+	case *v1alpha1.BlobStorage:
+		return cr, cr.Spec
+	}
+	return nil, croType.ResourceTypeSpec{}
+}
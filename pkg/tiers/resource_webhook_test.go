@@ -0,0 +1,175 @@
+package tiers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func buildTestResourceRequest(t *testing.T, op admissionv1beta1.Operation, kind string, oldObj, newObj metav1.Object) admission.Request {
+	encode := func(obj metav1.Object) runtime.RawExtension {
+		if obj == nil {
+			return runtime.RawExtension{}
+		}
+		raw, err := json.Marshal(obj)
+		if err != nil {
+			t.Fatal("failed to encode resource", err)
+		}
+		return runtime.RawExtension{Raw: raw}
+	}
+	return admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		Operation: op,
+		Kind:      metav1.GroupVersionKind{Group: "integreatly.org", Version: "v1alpha1", Kind: kind},
+		Name:      newObj.GetName(),
+		Namespace: newObj.GetNamespace(),
+		OldObject: encode(oldObj),
+		Object:    encode(newObj),
+	}}
+}
+
+func buildTestRedis(name, deploymentType, tier string) *v1alpha1.Redis {
+	return &v1alpha1.Redis{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+		},
+		Spec: croType.ResourceTypeSpec{
+			Type: deploymentType,
+			Tier: tier,
+		},
+	}
+}
+
+func TestResourceValidator_Handle(t *testing.T) {
+	scheme := buildTestScheme(t)
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		t.Fatal("failed to build decoder", err)
+	}
+	removedTier := buildTestPostgres("removed", "removed", "")
+	withTier := func(ps *v1alpha1.Postgres, tier string) *v1alpha1.Postgres {
+		ps = ps.DeepCopy()
+		ps.Spec.Tier = tier
+		return ps
+	}
+	deleting := withTier(removedTier, "other")
+	now := metav1.NewTime(time.Now())
+	deleting.DeletionTimestamp = &now
+	otherNamespace := buildTestPostgres("other", "unknown", "")
+	otherNamespace.Namespace = "other"
+	onBehalfOf := func(ps *v1alpha1.Postgres, ns string) *v1alpha1.Postgres {
+		ps = ps.DeepCopy()
+		ps.Spec.OnBehalfOf = ns
+		return ps
+	}
+
+	tests := []struct {
+		name        string
+		req         admission.Request
+		wantAllowed bool
+		wantReason  string
+	}{
+		{
+			name:        "test creating a resource with a defined type and tier is allowed",
+			req:         buildTestResourceRequest(t, admissionv1beta1.Create, "Postgres", nil, buildTestPostgres("test", "custom", "")),
+			wantAllowed: true,
+		},
+		{
+			name:       "test creating a resource with an unknown type is denied",
+			req:        buildTestResourceRequest(t, admissionv1beta1.Create, "Redis", nil, buildTestRedis("test", "unknown", "development")),
+			wantReason: "type unknown of Redis test isn't defined in the provider config map cloud-resource-config, defined types: workshop",
+		},
+		{
+			name:       "test creating a resource with an undefined tier is denied",
+			req:        buildTestResourceRequest(t, admissionv1beta1.Create, "Postgres", nil, buildTestPostgres("test", "missing", "")),
+			wantReason: "tier missing of Postgres test isn't defined for postgres in the strategy config map cloud-resources-openshift-strategies, defined tiers: custom, development, production",
+		},
+		{
+			name:       "test changing the type of a resource is denied",
+			req:        buildTestResourceRequest(t, admissionv1beta1.Update, "Redis", buildTestRedis("test", "workshop", "development"), buildTestRedis("test", "managed", "development")),
+			wantReason: "type of Redis test can't be changed from workshop to managed",
+		},
+		{
+			name:       "test changing the tier of a resource to an undefined tier is denied",
+			req:        buildTestResourceRequest(t, admissionv1beta1.Update, "Postgres", buildTestPostgres("test", "production", ""), buildTestPostgres("test", "missing", "")),
+			wantReason: "tier missing of Postgres test isn't defined",
+		},
+		{
+			name:        "test changing the tier of a resource to a defined tier is allowed",
+			req:         buildTestResourceRequest(t, admissionv1beta1.Update, "Postgres", buildTestPostgres("test", "production", ""), buildTestPostgres("test", "custom", "")),
+			wantAllowed: true,
+		},
+		{
+			name:        "test updating a resource whose tier was removed is allowed",
+			req:         buildTestResourceRequest(t, admissionv1beta1.Update, "Postgres", removedTier, removedTier),
+			wantAllowed: true,
+		},
+		{
+			name:        "test updating a resource being deleted is allowed",
+			req:         buildTestResourceRequest(t, admissionv1beta1.Update, "Postgres", removedTier, deleting),
+			wantAllowed: true,
+		},
+		{
+			name:        "test creating a resource on behalf of a namespace granting it is allowed",
+			req:         buildTestResourceRequest(t, admissionv1beta1.Create, "Postgres", nil, onBehalfOf(buildTestPostgres("test", "production", ""), "data")),
+			wantAllowed: true,
+		},
+		{
+			name:       "test creating a resource on behalf of a namespace not granting it is denied",
+			req:        buildTestResourceRequest(t, admissionv1beta1.Create, "Postgres", nil, onBehalfOf(buildTestPostgres("test", "production", ""), "other")),
+			wantReason: "no resource grant in namespace other allows namespace " + testNamespace + " to request Postgres resources of tier production",
+		},
+		{
+			name:       "test creating a resource with a tier the grant doesn't allow is denied",
+			req:        buildTestResourceRequest(t, admissionv1beta1.Create, "Postgres", nil, onBehalfOf(buildTestPostgres("test", "custom", ""), "data")),
+			wantReason: "no resource grant in namespace data allows namespace " + testNamespace + " to request Postgres resources of tier custom",
+		},
+		{
+			name:       "test changing the namespace a resource is requested on behalf of is denied",
+			req:        buildTestResourceRequest(t, admissionv1beta1.Update, "Postgres", buildTestPostgres("test", "production", ""), onBehalfOf(buildTestPostgres("test", "production", ""), "data")),
+			wantReason: "namespace Postgres test is requested on behalf of can't be changed from \"\" to \"data\"",
+		},
+		{
+			name:        "test resources outside the namespace of the operator are allowed",
+			req:         buildTestResourceRequest(t, admissionv1beta1.Create, "Postgres", nil, otherNamespace),
+			wantAllowed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme,
+				buildTestProviderConfigMap(),
+				buildTestStrategyConfigMap(`{"development": {}, "production": {}, "custom": {}, "removed": null}`),
+				&v1alpha1.ResourceGrant{
+					ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "data"},
+					Spec: v1alpha1.ResourceGrantSpec{
+						Namespaces: []string{testNamespace},
+						Kinds:      []v1alpha1.ResourceGrantKind{"Postgres"},
+						Tiers:      []string{"production"},
+					},
+				},
+			)
+			v := &ResourceValidator{Client: c, Namespace: testNamespace}
+			if err := v.InjectDecoder(decoder); err != nil {
+				t.Fatal("failed to inject decoder", err)
+			}
+			resp := v.Handle(context.TODO(), tt.req)
+			if resp.Allowed != tt.wantAllowed {
+				t.Fatalf("Handle() allowed = %v, want %v, result %v", resp.Allowed, tt.wantAllowed, resp.Result)
+			}
+			if tt.wantReason != "" && (resp.Result == nil || !strings.Contains(string(resp.Result.Reason), tt.wantReason)) {
+				t.Errorf("Handle() result = %v, want reason containing %s", resp.Result, tt.wantReason)
+			}
+		})
+	}
+}
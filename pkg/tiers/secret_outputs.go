@@ -0,0 +1,68 @@
+package tiers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	errorUtil "github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// tierOutputs is the part of a tier of any strategy config map configuring what is maintained alongside a resource,
+// the outputs of its connection secret, its alerts and the expiry warnings of its artifacts, it sits next to the
+// provider specific strategy of the tier
+type tierOutputs struct {
+	SecretOutputs []providers.SecretOutput  `json:"secretOutputs,omitempty"`
+	Alerts        *providers.ResourceAlerts `json:"alerts,omitempty"`
+	Expiry        *providers.ExpiryConfig   `json:"expiry,omitempty"`
+}
+
+// getTierOutputs returns the outputs of the tier of the resource type in the strategy config map of the provider
+// strategy, resolved like the strategy of the tier, or nil if the tier isn't defined
+func getTierOutputs(ctx context.Context, c client.Client, strategy, ns string, rt providers.ResourceType, tier string) (*tierOutputs, error) {
+	cm, err := GetStrategyConfigMap(ctx, c, strategy, ns)
+	if err != nil {
+		return nil, err
+	}
+	raw, _, err := providers.ResolveTier(cm.Data, rt, tier)
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to parse %s strategies of strategy config map %s", rt, cm.Name)
+	}
+	if raw == nil {
+		return nil, nil
+	}
+	outputs := &tierOutputs{}
+	if err := json.Unmarshal(raw, outputs); err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to parse tier %s of %s strategies of strategy config map %s", tier, rt, cm.Name)
+	}
+	return outputs, nil
+}
+
+// GetSecretOutputs returns the secret outputs of the tier of the resource type in the strategy config map of the
+// provider strategy
+func GetSecretOutputs(ctx context.Context, c client.Client, strategy, ns string, rt providers.ResourceType, tier string) ([]providers.SecretOutput, error) {
+	outputs, err := getTierOutputs(ctx, c, strategy, ns, rt, tier)
+	if err != nil || outputs == nil {
+		return nil, err
+	}
+	return outputs.SecretOutputs, nil
+}
+
+// BuildSecretData returns the data of the connection secret of a resource, the keys set by its provider and the
+// outputs configured by its tier
+func BuildSecretData(ctx context.Context, c client.Client, ns string, rt providers.ResourceType, strategy, tier string, data map[string][]byte) (map[string][]byte, croType.StatusMessage, error) {
+	outputs, err := GetSecretOutputs(ctx, c, strategy, ns, rt, tier)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to get secret outputs of tier %s", tier)
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	rendered, err := providers.RenderSecretOutputs(data, outputs)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to render secret outputs of tier %s", tier)
+		return nil, croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	return rendered, croType.StatusEmpty, nil
+}
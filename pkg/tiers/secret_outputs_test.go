@@ -0,0 +1,64 @@
+package tiers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestBuildSecretData(t *testing.T) {
+	scheme := buildTestScheme(t)
+	data := (&providers.PostgresDeploymentDetails{Username: "user", Password: "pass", Host: "host", Database: "db", Port: 5432}).Data()
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		tier     string
+		wantKeys []string
+		wantErr  bool
+	}{
+		{
+			name:     "test outputs of the tier are added",
+			existing: []runtime.Object{buildTestStrategyConfigMap(`{"production": {"strategy": {}, "secretOutputs": [{"key": ".pgpass", "format": "pgpass"}]}}`)},
+			tier:     "production",
+			wantKeys: []string{"username", "password", "host", "database", "port", ".pgpass"},
+		},
+		{
+			name:     "test only the provider keys without outputs",
+			existing: []runtime.Object{buildTestStrategyConfigMap(`{"development": {"strategy": {}}, "production": {"strategy": {}, "secretOutputs": [{"key": ".pgpass", "format": "pgpass"}]}}`)},
+			tier:     "development",
+			wantKeys: []string{"username", "password", "host", "database", "port"},
+		},
+		{
+			name:     "test error on an invalid output",
+			existing: []runtime.Object{buildTestStrategyConfigMap(`{"production": {"strategy": {}, "secretOutputs": [{"key": ".pgpass", "format": "unknown"}]}}`)},
+			tier:     "production",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme, tt.existing...)
+			got, msg, err := BuildSecretData(context.TODO(), c, testNamespace, providers.PostgresResourceType, providers.OpenShiftDeploymentStrategy, tt.tier, data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BuildSecretData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if msg == "" {
+					t.Errorf("BuildSecretData() expected a status message with the error")
+				}
+				return
+			}
+			if len(got) != len(tt.wantKeys) {
+				t.Errorf("BuildSecretData() keys = %d, want %v", len(got), tt.wantKeys)
+			}
+			for _, k := range tt.wantKeys {
+				if _, ok := got[k]; !ok {
+					t.Errorf("BuildSecretData() missing key %s", k)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,329 @@
+// Package tiers reports which tiers of the strategy config maps are used by resources, so tiers still in use aren't
+// removed from a strategy config map
+package tiers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/gcp"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/openshift"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	errorUtil "github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// TierAvailableCondition is the condition reporting if the tier of a resource is defined in the strategy config map
+	// of its provider strategy
+	TierAvailableCondition = "TierAvailable"
+	// TierDefinedReason is the reason of a true tier available condition
+	TierDefinedReason = "TierDefined"
+	// TierRemovedReason is the reason of a false tier available condition
+	TierRemovedReason = "TierRemoved"
+	// TierFallbackReason is the reason of a false tier available condition of a resource reconciled with the default
+	// tier of the strategy config map, its own tier isn't defined
+	TierFallbackReason = "TierFallback"
+)
+
+// Usage is the use of a tier of a strategy config map by a resource
+type Usage struct {
+	Strategy     string
+	ResourceType providers.ResourceType
+	Tier         string
+	Kind         string
+	Namespace    string
+	Name         string
+}
+
+func (u Usage) String() string {
+	return fmt.Sprintf("%s %s/%s", u.Kind, u.Namespace, u.Name)
+}
+
+// StrategyConfigMapName returns the name of the strategy config map of the provider strategy, empty if the strategy is
+// unknown
+func StrategyConfigMapName(strategy string) string {
+	switch strategy {
+	case providers.AWSDeploymentStrategy:
+		return aws.DefaultConfigMapName
+	case providers.GCPDeploymentStrategy:
+		return gcp.DefaultConfigMapName
+	case providers.OpenShiftDeploymentStrategy:
+		return openshift.DefaultConfigMapName
+	}
+	return ""
+}
+
+// StrategyForConfigMap returns the provider strategy of a strategy config map, empty if the config map isn't a
+// strategy config map
+func StrategyForConfigMap(name string) string {
+	for _, strategy := range []string{providers.AWSDeploymentStrategy, providers.GCPDeploymentStrategy, providers.OpenShiftDeploymentStrategy} {
+		if StrategyConfigMapName(strategy) == name {
+			return strategy
+		}
+	}
+	return ""
+}
+
+// DefaultStrategyConfigMap returns the config map the provider strategy falls back to when its strategy config map
+// doesn't exist
+func DefaultStrategyConfigMap(strategy, ns string) *v1.ConfigMap {
+	name := StrategyConfigMapName(strategy)
+	switch strategy {
+	case providers.AWSDeploymentStrategy:
+		return aws.BuildDefaultConfigMap(name, ns)
+	case providers.GCPDeploymentStrategy:
+		return gcp.BuildDefaultConfigMap(name, ns)
+	case providers.OpenShiftDeploymentStrategy:
+		return openshift.BuildDefaultConfigMap(name, ns)
+	}
+	return nil
+}
+
+// GetStrategyConfigMap returns the strategy config map of the provider strategy, or the config map the provider falls
+// back to if it doesn't exist
+func GetStrategyConfigMap(ctx context.Context, c client.Client, strategy, ns string) (*v1.ConfigMap, error) {
+	def := DefaultStrategyConfigMap(strategy, ns)
+	if def == nil {
+		return nil, errorUtil.Errorf("unknown strategy %s", strategy)
+	}
+	cm, err := resources.GetConfigMapOrDefault(ctx, c, types.NamespacedName{Name: def.Name, Namespace: ns}, def)
+	if err != nil {
+		return nil, errorUtil.Wrapf(err, "failed to get strategy config map %s", def.Name)
+	}
+	return cm, nil
+}
+
+// TierDefined reports if the tier of the resource type is defined in the data of a strategy config map
+func TierDefined(data map[string]string, rt providers.ResourceType, tier string) bool {
+	raw, ok := data[string(rt)]
+	if !ok {
+		return false
+	}
+	tiers := map[string]json.RawMessage{}
+	if err := json.Unmarshal([]byte(raw), &tiers); err != nil {
+		return false
+	}
+	strat, ok := tiers[tier]
+	return ok && string(strat) != "null"
+}
+
+// StrategyHash returns the sha256 of the configuration of the tier of a resource in the data of a strategy config map,
+// resolved with the tiers it inherits from and the default tier and merged with the provider config of the resource,
+// empty if the tier can't be resolved
+func StrategyHash(data map[string]string, rt providers.ResourceType, spec *croType.ResourceTypeSpec) string {
+	strat, _, err := providers.ResolveTier(data, rt, spec.Tier)
+	if err != nil || strat == nil {
+		return ""
+	}
+	merged, err := providers.MergeProviderConfig(strat, spec.ProviderConfig)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(merged)
+	return hex.EncodeToString(sum[:])
+}
+
+// GetUsage returns the tiers used by the postgres, redis, blob storage and queue resources in the namespace. The strategy of
+// a resource is taken from its status, or the provider config of its deployment type if it isn't set yet, resources
+// being deleted aren't included
+func GetUsage(ctx context.Context, c client.Client, ns string) ([]Usage, error) {
+	cfgMgr := providers.NewConfigManager(providers.DefaultProviderConfigMapName, ns, c)
+	mappings := map[string]*providers.DeploymentStrategyMapping{}
+	strategyFor := func(deploymentType, status string, rt providers.ResourceType) string {
+		if status != "" {
+			return status
+		}
+		mapping, ok := mappings[deploymentType]
+		if !ok {
+			// an unknown deployment type leaves the strategy empty, the resource fails to reconcile anyway
+			mapping, _ = cfgMgr.GetStrategyMappingForDeploymentType(ctx, deploymentType)
+			mappings[deploymentType] = mapping
+		}
+		return strategyForResourceType(mapping, rt)
+	}
+
+	var usages []Usage
+	add := func(kind string, rt providers.ResourceType, om metav1.ObjectMeta, spec croType.ResourceTypeSpec, status croType.ResourceTypeStatus) {
+		if om.DeletionTimestamp != nil {
+			return
+		}
+		usages = append(usages, Usage{
+			Strategy:     strategyFor(spec.Type, status.Strategy, rt),
+			ResourceType: rt,
+			Tier:         spec.Tier,
+			Kind:         kind,
+			Namespace:    om.Namespace,
+			Name:         om.Name,
+		})
+	}
+
+	bsList := &v1alpha1.BlobStorageList{}
+	if err := c.List(ctx, bsList, client.InNamespace(ns)); err != nil {
+		return nil, errorUtil.Wrap(err, "failed to list blob storages")
+	}
+	for _, bs := range bsList.Items {
+		add("BlobStorage", providers.BlobStorageResourceType, bs.ObjectMeta, bs.Spec, bs.Status)
+	}
+	pgList := &v1alpha1.PostgresList{}
+	if err := c.List(ctx, pgList, client.InNamespace(ns)); err != nil {
+		return nil, errorUtil.Wrap(err, "failed to list postgres")
+	}
+	for _, pg := range pgList.Items {
+		add("Postgres", providers.PostgresResourceType, pg.ObjectMeta, pg.Spec, pg.Status)
+	}
+	redisList := &v1alpha1.RedisList{}
+	if err := c.List(ctx, redisList, client.InNamespace(ns)); err != nil {
+		return nil, errorUtil.Wrap(err, "failed to list redis")
+	}
+	for _, r := range redisList.Items {
+		add("Redis", providers.RedisResourceType, r.ObjectMeta, r.Spec, r.Status)
+	}
+	queueList := &v1alpha1.QueueList{}
+	if err := c.List(ctx, queueList, client.InNamespace(ns)); err != nil {
+		return nil, errorUtil.Wrap(err, "failed to list queues")
+	}
+	for _, q := range queueList.Items {
+		add("Queue", providers.QueueResourceType, q.ObjectMeta, q.Spec, q.Status)
+	}
+	return usages, nil
+}
+
+// strategyForResourceType returns the provider strategy of the resource type in a deployment strategy mapping, empty if
+// the mapping doesn't set one
+func strategyForResourceType(mapping *providers.DeploymentStrategyMapping, rt providers.ResourceType) string {
+	if mapping == nil {
+		return ""
+	}
+	switch rt {
+	case providers.BlobStorageResourceType:
+		return mapping.BlobStorage
+	case providers.PostgresResourceType:
+		return mapping.Postgres
+	case providers.RedisResourceType:
+		return mapping.Redis
+	case providers.QueueResourceType:
+		return mapping.Queue
+	}
+	return ""
+}
+
+// DefinedTiers returns the tiers of the resource type defined in the data of a strategy config map, sorted by name
+func DefinedTiers(data map[string]string, rt providers.ResourceType) []string {
+	tiers := map[string]json.RawMessage{}
+	if err := json.Unmarshal([]byte(data[string(rt)]), &tiers); err != nil {
+		return nil
+	}
+	var defined []string
+	for tier, strat := range tiers {
+		if string(strat) != "null" {
+			defined = append(defined, tier)
+		}
+	}
+	sort.Strings(defined)
+	return defined
+}
+
+// MissingUsage returns the usages whose tier isn't defined in the strategy config map of their provider strategy,
+// usages with an unknown strategy are skipped
+func MissingUsage(ctx context.Context, c client.Client, ns string, usages []Usage) ([]Usage, error) {
+	configMaps := map[string]*v1.ConfigMap{}
+	var missing []Usage
+	for _, u := range usages {
+		if StrategyConfigMapName(u.Strategy) == "" {
+			continue
+		}
+		cm, ok := configMaps[u.Strategy]
+		if !ok {
+			var err error
+			if cm, err = GetStrategyConfigMap(ctx, c, u.Strategy, ns); err != nil {
+				return nil, err
+			}
+			configMaps[u.Strategy] = cm
+		}
+		if !TierDefined(cm.Data, u.ResourceType, u.Tier) {
+			missing = append(missing, u)
+		}
+	}
+	return missing, nil
+}
+
+// RemovedUsage returns the usages of the provider strategy whose tier is defined in the old data of its strategy
+// config map but not in the new data
+func RemovedUsage(usages []Usage, strategy string, oldData, newData map[string]string) []Usage {
+	var removed []Usage
+	for _, u := range usages {
+		if u.Strategy != strategy {
+			continue
+		}
+		if TierDefined(oldData, u.ResourceType, u.Tier) && !TierDefined(newData, u.ResourceType, u.Tier) {
+			removed = append(removed, u)
+		}
+	}
+	return removed
+}
+
+// ReconcileTierCondition checks the tier of a resource is defined in the strategy config map of its provider strategy
+// and reports it in the tier available condition of the resource status, a resource whose tier isn't defined is
+// reconciled with the default tier of the config map if it has one and the condition warns about it. A change of the configuration of the tier is
+// recorded in the history of the resource. The status is persisted with the rest of the resource status. It returns a
+// message if the tier was removed, empty if the resource can be reconciled
+func ReconcileTierCondition(ctx context.Context, c client.Client, inst metav1.Object, rt providers.ResourceType, strategy string, spec *croType.ResourceTypeSpec, status *croType.ResourceTypeStatus) (croType.StatusMessage, error) {
+	cm, err := GetStrategyConfigMap(ctx, c, strategy, inst.GetNamespace())
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to get strategy config map of strategy %s", strategy)
+		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	strat, resolved, err := providers.ResolveTier(cm.Data, rt, spec.Tier)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to resolve tier %s of %s in strategy config map %s", spec.Tier, rt, cm.Name)
+		return croType.StatusMessage(errMsg), errorUtil.Wrap(err, errMsg)
+	}
+	if strat == nil {
+		msg := fmt.Sprintf("tier %s of %s was removed from strategy config map %s", spec.Tier, rt, cm.Name)
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               TierAvailableCondition,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: inst.GetGeneration(),
+			Reason:             TierRemovedReason,
+			Message:            msg,
+		})
+		return croType.StatusMessage(msg), nil
+	}
+	cond := metav1.Condition{
+		Type:               TierAvailableCondition,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: inst.GetGeneration(),
+		Reason:             TierDefinedReason,
+		Message:            fmt.Sprintf("tier %s is defined in strategy config map %s", spec.Tier, cm.Name),
+	}
+	if resolved != spec.Tier {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = TierFallbackReason
+		cond.Message = fmt.Sprintf("tier %s of %s isn't defined in strategy config map %s, falling back to default tier %s", spec.Tier, rt, cm.Name, resolved)
+	}
+	meta.SetStatusCondition(&status.Conditions, cond)
+	// record when the resource is first reconciled with the configuration of its tier, and every change of it after
+	if hash := StrategyHash(cm.Data, rt, spec); hash != "" && hash != resources.LastStrategyHash(status) {
+		resources.RecordHistory(status, croType.HistoryEntry{
+			Time:         metav1.Now(),
+			Reason:       resources.StrategyAppliedHistoryReason,
+			Phase:        status.Phase,
+			Message:      croType.StatusMessage(fmt.Sprintf("configuration of tier %s in strategy config map %s applied", spec.Tier, cm.Name)),
+			StrategyHash: hash,
+		})
+	}
+	return croType.StatusEmpty, nil
+}
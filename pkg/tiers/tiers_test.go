@@ -0,0 +1,242 @@
+package tiers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/openshift"
+	"github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testNamespace = "test"
+
+func buildTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to build scheme", err)
+	}
+	return scheme
+}
+
+func buildTestStrategyConfigMap(postgres string) *v1.ConfigMap {
+	return &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      openshift.DefaultConfigMapName,
+			Namespace: testNamespace,
+		},
+		Data: map[string]string{
+			"postgres": postgres,
+			"redis":    `{"development": {}, "production": {}}`,
+		},
+	}
+}
+
+func buildTestProviderConfigMap() *v1.ConfigMap {
+	return &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      providers.DefaultProviderConfigMapName,
+			Namespace: testNamespace,
+		},
+		Data: map[string]string{
+			"workshop": `{"blobstorage": "openshift", "postgres": "openshift", "redis": "openshift"}`,
+		},
+	}
+}
+
+func buildTestPostgres(name, tier, strategy string) *v1alpha1.Postgres {
+	return &v1alpha1.Postgres{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+		},
+		Spec: croType.ResourceTypeSpec{
+			Type: "workshop",
+			Tier: tier,
+		},
+		Status: croType.ResourceTypeStatus{
+			Strategy: strategy,
+		},
+	}
+}
+
+func TestTierDefined(t *testing.T) {
+	data := map[string]string{
+		"postgres": `{"development": {}, "removed": null}`,
+		"redis":    `not json`,
+	}
+	tests := []struct {
+		name string
+		rt   providers.ResourceType
+		tier string
+		want bool
+	}{
+		{name: "test defined tier", rt: providers.PostgresResourceType, tier: "development", want: true},
+		{name: "test undefined tier", rt: providers.PostgresResourceType, tier: "production"},
+		{name: "test null tier is undefined", rt: providers.PostgresResourceType, tier: "removed"},
+		{name: "test invalid resource type json", rt: providers.RedisResourceType, tier: "development"},
+		{name: "test missing resource type", rt: providers.BlobStorageResourceType, tier: "development"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TierDefined(data, tt.rt, tt.tier); got != tt.want {
+				t.Errorf("TierDefined() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetUsageAndMissingUsage(t *testing.T) {
+	scheme := buildTestScheme(t)
+	c := fake.NewFakeClientWithScheme(scheme,
+		buildTestProviderConfigMap(),
+		buildTestStrategyConfigMap(`{"development": {}}`),
+		buildTestPostgres("dev", "development", providers.OpenShiftDeploymentStrategy),
+		buildTestPostgres("prod", "production", ""),
+	)
+	usages, err := GetUsage(context.TODO(), c, testNamespace)
+	if err != nil {
+		t.Fatalf("GetUsage() unexpected error = %v", err)
+	}
+	if len(usages) != 2 {
+		t.Fatalf("GetUsage() = %v, want 2 usages", usages)
+	}
+	for _, u := range usages {
+		if u.Strategy != providers.OpenShiftDeploymentStrategy || u.ResourceType != providers.PostgresResourceType {
+			t.Errorf("GetUsage() unexpected usage %v", u)
+		}
+	}
+	missing, err := MissingUsage(context.TODO(), c, testNamespace, usages)
+	if err != nil {
+		t.Fatalf("MissingUsage() unexpected error = %v", err)
+	}
+	if len(missing) != 1 || missing[0].Name != "prod" {
+		t.Errorf("MissingUsage() = %v, want the production postgres", missing)
+	}
+}
+
+func TestRemovedUsage(t *testing.T) {
+	usages := []Usage{
+		{Strategy: providers.OpenShiftDeploymentStrategy, ResourceType: providers.PostgresResourceType, Tier: "development", Name: "dev"},
+		{Strategy: providers.OpenShiftDeploymentStrategy, ResourceType: providers.PostgresResourceType, Tier: "production", Name: "prod"},
+		{Strategy: providers.AWSDeploymentStrategy, ResourceType: providers.PostgresResourceType, Tier: "production", Name: "aws"},
+	}
+	oldData := map[string]string{"postgres": `{"development": {}, "production": {}}`}
+	tests := []struct {
+		name    string
+		newData map[string]string
+		want    []string
+	}{
+		{name: "test no tier removed", newData: oldData},
+		{name: "test used tier removed", newData: map[string]string{"postgres": `{"development": {}}`}, want: []string{"prod"}},
+		{name: "test resource type removed", newData: map[string]string{}, want: []string{"dev", "prod"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			removed := RemovedUsage(usages, providers.OpenShiftDeploymentStrategy, oldData, tt.newData)
+			if len(removed) != len(tt.want) {
+				t.Fatalf("RemovedUsage() = %v, want %v", removed, tt.want)
+			}
+			for i, u := range removed {
+				if u.Name != tt.want[i] {
+					t.Errorf("RemovedUsage() = %v, want %v", removed, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileTierCondition(t *testing.T) {
+	scheme := buildTestScheme(t)
+	tests := []struct {
+		name          string
+		tier          string
+		existing      []runtime.Object
+		wantMsg       croType.StatusMessage
+		wantCondition metav1.ConditionStatus
+	}{
+		{
+			name:          "test tier defined in strategy config map",
+			tier:          "development",
+			existing:      []runtime.Object{buildTestStrategyConfigMap(`{"development": {}}`)},
+			wantCondition: metav1.ConditionTrue,
+		},
+		{
+			name:          "test tier defined in default config map",
+			tier:          "production",
+			wantCondition: metav1.ConditionTrue,
+		},
+		{
+			name:          "test tier removed from strategy config map",
+			tier:          "production",
+			existing:      []runtime.Object{buildTestStrategyConfigMap(`{"development": {}}`)},
+			wantMsg:       "tier production of postgres was removed from strategy config map cloud-resources-openshift-strategies",
+			wantCondition: metav1.ConditionFalse,
+		},
+		{
+			name: "test undefined tier falls back to the default tier",
+			tier: "production",
+			existing: []runtime.Object{func() *v1.ConfigMap {
+				cm := buildTestStrategyConfigMap(`{"development": {}}`)
+				cm.Data[providers.DefaultTierKey] = "development"
+				return cm
+			}()},
+			wantCondition: metav1.ConditionFalse,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme, tt.existing...)
+			pg := buildTestPostgres("test", tt.tier, providers.OpenShiftDeploymentStrategy)
+			msg, err := ReconcileTierCondition(context.TODO(), c, pg, providers.PostgresResourceType, providers.OpenShiftDeploymentStrategy, &pg.Spec, &pg.Status)
+			if err != nil {
+				t.Fatalf("ReconcileTierCondition() unexpected error = %v", err)
+			}
+			if msg != tt.wantMsg {
+				t.Errorf("ReconcileTierCondition() msg = %s, want %s", msg, tt.wantMsg)
+			}
+			cond := meta.FindStatusCondition(pg.Status.Conditions, TierAvailableCondition)
+			if cond == nil || cond.Status != tt.wantCondition {
+				t.Errorf("ReconcileTierCondition() condition = %v, want status %s", cond, tt.wantCondition)
+			}
+		})
+	}
+}
+
+func TestReconcileTierCondition_History(t *testing.T) {
+	scheme := buildTestScheme(t)
+	ctx := context.TODO()
+	cm := buildTestStrategyConfigMap(`{"development": {"storage": 1}}`)
+	c := fake.NewFakeClientWithScheme(scheme, cm)
+	pg := buildTestPostgres("test", "development", providers.OpenShiftDeploymentStrategy)
+
+	reconcile := func() {
+		if _, err := ReconcileTierCondition(ctx, c, pg, providers.PostgresResourceType, providers.OpenShiftDeploymentStrategy, &pg.Spec, &pg.Status); err != nil {
+			t.Fatalf("ReconcileTierCondition() unexpected error = %v", err)
+		}
+	}
+	reconcile()
+	reconcile()
+	if len(pg.Status.History) != 1 || pg.Status.History[0].Reason != resources.StrategyAppliedHistoryReason || pg.Status.History[0].StrategyHash == "" {
+		t.Fatalf("ReconcileTierCondition() history = %+v, want the strategy applied once", pg.Status.History)
+	}
+
+	cm.Data["postgres"] = `{"development": {"storage": 2}}`
+	if err := c.Update(ctx, cm); err != nil {
+		t.Fatal("failed to update strategy config map", err)
+	}
+	reconcile()
+	if len(pg.Status.History) != 2 || pg.Status.History[1].StrategyHash == pg.Status.History[0].StrategyHash {
+		t.Errorf("ReconcileTierCondition() history = %+v, want the changed strategy applied", pg.Status.History)
+	}
+}
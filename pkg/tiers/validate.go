@@ -0,0 +1,172 @@
+package tiers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/gcp"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/openshift"
+)
+
+// strategyField is a field of a tier holding the strategy of a provider, parsed into the struct the provider reads it
+// into
+type strategyField struct {
+	name   string
+	raw    json.RawMessage
+	target interface{}
+}
+
+// Validation is the result of validating a strategy config map. Errors are tiers the providers fail to parse, resources
+// using them can't be reconciled. Warnings are fields the providers ignore, e.g. a misspelt field
+type Validation struct {
+	Errors   []string
+	Warnings []string
+}
+
+// ValidateStrategyConfigMap parses every tier of the data of a strategy config map into the structs the provider
+// strategy reads them into
+func ValidateStrategyConfigMap(strategy string, data map[string]string) Validation {
+	var rts []string
+	for rt := range data {
+		rts = append(rts, rt)
+	}
+	sort.Strings(rts)
+
+	result := Validation{}
+	for _, rt := range rts {
+		if rt == providers.DefaultTierKey {
+			continue
+		}
+		tiers := map[string]json.RawMessage{}
+		if err := json.Unmarshal([]byte(data[rt]), &tiers); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s strategies are not valid json: %v", rt, err))
+			continue
+		}
+		var names []string
+		for tier := range tiers {
+			names = append(names, tier)
+		}
+		sort.Strings(names)
+		if def := data[providers.DefaultTierKey]; def != "" && !TierDefined(data, providers.ResourceType(rt), def) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: default tier %s is not defined, resources of undefined tiers can't fall back to it", rt, def))
+		}
+		for _, tier := range names {
+			if string(tiers[tier]) == "null" {
+				continue
+			}
+			// a tier is validated with the fields of the tiers it inherits from
+			raw, _, err := providers.ResolveTier(data, providers.ResourceType(rt), tier)
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s tier %s: %v", rt, tier, err))
+				continue
+			}
+			if raw == nil {
+				continue
+			}
+			errs, warnings := validateTier(strategy, providers.ResourceType(rt), raw)
+			for _, e := range errs {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s tier %s: %s", rt, tier, e))
+			}
+			for _, w := range warnings {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s tier %s: %s", rt, tier, w))
+			}
+		}
+	}
+	return result
+}
+
+// validateTier parses a tier into the strategy config of the provider strategy, the outputs shared by every provider
+// and the provider specific strategies of the resource type
+func validateTier(strategy string, rt providers.ResourceType, raw json.RawMessage) ([]string, []string) {
+	if string(raw) == "null" {
+		return nil, nil
+	}
+	if err := json.Unmarshal(raw, &tierOutputs{}); err != nil {
+		return []string{err.Error()}, nil
+	}
+
+	var fields []strategyField
+	switch strategy {
+	case providers.AWSDeploymentStrategy:
+		stratCfg := &aws.StrategyConfig{}
+		if err := json.Unmarshal(raw, stratCfg); err != nil {
+			return []string{err.Error()}, nil
+		}
+		fields = awsStrategyFields(rt, stratCfg)
+	case providers.GCPDeploymentStrategy:
+		stratCfg := &gcp.StrategyConfig{}
+		if err := json.Unmarshal(raw, stratCfg); err != nil {
+			return []string{err.Error()}, nil
+		}
+		if rt == providers.PostgresResourceType {
+			fields = []strategyField{{name: "createStrategy", raw: stratCfg.CreateStrategy, target: &gcp.DatabaseInstance{}}}
+		}
+	case providers.OpenShiftDeploymentStrategy:
+		stratCfg := &openshift.StrategyConfig{}
+		if err := json.Unmarshal(raw, stratCfg); err != nil {
+			return []string{err.Error()}, nil
+		}
+		switch rt {
+		case providers.PostgresResourceType:
+			fields = []strategyField{{name: "strategy", raw: stratCfg.RawStrategy, target: &openshift.PostgresStrat{}}}
+		case providers.RedisResourceType:
+			fields = []strategyField{{name: "strategy", raw: stratCfg.RawStrategy, target: &openshift.RedisStrat{}}}
+		}
+	}
+
+	var errs, warnings []string
+	for _, f := range fields {
+		if len(f.raw) == 0 || string(f.raw) == "null" {
+			continue
+		}
+		if err := json.Unmarshal(f.raw, f.target); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", f.name, err))
+			continue
+		}
+		// fields the provider doesn't know are ignored by it, they're most likely a typo
+		dec := json.NewDecoder(bytes.NewReader(f.raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(f.target); err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: %v", f.name, err))
+		}
+	}
+	return errs, warnings
+}
+
+// awsStrategyFields returns the strategies of a tier of the aws strategy config map and the structs the aws providers
+// read them into
+func awsStrategyFields(rt providers.ResourceType, stratCfg *aws.StrategyConfig) []strategyField {
+	serviceUpdates := strategyField{name: "serviceUpdates", raw: stratCfg.ServiceUpdates, target: &[]string{}}
+	switch rt {
+	case providers.PostgresResourceType:
+		return []strategyField{
+			{name: "createStrategy", raw: stratCfg.CreateStrategy, target: &rds.CreateDBInstanceInput{}},
+			{name: "deleteStrategy", raw: stratCfg.DeleteStrategy, target: &rds.DeleteDBInstanceInput{}},
+			serviceUpdates,
+		}
+	case providers.RedisResourceType:
+		return []strategyField{
+			{name: "createStrategy", raw: stratCfg.CreateStrategy, target: &elasticache.CreateReplicationGroupInput{}},
+			{name: "deleteStrategy", raw: stratCfg.DeleteStrategy, target: &elasticache.DeleteReplicationGroupInput{}},
+			serviceUpdates,
+		}
+	case providers.BlobStorageResourceType:
+		return []strategyField{
+			{name: "createStrategy", raw: stratCfg.CreateStrategy, target: &s3.CreateBucketInput{}},
+			{name: "deleteStrategy", raw: stratCfg.DeleteStrategy, target: &aws.S3DeleteStrat{}},
+		}
+	case providers.QueueResourceType:
+		return []strategyField{{name: "createStrategy", raw: stratCfg.CreateStrategy, target: &aws.SQSCreateStrat{}}}
+	case providers.NetworkResourceType:
+		return []strategyField{{name: "createStrategy", raw: stratCfg.CreateStrategy, target: &ec2.CreateVpcInput{}}}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package tiers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/gcp"
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers/openshift"
+)
+
+func TestValidateStrategyConfigMap(t *testing.T) {
+	tests := []struct {
+		name         string
+		strategy     string
+		data         map[string]string
+		wantErrors   []string
+		wantWarnings []string
+	}{
+		{
+			name:     "test default aws strategies are valid",
+			strategy: providers.AWSDeploymentStrategy,
+			data:     aws.BuildDefaultConfigMap("test", testNamespace).Data,
+		},
+		{
+			name:     "test default gcp strategies are valid",
+			strategy: providers.GCPDeploymentStrategy,
+			data:     gcp.BuildDefaultConfigMap("test", testNamespace).Data,
+		},
+		{
+			name:     "test default openshift strategies are valid",
+			strategy: providers.OpenShiftDeploymentStrategy,
+			data:     openshift.BuildDefaultConfigMap("test", testNamespace).Data,
+		},
+		{
+			name:       "test error on strategies which aren't json",
+			strategy:   providers.OpenShiftDeploymentStrategy,
+			data:       map[string]string{"postgres": `{"development": `},
+			wantErrors: []string{"postgres strategies are not valid json"},
+		},
+		{
+			name:     "test error on aws strategies of the wrong type",
+			strategy: providers.AWSDeploymentStrategy,
+			data: map[string]string{
+				"postgres": `{"development": {"createStrategy": {"AllocatedStorage": "20"}}}`,
+				"redis":    `{"development": {"serviceUpdates": "elasticache-20210615-002"}}`,
+			},
+			wantErrors: []string{"postgres tier development: createStrategy", "redis tier development: serviceUpdates"},
+		},
+		{
+			name:       "test error on tier outputs of the wrong type",
+			strategy:   providers.OpenShiftDeploymentStrategy,
+			data:       map[string]string{"redis": `{"development": {"secretOutputs": {}}}`},
+			wantErrors: []string{"redis tier development"},
+		},
+		{
+			name:         "test warning on fields unknown to the provider",
+			strategy:     providers.AWSDeploymentStrategy,
+			data:         map[string]string{"postgres": `{"development": {"createStrategy": {"DBInstanceClas": "db.t3.small"}}}`},
+			wantWarnings: []string{"postgres tier development: createStrategy"},
+		},
+		{
+			name:     "test inherited fields are validated with the tier",
+			strategy: providers.AWSDeploymentStrategy,
+			data: map[string]string{
+				"postgres": `{"production": {"createStrategy": {"AllocatedStorage": "20"}}, "staging": {"inherits": "production"}}`,
+			},
+			wantErrors: []string{"postgres tier production: createStrategy", "postgres tier staging: createStrategy"},
+		},
+		{
+			name:       "test error on an inheritance cycle",
+			strategy:   providers.OpenShiftDeploymentStrategy,
+			data:       map[string]string{"postgres": `{"a": {"inherits": "b"}, "b": {"inherits": "a"}}`},
+			wantErrors: []string{"postgres tier a", "postgres tier b"},
+		},
+		{
+			name:     "test warning on a default tier which isn't defined",
+			strategy: providers.OpenShiftDeploymentStrategy,
+			data: map[string]string{
+				"postgres":               `{"development": {"strategy": {}}}`,
+				providers.DefaultTierKey: "production",
+			},
+			wantWarnings: []string{"postgres: default tier production is not defined"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ValidateStrategyConfigMap(tt.strategy, tt.data)
+			check := func(kind string, got, want []string) {
+				if len(got) != len(want) {
+					t.Fatalf("ValidateStrategyConfigMap() %s = %v, want %v", kind, got, want)
+				}
+				for i := range want {
+					if !strings.HasPrefix(got[i], want[i]) {
+						t.Errorf("ValidateStrategyConfigMap() %s = %v, want %v", kind, got, want)
+					}
+				}
+			}
+			check("errors", got.Errors, tt.wantErrors)
+			check("warnings", got.Warnings, tt.wantWarnings)
+		})
+	}
+}
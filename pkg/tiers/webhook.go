@@ -0,0 +1,102 @@
+package tiers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// AllowTierRemovalAnnotation on a strategy config map allows tiers used by resources to be removed from it, the
+	// removal is returned as a warning instead of being denied
+	AllowTierRemovalAnnotation = "integreatly.org/allow-tier-removal"
+
+	// ValidateStrategyConfigMapsPath is the path the strategy config map validator is served on
+	ValidateStrategyConfigMapsPath = "/validate-strategy-configmaps"
+)
+
+// +kubebuilder:webhook:path=/validate-strategy-configmaps,mutating=false,failurePolicy=ignore,sideEffects=None,groups="",resources=configmaps,verbs=create;update;delete,versions=v1,name=vstrategyconfigmaps.integreatly.org,admissionReviewVersions=v1beta1
+
+// StrategyConfigMapValidator denies strategy config maps with tiers the providers fail to parse, and updates and
+// deletions of strategy config maps which remove a tier used by a resource in the namespace of the config map
+type StrategyConfigMapValidator struct {
+	Client    client.Client
+	Namespace string
+	decoder   *admission.Decoder
+}
+
+var _ admission.Handler = (*StrategyConfigMapValidator)(nil)
+var _ admission.DecoderInjector = (*StrategyConfigMapValidator)(nil)
+
+func (v *StrategyConfigMapValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+func (v *StrategyConfigMapValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	strategy := StrategyForConfigMap(req.Name)
+	if strategy == "" || (v.Namespace != "" && req.Namespace != v.Namespace) {
+		return admission.Allowed("not a strategy config map")
+	}
+
+	// a deleted strategy config map falls back to the default config map of the provider
+	newCM := DefaultStrategyConfigMap(strategy, req.Namespace)
+	var warnings []string
+	if req.Operation != admissionv1beta1.Delete {
+		newCM = &v1.ConfigMap{}
+		if err := v.decoder.DecodeRaw(req.Object, newCM); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		validation := ValidateStrategyConfigMap(strategy, newCM.Data)
+		if len(validation.Errors) > 0 {
+			return admission.Denied(fmt.Sprintf("strategy config map %s has invalid tiers: %s", req.Name, strings.Join(validation.Errors, ", ")))
+		}
+		warnings = validation.Warnings
+	}
+	if req.Operation == admissionv1beta1.Create {
+		return withWarnings(admission.Allowed("strategy config map is valid"), warnings)
+	}
+
+	oldCM := &v1.ConfigMap{}
+	if err := v.decoder.DecodeRaw(req.OldObject, oldCM); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	usages, err := GetUsage(ctx, v.Client, req.Namespace)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	removed := RemovedUsage(usages, strategy, oldCM.Data, newCM.Data)
+	if len(removed) == 0 {
+		return withWarnings(admission.Allowed("no tier in use is removed"), warnings)
+	}
+
+	msg := removedTiersMessage(req.Name, removed)
+	if newCM.Annotations[AllowTierRemovalAnnotation] == "true" {
+		resp := admission.Allowed(fmt.Sprintf("tier removal allowed by %s annotation", AllowTierRemovalAnnotation))
+		return withWarnings(resp, append([]string{msg}, warnings...))
+	}
+	return admission.Denied(fmt.Sprintf("%s, set the %s annotation to \"true\" to remove them anyway", msg, AllowTierRemovalAnnotation))
+}
+
+// withWarnings returns the response with the warnings shown to the client, e.g. fields of a tier ignored by its provider
+func withWarnings(resp admission.Response, warnings []string) admission.Response {
+	if len(warnings) > 0 {
+		resp.Warnings = warnings
+	}
+	return resp
+}
+
+// removedTiersMessage describes the tiers removed from a strategy config map and the resources using them
+func removedTiersMessage(name string, removed []Usage) string {
+	var uses []string
+	for _, u := range removed {
+		uses = append(uses, fmt.Sprintf("%s tier %s used by %s", u.ResourceType, u.Tier, u))
+	}
+	return fmt.Sprintf("strategy config map %s removes tiers in use: %s", name, strings.Join(uses, ", "))
+}
@@ -0,0 +1,130 @@
+package tiers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func buildTestRequest(t *testing.T, op admissionv1beta1.Operation, oldCM, newCM *v1.ConfigMap) admission.Request {
+	encode := func(cm *v1.ConfigMap) runtime.RawExtension {
+		if cm == nil {
+			return runtime.RawExtension{}
+		}
+		raw, err := json.Marshal(cm)
+		if err != nil {
+			t.Fatal("failed to encode config map", err)
+		}
+		return runtime.RawExtension{Raw: raw}
+	}
+	named := oldCM
+	if named == nil {
+		named = newCM
+	}
+	return admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		Operation: op,
+		Name:      named.Name,
+		Namespace: named.Namespace,
+		OldObject: encode(oldCM),
+		Object:    encode(newCM),
+	}}
+}
+
+func TestStrategyConfigMapValidator_Handle(t *testing.T) {
+	scheme := buildTestScheme(t)
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		t.Fatal("failed to build decoder", err)
+	}
+	oldCM := buildTestStrategyConfigMap(`{"development": {}, "production": {}, "custom": {}}`)
+	allowRemoval := buildTestStrategyConfigMap(`{"development": {}}`)
+	allowRemoval.Annotations = map[string]string{AllowTierRemovalAnnotation: "true"}
+	otherCM := buildTestStrategyConfigMap(`{}`)
+	otherCM.Name = "other"
+
+	tests := []struct {
+		name        string
+		req         admission.Request
+		wantAllowed bool
+		wantReason  string
+		wantWarning bool
+	}{
+		{
+			name:        "test config maps other than strategy config maps are allowed",
+			req:         buildTestRequest(t, admissionv1beta1.Update, otherCM, otherCM),
+			wantAllowed: true,
+		},
+		{
+			name:        "test removing an unused tier is allowed",
+			req:         buildTestRequest(t, admissionv1beta1.Update, buildTestStrategyConfigMap(`{"production": {}, "custom": {}, "unused": {}}`), oldCM),
+			wantAllowed: true,
+		},
+		{
+			name:       "test removing a tier in use is denied",
+			req:        buildTestRequest(t, admissionv1beta1.Update, oldCM, buildTestStrategyConfigMap(`{"development": {}, "custom": {}}`)),
+			wantReason: "postgres tier production used by Postgres test/prod",
+		},
+		{
+			name:       "test deleting a config map with a custom tier in use is denied",
+			req:        buildTestRequest(t, admissionv1beta1.Delete, oldCM, nil),
+			wantReason: "postgres tier custom used by Postgres test/custom",
+		},
+		{
+			name:        "test creating a valid config map is allowed",
+			req:         buildTestRequest(t, admissionv1beta1.Create, nil, buildTestStrategyConfigMap(`{"development": {"strategy": {"tls": true}}}`)),
+			wantAllowed: true,
+		},
+		{
+			name:       "test creating a config map with an invalid tier is denied",
+			req:        buildTestRequest(t, admissionv1beta1.Create, nil, buildTestStrategyConfigMap(`{"development": {"strategy": {"tls": "yes"}}}`)),
+			wantReason: "postgres tier development: strategy",
+		},
+		{
+			name:       "test updating a tier in use to an invalid strategy is denied",
+			req:        buildTestRequest(t, admissionv1beta1.Update, oldCM, buildTestStrategyConfigMap(`{"development": {}, "production": {"strategy": []}, "custom": {}}`)),
+			wantReason: "postgres tier production: strategy",
+		},
+		{
+			name:        "test unknown fields of a tier are allowed with a warning",
+			req:         buildTestRequest(t, admissionv1beta1.Update, oldCM, buildTestStrategyConfigMap(`{"development": {}, "production": {"strategy": {"tsl": true}}, "custom": {}}`)),
+			wantAllowed: true,
+			wantWarning: true,
+		},
+		{
+			name:        "test removing a tier in use is allowed with the annotation",
+			req:         buildTestRequest(t, admissionv1beta1.Update, oldCM, allowRemoval),
+			wantAllowed: true,
+			wantWarning: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme,
+				buildTestPostgres("prod", "production", providers.OpenShiftDeploymentStrategy),
+				buildTestPostgres("custom", "custom", providers.OpenShiftDeploymentStrategy),
+			)
+			v := &StrategyConfigMapValidator{Client: c, Namespace: testNamespace}
+			if err := v.InjectDecoder(decoder); err != nil {
+				t.Fatal("failed to inject decoder", err)
+			}
+			resp := v.Handle(context.TODO(), tt.req)
+			if resp.Allowed != tt.wantAllowed {
+				t.Fatalf("Handle() allowed = %v, want %v, result %v", resp.Allowed, tt.wantAllowed, resp.Result)
+			}
+			if tt.wantReason != "" && (resp.Result == nil || !strings.Contains(string(resp.Result.Reason), tt.wantReason)) {
+				t.Errorf("Handle() result = %v, want reason containing %s", resp.Result, tt.wantReason)
+			}
+			if (len(resp.Warnings) > 0) != tt.wantWarning {
+				t.Errorf("Handle() warnings = %v, want warning %v", resp.Warnings, tt.wantWarning)
+			}
+		})
+	}
+}
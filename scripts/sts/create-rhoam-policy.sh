@@ -81,6 +81,7 @@ cat <<EOM >"$MINIMAL_POLICY_NAME.json"
                 "ec2:CreateRoute",
                 "ec2:DeleteRoute",
                 "ec2:DescribeAvailabilityZones",
+                "ec2:DescribeInstances",
                 "ec2:DescribeInstanceTypeOfferings",
                 "ec2:DescribeInstanceTypes",
                 "ec2:DescribeRouteTables",
@@ -93,20 +94,41 @@ cat <<EOM >"$MINIMAL_POLICY_NAME.json"
                 "elasticache:DescribeCacheClusters",
                 "elasticache:DescribeCacheSubnetGroups",
                 "elasticache:DescribeReplicationGroups",
+                "elasticache:DescribeReservedCacheNodesOfferings",
                 "elasticache:DescribeSnapshots",
                 "elasticache:DescribeUpdateActions",
+                "kms:CreateAlias",
+                "kms:CreateGrant",
+                "kms:CreateKey",
+                "kms:DescribeKey",
+                "kms:EnableKeyRotation",
+                "kms:ScheduleKeyDeletion",
+                "kms:TagResource",
+                "rds:CopyDBSnapshot",
+                "rds:DescribeCertificates",
                 "rds:DescribeDBInstances",
                 "rds:DescribeDBSnapshots",
                 "rds:DescribeDBSubnetGroups",
+                "rds:DescribeOrderableDBInstanceOptions",
                 "rds:DescribePendingMaintenanceActions",
                 "rds:ListTagsForResource",
+                "s3:AbortMultipartUpload",
                 "s3:CreateBucket",
                 "s3:DeleteBucket",
+                "s3:GetLifecycleConfiguration",
                 "s3:ListAllMyBuckets",
                 "s3:ListBucket",
+                "s3:ListBucketMultipartUploads",
                 "s3:PutBucketPublicAccessBlock",
                 "s3:PutBucketTagging",
-                "s3:PutEncryptionConfiguration"
+                "s3:PutEncryptionConfiguration",
+                "s3:PutLifecycleConfiguration",
+                "sqs:CreateQueue",
+                "sqs:DeleteQueue",
+                "sqs:GetQueueAttributes",
+                "sqs:GetQueueUrl",
+                "sqs:SetQueueAttributes",
+                "sqs:TagQueue"
             ],
             "Resource": "*"
         },
@@ -145,6 +167,7 @@ cat <<EOM >"$MINIMAL_POLICY_NAME.json"
                 "ec2:DeleteSubnet",
                 "ec2:DeleteVpc",
                 "ec2:DeleteVpcPeeringConnection",
+                "ec2:RevokeSecurityGroupIngress",
                 "elasticache:BatchApplyUpdateAction",
                 "elasticache:CreateSnapshot",
                 "elasticache:DeleteCacheSubnetGroup",